@@ -1,12 +1,17 @@
 package dto
 
-import "time"
+import (
+	"fmt"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/model"
+)
 
 // ==================== AUTHENTICATION REQUEST DTOs ====================
 
 type RegisterRequest struct {
 	Email           string `json:"email" validate:"required,email" example:"user@example.com"`
-	Username        string `json:"username" validate:"required,min=3,max=30,alphanum" example:"johndoe"`
+	Username        string `json:"username" validate:"required,username" example:"johndoe"`
 	Password        string `json:"password" validate:"required,strong_password" example:"SecurePass123!"`
 	ConfirmPassword string `json:"confirm_password" validate:"required,eqfield=Password" example:"SecurePass123!"`
 }
@@ -79,6 +84,66 @@ func (v VerifyEmailRequest) Validate() error {
 	return GetValidator().Struct(v)
 }
 
+type ConfirmEmailChangeRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric" example:"123456"`
+}
+
+func (c ConfirmEmailChangeRequest) Validate() error {
+	return GetValidator().Struct(c)
+}
+
+type RevertEmailChangeRequest struct {
+	Token string `json:"token" validate:"required,len=64,hexadecimal" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+}
+
+func (r RevertEmailChangeRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+type DeviceActionRequest struct {
+	Token string `json:"token" validate:"required,max=2048"`
+}
+
+func (r DeviceActionRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+type StartAccountRecoveryRequest struct {
+	EmailOrUsername string `json:"email_or_username" validate:"required,min=3,max=255" example:"nguyenvana"`
+	NewEmail        string `json:"new_email" validate:"required,email" example:"new@example.com"`
+}
+
+func (r StartAccountRecoveryRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+type AccountRecoveryStatusRequest struct {
+	RecoveryID string `json:"recovery_id" validate:"required" example:"01890a5d-ac96-774b-bcce-b302099a8057"`
+	Token      string `json:"token" validate:"required,len=64,hexadecimal" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+}
+
+func (r AccountRecoveryStatusRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+type CompleteAccountRecoveryRequest struct {
+	RecoveryID  string `json:"recovery_id" validate:"required" example:"01890a5d-ac96-774b-bcce-b302099a8057"`
+	Token       string `json:"token" validate:"required,len=64,hexadecimal" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	NewPassword string `json:"new_password" validate:"required,min=8" example:"NewSecurePassword123!"`
+}
+
+func (r CompleteAccountRecoveryRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+type CancelAccountRecoveryRequest struct {
+	Token string `json:"token" validate:"required,len=64,hexadecimal" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+}
+
+func (r CancelAccountRecoveryRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
 type ResendVerificationRequest struct {
 	Email string `json:"email" validate:"required,email" example:"user@example.com"`
 }
@@ -100,7 +165,11 @@ type LoginResponse struct {
 	RefreshToken string   `json:"refresh_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
 	ExpiresIn    int64    `json:"expires_in" example:"900"`
 	SessionID    string   `json:"session_id" example:"sess_123456789"`
+	CSRFToken    string   `json:"csrf_token,omitempty" example:"3f9a1c..."`
 	User         UserInfo `json:"user"`
+
+	// Set when the login used a backup code
+	BackupCodesRemaining *int `json:"backup_codes_remaining,omitempty" example:"2"`
 }
 
 type TokenPair struct {
@@ -127,11 +196,35 @@ type UserProfileResponse struct {
 	Email         string     `json:"email" example:"user@example.com"`
 	Role          string     `json:"role" example:"user"`
 	EmailVerified bool       `json:"email_verified" example:"true"`
+	PendingEmail  string     `json:"pending_email,omitempty" example:"newemail@example.com"`
 	CreatedAt     time.Time  `json:"created_at" example:"2023-01-01T00:00:00Z"`
 	LastLoginAt   *time.Time `json:"last_login_at,omitempty" example:"2023-01-15T10:30:00Z"`
 	LastLoginIP   string     `json:"last_login_ip,omitempty" example:"192.168.1.1"`
 	IsActive      bool       `json:"is_active" example:"true"`
 	Stats         UserStats  `json:"stats"`
+
+	Timezone      string                   `json:"timezone,omitempty" example:"Asia/Ho_Chi_Minh"`
+	WeekendAmulet bool                     `json:"weekend_amulet" example:"false"`
+	Accessibility AccessibilityPreferences `json:"accessibility"`
+}
+
+// AccessibilityPreferences tell the apps how to present content to the user
+type AccessibilityPreferences struct {
+	// Replace animations and transitions with fades
+	ReducedMotion bool `json:"reduced_motion" example:"false"`
+	// Use a dyslexia-friendly font
+	DyslexiaFont bool `json:"dyslexia_font" example:"false"`
+	HighContrast bool `json:"high_contrast" example:"false"`
+	// Use a palette that doesn't tell states apart by red and green alone
+	ColorBlindSafe bool `json:"color_blind_safe" example:"true"`
+}
+
+// UpdateAccessibilityPreferences changes the preferences that are set and keeps the rest
+type UpdateAccessibilityPreferences struct {
+	ReducedMotion  *bool `json:"reduced_motion,omitempty" example:"true"`
+	DyslexiaFont   *bool `json:"dyslexia_font,omitempty" example:"false"`
+	HighContrast   *bool `json:"high_contrast,omitempty" example:"false"`
+	ColorBlindSafe *bool `json:"color_blind_safe,omitempty" example:"true"`
 }
 
 type UserStats struct {
@@ -142,8 +235,15 @@ type UserStats struct {
 }
 
 type UpdateProfileRequest struct {
-	Username string `json:"username,omitempty" validate:"omitempty,min=3,max=30" example:"newusername"`
+	Username string `json:"username,omitempty" validate:"omitempty,username" example:"newusername"`
 	Email    string `json:"email,omitempty" validate:"omitempty,email" example:"newemail@example.com"`
+
+	// IANA timezone streak days are counted in; empty resets to Vietnam time
+	Timezone *string `json:"timezone,omitempty" validate:"omitempty,max=64" example:"Asia/Ho_Chi_Minh"`
+	// Keep the streak when weekends are skipped
+	WeekendAmulet *bool `json:"weekend_amulet,omitempty" example:"true"`
+	// Accessibility preferences to change
+	Accessibility *UpdateAccessibilityPreferences `json:"accessibility,omitempty"`
 }
 
 func (u UpdateProfileRequest) Validate() error {
@@ -202,6 +302,42 @@ type AuditLogResponse struct {
 	Limit int            `json:"limit" example:"20"`
 }
 
+// AuditChainVerification is the result of checking the audit log hash chain. HeadHash
+// can be compared with earlier exports to detect entries cut from the end.
+type AuditChainVerification struct {
+	Valid         bool                `json:"valid" example:"true"`
+	Checked       int64               `json:"checked" example:"15230"`
+	FirstSequence int64               `json:"first_sequence" example:"1"`
+	LastSequence  int64               `json:"last_sequence" example:"15230"`
+	HeadHash      string              `json:"head_hash" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	Problems      []AuditChainProblem `json:"problems,omitempty"`
+	VerifiedAt    time.Time           `json:"verified_at" example:"2025-01-15T03:00:00Z"`
+}
+
+type AuditChainProblem struct {
+	Sequence int64  `json:"sequence" example:"1042"`
+	Problem  string `json:"problem" example:"entry was modified"`
+}
+
+// AuditLogSegment is an exported range of the audit log chain. Signature is the
+// hex HMAC-SHA256 of SigningPayload with the audit log signing key; the entries are bound
+// to it through their chain hashes, so checking a segment means checking the signature
+// and then recomputing the chain from PrevHash to HeadHash.
+type AuditLogSegment struct {
+	FromSequence int64                `json:"from_sequence" example:"1001"`
+	ToSequence   int64                `json:"to_sequence" example:"2000"`
+	PrevHash     string               `json:"prev_hash" example:"2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"`
+	HeadHash     string               `json:"head_hash" example:"fcde2b2edba56bf408601fb721fe9b5c338d10ee429ea04fae5511b68fbf8fb9"`
+	Count        int                  `json:"count" example:"1000"`
+	ExportedAt   time.Time            `json:"exported_at" example:"2025-01-15T03:00:00Z"`
+	Entries      []model.AuthAuditLog `json:"entries"`
+	Signature    string               `json:"signature" example:"4f1c..."`
+}
+
+func (s AuditLogSegment) SigningPayload() string {
+	return fmt.Sprintf("%d\n%d\n%s\n%s\n%d\n%s", s.FromSequence, s.ToSequence, s.PrevHash, s.HeadHash, s.Count, s.ExportedAt.UTC().Format(time.RFC3339Nano))
+}
+
 // ==================== PASSWORD RESET DTOs ====================
 
 type PasswordResetCode struct {
@@ -216,32 +352,78 @@ type PasswordResetCode struct {
 // ==================== SECURITY SETTINGS DTOs ====================
 
 type SecuritySettings struct {
-	TwoFactorEnabled     bool       `json:"two_factor_enabled" example:"false"`
-	BackupCodesGenerated bool       `json:"backup_codes_generated" example:"false"`
-	LastPasswordChange   *time.Time `json:"last_password_change,omitempty" example:"2023-01-10T15:30:00Z"`
-	LoginNotifications   bool       `json:"login_notifications" example:"true"`
-	SessionTimeout       int        `json:"session_timeout" example:"1440"`
+	TwoFactorEnabled      bool       `json:"two_factor_enabled" example:"false"`
+	BackupCodesGenerated  bool       `json:"backup_codes_generated" example:"false"`
+	LastPasswordChange    *time.Time `json:"last_password_change,omitempty" example:"2023-01-10T15:30:00Z"`
+	LoginNotifications    bool       `json:"login_notifications" example:"true"`
+	SessionTimeout        int        `json:"session_timeout" example:"1440"`
+	MaxActiveSessions     int        `json:"max_active_sessions" example:"5"`
+	SessionLimitPolicy    string     `json:"session_limit_policy" example:"evict_oldest"`
+	LeaderboardVisibility string     `json:"leaderboard_visibility" example:"public"`
+
+	// Security emails per event type (always, new_device or never) and the weekly digest
+	LoginEmails          string `json:"login_emails" example:"new_device"`
+	SessionEvictedEmails string `json:"session_evicted_emails" example:"always"`
+	SecurityDigest       bool   `json:"security_digest" example:"true"`
+
+	BackupCodesRemaining int `json:"backup_codes_remaining" example:"8"`
 }
 
 type UpdateSecuritySettingsRequest struct {
-	LoginNotifications *bool `json:"login_notifications,omitempty" example:"true"`
-	SessionTimeout     *int  `json:"session_timeout,omitempty" validate:"omitempty,min=300,max=43200" example:"720"`
+	LoginNotifications    *bool   `json:"login_notifications,omitempty" example:"true"`
+	SessionTimeout        *int    `json:"session_timeout,omitempty" validate:"omitempty,min=15,max=10080" example:"720"`
+	MaxActiveSessions     *int    `json:"max_active_sessions,omitempty" validate:"omitempty,min=1,max=20" example:"5"`
+	SessionLimitPolicy    *string `json:"session_limit_policy,omitempty" validate:"omitempty,oneof=evict_oldest reject" example:"evict_oldest"`
+	LeaderboardVisibility *string `json:"leaderboard_visibility,omitempty" validate:"omitempty,oneof=public anonymous hidden" example:"anonymous"`
+
+	LoginEmails          *string `json:"login_emails,omitempty" validate:"omitempty,oneof=always new_device never" example:"new_device"`
+	SessionEvictedEmails *string `json:"session_evicted_emails,omitempty" validate:"omitempty,oneof=always new_device never" example:"always"`
+	SecurityDigest       *bool   `json:"security_digest,omitempty" example:"true"`
 }
 
 func (u UpdateSecuritySettingsRequest) Validate() error {
 	return GetValidator().Struct(u)
 }
 
+// ==================== PARENTAL CONTROLS DTOs ====================
+
+type ParentalControlsResponse struct {
+	Enabled          bool   `json:"enabled" example:"true"`
+	MaxContentRating string `json:"max_content_rating,omitempty" example:"10+"`
+	// Ratings the user can currently see, from parental controls or their age
+	AllowedRatings []string `json:"allowed_ratings" example:"all,10+"`
+}
+
+// UpdateParentalControlsRequest sets or clears the parental rating. The first request
+// sets the PIN, later ones must repeat it. An empty rating turns parental controls off.
+type UpdateParentalControlsRequest struct {
+	PIN              string `json:"pin" validate:"required,numeric,min=4,max=8" example:"2468"`
+	MaxContentRating string `json:"max_content_rating" validate:"omitempty,oneof=all 10+ 13+ 16+" example:"10+"`
+}
+
+func (r UpdateParentalControlsRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
 // ==================== TWO-FACTOR AUTHENTICATION DTOs ====================
 
+// EnableTwoFactorResponse starts a 2FA setup. The client shows OTPAuthURL as a QR code
+// for the authenticator app; 2FA is only on once a code from the app is confirmed.
 type EnableTwoFactorResponse struct {
-	Secret      string   `json:"secret" example:"JBSWY3DPEHPK3PXP"`
-	QRCode      string   `json:"qr_code" example:"data:image/png;base64,iVBORw0KGgoAAAANSUhEUgAA..."`
-	BackupCodes []string `json:"backup_codes" example:"[\"123456\",\"789012\"]"`
+	Secret     string `json:"secret" example:"JBSWY3DPEHPK3PXP"`
+	OTPAuthURL string `json:"otpauth_url" example:"otpauth://totp/TechYouth:user@example.com?secret=JBSWY3DPEHPK3PXP&issuer=TechYouth"`
 }
 
+// BackupCodesResponse holds one-time backup codes. They are only shown here, the server
+// keeps their hashes.
+type BackupCodesResponse struct {
+	BackupCodes []string `json:"backup_codes" example:"[\"k3m9p-x7q2r\",\"h8w4n-c6t5j\"]"`
+}
+
+// VerifyTwoFactorRequest confirms a 2FA change. Enabling takes an authenticator code,
+// disabling and regenerating backup codes also accept an unused backup code.
 type VerifyTwoFactorRequest struct {
-	Code string `json:"code" validate:"required,len=6,numeric" example:"123456"`
+	Code string `json:"code" validate:"required,min=6,max=32" example:"123456"`
 }
 
 func (v VerifyTwoFactorRequest) Validate() error {
@@ -251,7 +433,7 @@ func (v VerifyTwoFactorRequest) Validate() error {
 type TwoFactorLoginRequest struct {
 	EmailOrUsername string `json:"email_or_username" validate:"required" example:"user@example.com"`
 	Password        string `json:"password" validate:"required" example:"SecurePass123!"`
-	Code            string `json:"code" validate:"required,len=6,numeric" example:"123456"`
+	Code            string `json:"code" validate:"required,min=6,max=32" example:"123456"` // Authenticator or backup code
 	DeviceID        string `json:"device_id,omitempty" example:"device_12345"`
 }
 
@@ -279,11 +461,17 @@ type AdminUserInfo struct {
 	LastLoginAt    *time.Time `json:"last_login_at,omitempty" example:"2023-01-15T10:30:00Z"`
 	FailedAttempts int        `json:"failed_attempts" example:"0"`
 	LockedUntil    *time.Time `json:"locked_until,omitempty" example:"2023-01-15T12:00:00Z"`
+	TenantID       string     `json:"tenant_id,omitempty" example:"lac-hong-hs"`
+
+	// Internal support notes on the account
+	NoteCount int `json:"note_count" example:"2"`
 }
 
 type AdminUpdateUserRequest struct {
 	Role     *string `json:"role,omitempty" validate:"omitempty,oneof=user admin moderator" example:"admin"`
 	IsActive *bool   `json:"is_active,omitempty" example:"true"`
+	// Empty moves the user out of their tenant
+	TenantID *string `json:"tenant_id,omitempty" validate:"omitempty,max=50" example:"lac-hong-hs"`
 }
 
 func (a AdminUpdateUserRequest) Validate() error {
@@ -299,6 +487,44 @@ type RateLimitInfo struct {
 	BlockedUntil *time.Time `json:"blocked_until,omitempty" example:"2023-01-15T12:00:00Z"`
 }
 
+// AccountRecoveryStartedResponse is returned to whoever asks for a recovery. The token
+// is only shown once and is needed to follow and complete the request.
+type AccountRecoveryStartedResponse struct {
+	RecoveryID string    `json:"recovery_id" example:"01890a5d-ac96-774b-bcce-b302099a8057"`
+	Token      string    `json:"token" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	Status     string    `json:"status" example:"pending"`
+	FallbackAt time.Time `json:"fallback_at" example:"2023-01-18T10:30:00Z"`
+	ExpiresAt  time.Time `json:"expires_at" example:"2023-01-25T10:30:00Z"`
+}
+
+type AccountRecoveryStatusResponse struct {
+	RecoveryID  string    `json:"recovery_id" example:"01890a5d-ac96-774b-bcce-b302099a8057"`
+	Status      string    `json:"status" example:"approved"`
+	CanComplete bool      `json:"can_complete" example:"true"`
+	FallbackAt  time.Time `json:"fallback_at" example:"2023-01-18T10:30:00Z"`
+	ExpiresAt   time.Time `json:"expires_at" example:"2023-01-25T10:30:00Z"`
+}
+
+// AccountRecoveryInfo is a recovery request as shown to the account owner, with every
+// step of the chain
+type AccountRecoveryInfo struct {
+	ID              string     `json:"id" example:"01890a5d-ac96-774b-bcce-b302099a8057"`
+	NewEmail        string     `json:"new_email" example:"new@example.com"`
+	Status          string     `json:"status" example:"pending"`
+	RequestIP       string     `json:"request_ip" example:"192.168.1.1"`
+	UserAgent       string     `json:"user_agent" example:"Mozilla/5.0..."`
+	DecidedByDevice string     `json:"decided_by_device,omitempty" example:"device-123"`
+	DecidedAt       *time.Time `json:"decided_at,omitempty" example:"2023-01-15T11:00:00Z"`
+	FallbackAt      time.Time  `json:"fallback_at" example:"2023-01-18T10:30:00Z"`
+	ExpiresAt       time.Time  `json:"expires_at" example:"2023-01-25T10:30:00Z"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty" example:"2023-01-15T11:05:00Z"`
+	CreatedAt       time.Time  `json:"created_at" example:"2023-01-15T10:30:00Z"`
+}
+
+type AccountRecoveryListResponse struct {
+	Requests []AccountRecoveryInfo `json:"requests"`
+}
+
 // ==================== DEVICE MANAGEMENT DTOs ====================
 
 type DeviceInfo struct {
@@ -395,10 +621,60 @@ type UserStatisticsResponse struct {
 }
 
 type SystemStatisticsResponse struct {
-	Users           UserStatisticsResponse `json:"users"`
-	ActiveSessions  int64                  `json:"active_sessions" example:"125"`
-	RecentLogins24h int64                  `json:"recent_logins_24h" example:"42"`
-	FailedLogins24h int64                  `json:"failed_logins_24h" example:"5"`
+	Users           UserStatisticsResponse     `json:"users"`
+	ActiveSessions  int64                      `json:"active_sessions" example:"125"`
+	RecentLogins24h int64                      `json:"recent_logins_24h" example:"42"`
+	FailedLogins24h int64                      `json:"failed_logins_24h" example:"5"`
+	RateLimitBlocks int64                      `json:"rate_limit_blocks" example:"3"`
+	EmailQueueDepth int                        `json:"email_queue_depth" example:"0"`
+	Health          map[string]ComponentHealth `json:"health"`
+	GeneratedAt     time.Time                  `json:"generated_at" example:"2023-01-15T10:30:00Z"`
+}
+
+type ComponentHealth struct {
+	Status    string `json:"status" example:"healthy"`
+	LatencyMs int64  `json:"latency_ms" example:"3"`
+	Error     string `json:"error,omitempty"`
+}
+
+// SlowQueryResponse is the slow query log kept since the API started
+type SlowQueryResponse struct {
+	ThresholdMs  int64                   `json:"threshold_ms" example:"200"`
+	Recent       []SlowQueryEntry        `json:"recent"`       // newest first
+	Fingerprints []QueryFingerprintStats `json:"fingerprints"` // slowest in total first
+}
+
+type SlowQueryEntry struct {
+	SQL        string    `json:"sql"` // with placeholders, arguments are not recorded
+	Operation  string    `json:"operation" example:"select"`
+	Table      string    `json:"table" example:"user_progresses"`
+	Caller     string    `json:"caller" example:"UserService.GetLeaderboard"`
+	Repository string    `json:"repository,omitempty" example:"ContentRepository.GetLeaderboard"`
+	DurationMs float64   `json:"duration_ms" example:"412.5"`
+	Rows       int64     `json:"rows" example:"50"`
+	Error      string    `json:"error,omitempty"`
+	At         time.Time `json:"at"`
+}
+
+// QueryFingerprintStats totals the slow executions of one query shape
+type QueryFingerprintStats struct {
+	Fingerprint  string    `json:"fingerprint"`
+	Table        string    `json:"table" example:"user_progresses"`
+	Caller       string    `json:"caller" example:"UserService.GetLeaderboard"`
+	Count        int64     `json:"count" example:"12"`
+	TotalMs      float64   `json:"total_ms" example:"5120.4"`
+	MaxMs        float64   `json:"max_ms" example:"812.1"`
+	LastSeen     time.Time `json:"last_seen"`
+	MissingIndex bool      `json:"missing_index"`
+	IndexHint    string    `json:"index_hint,omitempty" example:"sequential scan of user_progresses sorted by xp DESC"`
+}
+
+// OpsEvent is one message of the admin live operations stream
+type OpsEvent struct {
+	Type      string                 `json:"type" example:"login"`    // login, registration, rate_limit_block, error, lesson_completions
+	Severity  string                 `json:"severity" example:"info"` // info, warning, error
+	Data      map[string]interface{} `json:"data,omitempty"`
+	Timestamp time.Time              `json:"timestamp" example:"2023-01-15T10:30:00Z"`
 }
 
 // ==================== SEARCH AND PAGINATION DTOs ====================
@@ -0,0 +1,52 @@
+package dto
+
+import "time"
+
+// ==================== SPIRIT BATTLE DTOs ====================
+
+type StartBattleRequest struct {
+	Dynasty string `json:"dynasty" validate:"required,min=1,max=100"`
+}
+
+func (s StartBattleRequest) Validate() error {
+	return GetValidator().Struct(s)
+}
+
+type BattleActionRequest struct {
+	Answer interface{} `json:"answer" validate:"required"`
+}
+
+func (b BattleActionRequest) Validate() error {
+	return GetValidator().Struct(b)
+}
+
+type BattleResponse struct {
+	ID              string            `json:"id"`
+	Dynasty         string            `json:"dynasty"`
+	BossName        string            `json:"boss_name"`
+	BossHP          int               `json:"boss_hp"`
+	BossMaxHP       int               `json:"boss_max_hp"`
+	SpiritHP        int               `json:"spirit_hp"`
+	SpiritMaxHP     int               `json:"spirit_max_hp"`
+	Combo           int               `json:"combo"`
+	TurnCount       int               `json:"turn_count"`
+	TotalQuestions  int               `json:"total_questions"`
+	Status          string            `json:"status" example:"active"`
+	CurrentQuestion *QuestionResponse `json:"current_question,omitempty"`
+	XPReward        int               `json:"xp_reward"`
+	RewardPaid      bool              `json:"reward_paid"`
+	EndedAt         *time.Time        `json:"ended_at,omitempty"`
+	CreatedAt       time.Time         `json:"created_at"`
+}
+
+type BattleActionResponse struct {
+	Correct     bool           `json:"correct"`
+	DamageDealt int            `json:"damage_dealt"`
+	DamageTaken int            `json:"damage_taken"`
+	Battle      BattleResponse `json:"battle"`
+}
+
+type BattleResultResponse struct {
+	Battle   BattleResponse `json:"battle"`
+	XPEarned int            `json:"xp_earned"`
+}
@@ -0,0 +1,46 @@
+package dto
+
+import "time"
+
+// ==================== CERTIFICATE DTOs ====================
+
+type CertificateResponse struct {
+	ID            string    `json:"id"`
+	Dynasty       string    `json:"dynasty" example:"Nhà Trần"`
+	Code          string    `json:"code" example:"K7PQ-3XWM"`
+	RecipientName string    `json:"recipient_name" example:"john_doe"`
+	LessonCount   int       `json:"lesson_count" example:"12"`
+	AverageScore  int       `json:"average_score" example:"88"`
+	StartedAt     time.Time `json:"started_at"`
+	CompletedAt   time.Time `json:"completed_at"`
+	// Presigned link to the certificate image, empty if it couldn't be rendered
+	ImageURL string `json:"image_url,omitempty"`
+	// Where anyone can check the certificate
+	VerifyURL string `json:"verify_url"`
+}
+
+// CertificateLessonSummary is one lesson of the chapter a certificate covers
+type CertificateLessonSummary struct {
+	LessonID      string    `json:"lesson_id"`
+	Title         string    `json:"title"`
+	CharacterID   string    `json:"character_id"`
+	CharacterName string    `json:"character_name"`
+	Score         int       `json:"score" example:"90"`
+	CompletedAt   time.Time `json:"completed_at"`
+}
+
+// CertificateDetailResponse is a certificate with the chapter summary
+type CertificateDetailResponse struct {
+	CertificateResponse
+	Characters []string                   `json:"characters"`
+	Lessons    []CertificateLessonSummary `json:"lessons"`
+}
+
+type CertificateVerificationResponse struct {
+	Valid         bool      `json:"valid" example:"true"`
+	Code          string    `json:"code" example:"K7PQ-3XWM"`
+	Dynasty       string    `json:"dynasty" example:"Nhà Trần"`
+	RecipientName string    `json:"recipient_name" example:"john_doe"`
+	AverageScore  int       `json:"average_score" example:"88"`
+	CompletedAt   time.Time `json:"completed_at"`
+}
@@ -1,20 +1,65 @@
 package dto
 
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/model"
+)
+
 // Character DTOs
 type CharacterResponse struct {
-	ID           string   `json:"id"`
-	Name         string   `json:"name"`
-	Era          string   `json:"era"`
-	Dynasty      string   `json:"dynasty"`
-	Rarity       string   `json:"rarity"`
-	BirthYear    *int     `json:"birth_year"`
-	DeathYear    *int     `json:"death_year"`
-	Description  string   `json:"description"`
-	FamousQuote  string   `json:"famous_quote"`
-	Achievements []string `json:"achievements"`
-	ImageURL     string   `json:"image_url"`
-	IsUnlocked   bool     `json:"is_unlocked"`
-	LessonCount  int      `json:"lesson_count"`
+	ID           string     `json:"id"`
+	Name         string     `json:"name"`
+	Era          string     `json:"era"`
+	Dynasty      string     `json:"dynasty"`
+	Rarity       string     `json:"rarity"`
+	BirthYear    *int       `json:"birth_year"`
+	DeathYear    *int       `json:"death_year"`
+	Description  string     `json:"description"`
+	FamousQuote  string     `json:"famous_quote"`
+	Achievements []string   `json:"achievements"`
+	ImageURL     string     `json:"image_url"`
+	IsUnlocked   bool       `json:"is_unlocked"`
+	UnlockedAt   *time.Time `json:"unlocked_at,omitempty"`
+	IsNew        bool       `json:"is_new,omitempty"` // Unlocked since the user last opened their collection
+	IsFavorite   bool       `json:"is_favorite,omitempty"`
+	LessonCount  int        `json:"lesson_count"`
+
+	// Only filled in on the character details endpoint
+	RelatedCharacters []RelatedCharacterResponse `json:"related_characters,omitempty"`
+}
+
+// RelatedCharacterResponse is a related figure as seen from the character being viewed
+type RelatedCharacterResponse struct {
+	RelationID  string `json:"relation_id"`
+	Relation    string `json:"relation" example:"ally"` // ally, rival, mentor, student, family
+	Description string `json:"description,omitempty"`
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Era         string `json:"era"`
+	Dynasty     string `json:"dynasty"`
+	Rarity      string `json:"rarity"`
+	ImageURL    string `json:"image_url"`
+}
+
+type CreateCharacterRelationRequest struct {
+	RelatedCharacterID string `json:"related_character_id" validate:"required"`
+	Type               string `json:"type" validate:"required,oneof=ally rival mentor family" example:"mentor"`
+	Description        string `json:"description,omitempty" validate:"max=1000"`
+}
+
+func (r CreateCharacterRelationRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+type UpdateCharacterRelationRequest struct {
+	Type        string  `json:"type,omitempty" validate:"omitempty,oneof=ally rival mentor family" example:"ally"`
+	Description *string `json:"description,omitempty" validate:"omitempty,max=1000"`
+}
+
+func (r UpdateCharacterRelationRequest) Validate() error {
+	return GetValidator().Struct(r)
 }
 
 type CharacterCollectionResponse struct {
@@ -31,6 +76,9 @@ type QuestionResponse struct {
 	Options  []string               `json:"options,omitempty"`
 	Points   int                    `json:"points"`
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
+
+	// Attached media keyed by role (image, audio)
+	Media map[string]QuestionMediaResponse `json:"media,omitempty"`
 }
 
 type LessonResponse struct {
@@ -40,6 +88,7 @@ type LessonResponse struct {
 	Order       int    `json:"order"`
 	Story       string `json:"story"`
 	Script      string `json:"script"`
+	Locale      string `json:"locale,omitempty" example:"vi"`
 
 	// Production Workflow
 	ScriptStatus    string `json:"script_status"`
@@ -53,13 +102,44 @@ type LessonResponse struct {
 	ThumbnailURL string `json:"thumbnail_url,omitempty"`
 
 	// Content Settings
-	CanSkipAfter int  `json:"can_skip_after"`
-	HasSubtitles bool `json:"has_subtitles"`
+	CanSkipAfter  int    `json:"can_skip_after"`
+	HasSubtitles  bool   `json:"has_subtitles"`
+	ContentRating string `json:"content_rating,omitempty" example:"13+"`
+
+	// Set by an admin or worked out from the questions and video length
+	Difficulty       string `json:"difficulty" example:"medium"`
+	EstimatedMinutes int    `json:"estimated_minutes" example:"6"`
+
+	Accessibility LessonAccessibility `json:"accessibility"`
 
 	Questions []QuestionResponse `json:"questions"`
 	XPReward  int                `json:"xp_reward"`
 	MinScore  int                `json:"min_score"`
 	Character CharacterResponse  `json:"character"`
+
+	// Glossary terms found in Story, only for the source locale
+	GlossaryTerms []GlossaryAnnotation `json:"glossary_terms,omitempty"`
+}
+
+// LessonAccessibility lists the aids a lesson offers, so the client can follow the
+// learner's accessibility settings
+type LessonAccessibility struct {
+	Captions            bool   `json:"captions"`
+	CaptionsURL         string `json:"captions_url,omitempty"`
+	Transcript          string `json:"transcript,omitempty"`
+	TranscriptSource    string `json:"transcript_source,omitempty" example:"subtitles"` // subtitles, uploaded
+	AudioDescription    bool   `json:"audio_description"`
+	AudioDescriptionURL string `json:"audio_description_url,omitempty"`
+}
+
+// GlossaryAnnotation marks a glossary term in a lesson story. Start and End are
+// character (code point) offsets into the story, End exclusive.
+type GlossaryAnnotation struct {
+	TermID     string `json:"term_id"`
+	Term       string `json:"term" example:"Bắc thuộc"`
+	Start      int    `json:"start" example:"42"`
+	End        int    `json:"end" example:"51"`
+	Definition string `json:"definition"`
 }
 
 type LessonAccessRequest struct {
@@ -74,6 +154,8 @@ type LessonAccessResponse struct {
 	CanAccess    bool   `json:"can_access"`
 	Reason       string `json:"reason"`
 	HeartsNeeded int    `json:"hearts_needed,omitempty"`
+	// Daily lesson quota, guests only
+	Quota *GuestAttemptQuota `json:"quota,omitempty"`
 }
 
 type ValidateLessonRequest struct {
@@ -90,6 +172,26 @@ type ValidateLessonResponse struct {
 	Passed      bool `json:"passed"`
 	TotalPoints int  `json:"total_points"`
 	MinScore    int  `json:"min_score"`
+	// Points after category weights; Score is EarnedPoints out of WeightedTotal
+	EarnedPoints  float64         `json:"earned_points" example:"42.5"`
+	WeightedTotal float64         `json:"weighted_total" example:"60"`
+	Questions     []QuestionScore `json:"questions"`
+}
+
+// QuestionScore is how one question counted towards a lesson score
+type QuestionScore struct {
+	QuestionID string `json:"question_id"`
+	Type       string `json:"type"`
+	Category   string `json:"category" example:"comprehension"`
+	Answered   bool   `json:"answered"`
+	Correct    bool   `json:"correct"`
+	// Share of the question earned, between 0 and 1. Connect and drag_drop questions
+	// earn a share per right pair or position.
+	Credit       float64 `json:"credit" example:"0.75"`
+	Points       int     `json:"points" example:"20"`
+	Weight       float64 `json:"weight" example:"1.5"`
+	MaxPoints    float64 `json:"max_points" example:"30"`
+	EarnedPoints float64 `json:"earned_points" example:"22.5"`
 }
 
 type SubmitQuestionAnswerRequest struct {
@@ -134,6 +236,125 @@ type CheckLessonStatusResponse struct {
 	RemainingPoints   int  `json:"remaining_points"`
 }
 
+// UpdateLessonSessionRequest checkpoints a lesson in progress. Time spent only grows, a
+// smaller value than the stored one is ignored.
+type UpdateLessonSessionRequest struct {
+	VideoPositionSeconds *int `json:"video_position_seconds,omitempty" validate:"omitempty,min=0" example:"95"`
+	TimeSpentSeconds     *int `json:"time_spent_seconds,omitempty" validate:"omitempty,min=0" example:"140"`
+}
+
+func (u UpdateLessonSessionRequest) Validate() error {
+	return GetValidator().Struct(u)
+}
+
+type LessonSessionAnswer struct {
+	QuestionID string      `json:"question_id"`
+	Answer     interface{} `json:"answer"`
+	Correct    bool        `json:"correct"`
+	Points     int         `json:"points"`
+	AnsweredAt time.Time   `json:"answered_at"`
+}
+
+// LessonSessionResponse is what the app needs to resume a lesson where it was left.
+// Answers come in question order; NextQuestionID is empty once every question is answered.
+type LessonSessionResponse struct {
+	LessonID             string                    `json:"lesson_id"`
+	StartedAt            time.Time                 `json:"started_at"`
+	LastActiveAt         time.Time                 `json:"last_active_at"`
+	ExpiresAt            time.Time                 `json:"expires_at"`
+	RemainingSeconds     int                       `json:"remaining_seconds"`
+	VideoPositionSeconds int                       `json:"video_position_seconds"`
+	VideoDurationSeconds int                       `json:"video_duration_seconds,omitempty"`
+	TimeSpentSeconds     int                       `json:"time_spent_seconds"`
+	Answers              []LessonSessionAnswer     `json:"answers"`
+	NextQuestionID       string                    `json:"next_question_id,omitempty"`
+	Status               CheckLessonStatusResponse `json:"status"`
+	Aids                 LessonAidsResponse        `json:"aids"`
+}
+
+// UseLessonAidRequest picks what pays for a lesson aid: half a heart or coins
+type UseLessonAidRequest struct {
+	PayWith string `json:"pay_with" validate:"required,oneof=hearts coins" example:"hearts"`
+}
+
+func (u UseLessonAidRequest) Validate() error {
+	return GetValidator().Struct(u)
+}
+
+// LessonAidUseResponse is an option already taken out of a question in this attempt
+type LessonAidUseResponse struct {
+	QuestionID    string `json:"question_id"`
+	Aid           string `json:"aid" example:"fifty_fifty"`
+	RemovedOption string `json:"removed_option"`
+}
+
+// LessonAidsResponse is what is left of the lesson aids in the current attempt
+type LessonAidsResponse struct {
+	FiftyFiftyLimit     int                    `json:"fifty_fifty_limit" example:"2"`
+	FiftyFiftyRemaining int                    `json:"fifty_fifty_remaining" example:"1"`
+	FiftyFiftyCoinPrice int                    `json:"fifty_fifty_coin_price" example:"15"`
+	HalfHeartBanked     bool                   `json:"half_heart_banked"` // the next half-heart aid is already paid for
+	Used                []LessonAidUseResponse `json:"used"`
+}
+
+// FiftyFiftyResponse names the wrong option to hide. Charged is false when the aid was
+// already used on this question and the earlier result is returned.
+type FiftyFiftyResponse struct {
+	QuestionID    string             `json:"question_id"`
+	RemovedOption string             `json:"removed_option"`
+	PaidWith      string             `json:"paid_with" example:"hearts"`
+	Cost          int                `json:"cost" example:"1"` // whole hearts or coins taken
+	Charged       bool               `json:"charged"`
+	Hearts        int                `json:"hearts" example:"4"`
+	Coins         int                `json:"coins" example:"120"`
+	Aids          LessonAidsResponse `json:"aids"`
+}
+
+// VideoProgressRequest is a heartbeat sent every few seconds while a lesson video plays,
+// plus one when the user skips the rest and one when it ends
+type VideoProgressRequest struct {
+	Event           string `json:"event" validate:"required,oneof=progress skip ended" example:"progress"`
+	PositionSeconds int    `json:"position_seconds" validate:"min=0" example:"42"`
+}
+
+func (v VideoProgressRequest) Validate() error {
+	return GetValidator().Struct(v)
+}
+
+type VideoProgressResponse struct {
+	LessonID        string `json:"lesson_id"`
+	DurationSeconds int    `json:"duration_seconds"`
+	PositionSeconds int    `json:"position_seconds"`
+	WatchedSeconds  int    `json:"watched_seconds"`
+	WatchPercent    int    `json:"watch_percent"`
+	Skipped         bool   `json:"skipped"`
+	// Whether the user watched enough to earn the score bonus XP
+	BonusEligible        bool `json:"bonus_eligible"`
+	BonusMinWatchPercent int  `json:"bonus_min_watch_percent"`
+}
+
+type VideoAnalyticsDay struct {
+	Day   string `json:"day" example:"2025-01-31"`
+	Plays int64  `json:"plays"`
+	Skips int64  `json:"skips"`
+}
+
+// LessonVideoAnalyticsResponse shows how much of a lesson's video gets watched. Plays and
+// skips count the last Days days, the averages cover every viewer.
+type LessonVideoAnalyticsResponse struct {
+	LessonID            string              `json:"lesson_id"`
+	DurationSeconds     int                 `json:"duration_seconds"`
+	CanSkipAfter        int                 `json:"can_skip_after"`
+	Days                int                 `json:"days"`
+	Plays               int64               `json:"plays"`
+	Skips               int64               `json:"skips"`
+	SkipRate            float64             `json:"skip_rate"`
+	Viewers             int64               `json:"viewers"`
+	AverageWatchPercent float64             `json:"average_watch_percent"`
+	AverageSkipSecond   float64             `json:"average_skip_second"`
+	Daily               []VideoAnalyticsDay `json:"daily"`
+}
+
 type CompleteLessonResponse struct {
 	XPGained        int    `json:"xp_gained"`
 	NewLevel        int    `json:"new_level"`
@@ -180,6 +401,8 @@ type SearchRequest struct {
 	Era     string `json:"era" form:"era" validate:"omitempty"`
 	Dynasty string `json:"dynasty" form:"dynasty" validate:"omitempty"`
 	Rarity  string `json:"rarity" form:"rarity" validate:"omitempty,oneof=common rare epic legendary"`
+	Type    string `json:"type" form:"type" validate:"omitempty,oneof=character lesson"`
+	Page    int    `json:"page" form:"page" validate:"omitempty,min=1"`
 	Limit   int    `json:"limit" form:"limit" validate:"omitempty,min=1,max=100"`
 }
 
@@ -187,9 +410,77 @@ func (s SearchRequest) Validate() error {
 	return GetValidator().Struct(s)
 }
 
+// SearchResult is a single character or lesson hit. Snippet is HTML-escaped text
+// around the first match with the matched part wrapped in <mark> tags.
+type SearchResult struct {
+	Type         string             `json:"type" example:"lesson"`
+	ID           string             `json:"id"`
+	Title        string             `json:"title"`
+	MatchedField string             `json:"matched_field,omitempty" example:"story"`
+	Snippet      string             `json:"snippet,omitempty" example:"...the <mark>Bach Dang</mark> river..."`
+	Character    *CharacterResponse `json:"character,omitempty"`
+	CharacterID  string             `json:"character_id,omitempty"`
+}
+
 type SearchResponse struct {
+	Results []SearchResult `json:"results"`
+	Total   int            `json:"total"`
+	Page    int            `json:"page"`
+	Limit   int            `json:"limit"`
+
+	// Deprecated: character hits on this page, kept for older clients. Use Results.
 	Characters []CharacterResponse `json:"characters"`
-	Total      int                 `json:"total"`
+}
+
+type SearchSuggestRequest struct {
+	Query string `json:"q" form:"q" validate:"required,min=1,max=100"`
+	Limit int    `json:"limit" form:"limit" validate:"omitempty,min=1,max=20"`
+}
+
+func (s SearchSuggestRequest) Validate() error {
+	return GetValidator().Struct(s)
+}
+
+// SearchSuggestion is one typeahead entry. ID is the dynasty name for dynasty suggestions.
+type SearchSuggestion struct {
+	Type        string `json:"type" example:"character"`
+	ID          string `json:"id"`
+	Text        string `json:"text" example:"Trần Hưng Đạo"`
+	CharacterID string `json:"character_id,omitempty"`
+}
+
+type SearchSuggestResponse struct {
+	Query       string             `json:"query"`
+	Suggestions []SearchSuggestion `json:"suggestions"`
+}
+
+type TrendingContentRequest struct {
+	Type  string `json:"type" form:"type" validate:"omitempty,oneof=lesson character"`
+	Limit int    `json:"limit" form:"limit" validate:"omitempty,min=1,max=50"`
+}
+
+func (t TrendingContentRequest) Validate() error {
+	return GetValidator().Struct(t)
+}
+
+// TrendingContentItem is a lesson or character with its activity over the trending window
+type TrendingContentItem struct {
+	Type        string             `json:"type" example:"lesson"`
+	ID          string             `json:"id"`
+	Title       string             `json:"title" example:"Hai Bà Trưng khởi nghĩa"`
+	CharacterID string             `json:"character_id,omitempty"`
+	ImageURL    string             `json:"image_url,omitempty"`
+	Views       int64              `json:"views"`
+	Starts      int64              `json:"starts"`
+	Completions int64              `json:"completions"`
+	Score       int64              `json:"score"`
+	Character   *CharacterResponse `json:"character,omitempty"`
+}
+
+type TrendingContentResponse struct {
+	Items []TrendingContentItem `json:"items"`
+	Since time.Time             `json:"since"`
+	Days  int                   `json:"days" example:"7"`
 }
 
 // Lesson Creation DTOs
@@ -204,15 +495,64 @@ type CreateLessonRequest struct {
 	Questions    []CreateQuestionRequest `json:"questions" validate:"omitempty,dive"`
 	XPReward     int                     `json:"xp_reward" validate:"omitempty,min=1,max=1000"`
 	MinScore     int                     `json:"min_score" validate:"omitempty,min=0,max=100"`
+	// Leave empty to rate the lesson later, it then shows up in the unrated content report
+	ContentRating string `json:"content_rating" validate:"omitempty,oneof=all 10+ 13+ 16+" example:"all"`
+	// Leave empty or 0 to work them out from the questions and video length
+	Difficulty       string `json:"difficulty" validate:"omitempty,oneof=easy medium hard" example:"medium"`
+	EstimatedMinutes int    `json:"estimated_minutes" validate:"omitempty,min=1,max=180" example:"6"`
 }
 
 func (c CreateLessonRequest) Validate() error {
 	return GetValidator().Struct(c)
 }
 
+type UpdateContentRatingRequest struct {
+	ContentRating string `json:"content_rating" validate:"required,oneof=all 10+ 13+ 16+" example:"13+"`
+}
+
+func (r UpdateContentRatingRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+// UpdateLessonDifficultyRequest overrides the computed difficulty and estimated minutes.
+// An empty difficulty or 0 minutes goes back to the computed value.
+type UpdateLessonDifficultyRequest struct {
+	Difficulty       string `json:"difficulty" validate:"omitempty,oneof=easy medium hard" example:"hard"`
+	EstimatedMinutes int    `json:"estimated_minutes" validate:"min=0,max=180" example:"12"`
+}
+
+func (r UpdateLessonDifficultyRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+// UpdateLessonTranscriptRequest replaces the transcript generated from the subtitles.
+// An empty transcript goes back to the generated one.
+type UpdateLessonTranscriptRequest struct {
+	Transcript string `json:"transcript" validate:"max=100000"`
+}
+
+func (r UpdateLessonTranscriptRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+type UnratedLessonItem struct {
+	LessonID      string    `json:"lesson_id"`
+	CharacterID   string    `json:"character_id"`
+	CharacterName string    `json:"character_name"`
+	Title         string    `json:"title"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type UnratedLessonListResponse struct {
+	Lessons []UnratedLessonItem `json:"lessons"`
+	Total   int                 `json:"total"`
+	Page    int                 `json:"page"`
+	Limit   int                 `json:"limit"`
+}
+
 type CreateQuestionRequest struct {
 	ID       string                 `json:"id" validate:"omitempty"`
-	Type     string                 `json:"type" validate:"required,oneof=multiple_choice true_false fill_blank matching"`
+	Type     string                 `json:"type" validate:"required,oneof=multiple_choice drag_drop fill_blank connect"`
 	Question string                 `json:"question" validate:"required,min=1,max=1000"`
 	Options  []string               `json:"options,omitempty" validate:"omitempty,dive,min=1,max=200"`
 	Answer   interface{}            `json:"answer" validate:"required"`
@@ -243,3 +583,298 @@ type LessonProductionStatusResponse struct {
 	CanUploadAudio     bool   `json:"can_upload_audio"`
 	CanUploadAnimation bool   `json:"can_upload_animation"`
 }
+
+// LessonLintResponse lists the accessibility problems found in a lesson
+type LessonLintResponse struct {
+	LessonID string                   `json:"lesson_id"`
+	Title    string                   `json:"title"`
+	Warnings int                      `json:"warnings"`
+	Issues   []model.ContentLintIssue `json:"issues"`
+}
+
+// ==================== QUESTION REPORT DTOs ====================
+
+type ReportQuestionRequest struct {
+	LessonID string `json:"lesson_id" validate:"required" example:"lesson_123"`
+	Reason   string `json:"reason" validate:"required,oneof=wrong_answer confusing typo offensive other" example:"wrong_answer"`
+	Comment  string `json:"comment,omitempty" validate:"max=500" example:"Trận Bạch Đằng was in 938, not 939"`
+}
+
+func (r ReportQuestionRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+type QuestionReportResponse struct {
+	LessonID   string `json:"lesson_id"`
+	QuestionID string `json:"question_id"`
+	Reason     string `json:"reason" example:"wrong_answer"`
+	// The learner had reported this question before; the first report is kept
+	AlreadyReported bool `json:"already_reported"`
+}
+
+// QuestionAnalyticsResponse is how learners did on one question and what they reported
+type QuestionAnalyticsResponse struct {
+	QuestionID string `json:"question_id"`
+	Question   string `json:"question"`
+	// active, or disabled while it waits for review
+	Status     string  `json:"status" example:"active"`
+	DisabledID string  `json:"disabled_id,omitempty"`
+	Answers    int     `json:"answers" example:"240"`
+	Correct    int     `json:"correct" example:"96"`
+	CorrectPct float64 `json:"correct_pct" example:"40"`
+	// Reports no admin has reviewed yet, and all reports by reason
+	PendingReports  int            `json:"pending_reports" example:"3"`
+	ReportsByReason map[string]int `json:"reports_by_reason"`
+}
+
+type LessonQuestionAnalyticsResponse struct {
+	LessonID        string                      `json:"lesson_id"`
+	Title           string                      `json:"title"`
+	ReportThreshold int                         `json:"report_threshold" example:"5"`
+	Questions       []QuestionAnalyticsResponse `json:"questions"`
+}
+
+type DisabledQuestionResponse struct {
+	ID          string         `json:"id"`
+	LessonID    string         `json:"lesson_id"`
+	LessonTitle string         `json:"lesson_title"`
+	QuestionID  string         `json:"question_id"`
+	Question    model.Question `json:"question"`
+	Cause       string         `json:"cause" example:"reports"`
+	Reports     int            `json:"reports" example:"5"`
+	Status      string         `json:"status" example:"pending"`
+	ReviewedBy  string         `json:"reviewed_by,omitempty"`
+	ReviewNote  string         `json:"review_note,omitempty"`
+	ReviewedAt  *time.Time     `json:"reviewed_at,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+type DisabledQuestionListResponse struct {
+	Questions []DisabledQuestionResponse `json:"questions"`
+	Total     int                        `json:"total" example:"3"`
+	Page      int                        `json:"page" example:"1"`
+	Limit     int                        `json:"limit" example:"20"`
+}
+
+type ReviewDisabledQuestionRequest struct {
+	// restore puts the question back in its lesson, remove leaves it out for good
+	Action string `json:"action" validate:"required,oneof=restore remove" example:"restore"`
+	Note   string `json:"note,omitempty" validate:"max=500"`
+	// Corrected question to restore instead of the disabled one; its ID is kept
+	Question *CreateQuestionRequest `json:"question,omitempty"`
+}
+
+func (r ReviewDisabledQuestionRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+// ==================== CONTENT INCIDENT DTOs ====================
+
+type KillContentRequest struct {
+	// Pulls only this question instead of the whole lesson
+	QuestionID string `json:"question_id,omitempty" validate:"max=50" example:"q_003"`
+	Reason     string `json:"reason" validate:"required,max=1000" example:"Wrong year for the battle of Bạch Đằng"`
+}
+
+func (r KillContentRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+type ResolveContentIncidentRequest struct {
+	// restore puts the content back live, keep_disabled retires it for good
+	Action string `json:"action" validate:"required,oneof=restore keep_disabled" example:"restore"`
+	Note   string `json:"note,omitempty" validate:"max=1000"`
+}
+
+func (r ResolveContentIncidentRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+type ContentIncidentResponse struct {
+	ID                 string     `json:"id"`
+	EntityType         string     `json:"entity_type" example:"lesson"`
+	LessonID           string     `json:"lesson_id"`
+	LessonTitle        string     `json:"lesson_title"`
+	QuestionID         string     `json:"question_id,omitempty"`
+	DisabledQuestionID string     `json:"disabled_question_id,omitempty"`
+	Reason             string     `json:"reason"`
+	DisabledBy         string     `json:"disabled_by"`
+	SessionsNotified   int        `json:"sessions_notified" example:"12"`
+	CachesBroadcast    bool       `json:"caches_broadcast"`
+	Status             string     `json:"status" example:"open"`
+	Resolution         string     `json:"resolution,omitempty" example:"restored"`
+	ResolvedBy         string     `json:"resolved_by,omitempty"`
+	ResolutionNote     string     `json:"resolution_note,omitempty"`
+	ResolvedAt         *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+type ContentIncidentListResponse struct {
+	Incidents []ContentIncidentResponse `json:"incidents"`
+	Total     int                       `json:"total" example:"3"`
+	Page      int                       `json:"page" example:"1"`
+	Limit     int                       `json:"limit" example:"20"`
+}
+
+// ==================== CONTENT AUDIT DTOs ====================
+
+type ContentAuditLogResponse struct {
+	ID            string          `json:"id"`
+	AdminID       string          `json:"admin_id"`
+	EntityType    string          `json:"entity_type" example:"lesson"`
+	EntityID      string          `json:"entity_id"`
+	Action        string          `json:"action" example:"update"`
+	Before        json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After         json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+	ChangedFields []string        `json:"changed_fields,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+type ContentAuditLogListResponse struct {
+	Logs  []ContentAuditLogResponse `json:"logs"`
+	Total int                       `json:"total" example:"150"`
+	Page  int                       `json:"page" example:"1"`
+	Limit int                       `json:"limit" example:"20"`
+}
+
+// ==================== GLOSSARY DTOs ====================
+
+type GlossaryTermRequest struct {
+	Term       string   `json:"term" validate:"required,max=100" example:"Bắc thuộc"`
+	Aliases    []string `json:"aliases,omitempty" validate:"max=20,dive,required,max=100"`
+	Definition string   `json:"definition" validate:"required,max=5000"`
+	Era        string   `json:"era,omitempty" validate:"max=50"`
+	ImageURL   string   `json:"image_url,omitempty" validate:"omitempty,url"`
+	AudioURL   string   `json:"audio_url,omitempty" validate:"omitempty,url"`
+}
+
+func (r GlossaryTermRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+type GlossaryTermListResponse struct {
+	Terms []model.GlossaryTerm `json:"terms"`
+	Total int                  `json:"total" example:"120"`
+	Page  int                  `json:"page" example:"1"`
+	Limit int                  `json:"limit" example:"20"`
+}
+
+// ==================== ERA AND DYNASTY DTOs ====================
+
+type EraRequest struct {
+	Name        string `json:"name" validate:"required,max=100" example:"Độc lập"`
+	Description string `json:"description,omitempty" validate:"max=5000"`
+	Order       int    `json:"order" validate:"min=0,max=10000" example:"2"`
+}
+
+func (r EraRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+type CreateEraRequest struct {
+	// Letters, digits and underscores; content refers to the era by it, so it can't change
+	Code string `json:"code" validate:"required,max=50" example:"Doc_Lap"`
+	EraRequest
+}
+
+func (r CreateEraRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+type DynastyRequest struct {
+	Name      string `json:"name" validate:"required,max=100" example:"Trần"`
+	Era       string `json:"era" validate:"required,max=50" example:"Phong_Kien"`
+	StartYear *int   `json:"start_year,omitempty" example:"1225"`
+	EndYear   *int   `json:"end_year,omitempty" example:"1400"`
+	Order     int    `json:"order" validate:"min=0,max=10000" example:"7"`
+}
+
+func (r DynastyRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+// ==================== QUESTION BULK EDIT DTOs ====================
+
+type QuestionFieldChange struct {
+	Field  string      `json:"field" example:"points"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+type QuestionDiff struct {
+	QuestionID string                `json:"question_id"`
+	Row        int                   `json:"row" example:"3"`
+	Changes    []QuestionFieldChange `json:"changes"`
+}
+
+type QuestionImportError struct {
+	Row     int    `json:"row" example:"4"`
+	Field   string `json:"field,omitempty" example:"answer"`
+	Message string `json:"message"`
+}
+
+// QuestionImportResponse describes an import. With preview nothing is saved; pass
+// BaseVersion back when applying so edits made in the meantime are not overwritten.
+type QuestionImportResponse struct {
+	LessonID    string                `json:"lesson_id"`
+	Preview     bool                  `json:"preview"`
+	Applied     bool                  `json:"applied"`
+	BaseVersion string                `json:"base_version"`
+	Changed     []QuestionDiff        `json:"changed"`
+	Unchanged   int                   `json:"unchanged"`
+	Errors      []QuestionImportError `json:"errors,omitempty"`
+}
+
+// ==================== LESSON VERSION DTOs ====================
+
+// LessonVersion is a saved state of a lesson. Versions are numbered from 1 in the order
+// the lesson's content audit trail recorded them.
+type LessonVersion struct {
+	Version       int       `json:"version" example:"3"`
+	AuditLogID    string    `json:"audit_log_id"`
+	AdminID       string    `json:"admin_id"`
+	Action        string    `json:"action" example:"update"`
+	ChangedFields []string  `json:"changed_fields,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type LessonVersionListResponse struct {
+	LessonID string          `json:"lesson_id"`
+	Versions []LessonVersion `json:"versions"`
+}
+
+type LessonFieldChange struct {
+	Field  string      `json:"field" example:"title"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+	// Line edits for long text fields, numbered from 1 in the text they belong to:
+	// the version for removed lines, the live lesson for added ones
+	Lines []TextLineChange `json:"lines,omitempty"`
+}
+
+type TextLineChange struct {
+	Op   string `json:"op" example:"add"` // add, remove
+	Line int    `json:"line" example:"12"`
+	Text string `json:"text"`
+}
+
+type LessonQuestionChange struct {
+	QuestionID string                `json:"question_id"`
+	Change     string                `json:"change" example:"changed"` // added, removed, changed
+	Before     *model.Question       `json:"before,omitempty"`
+	After      *model.Question       `json:"after,omitempty"`
+	Changes    []QuestionFieldChange `json:"changes,omitempty"`
+}
+
+// LessonVersionDiffResponse compares a version with the live lesson. Before values are
+// the version's and After values the live lesson's; unchanged parts are left out.
+type LessonVersionDiffResponse struct {
+	LessonID      string                 `json:"lesson_id"`
+	Version       LessonVersion          `json:"version"`
+	LiveUpdatedAt time.Time              `json:"live_updated_at"`
+	Identical     bool                   `json:"identical"`
+	Story         []LessonFieldChange    `json:"story"`
+	Questions     []LessonQuestionChange `json:"questions"`
+	Media         []LessonFieldChange    `json:"media"`
+	Settings      []LessonFieldChange    `json:"settings"`
+}
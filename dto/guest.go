@@ -1,9 +1,32 @@
 package dto
 
-import "github.com/lac-hong-legacy/ven_api/model"
+import (
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/model"
+)
+
+// AttestationPayload carries proof that a request comes from a genuine app install.
+// Android sends a Play Integrity token whose nonce is the challenge. iOS sends an App
+// Attest attestation object the first time a key is used and assertions afterwards,
+// both over the SHA-256 of the challenge.
+type AttestationPayload struct {
+	Platform    string `json:"platform" validate:"required,oneof=android ios" example:"android"`
+	Challenge   string `json:"challenge" validate:"required,max=100"`
+	Token       string `json:"token,omitempty" validate:"omitempty,max=16384"`       // Play Integrity token
+	KeyID       string `json:"key_id,omitempty" validate:"omitempty,max=100"`        // App Attest key ID, base64
+	Attestation string `json:"attestation,omitempty" validate:"omitempty,max=16384"` // App Attest attestation object, base64
+	Assertion   string `json:"assertion,omitempty" validate:"omitempty,max=4096"`    // App Attest assertion, base64
+}
+
+type AttestationChallengeResponse struct {
+	Challenge string    `json:"challenge"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
 
 type CreateSessionRequest struct {
-	DeviceID string `json:"device_id" validate:"required,min=1,max=100"`
+	DeviceID    string              `json:"device_id" validate:"required,min=1,max=100"`
+	Attestation *AttestationPayload `json:"attestation,omitempty"`
 }
 
 func (c CreateSessionRequest) Validate() error {
@@ -13,6 +36,21 @@ func (c CreateSessionRequest) Validate() error {
 type CreateSessionResponse struct {
 	Session  *model.GuestSession  `json:"session"`
 	Progress *model.GuestProgress `json:"progress"`
+	Quota    *GuestAttemptQuota   `json:"quota,omitempty"`
+}
+
+// GuestAttemptQuota is how many more lessons a guest can play today, for showing a
+// registration nudge before it runs out. Omitted when no quota is configured.
+type GuestAttemptQuota struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	ResetsAt  time.Time `json:"resets_at"`
+}
+
+// GuestProgressResponse is a guest's progress with their quota after a lesson
+type GuestProgressResponse struct {
+	*model.GuestProgress
+	Quota *GuestAttemptQuota `json:"quota,omitempty"`
 }
 
 type CompleteLessonRequest struct {
@@ -24,3 +62,11 @@ type CompleteLessonRequest struct {
 func (c CompleteLessonRequest) Validate() error {
 	return GetValidator().Struct(c)
 }
+
+type AddHeartsFromAdRequest struct {
+	Attestation *AttestationPayload `json:"attestation,omitempty"`
+}
+
+func (c AddHeartsFromAdRequest) Validate() error {
+	return GetValidator().Struct(c)
+}
@@ -1,5 +1,7 @@
 package dto
 
+import "time"
+
 // Media Upload DTOs
 type MediaUploadResponse struct {
 	ID       string `json:"id"`
@@ -9,6 +11,25 @@ type MediaUploadResponse struct {
 	FileSize int64  `json:"file_size"`
 }
 
+// QuestionMediaResponse is media attached to a question. URL is presigned and stops
+// working at ExpiresAt.
+type QuestionMediaResponse struct {
+	MediaAssetID string    `json:"media_asset_id"`
+	Role         string    `json:"role" example:"image"`
+	URL          string    `json:"url"`
+	MimeType     string    `json:"mime_type,omitempty" example:"image/png"`
+	Duration     int       `json:"duration,omitempty"` // seconds, for audio
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+type LinkQuestionMediaRequest struct {
+	MediaAssetID string `json:"media_asset_id" validate:"required"`
+}
+
+func (l LinkQuestionMediaRequest) Validate() error {
+	return GetValidator().Struct(l)
+}
+
 type MediaAssetResponse struct {
 	ID       string `json:"id"`
 	URL      string `json:"url"`
@@ -113,3 +134,152 @@ type MediaValidationResult struct {
 	Resolution string   `json:"resolution,omitempty"`
 	FileSize   int64    `json:"file_size"`
 }
+
+// ==================== MEDIA LIBRARY DTOs ====================
+
+type MediaLibraryQuery struct {
+	FileType         string
+	Processed        *bool
+	ProcessingStatus string
+	LessonID         string
+	Orphaned         bool
+	Search           string
+	Page             int
+	Limit            int
+}
+
+type MediaUsage struct {
+	LessonID  string `json:"lesson_id"`
+	MediaType string `json:"media_type"`
+	IsActive  bool   `json:"is_active"`
+}
+
+type MediaLibraryItem struct {
+	ID           string       `json:"id"`
+	FileName     string       `json:"file_name"`
+	OriginalName string       `json:"original_name"`
+	FileType     string       `json:"file_type"`
+	MimeType     string       `json:"mime_type"`
+	FileSize     int64        `json:"file_size"`
+	Duration     int          `json:"duration,omitempty"`
+	StoragePath  string       `json:"storage_path"`
+	PreviewURL   string       `json:"preview_url"`
+	Tags         []string     `json:"tags"`
+	IsProcessed  bool         `json:"is_processed"`
+	UsageCount   int          `json:"usage_count"`
+	Usages       []MediaUsage `json:"usages"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+
+	ProcessingStatus   string     `json:"processing_status" example:"failed"`
+	ProcessingError    string     `json:"processing_error,omitempty"`
+	ProcessingAttempts int        `json:"processing_attempts" example:"3"`
+	ProcessedAt        *time.Time `json:"processed_at,omitempty"`
+
+	Bucket string `json:"bucket" example:"ven-learning-videos"`
+}
+
+type MediaLibraryResponse struct {
+	Items []MediaLibraryItem `json:"items"`
+	Total int                `json:"total" example:"150"`
+	Page  int                `json:"page" example:"1"`
+	Limit int                `json:"limit" example:"20"`
+}
+
+type UpdateMediaAssetRequest struct {
+	OriginalName *string  `json:"original_name,omitempty" validate:"omitempty,min=1,max=255"`
+	Tags         []string `json:"tags,omitempty" validate:"omitempty,max=20,dive,min=1,max=50"`
+}
+
+func (u UpdateMediaAssetRequest) Validate() error {
+	return GetValidator().Struct(u)
+}
+
+// ==================== RESUMABLE UPLOAD DTOs ====================
+
+type InitUploadRequest struct {
+	FileName  string `json:"file_name" validate:"required,min=1,max=255"`
+	FileType  string `json:"file_type" validate:"required,oneof=video audio animation"`
+	MimeType  string `json:"mime_type" validate:"omitempty,max=100"`
+	TotalSize int64  `json:"total_size" validate:"required,min=1"`
+}
+
+func (i InitUploadRequest) Validate() error {
+	return GetValidator().Struct(i)
+}
+
+type FinalizeUploadRequest struct {
+	Checksum string `json:"checksum" validate:"required,len=64,hexadecimal"` // SHA-256 of the whole file
+}
+
+func (f FinalizeUploadRequest) Validate() error {
+	return GetValidator().Struct(f)
+}
+
+type UploadSessionResponse struct {
+	UploadID     string    `json:"upload_id"`
+	LessonID     string    `json:"lesson_id"`
+	FileType     string    `json:"file_type"`
+	TotalSize    int64     `json:"total_size"`
+	Offset       int64     `json:"offset"`
+	MinChunkSize int64     `json:"min_chunk_size"`
+	MaxChunkSize int64     `json:"max_chunk_size"`
+	Status       string    `json:"status"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+type CDNPurgeResponse struct {
+	AssetID    string   `json:"asset_id"`
+	PurgedURLs []string `json:"purged_urls"`
+}
+
+// CDNPrewarmRegion is the progress of a prewarm job in one target region
+type CDNPrewarmRegion struct {
+	Region string `json:"region" example:"hanoi"`
+	Warmed int    `json:"warmed"`
+	Failed int    `json:"failed"`
+}
+
+type CDNPrewarmJobResponse struct {
+	ID          string             `json:"id"`
+	LessonID    string             `json:"lesson_id"`
+	Trigger     string             `json:"trigger" example:"publish"` // publish, media_processed, manual
+	RequestedBy string             `json:"requested_by,omitempty"`
+	Status      string             `json:"status" example:"running"` // pending, running, completed, failed
+	URLs        []string           `json:"urls"`
+	Regions     []CDNPrewarmRegion `json:"regions"`
+	Total       int                `json:"total" example:"16"`
+	Warmed      int                `json:"warmed" example:"11"`
+	Failed      int                `json:"failed" example:"1"`
+	Progress    int                `json:"progress" example:"75"` // percent of requests done
+	LastError   string             `json:"last_error,omitempty"`
+	CreatedAt   time.Time          `json:"created_at"`
+	StartedAt   *time.Time         `json:"started_at,omitempty"`
+	FinishedAt  *time.Time         `json:"finished_at,omitempty"`
+}
+
+type MediaStorageConfigResponse struct {
+	DefaultBucket string                 `json:"default_bucket" example:"ven-learning"`
+	DefaultRegion string                 `json:"default_region,omitempty" example:"ap-southeast-1"`
+	ColdTier      string                 `json:"cold_tier,omitempty" example:"COLD"`
+	Categories    []MediaStorageCategory `json:"categories"`
+}
+
+type MediaStorageCategory struct {
+	Category       string               `json:"category" example:"video"`
+	FileTypes      []string             `json:"file_types"`
+	Bucket         string               `json:"bucket" example:"ven-learning-videos"`
+	Region         string               `json:"region,omitempty" example:"ap-southeast-1"`
+	Prefixes       []string             `json:"prefixes"`                               // object prefixes of raw uploads
+	ColdAfterDays  int                  `json:"cold_after_days,omitempty" example:"90"` // 0 keeps uploads in hot storage
+	LifecycleRules []MediaLifecycleRule `json:"lifecycle_rules"`                        // rules currently set on the bucket
+	LifecycleError string               `json:"lifecycle_error,omitempty"`
+}
+
+type MediaLifecycleRule struct {
+	ID           string `json:"id" example:"media-cold-videos"`
+	Prefix       string `json:"prefix" example:"videos/"`
+	Status       string `json:"status" example:"Enabled"`
+	Days         int    `json:"days" example:"90"`
+	StorageClass string `json:"storage_class" example:"COLD"`
+}
@@ -0,0 +1,67 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ==================== NOTIFICATION DTOs ====================
+
+type NotificationResponse struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type" example:"achievement"`
+	Title     string          `json:"title"`
+	Body      string          `json:"body"`
+	Data      json.RawMessage `json:"data,omitempty" swaggertype:"object"`
+	IsRead    bool            `json:"is_read"`
+	ReadAt    *time.Time      `json:"read_at,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type NotificationListResponse struct {
+	Notifications []NotificationResponse `json:"notifications"`
+	UnreadCount   int                    `json:"unread_count" example:"3"`
+	Total         int                    `json:"total" example:"42"`
+	Page          int                    `json:"page" example:"1"`
+	Limit         int                    `json:"limit" example:"20"`
+}
+
+type UnreadCountResponse struct {
+	UnreadCount int `json:"unread_count" example:"3"`
+}
+
+// ==================== STUDY REMINDER DTOs ====================
+
+type StudyReminderRequest struct {
+	Days    []string `json:"days" validate:"required,min=1,dive,oneof=sun mon tue wed thu fri sat" example:"mon,wed,fri"`
+	Time    string   `json:"time" validate:"required,datetime=15:04" example:"19:30"`
+	Push    *bool    `json:"push,omitempty" example:"true"`
+	Email   *bool    `json:"email,omitempty" example:"false"`
+	Enabled *bool    `json:"enabled,omitempty" example:"true"`
+}
+
+func (r StudyReminderRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+type SnoozeStudyReminderRequest struct {
+	Minutes int `json:"minutes" validate:"required,min=5,max=720" example:"60"`
+}
+
+func (r SnoozeStudyReminderRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+// StudyReminderResponse is a reminder schedule. Time is in Timezone, the user's
+// profile timezone.
+type StudyReminderResponse struct {
+	Days           []string   `json:"days" example:"mon,wed,fri"`
+	Time           string     `json:"time" example:"19:30"`
+	Timezone       string     `json:"timezone" example:"Asia/Ho_Chi_Minh"`
+	Push           bool       `json:"push" example:"true"`
+	Email          bool       `json:"email" example:"false"`
+	Enabled        bool       `json:"enabled" example:"true"`
+	NextReminderAt *time.Time `json:"next_reminder_at,omitempty"`
+	SnoozedUntil   *time.Time `json:"snoozed_until,omitempty"`
+	LastSentAt     *time.Time `json:"last_sent_at,omitempty"`
+}
@@ -0,0 +1,76 @@
+package dto
+
+import "time"
+
+// ==================== PUBLIC CONTENT DTOs ====================
+// Served without sign-in to the marketing website, so they carry no progress, questions
+// or answers
+
+type PublicCharacterResponse struct {
+	ID          string `json:"id"`
+	Name        string `json:"name" example:"Trần Hưng Đạo"`
+	Era         string `json:"era" example:"Doc_Lap"`
+	Dynasty     string `json:"dynasty" example:"Nhà Trần"`
+	Rarity      string `json:"rarity" example:"Legendary"`
+	BirthYear   *int   `json:"birth_year"`
+	DeathYear   *int   `json:"death_year"`
+	Description string `json:"description"`
+	FamousQuote string `json:"famous_quote"`
+	ImageURL    string `json:"image_url"`
+	LessonCount int    `json:"lesson_count" example:"4"`
+}
+
+// PublicLessonTeaser is the start of a lesson's story, to make visitors want the rest
+type PublicLessonTeaser struct {
+	ID           string `json:"id"`
+	CharacterID  string `json:"character_id"`
+	Title        string `json:"title"`
+	Order        int    `json:"order" example:"1"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	Teaser       string `json:"teaser"`
+}
+
+type PublicCharacterDetailResponse struct {
+	PublicCharacterResponse
+	Lessons []PublicLessonTeaser `json:"lessons"`
+}
+
+type PublicCharacterListResponse struct {
+	Characters []PublicCharacterResponse `json:"characters"`
+	Total      int                       `json:"total"`
+}
+
+type PublicCharacterSummary struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	ImageURL string `json:"image_url"`
+}
+
+type PublicDynastyResponse struct {
+	Era         string                   `json:"era" example:"Doc_Lap"`
+	Dynasty     string                   `json:"dynasty" example:"Nhà Trần"`
+	StartYear   int                      `json:"start_year" example:"1225"`
+	EndYear     *int                     `json:"end_year" example:"1400"`
+	Description string                   `json:"description"`
+	ImageURL    string                   `json:"image_url"`
+	Characters  []PublicCharacterSummary `json:"characters"`
+}
+
+type PublicTimelineResponse struct {
+	Dynasties []PublicDynastyResponse `json:"dynasties"`
+}
+
+// PublicPageMetadata is what the website puts in the head of a server-rendered page so
+// search engines and chat apps show a rich preview
+type PublicPageMetadata struct {
+	Title        string `json:"title" example:"Trần Hưng Đạo | Ven"`
+	Description  string `json:"description"`
+	CanonicalURL string `json:"canonical_url"`
+	ImageURL     string `json:"image_url,omitempty"`
+	// Open Graph and Twitter card meta tags, keyed by property name (og:title, twitter:card, ...)
+	OpenGraph map[string]string `json:"open_graph"`
+	Twitter   map[string]string `json:"twitter"`
+	// schema.org JSON-LD for a <script type="application/ld+json"> tag
+	StructuredData map[string]interface{} `json:"structured_data"`
+	UpdatedAt      time.Time              `json:"updated_at"`
+}
@@ -0,0 +1,31 @@
+package dto
+
+import "github.com/lac-hong-legacy/ven_api/model"
+
+// ==================== QUESTION GENERATION DTOs ====================
+
+type GenerateQuestionsRequest struct {
+	MultipleChoice int `json:"multiple_choice" validate:"min=0,max=10" example:"3"`
+	FillBlank      int `json:"fill_blank" validate:"min=0,max=10" example:"2"`
+	Points         int `json:"points,omitempty" validate:"omitempty,min=1,max=100" example:"10"`
+}
+
+func (g GenerateQuestionsRequest) Validate() error {
+	return GetValidator().Struct(g)
+}
+
+// RejectedQuestionDraft is a question the provider returned that doesn't fit the
+// question schema
+type RejectedQuestionDraft struct {
+	Index  int    `json:"index" example:"2"`
+	Reason string `json:"reason" example:"answer is not one of the options"`
+}
+
+// GeneratedQuestionsResponse holds drafts that are not saved. Admins review them and add
+// the ones they keep through the usual question editing endpoints.
+type GeneratedQuestionsResponse struct {
+	LessonID string                  `json:"lesson_id"`
+	Model    string                  `json:"model"`
+	Drafts   []model.Question        `json:"drafts"`
+	Rejected []RejectedQuestionDraft `json:"rejected"`
+}
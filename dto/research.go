@@ -0,0 +1,44 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/model"
+)
+
+// ==================== RESEARCH EXPORT DTOs ====================
+
+type CreateResearchExportRequest struct {
+	Partner string `json:"partner" validate:"required,max=100" example:"Hanoi National University of Education"`
+	// Why the data is shared, kept with the export for the audit trail
+	Purpose string `json:"purpose" validate:"required,min=10,max=2000" example:"Study of retention in gamified history lessons"`
+	// Learners registered from CohortFrom up to but not including CohortTo are included
+	CohortFrom string `json:"cohort_from" validate:"required,datetime=2006-01-02" example:"2025-01-01"`
+	CohortTo   string `json:"cohort_to" validate:"required,datetime=2006-01-02" example:"2026-01-01"`
+	// Smallest group shown in the export, defaults to and can't go below the configured minimum
+	MinCohortSize int `json:"min_cohort_size,omitempty" validate:"omitempty,min=2,max=10000" example:"20"`
+}
+
+func (r CreateResearchExportRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+type ResearchExportListResponse struct {
+	Exports []model.ResearchExport `json:"exports"`
+	Total   int                    `json:"total"`
+	Page    int                    `json:"page"`
+	Limit   int                    `json:"limit"`
+}
+
+// ResearchExportDetailResponse is an export with its audit trail
+type ResearchExportDetailResponse struct {
+	model.ResearchExport
+	AuditTrail []model.ResearchExportAccess `json:"audit_trail"`
+}
+
+type ResearchExportDownloadResponse struct {
+	// Signed link to the archive, valid until ExpiresAt
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Checksum  string    `json:"checksum"`
+}
@@ -0,0 +1,109 @@
+package dto
+
+import "time"
+
+// ==================== SHOP DTOs ====================
+
+type ShopItemResponse struct {
+	ID          string `json:"id"`
+	Kind        string `json:"kind" example:"heart_refill"`
+	Name        string `json:"name" example:"Full heart refill"`
+	Description string `json:"description"`
+	ImageURL    string `json:"image_url"`
+	Price       int    `json:"price" example:"50"`
+	Quantity    int    `json:"quantity" example:"5"`
+	// Set for cosmetics the user already owns, which can't be bought again
+	Owned bool `json:"owned"`
+}
+
+type ShopResponse struct {
+	Balance int                `json:"balance" example:"120"`
+	Items   []ShopItemResponse `json:"items"`
+}
+
+type ShopPurchaseRequest struct {
+	ItemID string `json:"item_id" validate:"required"`
+	// Chosen by the client and reused when retrying, so a purchase is only paid once
+	IdempotencyKey string `json:"idempotency_key" validate:"required,min=8,max=64" example:"0b6f8a62-6c1e-4f0e-9d8e-2f3a1c5b7e90"`
+}
+
+func (r ShopPurchaseRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+type ShopPurchaseResponse struct {
+	PurchaseID string `json:"purchase_id"`
+	ItemID     string `json:"item_id"`
+	Kind       string `json:"kind" example:"streak_freeze"`
+	Quantity   int    `json:"quantity" example:"1"`
+	Price      int    `json:"price" example:"50"`
+	Balance    int    `json:"balance" example:"70"`
+	// False when the idempotency key was used before and the first purchase is returned
+	Created       bool      `json:"created"`
+	Hearts        int       `json:"hearts" example:"5"`
+	StreakFreezes int       `json:"streak_freezes" example:"1"`
+	PurchasedAt   time.Time `json:"purchased_at"`
+}
+
+type WalletResponse struct {
+	Balance        int       `json:"balance" example:"120"`
+	LifetimeEarned int       `json:"lifetime_earned" example:"480"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type CoinTransactionResponse struct {
+	ID           string    `json:"id"`
+	Source       string    `json:"source" example:"lesson"`
+	Amount       int       `json:"amount" example:"10"`
+	BalanceAfter int       `json:"balance_after" example:"120"`
+	ReferenceID  string    `json:"reference_id"`
+	Note         string    `json:"note,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type CoinLedgerResponse struct {
+	Balance      int                       `json:"balance" example:"120"`
+	Transactions []CoinTransactionResponse `json:"transactions"`
+	Total        int                       `json:"total"`
+	Page         int                       `json:"page"`
+	Limit        int                       `json:"limit"`
+}
+
+type UserCosmeticResponse struct {
+	ItemID      string    `json:"item_id"`
+	Name        string    `json:"name"`
+	ImageURL    string    `json:"image_url"`
+	PurchasedAt time.Time `json:"purchased_at"`
+}
+
+// ==================== ADMIN SHOP DTOs ====================
+
+type CreateShopItemRequest struct {
+	Kind        string `json:"kind" validate:"required,oneof=heart_refill streak_freeze cosmetic" example:"heart_refill"`
+	Name        string `json:"name" validate:"required,max=100" example:"Full heart refill"`
+	Description string `json:"description" validate:"max=1000"`
+	ImageURL    string `json:"image_url" validate:"omitempty,url"`
+	Price       int    `json:"price" validate:"required,min=1,max=100000" example:"50"`
+	// Hearts or streak freezes per purchase; cosmetics are always 1
+	Quantity  int  `json:"quantity" validate:"omitempty,min=1,max=100" example:"5"`
+	IsActive  bool `json:"is_active" example:"true"`
+	SortOrder int  `json:"sort_order"`
+}
+
+func (r CreateShopItemRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+type UpdateShopItemRequest struct {
+	Name        *string `json:"name" validate:"omitempty,max=100"`
+	Description *string `json:"description" validate:"omitempty,max=1000"`
+	ImageURL    *string `json:"image_url" validate:"omitempty,url"`
+	Price       *int    `json:"price" validate:"omitempty,min=1,max=100000"`
+	Quantity    *int    `json:"quantity" validate:"omitempty,min=1,max=100"`
+	IsActive    *bool   `json:"is_active"`
+	SortOrder   *int    `json:"sort_order"`
+}
+
+func (r UpdateShopItemRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
@@ -0,0 +1,106 @@
+package dto
+
+import "time"
+
+// ==================== STUDY ROOM DTOs ====================
+
+// Study room live event types
+const (
+	StudyRoomEventRoom     = "room"          // StudyRoomResponse, on connect and whenever members or the status change
+	StudyRoomEventProgress = "progress"      // StudyRoomMemberResponse, after a member answers
+	StudyRoomEventPresence = "presence"      // StudyRoomPresence, when a member connects or disconnects
+	StudyRoomEventAnswer   = "answer_result" // StudyRoomAnswerResponse, only to the member who answered
+	StudyRoomEventError    = "error"         // StudyRoomError, only to the member whose message failed
+)
+
+// StudyRoomMessageAnswer is the only message type clients send over the live connection
+const StudyRoomMessageAnswer = "answer"
+
+type CreateStudyRoomRequest struct {
+	LessonID string `json:"lesson_id" validate:"required"`
+}
+
+func (r CreateStudyRoomRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+type JoinStudyRoomRequest struct {
+	Code string `json:"code" validate:"required,len=6,alphanum" example:"K7XQ2M"`
+}
+
+func (r JoinStudyRoomRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+type StudyRoomAnswerRequest struct {
+	QuestionID string      `json:"question_id" validate:"required"`
+	Answer     interface{} `json:"answer" validate:"required"`
+}
+
+func (r StudyRoomAnswerRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+// StudyRoomMessage is a message sent by the client over the live connection
+type StudyRoomMessage struct {
+	Type string `json:"type" example:"answer"`
+	StudyRoomAnswerRequest
+}
+
+type StudyRoomMemberResponse struct {
+	UserID       string     `json:"user_id"`
+	Username     string     `json:"username"`
+	IsHost       bool       `json:"is_host"`
+	Online       bool       `json:"online"`
+	Answered     int        `json:"answered" example:"3"`
+	EarnedPoints int        `json:"earned_points" example:"30"`
+	Finished     bool       `json:"finished"`
+	Passed       bool       `json:"passed"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+	JoinedAt     time.Time  `json:"joined_at"`
+}
+
+type StudyRoomResponse struct {
+	ID             string                    `json:"id"`
+	Code           string                    `json:"code" example:"K7XQ2M"`
+	HostID         string                    `json:"host_id"`
+	LessonID       string                    `json:"lesson_id"`
+	LessonTitle    string                    `json:"lesson_title"`
+	Status         string                    `json:"status" example:"waiting"` // waiting, active, finished, closed
+	MaxMembers     int                       `json:"max_members" example:"5"`
+	QuestionsTotal int                       `json:"questions_total" example:"10"`
+	TotalPoints    int                       `json:"total_points" example:"100"`
+	PointsToPass   int                       `json:"points_to_pass" example:"70"`
+	GroupBonusXP   int                       `json:"group_bonus_xp" example:"25"` // earned by each member if everyone passes
+	BonusAwarded   bool                      `json:"bonus_awarded"`
+	Members        []StudyRoomMemberResponse `json:"members"`
+	// Questions the requesting member already answered, so a reconnecting client can
+	// resume. Left out of events broadcast to the room.
+	AnsweredQuestionIDs []string   `json:"answered_question_ids,omitempty"`
+	StartedAt           *time.Time `json:"started_at,omitempty"`
+	EndedAt             *time.Time `json:"ended_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+}
+
+type StudyRoomAnswerResponse struct {
+	QuestionID string                  `json:"question_id"`
+	Correct    bool                    `json:"correct"`
+	Points     int                     `json:"points" example:"10"`
+	Member     StudyRoomMemberResponse `json:"member"`
+}
+
+type StudyRoomPresence struct {
+	UserID string `json:"user_id"`
+	Online bool   `json:"online"`
+}
+
+type StudyRoomError struct {
+	Message string `json:"message"`
+}
+
+// StudyRoomEvent is one message pushed over a study room's live connection
+type StudyRoomEvent struct {
+	Type      string      `json:"type" example:"progress"`
+	Data      interface{} `json:"data"`
+	Timestamp time.Time   `json:"timestamp"`
+}
@@ -0,0 +1,82 @@
+package dto
+
+import (
+	"time"
+)
+
+// ==================== TRANSLATION DTOs ====================
+
+type TranslatedQuestion struct {
+	ID       string      `json:"id" validate:"required"`
+	Question string      `json:"question" validate:"required"`
+	Options  []string    `json:"options,omitempty"`
+	Answer   interface{} `json:"answer,omitempty"`
+}
+
+type UpdateLessonTranslationRequest struct {
+	Title     string               `json:"title" validate:"required,max=255"`
+	Story     string               `json:"story"`
+	Script    string               `json:"script"`
+	Questions []TranslatedQuestion `json:"questions" validate:"dive"`
+}
+
+func (r UpdateLessonTranslationRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+type ReviewTranslationRequest struct {
+	Approve *bool  `json:"approve" validate:"required" example:"true"`
+	Note    string `json:"note,omitempty" validate:"max=1000"`
+}
+
+func (r ReviewTranslationRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+type LessonTranslationResponse struct {
+	ID           string               `json:"id"`
+	LessonID     string               `json:"lesson_id"`
+	Locale       string               `json:"locale" example:"en"`
+	Title        string               `json:"title"`
+	Story        string               `json:"story"`
+	Script       string               `json:"script"`
+	Questions    []TranslatedQuestion `json:"questions"`
+	Status       string               `json:"status" example:"in_review"`
+	Source       string               `json:"source" example:"machine"`
+	TranslatedBy string               `json:"translated_by"`
+	ReviewerID   string               `json:"reviewer_id,omitempty"`
+	ReviewNote   string               `json:"review_note,omitempty"`
+	ReviewedAt   *time.Time           `json:"reviewed_at,omitempty"`
+	IsStale      bool                 `json:"is_stale"`
+	UpdatedAt    time.Time            `json:"updated_at"`
+}
+
+type TranslationCompleteness struct {
+	Locale             string   `json:"locale" example:"en"`
+	Status             string   `json:"status" example:"approved"`
+	Completeness       float64  `json:"completeness" example:"87.5"`
+	MissingFields      []string `json:"missing_fields"`
+	MissingQuestionIDs []string `json:"missing_question_ids"`
+	IsStale            bool     `json:"is_stale"`
+}
+
+type LessonTranslationReportResponse struct {
+	LessonID     string                    `json:"lesson_id"`
+	SourceLocale string                    `json:"source_locale" example:"vi"`
+	Locales      []TranslationCompleteness `json:"locales"`
+}
+
+type MissingTranslationItem struct {
+	LessonID    string `json:"lesson_id"`
+	CharacterID string `json:"character_id"`
+	Title       string `json:"title"`
+	Status      string `json:"status" example:"missing"`
+}
+
+type MissingTranslationListResponse struct {
+	Locale  string                   `json:"locale" example:"en"`
+	Lessons []MissingTranslationItem `json:"lessons"`
+	Total   int                      `json:"total"`
+	Page    int                      `json:"page"`
+	Limit   int                      `json:"limit"`
+}
@@ -0,0 +1,44 @@
+package dto
+
+// ==================== DAILY TRIVIA DTOs ====================
+
+type DailyTriviaAnswerRequest struct {
+	Answer interface{} `json:"answer" validate:"required"`
+}
+
+func (r DailyTriviaAnswerRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+type TriviaStatsResponse struct {
+	Date              string  `json:"date" example:"2026-03-14"`
+	TotalAnswers      int64   `json:"total_answers" example:"1250"`
+	CorrectAnswers    int64   `json:"correct_answers" example:"800"`
+	CorrectPercentage float64 `json:"correct_percentage" example:"64"`
+}
+
+type TriviaStreakResponse struct {
+	CurrentStreak    int    `json:"current_streak" example:"4"`
+	LongestStreak    int    `json:"longest_streak" example:"12"`
+	LastAnsweredDate string `json:"last_answered_date,omitempty" example:"2026-03-14"`
+}
+
+type DailyTriviaResponse struct {
+	Date     string           `json:"date" example:"2026-03-14"`
+	Question QuestionResponse `json:"question"`
+	LessonID string           `json:"lesson_id"`
+	XPReward int              `json:"xp_reward" example:"20"`
+	Answered bool             `json:"answered"`
+	// Set once the user has answered
+	Correct   *bool                `json:"correct,omitempty"`
+	XPAwarded int                  `json:"xp_awarded,omitempty"`
+	Stats     *TriviaStatsResponse `json:"stats,omitempty"`
+	Streak    TriviaStreakResponse `json:"streak"`
+}
+
+type DailyTriviaAnswerResponse struct {
+	Correct   bool                 `json:"correct"`
+	XPAwarded int                  `json:"xp_awarded" example:"20"`
+	Stats     TriviaStatsResponse  `json:"stats"`
+	Streak    TriviaStreakResponse `json:"streak"`
+}
@@ -1,6 +1,10 @@
 package dto
 
-import "time"
+import (
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/model"
+)
 
 // Progress DTOs
 type UserProgressResponse struct {
@@ -18,6 +22,16 @@ type UserProgressResponse struct {
 	LastActivity       *time.Time            `json:"last_activity"`
 	Spirit             SpiritResponse        `json:"spirit"`
 	Achievements       []AchievementResponse `json:"recent_achievements"`
+	ComebackBonus      *ComebackBonus        `json:"comeback_bonus,omitempty"`
+
+	// Weekend days the weekend amulet bridged in the current streak
+	StreakProtectedDays int `json:"streak_protected_days"`
+}
+
+// ComebackBonus is the temporary XP multiplier granted to returning users
+type ComebackBonus struct {
+	XPMultiplier float64   `json:"xp_multiplier" example:"1.5"`
+	ExpiresAt    time.Time `json:"expires_at"`
 }
 
 type SpiritResponse struct {
@@ -30,6 +44,41 @@ type SpiritResponse struct {
 	ImageURL string `json:"image_url"`
 }
 
+type InitializeProfileRequest struct {
+	BirthYear int `json:"birth_year" validate:"required,min=1900,max=2020" example:"2008"`
+	// Optional, tells the zodiac of births before Tết
+	BirthDate string `json:"birth_date,omitempty" validate:"omitempty,datetime=2006-01-02" example:"2008-02-03"`
+}
+
+func (r InitializeProfileRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+// CorrectZodiacRequest corrects the zodiac with the exact birth date, or by picking the
+// animal of the lunar year that starts or ends in the birth year
+type CorrectZodiacRequest struct {
+	BirthDate string `json:"birth_date,omitempty" validate:"omitempty,datetime=2006-01-02" example:"2008-02-03"`
+	Animal    string `json:"animal,omitempty" validate:"omitempty,oneof=rat ox tiger rabbit dragon snake horse goat monkey rooster dog pig" example:"pig"`
+}
+
+func (r CorrectZodiacRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+type ZodiacResponse struct {
+	Animal    string `json:"animal" example:"rat"`
+	LunarYear int    `json:"lunar_year" example:"2008"`
+	BirthYear int    `json:"birth_year" example:"2008"`
+	BirthDate string `json:"birth_date,omitempty" example:"2008-02-07"`
+	// Without a birth date a birth in January or February may belong to the lunar year
+	// before; Candidates are the animals the birth year can have
+	Uncertain   bool           `json:"uncertain"`
+	Candidates  []string       `json:"candidates,omitempty"`
+	CanCorrect  bool           `json:"can_correct"`
+	CorrectedAt *time.Time     `json:"corrected_at,omitempty"`
+	Spirit      SpiritResponse `json:"spirit"`
+}
+
 type AchievementResponse struct {
 	ID          string     `json:"id"`
 	Name        string     `json:"name"`
@@ -37,9 +86,25 @@ type AchievementResponse struct {
 	BadgeURL    string     `json:"badge_url"`
 	Category    string     `json:"category"`
 	XPReward    int        `json:"xp_reward"`
+	Tier        string     `json:"tier,omitempty" example:"silver"`
 	UnlockedAt  *time.Time `json:"unlocked_at,omitempty"`
 }
 
+// AchievementProgressResponse shows how far a user is towards the next tier of an achievement
+type AchievementProgressResponse struct {
+	AchievementID string `json:"achievement_id"`
+	Name          string `json:"name"`
+	Description   string `json:"description"`
+	BadgeURL      string `json:"badge_url"`
+	Category      string `json:"category"`
+	Metric        string `json:"metric" example:"lessons_completed"`
+	Current       int    `json:"current" example:"23"`
+	Target        int    `json:"target" example:"50"`
+	TierReached   string `json:"tier_reached,omitempty" example:"bronze"`
+	NextTier      string `json:"next_tier,omitempty" example:"silver"`
+	Completed     bool   `json:"completed"`
+}
+
 // Leaderboard DTOs
 type LeaderboardRequest struct {
 	Period string `json:"period" form:"period" validate:"omitempty,oneof=weekly monthly all_time"` // weekly, monthly, all_time
@@ -52,6 +117,7 @@ func (l LeaderboardRequest) Validate() error {
 
 type LeaderboardResponse struct {
 	Period      string                    `json:"period"`
+	Type        string                    `json:"type" example:"xp"`
 	CurrentUser LeaderboardUserResponse   `json:"current_user"`
 	TopUsers    []LeaderboardUserResponse `json:"top_users"`
 }
@@ -64,6 +130,16 @@ type LeaderboardUserResponse struct {
 	Rank        int    `json:"rank"`
 	SpiritType  string `json:"spirit_type"`
 	SpiritStage int    `json:"spirit_stage"`
+	Anonymous   bool   `json:"anonymous,omitempty"`
+
+	// Streak is the user's daily streak; StreakProtectedDays counts the weekend days in
+	// it that were kept by the weekend amulet rather than a lesson
+	Streak              int `json:"streak"`
+	StreakProtectedDays int `json:"streak_protected_days"`
+
+	// Collection leaderboard: characters unlocked and their share of all characters
+	CollectedCharacters int     `json:"collected_characters,omitempty" example:"12"`
+	CollectionPercent   float64 `json:"collection_percent,omitempty" example:"40.5"`
 }
 
 // Statistics DTOs
@@ -103,10 +179,27 @@ type HeartStatusResponse struct {
 }
 
 // Collection DTOs
+type CollectionQuery struct {
+	Sort          string `json:"sort" form:"sort" validate:"omitempty,oneof=rarity dynasty unlocked_at name"`
+	Order         string `json:"order" form:"order" validate:"omitempty,oneof=asc desc"`
+	Era           string `json:"era" form:"era" validate:"omitempty,max=50"`
+	UnlockedOnly  bool   `json:"unlocked_only" form:"unlocked_only"`
+	FavoritesOnly bool   `json:"favorites_only" form:"favorites_only"`
+}
+
+func (q CollectionQuery) Validate() error {
+	return GetValidator().Struct(q)
+}
+
 type CollectionResponse struct {
 	Characters   CharacterCollectionResponse `json:"characters"`
 	Achievements []AchievementResponse       `json:"achievements"`
 	Stats        CollectionStatsResponse     `json:"stats"`
+
+	AchievementProgress []AchievementProgressResponse `json:"achievement_progress"`
+
+	// Characters unlocked after this time are marked as new
+	LastViewedAt *time.Time `json:"last_viewed_at,omitempty"`
 }
 
 type CollectionStatsResponse struct {
@@ -130,7 +223,478 @@ func (s ShareRequest) Validate() error {
 
 type ShareResponse struct {
 	ShareURL   string   `json:"share_url"`
-	ShareImage string   `json:"share_image"`
+	ShareImage string   `json:"share_image"` // presigned URL of the rendered card, valid for 24 hours
 	ShareText  string   `json:"share_text"`
 	Platforms  []string `json:"platforms"`
 }
+
+// Game config DTOs
+type UpdateGameConfigRequest struct {
+	ComebackInactiveDays   *int     `json:"comeback_inactive_days,omitempty" validate:"omitempty,min=1,max=365" example:"7"`
+	ComebackXPMultiplier   *float64 `json:"comeback_xp_multiplier,omitempty" validate:"omitempty,min=1,max=5" example:"1.5"`
+	ComebackDurationHours  *int     `json:"comeback_duration_hours,omitempty" validate:"omitempty,min=1,max=720" example:"48"`
+	ComebackRestoresHearts *bool    `json:"comeback_restores_hearts,omitempty" example:"true"`
+
+	SpeedCheckMode        *string  `json:"speed_check_mode,omitempty" validate:"omitempty,oneof=off flag reject" example:"flag"`
+	MinVideoWatchRatio    *float64 `json:"min_video_watch_ratio,omitempty" validate:"omitempty,min=0,max=1" example:"0.5"`
+	MinSecondsPerQuestion *int     `json:"min_seconds_per_question,omitempty" validate:"omitempty,min=0,max=60" example:"4"`
+	BurstWindowMinutes    *int     `json:"burst_window_minutes,omitempty" validate:"omitempty,min=1,max=1440" example:"10"`
+	BurstMaxCompletions   *int     `json:"burst_max_completions,omitempty" validate:"omitempty,min=1,max=1000" example:"6"`
+
+	KnowledgeWeight     *float64 `json:"knowledge_weight,omitempty" validate:"omitempty,min=0.1,max=10" example:"1"`
+	ComprehensionWeight *float64 `json:"comprehension_weight,omitempty" validate:"omitempty,min=0.1,max=10" example:"1.5"`
+
+	BonusMinWatchPercent *int `json:"bonus_min_watch_percent,omitempty" validate:"omitempty,min=0,max=100" example:"80"`
+
+	AnomalyMaxDailyXP  *int `json:"anomaly_max_daily_xp,omitempty" validate:"omitempty,min=100,max=1000000" example:"3000"`
+	AnomalyMaxHourlyXP *int `json:"anomaly_max_hourly_xp,omitempty" validate:"omitempty,min=50,max=1000000" example:"1000"`
+
+	CoinsPerLesson          *int `json:"coins_per_lesson,omitempty" validate:"omitempty,min=0,max=1000" example:"10"`
+	CoinsPerAchievementTier *int `json:"coins_per_achievement_tier,omitempty" validate:"omitempty,min=0,max=10000" example:"25"`
+
+	FiftyFiftyPerLesson *int `json:"fifty_fifty_per_lesson,omitempty" validate:"omitempty,min=0,max=20" example:"2"`
+	FiftyFiftyCoinPrice *int `json:"fifty_fifty_coin_price,omitempty" validate:"omitempty,min=1,max=10000" example:"15"`
+
+	QuestionReportThreshold *int `json:"question_report_threshold,omitempty" validate:"omitempty,min=0,max=1000" example:"5"`
+}
+
+func (r UpdateGameConfigRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+// Onboarding DTOs
+type OnboardingStepStatus struct {
+	Step      string `json:"step" example:"verify_email"`
+	Completed bool   `json:"completed"`
+}
+
+type OnboardingResponse struct {
+	CurrentStep string                 `json:"current_step" example:"first_lesson"`
+	Steps       []OnboardingStepStatus `json:"steps"`
+	Completed   bool                   `json:"completed"`
+	CompletedAt *time.Time             `json:"completed_at,omitempty"`
+}
+
+// Bookmark DTOs
+type LessonBookmarkResponse struct {
+	LessonID      string    `json:"lesson_id"`
+	Title         string    `json:"title"`
+	CharacterID   string    `json:"character_id"`
+	CharacterName string    `json:"character_name"`
+	ThumbnailURL  string    `json:"thumbnail_url,omitempty"`
+	Completed     bool      `json:"completed"`
+	BookmarkedAt  time.Time `json:"bookmarked_at"`
+}
+
+type LessonBookmarkListResponse struct {
+	Bookmarks []LessonBookmarkResponse `json:"bookmarks"`
+	Total     int                      `json:"total" example:"5"`
+	Page      int                      `json:"page" example:"1"`
+	Limit     int                      `json:"limit" example:"20"`
+}
+
+// XP ledger DTOs
+type XPTransactionResponse struct {
+	ID           string    `json:"id"`
+	Source       string    `json:"source" example:"lesson"`
+	Amount       int       `json:"amount" example:"70"`
+	BalanceAfter int       `json:"balance_after" example:"1250"`
+	ReferenceID  string    `json:"reference_id,omitempty"`
+	ReasonCode   string    `json:"reason_code,omitempty" example:"support_remediation"`
+	ActorID      string    `json:"actor_id,omitempty"`
+	Note         string    `json:"note,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type XPLedgerResponse struct {
+	UserID       string                  `json:"user_id"`
+	XP           int                     `json:"xp" example:"1250"`        // stored total
+	LedgerXP     int                     `json:"ledger_xp" example:"1250"` // sum of all transactions
+	Transactions []XPTransactionResponse `json:"transactions"`
+	Total        int                     `json:"total" example:"42"`
+	Page         int                     `json:"page" example:"1"`
+	Limit        int                     `json:"limit" example:"20"`
+}
+
+// Admin economy DTOs
+type AdminCohortFilter struct {
+	MinLevel         *int       `json:"min_level,omitempty" validate:"omitempty,min=1" example:"5"`
+	MaxLevel         *int       `json:"max_level,omitempty" validate:"omitempty,min=1" example:"20"`
+	RegisteredAfter  *time.Time `json:"registered_after,omitempty"`
+	RegisteredBefore *time.Time `json:"registered_before,omitempty"`
+	ActiveWithinDays int        `json:"active_within_days,omitempty" validate:"omitempty,min=1,max=365" example:"30"`
+}
+
+type AdminEconomyAdjustRequest struct {
+	// Target either explicit users or a cohort, not both
+	UserIDs    []string           `json:"user_ids,omitempty" validate:"omitempty,max=1000,dive,required"`
+	Cohort     *AdminCohortFilter `json:"cohort,omitempty"`
+	Resource   string             `json:"resource" validate:"required,oneof=hearts xp item" example:"hearts"`
+	Action     string             `json:"action" validate:"required,oneof=grant revoke" example:"grant"`
+	Amount     int                `json:"amount,omitempty" validate:"omitempty,min=1,max=100000" example:"5"` // hearts and xp
+	ItemID     string             `json:"item_id,omitempty" example:"char_tran_hung_dao"`                     // character ID for items
+	ReasonCode string             `json:"reason_code" validate:"required,oneof=support_remediation promotion bug_compensation abuse_correction testing" example:"support_remediation"`
+	Note       string             `json:"note,omitempty" validate:"max=500"`
+	DryRun     bool               `json:"dry_run" example:"true"`
+}
+
+func (r AdminEconomyAdjustRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+type AdminEconomyAdjustResult struct {
+	UserID       string `json:"user_id"`
+	Delta        int    `json:"delta" example:"3"`                                 // hearts or xp actually applied; 1/-1 for items
+	BalanceAfter int    `json:"balance_after,omitempty" example:"5"`               // hearts or xp
+	Skipped      string `json:"skipped,omitempty" example:"already at max hearts"` // why nothing changed
+}
+
+type AdminEconomyAdjustResponse struct {
+	BatchID  string                     `json:"batch_id"` // reference ID on the ledger entries
+	Resource string                     `json:"resource" example:"hearts"`
+	Action   string                     `json:"action" example:"grant"`
+	DryRun   bool                       `json:"dry_run"`
+	Matched  int                        `json:"matched" example:"120"`
+	Applied  int                        `json:"applied" example:"118"`
+	Skipped  int                        `json:"skipped" example:"2"`
+	Results  []AdminEconomyAdjustResult `json:"results"` // first 100 users
+}
+
+type HeartTransactionResponse struct {
+	ID           string    `json:"id"`
+	Source       string    `json:"source" example:"admin"`
+	Amount       int       `json:"amount" example:"3"`
+	BalanceAfter int       `json:"balance_after" example:"5"`
+	ReferenceID  string    `json:"reference_id,omitempty"`
+	ReasonCode   string    `json:"reason_code,omitempty" example:"promotion"`
+	ActorID      string    `json:"actor_id,omitempty"`
+	Note         string    `json:"note,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type HeartLedgerResponse struct {
+	UserID       string                     `json:"user_id"`
+	Hearts       int                        `json:"hearts" example:"5"`
+	Transactions []HeartTransactionResponse `json:"transactions"`
+	Total        int                        `json:"total" example:"4"`
+	Page         int                        `json:"page" example:"1"`
+	Limit        int                        `json:"limit" example:"20"`
+}
+
+type ItemLedgerResponse struct {
+	UserID       string                  `json:"user_id"`
+	Transactions []model.ItemTransaction `json:"transactions"`
+	Total        int                     `json:"total" example:"2"`
+	Page         int                     `json:"page" example:"1"`
+	Limit        int                     `json:"limit" example:"20"`
+}
+
+// Progress repair DTOs
+type ProgressRepairRequest struct {
+	// Repair a single user and return the report; empty runs over every user in the background
+	UserID string `json:"user_id,omitempty"`
+	DryRun bool   `json:"dry_run" example:"true"`
+}
+
+type ProgressRepairReport struct {
+	UserID              string   `json:"user_id"`
+	DryRun              bool     `json:"dry_run"`
+	Changed             bool     `json:"changed"`
+	XPBefore            int      `json:"xp_before"`
+	XPAfter             int      `json:"xp_after"`
+	LevelBefore         int      `json:"level_before"`
+	LevelAfter          int      `json:"level_after"`
+	RestoredCompletions []string `json:"restored_completions,omitempty"` // lesson IDs
+	RestoredUnlocks     []string `json:"restored_unlocks,omitempty"`     // character IDs
+}
+
+type ProgressRepairJobResponse struct {
+	Status       string                 `json:"status" example:"running"` // idle, running, completed, failed
+	DryRun       bool                   `json:"dry_run"`
+	StartedAt    *time.Time             `json:"started_at,omitempty"`
+	FinishedAt   *time.Time             `json:"finished_at,omitempty"`
+	UsersScanned int                    `json:"users_scanned"`
+	UsersChanged int                    `json:"users_changed"`
+	Reports      []ProgressRepairReport `json:"reports"` // users that changed, or would change on a dry run
+	Error        string                 `json:"error,omitempty"`
+}
+
+// Retention DTOs
+type UpdateRetentionPolicyRequest struct {
+	Enabled         *bool `json:"enabled,omitempty" example:"true"`
+	RetentionMonths *int  `json:"retention_months,omitempty" validate:"omitempty,min=1,max=120" example:"12"`
+	MaxRowsPerUser  *int  `json:"max_rows_per_user,omitempty" validate:"omitempty,min=0,max=1000000" example:"5000"` // 0 removes the quota
+}
+
+func (r UpdateRetentionPolicyRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+type RetentionTableReport struct {
+	Table       string `json:"table" example:"user_question_answers"`
+	PrunedAged  int64  `json:"pruned_aged"`  // rows past the retention window
+	PrunedQuota int64  `json:"pruned_quota"` // rows over the per-user quota
+	Error       string `json:"error,omitempty"`
+}
+
+type RetentionRunResponse struct {
+	Status     string                 `json:"status" example:"running"` // idle, running, completed, failed
+	StartedAt  *time.Time             `json:"started_at,omitempty"`
+	FinishedAt *time.Time             `json:"finished_at,omitempty"`
+	Tables     []RetentionTableReport `json:"tables"`
+
+	// Inactive account anonymization, when enabled
+	Anonymization *model.AnonymizationRun `json:"anonymization,omitempty"`
+}
+
+type TableSizeResponse struct {
+	Table         string `json:"table" example:"user_question_answers"`
+	TotalBytes    int64  `json:"total_bytes"` // table, indexes and TOAST
+	TableBytes    int64  `json:"table_bytes"`
+	IndexBytes    int64  `json:"index_bytes"`
+	EstimatedRows int64  `json:"estimated_rows"` // planner estimate, refreshed by ANALYZE
+}
+
+// Completion flag DTOs
+type CompletionFlagResponse struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"user_id"`
+	Username    string     `json:"username"`
+	LessonID    string     `json:"lesson_id"`
+	Reasons     []string   `json:"reasons" example:"reported_duration,burst"`
+	TimeSpent   int        `json:"time_spent" example:"12"`
+	MinDuration int        `json:"min_duration" example:"95"`
+	Rejected    bool       `json:"rejected"`
+	Status      string     `json:"status" example:"pending"`
+	ReviewedBy  string     `json:"reviewed_by,omitempty"`
+	ReviewNote  string     `json:"review_note,omitempty"`
+	ReviewedAt  *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type CompletionFlagListResponse struct {
+	Flags []CompletionFlagResponse `json:"flags"`
+	Total int                      `json:"total" example:"3"`
+	Page  int                      `json:"page" example:"1"`
+	Limit int                      `json:"limit" example:"20"`
+}
+
+type ReviewCompletionFlagRequest struct {
+	// dismiss closes this flag; ban deactivates the user and closes all their pending flags
+	Action string `json:"action" validate:"required,oneof=dismiss ban" example:"dismiss"`
+	Note   string `json:"note,omitempty" validate:"max=500"`
+}
+
+func (r ReviewCompletionFlagRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+// Leaderboard anomaly DTOs
+type LeaderboardAnomalyResponse struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"user_id"`
+	Username    string     `json:"username"`
+	Reason      string     `json:"reason" example:"daily_xp"`
+	XPGained    int        `json:"xp_gained" example:"5400"`
+	Threshold   int        `json:"threshold" example:"3000"`
+	WindowStart time.Time  `json:"window_start"`
+	WindowEnd   time.Time  `json:"window_end"`
+	Quarantined bool       `json:"quarantined"`
+	Status      string     `json:"status" example:"pending"`
+	PenaltyXP   int        `json:"penalty_xp,omitempty"`
+	ReviewedBy  string     `json:"reviewed_by,omitempty"`
+	ReviewNote  string     `json:"review_note,omitempty"`
+	ReviewedAt  *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type LeaderboardAnomalyListResponse struct {
+	Anomalies []LeaderboardAnomalyResponse `json:"anomalies"`
+	Total     int                          `json:"total" example:"3"`
+	Page      int                          `json:"page" example:"1"`
+	Limit     int                          `json:"limit" example:"20"`
+}
+
+// AnomalyTimelineEntry is one thing the user did around the anomaly: an XP ledger
+// entry, a lesson completion or a completion flagged as too fast
+type AnomalyTimelineEntry struct {
+	At       time.Time `json:"at"`
+	Kind     string    `json:"kind" example:"xp"` // xp, lesson_completion, completion_flag
+	Source   string    `json:"source,omitempty" example:"lesson"`
+	Amount   int       `json:"amount,omitempty" example:"90"`
+	LessonID string    `json:"lesson_id,omitempty"`
+	Score    int       `json:"score,omitempty"`
+	Detail   string    `json:"detail,omitempty"`
+}
+
+type LeaderboardAnomalyEvidenceResponse struct {
+	Anomaly  LeaderboardAnomalyResponse `json:"anomaly"`
+	XP       int                        `json:"xp"`
+	Timeline []AnomalyTimelineEntry     `json:"timeline"`
+}
+
+type ReviewLeaderboardAnomalyRequest struct {
+	// clear returns the user to the leaderboards; penalize also revokes XP, PenaltyXP
+	// or by default the XP gained in the anomaly's window
+	Action    string `json:"action" validate:"required,oneof=clear penalize" example:"penalize"`
+	PenaltyXP int    `json:"penalty_xp,omitempty" validate:"omitempty,min=1,max=1000000" example:"2400"`
+	Note      string `json:"note,omitempty" validate:"max=500"`
+}
+
+func (r ReviewLeaderboardAnomalyRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+// Moderation flag DTOs
+type ModerationFlagResponse struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	Username   string     `json:"username"`
+	Context    string     `json:"context" example:"username"`
+	Text       string     `json:"text"`
+	Reasons    []string   `json:"reasons" example:"profanity,phone"`
+	Status     string     `json:"status" example:"pending"`
+	ReviewedBy string     `json:"reviewed_by,omitempty"`
+	ReviewNote string     `json:"review_note,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type ModerationFlagListResponse struct {
+	Flags []ModerationFlagResponse `json:"flags"`
+	Total int                      `json:"total" example:"3"`
+	Page  int                      `json:"page" example:"1"`
+	Limit int                      `json:"limit" example:"20"`
+}
+
+type ReviewModerationFlagRequest struct {
+	// dismiss closes this flag; ban deactivates the user and closes all their pending flags
+	Action string `json:"action" validate:"required,oneof=dismiss ban" example:"dismiss"`
+	Note   string `json:"note,omitempty" validate:"max=500"`
+}
+
+func (r ReviewModerationFlagRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+// User note DTOs
+type UserNoteRequest struct {
+	Note     string   `json:"note" validate:"required,min=1,max=5000"`
+	Tags     []string `json:"tags,omitempty" validate:"omitempty,max=10,dive,min=1,max=30,excludesall=0x2C"`
+	TicketID string   `json:"ticket_id,omitempty" validate:"omitempty,max=100" example:"SUP-1042"`
+}
+
+func (r UserNoteRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+type UserNoteResponse struct {
+	ID             string    `json:"id"`
+	UserID         string    `json:"user_id"`
+	AuthorID       string    `json:"author_id"`
+	AuthorUsername string    `json:"author_username"`
+	Note           string    `json:"note"`
+	Tags           []string  `json:"tags" example:"billing,refund"`
+	TicketID       string    `json:"ticket_id,omitempty" example:"SUP-1042"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type UserNoteListResponse struct {
+	Notes []UserNoteResponse `json:"notes"`
+	Total int                `json:"total" example:"3"`
+	Page  int                `json:"page" example:"1"`
+	Limit int                `json:"limit" example:"20"`
+}
+
+// MaintenanceState is the maintenance mode flag as stored in Redis and shown to admins
+type MaintenanceState struct {
+	Enabled           bool       `json:"enabled"`
+	MessageVI         string     `json:"message_vi,omitempty"`
+	MessageEN         string     `json:"message_en,omitempty"`
+	RetryAfterSeconds int        `json:"retry_after_seconds" example:"600"`
+	StartedAt         *time.Time `json:"started_at,omitempty"`
+	EndsAt            *time.Time `json:"ends_at,omitempty"`
+	WindowID          string     `json:"window_id,omitempty"` // set when a scheduled window turned it on
+	UpdatedBy         string     `json:"updated_by,omitempty"`
+}
+
+type UpdateMaintenanceRequest struct {
+	Enabled           *bool      `json:"enabled" validate:"required" example:"true"`
+	MessageVI         string     `json:"message_vi,omitempty" validate:"max=500"`
+	MessageEN         string     `json:"message_en,omitempty" validate:"max=500"`
+	RetryAfterSeconds int        `json:"retry_after_seconds,omitempty" validate:"omitempty,min=30,max=86400" example:"600"`
+	EndsAt            *time.Time `json:"ends_at,omitempty"` // expected end, used for Retry-After
+}
+
+func (r UpdateMaintenanceRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+type CreateMaintenanceWindowRequest struct {
+	StartsAt  time.Time `json:"starts_at" validate:"required"`
+	EndsAt    time.Time `json:"ends_at" validate:"required"`
+	MessageVI string    `json:"message_vi,omitempty" validate:"max=500"`
+	MessageEN string    `json:"message_en,omitempty" validate:"max=500"`
+	// Announce to every learner's inbox, defaults to true
+	Announce *bool `json:"announce,omitempty" example:"true"`
+}
+
+func (r CreateMaintenanceWindowRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+type UpdateAppVersionRequest struct {
+	MinVersion    string `json:"min_version" validate:"required,app_version" example:"2.3.0"`
+	LatestVersion string `json:"latest_version" validate:"required,app_version" example:"2.5.1"`
+	StoreURL      string `json:"store_url,omitempty" validate:"omitempty,url,max=500"`
+}
+
+func (r UpdateAppVersionRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+// AppVersionInfo is the supported version range of one platform. The update flags are
+// set when the request says which app version it comes from.
+type AppVersionInfo struct {
+	Platform        string `json:"platform" example:"android"`
+	MinVersion      string `json:"min_version" example:"2.3.0"`
+	LatestVersion   string `json:"latest_version" example:"2.5.1"`
+	StoreURL        string `json:"store_url,omitempty"`
+	UpdateRequired  bool   `json:"update_required"`
+	UpdateAvailable bool   `json:"update_available"`
+}
+
+type AppVersionResponse struct {
+	Platforms []AppVersionInfo `json:"platforms"`
+}
+
+type InvalidateCacheRequest struct {
+	Scope string `json:"scope" validate:"required,oneof=lesson character user all_content" example:"lesson"`
+	// Lesson, character or user ID, not used for all_content
+	ID string `json:"id,omitempty" validate:"max=50" example:"lesson_001"`
+}
+
+func (r InvalidateCacheRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+type CacheInvalidationResponse struct {
+	Scope   string   `json:"scope" example:"lesson"`
+	ID      string   `json:"id,omitempty" example:"lesson_001"`
+	Cleared []string `json:"cleared" example:"public_catalog,search_suggest,trending"`
+	// Whether the other API instances were told to clear their copies
+	Broadcast     bool      `json:"broadcast"`
+	InvalidatedAt time.Time `json:"invalidated_at"`
+}
+
+// MaintenanceStatusResponse is what learner apps see, with the message in their locale
+type MaintenanceStatusResponse struct {
+	Enabled           bool       `json:"enabled"`
+	Message           string     `json:"message,omitempty"`
+	RetryAfterSeconds int        `json:"retry_after_seconds,omitempty"`
+	EndsAt            *time.Time `json:"ends_at,omitempty"`
+	// The next scheduled window, if any
+	UpcomingStartsAt *time.Time `json:"upcoming_starts_at,omitempty"`
+	UpcomingEndsAt   *time.Time `json:"upcoming_ends_at,omitempty"`
+}
@@ -3,8 +3,10 @@ package dto
 import (
 	"regexp"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/lac-hong-legacy/ven_api/shared/text"
 )
 
 var validate *validator.Validate
@@ -12,6 +14,8 @@ var validate *validator.Validate
 func init() {
 	validate = validator.New()
 	validate.RegisterValidation("strong_password", validateStrongPassword)
+	validate.RegisterValidation("app_version", validateAppVersion)
+	validate.RegisterValidation("username", validateUsername)
 }
 
 func GetValidator() *validator.Validate {
@@ -48,13 +52,39 @@ func validateStrongPassword(fl validator.FieldLevel) bool {
 	return hasUpper && hasLower && hasNumber && hasSpecial
 }
 
+// appVersionRegex accepts dotted numeric versions such as 2, 2.4 or 2.4.1
+var appVersionRegex = regexp.MustCompile(`^[0-9]{1,5}(\.[0-9]{1,5}){0,3}$`)
+
+func validateAppVersion(fl validator.FieldLevel) bool {
+	return appVersionRegex.MatchString(fl.Field().String())
+}
+
+func validateUsername(fl validator.FieldLevel) bool {
+	return ValidUsername(fl.Field().String())
+}
+
+// ValidUsername reports whether a username is 3 to 30 letters of the Vietnamese
+// alphabet, digits and underscores once NFC normalized. Letters of other scripts are
+// refused so names can't imitate each other with look-alike characters.
+func ValidUsername(username string) bool {
+	username = text.NFC(username)
+	if length := utf8.RuneCountInString(username); length < 3 || length > 30 {
+		return false
+	}
+	for _, r := range username {
+		if !text.IsVietnameseLetter(r) && !(r >= '0' && r <= '9') && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
 func ValidateEmailOrUsername(fl validator.FieldLevel) bool {
 	value := fl.Field().String()
 
 	emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
-	usernameRegex := regexp.MustCompile(`^[a-zA-Z0-9_]{3,30}$`)
 
-	return emailRegex.MatchString(value) || usernameRegex.MatchString(value)
+	return emailRegex.MatchString(value) || ValidUsername(value)
 }
 
 func FormatValidationErrors(err error) []ValidationError {
@@ -81,6 +111,10 @@ func FormatValidationErrors(err error) []ValidationError {
 				message = fieldError.Field() + " must contain only letters and numbers"
 			case "strong_password":
 				message = "Password must contain at least 8 characters with uppercase, lowercase, number, and special character"
+			case "username":
+				message = fieldError.Field() + " must be 3 to 30 letters, numbers or underscores"
+			case "app_version":
+				message = fieldError.Field() + " must be a version like 2.4.1"
 			case "url":
 				message = fieldError.Field() + " must be a valid URL"
 			case "oneof":
@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/model"
+)
+
+type CreateWebhookRequest struct {
+	Name string `json:"name" validate:"required,max=100" example:"Lac Hong High School LMS"`
+	URL  string `json:"url" validate:"required,url,max=500" example:"https://lms.example.edu/hooks/ven"`
+	// Required for learner events, left empty for platform events
+	TenantID string   `json:"tenant_id,omitempty" validate:"omitempty,max=50" example:"lac-hong-hs"`
+	Events   []string `json:"events" validate:"required,min=1,dive,oneof=lesson.completed character.unlocked level.up media.processing_failed" example:"lesson.completed,level.up"`
+}
+
+func (r CreateWebhookRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+type UpdateWebhookRequest struct {
+	Name     *string  `json:"name,omitempty" validate:"omitempty,max=100"`
+	URL      *string  `json:"url,omitempty" validate:"omitempty,url,max=500"`
+	Events   []string `json:"events,omitempty" validate:"omitempty,min=1,dive,oneof=lesson.completed character.unlocked level.up media.processing_failed"`
+	IsActive *bool    `json:"is_active,omitempty"`
+}
+
+func (r UpdateWebhookRequest) Validate() error {
+	return GetValidator().Struct(r)
+}
+
+type WebhookResponse struct {
+	ID        string    `json:"id"`
+	TenantID  string    `json:"tenant_id,omitempty" example:"lac-hong-hs"`
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events" example:"lesson.completed,level.up"`
+	IsActive  bool      `json:"is_active"`
+	Secret    string    `json:"secret,omitempty"` // only returned when the webhook is created or its secret rotated
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type WebhookDeliveryListResponse struct {
+	Deliveries []model.WebhookDelivery `json:"deliveries"`
+	Total      int                     `json:"total" example:"57"`
+	Page       int                     `json:"page" example:"1"`
+	Limit      int                     `json:"limit" example:"20"`
+}
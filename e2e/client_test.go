@@ -0,0 +1,86 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// envelope mirrors shared.Response with the data left raw for the caller
+type envelope struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// apiClient is a thin JSON client. Requests carry the bearer token when one
+// is set, which also keeps them clear of the cookie CSRF check.
+type apiClient struct {
+	t     *testing.T
+	http  *http.Client
+	token string
+}
+
+func newClient(t *testing.T) *apiClient {
+	t.Helper()
+	return &apiClient{t: t, http: &http.Client{Timeout: 15 * time.Second}}
+}
+
+// do sends body as JSON and returns the status code and decoded envelope
+func (c *apiClient) do(method, path string, body interface{}) (int, envelope) {
+	c.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			c.t.Fatalf("marshal %s %s: %v", method, path, err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, baseURL+path, reader)
+	if err != nil {
+		c.t.Fatalf("build %s %s: %v", method, path, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		c.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	raw, _ := io.ReadAll(resp.Body)
+	var env envelope
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &env); err != nil {
+			c.t.Fatalf("%s %s: decode %q: %v", method, path, raw, err)
+		}
+	}
+	return resp.StatusCode, env
+}
+
+// mustDo fails the test unless the response has the wanted status, then
+// decodes the data field into out when out is non-nil
+func (c *apiClient) mustDo(method, path string, body interface{}, want int, out interface{}) {
+	c.t.Helper()
+
+	status, env := c.do(method, path, body)
+	if status != want {
+		c.t.Fatalf("%s %s: status %d, want %d (%s: %s)", method, path, status, want, env.Message, env.Data)
+	}
+	if out != nil {
+		if err := json.Unmarshal(env.Data, out); err != nil {
+			c.t.Fatalf("%s %s: decode data %s: %v", method, path, env.Data, err)
+		}
+	}
+}
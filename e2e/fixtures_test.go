@@ -0,0 +1,146 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+)
+
+const testPassword = "E2e-Passw0rd!"
+
+// testUser is a registered, verified and logged in learner
+type testUser struct {
+	*apiClient
+	ID       string
+	Email    string
+	Username string
+}
+
+func uniqueSuffix() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// registerUser signs up a fresh account without verifying it
+func registerUser(t *testing.T) *testUser {
+	t.Helper()
+
+	suffix := uniqueSuffix()
+	u := &testUser{
+		apiClient: newClient(t),
+		Email:     fmt.Sprintf("e2e_%s@example.com", suffix),
+		Username:  "e2e" + suffix,
+	}
+
+	var resp dto.RegisterResponse
+	u.mustDo(http.MethodPost, "/api/v1/register", dto.RegisterRequest{
+		Email:           u.Email,
+		Username:        u.Username,
+		Password:        testPassword,
+		ConfirmPassword: testPassword,
+	}, http.StatusCreated, &resp)
+	u.ID = resp.UserID
+	return u
+}
+
+// newVerifiedUser registers, verifies with the emailed code, logs in and
+// sets the birth year so the account can play lessons
+func newVerifiedUser(t *testing.T) *testUser {
+	t.Helper()
+
+	u := registerUser(t)
+	u.mustDo(http.MethodPost, "/api/v1/verify-email", dto.VerifyEmailRequest{
+		Email: u.Email,
+		Code:  waitForVerificationCode(t, u.Email),
+	}, http.StatusOK, nil)
+
+	u.login()
+	u.mustDo(http.MethodPost, "/api/v1/user/initialize", map[string]int{"birth_year": 2008}, http.StatusOK, nil)
+	return u
+}
+
+func (u *testUser) login() {
+	u.t.Helper()
+
+	var resp dto.LoginResponse
+	u.mustDo(http.MethodPost, "/api/v1/login", dto.LoginRequest{
+		EmailOrUsername: u.Email,
+		Password:        testPassword,
+	}, http.StatusOK, &resp)
+	u.token = resp.AccessToken
+	if u.ID == "" {
+		u.ID = resp.User.ID
+	}
+}
+
+var verificationCodePattern = regexp.MustCompile(`class="verification-code">\s*(\d{6})\s*<`)
+
+// waitForVerificationCode polls MailHog for the newest verification email
+// sent to email and pulls the code out of it
+func waitForVerificationCode(t *testing.T, email string) string {
+	t.Helper()
+
+	endpoint := mailhogURL + "/api/v2/search?kind=to&query=" + url.QueryEscape(email)
+	deadline := time.Now().Add(15 * time.Second)
+	for time.Now().Before(deadline) {
+		resp, err := http.Get(endpoint)
+		if err == nil {
+			var result struct {
+				Items []struct {
+					Content struct {
+						Body string `json:"Body"`
+					} `json:"Content"`
+				} `json:"items"`
+			}
+			err = json.NewDecoder(resp.Body).Decode(&result)
+			resp.Body.Close()
+
+			// MailHog lists newest first
+			if err == nil {
+				for _, item := range result.Items {
+					body := strings.ReplaceAll(item.Content.Body, "=\r\n", "")
+					if m := verificationCodePattern.FindStringSubmatch(body); m != nil {
+						return m[1]
+					}
+				}
+			}
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+
+	t.Fatalf("no verification email for %s in MailHog", email)
+	return ""
+}
+
+// firstSeededLesson returns a lesson from the seeded content, failing the
+// test when the database has not been seeded
+func firstSeededLesson(t *testing.T) dto.LessonResponse {
+	t.Helper()
+
+	c := newClient(t)
+	var characters dto.CharacterCollectionResponse
+	c.mustDo(http.MethodGet, "/api/v1/content/characters", nil, http.StatusOK, &characters)
+
+	for _, character := range characters.Characters {
+		var lessons []dto.LessonResponse
+		c.mustDo(http.MethodGet, "/api/v1/content/characters/"+character.ID+"/lessons", nil, http.StatusOK, &lessons)
+		if len(lessons) > 0 {
+			return lessons[0]
+		}
+	}
+
+	t.Fatal("no lessons found, seed the database with `go run ./seed`")
+	return dto.LessonResponse{}
+}
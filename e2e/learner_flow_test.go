@@ -0,0 +1,67 @@
+//go:build e2e
+
+package e2e
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+)
+
+func TestUnverifiedUserCannotLogin(t *testing.T) {
+	u := registerUser(t)
+
+	status, _ := u.do(http.MethodPost, "/api/v1/login", dto.LoginRequest{
+		EmailOrUsername: u.Email,
+		Password:        testPassword,
+	})
+	if status == http.StatusOK {
+		t.Fatal("login succeeded before email verification")
+	}
+}
+
+func TestLearnerCompletesLessonAndRanks(t *testing.T) {
+	lesson := firstSeededLesson(t)
+	u := newVerifiedUser(t)
+
+	var before dto.UserProgressResponse
+	u.mustDo(http.MethodGet, "/api/v1/user/progress", nil, http.StatusOK, &before)
+
+	// Long enough to pass the completion speed check
+	var after dto.UserProgressResponse
+	u.mustDo(http.MethodPost, "/api/v1/user/lesson/complete", dto.CompleteLessonRequest{
+		LessonID:  lesson.ID,
+		Score:     90,
+		TimeSpent: 600,
+	}, http.StatusOK, &after)
+
+	if after.XP <= before.XP {
+		t.Fatalf("XP did not increase: before %d, after %d", before.XP, after.XP)
+	}
+
+	var board dto.LeaderboardResponse
+	u.mustDo(http.MethodGet, "/api/v1/leaderboard/all-time?limit=100", nil, http.StatusOK, &board)
+
+	if board.CurrentUser.UserID != u.ID {
+		t.Fatalf("leaderboard current_user is %q, want %q", board.CurrentUser.UserID, u.ID)
+	}
+	if board.CurrentUser.XP != after.XP {
+		t.Fatalf("leaderboard XP %d, want %d", board.CurrentUser.XP, after.XP)
+	}
+	if board.CurrentUser.Rank < 1 {
+		t.Fatalf("leaderboard rank %d, want a ranked position", board.CurrentUser.Rank)
+	}
+
+	// Replays are accepted but earn nothing
+	var replay dto.UserProgressResponse
+	u.mustDo(http.MethodPost, "/api/v1/user/lesson/complete", dto.CompleteLessonRequest{
+		LessonID:  lesson.ID,
+		Score:     100,
+		TimeSpent: 600,
+	}, http.StatusOK, &replay)
+
+	if replay.XP != after.XP {
+		t.Fatalf("replay changed XP from %d to %d", after.XP, replay.XP)
+	}
+}
@@ -0,0 +1,52 @@
+//go:build e2e
+
+// Package e2e drives a running API over HTTP. Start the stack with
+// `make e2e-up` (or run everything with `make e2e`), then:
+//
+//	go test -tags e2e ./e2e/...
+//
+// E2E_BASE_URL and E2E_MAILHOG_URL point the suite at another stack.
+package e2e
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+var (
+	baseURL    = envOr("E2E_BASE_URL", "http://localhost:18000")
+	mailhogURL = envOr("E2E_MAILHOG_URL", "http://localhost:18025")
+)
+
+func TestMain(m *testing.M) {
+	if err := waitForAPI(60 * time.Second); err != nil {
+		fmt.Fprintf(os.Stderr, "e2e: %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(m.Run())
+}
+
+func waitForAPI(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		resp, err := http.Get(baseURL + "/ping")
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+		time.Sleep(time.Second)
+	}
+	return fmt.Errorf("API at %s not ready after %s", baseURL, timeout)
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
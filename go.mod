@@ -12,6 +12,7 @@ require (
 	github.com/redis/go-redis/v9 v9.14.0
 	github.com/swaggo/swag v1.16.3
 	golang.org/x/crypto v0.41.0
+	golang.org/x/text v0.28.0
 	gorm.io/driver/postgres v1.5.9
 	gorm.io/gorm v1.31.0
 )
@@ -83,5 +84,4 @@ require (
 	github.com/prometheus/client_golang v1.23.2
 	github.com/rs/zerolog v1.34.0
 	github.com/sirupsen/logrus v1.9.3
-	golang.org/x/text v0.28.0 // indirect
 )
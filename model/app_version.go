@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// Mobile platforms whose app versions can be gated
+const (
+	AppPlatformIOS     = "ios"
+	AppPlatformAndroid = "android"
+)
+
+var AppPlatforms = []string{AppPlatformIOS, AppPlatformAndroid}
+
+// AppVersionPolicy is the supported version range for one platform. Clients older than
+// MinVersion are refused with a force-update error; clients older than LatestVersion are
+// told an update is available.
+type AppVersionPolicy struct {
+	Platform      string `json:"platform" gorm:"primaryKey;size:20"`
+	MinVersion    string `json:"min_version" gorm:"size:20;not null"`
+	LatestVersion string `json:"latest_version" gorm:"size:20;not null"`
+	StoreURL      string `json:"store_url,omitempty" gorm:"size:500"`
+
+	UpdatedBy string    `json:"updated_by,omitempty" gorm:"size:50"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
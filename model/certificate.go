@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// Certificate is issued once a user has completed every active lesson of a dynasty.
+// Code is printed on the certificate image so anyone can check it is genuine.
+type Certificate struct {
+	ID            string    `json:"id" gorm:"primaryKey"`
+	UserID        string    `json:"user_id" gorm:"not null;uniqueIndex:idx_certificates_user_dynasty,priority:1"`
+	Dynasty       string    `json:"dynasty" gorm:"not null;uniqueIndex:idx_certificates_user_dynasty,priority:2"`
+	Code          string    `json:"code" gorm:"size:16;not null;uniqueIndex"`
+	RecipientName string    `json:"recipient_name" gorm:"not null"` // username when issued
+	LessonCount   int       `json:"lesson_count" gorm:"not null"`
+	AverageScore  int       `json:"average_score" gorm:"not null"`
+	StartedAt     time.Time `json:"started_at"`   // first lesson of the dynasty completed
+	CompletedAt   time.Time `json:"completed_at"` // last lesson of the dynasty completed
+	CreatedAt     time.Time `json:"created_at"`
+
+	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
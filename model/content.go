@@ -2,7 +2,9 @@
 package model
 
 import (
+	"crypto/sha256"
 	"database/sql/driver"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"time"
@@ -89,6 +91,47 @@ type Character struct {
 	UpdatedAt    time.Time       `json:"updated_at"`
 }
 
+// Character relation types. A relation reads "Character is the <type> of
+// RelatedCharacter"; only mentor is directional, its other side reads as student.
+const (
+	CharacterRelationAlly    = "ally"
+	CharacterRelationRival   = "rival"
+	CharacterRelationMentor  = "mentor"
+	CharacterRelationFamily  = "family"
+	CharacterRelationStudent = "student" // the inverse of mentor, never stored
+)
+
+// CharacterRelation connects two historical figures, e.g. Lê Lợi and his strategist
+// Nguyễn Trãi. Each pair is stored once and shows up on both characters.
+type CharacterRelation struct {
+	ID                 string    `json:"id" gorm:"primaryKey"`
+	CharacterID        string    `json:"character_id" gorm:"not null;uniqueIndex:idx_character_relations_pair,priority:1"`
+	RelatedCharacterID string    `json:"related_character_id" gorm:"not null;uniqueIndex:idx_character_relations_pair,priority:2;index"`
+	Type               string    `json:"type" gorm:"not null;size:20"` // ally, rival, mentor, family
+	Description        string    `json:"description,omitempty" gorm:"type:text"`
+	CreatedBy          string    `json:"created_by,omitempty" gorm:"size:50"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+
+	Character        Character `json:"-" gorm:"foreignKey:CharacterID;constraint:OnDelete:CASCADE"`
+	RelatedCharacter Character `json:"-" gorm:"foreignKey:RelatedCharacterID;constraint:OnDelete:CASCADE"`
+}
+
+// GlossaryTerm explains a historical term, e.g. "Bắc thuộc" or "hịch". Terms and their
+// aliases are linked wherever they appear in a lesson story.
+type GlossaryTerm struct {
+	ID         string          `json:"id" gorm:"primaryKey"`
+	Term       string          `json:"term" gorm:"not null;size:100;uniqueIndex"`
+	Aliases    json.RawMessage `json:"aliases,omitempty" gorm:"type:jsonb" swaggertype:"array,string"` // other spellings that link to the term
+	Definition string          `json:"definition" gorm:"type:text;not null"`
+	Era        string          `json:"era,omitempty" gorm:"index"`
+	ImageURL   string          `json:"image_url,omitempty"`
+	AudioURL   string          `json:"audio_url,omitempty"` // pronunciation
+	CreatedBy  string          `json:"created_by,omitempty" gorm:"size:50"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+}
+
 // Lesson represents individual learning content
 type Lesson struct {
 	ID          string `json:"id" gorm:"primaryKey"`
@@ -112,9 +155,20 @@ type Lesson struct {
 	SubtitleURL  string `json:"subtitle_url"`  // Subtitle file (VTT/SRT)
 	ThumbnailURL string `json:"thumbnail_url"` // Lesson thumbnail
 
+	// Accessibility. The transcript is generated from the subtitles unless an admin
+	// uploaded one; the audio description narrates the visuals for blind learners.
+	Transcript          string `json:"transcript" gorm:"type:text"`
+	TranscriptSource    string `json:"transcript_source" gorm:"size:10"` // subtitles, uploaded; empty without a transcript
+	AudioDescriptionURL string `json:"audio_description_url"`
+
 	// Content Settings
-	CanSkipAfter int  `json:"can_skip_after" gorm:"default:5"` // Seconds before skip allowed
-	HasSubtitles bool `json:"has_subtitles" gorm:"default:true"`
+	CanSkipAfter  int    `json:"can_skip_after" gorm:"default:5"` // Seconds before skip allowed
+	HasSubtitles  bool   `json:"has_subtitles" gorm:"default:true"`
+	ContentRating string `json:"content_rating" gorm:"size:10;index"` // all, 10+, 13+, 16+; empty until reviewed
+
+	// Admin overrides; empty and 0 mean they are worked out from the questions and video
+	Difficulty       string `json:"difficulty" gorm:"size:10"` // easy, medium, hard
+	EstimatedMinutes int    `json:"estimated_minutes" gorm:"default:0"`
 
 	Questions json.RawMessage `json:"questions" gorm:"type:jsonb"` // JSON array of questions
 	XPReward  int             `json:"xp_reward" gorm:"default:50"`
@@ -127,6 +181,52 @@ type Lesson struct {
 	Character Character `json:"character" gorm:"foreignKey:CharacterID"`
 }
 
+// Content ratings give the minimum age a lesson is suitable for. Unrated lessons are
+// shown to everyone until an admin reviews them.
+const (
+	ContentRatingUnrated = ""
+	ContentRatingAll     = "all"
+	ContentRating10      = "10+"
+	ContentRating13      = "13+"
+	ContentRating16      = "16+"
+)
+
+var ContentRatings = []string{ContentRatingAll, ContentRating10, ContentRating13, ContentRating16}
+
+var contentRatingMinAges = map[string]int{
+	ContentRatingAll: 0,
+	ContentRating10:  10,
+	ContentRating13:  13,
+	ContentRating16:  16,
+}
+
+// ContentRatingMinAge returns the youngest age a rating is suitable for
+func ContentRatingMinAge(rating string) int {
+	return contentRatingMinAges[rating]
+}
+
+// Lesson difficulties shown to learners before they start a lesson
+const (
+	LessonDifficultyEasy   = "easy"
+	LessonDifficultyMedium = "medium"
+	LessonDifficultyHard   = "hard"
+)
+
+var LessonDifficulties = []string{LessonDifficultyEasy, LessonDifficultyMedium, LessonDifficultyHard}
+
+// SuitableForAge reports whether the lesson may be shown to a viewer with the given
+// content age limit, see User.ContentAgeLimit
+func (l *Lesson) SuitableForAge(ageLimit int) bool {
+	return ContentRatingMinAge(l.ContentRating) <= ageLimit
+}
+
+// QuestionsVersion fingerprints the stored questions so bulk edits can detect that
+// the lesson changed between preview and apply
+func (l *Lesson) QuestionsVersion() string {
+	sum := sha256.Sum256(l.Questions)
+	return hex.EncodeToString(sum[:8])
+}
+
 // Question represents quiz questions within lessons
 type Question struct {
 	ID       string                 `json:"id"`
@@ -155,23 +255,174 @@ type Timeline struct {
 	UpdatedAt    time.Time       `json:"updated_at"`
 }
 
+// Era is a period of history that dynasties, characters, timeline entries and glossary
+// terms are filed under. Content refers to it by Code, which can't change.
+type Era struct {
+	Code        string    `json:"code" gorm:"primaryKey;size:50"` // "Bac_Thuoc", "Doc_Lap", etc.
+	Name        string    `json:"name" gorm:"not null;size:100"`
+	Description string    `json:"description,omitempty" gorm:"type:text"`
+	Order       int       `json:"order" gorm:"not null;default:0"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// DefaultEras are created when the eras table is first migrated
+func DefaultEras() []Era {
+	return []Era{
+		{Code: "Bac_Thuoc", Name: "Bắc thuộc", Order: 1},
+		{Code: "Doc_Lap", Name: "Độc lập", Order: 2},
+		{Code: "Phong_Kien", Name: "Phong kiến", Order: 3},
+		{Code: "Can_Dai", Name: "Cận đại", Order: 4},
+	}
+}
+
+// Dynasty is a ruling house or period within an era. Characters and timeline entries
+// refer to it by Name; renaming a dynasty renames it there too.
+type Dynasty struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"not null;size:100;uniqueIndex"`
+	Era       string    `json:"era" gorm:"not null;size:50;index"`
+	StartYear *int      `json:"start_year,omitempty"`
+	EndYear   *int      `json:"end_year,omitempty"`
+	Order     int       `json:"order" gorm:"not null;default:0"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
 // UserProgress represents registered user progress (different from guest)
 type UserProgress struct {
 	ID                 string     `json:"id" gorm:"primaryKey"`
-	UserID             string     `json:"user_id" gorm:"not null"`
+	UserID             string     `json:"user_id" gorm:"not null;index"`
 	Hearts             int        `json:"hearts" gorm:"default:5"`
 	MaxHearts          int        `json:"max_hearts" gorm:"default:5"`
-	XP                 int        `json:"xp" gorm:"default:0"`
+	XP                 int        `json:"xp" gorm:"default:0;index:idx_user_progress_xp,sort:desc"`
 	Level              int        `json:"level" gorm:"default:1"`
-	CompletedLessons   JSONB      `json:"completed_lessons" gorm:"type:jsonb"`
-	UnlockedCharacters JSONB      `json:"unlocked_characters" gorm:"type:jsonb"`
+	CompletedLessons   JSONB      `json:"completed_lessons" gorm:"type:jsonb"`   // Deprecated: superseded by UserLessonCompletion, kept for backfill
+	UnlockedCharacters JSONB      `json:"unlocked_characters" gorm:"type:jsonb"` // Deprecated: superseded by UserCharacter, kept for backfill
 	Streak             int        `json:"streak" gorm:"default:0"`
 	StreakFreezeUsed   bool       `json:"streak_freeze_used" gorm:"default:false"`
 	TotalPlayTime      int        `json:"total_play_time" gorm:"default:0"` // in minutes
 	LastHeartReset     *time.Time `json:"last_heart_reset"`
 	LastActivityDate   *time.Time `json:"last_activity_date"`
 	CreatedAt          time.Time  `json:"created_at"`
-	UpdatedAt          time.Time  `json:"updated_at"`
+	UpdatedAt          time.Time  `json:"updated_at" gorm:"index"` // weekly and monthly leaderboards
+
+	// Comeback bonus granted on the first lesson after a break
+	ComebackMultiplier float64    `json:"comeback_multiplier" gorm:"default:0"`
+	ComebackUntil      *time.Time `json:"comeback_until"`
+
+	// Last time the user opened their collection, used for "new" markers
+	CollectionViewedAt *time.Time `json:"collection_viewed_at"`
+
+	// Days the weekend amulet or streak freezes bridged in the current streak. They are not
+	// counted in Streak; leaderboards show them to tell protected streaks apart.
+	StreakProtectedDays int `json:"streak_protected_days" gorm:"default:0;not null"`
+	// Streak freezes bought in the shop, each covers one missed day
+	StreakFreezes int `json:"streak_freezes" gorm:"default:0;not null"`
+	// Set when a lesson aid paid with half a heart took a whole one; the next half-heart
+	// aid uses the other half instead of another heart
+	HalfHeartBanked bool `json:"half_heart_banked" gorm:"default:false;not null"`
+}
+
+// HasComebackBonus reports whether the comeback XP multiplier is active at t
+func (p *UserProgress) HasComebackBonus(t time.Time) bool {
+	return p.ComebackUntil != nil && t.Before(*p.ComebackUntil) && p.ComebackMultiplier > 1
+}
+
+// XP ledger sources
+const (
+	XPSourceLesson         = "lesson"
+	XPSourceAchievement    = "achievement"
+	XPSourceBattle         = "battle"
+	XPSourceTrivia         = "trivia"
+	XPSourceStudyRoom      = "study_room"
+	XPSourceOpeningBalance = "opening_balance" // XP earned before the ledger existed
+	XPSourceReconcile      = "reconcile"       // correction for drift found by reconciliation
+	XPSourceAdmin          = "admin"           // support remediation or promotion, see ReasonCode
+)
+
+// XPTransaction is one entry of a user's XP ledger. Every grant credits the user and
+// their spirit with Amount, so UserProgress.XP always equals the sum of the entries.
+type XPTransaction struct {
+	ID           string    `json:"id" gorm:"primaryKey"`
+	UserID       string    `json:"user_id" gorm:"not null;index:idx_xp_transactions_user,priority:1"`
+	Source       string    `json:"source" gorm:"size:30;not null"`
+	Amount       int       `json:"amount" gorm:"not null"`
+	BalanceAfter int       `json:"balance_after"`
+	ReferenceID  string    `json:"reference_id"`                         // lesson, achievement or battle ID, or admin batch ID
+	ReasonCode   string    `json:"reason_code,omitempty" gorm:"size:40"` // admin grants only
+	ActorID      string    `json:"actor_id,omitempty" gorm:"size:50"`    // admin who made the grant
+	Note         string    `json:"note"`
+	CreatedAt    time.Time `json:"created_at" gorm:"index:idx_xp_transactions_user,priority:2"`
+}
+
+// Reason codes required on admin economy adjustments
+const (
+	EconomyReasonSupport      = "support_remediation"
+	EconomyReasonPromotion    = "promotion"
+	EconomyReasonCompensation = "bug_compensation"
+	EconomyReasonAbuse        = "abuse_correction"
+	EconomyReasonTesting      = "testing"
+)
+
+// Heart ledger sources
+const (
+	HeartSourceAdmin     = "admin"
+	HeartSourceShop      = "shop"
+	HeartSourceLessonAid = "lesson_aid"
+)
+
+// HeartTransaction is one entry of a user's heart ledger. Amount is the change actually
+// applied after clamping to [0, MaxHearts].
+type HeartTransaction struct {
+	ID           string    `json:"id" gorm:"primaryKey"`
+	UserID       string    `json:"user_id" gorm:"not null;index:idx_heart_transactions_user,priority:1"`
+	Source       string    `json:"source" gorm:"size:30;not null"`
+	Amount       int       `json:"amount" gorm:"not null"`
+	BalanceAfter int       `json:"balance_after"`
+	ReferenceID  string    `json:"reference_id"` // admin batch ID
+	ReasonCode   string    `json:"reason_code,omitempty" gorm:"size:40"`
+	ActorID      string    `json:"actor_id,omitempty" gorm:"size:50"`
+	Note         string    `json:"note"`
+	CreatedAt    time.Time `json:"created_at" gorm:"index:idx_heart_transactions_user,priority:2"`
+}
+
+// ProgressChange is what a change to UserProgress stores along with it: the ledger
+// entries of the XP and hearts it moved, and outbox messages
+type ProgressChange struct {
+	XP     *XPTransaction
+	Hearts *HeartTransaction
+	Outbox []*OutboxMessage
+}
+
+// Item ledger actions
+const (
+	ItemActionGrant  = "grant"
+	ItemActionRevoke = "revoke"
+)
+
+const ItemTypeCharacter = "character"
+
+// ItemTransaction records an item given to or taken from a user by an admin. Characters
+// are the only item type; their ownership itself lives in UserCharacter.
+type ItemTransaction struct {
+	ID         string    `json:"id" gorm:"primaryKey"`
+	UserID     string    `json:"user_id" gorm:"not null;index:idx_item_transactions_user,priority:1"`
+	ItemType   string    `json:"item_type" gorm:"size:20;not null"`
+	ItemID     string    `json:"item_id" gorm:"not null"`
+	Action     string    `json:"action" gorm:"size:10;not null"`
+	BatchID    string    `json:"batch_id" gorm:"index"`
+	ReasonCode string    `json:"reason_code" gorm:"size:40"`
+	ActorID    string    `json:"actor_id" gorm:"size:50"`
+	Note       string    `json:"note"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index:idx_item_transactions_user,priority:2"`
+}
+
+// XPLedgerMismatch is a user whose stored XP differs from the sum of their ledger
+type XPLedgerMismatch struct {
+	UserID   string `json:"user_id"`
+	XP       int    `json:"xp"`
+	LedgerXP int    `json:"ledger_xp"`
 }
 
 // Achievement represents unlockable achievements
@@ -186,6 +437,43 @@ type Achievement struct {
 	IsActive    bool      `json:"is_active" gorm:"default:true"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Tiered achievements are driven by a metric and unlock once per tier
+	Metric string          `json:"metric,omitempty" gorm:"size:50;index"`
+	Tiers  json.RawMessage `json:"tiers,omitempty" gorm:"type:jsonb"` // JSON array of AchievementTier, ascending targets
+}
+
+const (
+	AchievementTierBronze = "bronze"
+	AchievementTierSilver = "silver"
+	AchievementTierGold   = "gold"
+)
+
+const (
+	AchievementMetricLessonsCompleted   = "lessons_completed"
+	AchievementMetricPerfectLessons     = "perfect_lessons"
+	AchievementMetricStreakDays         = "streak_days"
+	AchievementMetricCharactersUnlocked = "characters_unlocked"
+	AchievementMetricBattlesWon         = "battles_won"
+)
+
+// AchievementTier is one step of a tiered achievement
+type AchievementTier struct {
+	Tier     string `json:"tier"`
+	Target   int    `json:"target"`
+	XPReward int    `json:"xp_reward"`
+}
+
+// UserAchievementProgress is a user's running count towards a tiered achievement. It is
+// updated as events arrive so progress never needs to be recomputed from history.
+type UserAchievementProgress struct {
+	ID            string    `json:"id" gorm:"primaryKey"`
+	UserID        string    `json:"user_id" gorm:"not null;uniqueIndex:idx_user_achievement_progress"`
+	AchievementID string    `json:"achievement_id" gorm:"not null;uniqueIndex:idx_user_achievement_progress"`
+	Current       int       `json:"current" gorm:"not null;default:0"`
+	TierReached   string    `json:"tier_reached" gorm:"size:20"` // empty until the first tier unlocks
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
 
 // UserAchievement tracks which achievements users have unlocked
@@ -193,6 +481,7 @@ type UserAchievement struct {
 	ID            string    `json:"id" gorm:"primaryKey"`
 	UserID        string    `json:"user_id" gorm:"not null"`
 	AchievementID string    `json:"achievement_id" gorm:"not null"`
+	Tier          string    `json:"tier,omitempty" gorm:"size:20"`
 	UnlockedAt    time.Time `json:"unlocked_at"`
 	CreatedAt     time.Time `json:"created_at"`
 
@@ -203,8 +492,8 @@ type UserAchievement struct {
 // UserLessonAttempt tracks lesson attempts for registered users (different from guest)
 type UserLessonAttempt struct {
 	ID            string    `json:"id" gorm:"primaryKey"`
-	UserID        string    `json:"user_id" gorm:"not null"`
-	LessonID      string    `json:"lesson_id" gorm:"not null"`
+	UserID        string    `json:"user_id" gorm:"not null;index:idx_user_lesson_attempts_lookup,priority:1"`
+	LessonID      string    `json:"lesson_id" gorm:"not null;index:idx_user_lesson_attempts_lookup,priority:2"`
 	IsCompleted   bool      `json:"is_completed" gorm:"not null"`
 	Score         int       `json:"score" gorm:"not null"`
 	TimeSpent     int       `json:"time_spent" gorm:"not null"` // in seconds
@@ -217,17 +506,317 @@ type UserLessonAttempt struct {
 	Lesson Lesson `json:"lesson" gorm:"foreignKey:LessonID"`
 }
 
+// UserLessonCompletion records that a registered user has completed a lesson.
+// One row per (user, lesson) replaces the CompletedLessons JSON array.
+type UserLessonCompletion struct {
+	ID          string    `json:"id" gorm:"primaryKey"`
+	UserID      string    `json:"user_id" gorm:"not null;uniqueIndex:idx_user_lesson_completion;index"`
+	LessonID    string    `json:"lesson_id" gorm:"not null;uniqueIndex:idx_user_lesson_completion"`
+	Score       int       `json:"score" gorm:"not null;default:0"`
+	CompletedAt time.Time `json:"completed_at" gorm:"not null;index"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Reasons a lesson completion looks too fast to be genuine
+const (
+	SpeedReasonReportedDuration = "reported_duration"   // client reported less time than the lesson needs
+	SpeedReasonInterval         = "completion_interval" // less time passed since the previous completion
+	SpeedReasonBurst            = "burst"               // too many completions within the burst window
+)
+
+// Review states of a CompletionFlag
+const (
+	FlagStatusPending   = "pending"
+	FlagStatusDismissed = "dismissed"
+	FlagStatusBanned    = "banned"
+)
+
+// CompletionFlag records a lesson completion faster than a person could plausibly
+// manage. Pending flags form the ban-review queue.
+type CompletionFlag struct {
+	ID          string     `json:"id" gorm:"primaryKey"`
+	UserID      string     `json:"user_id" gorm:"not null;index"`
+	LessonID    string     `json:"lesson_id" gorm:"not null"`
+	Reasons     string     `json:"reasons" gorm:"size:100"` // comma separated SpeedReason values
+	TimeSpent   int        `json:"time_spent"`              // seconds reported by the client
+	MinDuration int        `json:"min_duration"`            // plausible minimum in seconds
+	Rejected    bool       `json:"rejected"`
+	Status      string     `json:"status" gorm:"size:20;not null;default:'pending';index"`
+	ReviewedBy  string     `json:"reviewed_by,omitempty"`
+	ReviewNote  string     `json:"review_note,omitempty"`
+	ReviewedAt  *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"index"`
+
+	// Relationship
+	User User `json:"user" gorm:"foreignKey:UserID"`
+}
+
+// Reasons a user's XP gain raised a LeaderboardAnomaly
+const (
+	AnomalyReasonDailyXP = "daily_xp" // more XP in a day than play can earn
+	AnomalyReasonXPJump  = "xp_jump"  // a sudden jump, too much XP within an hour
+)
+
+// Review states of a LeaderboardAnomaly
+const (
+	AnomalyStatusPending   = "pending"
+	AnomalyStatusCleared   = "cleared"
+	AnomalyStatusPenalized = "penalized"
+)
+
+// LeaderboardAnomaly records XP gained faster than the game allows. While one is pending
+// the user is quarantined from leaderboards.
+type LeaderboardAnomaly struct {
+	ID          string     `json:"id" gorm:"primaryKey"`
+	UserID      string     `json:"user_id" gorm:"not null;index"`
+	Reason      string     `json:"reason" gorm:"size:20;not null"`
+	XPGained    int        `json:"xp_gained"` // XP earned in the window
+	Threshold   int        `json:"threshold"` // the most XP allowed in the window
+	WindowStart time.Time  `json:"window_start"`
+	WindowEnd   time.Time  `json:"window_end"`
+	Status      string     `json:"status" gorm:"size:20;not null;default:'pending';index"`
+	PenaltyXP   int        `json:"penalty_xp"`
+	ReviewedBy  string     `json:"reviewed_by,omitempty"`
+	ReviewNote  string     `json:"review_note,omitempty"`
+	ReviewedAt  *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"index"`
+
+	// Relationship
+	User User `json:"user" gorm:"foreignKey:UserID"`
+}
+
+// XPGain is the XP a user earned over a window
+type XPGain struct {
+	UserID string
+	XP     int
+}
+
+const (
+	UnlockSourceLesson   = "lesson"
+	UnlockSourceAdmin    = "admin"
+	UnlockSourceBackfill = "backfill"
+	UnlockSourceRepair   = "repair"
+)
+
+// UserCharacter records a character unlocked by a registered user
+type UserCharacter struct {
+	ID          string    `json:"id" gorm:"primaryKey"`
+	UserID      string    `json:"user_id" gorm:"not null;uniqueIndex:idx_user_character;index"`
+	CharacterID string    `json:"character_id" gorm:"not null;uniqueIndex:idx_user_character"`
+	UnlockedAt  time.Time `json:"unlocked_at" gorm:"not null;index"`
+	Source      string    `json:"source" gorm:"size:20"` // lesson, admin, backfill, repair
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Entity types returned by content search
+const (
+	SearchEntityCharacter = "character"
+	SearchEntityLesson    = "lesson"
+	SearchEntityDynasty   = "dynasty"
+)
+
+// SearchHit is one row of a content search before it is hydrated
+type SearchHit struct {
+	EntityType string
+	EntityID   string
+	Rank       int
+}
+
+// SearchSuggestionSource is a name or title the search suggestion index is built from.
+// Dynasties have no table and use their name as EntityID.
+type SearchSuggestionSource struct {
+	EntityType  string
+	EntityID    string
+	Text        string
+	CharacterID string
+}
+
+// Entity types counted in ContentPopularityStat
+const (
+	PopularityEntityLesson    = "lesson"
+	PopularityEntityCharacter = "character"
+)
+
+// ContentPopularityStat is the daily total of views, starts and completions of a lesson
+// or character, and of video plays and skips of a lesson. Counters are kept in Redis and
+// added here periodically.
+type ContentPopularityStat struct {
+	EntityType  string    `json:"entity_type" gorm:"primaryKey;size:20"` // lesson, character
+	EntityID    string    `json:"entity_id" gorm:"primaryKey;size:50"`
+	Day         time.Time `json:"day" gorm:"primaryKey;type:date;index"`
+	Views       int64     `json:"views" gorm:"not null;default:0"`
+	Starts      int64     `json:"starts" gorm:"not null;default:0"`
+	Completions int64     `json:"completions" gorm:"not null;default:0"`
+	VideoPlays  int64     `json:"video_plays" gorm:"not null;default:0"`
+	VideoSkips  int64     `json:"video_skips" gorm:"not null;default:0"`
+}
+
+// TrendingContent is a lesson or character with its counters summed over a window
+type TrendingContent struct {
+	EntityType  string
+	EntityID    string
+	Views       int64
+	Starts      int64
+	Completions int64
+	Score       int64
+}
+
+// LessonVideoWatchStats sums the video progress of everyone who played a lesson's video
+type LessonVideoWatchStats struct {
+	Viewers             int64
+	Skipped             int64
+	AverageWatchPercent float64
+	AverageSkipSecond   float64
+}
+
+// UserFavoriteCharacter is a character a user pinned in their collection
+type UserFavoriteCharacter struct {
+	ID          string    `json:"id" gorm:"primaryKey"`
+	UserID      string    `json:"user_id" gorm:"not null;uniqueIndex:idx_user_favorite_character;index"`
+	CharacterID string    `json:"character_id" gorm:"not null;uniqueIndex:idx_user_favorite_character"`
+	CreatedAt   time.Time `json:"created_at" gorm:"not null"`
+}
+
+// CharacterRarityRank orders rarities from most common to rarest. Seeded content
+// uses the Vietnamese names, admin-created characters may use the English ones.
+var CharacterRarityRank = map[string]int{
+	"Thường":      1,
+	"Common":      1,
+	"Hiếm":        2,
+	"Rare":        2,
+	"Epic":        3,
+	"Huyền thoại": 4,
+	"Legendary":   4,
+}
+
+// Collection sort orders
+const (
+	CollectionSortRarity     = "rarity"
+	CollectionSortDynasty    = "dynasty"
+	CollectionSortUnlockedAt = "unlocked_at"
+	CollectionSortName       = "name"
+)
+
+// UserLessonBookmark is a lesson a user saved to come back to later
+type UserLessonBookmark struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	UserID    string    `json:"user_id" gorm:"not null;uniqueIndex:idx_user_lesson_bookmark;index"`
+	LessonID  string    `json:"lesson_id" gorm:"not null;uniqueIndex:idx_user_lesson_bookmark;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"not null;index"`
+
+	// Relationship
+	Lesson Lesson `json:"lesson" gorm:"foreignKey:LessonID"`
+}
+
+// LessonBookmarkCount is the number of users who bookmarked a lesson
+type LessonBookmarkCount struct {
+	LessonID      string `json:"lesson_id"`
+	BookmarkCount int64  `json:"bookmark_count"`
+}
+
+const (
+	ContentEntityCharacter   = "character"
+	ContentEntityLesson      = "lesson"
+	ContentEntityTimeline    = "timeline"
+	ContentEntityMedia       = "media"
+	ContentEntityTranslation = "translation"
+	ContentEntityRelation    = "character_relation"
+	ContentEntityGlossary    = "glossary_term"
+	ContentEntityEra         = "era"
+	ContentEntityDynasty     = "dynasty"
+	ContentEntityCache       = "cache"
+
+	ContentActionCreate     = "create"
+	ContentActionUpdate     = "update"
+	ContentActionDelete     = "delete"
+	ContentActionPublish    = "publish"
+	ContentActionInvalidate = "invalidate"
+)
+
+// ContentAuditLog records an admin change to a piece of content with before/after snapshots
+type ContentAuditLog struct {
+	ID            string          `json:"id" gorm:"primaryKey"`
+	AdminID       string          `json:"admin_id" gorm:"not null;index;size:50"`
+	EntityType    string          `json:"entity_type" gorm:"not null;size:20;index:idx_content_audit_entity;index:idx_content_audit_entity_time,priority:1"`
+	EntityID      string          `json:"entity_id" gorm:"not null;size:50;index:idx_content_audit_entity;index:idx_content_audit_entity_time,priority:2"`
+	Action        string          `json:"action" gorm:"not null;size:20;index"` // create, update, delete, publish, invalidate
+	Before        json.RawMessage `json:"before,omitempty" gorm:"type:jsonb"`
+	After         json.RawMessage `json:"after,omitempty" gorm:"type:jsonb"`
+	ChangedFields json.RawMessage `json:"changed_fields,omitempty" gorm:"type:jsonb"` // JSON array of top-level field names
+	CreatedAt     time.Time       `json:"created_at" gorm:"index;index:idx_content_audit_entity_time,priority:3,sort:desc"`
+}
+
+// LessonSession is a user's lesson in progress, kept so the app can resume it after it
+// was closed. The answers themselves are UserQuestionAnswers.
+type LessonSession struct {
+	UserID               string    `json:"user_id" gorm:"primaryKey;size:50"`
+	LessonID             string    `json:"lesson_id" gorm:"primaryKey;size:50"`
+	VideoPositionSeconds int       `json:"video_position_seconds" gorm:"not null;default:0"`
+	TimeSpentSeconds     int       `json:"time_spent_seconds" gorm:"not null;default:0"`
+	StartedAt            time.Time `json:"started_at" gorm:"not null"`
+	LastActiveAt         time.Time `json:"last_active_at" gorm:"not null;index"`
+}
+
+// Lesson aids a learner can buy during a lesson
+const (
+	LessonAidFiftyFifty = "fifty_fifty" // takes one wrong option out of a multiple choice question
+)
+
+// What a lesson aid was paid with
+const (
+	AidPaymentHearts = "hearts" // half a heart
+	AidPaymentCoins  = "coins"
+)
+
+// LessonAidUse is an aid used on one question in a lesson session. Uses belong to the
+// session they were bought in, so starting the lesson over gives a fresh allowance.
+type LessonAidUse struct {
+	ID               string    `json:"id" gorm:"primaryKey"`
+	UserID           string    `json:"user_id" gorm:"not null;size:50;uniqueIndex:idx_lesson_aid_use,priority:1"`
+	LessonID         string    `json:"lesson_id" gorm:"not null;size:50;uniqueIndex:idx_lesson_aid_use,priority:2"`
+	SessionStartedAt time.Time `json:"session_started_at" gorm:"not null;uniqueIndex:idx_lesson_aid_use,priority:3"`
+	QuestionID       string    `json:"question_id" gorm:"not null;uniqueIndex:idx_lesson_aid_use,priority:4"`
+	Aid              string    `json:"aid" gorm:"size:20;not null;uniqueIndex:idx_lesson_aid_use,priority:5"`
+	PaidWith         string    `json:"paid_with" gorm:"size:10;not null"` // hearts, coins
+	Cost             int       `json:"cost" gorm:"not null"`              // whole hearts or coins taken
+	RemovedOption    string    `json:"removed_option"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// Events the app reports while a lesson video plays
+const (
+	VideoEventProgress = "progress"
+	VideoEventSkip     = "skip"
+	VideoEventEnded    = "ended"
+)
+
+// LessonVideoProgress is how much of a lesson's video a user watched, built from the
+// heartbeats the app sends while it plays. Jumping ahead does not count as watching.
+type LessonVideoProgress struct {
+	UserID           string    `json:"user_id" gorm:"primaryKey;size:50"`
+	LessonID         string    `json:"lesson_id" gorm:"primaryKey;size:50;index"`
+	DurationSeconds  int       `json:"duration_seconds" gorm:"not null"`
+	PositionSeconds  int       `json:"position_seconds" gorm:"not null;default:0"` // last reported
+	WatchedSeconds   int       `json:"watched_seconds" gorm:"not null;default:0"`
+	WatchPercent     int       `json:"watch_percent" gorm:"not null;default:0"`
+	Skipped          bool      `json:"skipped" gorm:"not null;default:false"`
+	SkippedAtSeconds int       `json:"skipped_at_seconds" gorm:"not null;default:0"`
+	LastHeartbeatAt  time.Time `json:"last_heartbeat_at" gorm:"not null"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
 // UserQuestionAnswer tracks individual question answers for progressive lesson completion
 type UserQuestionAnswer struct {
 	ID         string    `json:"id" gorm:"primaryKey"`
-	UserID     string    `json:"user_id" gorm:"not null"`
-	LessonID   string    `json:"lesson_id" gorm:"not null"`
-	QuestionID string    `json:"question_id" gorm:"not null"`
+	UserID     string    `json:"user_id" gorm:"not null;index:idx_user_question_answers_lookup,priority:1"`
+	LessonID   string    `json:"lesson_id" gorm:"not null;index:idx_user_question_answers_lookup,priority:2"`
+	QuestionID string    `json:"question_id" gorm:"not null;index:idx_user_question_answers_lookup,priority:3"`
 	Answer     string    `json:"answer" gorm:"type:text"` // JSON string of the answer
 	IsCorrect  bool      `json:"is_correct" gorm:"not null"`
 	Points     int       `json:"points" gorm:"not null"`
 	CreatedAt  time.Time `json:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at"`
+	UpdatedAt  time.Time `json:"updated_at" gorm:"index"` // retention pruning
 
 	// Relationships
 	User   User   `json:"user" gorm:"foreignKey:UserID"`
@@ -248,6 +837,37 @@ type Spirit struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+const (
+	BattleStatusActive    = "active"
+	BattleStatusWon       = "won"
+	BattleStatusLost      = "lost"
+	BattleStatusAbandoned = "abandoned"
+)
+
+// SpiritBattle is a PvE encounter where the user's spirit fights a dynasty boss
+// by answering questions from lessons the user has already completed
+type SpiritBattle struct {
+	ID           string     `json:"id" gorm:"primaryKey"`
+	UserID       string     `json:"user_id" gorm:"not null;index"`
+	SpiritID     string     `json:"spirit_id" gorm:"not null"`
+	Dynasty      string     `json:"dynasty" gorm:"not null"`
+	BossName     string     `json:"boss_name"`
+	BossHP       int        `json:"boss_hp"`
+	BossMaxHP    int        `json:"boss_max_hp"`
+	SpiritHP     int        `json:"spirit_hp"`
+	SpiritMaxHP  int        `json:"spirit_max_hp"`
+	Questions    JSONB      `json:"-" gorm:"type:jsonb"` // JSON array of {lesson_id, question_id}
+	CurrentIndex int        `json:"current_index" gorm:"default:0"`
+	Combo        int        `json:"combo" gorm:"default:0"` // consecutive correct answers
+	TurnCount    int        `json:"turn_count" gorm:"default:0"`
+	Status       string     `json:"status" gorm:"not null;default:active;index"` // active, won, lost, abandoned
+	XPReward     int        `json:"xp_reward" gorm:"default:0"`
+	RewardPaid   bool       `json:"reward_paid" gorm:"default:false"`
+	EndedAt      *time.Time `json:"ended_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
 // MediaAsset represents uploaded media files
 type MediaAsset struct {
 	ID           string    `json:"id" gorm:"primaryKey"`
@@ -262,17 +882,109 @@ type MediaAsset struct {
 	URL          string    `json:"url"`
 	CDNUrl       string    `json:"cdn_url"`
 	StoragePath  string    `json:"storage_path"`
+	ContentHash  string    `json:"content_hash" gorm:"index"` // SHA-256 of the file, used for immutable CDN paths
+	Tags         JSONB     `json:"tags" gorm:"type:jsonb"`    // JSON array of admin tags
 	IsProcessed  bool      `json:"is_processed" gorm:"default:false"`
+	UploadedBy   string    `json:"uploaded_by" gorm:"index;size:50"` // admin user ID
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
+
+	// Background processing (metadata, thumbnails). ProcessingError holds the last failure.
+	ProcessingStatus    string     `json:"processing_status" gorm:"size:20;default:'pending';not null;index"`
+	ProcessingError     string     `json:"processing_error,omitempty" gorm:"type:text"`
+	ProcessingAttempts  int        `json:"processing_attempts" gorm:"default:0;not null"`
+	ProcessingStartedAt *time.Time `json:"processing_started_at,omitempty"`
+	ProcessedAt         *time.Time `json:"processed_at,omitempty"`
+
+	// Bucket the file is stored in. Empty for files uploaded before buckets were
+	// configured per media category, which are in the default bucket.
+	Bucket string `json:"bucket" gorm:"size:63"`
+}
+
+// Media processing states
+const (
+	MediaProcessingPending   = "pending"
+	MediaProcessingRunning   = "processing"
+	MediaProcessingCompleted = "completed"
+	MediaProcessingFailed    = "failed"
+)
+
+const (
+	UploadStatusPending   = "pending"
+	UploadStatusCompleted = "completed"
+	UploadStatusAborted   = "aborted"
+)
+
+// MediaUploadSession tracks a resumable upload backed by a MinIO multipart upload
+type MediaUploadSession struct {
+	ID                string     `json:"id" gorm:"primaryKey"`
+	AdminID           string     `json:"admin_id" gorm:"not null;index"`
+	LessonID          string     `json:"lesson_id" gorm:"index"`
+	FileType          string     `json:"file_type" gorm:"not null"` // video, audio, animation
+	FileName          string     `json:"file_name" gorm:"not null"`
+	OriginalName      string     `json:"original_name"`
+	MimeType          string     `json:"mime_type"`
+	TotalSize         int64      `json:"total_size" gorm:"not null"`
+	Offset            int64      `json:"offset" gorm:"not null;default:0"` // bytes received so far
+	ObjectName        string     `json:"object_name" gorm:"not null"`
+	MultipartUploadID string     `json:"-" gorm:"not null"`
+	Parts             JSONB      `json:"-" gorm:"type:jsonb"`                          // JSON array of {part_number, etag}
+	HashState         []byte     `json:"-" gorm:"type:bytea"`                          // serialized running SHA-256 state
+	Status            string     `json:"status" gorm:"not null;default:pending;index"` // pending, completed, aborted
+	MediaAssetID      string     `json:"media_asset_id,omitempty"`
+	ExpiresAt         time.Time  `json:"expires_at" gorm:"index"`
+	CompletedAt       *time.Time `json:"completed_at"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+
+	// Bucket of the multipart upload, empty for sessions started in the default bucket
+	Bucket string `json:"-" gorm:"size:63"`
+}
+
+// CDN prewarm triggers and states
+const (
+	CDNPrewarmTriggerPublish        = "publish"
+	CDNPrewarmTriggerMediaProcessed = "media_processed"
+	CDNPrewarmTriggerManual         = "manual"
+
+	CDNPrewarmPending   = "pending"
+	CDNPrewarmRunning   = "running"
+	CDNPrewarmCompleted = "completed"
+	CDNPrewarmFailed    = "failed" // every request failed
+)
+
+// CDNPrewarmJob requests a lesson's media and public content through the CDN from each
+// target region, so the first learners after a launch hit a warm cache
+type CDNPrewarmJob struct {
+	ID          string `json:"id" gorm:"primaryKey"`
+	LessonID    string `json:"lesson_id" gorm:"not null;size:50;index:idx_cdn_prewarm_lesson,priority:1"`
+	Trigger     string `json:"trigger" gorm:"not null;size:20"`       // publish, media_processed, manual
+	RequestedBy string `json:"requested_by,omitempty" gorm:"size:50"` // admin ID of manual runs
+	Status      string `json:"status" gorm:"not null;size:20;index"`  // pending, running, completed, failed
+	URLs        JSONB  `json:"urls" gorm:"type:jsonb"`                // JSON array of the URLs warmed
+	Regions     JSONB  `json:"regions" gorm:"type:jsonb"`             // JSON array of {region, warmed, failed}
+	Total       int    `json:"total" gorm:"not null;default:0"`       // URLs times regions
+	Warmed      int    `json:"warmed" gorm:"not null;default:0"`
+	Failed      int    `json:"failed" gorm:"not null;default:0"`
+	LastError   string `json:"last_error,omitempty" gorm:"type:text"`
+
+	CreatedAt  time.Time  `json:"created_at" gorm:"index:idx_cdn_prewarm_lesson,priority:2,sort:desc"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
 }
 
+// Where a lesson transcript comes from
+const (
+	TranscriptSourceSubtitles = "subtitles"
+	TranscriptSourceUploaded  = "uploaded"
+)
+
 // LessonMedia links lessons to their media assets
 type LessonMedia struct {
 	ID           string    `json:"id" gorm:"primaryKey"`
 	LessonID     string    `json:"lesson_id" gorm:"not null"`
 	MediaAssetID string    `json:"media_asset_id" gorm:"not null"`
-	MediaType    string    `json:"media_type"` // video, subtitle, thumbnail
+	MediaType    string    `json:"media_type"` // video, subtitle, thumbnail, audio_description
 	IsActive     bool      `json:"is_active" gorm:"default:true"`
 	CreatedAt    time.Time `json:"created_at"`
 
@@ -280,3 +992,23 @@ type LessonMedia struct {
 	Lesson     Lesson     `json:"lesson" gorm:"foreignKey:LessonID"`
 	MediaAsset MediaAsset `json:"media_asset" gorm:"foreignKey:MediaAssetID"`
 }
+
+// Question media roles
+const (
+	QuestionMediaImage = "image"
+	QuestionMediaAudio = "audio"
+)
+
+// QuestionMedia attaches a media asset to one question of a lesson. Questions are
+// stored in the lesson's JSON, so the link is keyed by lesson and question ID.
+type QuestionMedia struct {
+	ID           string    `json:"id" gorm:"primaryKey"`
+	LessonID     string    `json:"lesson_id" gorm:"not null;uniqueIndex:idx_question_media;index"`
+	QuestionID   string    `json:"question_id" gorm:"not null;uniqueIndex:idx_question_media"`
+	Role         string    `json:"role" gorm:"not null;size:20;uniqueIndex:idx_question_media"` // image, audio
+	MediaAssetID string    `json:"media_asset_id" gorm:"not null;index"`
+	CreatedAt    time.Time `json:"created_at"`
+
+	// Relationships
+	MediaAsset MediaAsset `json:"media_asset" gorm:"foreignKey:MediaAssetID"`
+}
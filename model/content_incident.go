@@ -0,0 +1,48 @@
+package model
+
+import "time"
+
+// What the kill switch pulled: a whole lesson or one of its questions
+const (
+	ContentIncidentLesson   = "lesson"
+	ContentIncidentQuestion = "question"
+)
+
+// States of a ContentIncident. Open incidents keep the content pulled until an admin
+// resolves them.
+const (
+	ContentIncidentOpen     = "open"
+	ContentIncidentResolved = "resolved"
+)
+
+// How an incident was resolved
+const (
+	ContentIncidentRestored     = "restored"      // the content is live again
+	ContentIncidentKeptDisabled = "kept_disabled" // the content stays out for good
+)
+
+// ContentIncident records an emergency takedown of shipped content, e.g. a factual
+// error, from the moment an admin pulled it until it was fixed or retired
+type ContentIncident struct {
+	ID         string `json:"id" gorm:"primaryKey"`
+	EntityType string `json:"entity_type" gorm:"size:20;not null"`
+	LessonID   string `json:"lesson_id" gorm:"not null;size:50;index"`
+	QuestionID string `json:"question_id,omitempty"`
+	// Holds a pulled question while the incident is open
+	DisabledQuestionID string `json:"disabled_question_id,omitempty"`
+	Reason             string `json:"reason" gorm:"size:1000;not null"`
+	DisabledBy         string `json:"disabled_by" gorm:"not null"`
+	// Learners with the lesson in progress when it was pulled, all of them notified
+	SessionsNotified int  `json:"sessions_notified"`
+	CachesBroadcast  bool `json:"caches_broadcast"`
+
+	Status         string     `json:"status" gorm:"size:20;not null;default:'open';index"`
+	Resolution     string     `json:"resolution,omitempty" gorm:"size:20"`
+	ResolvedBy     string     `json:"resolved_by,omitempty"`
+	ResolutionNote string     `json:"resolution_note,omitempty" gorm:"size:1000"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"index"`
+
+	// Relationship
+	Lesson Lesson `json:"-" gorm:"foreignKey:LessonID"`
+}
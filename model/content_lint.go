@@ -0,0 +1,135 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/lac-hong-legacy/ven_api/shared/text"
+)
+
+// Content lint rules
+const (
+	LintRuleReliesOnColor           = "relies_on_color"
+	LintRuleMissingCaptions         = "missing_captions"
+	LintRuleMissingAudioDescription = "missing_audio_description"
+)
+
+// Content lint severities. Warnings keep some learners from answering or following
+// the lesson, notices point at aids worth adding.
+const (
+	LintSeverityWarning = "warning"
+	LintSeverityNotice  = "notice"
+)
+
+// ContentLintIssue is an accessibility problem found in a lesson for editors to fix
+type ContentLintIssue struct {
+	Rule       string `json:"rule" example:"relies_on_color"`
+	Severity   string `json:"severity" example:"warning"`
+	Path       string `json:"path,omitempty" example:"questions[2].options"`
+	QuestionID string `json:"question_id,omitempty" example:"q3"`
+	Message    string `json:"message" example:"Options are told apart by color only"`
+}
+
+// colorWords name colors, in Vietnamese and English
+var colorWords = map[string]bool{
+	"đỏ": true, "xanh": true, "vàng": true, "tím": true, "cam": true, "hồng": true,
+	"nâu": true, "đen": true, "trắng": true, "xám": true, "lục": true, "lam": true,
+	"red": true, "green": true, "blue": true, "yellow": true, "purple": true, "orange": true,
+	"pink": true, "brown": true, "black": true, "white": true, "grey": true, "gray": true,
+}
+
+// colorQualifiers may surround a color name in an option, as in "màu xanh lá cây" or
+// "light blue"
+var colorQualifiers = map[string]bool{
+	"màu": true, "lá": true, "cây": true, "dương": true, "da": true, "trời": true,
+	"nhạt": true, "đậm": true, "light": true, "dark": true,
+}
+
+// colorNouns refer to color itself. "màu" is matched with its diacritics so "máu"
+// (blood) and "mau" (quick) are not taken for it.
+var colorNouns = map[string]bool{
+	"màu": true, "color": true, "colors": true, "colour": true, "colours": true,
+	"colored": true, "coloured": true,
+}
+
+func lintWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(text.NFC(s)), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+}
+
+// isColorOption reports whether an option is nothing but a color, like "Đỏ" or "Màu xanh lá"
+func isColorOption(option string) bool {
+	words := lintWords(option)
+	named := false
+	for _, word := range words {
+		switch {
+		case colorWords[word]:
+			named = true
+		case !colorQualifiers[word]:
+			return false
+		}
+	}
+	return named
+}
+
+// LintQuestionAccessibility flags questions that can't be answered without telling
+// colors apart: ones asking about a color, and ones with two or more color options
+func LintQuestionAccessibility(questions []Question) []ContentLintIssue {
+	var issues []ContentLintIssue
+	for i, q := range questions {
+		for _, word := range lintWords(q.Question) {
+			if colorNouns[word] {
+				issues = append(issues, ContentLintIssue{
+					Rule:       LintRuleReliesOnColor,
+					Severity:   LintSeverityWarning,
+					Path:       fmt.Sprintf("questions[%d].question", i),
+					QuestionID: q.ID,
+					Message:    "Question asks about a color; describe what it shows in words or with a label",
+				})
+				break
+			}
+		}
+
+		colorOptions := 0
+		for _, option := range q.Options {
+			if isColorOption(option) {
+				colorOptions++
+			}
+		}
+		if colorOptions >= 2 {
+			issues = append(issues, ContentLintIssue{
+				Rule:       LintRuleReliesOnColor,
+				Severity:   LintSeverityWarning,
+				Path:       fmt.Sprintf("questions[%d].options", i),
+				QuestionID: q.ID,
+				Message:    "Options are told apart by color only; name what each color stands for",
+			})
+		}
+	}
+	return issues
+}
+
+// LintLessonAccessibility flags missing accessibility aids of a lesson and the questions
+// LintQuestionAccessibility finds
+func LintLessonAccessibility(lesson *Lesson, questions []Question) []ContentLintIssue {
+	var issues []ContentLintIssue
+	if lesson.AnimationURL != "" && lesson.SubtitleURL == "" {
+		issues = append(issues, ContentLintIssue{
+			Rule:     LintRuleMissingCaptions,
+			Severity: LintSeverityWarning,
+			Path:     "subtitle_url",
+			Message:  "Lesson video has no captions for deaf and hard of hearing learners",
+		})
+	}
+	if lesson.AnimationURL != "" && lesson.AudioDescriptionURL == "" {
+		issues = append(issues, ContentLintIssue{
+			Rule:     LintRuleMissingAudioDescription,
+			Severity: LintSeverityNotice,
+			Path:     "audio_description_url",
+			Message:  "Lesson video has no audio description for blind and low-vision learners",
+		})
+	}
+	return append(issues, LintQuestionAccessibility(questions)...)
+}
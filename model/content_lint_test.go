@@ -0,0 +1,35 @@
+package model
+
+import "testing"
+
+func TestLintQuestionAccessibility(t *testing.T) {
+	questions := []Question{
+		{ID: "q1", Question: "Lá cờ của Hai Bà Trưng có màu gì?", Options: []string{"Nhiều màu", "Không có cờ"}},
+		{ID: "q2", Question: "Ô nào chỉ kinh đô Thăng Long?", Options: []string{"Ô đỏ", "Màu xanh lá", "Vàng", "Ô tím"}},
+		{ID: "q3", Question: "Ai đổ máu ở trận Bạch Đằng?", Options: []string{"Ngô Quyền", "Quân Nam Hán"}},
+		{ID: "q4", Question: "Which metal did the Đông Sơn drums use?", Options: []string{"Gold", "Bronze", "Light blue"}},
+	}
+
+	issues := LintQuestionAccessibility(questions)
+	if len(issues) != 2 {
+		t.Fatalf("issues = %+v", issues)
+	}
+	if issues[0].QuestionID != "q1" || issues[0].Path != "questions[0].question" {
+		t.Errorf("question about a color flagged as %+v", issues[0])
+	}
+	if issues[1].QuestionID != "q2" || issues[1].Path != "questions[1].options" || issues[1].Rule != LintRuleReliesOnColor {
+		t.Errorf("color options flagged as %+v", issues[1])
+	}
+}
+
+func TestLintLessonAccessibility(t *testing.T) {
+	lesson := &Lesson{AnimationURL: "https://cdn.example.com/cdn/a.mp4", SubtitleURL: "https://cdn.example.com/cdn/a.vtt"}
+	issues := LintLessonAccessibility(lesson, nil)
+	if len(issues) != 1 || issues[0].Rule != LintRuleMissingAudioDescription || issues[0].Severity != LintSeverityNotice {
+		t.Errorf("issues = %+v", issues)
+	}
+
+	if issues := LintLessonAccessibility(&Lesson{}, nil); len(issues) != 0 {
+		t.Errorf("lesson without video got %+v", issues)
+	}
+}
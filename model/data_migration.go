@@ -0,0 +1,9 @@
+package model
+
+import "time"
+
+// DataMigration marks a one-off data migration as applied, so later starts skip it
+type DataMigration struct {
+	Name      string    `json:"name" gorm:"primaryKey;size:100"`
+	AppliedAt time.Time `json:"applied_at"`
+}
@@ -0,0 +1,95 @@
+package model
+
+import "time"
+
+// GameConfigID is the primary key of the single GameConfig row
+const GameConfigID = "default"
+
+// Speed check modes
+const (
+	SpeedCheckOff    = "off"
+	SpeedCheckFlag   = "flag"   // accept the completion and queue the user for review
+	SpeedCheckReject = "reject" // refuse the completion and queue the user for review
+)
+
+// GameConfig holds game balance settings admins can tune without a deploy
+type GameConfig struct {
+	ID string `json:"id" gorm:"primaryKey"`
+
+	// Comeback bonus for users returning after a break
+	ComebackInactiveDays   int     `json:"comeback_inactive_days" gorm:"not null;default:7"`
+	ComebackXPMultiplier   float64 `json:"comeback_xp_multiplier" gorm:"not null;default:1.5"`
+	ComebackDurationHours  int     `json:"comeback_duration_hours" gorm:"not null;default:48"`
+	ComebackRestoresHearts bool    `json:"comeback_restores_hearts" gorm:"not null;default:true"`
+
+	// Suspicious completion speed detection. A lesson needs at least MinVideoWatchRatio
+	// of its video plus MinSecondsPerQuestion per question.
+	SpeedCheckMode        string  `json:"speed_check_mode" gorm:"size:10;not null;default:'flag'"` // off, flag, reject
+	MinVideoWatchRatio    float64 `json:"min_video_watch_ratio" gorm:"not null;default:0.5"`
+	MinSecondsPerQuestion int     `json:"min_seconds_per_question" gorm:"not null;default:4"`
+	BurstWindowMinutes    int     `json:"burst_window_minutes" gorm:"not null;default:10"`
+	BurstMaxCompletions   int     `json:"burst_max_completions" gorm:"not null;default:6"`
+
+	// Multipliers of question points by category when lessons are scored
+	KnowledgeWeight     float64 `json:"knowledge_weight" gorm:"not null;default:1"`
+	ComprehensionWeight float64 `json:"comprehension_weight" gorm:"not null;default:1.5"`
+
+	// Share of a lesson's video a user must watch to earn the score bonus XP
+	BonusMinWatchPercent int `json:"bonus_min_watch_percent" gorm:"not null;default:80"`
+
+	// Leaderboard anomaly detection: earning more XP than this in a day or an hour
+	// quarantines the user from rankings until an admin reviews it
+	AnomalyMaxDailyXP  int `json:"anomaly_max_daily_xp" gorm:"not null;default:3000"`
+	AnomalyMaxHourlyXP int `json:"anomaly_max_hourly_xp" gorm:"not null;default:1000"`
+
+	// Coins earned for the first completion of a lesson and for each achievement tier
+	CoinsPerLesson          int `json:"coins_per_lesson" gorm:"not null;default:10"`
+	CoinsPerAchievementTier int `json:"coins_per_achievement_tier" gorm:"not null;default:25"`
+
+	// Fifty-fifty aids a learner may use per lesson attempt, and their price when paid
+	// with coins instead of half a heart
+	FiftyFiftyPerLesson int `json:"fifty_fifty_per_lesson" gorm:"not null;default:2"`
+	FiftyFiftyCoinPrice int `json:"fifty_fifty_coin_price" gorm:"not null;default:15"`
+
+	// Learner reports that take a question out of its lesson until an admin reviews
+	// it; 0 never disables questions
+	QuestionReportThreshold int `json:"question_report_threshold" gorm:"not null;default:5"`
+
+	UpdatedBy string    `json:"updated_by,omitempty" gorm:"size:50"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DefaultGameConfig returns the settings used until an admin changes them
+func DefaultGameConfig() GameConfig {
+	return GameConfig{
+		ID:                      GameConfigID,
+		ComebackInactiveDays:    7,
+		ComebackXPMultiplier:    1.5,
+		ComebackDurationHours:   48,
+		ComebackRestoresHearts:  true,
+		SpeedCheckMode:          SpeedCheckFlag,
+		MinVideoWatchRatio:      0.5,
+		MinSecondsPerQuestion:   4,
+		BurstWindowMinutes:      10,
+		BurstMaxCompletions:     6,
+		KnowledgeWeight:         1,
+		ComprehensionWeight:     1.5,
+		BonusMinWatchPercent:    80,
+		AnomalyMaxDailyXP:       3000,
+		AnomalyMaxHourlyXP:      1000,
+		CoinsPerLesson:          10,
+		CoinsPerAchievementTier: 25,
+		FiftyFiftyPerLesson:     2,
+		FiftyFiftyCoinPrice:     15,
+		QuestionReportThreshold: 5,
+	}
+}
+
+// CategoryWeight returns the multiplier for points of questions in a category
+func (c *GameConfig) CategoryWeight(category string) float64 {
+	if category == QuestionCategoryComprehension {
+		return c.ComprehensionWeight
+	}
+	return c.KnowledgeWeight
+}
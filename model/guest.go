@@ -30,13 +30,47 @@ type GuestProgress struct {
 }
 
 type GuestLessonAttempt struct {
-	ID             string    `json:"id" gorm:"primaryKey"`
-	GuestSessionID string    `json:"guest_session_id" gorm:"not null"`
-	LessonID       string    `json:"lesson_id" gorm:"not null"`
-	IsCompleted    bool      `json:"is_completed" gorm:"not null"`
-	Score          int       `json:"score" gorm:"not null"`
-	TimeSpent      int       `json:"time_spent" gorm:"not null"` // in seconds
-	AttemptsCount  int       `json:"attempts_count" gorm:"not null"`
-	CreatedAt      time.Time `json:"created_at" gorm:"not null"`
-	UpdatedAt      time.Time `json:"updated_at" gorm:"not null"`
+	ID             string `json:"id" gorm:"primaryKey"`
+	GuestSessionID string `json:"guest_session_id" gorm:"not null"`
+	LessonID       string `json:"lesson_id" gorm:"not null"`
+	IsCompleted    bool   `json:"is_completed" gorm:"not null"`
+	Score          int    `json:"score" gorm:"not null"`
+	TimeSpent      int    `json:"time_spent" gorm:"not null"` // in seconds
+	AttemptsCount  int    `json:"attempts_count" gorm:"not null"`
+	// Where the attempt came from, counted against the daily guest quota
+	DeviceID  string    `json:"device_id,omitempty" gorm:"size:100;index"`
+	IPAddress string    `json:"ip_address,omitempty" gorm:"size:45;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"not null;index"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"not null"`
+}
+
+// Device attestation verdicts
+const (
+	AttestationVerified    = "verified"
+	AttestationFailed      = "failed"
+	AttestationMissing     = "missing"
+	AttestationUnavailable = "unavailable" // provider not configured or unreachable
+)
+
+const (
+	AttestationPlatformAndroid = "android"
+	AttestationPlatformIOS     = "ios"
+)
+
+// DeviceAttestation keeps the latest Play Integrity / App Attest outcome for a guest
+// device. For iOS it also holds the App Attest key used to check later assertions.
+type DeviceAttestation struct {
+	ID             string     `json:"id" gorm:"primaryKey"`
+	DeviceID       string     `json:"device_id" gorm:"not null;uniqueIndex;size:100"`
+	Platform       string     `json:"platform" gorm:"size:10"`
+	KeyID          string     `json:"key_id,omitempty" gorm:"size:100"`
+	PublicKey      []byte     `json:"-" gorm:"type:bytea"` // PKIX DER, iOS only
+	SignCount      uint32     `json:"-"`
+	LastVerdict    string     `json:"last_verdict" gorm:"size:20;index"`
+	LastReason     string     `json:"last_reason,omitempty" gorm:"size:255"`
+	LastPurpose    string     `json:"last_purpose,omitempty" gorm:"size:20"`
+	FailureCount   int        `json:"failure_count" gorm:"not null;default:0"`
+	LastVerifiedAt *time.Time `json:"last_verified_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
 }
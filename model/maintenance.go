@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// Maintenance window states
+const (
+	MaintenanceWindowScheduled = "scheduled"
+	MaintenanceWindowActive    = "active"
+	MaintenanceWindowCompleted = "completed"
+	MaintenanceWindowCancelled = "cancelled"
+)
+
+// MaintenanceWindow is planned downtime. Learners are told about it through an
+// announcement when it is scheduled, and maintenance mode is switched on and off
+// automatically at its start and end.
+type MaintenanceWindow struct {
+	ID       string    `json:"id" gorm:"primaryKey"`
+	StartsAt time.Time `json:"starts_at" gorm:"not null;index"`
+	EndsAt   time.Time `json:"ends_at" gorm:"not null"`
+	Status   string    `json:"status" gorm:"not null;size:20;default:'scheduled';index"`
+
+	// Shown to learners while the window is active, per locale. Empty uses the default text.
+	MessageVI string `json:"message_vi,omitempty" gorm:"type:text"`
+	MessageEN string `json:"message_en,omitempty" gorm:"type:text"`
+
+	AnnouncedAt *time.Time `json:"announced_at,omitempty"`
+	CreatedBy   string     `json:"created_by" gorm:"size:50"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// Places user text is moderated. Each can be given its own action.
+const (
+	ModerationContextUsername       = "username"
+	ModerationContextSpiritName     = "spirit_name"
+	ModerationContextLessonFeedback = "lesson_feedback"
+	ModerationContextChat           = "chat"
+)
+
+// Actions taken on user text that fails moderation
+const (
+	ModerationActionReject = "reject"
+	ModerationActionMask   = "mask"
+	ModerationActionFlag   = "flag"
+)
+
+// ModerationFlag records user text that was let through for an admin to review. Pending
+// flags form the moderation review queue; review states are the FlagStatus values.
+type ModerationFlag struct {
+	ID         string     `json:"id" gorm:"primaryKey"`
+	UserID     string     `json:"user_id" gorm:"not null;index"`
+	Context    string     `json:"context" gorm:"size:30;not null"`
+	Text       string     `json:"text" gorm:"type:text"`
+	Reasons    string     `json:"reasons" gorm:"size:100"` // comma separated match kinds
+	Status     string     `json:"status" gorm:"size:20;not null;default:'pending';index"`
+	ReviewedBy string     `json:"reviewed_by,omitempty"`
+	ReviewNote string     `json:"review_note,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"index"`
+
+	// Relationship
+	User User `json:"user" gorm:"foreignKey:UserID"`
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	NotificationTypeAchievement   = "achievement"
+	NotificationTypeFriendRequest = "friend_request"
+	NotificationTypeAnnouncement  = "announcement"
+	NotificationTypeReward        = "reward"
+	NotificationTypeSecurity      = "security"
+	NotificationTypeReminder      = "reminder"
+	NotificationTypeContent       = "content"
+)
+
+// Notification is a persistent inbox entry so users can catch up on missed push messages
+type Notification struct {
+	ID        string          `json:"id" gorm:"primaryKey"`
+	UserID    string          `json:"user_id" gorm:"not null;index:idx_notification_user_read"`
+	Type      string          `json:"type" gorm:"not null;size:30"` // achievement, friend_request, announcement, reward, security, reminder
+	Title     string          `json:"title" gorm:"not null"`
+	Body      string          `json:"body" gorm:"type:text"`
+	Data      json.RawMessage `json:"data,omitempty" gorm:"type:jsonb"` // type-specific payload, e.g. {"character_id": "..."}
+	IsRead    bool            `json:"is_read" gorm:"default:false;index:idx_notification_user_read"`
+	ReadAt    *time.Time      `json:"read_at"`
+	CreatedAt time.Time       `json:"created_at" gorm:"index"`
+}
+
+// StudyReminderDays names the days of a reminder schedule, indexed by time.Weekday
+var StudyReminderDays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// StudyReminder is a user's study reminder schedule. Reminders go out at TimeOfDay
+// ("15:04") in the user's timezone on the days set in Days, a bit set indexed by
+// time.Weekday, and are skipped on days the user already completed a lesson.
+type StudyReminder struct {
+	ID        string `json:"id" gorm:"primaryKey"`
+	UserID    string `json:"user_id" gorm:"not null;uniqueIndex"`
+	Days      int    `json:"days" gorm:"not null"`
+	TimeOfDay string `json:"time_of_day" gorm:"size:5;not null"`
+	Push      bool   `json:"push" gorm:"default:true;not null"`
+	Email     bool   `json:"email" gorm:"default:false;not null"`
+	Enabled   bool   `json:"enabled" gorm:"default:true;not null"`
+
+	// When the scheduler sends the next reminder, a snooze included. Nil while disabled.
+	NextReminderAt *time.Time `json:"next_reminder_at" gorm:"index"`
+	SnoozedUntil   *time.Time `json:"snoozed_until"`
+	LastSentAt     *time.Time `json:"last_sent_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Relationship
+	User User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// StudyReminderDayMask turns day names into a StudyReminder.Days bit set. Unknown names
+// are ignored.
+func StudyReminderDayMask(days []string) int {
+	mask := 0
+	for _, day := range days {
+		for weekday, name := range StudyReminderDays {
+			if day == name {
+				mask |= 1 << weekday
+			}
+		}
+	}
+	return mask
+}
+
+// DayNames lists the reminder days from Sunday to Saturday
+func (r *StudyReminder) DayNames() []string {
+	names := make([]string, 0, len(StudyReminderDays))
+	for weekday, name := range StudyReminderDays {
+		if r.Days&(1<<weekday) != 0 {
+			names = append(names, name)
+		}
+	}
+	return names
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Outbox message states
+const (
+	OutboxStatusPending = "pending"
+	OutboxStatusSent    = "sent"
+	OutboxStatusFailed  = "failed" // gave up after the last retry
+)
+
+// OutboxMessage is an email, audit entry or domain event written in the same transaction
+// as the state change that caused it, then dispatched by the outbox relay. A message is
+// dispatched at least once, so handlers must tolerate the occasional repeat.
+type OutboxMessage struct {
+	ID            string          `json:"id" gorm:"primaryKey"`
+	Topic         string          `json:"topic" gorm:"not null;size:60"`
+	Payload       json.RawMessage `json:"payload" gorm:"type:jsonb;not null"`
+	Status        string          `json:"status" gorm:"not null;size:20;index:idx_outbox_due,priority:1"`
+	Attempts      int             `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt time.Time       `json:"next_attempt_at" gorm:"index:idx_outbox_due,priority:2"`
+	LastError     string          `json:"last_error,omitempty" gorm:"size:500"`
+	SentAt        *time.Time      `json:"sent_at,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
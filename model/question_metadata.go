@@ -0,0 +1,303 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// MetadataSchema is the subset of JSON Schema used to describe question metadata. It
+// marshals to a regular JSON Schema document.
+type MetadataSchema struct {
+	Type        string                     `json:"type,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	Properties  map[string]*MetadataSchema `json:"properties,omitempty"`
+	Required    []string                   `json:"required,omitempty"`
+	// false or a *MetadataSchema every other property must match; nil allows anything
+	AdditionalProperties interface{}     `json:"additionalProperties,omitempty"`
+	MinProperties        int             `json:"minProperties,omitempty"`
+	Enum                 []string        `json:"enum,omitempty"`
+	MinLength            int             `json:"minLength,omitempty"`
+	MaxLength            int             `json:"maxLength,omitempty"`
+	Items                *MetadataSchema `json:"items,omitempty"`
+	MaxItems             int             `json:"maxItems,omitempty"`
+	Minimum              *int            `json:"minimum,omitempty"`
+	Maximum              *int            `json:"maximum,omitempty"`
+}
+
+// QuestionMetadataError points at the part of a question that doesn't match its schema
+type QuestionMetadataError struct {
+	Path    string `json:"path" example:"questions[2].metadata.pairs"`
+	Message string `json:"message" example:"is required"`
+}
+
+func (e QuestionMetadataError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// Question categories, weighted separately when a lesson is scored
+const (
+	QuestionCategoryKnowledge     = "knowledge"     // recalling facts from the story
+	QuestionCategoryComprehension = "comprehension" // explaining causes, meaning or consequences
+)
+
+// metadataCommonProperties are allowed on every question type
+var metadataCommonProperties = map[string]*MetadataSchema{
+	"explanation": {Type: "string", Description: "Shown after the question is answered", MaxLength: 2000},
+	"hint":        {Type: "string", Description: "Shown on request before answering", MaxLength: 500},
+	"category": {
+		Type:        "string",
+		Description: "Weighting category used when the lesson is scored, knowledge when not set",
+		Enum:        []string{QuestionCategoryKnowledge, QuestionCategoryComprehension},
+	},
+}
+
+// metadataPartialCreditProperties are allowed on the types that earn a share of their
+// points per right item
+var metadataPartialCreditProperties = map[string]*MetadataSchema{
+	"all_or_nothing": {Type: "boolean", Description: "Give no points unless every item is right"},
+}
+
+func metadataProperties(extra ...map[string]*MetadataSchema) map[string]*MetadataSchema {
+	properties := make(map[string]*MetadataSchema, len(metadataCommonProperties))
+	for name, schema := range metadataCommonProperties {
+		properties[name] = schema
+	}
+	for _, set := range extra {
+		for name, schema := range set {
+			properties[name] = schema
+		}
+	}
+	return properties
+}
+
+// QuestionMetadataSchemas holds the metadata schema of every question type the grader
+// supports. Questions of other types are rejected.
+var QuestionMetadataSchemas = map[string]*MetadataSchema{
+	"multiple_choice": {
+		Type:                 "object",
+		Properties:           metadataProperties(),
+		AdditionalProperties: false,
+	},
+	"fill_blank": {
+		Type: "object",
+		Properties: metadataProperties(map[string]*MetadataSchema{
+			"accept_without_diacritics": {
+				Type:        "boolean",
+				Description: "Also accept the answer typed without Vietnamese diacritics, e.g. \"Thang Long\" for \"Thăng Long\"",
+			},
+			"accepted_answers": {
+				Type:        "array",
+				Description: "Other answers graded as correct, e.g. \"Ngô Vương\" for \"Ngô Quyền\"",
+				Items:       &MetadataSchema{Type: "string", MinLength: 1, MaxLength: 200},
+				MaxItems:    20,
+			},
+			"max_typos": {
+				Type:        "integer",
+				Description: "Letters that may be missing, extra or wrong in an answer. Digits must always be right.",
+				Minimum:     intPtr(0),
+				Maximum:     intPtr(MaxFillBlankTypos),
+			},
+		}),
+		AdditionalProperties: false,
+	},
+	"drag_drop": {
+		Type:                 "object",
+		Properties:           metadataProperties(metadataPartialCreditProperties),
+		AdditionalProperties: false,
+	},
+	"connect": {
+		Type: "object",
+		Properties: metadataProperties(metadataPartialCreditProperties, map[string]*MetadataSchema{
+			"pairs": {
+				Type:                 "object",
+				Description:          "Each left item mapped to the right item it connects to",
+				MinProperties:        2,
+				AdditionalProperties: &MetadataSchema{Type: "string", MinLength: 1},
+			},
+		}),
+		Required:             []string{"pairs"},
+		AdditionalProperties: false,
+	},
+}
+
+// Category returns the weighting category of the question
+func (q *Question) Category() string {
+	if category, _ := q.Metadata["category"].(string); category != "" {
+		return category
+	}
+	return QuestionCategoryKnowledge
+}
+
+// AllOrNothing reports whether a partial credit question was set to give no points
+// unless every item is right
+func (q *Question) AllOrNothing() bool {
+	allOrNothing, _ := q.Metadata["all_or_nothing"].(bool)
+	return allOrNothing
+}
+
+// MaxFillBlankTypos caps the typo tolerance editors can give a fill_blank question
+const MaxFillBlankTypos = 3
+
+func intPtr(v int) *int {
+	return &v
+}
+
+// FillBlankGrading holds the grading options of a fill_blank question's metadata
+type FillBlankGrading struct {
+	AcceptWithoutDiacritics bool     `json:"accept_without_diacritics"`
+	AcceptedAnswers         []string `json:"accepted_answers"`
+	MaxTypos                int      `json:"max_typos"`
+}
+
+// FillBlankGrading reads the grading options from the metadata. Metadata that doesn't
+// decode grades strictly.
+func (q *Question) FillBlankGrading() FillBlankGrading {
+	var grading FillBlankGrading
+	if len(q.Metadata) == 0 {
+		return grading
+	}
+
+	// Round trip through JSON so typed Go values (seeds) read like request bodies
+	encoded, err := json.Marshal(q.Metadata)
+	if err != nil {
+		return grading
+	}
+	if err := json.Unmarshal(encoded, &grading); err != nil {
+		return FillBlankGrading{}
+	}
+	grading.MaxTypos = min(max(grading.MaxTypos, 0), MaxFillBlankTypos)
+	return grading
+}
+
+// ValidateMetadata checks the metadata of a question against the schema of its type
+func (q *Question) ValidateMetadata() []QuestionMetadataError {
+	schema, ok := QuestionMetadataSchemas[q.Type]
+	if !ok {
+		return []QuestionMetadataError{{Path: "type", Message: fmt.Sprintf("unknown question type %q", q.Type)}}
+	}
+
+	// Round trip through JSON so typed Go values (seeds) are checked like request bodies
+	var metadata interface{} = map[string]interface{}{}
+	if q.Metadata != nil {
+		encoded, err := json.Marshal(q.Metadata)
+		if err != nil {
+			return []QuestionMetadataError{{Path: "metadata", Message: "is not valid JSON"}}
+		}
+		if err := json.Unmarshal(encoded, &metadata); err != nil {
+			return []QuestionMetadataError{{Path: "metadata", Message: "is not valid JSON"}}
+		}
+	}
+
+	var errs []QuestionMetadataError
+	schema.validate("metadata", metadata, &errs)
+	return errs
+}
+
+// ValidateQuestions checks the metadata of every question, with paths starting at
+// questions[i]
+func ValidateQuestions(questions []Question) []QuestionMetadataError {
+	var errs []QuestionMetadataError
+	for i := range questions {
+		for _, err := range questions[i].ValidateMetadata() {
+			err.Path = fmt.Sprintf("questions[%d].%s", i, err.Path)
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+func (s *MetadataSchema) validate(path string, value interface{}, errs *[]QuestionMetadataError) {
+	fail := func(path, format string, args ...interface{}) {
+		*errs = append(*errs, QuestionMetadataError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			fail(path, "must be an object")
+			return
+		}
+		for _, name := range s.Required {
+			if _, ok := object[name]; !ok {
+				fail(path+"."+name, "is required")
+			}
+		}
+		if len(object) < s.MinProperties {
+			fail(path, "must have at least %d entries", s.MinProperties)
+		}
+
+		names := make([]string, 0, len(object))
+		for name := range object {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			if property, ok := s.Properties[name]; ok {
+				property.validate(path+"."+name, object[name], errs)
+				continue
+			}
+			switch additional := s.AdditionalProperties.(type) {
+			case bool:
+				if !additional {
+					fail(path+"."+name, "is not allowed")
+				}
+			case *MetadataSchema:
+				additional.validate(path+"["+strconv.Quote(name)+"]", object[name], errs)
+			}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail(path, "must be true or false")
+		}
+	case "integer":
+		number, ok := value.(float64)
+		if !ok || number != math.Trunc(number) {
+			fail(path, "must be a whole number")
+			return
+		}
+		if s.Minimum != nil && number < float64(*s.Minimum) {
+			fail(path, "must be at least %d", *s.Minimum)
+		}
+		if s.Maximum != nil && number > float64(*s.Maximum) {
+			fail(path, "must be at most %d", *s.Maximum)
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			fail(path, "must be an array")
+			return
+		}
+		if s.MaxItems > 0 && len(items) > s.MaxItems {
+			fail(path, "must have at most %d items", s.MaxItems)
+		}
+		if s.Items != nil {
+			for i, item := range items {
+				s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, errs)
+			}
+		}
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			fail(path, "must be a string")
+			return
+		}
+		if len(s.Enum) > 0 && !slices.Contains(s.Enum, text) {
+			fail(path, "must be one of: %s", strings.Join(s.Enum, ", "))
+		}
+		length := utf8.RuneCountInString(text)
+		if length < s.MinLength {
+			fail(path, "must be at least %d characters", s.MinLength)
+		}
+		if s.MaxLength > 0 && length > s.MaxLength {
+			fail(path, "must be at most %d characters", s.MaxLength)
+		}
+	}
+}
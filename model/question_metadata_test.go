@@ -0,0 +1,121 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateQuestionsMetadata(t *testing.T) {
+	pairs := map[string]string{"Văn Lang": "Vương quốc đầu tiên", "Hùng Vương": "Vua đầu tiên"}
+
+	tests := []struct {
+		name     string
+		question Question
+		want     []string
+	}{
+		{
+			name:     "multiple choice without metadata",
+			question: Question{Type: "multiple_choice"},
+		},
+		{
+			name:     "connect with typed pairs",
+			question: Question{Type: "connect", Metadata: map[string]interface{}{"pairs": pairs, "hint": "Think of the first kings"}},
+		},
+		{
+			name:     "unknown type",
+			question: Question{Type: "matching"},
+			want:     []string{"questions[0].type"},
+		},
+		{
+			name:     "connect without pairs",
+			question: Question{Type: "connect"},
+			want:     []string{"questions[0].metadata.pairs"},
+		},
+		{
+			name:     "connect pairs not an object",
+			question: Question{Type: "connect", Metadata: map[string]interface{}{"pairs": []string{"Văn Lang"}}},
+			want:     []string{"questions[0].metadata.pairs"},
+		},
+		{
+			name:     "connect pair with empty and non-string targets",
+			question: Question{Type: "connect", Metadata: map[string]interface{}{"pairs": map[string]interface{}{"Văn Lang": "", "Hùng Vương": 1}}},
+			want:     []string{`questions[0].metadata.pairs["Hùng Vương"]`, `questions[0].metadata.pairs["Văn Lang"]`},
+		},
+		{
+			name:     "fill blank accepting answers without diacritics",
+			question: Question{Type: "fill_blank", Metadata: map[string]interface{}{"accept_without_diacritics": true}},
+		},
+		{
+			name:     "fill blank diacritics flag not a boolean",
+			question: Question{Type: "fill_blank", Metadata: map[string]interface{}{"accept_without_diacritics": "yes"}},
+			want:     []string{"questions[0].metadata.accept_without_diacritics"},
+		},
+		{
+			name: "fill blank with lenient grading",
+			question: Question{Type: "fill_blank", Metadata: map[string]interface{}{
+				"accepted_answers": []string{"Ngô Vương"}, "max_typos": 2,
+			}},
+		},
+		{
+			name: "fill blank with invalid lenient grading",
+			question: Question{Type: "fill_blank", Metadata: map[string]interface{}{
+				"accepted_answers": []interface{}{"Ngô Vương", ""}, "max_typos": 1.5,
+			}},
+			want: []string{"questions[0].metadata.accepted_answers[1]", "questions[0].metadata.max_typos"},
+		},
+		{
+			name:     "fill blank typos over the cap",
+			question: Question{Type: "fill_blank", Metadata: map[string]interface{}{"max_typos": 4}},
+			want:     []string{"questions[0].metadata.max_typos"},
+		},
+		{
+			name:     "drag drop graded all or nothing as comprehension",
+			question: Question{Type: "drag_drop", Metadata: map[string]interface{}{"all_or_nothing": true, "category": "comprehension"}},
+		},
+		{
+			name:     "unknown category",
+			question: Question{Type: "multiple_choice", Metadata: map[string]interface{}{"category": "analysis"}},
+			want:     []string{"questions[0].metadata.category"},
+		},
+		{
+			name:     "all or nothing on a type without partial credit",
+			question: Question{Type: "multiple_choice", Metadata: map[string]interface{}{"all_or_nothing": true}},
+			want:     []string{"questions[0].metadata.all_or_nothing"},
+		},
+		{
+			name:     "unknown property",
+			question: Question{Type: "fill_blank", Metadata: map[string]interface{}{"pairs": pairs}},
+			want:     []string{"questions[0].metadata.pairs"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, err := range ValidateQuestions([]Question{tt.question}) {
+				got = append(got, err.Path)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("error paths %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFillBlankGrading(t *testing.T) {
+	question := Question{Type: "fill_blank", Metadata: map[string]interface{}{
+		"accept_without_diacritics": true,
+		"accepted_answers":          []string{"Ngô Vương"},
+		"max_typos":                 9,
+	}}
+
+	want := FillBlankGrading{AcceptWithoutDiacritics: true, AcceptedAnswers: []string{"Ngô Vương"}, MaxTypos: MaxFillBlankTypos}
+	if got := question.FillBlankGrading(); !reflect.DeepEqual(got, want) {
+		t.Errorf("FillBlankGrading() = %+v, want %+v", got, want)
+	}
+
+	question.Metadata = map[string]interface{}{"max_typos": "two"}
+	if got := question.FillBlankGrading(); !reflect.DeepEqual(got, FillBlankGrading{}) {
+		t.Errorf("FillBlankGrading() of invalid metadata = %+v, want strict grading", got)
+	}
+}
@@ -0,0 +1,84 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Reasons learners give when reporting a question
+const (
+	QuestionReportWrongAnswer = "wrong_answer"
+	QuestionReportConfusing   = "confusing"
+	QuestionReportTypo        = "typo"
+	QuestionReportOffensive   = "offensive"
+	QuestionReportOther       = "other"
+)
+
+// Review states of a QuestionReport. Pending reports count towards disabling the
+// question; reviewing the question closes them.
+const (
+	QuestionReportPending  = "pending"
+	QuestionReportReviewed = "reviewed"
+)
+
+// QuestionReport is a learner flagging a question as wrong or confusing. Each learner
+// can report a question once.
+type QuestionReport struct {
+	ID         string    `json:"id" gorm:"primaryKey"`
+	LessonID   string    `json:"lesson_id" gorm:"not null;size:50;uniqueIndex:idx_question_report_user,priority:1"`
+	QuestionID string    `json:"question_id" gorm:"not null;uniqueIndex:idx_question_report_user,priority:2"`
+	UserID     string    `json:"user_id" gorm:"not null;uniqueIndex:idx_question_report_user,priority:3;index"`
+	Reason     string    `json:"reason" gorm:"size:20;not null"`
+	Comment    string    `json:"comment,omitempty" gorm:"size:500"`
+	Status     string    `json:"status" gorm:"size:20;not null;default:'pending';index"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// QuestionReportCount is the number of reports of one question for one reason
+type QuestionReportCount struct {
+	QuestionID string
+	Reason     string
+	Status     string
+	Count      int
+}
+
+// QuestionAnswerCount is how often one question of a lesson was answered, and how often
+// correctly
+type QuestionAnswerCount struct {
+	QuestionID string
+	Answers    int
+	Correct    int
+}
+
+// Why a question was taken out of its lesson
+const (
+	QuestionDisabledByReports    = "reports"
+	QuestionDisabledByKillSwitch = "kill_switch"
+)
+
+// Review states of a DisabledQuestion
+const (
+	DisabledQuestionPending  = "pending"
+	DisabledQuestionRestored = "restored" // put back in the lesson
+	DisabledQuestionRemoved  = "removed"  // left out for good
+)
+
+// DisabledQuestion holds a question taken out of its lesson until an admin reviews it.
+// Learners never see it meanwhile, and it doesn't count towards lesson scores.
+type DisabledQuestion struct {
+	ID         string          `json:"id" gorm:"primaryKey"`
+	LessonID   string          `json:"lesson_id" gorm:"not null;size:50;index"`
+	QuestionID string          `json:"question_id" gorm:"not null"`
+	Position   int             `json:"position"` // index in the lesson's questions, used when restoring
+	Question   json.RawMessage `json:"question" gorm:"type:jsonb"`
+	Cause      string          `json:"cause" gorm:"size:20;not null"`
+	Reports    int             `json:"reports"` // pending reports when it was disabled
+	Status     string          `json:"status" gorm:"size:20;not null;default:'pending';index"`
+	ReviewedBy string          `json:"reviewed_by,omitempty"`
+	ReviewNote string          `json:"review_note,omitempty"`
+	ReviewedAt *time.Time      `json:"reviewed_at,omitempty"`
+	CreatedAt  time.Time       `json:"created_at" gorm:"index"`
+
+	// Relationship
+	Lesson Lesson `json:"-" gorm:"foreignKey:LessonID"`
+}
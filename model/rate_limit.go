@@ -4,13 +4,16 @@ import "time"
 
 type RateLimit struct {
 	ID           string     `json:"id" gorm:"primaryKey;type:text;not null"`
-	Identifier   string     `json:"identifier" gorm:"not null;index;size:255"`
-	EndpointType string     `json:"endpoint_type" gorm:"not null;size:50"`
+	Identifier   string     `json:"identifier" gorm:"not null;index;index:idx_rate_limits_lookup,priority:1;size:255"`
+	EndpointType string     `json:"endpoint_type" gorm:"not null;index:idx_rate_limits_lookup,priority:2;size:50"`
 	RequestCount int        `json:"request_count" gorm:"default:0;not null"`
 	WindowStart  time.Time  `json:"window_start" gorm:"not null"`
 	BlockedUntil *time.Time `json:"blocked_until,omitempty" gorm:"index"`
 	CreatedAt    time.Time  `json:"created_at" gorm:"not null"`
 	UpdatedAt    time.Time  `json:"updated_at" gorm:"not null"`
+
+	// Requests in the window before WindowStart, weighted into the sliding window
+	PreviousCount int `json:"previous_count" gorm:"default:0;not null"`
 }
 
 type RateLimitConfig struct {
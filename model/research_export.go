@@ -0,0 +1,89 @@
+package model
+
+import "time"
+
+// Research export states
+const (
+	ResearchExportPending   = "pending"
+	ResearchExportRunning   = "running"
+	ResearchExportCompleted = "completed"
+	ResearchExportFailed    = "failed"
+	ResearchExportExpired   = "expired"
+)
+
+// Actions recorded in the access log of a research export
+const (
+	ResearchExportActionRequested = "requested"
+	ResearchExportActionDownload  = "download_link"
+	ResearchExportActionExpired   = "expired"
+)
+
+// ResearchExport is an anonymized cohort dataset prepared for a research partner. It
+// holds only cohort-level counts; groups smaller than MinCohortSize are suppressed so no
+// row or cell describes fewer than that many learners.
+type ResearchExport struct {
+	ID          string `json:"id" gorm:"primaryKey"`
+	RequestedBy string `json:"requested_by" gorm:"not null;size:50;index"`
+	Partner     string `json:"partner" gorm:"not null;size:100"`
+	Purpose     string `json:"purpose" gorm:"not null;type:text"`
+
+	// Learners who registered in [CohortFrom, CohortTo) are included, grouped by month
+	CohortFrom    time.Time `json:"cohort_from" gorm:"type:date;not null"`
+	CohortTo      time.Time `json:"cohort_to" gorm:"type:date;not null"`
+	MinCohortSize int       `json:"min_cohort_size" gorm:"not null"`
+
+	Status string `json:"status" gorm:"not null;size:20;index"`
+	// Cohorts and dynasties in the export, and those left out for being too small
+	Rows           int `json:"rows" gorm:"not null;default:0"`
+	SuppressedRows int `json:"suppressed_rows" gorm:"not null;default:0"`
+	// SHA-256 of the archive, so partners can check what they received
+	Checksum   string `json:"checksum,omitempty" gorm:"size:64"`
+	Size       int64  `json:"size" gorm:"not null;default:0"`
+	ObjectName string `json:"-" gorm:"size:255"`
+	Error      string `json:"error,omitempty" gorm:"type:text"`
+
+	CreatedAt   time.Time  `json:"created_at" gorm:"index"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	// The archive is deleted from storage after this time
+	ExpiresAt *time.Time `json:"expires_at,omitempty" gorm:"index"`
+}
+
+// ResearchExportAccess is one entry of the audit trail of a research export: the
+// request, every download link handed out and the removal of the archive
+type ResearchExportAccess struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	ExportID  string    `json:"export_id" gorm:"not null;size:50;index"`
+	AdminID   string    `json:"admin_id,omitempty" gorm:"size:50;index"` // empty for the expiry job
+	Action    string    `json:"action" gorm:"not null;size:20"`
+	IPAddress string    `json:"ip_address,omitempty" gorm:"size:45"`
+	UserAgent string    `json:"user_agent,omitempty" gorm:"size:255"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// CohortActivityRow is the registration-month cohort aggregate a research export is built
+// from. Retained counts only consider learners registered at least that many days ago,
+// which EligibleDayN counts.
+type CohortActivityRow struct {
+	Cohort         string
+	Learners       int64
+	ActiveLearners int64 // completed at least one lesson
+	Completions    int64
+	EligibleDay1   int64
+	RetainedDay1   int64
+	EligibleDay7   int64
+	RetainedDay7   int64
+	EligibleDay30  int64
+	RetainedDay30  int64
+}
+
+// DynastyScoreRow is the distribution of lesson completion scores in a dynasty
+type DynastyScoreRow struct {
+	Dynasty      string
+	Learners     int64
+	Completions  int64
+	AverageScore float64
+	Score0To49   int64
+	Score50To69  int64
+	Score70To89  int64
+	Score90To100 int64
+}
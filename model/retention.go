@@ -0,0 +1,98 @@
+package model
+
+import "time"
+
+// Tables with a retention policy
+const (
+	RetentionTableQuestionAnswers     = "user_question_answers"
+	RetentionTableLessonAttempts      = "user_lesson_attempts"
+	RetentionTableGuestLessonAttempts = "guest_lesson_attempts"
+)
+
+// RetentionTables lists every table a retention policy can be set for
+var RetentionTables = []string{
+	RetentionTableQuestionAnswers,
+	RetentionTableLessonAttempts,
+	RetentionTableGuestLessonAttempts,
+}
+
+// RetentionPolicy decides how long raw rows of a table are kept. Rows are folded into
+// the daily stats tables before they are deleted, so reports keep their totals.
+type RetentionPolicy struct {
+	Table string `json:"table" gorm:"primaryKey;column:table_name;size:50"`
+
+	Enabled bool `json:"enabled" gorm:"not null;default:false"`
+	// Rows not touched for this many months are pruned
+	RetentionMonths int `json:"retention_months" gorm:"not null;default:12"`
+	// Per-user quota: only the newest rows of each user are kept. 0 means no limit.
+	// Not applied to guest tables.
+	MaxRowsPerUser int `json:"max_rows_per_user" gorm:"not null;default:0"`
+
+	LastRunAt      *time.Time `json:"last_run_at,omitempty"`
+	LastPrunedRows int64      `json:"last_pruned_rows" gorm:"not null;default:0"`
+
+	UpdatedBy string    `json:"updated_by,omitempty" gorm:"size:50"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DefaultRetentionPolicy returns the policy used for a table until an admin changes it.
+// Policies start disabled so nothing is deleted before someone has chosen a window.
+func DefaultRetentionPolicy(table string) RetentionPolicy {
+	return RetentionPolicy{
+		Table:           table,
+		RetentionMonths: 12,
+	}
+}
+
+// QuestionAnswerStat is the daily total of answers to a question, kept after the raw
+// answers are pruned
+type QuestionAnswerStat struct {
+	QuestionID string    `json:"question_id" gorm:"primaryKey;size:50"`
+	Day        time.Time `json:"day" gorm:"primaryKey;type:date"`
+	LessonID   string    `json:"lesson_id" gorm:"not null;size:50;index"`
+	Answers    int64     `json:"answers" gorm:"not null;default:0"`
+	Correct    int64     `json:"correct" gorm:"not null;default:0"`
+	Points     int64     `json:"points" gorm:"not null;default:0"`
+}
+
+// Sources of a LessonAttemptStat
+const (
+	AttemptSourceUser  = "user"
+	AttemptSourceGuest = "guest"
+)
+
+// LessonAttemptStat is the daily total of lesson attempts by users or guests, kept after
+// the raw attempts are pruned
+type LessonAttemptStat struct {
+	LessonID       string    `json:"lesson_id" gorm:"primaryKey;size:50"`
+	Day            time.Time `json:"day" gorm:"primaryKey;type:date"`
+	Source         string    `json:"source" gorm:"primaryKey;size:10"` // user, guest
+	Attempts       int64     `json:"attempts" gorm:"not null;default:0"`
+	Completed      int64     `json:"completed" gorm:"not null;default:0"`
+	TotalScore     int64     `json:"total_score" gorm:"not null;default:0"`
+	TotalTimeSpent int64     `json:"total_time_spent" gorm:"not null;default:0"` // in seconds
+}
+
+// InactiveUser is an account found by the anonymization job with the time it was last
+// active. Warnings only counts warnings sent after that time.
+type InactiveUser struct {
+	ID           string
+	Email        string
+	Username     string
+	LastActiveAt time.Time
+	Warnings     int
+	WarnedAt     *time.Time
+}
+
+// AnonymizationRun reports one run of the inactive account anonymization job
+type AnonymizationRun struct {
+	ID           string     `json:"id" gorm:"primaryKey"`
+	InactiveDays int        `json:"inactive_days"`
+	Warned       int        `json:"warned"`
+	Anonymized   int        `json:"anonymized"`
+	Failed       int        `json:"failed"`
+	Error        string     `json:"error,omitempty" gorm:"type:text"`
+	StartedAt    time.Time  `json:"started_at" gorm:"index"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+}
@@ -0,0 +1,83 @@
+package model
+
+import "time"
+
+// Kinds of shop items
+const (
+	ShopItemHeartRefill  = "heart_refill"  // Quantity hearts, up to the user's maximum
+	ShopItemStreakFreeze = "streak_freeze" // Quantity streak freezes
+	ShopItemCosmetic     = "cosmetic"      // owned once, see UserCosmetic
+)
+
+// ShopItem is sold for coins. Coins are only earned by playing; real-money purchases are
+// a separate system and never touch the wallet.
+type ShopItem struct {
+	ID          string    `json:"id" gorm:"primaryKey"`
+	Kind        string    `json:"kind" gorm:"size:20;not null;index"`
+	Name        string    `json:"name" gorm:"size:100;not null"`
+	Description string    `json:"description" gorm:"type:text"`
+	ImageURL    string    `json:"image_url"`
+	Price       int       `json:"price" gorm:"not null"` // coins
+	Quantity    int       `json:"quantity" gorm:"not null;default:1"`
+	IsActive    bool      `json:"is_active" gorm:"not null;default:true;index"`
+	SortOrder   int       `json:"sort_order" gorm:"not null;default:0"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Wallet holds a user's coins. Balance always equals the sum of the user's
+// CoinTransactions.
+type Wallet struct {
+	UserID         string    `json:"user_id" gorm:"primaryKey"`
+	Balance        int       `json:"balance" gorm:"not null;default:0"`
+	LifetimeEarned int       `json:"lifetime_earned" gorm:"not null;default:0"`
+	UpdatedAt      time.Time `json:"updated_at"`
+
+	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// Coin ledger sources
+const (
+	CoinSourceLesson      = "lesson"
+	CoinSourceAchievement = "achievement"
+	CoinSourcePurchase    = "purchase"
+	CoinSourceLessonAid   = "lesson_aid"
+)
+
+// CoinTransaction is one entry of a user's coin ledger. A user gets at most one entry
+// per source and reference, so rewards that are retried are only paid once.
+type CoinTransaction struct {
+	ID           string    `json:"id" gorm:"primaryKey"`
+	UserID       string    `json:"user_id" gorm:"not null;uniqueIndex:idx_coin_transactions_reference,priority:1;index:idx_coin_transactions_user,priority:1"`
+	Source       string    `json:"source" gorm:"size:30;not null;uniqueIndex:idx_coin_transactions_reference,priority:2"`
+	Amount       int       `json:"amount" gorm:"not null"`
+	BalanceAfter int       `json:"balance_after"`
+	ReferenceID  string    `json:"reference_id" gorm:"not null;uniqueIndex:idx_coin_transactions_reference,priority:3"` // lesson, achievement tier or purchase ID
+	Note         string    `json:"note"`
+	CreatedAt    time.Time `json:"created_at" gorm:"index:idx_coin_transactions_user,priority:2"`
+}
+
+// ShopPurchase is an item bought with coins. The client picks IdempotencyKey, so a
+// retried request finds the first purchase instead of paying twice.
+type ShopPurchase struct {
+	ID             string    `json:"id" gorm:"primaryKey"`
+	UserID         string    `json:"user_id" gorm:"not null;uniqueIndex:idx_shop_purchases_key,priority:1"`
+	IdempotencyKey string    `json:"idempotency_key" gorm:"size:64;not null;uniqueIndex:idx_shop_purchases_key,priority:2"`
+	ItemID         string    `json:"item_id" gorm:"not null;index"`
+	Kind           string    `json:"kind" gorm:"size:20;not null"`
+	Quantity       int       `json:"quantity" gorm:"not null"`
+	Price          int       `json:"price" gorm:"not null"`
+	BalanceAfter   int       `json:"balance_after"`
+	CreatedAt      time.Time `json:"created_at"`
+
+	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// UserCosmetic is a cosmetic shop item the user owns
+type UserCosmetic struct {
+	UserID      string    `json:"user_id" gorm:"primaryKey"`
+	ItemID      string    `json:"item_id" gorm:"primaryKey"`
+	PurchasedAt time.Time `json:"purchased_at"`
+
+	Item ShopItem `json:"item" gorm:"foreignKey:ItemID"`
+}
@@ -0,0 +1,62 @@
+package model
+
+import "time"
+
+const (
+	StudyRoomStatusWaiting  = "waiting"  // open for friends to join with the code
+	StudyRoomStatusActive   = "active"   // members are answering
+	StudyRoomStatusFinished = "finished" // every member still in the room answered every question
+	StudyRoomStatusClosed   = "closed"   // closed by the host, emptied or expired before finishing
+)
+
+// StudyRoom is a co-op session where a few friends answer the same lesson's questions
+// together. Friends join with Code while the room is waiting; members who pass all
+// get the group bonus when the room finishes.
+type StudyRoom struct {
+	ID             string     `json:"id" gorm:"primaryKey"`
+	Code           string     `json:"code" gorm:"size:10;not null;index"`
+	HostID         string     `json:"host_id" gorm:"not null;index"`
+	LessonID       string     `json:"lesson_id" gorm:"not null"`
+	Status         string     `json:"status" gorm:"size:20;not null;default:waiting;index"`
+	BonusXP        int        `json:"bonus_xp" gorm:"not null;default:0"` // paid to each member, 0 unless everyone passed
+	StartedAt      *time.Time `json:"started_at"`
+	EndedAt        *time.Time `json:"ended_at"`
+	LastActivityAt time.Time  `json:"last_activity_at" gorm:"not null;index"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Relationships
+	Lesson  Lesson            `json:"-" gorm:"foreignKey:LessonID"`
+	Members []StudyRoomMember `json:"-" gorm:"foreignKey:RoomID"`
+}
+
+// IsOpen reports whether the room has not finished or closed yet
+func (r *StudyRoom) IsOpen() bool {
+	return r.Status == StudyRoomStatusWaiting || r.Status == StudyRoomStatusActive
+}
+
+// StudyRoomMember is a user's place and progress in a room. Members leaving a waiting
+// room are removed; members leaving once it started keep their row with LeftAt set.
+type StudyRoomMember struct {
+	RoomID       string     `json:"room_id" gorm:"primaryKey"`
+	UserID       string     `json:"user_id" gorm:"primaryKey;index"`
+	Answered     int        `json:"answered" gorm:"not null;default:0"`
+	EarnedPoints int        `json:"earned_points" gorm:"not null;default:0"`
+	Passed       bool       `json:"passed" gorm:"not null;default:false"`
+	FinishedAt   *time.Time `json:"finished_at"`
+	LeftAt       *time.Time `json:"left_at"`
+	JoinedAt     time.Time  `json:"joined_at"`
+
+	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// StudyRoomAnswer is a member's single answer to one of the room's questions
+type StudyRoomAnswer struct {
+	RoomID     string    `json:"room_id" gorm:"primaryKey"`
+	UserID     string    `json:"user_id" gorm:"primaryKey"`
+	QuestionID string    `json:"question_id" gorm:"primaryKey"`
+	Answer     string    `json:"answer" gorm:"type:text"` // JSON string of the answer
+	IsCorrect  bool      `json:"is_correct" gorm:"not null"`
+	Points     int       `json:"points" gorm:"not null;default:0"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// SourceLocale is the language lessons are authored in
+const SourceLocale = "vi"
+
+// SupportedLocales lists the locales lessons are translated into
+var SupportedLocales = []string{"en"}
+
+const (
+	TranslationStatusMachineDraft = "machine_draft"
+	TranslationStatusDraft        = "draft"
+	TranslationStatusInReview     = "in_review"
+	TranslationStatusApproved     = "approved"
+	TranslationStatusRejected     = "rejected"
+
+	TranslationSourceMachine = "machine"
+	TranslationSourceHuman   = "human"
+)
+
+// LessonTranslation holds a lesson's text in another locale and its review state.
+// Only approved translations are shown to learners.
+type LessonTranslation struct {
+	ID              string          `json:"id" gorm:"primaryKey"`
+	LessonID        string          `json:"lesson_id" gorm:"not null;uniqueIndex:idx_lesson_translation_locale"`
+	Locale          string          `json:"locale" gorm:"not null;size:10;uniqueIndex:idx_lesson_translation_locale;index"`
+	Title           string          `json:"title"`
+	Story           string          `json:"story" gorm:"type:text"`
+	Script          string          `json:"script" gorm:"type:text"`
+	Questions       json.RawMessage `json:"questions" gorm:"type:jsonb"` // JSON array of TranslatedQuestion
+	Status          string          `json:"status" gorm:"not null;size:20;index"`
+	Source          string          `json:"source" gorm:"size:20"` // machine, human
+	TranslatedBy    string          `json:"translated_by" gorm:"size:50"`
+	ReviewerID      string          `json:"reviewer_id,omitempty" gorm:"size:50"`
+	ReviewNote      string          `json:"review_note,omitempty" gorm:"type:text"`
+	ReviewedAt      *time.Time      `json:"reviewed_at,omitempty"`
+	SourceUpdatedAt time.Time       `json:"source_updated_at"` // lesson UpdatedAt the translation was made from
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+}
+
+// TranslatedQuestion is the localized text of a lesson question. Options must keep the
+// order of the source question so answers can be mapped back for grading.
+type TranslatedQuestion struct {
+	ID       string      `json:"id"`
+	Question string      `json:"question"`
+	Options  []string    `json:"options,omitempty"`
+	Answer   interface{} `json:"answer,omitempty"` // only needed for fill_blank
+}
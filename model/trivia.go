@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+// DailyTrivia is the question of the day, picked from a lesson's question bank the
+// first time anyone asks for it that day. Date is the calendar day in the trivia
+// timezone, formatted 2006-01-02.
+type DailyTrivia struct {
+	Date       string    `json:"date" gorm:"primaryKey;size:10"`
+	LessonID   string    `json:"lesson_id" gorm:"not null"`
+	QuestionID string    `json:"question_id" gorm:"not null"`
+	XPReward   int       `json:"xp_reward" gorm:"not null"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// DailyTriviaAnswer is a user's single answer to a day's trivia question
+type DailyTriviaAnswer struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	UserID    string    `json:"user_id" gorm:"not null;uniqueIndex:idx_daily_trivia_answers_user_date,priority:1"`
+	Date      string    `json:"date" gorm:"not null;size:10;uniqueIndex:idx_daily_trivia_answers_user_date,priority:2;index"`
+	Answer    string    `json:"answer" gorm:"type:text"` // JSON string of the answer
+	IsCorrect bool      `json:"is_correct" gorm:"not null"`
+	XPAwarded int       `json:"xp_awarded" gorm:"not null;default:0"`
+	CreatedAt time.Time `json:"created_at"`
+
+	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// UserTriviaStreak counts consecutive days a user answered the daily trivia, right or
+// wrong. It is separate from the lesson streak.
+type UserTriviaStreak struct {
+	UserID           string    `json:"user_id" gorm:"primaryKey"`
+	CurrentStreak    int       `json:"current_streak" gorm:"not null;default:0"`
+	LongestStreak    int       `json:"longest_streak" gorm:"not null;default:0"`
+	LastAnsweredDate string    `json:"last_answered_date" gorm:"size:10"`
+	UpdatedAt        time.Time `json:"updated_at"`
+
+	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// DailyTriviaStats is the global participation in one day's trivia
+type DailyTriviaStats struct {
+	Date           string `json:"date"`
+	TotalAnswers   int64  `json:"total_answers"`
+	CorrectAnswers int64  `json:"correct_answers"`
+}
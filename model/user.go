@@ -1,6 +1,13 @@
 package model
 
-import "time"
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
 
 const (
 	RoleAdmin            = "admin"
@@ -24,15 +31,27 @@ type User struct {
 	BirthYear int    `json:"birth_year" gorm:"default:0;not null"`
 	Password  string `json:"-" gorm:"not null;size:255"` // Never expose in JSON
 
+	// Zodiac spirit: the birth date is optional and places births in January and February
+	// before or after Tết. The zodiac can be corrected once.
+	BirthDate         *time.Time `json:"birth_date,omitempty" gorm:"type:date"`
+	ZodiacCorrectedAt *time.Time `json:"zodiac_corrected_at,omitempty"`
+
 	// Role and Status
 	Role     string `json:"role" gorm:"default:user;not null;size:20;index"`
 	IsActive bool   `json:"is_active" gorm:"default:true;not null;index"`
 
+	// School or organisation the learner belongs to, set by admins. Only that tenant's
+	// webhook endpoints receive the learner's events.
+	TenantID string `json:"tenant_id,omitempty" gorm:"size:50;index"`
+
 	// Email Verification
 	EmailVerified          bool       `json:"email_verified" gorm:"default:false;not null;index"`
 	VerificationCode       string     `json:"-" gorm:"size:6;index"`
 	VerificationCodeExpiry *time.Time `json:"-" gorm:"index"`
 
+	// Email change waiting for confirmation from the new address
+	PendingEmail string `json:"pending_email,omitempty" gorm:"size:255;index"`
+
 	// Security Fields
 	FailedAttempts     int        `json:"failed_attempts" gorm:"default:0;not null"`
 	LockedUntil        *time.Time `json:"locked_until,omitempty" gorm:"index"`
@@ -43,23 +62,190 @@ type User struct {
 	// Two-Factor Authentication
 	TwoFactorEnabled bool   `json:"two_factor_enabled" gorm:"default:false;not null"`
 	TwoFactorSecret  string `json:"-" gorm:"size:255"`
-	BackupCodes      string `json:"-" gorm:"type:text"` // JSON array stored as string
+	BackupCodes      string `json:"-" gorm:"type:text"` // JSON array of backup code hashes, see BackupCodeHashes
+	// Time step of the last authenticator code accepted, so a code can't be used twice
+	TwoFactorLastStep int64 `json:"-" gorm:"default:0;not null"`
 
 	// User Preferences
 	LoginNotifications bool `json:"login_notifications" gorm:"default:true;not null"`
 	SessionTimeout     int  `json:"session_timeout" gorm:"default:1440;not null"` // minutes, default 24h
 
+	// Security emails: when each event type is emailed (see SecurityEmail* modes), and
+	// whether a weekly digest of all security events is sent
+	LoginEmailMode          string     `json:"login_email_mode" gorm:"size:20;default:'always';not null"`
+	SessionEvictedEmailMode string     `json:"session_evicted_email_mode" gorm:"size:20;default:'always';not null"`
+	SecurityDigest          bool       `json:"security_digest" gorm:"default:false;not null;index"`
+	LastSecurityDigestAt    *time.Time `json:"last_security_digest_at,omitempty"`
+
+	// Concurrent session policy
+	MaxActiveSessions  int    `json:"max_active_sessions" gorm:"default:5;not null"`
+	SessionLimitPolicy string `json:"session_limit_policy" gorm:"size:20;default:'evict_oldest';not null"`
+
+	// Leaderboard privacy: public, anonymous or hidden
+	LeaderboardVisibility string `json:"leaderboard_visibility" gorm:"size:20;default:'public';not null;index"`
+	// Set while a leaderboard anomaly of the user is under review, keeps them out of rankings
+	LeaderboardQuarantined bool `json:"leaderboard_quarantined" gorm:"default:false;not null"`
+
+	// Parental controls: a rating set here replaces the age-based content limit.
+	// Changing it requires ParentalPIN.
+	ParentalMaxRating string `json:"parental_max_rating,omitempty" gorm:"size:10"`
+	ParentalPIN       string `json:"-" gorm:"size:255"`
+
+	// Onboarding: the next step the user has to complete
+	OnboardingStep        string     `json:"onboarding_step" gorm:"size:30;default:'verify_email';not null"`
+	OnboardingCompletedAt *time.Time `json:"onboarding_completed_at,omitempty"`
+
+	// Streak days follow Timezone (Vietnam time when empty). With the weekend amulet,
+	// Saturdays and Sundays without a lesson don't break the streak.
+	Timezone      string `json:"timezone,omitempty" gorm:"size:64"`
+	WeekendAmulet bool   `json:"weekend_amulet" gorm:"default:false;not null"`
+
+	// Accessibility preferences, kept on the server so every device of the user applies them
+	ReducedMotion  bool `json:"reduced_motion" gorm:"default:false;not null"`
+	DyslexiaFont   bool `json:"dyslexia_font" gorm:"default:false;not null"`
+	HighContrast   bool `json:"high_contrast" gorm:"default:false;not null"`
+	ColorBlindSafe bool `json:"color_blind_safe" gorm:"default:false;not null"`
+
+	// Inactive account anonymization: warning emails sent since the user was last
+	// active, and when the account was scrubbed
+	InactivityWarnings int        `json:"-" gorm:"default:0;not null"`
+	InactivityWarnedAt *time.Time `json:"-"`
+	AnonymizedAt       *time.Time `json:"anonymized_at,omitempty" gorm:"index"`
+
 	// Timestamps
 	CreatedAt time.Time  `json:"created_at" gorm:"not null;index"`
 	UpdatedAt time.Time  `json:"updated_at" gorm:"not null"`
 	DeletedAt *time.Time `json:"deleted_at,omitempty" gorm:"index"`
 }
 
+// Session limit policies applied when a login would exceed MaxActiveSessions
+const (
+	SessionLimitPolicyEvictOldest = "evict_oldest"
+	SessionLimitPolicyReject      = "reject"
+)
+
+// Leaderboard visibility options. Anonymous users are ranked under an alias,
+// hidden users are left out of leaderboards entirely.
+const (
+	LeaderboardVisibilityPublic    = "public"
+	LeaderboardVisibilityAnonymous = "anonymous"
+	LeaderboardVisibilityHidden    = "hidden"
+)
+
+// Security event types with their own email preference
+const (
+	SecurityEventLogin          = "login"
+	SecurityEventSessionEvicted = "session_evicted"
+)
+
+// Security email modes: email every event, only events caused by a login from a device
+// the user hasn't used before, or none
+const (
+	SecurityEmailAlways    = "always"
+	SecurityEmailNewDevice = "new_device"
+	SecurityEmailNever     = "never"
+)
+
+// SecurityEmailMode is the email mode the user chose for a security event type. Users
+// who turned LoginNotifications off before modes existed get no login emails.
+func (u *User) SecurityEmailMode(eventType string) string {
+	var mode string
+	switch eventType {
+	case SecurityEventLogin:
+		if !u.LoginNotifications {
+			return SecurityEmailNever
+		}
+		mode = u.LoginEmailMode
+	case SecurityEventSessionEvicted:
+		mode = u.SessionEvictedEmailMode
+	}
+
+	if mode == "" {
+		return SecurityEmailAlways
+	}
+	return mode
+}
+
+// BackupCodeHashes are the hashes of the user's unused 2FA backup codes. Codes are never
+// stored in clear; each one is removed once it's used.
+func (u *User) BackupCodeHashes() []string {
+	if u.BackupCodes == "" {
+		return nil
+	}
+	var hashes []string
+	if err := json.Unmarshal([]byte(u.BackupCodes), &hashes); err != nil {
+		return nil
+	}
+	return hashes
+}
+
+// ContentAgeLimit is the oldest content rating age the user may see. A parental rating
+// wins over the birth year; users without either only see content for all ages. Age is
+// counted in calendar years, so it can run a few months ahead of the real birthday.
+func (u *User) ContentAgeLimit(now time.Time) int {
+	if u.ParentalMaxRating != "" {
+		return ContentRatingMinAge(u.ParentalMaxRating)
+	}
+	if u.BirthYear <= 0 {
+		return 0
+	}
+	return now.Year() - u.BirthYear
+}
+
+// LeaderboardProfile is a denormalized copy of the user and spirit fields shown on
+// leaderboards, so a page of rankings is hydrated with one query. It is refreshed when
+// the username, visibility or spirit changes and rebuilt from the source tables when a
+// row is missing.
+type LeaderboardProfile struct {
+	UserID      string    `json:"user_id" gorm:"primaryKey;size:50"`
+	Username    string    `json:"username" gorm:"size:50"`
+	Visibility  string    `json:"visibility" gorm:"size:20;default:'public';not null"`
+	SpiritType  string    `json:"spirit_type" gorm:"size:20"`
+	SpiritStage int       `json:"spirit_stage" gorm:"default:1"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// Leaderboard types: what users are ranked by
+const (
+	LeaderboardTypeXP         = "xp"
+	LeaderboardTypeSpirit     = "spirit"     // spirit stage
+	LeaderboardTypeCollection = "collection" // share of all characters unlocked
+)
+
+var LeaderboardTypes = []string{LeaderboardTypeXP, LeaderboardTypeSpirit, LeaderboardTypeCollection}
+
+// LeaderboardEntry is a user's progress with the scores of the spirit and collection
+// leaderboards
+type LeaderboardEntry struct {
+	UserProgress
+	SpiritStage         int
+	CollectedCharacters int
+	CollectionPercent   float64
+}
+
+// Onboarding steps in the order users complete them
+const (
+	OnboardingStepVerifyEmail    = "verify_email"
+	OnboardingStepSetBirthYear   = "set_birth_year"
+	OnboardingStepFirstLesson    = "first_lesson"
+	OnboardingStepFirstCharacter = "first_character"
+	OnboardingStepCompleted      = "completed"
+)
+
+var OnboardingSteps = []string{
+	OnboardingStepVerifyEmail,
+	OnboardingStepSetBirthYear,
+	OnboardingStepFirstLesson,
+	OnboardingStepFirstCharacter,
+}
+
 // UserSession represents an active user session
 type UserSession struct {
 	ID               string    `json:"id" gorm:"primaryKey;type:text;not null"`
-	UserID           string    `json:"user_id" gorm:"not null;index;size:50"`
-	TokenHash        string    `json:"token_hash" gorm:"not null;index;size:255"`
+	UserID           string    `json:"user_id" gorm:"not null;index;index:idx_user_sessions_lookup,priority:1;index:idx_user_sessions_active,priority:1;size:50"`
+	TokenHash        string    `json:"token_hash" gorm:"not null;index;index:idx_user_sessions_lookup,priority:2;size:255"`
 	RefreshTokenJTI  string    `json:"refresh_token_jti" gorm:"index;size:255"` // Nullable for existing sessions
 	RefreshExpiresAt time.Time `json:"refresh_expires_at" gorm:"not null"`
 	DeviceID         string    `json:"device_id,omitempty" gorm:"index;size:100"`
@@ -67,8 +253,8 @@ type UserSession struct {
 	UserAgent        string    `json:"user_agent" gorm:"type:text"`
 	CreatedAt        time.Time `json:"created_at" gorm:"not null"`
 	LastUsed         time.Time `json:"last_used" gorm:"not null;index"`
-	IsActive         bool      `json:"is_active" gorm:"default:true;not null;index"`
-	ExpiresAt        time.Time `json:"expires_at" gorm:"not null;index"`
+	IsActive         bool      `json:"is_active" gorm:"default:true;not null;index;index:idx_user_sessions_lookup,priority:3;index:idx_user_sessions_active,priority:2"`
+	ExpiresAt        time.Time `json:"expires_at" gorm:"not null;index;index:idx_user_sessions_lookup,priority:4;index:idx_user_sessions_active,priority:3"`
 
 	// Relationships
 	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
@@ -77,18 +263,109 @@ type UserSession struct {
 // AuthAuditLog represents authentication audit logs
 type AuthAuditLog struct {
 	ID        string    `json:"id" gorm:"primaryKey;type:text;not null"`
-	UserID    string    `json:"user_id,omitempty" gorm:"index;size:50"`
-	Action    string    `json:"action" gorm:"not null;index;size:50"`
+	UserID    string    `json:"user_id,omitempty" gorm:"index;index:idx_auth_audit_user_time,priority:1;size:50"`
+	Action    string    `json:"action" gorm:"not null;index;index:idx_auth_audit_action_time,priority:1;size:50"`
 	IP        string    `json:"ip,omitempty" gorm:"index;size:45"`
 	UserAgent string    `json:"user_agent,omitempty" gorm:"type:text"`
-	Timestamp time.Time `json:"timestamp" gorm:"not null;index"`
+	Timestamp time.Time `json:"timestamp" gorm:"not null;index;index:idx_auth_audit_user_time,priority:2,sort:desc;index:idx_auth_audit_action_time,priority:2,sort:desc"`
 	Success   bool      `json:"success" gorm:"not null;index"`
 	Details   string    `json:"details,omitempty" gorm:"type:text"`
 
+	// Hash chain: Hash covers PrevHash and the fields above, so editing, removing or
+	// reordering entries breaks the chain. Entries from before chaining have no Sequence.
+	// IP and UserAgent are hashed through ClientDigest, keyed with ClientKey, so they can
+	// be cleared with the key when the user is anonymized and the chain still verifies.
+	Sequence     int64  `json:"sequence,omitempty" gorm:"uniqueIndex"`
+	PrevHash     string `json:"prev_hash,omitempty" gorm:"size:64"`
+	Hash         string `json:"hash,omitempty" gorm:"size:64"`
+	ClientDigest string `json:"client_digest,omitempty" gorm:"size:64"`
+	ClientKey    string `json:"-" gorm:"size:64"`
+	// Set when IP and UserAgent were cleared. Entries chained before ClientDigest existed
+	// can't be rehashed after that, only their place in the chain is verified.
+	RedactedAt *time.Time `json:"redacted_at,omitempty"`
+
 	// Relationships
 	User *User `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:SET NULL"`
 }
 
+// ComputeHash is the chain hash of the entry. Timestamps are hashed in UTC at microsecond
+// precision, as stored by Postgres. Entries with a ClientDigest hash it in place of IP
+// and UserAgent; older entries hash those as they are.
+func (l *AuthAuditLog) ComputeHash() string {
+	ip, userAgent := l.IP, l.UserAgent
+	if l.ClientDigest != "" {
+		ip, userAgent = "", ""
+	}
+
+	payload, _ := json.Marshal(struct {
+		Sequence     int64  `json:"sequence"`
+		PrevHash     string `json:"prev_hash"`
+		ID           string `json:"id"`
+		UserID       string `json:"user_id"`
+		Action       string `json:"action"`
+		IP           string `json:"ip"`
+		UserAgent    string `json:"user_agent"`
+		Timestamp    string `json:"timestamp"`
+		Success      bool   `json:"success"`
+		Details      string `json:"details"`
+		ClientDigest string `json:"client_digest,omitempty"`
+	}{
+		Sequence:     l.Sequence,
+		PrevHash:     l.PrevHash,
+		ID:           l.ID,
+		UserID:       l.UserID,
+		Action:       l.Action,
+		IP:           ip,
+		UserAgent:    userAgent,
+		Timestamp:    l.Timestamp.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano),
+		Success:      l.Success,
+		Details:      l.Details,
+		ClientDigest: l.ClientDigest,
+	})
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// SealClientDetails keys a new entry's IP and UserAgent into ClientDigest with a random
+// key of the entry. Without the key the digest says nothing about them.
+func (l *AuthAuditLog) SealClientDetails() error {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	l.ClientKey = hex.EncodeToString(key)
+	l.ClientDigest = l.computeClientDigest()
+	return nil
+}
+
+// ClientDetailsIntact reports whether IP and UserAgent still match ClientDigest. Once
+// redacted they are gone along with the key, and there is nothing left to match.
+func (l *AuthAuditLog) ClientDetailsIntact() bool {
+	if l.ClientDigest == "" {
+		return true
+	}
+	if l.ClientKey == "" {
+		return l.RedactedAt != nil && l.IP == "" && l.UserAgent == ""
+	}
+	return hmac.Equal([]byte(l.ClientDigest), []byte(l.computeClientDigest()))
+}
+
+func (l *AuthAuditLog) computeClientDigest() string {
+	mac := hmac.New(sha256.New, []byte(l.ClientKey))
+	mac.Write([]byte(l.IP))
+	mac.Write([]byte{0})
+	mac.Write([]byte(l.UserAgent))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// AuthAuditCheckpoint keeps the end of the audit log chain that retention cleanup
+// removed, so the first remaining entry can still be verified
+type AuthAuditCheckpoint struct {
+	Sequence  int64     `json:"sequence" gorm:"primaryKey;autoIncrement:false"`
+	Hash      string    `json:"hash" gorm:"not null;size:64"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 // PasswordResetCode represents password reset codes
 type PasswordResetCode struct {
 	ID        string    `json:"id" gorm:"primaryKey;type:text;not null"`
@@ -102,6 +379,58 @@ type PasswordResetCode struct {
 	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
 }
 
+// EmailChangeRequest tracks a change of email address. The new address confirms with
+// a code; the old address gets a revert token that can undo the change.
+type EmailChangeRequest struct {
+	ID              string     `json:"id" gorm:"primaryKey;type:text;not null"`
+	UserID          string     `json:"user_id" gorm:"not null;index;size:50"`
+	OldEmail        string     `json:"old_email" gorm:"not null;size:255"`
+	NewEmail        string     `json:"new_email" gorm:"not null;size:255"`
+	CodeHash        string     `json:"-" gorm:"not null;size:64"`
+	CodeExpiresAt   time.Time  `json:"code_expires_at" gorm:"not null"`
+	RevertTokenHash string     `json:"-" gorm:"not null;uniqueIndex;size:64"`
+	RevertExpiresAt time.Time  `json:"revert_expires_at" gorm:"not null"`
+	ConfirmedAt     *time.Time `json:"confirmed_at,omitempty"`
+	RevertedAt      *time.Time `json:"reverted_at,omitempty"`
+	CancelledAt     *time.Time `json:"cancelled_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at" gorm:"not null"`
+
+	// Relationships
+	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// AccountRecoveryRequest moves an account to a new email address when the user lost
+// access to the old one. A trusted device approves or denies it; without an answer it
+// can be completed once FallbackAt has passed, unless it was cancelled from the link sent
+// to the old address. The row keeps the whole chain for auditing.
+type AccountRecoveryRequest struct {
+	ID              string     `json:"id" gorm:"primaryKey;type:text;not null"`
+	UserID          string     `json:"user_id" gorm:"not null;index;size:50"`
+	NewEmail        string     `json:"new_email" gorm:"not null;size:255"`
+	Status          string     `json:"status" gorm:"not null;size:20;index"`
+	TokenHash       string     `json:"-" gorm:"not null;uniqueIndex;size:64"`
+	CancelTokenHash string     `json:"-" gorm:"not null;uniqueIndex;size:64"`
+	RequestIP       string     `json:"request_ip" gorm:"size:45"`
+	UserAgent       string     `json:"user_agent" gorm:"type:text"`
+	DecidedByDevice string     `json:"decided_by_device,omitempty" gorm:"size:100"`
+	DecidedAt       *time.Time `json:"decided_at,omitempty"`
+	FallbackAt      time.Time  `json:"fallback_at" gorm:"not null"`
+	ExpiresAt       time.Time  `json:"expires_at" gorm:"not null"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at" gorm:"not null"`
+
+	// Relationships
+	User User `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+const (
+	AccountRecoveryPending   = "pending"
+	AccountRecoveryApproved  = "approved"
+	AccountRecoveryDenied    = "denied"
+	AccountRecoveryCancelled = "cancelled"
+	AccountRecoveryCompleted = "completed"
+)
+
 // BlacklistedToken represents blacklisted JWT tokens
 type BlacklistedToken struct {
 	JTI       string    `json:"jti" gorm:"primaryKey;size:255"`
@@ -115,7 +444,7 @@ type TrustedDevice struct {
 	UserID    string    `json:"user_id" gorm:"not null;index;size:50"`
 	DeviceID  string    `json:"device_id" gorm:"not null;size:100"`
 	Name      string    `json:"name" gorm:"size:255"`
-	Type      string    `json:"type" gorm:"size:20"` // mobile, desktop, tablet
+	Type      string    `json:"type" gorm:"size:20"` // mobile, desktop, tablet, bot or unknown, see shared/useragent
 	OS        string    `json:"os" gorm:"size:50"`
 	Browser   string    `json:"browser" gorm:"size:50"`
 	IP        string    `json:"ip" gorm:"size:45"`
@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// UserNote is an internal note support staff keep on an account, optionally linked to a
+// ticket in the support desk. Notes are never shown to the user.
+type UserNote struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	UserID    string    `json:"user_id" gorm:"not null;index"`
+	AuthorID  string    `json:"author_id" gorm:"not null"`
+	Note      string    `json:"note" gorm:"type:text;not null"`
+	Tags      string    `json:"tags" gorm:"size:255"` // comma separated
+	TicketID  string    `json:"ticket_id,omitempty" gorm:"size:100;index"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relationship
+	Author User `json:"author" gorm:"foreignKey:AuthorID"`
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+// Clearing an entry's client details with its key, as anonymization does, keeps the
+// chain hash valid, while editing them is noticed
+func TestAuthAuditLogRedaction(t *testing.T) {
+	entry := AuthAuditLog{
+		ID:        "log_1",
+		UserID:    "user_1",
+		Action:    ActionLogin,
+		IP:        "203.0.113.7",
+		UserAgent: "Mozilla/5.0",
+		Timestamp: time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC),
+		Success:   true,
+		Sequence:  42,
+		PrevHash:  "prev",
+	}
+	if err := entry.SealClientDetails(); err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	entry.Hash = entry.ComputeHash()
+
+	edited := entry
+	edited.IP = "198.51.100.1"
+	if edited.ClientDetailsIntact() {
+		t.Error("edited IP went unnoticed")
+	}
+
+	redactedAt := time.Now()
+	entry.IP, entry.UserAgent, entry.ClientKey, entry.RedactedAt = "", "", "", &redactedAt
+	if entry.Hash != entry.ComputeHash() {
+		t.Error("redaction broke the chain hash")
+	}
+	if !entry.ClientDetailsIntact() {
+		t.Error("redacted entry reported as modified")
+	}
+}
+
+// Entries chained before client digests existed keep hashing their raw client details
+func TestAuthAuditLogLegacyHash(t *testing.T) {
+	entry := AuthAuditLog{ID: "log_1", Action: ActionLogin, IP: "203.0.113.7", Sequence: 1}
+	hash := entry.ComputeHash()
+
+	entry.IP = ""
+	if entry.ComputeHash() == hash {
+		t.Error("legacy hash does not cover the IP")
+	}
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Webhook event types
+const (
+	WebhookEventLessonCompleted   = "lesson.completed"
+	WebhookEventCharacterUnlocked = "character.unlocked"
+	WebhookEventLevelUp           = "level.up"
+
+	// Sent when a media asset keeps failing to process, for content team alerting
+	WebhookEventMediaProcessingFailed = "media.processing_failed"
+)
+
+// Webhook delivery states
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed" // gave up after the last retry
+)
+
+// WebhookEndpoint is an external system, e.g. a school's LMS, that receives progress
+// events. Each endpoint signs its deliveries with its own secret. Endpoints of a tenant
+// get the events of that tenant's learners only; endpoints without one get platform
+// events such as media alerts.
+type WebhookEndpoint struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	TenantID  string    `json:"tenant_id,omitempty" gorm:"size:50;not null;default:'';index"`
+	Name      string    `json:"name" gorm:"not null;size:100"`
+	URL       string    `json:"url" gorm:"not null;size:500"`
+	Secret    string    `json:"-" gorm:"not null;size:100"`
+	Events    string    `json:"events" gorm:"size:255"` // comma separated event types
+	IsActive  bool      `json:"is_active" gorm:"not null;default:true;index"`
+	CreatedBy string    `json:"created_by" gorm:"size:50"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WebhookDelivery is one event queued for one endpoint, kept as the delivery log
+type WebhookDelivery struct {
+	ID             string          `json:"id" gorm:"primaryKey"`
+	EndpointID     string          `json:"endpoint_id" gorm:"not null;index:idx_webhook_delivery_endpoint,priority:1"`
+	Event          string          `json:"event" gorm:"not null;size:50"`
+	Payload        json.RawMessage `json:"payload" gorm:"type:jsonb"`
+	Status         string          `json:"status" gorm:"not null;size:20;index:idx_webhook_delivery_due,priority:1"`
+	Attempts       int             `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at" gorm:"index:idx_webhook_delivery_due,priority:2"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty" gorm:"size:500"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at" gorm:"index:idx_webhook_delivery_endpoint,priority:2"`
+}
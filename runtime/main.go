@@ -3,7 +3,6 @@ package main
 import (
 	"github.com/lac-hong-legacy/ven_api/services"
 
-	"github.com/cloakd/common/context"
 	"github.com/joho/godotenv"
 	log "github.com/sirupsen/logrus"
 )
@@ -14,20 +13,41 @@ func main() {
 		log.Info("Error loading .env file", err)
 	}
 
-	ctx, err := context.NewContext(
+	ctx, err := services.NewContext(
 		&services.PostgresService{},
 		&services.RedisService{},
+		&services.EventBusService{},
 		&services.MinIOService{},
 		&services.JWTService{},
 		&services.RateLimitService{},
 		&services.GeolocationService{},
+		&services.AttestationService{},
+		&services.ModerationService{},
 		// &services.MonitoringService{},
 		&services.AuthService{},
 		&services.GuestService{},
 		&services.ContentService{},
+		&services.TranslationService{},
+		&services.QuestionGenerationService{},
 		&services.MediaService{},
+		&services.NotificationService{},
+		&services.WebhookService{},
+		&services.AchievementService{},
 		&services.UserService{},
+		&services.BattleService{},
+		&services.TriviaService{},
+		&services.StudyRoomService{},
+		&services.ShopService{},
+		&services.CertificateService{},
 		&services.EmailService{},
+		&services.SystemService{},
+		&services.OutboxService{},
+		&services.RetentionService{},
+		&services.ResearchExportService{},
+		&services.MaintenanceService{},
+		&services.AppVersionService{},
+		&services.CacheService{},
+		&services.KillSwitchService{},
 		&services.HttpService{},
 	)
 	if err != nil {
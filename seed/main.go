@@ -22,7 +22,9 @@ func main() {
 
 	// Parse command line flags
 	var (
-		seedType = flag.String("type", "all", "Type of seeding: all")
+		seedType = flag.String("type", "all", "Type of seeding: all, loadtest")
+		users    = flag.Int("users", 1000, "Number of users to generate with -type loadtest")
+		seed     = flag.Int64("seed", 1, "Random seed for -type loadtest, the same seed gives the same data")
 	)
 	flag.Parse()
 
@@ -83,8 +85,16 @@ func main() {
 		if err := mainSeeder.SeedAll(); err != nil {
 			log.Fatalf("Failed to seed database: %v", err)
 		}
+	case "loadtest":
+		if *users < 1 {
+			log.Fatalf("-users must be at least 1")
+		}
+		log.Println("Running load test seeding...")
+		if err := seeders.NewLoadTestSeeder(db, *users, *seed).SeedLoadTest(); err != nil {
+			log.Fatalf("Failed to seed load test data: %v", err)
+		}
 	default:
-		log.Fatalf("Unknown seed type: %s. Use 'all'", *seedType)
+		log.Fatalf("Unknown seed type: %s. Use 'all' or 'loadtest'", *seedType)
 	}
 
 	log.Println("Seeding operation completed successfully!")
@@ -3,6 +3,7 @@ package seeders
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"time"
 
@@ -25,6 +26,17 @@ func (s *LessonSeeder) SeedLessons() error {
 	lessons := s.getHistoricalLessons()
 
 	for _, lesson := range lessons {
+		var questions []model.Question
+		if err := json.Unmarshal(lesson.Questions, &questions); err != nil {
+			return fmt.Errorf("lesson %s has invalid questions: %w", lesson.ID, err)
+		}
+		if errs := model.ValidateQuestions(questions); len(errs) > 0 {
+			for _, err := range errs {
+				log.Printf("Invalid question in lesson %s: %v", lesson.ID, err)
+			}
+			return fmt.Errorf("lesson %s has %d invalid question(s)", lesson.ID, len(errs))
+		}
+
 		// Check if lesson already exists
 		var existingLesson model.Lesson
 		if err := s.db.Where("id = ?", lesson.ID).First(&existingLesson).Error; err != nil {
@@ -0,0 +1,335 @@
+// seeders/loadtest_seeder.go
+package seeders
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LoadTestPassword is the password of every generated user
+const LoadTestPassword = "LoadTest123!"
+
+const loadTestBatchSize = 500
+
+var (
+	loadTestZodiac = []string{
+		"rat", "ox", "tiger", "rabbit", "dragon", "snake",
+		"horse", "goat", "monkey", "rooster", "dog", "pig",
+	}
+	loadTestUserAgents = []string{
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148",
+		"Mozilla/5.0 (Linux; Android 14; SM-A546E) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0 Mobile Safari/537.36",
+		"Mozilla/5.0 (Linux; Android 13; Redmi Note 12) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/125.0 Mobile Safari/537.36",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0 Safari/537.36",
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_5) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Safari/605.1.15",
+	}
+)
+
+// LoadTestSeeder generates synthetic users with progress, lesson attempts, XP
+// ledgers and sessions for performance testing. Every user is generated from its
+// own random source derived from the seed and its index, so a given seed always
+// produces the same user N, and re-running with more users only adds the new ones.
+// Timestamps are spread over the year before the day the seeder runs.
+type LoadTestSeeder struct {
+	db    *gorm.DB
+	users int
+	seed  int64
+	now   time.Time
+}
+
+type loadTestLesson struct {
+	ID          string
+	CharacterID string
+}
+
+// loadTestBatch holds the rows generated for one batch of users
+type loadTestBatch struct {
+	users       []model.User
+	sessions    []model.UserSession
+	progress    []model.UserProgress
+	spirits     []model.Spirit
+	completions []model.UserLessonCompletion
+	attempts    []model.UserLessonAttempt
+	xpTxns      []model.XPTransaction
+	characters  []model.UserCharacter
+}
+
+// NewLoadTestSeeder creates a new load test seeder
+func NewLoadTestSeeder(db *gorm.DB, users int, seed int64) *LoadTestSeeder {
+	return &LoadTestSeeder{
+		db:    db,
+		users: users,
+		seed:  seed,
+		now:   time.Now().UTC().Truncate(24 * time.Hour),
+	}
+}
+
+// SeedLoadTest generates the users in batches. Lessons must already be seeded.
+func (s *LoadTestSeeder) SeedLoadTest() error {
+	var lessons []loadTestLesson
+	if err := s.db.Model(&model.Lesson{}).Select("id, character_id").
+		Order("character_id, \"order\"").Find(&lessons).Error; err != nil {
+		return err
+	}
+	if len(lessons) == 0 {
+		return errors.New("no lessons found, run the seeder with -type all first")
+	}
+
+	// One hash for everyone, bcrypt at full cost would dominate the run
+	hash, err := bcrypt.GenerateFromPassword([]byte(LoadTestPassword), bcrypt.MinCost)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Generating %d load test users (seed %d) over %d lessons...", s.users, s.seed, len(lessons))
+	start := time.Now()
+
+	for from := 0; from < s.users; from += loadTestBatchSize {
+		to := min(from+loadTestBatchSize, s.users)
+
+		batch := &loadTestBatch{}
+		for i := from; i < to; i++ {
+			s.generateUser(batch, i, lessons, string(hash))
+		}
+		if err := s.insertBatch(batch); err != nil {
+			return fmt.Errorf("users %d-%d: %w", from, to-1, err)
+		}
+		log.Printf("Generated %d/%d users", to, s.users)
+	}
+
+	log.Printf("Load test seeding completed in %s", time.Since(start).Round(time.Second))
+	return nil
+}
+
+func (s *LoadTestSeeder) generateUser(batch *loadTestBatch, index int, lessons []loadTestLesson, passwordHash string) {
+	rng := rand.New(rand.NewSource(s.seed*1_000_003 + int64(index)))
+	newID := func() string {
+		id, _ := uuid.NewRandomFromReader(rng)
+		return id.String()
+	}
+
+	userID := newID()
+	createdAt := s.now.Add(-time.Duration(rng.Int63n(int64(365 * 24 * time.Hour))))
+	// Activity skews recent: most users were last seen shortly before now
+	lastActive := createdAt.Add(time.Duration((1 - math.Pow(rng.Float64(), 3)) * float64(s.now.Sub(createdAt))))
+	birthYear := 1995 + rng.Intn(20)
+
+	// Heavy tail: most users finish a handful of lessons, a few finish nearly all
+	completed := int(math.Pow(rng.Float64(), 2.5) * float64(len(lessons)+1))
+	completed = min(completed, len(lessons))
+
+	visibility := model.LeaderboardVisibilityPublic
+	switch r := rng.Float64(); {
+	case r < 0.03:
+		visibility = model.LeaderboardVisibilityHidden
+	case r < 0.10:
+		visibility = model.LeaderboardVisibilityAnonymous
+	}
+
+	onboarding := model.OnboardingStepFirstLesson
+	var onboardedAt *time.Time
+	if completed > 0 {
+		onboarding = model.OnboardingStepCompleted
+		onboardedAt = &createdAt
+	}
+
+	batch.users = append(batch.users, model.User{
+		ID:                    userID,
+		Username:              fmt.Sprintf("lt_%07d", index),
+		Email:                 fmt.Sprintf("lt_%07d@loadtest.invalid", index),
+		BirthYear:             birthYear,
+		Password:              passwordHash,
+		Role:                  "user",
+		IsActive:              true,
+		EmailVerified:         true,
+		LastLoginAt:           &lastActive,
+		LoginNotifications:    true,
+		SessionTimeout:        1440,
+		MaxActiveSessions:     5,
+		SessionLimitPolicy:    model.SessionLimitPolicyEvictOldest,
+		LeaderboardVisibility: visibility,
+		OnboardingStep:        onboarding,
+		OnboardingCompletedAt: onboardedAt,
+		CreatedAt:             createdAt,
+		UpdatedAt:             lastActive,
+	})
+
+	// Lessons are played in catalog order, spread between signup and last activity
+	xp, playTime := 0, 0
+	unlocked := map[string]bool{}
+	for n := 0; n < completed; n++ {
+		lesson := lessons[n]
+		at := createdAt.Add(time.Duration(float64(lastActive.Sub(createdAt)) * float64(n+1) / float64(completed+1)))
+		score := min(max(int(rng.NormFloat64()*12+80), 40), 100)
+		timeSpent := 120 + rng.Intn(780)
+		tries := 1 + int(math.Floor(math.Pow(rng.Float64(), 4)*3))
+
+		batch.attempts = append(batch.attempts, model.UserLessonAttempt{
+			ID:            newID(),
+			UserID:        userID,
+			LessonID:      lesson.ID,
+			IsCompleted:   true,
+			Score:         score,
+			TimeSpent:     timeSpent,
+			AttemptsCount: tries,
+			CreatedAt:     at,
+			UpdatedAt:     at,
+		})
+		batch.completions = append(batch.completions, model.UserLessonCompletion{
+			ID:          newID(),
+			UserID:      userID,
+			LessonID:    lesson.ID,
+			Score:       score,
+			CompletedAt: at,
+			CreatedAt:   at,
+		})
+
+		gained := 50 + max(0, (score-60)/10*10)
+		xp += gained
+		playTime += timeSpent / 60
+		batch.xpTxns = append(batch.xpTxns, model.XPTransaction{
+			ID:           newID(),
+			UserID:       userID,
+			Source:       model.XPSourceLesson,
+			Amount:       gained,
+			BalanceAfter: xp,
+			ReferenceID:  lesson.ID,
+			CreatedAt:    at,
+		})
+
+		if !unlocked[lesson.CharacterID] {
+			unlocked[lesson.CharacterID] = true
+			batch.characters = append(batch.characters, model.UserCharacter{
+				ID:          newID(),
+				UserID:      userID,
+				CharacterID: lesson.CharacterID,
+				UnlockedAt:  at,
+				Source:      model.UnlockSourceLesson,
+				CreatedAt:   at,
+			})
+		}
+	}
+
+	// Abandoned attempts at the next lesson
+	if completed < len(lessons) && rng.Float64() < 0.4 {
+		batch.attempts = append(batch.attempts, model.UserLessonAttempt{
+			ID:            newID(),
+			UserID:        userID,
+			LessonID:      lessons[completed].ID,
+			Score:         rng.Intn(60),
+			TimeSpent:     30 + rng.Intn(300),
+			AttemptsCount: 1 + rng.Intn(3),
+			CreatedAt:     lastActive,
+			UpdatedAt:     lastActive,
+		})
+	}
+
+	streak := 0
+	if s.now.Sub(lastActive) < 48*time.Hour && completed > 0 {
+		streak = 1 + int(math.Pow(rng.Float64(), 2)*60)
+	}
+	emptyArray := model.JSONB("[]")
+	batch.progress = append(batch.progress, model.UserProgress{
+		ID:                 newID(),
+		UserID:             userID,
+		Hearts:             rng.Intn(6),
+		MaxHearts:          5,
+		XP:                 xp,
+		Level:              loadTestLevel(xp),
+		CompletedLessons:   emptyArray,
+		UnlockedCharacters: emptyArray,
+		Streak:             streak,
+		TotalPlayTime:      playTime,
+		LastHeartReset:     &lastActive,
+		LastActivityDate:   &lastActive,
+		CreatedAt:          createdAt,
+		UpdatedAt:          lastActive,
+	})
+
+	spiritType := loadTestZodiac[(birthYear-4)%12]
+	stage, spiritXP, xpToNext := loadTestSpiritStage(xp)
+	batch.spirits = append(batch.spirits, model.Spirit{
+		ID:        newID(),
+		UserID:    userID,
+		Type:      spiritType,
+		Stage:     stage,
+		XP:        spiritXP,
+		XPToNext:  xpToNext,
+		ImageURL:  fmt.Sprintf("/assets/spirits/%s_stage_%d.png", spiritType, stage),
+		CreatedAt: createdAt,
+		UpdatedAt: lastActive,
+	})
+
+	sessions := 1 + rng.Intn(3)
+	for n := 0; n < sessions; n++ {
+		created := lastActive.Add(-time.Duration(rng.Int63n(int64(14 * 24 * time.Hour))))
+		if created.Before(createdAt) {
+			created = createdAt
+		}
+		token := make([]byte, 32)
+		rng.Read(token)
+		batch.sessions = append(batch.sessions, model.UserSession{
+			ID:               newID(),
+			UserID:           userID,
+			TokenHash:        hex.EncodeToString(token),
+			RefreshExpiresAt: created.Add(30 * 24 * time.Hour),
+			DeviceID:         fmt.Sprintf("lt-device-%07d-%d", index, n),
+			IP:               fmt.Sprintf("10.%d.%d.%d", rng.Intn(256), rng.Intn(256), 1+rng.Intn(254)),
+			UserAgent:        loadTestUserAgents[rng.Intn(len(loadTestUserAgents))],
+			CreatedAt:        created,
+			LastUsed:         lastActive,
+			IsActive:         s.now.Sub(lastActive) < 7*24*time.Hour,
+			ExpiresAt:        lastActive.Add(24 * time.Hour),
+		})
+	}
+}
+
+// insertBatch writes a batch in one transaction. Rows that already exist from an
+// earlier run with the same seed are skipped.
+func (s *LoadTestSeeder) insertBatch(batch *loadTestBatch) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for _, rows := range []interface{}{
+			&batch.users, &batch.progress, &batch.spirits, &batch.sessions,
+			&batch.completions, &batch.attempts, &batch.xpTxns, &batch.characters,
+		} {
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Omit(clause.Associations).
+				CreateInBatches(rows, loadTestBatchSize).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// loadTestLevel mirrors UserService.calculateLevel
+func loadTestLevel(totalXP int) int {
+	level, required := 1, 100
+	for totalXP >= required {
+		totalXP -= required
+		level++
+		required = int(float64(required) * 1.5)
+	}
+	return level
+}
+
+// loadTestSpiritStage mirrors the evolution thresholds in UserService.updateSpiritXP
+func loadTestSpiritStage(totalXP int) (stage, xp, xpToNext int) {
+	thresholds := []int{500, 1000, 2000, 3500, 5000}
+	stage, xp = 1, totalXP
+	for stage < 5 && xp >= thresholds[stage-1] {
+		xp -= thresholds[stage-1]
+		stage++
+	}
+	return stage, xp, thresholds[stage-1]
+}
@@ -0,0 +1,369 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	"github.com/lac-hong-legacy/ven_api/shared/ids"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultRecoveryFallbackDelay = 72 * time.Hour
+
+	// How long a recovery stays usable after the fallback delay
+	recoveryCompleteWindow = 7 * 24 * time.Hour
+
+	recoveryHistoryLimit = 20
+)
+
+// StartAccountRecovery is used by someone who can't reach the account's email anymore.
+// Trusted devices are asked to approve the move to the new address and the old address
+// gets a link to cancel it. The returned token is needed to complete the recovery.
+//
+// Unknown accounts get a response that looks the same, so the endpoint can't be used to
+// find out which accounts exist.
+func (svc *AuthService) StartAccountRecovery(req dto.StartAccountRecoveryRequest, clientIP, userAgent string) (*dto.AccountRecoveryStartedResponse, error) {
+	// Checked before the account is looked up, so the answer is the same for every account
+	available, err := svc.userRepo.IsEmailAvailable(req.NewEmail)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to check email availability")
+	}
+	if !available {
+		return nil, shared.NewConflictError(errors.New("email taken"), "Email is already taken")
+	}
+
+	token, err := generateRecoveryToken()
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to generate recovery token")
+	}
+	cancelToken, err := generateRecoveryToken()
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to generate recovery token")
+	}
+
+	now := time.Now()
+	recovery := &model.AccountRecoveryRequest{
+		NewEmail:        req.NewEmail,
+		Status:          model.AccountRecoveryPending,
+		TokenHash:       svc.hashToken(token),
+		CancelTokenHash: svc.hashToken(cancelToken),
+		RequestIP:       clientIP,
+		UserAgent:       userAgent,
+		FallbackAt:      now.Add(svc.recoveryFallbackDelay),
+		ExpiresAt:       now.Add(svc.recoveryFallbackDelay + recoveryCompleteWindow),
+	}
+	recovery.ID = ids.New()
+
+	response := &dto.AccountRecoveryStartedResponse{
+		RecoveryID: recovery.ID,
+		Token:      token,
+		Status:     recovery.Status,
+		FallbackAt: recovery.FallbackAt,
+		ExpiresAt:  recovery.ExpiresAt,
+	}
+
+	user, err := svc.userRepo.GetUserByEmailOrUsername(req.EmailOrUsername)
+	if err != nil || !user.IsActive {
+		return response, nil
+	}
+	recovery.UserID = user.ID
+
+	messages := []*model.OutboxMessage{
+		newOutboxMessage(OutboxTopicAccountRecoveryEmail, AccountRecoveryEmail{
+			Email:       user.Email,
+			Username:    user.Username,
+			NewEmail:    req.NewEmail,
+			RequestIP:   clientIP,
+			FallbackAt:  recovery.FallbackAt.Local().Format("2006-01-02 15:04"),
+			CancelToken: cancelToken,
+		}),
+		newOutboxMessage(OutboxTopicAuthAudit, dto.AuthAuditLog{
+			UserID:    user.ID,
+			Action:    "account_recovery_requested",
+			IP:        clientIP,
+			UserAgent: userAgent,
+			Timestamp: now,
+			Success:   true,
+			Details:   fmt.Sprintf("recovery %s: new email %s", recovery.ID, req.NewEmail),
+		}),
+	}
+
+	if err := svc.userRepo.CreateAccountRecoveryRequest(recovery, messages...); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to start account recovery")
+	}
+	go svc.outboxSvc.Relay(messages...)
+
+	// Every signed-in device sees this in its inbox; only trusted ones can answer it
+	svc.notificationSvc.Notify(user.ID, model.NotificationTypeSecurity, "Account recovery requested",
+		fmt.Sprintf("Someone asked to move your account to %s. Approve it from a trusted device only if it was you.", req.NewEmail),
+		map[string]interface{}{
+			"recovery_id": recovery.ID,
+			"new_email":   req.NewEmail,
+			"ip":          clientIP,
+			"fallback_at": recovery.FallbackAt,
+		})
+
+	return response, nil
+}
+
+// GetAccountRecoveryStatus lets the requester follow their recovery request
+func (svc *AuthService) GetAccountRecoveryStatus(recoveryID, token string) (*dto.AccountRecoveryStatusResponse, error) {
+	recovery, err := svc.getRecoveryWithToken(recoveryID, token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.AccountRecoveryStatusResponse{
+		RecoveryID:  recovery.ID,
+		Status:      recovery.Status,
+		CanComplete: svc.recoveryCompletable(recovery, time.Now()) == nil,
+		FallbackAt:  recovery.FallbackAt,
+		ExpiresAt:   recovery.ExpiresAt,
+	}, nil
+}
+
+// GetAccountRecoveries lists the recent recovery requests of the signed-in user
+func (svc *AuthService) GetAccountRecoveries(userID string) (*dto.AccountRecoveryListResponse, error) {
+	requests, err := svc.userRepo.GetUserAccountRecoveries(userID, recoveryHistoryLimit)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get account recovery requests")
+	}
+
+	infos := make([]dto.AccountRecoveryInfo, len(requests))
+	for i, recovery := range requests {
+		infos[i] = dto.AccountRecoveryInfo{
+			ID:              recovery.ID,
+			NewEmail:        recovery.NewEmail,
+			Status:          recovery.Status,
+			RequestIP:       recovery.RequestIP,
+			UserAgent:       recovery.UserAgent,
+			DecidedByDevice: recovery.DecidedByDevice,
+			DecidedAt:       recovery.DecidedAt,
+			FallbackAt:      recovery.FallbackAt,
+			ExpiresAt:       recovery.ExpiresAt,
+			CompletedAt:     recovery.CompletedAt,
+			CreatedAt:       recovery.CreatedAt,
+		}
+	}
+
+	return &dto.AccountRecoveryListResponse{Requests: infos}, nil
+}
+
+// DecideAccountRecovery approves or denies a pending recovery. It has to come from a
+// session on one of the user's trusted devices.
+func (svc *AuthService) DecideAccountRecovery(userID, sessionID, recoveryID string, approve bool, clientIP, userAgent string) error {
+	session, err := svc.userRepo.GetSessionByID(sessionID)
+	if err != nil || session.UserID != userID || session.DeviceID == "" {
+		return shared.NewForbiddenError(errors.New("no device"), "Account recovery can only be answered from a trusted device")
+	}
+
+	device, err := svc.userRepo.GetTrustedDevice(userID, session.DeviceID)
+	if err != nil || !device.IsTrusted {
+		return shared.NewForbiddenError(errors.New("device not trusted"), "Account recovery can only be answered from a trusted device")
+	}
+
+	recovery, err := svc.userRepo.GetAccountRecoveryRequest(recoveryID)
+	if err != nil || recovery.UserID != userID {
+		return shared.NewNotFoundError(err, "Account recovery request not found")
+	}
+
+	now := time.Now()
+	if recovery.Status != model.AccountRecoveryPending || now.After(recovery.ExpiresAt) {
+		return shared.NewBadRequestError(errors.New("not pending"), "This account recovery is no longer pending")
+	}
+
+	action := "account_recovery_denied"
+	recovery.Status = model.AccountRecoveryDenied
+	if approve {
+		action = "account_recovery_approved"
+		recovery.Status = model.AccountRecoveryApproved
+	}
+	recovery.DecidedByDevice = device.DeviceID
+	recovery.DecidedAt = &now
+
+	audit := newOutboxMessage(OutboxTopicAuthAudit, dto.AuthAuditLog{
+		UserID:    userID,
+		Action:    action,
+		IP:        clientIP,
+		UserAgent: userAgent,
+		Timestamp: now,
+		Success:   true,
+		Details:   fmt.Sprintf("recovery %s: by device %s", recovery.ID, device.DeviceID),
+	})
+
+	updated, err := svc.userRepo.UpdateAccountRecoveryStatus(recovery, []string{model.AccountRecoveryPending}, audit)
+	if err != nil {
+		return shared.NewInternalError(err, "Failed to update account recovery")
+	}
+	if !updated {
+		return shared.NewConflictError(errors.New("status changed"), "This account recovery is no longer pending")
+	}
+
+	go svc.outboxSvc.Relay(audit)
+	return nil
+}
+
+// CancelAccountRecovery is used from the link sent to the account's current address
+func (svc *AuthService) CancelAccountRecovery(cancelToken, clientIP, userAgent string) error {
+	recovery, err := svc.userRepo.GetAccountRecoveryByCancelToken(svc.hashToken(cancelToken))
+	if err != nil {
+		return shared.NewBadRequestError(err, "Invalid or expired link")
+	}
+
+	now := time.Now()
+	if now.After(recovery.ExpiresAt) {
+		return shared.NewBadRequestError(errors.New("link expired"), "Invalid or expired link")
+	}
+
+	recovery.Status = model.AccountRecoveryCancelled
+	audit := newOutboxMessage(OutboxTopicAuthAudit, dto.AuthAuditLog{
+		UserID:    recovery.UserID,
+		Action:    "account_recovery_cancelled",
+		IP:        clientIP,
+		UserAgent: userAgent,
+		Timestamp: now,
+		Success:   true,
+		Details:   fmt.Sprintf("recovery %s: cancelled from email link", recovery.ID),
+	})
+
+	updated, err := svc.userRepo.UpdateAccountRecoveryStatus(recovery,
+		[]string{model.AccountRecoveryPending, model.AccountRecoveryApproved}, audit)
+	if err != nil {
+		return shared.NewInternalError(err, "Failed to cancel account recovery")
+	}
+	if !updated {
+		return shared.NewBadRequestError(errors.New("not active"), "This account recovery is no longer active")
+	}
+
+	go svc.outboxSvc.Relay(audit)
+	return nil
+}
+
+// CompleteAccountRecovery moves the account to the new address with a new password and
+// signs out every session. The new address then has to be verified like on registration.
+func (svc *AuthService) CompleteAccountRecovery(req dto.CompleteAccountRecoveryRequest, clientIP, userAgent string) error {
+	if err := svc.validatePassword(req.NewPassword); err != nil {
+		return shared.NewBadRequestError(err, err.Error())
+	}
+
+	recovery, err := svc.getRecoveryWithToken(req.RecoveryID, req.Token)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := svc.recoveryCompletable(recovery, now); err != nil {
+		return err
+	}
+
+	user, err := svc.userRepo.GetUserByID(recovery.UserID)
+	if err != nil {
+		return shared.NewNotFoundError(err, "User not found")
+	}
+
+	// The address may have been registered since the recovery was requested
+	available, err := svc.userRepo.IsEmailAvailable(recovery.NewEmail)
+	if err != nil {
+		return shared.NewInternalError(err, "Failed to check email availability")
+	}
+	if !available {
+		return shared.NewConflictError(errors.New("email taken"), "Email is already taken")
+	}
+
+	hashedPassword, err := svc.hashPassword(req.NewPassword)
+	if err != nil {
+		return shared.NewInternalError(err, "Failed to hash password")
+	}
+
+	verificationCode, err := svc.generateVerificationCode()
+	if err != nil {
+		return shared.NewInternalError(err, "Failed to generate verification code")
+	}
+
+	approval := "trusted device " + recovery.DecidedByDevice
+	if recovery.Status == model.AccountRecoveryPending {
+		approval = "fallback delay"
+	}
+
+	messages := []*model.OutboxMessage{
+		newOutboxMessage(OutboxTopicVerificationEmail, VerificationEmail{
+			Email:            recovery.NewEmail,
+			Username:         user.Username,
+			VerificationCode: verificationCode,
+			VerificationLink: svc.buildVerificationLink(user.ID, recovery.NewEmail, verificationCode),
+		}),
+		newOutboxMessage(OutboxTopicAuthAudit, dto.AuthAuditLog{
+			UserID:    user.ID,
+			Action:    "account_recovery_completed",
+			IP:        clientIP,
+			UserAgent: userAgent,
+			Timestamp: now,
+			Success:   true,
+			Details:   fmt.Sprintf("recovery %s: email changed from %s to %s, approved by %s", recovery.ID, user.Email, recovery.NewEmail, approval),
+		}),
+	}
+
+	completed, err := svc.userRepo.CompleteAccountRecovery(recovery, hashedPassword, verificationCode, messages...)
+	if err != nil {
+		return shared.NewInternalError(err, "Failed to complete account recovery")
+	}
+	if !completed {
+		return shared.NewConflictError(errors.New("status changed"), "This account recovery is no longer active")
+	}
+	go svc.outboxSvc.Relay(messages...)
+
+	if err := svc.userRepo.DeactivateAllUserSessions(user.ID, ""); err != nil {
+		log.WithError(err).Error("Failed to revoke sessions after account recovery")
+	}
+	svc.invalidateAuthState(user.ID)
+	return nil
+}
+
+func (svc *AuthService) getRecoveryWithToken(recoveryID, token string) (*model.AccountRecoveryRequest, error) {
+	recovery, err := svc.userRepo.GetAccountRecoveryRequest(recoveryID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Account recovery request not found")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(svc.hashToken(token)), []byte(recovery.TokenHash)) != 1 {
+		return nil, shared.NewNotFoundError(errors.New("invalid token"), "Account recovery request not found")
+	}
+	return recovery, nil
+}
+
+// recoveryCompletable explains why a recovery can't be completed yet, or returns nil.
+// Approved requests complete right away, unanswered ones after the fallback delay.
+func (svc *AuthService) recoveryCompletable(recovery *model.AccountRecoveryRequest, now time.Time) error {
+	if now.After(recovery.ExpiresAt) {
+		return shared.NewBadRequestError(errors.New("expired"), "This account recovery has expired")
+	}
+
+	switch recovery.Status {
+	case model.AccountRecoveryApproved:
+		return nil
+	case model.AccountRecoveryPending:
+		if now.Before(recovery.FallbackAt) {
+			return shared.NewForbiddenError(errors.New("awaiting approval"),
+				fmt.Sprintf("Waiting for approval from a trusted device until %s", recovery.FallbackAt.Format(time.RFC3339)))
+		}
+		return nil
+	default:
+		return shared.NewBadRequestError(errors.New("not active"), "This account recovery is no longer active")
+	}
+}
+
+func generateRecoveryToken() (string, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(tokenBytes), nil
+}
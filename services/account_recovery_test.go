@@ -0,0 +1,32 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/services/repositories/mocks"
+	"github.com/lac-hong-legacy/ven_api/shared"
+)
+
+// A taken new address is refused the same way whether or not the account exists, so the
+// answer doesn't reveal which accounts do
+func TestStartAccountRecoveryTakenEmail(t *testing.T) {
+	for _, known := range []bool{true, false} {
+		svc := &AuthService{userRepo: &mocks.UserRepo{
+			IsEmailAvailableFunc: func(email string) (bool, error) { return false, nil },
+			GetUserByEmailOrUsernameFunc: func(emailOrUsername string) (*model.User, error) {
+				if !known {
+					return nil, shared.NewNotFoundError(nil, "User not found")
+				}
+				return &model.User{ID: "user_1", IsActive: true}, nil
+			},
+		}}
+
+		_, err := svc.StartAccountRecovery(dto.StartAccountRecoveryRequest{EmailOrUsername: "ngoquyen", NewEmail: "taken@example.com"}, "203.0.113.7", "")
+		if appErr, ok := shared.GetAppError(err); !ok || appErr.StatusCode != http.StatusConflict {
+			t.Errorf("known account %v: got %v, want a conflict", known, err)
+		}
+	}
+}
@@ -0,0 +1,321 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/cloakd/common/context"
+	serviceContext "github.com/cloakd/common/services"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type AchievementService struct {
+	serviceContext.DefaultService
+
+	sqlSvc          *PostgresService
+	userSvc         *UserService
+	notificationSvc *NotificationService
+	eventBusSvc     *EventBusService
+	shopSvc         *ShopService
+}
+
+const ACHIEVEMENT_SVC = "achievement_svc"
+
+func (svc AchievementService) Id() string {
+	return ACHIEVEMENT_SVC
+}
+
+func (svc *AchievementService) Configure(ctx *context.Context) error {
+	return svc.DefaultService.Configure(ctx)
+}
+
+func (svc *AchievementService) Start() error {
+	deps := newDependencies(svc.Id(), svc.Service)
+	svc.sqlSvc = resolve[*PostgresService](deps, POSTGRES_SVC)
+	svc.userSvc = resolve[*UserService](deps, USER_SVC)
+	svc.notificationSvc = resolve[*NotificationService](deps, NOTIFICATION_SVC)
+	svc.eventBusSvc = resolve[*EventBusService](deps, EVENT_BUS_SVC)
+	svc.shopSvc = resolve[*ShopService](deps, SHOP_SVC)
+	if err := deps.err(); err != nil {
+		return err
+	}
+
+	svc.seedTieredAchievements()
+
+	svc.eventBusSvc.Subscribe(EventLessonCompleted, ACHIEVEMENT_SVC, func(event DomainEvent) {
+		e := event.(*LessonCompletedEvent)
+		if !e.FirstCompletion {
+			return
+		}
+		svc.Increment(e.UserID, model.AchievementMetricLessonsCompleted, 1)
+		if e.Score >= 100 {
+			svc.Increment(e.UserID, model.AchievementMetricPerfectLessons, 1)
+		}
+	})
+	svc.eventBusSvc.Subscribe(EventCharacterUnlocked, ACHIEVEMENT_SVC, func(event DomainEvent) {
+		svc.Increment(event.(*CharacterUnlockedEvent).UserID, model.AchievementMetricCharactersUnlocked, 1)
+	})
+
+	return nil
+}
+
+// defaultTieredAchievements are created on startup if missing
+var defaultTieredAchievements = []struct {
+	id          string
+	name        string
+	description string
+	category    string
+	metric      string
+	tiers       []model.AchievementTier
+}{
+	{"tiered-lessons-completed", "Scholar", "Complete lessons", "learning", model.AchievementMetricLessonsCompleted, []model.AchievementTier{
+		{Tier: model.AchievementTierBronze, Target: 10, XPReward: 50},
+		{Tier: model.AchievementTierSilver, Target: 50, XPReward: 150},
+		{Tier: model.AchievementTierGold, Target: 200, XPReward: 500},
+	}},
+	{"tiered-perfect-lessons", "Perfectionist", "Finish lessons with a perfect score", "learning", model.AchievementMetricPerfectLessons, []model.AchievementTier{
+		{Tier: model.AchievementTierBronze, Target: 5, XPReward: 50},
+		{Tier: model.AchievementTierSilver, Target: 25, XPReward: 150},
+		{Tier: model.AchievementTierGold, Target: 100, XPReward: 500},
+	}},
+	{"tiered-streak-days", "Dedicated", "Keep a daily learning streak", "streak", model.AchievementMetricStreakDays, []model.AchievementTier{
+		{Tier: model.AchievementTierBronze, Target: 7, XPReward: 50},
+		{Tier: model.AchievementTierSilver, Target: 30, XPReward: 200},
+		{Tier: model.AchievementTierGold, Target: 100, XPReward: 750},
+	}},
+	{"tiered-characters-unlocked", "Collector", "Unlock historical characters", "collection", model.AchievementMetricCharactersUnlocked, []model.AchievementTier{
+		{Tier: model.AchievementTierBronze, Target: 5, XPReward: 50},
+		{Tier: model.AchievementTierSilver, Target: 15, XPReward: 150},
+		{Tier: model.AchievementTierGold, Target: 30, XPReward: 500},
+	}},
+	{"tiered-battles-won", "Spirit Warrior", "Win spirit battles", "special", model.AchievementMetricBattlesWon, []model.AchievementTier{
+		{Tier: model.AchievementTierBronze, Target: 5, XPReward: 50},
+		{Tier: model.AchievementTierSilver, Target: 25, XPReward: 150},
+		{Tier: model.AchievementTierGold, Target: 100, XPReward: 500},
+	}},
+}
+
+func (svc *AchievementService) seedTieredAchievements() {
+	for _, def := range defaultTieredAchievements {
+		tiers, err := json.Marshal(def.tiers)
+		if err != nil {
+			log.Printf("Failed to marshal tiers for achievement %s: %v", def.id, err)
+			continue
+		}
+
+		err = svc.sqlSvc.contentRepo.EnsureAchievement(&model.Achievement{
+			ID:          def.id,
+			Name:        def.name,
+			Description: def.description,
+			Category:    def.category,
+			Metric:      def.metric,
+			Tiers:       tiers,
+			IsActive:    true,
+		})
+		if err != nil {
+			log.Printf("Failed to seed achievement %s: %v", def.id, err)
+		}
+	}
+}
+
+// Increment records delta more occurrences of a counted metric, e.g. a lesson completion.
+// Failures are logged so achievement tracking never breaks the caller.
+func (svc *AchievementService) Increment(userID, metric string, delta int) {
+	svc.track(userID, metric, func(progressID string) (int, error) {
+		return svc.sqlSvc.contentRepo.IncrementAchievementProgress(progressID, delta)
+	})
+}
+
+// Observe records the latest value of a high-water-mark metric such as the daily streak
+func (svc *AchievementService) Observe(userID, metric string, value int) {
+	svc.track(userID, metric, func(progressID string) (int, error) {
+		return svc.sqlSvc.contentRepo.RaiseAchievementProgress(progressID, value)
+	})
+}
+
+func (svc *AchievementService) track(userID, metric string, apply func(progressID string) (int, error)) {
+	achievements, err := svc.sqlSvc.contentRepo.GetActiveAchievementsByMetric(metric)
+	if err != nil {
+		log.Printf("Failed to load %s achievements: %v", metric, err)
+		return
+	}
+
+	for i := range achievements {
+		achievement := &achievements[i]
+		tiers := svc.parseTiers(achievement)
+		if len(tiers) == 0 {
+			continue
+		}
+
+		progress, created, err := svc.getOrCreateProgress(userID, achievement)
+		if err != nil {
+			log.Printf("Failed to load achievement progress %s for user %s: %v", achievement.ID, userID, err)
+			continue
+		}
+
+		// A freshly seeded row already counts the current event
+		if !created {
+			if progress.Current, err = apply(progress.ID); err != nil {
+				log.Printf("Failed to update achievement progress %s for user %s: %v", achievement.ID, userID, err)
+				continue
+			}
+		}
+
+		svc.unlockTiers(userID, achievement, tiers, progress)
+	}
+}
+
+// getOrCreateProgress loads a user's progress row, seeding a new one from history the
+// first time the achievement is seen
+func (svc *AchievementService) getOrCreateProgress(userID string, achievement *model.Achievement) (*model.UserAchievementProgress, bool, error) {
+	progress, err := svc.sqlSvc.contentRepo.GetAchievementProgress(userID, achievement.ID)
+	if err == nil {
+		return progress, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+
+	current, err := svc.sqlSvc.contentRepo.CountAchievementMetric(userID, achievement.Metric)
+	if err != nil {
+		return nil, false, err
+	}
+
+	progress = &model.UserAchievementProgress{
+		UserID:        userID,
+		AchievementID: achievement.ID,
+		Current:       current,
+	}
+	created, err := svc.sqlSvc.contentRepo.CreateAchievementProgress(progress)
+	if err != nil {
+		return nil, false, err
+	}
+	if !created {
+		// Lost a race with another event; use the row that won
+		progress, err = svc.sqlSvc.contentRepo.GetAchievementProgress(userID, achievement.ID)
+		return progress, false, err
+	}
+
+	return progress, true, nil
+}
+
+// unlockTiers grants every tier the progress has reached since the last unlock
+func (svc *AchievementService) unlockTiers(userID string, achievement *model.Achievement, tiers []model.AchievementTier, progress *model.UserAchievementProgress) {
+	reached := tierIndex(tiers, progress.TierReached)
+	next := reached
+	for next+1 < len(tiers) && progress.Current >= tiers[next+1].Target {
+		next++
+	}
+	if next == reached {
+		return
+	}
+
+	advanced, err := svc.sqlSvc.contentRepo.AdvanceAchievementTier(progress.ID, progress.TierReached, tiers[next].Tier)
+	if err != nil {
+		log.Printf("Failed to advance achievement %s for user %s: %v", achievement.ID, userID, err)
+		return
+	}
+	if !advanced {
+		return
+	}
+	progress.TierReached = tiers[next].Tier
+
+	xpReward := 0
+	for _, tier := range tiers[reached+1 : next+1] {
+		if err := svc.sqlSvc.contentRepo.CreateUserAchievement(&model.UserAchievement{
+			UserID:        userID,
+			AchievementID: achievement.ID,
+			Tier:          tier.Tier,
+		}); err != nil {
+			log.Printf("Failed to record %s tier of achievement %s for user %s: %v", tier.Tier, achievement.ID, userID, err)
+		}
+		xpReward += tier.XPReward
+		svc.shopSvc.AwardAchievementCoins(userID, achievement.ID, tier.Tier)
+	}
+	svc.eventBusSvc.Publish(&ProgressChangedEvent{UserID: userID, Reason: "achievement"})
+
+	if xpReward > 0 {
+		if err := svc.userSvc.awardXP(userID, xpReward, model.XPSourceAchievement, achievement.ID); err != nil {
+			log.Printf("Failed to award achievement XP to user %s: %v", userID, err)
+		}
+	}
+
+	svc.notificationSvc.Notify(userID, model.NotificationTypeAchievement, "Achievement unlocked",
+		fmt.Sprintf("%s (%s) unlocked", achievement.Name, tiers[next].Tier),
+		map[string]interface{}{"achievement_id": achievement.ID, "tier": tiers[next].Tier, "xp": xpReward})
+}
+
+// GetUserAchievementProgress returns the user's progress towards every tiered achievement
+func (svc *AchievementService) GetUserAchievementProgress(userID string) ([]dto.AchievementProgressResponse, error) {
+	achievements, err := svc.sqlSvc.contentRepo.GetTieredAchievements()
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]dto.AchievementProgressResponse, 0, len(achievements))
+	for i := range achievements {
+		achievement := &achievements[i]
+		tiers := svc.parseTiers(achievement)
+		if len(tiers) == 0 {
+			continue
+		}
+
+		progress, _, err := svc.getOrCreateProgress(userID, achievement)
+		if err != nil {
+			return nil, err
+		}
+		// Rows seeded from history may already qualify for tiers
+		svc.unlockTiers(userID, achievement, tiers, progress)
+
+		response := dto.AchievementProgressResponse{
+			AchievementID: achievement.ID,
+			Name:          achievement.Name,
+			Description:   achievement.Description,
+			BadgeURL:      achievement.BadgeURL,
+			Category:      achievement.Category,
+			Metric:        achievement.Metric,
+			Current:       progress.Current,
+			TierReached:   progress.TierReached,
+		}
+
+		reached := tierIndex(tiers, progress.TierReached)
+		if reached+1 < len(tiers) {
+			response.NextTier = tiers[reached+1].Tier
+			response.Target = tiers[reached+1].Target
+		} else {
+			response.Target = tiers[len(tiers)-1].Target
+			response.Completed = true
+		}
+
+		responses = append(responses, response)
+	}
+
+	return responses, nil
+}
+
+func (svc *AchievementService) parseTiers(achievement *model.Achievement) []model.AchievementTier {
+	if len(achievement.Tiers) == 0 {
+		return nil
+	}
+
+	var tiers []model.AchievementTier
+	if err := json.Unmarshal(achievement.Tiers, &tiers); err != nil {
+		log.Printf("Failed to parse tiers for achievement %s: %v", achievement.ID, err)
+		return nil
+	}
+	return tiers
+}
+
+// tierIndex returns the position of tier in tiers, or -1 if no tier has been reached
+func tierIndex(tiers []model.AchievementTier, tier string) int {
+	for i, t := range tiers {
+		if t.Tier == tier {
+			return i
+		}
+	}
+	return -1
+}
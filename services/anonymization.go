@@ -0,0 +1,182 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultAnonymizeInactiveDays = 730
+	anonymizationRunHistory      = 50
+)
+
+var defaultAnonymizeWarningDays = []int{30, 7}
+
+type InactivityWarningEmail struct {
+	Email       string
+	Username    string
+	AnonymizeOn string
+}
+
+// configureAnonymization reads the inactive account anonymization settings. The job is
+// off unless ANONYMIZE_INACTIVE_ENABLED is set.
+func (svc *RetentionService) configureAnonymization() error {
+	svc.anonymizeEnabled, _ = strconv.ParseBool(os.Getenv("ANONYMIZE_INACTIVE_ENABLED"))
+
+	svc.anonymizeInactiveDays = defaultAnonymizeInactiveDays
+	if v := os.Getenv("ANONYMIZE_INACTIVE_DAYS"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 1 {
+			return fmt.Errorf("invalid ANONYMIZE_INACTIVE_DAYS: %q", v)
+		}
+		svc.anonymizeInactiveDays = days
+	}
+
+	// Days before anonymization that each warning is sent, e.g. "30,7". Empty sends none.
+	svc.anonymizeWarningDays = defaultAnonymizeWarningDays
+	if v, ok := os.LookupEnv("ANONYMIZE_WARNING_DAYS"); ok {
+		svc.anonymizeWarningDays = nil
+		for _, field := range strings.Split(v, ",") {
+			if field = strings.TrimSpace(field); field == "" {
+				continue
+			}
+			days, err := strconv.Atoi(field)
+			if err != nil || days < 1 || days >= svc.anonymizeInactiveDays {
+				return fmt.Errorf("invalid ANONYMIZE_WARNING_DAYS: %q", v)
+			}
+			svc.anonymizeWarningDays = append(svc.anonymizeWarningDays, days)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(svc.anonymizeWarningDays)))
+
+	return nil
+}
+
+func (svc *RetentionService) GetAnonymizationRuns() ([]model.AnonymizationRun, error) {
+	runs, err := svc.sqlSvc.retentionRepo.GetAnonymizationRuns(anonymizationRunHistory)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to load anonymization runs")
+	}
+	return runs, nil
+}
+
+// anonymizeInactiveUsers warns and then anonymizes accounts inactive for
+// anonymizeInactiveDays. Warnings go out in order, each once its number of days before
+// anonymization is reached, and an account is only anonymized once the last warning is
+// that many days old, so users inactive for longer when the job is enabled still get
+// the full notice. Activity after a warning starts the count over.
+func (svc *RetentionService) anonymizeInactiveUsers() *model.AnonymizationRun {
+	run := &model.AnonymizationRun{InactiveDays: svc.anonymizeInactiveDays, StartedAt: time.Now()}
+	if err := svc.sqlSvc.retentionRepo.CreateAnonymizationRun(run); err != nil {
+		log.WithError(err).Error("Failed to record anonymization run")
+	}
+
+	now := time.Now()
+	firstNotice := svc.anonymizeInactiveDays
+	if len(svc.anonymizeWarningDays) > 0 {
+		firstNotice -= svc.anonymizeWarningDays[0]
+	}
+	inactiveSince := now.AddDate(0, 0, -firstNotice)
+	log.Printf("Anonymization: checking accounts inactive since %s", inactiveSince.Format("2006-01-02"))
+
+	afterID := ""
+	for {
+		users, err := svc.sqlSvc.retentionRepo.GetInactiveUsers(inactiveSince, afterID, svc.batchSize)
+		if err != nil {
+			log.WithError(err).Error("Failed to load inactive users")
+			run.Error = err.Error()
+			break
+		}
+
+		for i := range users {
+			switch svc.anonymizeStep(&users[i], now) {
+			case "warned":
+				run.Warned++
+			case "anonymized":
+				run.Anonymized++
+			case "failed":
+				run.Failed++
+			}
+		}
+
+		if len(users) < svc.batchSize {
+			break
+		}
+		afterID = users[len(users)-1].ID
+		time.Sleep(retentionBatchPause)
+	}
+
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	if err := svc.sqlSvc.retentionRepo.UpdateAnonymizationRun(run); err != nil {
+		log.WithError(err).Error("Failed to record anonymization run")
+	}
+
+	log.Printf("Anonymization: %d account(s) warned, %d anonymized, %d failed", run.Warned, run.Anonymized, run.Failed)
+	return run
+}
+
+// anonymizeStep sends the user's next warning or anonymizes them when either is due,
+// returning what was done or an empty string
+func (svc *RetentionService) anonymizeStep(user *model.InactiveUser, now time.Time) string {
+	deadline := user.LastActiveAt.AddDate(0, 0, svc.anonymizeInactiveDays)
+	warnings := svc.anonymizeWarningDays
+	sent := user.Warnings
+
+	// Time since the previous warning, compared with the gap the schedule puts after it
+	sinceWarning := func(days int) bool {
+		return sent == 0 || user.WarnedAt == nil || !now.Before(user.WarnedAt.AddDate(0, 0, days))
+	}
+
+	if sent < len(warnings) {
+		gap := 0
+		if sent > 0 {
+			gap = warnings[sent-1] - warnings[sent]
+		}
+		if now.Before(deadline.AddDate(0, 0, -warnings[sent])) || !sinceWarning(gap) {
+			return ""
+		}
+
+		message := newOutboxMessage(OutboxTopicInactivityWarningEmail, InactivityWarningEmail{
+			Email:       user.Email,
+			Username:    user.Username,
+			AnonymizeOn: now.AddDate(0, 0, warnings[sent]).Format("2006-01-02"),
+		})
+		claimed, err := svc.sqlSvc.retentionRepo.ClaimInactivityWarning(user, sent+1, message)
+		if err != nil {
+			log.WithError(err).Errorf("Failed to warn inactive user %s", user.ID)
+			return "failed"
+		}
+		if !claimed {
+			return ""
+		}
+		go svc.outboxSvc.Relay(message)
+		return "warned"
+	}
+
+	if now.Before(deadline) || (len(warnings) > 0 && !sinceWarning(warnings[len(warnings)-1])) {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(user.ID))
+	username := "anon_" + hex.EncodeToString(sum[:8])
+	anonymized, err := svc.sqlSvc.retentionRepo.AnonymizeUser(user, username, user.ID+"@anonymized.invalid")
+	if err != nil {
+		log.WithError(err).Errorf("Failed to anonymize inactive user %s", user.ID)
+		return "failed"
+	}
+	if !anonymized {
+		return ""
+	}
+	return "anonymized"
+}
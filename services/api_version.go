@@ -0,0 +1,122 @@
+package services
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+// API versions served side by side. Every version mounts the same routes on the same
+// services; handlers that answer differently read the version from
+// c.Locals(shared.APIVersion).
+const (
+	APIVersion1 = "v1"
+	APIVersion2 = "v2"
+
+	apiPathPrefix = "/api/"
+)
+
+// APIVersions lists the served versions, oldest first
+var APIVersions = []string{APIVersion1, APIVersion2}
+
+var apiVersionRequestsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "api_version_requests_total",
+		Help: "API requests by version and route, to track old-client traffic before a version is removed",
+	},
+	[]string{"version", "method", "route"},
+)
+
+// apiVersionDeprecation is what deprecated versions announce on every response
+type apiVersionDeprecation struct {
+	deprecatedAt *time.Time
+	sunset       *time.Time
+	successor    string
+}
+
+// loadAPIVersionDeprecations reads API_<VERSION>_DEPRECATED_AT and API_<VERSION>_SUNSET,
+// both RFC 3339 timestamps. A version with neither set is not deprecated.
+func loadAPIVersionDeprecations() map[string]apiVersionDeprecation {
+	deprecations := make(map[string]apiVersionDeprecation)
+	for i, version := range APIVersions {
+		prefix := "API_" + strings.ToUpper(version)
+
+		var d apiVersionDeprecation
+		if at, ok := parseAPIVersionTime(prefix + "_DEPRECATED_AT"); ok {
+			d.deprecatedAt = &at
+		}
+		if at, ok := parseAPIVersionTime(prefix + "_SUNSET"); ok {
+			d.sunset = &at
+		}
+		if d.deprecatedAt == nil && d.sunset == nil {
+			continue
+		}
+		if i+1 < len(APIVersions) {
+			d.successor = APIVersions[len(APIVersions)-1]
+		}
+		deprecations[version] = d
+	}
+	return deprecations
+}
+
+func parseAPIVersionTime(name string) (time.Time, bool) {
+	value := os.Getenv(name)
+	if value == "" {
+		return time.Time{}, false
+	}
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		log.WithError(err).Warnf("Ignoring %s, expected an RFC 3339 timestamp", name)
+		return time.Time{}, false
+	}
+	return at, true
+}
+
+// apiVersion tags requests with their API version, adds the Deprecation, Sunset and
+// successor Link headers (RFC 9745, RFC 8594) when the version is deprecated, and counts
+// requests per version and route
+func (svc *HttpService) apiVersion(version string) fiber.Handler {
+	deprecation, deprecated := svc.apiDeprecations[version]
+
+	return func(c *fiber.Ctx) error {
+		c.Locals(shared.APIVersion, version)
+
+		if deprecated {
+			if deprecation.deprecatedAt != nil {
+				c.Set("Deprecation", "@"+strconv.FormatInt(deprecation.deprecatedAt.Unix(), 10))
+			}
+			if deprecation.sunset != nil {
+				c.Set("Sunset", deprecation.sunset.UTC().Format(http.TimeFormat))
+			}
+			if deprecation.successor != "" {
+				successor := apiPathPrefix + deprecation.successor + apiRoutePath(c.Path())
+				c.Append(fiber.HeaderLink, "<"+successor+`>; rel="successor-version"`)
+			}
+		}
+
+		err := c.Next()
+
+		apiVersionRequestsTotal.WithLabelValues(version, c.Method(), apiRoutePath(c.Route().Path)).Inc()
+		return err
+	}
+}
+
+// apiRoutePath strips the /api/<version> prefix, so rules and metrics apply to a route
+// in every version
+func apiRoutePath(path string) string {
+	if !strings.HasPrefix(path, apiPathPrefix) {
+		return path
+	}
+	rest := strings.TrimPrefix(path, apiPathPrefix)
+	if i := strings.IndexByte(rest, '/'); i >= 0 {
+		return rest[i:]
+	}
+	return "/"
+}
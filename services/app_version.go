@@ -0,0 +1,264 @@
+package services
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloakd/common/context"
+	serviceContext "github.com/cloakd/common/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	log "github.com/sirupsen/logrus"
+)
+
+// How often each instance re-reads the version policies, so an admin change reaches
+// every instance without a deploy
+const appVersionRefreshInterval = 30 * time.Second
+
+// appVersionExemptPrefixes are reachable from clients below the minimum version, so an
+// outdated app can still find out where to update. API routes are matched without the
+// /api/<version> part.
+var appVersionExemptPrefixes = []string{
+	"/ping",
+	"/swagger",
+	"/app/version",
+}
+
+// AppVersionService keeps the supported mobile app versions per platform and refuses
+// requests from apps that are too old to work with the API.
+type AppVersionService struct {
+	serviceContext.DefaultService
+
+	sqlSvc *PostgresService
+
+	mutex    sync.RWMutex
+	policies map[string]model.AppVersionPolicy
+}
+
+const APP_VERSION_SVC = "app_version_svc"
+
+func (svc *AppVersionService) Id() string {
+	return APP_VERSION_SVC
+}
+
+func (svc *AppVersionService) Configure(ctx *context.Context) error {
+	svc.policies = make(map[string]model.AppVersionPolicy)
+	return svc.DefaultService.Configure(ctx)
+}
+
+func (svc *AppVersionService) Start() error {
+	deps := newDependencies(svc.Id(), svc.Service)
+	svc.sqlSvc = resolve[*PostgresService](deps, POSTGRES_SVC)
+	if err := deps.err(); err != nil {
+		return err
+	}
+
+	svc.refreshPolicies()
+	go svc.startRefresher()
+
+	return nil
+}
+
+func (svc *AppVersionService) startRefresher() {
+	ticker := time.NewTicker(appVersionRefreshInterval)
+	for range ticker.C {
+		svc.refreshPolicies()
+	}
+}
+
+// refreshPolicies reloads the policies from the database. If they cannot be read the
+// last known policies are kept.
+func (svc *AppVersionService) refreshPolicies() {
+	policies, err := svc.sqlSvc.appVersionRepo.GetAppVersionPolicies()
+	if err != nil {
+		log.WithError(err).Warn("Failed to load app version policies")
+		return
+	}
+
+	byPlatform := make(map[string]model.AppVersionPolicy, len(policies))
+	for _, policy := range policies {
+		byPlatform[policy.Platform] = policy
+	}
+
+	svc.mutex.Lock()
+	svc.policies = byPlatform
+	svc.mutex.Unlock()
+}
+
+func (svc *AppVersionService) policy(platform string) (model.AppVersionPolicy, bool) {
+	svc.mutex.RLock()
+	defer svc.mutex.RUnlock()
+	policy, ok := svc.policies[platform]
+	return policy, ok
+}
+
+// GetVersions returns the supported range of every configured platform. When platform
+// and version identify the calling app, its entry says whether it must or can update.
+func (svc *AppVersionService) GetVersions(platform, version string) *dto.AppVersionResponse {
+	platform = strings.ToLower(platform)
+	current, hasCurrent := parseAppVersion(version)
+
+	svc.mutex.RLock()
+	defer svc.mutex.RUnlock()
+
+	resp := &dto.AppVersionResponse{Platforms: []dto.AppVersionInfo{}}
+	for _, name := range model.AppPlatforms {
+		policy, ok := svc.policies[name]
+		if !ok {
+			continue
+		}
+
+		info := dto.AppVersionInfo{
+			Platform:      policy.Platform,
+			MinVersion:    policy.MinVersion,
+			LatestVersion: policy.LatestVersion,
+			StoreURL:      policy.StoreURL,
+		}
+		if hasCurrent && name == platform {
+			info.UpdateRequired = isBelowAppVersion(current, policy.MinVersion)
+			info.UpdateAvailable = isBelowAppVersion(current, policy.LatestVersion)
+		}
+		resp.Platforms = append(resp.Platforms, info)
+	}
+	return resp
+}
+
+func (svc *AppVersionService) GetPolicies() ([]model.AppVersionPolicy, error) {
+	policies, err := svc.sqlSvc.appVersionRepo.GetAppVersionPolicies()
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get app version policies")
+	}
+	return policies, nil
+}
+
+// UpdatePolicy sets the supported version range of a platform. It applies on this
+// instance immediately and on the others within appVersionRefreshInterval.
+func (svc *AppVersionService) UpdatePolicy(adminID, platform string, req dto.UpdateAppVersionRequest) (*model.AppVersionPolicy, error) {
+	platform = strings.ToLower(platform)
+	if !slices.Contains(model.AppPlatforms, platform) {
+		return nil, shared.NewBadRequestError(nil, "Unsupported platform, expected one of: "+strings.Join(model.AppPlatforms, ", "))
+	}
+
+	minVersion, _ := parseAppVersion(req.MinVersion)
+	latestVersion, _ := parseAppVersion(req.LatestVersion)
+	if compareAppVersions(minVersion, latestVersion) > 0 {
+		return nil, shared.NewBadRequestError(nil, "min_version cannot be newer than latest_version")
+	}
+
+	policy := &model.AppVersionPolicy{
+		Platform:      platform,
+		MinVersion:    req.MinVersion,
+		LatestVersion: req.LatestVersion,
+		StoreURL:      req.StoreURL,
+		UpdatedBy:     adminID,
+		UpdatedAt:     time.Now(),
+	}
+	if err := svc.sqlSvc.appVersionRepo.SaveAppVersionPolicy(policy); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to save app version policy")
+	}
+
+	log.Infof("Admin %s set %s app versions to min %s, latest %s", adminID, platform, req.MinVersion, req.LatestVersion)
+	svc.refreshPolicies()
+
+	return policy, nil
+}
+
+// Gate refuses requests from apps below their platform's minimum version with a
+// force-update error. Apps identify themselves with the X-App-Platform and X-App-Version
+// headers; requests without them, such as the web app, are not gated.
+func (svc *AppVersionService) Gate() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		platform := strings.ToLower(c.Get(shared.AppPlatformHeader))
+		version := c.Get(shared.AppVersionHeader)
+		if platform == "" || version == "" || isAppVersionExempt(c.Path()) {
+			return c.Next()
+		}
+
+		policy, ok := svc.policy(platform)
+		if !ok {
+			return c.Next()
+		}
+
+		current, ok := parseAppVersion(version)
+		if !ok || !isBelowAppVersion(current, policy.MinVersion) {
+			return c.Next()
+		}
+
+		return shared.NewUpgradeRequiredError(nil, "This version of the app is no longer supported. Please update to continue.").WithData(map[string]interface{}{
+			"force_update":    true,
+			"platform":        policy.Platform,
+			"current_version": version,
+			"min_version":     policy.MinVersion,
+			"latest_version":  policy.LatestVersion,
+			"store_url":       policy.StoreURL,
+		})
+	}
+}
+
+func isAppVersionExempt(path string) bool {
+	path = apiRoutePath(path)
+	for _, prefix := range appVersionExemptPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAppVersion reads a dotted numeric version. Pre-release and build suffixes such as
+// "-beta" or "+412" are ignored, so 2.4.1-beta counts as 2.4.1.
+func parseAppVersion(version string) ([]int, bool) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(version, "-+ "); i >= 0 {
+		version = version[:i]
+	}
+	if version == "" {
+		return nil, false
+	}
+
+	parts := strings.Split(version, ".")
+	numbers := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		numbers[i] = n
+	}
+	return numbers, true
+}
+
+// compareAppVersions compares two parsed versions, treating missing parts as zero
+func compareAppVersions(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// isBelowAppVersion reports whether current is older than the configured version. An
+// unparseable configured version never blocks anyone.
+func isBelowAppVersion(current []int, configured string) bool {
+	target, ok := parseAppVersion(configured)
+	if !ok {
+		return false
+	}
+	return compareAppVersions(current, target) < 0
+}
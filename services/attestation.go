@@ -0,0 +1,626 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	appContext "github.com/cloakd/common/context"
+	serviceContext "github.com/cloakd/common/services"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	log "github.com/sirupsen/logrus"
+)
+
+// Attestation modes, set with ATTESTATION_MODE
+const (
+	AttestationModeOff     = "off"
+	AttestationModeReport  = "report"  // verify and record, never reject
+	AttestationModeEnforce = "enforce" // reject failed or missing attestation
+)
+
+// What an attestation was presented for
+const (
+	AttestationPurposeSession = "session"
+	AttestationPurposeAdHeart = "ad_hearts"
+)
+
+const (
+	attestationChallengeTTL = 5 * time.Minute
+	// Play Integrity tokens older than this are treated as replays
+	playIntegrityMaxTokenAge = 10 * time.Minute
+
+	playIntegrityScope = "https://www.googleapis.com/auth/playintegrity"
+)
+
+// OID of the App Attest leaf certificate extension holding the nonce
+var appAttestNonceOID = asn1.ObjectIdentifier{1, 2, 840, 113635, 100, 8, 2}
+
+var (
+	appAttestAAGUIDProduction  = []byte("appattest\x00\x00\x00\x00\x00\x00\x00")
+	appAttestAAGUIDDevelopment = []byte("appattestdevelop")
+)
+
+type AttestationService struct {
+	serviceContext.DefaultService
+
+	sqlSvc   *PostgresService
+	redisSvc *RedisService
+
+	httpClient *http.Client
+	mode       string
+
+	// Play Integrity
+	playPackageName string
+	playCredentials *googleServiceAccount
+	playTokenMutex  sync.Mutex
+	playAccessToken string
+	playTokenExpiry time.Time
+
+	// App Attest
+	appAttestAppID    string
+	appAttestRoots    *x509.CertPool
+	appAttestAllowDev bool
+	appAttestRPIDHash [32]byte
+}
+
+type googleServiceAccount struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// attestationResult is the outcome of one verification
+type attestationResult struct {
+	Verdict string
+	Reason  string
+}
+
+const ATTESTATION_SVC = "attestation_svc"
+
+func (svc *AttestationService) Id() string {
+	return ATTESTATION_SVC
+}
+
+func (svc *AttestationService) Configure(ctx *appContext.Context) error {
+	svc.httpClient = &http.Client{
+		Timeout: 10 * time.Second,
+	}
+
+	svc.mode = strings.ToLower(os.Getenv("ATTESTATION_MODE"))
+	switch svc.mode {
+	case AttestationModeOff, AttestationModeReport, AttestationModeEnforce:
+	case "":
+		svc.mode = AttestationModeReport
+	default:
+		log.WithField("mode", svc.mode).Warn("Unknown ATTESTATION_MODE, falling back to report")
+		svc.mode = AttestationModeReport
+	}
+
+	svc.playPackageName = os.Getenv("PLAY_INTEGRITY_PACKAGE_NAME")
+	if path := os.Getenv("PLAY_INTEGRITY_CREDENTIALS_FILE"); path != "" {
+		creds, err := loadGoogleServiceAccount(path)
+		if err != nil {
+			log.WithError(err).Error("Failed to load Play Integrity credentials")
+		} else {
+			svc.playCredentials = creds
+		}
+	}
+
+	// APP_ATTEST_APP_ID is "<team id>.<bundle id>"
+	svc.appAttestAppID = os.Getenv("APP_ATTEST_APP_ID")
+	svc.appAttestRPIDHash = sha256.Sum256([]byte(svc.appAttestAppID))
+	svc.appAttestAllowDev = os.Getenv("APP_ATTEST_ALLOW_DEVELOPMENT") == "true"
+	if path := os.Getenv("APP_ATTEST_ROOT_CA_FILE"); path != "" {
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			log.WithError(err).Error("Failed to read App Attest root CA")
+		} else {
+			pool := x509.NewCertPool()
+			if pool.AppendCertsFromPEM(pem) {
+				svc.appAttestRoots = pool
+			} else {
+				log.Error("App Attest root CA file contains no certificates")
+			}
+		}
+	}
+
+	return svc.DefaultService.Configure(ctx)
+}
+
+func (svc *AttestationService) Start() error {
+	deps := newDependencies(svc.Id(), svc.Service)
+	svc.sqlSvc = resolve[*PostgresService](deps, POSTGRES_SVC)
+	svc.redisSvc = resolve[*RedisService](deps, REDIS_SVC)
+	if err := deps.err(); err != nil {
+		return err
+	}
+
+	if svc.mode != AttestationModeOff {
+		if svc.playCredentials == nil || svc.playPackageName == "" {
+			log.Warn("Play Integrity is not configured, Android attestations will be recorded as unavailable")
+		}
+		if svc.appAttestRoots == nil || svc.appAttestAppID == "" {
+			log.Warn("App Attest is not configured, iOS attestations will be recorded as unavailable")
+		}
+	}
+	log.WithField("mode", svc.mode).Info("Device attestation configured")
+	return nil
+}
+
+// IssueChallenge returns a single-use nonce the client binds into its next attestation
+func (svc *AttestationService) IssueChallenge() (*dto.AttestationChallengeResponse, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to generate challenge")
+	}
+	challenge := base64.RawURLEncoding.EncodeToString(raw)
+
+	if err := svc.redisSvc.Set(context.Background(), attestationChallengeKey(challenge), "1", attestationChallengeTTL); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to store challenge")
+	}
+
+	return &dto.AttestationChallengeResponse{
+		Challenge: challenge,
+		ExpiresAt: time.Now().Add(attestationChallengeTTL),
+	}, nil
+}
+
+// VerifyDevice checks the attestation presented by a guest device and records the verdict.
+// Only enforce mode turns a failed or missing attestation into an error. When the
+// provider is unconfigured or unreachable the request is let through so an outage at
+// Google or Apple cannot lock every guest out.
+func (svc *AttestationService) VerifyDevice(deviceID, purpose string, payload *dto.AttestationPayload) error {
+	if svc.mode == AttestationModeOff {
+		return nil
+	}
+
+	record, err := svc.sqlSvc.sessionRepo.GetDeviceAttestation(deviceID)
+	if err != nil {
+		record = &model.DeviceAttestation{DeviceID: deviceID}
+	}
+
+	result := svc.verify(record, payload)
+
+	now := time.Now()
+	record.LastVerdict = result.Verdict
+	record.LastReason = result.Reason
+	record.LastPurpose = purpose
+	if payload != nil {
+		record.Platform = payload.Platform
+	}
+	if result.Verdict == model.AttestationVerified {
+		record.LastVerifiedAt = &now
+	} else if result.Verdict != model.AttestationUnavailable {
+		record.FailureCount++
+	}
+	if err := svc.sqlSvc.sessionRepo.SaveDeviceAttestation(record); err != nil {
+		log.WithError(err).WithField("device_id", deviceID).Error("Failed to save device attestation")
+	}
+
+	if result.Verdict == model.AttestationVerified {
+		return nil
+	}
+
+	entry := log.WithFields(log.Fields{
+		"device_id": deviceID,
+		"purpose":   purpose,
+		"verdict":   result.Verdict,
+		"reason":    result.Reason,
+		"mode":      svc.mode,
+	})
+	if result.Verdict == model.AttestationUnavailable || svc.mode == AttestationModeReport {
+		entry.Warn("Device attestation did not pass")
+		return nil
+	}
+
+	entry.Warn("Rejected request with failed device attestation")
+	return shared.NewForbiddenError(errors.New(result.Reason), "Device attestation failed").WithData(map[string]interface{}{
+		"verdict": result.Verdict,
+	})
+}
+
+func (svc *AttestationService) verify(record *model.DeviceAttestation, payload *dto.AttestationPayload) attestationResult {
+	if payload == nil {
+		return attestationResult{model.AttestationMissing, "no attestation supplied"}
+	}
+
+	if !svc.consumeChallenge(payload.Challenge) {
+		return attestationResult{model.AttestationFailed, "unknown or reused challenge"}
+	}
+
+	switch payload.Platform {
+	case model.AttestationPlatformAndroid:
+		return svc.verifyPlayIntegrity(payload)
+	case model.AttestationPlatformIOS:
+		if payload.Attestation != "" {
+			return svc.verifyAppAttestation(record, payload)
+		}
+		return svc.verifyAppAssertion(record, payload)
+	}
+	return attestationResult{model.AttestationFailed, "unsupported platform"}
+}
+
+func (svc *AttestationService) consumeChallenge(challenge string) bool {
+	deleted, err := svc.redisSvc.GetClient().Del(context.Background(), attestationChallengeKey(challenge)).Result()
+	if err != nil {
+		log.WithError(err).Error("Failed to consume attestation challenge")
+		return false
+	}
+	return deleted == 1
+}
+
+func attestationChallengeKey(challenge string) string {
+	return fmt.Sprintf("attestation:challenge:%s", challenge)
+}
+
+// Play Integrity
+
+type playIntegrityVerdict struct {
+	TokenPayloadExternal struct {
+		RequestDetails struct {
+			RequestPackageName string `json:"requestPackageName"`
+			Nonce              string `json:"nonce"`
+			RequestHash        string `json:"requestHash"`
+			TimestampMillis    string `json:"timestampMillis"`
+		} `json:"requestDetails"`
+		AppIntegrity struct {
+			AppRecognitionVerdict string `json:"appRecognitionVerdict"`
+		} `json:"appIntegrity"`
+		DeviceIntegrity struct {
+			DeviceRecognitionVerdict []string `json:"deviceRecognitionVerdict"`
+		} `json:"deviceIntegrity"`
+	} `json:"tokenPayloadExternal"`
+}
+
+func (svc *AttestationService) verifyPlayIntegrity(payload *dto.AttestationPayload) attestationResult {
+	if svc.playCredentials == nil || svc.playPackageName == "" {
+		return attestationResult{model.AttestationUnavailable, "play integrity not configured"}
+	}
+	if payload.Token == "" {
+		return attestationResult{model.AttestationMissing, "no integrity token supplied"}
+	}
+
+	verdict, err := svc.decodeIntegrityToken(payload.Token)
+	if err != nil {
+		log.WithError(err).Error("Play Integrity decode failed")
+		return attestationResult{model.AttestationUnavailable, "play integrity request failed"}
+	}
+	if verdict == nil {
+		return attestationResult{model.AttestationFailed, "integrity token rejected"}
+	}
+
+	details := verdict.TokenPayloadExternal.RequestDetails
+	if details.RequestPackageName != svc.playPackageName {
+		return attestationResult{model.AttestationFailed, "package name mismatch"}
+	}
+	if details.Nonce != payload.Challenge && details.RequestHash != payload.Challenge {
+		return attestationResult{model.AttestationFailed, "challenge mismatch"}
+	}
+
+	var millis int64
+	if _, err := fmt.Sscan(details.TimestampMillis, &millis); err != nil || time.Since(time.UnixMilli(millis)) > playIntegrityMaxTokenAge {
+		return attestationResult{model.AttestationFailed, "integrity token too old"}
+	}
+
+	if verdict.TokenPayloadExternal.AppIntegrity.AppRecognitionVerdict != "PLAY_RECOGNIZED" {
+		return attestationResult{model.AttestationFailed, "app not recognized by play"}
+	}
+	for _, v := range verdict.TokenPayloadExternal.DeviceIntegrity.DeviceRecognitionVerdict {
+		if v == "MEETS_DEVICE_INTEGRITY" || v == "MEETS_STRONG_INTEGRITY" {
+			return attestationResult{model.AttestationVerified, ""}
+		}
+	}
+	return attestationResult{model.AttestationFailed, "device integrity not met"}
+}
+
+// decodeIntegrityToken asks Google to decrypt and verify a token. A nil verdict with a
+// nil error means Google rejected the token itself.
+func (svc *AttestationService) decodeIntegrityToken(token string) (*playIntegrityVerdict, error) {
+	accessToken, err := svc.googleAccessToken()
+	if err != nil {
+		return nil, err
+	}
+
+	body, _ := json.Marshal(map[string]string{"integrity_token": token})
+	endpoint := fmt.Sprintf("https://playintegrity.googleapis.com/v1/%s:decodeIntegrityToken", url.PathEscape(svc.playPackageName))
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := svc.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusBadRequest {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("play integrity returned status %d", resp.StatusCode)
+	}
+
+	var verdict playIntegrityVerdict
+	if err := json.NewDecoder(resp.Body).Decode(&verdict); err != nil {
+		return nil, err
+	}
+	return &verdict, nil
+}
+
+// googleAccessToken exchanges a signed service account assertion for an OAuth token,
+// caching it until shortly before it expires
+func (svc *AttestationService) googleAccessToken() (string, error) {
+	svc.playTokenMutex.Lock()
+	defer svc.playTokenMutex.Unlock()
+
+	if svc.playAccessToken != "" && time.Now().Before(svc.playTokenExpiry) {
+		return svc.playAccessToken, nil
+	}
+
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(svc.playCredentials.PrivateKey))
+	if err != nil {
+		return "", fmt.Errorf("invalid service account key: %v", err)
+	}
+
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   svc.playCredentials.ClientEmail,
+		"scope": playIntegrityScope,
+		"aud":   svc.playCredentials.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign service account assertion: %v", err)
+	}
+
+	resp, err := svc.httpClient.PostForm(svc.playCredentials.TokenURI, url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("google token endpoint returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+
+	svc.playAccessToken = result.AccessToken
+	svc.playTokenExpiry = now.Add(time.Duration(result.ExpiresIn)*time.Second - time.Minute)
+	return svc.playAccessToken, nil
+}
+
+func loadGoogleServiceAccount(path string) (*googleServiceAccount, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var creds googleServiceAccount
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, err
+	}
+	if creds.ClientEmail == "" || creds.PrivateKey == "" {
+		return nil, errors.New("service account file is missing client_email or private_key")
+	}
+	if creds.TokenURI == "" {
+		creds.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	return &creds, nil
+}
+
+// App Attest
+
+func (svc *AttestationService) appAttestConfigured() bool {
+	return svc.appAttestRoots != nil && svc.appAttestAppID != ""
+}
+
+// verifyAppAttestation validates a first-use attestation object and stores the key it
+// certifies, following Apple's "Validating apps that connect to your server" steps
+func (svc *AttestationService) verifyAppAttestation(record *model.DeviceAttestation, payload *dto.AttestationPayload) attestationResult {
+	if !svc.appAttestConfigured() {
+		return attestationResult{model.AttestationUnavailable, "app attest not configured"}
+	}
+
+	keyID, err := base64.StdEncoding.DecodeString(payload.KeyID)
+	if err != nil || len(keyID) != sha256.Size {
+		return attestationResult{model.AttestationFailed, "invalid key id"}
+	}
+	raw, err := base64.StdEncoding.DecodeString(payload.Attestation)
+	if err != nil {
+		return attestationResult{model.AttestationFailed, "attestation is not base64"}
+	}
+
+	decoded, err := decodeCBOR(raw)
+	if err != nil {
+		return attestationResult{model.AttestationFailed, "malformed attestation object"}
+	}
+	object, ok := decoded.(map[interface{}]interface{})
+	if !ok || object["fmt"] != "apple-appattest" {
+		return attestationResult{model.AttestationFailed, "unexpected attestation format"}
+	}
+	stmt, _ := object["attStmt"].(map[interface{}]interface{})
+	authData, ok := cborBytes(object, "authData")
+	if stmt == nil || !ok {
+		return attestationResult{model.AttestationFailed, "malformed attestation object"}
+	}
+	x5c, _ := stmt["x5c"].([]interface{})
+	if len(x5c) == 0 {
+		return attestationResult{model.AttestationFailed, "missing certificate chain"}
+	}
+
+	// 1. The certificate chain leads to Apple's App Attest root
+	certs := make([]*x509.Certificate, 0, len(x5c))
+	for _, c := range x5c {
+		der, ok := c.([]byte)
+		if !ok {
+			return attestationResult{model.AttestationFailed, "malformed certificate chain"}
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return attestationResult{model.AttestationFailed, "malformed certificate chain"}
+		}
+		certs = append(certs, cert)
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	leaf := certs[0]
+	if _, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         svc.appAttestRoots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return attestationResult{model.AttestationFailed, "certificate chain not trusted"}
+	}
+
+	// 2-4. The leaf carries nonce = SHA256(authData || SHA256(challenge))
+	clientDataHash := sha256.Sum256([]byte(payload.Challenge))
+	expectedNonce := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	var certNonce []byte
+	for _, ext := range leaf.Extensions {
+		if ext.Id.Equal(appAttestNonceOID) {
+			var wrapper struct {
+				Nonce []byte `asn1:"tag:1,explicit"`
+			}
+			if _, err := asn1.Unmarshal(ext.Value, &wrapper); err == nil {
+				certNonce = wrapper.Nonce
+			}
+		}
+	}
+	if !bytes.Equal(certNonce, expectedNonce[:]) {
+		return attestationResult{model.AttestationFailed, "nonce mismatch"}
+	}
+
+	// 5. The key ID is the hash of the certified public key
+	pub, ok := leaf.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return attestationResult{model.AttestationFailed, "unexpected key type"}
+	}
+	ecdhKey, err := pub.ECDH()
+	if err != nil {
+		return attestationResult{model.AttestationFailed, "unexpected key type"}
+	}
+	pubHash := sha256.Sum256(ecdhKey.Bytes())
+	if !bytes.Equal(pubHash[:], keyID) {
+		return attestationResult{model.AttestationFailed, "key id mismatch"}
+	}
+
+	// 6-9. Authenticator data names this app, a zero counter, the App Attest AAGUID and the key
+	if len(authData) < 55 {
+		return attestationResult{model.AttestationFailed, "authenticator data too short"}
+	}
+	if !bytes.Equal(authData[:32], svc.appAttestRPIDHash[:]) {
+		return attestationResult{model.AttestationFailed, "app id mismatch"}
+	}
+	if binary.BigEndian.Uint32(authData[33:37]) != 0 {
+		return attestationResult{model.AttestationFailed, "non-zero counter"}
+	}
+	aaguid := authData[37:53]
+	if !bytes.Equal(aaguid, appAttestAAGUIDProduction) && !(svc.appAttestAllowDev && bytes.Equal(aaguid, appAttestAAGUIDDevelopment)) {
+		return attestationResult{model.AttestationFailed, "unexpected environment"}
+	}
+	credLen := int(binary.BigEndian.Uint16(authData[53:55]))
+	if len(authData) < 55+credLen || !bytes.Equal(authData[55:55+credLen], keyID) {
+		return attestationResult{model.AttestationFailed, "credential id mismatch"}
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return attestationResult{model.AttestationFailed, "unexpected key type"}
+	}
+	record.KeyID = payload.KeyID
+	record.PublicKey = der
+	record.SignCount = 0
+	return attestationResult{model.AttestationVerified, ""}
+}
+
+// verifyAppAssertion checks a request signed with the key stored by a previous attestation
+func (svc *AttestationService) verifyAppAssertion(record *model.DeviceAttestation, payload *dto.AttestationPayload) attestationResult {
+	if !svc.appAttestConfigured() {
+		return attestationResult{model.AttestationUnavailable, "app attest not configured"}
+	}
+	if payload.Assertion == "" {
+		return attestationResult{model.AttestationMissing, "no assertion supplied"}
+	}
+	if len(record.PublicKey) == 0 || record.KeyID != payload.KeyID {
+		return attestationResult{model.AttestationFailed, "key not attested for this device"}
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(payload.Assertion)
+	if err != nil {
+		return attestationResult{model.AttestationFailed, "assertion is not base64"}
+	}
+	decoded, err := decodeCBOR(raw)
+	if err != nil {
+		return attestationResult{model.AttestationFailed, "malformed assertion"}
+	}
+	assertion, ok := decoded.(map[interface{}]interface{})
+	if !ok {
+		return attestationResult{model.AttestationFailed, "malformed assertion"}
+	}
+	signature, okSig := cborBytes(assertion, "signature")
+	authData, okAuth := cborBytes(assertion, "authenticatorData")
+	if !okSig || !okAuth || len(authData) < 37 {
+		return attestationResult{model.AttestationFailed, "malformed assertion"}
+	}
+
+	parsed, err := x509.ParsePKIXPublicKey(record.PublicKey)
+	if err != nil {
+		return attestationResult{model.AttestationFailed, "stored key unreadable"}
+	}
+	pub, ok := parsed.(*ecdsa.PublicKey)
+	if !ok {
+		return attestationResult{model.AttestationFailed, "stored key unreadable"}
+	}
+
+	clientDataHash := sha256.Sum256([]byte(payload.Challenge))
+	nonce := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	digest := sha256.Sum256(nonce[:])
+	if !ecdsa.VerifyASN1(pub, digest[:], signature) {
+		return attestationResult{model.AttestationFailed, "invalid assertion signature"}
+	}
+
+	if !bytes.Equal(authData[:32], svc.appAttestRPIDHash[:]) {
+		return attestationResult{model.AttestationFailed, "app id mismatch"}
+	}
+
+	counter := binary.BigEndian.Uint32(authData[33:37])
+	if counter <= record.SignCount {
+		return attestationResult{model.AttestationFailed, "assertion counter did not increase"}
+	}
+	record.SignCount = counter
+	return attestationResult{model.AttestationVerified, ""}
+}
@@ -0,0 +1,177 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	auditChainVerifyInterval = 24 * time.Hour
+	auditChainBatchSize      = 1000
+	auditChainMaxProblems    = 100
+
+	// Largest range of entries one export may hold
+	auditSegmentMaxEntries = 10000
+)
+
+// VerifyAuditLogChain walks the audit log hash chain from the latest retention checkpoint
+// and reports entries that were modified, removed or inserted. Entries cut from the end
+// of the chain can only be noticed against an earlier head: the one of the previous
+// verification on this instance, or the HeadHash of an exported segment.
+func (svc *AuthService) VerifyAuditLogChain() (*dto.AuditChainVerification, error) {
+	checkpoint, err := svc.userRepo.GetLatestAuthAuditCheckpoint()
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to load audit log checkpoint")
+	}
+
+	expected := int64(1)
+	prevHash := ""
+	if checkpoint != nil {
+		expected = checkpoint.Sequence + 1
+		prevHash = checkpoint.Hash
+	}
+
+	result := &dto.AuditChainVerification{
+		Valid:         true,
+		FirstSequence: expected,
+		VerifiedAt:    time.Now(),
+	}
+	problem := func(sequence int64, format string, args ...interface{}) {
+		result.Valid = false
+		if len(result.Problems) < auditChainMaxProblems {
+			result.Problems = append(result.Problems, dto.AuditChainProblem{Sequence: sequence, Problem: fmt.Sprintf(format, args...)})
+		}
+	}
+
+	from := int64(1)
+	for {
+		entries, err := svc.userRepo.GetAuthAuditChain(from, 0, auditChainBatchSize)
+		if err != nil {
+			return nil, shared.NewInternalError(err, "Failed to load audit logs")
+		}
+
+		for _, entry := range entries {
+			switch {
+			case entry.Sequence < expected:
+				problem(entry.Sequence, "entry is before checkpoint %d", expected-1)
+				continue
+			case entry.Sequence > expected:
+				problem(expected, "entries %d to %d are missing", expected, entry.Sequence-1)
+			case entry.PrevHash != prevHash:
+				problem(entry.Sequence, "previous hash does not match the chain")
+			}
+			legacyRedacted := entry.ClientDigest == "" && entry.RedactedAt != nil
+			if !legacyRedacted && entry.Hash != entry.ComputeHash() {
+				problem(entry.Sequence, "entry was modified")
+			}
+			if !entry.ClientDetailsIntact() {
+				problem(entry.Sequence, "client details were modified")
+			}
+
+			prevHash = entry.Hash
+			expected = entry.Sequence + 1
+			result.Checked++
+		}
+
+		if len(entries) < auditChainBatchSize {
+			break
+		}
+		from = entries[len(entries)-1].Sequence + 1
+	}
+
+	result.LastSequence = expected - 1
+	result.HeadHash = prevHash
+
+	svc.auditChainMu.Lock()
+	defer svc.auditChainMu.Unlock()
+	if last := svc.lastAuditChainHead; last != nil && result.LastSequence < last.LastSequence {
+		problem(result.LastSequence+1, "chain ends before sequence %d seen at the previous verification", last.LastSequence)
+	}
+	if result.Valid {
+		svc.lastAuditChainHead = result
+	}
+
+	return result, nil
+}
+
+// ExportAuditLogSegment exports the chained entries from fromSequence to toSequence,
+// signed with the audit log signing key
+func (svc *AuthService) ExportAuditLogSegment(fromSequence, toSequence int64) (*dto.AuditLogSegment, error) {
+	if svc.auditSigningKey == "" {
+		return nil, shared.NewInternalError(errors.New("AUDIT_LOG_SIGNING_KEY not set"), "Audit log signing is not configured")
+	}
+	if fromSequence < 1 || toSequence < fromSequence {
+		return nil, shared.NewBadRequestError(errors.New("invalid range"), "Invalid sequence range")
+	}
+	if toSequence-fromSequence >= auditSegmentMaxEntries {
+		return nil, shared.NewBadRequestError(errors.New("range too large"), fmt.Sprintf("A segment can hold at most %d entries", auditSegmentMaxEntries))
+	}
+
+	entries, err := svc.userRepo.GetAuthAuditChain(fromSequence, toSequence, auditSegmentMaxEntries)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to load audit logs")
+	}
+	if len(entries) == 0 {
+		return nil, shared.NewNotFoundError(errors.New("no entries"), "No audit log entries in this range")
+	}
+
+	segment := &dto.AuditLogSegment{
+		FromSequence: entries[0].Sequence,
+		ToSequence:   entries[len(entries)-1].Sequence,
+		PrevHash:     entries[0].PrevHash,
+		HeadHash:     entries[len(entries)-1].Hash,
+		Count:        len(entries),
+		ExportedAt:   time.Now().UTC(),
+		Entries:      entries,
+	}
+	segment.Signature = signAuditSegment(svc.auditSigningKey, segment)
+
+	return segment, nil
+}
+
+func signAuditSegment(key string, segment *dto.AuditLogSegment) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(segment.SigningPayload()))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (svc *AuthService) startAuditChainVerifyJob() {
+	ticker := time.NewTicker(auditChainVerifyInterval)
+	for range ticker.C {
+		svc.runAuditChainVerification()
+	}
+}
+
+// runAuditChainVerification verifies the chain and reports the result as an ops event. The
+// head of every run is logged, so it can be compared later even across restarts.
+func (svc *AuthService) runAuditChainVerification() {
+	result, err := svc.VerifyAuditLogChain()
+	if err != nil {
+		log.WithError(err).Error("Failed to verify audit log chain")
+		return
+	}
+
+	data := map[string]interface{}{
+		"valid":         result.Valid,
+		"checked":       result.Checked,
+		"last_sequence": result.LastSequence,
+		"head_hash":     result.HeadHash,
+	}
+	if result.Valid {
+		log.WithFields(data).Info("Audit log chain verified")
+		svc.systemSvc.PublishOpsEvent(OpsEventAuditChain, OpsSeverityInfo, data)
+		return
+	}
+
+	data["problems"] = result.Problems
+	log.WithFields(data).Error("Audit log chain verification failed")
+	svc.systemSvc.PublishOpsEvent(OpsEventAuditChain, OpsSeverityError, data)
+}
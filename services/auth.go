@@ -3,18 +3,30 @@ package services
 import (
 	"crypto/rand"
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/lac-hong-legacy/ven_api/dto"
 	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/services/repositories"
 	"github.com/lac-hong-legacy/ven_api/shared"
+	"github.com/lac-hong-legacy/ven_api/shared/ids"
+	"github.com/lac-hong-legacy/ven_api/shared/text"
+	"github.com/lac-hong-legacy/ven_api/shared/useragent"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 
 	"github.com/cloakd/common/context"
 	serviceContext "github.com/cloakd/common/services"
@@ -25,6 +37,7 @@ type VerificationEmail struct {
 	Email            string
 	Username         string
 	VerificationCode string
+	VerificationLink string
 }
 
 type PasswordResetEmail struct {
@@ -34,38 +47,102 @@ type PasswordResetEmail struct {
 }
 
 type LoginNotificationEmail struct {
-	Email     string
-	Username  string
-	LoginTime string
-	IP        string
-	Device    string
-	Location  string
+	Email          string
+	Username       string
+	LoginTime      string
+	IP             string
+	Device         string
+	NewDevice      bool
+	Location       string
+	EvictedDevices []string
+	// Tokens of the links to trust the device, or to untrust it and sign it out
+	TrustToken   string
+	UntrustToken string
+}
+
+type EmailChangeEmails struct {
+	OldEmail    string
+	NewEmail    string
+	Username    string
+	Code        string
+	RevertToken string
+}
+
+type AccountRecoveryEmail struct {
+	Email       string
+	Username    string
+	NewEmail    string
+	RequestIP   string
+	FallbackAt  string
+	CancelToken string
 }
 
 type AuthService struct {
 	serviceContext.DefaultService
 
-	sqlSvc         *PostgresService
-	jwtSvc         *JWTService
-	emailSvc       *EmailService
-	rateLimitSvc   *RateLimitService
-	geolocationSvc *GeolocationService
+	sqlSvc          *PostgresService
+	jwtSvc          *JWTService
+	emailSvc        *EmailService
+	rateLimitSvc    *RateLimitService
+	geolocationSvc  *GeolocationService
+	userSvc         *UserService
+	systemSvc       *SystemService
+	outboxSvc       *OutboxService
+	notificationSvc *NotificationService
+	moderationSvc   *ModerationService
+
+	// An interface so tests can use mocks.UserRepo
+	userRepo repositories.UserRepo
+
+	// Users and sessions RequiredAuth checks, see invalidateAuthState
+	authState *authStateCache
 
 	maxLoginAttempts   int
 	lockoutDuration    time.Duration
 	passwordMinLength  int
 	requireEmailVerify bool
+	verifyLinkBase     string
+
+	// How long a recovery request waits for a trusted device before it can complete anyway
+	recoveryFallbackDelay time.Duration
+
+	// HMAC key for exported audit log segments, and the head of the last valid chain
+	// verification
+	auditSigningKey    string
+	auditChainMu       sync.Mutex
+	lastAuditChainHead *dto.AuditChainVerification
+
+	// Cookie session mode for the web client
+	cookieSecure   bool
+	cookieSameSite string
+	cookieDomain   string
 
-	sendVerificationEmailAsync      chan VerificationEmail
-	sendPasswordResetEmailAsync     chan PasswordResetEmail
-	sendLoginNotificationEmailAsync chan LoginNotificationEmail
-	logAuthEventCh                  chan dto.AuthAuditLog
-	dbOperationCh                   chan func()
+	logAuthEventCh chan dto.AuthAuditLog
+	dbOperationCh  chan func()
 }
 
 const AUTH_SVC = "auth_svc"
 
-func (svc AuthService) Id() string {
+const (
+	// Matches the code expiry set by the user repository
+	emailVerifyLinkTTL = 15 * time.Minute
+
+	emailChangeCodeTTL   = 30 * time.Minute
+	emailChangeRevertTTL = 7 * 24 * time.Hour
+
+	deviceActionLinkTTL = 7 * 24 * time.Hour
+)
+
+// Actions of the device links in login emails
+const (
+	deviceActionTrust   = "trust"
+	deviceActionUntrust = "untrust"
+)
+
+// sessionActivityResolution is how stale a session's LastUsed may get before a request refreshes it
+const sessionActivityResolution = time.Minute
+
+func (svc *AuthService) Id() string {
 	return AUTH_SVC
 }
 
@@ -75,9 +152,29 @@ func (svc *AuthService) Configure(ctx *context.Context) error {
 	svc.passwordMinLength = 8
 	svc.requireEmailVerify = true
 
-	svc.sendVerificationEmailAsync = make(chan VerificationEmail, 100)
-	svc.sendPasswordResetEmailAsync = make(chan PasswordResetEmail, 100)
-	svc.sendLoginNotificationEmailAsync = make(chan LoginNotificationEmail, 100)
+	svc.verifyLinkBase = os.Getenv("EMAIL_VERIFY_LINK_BASE")
+	if svc.verifyLinkBase == "" {
+		svc.verifyLinkBase = "ven://verify"
+	}
+
+	svc.cookieSecure = os.Getenv("AUTH_COOKIE_SECURE") != "false"
+	svc.cookieSameSite = os.Getenv("AUTH_COOKIE_SAMESITE")
+	if svc.cookieSameSite == "" {
+		svc.cookieSameSite = fiber.CookieSameSiteStrictMode
+	}
+	svc.cookieDomain = os.Getenv("AUTH_COOKIE_DOMAIN")
+
+	svc.recoveryFallbackDelay = defaultRecoveryFallbackDelay
+	if hours := os.Getenv("ACCOUNT_RECOVERY_DELAY_HOURS"); hours != "" {
+		value, err := strconv.Atoi(hours)
+		if err != nil || value < 1 {
+			return fmt.Errorf("invalid ACCOUNT_RECOVERY_DELAY_HOURS: %s", hours)
+		}
+		svc.recoveryFallbackDelay = time.Duration(value) * time.Hour
+	}
+
+	svc.auditSigningKey = os.Getenv("AUDIT_LOG_SIGNING_KEY")
+
 	svc.logAuthEventCh = make(chan dto.AuthAuditLog, 100)
 	svc.dbOperationCh = make(chan func(), 100)
 
@@ -85,17 +182,30 @@ func (svc *AuthService) Configure(ctx *context.Context) error {
 }
 
 func (svc *AuthService) Start() error {
-	svc.sqlSvc = svc.Service(POSTGRES_SVC).(*PostgresService)
-	svc.jwtSvc = svc.Service(JWT_SVC).(*JWTService)
-	svc.emailSvc = svc.Service(EMAIL_SVC).(*EmailService)
-	svc.rateLimitSvc = svc.Service(RATE_LIMIT_SVC).(*RateLimitService)
-	svc.geolocationSvc = svc.Service(GEOLOCATION_SVC).(*GeolocationService)
-
-	go svc.startVerificationEmailJob()
-	go svc.startPasswordResetEmailJob()
-	go svc.startLoginNotificationEmailJob()
+	deps := newDependencies(svc.Id(), svc.Service)
+	svc.sqlSvc = resolve[*PostgresService](deps, POSTGRES_SVC)
+	svc.jwtSvc = resolve[*JWTService](deps, JWT_SVC)
+	svc.emailSvc = resolve[*EmailService](deps, EMAIL_SVC)
+	svc.userSvc = resolve[*UserService](deps, USER_SVC)
+	svc.rateLimitSvc = resolve[*RateLimitService](deps, RATE_LIMIT_SVC)
+	svc.geolocationSvc = resolve[*GeolocationService](deps, GEOLOCATION_SVC)
+	svc.systemSvc = resolve[*SystemService](deps, SYSTEM_SVC)
+	svc.outboxSvc = resolve[*OutboxService](deps, OUTBOX_SVC)
+	svc.notificationSvc = resolve[*NotificationService](deps, NOTIFICATION_SVC)
+	svc.moderationSvc = resolve[*ModerationService](deps, MODERATION_SVC)
+	redisSvc := resolve[*RedisService](deps, REDIS_SVC)
+	if err := deps.err(); err != nil {
+		return err
+	}
+	svc.userRepo = svc.sqlSvc.userRepo
+	svc.authState = newAuthStateCache(redisSvc, authStateCacheTTL)
+
+	svc.registerOutboxHandlers()
+
 	go svc.startLogAuthEventJob()
 	go svc.startDBOperationJob()
+	go svc.startSecurityDigestJob()
+	go svc.startAuditChainVerifyJob()
 
 	return nil
 }
@@ -139,7 +249,13 @@ func (svc *AuthService) generateVerificationCode() (string, error) {
 }
 
 func (svc *AuthService) Register(registerRequest dto.RegisterRequest) (*dto.RegisterResponse, error) {
-	_, err := svc.sqlSvc.userRepo.GetUserByUsername(registerRequest.Username)
+	moderated, err := svc.moderationSvc.Moderate(model.ModerationContextUsername, registerRequest.Username)
+	if err != nil {
+		return nil, err
+	}
+	registerRequest.Username = moderated.Text
+
+	_, err = svc.userRepo.GetUserByUsername(registerRequest.Username)
 	if err == nil {
 		return nil, shared.NewBadRequestError(errors.New("username taken"), "Username is already taken")
 	}
@@ -159,27 +275,34 @@ func (svc *AuthService) Register(registerRequest dto.RegisterRequest) (*dto.Regi
 	}
 
 	registerRequest.Password = hashedPassword
-	user, err := svc.sqlSvc.userRepo.CreateUser(registerRequest, verificationCode)
-	if err != nil {
-		return nil, shared.NewInternalError(err, err.Error())
-	}
+	userID := ids.NewPrefixed(ids.User)
 
+	var messages []*model.OutboxMessage
 	if svc.requireEmailVerify {
-		svc.sendVerificationEmailAsync <- VerificationEmail{
+		messages = append(messages, newOutboxMessage(OutboxTopicVerificationEmail, VerificationEmail{
 			Email:            registerRequest.Email,
 			Username:         registerRequest.Username,
 			VerificationCode: verificationCode,
-		}
+			VerificationLink: svc.buildVerificationLink(userID, registerRequest.Email, verificationCode),
+		}))
 	}
-
-	svc.logAuthEventCh <- dto.AuthAuditLog{
-		UserID:    user.ID,
+	messages = append(messages, newOutboxMessage(OutboxTopicAuthAudit, dto.AuthAuditLog{
+		UserID:    userID,
 		Action:    "register",
 		IP:        "",
 		UserAgent: "",
 		Timestamp: time.Now(),
 		Success:   true,
+	}))
+
+	user, err := svc.userRepo.CreateUser(userID, registerRequest, verificationCode, messages...)
+	if err != nil {
+		return nil, shared.NewInternalError(err, err.Error())
 	}
+	svc.moderationSvc.Flag(user.ID, moderated)
+
+	// Sending mail should not hold up the response
+	go svc.outboxSvc.Relay(messages...)
 
 	return &dto.RegisterResponse{
 		UserID:               user.ID,
@@ -189,11 +312,27 @@ func (svc *AuthService) Register(registerRequest dto.RegisterRequest) (*dto.Regi
 }
 
 func (svc *AuthService) Login(loginRequest dto.LoginRequest, clientIP, userAgent string) (*dto.LoginResponse, error) {
+	return svc.login(loginRequest, "", clientIP, userAgent)
+}
+
+// LoginTwoFactor is the login of users with 2FA on, who also send an authenticator or
+// backup code
+func (svc *AuthService) LoginTwoFactor(req dto.TwoFactorLoginRequest, clientIP, userAgent string) (*dto.LoginResponse, error) {
+	return svc.login(dto.LoginRequest{
+		EmailOrUsername: req.EmailOrUsername,
+		Password:        req.Password,
+		DeviceID:        req.DeviceID,
+	}, req.Code, clientIP, userAgent)
+}
+
+func (svc *AuthService) login(loginRequest dto.LoginRequest, twoFactorCode, clientIP, userAgent string) (*dto.LoginResponse, error) {
 	// if blocked := svc.rateLimitSvc.IsBlocked(clientIP, "login"); blocked {
 	// 	return nil, shared.NewTooManyRequestsError(errors.New("too many login attempts"), "Too many login attempts. Please try again later.")
 	// }
 
-	user, err := svc.sqlSvc.userRepo.GetUserByEmailOrUsername(loginRequest.EmailOrUsername)
+	// Usernames are stored NFC normalized, as moderation returns them
+	loginRequest.EmailOrUsername = text.NFC(loginRequest.EmailOrUsername)
+	user, err := svc.userRepo.GetUserByEmailOrUsername(loginRequest.EmailOrUsername)
 	if err != nil {
 		svc.logAuthEventCh <- dto.AuthAuditLog{
 			UserID:    "",
@@ -219,25 +358,7 @@ func (svc *AuthService) Login(loginRequest dto.LoginRequest, clientIP, userAgent
 	}
 
 	if !svc.checkPasswordHash(loginRequest.Password, user.Password) {
-		svc.dbOperationCh <- func() {
-			svc.sqlSvc.userRepo.IncrementFailedAttempts(user.ID)
-		}
-
-		if user.FailedAttempts >= svc.maxLoginAttempts-1 {
-			lockUntil := time.Now().Add(svc.lockoutDuration)
-			svc.dbOperationCh <- func() {
-				svc.sqlSvc.userRepo.LockAccount(user.ID, lockUntil)
-			}
-		}
-
-		svc.logAuthEventCh <- dto.AuthAuditLog{
-			UserID:    user.ID,
-			Action:    "failed_login",
-			IP:        clientIP,
-			UserAgent: userAgent,
-			Timestamp: time.Now(),
-			Success:   false,
-		}
+		svc.recordFailedLogin(user, "failed_login", clientIP, userAgent)
 		return nil, shared.NewUnauthorizedError(errors.New("invalid password"), "Invalid credentials")
 	}
 
@@ -245,8 +366,40 @@ func (svc *AuthService) Login(loginRequest dto.LoginRequest, clientIP, userAgent
 		return nil, shared.NewUnauthorizedError(errors.New("email not verified"), "Please verify your email address before logging in")
 	}
 
+	// A trusted device only skips the login email. Device IDs are chosen by the client and
+	// listed with the sessions, so they can't stand in for the second factor.
+	trustedDevice := svc.isTrustedDevice(user.ID, loginRequest.DeviceID)
+
+	var backupCodesRemaining *int
+	if user.TwoFactorEnabled {
+		if twoFactorCode == "" {
+			return nil, shared.NewUnauthorizedError(errors.New("2fa code required"), "Two-factor code required").WithData(map[string]interface{}{
+				"two_factor_required": true,
+			})
+		}
+
+		remaining, ok, err := svc.verifyTwoFactorCode(user, twoFactorCode, clientIP, userAgent)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			svc.recordFailedLogin(user, "failed_login_2fa", clientIP, userAgent)
+			return nil, shared.NewUnauthorizedError(errors.New("invalid 2fa code"), "Invalid two-factor code")
+		}
+		backupCodesRemaining = remaining
+	}
+
 	svc.dbOperationCh <- func() {
-		svc.sqlSvc.userRepo.ResetFailedAttempts(user.ID)
+		svc.userRepo.ResetFailedAttempts(user.ID)
+	}
+
+	// Checked before this login registers the device
+	newDevice := svc.isNewDevice(user.ID, loginRequest.DeviceID)
+	device := useragent.Parse(userAgent)
+
+	evictedDevices, err := svc.enforceSessionLimit(user, clientIP, userAgent)
+	if err != nil {
+		return nil, err
 	}
 
 	// Generate tokens
@@ -274,10 +427,53 @@ func (svc *AuthService) Login(loginRequest dto.LoginRequest, clientIP, userAgent
 		IsActive:         true,
 	}
 
-	sessionID, err := svc.sqlSvc.userRepo.CreateUserSession(session)
+	location, geoErr := svc.geolocationSvc.GetLocationByIP(clientIP)
+	if geoErr != nil {
+		location = "Unknown"
+	}
+
+	messages := []*model.OutboxMessage{
+		newOutboxMessage(OutboxTopicAuthAudit, dto.AuthAuditLog{
+			UserID:    user.ID,
+			Action:    "login",
+			IP:        clientIP,
+			UserAgent: userAgent,
+			Timestamp: time.Now(),
+			Success:   true,
+			Details:   deviceLabel(device),
+		}),
+	}
+
+	// One email covers the login and the sessions it evicted, each as far as the user wants to hear about it
+	if !shouldEmailSecurityEvent(user.SecurityEmailMode(model.SecurityEventSessionEvicted), newDevice) {
+		evictedDevices = nil
+	}
+	if shouldEmailLogin(user.SecurityEmailMode(model.SecurityEventLogin), newDevice, trustedDevice) || len(evictedDevices) > 0 {
+		email := LoginNotificationEmail{
+			Email:     user.Email,
+			Username:  user.Username,
+			LoginTime: time.Now().Local().Format("2006-01-02 15:04:05"),
+			IP:        clientIP,
+			Device:    deviceLabel(device),
+			NewDevice: newDevice,
+			Location:  location,
+
+			EvictedDevices: evictedDevices,
+		}
+		if loginRequest.DeviceID != "" {
+			if !trustedDevice {
+				email.TrustToken = svc.deviceActionToken(user.ID, deviceActionTrust, loginRequest.DeviceID)
+			}
+			email.UntrustToken = svc.deviceActionToken(user.ID, deviceActionUntrust, loginRequest.DeviceID)
+		}
+		messages = append(messages, newOutboxMessage(OutboxTopicLoginNotificationEmail, email))
+	}
+
+	sessionID, err := svc.userRepo.CreateUserSession(session, messages...)
 	if err != nil {
 		return nil, shared.NewInternalError(err, "Failed to create session")
 	}
+	go svc.outboxSvc.Relay(messages...)
 
 	accessToken, err := svc.jwtSvc.GenerateAccessTokenWithSession(user.ID, sessionID)
 	if err != nil {
@@ -286,32 +482,19 @@ func (svc *AuthService) Login(loginRequest dto.LoginRequest, clientIP, userAgent
 
 	tokenPair.AccessToken = accessToken
 
-	svc.logAuthEventCh <- dto.AuthAuditLog{
-		UserID:    user.ID,
-		Action:    "login",
-		IP:        clientIP,
-		UserAgent: userAgent,
-		Timestamp: time.Now(),
-		Success:   true,
-	}
-
 	svc.dbOperationCh <- func() {
-		svc.sqlSvc.userRepo.UpdateLastLogin(user.ID, clientIP)
+		svc.userRepo.UpdateLastLogin(user.ID, clientIP)
 	}
 
-	location, geoErr := svc.geolocationSvc.GetLocationByIP(clientIP)
-	if geoErr != nil {
-		location = "Unknown"
-	}
-
-	// Send login notification email
-	svc.sendLoginNotificationEmailAsync <- LoginNotificationEmail{
-		Email:     user.Email,
-		Username:  user.Username,
-		LoginTime: time.Now().Local().Format("2006-01-02 15:04:05"),
-		IP:        clientIP,
-		Device:    userAgent,
-		Location:  location,
+	// Devices have to be known before the user can trust them, e.g. to approve recoveries.
+	// New devices start untrusted.
+	if loginRequest.DeviceID != "" {
+		svc.dbOperationCh <- func() {
+			if err := svc.RegisterOrUpdateDevice(user.ID, loginRequest.DeviceID, truncate(device.String(), 255),
+				device.DeviceType, truncate(device.OS, 50), truncate(device.Browser, 50), clientIP); err != nil {
+				log.WithError(err).Warnf("Failed to register device for user %s", user.ID)
+			}
+		}
 	}
 
 	return &dto.LoginResponse{
@@ -325,9 +508,104 @@ func (svc *AuthService) Login(loginRequest dto.LoginRequest, clientIP, userAgent
 			Email:    user.Email,
 			Role:     user.Role,
 		},
+		BackupCodesRemaining: backupCodesRemaining,
 	}, nil
 }
 
+// recordFailedLogin counts a failed password or 2FA check towards the account lockout.
+// The lock is decided on the count returned by the increment, not the one loaded with the
+// user, which concurrent failures may already have moved on.
+func (svc *AuthService) recordFailedLogin(user *model.User, action, clientIP, userAgent string) {
+	attempts, err := svc.userRepo.IncrementFailedAttempts(user.ID)
+	if err != nil {
+		log.WithError(err).Errorf("Failed to count failed login for user %s", user.ID)
+	} else if shouldLockAccount(attempts, svc.maxLoginAttempts) {
+		if err := svc.userRepo.LockAccount(user.ID, time.Now().Add(svc.lockoutDuration)); err != nil {
+			log.WithError(err).Errorf("Failed to lock account of user %s", user.ID)
+		}
+		svc.invalidateAuthState(user.ID)
+	}
+
+	svc.logAuthEventCh <- dto.AuthAuditLog{
+		UserID:    user.ID,
+		Action:    action,
+		IP:        clientIP,
+		UserAgent: userAgent,
+		Timestamp: time.Now(),
+		Success:   false,
+	}
+}
+
+// shouldLockAccount reports whether the failed login that brought the count to attempts
+// locks the account. Every maxAttempts-th failure in a row locks, so each failure counts
+// towards exactly one lockout and concurrent failures can't lock twice.
+func shouldLockAccount(attempts, maxAttempts int) bool {
+	return maxAttempts > 0 && attempts > 0 && attempts%maxAttempts == 0
+}
+
+// enforceSessionLimit makes room for a new session according to the user's session
+// limit policy, returning a description of every session that was evicted.
+func (svc *AuthService) enforceSessionLimit(user *model.User, clientIP, userAgent string) ([]string, error) {
+	if user.MaxActiveSessions <= 0 {
+		return nil, nil
+	}
+
+	sessions, err := svc.userRepo.GetUserActiveSessions(user.ID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to check active sessions")
+	}
+
+	excess := len(sessions) - user.MaxActiveSessions + 1
+	if excess <= 0 {
+		return nil, nil
+	}
+
+	if user.SessionLimitPolicy == model.SessionLimitPolicyReject {
+		svc.logAuthEventCh <- dto.AuthAuditLog{
+			UserID:    user.ID,
+			Action:    "failed_login_session_limit",
+			IP:        clientIP,
+			UserAgent: userAgent,
+			Timestamp: time.Now(),
+			Success:   false,
+			Details:   fmt.Sprintf("Active session limit of %d reached", user.MaxActiveSessions),
+		}
+		return nil, shared.NewForbiddenError(errors.New("session limit reached"),
+			"Maximum number of active sessions reached. Sign out of another device to continue").
+			WithData(map[string]interface{}{"max_active_sessions": user.MaxActiveSessions})
+	}
+
+	// Sessions come back most recently used first, so the oldest are at the end
+	evicted := make([]string, 0, excess)
+	for _, session := range sessions[len(sessions)-excess:] {
+		if session.RefreshTokenJTI != "" {
+			if err := svc.jwtSvc.BlacklistJTI(session.RefreshTokenJTI, session.RefreshExpiresAt); err != nil {
+				log.WithError(err).Errorf("Failed to blacklist refresh token for session %s", session.ID)
+			}
+		}
+
+		if err := svc.userRepo.DeactivateSession(session.ID, user.ID); err != nil {
+			return nil, shared.NewInternalError(err, "Failed to evict old session")
+		}
+		svc.invalidateAuthState(user.ID)
+
+		svc.logAuthEventCh <- dto.AuthAuditLog{
+			UserID:    user.ID,
+			Action:    "session_evicted",
+			IP:        session.IP,
+			UserAgent: session.UserAgent,
+			Timestamp: time.Now(),
+			Success:   true,
+			Details:   fmt.Sprintf("Session %s evicted by new login from %s", session.ID, clientIP),
+		}
+
+		evicted = append(evicted, fmt.Sprintf("%s (%s, last used %s)",
+			deviceLabel(useragent.Parse(session.UserAgent)), session.IP, session.LastUsed.Local().Format("2006-01-02 15:04:05")))
+	}
+
+	return evicted, nil
+}
+
 func (svc *AuthService) RefreshToken(refreshRequest dto.RefreshTokenRequest, clientIP, userAgent string) (*dto.LoginResponse, error) {
 	userID, err := svc.jwtSvc.VerifyRefreshToken(refreshRequest.RefreshToken)
 	if err != nil {
@@ -335,13 +613,23 @@ func (svc *AuthService) RefreshToken(refreshRequest dto.RefreshTokenRequest, cli
 	}
 
 	tokenHash := svc.hashToken(refreshRequest.RefreshToken)
-	session, err := svc.sqlSvc.userRepo.GetActiveSession(userID, tokenHash)
+	session, err := svc.userRepo.GetActiveSession(userID, tokenHash)
 	if err != nil {
 		return nil, shared.NewUnauthorizedError(err, "Session not found or expired")
 	}
 
+	user, err := svc.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get user info")
+	}
+
+	if svc.isSessionIdle(session, user) {
+		svc.expireIdleSession(session)
+		return nil, shared.NewUnauthorizedError(nil, "Session expired due to inactivity")
+	}
+
 	svc.dbOperationCh <- func() {
-		svc.sqlSvc.userRepo.UpdateSessionLastUsed(session.ID)
+		svc.userRepo.UpdateSessionLastUsed(session.ID)
 	}
 
 	// Generate tokens with session_id
@@ -352,12 +640,7 @@ func (svc *AuthService) RefreshToken(refreshRequest dto.RefreshTokenRequest, cli
 
 	newTokenHash := svc.hashToken(tokenPair.RefreshToken)
 	svc.dbOperationCh <- func() {
-		svc.sqlSvc.userRepo.UpdateSessionToken(session.ID, newTokenHash)
-	}
-
-	user, err := svc.sqlSvc.userRepo.GetUserByID(userID)
-	if err != nil {
-		return nil, shared.NewInternalError(err, "Failed to get user info")
+		svc.userRepo.UpdateSessionToken(session.ID, newTokenHash)
 	}
 
 	svc.logAuthEventCh <- dto.AuthAuditLog{
@@ -401,17 +684,18 @@ func (svc *AuthService) Logout(userID, sessionID, accessToken, clientIP, userAge
 		}
 	}
 
-	session, err := svc.sqlSvc.userRepo.GetSessionByID(sessionID)
+	session, err := svc.userRepo.GetSessionByID(sessionID)
 	if err == nil && session != nil && session.RefreshTokenJTI != "" {
-		if err := svc.sqlSvc.userRepo.BlacklistToken(session.RefreshTokenJTI, session.RefreshExpiresAt); err != nil {
+		if err := svc.jwtSvc.BlacklistJTI(session.RefreshTokenJTI, session.RefreshExpiresAt); err != nil {
 			log.WithError(err).Error("Failed to blacklist refresh token")
 		}
 	}
 
-	err = svc.sqlSvc.userRepo.DeactivateSession(sessionID, userID)
+	err = svc.userRepo.DeactivateSession(sessionID, userID)
 	if err != nil {
 		return shared.NewInternalError(err, "Failed to logout")
 	}
+	svc.invalidateAuthState(userID)
 
 	svc.logAuthEventCh <- dto.AuthAuditLog{
 		UserID:    userID,
@@ -432,21 +716,22 @@ func (svc *AuthService) LogoutAllDevices(userID, currentSessionID, accessToken,
 		}
 	}
 
-	sessions, err := svc.sqlSvc.userRepo.GetUserActiveSessions(userID)
+	sessions, err := svc.userRepo.GetUserActiveSessions(userID)
 	if err == nil {
 		for _, session := range sessions {
 			if session.RefreshTokenJTI != "" {
-				if err := svc.sqlSvc.userRepo.BlacklistToken(session.RefreshTokenJTI, session.RefreshExpiresAt); err != nil {
+				if err := svc.jwtSvc.BlacklistJTI(session.RefreshTokenJTI, session.RefreshExpiresAt); err != nil {
 					log.WithError(err).Errorf("Failed to blacklist refresh token for session %s", session.ID)
 				}
 			}
 		}
 	}
 
-	err = svc.sqlSvc.userRepo.DeactivateAllUserSessions(userID, currentSessionID)
+	err = svc.userRepo.DeactivateAllUserSessions(userID, currentSessionID)
 	if err != nil {
 		return shared.NewInternalError(err, "Failed to logout from all devices")
 	}
+	svc.invalidateAuthState(userID)
 
 	svc.logAuthEventCh <- dto.AuthAuditLog{
 		UserID:    userID,
@@ -461,7 +746,7 @@ func (svc *AuthService) LogoutAllDevices(userID, currentSessionID, accessToken,
 }
 
 func (svc *AuthService) VerifyEmail(email, code string) error {
-	user, err := svc.sqlSvc.userRepo.GetUserByVerificationCode(email, code)
+	user, err := svc.userRepo.GetUserByVerificationCode(email, code)
 	if err != nil {
 		return shared.NewBadRequestError(err, "Invalid verification code or email")
 	}
@@ -475,10 +760,13 @@ func (svc *AuthService) VerifyEmail(email, code string) error {
 		return shared.NewBadRequestError(errors.New("code expired"), "Verification code has expired. Please request a new one")
 	}
 
-	err = svc.sqlSvc.userRepo.VerifyUserEmail(user.ID)
+	err = svc.userRepo.VerifyUserEmail(user.ID)
 	if err != nil {
 		return shared.NewInternalError(err, "Failed to verify email")
 	}
+	svc.invalidateAuthState(user.ID)
+
+	svc.userSvc.AdvanceOnboarding(user.ID)
 
 	svc.logAuthEventCh <- dto.AuthAuditLog{
 		UserID:    user.ID,
@@ -491,8 +779,67 @@ func (svc *AuthService) VerifyEmail(email, code string) error {
 	return nil
 }
 
+// VerifyEmailLink verifies an address from the one-tap link in the verification email.
+// The token is bound to the code it was issued with, so it stops working once the email
+// is verified or a new code is requested.
+func (svc *AuthService) VerifyEmailLink(token string) error {
+	claims, err := svc.jwtSvc.VerifyEmailVerificationToken(token)
+	if err != nil {
+		return shared.NewBadRequestError(err, "Invalid or expired link")
+	}
+
+	user, err := svc.userRepo.GetUserByID(claims.UserID)
+	if err != nil {
+		return shared.NewBadRequestError(err, "Invalid or expired link")
+	}
+
+	if user.EmailVerified {
+		return shared.NewBadRequestError(errors.New("already verified"), "Email is already verified")
+	}
+
+	if user.VerificationCode == "" || user.VerificationCodeExpiry == nil || user.VerificationCodeExpiry.Before(time.Now()) {
+		return shared.NewBadRequestError(errors.New("code expired"), "Verification link has expired. Please request a new one")
+	}
+
+	binding := svc.verificationBinding(user.ID, user.Email, user.VerificationCode)
+	if subtle.ConstantTimeCompare([]byte(binding), []byte(claims.Binding)) != 1 {
+		return shared.NewBadRequestError(errors.New("binding mismatch"), "This link has been replaced by a newer one")
+	}
+
+	if err := svc.userRepo.VerifyUserEmail(user.ID); err != nil {
+		return shared.NewInternalError(err, "Failed to verify email")
+	}
+	svc.invalidateAuthState(user.ID)
+
+	svc.userSvc.AdvanceOnboarding(user.ID)
+
+	svc.logAuthEventCh <- dto.AuthAuditLog{
+		UserID:    user.ID,
+		Action:    "email_verified",
+		Timestamp: time.Now(),
+		Success:   true,
+		Details:   "verified via link",
+	}
+	return nil
+}
+
+// buildVerificationLink returns the deep link for a verification email, or "" if signing
+// fails so the email still goes out with the code alone
+func (svc *AuthService) buildVerificationLink(userID, email, code string) string {
+	token, err := svc.jwtSvc.GenerateEmailVerificationToken(userID, svc.verificationBinding(userID, email, code), emailVerifyLinkTTL)
+	if err != nil {
+		log.WithError(err).Error("Failed to sign email verification link")
+		return ""
+	}
+	return fmt.Sprintf("%s?token=%s", svc.verifyLinkBase, url.QueryEscape(token))
+}
+
+func (svc *AuthService) verificationBinding(userID, email, code string) string {
+	return svc.hashToken(userID + ":" + strings.ToLower(email) + ":" + code)
+}
+
 func (svc *AuthService) ResendVerificationEmail(email string) error {
-	user, err := svc.sqlSvc.userRepo.GetUserByEmail(email)
+	user, err := svc.userRepo.GetUserByEmail(email)
 	if err != nil {
 		return shared.NewBadRequestError(err, "User not found")
 	}
@@ -506,22 +853,25 @@ func (svc *AuthService) ResendVerificationEmail(email string) error {
 		return shared.NewInternalError(err, "Failed to generate verification code")
 	}
 
-	err = svc.sqlSvc.userRepo.UpdateVerificationCode(user.ID, verificationCode)
-	if err != nil {
-		return shared.NewInternalError(err, "Failed to update verification code")
-	}
-
-	svc.sendVerificationEmailAsync <- VerificationEmail{
+	message := newOutboxMessage(OutboxTopicVerificationEmail, VerificationEmail{
 		Email:            user.Email,
 		Username:         user.Username,
 		VerificationCode: verificationCode,
+		VerificationLink: svc.buildVerificationLink(user.ID, user.Email, verificationCode),
+	})
+
+	err = svc.userRepo.UpdateVerificationCode(user.ID, verificationCode, message)
+	if err != nil {
+		return shared.NewInternalError(err, "Failed to update verification code")
 	}
 
+	go svc.outboxSvc.Relay(message)
+
 	return nil
 }
 
 func (svc *AuthService) ForgotPassword(email string) error {
-	user, err := svc.sqlSvc.userRepo.GetUserByEmail(email)
+	user, err := svc.userRepo.GetUserByEmail(email)
 	if err != nil {
 		return nil
 	}
@@ -531,26 +881,29 @@ func (svc *AuthService) ForgotPassword(email string) error {
 		return shared.NewInternalError(err, "Failed to generate reset code")
 	}
 
+	messages := []*model.OutboxMessage{
+		newOutboxMessage(OutboxTopicPasswordResetEmail, PasswordResetEmail{
+			Email:     user.Email,
+			Username:  user.Username,
+			ResetCode: resetCode,
+		}),
+		newOutboxMessage(OutboxTopicAuthAudit, dto.AuthAuditLog{
+			UserID:    user.ID,
+			Action:    "password_reset_requested",
+			IP:        "",
+			UserAgent: "",
+			Timestamp: time.Now(),
+			Success:   true,
+		}),
+	}
+
 	expiresAt := time.Now().Add(time.Hour)
-	err = svc.sqlSvc.userRepo.CreatePasswordResetCode(user.ID, resetCode, expiresAt)
+	err = svc.userRepo.CreatePasswordResetCode(user.ID, resetCode, expiresAt, messages...)
 	if err != nil {
 		return shared.NewInternalError(err, "Failed to create reset code")
 	}
 
-	svc.sendPasswordResetEmailAsync <- PasswordResetEmail{
-		Email:     user.Email,
-		Username:  user.Username,
-		ResetCode: resetCode,
-	}
-
-	svc.logAuthEventCh <- dto.AuthAuditLog{
-		UserID:    user.ID,
-		Action:    "password_reset_requested",
-		IP:        "",
-		UserAgent: "",
-		Timestamp: time.Now(),
-		Success:   true,
-	}
+	go svc.outboxSvc.Relay(messages...)
 	return nil
 }
 
@@ -559,7 +912,7 @@ func (svc *AuthService) ResetPassword(resetRequest dto.ResetPasswordRequest) err
 		return shared.NewBadRequestError(err, err.Error())
 	}
 
-	resetCode, err := svc.sqlSvc.userRepo.GetPasswordResetCode(resetRequest.Code)
+	resetCode, err := svc.userRepo.GetPasswordResetCode(resetRequest.Code)
 	if err != nil {
 		return shared.NewBadRequestError(err, "Invalid reset code")
 	}
@@ -573,17 +926,18 @@ func (svc *AuthService) ResetPassword(resetRequest dto.ResetPasswordRequest) err
 		return shared.NewInternalError(err, "Failed to hash password")
 	}
 
-	err = svc.sqlSvc.userRepo.UpdateUserPassword(resetCode.UserID, hashedPassword)
+	err = svc.userRepo.UpdateUserPassword(resetCode.UserID, hashedPassword)
 	if err != nil {
 		return shared.NewInternalError(err, "Failed to update password")
 	}
 
 	svc.dbOperationCh <- func() {
-		svc.sqlSvc.userRepo.InvalidatePasswordResetCode(resetRequest.Code)
+		svc.userRepo.InvalidatePasswordResetCode(resetRequest.Code)
 	}
 
 	svc.dbOperationCh <- func() {
-		svc.sqlSvc.userRepo.DeactivateAllUserSessions(resetCode.UserID, "")
+		svc.userRepo.DeactivateAllUserSessions(resetCode.UserID, "")
+		svc.invalidateAuthState(resetCode.UserID)
 	}
 
 	svc.logAuthEventCh <- dto.AuthAuditLog{
@@ -597,8 +951,148 @@ func (svc *AuthService) ResetPassword(resetRequest dto.ResetPasswordRequest) err
 	return nil
 }
 
+// RequestEmailChange starts moving the account to a new address. The current address
+// stays active until the new one is confirmed, and is told how to revert the change.
+func (svc *AuthService) RequestEmailChange(userID, newEmail string) error {
+	user, err := svc.userRepo.GetUserByID(userID)
+	if err != nil {
+		return shared.NewNotFoundError(err, "User not found")
+	}
+
+	if strings.EqualFold(user.Email, newEmail) {
+		return shared.NewBadRequestError(errors.New("same email"), "This is already your email address")
+	}
+
+	available, err := svc.userRepo.IsEmailAvailable(newEmail)
+	if err != nil {
+		return shared.NewInternalError(err, "Failed to check email availability")
+	}
+	if !available {
+		return shared.NewConflictError(errors.New("email taken"), "Email is already taken")
+	}
+
+	code, err := svc.generateVerificationCode()
+	if err != nil {
+		return shared.NewInternalError(err, "Failed to generate verification code")
+	}
+
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return shared.NewInternalError(err, "Failed to generate revert token")
+	}
+	revertToken := hex.EncodeToString(tokenBytes)
+
+	now := time.Now()
+	messages := []*model.OutboxMessage{
+		newOutboxMessage(OutboxTopicEmailChangeEmails, EmailChangeEmails{
+			OldEmail:    user.Email,
+			NewEmail:    newEmail,
+			Username:    user.Username,
+			Code:        code,
+			RevertToken: revertToken,
+		}),
+		newOutboxMessage(OutboxTopicAuthAudit, dto.AuthAuditLog{
+			UserID:    user.ID,
+			Action:    "email_change_requested",
+			Timestamp: now,
+			Success:   true,
+			Details:   fmt.Sprintf("new email: %s", newEmail),
+		}),
+	}
+
+	err = svc.userRepo.CreateEmailChangeRequest(&model.EmailChangeRequest{
+		UserID:          user.ID,
+		OldEmail:        user.Email,
+		NewEmail:        newEmail,
+		CodeHash:        svc.hashToken(code),
+		CodeExpiresAt:   now.Add(emailChangeCodeTTL),
+		RevertTokenHash: svc.hashToken(revertToken),
+		RevertExpiresAt: now.Add(emailChangeRevertTTL),
+	}, messages...)
+	if err != nil {
+		return shared.NewInternalError(err, "Failed to start email change")
+	}
+
+	go svc.outboxSvc.Relay(messages...)
+	return nil
+}
+
+// ConfirmEmailChange activates the pending email once the code sent to it is entered
+func (svc *AuthService) ConfirmEmailChange(userID, code string) error {
+	req, err := svc.userRepo.GetPendingEmailChange(userID)
+	if err != nil {
+		return shared.NewBadRequestError(err, "No email change is pending")
+	}
+
+	if req.CodeExpiresAt.Before(time.Now()) {
+		return shared.NewBadRequestError(errors.New("code expired"), "Confirmation code has expired. Please request the change again")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(svc.hashToken(code)), []byte(req.CodeHash)) != 1 {
+		return shared.NewBadRequestError(errors.New("invalid code"), "Invalid confirmation code")
+	}
+
+	// The address may have been registered since the change was requested
+	available, err := svc.userRepo.IsEmailAvailable(req.NewEmail)
+	if err != nil {
+		return shared.NewInternalError(err, "Failed to check email availability")
+	}
+	if !available {
+		return shared.NewConflictError(errors.New("email taken"), "Email is already taken")
+	}
+
+	if err := svc.userRepo.ConfirmEmailChange(req); err != nil {
+		return shared.NewInternalError(err, "Failed to change email")
+	}
+	svc.invalidateAuthState(userID)
+
+	svc.logAuthEventCh <- dto.AuthAuditLog{
+		UserID:    userID,
+		Action:    "email_changed",
+		Timestamp: time.Now(),
+		Success:   true,
+		Details:   fmt.Sprintf("from %s to %s", req.OldEmail, req.NewEmail),
+	}
+	return nil
+}
+
+// RevertEmailChange is used from the link sent to the old address. It cancels a pending
+// change or restores the old address, and signs out every session in case the account
+// was taken over.
+func (svc *AuthService) RevertEmailChange(token string) error {
+	req, err := svc.userRepo.GetEmailChangeByRevertToken(svc.hashToken(token))
+	if err != nil {
+		return shared.NewBadRequestError(err, "Invalid or expired link")
+	}
+
+	if req.RevertedAt != nil || req.CancelledAt != nil {
+		return shared.NewBadRequestError(errors.New("not active"), "This email change is no longer active")
+	}
+	if req.RevertExpiresAt.Before(time.Now()) {
+		return shared.NewBadRequestError(errors.New("link expired"), "Invalid or expired link")
+	}
+
+	if err := svc.userRepo.RevertEmailChange(req); err != nil {
+		return shared.NewConflictError(err, "Failed to restore the previous email address")
+	}
+
+	if err := svc.userRepo.DeactivateAllUserSessions(req.UserID, ""); err != nil {
+		log.WithError(err).Error("Failed to revoke sessions after email change revert")
+	}
+	svc.invalidateAuthState(req.UserID)
+
+	svc.logAuthEventCh <- dto.AuthAuditLog{
+		UserID:    req.UserID,
+		Action:    "email_change_reverted",
+		Timestamp: time.Now(),
+		Success:   true,
+		Details:   fmt.Sprintf("restored %s", req.OldEmail),
+	}
+	return nil
+}
+
 func (svc *AuthService) ChangePassword(userID string, changeRequest dto.ChangePasswordRequest) error {
-	user, err := svc.sqlSvc.userRepo.GetUserByID(userID)
+	user, err := svc.userRepo.GetUserByID(userID)
 	if err != nil {
 		return shared.NewInternalError(err, "User not found")
 	}
@@ -616,7 +1110,7 @@ func (svc *AuthService) ChangePassword(userID string, changeRequest dto.ChangePa
 		return shared.NewInternalError(err, "Failed to hash password")
 	}
 
-	err = svc.sqlSvc.userRepo.UpdateUserPassword(userID, hashedPassword)
+	err = svc.userRepo.UpdateUserPassword(userID, hashedPassword)
 	if err != nil {
 		return shared.NewInternalError(err, "Failed to update password")
 	}
@@ -640,8 +1134,7 @@ func (svc *AuthService) startDBOperationJob() {
 
 func (svc *AuthService) RequiredAuth() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		authHeader := c.Get("Authorization")
-		token, err := svc.jwtSvc.ExtractTokenFromHeader(authHeader)
+		token, err := svc.ExtractAccessToken(c)
 		if err != nil {
 			return shared.ResponseJSON(c, http.StatusUnauthorized, "Unauthorized", err.Error())
 		}
@@ -656,10 +1149,34 @@ func (svc *AuthService) RequiredAuth() fiber.Handler {
 		}
 
 		// Check if user exists and is active
-		user, err := svc.sqlSvc.userRepo.GetUserByID(claims.UserID)
-		if err != nil || !user.IsActive {
+		state, err := svc.authState.Get(claims.UserID, claims.SessionID, func() (*authState, error) {
+			return svc.loadAuthState(claims.UserID, claims.SessionID)
+		})
+		if err != nil || !state.User.IsActive {
 			return shared.ResponseJSON(c, http.StatusUnauthorized, "Unauthorized", "User account is inactive")
 		}
+		user := state.User
+
+		if claims.SessionID != "" {
+			session := state.Session
+			if session == nil || !session.IsActive || session.UserID != user.ID || time.Now().After(session.ExpiresAt) {
+				return shared.ResponseJSON(c, http.StatusUnauthorized, "Unauthorized", "Session not found or expired")
+			}
+
+			if svc.isSessionIdle(session, user) {
+				svc.expireIdleSession(session)
+				return shared.ResponseJSON(c, http.StatusUnauthorized, "Unauthorized", "Session expired due to inactivity")
+			}
+
+			// Only touch last_used once a minute so busy clients don't write on every request.
+			// The cached state is dropped with it so the idle check sees the new time.
+			if time.Since(session.LastUsed) > sessionActivityResolution {
+				svc.dbOperationCh <- func() {
+					svc.userRepo.UpdateSessionLastUsed(session.ID)
+					svc.invalidateAuthState(session.UserID)
+				}
+			}
+		}
 
 		c.Locals(shared.UserID, claims.UserID)
 		c.Locals("user", user)
@@ -668,7 +1185,183 @@ func (svc *AuthService) RequiredAuth() fiber.Handler {
 	}
 }
 
-func (svc *AuthService) RequireRole(role string) fiber.Handler {
+// OptionalAuth identifies the caller when a valid access token is sent and otherwise
+// lets the request through anonymously, for public routes that adapt to the user
+func (svc *AuthService) OptionalAuth() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token, err := svc.ExtractAccessToken(c)
+		if err != nil {
+			return c.Next()
+		}
+
+		claims, err := svc.jwtSvc.VerifyAndGetClaims(token)
+		if err != nil || claims.UserID == "" {
+			return c.Next()
+		}
+
+		c.Locals(shared.UserID, claims.UserID)
+		return c.Next()
+	}
+}
+
+// loadAuthState loads the user and session RequiredAuth checks. A session that doesn't
+// exist is left nil so the state can be cached like a revoked one.
+func (svc *AuthService) loadAuthState(userID, sessionID string) (*authState, error) {
+	user, err := svc.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	state := &authState{User: user}
+	if sessionID != "" {
+		state.Session, err = svc.userRepo.GetSessionByID(sessionID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+	return state, nil
+}
+
+// invalidateAuthState drops the cached auth state of the user and all of their sessions.
+// Call it after anything RequiredAuth checks changes: a session is revoked, the account
+// is locked or deactivated, or the user's role or verification changes.
+func (svc *AuthService) invalidateAuthState(userID string) {
+	if svc == nil {
+		return
+	}
+	svc.authState.Invalidate(userID)
+}
+
+// isSessionIdle reports whether the session has been unused for longer than the
+// user's configured SessionTimeout (in minutes).
+func (svc *AuthService) isSessionIdle(session *model.UserSession, user *model.User) bool {
+	if user.SessionTimeout <= 0 {
+		return false
+	}
+	return time.Since(session.LastUsed) > time.Duration(user.SessionTimeout)*time.Minute
+}
+
+func (svc *AuthService) expireIdleSession(session *model.UserSession) {
+	svc.dbOperationCh <- func() {
+		if err := svc.userRepo.DeactivateSession(session.ID, session.UserID); err != nil {
+			log.WithError(err).Error("Failed to expire idle session")
+		}
+		svc.invalidateAuthState(session.UserID)
+	}
+
+	svc.logAuthEventCh <- dto.AuthAuditLog{
+		UserID:    session.UserID,
+		Action:    "session_expired",
+		IP:        session.IP,
+		Timestamp: time.Now(),
+		Success:   true,
+		Details:   "Session expired due to inactivity",
+	}
+}
+
+// ExtractAccessToken reads the access token from the Authorization header, falling back to
+// the session cookie for web clients using cookie mode.
+func (svc *AuthService) ExtractAccessToken(c *fiber.Ctx) (string, error) {
+	authHeader := c.Get("Authorization")
+	if authHeader == "" {
+		if token := c.Cookies(shared.AccessTokenCookie); token != "" {
+			return token, nil
+		}
+	}
+	return svc.jwtSvc.ExtractTokenFromHeader(authHeader)
+}
+
+// UsesCookieSession reports whether the client asked for cookie-based sessions
+func (svc *AuthService) UsesCookieSession(c *fiber.Ctx) bool {
+	return strings.EqualFold(c.Get(shared.AuthModeHeader), shared.AuthModeCookie)
+}
+
+// SetSessionCookies moves the tokens from the response into HttpOnly cookies and issues a
+// fresh CSRF token, which is returned in the body and in a cookie readable by the client.
+func (svc *AuthService) SetSessionCookies(c *fiber.Ctx, resp *dto.LoginResponse) error {
+	csrfBytes := make([]byte, 32)
+	if _, err := rand.Read(csrfBytes); err != nil {
+		return shared.NewInternalError(err, "Failed to generate CSRF token")
+	}
+	csrfToken := hex.EncodeToString(csrfBytes)
+	refreshExpiry := time.Now().Add(svc.jwtSvc.RefreshTokenDuration)
+
+	c.Cookie(svc.sessionCookie(shared.AccessTokenCookie, resp.AccessToken, "/", time.Now().Add(time.Duration(resp.ExpiresIn)*time.Second), true))
+	c.Cookie(svc.sessionCookie(shared.RefreshTokenCookie, resp.RefreshToken, refreshCookiePath(c), refreshExpiry, true))
+	c.Cookie(svc.sessionCookie(shared.CSRFTokenCookie, csrfToken, "/", refreshExpiry, false))
+
+	resp.AccessToken = ""
+	resp.RefreshToken = ""
+	resp.CSRFToken = csrfToken
+	return nil
+}
+
+func (svc *AuthService) ClearSessionCookies(c *fiber.Ctx) {
+	expired := time.Unix(0, 0)
+	c.Cookie(svc.sessionCookie(shared.AccessTokenCookie, "", "/", expired, true))
+	c.Cookie(svc.sessionCookie(shared.RefreshTokenCookie, "", refreshCookiePath(c), expired, true))
+	c.Cookie(svc.sessionCookie(shared.CSRFTokenCookie, "", "/", expired, false))
+}
+
+// refreshCookiePath scopes the refresh cookie to the API version that issued it, so a
+// client only ever holds one refresh cookie per version it talks to
+func refreshCookiePath(c *fiber.Ctx) string {
+	version, ok := c.Locals(shared.APIVersion).(string)
+	if !ok {
+		version = APIVersion1
+	}
+	return apiPathPrefix + version
+}
+
+func (svc *AuthService) sessionCookie(name, value, path string, expires time.Time, httpOnly bool) *fiber.Cookie {
+	return &fiber.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   svc.cookieDomain,
+		Expires:  expires,
+		Secure:   svc.cookieSecure,
+		HTTPOnly: httpOnly,
+		SameSite: svc.cookieSameSite,
+	}
+}
+
+// csrfExemptPaths authenticate with the single-use token from an email link, which a
+// cross-site form can't know. Their link pages post as plain forms without the header.
+var csrfExemptPaths = []string{emailChangeRevertPath, accountRecoveryCancelPath, deviceActionPath}
+
+// RequireCSRF applies double-submit CSRF validation to state-changing requests that
+// authenticate with session cookies. Requests carrying an Authorization header are not
+// exposed to CSRF and pass through unchanged.
+func (svc *AuthService) RequireCSRF() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			return c.Next()
+		}
+		if slices.Contains(csrfExemptPaths, apiRoutePath(c.Path())) {
+			return c.Next()
+		}
+
+		if c.Get("Authorization") != "" {
+			return c.Next()
+		}
+		if c.Cookies(shared.AccessTokenCookie) == "" && c.Cookies(shared.RefreshTokenCookie) == "" {
+			return c.Next()
+		}
+
+		cookieToken := c.Cookies(shared.CSRFTokenCookie)
+		headerToken := c.Get(shared.CSRFTokenHeader)
+		if cookieToken == "" || subtle.ConstantTimeCompare([]byte(cookieToken), []byte(headerToken)) != 1 {
+			return shared.ResponseJSON(c, http.StatusForbidden, "Forbidden", "Invalid or missing CSRF token")
+		}
+
+		return c.Next()
+	}
+}
+
+// RequireRole lets the request through when the user has one of the given roles
+func (svc *AuthService) RequireRole(roles ...string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		user := c.Locals("user")
 		if user == nil {
@@ -676,7 +1369,7 @@ func (svc *AuthService) RequireRole(role string) fiber.Handler {
 		}
 
 		userObj := user.(*model.User)
-		if userObj.Role != role {
+		if !slices.Contains(roles, userObj.Role) {
 			return shared.ResponseJSON(c, http.StatusForbidden, "Forbidden", "Insufficient permissions")
 		}
 
@@ -700,37 +1393,113 @@ func (svc *AuthService) RequireEmailVerified() fiber.Handler {
 	}
 }
 
-func (svc *AuthService) startVerificationEmailJob() {
-	for email := range svc.sendVerificationEmailAsync {
-		err := svc.emailSvc.SendVerificationEmail(email.Email, email.Username, email.VerificationCode)
-		if err != nil {
-			log.WithError(err).Error("Failed to send verification email")
-		}
+// EmailQueueDepth is the number of emails waiting to be sent
+func (svc *AuthService) EmailQueueDepth() int {
+	count, err := svc.sqlSvc.outboxRepo.CountPending(outboxTopicEmailPrefix)
+	if err != nil {
+		log.WithError(err).Error("Failed to count pending emails")
+		return 0
 	}
+	return int(count)
 }
 
-func (svc *AuthService) startPasswordResetEmailJob() {
-	for email := range svc.sendPasswordResetEmailAsync {
-		err := svc.emailSvc.SendPasswordResetEmail(email.Email, email.Username, email.ResetCode)
-		if err != nil {
-			log.WithError(err).Error("Failed to send password reset email")
+// registerOutboxHandlers sends the emails and audit entries that auth flows write to
+// the outbox alongside their state change. Returning the send error retries the email.
+func (svc *AuthService) registerOutboxHandlers() {
+	svc.outboxSvc.Handle(OutboxTopicVerificationEmail, func(payload json.RawMessage) error {
+		var email VerificationEmail
+		if err := json.Unmarshal(payload, &email); err != nil {
+			return err
 		}
-	}
-}
+		return svc.emailSvc.SendVerificationEmail(email.Email, email.Username, email.VerificationCode, email.VerificationLink)
+	})
 
-func (svc *AuthService) startLoginNotificationEmailJob() {
-	for email := range svc.sendLoginNotificationEmailAsync {
-		err := svc.emailSvc.SendLoginNotificationEmail(email.Email, email.Username, email.LoginTime, email.IP, email.Device, email.Location)
-		if err != nil {
-			log.WithError(err).Error("Failed to send login notification email")
+	svc.outboxSvc.Handle(OutboxTopicPasswordResetEmail, func(payload json.RawMessage) error {
+		var email PasswordResetEmail
+		if err := json.Unmarshal(payload, &email); err != nil {
+			return err
 		}
-	}
+		return svc.emailSvc.SendPasswordResetEmail(email.Email, email.Username, email.ResetCode)
+	})
+
+	svc.outboxSvc.Handle(OutboxTopicLoginNotificationEmail, func(payload json.RawMessage) error {
+		var email LoginNotificationEmail
+		if err := json.Unmarshal(payload, &email); err != nil {
+			return err
+		}
+		return svc.emailSvc.SendLoginNotificationEmail(email.Email, email.Username, email.LoginTime, email.IP, email.Device, email.NewDevice, email.Location, email.EvictedDevices, email.TrustToken, email.UntrustToken)
+	})
+
+	// Both emails go out together, so a retry after a failed notice resends the code too
+	svc.outboxSvc.Handle(OutboxTopicEmailChangeEmails, func(payload json.RawMessage) error {
+		var email EmailChangeEmails
+		if err := json.Unmarshal(payload, &email); err != nil {
+			return err
+		}
+		if err := svc.emailSvc.SendEmailChangeVerificationEmail(email.NewEmail, email.Username, email.Code); err != nil {
+			return err
+		}
+		return svc.emailSvc.SendEmailChangeNoticeEmail(email.OldEmail, email.Username, email.NewEmail, email.RevertToken)
+	})
+
+	svc.outboxSvc.Handle(OutboxTopicAccountRecoveryEmail, func(payload json.RawMessage) error {
+		var email AccountRecoveryEmail
+		if err := json.Unmarshal(payload, &email); err != nil {
+			return err
+		}
+		return svc.emailSvc.SendAccountRecoveryNoticeEmail(email.Email, email.Username, email.NewEmail, email.RequestIP, email.FallbackAt, email.CancelToken)
+	})
+
+	svc.outboxSvc.Handle(OutboxTopicBackupCodesLowEmail, func(payload json.RawMessage) error {
+		var email BackupCodesLowEmail
+		if err := json.Unmarshal(payload, &email); err != nil {
+			return err
+		}
+		return svc.emailSvc.SendBackupCodesLowEmail(email.Email, email.Username, email.IP, email.Remaining)
+	})
+
+	svc.outboxSvc.Handle(OutboxTopicSecurityDigestEmail, func(payload json.RawMessage) error {
+		var email SecurityDigestEmail
+		if err := json.Unmarshal(payload, &email); err != nil {
+			return err
+		}
+		return svc.emailSvc.SendSecurityDigestEmail(email.Email, email.Data)
+	})
+
+	svc.outboxSvc.Handle(OutboxTopicAuthAudit, func(payload json.RawMessage) error {
+		var auditLog dto.AuthAuditLog
+		if err := json.Unmarshal(payload, &auditLog); err != nil {
+			return err
+		}
+		return svc.recordAuthEvent(auditLog)
+	})
 }
 
 func (svc *AuthService) startLogAuthEventJob() {
 	for auditLog := range svc.logAuthEventCh {
-		svc.sqlSvc.userRepo.CreateAuthAuditLog(auditLog)
+		if err := svc.recordAuthEvent(auditLog); err != nil {
+			log.WithError(err).WithField("action", auditLog.Action).Error("Failed to record auth event")
+		}
+	}
+}
+
+func (svc *AuthService) recordAuthEvent(auditLog dto.AuthAuditLog) error {
+	if err := svc.userRepo.CreateAuthAuditLog(auditLog); err != nil {
+		return err
 	}
+
+	switch auditLog.Action {
+	case "login":
+		svc.systemSvc.PublishOpsEvent(OpsEventLogin, OpsSeverityInfo, map[string]interface{}{
+			"user_id": auditLog.UserID,
+			"ip":      auditLog.IP,
+		})
+	case "register":
+		svc.systemSvc.PublishOpsEvent(OpsEventRegistration, OpsSeverityInfo, map[string]interface{}{
+			"user_id": auditLog.UserID,
+		})
+	}
+	return nil
 }
 
 func (svc *AuthService) hashToken(token string) string {
@@ -743,7 +1512,7 @@ func (svc *AuthService) GetDetailedLocationInfo(ip string) (*GeolocationResponse
 }
 
 func (svc *AuthService) GetUserDevices(userID string) ([]dto.DeviceInfo, error) {
-	devices, err := svc.sqlSvc.userRepo.GetUserTrustedDevices(userID)
+	devices, err := svc.userRepo.GetUserTrustedDevices(userID)
 	if err != nil {
 		return nil, shared.NewInternalError(err, "Failed to get user devices")
 	}
@@ -766,13 +1535,13 @@ func (svc *AuthService) GetUserDevices(userID string) ([]dto.DeviceInfo, error)
 }
 
 func (svc *AuthService) UpdateDeviceTrust(userID, deviceID string, trust bool) error {
-	device, err := svc.sqlSvc.userRepo.GetTrustedDevice(userID, deviceID)
+	device, err := svc.userRepo.GetTrustedDevice(userID, deviceID)
 	if err != nil {
 		return shared.NewNotFoundError(err, "Device not found")
 	}
 
 	device.IsTrusted = trust
-	if err := svc.sqlSvc.userRepo.UpdateTrustedDevice(device); err != nil {
+	if err := svc.userRepo.UpdateTrustedDevice(device); err != nil {
 		return shared.NewInternalError(err, "Failed to update device trust")
 	}
 
@@ -792,8 +1561,78 @@ func (svc *AuthService) UpdateDeviceTrust(userID, deviceID string, trust bool) e
 	return nil
 }
 
+// ApplyDeviceAction is used from the links in a login email. Trusting the device stops
+// login emails for it; untrusting it also signs it out, for logins the user doesn't
+// recognise.
+func (svc *AuthService) ApplyDeviceAction(token, clientIP, userAgent string) error {
+	claims, action, deviceID, err := svc.jwtSvc.VerifyDeviceActionToken(token)
+	if err != nil || (action != deviceActionTrust && action != deviceActionUntrust) {
+		return shared.NewBadRequestError(err, "Invalid or expired link")
+	}
+
+	device, err := svc.userRepo.GetTrustedDevice(claims.UserID, deviceID)
+	if err != nil {
+		return shared.NewNotFoundError(err, "Device not found")
+	}
+
+	device.IsTrusted = action == deviceActionTrust
+	if err := svc.userRepo.UpdateTrustedDevice(device); err != nil {
+		return shared.NewInternalError(err, "Failed to update device trust")
+	}
+
+	if err := svc.jwtSvc.BlacklistJTI(claims.ID, claims.ExpiresAt.Time); err != nil {
+		log.WithError(err).Errorf("Failed to revoke device action link for user %s", claims.UserID)
+	}
+
+	if action == deviceActionUntrust {
+		if err := svc.signOutDevice(claims.UserID, deviceID); err != nil {
+			return err
+		}
+	}
+
+	auditAction := "device_untrusted"
+	if device.IsTrusted {
+		auditAction = "device_trusted"
+	}
+	svc.logAuthEventCh <- dto.AuthAuditLog{
+		UserID:    claims.UserID,
+		Action:    auditAction,
+		IP:        clientIP,
+		UserAgent: userAgent,
+		Timestamp: time.Now(),
+		Success:   true,
+		Details:   fmt.Sprintf("Device %s via email link", deviceID),
+	}
+
+	return nil
+}
+
+// signOutDevice ends every active session of one device
+func (svc *AuthService) signOutDevice(userID, deviceID string) error {
+	sessions, err := svc.userRepo.GetUserActiveSessions(userID)
+	if err != nil {
+		return shared.NewInternalError(err, "Failed to get active sessions")
+	}
+
+	for _, session := range sessions {
+		if session.DeviceID != deviceID {
+			continue
+		}
+		if session.RefreshTokenJTI != "" {
+			if err := svc.jwtSvc.BlacklistJTI(session.RefreshTokenJTI, session.RefreshExpiresAt); err != nil {
+				log.WithError(err).Errorf("Failed to blacklist refresh token for session %s", session.ID)
+			}
+		}
+		if err := svc.userRepo.DeactivateSession(session.ID, userID); err != nil {
+			return shared.NewInternalError(err, "Failed to sign out device")
+		}
+	}
+	svc.invalidateAuthState(userID)
+	return nil
+}
+
 func (svc *AuthService) RemoveDevice(userID, deviceID string) error {
-	if err := svc.sqlSvc.userRepo.RemoveTrustedDevice(userID, deviceID); err != nil {
+	if err := svc.userRepo.RemoveTrustedDevice(userID, deviceID); err != nil {
 		return shared.NewInternalError(err, "Failed to remove device")
 	}
 
@@ -808,12 +1647,23 @@ func (svc *AuthService) RemoveDevice(userID, deviceID string) error {
 	return nil
 }
 
+// RegisterOrUpdateDevice records a device the user signed in from, untrusted until they
+// trust it. A known device keeps its trust and name; its OS and browser follow updates.
 func (svc *AuthService) RegisterOrUpdateDevice(userID, deviceID, name, deviceType, os, browser, ip string) error {
-	device, err := svc.sqlSvc.userRepo.GetTrustedDevice(userID, deviceID)
+	device, err := svc.userRepo.GetTrustedDevice(userID, deviceID)
 	if err == nil {
 		device.LastUsed = time.Now()
 		device.IP = ip
-		return svc.sqlSvc.userRepo.UpdateTrustedDevice(device)
+		if os != "" {
+			device.OS = os
+		}
+		if browser != "" {
+			device.Browser = browser
+		}
+		if deviceType != "" && deviceType != useragent.TypeUnknown {
+			device.Type = deviceType
+		}
+		return svc.userRepo.UpdateTrustedDevice(device)
 	}
 
 	newDevice := &model.TrustedDevice{
@@ -827,5 +1677,5 @@ func (svc *AuthService) RegisterOrUpdateDevice(userID, deviceID, name, deviceTyp
 		IsTrusted: false,
 	}
 
-	return svc.sqlSvc.userRepo.CreateTrustedDevice(newDevice)
+	return svc.userRepo.CreateTrustedDevice(newDevice)
 }
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/services/repositories/mocks"
+)
+
+func TestShouldLockAccount(t *testing.T) {
+	cases := []struct {
+		attempts, max int
+		want          bool
+	}{
+		{1, 5, false},
+		{4, 5, false},
+		{5, 5, true},
+		{6, 5, false},
+		{9, 5, false},
+		{10, 5, true},
+		{0, 5, false},
+		{3, 0, false},
+	}
+	for _, c := range cases {
+		if got := shouldLockAccount(c.attempts, c.max); got != c.want {
+			t.Errorf("shouldLockAccount(%d, %d) = %v, want %v", c.attempts, c.max, got, c.want)
+		}
+	}
+}
+
+// Concurrent failed logins go through recordFailedLogin against a repository whose
+// increment is atomic like the database's, so each streak of maxLoginAttempts failures
+// locks exactly once however the requests interleave
+func TestConcurrentFailedLoginsLockOnce(t *testing.T) {
+	const maxAttempts = 5
+	for _, failures := range []int{4, 5, 7, 25, 101} {
+		var counter, locks int64
+		svc := &AuthService{
+			maxLoginAttempts: maxAttempts,
+			lockoutDuration:  time.Minute,
+			logAuthEventCh:   make(chan dto.AuthAuditLog, failures),
+			userRepo: &mocks.UserRepo{
+				IncrementFailedAttemptsFunc: func(userID string) (int, error) {
+					return int(atomic.AddInt64(&counter, 1)), nil
+				},
+				LockAccountFunc: func(userID string, lockUntil time.Time) error {
+					atomic.AddInt64(&locks, 1)
+					return nil
+				},
+			},
+		}
+
+		user := &model.User{ID: "user_1"}
+		var wg sync.WaitGroup
+		for i := 0; i < failures; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				svc.recordFailedLogin(user, "failed_login", "203.0.113.7", "test")
+			}()
+		}
+		wg.Wait()
+
+		if want := int64(failures / maxAttempts); locks != want {
+			t.Errorf("%d concurrent failures locked %d times, want %d", failures, locks, want)
+		}
+		if events := len(svc.logAuthEventCh); events != failures {
+			t.Errorf("%d concurrent failures logged %d events", failures, events)
+		}
+	}
+}
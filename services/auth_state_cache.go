@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/redis/go-redis/v9"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	authStateKeyPrefix = "ven:auth:"
+	// Logout, revocation and lockout invalidate the cached state right away. The TTL bounds
+	// how long a change made any other way, e.g. straight in the database, goes unseen.
+	authStateCacheTTL = 30 * time.Second
+	// Version keys outlive every cached state so a reset version cannot resurrect an old one
+	authStateVersionTTL = time.Hour
+)
+
+// authState is what RequiredAuth checks a request against: the user and, for tokens
+// issued with one, their session
+type authState struct {
+	User    *model.User        `json:"user"`
+	Session *model.UserSession `json:"session,omitempty"`
+}
+
+// authStateCache keeps the auth state of recent requests in Redis, so authenticated
+// requests don't each load the user and the session. Like progressCache, each user has
+// a version counter and states are stored under the version they were loaded at, so
+// invalidating also drops a load that was in flight when the user or a session changed.
+type authStateCache struct {
+	redisSvc *RedisService
+	ttl      time.Duration
+}
+
+func newAuthStateCache(redisSvc *RedisService, ttl time.Duration) *authStateCache {
+	return &authStateCache{redisSvc: redisSvc, ttl: ttl}
+}
+
+func authStateVersionKey(userID string) string {
+	return authStateKeyPrefix + "ver:" + userID
+}
+
+func authStateKey(userID, version, sessionID string) string {
+	return fmt.Sprintf("%s%s:%s:%s", authStateKeyPrefix, userID, version, sessionID)
+}
+
+// Get returns the cached state for the user and session, or loads it with load and
+// caches it. Without Redis every call loads.
+func (a *authStateCache) Get(userID, sessionID string, load func() (*authState, error)) (*authState, error) {
+	if a == nil || a.ttl <= 0 {
+		return load()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	client := a.redisSvc.GetClient()
+	version, err := client.Get(ctx, authStateVersionKey(userID)).Result()
+	if err == redis.Nil {
+		version = "0"
+	} else if err != nil {
+		log.WithError(err).Debug("Auth state cache read failed")
+		return load()
+	}
+
+	key := authStateKey(userID, version, sessionID)
+	if data, err := client.Get(ctx, key).Bytes(); err == nil {
+		var state authState
+		if err := json.Unmarshal(data, &state); err == nil && state.User != nil {
+			return &state, nil
+		}
+	}
+
+	state, err := load()
+	if err != nil {
+		return nil, err
+	}
+	if err := a.redisSvc.Set(ctx, key, state, a.ttl); err != nil {
+		log.WithError(err).Debug("Failed to cache auth state")
+	}
+	return state, nil
+}
+
+// Invalidate bumps the user's version so no cached state of the user or any of their
+// sessions is read again
+func (a *authStateCache) Invalidate(userID string) {
+	if a == nil || a.ttl <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	pipe := a.redisSvc.GetClient().TxPipeline()
+	pipe.Incr(ctx, authStateVersionKey(userID))
+	pipe.Expire(ctx, authStateVersionKey(userID), authStateVersionTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.WithError(err).WithField("user_id", userID).Warn("Failed to invalidate auth state")
+	}
+}
@@ -0,0 +1,361 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/cloakd/common/context"
+	serviceContext "github.com/cloakd/common/services"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	log "github.com/sirupsen/logrus"
+)
+
+type BattleService struct {
+	serviceContext.DefaultService
+
+	sqlSvc          *PostgresService
+	contentSvc      *ContentService
+	userSvc         *UserService
+	notificationSvc *NotificationService
+	achievementSvc  *AchievementService
+}
+
+const BATTLE_SVC = "battle_svc"
+
+const (
+	battleMaxQuestions  = 10
+	battleBaseDamage    = 20
+	battleBossDamage    = 25
+	battleBaseSpiritHP  = 100
+	battleBaseXPReward  = 30
+	battleXPPerQuestion = 5
+)
+
+type battleQuestion struct {
+	LessonID   string `json:"lesson_id"`
+	QuestionID string `json:"question_id"`
+}
+
+func (svc BattleService) Id() string {
+	return BATTLE_SVC
+}
+
+func (svc *BattleService) Configure(ctx *context.Context) error {
+	return svc.DefaultService.Configure(ctx)
+}
+
+func (svc *BattleService) Start() error {
+	deps := newDependencies(svc.Id(), svc.Service)
+	svc.sqlSvc = resolve[*PostgresService](deps, POSTGRES_SVC)
+	svc.contentSvc = resolve[*ContentService](deps, CONTENT_SVC)
+	svc.userSvc = resolve[*UserService](deps, USER_SVC)
+	svc.notificationSvc = resolve[*NotificationService](deps, NOTIFICATION_SVC)
+	svc.achievementSvc = resolve[*AchievementService](deps, ACHIEVEMENT_SVC)
+	return deps.err()
+}
+
+// StartBattle creates a battle against a dynasty boss using questions from lessons the
+// user has completed in that dynasty. An unfinished battle is returned instead of
+// starting a new one.
+func (svc *BattleService) StartBattle(userID, dynasty string) (*dto.BattleResponse, error) {
+	if active, err := svc.sqlSvc.contentRepo.GetActiveBattle(userID); err == nil {
+		return svc.mapBattleToResponse(active)
+	}
+
+	spirit, err := svc.sqlSvc.contentRepo.GetUserSpirit(userID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Spirit not found. Initialize your profile first")
+	}
+
+	questions, err := svc.collectBattleQuestions(userID, dynasty)
+	if err != nil {
+		return nil, err
+	}
+	if len(questions) == 0 {
+		return nil, shared.NewBadRequestError(nil, "Complete a lesson from this dynasty before battling its boss")
+	}
+
+	questionsJSON, err := json.Marshal(questions)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to prepare battle")
+	}
+
+	// The boss is tuned so roughly 70% correct answers win without any bonuses
+	bossHP := len(questions) * battleBaseDamage * 7 / 10
+	spiritHP := battleBaseSpiritHP + (spirit.Stage-1)*20
+
+	battle, err := svc.sqlSvc.contentRepo.CreateBattle(&model.SpiritBattle{
+		UserID:      userID,
+		SpiritID:    spirit.ID,
+		Dynasty:     dynasty,
+		BossName:    fmt.Sprintf("Guardian of %s", dynasty),
+		BossHP:      bossHP,
+		BossMaxHP:   bossHP,
+		SpiritHP:    spiritHP,
+		SpiritMaxHP: spiritHP,
+		Questions:   questionsJSON,
+		Status:      model.BattleStatusActive,
+		XPReward:    battleBaseXPReward + len(questions)*battleXPPerQuestion,
+	})
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to start battle")
+	}
+
+	return svc.mapBattleToResponse(battle)
+}
+
+func (svc *BattleService) collectBattleQuestions(userID, dynasty string) ([]battleQuestion, error) {
+	lessonIDs, err := svc.sqlSvc.contentRepo.GetCompletedLessonIDs(userID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to load completed lessons")
+	}
+
+	lessons, err := svc.sqlSvc.contentRepo.GetLessonsByIDs(lessonIDs)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to load lessons")
+	}
+
+	questions := make([]battleQuestion, 0)
+	for _, lesson := range lessons {
+		if lesson.Character.Dynasty != dynasty {
+			continue
+		}
+
+		var lessonQuestions []model.Question
+		if err := json.Unmarshal(lesson.Questions, &lessonQuestions); err != nil {
+			continue
+		}
+
+		for _, q := range lessonQuestions {
+			questions = append(questions, battleQuestion{LessonID: lesson.ID, QuestionID: q.ID})
+		}
+	}
+
+	rand.Shuffle(len(questions), func(i, j int) {
+		questions[i], questions[j] = questions[j], questions[i]
+	})
+	if len(questions) > battleMaxQuestions {
+		questions = questions[:battleMaxQuestions]
+	}
+
+	return questions, nil
+}
+
+func (svc *BattleService) GetBattle(userID, battleID string) (*dto.BattleResponse, error) {
+	battle, err := svc.sqlSvc.contentRepo.GetUserBattle(userID, battleID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Battle not found")
+	}
+
+	return svc.mapBattleToResponse(battle)
+}
+
+// Act answers the current question. A correct answer damages the boss, scaled by the
+// in-battle combo and the user's daily streak; a wrong answer lets the boss strike back.
+func (svc *BattleService) Act(userID, battleID string, answer interface{}) (*dto.BattleActionResponse, error) {
+	battle, err := svc.sqlSvc.contentRepo.GetUserBattle(userID, battleID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Battle not found")
+	}
+
+	if battle.Status != model.BattleStatusActive {
+		return nil, shared.NewBadRequestError(nil, "Battle is already over")
+	}
+
+	questions := svc.parseBattleQuestions(battle)
+	if battle.CurrentIndex >= len(questions) {
+		return nil, shared.NewBadRequestError(nil, "No questions left in this battle")
+	}
+
+	ref := questions[battle.CurrentIndex]
+	question, err := svc.getBattleQuestion(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	variants := svc.contentSvc.getTranslatedQuestions(ref.LessonID)
+	response := &dto.BattleActionResponse{
+		Correct: svc.contentSvc.isLocalizedAnswerCorrect(*question, variants[ref.QuestionID], answer),
+	}
+
+	if response.Correct {
+		battle.Combo++
+		response.DamageDealt = svc.calculateDamage(userID, battle)
+		battle.BossHP = max(0, battle.BossHP-response.DamageDealt)
+	} else {
+		battle.Combo = 0
+		response.DamageTaken = battleBossDamage
+		battle.SpiritHP = max(0, battle.SpiritHP-response.DamageTaken)
+	}
+
+	battle.CurrentIndex++
+	battle.TurnCount++
+
+	switch {
+	case battle.BossHP == 0:
+		svc.endBattle(battle, model.BattleStatusWon)
+	case battle.SpiritHP == 0 || battle.CurrentIndex >= len(questions):
+		svc.endBattle(battle, model.BattleStatusLost)
+	}
+
+	if err := svc.sqlSvc.contentRepo.UpdateBattle(battle); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to save battle")
+	}
+
+	if battle.Status == model.BattleStatusWon {
+		svc.achievementSvc.Increment(userID, model.AchievementMetricBattlesWon, 1)
+	}
+
+	battleResponse, err := svc.mapBattleToResponse(battle)
+	if err != nil {
+		return nil, err
+	}
+	response.Battle = *battleResponse
+
+	return response, nil
+}
+
+func (svc *BattleService) calculateDamage(userID string, battle *model.SpiritBattle) int {
+	stage := 1
+	if spirit, err := svc.sqlSvc.contentRepo.GetUserSpirit(userID); err == nil {
+		stage = spirit.Stage
+	}
+
+	streak := 0
+	if progress, err := svc.sqlSvc.contentRepo.GetUserProgress(userID); err == nil {
+		streak = progress.Streak
+	}
+
+	stageMultiplier := 1 + 0.1*float64(stage-1)
+	comboMultiplier := 1 + 0.25*float64(battle.Combo-1)
+	streakMultiplier := 1 + 0.05*float64(min(streak, 10))
+
+	return int(float64(battleBaseDamage) * stageMultiplier * comboMultiplier * streakMultiplier)
+}
+
+// ResolveBattle pays out the reward for a won battle. Resolving an active battle forfeits it.
+func (svc *BattleService) ResolveBattle(userID, battleID string) (*dto.BattleResultResponse, error) {
+	battle, err := svc.sqlSvc.contentRepo.GetUserBattle(userID, battleID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Battle not found")
+	}
+
+	if battle.Status == model.BattleStatusActive {
+		svc.endBattle(battle, model.BattleStatusAbandoned)
+		if err := svc.sqlSvc.contentRepo.UpdateBattle(battle); err != nil {
+			return nil, shared.NewInternalError(err, "Failed to save battle")
+		}
+	}
+
+	xpEarned := 0
+	if battle.Status == model.BattleStatusWon {
+		paid, err := svc.sqlSvc.contentRepo.MarkBattleRewardPaid(battle.ID)
+		if err != nil {
+			return nil, shared.NewInternalError(err, "Failed to pay battle reward")
+		}
+
+		if paid {
+			if err := svc.payBattleReward(userID, battle.ID, battle.XPReward); err != nil {
+				log.Printf("Failed to pay battle reward for %s: %v", battle.ID, err)
+			}
+			xpEarned = battle.XPReward
+
+			svc.notificationSvc.Notify(userID, model.NotificationTypeReward, "Battle reward",
+				fmt.Sprintf("You defeated %s and earned %d XP", battle.BossName, xpEarned),
+				map[string]interface{}{"battle_id": battle.ID, "xp": xpEarned})
+		}
+		battle.RewardPaid = true
+	}
+
+	battleResponse, err := svc.mapBattleToResponse(battle)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.BattleResultResponse{
+		Battle:   *battleResponse,
+		XPEarned: xpEarned,
+	}, nil
+}
+
+func (svc *BattleService) payBattleReward(userID, battleID string, xp int) error {
+	return svc.userSvc.awardXP(userID, xp, model.XPSourceBattle, battleID)
+}
+
+func (svc *BattleService) endBattle(battle *model.SpiritBattle, status string) {
+	now := time.Now()
+	battle.Status = status
+	battle.EndedAt = &now
+}
+
+func (svc *BattleService) parseBattleQuestions(battle *model.SpiritBattle) []battleQuestion {
+	var questions []battleQuestion
+	if err := json.Unmarshal([]byte(battle.Questions), &questions); err != nil {
+		return []battleQuestion{}
+	}
+	return questions
+}
+
+func (svc *BattleService) getBattleQuestion(ref battleQuestion) (*model.Question, error) {
+	lesson, err := svc.sqlSvc.contentRepo.GetLesson(ref.LessonID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Battle question no longer available")
+	}
+
+	var questions []model.Question
+	if err := json.Unmarshal(lesson.Questions, &questions); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to parse lesson questions")
+	}
+
+	for i := range questions {
+		if questions[i].ID == ref.QuestionID {
+			return &questions[i], nil
+		}
+	}
+
+	return nil, shared.NewNotFoundError(nil, "Battle question no longer available")
+}
+
+func (svc *BattleService) mapBattleToResponse(battle *model.SpiritBattle) (*dto.BattleResponse, error) {
+	questions := svc.parseBattleQuestions(battle)
+
+	response := &dto.BattleResponse{
+		ID:             battle.ID,
+		Dynasty:        battle.Dynasty,
+		BossName:       battle.BossName,
+		BossHP:         battle.BossHP,
+		BossMaxHP:      battle.BossMaxHP,
+		SpiritHP:       battle.SpiritHP,
+		SpiritMaxHP:    battle.SpiritMaxHP,
+		Combo:          battle.Combo,
+		TurnCount:      battle.TurnCount,
+		TotalQuestions: len(questions),
+		Status:         battle.Status,
+		XPReward:       battle.XPReward,
+		RewardPaid:     battle.RewardPaid,
+		EndedAt:        battle.EndedAt,
+		CreatedAt:      battle.CreatedAt,
+	}
+
+	if battle.Status == model.BattleStatusActive && battle.CurrentIndex < len(questions) {
+		question, err := svc.getBattleQuestion(questions[battle.CurrentIndex])
+		if err != nil {
+			return nil, err
+		}
+
+		response.CurrentQuestion = &dto.QuestionResponse{
+			ID:       question.ID,
+			Type:     question.Type,
+			Question: question.Question,
+			Options:  question.Options,
+			Points:   question.Points,
+			Metadata: question.Metadata,
+		}
+	}
+
+	return response, nil
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	stdContext "context"
+	"encoding/json"
+	"time"
+
+	"github.com/cloakd/common/context"
+	serviceContext "github.com/cloakd/common/services"
+	"github.com/google/uuid"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	log "github.com/sirupsen/logrus"
+)
+
+// Every instance listens here for content caches to clear
+const cacheInvalidationChannel = "ven:cache:invalidate"
+
+// Scopes an admin can invalidate
+const (
+	CacheScopeLesson     = "lesson"
+	CacheScopeCharacter  = "character"
+	CacheScopeUser       = "user"
+	CacheScopeAllContent = "all_content"
+)
+
+// Names of the caches reported back to the admin
+const (
+	cacheEras               = "eras"
+	cacheGlossary           = "glossary"
+	cacheSearchSuggest      = "search_suggest"
+	cachePublicCatalog      = "public_catalog"
+	cacheTrending           = "trending"
+	cacheProgress           = "progress"
+	cacheLeaderboardProfile = "leaderboard_profile"
+)
+
+// cacheInvalidation is the message broadcast to the other instances
+type cacheInvalidation struct {
+	Scope  string `json:"scope"`
+	ID     string `json:"id,omitempty"`
+	Origin string `json:"origin"`
+}
+
+// CacheService lets admins clear cached content and progress right away instead of
+// waiting for the caches to expire, e.g. after an emergency content fix. Content caches
+// are kept in memory by every instance, so invalidations are broadcast over Redis and
+// each instance clears its own copy. Progress snapshots live in Redis and are shared.
+type CacheService struct {
+	serviceContext.DefaultService
+
+	sqlSvc     *PostgresService
+	redisSvc   *RedisService
+	contentSvc *ContentService
+	userSvc    *UserService
+	systemSvc  *SystemService
+
+	// Tells this instance's broadcasts apart from the others'
+	instanceID string
+}
+
+const CACHE_SVC = "cache_svc"
+
+func (svc *CacheService) Id() string {
+	return CACHE_SVC
+}
+
+func (svc *CacheService) Configure(ctx *context.Context) error {
+	svc.instanceID = uuid.NewString()
+	return svc.DefaultService.Configure(ctx)
+}
+
+func (svc *CacheService) Start() error {
+	deps := newDependencies(svc.Id(), svc.Service)
+	svc.sqlSvc = resolve[*PostgresService](deps, POSTGRES_SVC)
+	svc.redisSvc = resolve[*RedisService](deps, REDIS_SVC)
+	svc.contentSvc = resolve[*ContentService](deps, CONTENT_SVC)
+	svc.userSvc = resolve[*UserService](deps, USER_SVC)
+	svc.systemSvc = resolve[*SystemService](deps, SYSTEM_SVC)
+	if err := deps.err(); err != nil {
+		return err
+	}
+
+	go svc.listen()
+
+	return nil
+}
+
+// listen clears the content caches named by other instances' broadcasts. The Redis
+// client resubscribes by itself after a lost connection.
+func (svc *CacheService) listen() {
+	pubsub := svc.redisSvc.GetClient().Subscribe(stdContext.Background(), cacheInvalidationChannel)
+	defer pubsub.Close()
+
+	for msg := range pubsub.Channel() {
+		var invalidation cacheInvalidation
+		if err := json.Unmarshal([]byte(msg.Payload), &invalidation); err != nil {
+			log.WithError(err).Warn("Ignoring malformed cache invalidation")
+			continue
+		}
+		if invalidation.Origin == svc.instanceID {
+			continue
+		}
+		svc.clearContent(invalidation.Scope)
+		log.Printf("Cleared %s caches on request of instance %s", invalidation.Scope, invalidation.Origin)
+	}
+}
+
+// Invalidate clears the caches holding the given lesson, character, user or all content,
+// on this instance and, for content, on every other instance
+func (svc *CacheService) Invalidate(adminID string, req dto.InvalidateCacheRequest) (*dto.CacheInvalidationResponse, error) {
+	if req.Scope == CacheScopeAllContent {
+		req.ID = ""
+	} else if req.ID == "" {
+		return nil, shared.NewBadRequestError(nil, "An ID is required for this scope")
+	}
+
+	switch req.Scope {
+	case CacheScopeLesson:
+		if _, err := svc.sqlSvc.contentRepo.GetLesson(req.ID); err != nil {
+			return nil, shared.NewNotFoundError(err, "Lesson not found")
+		}
+	case CacheScopeCharacter:
+		if _, err := svc.sqlSvc.contentRepo.GetCharacter(req.ID); err != nil {
+			return nil, shared.NewNotFoundError(err, "Character not found")
+		}
+	case CacheScopeUser:
+		if _, err := svc.sqlSvc.userRepo.GetUserByID(req.ID); err != nil {
+			return nil, shared.NewNotFoundError(err, "User not found")
+		}
+	}
+
+	resp := &dto.CacheInvalidationResponse{
+		Scope:         req.Scope,
+		ID:            req.ID,
+		InvalidatedAt: time.Now(),
+	}
+
+	if req.Scope == CacheScopeUser {
+		// Both live in shared storage, so there is nothing to tell the other instances
+		svc.userSvc.progressCache.Invalidate(req.ID)
+		svc.userSvc.refreshLeaderboardProfile(req.ID)
+		resp.Cleared = []string{cacheProgress, cacheLeaderboardProfile}
+	} else {
+		resp.Cleared = svc.clearContent(req.Scope)
+		resp.Broadcast = svc.broadcast(req.Scope, req.ID)
+	}
+
+	entityID := req.Scope
+	if req.ID != "" {
+		entityID += ":" + req.ID
+	}
+	svc.contentSvc.RecordContentAudit(adminID, model.ContentEntityCache, entityID, model.ContentActionInvalidate, nil, resp)
+
+	svc.systemSvc.PublishOpsEvent(OpsEventCacheInvalidation, OpsSeverityInfo, map[string]interface{}{
+		"scope":     req.Scope,
+		"id":        req.ID,
+		"admin_id":  adminID,
+		"broadcast": resp.Broadcast,
+	})
+	log.Printf("Admin %s invalidated %s caches", adminID, entityID)
+
+	return resp, nil
+}
+
+// clearContent empties this instance's content caches for the scope. The caches are not
+// keyed by lesson or character, so a single entity clears every cache it appears in.
+func (svc *CacheService) clearContent(scope string) []string {
+	content := svc.contentSvc
+	switch scope {
+	case CacheScopeLesson, CacheScopeCharacter:
+		content.suggest.invalidate()
+		content.public.invalidate()
+		content.trending.invalidate()
+		return []string{cacheSearchSuggest, cachePublicCatalog, cacheTrending}
+	case CacheScopeAllContent:
+		content.eras.invalidate()
+		content.glossary.invalidate()
+		content.suggest.invalidate()
+		content.public.invalidate()
+		content.trending.invalidate()
+		return []string{cacheEras, cacheGlossary, cacheSearchSuggest, cachePublicCatalog, cacheTrending}
+	}
+	return nil
+}
+
+// broadcast asks the other instances to clear the scope too and reports whether the
+// message went out. Without Redis they catch up when their caches expire.
+func (svc *CacheService) broadcast(scope, id string) bool {
+	payload, err := json.Marshal(cacheInvalidation{Scope: scope, ID: id, Origin: svc.instanceID})
+	if err != nil {
+		log.WithError(err).Error("Failed to marshal cache invalidation")
+		return false
+	}
+
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := svc.redisSvc.GetClient().Publish(ctx, cacheInvalidationChannel, payload).Err(); err != nil {
+		log.WithError(err).Warn("Failed to broadcast cache invalidation")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestClearContent(t *testing.T) {
+	content := &ContentService{
+		glossary: newGlossaryIndex(),
+		suggest:  newSuggestIndex(),
+		eras:     newEraCatalog(),
+		trending: newTrendingCache(),
+		public:   &publicCatalogCache{loadedAt: time.Now()},
+	}
+	content.eras.loadedAt = time.Now()
+	content.trending.entries["lesson"] = trendingCacheEntry{loadedAt: time.Now()}
+	svc := &CacheService{contentSvc: content}
+
+	cleared := svc.clearContent(CacheScopeLesson)
+	if !slices.Contains(cleared, cacheTrending) || slices.Contains(cleared, cacheEras) {
+		t.Errorf("lesson scope cleared %v", cleared)
+	}
+	if len(content.trending.entries) != 0 || !content.public.loadedAt.IsZero() {
+		t.Error("lesson scope left the trending or public catalog cache filled")
+	}
+	if content.eras.loadedAt.IsZero() {
+		t.Error("lesson scope cleared the era catalog")
+	}
+
+	if cleared := svc.clearContent(CacheScopeAllContent); len(cleared) != 5 || !content.eras.loadedAt.IsZero() {
+		t.Errorf("all content scope cleared %v", cleared)
+	}
+	if cleared := svc.clearContent(CacheScopeUser); cleared != nil {
+		t.Errorf("user scope cleared content caches %v", cleared)
+	}
+}
@@ -0,0 +1,350 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Long enough to pull a full lesson video through a cold edge
+	cdnPrewarmRequestTimeout = 2 * time.Minute
+	// A job not finished after this long is assumed lost, e.g. to a restart
+	cdnPrewarmStaleAfter = 30 * time.Minute
+	// Jobs shown on a lesson's prewarm status
+	cdnPrewarmHistory = 10
+)
+
+// cdnPrewarmRegion is a region the CDN is warmed in. Requests connect to the region's
+// edge instead of wherever DNS points, keeping the URL's host for TLS and Host.
+type cdnPrewarmRegion struct {
+	name   string
+	edge   string // host or IP of the region's edge, empty to follow DNS
+	client *http.Client
+}
+
+// parseCDNPrewarmRegions reads CDN_PREWARM_REGIONS, comma separated region=edge pairs
+// such as "hanoi=203.0.113.10,saigon=203.0.113.20". Without any, the CDN is warmed from
+// wherever DNS sends this server.
+func parseCDNPrewarmRegions(value string) ([]cdnPrewarmRegion, error) {
+	var regions []cdnPrewarmRegion
+	for _, pair := range strings.Split(value, ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		name, edge, ok := strings.Cut(pair, "=")
+		name, edge = strings.TrimSpace(name), strings.TrimSpace(edge)
+		if !ok || name == "" || edge == "" {
+			return nil, fmt.Errorf("invalid CDN_PREWARM_REGIONS entry %q, want region=edge", pair)
+		}
+		regions = append(regions, cdnPrewarmRegion{name: name, edge: edge, client: newCDNPrewarmClient(edge)})
+	}
+
+	if len(regions) == 0 {
+		regions = append(regions, cdnPrewarmRegion{name: "default", client: newCDNPrewarmClient("")})
+	}
+	return regions, nil
+}
+
+func newCDNPrewarmClient(edge string) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if edge != "" {
+		dialer := &net.Dialer{Timeout: 10 * time.Second}
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			_, port, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			return dialer.DialContext(ctx, network, net.JoinHostPort(edge, port))
+		}
+	}
+	return &http.Client{Timeout: cdnPrewarmRequestTimeout, Transport: transport}
+}
+
+// cdnPrewarmEnabled reports whether there is a CDN in front of media or public content
+func (svc *MediaService) cdnPrewarmEnabled() bool {
+	return svc.cdnBaseURL != "" || svc.prewarmAPIBaseURL != ""
+}
+
+// PrewarmLesson warms the CDN for a lesson on an admin's request
+func (svc *MediaService) PrewarmLesson(adminID, lessonID string) (*dto.CDNPrewarmJobResponse, error) {
+	return svc.prewarmLesson(adminID, lessonID, model.CDNPrewarmTriggerManual)
+}
+
+// prewarmLesson starts warming the CDN for a lesson's media and public content in every
+// target region. Only one job per lesson runs at a time.
+func (svc *MediaService) prewarmLesson(adminID, lessonID, trigger string) (*dto.CDNPrewarmJobResponse, error) {
+	if !svc.cdnPrewarmEnabled() {
+		return nil, shared.NewBadRequestError(nil, "CDN prewarming is not configured")
+	}
+
+	lesson, media, err := svc.sqlSvc.mediaRepo.GetLessonWithMedia(lessonID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Lesson not found")
+	}
+	if !lesson.IsActive {
+		return nil, shared.NewBadRequestError(nil, "Inactive lessons are not prewarmed")
+	}
+
+	recent, err := svc.sqlSvc.mediaRepo.GetLessonCDNPrewarmJobs(lessonID, 1)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get prewarm jobs")
+	}
+	if len(recent) > 0 && cdnPrewarmInProgress(&recent[0], time.Now()) {
+		return nil, shared.NewConflictError(nil, "The lesson is already being prewarmed")
+	}
+
+	urls := svc.lessonPrewarmURLs(lesson, media)
+	if len(urls) == 0 {
+		return nil, shared.NewBadRequestError(nil, "The lesson has nothing served from the CDN")
+	}
+
+	regions := make([]dto.CDNPrewarmRegion, len(svc.prewarmRegions))
+	for i, region := range svc.prewarmRegions {
+		regions[i].Region = region.name
+	}
+	urlsJSON, _ := json.Marshal(urls)
+	regionsJSON, _ := json.Marshal(regions)
+
+	job := &model.CDNPrewarmJob{
+		LessonID:    lessonID,
+		Trigger:     trigger,
+		RequestedBy: adminID,
+		Status:      model.CDNPrewarmPending,
+		URLs:        urlsJSON,
+		Regions:     regionsJSON,
+		Total:       len(urls) * len(regions),
+		CreatedAt:   time.Now(),
+	}
+	if err := svc.sqlSvc.mediaRepo.CreateCDNPrewarmJob(job); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to create prewarm job")
+	}
+
+	// Mapped before the run starts changing the job
+	response := mapCDNPrewarmJob(job)
+	go svc.runCDNPrewarm(job, urls, regions)
+
+	return response, nil
+}
+
+// prewarmLessonAfter warms a lesson after it went live or its media changed. Lessons
+// without CDN content or already being warmed are skipped.
+func (svc *MediaService) prewarmLessonAfter(lessonID, trigger string) {
+	if !svc.cdnPrewarmEnabled() {
+		return
+	}
+	if _, err := svc.prewarmLesson("", lessonID, trigger); err != nil {
+		log.WithError(err).Debugf("Skipped CDN prewarm of lesson %s after %s", lessonID, trigger)
+	}
+}
+
+// prewarmAssetLessons warms every active lesson using a freshly processed asset
+func (svc *MediaService) prewarmAssetLessons(assetID string) {
+	if !svc.cdnPrewarmEnabled() {
+		return
+	}
+
+	links, err := svc.sqlSvc.mediaRepo.GetLessonMediaByAssetIDs([]string{assetID})
+	if err != nil {
+		log.WithError(err).Warnf("Failed to find lessons of media asset %s to prewarm", assetID)
+		return
+	}
+	for _, link := range links {
+		if link.IsActive {
+			svc.prewarmLessonAfter(link.LessonID, model.CDNPrewarmTriggerMediaProcessed)
+		}
+	}
+}
+
+// lessonPrewarmURLs lists the CDN URLs of a lesson: its media and, when the public API
+// is behind a CDN, the public pages about the lesson and its character
+func (svc *MediaService) lessonPrewarmURLs(lesson *model.Lesson, media []model.LessonMedia) []string {
+	var urls []string
+	seen := make(map[string]bool)
+	add := func(url string) {
+		if url != "" && !seen[url] {
+			seen[url] = true
+			urls = append(urls, url)
+		}
+	}
+
+	if svc.cdnBaseURL != "" {
+		candidates := []string{lesson.AnimationURL, lesson.AudioURL, lesson.SubtitleURL, lesson.ThumbnailURL}
+		for i := range media {
+			candidates = append(candidates, svc.PublicURL(&media[i].MediaAsset))
+		}
+		for _, url := range candidates {
+			if strings.HasPrefix(url, svc.cdnBaseURL+"/") {
+				add(url)
+			}
+		}
+	}
+
+	if svc.prewarmAPIBaseURL != "" {
+		base := svc.prewarmAPIBaseURL + publicPathPrefix
+		add(base + "/lessons/" + lesson.ID)
+		add(base + "/meta/lessons/" + lesson.ID)
+		add(base + "/characters/" + lesson.CharacterID)
+		add(base + "/meta/characters/" + lesson.CharacterID)
+	}
+
+	return urls
+}
+
+// runCDNPrewarm requests every URL from every region, the regions in parallel, saving
+// progress after each request so the status endpoint can follow along
+func (svc *MediaService) runCDNPrewarm(job *model.CDNPrewarmJob, urls []string, regions []dto.CDNPrewarmRegion) {
+	var mutex sync.Mutex
+	save := func() {
+		job.Regions, _ = json.Marshal(regions)
+		if err := svc.sqlSvc.mediaRepo.UpdateCDNPrewarmJob(job); err != nil {
+			log.WithError(err).Warnf("Failed to save progress of CDN prewarm job %s", job.ID)
+		}
+	}
+
+	started := time.Now()
+	job.Status = model.CDNPrewarmRunning
+	job.StartedAt = &started
+	save()
+
+	var wg sync.WaitGroup
+	for i, region := range svc.prewarmRegions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, url := range urls {
+				err := warmCDNURL(region.client, url)
+
+				mutex.Lock()
+				if err != nil {
+					regions[i].Failed++
+					job.Failed++
+					job.LastError = truncate(fmt.Sprintf("%s: %s: %v", region.name, url, err), 2000)
+				} else {
+					regions[i].Warmed++
+					job.Warmed++
+				}
+				save()
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	finished := time.Now()
+	job.FinishedAt = &finished
+	job.Status = model.CDNPrewarmCompleted
+	if job.Warmed == 0 {
+		job.Status = model.CDNPrewarmFailed
+	}
+	save()
+
+	log.Printf("CDN prewarm of lesson %s %s: %d of %d requests warmed in %s",
+		job.LessonID, job.Status, job.Warmed, job.Total, finished.Sub(started).Round(time.Second))
+	if job.Failed > 0 {
+		svc.systemSvc.PublishOpsEvent(OpsEventCDNPrewarm, OpsSeverityWarning, map[string]interface{}{
+			"job_id":     job.ID,
+			"lesson_id":  job.LessonID,
+			"failed":     job.Failed,
+			"total":      job.Total,
+			"last_error": job.LastError,
+		})
+	}
+}
+
+// warmCDNURL downloads a URL in full, which is what makes the edge keep a copy
+func warmCDNURL(client *http.Client, url string) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "ven-cdn-prewarm")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func cdnPrewarmInProgress(job *model.CDNPrewarmJob, now time.Time) bool {
+	if job.Status != model.CDNPrewarmPending && job.Status != model.CDNPrewarmRunning {
+		return false
+	}
+	return job.CreatedAt.After(now.Add(-cdnPrewarmStaleAfter))
+}
+
+// GetLessonPrewarmStatus returns a lesson's recent prewarm jobs, newest first
+func (svc *MediaService) GetLessonPrewarmStatus(lessonID string) ([]dto.CDNPrewarmJobResponse, error) {
+	if _, err := svc.sqlSvc.contentRepo.GetLesson(lessonID); err != nil {
+		return nil, shared.NewNotFoundError(err, "Lesson not found")
+	}
+
+	jobs, err := svc.sqlSvc.mediaRepo.GetLessonCDNPrewarmJobs(lessonID, cdnPrewarmHistory)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get prewarm jobs")
+	}
+
+	responses := make([]dto.CDNPrewarmJobResponse, len(jobs))
+	for i := range jobs {
+		responses[i] = *mapCDNPrewarmJob(&jobs[i])
+	}
+	return responses, nil
+}
+
+// GetPrewarmJob returns one prewarm job
+func (svc *MediaService) GetPrewarmJob(jobID string) (*dto.CDNPrewarmJobResponse, error) {
+	job, err := svc.sqlSvc.mediaRepo.GetCDNPrewarmJob(jobID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Prewarm job not found")
+	}
+	return mapCDNPrewarmJob(job), nil
+}
+
+func mapCDNPrewarmJob(job *model.CDNPrewarmJob) *dto.CDNPrewarmJobResponse {
+	resp := &dto.CDNPrewarmJobResponse{
+		ID:          job.ID,
+		LessonID:    job.LessonID,
+		Trigger:     job.Trigger,
+		RequestedBy: job.RequestedBy,
+		Status:      job.Status,
+		URLs:        []string{},
+		Regions:     []dto.CDNPrewarmRegion{},
+		Total:       job.Total,
+		Warmed:      job.Warmed,
+		Failed:      job.Failed,
+		LastError:   job.LastError,
+		CreatedAt:   job.CreatedAt,
+		StartedAt:   job.StartedAt,
+		FinishedAt:  job.FinishedAt,
+	}
+	if len(job.URLs) > 0 {
+		_ = json.Unmarshal(job.URLs, &resp.URLs)
+	}
+	if len(job.Regions) > 0 {
+		_ = json.Unmarshal(job.Regions, &resp.Regions)
+	}
+	if job.Total > 0 {
+		resp.Progress = (job.Warmed + job.Failed) * 100 / job.Total
+	}
+	return resp
+}
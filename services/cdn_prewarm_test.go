@@ -0,0 +1,71 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lac-hong-legacy/ven_api/model"
+)
+
+func TestParseCDNPrewarmRegions(t *testing.T) {
+	regions, err := parseCDNPrewarmRegions(" hanoi=203.0.113.10, saigon = edge-sgn.example.net ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(regions) != 2 || regions[0].name != "hanoi" || regions[1].edge != "edge-sgn.example.net" {
+		t.Errorf("regions = %+v", regions)
+	}
+
+	regions, err = parseCDNPrewarmRegions("")
+	if err != nil || len(regions) != 1 || regions[0].name != "default" || regions[0].edge != "" {
+		t.Errorf("without regions got %+v, %v", regions, err)
+	}
+
+	if _, err := parseCDNPrewarmRegions("hanoi"); err == nil {
+		t.Error("entry without an edge accepted")
+	}
+}
+
+func TestLessonPrewarmURLs(t *testing.T) {
+	svc := &MediaService{
+		cdnBaseURL:        "https://cdn.example.com",
+		prewarmAPIBaseURL: "https://api-cdn.example.com",
+	}
+	lesson := &model.Lesson{
+		ID:           "lesson_1",
+		CharacterID:  "char_1",
+		AnimationURL: "https://cdn.example.com/cdn/abc.mp4",
+		ThumbnailURL: "https://minio.internal/ven/thumb.jpg", // not on the CDN
+	}
+	media := []model.LessonMedia{
+		{MediaAsset: model.MediaAsset{CDNUrl: "https://cdn.example.com/cdn/abc.mp4"}},
+		{MediaAsset: model.MediaAsset{CDNUrl: "https://cdn.example.com/cdn/def.vtt"}},
+	}
+
+	want := []string{
+		"https://cdn.example.com/cdn/abc.mp4",
+		"https://cdn.example.com/cdn/def.vtt",
+		"https://api-cdn.example.com/public/v1/lessons/lesson_1",
+		"https://api-cdn.example.com/public/v1/meta/lessons/lesson_1",
+		"https://api-cdn.example.com/public/v1/characters/char_1",
+		"https://api-cdn.example.com/public/v1/meta/characters/char_1",
+	}
+	if got := svc.lessonPrewarmURLs(lesson, media); !reflect.DeepEqual(got, want) {
+		t.Errorf("lessonPrewarmURLs = %v, want %v", got, want)
+	}
+}
+
+func TestMapCDNPrewarmJob(t *testing.T) {
+	job := &model.CDNPrewarmJob{
+		Status:  model.CDNPrewarmRunning,
+		Regions: model.JSONB(`[{"region":"hanoi","warmed":3,"failed":1},{"region":"saigon","warmed":2,"failed":0}]`),
+		Total:   8,
+		Warmed:  5,
+		Failed:  1,
+	}
+
+	resp := mapCDNPrewarmJob(job)
+	if resp.Progress != 75 || len(resp.Regions) != 2 || resp.Regions[0].Failed != 1 {
+		t.Errorf("mapCDNPrewarmJob = %+v", resp)
+	}
+}
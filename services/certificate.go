@@ -0,0 +1,441 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cloakd/common/context"
+	serviceContext "github.com/cloakd/common/services"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	"github.com/lac-hong-legacy/ven_api/shared/text"
+	log "github.com/sirupsen/logrus"
+)
+
+// CertificateService issues a certificate when a user completes every lesson of a
+// dynasty. The certificate image is rendered once and kept in MinIO; its code lets
+// anyone check it without signing in.
+type CertificateService struct {
+	serviceContext.DefaultService
+
+	sqlSvc          *PostgresService
+	minioSvc        *MinIOService
+	notificationSvc *NotificationService
+	eventBusSvc     *EventBusService
+
+	// Frontend address the verification links point to
+	baseURL string
+}
+
+const CERTIFICATE_SVC = "certificate_svc"
+
+const (
+	certificateWidth  = 1200
+	certificateHeight = 850
+
+	certificateURLTTL = 24 * time.Hour
+
+	// Codes skip look-alike characters and are shown as two groups of four
+	certificateCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	certificateCodeLength   = 8
+)
+
+func (svc *CertificateService) Id() string {
+	return CERTIFICATE_SVC
+}
+
+func (svc *CertificateService) Configure(ctx *context.Context) error {
+	svc.baseURL = os.Getenv("BASE_URL")
+	return svc.DefaultService.Configure(ctx)
+}
+
+func (svc *CertificateService) Start() error {
+	deps := newDependencies(svc.Id(), svc.Service)
+	svc.sqlSvc = resolve[*PostgresService](deps, POSTGRES_SVC)
+	svc.minioSvc = resolve[*MinIOService](deps, MINIO_SVC)
+	svc.notificationSvc = resolve[*NotificationService](deps, NOTIFICATION_SVC)
+	svc.eventBusSvc = resolve[*EventBusService](deps, EVENT_BUS_SVC)
+	if err := deps.err(); err != nil {
+		return err
+	}
+
+	svc.eventBusSvc.Subscribe(EventLessonCompleted, CERTIFICATE_SVC, func(event DomainEvent) {
+		e := event.(*LessonCompletedEvent)
+		if !e.FirstCompletion {
+			return
+		}
+		lesson, err := svc.sqlSvc.contentRepo.GetLesson(e.LessonID)
+		if err != nil || lesson.Character.Dynasty == "" {
+			return
+		}
+		if _, err := svc.issue(e.UserID, lesson.Character.Dynasty); err != nil {
+			log.Printf("Failed to issue %s certificate to user %s: %v", lesson.Character.Dynasty, e.UserID, err)
+		}
+	})
+
+	return nil
+}
+
+// chapterSummary is how a user did in the lessons of one dynasty
+type chapterSummary struct {
+	LessonCount  int
+	AverageScore int
+	StartedAt    time.Time
+	CompletedAt  time.Time
+}
+
+// summarizeChapter sums up the user's completions of a dynasty's lessons. It reports
+// false while any lesson is left, or when the dynasty has no lessons.
+func summarizeChapter(lessons []model.Lesson, completions []model.UserLessonCompletion) (chapterSummary, bool) {
+	var summary chapterSummary
+	if len(lessons) == 0 {
+		return summary, false
+	}
+
+	byLesson := make(map[string]model.UserLessonCompletion, len(completions))
+	for _, completion := range completions {
+		byLesson[completion.LessonID] = completion
+	}
+
+	total := 0
+	for _, lesson := range lessons {
+		completion, ok := byLesson[lesson.ID]
+		if !ok {
+			return chapterSummary{}, false
+		}
+		total += completion.Score
+		if summary.StartedAt.IsZero() || completion.CompletedAt.Before(summary.StartedAt) {
+			summary.StartedAt = completion.CompletedAt
+		}
+		if completion.CompletedAt.After(summary.CompletedAt) {
+			summary.CompletedAt = completion.CompletedAt
+		}
+	}
+
+	summary.LessonCount = len(lessons)
+	summary.AverageScore = (total + len(lessons)/2) / len(lessons)
+	return summary, true
+}
+
+// issue gives the user the dynasty's certificate if they completed all its lessons and
+// don't have it yet. It returns nil when nothing was issued.
+func (svc *CertificateService) issue(userID, dynasty string) (*model.Certificate, error) {
+	lessons, err := svc.sqlSvc.contentRepo.GetDynastyLessons(dynasty)
+	if err != nil {
+		return nil, err
+	}
+	lessonIDs := make([]string, len(lessons))
+	for i, lesson := range lessons {
+		lessonIDs[i] = lesson.ID
+	}
+	completions, err := svc.sqlSvc.contentRepo.GetLessonCompletions(userID, lessonIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	summary, complete := summarizeChapter(lessons, completions)
+	if !complete {
+		return nil, nil
+	}
+
+	user, err := svc.sqlSvc.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	code, err := svc.newCertificateCode()
+	if err != nil {
+		return nil, err
+	}
+
+	certificate := &model.Certificate{
+		UserID:        userID,
+		Dynasty:       dynasty,
+		Code:          code,
+		RecipientName: user.Username,
+		LessonCount:   summary.LessonCount,
+		AverageScore:  summary.AverageScore,
+		StartedAt:     summary.StartedAt,
+		CompletedAt:   summary.CompletedAt,
+	}
+	created, err := svc.sqlSvc.certificateRepo.CreateCertificate(certificate)
+	if err != nil || !created {
+		return nil, err
+	}
+	log.Printf("Issued %s certificate %s to user %s", dynasty, code, userID)
+
+	// Rendered now so the first request doesn't wait; a failure is retried on the next request
+	if _, err := svc.certificateImageURL(certificate); err != nil {
+		log.Printf("Failed to render certificate %s: %v", certificate.ID, err)
+	}
+
+	svc.notificationSvc.Notify(userID, model.NotificationTypeReward, "Certificate earned",
+		fmt.Sprintf("You completed every lesson of %s", dynasty),
+		map[string]interface{}{"certificate_id": certificate.ID, "dynasty": dynasty})
+	return certificate, nil
+}
+
+// issueMissing issues the certificates of dynasties the user completed before
+// certificates existed, or while issuing failed
+func (svc *CertificateService) issueMissing(userID string, certificates []model.Certificate) []model.Certificate {
+	lessonIDs, err := svc.sqlSvc.contentRepo.GetCompletedLessonIDs(userID)
+	if err != nil {
+		log.Printf("Failed to load completed lessons of user %s: %v", userID, err)
+		return certificates
+	}
+	lessons, err := svc.sqlSvc.contentRepo.GetLessonsByIDs(lessonIDs)
+	if err != nil {
+		log.Printf("Failed to load completed lessons of user %s: %v", userID, err)
+		return certificates
+	}
+
+	checked := make(map[string]bool, len(certificates))
+	for _, certificate := range certificates {
+		checked[certificate.Dynasty] = true
+	}
+	for _, lesson := range lessons {
+		dynasty := lesson.Character.Dynasty
+		if dynasty == "" || checked[dynasty] {
+			continue
+		}
+		checked[dynasty] = true
+
+		certificate, err := svc.issue(userID, dynasty)
+		if err != nil {
+			log.Printf("Failed to issue %s certificate to user %s: %v", dynasty, userID, err)
+			continue
+		}
+		if certificate != nil {
+			certificates = append(certificates, *certificate)
+		}
+	}
+	return certificates
+}
+
+// newCertificateCode picks a code no certificate uses
+func (svc *CertificateService) newCertificateCode() (string, error) {
+	max := big.NewInt(int64(len(certificateCodeAlphabet)))
+	for {
+		var code strings.Builder
+		for i := 0; i < certificateCodeLength; i++ {
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return "", err
+			}
+			code.WriteByte(certificateCodeAlphabet[n.Int64()])
+		}
+
+		inUse, err := svc.sqlSvc.certificateRepo.CertificateCodeInUse(code.String())
+		if err != nil {
+			return "", err
+		}
+		if !inUse {
+			return code.String(), nil
+		}
+	}
+}
+
+// normalizeCertificateCode reads a code as printed or typed: case, dashes and spaces
+// don't matter
+func normalizeCertificateCode(code string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+// formatCertificateCode shows a code as two groups of four, like K7PQ-3XWM
+func formatCertificateCode(code string) string {
+	if len(code) != certificateCodeLength {
+		return code
+	}
+	return code[:certificateCodeLength/2] + "-" + code[certificateCodeLength/2:]
+}
+
+// ==================== CERTIFICATE METHODS ====================
+
+// GetCertificates lists the user's certificates, issuing any that are missing
+func (svc *CertificateService) GetCertificates(userID string) ([]dto.CertificateResponse, error) {
+	certificates, err := svc.sqlSvc.certificateRepo.GetUserCertificates(userID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get certificates")
+	}
+	certificates = svc.issueMissing(userID, certificates)
+
+	responses := make([]dto.CertificateResponse, len(certificates))
+	for i := range certificates {
+		responses[i] = svc.certificateResponse(&certificates[i])
+	}
+	return responses, nil
+}
+
+// GetCertificate returns a certificate with a summary of the lessons it covers
+func (svc *CertificateService) GetCertificate(userID, certificateID string) (*dto.CertificateDetailResponse, error) {
+	certificate, err := svc.sqlSvc.certificateRepo.GetUserCertificate(userID, certificateID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Certificate not found")
+	}
+
+	lessons, err := svc.sqlSvc.contentRepo.GetDynastyLessons(certificate.Dynasty)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get lessons")
+	}
+	lessonIDs := make([]string, len(lessons))
+	for i, lesson := range lessons {
+		lessonIDs[i] = lesson.ID
+	}
+	completions, err := svc.sqlSvc.contentRepo.GetLessonCompletions(userID, lessonIDs)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get lesson completions")
+	}
+	byLesson := make(map[string]model.UserLessonCompletion, len(completions))
+	for _, completion := range completions {
+		byLesson[completion.LessonID] = completion
+	}
+
+	response := &dto.CertificateDetailResponse{
+		CertificateResponse: svc.certificateResponse(certificate),
+		Characters:          []string{},
+		Lessons:             make([]dto.CertificateLessonSummary, 0, len(lessons)),
+	}
+	seen := make(map[string]bool)
+	for _, lesson := range lessons {
+		// Lessons added after the certificate was issued are left out
+		completion, ok := byLesson[lesson.ID]
+		if !ok {
+			continue
+		}
+		if !seen[lesson.CharacterID] {
+			seen[lesson.CharacterID] = true
+			response.Characters = append(response.Characters, lesson.Character.Name)
+		}
+		response.Lessons = append(response.Lessons, dto.CertificateLessonSummary{
+			LessonID:      lesson.ID,
+			Title:         lesson.Title,
+			CharacterID:   lesson.CharacterID,
+			CharacterName: lesson.Character.Name,
+			Score:         completion.Score,
+			CompletedAt:   completion.CompletedAt,
+		})
+	}
+	return response, nil
+}
+
+// VerifyCertificate looks up a certificate by the code printed on it
+func (svc *CertificateService) VerifyCertificate(code string) (*dto.CertificateVerificationResponse, error) {
+	code = normalizeCertificateCode(code)
+	if len(code) != certificateCodeLength {
+		return nil, shared.NewNotFoundError(nil, "Certificate not found")
+	}
+
+	certificate, err := svc.sqlSvc.certificateRepo.GetCertificateByCode(code)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Certificate not found")
+	}
+
+	return &dto.CertificateVerificationResponse{
+		Valid:         true,
+		Code:          formatCertificateCode(certificate.Code),
+		Dynasty:       certificate.Dynasty,
+		RecipientName: certificate.RecipientName,
+		AverageScore:  certificate.AverageScore,
+		CompletedAt:   certificate.CompletedAt,
+	}, nil
+}
+
+func (svc *CertificateService) certificateResponse(certificate *model.Certificate) dto.CertificateResponse {
+	response := dto.CertificateResponse{
+		ID:            certificate.ID,
+		Dynasty:       certificate.Dynasty,
+		Code:          formatCertificateCode(certificate.Code),
+		RecipientName: certificate.RecipientName,
+		LessonCount:   certificate.LessonCount,
+		AverageScore:  certificate.AverageScore,
+		StartedAt:     certificate.StartedAt,
+		CompletedAt:   certificate.CompletedAt,
+		VerifyURL:     fmt.Sprintf("%s/certificates/verify/%s", svc.baseURL, formatCertificateCode(certificate.Code)),
+	}
+
+	imageURL, err := svc.certificateImageURL(certificate)
+	if err != nil {
+		log.Printf("Failed to get certificate image %s: %v", certificate.ID, err)
+	}
+	response.ImageURL = imageURL
+	return response
+}
+
+// ==================== CERTIFICATE IMAGE ====================
+
+// certificateImageURL returns a presigned URL of the certificate image, rendering and
+// storing it first if it isn't stored yet
+func (svc *CertificateService) certificateImageURL(certificate *model.Certificate) (string, error) {
+	bucket := svc.minioSvc.BucketFor("certificate")
+	objectName := fmt.Sprintf("%s/%s.png", mediaObjectDir("certificate"), certificate.Code)
+
+	if _, err := svc.minioSvc.GetFileInfo(bucket, objectName); err != nil {
+		rendered, err := renderCertificate(certificate)
+		if err != nil {
+			return "", err
+		}
+		if _, err := svc.minioSvc.UploadFile(bucket, objectName, bytes.NewReader(rendered), int64(len(rendered)), "image/png"); err != nil {
+			return "", fmt.Errorf("failed to store certificate: %w", err)
+		}
+	}
+
+	return svc.minioSvc.GetFileURL(bucket, objectName, certificateURLTTL)
+}
+
+// renderCertificate draws the recipient, dynasty, score, completion date and code on a
+// framed parchment background. The pixel font has no diacritics, so the dynasty is
+// drawn without them.
+func renderCertificate(certificate *model.Certificate) ([]byte, error) {
+	canvas := image.NewRGBA(image.Rect(0, 0, certificateWidth, certificateHeight))
+
+	top, bottom := color.RGBA{0xfb, 0xf1, 0xd9, 0xff}, color.RGBA{0xe8, 0xd2, 0xa0, 0xff}
+	for y := 0; y < certificateHeight; y++ {
+		line := blendShareColor(top, bottom, float64(y)/float64(certificateHeight-1))
+		draw.Draw(canvas, image.Rect(0, y, certificateWidth, y+1), image.NewUniform(line), image.Point{}, draw.Src)
+	}
+
+	red := color.RGBA{0x8b, 0x1a, 0x1a, 0xff}
+	gold := color.RGBA{0xb8, 0x86, 0x0b, 0xff}
+	drawCertificateFrame(canvas, 24, 8, red)
+	drawCertificateFrame(canvas, 44, 3, gold)
+
+	ink := color.RGBA{0x3b, 0x2a, 0x1a, 0xff}
+	completedAt := certificate.CompletedAt.In(streakLocation(""))
+	drawShareTextCentered(canvas, 90, 7, "CERTIFICATE OF COMPLETION", red)
+	drawShareTextCentered(canvas, 200, 3, "AWARDED TO", ink)
+	drawShareTextCentered(canvas, 250, 10, certificate.RecipientName, ink)
+	drawShareTextCentered(canvas, 380, 3, "FOR COMPLETING EVERY LESSON OF", ink)
+	drawShareTextCentered(canvas, 430, 8, text.StripDiacritics(certificate.Dynasty), red)
+	drawShareTextCentered(canvas, 560, 4, fmt.Sprintf("%d LESSONS  SCORE %d%%", certificate.LessonCount, certificate.AverageScore), ink)
+	drawShareTextCentered(canvas, 620, 4, completedAt.Format("02/01/2006"), ink)
+	drawShareTextCentered(canvas, 740, 3, "VERIFY CODE "+formatCertificateCode(certificate.Code), gold)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, canvas); err != nil {
+		return nil, fmt.Errorf("failed to encode certificate: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// drawCertificateFrame draws a border of the given width inset from the canvas edges
+func drawCertificateFrame(canvas *image.RGBA, inset, width int, c color.Color) {
+	bounds := canvas.Bounds().Inset(inset)
+	fill := image.NewUniform(c)
+	for _, side := range []image.Rectangle{
+		image.Rect(bounds.Min.X, bounds.Min.Y, bounds.Max.X, bounds.Min.Y+width),
+		image.Rect(bounds.Min.X, bounds.Max.Y-width, bounds.Max.X, bounds.Max.Y),
+		image.Rect(bounds.Min.X, bounds.Min.Y, bounds.Min.X+width, bounds.Max.Y),
+		image.Rect(bounds.Max.X-width, bounds.Min.Y, bounds.Max.X, bounds.Max.Y),
+	} {
+		draw.Draw(canvas, side, fill, image.Point{}, draw.Src)
+	}
+}
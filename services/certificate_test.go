@@ -0,0 +1,50 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/model"
+)
+
+func TestSummarizeChapter(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2026, 3, d, 10, 0, 0, 0, time.UTC)
+	}
+	lessons := []model.Lesson{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	completions := []model.UserLessonCompletion{
+		{LessonID: "b", Score: 90, CompletedAt: day(2)},
+		{LessonID: "a", Score: 80, CompletedAt: day(5)},
+		{LessonID: "c", Score: 85, CompletedAt: day(9)},
+		{LessonID: "other", Score: 10, CompletedAt: day(1)},
+	}
+
+	summary, complete := summarizeChapter(lessons, completions)
+	if !complete {
+		t.Fatal("expected the chapter to be complete")
+	}
+	if summary.LessonCount != 3 || summary.AverageScore != 85 {
+		t.Errorf("got %d lessons averaging %d, want 3 averaging 85", summary.LessonCount, summary.AverageScore)
+	}
+	if !summary.StartedAt.Equal(day(2)) || !summary.CompletedAt.Equal(day(9)) {
+		t.Errorf("got %v to %v, want %v to %v", summary.StartedAt, summary.CompletedAt, day(2), day(9))
+	}
+
+	if _, complete := summarizeChapter(lessons, completions[:2]); complete {
+		t.Error("expected a chapter with a lesson left to be incomplete")
+	}
+	if _, complete := summarizeChapter(nil, completions); complete {
+		t.Error("expected a chapter without lessons to be incomplete")
+	}
+}
+
+func TestCertificateCode(t *testing.T) {
+	for _, typed := range []string{"K7PQ-3XWM", "k7pq3xwm", " k7pq 3xwm "} {
+		if got := normalizeCertificateCode(typed); got != "K7PQ3XWM" {
+			t.Errorf("normalizeCertificateCode(%q) = %q, want K7PQ3XWM", typed, got)
+		}
+	}
+	if got := formatCertificateCode("K7PQ3XWM"); got != "K7PQ-3XWM" {
+		t.Errorf("formatCertificateCode = %q, want K7PQ-3XWM", got)
+	}
+}
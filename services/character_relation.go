@@ -0,0 +1,174 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	"gorm.io/gorm"
+)
+
+// getRelatedCharacters returns the figures related to a character, each labelled from
+// the character's point of view
+func (svc *ContentService) getRelatedCharacters(characterID string) ([]dto.RelatedCharacterResponse, error) {
+	relations, err := svc.contentRepo.GetCharacterRelations(characterID)
+	if err != nil {
+		return nil, err
+	}
+	if len(relations) == 0 {
+		return []dto.RelatedCharacterResponse{}, nil
+	}
+
+	otherIDs := make([]string, 0, len(relations))
+	for _, relation := range relations {
+		otherIDs = append(otherIDs, otherRelatedCharacterID(relation, characterID))
+	}
+
+	characters, err := svc.contentRepo.GetCharactersByIDs(otherIDs)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]model.Character, len(characters))
+	for _, character := range characters {
+		byID[character.ID] = character
+	}
+
+	related := make([]dto.RelatedCharacterResponse, 0, len(relations))
+	for _, relation := range relations {
+		other, ok := byID[otherRelatedCharacterID(relation, characterID)]
+		if !ok {
+			continue
+		}
+		related = append(related, dto.RelatedCharacterResponse{
+			RelationID:  relation.ID,
+			Relation:    relationTypeFor(relation, characterID),
+			Description: relation.Description,
+			ID:          other.ID,
+			Name:        other.Name,
+			Era:         other.Era,
+			Dynasty:     other.Dynasty,
+			Rarity:      other.Rarity,
+			ImageURL:    other.ImageURL,
+		})
+	}
+	return related, nil
+}
+
+func otherRelatedCharacterID(relation model.CharacterRelation, characterID string) string {
+	if relation.CharacterID == characterID {
+		return relation.RelatedCharacterID
+	}
+	return relation.CharacterID
+}
+
+// relationTypeFor reads a relation from one side. Mentor is the only directional type:
+// the related character of a mentor is their student.
+func relationTypeFor(relation model.CharacterRelation, characterID string) string {
+	if relation.Type == model.CharacterRelationMentor && relation.RelatedCharacterID == characterID {
+		return model.CharacterRelationStudent
+	}
+	return relation.Type
+}
+
+// GetCharacterRelations lists a character's relations for the admin editor
+func (svc *ContentService) GetCharacterRelations(characterID string) ([]dto.RelatedCharacterResponse, error) {
+	if _, err := svc.getCharacterOrNotFound(characterID); err != nil {
+		return nil, err
+	}
+
+	related, err := svc.getRelatedCharacters(characterID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get character relations")
+	}
+	return related, nil
+}
+
+// CreateCharacterRelation relates two characters. The character in the path is the
+// subject, so a mentor relation makes it the mentor of the related character.
+func (svc *ContentService) CreateCharacterRelation(adminID, characterID string, req dto.CreateCharacterRelationRequest) (*model.CharacterRelation, error) {
+	if characterID == req.RelatedCharacterID {
+		return nil, shared.NewBadRequestError(nil, "A character cannot be related to itself")
+	}
+	if _, err := svc.getCharacterOrNotFound(characterID); err != nil {
+		return nil, err
+	}
+	if _, err := svc.getCharacterOrNotFound(req.RelatedCharacterID); err != nil {
+		return nil, err
+	}
+
+	exists, err := svc.contentRepo.CharacterRelationExists(characterID, req.RelatedCharacterID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to check character relations")
+	}
+	if exists {
+		return nil, shared.NewConflictError(nil, "These characters are already related")
+	}
+
+	relation := &model.CharacterRelation{
+		CharacterID:        characterID,
+		RelatedCharacterID: req.RelatedCharacterID,
+		Type:               req.Type,
+		Description:        req.Description,
+		CreatedBy:          adminID,
+	}
+	if err := svc.contentRepo.CreateCharacterRelation(relation); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to create character relation")
+	}
+
+	svc.RecordContentAudit(adminID, model.ContentEntityRelation, relation.ID, model.ContentActionCreate, nil, relation)
+	return relation, nil
+}
+
+func (svc *ContentService) UpdateCharacterRelation(adminID, relationID string, req dto.UpdateCharacterRelationRequest) (*model.CharacterRelation, error) {
+	relation, err := svc.contentRepo.GetCharacterRelation(relationID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, shared.NewNotFoundError(err, "Character relation not found")
+	}
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get character relation")
+	}
+
+	before := *relation
+	if req.Type != "" {
+		relation.Type = req.Type
+	}
+	if req.Description != nil {
+		relation.Description = *req.Description
+	}
+
+	if err := svc.contentRepo.UpdateCharacterRelation(relation); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to update character relation")
+	}
+
+	svc.RecordContentAudit(adminID, model.ContentEntityRelation, relation.ID, model.ContentActionUpdate, before, relation)
+	return relation, nil
+}
+
+func (svc *ContentService) DeleteCharacterRelation(adminID, relationID string) error {
+	relation, err := svc.contentRepo.GetCharacterRelation(relationID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return shared.NewNotFoundError(err, "Character relation not found")
+	}
+	if err != nil {
+		return shared.NewInternalError(err, "Failed to get character relation")
+	}
+
+	if err := svc.contentRepo.DeleteCharacterRelation(relationID); err != nil {
+		return shared.NewInternalError(err, "Failed to delete character relation")
+	}
+
+	svc.RecordContentAudit(adminID, model.ContentEntityRelation, relation.ID, model.ContentActionDelete, relation, nil)
+	return nil
+}
+
+func (svc *ContentService) getCharacterOrNotFound(characterID string) (*model.Character, error) {
+	character, err := svc.contentRepo.GetCharacter(characterID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, shared.NewNotFoundError(err, "Character not found")
+	}
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get character")
+	}
+	return character, nil
+}
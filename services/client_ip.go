@@ -0,0 +1,121 @@
+package services
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// defaultTrustedProxies are used when TRUSTED_PROXIES is not set: loopback and private
+// networks, where the load balancer and reverse proxy normally run
+const defaultTrustedProxies = "127.0.0.0/8,::1/128,10.0.0.0/8,172.16.0.0/12,192.168.0.0/16,fc00::/7"
+
+// clientIPResolver finds the address of the client behind a chain of proxies. Forwarding
+// headers are only believed when the peer is a trusted proxy, and X-Forwarded-For is
+// read from the right, so the client is the first hop no trusted proxy vouches for.
+// Anything left of that hop was written by the client and can be forged.
+type clientIPResolver struct {
+	trusted []netip.Prefix
+}
+
+// newClientIPResolver parses a comma separated list of CIDRs and single addresses
+func newClientIPResolver(proxies string) (*clientIPResolver, error) {
+	resolver := &clientIPResolver{}
+	for _, entry := range strings.Split(proxies, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+			}
+			if prefix.Addr().Is4In6() {
+				prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+			}
+			resolver.trusted = append(resolver.trusted, prefix.Masked())
+			continue
+		}
+
+		addr, ok := parseHopIP(entry)
+		if !ok {
+			return nil, fmt.Errorf("invalid trusted proxy %q", entry)
+		}
+		resolver.trusted = append(resolver.trusted, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return resolver, nil
+}
+
+func (r *clientIPResolver) trusts(addr netip.Addr) bool {
+	for _, prefix := range r.trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the client address for a request from remoteAddr with the given
+// X-Forwarded-For headers (in the order received) and X-Real-IP header
+func (r *clientIPResolver) resolve(remoteAddr string, forwardedFor []string, realIP string) string {
+	peer, ok := parseHopIP(remoteAddr)
+	if !ok {
+		return remoteAddr
+	}
+	if !r.trusts(peer) {
+		return peer.String()
+	}
+
+	var hops []string
+	for _, header := range forwardedFor {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, ok := parseHopIP(hops[i])
+		if !ok {
+			// Garbage means the hop was not written by a proxy; the trusted hop after it
+			// is the best address we have
+			return client.String()
+		}
+		client = hop
+		if !r.trusts(hop) {
+			return client.String()
+		}
+	}
+
+	// Every hop was a trusted proxy; a proxy that only sets X-Real-IP may still have
+	// said who the client is
+	if len(hops) == 0 {
+		if addr, ok := parseHopIP(realIP); ok {
+			return addr.String()
+		}
+	}
+	return client.String()
+}
+
+// parseHopIP parses an address as found in headers and remote addresses: IPv4 or IPv6,
+// optionally with a port ("1.2.3.4:80", "[2001:db8::1]:443"), brackets, quotes or an
+// IPv6 zone. IPv4-mapped IPv6 addresses are returned as IPv4 so both forms of the same
+// client share rate limits and lockouts.
+func parseHopIP(value string) (netip.Addr, bool) {
+	value = strings.Trim(strings.TrimSpace(value), `"`)
+	if value == "" {
+		return netip.Addr{}, false
+	}
+
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		value = host
+	}
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap().WithZone(""), true
+}
@@ -0,0 +1,64 @@
+package services
+
+import "testing"
+
+func TestClientIPResolver(t *testing.T) {
+	resolver, err := newClientIPResolver(defaultTrustedProxies + ", 203.0.113.7, 2001:db8:cafe::/48")
+	if err != nil {
+		t.Fatalf("newClientIPResolver: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		realIP       string
+		want         string
+	}{
+		{"direct client", "198.51.100.10:51234", nil, "", "198.51.100.10"},
+		{"untrusted peer spoofing headers", "198.51.100.10:51234", []string{"1.1.1.1"}, "2.2.2.2", "198.51.100.10"},
+		{"single proxy", "10.0.0.5:443", []string{"198.51.100.10"}, "", "198.51.100.10"},
+		{"spoofed entry left of client", "10.0.0.5:443", []string{"1.1.1.1, 198.51.100.10"}, "", "198.51.100.10"},
+		{"proxy chain", "10.0.0.5:443", []string{"1.1.1.1, 198.51.100.10, 203.0.113.7, 192.168.1.2"}, "", "198.51.100.10"},
+		{"repeated headers", "10.0.0.5:443", []string{"1.1.1.1, 198.51.100.10", "172.16.0.9"}, "", "198.51.100.10"},
+		{"all hops trusted", "10.0.0.5:443", []string{"192.168.1.9, 10.1.1.1"}, "", "192.168.1.9"},
+		{"garbage hop", "10.0.0.5:443", []string{"1.1.1.1, unknown, 10.1.1.1"}, "", "10.1.1.1"},
+		{"real ip from trusted peer", "127.0.0.1:8080", nil, "198.51.100.10", "198.51.100.10"},
+		{"real ip ignored with forwarded for", "127.0.0.1:8080", []string{"198.51.100.20"}, "198.51.100.10", "198.51.100.20"},
+		{"ipv6 peer", "[2001:db8::1]:443", nil, "", "2001:db8::1"},
+		{"ipv6 hop with port", "[::1]:8080", []string{"[2001:DB8:0:0::2]:51234"}, "", "2001:db8::2"},
+		{"ipv6 trusted proxy", "[fd00::1]:443", []string{"2001:db8::3, 2001:db8:cafe::10"}, "", "2001:db8::3"},
+		{"ipv4 mapped peer", "[::ffff:198.51.100.10]:51234", nil, "", "198.51.100.10"},
+		{"ipv4 mapped hop", "10.0.0.5:443", []string{"::ffff:198.51.100.10"}, "", "198.51.100.10"},
+		{"ipv4 mapped trusted peer", "[::ffff:10.0.0.5]:443", []string{"198.51.100.10"}, "", "198.51.100.10"},
+		{"ipv4 hop with port and quotes", "10.0.0.5:443", []string{`"198.51.100.10:8080"`}, "", "198.51.100.10"},
+		{"zoned ipv6", "[fe80::1%eth0]:443", nil, "", "fe80::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolver.resolve(tt.remoteAddr, tt.forwardedFor, tt.realIP); got != tt.want {
+				t.Errorf("resolve(%q, %q, %q) = %q, want %q", tt.remoteAddr, tt.forwardedFor, tt.realIP, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientIPResolverNoTrustedProxies(t *testing.T) {
+	resolver, err := newClientIPResolver("")
+	if err != nil {
+		t.Fatalf("newClientIPResolver: %v", err)
+	}
+
+	if got := resolver.resolve("127.0.0.1:8080", []string{"198.51.100.10"}, "198.51.100.20"); got != "127.0.0.1" {
+		t.Errorf("resolve = %q, want the peer address", got)
+	}
+}
+
+func TestNewClientIPResolverRejectsInvalidProxies(t *testing.T) {
+	for _, proxies := range []string{"10.0.0.0/33", "not-an-ip", "10.0.0.0/8, 300.1.1.1"} {
+		if _, err := newClientIPResolver(proxies); err == nil {
+			t.Errorf("newClientIPResolver(%q) succeeded, want an error", proxies)
+		}
+	}
+}
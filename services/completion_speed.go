@@ -0,0 +1,184 @@
+package services
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	log "github.com/sirupsen/logrus"
+)
+
+// checkCompletionSpeed flags a first-time lesson completion that came faster than the
+// lesson can plausibly be done. In reject mode the completion is refused as well.
+func (svc *UserService) checkCompletionSpeed(userID, lessonID string, timeSpent int, now time.Time) error {
+	config, err := svc.contentRepo.GetGameConfig()
+	if err != nil {
+		log.Printf("Failed to load game config: %v", err)
+		return nil
+	}
+	if config.SpeedCheckMode == model.SpeedCheckOff {
+		return nil
+	}
+
+	// Replays earn nothing, so only first completions are checked
+	if completed, err := svc.progressRepo.HasCompletedLesson(userID, lessonID); err != nil || completed {
+		return nil
+	}
+
+	lesson, err := svc.contentRepo.GetLesson(lessonID)
+	if err != nil {
+		return nil
+	}
+	minDuration := svc.minPlausibleDuration(lesson, config)
+
+	var reasons []string
+	if timeSpent < minDuration {
+		reasons = append(reasons, model.SpeedReasonReportedDuration)
+	}
+
+	if last, err := svc.progressRepo.GetLastLessonCompletion(userID); err == nil &&
+		now.Sub(last.CompletedAt) < time.Duration(minDuration)*time.Second {
+		reasons = append(reasons, model.SpeedReasonInterval)
+	}
+
+	window := time.Duration(config.BurstWindowMinutes) * time.Minute
+	if count, err := svc.progressRepo.CountLessonCompletionsSince(userID, now.Add(-window)); err == nil &&
+		int(count) >= config.BurstMaxCompletions {
+		reasons = append(reasons, model.SpeedReasonBurst)
+	}
+
+	if len(reasons) == 0 {
+		return nil
+	}
+
+	rejected := config.SpeedCheckMode == model.SpeedCheckReject
+	if err := svc.progressRepo.CreateCompletionFlag(&model.CompletionFlag{
+		UserID:      userID,
+		LessonID:    lessonID,
+		Reasons:     strings.Join(reasons, ","),
+		TimeSpent:   timeSpent,
+		MinDuration: minDuration,
+		Rejected:    rejected,
+	}); err != nil {
+		log.Printf("Failed to flag completion of lesson %s by user %s: %v", lessonID, userID, err)
+	}
+	log.Warnf("Suspicious completion of lesson %s by user %s: %s (reported %ds, minimum %ds)",
+		lessonID, userID, strings.Join(reasons, ","), timeSpent, minDuration)
+
+	if rejected {
+		return shared.NewTooManyRequestsError(nil, "Lesson completed faster than expected").WithData(map[string]interface{}{
+			"min_duration": minDuration,
+			"reasons":      reasons,
+		})
+	}
+	return nil
+}
+
+// minPlausibleDuration is the fewest seconds a person needs for a lesson: part of its
+// video plus a few seconds per question
+func (svc *UserService) minPlausibleDuration(lesson *model.Lesson, config *model.GameConfig) int {
+	seconds := 0.0
+	if media, err := svc.sqlSvc.mediaRepo.GetLessonMediaByType(lesson.ID, "animation"); err == nil {
+		seconds += float64(media.MediaAsset.Duration) * config.MinVideoWatchRatio
+	}
+
+	var questions []json.RawMessage
+	if lesson.Questions != nil {
+		if err := json.Unmarshal(lesson.Questions, &questions); err != nil {
+			log.Printf("Failed to unmarshal questions for lesson %s: %v", lesson.ID, err)
+		}
+	}
+	seconds += float64(len(questions) * config.MinSecondsPerQuestion)
+
+	return int(math.Floor(seconds))
+}
+
+// ==================== REVIEW QUEUE ====================
+
+func (svc *UserService) GetCompletionFlags(status string, page, limit int) (*dto.CompletionFlagListResponse, error) {
+	flags, total, err := svc.progressRepo.GetCompletionFlags(status, page, limit)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get completion flags")
+	}
+
+	responses := make([]dto.CompletionFlagResponse, len(flags))
+	for i := range flags {
+		responses[i] = mapCompletionFlag(&flags[i])
+	}
+
+	return &dto.CompletionFlagListResponse{
+		Flags: responses,
+		Total: int(total),
+		Page:  page,
+		Limit: limit,
+	}, nil
+}
+
+// ReviewCompletionFlag dismisses a flag, or bans the flagged user: their account is
+// deactivated, their sessions ended and all their pending flags closed
+func (svc *UserService) ReviewCompletionFlag(adminID, flagID string, req dto.ReviewCompletionFlagRequest) (*dto.CompletionFlagResponse, error) {
+	flag, err := svc.progressRepo.GetCompletionFlag(flagID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Completion flag not found")
+	}
+	if flag.Status != model.FlagStatusPending {
+		return nil, shared.NewConflictError(nil, "Completion flag has already been reviewed")
+	}
+
+	switch req.Action {
+	case "ban":
+		if err := svc.banUser(flag.UserID); err != nil {
+			return nil, err
+		}
+		if _, err := svc.progressRepo.ResolveCompletionFlags(flag.ID, flag.UserID, model.FlagStatusBanned, adminID, req.Note); err != nil {
+			return nil, shared.NewInternalError(err, "Failed to update completion flags")
+		}
+		log.Printf("User %s banned by %s after speed review", flag.UserID, adminID)
+	default:
+		if _, err := svc.progressRepo.ResolveCompletionFlags(flag.ID, "", model.FlagStatusDismissed, adminID, req.Note); err != nil {
+			return nil, shared.NewInternalError(err, "Failed to update completion flag")
+		}
+	}
+
+	flag, err = svc.progressRepo.GetCompletionFlag(flagID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get completion flag")
+	}
+
+	response := mapCompletionFlag(flag)
+	return &response, nil
+}
+
+// banUser deactivates an account and ends its sessions
+func (svc *UserService) banUser(userID string) error {
+	if err := svc.userRepo.AdminUpdateUser(userID, map[string]interface{}{"is_active": false}); err != nil {
+		return shared.NewInternalError(err, "Failed to deactivate user")
+	}
+	if err := svc.userRepo.DeactivateAllUserSessions(userID, ""); err != nil {
+		log.Printf("Failed to end sessions of banned user %s: %v", userID, err)
+	}
+	svc.authSvc.invalidateAuthState(userID)
+	return nil
+}
+
+func mapCompletionFlag(flag *model.CompletionFlag) dto.CompletionFlagResponse {
+	return dto.CompletionFlagResponse{
+		ID:          flag.ID,
+		UserID:      flag.UserID,
+		Username:    flag.User.Username,
+		LessonID:    flag.LessonID,
+		Reasons:     strings.Split(flag.Reasons, ","),
+		TimeSpent:   flag.TimeSpent,
+		MinDuration: flag.MinDuration,
+		Rejected:    flag.Rejected,
+		Status:      flag.Status,
+		ReviewedBy:  flag.ReviewedBy,
+		ReviewNote:  flag.ReviewNote,
+		ReviewedAt:  flag.ReviewedAt,
+		CreatedAt:   flag.CreatedAt,
+	}
+}
@@ -4,19 +4,53 @@ package services
 import (
 	"encoding/json"
 	"fmt"
+	"html"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/cloakd/common/context"
 	serviceContext "github.com/cloakd/common/services"
 	"github.com/lac-hong-legacy/ven_api/dto"
 	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/services/repositories"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	"github.com/lac-hong-legacy/ven_api/shared/text"
 	log "github.com/sirupsen/logrus"
 )
 
 type ContentService struct {
 	serviceContext.DefaultService
-	sqlSvc *PostgresService
+	sqlSvc   *PostgresService
+	mediaSvc *MediaService
+
+	// Set from sqlSvc in Start; tests set fakes from repositories/mocks instead
+	userRepo     repositories.UserRepo
+	contentRepo  repositories.ContentRepo
+	progressRepo repositories.ProgressRepo
+
+	glossary *glossaryIndex
+	suggest  *suggestIndex
+	eras     *eraCatalog
+
+	redisSvc    *RedisService
+	eventBusSvc *EventBusService
+	trending    *trendingCache
+	public      *publicCatalogCache
+
+	// Website pages the sitemap and page metadata link to, and the base of relative
+	// image paths in link previews
+	publicSiteURL string
+	assetBaseURL  string
 }
 
 const CONTENT_SVC = "content_svc"
@@ -26,18 +60,49 @@ func (svc ContentService) Id() string {
 }
 
 func (svc *ContentService) Configure(ctx *context.Context) error {
+	svc.glossary = newGlossaryIndex()
+	svc.suggest = newSuggestIndex()
+	svc.eras = newEraCatalog()
+	svc.trending = newTrendingCache()
+	svc.public = &publicCatalogCache{}
+	svc.publicSiteURL = strings.TrimRight(os.Getenv("PUBLIC_SITE_URL"), "/")
+	if svc.publicSiteURL == "" {
+		svc.publicSiteURL = strings.TrimRight(os.Getenv("BASE_URL"), "/")
+	}
+	svc.assetBaseURL = strings.TrimRight(os.Getenv("SHARE_CARD_ASSET_BASE_URL"), "/")
 	return svc.DefaultService.Configure(ctx)
 }
 
 func (svc *ContentService) Start() error {
-	svc.sqlSvc = svc.Service(POSTGRES_SVC).(*PostgresService)
+	deps := newDependencies(svc.Id(), svc.Service)
+	svc.sqlSvc = resolve[*PostgresService](deps, POSTGRES_SVC)
+	svc.mediaSvc = resolve[*MediaService](deps, MEDIA_SVC)
+	svc.redisSvc = resolve[*RedisService](deps, REDIS_SVC)
+	svc.eventBusSvc = resolve[*EventBusService](deps, EVENT_BUS_SVC)
+	if err := deps.err(); err != nil {
+		return err
+	}
+	svc.userRepo = svc.sqlSvc.userRepo
+	svc.contentRepo = svc.sqlSvc.contentRepo
+	svc.progressRepo = svc.sqlSvc.contentRepo
+
+	svc.eventBusSvc.Subscribe(EventLessonCompleted, CONTENT_SVC, func(event DomainEvent) {
+		completed := event.(*LessonCompletedEvent)
+		svc.recordPopularity(model.PopularityEntityLesson, completed.LessonID, popularityMetricCompletions)
+		if err := svc.progressRepo.DeleteLessonSession(completed.UserID, completed.LessonID); err != nil {
+			log.Errorf("Failed to end lesson session for user %s, lesson %s: %v", completed.UserID, completed.LessonID, err)
+		}
+	})
+
+	go svc.startPopularityFlushJob()
+
 	return nil
 }
 
 // ==================== TIMELINE METHODS ====================
 
 func (svc *ContentService) GetTimeline() (*dto.TimelineCollectionResponse, error) {
-	timelines, err := svc.sqlSvc.contentRepo.GetTimeline()
+	timelines, err := svc.contentRepo.GetTimeline()
 	if err != nil {
 		return nil, err
 	}
@@ -70,7 +135,7 @@ func (svc *ContentService) GetTimeline() (*dto.TimelineCollectionResponse, error
 			// Fetch characters by IDs
 			characters := []model.Character{}
 			for _, charID := range characterIDs {
-				char, err := svc.sqlSvc.contentRepo.GetCharacter(charID)
+				char, err := svc.contentRepo.GetCharacter(charID)
 				if err != nil {
 					log.Printf("Failed to get character %s: %v", charID, err)
 					continue
@@ -139,11 +204,11 @@ func (svc *ContentService) GetCharacters(dynasty, rarity string) (*dto.Character
 	var err error
 
 	if dynasty != "" {
-		characters, err = svc.sqlSvc.contentRepo.GetCharactersByDynasty(dynasty)
+		characters, err = svc.contentRepo.GetCharactersByDynasty(dynasty)
 	} else if rarity != "" {
-		characters, err = svc.sqlSvc.contentRepo.GetCharactersByRarity(rarity)
+		characters, err = svc.contentRepo.GetCharactersByRarity(rarity)
 	} else {
-		characters, err = svc.sqlSvc.contentRepo.GetCharactersByDynasty("") // Get all
+		characters, err = svc.contentRepo.GetCharactersByDynasty("") // Get all
 	}
 
 	if err != nil {
@@ -158,7 +223,7 @@ func (svc *ContentService) GetCharacters(dynasty, rarity string) (*dto.Character
 		if char.IsUnlocked {
 			unlockedCount++
 		}
-		lessons, err := svc.sqlSvc.contentRepo.GetLessonsByCharacter(char.ID)
+		lessons, err := svc.contentRepo.GetLessonsByCharacter(char.ID)
 		if err != nil {
 			log.Printf("Failed to get lesson count for character %s: %v", char.ID, err)
 		} else {
@@ -174,7 +239,7 @@ func (svc *ContentService) GetCharacters(dynasty, rarity string) (*dto.Character
 }
 
 func (svc *ContentService) GetCharacterDetails(characterID string) (*dto.CharacterResponse, error) {
-	character, err := svc.sqlSvc.contentRepo.GetCharacter(characterID)
+	character, err := svc.contentRepo.GetCharacter(characterID)
 	if err != nil {
 		return nil, err
 	}
@@ -182,13 +247,22 @@ func (svc *ContentService) GetCharacterDetails(characterID string) (*dto.Charact
 	response := svc.mapCharacterToResponse(character)
 
 	// Add lesson count
-	lessons, err := svc.sqlSvc.contentRepo.GetLessonsByCharacter(characterID)
+	lessons, err := svc.contentRepo.GetLessonsByCharacter(characterID)
 	if err != nil {
 		log.Printf("Failed to get lesson count for character %s: %v", characterID, err)
 	} else {
 		response.LessonCount = len(lessons)
 	}
 
+	related, err := svc.getRelatedCharacters(characterID)
+	if err != nil {
+		log.Printf("Failed to get related characters for character %s: %v", characterID, err)
+	} else {
+		response.RelatedCharacters = related
+	}
+
+	svc.recordPopularity(model.PopularityEntityCharacter, character.ID, popularityMetricViews)
+
 	return &response, nil
 }
 
@@ -219,38 +293,67 @@ func (svc *ContentService) mapCharacterToResponse(char *model.Character) dto.Cha
 
 // ==================== LESSON METHODS ====================
 
-func (svc *ContentService) GetCharacterLessons(characterID string) ([]dto.LessonResponse, error) {
-	lessons, err := svc.sqlSvc.contentRepo.GetLessonsByCharacter(characterID)
+// GetCharacterLessons lists a character's lessons, leaving out those rated above the
+// viewer's age. userID is empty for anonymous viewers.
+func (svc *ContentService) GetCharacterLessons(characterID, userID string) ([]dto.LessonResponse, error) {
+	lessons, err := svc.contentRepo.GetLessonsByCharacter(characterID)
 	if err != nil {
 		return nil, err
 	}
 
-	responses := make([]dto.LessonResponse, len(lessons))
-	for i, lesson := range lessons {
-		responses[i] = svc.MapLessonToResponse(&lesson)
+	ageLimit := svc.viewerAgeLimit(userID)
+	responses := make([]dto.LessonResponse, 0, len(lessons))
+	for _, lesson := range lessons {
+		if lesson.SuitableForAge(ageLimit) {
+			responses = append(responses, svc.MapLessonToResponse(&lesson))
+		}
 	}
 
 	return responses, nil
 }
 
-func (svc *ContentService) GetLessonContent(lessonID string) (*dto.LessonResponse, error) {
-	lesson, err := svc.sqlSvc.contentRepo.GetLesson(lessonID)
+// GetLessonContent returns a lesson in the requested locale. When there is no approved
+// translation for that locale the Vietnamese source is returned instead. Lessons rated
+// above the viewer's age are refused.
+func (svc *ContentService) GetLessonContent(lessonID, locale, userID string) (*dto.LessonResponse, error) {
+	lesson, err := svc.contentRepo.GetLesson(lessonID)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkLessonActive(lesson); err != nil {
+		return nil, err
+	}
+
+	if !lesson.SuitableForAge(svc.viewerAgeLimit(userID)) {
+		return nil, shared.NewForbiddenError(nil, "This lesson is not available for your age")
+	}
 
 	response := svc.MapLessonToResponse(lesson)
+	response.Locale = model.SourceLocale
+
+	if locale != "" && locale != model.SourceLocale {
+		svc.applyTranslation(&response, locale)
+	}
+
+	// Glossary terms are written in the source language
+	if response.Locale == model.SourceLocale {
+		response.GlossaryTerms = svc.annotateGlossary(response.Story)
+	}
+
+	svc.recordPopularity(model.PopularityEntityLesson, lesson.ID, popularityMetricViews)
+
 	return &response, nil
 }
 
 func (svc *ContentService) MapLessonToResponse(lesson *model.Lesson) dto.LessonResponse {
 	var questions []dto.QuestionResponse
+	var rawQuestions []model.Question
 	if lesson.Questions != nil {
-		var rawQuestions []model.Question
 		if err := json.Unmarshal(lesson.Questions, &rawQuestions); err != nil {
 			log.Printf("Failed to unmarshal questions for lesson %s: %v", lesson.ID, err)
 			questions = []dto.QuestionResponse{}
 		} else {
+			questionMedia := svc.mediaSvc.questionMediaForLesson(lesson.ID)
 			questions = make([]dto.QuestionResponse, len(rawQuestions))
 			for i, q := range rawQuestions {
 				questions[i] = dto.QuestionResponse{
@@ -260,11 +363,13 @@ func (svc *ContentService) MapLessonToResponse(lesson *model.Lesson) dto.LessonR
 					Options:  q.Options,
 					Points:   q.Points,
 					Metadata: q.Metadata,
+					Media:    questionMedia[q.ID],
 					// Note: We don't include the Answer in the response for security
 				}
 			}
 		}
 	}
+	difficulty, estimatedMinutes := svc.lessonDifficulty(lesson, rawQuestions)
 
 	return dto.LessonResponse{
 		ID:          lesson.ID,
@@ -286,8 +391,14 @@ func (svc *ContentService) MapLessonToResponse(lesson *model.Lesson) dto.LessonR
 		ThumbnailURL: lesson.ThumbnailURL,
 
 		// Content Settings
-		CanSkipAfter: lesson.CanSkipAfter,
-		HasSubtitles: lesson.HasSubtitles,
+		CanSkipAfter:  lesson.CanSkipAfter,
+		HasSubtitles:  lesson.HasSubtitles,
+		ContentRating: lesson.ContentRating,
+
+		Difficulty:       difficulty,
+		EstimatedMinutes: estimatedMinutes,
+
+		Accessibility: lessonAccessibility(lesson),
 
 		Questions: questions,
 		XPReward:  lesson.XPReward,
@@ -299,47 +410,209 @@ func (svc *ContentService) MapLessonToResponse(lesson *model.Lesson) dto.LessonR
 // ==================== SEARCH METHODS ====================
 
 func (svc *ContentService) SearchContent(req dto.SearchRequest) (*dto.SearchResponse, error) {
-	characters, err := svc.sqlSvc.contentRepo.SearchCharacters(req.Query, req.Era, req.Dynasty, req.Rarity, req.Limit)
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	// Stored content is NFC, decomposed queries would match nothing
+	req.Query = text.NFC(req.Query)
+
+	hits, total, err := svc.contentRepo.SearchContent(req.Query, req.Type, req.Era, req.Dynasty, req.Rarity, req.Page, req.Limit)
 	if err != nil {
-		return nil, err
+		return nil, shared.NewInternalError(err, "Failed to search content")
 	}
 
-	characterResponses := make([]dto.CharacterResponse, len(characters))
-	for i, char := range characters {
-		characterResponses[i] = svc.mapCharacterToResponse(&char)
+	var characterIDs, lessonIDs []string
+	for _, hit := range hits {
+		if hit.EntityType == model.SearchEntityLesson {
+			lessonIDs = append(lessonIDs, hit.EntityID)
+		} else {
+			characterIDs = append(characterIDs, hit.EntityID)
+		}
+	}
+
+	characters, err := svc.contentRepo.GetCharactersByIDs(characterIDs)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to load characters")
+	}
+	charactersByID := make(map[string]*model.Character, len(characters))
+	for i := range characters {
+		charactersByID[characters[i].ID] = &characters[i]
+	}
+
+	lessons, err := svc.contentRepo.GetLessonsByIDs(lessonIDs)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to load lessons")
+	}
+	lessonsByID := make(map[string]*model.Lesson, len(lessons))
+	for i := range lessons {
+		lessonsByID[lessons[i].ID] = &lessons[i]
+	}
+
+	results := make([]dto.SearchResult, 0, len(hits))
+	characterResponses := make([]dto.CharacterResponse, 0, len(characterIDs))
+	for _, hit := range hits {
+		switch hit.EntityType {
+		case model.SearchEntityCharacter:
+			char, ok := charactersByID[hit.EntityID]
+			if !ok {
+				continue
+			}
+			response := svc.mapCharacterToResponse(char)
+			field, snippet := searchSnippet(req.Query,
+				searchField{"name", char.Name}, searchField{"description", char.Description})
+
+			results = append(results, dto.SearchResult{
+				Type:         hit.EntityType,
+				ID:           char.ID,
+				Title:        char.Name,
+				MatchedField: field,
+				Snippet:      snippet,
+				Character:    &response,
+			})
+			characterResponses = append(characterResponses, response)
+
+		case model.SearchEntityLesson:
+			lesson, ok := lessonsByID[hit.EntityID]
+			if !ok {
+				continue
+			}
+			fields := []searchField{{"title", lesson.Title}, {"story", lesson.Story}}
+			var questions []model.Question
+			if err := json.Unmarshal(lesson.Questions, &questions); err == nil {
+				for _, q := range questions {
+					fields = append(fields, searchField{"question", q.Question})
+				}
+			}
+			field, snippet := searchSnippet(req.Query, fields...)
+
+			results = append(results, dto.SearchResult{
+				Type:         hit.EntityType,
+				ID:           lesson.ID,
+				Title:        lesson.Title,
+				MatchedField: field,
+				Snippet:      snippet,
+				CharacterID:  lesson.CharacterID,
+			})
+		}
 	}
 
 	return &dto.SearchResponse{
+		Results:    results,
+		Total:      int(total),
+		Page:       req.Page,
+		Limit:      req.Limit,
 		Characters: characterResponses,
-		Total:      len(characters),
 	}, nil
 }
 
+type searchField struct {
+	name string
+	text string
+}
+
+// searchSnippetRadius is how many characters of context are kept on each side of a match
+const searchSnippetRadius = 60
+
+// searchSnippet finds the first field containing query (case-insensitive) and returns
+// its name with an HTML-escaped excerpt around the match, the match wrapped in <mark>.
+func searchSnippet(query string, fields ...searchField) (string, string) {
+	needle := lowerRunes([]rune(query))
+	if len(needle) == 0 {
+		return "", ""
+	}
+
+	for _, field := range fields {
+		text := []rune(field.text)
+		idx := indexRunes(lowerRunes(text), needle)
+		if idx < 0 {
+			continue
+		}
+
+		start := max(0, idx-searchSnippetRadius)
+		end := min(len(text), idx+len(needle)+searchSnippetRadius)
+
+		var b strings.Builder
+		if start > 0 {
+			b.WriteString("...")
+		}
+		b.WriteString(html.EscapeString(string(text[start:idx])))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(string(text[idx : idx+len(needle)])))
+		b.WriteString("</mark>")
+		b.WriteString(html.EscapeString(string(text[idx+len(needle) : end])))
+		if end < len(text) {
+			b.WriteString("...")
+		}
+		return field.name, b.String()
+	}
+
+	return "", ""
+}
+
+// lowerRunes lower-cases rune by rune so offsets stay aligned with the original text
+func lowerRunes(text []rune) []rune {
+	lowered := make([]rune, len(text))
+	for i, r := range text {
+		lowered[i] = unicode.ToLower(r)
+	}
+	return lowered
+}
+
+func indexRunes(haystack, needle []rune) int {
+	for i := 0; i+len(needle) <= len(haystack); i++ {
+		if slices.Equal(haystack[i:i+len(needle)], needle) {
+			return i
+		}
+	}
+	return -1
+}
+
 // ==================== ADMIN METHODS ====================
 
-func (svc *ContentService) CreateCharacter(character *model.Character) (*dto.CharacterResponse, error) {
-	created, err := svc.sqlSvc.contentRepo.CreateCharacter(character)
+func (svc *ContentService) CreateCharacter(adminID string, character *model.Character) (*dto.CharacterResponse, error) {
+	if err := svc.checkContentReferences(character.Era, character.Dynasty); err != nil {
+		return nil, err
+	}
+
+	created, err := svc.contentRepo.CreateCharacter(character)
 	if err != nil {
 		return nil, err
 	}
 
+	svc.RecordContentAudit(adminID, model.ContentEntityCharacter, created.ID, model.ContentActionCreate, nil, created)
+
 	response := svc.mapCharacterToResponse(created)
 	return &response, nil
 }
 
-func (svc *ContentService) CreateLesson(lesson *model.Lesson) (*dto.LessonResponse, error) {
-	created, err := svc.sqlSvc.contentRepo.CreateLesson(lesson)
+func (svc *ContentService) CreateLesson(adminID string, lesson *model.Lesson) (*dto.LessonResponse, error) {
+	if len(lesson.Questions) > 0 {
+		var questions []model.Question
+		if err := json.Unmarshal(lesson.Questions, &questions); err != nil {
+			return nil, shared.NewBadRequestError(err, "Invalid lesson questions")
+		}
+		if errs := model.ValidateQuestions(questions); len(errs) > 0 {
+			return nil, shared.NewBadRequestError(errs[0], "Invalid question metadata").WithData(map[string]interface{}{
+				"errors": errs,
+			})
+		}
+	}
+
+	created, err := svc.contentRepo.CreateLesson(lesson)
 	if err != nil {
 		return nil, err
 	}
 
+	svc.RecordContentAudit(adminID, model.ContentEntityLesson, created.ID, model.ContentActionCreate, nil, created)
+	go svc.mediaSvc.prewarmLessonAfter(created.ID, model.CDNPrewarmTriggerPublish)
+
 	response := svc.MapLessonToResponse(created)
 	return &response, nil
 }
 
-func (svc *ContentService) CreateLessonFromRequest(req dto.CreateLessonRequest) (*dto.LessonResponse, error) {
+func (svc *ContentService) CreateLessonFromRequest(adminID string, req dto.CreateLessonRequest) (*dto.LessonResponse, error) {
 	// Validate character exists
-	_, err := svc.sqlSvc.contentRepo.GetCharacter(req.CharacterID)
+	_, err := svc.contentRepo.GetCharacter(req.CharacterID)
 	if err != nil {
 		return nil, fmt.Errorf("character not found: %v", err)
 	}
@@ -380,29 +653,32 @@ func (svc *ContentService) CreateLessonFromRequest(req dto.CreateLessonRequest)
 	}
 
 	lesson := &model.Lesson{
-		CharacterID:     req.CharacterID,
-		Title:           req.Title,
-		Order:           req.Order,
-		Story:           req.Story,
-		Script:          req.Script,
-		ScriptStatus:    "draft",
-		AudioStatus:     "pending",
-		AnimationStatus: "pending",
-		CanSkipAfter:    req.CanSkipAfter,
-		HasSubtitles:    req.HasSubtitles,
-		Questions:       questionsJSON,
-		XPReward:        req.XPReward,
-		MinScore:        req.MinScore,
-		IsActive:        true,
+		CharacterID:      req.CharacterID,
+		Title:            req.Title,
+		Order:            req.Order,
+		Story:            req.Story,
+		Script:           req.Script,
+		ScriptStatus:     "draft",
+		AudioStatus:      "pending",
+		AnimationStatus:  "pending",
+		CanSkipAfter:     req.CanSkipAfter,
+		HasSubtitles:     req.HasSubtitles,
+		Questions:        questionsJSON,
+		XPReward:         req.XPReward,
+		MinScore:         req.MinScore,
+		ContentRating:    req.ContentRating,
+		Difficulty:       req.Difficulty,
+		EstimatedMinutes: req.EstimatedMinutes,
+		IsActive:         true,
 	}
 
-	return svc.CreateLesson(lesson)
+	return svc.CreateLesson(adminID, lesson)
 }
 
 // ==================== VALIDATION METHODS ====================
 
 func (svc *ContentService) ValidateLessonAnswers(lessonID string, userAnswers map[string]interface{}) (*dto.ValidateLessonResponse, error) {
-	lesson, err := svc.sqlSvc.contentRepo.GetLesson(lessonID)
+	lesson, err := svc.contentRepo.GetLesson(lessonID)
 	if err != nil {
 		return nil, err
 	}
@@ -412,35 +688,35 @@ func (svc *ContentService) ValidateLessonAnswers(lessonID string, userAnswers ma
 		return nil, fmt.Errorf("failed to parse lesson questions: %v", err)
 	}
 
-	totalPoints := 0
-	earnedPoints := 0
-
-	for _, question := range questions {
-		totalPoints += question.Points
+	variants := svc.getTranslatedQuestions(lessonID)
 
-		userAnswer, exists := userAnswers[question.ID]
-		if exists && svc.isAnswerCorrect(question, userAnswer) {
-			earnedPoints += question.Points
-		}
+	config, err := svc.contentRepo.GetGameConfig()
+	if err != nil {
+		log.WithError(err).Warn("Failed to load game config, scoring with default category weights")
+		defaults := model.DefaultGameConfig()
+		config = &defaults
 	}
 
-	if totalPoints == 0 {
-		return &dto.ValidateLessonResponse{
-			Score:       100,
-			Passed:      true,
-			TotalPoints: 0,
-			MinScore:    lesson.MinScore,
-		}, nil
+	earnedPoints, weightedTotal, scores := scoreLessonQuestions(questions, userAnswers, config,
+		func(question model.Question, userAnswer interface{}) bool {
+			return svc.isLocalizedAnswerCorrect(question, variants[question.ID], userAnswer)
+		})
+
+	totalPoints := 0
+	for _, question := range questions {
+		totalPoints += question.Points
 	}
 
-	score := (earnedPoints * 100) / totalPoints
-	passed := score >= lesson.MinScore
+	score := lessonScorePercent(earnedPoints, weightedTotal)
 
 	return &dto.ValidateLessonResponse{
-		Score:       score,
-		Passed:      passed,
-		TotalPoints: totalPoints,
-		MinScore:    lesson.MinScore,
+		Score:         score,
+		Passed:        score >= lesson.MinScore,
+		TotalPoints:   totalPoints,
+		MinScore:      lesson.MinScore,
+		EarnedPoints:  earnedPoints,
+		WeightedTotal: weightedTotal,
+		Questions:     scores,
 	}, nil
 }
 
@@ -451,16 +727,23 @@ func (svc *ContentService) isAnswerCorrect(question model.Question, userAnswer i
 		correctAnswer, ok1 := question.Answer.(string)
 		userAnswerStr, ok2 := userAnswer.(string)
 		if ok1 && ok2 {
-			return strings.EqualFold(strings.TrimSpace(correctAnswer), strings.TrimSpace(userAnswerStr))
+			return text.Match(userAnswerStr, correctAnswer, text.MatchOptions{})
 		}
 		// Fallback to direct comparison
 		return question.Answer == userAnswer
 	case "fill_blank":
-		// Case-insensitive string comparison
+		// Case-insensitive string comparison, loosened by the question's grading options
 		correctAnswer, ok1 := question.Answer.(string)
 		userAnswerStr, ok2 := userAnswer.(string)
 		if ok1 && ok2 {
-			return strings.EqualFold(strings.TrimSpace(correctAnswer), strings.TrimSpace(userAnswerStr))
+			grading := question.FillBlankGrading()
+			opts := text.MatchOptions{IgnoreDiacritics: grading.AcceptWithoutDiacritics, MaxEdits: grading.MaxTypos}
+			if text.Match(userAnswerStr, correctAnswer, opts) {
+				return true
+			}
+			return slices.ContainsFunc(grading.AcceptedAnswers, func(accepted string) bool {
+				return text.Match(userAnswerStr, accepted, opts)
+			})
 		}
 	case "drag_drop", "connect":
 		// For array-based answers, compare as JSON
@@ -472,22 +755,146 @@ func (svc *ContentService) isAnswerCorrect(question model.Question, userAnswer i
 	return false
 }
 
-func (svc *ContentService) GetEras() ([]string, error) {
-	return []string{"Bac_Thuoc", "Doc_Lap", "Phong_Kien", "Can_Dai"}, nil
+// ==================== LOCALIZATION METHODS ====================
+
+// applyTranslation overlays an approved translation onto a lesson response. Questions
+// without a translation keep their source text.
+func (svc *ContentService) applyTranslation(response *dto.LessonResponse, locale string) {
+	translation, err := svc.contentRepo.GetLessonTranslation(response.ID, locale)
+	if err != nil || translation.Status != model.TranslationStatusApproved {
+		return
+	}
+
+	var translated []model.TranslatedQuestion
+	if err := json.Unmarshal(translation.Questions, &translated); err != nil {
+		log.Printf("Failed to unmarshal %s translation for lesson %s: %v", locale, response.ID, err)
+		return
+	}
+
+	response.Locale = locale
+	if translation.Title != "" {
+		response.Title = translation.Title
+	}
+	if translation.Story != "" {
+		response.Story = translation.Story
+	}
+	if translation.Script != "" {
+		response.Script = translation.Script
+	}
+
+	byID := make(map[string]model.TranslatedQuestion, len(translated))
+	for _, tq := range translated {
+		byID[tq.ID] = tq
+	}
+
+	for i, q := range response.Questions {
+		tq, exists := byID[q.ID]
+		if !exists {
+			continue
+		}
+		if tq.Question != "" {
+			response.Questions[i].Question = tq.Question
+		}
+		if len(tq.Options) == len(q.Options) {
+			response.Questions[i].Options = tq.Options
+		}
+	}
+}
+
+// getTranslatedQuestions returns the approved translations of a lesson's questions keyed
+// by question ID, with answers mapped to the translated text so they can be graded.
+func (svc *ContentService) getTranslatedQuestions(lessonID string) map[string][]model.Question {
+	lesson, err := svc.contentRepo.GetLesson(lessonID)
+	if err != nil {
+		return nil
+	}
+
+	translations, err := svc.contentRepo.GetApprovedTranslations(lessonID)
+	if err != nil || len(translations) == 0 {
+		return nil
+	}
+
+	var questions []model.Question
+	if err := json.Unmarshal(lesson.Questions, &questions); err != nil {
+		return nil
+	}
+
+	sources := make(map[string]model.Question, len(questions))
+	for _, q := range questions {
+		sources[q.ID] = q
+	}
+
+	variants := make(map[string][]model.Question)
+	for _, translation := range translations {
+		var translated []model.TranslatedQuestion
+		if err := json.Unmarshal(translation.Questions, &translated); err != nil {
+			continue
+		}
+
+		for _, tq := range translated {
+			source, exists := sources[tq.ID]
+			if !exists {
+				continue
+			}
+
+			variant := source
+			switch source.Type {
+			case "multiple_choice":
+				// Options keep the source order, so the answer maps across by index
+				answer, ok := source.Answer.(string)
+				if !ok || len(tq.Options) != len(source.Options) {
+					continue
+				}
+				index := slices.IndexFunc(source.Options, func(option string) bool {
+					return text.Match(option, answer, text.MatchOptions{})
+				})
+				if index < 0 {
+					continue
+				}
+				variant.Answer = tq.Options[index]
+			case "fill_blank":
+				if tq.Answer == nil {
+					continue
+				}
+				variant.Answer = tq.Answer
+			default:
+				continue
+			}
+
+			variants[tq.ID] = append(variants[tq.ID], variant)
+		}
+	}
+
+	return variants
 }
 
-func (svc *ContentService) GetDynasties() ([]string, error) {
-	return []string{"Văn Lang", "Âu Lạc", "Bắc Thuộc", "Ngô", "Cận Đại", "Đinh - Tiền Lê", "Lý", "Trần", "Hồ", "Nguyễn", "Minh Chiếm Đóng", "Hậu Lê", "Mạc", "Tây Sơn"}, nil
+// isLocalizedAnswerCorrect accepts an answer given in the source language or in any
+// approved translation of the question
+func (svc *ContentService) isLocalizedAnswerCorrect(question model.Question, variants []model.Question, userAnswer interface{}) bool {
+	if svc.isAnswerCorrect(question, userAnswer) {
+		return true
+	}
+
+	for _, variant := range variants {
+		if svc.isAnswerCorrect(variant, userAnswer) {
+			return true
+		}
+	}
+
+	return false
 }
 
 // ==================== INDIVIDUAL QUESTION ANSWER METHODS ====================
 
 func (svc *ContentService) SubmitQuestionAnswer(userID, lessonID, questionID string, answer interface{}) (*dto.SubmitQuestionAnswerResponse, error) {
 	// Get the lesson to validate the question
-	lesson, err := svc.sqlSvc.contentRepo.GetLesson(lessonID)
+	lesson, err := svc.contentRepo.GetLesson(lessonID)
 	if err != nil {
 		return nil, err
 	}
+	if err := checkLessonActive(lesson); err != nil {
+		return nil, err
+	}
 
 	var questions []model.Question
 	if err := json.Unmarshal(lesson.Questions, &questions); err != nil {
@@ -509,12 +916,17 @@ func (svc *ContentService) SubmitQuestionAnswer(userID, lessonID, questionID str
 	}
 
 	// Check if answer is correct
-	isCorrect := svc.isAnswerCorrect(*targetQuestion, answer)
+	isCorrect := svc.isLocalizedAnswerCorrect(*targetQuestion, svc.getTranslatedQuestions(lessonID)[questionID], answer)
 	points := 0
 	if isCorrect {
 		points = targetQuestion.Points
 	}
 
+	// Keeps the lesson resumable; an attempt abandoned long ago starts over here
+	if _, err := svc.touchLessonSession(userID, lessonID); err != nil {
+		return nil, err
+	}
+
 	// Convert answer to JSON string for storage
 	answerJSON, err := json.Marshal(answer)
 	if err != nil {
@@ -531,10 +943,15 @@ func (svc *ContentService) SubmitQuestionAnswer(userID, lessonID, questionID str
 		Points:     points,
 	}
 
-	if err := svc.sqlSvc.contentRepo.SaveUserQuestionAnswer(userAnswer); err != nil {
+	if err := svc.progressRepo.SaveUserQuestionAnswer(userAnswer); err != nil {
 		return nil, err
 	}
 
+	// Answering the first question is what counts as starting the lesson
+	if questionID == questions[0].ID {
+		svc.recordPopularity(model.PopularityEntityLesson, lessonID, popularityMetricStarts)
+	}
+
 	// Get updated lesson status after this answer
 	status, err := svc.CheckLessonStatus(userID, lessonID)
 	if err != nil {
@@ -555,7 +972,7 @@ func (svc *ContentService) SubmitQuestionAnswer(userID, lessonID, questionID str
 
 func (svc *ContentService) CheckLessonStatus(userID, lessonID string) (*dto.CheckLessonStatusResponse, error) {
 	// Get the lesson
-	lesson, err := svc.sqlSvc.contentRepo.GetLesson(lessonID)
+	lesson, err := svc.contentRepo.GetLesson(lessonID)
 	if err != nil {
 		return nil, err
 	}
@@ -566,7 +983,7 @@ func (svc *ContentService) CheckLessonStatus(userID, lessonID string) (*dto.Chec
 	}
 
 	// Get user's answers for this lesson
-	userAnswers, err := svc.sqlSvc.contentRepo.GetUserQuestionAnswers(userID, lessonID)
+	userAnswers, err := svc.progressRepo.GetUserQuestionAnswers(userID, lessonID)
 	if err != nil {
 		return nil, err
 	}
@@ -630,26 +1047,28 @@ func maxInt(a, b int) int {
 	return b
 }
 
-func (svc *ContentService) UpdateLessonScript(lessonID, script string) (*model.Lesson, error) {
-	lesson, err := svc.sqlSvc.contentRepo.GetLesson(lessonID)
+func (svc *ContentService) UpdateLessonScript(adminID, lessonID, script string) (*model.Lesson, error) {
+	lesson, err := svc.contentRepo.GetLesson(lessonID)
 	if err != nil {
 		return nil, err
 	}
+	before := *lesson
 
 	now := time.Now()
 	lesson.Script = script
 	lesson.ScriptStatus = "finalized"
 	lesson.ScriptUpdatedAt = &now
 
-	if err := svc.sqlSvc.contentRepo.UpdateLesson(lesson); err != nil {
+	if err := svc.contentRepo.UpdateLesson(lesson); err != nil {
 		return nil, err
 	}
 
+	svc.RecordContentAudit(adminID, model.ContentEntityLesson, lesson.ID, model.ContentActionUpdate, before, lesson)
 	return lesson, nil
 }
 
 func (svc *ContentService) GetLessonProductionStatus(lessonID string) (*dto.LessonProductionStatusResponse, error) {
-	lesson, err := svc.sqlSvc.contentRepo.GetLesson(lessonID)
+	lesson, err := svc.contentRepo.GetLesson(lessonID)
 	if err != nil {
 		return nil, err
 	}
@@ -676,11 +1095,43 @@ func (svc *ContentService) GetLessonProductionStatus(lessonID string) (*dto.Less
 	return response, nil
 }
 
-func (svc *ContentService) MarkAudioUploaded(lessonID string) error {
-	lesson, err := svc.sqlSvc.contentRepo.GetLesson(lessonID)
+// LintLesson checks a lesson for content some learners can't follow, such as questions
+// that rely on color or a video without captions
+func (svc *ContentService) LintLesson(lessonID string) (*dto.LessonLintResponse, error) {
+	lesson, err := svc.contentRepo.GetLesson(lessonID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Lesson not found")
+	}
+
+	var questions []model.Question
+	if len(lesson.Questions) > 0 {
+		if err := json.Unmarshal(lesson.Questions, &questions); err != nil {
+			return nil, shared.NewInternalError(err, "Failed to parse lesson questions")
+		}
+	}
+
+	response := &dto.LessonLintResponse{
+		LessonID: lesson.ID,
+		Title:    lesson.Title,
+		Issues:   model.LintLessonAccessibility(lesson, questions),
+	}
+	for _, issue := range response.Issues {
+		if issue.Severity == model.LintSeverityWarning {
+			response.Warnings++
+		}
+	}
+	if response.Issues == nil {
+		response.Issues = []model.ContentLintIssue{}
+	}
+	return response, nil
+}
+
+func (svc *ContentService) MarkAudioUploaded(adminID, lessonID string) error {
+	lesson, err := svc.contentRepo.GetLesson(lessonID)
 	if err != nil {
 		return err
 	}
+	before := *lesson
 
 	if lesson.ScriptStatus != "finalized" {
 		return fmt.Errorf("cannot upload audio: script must be finalized first")
@@ -690,14 +1141,20 @@ func (svc *ContentService) MarkAudioUploaded(lessonID string) error {
 	lesson.AudioStatus = "uploaded"
 	lesson.AudioUploadedAt = &now
 
-	return svc.sqlSvc.contentRepo.UpdateLesson(lesson)
+	if err := svc.contentRepo.UpdateLesson(lesson); err != nil {
+		return err
+	}
+
+	svc.RecordContentAudit(adminID, model.ContentEntityLesson, lesson.ID, model.ContentActionUpdate, before, lesson)
+	return nil
 }
 
-func (svc *ContentService) MarkAnimationUploaded(lessonID string) error {
-	lesson, err := svc.sqlSvc.contentRepo.GetLesson(lessonID)
+func (svc *ContentService) MarkAnimationUploaded(adminID, lessonID string) error {
+	lesson, err := svc.contentRepo.GetLesson(lessonID)
 	if err != nil {
 		return err
 	}
+	before := *lesson
 
 	if lesson.AudioStatus != "uploaded" && lesson.AudioStatus != "approved" {
 		return fmt.Errorf("cannot upload animation: audio must be uploaded first")
@@ -707,9 +1164,409 @@ func (svc *ContentService) MarkAnimationUploaded(lessonID string) error {
 	lesson.AnimationStatus = "uploaded"
 	lesson.AnimationUploadedAt = &now
 
-	return svc.sqlSvc.contentRepo.UpdateLesson(lesson)
+	if err := svc.contentRepo.UpdateLesson(lesson); err != nil {
+		return err
+	}
+
+	svc.RecordContentAudit(adminID, model.ContentEntityLesson, lesson.ID, model.ContentActionUpdate, before, lesson)
+	return nil
+}
+
+// ==================== QUESTION BULK EDIT ====================
+
+// ExportLessonQuestions renders a lesson's questions as a CSV or XLSX sheet and
+// returns it together with a download file name
+func (svc *ContentService) ExportLessonQuestions(lessonID, format string) ([]byte, string, error) {
+	lesson, err := svc.contentRepo.GetLesson(lessonID)
+	if err != nil {
+		return nil, "", shared.NewNotFoundError(err, "Lesson not found")
+	}
+
+	var questions []model.Question
+	if len(lesson.Questions) > 0 {
+		if err := json.Unmarshal(lesson.Questions, &questions); err != nil {
+			return nil, "", shared.NewInternalError(err, "Failed to parse lesson questions")
+		}
+	}
+
+	var data []byte
+	switch format {
+	case QuestionSheetXLSX:
+		data, err = writeQuestionsXLSX(questions)
+	default:
+		format = QuestionSheetCSV
+		data, err = writeQuestionsCSV(questions)
+	}
+	if err != nil {
+		return nil, "", shared.NewInternalError(err, "Failed to export questions")
+	}
+
+	name := text.Slug(lesson.Title)
+	if name == "" {
+		name = lesson.ID
+	}
+	return data, fmt.Sprintf("%s-questions.%s", name, format), nil
+}
+
+// maxQuestionSheetSize caps imported question sheets
+const maxQuestionSheetSize = 5 << 20
+
+// ImportLessonQuestions applies edited question sheets to a lesson. Rows are matched
+// to questions by id; only the columns present in the sheet are changed. With preview
+// the diff is returned without saving. Applying requires the base version returned by
+// the preview and replaces all questions in a single update, or none if any row is invalid.
+func (svc *ContentService) ImportLessonQuestions(adminID, lessonID string, file *multipart.FileHeader, preview bool, baseVersion string) (*dto.QuestionImportResponse, error) {
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(file.Filename)), ".")
+	if format != QuestionSheetCSV && format != QuestionSheetXLSX {
+		return nil, shared.NewBadRequestError(fmt.Errorf("unsupported file type %q", format), "Only .csv and .xlsx files can be imported")
+	}
+	if file.Size > maxQuestionSheetSize {
+		return nil, shared.NewBadRequestError(fmt.Errorf("file too large"), "Question sheets must be smaller than 5MB")
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, shared.NewBadRequestError(err, "Could not open the uploaded file")
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return nil, shared.NewBadRequestError(err, "Could not read the uploaded file")
+	}
+
+	lesson, err := svc.contentRepo.GetLesson(lessonID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Lesson not found")
+	}
+	before := *lesson
+
+	var questions []model.Question
+	if len(lesson.Questions) > 0 {
+		if err := json.Unmarshal(lesson.Questions, &questions); err != nil {
+			return nil, shared.NewInternalError(err, "Failed to parse lesson questions")
+		}
+	}
+
+	var rows []questionSheetRow
+	if format == QuestionSheetXLSX {
+		rows, err = readQuestionsXLSX(data)
+	} else {
+		rows, err = readQuestionsCSV(data)
+	}
+	if err != nil {
+		return nil, shared.NewBadRequestError(err, "Could not read the uploaded sheet")
+	}
+
+	result := &dto.QuestionImportResponse{
+		LessonID:    lesson.ID,
+		Preview:     preview,
+		BaseVersion: lesson.QuestionsVersion(),
+	}
+	questions, result.Changed, result.Errors = applyQuestionSheet(questions, rows)
+	result.Unchanged = len(questions) - len(result.Changed)
+
+	if preview || len(result.Errors) > 0 || len(result.Changed) == 0 {
+		return result, nil
+	}
+
+	if baseVersion == "" {
+		return nil, shared.NewBadRequestError(fmt.Errorf("missing base version"), "base_version from the preview is required to apply an import")
+	}
+
+	encoded, err := json.Marshal(questions)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to encode questions")
+	}
+
+	updated, err := svc.contentRepo.UpdateLessonQuestions(lesson.ID, baseVersion, encoded)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to save questions")
+	}
+	if !updated {
+		return nil, shared.NewConflictError(fmt.Errorf("questions changed since preview"),
+			"The lesson's questions changed since the preview. Preview the import again")
+	}
+
+	after, err := svc.contentRepo.GetLesson(lesson.ID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to reload lesson")
+	}
+
+	svc.RecordContentAudit(adminID, model.ContentEntityLesson, lesson.ID, model.ContentActionUpdate, before, after)
+
+	result.Applied = true
+	result.BaseVersion = after.QuestionsVersion()
+	return result, nil
+}
+
+// applyQuestionSheet edits questions in place from sheet rows, returning the edited
+// slice, a per-question diff and any validation errors
+func applyQuestionSheet(questions []model.Question, rows []questionSheetRow) ([]model.Question, []dto.QuestionDiff, []dto.QuestionImportError) {
+	var diffs []dto.QuestionDiff
+	var errs []dto.QuestionImportError
+
+	if len(rows) == 0 {
+		return questions, nil, []dto.QuestionImportError{{Row: 1, Message: "sheet is empty"}}
+	}
+
+	columns := make(map[string]int)
+	for i, name := range rows[0].Values {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["id"]; !ok {
+		return questions, nil, []dto.QuestionImportError{{Row: rows[0].Row, Field: "id", Message: "id column is required"}}
+	}
+	cell := func(row questionSheetRow, column string) (string, bool) {
+		idx, ok := columns[column]
+		if !ok {
+			return "", false
+		}
+		if idx >= len(row.Values) {
+			return "", true
+		}
+		return row.Values[idx], true
+	}
+
+	indexByID := make(map[string]int, len(questions))
+	for i, q := range questions {
+		indexByID[q.ID] = i
+	}
+	seen := make(map[string]int)
+
+	for _, row := range rows[1:] {
+		if strings.TrimSpace(strings.Join(row.Values, "")) == "" {
+			continue
+		}
+		rowErr := func(field, message string) {
+			errs = append(errs, dto.QuestionImportError{Row: row.Row, Field: field, Message: message})
+		}
+
+		id, _ := cell(row, "id")
+		id = strings.TrimSpace(id)
+		if id == "" {
+			rowErr("id", "id is required; adding questions is not supported")
+			continue
+		}
+		if firstRow, ok := seen[id]; ok {
+			rowErr("id", fmt.Sprintf("question %s already edited in row %d", id, firstRow))
+			continue
+		}
+		seen[id] = row.Row
+
+		idx, ok := indexByID[id]
+		if !ok {
+			rowErr("id", fmt.Sprintf("question %s does not exist in this lesson", id))
+			continue
+		}
+		original := questions[idx]
+		edited := original
+		edited.Options = slices.Clone(original.Options)
+		var changes []dto.QuestionFieldChange
+		valid := true
+
+		if value, ok := cell(row, "type"); ok && strings.TrimSpace(value) != "" && strings.TrimSpace(value) != original.Type {
+			rowErr("type", "question type cannot be changed")
+			valid = false
+		}
+
+		if value, ok := cell(row, "question"); ok {
+			value = strings.TrimSpace(value)
+			switch {
+			case value == "":
+				rowErr("question", "question text is required")
+				valid = false
+			case utf8.RuneCountInString(value) > 1000:
+				rowErr("question", "question text must be at most 1000 characters")
+				valid = false
+			case value != original.Question:
+				edited.Question = value
+				changes = append(changes, dto.QuestionFieldChange{Field: "question", Before: original.Question, After: value})
+			}
+		}
+
+		if value, ok := cell(row, "options"); ok {
+			options := splitSheetOptions(value)
+			for _, option := range options {
+				if utf8.RuneCountInString(option) > 200 {
+					rowErr("options", "each option must be at most 200 characters")
+					valid = false
+					break
+				}
+			}
+			if valid && !slices.Equal(options, original.Options) {
+				edited.Options = options
+				changes = append(changes, dto.QuestionFieldChange{Field: "options", Before: original.Options, After: options})
+			}
+		}
+
+		if value, ok := cell(row, "answer"); ok {
+			answer, err := decodeSheetAnswer(original.Type, value)
+			switch {
+			case err != nil:
+				rowErr("answer", err.Error())
+				valid = false
+			case answer == "" || answer == nil:
+				rowErr("answer", "answer is required")
+				valid = false
+			case !reflect.DeepEqual(answer, original.Answer):
+				edited.Answer = answer
+				changes = append(changes, dto.QuestionFieldChange{Field: "answer", Before: original.Answer, After: answer})
+			}
+		}
+
+		if value, ok := cell(row, "points"); ok {
+			points, err := strconv.Atoi(strings.TrimSpace(value))
+			switch {
+			case err != nil || points < 1 || points > 100:
+				rowErr("points", "points must be a whole number between 1 and 100")
+				valid = false
+			case points != original.Points:
+				edited.Points = points
+				changes = append(changes, dto.QuestionFieldChange{Field: "points", Before: original.Points, After: points})
+			}
+		}
+
+		// A multiple choice answer has to be one of the options after all edits
+		if valid && edited.Type == "multiple_choice" && len(edited.Options) > 0 {
+			answer, _ := edited.Answer.(string)
+			if !slices.ContainsFunc(edited.Options, func(option string) bool {
+				return text.Match(option, answer, text.MatchOptions{})
+			}) {
+				rowErr("answer", "answer must match one of the options")
+				valid = false
+			}
+		}
+
+		if valid && len(changes) > 0 {
+			for _, metadataErr := range edited.ValidateMetadata() {
+				rowErr(metadataErr.Path, metadataErr.Message)
+				valid = false
+			}
+		}
+
+		if valid && len(changes) > 0 {
+			questions[idx] = edited
+			diffs = append(diffs, dto.QuestionDiff{QuestionID: id, Row: row.Row, Changes: changes})
+		}
+	}
+
+	return questions, diffs, errs
+}
+
+// ==================== CONTENT AUDIT METHODS ====================
+
+// RecordContentAudit stores a before/after snapshot of an admin content change.
+// Failures are logged rather than returned so auditing never blocks the edit itself.
+func (svc *ContentService) RecordContentAudit(adminID, entityType, entityID, action string, before, after interface{}) {
+	switch entityType {
+	case model.ContentEntityCharacter, model.ContentEntityLesson:
+		svc.suggest.invalidate()
+		svc.public.invalidate()
+	case model.ContentEntityTimeline:
+		svc.public.invalidate()
+	case model.ContentEntityDynasty:
+		// Renames are carried over to characters and timeline entries
+		svc.eras.invalidate()
+		svc.suggest.invalidate()
+		svc.public.invalidate()
+	case model.ContentEntityEra:
+		svc.eras.invalidate()
+	}
+
+	auditLog := &model.ContentAuditLog{
+		AdminID:    adminID,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Action:     action,
+	}
+
+	var err error
+	if before != nil {
+		if auditLog.Before, err = json.Marshal(before); err != nil {
+			log.Printf("Failed to marshal audit snapshot for %s %s: %v", entityType, entityID, err)
+		}
+	}
+	if after != nil {
+		if auditLog.After, err = json.Marshal(after); err != nil {
+			log.Printf("Failed to marshal audit snapshot for %s %s: %v", entityType, entityID, err)
+		}
+	}
+
+	if changed := changedContentFields(auditLog.Before, auditLog.After); len(changed) > 0 {
+		auditLog.ChangedFields, _ = json.Marshal(changed)
+	}
+
+	if err := svc.contentRepo.CreateContentAuditLog(auditLog); err != nil {
+		log.Printf("Failed to record content audit log for %s %s: %v", entityType, entityID, err)
+	}
+}
+
+// changedContentFields lists the top-level JSON fields that differ between two snapshots
+func changedContentFields(before, after json.RawMessage) []string {
+	var beforeFields, afterFields map[string]interface{}
+	if len(before) > 0 {
+		_ = json.Unmarshal(before, &beforeFields)
+	}
+	if len(after) > 0 {
+		_ = json.Unmarshal(after, &afterFields)
+	}
+
+	keys := make(map[string]bool)
+	for key := range beforeFields {
+		keys[key] = true
+	}
+	for key := range afterFields {
+		keys[key] = true
+	}
+
+	changed := make([]string, 0)
+	for key := range keys {
+		if key == "updated_at" {
+			continue
+		}
+		if !reflect.DeepEqual(beforeFields[key], afterFields[key]) {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+func (svc *ContentService) GetContentAuditLogs(entityType, entityID, adminID string, page, limit int) (*dto.ContentAuditLogListResponse, error) {
+	logs, total, err := svc.contentRepo.GetContentAuditLogs(entityType, entityID, adminID, page, limit)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get content audit logs")
+	}
+
+	responses := make([]dto.ContentAuditLogResponse, len(logs))
+	for i, entry := range logs {
+		var changedFields []string
+		if len(entry.ChangedFields) > 0 {
+			_ = json.Unmarshal(entry.ChangedFields, &changedFields)
+		}
+
+		responses[i] = dto.ContentAuditLogResponse{
+			ID:            entry.ID,
+			AdminID:       entry.AdminID,
+			EntityType:    entry.EntityType,
+			EntityID:      entry.EntityID,
+			Action:        entry.Action,
+			Before:        entry.Before,
+			After:         entry.After,
+			ChangedFields: changedFields,
+			CreatedAt:     entry.CreatedAt,
+		}
+	}
+
+	return &dto.ContentAuditLogListResponse{
+		Logs:  responses,
+		Total: int(total),
+		Page:  page,
+		Limit: limit,
+	}, nil
 }
 
 func (svc *ContentService) GetProgress(sessionID string) (*model.GuestProgress, error) {
-	return svc.sqlSvc.contentRepo.GetProgress(sessionID)
+	return svc.progressRepo.GetProgress(sessionID)
 }
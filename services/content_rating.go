@@ -0,0 +1,85 @@
+package services
+
+import (
+	"slices"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	log "github.com/sirupsen/logrus"
+)
+
+// viewerAgeLimit returns the content age limit of the user viewing content. Anonymous
+// viewers, and users that can't be loaded, only see content rated for all ages.
+func (svc *ContentService) viewerAgeLimit(userID string) int {
+	if userID == "" {
+		return 0
+	}
+
+	user, err := svc.userRepo.GetUserByID(userID)
+	if err != nil {
+		log.WithError(err).Warnf("Failed to load user %s for content rating, using all ages", userID)
+		return 0
+	}
+	return user.ContentAgeLimit(time.Now())
+}
+
+// allowedContentRatings lists the ratings visible under an age limit
+func allowedContentRatings(ageLimit int) []string {
+	var ratings []string
+	for _, rating := range model.ContentRatings {
+		if model.ContentRatingMinAge(rating) <= ageLimit {
+			ratings = append(ratings, rating)
+		}
+	}
+	return ratings
+}
+
+// UpdateLessonContentRating sets the minimum age a lesson is suitable for
+func (svc *ContentService) UpdateLessonContentRating(adminID, lessonID, rating string) (*model.Lesson, error) {
+	if !slices.Contains(model.ContentRatings, rating) {
+		return nil, shared.NewBadRequestError(nil, "Invalid content rating")
+	}
+
+	lesson, err := svc.contentRepo.GetLesson(lessonID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Lesson not found")
+	}
+	before := *lesson
+
+	lesson.ContentRating = rating
+	if err := svc.contentRepo.UpdateLesson(lesson); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to update content rating")
+	}
+
+	svc.RecordContentAudit(adminID, model.ContentEntityLesson, lesson.ID, model.ContentActionUpdate, before, lesson)
+	return lesson, nil
+}
+
+// GetUnratedLessons reports active lessons that still need a content rating. Until they
+// get one they are shown to every age.
+func (svc *ContentService) GetUnratedLessons(page, limit int) (*dto.UnratedLessonListResponse, error) {
+	lessons, total, err := svc.contentRepo.GetUnratedLessons(page, limit)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to load unrated lessons")
+	}
+
+	items := make([]dto.UnratedLessonItem, len(lessons))
+	for i, lesson := range lessons {
+		items[i] = dto.UnratedLessonItem{
+			LessonID:      lesson.ID,
+			CharacterID:   lesson.CharacterID,
+			CharacterName: lesson.Character.Name,
+			Title:         lesson.Title,
+			CreatedAt:     lesson.CreatedAt,
+		}
+	}
+
+	return &dto.UnratedLessonListResponse{
+		Lessons: items,
+		Total:   int(total),
+		Page:    page,
+		Limit:   limit,
+	}, nil
+}
@@ -0,0 +1,278 @@
+package services
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	"github.com/lac-hong-legacy/ven_api/shared/ids"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	// Cohorts larger than this must be narrowed before they can be adjusted
+	maxEconomyCohortSize = 10000
+	// Per-user results returned from an adjustment, the ledgers hold the rest
+	maxEconomyResults = 100
+)
+
+// AdjustEconomy grants or revokes hearts, XP or a character for a list of users or a
+// cohort. Every change is written to the matching ledger with the reason code, the
+// admin's ID and a shared batch ID. With DryRun the outcome is computed but not saved.
+func (svc *UserService) AdjustEconomy(adminID string, req dto.AdminEconomyAdjustRequest) (*dto.AdminEconomyAdjustResponse, error) {
+	if (len(req.UserIDs) == 0) == (req.Cohort == nil) {
+		return nil, shared.NewBadRequestError(errors.New("invalid target"), "Provide exactly one of user_ids or cohort")
+	}
+	if req.Resource == "item" {
+		if req.ItemID == "" {
+			return nil, shared.NewBadRequestError(errors.New("missing item"), "item_id is required for item adjustments")
+		}
+		if _, err := svc.contentRepo.GetCharacter(req.ItemID); err != nil {
+			return nil, shared.NewNotFoundError(err, "Character not found")
+		}
+	} else if req.Amount == 0 {
+		return nil, shared.NewBadRequestError(errors.New("missing amount"), "amount is required for hearts and xp adjustments")
+	}
+
+	userIDs := req.UserIDs
+	if req.Cohort != nil {
+		var err error
+		userIDs, err = svc.userRepo.FindCohortUserIDs(*req.Cohort, maxEconomyCohortSize+1)
+		if err != nil {
+			return nil, shared.NewInternalError(err, "Failed to resolve cohort")
+		}
+		if len(userIDs) > maxEconomyCohortSize {
+			return nil, shared.NewBadRequestError(errors.New("cohort too large"), "Cohort matches too many users, narrow the filter").WithData(map[string]interface{}{
+				"max_users": maxEconomyCohortSize,
+			})
+		}
+	} else {
+		slices.Sort(userIDs)
+		userIDs = slices.Compact(userIDs)
+	}
+
+	resp := &dto.AdminEconomyAdjustResponse{
+		BatchID:  ids.New(),
+		Resource: req.Resource,
+		Action:   req.Action,
+		DryRun:   req.DryRun,
+		Matched:  len(userIDs),
+		Results:  []dto.AdminEconomyAdjustResult{},
+	}
+
+	for _, userID := range userIDs {
+		result, err := svc.adjustUserEconomy(adminID, resp.BatchID, userID, req)
+		if err != nil {
+			log.WithError(err).WithField("user_id", userID).Error("Failed to apply economy adjustment")
+			result = dto.AdminEconomyAdjustResult{UserID: userID, Skipped: "failed to apply"}
+		}
+		if !req.DryRun {
+			svc.publishProgressChanged(userID, "admin_adjustment")
+		}
+
+		if result.Skipped != "" {
+			resp.Skipped++
+		} else {
+			resp.Applied++
+		}
+		if len(resp.Results) < maxEconomyResults {
+			resp.Results = append(resp.Results, result)
+		}
+	}
+
+	if !req.DryRun {
+		log.Printf("Admin %s applied %s %s to %d user(s), batch %s (%s)", adminID, req.Resource, req.Action, resp.Applied, resp.BatchID, req.ReasonCode)
+	}
+	return resp, nil
+}
+
+func (svc *UserService) adjustUserEconomy(adminID, batchID, userID string, req dto.AdminEconomyAdjustRequest) (dto.AdminEconomyAdjustResult, error) {
+	result := dto.AdminEconomyAdjustResult{UserID: userID}
+
+	if req.Resource == "item" {
+		return svc.adjustUserItem(adminID, batchID, userID, req)
+	}
+
+	amount := req.Amount
+	if req.Action == model.ItemActionRevoke {
+		amount = -amount
+	}
+
+	// The balance is read and changed under the progress row lock, so concurrent
+	// purchases and rewards are neither lost nor double counted
+	var modify func(progress *model.UserProgress) (*model.ProgressChange, error)
+	switch req.Resource {
+	case "hearts":
+		modify = func(progress *model.UserProgress) (*model.ProgressChange, error) {
+			hearts := min(max(progress.Hearts+amount, 0), progress.MaxHearts)
+			result.Delta = hearts - progress.Hearts
+			result.BalanceAfter = hearts
+			if result.Delta == 0 {
+				result.Skipped = "hearts already at limit"
+				return nil, nil
+			}
+			if req.DryRun {
+				return nil, nil
+			}
+
+			progress.Hearts = hearts
+			return &model.ProgressChange{Hearts: &model.HeartTransaction{
+				Source:      model.HeartSourceAdmin,
+				Amount:      result.Delta,
+				ReferenceID: batchID,
+				ReasonCode:  req.ReasonCode,
+				ActorID:     adminID,
+				Note:        req.Note,
+			}}, nil
+		}
+
+	case "xp":
+		modify = func(progress *model.UserProgress) (*model.ProgressChange, error) {
+			xp := max(progress.XP+amount, 0)
+			result.Delta = xp - progress.XP
+			result.BalanceAfter = xp
+			if result.Delta == 0 {
+				result.Skipped = "no XP to revoke"
+				return nil, nil
+			}
+			if req.DryRun {
+				return nil, nil
+			}
+
+			progress.XP = xp
+			progress.Level = svc.calculateLevel(xp)
+			return &model.ProgressChange{XP: &model.XPTransaction{
+				Source:      model.XPSourceAdmin,
+				Amount:      result.Delta,
+				ReferenceID: batchID,
+				ReasonCode:  req.ReasonCode,
+				ActorID:     adminID,
+				Note:        req.Note,
+			}}, nil
+		}
+
+	default:
+		return result, errors.New("unknown resource")
+	}
+
+	if _, err := svc.progressRepo.ModifyUserProgress(userID, modify); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			result.Skipped = "user progress not found"
+			return result, nil
+		}
+		return result, err
+	}
+
+	// Revocations leave the spirit's stage alone rather than devolving it
+	if req.Resource == "xp" && result.Delta > 0 && result.Skipped == "" && !req.DryRun {
+		if err := svc.updateSpiritXP(userID, result.Delta); err != nil {
+			log.WithError(err).WithField("user_id", userID).Warn("Failed to credit spirit XP for admin grant")
+		}
+	}
+	return result, nil
+}
+
+func (svc *UserService) adjustUserItem(adminID, batchID, userID string, req dto.AdminEconomyAdjustRequest) (dto.AdminEconomyAdjustResult, error) {
+	result := dto.AdminEconomyAdjustResult{UserID: userID}
+
+	owned, err := svc.progressRepo.HasUserCharacter(userID, req.ItemID)
+	if err != nil {
+		return result, err
+	}
+	if req.Action == model.ItemActionGrant && owned {
+		result.Skipped = "already owns item"
+		return result, nil
+	}
+	if req.Action == model.ItemActionRevoke && !owned {
+		result.Skipped = "does not own item"
+		return result, nil
+	}
+
+	result.Delta = 1
+	if req.Action == model.ItemActionRevoke {
+		result.Delta = -1
+	}
+	if req.DryRun {
+		return result, nil
+	}
+
+	txn := &model.ItemTransaction{
+		UserID:     userID,
+		ItemType:   model.ItemTypeCharacter,
+		ItemID:     req.ItemID,
+		Action:     req.Action,
+		BatchID:    batchID,
+		ReasonCode: req.ReasonCode,
+		ActorID:    adminID,
+		Note:       req.Note,
+	}
+
+	var changed bool
+	if req.Action == model.ItemActionGrant {
+		changed, err = svc.progressRepo.GrantUserItem(txn)
+	} else {
+		changed, err = svc.progressRepo.RevokeUserItem(txn)
+	}
+	if err == nil && !changed {
+		// Lost a race with another grant or revoke
+		result.Delta = 0
+		result.Skipped = "item already in requested state"
+	}
+	return result, err
+}
+
+// GetHeartLedger returns a user's heart history, newest first
+func (svc *UserService) GetHeartLedger(userID string, page, limit int) (*dto.HeartLedgerResponse, error) {
+	progress, err := svc.progressRepo.GetUserProgress(userID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "User progress not found")
+	}
+
+	txns, total, err := svc.progressRepo.GetHeartTransactions(userID, page, limit)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get heart transactions")
+	}
+
+	responses := make([]dto.HeartTransactionResponse, len(txns))
+	for i, txn := range txns {
+		responses[i] = dto.HeartTransactionResponse{
+			ID:           txn.ID,
+			Source:       txn.Source,
+			Amount:       txn.Amount,
+			BalanceAfter: txn.BalanceAfter,
+			ReferenceID:  txn.ReferenceID,
+			ReasonCode:   txn.ReasonCode,
+			ActorID:      txn.ActorID,
+			Note:         txn.Note,
+			CreatedAt:    txn.CreatedAt,
+		}
+	}
+
+	return &dto.HeartLedgerResponse{
+		UserID:       userID,
+		Hearts:       progress.Hearts,
+		Transactions: responses,
+		Total:        int(total),
+		Page:         page,
+		Limit:        limit,
+	}, nil
+}
+
+// GetItemLedger returns the items admins have granted to or revoked from a user
+func (svc *UserService) GetItemLedger(userID string, page, limit int) (*dto.ItemLedgerResponse, error) {
+	txns, total, err := svc.progressRepo.GetItemTransactions(userID, page, limit)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get item transactions")
+	}
+
+	return &dto.ItemLedgerResponse{
+		UserID:       userID,
+		Transactions: txns,
+		Total:        int(total),
+		Page:         page,
+		Limit:        limit,
+	}, nil
+}
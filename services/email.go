@@ -5,13 +5,23 @@ import (
 	"fmt"
 	"html/template"
 	"net/smtp"
+	"net/url"
 	"os"
+	"strings"
 
 	"github.com/cloakd/common/context"
 	serviceContext "github.com/cloakd/common/services"
 	log "github.com/sirupsen/logrus"
 )
 
+// Pages opened by the action links in security emails, under /api/v1. They only ask for
+// confirmation and post the token back, since mail scanners follow links on their own.
+const (
+	emailChangeRevertPath     = "/email-change/revert"
+	accountRecoveryCancelPath = "/account-recovery/cancel"
+	deviceActionPath          = "/devices/action"
+)
+
 type EmailService struct {
 	serviceContext.DefaultService
 
@@ -105,6 +115,12 @@ const verificationEmailHTML = `
             </div>
             
             <p>Enter this code in the verification form to activate your account.</p>
+            {{if .VerificationLink}}
+            <p style="text-align: center;">
+                <a href="{{.VerificationLink}}" style="display: inline-block; background-color: #4F46E5; color: white; padding: 12px 24px; border-radius: 6px; text-decoration: none; font-weight: bold;">Verify in the app</a>
+            </p>
+            <p style="font-size: 14px; color: #666;">Or open this email on the phone where {{.AppName}} is installed and tap the button to verify in one step.</p>
+            {{end}}
             <p>If you didn't create an account with {{.AppName}}, you can safely ignore this email.</p>
         </div>
         <div class="footer">
@@ -190,13 +206,25 @@ const loginNotificationEmailHTML = `
                 <strong>Login Details:</strong><br>
                 <strong>Time:</strong> {{.LoginTime}}<br>
                 <strong>IP Address:</strong> {{.IP}}<br>
-                <strong>Device:</strong> {{.Device}}<br>
+                <strong>Device:</strong> {{.Device}}{{if .NewDevice}} (first sign-in from this device){{end}}<br>
                 <strong>Location:</strong> {{.Location}}
             </div>
+            {{if .EvictedDevices}}
+            <div class="info-box">
+                <strong>Signed out to stay within your device limit:</strong>
+                <ul>
+                    {{range .EvictedDevices}}<li>{{.}}</li>{{end}}
+                </ul>
+            </div>
+            {{end}}
             
             <div class="info-box">
                 <strong>Was this you?</strong> If you recognize this login, no action is needed.
+                {{if .TrustURL}}<br><a href="{{.TrustURL}}">Trust this device</a> to stop these emails when signing in from it.{{end}}
             </div>
+            {{if .UntrustURL}}
+            <p><a href="{{.UntrustURL}}">Not you? Untrust and sign out this device</a></p>
+            {{end}}
             
             <p>If you don't recognize this login, please:</p>
             <ul>
@@ -213,11 +241,316 @@ const loginNotificationEmailHTML = `
 </html>
 `
 
+const emailChangeVerificationHTML = `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>Confirm Your New Email - {{.AppName}}</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #4F46E5; color: white; padding: 20px; text-align: center; }
+        .content { padding: 20px; background-color: #f9f9f9; }
+        .code-box { background-color: #fff; border: 2px dashed #4F46E5; border-radius: 8px; padding: 20px; text-align: center; margin: 20px 0; }
+        .verification-code { font-size: 32px; font-weight: bold; letter-spacing: 8px; color: #4F46E5; font-family: 'Courier New', monospace; }
+        .footer { padding: 20px; text-align: center; color: #666; font-size: 12px; }
+        .warning { background-color: #FEF2F2; border-left: 4px solid #DC2626; padding: 10px; margin: 20px 0; font-size: 14px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>Confirm Your New Email</h1>
+        </div>
+        <div class="content">
+            <h2>Hi {{.Username}},</h2>
+            <p>You asked to use this address for your {{.AppName}} account. Enter the code below to confirm the change:</p>
+
+            <div class="code-box">
+                <p style="margin: 0 0 10px 0; font-size: 14px; color: #666;">Your Confirmation Code</p>
+                <div class="verification-code">{{.VerificationCode}}</div>
+            </div>
+
+            <div class="warning">
+                <strong>⏰ Important:</strong> This code will expire in 30 minutes. Your old address stays active until you confirm.
+            </div>
+
+            <p>If you didn't request this change, you can safely ignore this email.</p>
+        </div>
+        <div class="footer">
+            <p>&copy; 2025 {{.AppName}}. All rights reserved.</p>
+        </div>
+    </div>
+</body>
+</html>
+`
+
+const emailChangeNoticeHTML = `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>Email Change Requested - {{.AppName}}</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #DC2626; color: white; padding: 20px; text-align: center; }
+        .content { padding: 20px; background-color: #f9f9f9; }
+        .button { display: inline-block; background-color: #DC2626; color: white; padding: 12px 24px; border-radius: 6px; text-decoration: none; font-weight: bold; }
+        .footer { padding: 20px; text-align: center; color: #666; font-size: 12px; }
+        .warning { background-color: #FEF2F2; border-left: 4px solid #DC2626; padding: 10px; margin: 20px 0; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>Email Change Requested</h1>
+        </div>
+        <div class="content">
+            <h2>Hi {{.Username}},</h2>
+            <p>Someone asked to change the email address of your {{.AppName}} account to <strong>{{.NewEmail}}</strong>.</p>
+            <p>If this was you, there is nothing to do. If it wasn't, undo the change and sign out every device:</p>
+
+            <p style="text-align: center;"><a class="button" href="{{.RevertURL}}">This wasn't me</a></p>
+
+            <div class="warning">
+                <strong>⏰ Important:</strong> This link works for 7 days, even after the new address is confirmed.
+            </div>
+        </div>
+        <div class="footer">
+            <p>&copy; 2025 {{.AppName}}. All rights reserved.</p>
+        </div>
+    </div>
+</body>
+</html>
+`
+
+const accountRecoveryNoticeHTML = `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>Account Recovery Requested - {{.AppName}}</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #DC2626; color: white; padding: 20px; text-align: center; }
+        .content { padding: 20px; background-color: #f9f9f9; }
+        .button { display: inline-block; background-color: #DC2626; color: white; padding: 12px 24px; border-radius: 6px; text-decoration: none; font-weight: bold; }
+        .footer { padding: 20px; text-align: center; color: #666; font-size: 12px; }
+        .warning { background-color: #FEF2F2; border-left: 4px solid #DC2626; padding: 10px; margin: 20px 0; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>Account Recovery Requested</h1>
+        </div>
+        <div class="content">
+            <h2>Hi {{.Username}},</h2>
+            <p>Someone at {{.RequestIP}} asked to recover your {{.AppName}} account and move it to <strong>{{.NewEmail}}</strong>.</p>
+            <p>A trusted device can approve or deny this from the notifications in the app. If nobody answers, the recovery goes through on <strong>{{.FallbackAt}}</strong>.</p>
+            <p>If this wasn't you, stop it now:</p>
+
+            <p style="text-align: center;"><a class="button" href="{{.CancelURL}}">This wasn't me</a></p>
+
+            <div class="warning">
+                <strong>⏰ Important:</strong> Recovery replaces your email address and password and signs out every device.
+            </div>
+        </div>
+        <div class="footer">
+            <p>&copy; 2025 {{.AppName}}. All rights reserved.</p>
+        </div>
+    </div>
+</body>
+</html>
+`
+
+const securityDigestHTML = `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>Your Weekly Security Summary - {{.AppName}}</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #4F46E5; color: white; padding: 20px; text-align: center; }
+        .content { padding: 20px; background-color: #f9f9f9; }
+        .footer { padding: 20px; text-align: center; color: #666; font-size: 12px; }
+        table { width: 100%; border-collapse: collapse; font-size: 14px; }
+        th, td { text-align: left; padding: 6px; border-bottom: 1px solid #e5e7eb; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>Weekly Security Summary</h1>
+        </div>
+        <div class="content">
+            <h2>Hi {{.Username}},</h2>
+            <p>Here is the security activity on your {{.AppName}} account from {{.PeriodStart}} to {{.PeriodEnd}}.</p>
+            <table>
+                <tr><th>Time</th><th>Event</th><th>IP</th></tr>
+                {{range .Events}}
+                <tr><td>{{.Time}}</td><td>{{.Event}}{{if .Details}}<br><small>{{.Details}}</small>{{end}}</td><td>{{.IP}}</td></tr>
+                {{end}}
+            </table>
+            {{if .MoreEvents}}<p>…and {{.MoreEvents}} more. See the security log in the app for everything.</p>{{end}}
+            <p>If you don't recognise something, change your password and sign out all devices.</p>
+        </div>
+        <div class="footer">
+            <p>You get this summary because you turned on the weekly security digest.</p>
+            <p>&copy; 2025 {{.AppName}}. All rights reserved.</p>
+        </div>
+    </div>
+</body>
+</html>
+`
+
+const backupCodesLowHTML = `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>Running Out Of Backup Codes - {{.AppName}}</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #D97706; color: white; padding: 20px; text-align: center; }
+        .content { padding: 20px; background-color: #f9f9f9; }
+        .footer { padding: 20px; text-align: center; color: #666; font-size: 12px; }
+        .warning { background-color: #FFFBEB; border-left: 4px solid #D97706; padding: 10px; margin: 20px 0; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>Running Out Of Backup Codes</h1>
+        </div>
+        <div class="content">
+            <h2>Hi {{.Username}},</h2>
+            <p>A backup code was just used to sign in to your {{.AppName}} account from {{.IP}}.</p>
+            {{if .Remaining}}<p>You have <strong>{{.Remaining}}</strong> backup codes left.</p>{{else}}<p>That was your <strong>last</strong> backup code.</p>{{end}}
+            <p>Create new backup codes in the security settings of the app and keep them somewhere safe.</p>
+
+            <div class="warning">
+                <strong>⚠️ Not you?</strong> Change your password and sign out all devices right away.
+            </div>
+        </div>
+        <div class="footer">
+            <p>&copy; 2025 {{.AppName}}. All rights reserved.</p>
+        </div>
+    </div>
+</body>
+</html>
+`
+
+const studyReminderHTML = `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>Time To Study - {{.AppName}}</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #B71C1C; color: white; padding: 20px; text-align: center; }
+        .content { padding: 20px; background-color: #f9f9f9; }
+        .footer { padding: 20px; text-align: center; color: #666; font-size: 12px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>Time To Study</h1>
+        </div>
+        <div class="content">
+            <h2>Hi {{.Username}},</h2>
+            {{if gt .Streak 1}}<p>You're on a <strong>{{.Streak}} day</strong> streak. One lesson today keeps it going.</p>{{else}}<p>A few minutes of history a day adds up. Your next lesson is waiting.</p>{{end}}
+            <p>You get this email because you scheduled study reminders. Change or turn them off in the app settings.</p>
+        </div>
+        <div class="footer">
+            <p>&copy; 2025 {{.AppName}}. All rights reserved.</p>
+        </div>
+    </div>
+</body>
+</html>
+`
+
+const inactivityWarningHTML = `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>We Miss You - {{.AppName}}</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #B71C1C; color: white; padding: 20px; text-align: center; }
+        .content { padding: 20px; background-color: #f9f9f9; }
+        .footer { padding: 20px; text-align: center; color: #666; font-size: 12px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>We Miss You</h1>
+        </div>
+        <div class="content">
+            <h2>Hi {{.Username}},</h2>
+            <p>You haven't used your {{.AppName}} account in a long time. To protect your privacy, we remove personal details from accounts that stay inactive.</p>
+            <p>Unless you log in before <strong>{{.AnonymizeOn}}</strong>, your email address, username and login details will be deleted. Your learning statistics are kept without anything that identifies you, and you will no longer be able to log in to this account.</p>
+            <p>Logging in once is enough to keep your account.</p>
+        </div>
+        <div class="footer">
+            <p>&copy; 2025 {{.AppName}}. All rights reserved.</p>
+        </div>
+    </div>
+</body>
+</html>
+`
+
+const mediaProcessingAlertHTML = `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>Media Processing Failed - {{.AppName}}</title>
+    <style>
+        body { font-family: Arial, sans-serif; line-height: 1.6; color: #333; }
+        .container { max-width: 600px; margin: 0 auto; padding: 20px; }
+        .header { background-color: #DC2626; color: white; padding: 20px; text-align: center; }
+        .content { padding: 20px; background-color: #f9f9f9; }
+        .footer { padding: 20px; text-align: center; color: #666; font-size: 12px; }
+        .warning { background-color: #FEF2F2; border-left: 4px solid #DC2626; padding: 10px; margin: 20px 0; font-family: monospace; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>Media Processing Failed</h1>
+        </div>
+        <div class="content">
+            <p><strong>{{.FileName}}</strong> ({{.FileType}}, asset {{.AssetID}}) failed to process {{.Attempts}} times.</p>
+            <div class="warning">{{.Error}}</div>
+            <p>Retry it from the failed media list in the admin console once the cause is fixed.</p>
+        </div>
+        <div class="footer">
+            <p>&copy; 2025 {{.AppName}}. All rights reserved.</p>
+        </div>
+    </div>
+</body>
+</html>
+`
+
 // Template data structures
 type VerificationEmailData struct {
 	AppName          string
 	Username         string
 	VerificationCode string
+	VerificationLink template.URL // app deep link, html/template would otherwise strip the ven:// scheme
 }
 
 type PasswordResetEmailData struct {
@@ -227,12 +560,76 @@ type PasswordResetEmailData struct {
 }
 
 type LoginNotificationEmailData struct {
+	AppName        string
+	Username       string
+	LoginTime      string
+	IP             string
+	Device         string
+	NewDevice      bool
+	Location       string
+	EvictedDevices []string
+	TrustURL       string
+	UntrustURL     string
+}
+
+type EmailChangeNoticeEmailData struct {
+	AppName   string
+	Username  string
+	NewEmail  string
+	RevertURL string
+}
+
+type AccountRecoveryNoticeEmailData struct {
+	AppName    string
+	Username   string
+	NewEmail   string
+	RequestIP  string
+	FallbackAt string
+	CancelURL  string
+}
+
+type SecurityDigestEvent struct {
+	Time    string
+	Event   string
+	IP      string
+	Details string
+}
+
+type SecurityDigestEmailData struct {
+	AppName     string
+	Username    string
+	PeriodStart string
+	PeriodEnd   string
+	Events      []SecurityDigestEvent
+	MoreEvents  int
+}
+
+type BackupCodesLowEmailData struct {
 	AppName   string
 	Username  string
-	LoginTime string
 	IP        string
-	Device    string
-	Location  string
+	Remaining int
+}
+
+type StudyReminderEmailData struct {
+	AppName  string
+	Username string
+	Streak   int
+}
+
+type InactivityWarningEmailData struct {
+	AppName     string
+	Username    string
+	AnonymizeOn string
+}
+
+type MediaProcessingAlertEmailData struct {
+	AppName  string
+	AssetID  string
+	FileName string
+	FileType string
+	Attempts int
+	Error    string
 }
 
 func (svc *EmailService) loadTemplates() error {
@@ -253,10 +650,50 @@ func (svc *EmailService) loadTemplates() error {
 		return fmt.Errorf("failed to parse login notification email template: %v", err)
 	}
 
+	svc.templates["email_change_verification"], err = template.New("email_change_verification").Parse(emailChangeVerificationHTML)
+	if err != nil {
+		return fmt.Errorf("failed to parse email change verification template: %v", err)
+	}
+
+	svc.templates["email_change_notice"], err = template.New("email_change_notice").Parse(emailChangeNoticeHTML)
+	if err != nil {
+		return fmt.Errorf("failed to parse email change notice template: %v", err)
+	}
+
+	svc.templates["account_recovery_notice"], err = template.New("account_recovery_notice").Parse(accountRecoveryNoticeHTML)
+	if err != nil {
+		return fmt.Errorf("failed to parse account recovery notice template: %v", err)
+	}
+
+	svc.templates["security_digest"], err = template.New("security_digest").Parse(securityDigestHTML)
+	if err != nil {
+		return fmt.Errorf("failed to parse security digest template: %v", err)
+	}
+
+	svc.templates["backup_codes_low"], err = template.New("backup_codes_low").Parse(backupCodesLowHTML)
+	if err != nil {
+		return fmt.Errorf("failed to parse backup codes low template: %v", err)
+	}
+
+	svc.templates["study_reminder"], err = template.New("study_reminder").Parse(studyReminderHTML)
+	if err != nil {
+		return fmt.Errorf("failed to parse study reminder template: %v", err)
+	}
+
+	svc.templates["inactivity_warning"], err = template.New("inactivity_warning").Parse(inactivityWarningHTML)
+	if err != nil {
+		return fmt.Errorf("failed to parse inactivity warning template: %v", err)
+	}
+
+	svc.templates["media_processing_alert"], err = template.New("media_processing_alert").Parse(mediaProcessingAlertHTML)
+	if err != nil {
+		return fmt.Errorf("failed to parse media processing alert template: %v", err)
+	}
+
 	return nil
 }
 
-func (svc *EmailService) SendVerificationEmail(email, username, code string) error {
+func (svc *EmailService) SendVerificationEmail(email, username, code, link string) error {
 	if svc.smtpHost == "" {
 		log.Warn("SMTP not configured, skipping verification email")
 		return nil
@@ -266,6 +703,7 @@ func (svc *EmailService) SendVerificationEmail(email, username, code string) err
 		AppName:          "Ven",
 		Username:         username,
 		VerificationCode: code,
+		VerificationLink: template.URL(link),
 	}
 
 	subject := "Verify Your Email Address - TechYouth"
@@ -288,7 +726,9 @@ func (svc *EmailService) SendPasswordResetEmail(email, username, code string) er
 	return svc.sendTemplateEmail(email, subject, "password_reset", data)
 }
 
-func (svc *EmailService) SendLoginNotificationEmail(email, username, loginTime, ip, device, location string) error {
+// SendLoginNotificationEmail reports a login. Given the tokens, it links to trusting the
+// device and to untrusting and signing it out.
+func (svc *EmailService) SendLoginNotificationEmail(email, username, loginTime, ip, device string, newDevice bool, location string, evictedDevices []string, trustToken, untrustToken string) error {
 	if svc.smtpHost == "" {
 		log.Warn("SMTP not configured, skipping login notification email")
 		return nil
@@ -300,13 +740,162 @@ func (svc *EmailService) SendLoginNotificationEmail(email, username, loginTime,
 		LoginTime: loginTime,
 		IP:        ip,
 		Device:    device,
+		NewDevice: newDevice,
 		Location:  location,
+
+		EvictedDevices: evictedDevices,
+	}
+	if trustToken != "" {
+		data.TrustURL = svc.actionURL(deviceActionPath, trustToken)
+	}
+	if untrustToken != "" {
+		data.UntrustURL = svc.actionURL(deviceActionPath, untrustToken)
 	}
 
 	subject := "New Login Detected - TechYouth"
+	if newDevice {
+		subject = "New Device Signed In - TechYouth"
+	}
 	return svc.sendTemplateEmail(email, subject, "login_notification", data)
 }
 
+func (svc *EmailService) SendEmailChangeVerificationEmail(newEmail, username, code string) error {
+	if svc.smtpHost == "" {
+		log.Warn("SMTP not configured, skipping email change verification email")
+		return nil
+	}
+
+	data := VerificationEmailData{
+		AppName:          "TechYouth",
+		Username:         username,
+		VerificationCode: code,
+	}
+
+	subject := "Confirm Your New Email Address - TechYouth"
+	return svc.sendTemplateEmail(newEmail, subject, "email_change_verification", data)
+}
+
+// SendEmailChangeNoticeEmail warns the old address about an email change and links to the
+// revert page
+func (svc *EmailService) SendEmailChangeNoticeEmail(oldEmail, username, newEmail, revertToken string) error {
+	if svc.smtpHost == "" {
+		log.Warn("SMTP not configured, skipping email change notice email")
+		return nil
+	}
+
+	data := EmailChangeNoticeEmailData{
+		AppName:   "TechYouth",
+		Username:  username,
+		NewEmail:  newEmail,
+		RevertURL: svc.actionURL(emailChangeRevertPath, revertToken),
+	}
+
+	subject := "Your Email Address Is Being Changed - TechYouth"
+	return svc.sendTemplateEmail(oldEmail, subject, "email_change_notice", data)
+}
+
+// actionURL links to the confirmation page of an email action
+func (svc *EmailService) actionURL(path, token string) string {
+	return fmt.Sprintf("%s%s%s%s?token=%s", strings.TrimRight(svc.baseURL, "/"), apiPathPrefix, APIVersion1, path, url.QueryEscape(token))
+}
+
+// SendAccountRecoveryNoticeEmail warns the current address about a recovery request and
+// links to the cancel page
+func (svc *EmailService) SendAccountRecoveryNoticeEmail(email, username, newEmail, requestIP, fallbackAt, cancelToken string) error {
+	if svc.smtpHost == "" {
+		log.Warn("SMTP not configured, skipping account recovery notice email")
+		return nil
+	}
+
+	data := AccountRecoveryNoticeEmailData{
+		AppName:    "TechYouth",
+		Username:   username,
+		NewEmail:   newEmail,
+		RequestIP:  requestIP,
+		FallbackAt: fallbackAt,
+		CancelURL:  svc.actionURL(accountRecoveryCancelPath, cancelToken),
+	}
+
+	subject := "Someone Is Trying To Recover Your Account - TechYouth"
+	return svc.sendTemplateEmail(email, subject, "account_recovery_notice", data)
+}
+
+// SendSecurityDigestEmail sends the weekly summary of a user's security events
+func (svc *EmailService) SendSecurityDigestEmail(email string, data SecurityDigestEmailData) error {
+	if svc.smtpHost == "" {
+		log.Warn("SMTP not configured, skipping security digest email")
+		return nil
+	}
+
+	data.AppName = "TechYouth"
+	subject := "Your Weekly Security Summary - TechYouth"
+	return svc.sendTemplateEmail(email, subject, "security_digest", data)
+}
+
+// SendBackupCodesLowEmail warns a user who just used one of their last 2FA backup codes
+func (svc *EmailService) SendBackupCodesLowEmail(email, username, ip string, remaining int) error {
+	if svc.smtpHost == "" {
+		log.Warn("SMTP not configured, skipping backup codes low email")
+		return nil
+	}
+
+	data := BackupCodesLowEmailData{
+		AppName:   "TechYouth",
+		Username:  username,
+		IP:        ip,
+		Remaining: remaining,
+	}
+
+	subject := "You're Running Out Of Backup Codes - TechYouth"
+	return svc.sendTemplateEmail(email, subject, "backup_codes_low", data)
+}
+
+// SendStudyReminderEmail sends a scheduled study reminder
+func (svc *EmailService) SendStudyReminderEmail(email, username string, streak int) error {
+	if svc.smtpHost == "" {
+		log.Warn("SMTP not configured, skipping study reminder email")
+		return nil
+	}
+
+	data := StudyReminderEmailData{
+		AppName:  "TechYouth",
+		Username: username,
+		Streak:   streak,
+	}
+
+	subject := "Time To Study - TechYouth"
+	return svc.sendTemplateEmail(email, subject, "study_reminder", data)
+}
+
+// SendInactivityWarningEmail warns that an inactive account will be anonymized
+func (svc *EmailService) SendInactivityWarningEmail(email, username, anonymizeOn string) error {
+	if svc.smtpHost == "" {
+		log.Warn("SMTP not configured, skipping inactivity warning email")
+		return nil
+	}
+
+	data := InactivityWarningEmailData{
+		AppName:     "TechYouth",
+		Username:    username,
+		AnonymizeOn: anonymizeOn,
+	}
+
+	subject := "Your Inactive Account Will Be Anonymized - TechYouth"
+	return svc.sendTemplateEmail(email, subject, "inactivity_warning", data)
+}
+
+// SendMediaProcessingAlertEmail tells a content admin that an asset keeps failing to process
+func (svc *EmailService) SendMediaProcessingAlertEmail(email string, data MediaProcessingAlertEmailData) error {
+	if svc.smtpHost == "" {
+		log.Warn("SMTP not configured, skipping media processing alert email")
+		return nil
+	}
+
+	data.AppName = "TechYouth"
+	subject := fmt.Sprintf("Media Processing Failed: %s - TechYouth", data.FileName)
+	return svc.sendTemplateEmail(email, subject, "media_processing_alert", data)
+}
+
 func (svc *EmailService) sendTemplateEmail(to, subject, templateName string, data interface{}) error {
 	tmpl, exists := svc.templates[templateName]
 	if !exists {
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"io"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lac-hong-legacy/ven_api/services/handlers"
+)
+
+// The action links in security emails must open a page the API serves, and that page
+// must post to an endpoint that exists
+func TestEmailActionLinksOpenPages(t *testing.T) {
+	email := &EmailService{baseURL: "https://api.example.com/"}
+	server := &HttpService{authSvc: &AuthService{}, authHandler: handlers.NewAuthHandler(nil, nil, nil)}
+	app := fiber.New()
+	server.setupAuthRoutes(app.Group(apiPathPrefix + APIVersion1))
+
+	posts := map[string]bool{}
+	for _, route := range app.GetRoutes() {
+		if route.Method == fiber.MethodPost {
+			posts[route.Path] = true
+		}
+	}
+
+	for _, path := range []string{emailChangeRevertPath, accountRecoveryCancelPath, deviceActionPath} {
+		link, err := url.Parse(email.actionURL(path, "tok+en"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if link.Host != "api.example.com" || link.Query().Get("token") != "tok+en" {
+			t.Errorf("link %s", link)
+		}
+
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, link.RequestURI(), nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != fiber.StatusOK || !strings.Contains(string(body), `action="`+link.Path+`"`) {
+			t.Errorf("GET %s = %d %s", link.Path, resp.StatusCode, body)
+		}
+		if !posts[link.Path] {
+			t.Errorf("page %s posts to a route that doesn't exist", link.Path)
+		}
+	}
+}
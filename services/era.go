@@ -0,0 +1,325 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// How long an instance serves eras and dynasties from its copy. Admin changes apply at
+// once on the instance that made them.
+const eraCatalogTTL = 10 * time.Minute
+
+var eraCodePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// eraCatalog holds the era and dynasty reference tables in display order
+type eraCatalog struct {
+	mutex     sync.Mutex
+	eras      []model.Era
+	dynasties []model.Dynasty
+	loadedAt  time.Time
+}
+
+func newEraCatalog() *eraCatalog {
+	return &eraCatalog{}
+}
+
+func (c *eraCatalog) invalidate() {
+	c.mutex.Lock()
+	c.loadedAt = time.Time{}
+	c.mutex.Unlock()
+}
+
+// erasAndDynasties returns the cached reference tables, reloading them when older than
+// eraCatalogTTL. If the reload fails the previous copy is kept.
+func (svc *ContentService) erasAndDynasties() ([]model.Era, []model.Dynasty, error) {
+	catalog := svc.eras
+	catalog.mutex.Lock()
+	defer catalog.mutex.Unlock()
+
+	if catalog.eras != nil && time.Since(catalog.loadedAt) < eraCatalogTTL {
+		return catalog.eras, catalog.dynasties, nil
+	}
+
+	eras, err := svc.contentRepo.GetEras()
+	if err == nil {
+		var dynasties []model.Dynasty
+		if dynasties, err = svc.contentRepo.GetDynasties(); err == nil {
+			catalog.eras, catalog.dynasties, catalog.loadedAt = eras, dynasties, time.Now()
+			return eras, dynasties, nil
+		}
+	}
+
+	if catalog.eras != nil {
+		log.Printf("Failed to reload eras and dynasties, serving the previous copy: %v", err)
+		return catalog.eras, catalog.dynasties, nil
+	}
+	return nil, nil, shared.NewInternalError(err, "Failed to load eras and dynasties")
+}
+
+// checkContentReferences refuses an era or dynasty that isn't in the reference tables,
+// and a dynasty filed under another era. Empty values are not checked.
+func (svc *ContentService) checkContentReferences(era, dynasty string) error {
+	if era == "" && dynasty == "" {
+		return nil
+	}
+	eras, dynasties, err := svc.erasAndDynasties()
+	if err != nil {
+		return err
+	}
+
+	if era != "" && findEra(eras, era) == nil {
+		return shared.NewBadRequestError(nil, fmt.Sprintf("Unknown era %q", era))
+	}
+	if dynasty != "" {
+		found := findDynasty(dynasties, dynasty)
+		if found == nil {
+			return shared.NewBadRequestError(nil, fmt.Sprintf("Unknown dynasty %q", dynasty))
+		}
+		if era != "" && found.Era != "" && found.Era != era {
+			return shared.NewBadRequestError(nil, fmt.Sprintf("Dynasty %q belongs to era %q", dynasty, found.Era))
+		}
+	}
+	return nil
+}
+
+func findEra(eras []model.Era, code string) *model.Era {
+	for i := range eras {
+		if eras[i].Code == code {
+			return &eras[i]
+		}
+	}
+	return nil
+}
+
+func findDynasty(dynasties []model.Dynasty, name string) *model.Dynasty {
+	for i := range dynasties {
+		if dynasties[i].Name == name {
+			return &dynasties[i]
+		}
+	}
+	return nil
+}
+
+// ==================== ERA METHODS ====================
+
+// GetEras returns the era codes in display order
+func (svc *ContentService) GetEras() ([]string, error) {
+	eras, _, err := svc.erasAndDynasties()
+	if err != nil {
+		return nil, err
+	}
+	codes := make([]string, len(eras))
+	for i, era := range eras {
+		codes[i] = era.Code
+	}
+	return codes, nil
+}
+
+func (svc *ContentService) GetAllEras() ([]model.Era, error) {
+	eras, err := svc.contentRepo.GetEras()
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get eras")
+	}
+	return eras, nil
+}
+
+func (svc *ContentService) CreateEra(adminID string, req dto.CreateEraRequest) (*model.Era, error) {
+	if !eraCodePattern.MatchString(req.Code) {
+		return nil, shared.NewBadRequestError(nil, "Era code may only contain letters, digits and underscores")
+	}
+	if _, err := svc.contentRepo.GetEra(req.Code); err == nil {
+		return nil, shared.NewConflictError(nil, "An era with this code already exists")
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, shared.NewInternalError(err, "Failed to check era")
+	}
+
+	era := &model.Era{
+		Code:        req.Code,
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		Order:       req.Order,
+	}
+	if err := svc.contentRepo.CreateEra(era); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to create era")
+	}
+
+	svc.RecordContentAudit(adminID, model.ContentEntityEra, era.Code, model.ContentActionCreate, nil, era)
+	return era, nil
+}
+
+func (svc *ContentService) UpdateEra(adminID, code string, req dto.EraRequest) (*model.Era, error) {
+	era, err := svc.getEra(code)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *era
+	era.Name = strings.TrimSpace(req.Name)
+	era.Description = req.Description
+	era.Order = req.Order
+	if err := svc.contentRepo.UpdateEra(era); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to update era")
+	}
+
+	svc.RecordContentAudit(adminID, model.ContentEntityEra, era.Code, model.ContentActionUpdate, before, era)
+	return era, nil
+}
+
+// DeleteEra removes an era nothing is filed under
+func (svc *ContentService) DeleteEra(adminID, code string) error {
+	era, err := svc.getEra(code)
+	if err != nil {
+		return err
+	}
+	references, err := svc.contentRepo.CountEraReferences(code)
+	if err != nil {
+		return shared.NewInternalError(err, "Failed to check era references")
+	}
+	if references > 0 {
+		return shared.NewConflictError(nil, fmt.Sprintf("Era is still used by %d dynasties, characters, timeline entries or glossary terms", references))
+	}
+
+	if err := svc.contentRepo.DeleteEra(code); err != nil {
+		return shared.NewInternalError(err, "Failed to delete era")
+	}
+
+	svc.RecordContentAudit(adminID, model.ContentEntityEra, era.Code, model.ContentActionDelete, era, nil)
+	return nil
+}
+
+func (svc *ContentService) getEra(code string) (*model.Era, error) {
+	era, err := svc.contentRepo.GetEra(code)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, shared.NewNotFoundError(err, "Era not found")
+		}
+		return nil, shared.NewInternalError(err, "Failed to get era")
+	}
+	return era, nil
+}
+
+// ==================== DYNASTY METHODS ====================
+
+// GetDynasties returns the dynasty names in display order
+func (svc *ContentService) GetDynasties() ([]string, error) {
+	_, dynasties, err := svc.erasAndDynasties()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(dynasties))
+	for i, dynasty := range dynasties {
+		names[i] = dynasty.Name
+	}
+	return names, nil
+}
+
+func (svc *ContentService) GetAllDynasties() ([]model.Dynasty, error) {
+	dynasties, err := svc.contentRepo.GetDynasties()
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get dynasties")
+	}
+	return dynasties, nil
+}
+
+func (svc *ContentService) CreateDynasty(adminID string, req dto.DynastyRequest) (*model.Dynasty, error) {
+	dynasty := &model.Dynasty{}
+	if err := svc.applyDynastyRequest(dynasty, req); err != nil {
+		return nil, err
+	}
+	if err := svc.contentRepo.CreateDynasty(dynasty); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to create dynasty")
+	}
+
+	svc.RecordContentAudit(adminID, model.ContentEntityDynasty, dynasty.ID, model.ContentActionCreate, nil, dynasty)
+	return dynasty, nil
+}
+
+// UpdateDynasty changes a dynasty. Renaming it renames it on its characters, timeline
+// entries and certificates too.
+func (svc *ContentService) UpdateDynasty(adminID, dynastyID string, req dto.DynastyRequest) (*model.Dynasty, error) {
+	dynasty, err := svc.getDynasty(dynastyID)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *dynasty
+	if err := svc.applyDynastyRequest(dynasty, req); err != nil {
+		return nil, err
+	}
+	if err := svc.contentRepo.UpdateDynasty(dynasty, before.Name); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to update dynasty")
+	}
+
+	svc.RecordContentAudit(adminID, model.ContentEntityDynasty, dynasty.ID, model.ContentActionUpdate, before, dynasty)
+	return dynasty, nil
+}
+
+// DeleteDynasty removes a dynasty without characters or timeline entries
+func (svc *ContentService) DeleteDynasty(adminID, dynastyID string) error {
+	dynasty, err := svc.getDynasty(dynastyID)
+	if err != nil {
+		return err
+	}
+	references, err := svc.contentRepo.CountDynastyReferences(dynasty.Name)
+	if err != nil {
+		return shared.NewInternalError(err, "Failed to check dynasty references")
+	}
+	if references > 0 {
+		return shared.NewConflictError(nil, fmt.Sprintf("Dynasty is still used by %d characters or timeline entries", references))
+	}
+
+	if err := svc.contentRepo.DeleteDynasty(dynastyID); err != nil {
+		return shared.NewInternalError(err, "Failed to delete dynasty")
+	}
+
+	svc.RecordContentAudit(adminID, model.ContentEntityDynasty, dynasty.ID, model.ContentActionDelete, dynasty, nil)
+	return nil
+}
+
+func (svc *ContentService) getDynasty(dynastyID string) (*model.Dynasty, error) {
+	dynasty, err := svc.contentRepo.GetDynasty(dynastyID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, shared.NewNotFoundError(err, "Dynasty not found")
+		}
+		return nil, shared.NewInternalError(err, "Failed to get dynasty")
+	}
+	return dynasty, nil
+}
+
+// applyDynastyRequest checks the request against the reference tables and copies it
+// onto the dynasty
+func (svc *ContentService) applyDynastyRequest(dynasty *model.Dynasty, req dto.DynastyRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if req.StartYear != nil && req.EndYear != nil && *req.EndYear < *req.StartYear {
+		return shared.NewBadRequestError(nil, "end_year can't be before start_year")
+	}
+
+	eras, dynasties, err := svc.erasAndDynasties()
+	if err != nil {
+		return err
+	}
+	if findEra(eras, req.Era) == nil {
+		return shared.NewBadRequestError(nil, fmt.Sprintf("Unknown era %q", req.Era))
+	}
+	if existing := findDynasty(dynasties, name); existing != nil && existing.ID != dynasty.ID {
+		return shared.NewConflictError(nil, "A dynasty with this name already exists")
+	}
+
+	dynasty.Name = name
+	dynasty.Era = req.Era
+	dynasty.StartYear = req.StartYear
+	dynasty.EndYear = req.EndYear
+	dynasty.Order = req.Order
+	return nil
+}
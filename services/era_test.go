@@ -0,0 +1,54 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/services/repositories/mocks"
+)
+
+func TestEraCatalog(t *testing.T) {
+	loads := 0
+	svc := &ContentService{
+		contentRepo: &mocks.ContentRepo{
+			GetErasFunc: func() ([]model.Era, error) {
+				loads++
+				return []model.Era{{Code: "Bac_Thuoc", Order: 1}, {Code: "Doc_Lap", Order: 2}}, nil
+			},
+			GetDynastiesFunc: func() ([]model.Dynasty, error) {
+				return []model.Dynasty{{Name: "Ngô", Era: "Doc_Lap"}, {Name: "Lý", Era: "Doc_Lap"}}, nil
+			},
+		},
+		eras: newEraCatalog(),
+	}
+
+	eras, err := svc.GetEras()
+	if err != nil || !reflect.DeepEqual(eras, []string{"Bac_Thuoc", "Doc_Lap"}) {
+		t.Fatalf("GetEras() = %v, %v", eras, err)
+	}
+	dynasties, err := svc.GetDynasties()
+	if err != nil || !reflect.DeepEqual(dynasties, []string{"Ngô", "Lý"}) {
+		t.Fatalf("GetDynasties() = %v, %v", dynasties, err)
+	}
+	if loads != 1 {
+		t.Errorf("catalog loaded %d times, want once", loads)
+	}
+
+	tests := []struct {
+		era, dynasty string
+		valid        bool
+	}{
+		{"", "", true},
+		{"Doc_Lap", "Lý", true},
+		{"", "Ngô", true},
+		{"Bac_Thuoc", "Lý", false},
+		{"Phong_Kien", "", false},
+		{"", "Trần", false},
+	}
+	for _, tt := range tests {
+		if err := svc.checkContentReferences(tt.era, tt.dynasty); (err == nil) != tt.valid {
+			t.Errorf("checkContentReferences(%q, %q) = %v, want valid %v", tt.era, tt.dynasty, err, tt.valid)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	appContext "github.com/cloakd/common/context"
+	serviceContext "github.com/cloakd/common/services"
+	"github.com/lac-hong-legacy/ven_api/model"
+	log "github.com/sirupsen/logrus"
+)
+
+// Domain event names
+const (
+	EventLessonCompleted   = "lesson.completed"
+	EventLevelUp           = "level.up"
+	EventStreakBroken      = "streak.broken"
+	EventCharacterUnlocked = "character.unlocked"
+	EventProgressChanged   = "progress.changed"
+)
+
+// eventBusChannelPrefix is prepended to the event name to form the Redis channel
+const eventBusChannelPrefix = "ven:events:"
+
+// DomainEvent is something that happened to a learner that other subsystems may react to
+type DomainEvent interface {
+	EventName() string
+}
+
+// LessonCompletedEvent is published after a registered user's completion and XP are saved
+type LessonCompletedEvent struct {
+	UserID          string    `json:"user_id"`
+	LessonID        string    `json:"lesson_id"`
+	Score           int       `json:"score"`
+	TimeSpent       int       `json:"time_spent"`
+	XPGained        int       `json:"xp_gained"`
+	FirstCompletion bool      `json:"first_completion"`
+	CompletedAt     time.Time `json:"completed_at"`
+}
+
+func (e *LessonCompletedEvent) EventName() string { return EventLessonCompleted }
+
+// LevelUpEvent is published when XP from a lesson moves a user to a higher level
+type LevelUpEvent struct {
+	UserID        string `json:"user_id"`
+	Level         int    `json:"level"`
+	PreviousLevel int    `json:"previous_level"`
+	XP            int    `json:"xp"`
+}
+
+func (e *LevelUpEvent) EventName() string { return EventLevelUp }
+
+// StreakBrokenEvent is published when a user returns after missing a day and their
+// streak restarts
+type StreakBrokenEvent struct {
+	UserID         string    `json:"user_id"`
+	PreviousStreak int       `json:"previous_streak"`
+	LastActivityAt time.Time `json:"last_activity_at"`
+}
+
+func (e *StreakBrokenEvent) EventName() string { return EventStreakBroken }
+
+// CharacterUnlockedEvent is published when a lesson adds a character to a user's collection
+type CharacterUnlockedEvent struct {
+	UserID        string `json:"user_id"`
+	CharacterID   string `json:"character_id"`
+	CharacterName string `json:"character_name"`
+	LessonID      string `json:"lesson_id"`
+}
+
+func (e *CharacterUnlockedEvent) EventName() string { return EventCharacterUnlocked }
+
+// ProgressChangedEvent is published after any other write to a user's progress, spirit,
+// achievements or collection, e.g. hearts, bonus XP and admin adjustments
+type ProgressChangedEvent struct {
+	UserID string `json:"user_id"`
+	Reason string `json:"reason"`
+}
+
+func (e *ProgressChangedEvent) EventName() string { return EventProgressChanged }
+
+type eventListener struct {
+	name    string
+	handler func(DomainEvent)
+}
+
+// EventBusService dispatches domain events to in-process listeners. Listeners run
+// synchronously in the order they subscribed, so a listener sees the state left by
+// the publisher and by the listeners before it. With EVENT_BUS_REDIS_FANOUT=true
+// every event is also published to Redis for consumers outside the API.
+type EventBusService struct {
+	serviceContext.DefaultService
+
+	redisSvc  *RedisService
+	outboxSvc *OutboxService
+	fanOut    bool
+
+	mutex     sync.RWMutex
+	listeners map[string][]eventListener
+}
+
+const EVENT_BUS_SVC = "event_bus_svc"
+
+func (svc *EventBusService) Id() string {
+	return EVENT_BUS_SVC
+}
+
+func (svc *EventBusService) Configure(ctx *appContext.Context) error {
+	// Created here rather than in Start so services started earlier can subscribe
+	svc.listeners = make(map[string][]eventListener)
+	svc.fanOut = os.Getenv("EVENT_BUS_REDIS_FANOUT") == "true"
+	return svc.DefaultService.Configure(ctx)
+}
+
+func (svc *EventBusService) Start() error {
+	deps := newDependencies(svc.Id(), svc.Service)
+	svc.redisSvc = resolve[*RedisService](deps, REDIS_SVC)
+	svc.outboxSvc = resolve[*OutboxService](deps, OUTBOX_SVC)
+	if err := deps.err(); err != nil {
+		return err
+	}
+
+	for name, newEvent := range domainEventTypes {
+		svc.outboxSvc.Handle(outboxTopicEventPrefix+name, func(payload json.RawMessage) error {
+			event := newEvent()
+			if err := json.Unmarshal(payload, event); err != nil {
+				return err
+			}
+			svc.Publish(event)
+			return nil
+		})
+	}
+
+	return nil
+}
+
+// domainEventTypes creates an empty event of each type for decoding outbox payloads
+var domainEventTypes = map[string]func() DomainEvent{
+	EventLessonCompleted:   func() DomainEvent { return &LessonCompletedEvent{} },
+	EventLevelUp:           func() DomainEvent { return &LevelUpEvent{} },
+	EventStreakBroken:      func() DomainEvent { return &StreakBrokenEvent{} },
+	EventCharacterUnlocked: func() DomainEvent { return &CharacterUnlockedEvent{} },
+	EventProgressChanged:   func() DomainEvent { return &ProgressChangedEvent{} },
+}
+
+// eventMessage wraps an event for the outbox. Write it with the state change the event
+// describes and Relay it after the commit, and listeners run once the change is durable.
+func eventMessage(event DomainEvent) *model.OutboxMessage {
+	return newOutboxMessage(outboxTopicEventPrefix+event.EventName(), event)
+}
+
+// Subscribe registers handler for events with the given name. listener names the
+// subscriber in logs.
+func (svc *EventBusService) Subscribe(eventName, listener string, handler func(DomainEvent)) {
+	svc.mutex.Lock()
+	defer svc.mutex.Unlock()
+
+	svc.listeners[eventName] = append(svc.listeners[eventName], eventListener{name: listener, handler: handler})
+}
+
+// Publish runs every listener for the event before returning. A listener that panics
+// is logged and skipped so it cannot break the publisher or the listeners after it.
+func (svc *EventBusService) Publish(event DomainEvent) {
+	svc.mutex.RLock()
+	listeners := svc.listeners[event.EventName()]
+	svc.mutex.RUnlock()
+
+	for _, l := range listeners {
+		svc.dispatch(l, event)
+	}
+
+	if svc.fanOut {
+		svc.publishToRedis(event)
+	}
+}
+
+func (svc *EventBusService) dispatch(l eventListener, event DomainEvent) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.WithFields(log.Fields{
+				"event":    event.EventName(),
+				"listener": l.name,
+			}).Errorf("Event listener panicked: %v", r)
+		}
+	}()
+
+	l.handler(event)
+}
+
+func (svc *EventBusService) publishToRedis(event DomainEvent) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"event":        event.EventName(),
+		"data":         event,
+		"published_at": time.Now().UTC(),
+	})
+	if err != nil {
+		log.WithError(err).WithField("event", event.EventName()).Error("Failed to marshal domain event")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	channel := fmt.Sprintf("%s%s", eventBusChannelPrefix, event.EventName())
+	if err := svc.redisSvc.GetClient().Publish(ctx, channel, payload).Err(); err != nil {
+		log.WithError(err).WithField("event", event.EventName()).Warn("Failed to fan out domain event")
+	}
+}
@@ -49,8 +49,9 @@ func (svc *GeolocationService) Configure(ctx *appContext.Context) error {
 }
 
 func (svc *GeolocationService) Start() error {
-	svc.redisSvc = svc.Service(REDIS_SVC).(*RedisService)
-	return nil
+	deps := newDependencies(svc.Id(), svc.Service)
+	svc.redisSvc = resolve[*RedisService](deps, REDIS_SVC)
+	return deps.err()
 }
 
 func (svc *GeolocationService) GetLocationByIP(ip string) (string, error) {
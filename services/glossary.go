@@ -0,0 +1,276 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	"github.com/lac-hong-legacy/ven_api/shared/text"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// How long an instance links lesson stories against its copy of the glossary. Admin
+// changes apply at once on the instance that made them.
+const glossaryIndexTTL = 5 * time.Minute
+
+// glossaryIndex holds the glossary spellings to look for in lesson stories, keyed by
+// their first lowercase rune and longest first, so "Bắc thuộc lần thứ nhất" wins over
+// "Bắc thuộc"
+type glossaryIndex struct {
+	mutex    sync.Mutex
+	entries  map[rune][]glossaryEntry
+	loadedAt time.Time
+}
+
+type glossaryEntry struct {
+	term    *model.GlossaryTerm
+	pattern []rune
+}
+
+func newGlossaryIndex() *glossaryIndex {
+	return &glossaryIndex{}
+}
+
+func (idx *glossaryIndex) invalidate() {
+	idx.mutex.Lock()
+	idx.loadedAt = time.Time{}
+	idx.mutex.Unlock()
+}
+
+// glossaryEntries returns the index, reloading it when it is older than glossaryIndexTTL.
+// If the reload fails the previous index is kept.
+func (svc *ContentService) glossaryEntries() map[rune][]glossaryEntry {
+	idx := svc.glossary
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+
+	if idx.entries != nil && time.Since(idx.loadedAt) < glossaryIndexTTL {
+		return idx.entries
+	}
+
+	terms, err := svc.contentRepo.GetAllGlossaryTerms()
+	if err != nil {
+		log.Printf("Failed to load glossary terms: %v", err)
+		return idx.entries
+	}
+
+	entries := make(map[rune][]glossaryEntry)
+	for i := range terms {
+		term := &terms[i]
+		for _, spelling := range glossarySpellings(term) {
+			pattern := []rune(strings.ToLower(strings.TrimSpace(spelling)))
+			if len(pattern) == 0 {
+				continue
+			}
+			entries[pattern[0]] = append(entries[pattern[0]], glossaryEntry{term: term, pattern: pattern})
+		}
+	}
+	for first := range entries {
+		sort.SliceStable(entries[first], func(i, j int) bool {
+			return len(entries[first][i].pattern) > len(entries[first][j].pattern)
+		})
+	}
+
+	idx.entries = entries
+	idx.loadedAt = time.Now()
+	return entries
+}
+
+func glossarySpellings(term *model.GlossaryTerm) []string {
+	spellings := []string{term.Term}
+	if len(term.Aliases) > 0 {
+		var aliases []string
+		if err := json.Unmarshal(term.Aliases, &aliases); err != nil {
+			log.Printf("Failed to unmarshal aliases for glossary term %s: %v", term.ID, err)
+		}
+		spellings = append(spellings, aliases...)
+	}
+	return spellings
+}
+
+// annotateGlossary finds glossary terms in a story. Matching ignores case and only
+// accepts whole words; overlapping matches go to the one that starts first.
+func (svc *ContentService) annotateGlossary(story string) []dto.GlossaryAnnotation {
+	if story == "" {
+		return nil
+	}
+	entries := svc.glossaryEntries()
+	if len(entries) == 0 {
+		return nil
+	}
+
+	text := []rune(story)
+	lower := make([]rune, len(text))
+	for i, r := range text {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	var annotations []dto.GlossaryAnnotation
+	for i := 0; i < len(lower); {
+		if i > 0 && isGlossaryWordRune(lower[i-1]) {
+			i++
+			continue
+		}
+
+		matched := false
+		for _, entry := range entries[lower[i]] {
+			end := i + len(entry.pattern)
+			if end > len(lower) || !slices.Equal(lower[i:end], entry.pattern) {
+				continue
+			}
+			if end < len(lower) && isGlossaryWordRune(lower[end]) {
+				continue
+			}
+
+			annotations = append(annotations, dto.GlossaryAnnotation{
+				TermID:     entry.term.ID,
+				Term:       entry.term.Term,
+				Start:      i,
+				End:        end,
+				Definition: entry.term.Definition,
+			})
+			i = end
+			matched = true
+			break
+		}
+		if !matched {
+			i++
+		}
+	}
+	return annotations
+}
+
+// isGlossaryWordRune counts combining marks as part of a word, for stories stored with
+// decomposed Vietnamese diacritics
+func isGlossaryWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
+
+// ==================== GLOSSARY METHODS ====================
+
+func (svc *ContentService) SearchGlossary(query, era string, page, limit int) (*dto.GlossaryTermListResponse, error) {
+	terms, total, err := svc.contentRepo.SearchGlossaryTerms(text.NFC(strings.TrimSpace(query)), era, page, limit)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to search glossary")
+	}
+
+	return &dto.GlossaryTermListResponse{
+		Terms: terms,
+		Total: int(total),
+		Page:  page,
+		Limit: limit,
+	}, nil
+}
+
+func (svc *ContentService) GetGlossaryTerm(termID string) (*model.GlossaryTerm, error) {
+	term, err := svc.contentRepo.GetGlossaryTerm(termID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, shared.NewNotFoundError(err, "Glossary term not found")
+	}
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get glossary term")
+	}
+	return term, nil
+}
+
+func (svc *ContentService) CreateGlossaryTerm(adminID string, req dto.GlossaryTermRequest) (*model.GlossaryTerm, error) {
+	if err := svc.checkGlossaryTermAvailable(req.Term, ""); err != nil {
+		return nil, err
+	}
+	if err := svc.checkContentReferences(req.Era, ""); err != nil {
+		return nil, err
+	}
+
+	term := &model.GlossaryTerm{CreatedBy: adminID}
+	if err := applyGlossaryTermRequest(term, req); err != nil {
+		return nil, err
+	}
+	if err := svc.contentRepo.CreateGlossaryTerm(term); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to create glossary term")
+	}
+
+	svc.glossary.invalidate()
+	svc.RecordContentAudit(adminID, model.ContentEntityGlossary, term.ID, model.ContentActionCreate, nil, term)
+	return term, nil
+}
+
+func (svc *ContentService) UpdateGlossaryTerm(adminID, termID string, req dto.GlossaryTermRequest) (*model.GlossaryTerm, error) {
+	term, err := svc.GetGlossaryTerm(termID)
+	if err != nil {
+		return nil, err
+	}
+	if err := svc.checkGlossaryTermAvailable(req.Term, term.ID); err != nil {
+		return nil, err
+	}
+	if err := svc.checkContentReferences(req.Era, ""); err != nil {
+		return nil, err
+	}
+
+	before := *term
+	if err := applyGlossaryTermRequest(term, req); err != nil {
+		return nil, err
+	}
+	if err := svc.contentRepo.UpdateGlossaryTerm(term); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to update glossary term")
+	}
+
+	svc.glossary.invalidate()
+	svc.RecordContentAudit(adminID, model.ContentEntityGlossary, term.ID, model.ContentActionUpdate, before, term)
+	return term, nil
+}
+
+func (svc *ContentService) DeleteGlossaryTerm(adminID, termID string) error {
+	term, err := svc.GetGlossaryTerm(termID)
+	if err != nil {
+		return err
+	}
+	if err := svc.contentRepo.DeleteGlossaryTerm(termID); err != nil {
+		return shared.NewInternalError(err, "Failed to delete glossary term")
+	}
+
+	svc.glossary.invalidate()
+	svc.RecordContentAudit(adminID, model.ContentEntityGlossary, term.ID, model.ContentActionDelete, term, nil)
+	return nil
+}
+
+// checkGlossaryTermAvailable refuses a term that another entry already uses
+func (svc *ContentService) checkGlossaryTermAvailable(term, termID string) error {
+	existing, err := svc.contentRepo.GetGlossaryTermByTerm(strings.TrimSpace(term))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return shared.NewInternalError(err, "Failed to check glossary term")
+	}
+	if existing.ID != termID {
+		return shared.NewConflictError(nil, "Glossary term already exists")
+	}
+	return nil
+}
+
+func applyGlossaryTermRequest(term *model.GlossaryTerm, req dto.GlossaryTermRequest) error {
+	term.Term = strings.TrimSpace(req.Term)
+	term.Definition = req.Definition
+	term.Era = req.Era
+	term.ImageURL = req.ImageURL
+	term.AudioURL = req.AudioURL
+
+	term.Aliases = nil
+	if len(req.Aliases) > 0 {
+		aliases, err := json.Marshal(req.Aliases)
+		if err != nil {
+			return shared.NewBadRequestError(err, "Invalid aliases")
+		}
+		term.Aliases = aliases
+	}
+	return nil
+}
@@ -3,38 +3,84 @@ package services
 import (
 	"encoding/json"
 	"fmt"
+	"os"
+	"strconv"
 	"time"
 
 	"github.com/cloakd/common/context"
 	serviceContext "github.com/cloakd/common/services"
-	"github.com/google/uuid"
+	"github.com/lac-hong-legacy/ven_api/dto"
 	"github.com/lac-hong-legacy/ven_api/model"
 	"github.com/lac-hong-legacy/ven_api/shared"
+	"github.com/lac-hong-legacy/ven_api/shared/ids"
 	log "github.com/sirupsen/logrus"
 )
 
 type GuestService struct {
 	serviceContext.DefaultService
 
-	sqlSvc *PostgresService
+	sqlSvc         *PostgresService
+	systemSvc      *SystemService
+	attestationSvc *AttestationService
+	contentSvc     *ContentService
+
+	// Lessons a guest can play per day from one device and from one IP address, 0 for no
+	// limit. The IP limit is higher since classrooms share an address.
+	attemptLimit   int
+	ipAttemptLimit int
+	location       *time.Location
 }
 
 const GUEST_SVC = "guest_svc"
 
+const (
+	defaultGuestAttemptLimit   = 10
+	defaultGuestIPAttemptLimit = 60
+)
+
+const guestQuotaReachedMessage = "Daily guest lesson limit reached. Please register to continue."
+
 func (svc GuestService) Id() string {
 	return GUEST_SVC
 }
 
 func (svc *GuestService) Configure(ctx *context.Context) error {
+	svc.attemptLimit = defaultGuestAttemptLimit
+	if v := os.Getenv("GUEST_DAILY_ATTEMPT_LIMIT"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			return fmt.Errorf("invalid GUEST_DAILY_ATTEMPT_LIMIT: %q", v)
+		}
+		svc.attemptLimit = limit
+	}
+
+	svc.ipAttemptLimit = defaultGuestIPAttemptLimit
+	if v := os.Getenv("GUEST_DAILY_ATTEMPT_LIMIT_PER_IP"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 0 {
+			return fmt.Errorf("invalid GUEST_DAILY_ATTEMPT_LIMIT_PER_IP: %q", v)
+		}
+		svc.ipAttemptLimit = limit
+	}
+
+	svc.location = streakLocation("")
 	return svc.DefaultService.Configure(ctx)
 }
 
 func (svc *GuestService) Start() error {
-	svc.sqlSvc = svc.Service(POSTGRES_SVC).(*PostgresService)
-	return nil
+	deps := newDependencies(svc.Id(), svc.Service)
+	svc.sqlSvc = resolve[*PostgresService](deps, POSTGRES_SVC)
+	svc.systemSvc = resolve[*SystemService](deps, SYSTEM_SVC)
+	svc.attestationSvc = resolve[*AttestationService](deps, ATTESTATION_SVC)
+	svc.contentSvc = resolve[*ContentService](deps, CONTENT_SVC)
+	return deps.err()
 }
 
-func (svc *GuestService) CreateOrGetSession(deviceID string) (*model.GuestSession, error) {
+func (svc *GuestService) CreateOrGetSession(deviceID string, attestation *dto.AttestationPayload) (*model.GuestSession, error) {
+	if err := svc.attestationSvc.VerifyDevice(deviceID, AttestationPurposeSession, attestation); err != nil {
+		return nil, err
+	}
+
 	session, err := svc.sqlSvc.sessionRepo.GetSessionByDeviceID(deviceID)
 	if err == nil && session != nil {
 		session.LastActivity = time.Now()
@@ -43,10 +89,10 @@ func (svc *GuestService) CreateOrGetSession(deviceID string) (*model.GuestSessio
 		}
 		return session, nil
 	}
-	id, _ := uuid.NewV7()
+	id := ids.New()
 
 	session = &model.GuestSession{
-		ID:           id.String(),
+		ID:           id,
 		DeviceID:     deviceID,
 		SessionStart: time.Now(),
 		LastActivity: time.Now(),
@@ -63,7 +109,7 @@ func (svc *GuestService) CreateOrGetSession(deviceID string) (*model.GuestSessio
 	// Create initial progress
 	emptyArray, _ := json.Marshal([]string{})
 	progress := &model.GuestProgress{
-		ID:               id.String(),
+		ID:               id,
 		GuestSessionID:   session.ID,
 		Hearts:           5,
 		MaxHearts:        5,
@@ -122,24 +168,81 @@ func (svc *GuestService) CanAccessLesson(sessionID, lessonID string) (bool, stri
 	return false, "Lesson not available for guest users", nil
 }
 
-func (svc *GuestService) CompleteLesson(sessionID, lessonID string, score, timeSpent int) error {
+// GetAttemptQuota returns how many more lessons the guest can play today. It is nil when
+// no quota is configured.
+func (svc *GuestService) GetAttemptQuota(sessionID, ipAddress string) (*dto.GuestAttemptQuota, error) {
+	session, err := svc.sqlSvc.sessionRepo.GetSessionByID(sessionID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Session not found")
+	}
+	return svc.attemptQuota(session.DeviceID, ipAddress)
+}
+
+func (svc *GuestService) attemptQuota(deviceID, ipAddress string) (*dto.GuestAttemptQuota, error) {
+	if svc.attemptLimit == 0 && svc.ipAttemptLimit == 0 {
+		return nil, nil
+	}
+
+	now := time.Now().In(svc.location)
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, svc.location)
+	byDevice, byIP, err := svc.sqlSvc.contentRepo.CountGuestAttemptsSince(deviceID, ipAddress, dayStart)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to count guest lesson attempts")
+	}
+	return guestAttemptQuota(svc.attemptLimit, svc.ipAttemptLimit, byDevice, byIP, dayStart.AddDate(0, 0, 1)), nil
+}
+
+// guestAttemptQuota reports the device limit, or the IP limit when only that is set. The
+// remaining count is whichever of the two runs out first.
+func guestAttemptQuota(deviceLimit, ipLimit int, byDevice, byIP int64, resetsAt time.Time) *dto.GuestAttemptQuota {
+	quota := &dto.GuestAttemptQuota{Limit: deviceLimit, ResetsAt: resetsAt}
+	if deviceLimit > 0 {
+		quota.Remaining = deviceLimit - int(byDevice)
+	}
+	if ipLimit > 0 {
+		left := ipLimit - int(byIP)
+		if deviceLimit == 0 {
+			quota.Limit, quota.Remaining = ipLimit, left
+		}
+		quota.Remaining = min(quota.Remaining, left)
+	}
+	quota.Remaining = max(quota.Remaining, 0)
+	return quota
+}
+
+// CompleteLesson records a lesson played by a guest and returns their quota after it.
+// Once the daily quota is used up lessons are refused until the next day.
+func (svc *GuestService) CompleteLesson(sessionID, ipAddress, lessonID string, score, timeSpent int) (*dto.GuestAttemptQuota, error) {
 	canAccess, reason, err := svc.CanAccessLesson(sessionID, lessonID)
 	if err != nil {
-		return shared.NewInternalError(err, "Failed to check lesson access")
+		return nil, shared.NewInternalError(err, "Failed to check lesson access")
 	}
 
 	if !canAccess {
-		return shared.NewForbiddenError(fmt.Errorf("access denied: %s", reason), "Access denied")
+		return nil, shared.NewForbiddenError(fmt.Errorf("access denied: %s", reason), "Access denied")
+	}
+
+	session, err := svc.sqlSvc.sessionRepo.GetSessionByID(sessionID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Session not found")
+	}
+
+	quota, err := svc.attemptQuota(session.DeviceID, ipAddress)
+	if err != nil {
+		return nil, err
+	}
+	if quota != nil && quota.Remaining == 0 {
+		return nil, shared.NewTooManyRequestsError(nil, guestQuotaReachedMessage)
 	}
 
 	progress, err := svc.sqlSvc.contentRepo.GetProgress(sessionID)
 	if err != nil {
-		return shared.NewInternalError(err, "Failed to get progress")
+		return nil, shared.NewInternalError(err, "Failed to get progress")
 	}
 
 	var completedLessons []string
 	if err := json.Unmarshal([]byte(progress.CompletedLessons), &completedLessons); err != nil {
-		return shared.NewInternalError(err, "Failed to parse completed lessons")
+		return nil, shared.NewInternalError(err, "Failed to parse completed lessons")
 	}
 
 	isAlreadyCompleted := false
@@ -154,7 +257,7 @@ func (svc *GuestService) CompleteLesson(sessionID, lessonID string, score, timeS
 		completedLessons = append(completedLessons, lessonID)
 		completedLessonsJSON, err := json.Marshal(completedLessons)
 		if err != nil {
-			return shared.NewInternalError(err, "Failed to marshal completed lessons")
+			return nil, shared.NewInternalError(err, "Failed to marshal completed lessons")
 		}
 		progress.CompletedLessons = model.JSONB(completedLessonsJSON)
 
@@ -166,26 +269,37 @@ func (svc *GuestService) CompleteLesson(sessionID, lessonID string, score, timeS
 	// Update total play time
 	progress.TotalPlayTime += timeSpent / 60 // Convert seconds to minutes
 
-	id, _ := uuid.NewV7()
 	// Save lesson attempt
 	attempt := &model.GuestLessonAttempt{
-		ID:             id.String(),
+		ID:             ids.New(),
 		GuestSessionID: sessionID,
 		LessonID:       lessonID,
 		IsCompleted:    true,
 		Score:          score,
 		TimeSpent:      timeSpent,
 		AttemptsCount:  1,
+		DeviceID:       session.DeviceID,
+		IPAddress:      ipAddress,
 		CreatedAt:      time.Now(),
 		UpdatedAt:      time.Now(),
 	}
 
 	if err := svc.sqlSvc.contentRepo.CreateLessonAttempt(attempt); err != nil {
-		return shared.NewInternalError(err, "Failed to create lesson attempt")
+		return nil, shared.NewInternalError(err, "Failed to create lesson attempt")
 	}
 
 	// Update progress
-	return svc.sqlSvc.contentRepo.UpdateProgress(progress)
+	if err := svc.sqlSvc.contentRepo.UpdateProgress(progress); err != nil {
+		return nil, err
+	}
+
+	svc.systemSvc.RecordLessonCompletion()
+	svc.contentSvc.recordPopularity(model.PopularityEntityLesson, lessonID, popularityMetricCompletions)
+
+	if quota != nil {
+		quota.Remaining--
+	}
+	return quota, nil
 }
 
 func calculateXP(score int) int {
@@ -210,7 +324,16 @@ func calculateLevel(totalXP int) int {
 	return level
 }
 
-func (svc *GuestService) AddHeartsFromAd(sessionID string) error {
+func (svc *GuestService) AddHeartsFromAd(sessionID string, attestation *dto.AttestationPayload) error {
+	session, err := svc.sqlSvc.sessionRepo.GetSessionByID(sessionID)
+	if err != nil {
+		return shared.NewNotFoundError(err, "Session not found")
+	}
+
+	if err := svc.attestationSvc.VerifyDevice(session.DeviceID, AttestationPurposeAdHeart, attestation); err != nil {
+		return err
+	}
+
 	progress, err := svc.sqlSvc.contentRepo.GetProgress(sessionID)
 	if err != nil {
 		return shared.NewInternalError(err, "Failed to get progress")
@@ -222,6 +345,12 @@ func (svc *GuestService) AddHeartsFromAd(sessionID string) error {
 	return svc.sqlSvc.contentRepo.UpdateProgress(progress)
 }
 
+// IssueAttestationChallenge hands out the nonce a guest device signs before creating a
+// session or claiming ad hearts
+func (svc *GuestService) IssueAttestationChallenge() (*dto.AttestationChallengeResponse, error) {
+	return svc.attestationSvc.IssueChallenge()
+}
+
 func (svc *GuestService) LoseHeart(sessionID string) error {
 	progress, err := svc.sqlSvc.contentRepo.GetProgress(sessionID)
 	if err != nil {
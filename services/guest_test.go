@@ -0,0 +1,31 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGuestAttemptQuota(t *testing.T) {
+	resetsAt := time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name                  string
+		deviceLimit, ipLimit  int
+		byDevice, byIP        int64
+		wantLimit, wantRemain int
+	}{
+		{"device runs out first", 10, 60, 7, 20, 10, 3},
+		{"shared address runs out first", 10, 60, 2, 58, 10, 2},
+		{"used up", 10, 60, 12, 12, 10, 0},
+		{"device limit only", 10, 0, 4, 100, 10, 6},
+		{"address limit only", 0, 60, 100, 15, 60, 45},
+	}
+	for _, tt := range tests {
+		quota := guestAttemptQuota(tt.deviceLimit, tt.ipLimit, tt.byDevice, tt.byIP, resetsAt)
+		if quota.Limit != tt.wantLimit || quota.Remaining != tt.wantRemain {
+			t.Errorf("%s: got limit %d remaining %d, want %d and %d", tt.name, quota.Limit, quota.Remaining, tt.wantLimit, tt.wantRemain)
+		}
+		if !quota.ResetsAt.Equal(resetsAt) {
+			t.Errorf("%s: resets at %v, want %v", tt.name, quota.ResetsAt, resetsAt)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/lac-hong-legacy/ven_api/dto"
@@ -13,12 +14,16 @@ import (
 type AdminHandler struct {
 	userSvc    UserServiceInterface
 	contentSvc ContentServiceInterface
+	systemSvc  SystemServiceInterface
+	authSvc    AuthServiceInterface
 }
 
-func NewAdminHandler(userSvc UserServiceInterface, contentSvc ContentServiceInterface) *AdminHandler {
+func NewAdminHandler(userSvc UserServiceInterface, contentSvc ContentServiceInterface, systemSvc SystemServiceInterface, authSvc AuthServiceInterface) *AdminHandler {
 	return &AdminHandler{
 		userSvc:    userSvc,
 		contentSvc: contentSvc,
+		systemSvc:  systemSvc,
+		authSvc:    authSvc,
 	}
 }
 
@@ -113,42 +118,1027 @@ func (h *AdminHandler) AdminDeleteUser(c *fiber.Ctx) error {
 	return shared.ResponseJSON(c, http.StatusOK, "User deleted successfully", nil)
 }
 
+// @Summary Get user notes (Support)
+// @Description Get the internal support notes on a user's account, newest first (admin and moderator only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin or moderator Bearer Token" default(Bearer <admin_token>)
+// @Param userId path string true "User ID"
+// @Param tag query string false "Only notes with this tag"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} shared.Response{data=dto.UserNoteListResponse}
+// @Router /api/v1/support/users/{userId}/notes [get]
+func (h *AdminHandler) GetUserNotes(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	notes, err := h.userSvc.GetUserNotes(c.Params("userId"), c.Query("tag"), page, limit)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Success", notes)
+}
+
+// @Summary Add user note (Support)
+// @Description Add an internal support note to a user's account, optionally linked to a support ticket (admin and moderator only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin or moderator Bearer Token" default(Bearer <admin_token>)
+// @Param userId path string true "User ID"
+// @Param request body dto.UserNoteRequest true "Note"
+// @Success 201 {object} shared.Response{data=dto.UserNoteResponse}
+// @Router /api/v1/support/users/{userId}/notes [post]
+func (h *AdminHandler) CreateUserNote(c *fiber.Ctx) error {
+	authorID := c.Locals(shared.UserID).(string)
+
+	var req dto.UserNoteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.CreateValidationErrorResponse(err))
+	}
+
+	note, err := h.userSvc.CreateUserNote(authorID, c.Params("userId"), req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusCreated, "Note added", note)
+}
+
+// @Summary Update user note (Support)
+// @Description Replace the text, tags and ticket of a support note. Moderators can only edit their own notes (admin and moderator only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin or moderator Bearer Token" default(Bearer <admin_token>)
+// @Param userId path string true "User ID"
+// @Param noteId path string true "Note ID"
+// @Param request body dto.UserNoteRequest true "Note"
+// @Success 200 {object} shared.Response{data=dto.UserNoteResponse}
+// @Failure 403 {object} shared.Response "Not the note's author"
+// @Router /api/v1/support/users/{userId}/notes/{noteId} [put]
+func (h *AdminHandler) UpdateUserNote(c *fiber.Ctx) error {
+	actor := c.Locals("user").(*model.User)
+
+	var req dto.UserNoteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.CreateValidationErrorResponse(err))
+	}
+
+	note, err := h.userSvc.UpdateUserNote(actor, c.Params("userId"), c.Params("noteId"), req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Note updated", note)
+}
+
+// @Summary Delete user note (Support)
+// @Description Delete a support note. Moderators can only delete their own notes (admin and moderator only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin or moderator Bearer Token" default(Bearer <admin_token>)
+// @Param userId path string true "User ID"
+// @Param noteId path string true "Note ID"
+// @Success 200 {object} shared.Response{data=nil}
+// @Failure 403 {object} shared.Response "Not the note's author"
+// @Router /api/v1/support/users/{userId}/notes/{noteId} [delete]
+func (h *AdminHandler) DeleteUserNote(c *fiber.Ctx) error {
+	actor := c.Locals("user").(*model.User)
+
+	if err := h.userSvc.DeleteUserNote(actor, c.Params("userId"), c.Params("noteId")); err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Note deleted", nil)
+}
+
+// @Summary Get user XP ledger (Admin)
+// @Description Get every XP grant recorded for a user, newest first (admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param userId path string true "User ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} shared.Response{data=dto.XPLedgerResponse}
+// @Router /api/v1/admin/users/{userId}/xp-ledger [get]
+func (h *AdminHandler) GetUserXPLedger(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	ledger, err := h.userSvc.GetXPLedger(c.Params("userId"), page, limit)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "XP ledger retrieved successfully", ledger)
+}
+
+// @Summary Get user heart ledger (Admin)
+// @Description Get every recorded heart adjustment for a user, newest first (admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param userId path string true "User ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} shared.Response{data=dto.HeartLedgerResponse}
+// @Router /api/v1/admin/users/{userId}/heart-ledger [get]
+func (h *AdminHandler) GetUserHeartLedger(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	ledger, err := h.userSvc.GetHeartLedger(c.Params("userId"), page, limit)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Heart ledger retrieved successfully", ledger)
+}
+
+// @Summary Get user item ledger (Admin)
+// @Description Get every item an admin granted to or revoked from a user, newest first (admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param userId path string true "User ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} shared.Response{data=dto.ItemLedgerResponse}
+// @Router /api/v1/admin/users/{userId}/item-ledger [get]
+func (h *AdminHandler) GetUserItemLedger(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	ledger, err := h.userSvc.GetItemLedger(c.Params("userId"), page, limit)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Item ledger retrieved successfully", ledger)
+}
+
+// @Summary Adjust hearts, XP or items (Admin)
+// @Description Grant or revoke hearts, XP or a character for a list of users or a cohort, for support remediation and promotions (admin only).
+// @Description Every change is written to the heart, XP or item ledger with the reason code, the acting admin and a batch ID. Set dry_run to preview the outcome.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param request body dto.AdminEconomyAdjustRequest true "Adjustment"
+// @Success 200 {object} shared.Response{data=dto.AdminEconomyAdjustResponse}
+// @Router /api/v1/admin/economy/adjustments [post]
+func (h *AdminHandler) AdjustEconomy(c *fiber.Ctx) error {
+	var req dto.AdminEconomyAdjustRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	adminID := c.Locals(shared.UserID).(string)
+	result, err := h.userSvc.AdjustEconomy(adminID, req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Adjustment applied", result)
+}
+
+// @Summary Repair user progress (Admin)
+// @Description Recompute XP, level, completed lessons and character unlocks from the attempt and XP ledger tables (admin only).
+// @Description With a user_id the repair runs immediately and returns its report; without one it starts a background run over every user.
+// @Description Set dry_run to only report what would change.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param request body dto.ProgressRepairRequest true "Repair options"
+// @Success 200 {object} shared.Response{data=dto.ProgressRepairReport}
+// @Success 202 {object} shared.Response{data=dto.ProgressRepairJobResponse}
+// @Router /api/v1/admin/progress/repair [post]
+func (h *AdminHandler) RepairProgress(c *fiber.Ctx) error {
+	var req dto.ProgressRepairRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if req.UserID != "" {
+		report, err := h.userSvc.RepairUserProgress(req.UserID, req.DryRun)
+		if err != nil {
+			return err
+		}
+		return shared.ResponseJSON(c, http.StatusOK, "Progress repair completed", report)
+	}
+
+	job, err := h.userSvc.StartProgressRepair(req.DryRun)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusAccepted, "Progress repair started", job)
+}
+
+// @Summary Get progress repair status (Admin)
+// @Description Get the status and report of the latest progress repair over all users (admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Success 200 {object} shared.Response{data=dto.ProgressRepairJobResponse}
+// @Router /api/v1/admin/progress/repair [get]
+func (h *AdminHandler) GetProgressRepairStatus(c *fiber.Ctx) error {
+	return shared.ResponseJSON(c, http.StatusOK, "Success", h.userSvc.GetProgressRepairStatus())
+}
+
+// @Summary Get completion flag review queue (Admin)
+// @Description List lesson completions flagged as implausibly fast, oldest first (admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param status query string false "Flag status" Enums(pending, dismissed, banned) default(pending)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} shared.Response{data=dto.CompletionFlagListResponse}
+// @Router /api/v1/admin/review/completion-flags [get]
+func (h *AdminHandler) GetCompletionFlags(c *fiber.Ctx) error {
+	status := c.Query("status", model.FlagStatusPending)
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	flags, err := h.userSvc.GetCompletionFlags(status, page, limit)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Success", flags)
+}
+
+// @Summary Review completion flag (Admin)
+// @Description Dismiss a flagged completion or ban the user who made it (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param flagId path string true "Completion flag ID"
+// @Param request body dto.ReviewCompletionFlagRequest true "Review decision"
+// @Success 200 {object} shared.Response{data=dto.CompletionFlagResponse}
+// @Router /api/v1/admin/review/completion-flags/{flagId} [post]
+func (h *AdminHandler) ReviewCompletionFlag(c *fiber.Ctx) error {
+	adminID := c.Locals(shared.UserID).(string)
+
+	var req dto.ReviewCompletionFlagRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.CreateValidationErrorResponse(err))
+	}
+
+	flag, err := h.userSvc.ReviewCompletionFlag(adminID, c.Params("flagId"), req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Completion flag reviewed", flag)
+}
+
+// @Summary Get disabled question review queue (Admin)
+// @Description List questions taken out of their lesson after learner reports, oldest first (admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param status query string false "Review status" Enums(pending, restored, removed) default(pending)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} shared.Response{data=dto.DisabledQuestionListResponse}
+// @Router /api/v1/admin/review/disabled-questions [get]
+func (h *AdminHandler) GetDisabledQuestions(c *fiber.Ctx) error {
+	status := c.Query("status", model.DisabledQuestionPending)
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	questions, err := h.contentSvc.GetDisabledQuestions(status, page, limit)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Success", questions)
+}
+
+// @Summary Review disabled question (Admin)
+// @Description Put a disabled question back in its lesson, optionally corrected, or remove it for good. Its reports are closed either way (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param disabledId path string true "Disabled question ID"
+// @Param request body dto.ReviewDisabledQuestionRequest true "Review decision"
+// @Success 200 {object} shared.Response{data=dto.DisabledQuestionResponse}
+// @Failure 409 {object} shared.Response "Already reviewed, or the question ID is in use in the lesson"
+// @Router /api/v1/admin/review/disabled-questions/{disabledId} [post]
+func (h *AdminHandler) ReviewDisabledQuestion(c *fiber.Ctx) error {
+	adminID := c.Locals(shared.UserID).(string)
+
+	var req dto.ReviewDisabledQuestionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.CreateValidationErrorResponse(err))
+	}
+
+	question, err := h.contentSvc.ReviewDisabledQuestion(adminID, c.Params("disabledId"), req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Disabled question reviewed", question)
+}
+
+// @Summary Get leaderboard anomaly review queue (Admin)
+// @Description List users who earned XP faster than the game allows, oldest first. Users with a pending anomaly are hidden from leaderboards (admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param status query string false "Anomaly status" Enums(pending, cleared, penalized) default(pending)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} shared.Response{data=dto.LeaderboardAnomalyListResponse}
+// @Router /api/v1/admin/review/leaderboard-anomalies [get]
+func (h *AdminHandler) GetLeaderboardAnomalies(c *fiber.Ctx) error {
+	status := c.Query("status", model.AnomalyStatusPending)
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	anomalies, err := h.userSvc.GetLeaderboardAnomalies(status, page, limit)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Success", anomalies)
+}
+
+// @Summary Get leaderboard anomaly evidence (Admin)
+// @Description Get an anomaly with the user's XP ledger entries, lesson completions and flagged completions around it, oldest first (admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param anomalyId path string true "Leaderboard anomaly ID"
+// @Success 200 {object} shared.Response{data=dto.LeaderboardAnomalyEvidenceResponse}
+// @Router /api/v1/admin/review/leaderboard-anomalies/{anomalyId} [get]
+func (h *AdminHandler) GetLeaderboardAnomalyEvidence(c *fiber.Ctx) error {
+	evidence, err := h.userSvc.GetLeaderboardAnomalyEvidence(c.Params("anomalyId"))
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Success", evidence)
+}
+
+// @Summary Review leaderboard anomaly (Admin)
+// @Description Clear the user, or penalize them by revoking XP. Either way the user returns to the leaderboards (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param anomalyId path string true "Leaderboard anomaly ID"
+// @Param request body dto.ReviewLeaderboardAnomalyRequest true "Review decision"
+// @Success 200 {object} shared.Response{data=dto.LeaderboardAnomalyResponse}
+// @Router /api/v1/admin/review/leaderboard-anomalies/{anomalyId} [post]
+func (h *AdminHandler) ReviewLeaderboardAnomaly(c *fiber.Ctx) error {
+	adminID := c.Locals(shared.UserID).(string)
+
+	var req dto.ReviewLeaderboardAnomalyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.CreateValidationErrorResponse(err))
+	}
+
+	anomaly, err := h.userSvc.ReviewLeaderboardAnomaly(adminID, c.Params("anomalyId"), req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Leaderboard anomaly reviewed", anomaly)
+}
+
+// @Summary Get moderation flag review queue (Admin)
+// @Description List user text let through for review by text moderation, oldest first (admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param status query string false "Flag status" Enums(pending, dismissed, banned) default(pending)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} shared.Response{data=dto.ModerationFlagListResponse}
+// @Router /api/v1/admin/review/moderation-flags [get]
+func (h *AdminHandler) GetModerationFlags(c *fiber.Ctx) error {
+	status := c.Query("status", model.FlagStatusPending)
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	flags, err := h.userSvc.GetModerationFlags(status, page, limit)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Success", flags)
+}
+
+// @Summary Review moderation flag (Admin)
+// @Description Dismiss flagged user text or ban the user who wrote it (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param flagId path string true "Moderation flag ID"
+// @Param request body dto.ReviewModerationFlagRequest true "Review decision"
+// @Success 200 {object} shared.Response{data=dto.ModerationFlagResponse}
+// @Router /api/v1/admin/review/moderation-flags/{flagId} [post]
+func (h *AdminHandler) ReviewModerationFlag(c *fiber.Ctx) error {
+	adminID := c.Locals(shared.UserID).(string)
+
+	var req dto.ReviewModerationFlagRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.CreateValidationErrorResponse(err))
+	}
+
+	flag, err := h.userSvc.ReviewModerationFlag(adminID, c.Params("flagId"), req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Moderation flag reviewed", flag)
+}
+
 // @Summary Create Character (Admin)
 // @Description Create a new historical character (admin only)
 // @Tags admin
 // @Accept json
 // @Produce json
 // @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
-// @Param character body model.Character true "Character data"
-// @Success 201 {object} shared.Response{data=dto.CharacterResponse}
-// @Router /api/v1/admin/characters [post]
-func (h *AdminHandler) CreateCharacter(c *fiber.Ctx) error {
-	var character model.Character
-	if err := c.BodyParser(&character); err != nil {
-		return shared.NewBadRequestError(err, "Invalid character data")
+// @Param character body model.Character true "Character data"
+// @Success 201 {object} shared.Response{data=dto.CharacterResponse}
+// @Router /api/v1/admin/characters [post]
+func (h *AdminHandler) CreateCharacter(c *fiber.Ctx) error {
+	var character model.Character
+	if err := c.BodyParser(&character); err != nil {
+		return shared.NewBadRequestError(err, "Invalid character data")
+	}
+
+	adminID := c.Locals(shared.UserID).(string)
+	created, err := h.contentSvc.CreateCharacter(adminID, &character)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusCreated, "Character created successfully", created)
+}
+
+// @Summary Get Character Relations (Admin)
+// @Description Get the figures related to a character, labelled from its point of view (admin only)
+// @Tags admin
+// @Produce json
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param characterId path string true "Character ID"
+// @Success 200 {object} shared.Response{data=[]dto.RelatedCharacterResponse}
+// @Router /api/v1/admin/characters/{characterId}/relations [get]
+func (h *AdminHandler) GetCharacterRelations(c *fiber.Ctx) error {
+	relations, err := h.contentSvc.GetCharacterRelations(c.Params("characterId"))
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", relations)
+}
+
+// @Summary Create Character Relation (Admin)
+// @Description Relate a character to another historical figure. For mentor, the character in the path is the mentor (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param characterId path string true "Character ID"
+// @Param request body dto.CreateCharacterRelationRequest true "Relation"
+// @Success 201 {object} shared.Response{data=model.CharacterRelation}
+// @Router /api/v1/admin/characters/{characterId}/relations [post]
+func (h *AdminHandler) CreateCharacterRelation(c *fiber.Ctx) error {
+	var req dto.CreateCharacterRelationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	adminID := c.Locals(shared.UserID).(string)
+	relation, err := h.contentSvc.CreateCharacterRelation(adminID, c.Params("characterId"), req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusCreated, "Character relation created", relation)
+}
+
+// @Summary Update Character Relation (Admin)
+// @Description Change the type or description of a character relation (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param relationId path string true "Relation ID"
+// @Param request body dto.UpdateCharacterRelationRequest true "Fields to change"
+// @Success 200 {object} shared.Response{data=model.CharacterRelation}
+// @Router /api/v1/admin/character-relations/{relationId} [put]
+func (h *AdminHandler) UpdateCharacterRelation(c *fiber.Ctx) error {
+	var req dto.UpdateCharacterRelationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	adminID := c.Locals(shared.UserID).(string)
+	relation, err := h.contentSvc.UpdateCharacterRelation(adminID, c.Params("relationId"), req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Character relation updated", relation)
+}
+
+// @Summary Delete Character Relation (Admin)
+// @Description Remove a relation between two characters (admin only)
+// @Tags admin
+// @Produce json
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param relationId path string true "Relation ID"
+// @Success 200 {object} shared.Response
+// @Router /api/v1/admin/character-relations/{relationId} [delete]
+func (h *AdminHandler) DeleteCharacterRelation(c *fiber.Ctx) error {
+	adminID := c.Locals(shared.UserID).(string)
+	if err := h.contentSvc.DeleteCharacterRelation(adminID, c.Params("relationId")); err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Character relation deleted", nil)
+}
+
+// @Summary Create Glossary Term (Admin)
+// @Description Add a historical term to the glossary. The term and its aliases are linked wherever they appear in lesson stories (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param request body dto.GlossaryTermRequest true "Glossary term"
+// @Success 201 {object} shared.Response{data=model.GlossaryTerm}
+// @Router /api/v1/admin/glossary [post]
+func (h *AdminHandler) CreateGlossaryTerm(c *fiber.Ctx) error {
+	var req dto.GlossaryTermRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	adminID := c.Locals(shared.UserID).(string)
+	term, err := h.contentSvc.CreateGlossaryTerm(adminID, req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusCreated, "Glossary term created", term)
+}
+
+// @Summary Update Glossary Term (Admin)
+// @Description Replace a glossary term (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param termId path string true "Term ID"
+// @Param request body dto.GlossaryTermRequest true "Glossary term"
+// @Success 200 {object} shared.Response{data=model.GlossaryTerm}
+// @Router /api/v1/admin/glossary/{termId} [put]
+func (h *AdminHandler) UpdateGlossaryTerm(c *fiber.Ctx) error {
+	var req dto.GlossaryTermRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	adminID := c.Locals(shared.UserID).(string)
+	term, err := h.contentSvc.UpdateGlossaryTerm(adminID, c.Params("termId"), req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Glossary term updated", term)
+}
+
+// @Summary Delete Glossary Term (Admin)
+// @Description Remove a term from the glossary (admin only)
+// @Tags admin
+// @Produce json
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param termId path string true "Term ID"
+// @Success 200 {object} shared.Response
+// @Router /api/v1/admin/glossary/{termId} [delete]
+func (h *AdminHandler) DeleteGlossaryTerm(c *fiber.Ctx) error {
+	adminID := c.Locals(shared.UserID).(string)
+	if err := h.contentSvc.DeleteGlossaryTerm(adminID, c.Params("termId")); err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Glossary term deleted", nil)
+}
+
+// @Summary Get Eras (Admin)
+// @Description Get the eras with their names and display order (admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Success 200 {object} shared.Response{data=[]model.Era}
+// @Router /api/v1/admin/eras [get]
+func (h *AdminHandler) GetAllEras(c *fiber.Ctx) error {
+	eras, err := h.contentSvc.GetAllEras()
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Success", eras)
+}
+
+// @Summary Create Era (Admin)
+// @Description Add an era that dynasties, characters and glossary terms can be filed under (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param request body dto.CreateEraRequest true "Era"
+// @Success 201 {object} shared.Response{data=model.Era}
+// @Failure 409 {object} shared.Response "Era code already exists"
+// @Router /api/v1/admin/eras [post]
+func (h *AdminHandler) CreateEra(c *fiber.Ctx) error {
+	var req dto.CreateEraRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	adminID := c.Locals(shared.UserID).(string)
+	era, err := h.contentSvc.CreateEra(adminID, req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusCreated, "Era created", era)
+}
+
+// @Summary Update Era (Admin)
+// @Description Change the name, description and order of an era. The code can't change (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param code path string true "Era code"
+// @Param request body dto.EraRequest true "Era"
+// @Success 200 {object} shared.Response{data=model.Era}
+// @Router /api/v1/admin/eras/{code} [put]
+func (h *AdminHandler) UpdateEra(c *fiber.Ctx) error {
+	var req dto.EraRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	adminID := c.Locals(shared.UserID).(string)
+	era, err := h.contentSvc.UpdateEra(adminID, c.Params("code"), req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Era updated", era)
+}
+
+// @Summary Delete Era (Admin)
+// @Description Remove an era nothing is filed under (admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param code path string true "Era code"
+// @Success 200 {object} shared.Response
+// @Failure 409 {object} shared.Response "Era still in use"
+// @Router /api/v1/admin/eras/{code} [delete]
+func (h *AdminHandler) DeleteEra(c *fiber.Ctx) error {
+	adminID := c.Locals(shared.UserID).(string)
+	if err := h.contentSvc.DeleteEra(adminID, c.Params("code")); err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Era deleted", nil)
+}
+
+// @Summary Get Dynasties (Admin)
+// @Description Get the dynasties with their era, years and display order (admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Success 200 {object} shared.Response{data=[]model.Dynasty}
+// @Router /api/v1/admin/dynasties [get]
+func (h *AdminHandler) GetAllDynasties(c *fiber.Ctx) error {
+	dynasties, err := h.contentSvc.GetAllDynasties()
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Success", dynasties)
+}
+
+// @Summary Create Dynasty (Admin)
+// @Description Add a dynasty under an existing era (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param request body dto.DynastyRequest true "Dynasty"
+// @Success 201 {object} shared.Response{data=model.Dynasty}
+// @Failure 409 {object} shared.Response "Dynasty name already exists"
+// @Router /api/v1/admin/dynasties [post]
+func (h *AdminHandler) CreateDynasty(c *fiber.Ctx) error {
+	var req dto.DynastyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	adminID := c.Locals(shared.UserID).(string)
+	dynasty, err := h.contentSvc.CreateDynasty(adminID, req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusCreated, "Dynasty created", dynasty)
+}
+
+// @Summary Update Dynasty (Admin)
+// @Description Replace a dynasty. A new name is carried over to its characters, timeline entries and certificates (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param dynastyId path string true "Dynasty ID"
+// @Param request body dto.DynastyRequest true "Dynasty"
+// @Success 200 {object} shared.Response{data=model.Dynasty}
+// @Router /api/v1/admin/dynasties/{dynastyId} [put]
+func (h *AdminHandler) UpdateDynasty(c *fiber.Ctx) error {
+	var req dto.DynastyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	adminID := c.Locals(shared.UserID).(string)
+	dynasty, err := h.contentSvc.UpdateDynasty(adminID, c.Params("dynastyId"), req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Dynasty updated", dynasty)
+}
+
+// @Summary Delete Dynasty (Admin)
+// @Description Remove a dynasty without characters or timeline entries (admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param dynastyId path string true "Dynasty ID"
+// @Success 200 {object} shared.Response
+// @Failure 409 {object} shared.Response "Dynasty still in use"
+// @Router /api/v1/admin/dynasties/{dynastyId} [delete]
+func (h *AdminHandler) DeleteDynasty(c *fiber.Ctx) error {
+	adminID := c.Locals(shared.UserID).(string)
+	if err := h.contentSvc.DeleteDynasty(adminID, c.Params("dynastyId")); err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Dynasty deleted", nil)
+}
+
+// @Summary Create Lesson from Request (Admin)
+// @Description Create a new lesson from request (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param createLessonRequest body dto.CreateLessonRequest true "Lesson creation request"
+// @Success 201 {object} shared.Response{data=dto.LessonResponse}
+// @Router /api/v1/admin/lessons/new [post]
+func (h *AdminHandler) CreateLessonFromRequest(c *fiber.Ctx) error {
+	var req dto.CreateLessonRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid lesson request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	adminID := c.Locals(shared.UserID).(string)
+	created, err := h.contentSvc.CreateLessonFromRequest(adminID, req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusCreated, "Lesson created successfully", created)
+}
+
+// @Summary Update Lesson Script (Admin)
+// @Description Finalize the lesson script - Step 1 of production workflow (Admin only)
+// @Tags admin,production
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param lessonId path string true "Lesson ID"
+// @Param scriptRequest body dto.UpdateLessonScriptRequest true "Script content"
+// @Success 200 {object} shared.Response{data=dto.LessonResponse}
+// @Router /api/v1/admin/lessons/{lessonId}/script [put]
+func (h *AdminHandler) UpdateLessonScript(c *fiber.Ctx) error {
+	lessonID := c.Params("lessonId")
+
+	var req dto.UpdateLessonScriptRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
 	}
 
-	created, err := h.contentSvc.CreateCharacter(&character)
+	adminID := c.Locals(shared.UserID).(string)
+	lesson, err := h.contentSvc.UpdateLessonScript(adminID, lessonID, req.Script)
 	if err != nil {
 		return err
 	}
 
-	return shared.ResponseJSON(c, fiber.StatusCreated, "Character created successfully", created)
+	response := h.contentSvc.MapLessonToResponse(lesson)
+	return shared.ResponseJSON(c, fiber.StatusOK, "Script finalized successfully", &response)
 }
 
-// @Summary Create Lesson from Request (Admin)
-// @Description Create a new lesson from request (admin only)
+// @Summary Update Lesson Content Rating (Admin)
+// @Description Set the minimum age a lesson is suitable for (Admin only)
 // @Tags admin
 // @Accept json
 // @Produce json
+// @Security Bearer
 // @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
-// @Param createLessonRequest body dto.CreateLessonRequest true "Lesson creation request"
-// @Success 201 {object} shared.Response{data=dto.LessonResponse}
-// @Router /api/v1/admin/lessons/new [post]
-func (h *AdminHandler) CreateLessonFromRequest(c *fiber.Ctx) error {
-	var req dto.CreateLessonRequest
+// @Param lessonId path string true "Lesson ID"
+// @Param ratingRequest body dto.UpdateContentRatingRequest true "Content rating"
+// @Success 200 {object} shared.Response{data=dto.LessonResponse}
+// @Router /api/v1/admin/lessons/{lessonId}/content-rating [put]
+func (h *AdminHandler) UpdateLessonContentRating(c *fiber.Ctx) error {
+	lessonID := c.Params("lessonId")
+
+	var req dto.UpdateContentRatingRequest
 	if err := c.BodyParser(&req); err != nil {
-		return shared.NewBadRequestError(err, "Invalid lesson request")
+		return shared.NewBadRequestError(err, "Invalid request")
 	}
 
 	if err := req.Validate(); err != nil {
@@ -156,29 +1146,31 @@ func (h *AdminHandler) CreateLessonFromRequest(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
 	}
 
-	created, err := h.contentSvc.CreateLessonFromRequest(req)
+	adminID := c.Locals(shared.UserID).(string)
+	lesson, err := h.contentSvc.UpdateLessonContentRating(adminID, lessonID, req.ContentRating)
 	if err != nil {
 		return err
 	}
 
-	return shared.ResponseJSON(c, fiber.StatusCreated, "Lesson created successfully", created)
+	response := h.contentSvc.MapLessonToResponse(lesson)
+	return shared.ResponseJSON(c, fiber.StatusOK, "Content rating updated successfully", &response)
 }
 
-// @Summary Update Lesson Script (Admin)
-// @Description Finalize the lesson script - Step 1 of production workflow (Admin only)
-// @Tags admin,production
+// @Summary Update Lesson Difficulty (Admin)
+// @Description Override the difficulty and estimated minutes worked out from a lesson's questions and video. An empty difficulty or 0 minutes goes back to the computed value (Admin only)
+// @Tags admin
 // @Accept json
 // @Produce json
 // @Security Bearer
 // @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
 // @Param lessonId path string true "Lesson ID"
-// @Param scriptRequest body dto.UpdateLessonScriptRequest true "Script content"
+// @Param difficultyRequest body dto.UpdateLessonDifficultyRequest true "Difficulty override"
 // @Success 200 {object} shared.Response{data=dto.LessonResponse}
-// @Router /api/v1/admin/lessons/{lessonId}/script [put]
-func (h *AdminHandler) UpdateLessonScript(c *fiber.Ctx) error {
+// @Router /api/v1/admin/lessons/{lessonId}/difficulty [put]
+func (h *AdminHandler) UpdateLessonDifficulty(c *fiber.Ctx) error {
 	lessonID := c.Params("lessonId")
 
-	var req dto.UpdateLessonScriptRequest
+	var req dto.UpdateLessonDifficultyRequest
 	if err := c.BodyParser(&req); err != nil {
 		return shared.NewBadRequestError(err, "Invalid request")
 	}
@@ -188,13 +1180,43 @@ func (h *AdminHandler) UpdateLessonScript(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
 	}
 
-	lesson, err := h.contentSvc.UpdateLessonScript(lessonID, req.Script)
+	adminID := c.Locals(shared.UserID).(string)
+	lesson, err := h.contentSvc.UpdateLessonDifficulty(adminID, lessonID, req)
 	if err != nil {
 		return err
 	}
 
 	response := h.contentSvc.MapLessonToResponse(lesson)
-	return shared.ResponseJSON(c, fiber.StatusOK, "Script finalized successfully", &response)
+	return shared.ResponseJSON(c, fiber.StatusOK, "Lesson difficulty updated successfully", &response)
+}
+
+// @Summary Get Unrated Content Report (Admin)
+// @Description List active lessons without a content rating, oldest first. They are shown to every age until rated (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} shared.Response{data=dto.UnratedLessonListResponse}
+// @Router /api/v1/admin/reports/unrated-content [get]
+func (h *AdminHandler) GetUnratedContent(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	report, err := h.contentSvc.GetUnratedLessons(page, limit)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", report)
 }
 
 // @Summary Get Lesson Production Status (Admin)
@@ -216,3 +1238,375 @@ func (h *AdminHandler) GetLessonProductionStatus(c *fiber.Ctx) error {
 
 	return shared.ResponseJSON(c, fiber.StatusOK, "Success", status)
 }
+
+// @Summary Get Lesson Question Analytics (Admin)
+// @Description How often each question of a lesson is answered and answered correctly, and what learners report about it. Questions disabled pending review are listed last (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param lessonId path string true "Lesson ID"
+// @Success 200 {object} shared.Response{data=dto.LessonQuestionAnalyticsResponse}
+// @Failure 404 {object} shared.Response
+// @Router /api/v1/admin/lessons/{lessonId}/questions/analytics [get]
+func (h *AdminHandler) GetLessonQuestionAnalytics(c *fiber.Ctx) error {
+	analytics, err := h.contentSvc.GetLessonQuestionAnalytics(c.Params("lessonId"))
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", analytics)
+}
+
+// @Summary Lint Lesson Accessibility (Admin)
+// @Description Find content some learners can't follow: questions that rely on telling colors apart, and a video without captions or audio description (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param lessonId path string true "Lesson ID"
+// @Success 200 {object} shared.Response{data=dto.LessonLintResponse}
+// @Failure 404 {object} shared.Response
+// @Router /api/v1/admin/lessons/{lessonId}/lint [get]
+func (h *AdminHandler) LintLesson(c *fiber.Ctx) error {
+	lint, err := h.contentSvc.LintLesson(c.Params("lessonId"))
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", lint)
+}
+
+// @Summary Get Lesson Video Analytics (Admin)
+// @Description Plays, skips and skip rate of a lesson video per day, and how much of it viewers watch on average (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param lessonId path string true "Lesson ID"
+// @Param days query int false "Days to count plays and skips over" default(30)
+// @Success 200 {object} shared.Response{data=dto.LessonVideoAnalyticsResponse}
+// @Router /api/v1/admin/lessons/{lessonId}/video-analytics [get]
+func (h *AdminHandler) GetLessonVideoAnalytics(c *fiber.Ctx) error {
+	days, _ := strconv.Atoi(c.Query("days", "30"))
+
+	analytics, err := h.contentSvc.GetLessonVideoAnalytics(c.Params("lessonId"), days)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", analytics)
+}
+
+// @Summary Get Lesson Versions (Admin)
+// @Description List the saved versions of a lesson, numbered from its content audit trail (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param lessonId path string true "Lesson ID"
+// @Success 200 {object} shared.Response{data=dto.LessonVersionListResponse}
+// @Router /api/v1/admin/lessons/{lessonId}/versions [get]
+func (h *AdminHandler) GetLessonVersions(c *fiber.Ctx) error {
+	versions, err := h.contentSvc.GetLessonVersions(c.Params("lessonId"))
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", versions)
+}
+
+// @Summary Diff Lesson Version (Admin)
+// @Description Compare a saved version of a lesson with the live lesson: story, questions, media and settings, with before values from the version (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param lessonId path string true "Lesson ID"
+// @Param version path int true "Version number"
+// @Success 200 {object} shared.Response{data=dto.LessonVersionDiffResponse}
+// @Failure 404 {object} shared.Response
+// @Router /api/v1/admin/lessons/{lessonId}/versions/{version}/diff [get]
+func (h *AdminHandler) GetLessonVersionDiff(c *fiber.Ctx) error {
+	version, err := strconv.Atoi(c.Params("version"))
+	if err != nil {
+		return shared.NewBadRequestError(err, "Version must be a number")
+	}
+
+	diff, err := h.contentSvc.GetLessonVersionDiff(c.Params("lessonId"), version)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", diff)
+}
+
+// @Summary Export Lesson Questions (Admin)
+// @Description Download a lesson's questions as a CSV or XLSX sheet for bulk editing (Admin only)
+// @Tags admin
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param lessonId path string true "Lesson ID"
+// @Param format query string false "csv or xlsx" default(csv)
+// @Success 200 {file} file
+// @Router /api/v1/admin/lessons/{lessonId}/questions/export [get]
+func (h *AdminHandler) ExportLessonQuestions(c *fiber.Ctx) error {
+	lessonID := c.Params("lessonId")
+	format := c.Query("format", "csv")
+	if format != "csv" && format != "xlsx" {
+		return shared.NewBadRequestError(nil, "format must be csv or xlsx")
+	}
+
+	data, filename, err := h.contentSvc.ExportLessonQuestions(lessonID, format)
+	if err != nil {
+		return err
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if format == "xlsx" {
+		contentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Attachment(filename)
+	return c.Send(data)
+}
+
+// @Summary Import Lesson Questions (Admin)
+// @Description Apply edits from a CSV or XLSX question sheet. Rows are matched by question id and only the columns present are changed. Send preview=true first to get the diff and base_version, then send the same file with base_version to apply all edits at once (Admin only)
+// @Tags admin
+// @Accept multipart/form-data
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param lessonId path string true "Lesson ID"
+// @Param file formData file true "Question sheet (.csv or .xlsx)"
+// @Param preview formData bool false "Only return the diff without saving"
+// @Param base_version formData string false "base_version from the preview, required to apply"
+// @Success 200 {object} shared.Response{data=dto.QuestionImportResponse}
+// @Failure 409 {object} shared.Response
+// @Failure 422 {object} shared.Response{data=dto.QuestionImportResponse}
+// @Router /api/v1/admin/lessons/{lessonId}/questions/import [post]
+func (h *AdminHandler) ImportLessonQuestions(c *fiber.Ctx) error {
+	lessonID := c.Params("lessonId")
+	adminID := c.Locals(shared.UserID).(string)
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return shared.NewBadRequestError(err, "No question sheet provided")
+	}
+	preview, _ := strconv.ParseBool(c.FormValue("preview", "false"))
+
+	result, err := h.contentSvc.ImportLessonQuestions(adminID, lessonID, file, preview, c.FormValue("base_version"))
+	if err != nil {
+		return err
+	}
+
+	if len(result.Errors) > 0 {
+		return shared.ResponseJSON(c, fiber.StatusUnprocessableEntity, "Question sheet has errors", result)
+	}
+	if result.Applied {
+		return shared.ResponseJSON(c, fiber.StatusOK, "Questions updated", result)
+	}
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", result)
+}
+
+// @Summary Get Content Audit Logs (Admin)
+// @Description Get the audit trail of admin content changes, filterable by entity and admin (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param entity_type query string false "Entity type (character, lesson, timeline, media)"
+// @Param entity_id query string false "Entity ID"
+// @Param admin_id query string false "Admin user ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} shared.Response{data=dto.ContentAuditLogListResponse}
+// @Router /api/v1/admin/audit/content [get]
+func (h *AdminHandler) GetContentAuditLogs(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	logs, err := h.contentSvc.GetContentAuditLogs(c.Query("entity_type"), c.Query("entity_id"), c.Query("admin_id"), page, limit)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", logs)
+}
+
+// @Summary Verify Auth Audit Log Chain (Admin)
+// @Description Check the hash chain of the auth audit log for modified, missing or inserted entries (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Success 200 {object} shared.Response{data=dto.AuditChainVerification}
+// @Router /api/v1/admin/audit/auth/verify [get]
+func (h *AdminHandler) VerifyAuditLogChain(c *fiber.Ctx) error {
+	result, err := h.authSvc.VerifyAuditLogChain()
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", result)
+}
+
+// @Summary Export Signed Auth Audit Log Segment (Admin)
+// @Description Export a range of the auth audit log chain signed with HMAC-SHA256, for archiving outside the database (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param from query int true "First sequence"
+// @Param to query int true "Last sequence, at most 10000 after from"
+// @Success 200 {object} shared.Response{data=dto.AuditLogSegment}
+// @Router /api/v1/admin/audit/auth/export [get]
+func (h *AdminHandler) ExportAuditLogSegment(c *fiber.Ctx) error {
+	from, err := strconv.ParseInt(c.Query("from"), 10, 64)
+	if err != nil {
+		return shared.NewBadRequestError(err, "Invalid from sequence")
+	}
+	to, err := strconv.ParseInt(c.Query("to"), 10, 64)
+	if err != nil {
+		return shared.NewBadRequestError(err, "Invalid to sequence")
+	}
+
+	segment, err := h.authSvc.ExportAuditLogSegment(from, to)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", segment)
+}
+
+// @Summary Get System Statistics (Admin)
+// @Description Get user, session and login statistics together with database, Redis and MinIO health (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Success 200 {object} shared.Response{data=dto.SystemStatisticsResponse}
+// @Router /api/v1/admin/stats/system [get]
+func (h *AdminHandler) GetSystemStatistics(c *fiber.Ctx) error {
+	stats, err := h.systemSvc.GetSystemStatistics()
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", stats)
+}
+
+// @Summary Get Slow Queries (Admin)
+// @Description Get database queries slower than DB_SLOW_QUERY_MS since the API started, with the calling service and a hint when the plan suggests a missing index (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Success 200 {object} shared.Response{data=dto.SlowQueryResponse}
+// @Router /api/v1/admin/db/slow-queries [get]
+func (h *AdminHandler) GetSlowQueries(c *fiber.Ctx) error {
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", h.systemSvc.GetSlowQueries())
+}
+
+// @Summary Stream Ops Events (Admin)
+// @Description Upgrade to a WebSocket that pushes live logins, registrations, rate-limit blocks, server errors and lesson completions per minute as JSON messages. Browsers can authenticate with the access token cookie (Admin only)
+// @Tags admin
+// @Security Bearer
+// @Param Authorization header string false "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param min_severity query string false "Lowest severity to receive (info, warning, error)" default(info)
+// @Success 101 {object} dto.OpsEvent
+// @Failure 400 {object} shared.Response
+// @Failure 426 {object} shared.Response
+// @Router /api/v1/admin/ops/stream [get]
+func (h *AdminHandler) StreamOpsEvents(c *fiber.Ctx) error {
+	minSeverity := c.Query("min_severity", "info")
+	switch minSeverity {
+	case "info", "warning", "error":
+	default:
+		return shared.NewBadRequestError(nil, "min_severity must be one of info, warning, error")
+	}
+
+	if !isWebSocketUpgrade(c) {
+		return shared.ResponseJSON(c, fiber.StatusUpgradeRequired, "WebSocket upgrade required", nil)
+	}
+
+	return upgradeWebSocket(c, func(ws *wsConn) {
+		events, unsubscribe := h.systemSvc.SubscribeOpsEvents(minSeverity)
+		defer unsubscribe()
+
+		ticker := time.NewTicker(wsPingInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ws.Done():
+				return
+			case <-ticker.C:
+				if err := ws.Ping(); err != nil {
+					return
+				}
+			case event := <-events:
+				if err := ws.WriteJSON(event); err != nil {
+					return
+				}
+			}
+		}
+	})
+}
+
+// @Summary Get Game Config (Admin)
+// @Description Get tunable game balance settings such as the comeback bonus (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Success 200 {object} shared.Response{data=model.GameConfig}
+// @Router /api/v1/admin/game-config [get]
+func (h *AdminHandler) GetGameConfig(c *fiber.Ctx) error {
+	config, err := h.userSvc.GetGameConfig()
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", config)
+}
+
+// @Summary Update Game Config (Admin)
+// @Description Update game balance settings. Omitted fields are left unchanged (Admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param config body dto.UpdateGameConfigRequest true "Settings to change"
+// @Success 200 {object} shared.Response{data=model.GameConfig}
+// @Router /api/v1/admin/game-config [put]
+func (h *AdminHandler) UpdateGameConfig(c *fiber.Ctx) error {
+	var req dto.UpdateGameConfigRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	adminID := c.Locals(shared.UserID).(string)
+	config, err := h.userSvc.UpdateGameConfig(adminID, req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Game config updated", config)
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/shared"
+)
+
+type AppVersionHandler struct {
+	appVersionSvc AppVersionServiceInterface
+}
+
+func NewAppVersionHandler(appVersionSvc AppVersionServiceInterface) *AppVersionHandler {
+	return &AppVersionHandler{
+		appVersionSvc: appVersionSvc,
+	}
+}
+
+// @Summary Get Supported App Versions
+// @Description Get the minimum supported and latest app version per platform. When the app sends X-App-Platform and X-App-Version, its entry says whether it must or can update. Available to outdated apps.
+// @Tags system
+// @Produce json
+// @Param X-App-Platform header string false "Calling app platform" Enums(ios, android)
+// @Param X-App-Version header string false "Calling app version" example(2.4.1)
+// @Success 200 {object} shared.Response{data=dto.AppVersionResponse}
+// @Router /api/v1/app/version [get]
+func (h *AppVersionHandler) GetVersions(c *fiber.Ctx) error {
+	resp := h.appVersionSvc.GetVersions(c.Get(shared.AppPlatformHeader), c.Get(shared.AppVersionHeader))
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", resp)
+}
+
+// @Summary List App Version Policies (Admin)
+// @Description Get the supported app version range of each configured platform (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Success 200 {object} shared.Response{data=[]model.AppVersionPolicy}
+// @Router /api/v1/admin/app-versions [get]
+func (h *AppVersionHandler) GetPolicies(c *fiber.Ctx) error {
+	policies, err := h.appVersionSvc.GetPolicies()
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", policies)
+}
+
+// @Summary Update App Version Policy (Admin)
+// @Description Set the minimum supported and latest app version of a platform. Apps below the minimum are refused with 426 and a force-update payload (Admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param platform path string true "Platform" Enums(ios, android)
+// @Param request body dto.UpdateAppVersionRequest true "Version range"
+// @Success 200 {object} shared.Response{data=model.AppVersionPolicy}
+// @Router /api/v1/admin/app-versions/{platform} [put]
+func (h *AppVersionHandler) UpdatePolicy(c *fiber.Ctx) error {
+	var req dto.UpdateAppVersionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	adminID := c.Locals(shared.UserID).(string)
+	policy, err := h.appVersionSvc.UpdatePolicy(adminID, c.Params("platform"), req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "App version policy updated", policy)
+}
@@ -50,10 +50,11 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 }
 
 // @Summary Login user
-// @Description Authenticate user and return access token
+// @Description Authenticate user and return access token. With X-Auth-Mode: cookie the tokens are set as HttpOnly cookies and a CSRF token is returned instead
 // @Tags auth
 // @Accept json
 // @Produce json
+// @Param X-Auth-Mode header string false "Set to 'cookie' for cookie-based sessions"
 // @Param loginRequest body dto.LoginRequest true "Login credentials"
 // @Success 200 {object} shared.Response{data=dto.LoginResponse}
 // @Router /api/v1/login [post]
@@ -68,7 +69,7 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
 	}
 
-	clientIP := c.IP()
+	clientIP := shared.RequestIP(c)
 	userAgent := c.Get("User-Agent")
 
 	resp, err := h.authSvc.Login(req, clientIP, userAgent)
@@ -76,20 +77,66 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 		return err
 	}
 
+	if h.authSvc.UsesCookieSession(c) {
+		if err := h.authSvc.SetSessionCookies(c, resp); err != nil {
+			return err
+		}
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Login successful", resp)
+}
+
+// @Summary Login with two-factor code
+// @Description Second step of the login for users with two-factor authentication, sent after /login answered with two_factor_required. The code is from the authenticator app or one of the backup codes; each backup code works once
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param X-Auth-Mode header string false "Set to 'cookie' for cookie-based sessions"
+// @Param loginRequest body dto.TwoFactorLoginRequest true "Login credentials and two-factor code"
+// @Success 200 {object} shared.Response{data=dto.LoginResponse}
+// @Failure 401 {object} shared.Response "Invalid credentials or two-factor code"
+// @Router /api/v1/login/2fa [post]
+func (h *AuthHandler) LoginTwoFactor(c *fiber.Ctx) error {
+	var req dto.TwoFactorLoginRequest
+	if err := c.BodyParser(&req); err != nil {
+		return err
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	resp, err := h.authSvc.LoginTwoFactor(req, shared.RequestIP(c), c.Get("User-Agent"))
+	if err != nil {
+		return err
+	}
+
+	if h.authSvc.UsesCookieSession(c) {
+		if err := h.authSvc.SetSessionCookies(c, resp); err != nil {
+			return err
+		}
+	}
+
 	return shared.ResponseJSON(c, http.StatusOK, "Login successful", resp)
 }
 
 // @Summary Refresh access token
-// @Description Generate new access token using refresh token
+// @Description Generate new access token using refresh token. In cookie mode the refresh token cookie is used and the body may be omitted
 // @Tags auth
 // @Accept json
 // @Produce json
-// @Param refreshRequest body dto.RefreshTokenRequest true "Refresh token"
+// @Param X-Auth-Mode header string false "Set to 'cookie' for cookie-based sessions"
+// @Param refreshRequest body dto.RefreshTokenRequest false "Refresh token"
 // @Success 200 {object} shared.Response{data=dto.LoginResponse}
 // @Router /api/v1/refresh [post]
 func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
 	var req dto.RefreshTokenRequest
-	if err := c.BodyParser(&req); err != nil {
+	cookieSession := h.authSvc.UsesCookieSession(c)
+
+	if cookieSession && c.Cookies(shared.RefreshTokenCookie) != "" {
+		req.RefreshToken = c.Cookies(shared.RefreshTokenCookie)
+	} else if err := c.BodyParser(&req); err != nil {
 		return err
 	}
 
@@ -98,7 +145,7 @@ func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
 	}
 
-	clientIP := c.IP()
+	clientIP := shared.RequestIP(c)
 	userAgent := c.Get("User-Agent")
 
 	resp, err := h.authSvc.RefreshToken(req, clientIP, userAgent)
@@ -106,6 +153,12 @@ func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
 		return err
 	}
 
+	if cookieSession {
+		if err := h.authSvc.SetSessionCookies(c, resp); err != nil {
+			return err
+		}
+	}
+
 	return shared.ResponseJSON(c, http.StatusOK, "Token refreshed successfully", resp)
 }
 
@@ -121,17 +174,18 @@ func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
 func (h *AuthHandler) Logout(c *fiber.Ctx) error {
 	userID := c.Locals(shared.UserID).(string)
 	sessionID := c.Locals("session_id").(string)
-	clientIP := c.IP()
+	clientIP := shared.RequestIP(c)
 	userAgent := c.Get("User-Agent")
 
-	authHeader := c.Get("Authorization")
-	accessToken, _ := h.jwtSvc.ExtractTokenFromHeader(authHeader)
+	accessToken, _ := h.authSvc.ExtractAccessToken(c)
 
 	err := h.authSvc.Logout(userID, sessionID, accessToken, clientIP, userAgent)
 	if err != nil {
 		return err
 	}
 
+	h.authSvc.ClearSessionCookies(c)
+
 	return shared.ResponseJSON(c, http.StatusOK, "Logged out successfully", nil)
 }
 
@@ -147,17 +201,18 @@ func (h *AuthHandler) Logout(c *fiber.Ctx) error {
 func (h *AuthHandler) LogoutAll(c *fiber.Ctx) error {
 	userID := c.Locals(shared.UserID).(string)
 	sessionID := c.Locals("session_id").(string)
-	clientIP := c.IP()
+	clientIP := shared.RequestIP(c)
 	userAgent := c.Get("User-Agent")
 
-	authHeader := c.Get("Authorization")
-	accessToken, _ := h.jwtSvc.ExtractTokenFromHeader(authHeader)
+	accessToken, _ := h.authSvc.ExtractAccessToken(c)
 
 	err := h.authSvc.LogoutAllDevices(userID, sessionID, accessToken, clientIP, userAgent)
 	if err != nil {
 		return err
 	}
 
+	h.authSvc.ClearSessionCookies(c)
+
 	return shared.ResponseJSON(c, http.StatusOK, "Logged out from all devices successfully", nil)
 }
 
@@ -187,6 +242,26 @@ func (h *AuthHandler) VerifyEmail(c *fiber.Ctx) error {
 	return shared.ResponseJSON(c, http.StatusOK, "Email verified successfully", nil)
 }
 
+// @Summary Verify email via link
+// @Description Verify user email with the token from the one-tap deep link (ven://verify?token=...) in the verification email. Each link works once and is replaced when a new code is requested
+// @Tags auth
+// @Produce json
+// @Param token query string true "Token from the verification link"
+// @Success 200 {object} shared.Response{data=nil}
+// @Router /api/v1/verify-link [get]
+func (h *AuthHandler) VerifyEmailLink(c *fiber.Ctx) error {
+	token := c.Query("token")
+	if token == "" {
+		return shared.NewBadRequestError(nil, "Token is required")
+	}
+
+	if err := h.authSvc.VerifyEmailLink(token); err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Email verified successfully", nil)
+}
+
 // @Summary Resend verification email
 // @Description Send a new verification email to user
 // @Tags auth
@@ -268,6 +343,205 @@ func (h *AuthHandler) ResetPassword(c *fiber.Ctx) error {
 	return shared.ResponseJSON(c, http.StatusOK, "Password reset successfully", nil)
 }
 
+// @Summary Email change revert page
+// @Description Page opened by the link sent to the previous address. It asks for confirmation and posts the token to the revert endpoint
+// @Tags auth
+// @Produce html
+// @Param token query string true "Revert token from the email link"
+// @Success 200 {string} string "Confirmation page"
+// @Router /api/v1/email-change/revert [get]
+func (h *AuthHandler) RevertEmailChangePage(c *fiber.Ctx) error {
+	return renderLinkConfirmation(c, "Undo email change",
+		"Your account's email address is being changed. If you didn't ask for this, undo it to keep this address and sign out every device.",
+		"Undo the change")
+}
+
+// @Summary Revert email change
+// @Description Undo an email change using the link sent to the previous address. Restores the old address and signs out every session. Form posts from the revert page are answered with a page
+// @Tags auth
+// @Accept json,x-www-form-urlencoded
+// @Produce json,html
+// @Param revertRequest body dto.RevertEmailChangeRequest true "Revert token from the email link"
+// @Success 200 {object} shared.Response{data=nil}
+// @Router /api/v1/email-change/revert [post]
+func (h *AuthHandler) RevertEmailChange(c *fiber.Ctx) error {
+	var req dto.RevertEmailChangeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		if isFormPost(c) {
+			return linkActionResult(c, shared.NewBadRequestError(err, "This link is invalid"), "", "")
+		}
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	err := h.authSvc.RevertEmailChange(req.Token)
+	return linkActionResult(c, err, "Email change undone", "Email change reverted. Please log in again")
+}
+
+// @Summary Device link page
+// @Description Page opened by the trust or untrust link of a login email. It asks for confirmation and posts the token to the device action endpoint
+// @Tags auth
+// @Produce html
+// @Param token query string true "Token from the email link"
+// @Success 200 {string} string "Confirmation page"
+// @Router /api/v1/devices/action [get]
+func (h *AuthHandler) DeviceActionPage(c *fiber.Ctx) error {
+	return renderLinkConfirmation(c, "Update device",
+		"Confirm to apply the change to the device from your login email. Untrusting a device also signs it out.",
+		"Confirm")
+}
+
+// @Summary Trust or untrust a device from a login email
+// @Description Apply the trust or untrust link of a login email. Trusted devices sign in without login emails but still need the two-factor code; untrusting a device also signs it out. Each link works once. Form posts from the device link page are answered with a page
+// @Tags auth
+// @Accept json,x-www-form-urlencoded
+// @Produce json,html
+// @Param actionRequest body dto.DeviceActionRequest true "Token from the email link"
+// @Success 200 {object} shared.Response{data=nil}
+// @Router /api/v1/devices/action [post]
+func (h *AuthHandler) ApplyDeviceAction(c *fiber.Ctx) error {
+	var req dto.DeviceActionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		if isFormPost(c) {
+			return linkActionResult(c, shared.NewBadRequestError(err, "This link is invalid"), "", "")
+		}
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	err := h.authSvc.ApplyDeviceAction(req.Token, shared.RequestIP(c), c.Get("User-Agent"))
+	return linkActionResult(c, err, "Device updated", "Device updated")
+}
+
+// @Summary Start account recovery
+// @Description Recover an account whose email is no longer reachable. Trusted devices are asked to approve the move to the new address; without an answer it can be completed after a delay. The returned token is shown once and is needed to complete the recovery
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param recoveryRequest body dto.StartAccountRecoveryRequest true "Account and new email address"
+// @Success 200 {object} shared.Response{data=dto.AccountRecoveryStartedResponse}
+// @Failure 409 {object} shared.Response "New email is already taken"
+// @Router /api/v1/account-recovery [post]
+func (h *AuthHandler) StartAccountRecovery(c *fiber.Ctx) error {
+	var req dto.StartAccountRecoveryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	resp, err := h.authSvc.StartAccountRecovery(req, shared.RequestIP(c), c.Get("User-Agent"))
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Account recovery requested", resp)
+}
+
+// @Summary Get account recovery status
+// @Description Check whether a recovery request was approved or can be completed after the delay
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param statusRequest body dto.AccountRecoveryStatusRequest true "Recovery ID and token"
+// @Success 200 {object} shared.Response{data=dto.AccountRecoveryStatusResponse}
+// @Router /api/v1/account-recovery/status [post]
+func (h *AuthHandler) GetAccountRecoveryStatus(c *fiber.Ctx) error {
+	var req dto.AccountRecoveryStatusRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	resp, err := h.authSvc.GetAccountRecoveryStatus(req.RecoveryID, req.Token)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Success", resp)
+}
+
+// @Summary Complete account recovery
+// @Description Move the account to the new email with a new password once a trusted device approved it or the delay passed. Signs out every session and sends a verification code to the new address
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param completeRequest body dto.CompleteAccountRecoveryRequest true "Recovery ID, token and new password"
+// @Success 200 {object} shared.Response{data=nil}
+// @Failure 403 {object} shared.Response "Still waiting for approval"
+// @Router /api/v1/account-recovery/complete [post]
+func (h *AuthHandler) CompleteAccountRecovery(c *fiber.Ctx) error {
+	var req dto.CompleteAccountRecoveryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	if err := h.authSvc.CompleteAccountRecovery(req, shared.RequestIP(c), c.Get("User-Agent")); err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Account recovered. Please verify your new email address", nil)
+}
+
+// @Summary Account recovery cancel page
+// @Description Page opened by the link sent to the account's current address. It asks for confirmation and posts the token to the cancel endpoint
+// @Tags auth
+// @Produce html
+// @Param token query string true "Cancel token from the email link"
+// @Success 200 {string} string "Confirmation page"
+// @Router /api/v1/account-recovery/cancel [get]
+func (h *AuthHandler) CancelAccountRecoveryPage(c *fiber.Ctx) error {
+	return renderLinkConfirmation(c, "Stop account recovery",
+		"Someone asked to move your account to a new email address. If it wasn't you, stop the recovery to keep your account.",
+		"Stop the recovery")
+}
+
+// @Summary Cancel account recovery
+// @Description Stop a recovery request using the link sent to the account's current address. Form posts from the cancel page are answered with a page
+// @Tags auth
+// @Accept json,x-www-form-urlencoded
+// @Produce json,html
+// @Param cancelRequest body dto.CancelAccountRecoveryRequest true "Cancel token from the email link"
+// @Success 200 {object} shared.Response{data=nil}
+// @Router /api/v1/account-recovery/cancel [post]
+func (h *AuthHandler) CancelAccountRecovery(c *fiber.Ctx) error {
+	var req dto.CancelAccountRecoveryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		if isFormPost(c) {
+			return linkActionResult(c, shared.NewBadRequestError(err, "This link is invalid"), "", "")
+		}
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	err := h.authSvc.CancelAccountRecovery(req.Token, shared.RequestIP(c), c.Get("User-Agent"))
+	return linkActionResult(c, err, "Account recovery stopped", "Account recovery cancelled")
+}
+
 // @Summary Change password
 // @Description Change password for authenticated user
 // @Tags auth
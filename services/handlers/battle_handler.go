@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/shared"
+)
+
+type BattleHandler struct {
+	battleSvc BattleServiceInterface
+}
+
+func NewBattleHandler(battleSvc BattleServiceInterface) *BattleHandler {
+	return &BattleHandler{
+		battleSvc: battleSvc,
+	}
+}
+
+// @Summary Start spirit battle
+// @Description Start a battle against a dynasty boss using questions from completed lessons. Returns the current battle if one is already active.
+// @Tags battle
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param request body dto.StartBattleRequest true "Dynasty to battle"
+// @Success 200 {object} shared.Response{data=dto.BattleResponse}
+// @Router /api/v1/user/battles [post]
+func (h *BattleHandler) StartBattle(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	var req dto.StartBattleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.CreateValidationErrorResponse(err))
+	}
+
+	battle, err := h.battleSvc.StartBattle(userID, req.Dynasty)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", battle)
+}
+
+// @Summary Get spirit battle
+// @Description Get battle state and the current question
+// @Tags battle
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param battleId path string true "Battle ID"
+// @Success 200 {object} shared.Response{data=dto.BattleResponse}
+// @Router /api/v1/user/battles/{battleId} [get]
+func (h *BattleHandler) GetBattle(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	battle, err := h.battleSvc.GetBattle(userID, c.Params("battleId"))
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", battle)
+}
+
+// @Summary Act in spirit battle
+// @Description Answer the current question. Correct answers damage the boss, wrong answers damage your spirit.
+// @Tags battle
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param battleId path string true "Battle ID"
+// @Param request body dto.BattleActionRequest true "Answer to the current question"
+// @Success 200 {object} shared.Response{data=dto.BattleActionResponse}
+// @Router /api/v1/user/battles/{battleId}/act [post]
+func (h *BattleHandler) Act(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	var req dto.BattleActionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.CreateValidationErrorResponse(err))
+	}
+
+	result, err := h.battleSvc.Act(userID, c.Params("battleId"), req.Answer)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", result)
+}
+
+// @Summary Resolve spirit battle
+// @Description Claim the reward for a won battle, or forfeit an active one
+// @Tags battle
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param battleId path string true "Battle ID"
+// @Success 200 {object} shared.Response{data=dto.BattleResultResponse}
+// @Router /api/v1/user/battles/{battleId}/resolve [post]
+func (h *BattleHandler) ResolveBattle(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	result, err := h.battleSvc.ResolveBattle(userID, c.Params("battleId"))
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", result)
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/shared"
+)
+
+type CacheHandler struct {
+	cacheSvc CacheServiceInterface
+}
+
+func NewCacheHandler(cacheSvc CacheServiceInterface) *CacheHandler {
+	return &CacheHandler{
+		cacheSvc: cacheSvc,
+	}
+}
+
+// @Summary Invalidate Caches
+// @Description Clear the cached copies of a lesson, character, user or all content at once, e.g. after an emergency content fix. Content caches are cleared on every API instance; the invalidation is recorded in the content audit log (Admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param request body dto.InvalidateCacheRequest true "Scope to invalidate"
+// @Success 200 {object} shared.Response{data=dto.CacheInvalidationResponse}
+// @Failure 404 {object} shared.Response "Lesson, character or user not found"
+// @Router /api/v1/admin/cache/invalidate [post]
+func (h *CacheHandler) Invalidate(c *fiber.Ctx) error {
+	var req dto.InvalidateCacheRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	adminID := c.Locals(shared.UserID).(string)
+	resp, err := h.cacheSvc.Invalidate(adminID, req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Caches invalidated", resp)
+}
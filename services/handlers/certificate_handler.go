@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/lac-hong-legacy/ven_api/shared"
+)
+
+type CertificateHandler struct {
+	certificateSvc CertificateServiceInterface
+}
+
+func NewCertificateHandler(certificateSvc CertificateServiceInterface) *CertificateHandler {
+	return &CertificateHandler{
+		certificateSvc: certificateSvc,
+	}
+}
+
+// @Summary Get certificates
+// @Description Get the certificates the user earned by completing every lesson of a dynasty, with links to their images
+// @Tags certificates
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Success 200 {object} shared.Response{data=[]dto.CertificateResponse}
+// @Router /api/v1/certificates [get]
+func (h *CertificateHandler) GetCertificates(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	certificates, err := h.certificateSvc.GetCertificates(userID)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", certificates)
+}
+
+// @Summary Get certificate
+// @Description Get a certificate with a summary of the dynasty's lessons and the user's scores
+// @Tags certificates
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param certificateId path string true "Certificate ID"
+// @Success 200 {object} shared.Response{data=dto.CertificateDetailResponse}
+// @Failure 404 {object} shared.Response "Certificate not found"
+// @Router /api/v1/certificates/{certificateId} [get]
+func (h *CertificateHandler) GetCertificate(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	certificate, err := h.certificateSvc.GetCertificate(userID, c.Params("certificateId"))
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", certificate)
+}
+
+// @Summary Verify certificate
+// @Description Check a certificate by the code printed on it. No sign-in needed.
+// @Tags certificates
+// @Accept json
+// @Produce json
+// @Param code path string true "Verification code, e.g. K7PQ-3XWM"
+// @Success 200 {object} shared.Response{data=dto.CertificateVerificationResponse}
+// @Failure 404 {object} shared.Response "Certificate not found"
+// @Router /api/v1/certificates/verify/{code} [get]
+func (h *CertificateHandler) VerifyCertificate(c *fiber.Ctx) error {
+	verification, err := h.certificateSvc.VerifyCertificate(c.Params("code"))
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", verification)
+}
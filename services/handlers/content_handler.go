@@ -3,6 +3,7 @@ package handlers
 import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
 	"github.com/lac-hong-legacy/ven_api/shared"
 )
 
@@ -73,17 +74,19 @@ func (h *ContentHandler) GetCharacter(c *fiber.Ctx) error {
 }
 
 // @Summary Get Character Lessons
-// @Description Get all lessons for a specific character
+// @Description Get all lessons for a specific character. Lessons rated above the caller's age are left out; anonymous callers only get lessons for all ages.
 // @Tags content
 // @Accept json
 // @Produce json
+// @Param Authorization header string false "User Bearer Token" default(Bearer <user_token>)
 // @Param characterId path string true "Character ID"
 // @Success 200 {object} shared.Response{data=[]dto.LessonResponse}
 // @Router /api/v1/content/characters/{characterId}/lessons [get]
 func (h *ContentHandler) GetCharacterLessons(c *fiber.Ctx) error {
 	characterID := c.Params("characterId")
+	userID, _ := c.Locals(shared.UserID).(string)
 
-	lessons, err := h.contentSvc.GetCharacterLessons(characterID)
+	lessons, err := h.contentSvc.GetCharacterLessons(characterID, userID)
 	if err != nil {
 		return err
 	}
@@ -92,17 +95,27 @@ func (h *ContentHandler) GetCharacterLessons(c *fiber.Ctx) error {
 }
 
 // @Summary Get Lesson
-// @Description Get detailed lesson content including questions
+// @Description Get detailed lesson content including questions. Text is returned in the requested locale when an approved translation exists, otherwise in Vietnamese. Lessons rated above the caller's age are refused.
 // @Tags content
 // @Accept json
 // @Produce json
+// @Param Authorization header string false "User Bearer Token" default(Bearer <user_token>)
 // @Param lessonId path string true "Lesson ID"
+// @Param locale query string false "Locale (falls back to Accept-Language)" example(en)
+// @Param Accept-Language header string false "Preferred languages"
 // @Success 200 {object} shared.Response{data=dto.LessonResponse}
+// @Failure 403 {object} shared.Response "Lesson not available for the caller's age"
 // @Router /api/v1/content/lessons/{lessonId} [get]
 func (h *ContentHandler) GetLesson(c *fiber.Ctx) error {
 	lessonID := c.Params("lessonId")
+	userID, _ := c.Locals(shared.UserID).(string)
 
-	lesson, err := h.contentSvc.GetLessonContent(lessonID)
+	locale := c.Query("locale")
+	if locale == "" {
+		locale = c.AcceptsLanguages(append([]string{model.SourceLocale}, model.SupportedLocales...)...)
+	}
+
+	lesson, err := h.contentSvc.GetLessonContent(lessonID, locale, userID)
 	if err != nil {
 		return err
 	}
@@ -111,7 +124,7 @@ func (h *ContentHandler) GetLesson(c *fiber.Ctx) error {
 }
 
 // @Summary Validate Lesson Answers
-// @Description Validate user answers for a lesson and return score
+// @Description Validate user answers for a lesson and return the score with a per-question breakdown. Connect and drag_drop questions earn a share of their points per right pair or position, and question points are weighted by category.
 // @Tags content
 // @Accept json
 // @Produce json
@@ -138,14 +151,16 @@ func (h *ContentHandler) ValidateLessonAnswers(c *fiber.Ctx) error {
 }
 
 // @Summary Search Content
-// @Description Search characters and content with various filters
+// @Description Search characters (name, description) and lessons (title, story, question text). Results are typed, ranked with title matches first, and include a highlighted snippet
 // @Tags content
 // @Accept json
 // @Produce json
 // @Param query query string false "Search query"
 // @Param dynasty query string false "Filter by dynasty"
 // @Param rarity query string false "Filter by rarity"
-// @Param limit query int false "Limit results"
+// @Param type query string false "Only return character or lesson results"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Limit results" default(20)
 // @Success 200 {object} shared.Response{data=dto.SearchResponse}
 // @Router /api/v1/content/search [get]
 func (h *ContentHandler) SearchContent(c *fiber.Ctx) error {
@@ -171,6 +186,70 @@ func (h *ContentHandler) SearchContent(c *fiber.Ctx) error {
 	return shared.ResponseJSON(c, fiber.StatusOK, "Success", results)
 }
 
+// @Summary Search Suggestions
+// @Description Typeahead suggestions for character names, dynasties and lesson titles. Any word of a name can match the start of the query; case and Vietnamese diacritics are ignored
+// @Tags content
+// @Produce json
+// @Param q query string true "Text typed so far"
+// @Param limit query int false "Maximum suggestions" default(10)
+// @Success 200 {object} shared.Response{data=dto.SearchSuggestResponse}
+// @Router /api/v1/search/suggest [get]
+func (h *ContentHandler) SuggestSearch(c *fiber.Ctx) error {
+	var req dto.SearchSuggestRequest
+	if err := c.QueryParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid query parameters")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	if req.Limit == 0 {
+		req.Limit = 10
+	}
+
+	results, err := h.contentSvc.SuggestSearch(req.Query, req.Limit)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", results)
+}
+
+// @Summary Trending Content
+// @Description Lessons and characters ranked by views, starts and completions over the last 7 days, for the home screen. Counters are updated about once a minute. Lessons rated above the caller's age are left out.
+// @Tags content
+// @Produce json
+// @Param Authorization header string false "User Bearer Token" default(Bearer <user_token>)
+// @Param type query string false "Only lessons or only characters" Enums(lesson, character)
+// @Param limit query int false "Maximum items" default(10)
+// @Success 200 {object} shared.Response{data=dto.TrendingContentResponse}
+// @Router /api/v1/content/trending [get]
+func (h *ContentHandler) GetTrendingContent(c *fiber.Ctx) error {
+	var req dto.TrendingContentRequest
+	if err := c.QueryParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid query parameters")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	if req.Limit == 0 {
+		req.Limit = 10
+	}
+
+	userID, _ := c.Locals(shared.UserID).(string)
+	results, err := h.contentSvc.GetTrendingContent(req.Type, req.Limit, userID)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", results)
+}
+
 // @Summary Submit Question Answer
 // @Description Submit answer for individual question in a lesson
 // @Tags content
@@ -233,8 +312,185 @@ func (h *ContentHandler) CheckLessonStatus(c *fiber.Ctx) error {
 	return shared.ResponseJSON(c, fiber.StatusOK, "Lesson status retrieved", result)
 }
 
+// @Summary Get lesson session
+// @Description Get the lesson the user left unfinished so the app can resume it: answered questions, video position and time left before it starts over
+// @Tags content
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param lessonId path string true "Lesson ID"
+// @Success 200 {object} shared.Response{data=dto.LessonSessionResponse}
+// @Failure 404 {object} shared.Response "No lesson in progress"
+// @Router /api/v1/lessons/{lessonId}/session [get]
+func (h *ContentHandler) GetLessonSession(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+	lessonID := c.Params("lessonId")
+
+	session, err := h.contentSvc.GetLessonSession(userID, lessonID)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Lesson session retrieved", session)
+}
+
+// @Summary Update lesson session
+// @Description Save the video position and time spent in a lesson in progress, starting a session if there is none
+// @Tags content
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param lessonId path string true "Lesson ID"
+// @Param request body dto.UpdateLessonSessionRequest true "Session checkpoint"
+// @Success 200 {object} shared.Response{data=dto.LessonSessionResponse}
+// @Router /api/v1/lessons/{lessonId}/session [put]
+func (h *ContentHandler) UpdateLessonSession(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+	lessonID := c.Params("lessonId")
+
+	var req dto.UpdateLessonSessionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	session, err := h.contentSvc.UpdateLessonSession(userID, lessonID, req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Lesson session saved", session)
+}
+
+// @Summary Restart lesson
+// @Description Discard the lesson in progress and the answers given in it
+// @Tags content
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param lessonId path string true "Lesson ID"
+// @Success 200 {object} shared.Response{data=nil}
+// @Router /api/v1/lessons/{lessonId}/session [delete]
+func (h *ContentHandler) RestartLessonSession(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+	lessonID := c.Params("lessonId")
+
+	if err := h.contentSvc.RestartLessonSession(userID, lessonID); err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Lesson restarted", nil)
+}
+
+// @Summary Record video progress
+// @Description Heartbeat sent while a lesson video plays, and when the user skips it or it ends. Watched time decides the score bonus XP of the lesson
+// @Tags content
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param lessonId path string true "Lesson ID"
+// @Param request body dto.VideoProgressRequest true "Video heartbeat"
+// @Success 200 {object} shared.Response{data=dto.VideoProgressResponse}
+// @Router /api/v1/lessons/{lessonId}/video-progress [post]
+func (h *ContentHandler) RecordVideoProgress(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+	lessonID := c.Params("lessonId")
+
+	var req dto.VideoProgressRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	progress, err := h.contentSvc.RecordVideoProgress(userID, lessonID, req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Video progress recorded", progress)
+}
+
+// @Summary Use fifty-fifty
+// @Description Take one wrong option out of an unanswered multiple choice question in the lesson being played, paid with half a heart or coins. Each lesson attempt has a limited number; asking again for the same question returns the same option without charging
+// @Tags content
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param lessonId path string true "Lesson ID"
+// @Param questionId path string true "Question ID"
+// @Param request body dto.UseLessonAidRequest true "Payment"
+// @Success 200 {object} shared.Response{data=dto.FiftyFiftyResponse}
+// @Failure 400 {object} shared.Response "No fifty-fifty left, or not enough hearts or coins"
+// @Router /api/v1/lessons/{lessonId}/questions/{questionId}/fifty-fifty [post]
+func (h *ContentHandler) UseFiftyFifty(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+	lessonID := c.Params("lessonId")
+	questionID := c.Params("questionId")
+
+	var req dto.UseLessonAidRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	result, err := h.contentSvc.UseFiftyFifty(userID, lessonID, questionID, req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Fifty-fifty used", result)
+}
+
+// @Summary Report a question
+// @Description Flag a question as wrong or confusing. Each learner can report a question once; reporting it again returns already_reported. Questions with enough unreviewed reports are taken out of their lesson until an admin reviews them
+// @Tags content
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param id path string true "Question ID"
+// @Param request body dto.ReportQuestionRequest true "Lesson and reason"
+// @Success 200 {object} shared.Response{data=dto.QuestionReportResponse}
+// @Failure 404 {object} shared.Response "Lesson or question not found"
+// @Router /api/v1/questions/{id}/report [post]
+func (h *ContentHandler) ReportQuestion(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	var req dto.ReportQuestionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	report, err := h.contentSvc.ReportQuestion(userID, c.Params("id"), req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Question reported", report)
+}
+
 // @Summary Get Eras
-// @Description Get list of eras
+// @Description Get the era codes in display order
 // @Tags content
 // @Accept json
 // @Produce json
@@ -249,7 +505,7 @@ func (h *ContentHandler) GetEras(c *fiber.Ctx) error {
 }
 
 // @Summary Get Dynasties
-// @Description Get list of dynasties
+// @Description Get the dynasty names in display order
 // @Tags content
 // @Accept json
 // @Produce json
@@ -262,3 +518,46 @@ func (h *ContentHandler) GetDynasties(c *fiber.Ctx) error {
 	}
 	return shared.ResponseJSON(c, fiber.StatusOK, "Success", dynasties)
 }
+
+// @Summary Search Glossary
+// @Description Search the glossary of historical terms by term, alias or definition
+// @Tags content
+// @Produce json
+// @Param q query string false "Search text" example(Bắc thuộc)
+// @Param era query string false "Era filter"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} shared.Response{data=dto.GlossaryTermListResponse}
+// @Router /api/v1/glossary [get]
+func (h *ContentHandler) SearchGlossary(c *fiber.Ctx) error {
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 20)
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	terms, err := h.contentSvc.SearchGlossary(c.Query("q"), c.Query("era"), page, limit)
+	if err != nil {
+		return err
+	}
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", terms)
+}
+
+// @Summary Get Glossary Term
+// @Description Get a glossary term, e.g. after tapping a linked term in a lesson story
+// @Tags content
+// @Produce json
+// @Param termId path string true "Term ID"
+// @Success 200 {object} shared.Response{data=model.GlossaryTerm}
+// @Router /api/v1/glossary/{termId} [get]
+func (h *ContentHandler) GetGlossaryTerm(c *fiber.Ctx) error {
+	term, err := h.contentSvc.GetGlossaryTerm(c.Params("termId"))
+	if err != nil {
+		return err
+	}
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", term)
+}
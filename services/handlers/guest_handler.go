@@ -19,7 +19,7 @@ func NewGuestHandler(guestSvc GuestServiceInterface, contentSvc ContentServiceIn
 }
 
 // @Summary Create or Get Guest Session
-// @Description This endpoint creates a new guest session or retrieves an existing one based on device ID
+// @Description This endpoint creates a new guest session or retrieves an existing one based on device ID, with the lessons the guest can still play today. Include a device attestation over a challenge from /guest/attestation/challenge; it is required when attestation runs in enforce mode
 // @Tags guest
 // @Accept  json
 // @Produce json
@@ -37,7 +37,7 @@ func (h *GuestHandler) CreateSession(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
 	}
 
-	session, err := h.guestSvc.CreateOrGetSession(req.DeviceID)
+	session, err := h.guestSvc.CreateOrGetSession(req.DeviceID, req.Attestation)
 	if err != nil {
 		return err
 	}
@@ -47,12 +47,33 @@ func (h *GuestHandler) CreateSession(c *fiber.Ctx) error {
 		return err
 	}
 
+	quota, err := h.guestSvc.GetAttemptQuota(session.ID, shared.RequestIP(c))
+	if err != nil {
+		return err
+	}
+
 	return shared.ResponseJSON(c, fiber.StatusOK, "Success", dto.CreateSessionResponse{
 		Session:  session,
 		Progress: progress,
+		Quota:    quota,
 	})
 }
 
+// @Summary Get Attestation Challenge
+// @Description This endpoint issues a single-use challenge, valid for 5 minutes, to bind into a Play Integrity token or App Attest attestation/assertion
+// @Tags guest
+// @Produce json
+// @Success 200 {object} shared.Response{data=dto.AttestationChallengeResponse}
+// @Router /api/v1/guest/attestation/challenge [post]
+func (h *GuestHandler) GetAttestationChallenge(c *fiber.Ctx) error {
+	challenge, err := h.guestSvc.IssueAttestationChallenge()
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", challenge)
+}
+
 // @Summary Get Guest Progress
 // @Description This endpoint retrieves the progress of a guest session
 // @Tags guest
@@ -73,7 +94,7 @@ func (h *GuestHandler) GetProgress(c *fiber.Ctx) error {
 }
 
 // @Summary Check Lesson Access
-// @Description This endpoint checks if a guest session can access a specific lesson
+// @Description This endpoint checks if a guest session can access a specific lesson and how many lessons it can still play today
 // @Tags guest
 // @Accept  json
 // @Produce json
@@ -90,22 +111,32 @@ func (h *GuestHandler) CheckLessonAccess(c *fiber.Ctx) error {
 		return err
 	}
 
+	quota, err := h.guestSvc.GetAttemptQuota(sessionID, shared.RequestIP(c))
+	if err != nil {
+		return err
+	}
+	if canAccess && quota != nil && quota.Remaining == 0 {
+		canAccess, reason = false, "Daily guest lesson limit reached. Please register to continue."
+	}
+
 	res := dto.LessonAccessResponse{
 		CanAccess: canAccess,
 		Reason:    reason,
+		Quota:     quota,
 	}
 
 	return shared.ResponseJSON(c, fiber.StatusOK, "Success", res)
 }
 
 // @Summary Complete Lesson
-// @Description This endpoint marks a lesson as completed for a guest session
+// @Description This endpoint marks a lesson as completed for a guest session and returns its progress with the lessons it can still play today. Guests are limited to a number of lessons per day per device and per IP address
 // @Tags guest
 // @Accept  json
 // @Produce json
 // @Param sessionId path string true "Session ID"
 // @Param completeLessonRequest body dto.CompleteLessonRequest true "Complete lesson request"
-// @Success 200
+// @Success 200 {object} shared.Response{data=dto.GuestProgressResponse}
+// @Failure 429 {object} shared.Response "Daily guest lesson limit reached"
 // @Router /api/v1/guest/session/{sessionId}/lesson/complete [post]
 func (h *GuestHandler) CompleteLesson(c *fiber.Ctx) error {
 	sessionID := c.Params("sessionId")
@@ -120,7 +151,7 @@ func (h *GuestHandler) CompleteLesson(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
 	}
 
-	err := h.guestSvc.CompleteLesson(sessionID, req.LessonID, req.Score, req.TimeSpent)
+	quota, err := h.guestSvc.CompleteLesson(sessionID, shared.RequestIP(c), req.LessonID, req.Score, req.TimeSpent)
 	if err != nil {
 		return err
 	}
@@ -130,21 +161,38 @@ func (h *GuestHandler) CompleteLesson(c *fiber.Ctx) error {
 		return err
 	}
 
-	return shared.ResponseJSON(c, fiber.StatusOK, "Success", progress)
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", dto.GuestProgressResponse{
+		GuestProgress: progress,
+		Quota:         quota,
+	})
 }
 
 // @Summary Add Hearts from Ad
-// @Description This endpoint adds hearts to a guest session when an ad is watched
+// @Description This endpoint adds hearts to a guest session when an ad is watched. Include a fresh device attestation; it is required when attestation runs in enforce mode
 // @Tags guest
 // @Accept  json
 // @Produce json
 // @Param sessionId path string true "Session ID"
+// @Param addHeartsRequest body dto.AddHeartsFromAdRequest false "Device attestation"
 // @Success 200
+// @Failure 403 {object} shared.Response
 // @Router /api/v1/guest/session/{sessionId}/hearts/add [post]
 func (h *GuestHandler) AddHeartsFromAd(c *fiber.Ctx) error {
 	sessionID := c.Params("sessionId")
 
-	err := h.guestSvc.AddHeartsFromAd(sessionID)
+	var req dto.AddHeartsFromAdRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return shared.NewBadRequestError(err, "Invalid request")
+		}
+
+		if err := req.Validate(); err != nil {
+			validationResp := dto.CreateValidationErrorResponse(err)
+			return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+		}
+	}
+
+	err := h.guestSvc.AddHeartsFromAd(sessionID, req.Attestation)
 	if err != nil {
 		return err
 	}
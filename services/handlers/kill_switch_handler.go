@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+)
+
+type KillSwitchHandler struct {
+	killSwitchSvc KillSwitchServiceInterface
+}
+
+func NewKillSwitchHandler(killSwitchSvc KillSwitchServiceInterface) *KillSwitchHandler {
+	return &KillSwitchHandler{
+		killSwitchSvc: killSwitchSvc,
+	}
+}
+
+// @Summary Emergency Disable Content
+// @Description Take a lesson offline at once, or only one of its questions, e.g. when a factual error ships. Content caches are cleared on every API instance, learners in the middle of the lesson get a notification and the takedown is recorded as an incident (Admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param lessonId path string true "Lesson ID"
+// @Param request body dto.KillContentRequest true "Reason, and the question to pull instead of the whole lesson"
+// @Success 201 {object} shared.Response{data=dto.ContentIncidentResponse}
+// @Failure 404 {object} shared.Response "Lesson or question not found"
+// @Failure 409 {object} shared.Response "Lesson is already disabled"
+// @Router /api/v1/admin/lessons/{lessonId}/kill-switch [post]
+func (h *KillSwitchHandler) KillContent(c *fiber.Ctx) error {
+	var req dto.KillContentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.CreateValidationErrorResponse(err))
+	}
+
+	adminID := c.Locals(shared.UserID).(string)
+	incident, err := h.killSwitchSvc.KillContent(adminID, c.Params("lessonId"), req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusCreated, "Content disabled", incident)
+}
+
+// @Summary Get Content Incidents
+// @Description List emergency content takedowns, newest first (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param status query string false "Incident status" Enums(open, resolved) default(open)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} shared.Response{data=dto.ContentIncidentListResponse}
+// @Router /api/v1/admin/incidents [get]
+func (h *KillSwitchHandler) GetIncidents(c *fiber.Ctx) error {
+	status := c.Query("status", model.ContentIncidentOpen)
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	incidents, err := h.killSwitchSvc.GetIncidents(status, page, limit)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", incidents)
+}
+
+// @Summary Resolve Content Incident
+// @Description Put emergency-disabled content back live or leave it out for good, closing its incident. A pulled question still in the disabled question queue is reviewed along with it (Admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param incidentId path string true "Incident ID"
+// @Param request body dto.ResolveContentIncidentRequest true "Resolution"
+// @Success 200 {object} shared.Response{data=dto.ContentIncidentResponse}
+// @Failure 404 {object} shared.Response "Incident not found"
+// @Failure 409 {object} shared.Response "Incident already resolved"
+// @Router /api/v1/admin/incidents/{incidentId}/resolve [post]
+func (h *KillSwitchHandler) ResolveIncident(c *fiber.Ctx) error {
+	var req dto.ResolveContentIncidentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.CreateValidationErrorResponse(err))
+	}
+
+	adminID := c.Locals(shared.UserID).(string)
+	incident, err := h.killSwitchSvc.ResolveIncident(adminID, c.Params("incidentId"), req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Content incident resolved", incident)
+}
@@ -1,9 +1,12 @@
 package handlers
 
 import (
+	"fmt"
+	"slices"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/lac-hong-legacy/ven_api/model"
 	"github.com/lac-hong-legacy/ven_api/shared"
 )
 
@@ -19,15 +22,31 @@ func NewLeaderboardHandler(userSvc UserServiceInterface, jwtSvc JWTServiceInterf
 	}
 }
 
+// parseLeaderboardType reads what users are ranked by, XP unless the type query says otherwise
+func parseLeaderboardType(c *fiber.Ctx) (string, error) {
+	leaderboardType := c.Query("type", model.LeaderboardTypeXP)
+	if !slices.Contains(model.LeaderboardTypes, leaderboardType) {
+		return "", shared.NewBadRequestError(fmt.Errorf("unknown leaderboard type %q", leaderboardType), "Leaderboard type must be xp, spirit or collection")
+	}
+	return leaderboardType, nil
+}
+
 // @Summary Get Weekly Leaderboard
 // @Description Get weekly leaderboard rankings
 // @Tags leaderboard
 // @Accept json
 // @Produce json
 // @Param limit query int false "Limit results (default 50)"
+// @Param type query string false "Rank by xp, spirit stage or collection completion" Enums(xp, spirit, collection) default(xp)
 // @Success 200 {object} shared.Response{data=dto.LeaderboardResponse}
+// @Failure 400 {object} shared.Response
 // @Router /api/v1/leaderboard/weekly [get]
 func (h *LeaderboardHandler) GetWeeklyLeaderboard(c *fiber.Ctx) error {
+	leaderboardType, err := parseLeaderboardType(c)
+	if err != nil {
+		return err
+	}
+
 	limit := 50
 	if l := c.Query("limit"); l != "" {
 		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 100 {
@@ -44,7 +63,7 @@ func (h *LeaderboardHandler) GetWeeklyLeaderboard(c *fiber.Ctx) error {
 		}
 	}
 
-	leaderboard, err := h.userSvc.GetWeeklyLeaderboard(limit, userID)
+	leaderboard, err := h.userSvc.GetWeeklyLeaderboard(leaderboardType, limit, userID)
 	if err != nil {
 		return err
 	}
@@ -58,9 +77,16 @@ func (h *LeaderboardHandler) GetWeeklyLeaderboard(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param limit query int false "Limit results (default 50)"
+// @Param type query string false "Rank by xp, spirit stage or collection completion" Enums(xp, spirit, collection) default(xp)
 // @Success 200 {object} shared.Response{data=dto.LeaderboardResponse}
+// @Failure 400 {object} shared.Response
 // @Router /api/v1/leaderboard/monthly [get]
 func (h *LeaderboardHandler) GetMonthlyLeaderboard(c *fiber.Ctx) error {
+	leaderboardType, err := parseLeaderboardType(c)
+	if err != nil {
+		return err
+	}
+
 	limit := 50
 	if l := c.Query("limit"); l != "" {
 		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 100 {
@@ -77,7 +103,7 @@ func (h *LeaderboardHandler) GetMonthlyLeaderboard(c *fiber.Ctx) error {
 		}
 	}
 
-	leaderboard, err := h.userSvc.GetMonthlyLeaderboard(limit, userID)
+	leaderboard, err := h.userSvc.GetMonthlyLeaderboard(leaderboardType, limit, userID)
 	if err != nil {
 		return err
 	}
@@ -91,9 +117,16 @@ func (h *LeaderboardHandler) GetMonthlyLeaderboard(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param limit query int false "Limit results (default 50)"
+// @Param type query string false "Rank by xp, spirit stage or collection completion" Enums(xp, spirit, collection) default(xp)
 // @Success 200 {object} shared.Response{data=dto.LeaderboardResponse}
+// @Failure 400 {object} shared.Response
 // @Router /api/v1/leaderboard/all-time [get]
 func (h *LeaderboardHandler) GetAllTimeLeaderboard(c *fiber.Ctx) error {
+	leaderboardType, err := parseLeaderboardType(c)
+	if err != nil {
+		return err
+	}
+
 	limit := 50
 	if l := c.Query("limit"); l != "" {
 		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 100 {
@@ -110,7 +143,7 @@ func (h *LeaderboardHandler) GetAllTimeLeaderboard(c *fiber.Ctx) error {
 		}
 	}
 
-	leaderboard, err := h.userSvc.GetAllTimeLeaderboard(limit, userID)
+	leaderboard, err := h.userSvc.GetAllTimeLeaderboard(leaderboardType, limit, userID)
 	if err != nil {
 		return err
 	}
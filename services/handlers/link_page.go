@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lac-hong-legacy/ven_api/shared"
+)
+
+// Action links in security emails open a page served here instead of acting on GET,
+// since mail scanners and link previews follow links on their own. The page posts the
+// token back to the same path as a form, and the answer is a page again.
+
+var linkPageTemplate = template.Must(template.New("link_page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}} - TechYouth</title>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Message}}</p>
+{{if .Token}}<form method="post" action="{{.Action}}">
+<input type="hidden" name="token" value="{{.Token}}">
+<button type="submit">{{.Button}}</button>
+</form>{{end}}
+</body>
+</html>
+`))
+
+type linkPage struct {
+	Title   string
+	Message string
+	// Form posting Token back to Action, left out without a token
+	Action string
+	Token  string
+	Button string
+}
+
+func renderLinkPage(c *fiber.Ctx, status int, page linkPage) error {
+	var body bytes.Buffer
+	if err := linkPageTemplate.Execute(&body, page); err != nil {
+		return shared.NewInternalError(err, "Failed to render page")
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(status).Send(body.Bytes())
+}
+
+// renderLinkConfirmation asks before applying the email link's token
+func renderLinkConfirmation(c *fiber.Ctx, title, message, button string) error {
+	token := c.Query("token")
+	if token == "" {
+		return renderLinkPage(c, http.StatusBadRequest, linkPage{
+			Title:   "Invalid link",
+			Message: "This link is incomplete. Please open it again from the email.",
+		})
+	}
+
+	return renderLinkPage(c, http.StatusOK, linkPage{
+		Title:   title,
+		Message: message,
+		Action:  c.Path(),
+		Token:   token,
+		Button:  button,
+	})
+}
+
+func isFormPost(c *fiber.Ctx) bool {
+	return strings.HasPrefix(string(c.Request().Header.ContentType()), fiber.MIMEApplicationForm)
+}
+
+// linkActionResult answers the form of a link page with a page, and API clients as before
+func linkActionResult(c *fiber.Ctx, err error, title, message string) error {
+	if !isFormPost(c) {
+		if err != nil {
+			return err
+		}
+		return shared.ResponseJSON(c, http.StatusOK, message, nil)
+	}
+
+	if err != nil {
+		status, reason := http.StatusInternalServerError, "Something went wrong. Please try again later."
+		if appErr, ok := shared.GetAppError(err); ok && appErr.StatusCode < http.StatusInternalServerError {
+			status, reason = appErr.StatusCode, appErr.Message
+		}
+		return renderLinkPage(c, status, linkPage{Title: "This link did not work", Message: reason})
+	}
+	return renderLinkPage(c, http.StatusOK, linkPage{Title: title, Message: message})
+}
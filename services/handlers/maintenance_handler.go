@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+)
+
+type MaintenanceHandler struct {
+	maintenanceSvc MaintenanceServiceInterface
+}
+
+func NewMaintenanceHandler(maintenanceSvc MaintenanceServiceInterface) *MaintenanceHandler {
+	return &MaintenanceHandler{
+		maintenanceSvc: maintenanceSvc,
+	}
+}
+
+// @Summary Get Maintenance Status
+// @Description Get whether the app is in maintenance, with a message in the requested locale, and the next scheduled window. Available during maintenance.
+// @Tags system
+// @Produce json
+// @Param locale query string false "Locale (falls back to Accept-Language)" example(en)
+// @Param Accept-Language header string false "Preferred languages"
+// @Success 200 {object} shared.Response{data=dto.MaintenanceStatusResponse}
+// @Router /api/v1/maintenance [get]
+func (h *MaintenanceHandler) GetStatus(c *fiber.Ctx) error {
+	locale := c.Query("locale")
+	if locale == "" {
+		locale = c.AcceptsLanguages(append([]string{model.SourceLocale}, model.SupportedLocales...)...)
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", h.maintenanceSvc.GetStatus(locale))
+}
+
+// @Summary Get Maintenance Mode (Admin)
+// @Description Get the maintenance mode flag and its messages (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Success 200 {object} shared.Response{data=dto.MaintenanceState}
+// @Router /api/v1/admin/maintenance [get]
+func (h *MaintenanceHandler) GetState(c *fiber.Ctx) error {
+	state, err := h.maintenanceSvc.GetState()
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", state)
+}
+
+// @Summary Set Maintenance Mode (Admin)
+// @Description Turn maintenance mode on or off. While on, learner endpoints answer 503 with Retry-After; admin and health endpoints stay available (Admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param request body dto.UpdateMaintenanceRequest true "Maintenance mode"
+// @Success 200 {object} shared.Response{data=dto.MaintenanceState}
+// @Router /api/v1/admin/maintenance [put]
+func (h *MaintenanceHandler) SetMaintenance(c *fiber.Ctx) error {
+	var req dto.UpdateMaintenanceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	adminID := c.Locals(shared.UserID).(string)
+	state, err := h.maintenanceSvc.SetMaintenance(adminID, req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Maintenance mode updated", state)
+}
+
+// @Summary List Maintenance Windows (Admin)
+// @Description Get scheduled and active maintenance windows and those that ended in the last 30 days (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Success 200 {object} shared.Response{data=[]model.MaintenanceWindow}
+// @Router /api/v1/admin/maintenance/windows [get]
+func (h *MaintenanceHandler) GetWindows(c *fiber.Ctx) error {
+	windows, err := h.maintenanceSvc.GetWindows()
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", windows)
+}
+
+// @Summary Schedule Maintenance Window (Admin)
+// @Description Plan maintenance that turns maintenance mode on at starts_at and off at ends_at. Learners are notified with an announcement unless announce is false (Admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param request body dto.CreateMaintenanceWindowRequest true "Window"
+// @Success 201 {object} shared.Response{data=model.MaintenanceWindow}
+// @Router /api/v1/admin/maintenance/windows [post]
+func (h *MaintenanceHandler) ScheduleWindow(c *fiber.Ctx) error {
+	var req dto.CreateMaintenanceWindowRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	adminID := c.Locals(shared.UserID).(string)
+	window, err := h.maintenanceSvc.ScheduleWindow(adminID, req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusCreated, "Maintenance window scheduled", window)
+}
+
+// @Summary Cancel Maintenance Window (Admin)
+// @Description Cancel a scheduled or active maintenance window. Cancelling an active window ends maintenance mode (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param windowId path string true "Window ID"
+// @Success 200 {object} shared.Response
+// @Router /api/v1/admin/maintenance/windows/{windowId} [delete]
+func (h *MaintenanceHandler) CancelWindow(c *fiber.Ctx) error {
+	adminID := c.Locals(shared.UserID).(string)
+	if err := h.maintenanceSvc.CancelWindow(adminID, c.Params("windowId")); err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Maintenance window cancelled", nil)
+}
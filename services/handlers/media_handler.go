@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"strconv"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/lac-hong-legacy/ven_api/dto"
 	"github.com/lac-hong-legacy/ven_api/shared"
 )
 
@@ -18,7 +21,7 @@ func NewMediaHandler(mediaSvc MediaServiceInterface, contentSvc ContentServiceIn
 }
 
 // @Summary Upload Lesson Subtitle (Admin)
-// @Description Upload subtitle file for lesson video (Admin only)
+// @Description Upload subtitle file for lesson video. The lesson transcript is generated from it unless one was written by hand (Admin only)
 // @Tags admin
 // @Accept multipart/form-data
 // @Produce json
@@ -30,13 +33,14 @@ func NewMediaHandler(mediaSvc MediaServiceInterface, contentSvc ContentServiceIn
 // @Router /api/v1/admin/lessons/{lessonId}/subtitle [post]
 func (h *MediaHandler) UploadLessonSubtitle(c *fiber.Ctx) error {
 	lessonID := c.Params("lessonId")
+	adminID := c.Locals(shared.UserID).(string)
 
 	file, err := c.FormFile("subtitle")
 	if err != nil {
 		return shared.NewBadRequestError(err, "No subtitle file provided")
 	}
 
-	response, err := h.mediaSvc.UploadLessonSubtitle(lessonID, file)
+	response, err := h.mediaSvc.UploadLessonSubtitle(adminID, lessonID, file)
 	if err != nil {
 		return err
 	}
@@ -44,6 +48,65 @@ func (h *MediaHandler) UploadLessonSubtitle(c *fiber.Ctx) error {
 	return shared.ResponseJSON(c, fiber.StatusOK, "Subtitle uploaded successfully", response)
 }
 
+// @Summary Upload Lesson Audio Description (Admin)
+// @Description Upload the audio description track of a lesson, a narration of what happens on screen for blind and low-vision learners (Admin only)
+// @Tags admin
+// @Accept multipart/form-data
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param lessonId path string true "Lesson ID"
+// @Param audio formData file true "Audio file (MP3, WAV, AAC, M4A)"
+// @Success 200 {object} shared.Response{data=dto.MediaUploadResponse}
+// @Router /api/v1/admin/lessons/{lessonId}/audio-description [post]
+func (h *MediaHandler) UploadAudioDescription(c *fiber.Ctx) error {
+	lessonID := c.Params("lessonId")
+	adminID := c.Locals(shared.UserID).(string)
+
+	file, err := c.FormFile("audio")
+	if err != nil {
+		return shared.NewBadRequestError(err, "No audio file provided")
+	}
+
+	response, err := h.mediaSvc.UploadAudioDescription(adminID, lessonID, file)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Audio description uploaded successfully", response)
+}
+
+// @Summary Update Lesson Transcript (Admin)
+// @Description Replace the transcript generated from the lesson subtitles with one written by hand. An empty transcript goes back to the generated one (Admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param lessonId path string true "Lesson ID"
+// @Param request body dto.UpdateLessonTranscriptRequest true "Transcript"
+// @Success 200 {object} shared.Response{data=dto.LessonAccessibility}
+// @Router /api/v1/admin/lessons/{lessonId}/transcript [put]
+func (h *MediaHandler) UpdateLessonTranscript(c *fiber.Ctx) error {
+	var req dto.UpdateLessonTranscriptRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	adminID := c.Locals(shared.UserID).(string)
+	response, err := h.mediaSvc.UpdateLessonTranscript(adminID, c.Params("lessonId"), req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Lesson transcript updated", response)
+}
+
 // @Summary Upload Lesson Thumbnail (Admin)
 // @Description Upload thumbnail image for lesson (Admin only)
 // @Tags admin
@@ -57,13 +120,14 @@ func (h *MediaHandler) UploadLessonSubtitle(c *fiber.Ctx) error {
 // @Router /api/v1/admin/lessons/{lessonId}/thumbnail [post]
 func (h *MediaHandler) UploadThumbnail(c *fiber.Ctx) error {
 	lessonID := c.Params("lessonId")
+	adminID := c.Locals(shared.UserID).(string)
 
 	file, err := c.FormFile("thumbnail")
 	if err != nil {
 		return shared.NewBadRequestError(err, "No thumbnail file provided")
 	}
 
-	response, err := h.mediaSvc.UploadThumbnail(lessonID, file)
+	response, err := h.mediaSvc.UploadThumbnail(adminID, lessonID, file)
 	if err != nil {
 		return err
 	}
@@ -102,8 +166,9 @@ func (h *MediaHandler) GetLessonMedia(c *fiber.Ctx) error {
 // @Router /api/v1/admin/media/assets/{assetId} [delete]
 func (h *MediaHandler) DeleteMediaAsset(c *fiber.Ctx) error {
 	assetID := c.Params("assetId")
+	adminID := c.Locals(shared.UserID).(string)
 
-	err := h.mediaSvc.DeleteMediaAsset(assetID)
+	err := h.mediaSvc.DeleteMediaAsset(adminID, assetID)
 	if err != nil {
 		return err
 	}
@@ -128,6 +193,18 @@ func (h *MediaHandler) GetMediaStatistics(c *fiber.Ctx) error {
 	return shared.ResponseJSON(c, fiber.StatusOK, "Success", stats)
 }
 
+// @Summary Get Media Storage Config (Admin)
+// @Description Get the bucket, region and cold storage lifecycle of every media category (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Success 200 {object} shared.Response{data=dto.MediaStorageConfigResponse}
+// @Router /api/v1/admin/media/storage [get]
+func (h *MediaHandler) GetStorageConfig(c *fiber.Ctx) error {
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", h.mediaSvc.GetStorageConfig())
+}
+
 // @Summary Upload Lesson Audio (Admin)
 // @Description Upload voice-over audio file - Step 2 of production workflow (Admin only)
 // @Tags admin,production
@@ -141,18 +218,19 @@ func (h *MediaHandler) GetMediaStatistics(c *fiber.Ctx) error {
 // @Router /api/v1/admin/lessons/{lessonId}/audio [post]
 func (h *MediaHandler) UploadLessonAudio(c *fiber.Ctx) error {
 	lessonID := c.Params("lessonId")
+	adminID := c.Locals(shared.UserID).(string)
 
 	file, err := c.FormFile("audio")
 	if err != nil {
 		return shared.NewBadRequestError(err, "No audio file provided")
 	}
 
-	response, err := h.mediaSvc.UploadLessonAudio(lessonID, file)
+	response, err := h.mediaSvc.UploadLessonAudio(adminID, lessonID, file)
 	if err != nil {
 		return err
 	}
 
-	if err := h.contentSvc.MarkAudioUploaded(lessonID); err != nil {
+	if err := h.contentSvc.MarkAudioUploaded(adminID, lessonID); err != nil {
 		return err
 	}
 
@@ -172,20 +250,466 @@ func (h *MediaHandler) UploadLessonAudio(c *fiber.Ctx) error {
 // @Router /api/v1/admin/lessons/{lessonId}/animation [post]
 func (h *MediaHandler) UploadLessonAnimation(c *fiber.Ctx) error {
 	lessonID := c.Params("lessonId")
+	adminID := c.Locals(shared.UserID).(string)
 
 	file, err := c.FormFile("animation")
 	if err != nil {
 		return shared.NewBadRequestError(err, "No animation file provided")
 	}
 
-	response, err := h.mediaSvc.UploadLessonAnimation(lessonID, file)
+	response, err := h.mediaSvc.UploadLessonAnimation(adminID, lessonID, file)
 	if err != nil {
 		return err
 	}
 
-	if err := h.contentSvc.MarkAnimationUploaded(lessonID); err != nil {
+	if err := h.contentSvc.MarkAnimationUploaded(adminID, lessonID); err != nil {
 		return err
 	}
 
 	return shared.ResponseJSON(c, fiber.StatusOK, "Animation uploaded successfully", response)
 }
+
+// @Summary Browse Media Library (Admin)
+// @Description List uploaded media assets with filters, preview URLs and usage counts (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param type query string false "File type (audio, animation, subtitle, thumbnail, ...)"
+// @Param processed query bool false "Filter by processed status"
+// @Param processing_status query string false "Processing status (pending, processing, completed, failed)"
+// @Param lesson_id query string false "Only assets linked to this lesson"
+// @Param orphaned query bool false "Only assets not actively linked to any lesson"
+// @Param search query string false "Search by file name"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} shared.Response{data=dto.MediaLibraryResponse}
+// @Router /api/v1/admin/media/library [get]
+func (h *MediaHandler) GetMediaLibrary(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	query := dto.MediaLibraryQuery{
+		FileType:         c.Query("type"),
+		ProcessingStatus: c.Query("processing_status"),
+		LessonID:         c.Query("lesson_id"),
+		Orphaned:         c.QueryBool("orphaned", false),
+		Search:           c.Query("search"),
+		Page:             page,
+		Limit:            limit,
+	}
+
+	if processed := c.Query("processed"); processed != "" {
+		value, err := strconv.ParseBool(processed)
+		if err != nil {
+			return shared.NewBadRequestError(err, "Invalid processed filter")
+		}
+		query.Processed = &value
+	}
+
+	library, err := h.mediaSvc.GetMediaLibrary(query)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", library)
+}
+
+// @Summary Get Media Asset (Admin)
+// @Description Get a single media asset with preview URL and where it is used (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param assetId path string true "Media Asset ID"
+// @Success 200 {object} shared.Response{data=dto.MediaLibraryItem}
+// @Router /api/v1/admin/media/assets/{assetId} [get]
+func (h *MediaHandler) GetMediaAsset(c *fiber.Ctx) error {
+	assetID := c.Params("assetId")
+
+	asset, err := h.mediaSvc.GetMediaAssetDetails(assetID)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", asset)
+}
+
+// @Summary List Failed Media Processing (Admin)
+// @Description List media assets whose last processing attempt failed, with the error (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} shared.Response{data=dto.MediaLibraryResponse}
+// @Router /api/v1/admin/media/processing/failed [get]
+func (h *MediaHandler) GetFailedMediaProcessing(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	failed, err := h.mediaSvc.GetFailedMediaProcessing(page, limit)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", failed)
+}
+
+// @Summary Retry Media Processing (Admin)
+// @Description Process a failed or stuck media asset again. Processing runs in the background. (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param assetId path string true "Media Asset ID"
+// @Success 200 {object} shared.Response{data=dto.MediaLibraryItem}
+// @Failure 409 {object} shared.Response "Already processed or processing"
+// @Router /api/v1/admin/media/assets/{assetId}/reprocess [post]
+func (h *MediaHandler) RetryMediaProcessing(c *fiber.Ctx) error {
+	adminID := c.Locals(shared.UserID).(string)
+	assetID := c.Params("assetId")
+
+	asset, err := h.mediaSvc.RetryMediaProcessing(adminID, assetID)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Processing restarted", asset)
+}
+
+// @Summary Update Media Asset (Admin)
+// @Description Rename or retag a media asset (Admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param assetId path string true "Media Asset ID"
+// @Param request body dto.UpdateMediaAssetRequest true "New name and/or tags"
+// @Success 200 {object} shared.Response{data=dto.MediaLibraryItem}
+// @Router /api/v1/admin/media/assets/{assetId} [patch]
+func (h *MediaHandler) UpdateMediaAsset(c *fiber.Ctx) error {
+	assetID := c.Params("assetId")
+	adminID := c.Locals(shared.UserID).(string)
+
+	var req dto.UpdateMediaAssetRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.CreateValidationErrorResponse(err))
+	}
+
+	asset, err := h.mediaSvc.UpdateMediaAsset(adminID, assetID, req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Media asset updated successfully", asset)
+}
+
+// @Summary Start Resumable Upload (Admin)
+// @Description Start a chunked, resumable upload for a large lesson video or audio file (Admin only)
+// @Tags admin,production
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param lessonId path string true "Lesson ID"
+// @Param request body dto.InitUploadRequest true "File details"
+// @Success 201 {object} shared.Response{data=dto.UploadSessionResponse}
+// @Router /api/v1/admin/lessons/{lessonId}/uploads [post]
+func (h *MediaHandler) InitUpload(c *fiber.Ctx) error {
+	lessonID := c.Params("lessonId")
+	adminID := c.Locals(shared.UserID).(string)
+
+	var req dto.InitUploadRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.CreateValidationErrorResponse(err))
+	}
+
+	session, err := h.mediaSvc.InitUpload(adminID, lessonID, req)
+	if err != nil {
+		return err
+	}
+
+	setUploadHeaders(c, session)
+	return shared.ResponseJSON(c, fiber.StatusCreated, "Upload started", session)
+}
+
+// @Summary Get Resumable Upload Status (Admin)
+// @Description Get the current offset of a resumable upload so the client can resume (Admin only)
+// @Tags admin,production
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param uploadId path string true "Upload ID"
+// @Success 200 {object} shared.Response{data=dto.UploadSessionResponse}
+// @Router /api/v1/admin/uploads/{uploadId} [get]
+func (h *MediaHandler) GetUploadStatus(c *fiber.Ctx) error {
+	session, err := h.mediaSvc.GetUploadStatus(c.Params("uploadId"))
+	if err != nil {
+		return err
+	}
+
+	setUploadHeaders(c, session)
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", session)
+}
+
+// @Summary Append Upload Chunk (Admin)
+// @Description Append raw bytes at the given Upload-Offset (Admin only)
+// @Tags admin,production
+// @Accept application/offset+octet-stream
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param Upload-Offset header int true "Byte offset of this chunk"
+// @Param uploadId path string true "Upload ID"
+// @Success 200 {object} shared.Response{data=dto.UploadSessionResponse}
+// @Failure 409 {object} shared.Response{data=dto.UploadSessionResponse}
+// @Router /api/v1/admin/uploads/{uploadId} [patch]
+func (h *MediaHandler) AppendUploadChunk(c *fiber.Ctx) error {
+	offset, err := strconv.ParseInt(c.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return shared.NewBadRequestError(err, "Invalid Upload-Offset header")
+	}
+
+	session, err := h.mediaSvc.AppendUploadChunk(c.Params("uploadId"), offset, c.Body())
+	if err != nil {
+		return err
+	}
+
+	setUploadHeaders(c, session)
+	return shared.ResponseJSON(c, fiber.StatusOK, "Chunk received", session)
+}
+
+// @Summary Finalize Resumable Upload (Admin)
+// @Description Verify the SHA-256 checksum, assemble the file and run the lesson production step (Admin only)
+// @Tags admin,production
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param uploadId path string true "Upload ID"
+// @Param request body dto.FinalizeUploadRequest true "Checksum of the full file"
+// @Success 200 {object} shared.Response{data=dto.MediaUploadResponse}
+// @Router /api/v1/admin/uploads/{uploadId}/finalize [post]
+func (h *MediaHandler) FinalizeUpload(c *fiber.Ctx) error {
+	adminID := c.Locals(shared.UserID).(string)
+
+	var req dto.FinalizeUploadRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.CreateValidationErrorResponse(err))
+	}
+
+	response, err := h.mediaSvc.FinalizeUpload(adminID, c.Params("uploadId"), req.Checksum)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Upload completed successfully", response)
+}
+
+// @Summary Abort Resumable Upload (Admin)
+// @Description Cancel a resumable upload and discard received chunks (Admin only)
+// @Tags admin,production
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param uploadId path string true "Upload ID"
+// @Success 200 {object} shared.Response{data=string}
+// @Router /api/v1/admin/uploads/{uploadId} [delete]
+func (h *MediaHandler) AbortUpload(c *fiber.Ctx) error {
+	if err := h.mediaSvc.AbortUpload(c.Params("uploadId")); err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Upload aborted", "aborted")
+}
+
+func setUploadHeaders(c *fiber.Ctx, session *dto.UploadSessionResponse) {
+	c.Set("Upload-Offset", strconv.FormatInt(session.Offset, 10))
+	c.Set("Upload-Length", strconv.FormatInt(session.TotalSize, 10))
+}
+
+// @Summary Purge Asset CDN Cache (Admin)
+// @Description Invalidate the CDN cache for a media asset after it has been replaced (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param assetId path string true "Media Asset ID"
+// @Success 200 {object} shared.Response{data=dto.CDNPurgeResponse}
+// @Router /api/v1/admin/media/assets/{assetId}/purge [post]
+func (h *MediaHandler) PurgeAssetCache(c *fiber.Ctx) error {
+	response, err := h.mediaSvc.PurgeAssetCache(c.Params("assetId"))
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "CDN cache purged", response)
+}
+
+// @Summary Prewarm Lesson CDN Cache (Admin)
+// @Description Request a lesson's media and public content through the CDN in every target region so the first learners hit a warm cache. Lessons are also prewarmed automatically when created and when their media finishes processing (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param lessonId path string true "Lesson ID"
+// @Success 202 {object} shared.Response{data=dto.CDNPrewarmJobResponse}
+// @Failure 409 {object} shared.Response "Lesson is already being prewarmed"
+// @Router /api/v1/admin/lessons/{lessonId}/cdn-prewarm [post]
+func (h *MediaHandler) PrewarmLesson(c *fiber.Ctx) error {
+	adminID := c.Locals(shared.UserID).(string)
+	response, err := h.mediaSvc.PrewarmLesson(adminID, c.Params("lessonId"))
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusAccepted, "CDN prewarm started", response)
+}
+
+// @Summary Get Lesson CDN Prewarm Status (Admin)
+// @Description Get the progress of a lesson's most recent CDN prewarm jobs, newest first (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param lessonId path string true "Lesson ID"
+// @Success 200 {object} shared.Response{data=[]dto.CDNPrewarmJobResponse}
+// @Router /api/v1/admin/lessons/{lessonId}/cdn-prewarm [get]
+func (h *MediaHandler) GetLessonPrewarmStatus(c *fiber.Ctx) error {
+	response, err := h.mediaSvc.GetLessonPrewarmStatus(c.Params("lessonId"))
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "CDN prewarm status retrieved", response)
+}
+
+// @Summary Get CDN Prewarm Job (Admin)
+// @Description Get the progress of one CDN prewarm job per target region (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param jobId path string true "Prewarm job ID"
+// @Success 200 {object} shared.Response{data=dto.CDNPrewarmJobResponse}
+// @Router /api/v1/admin/cdn-prewarm/{jobId} [get]
+func (h *MediaHandler) GetPrewarmJob(c *fiber.Ctx) error {
+	response, err := h.mediaSvc.GetPrewarmJob(c.Params("jobId"))
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "CDN prewarm job retrieved", response)
+}
+
+// @Summary Upload Question Media (Admin)
+// @Description Upload an image prompt or audio clip for a question, replacing any media already in that role (Admin only)
+// @Tags admin
+// @Accept multipart/form-data
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param lessonId path string true "Lesson ID"
+// @Param questionId path string true "Question ID"
+// @Param role path string true "Media role" Enums(image, audio)
+// @Param file formData file true "Image (JPG, PNG, WEBP, GIF) or audio (MP3, WAV, AAC, M4A, OGG) file"
+// @Success 200 {object} shared.Response{data=dto.QuestionMediaResponse}
+// @Router /api/v1/admin/lessons/{lessonId}/questions/{questionId}/media/{role} [post]
+func (h *MediaHandler) UploadQuestionMedia(c *fiber.Ctx) error {
+	adminID := c.Locals(shared.UserID).(string)
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return shared.NewBadRequestError(err, "No media file provided")
+	}
+
+	response, err := h.mediaSvc.UploadQuestionMedia(adminID, c.Params("lessonId"), c.Params("questionId"), c.Params("role"), file)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Question media uploaded successfully", response)
+}
+
+// @Summary Link Question Media (Admin)
+// @Description Attach an existing media library asset to a question (Admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param lessonId path string true "Lesson ID"
+// @Param questionId path string true "Question ID"
+// @Param role path string true "Media role" Enums(image, audio)
+// @Param request body dto.LinkQuestionMediaRequest true "Media asset to attach"
+// @Success 200 {object} shared.Response{data=dto.QuestionMediaResponse}
+// @Router /api/v1/admin/lessons/{lessonId}/questions/{questionId}/media/{role} [put]
+func (h *MediaHandler) LinkQuestionMedia(c *fiber.Ctx) error {
+	adminID := c.Locals(shared.UserID).(string)
+
+	var req dto.LinkQuestionMediaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.CreateValidationErrorResponse(err))
+	}
+
+	response, err := h.mediaSvc.LinkQuestionMedia(adminID, c.Params("lessonId"), c.Params("questionId"), c.Params("role"), req.MediaAssetID)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Question media linked successfully", response)
+}
+
+// @Summary Remove Question Media (Admin)
+// @Description Detach the media in a role from a question (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param lessonId path string true "Lesson ID"
+// @Param questionId path string true "Question ID"
+// @Param role path string true "Media role" Enums(image, audio)
+// @Success 200 {object} shared.Response{data=string}
+// @Router /api/v1/admin/lessons/{lessonId}/questions/{questionId}/media/{role} [delete]
+func (h *MediaHandler) UnlinkQuestionMedia(c *fiber.Ctx) error {
+	adminID := c.Locals(shared.UserID).(string)
+
+	if err := h.mediaSvc.UnlinkQuestionMedia(adminID, c.Params("lessonId"), c.Params("questionId"), c.Params("role")); err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Question media removed", "removed")
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/shared"
+)
+
+type NotificationHandler struct {
+	notificationSvc NotificationServiceInterface
+}
+
+func NewNotificationHandler(notificationSvc NotificationServiceInterface) *NotificationHandler {
+	return &NotificationHandler{
+		notificationSvc: notificationSvc,
+	}
+}
+
+// @Summary Get notifications
+// @Description Get the user's notification inbox, newest first
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param unread query bool false "Only unread notifications"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} shared.Response{data=dto.NotificationListResponse}
+// @Router /api/v1/notifications [get]
+func (h *NotificationHandler) GetNotifications(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	notifications, err := h.notificationSvc.GetNotifications(userID, c.QueryBool("unread", false), page, limit)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", notifications)
+}
+
+// @Summary Get unread notification count
+// @Description Get the number of unread notifications for badge display
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Success 200 {object} shared.Response{data=dto.UnreadCountResponse}
+// @Router /api/v1/notifications/unread-count [get]
+func (h *NotificationHandler) GetUnreadCount(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	count, err := h.notificationSvc.GetUnreadCount(userID)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", count)
+}
+
+// @Summary Mark notification as read
+// @Description Mark a single notification as read
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param notificationId path string true "Notification ID"
+// @Success 200 {object} shared.Response{data=dto.UnreadCountResponse}
+// @Router /api/v1/notifications/{notificationId}/read [post]
+func (h *NotificationHandler) MarkRead(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	count, err := h.notificationSvc.MarkRead(userID, c.Params("notificationId"))
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Notification marked as read", count)
+}
+
+// @Summary Mark all notifications as read
+// @Description Mark every unread notification as read
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Success 200 {object} shared.Response{data=dto.UnreadCountResponse}
+// @Router /api/v1/notifications/read-all [post]
+func (h *NotificationHandler) MarkAllRead(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	count, err := h.notificationSvc.MarkAllRead(userID)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "All notifications marked as read", count)
+}
+
+// @Summary Get study reminder
+// @Description Get the user's study reminder schedule. Times are in the timezone of the user's profile
+// @Tags notifications
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Success 200 {object} shared.Response{data=dto.StudyReminderResponse}
+// @Failure 404 {object} shared.Response
+// @Router /api/v1/notifications/reminders [get]
+func (h *NotificationHandler) GetStudyReminder(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	reminder, err := h.notificationSvc.GetStudyReminder(userID)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", reminder)
+}
+
+// @Summary Set study reminder
+// @Description Create or replace the user's study reminder schedule: the days of the week and the time, in the timezone of the user's profile. Reminders are sent as push notifications and/or emails, and skipped on days the user already completed a lesson
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param request body dto.StudyReminderRequest true "Reminder schedule"
+// @Success 200 {object} shared.Response{data=dto.StudyReminderResponse}
+// @Router /api/v1/notifications/reminders [put]
+func (h *NotificationHandler) SetStudyReminder(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	var req dto.StudyReminderRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	reminder, err := h.notificationSvc.SetStudyReminder(userID, req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Study reminder saved", reminder)
+}
+
+// @Summary Delete study reminder
+// @Description Remove the user's study reminder schedule
+// @Tags notifications
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Success 200 {object} shared.Response
+// @Failure 404 {object} shared.Response
+// @Router /api/v1/notifications/reminders [delete]
+func (h *NotificationHandler) DeleteStudyReminder(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	if err := h.notificationSvc.DeleteStudyReminder(userID); err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Study reminder deleted", nil)
+}
+
+// @Summary Snooze study reminder
+// @Description Remind the user again after the given number of minutes. The regular schedule resumes afterwards
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param request body dto.SnoozeStudyReminderRequest true "Snooze length"
+// @Success 200 {object} shared.Response{data=dto.StudyReminderResponse}
+// @Failure 409 {object} shared.Response
+// @Router /api/v1/notifications/reminders/snooze [post]
+func (h *NotificationHandler) SnoozeStudyReminder(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	var req dto.SnoozeStudyReminderRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	reminder, err := h.notificationSvc.SnoozeStudyReminder(userID, req.Minutes)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Study reminder snoozed", reminder)
+}
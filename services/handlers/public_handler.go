@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/lac-hong-legacy/ven_api/shared"
+)
+
+// PublicHandler serves read-only content to the marketing website without sign-in
+type PublicHandler struct {
+	contentSvc PublicContentServiceInterface
+}
+
+func NewPublicHandler(contentSvc PublicContentServiceInterface) *PublicHandler {
+	return &PublicHandler{
+		contentSvc: contentSvc,
+	}
+}
+
+// @Summary List public characters
+// @Description Get the published characters for the marketing website. No sign-in needed.
+// @Tags public
+// @Produce json
+// @Success 200 {object} shared.Response{data=dto.PublicCharacterListResponse}
+// @Failure 429 {object} shared.Response "Rate limit exceeded"
+// @Router /public/v1/characters [get]
+func (h *PublicHandler) GetCharacters(c *fiber.Ctx) error {
+	characters, err := h.contentSvc.GetPublicCharacters()
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", characters)
+}
+
+// @Summary Get public character
+// @Description Get a published character with teasers of its lessons. No sign-in needed.
+// @Tags public
+// @Produce json
+// @Param characterId path string true "Character ID"
+// @Success 200 {object} shared.Response{data=dto.PublicCharacterDetailResponse}
+// @Failure 404 {object} shared.Response "Character not found"
+// @Router /public/v1/characters/{characterId} [get]
+func (h *PublicHandler) GetCharacter(c *fiber.Ctx) error {
+	character, err := h.contentSvc.GetPublicCharacter(c.Params("characterId"))
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", character)
+}
+
+// @Summary Get public timeline
+// @Description Get the dynasties in historical order with their published characters. No sign-in needed.
+// @Tags public
+// @Produce json
+// @Success 200 {object} shared.Response{data=dto.PublicTimelineResponse}
+// @Router /public/v1/timeline [get]
+func (h *PublicHandler) GetTimeline(c *fiber.Ctx) error {
+	timeline, err := h.contentSvc.GetPublicTimeline()
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", timeline)
+}
+
+// @Summary Get lesson teaser
+// @Description Get the title and opening of a lesson's story, without questions or answers. No sign-in needed.
+// @Tags public
+// @Produce json
+// @Param lessonId path string true "Lesson ID"
+// @Success 200 {object} shared.Response{data=dto.PublicLessonTeaser}
+// @Failure 404 {object} shared.Response "Lesson not found"
+// @Router /public/v1/lessons/{lessonId} [get]
+func (h *PublicHandler) GetLesson(c *fiber.Ctx) error {
+	lesson, err := h.contentSvc.GetPublicLesson(c.Params("lessonId"))
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", lesson)
+}
+
+// @Summary Get sitemap
+// @Description Get the sitemap of the website's public character and lesson pages. It changes when content is published. No sign-in needed.
+// @Tags public
+// @Produce xml
+// @Success 200 {string} string "sitemaps.org urlset"
+// @Router /public/v1/sitemap.xml [get]
+func (h *PublicHandler) GetSitemap(c *fiber.Ctx) error {
+	sitemap, err := h.contentSvc.GetPublicSitemap()
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationXMLCharsetUTF8)
+	return c.Status(fiber.StatusOK).Send(sitemap)
+}
+
+// @Summary Get character page metadata
+// @Description Get the title, description, Open Graph tags and JSON-LD for a character's page, for server-side rendering. No sign-in needed.
+// @Tags public
+// @Produce json
+// @Param characterId path string true "Character ID"
+// @Success 200 {object} shared.Response{data=dto.PublicPageMetadata}
+// @Failure 404 {object} shared.Response "Character not found"
+// @Router /public/v1/meta/characters/{characterId} [get]
+func (h *PublicHandler) GetCharacterMetadata(c *fiber.Ctx) error {
+	metadata, err := h.contentSvc.GetPublicCharacterMetadata(c.Params("characterId"))
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", metadata)
+}
+
+// @Summary Get lesson page metadata
+// @Description Get the title, description, Open Graph tags and JSON-LD for a lesson teaser page, for server-side rendering. No sign-in needed.
+// @Tags public
+// @Produce json
+// @Param lessonId path string true "Lesson ID"
+// @Success 200 {object} shared.Response{data=dto.PublicPageMetadata}
+// @Failure 404 {object} shared.Response "Lesson not found"
+// @Router /public/v1/meta/lessons/{lessonId} [get]
+func (h *PublicHandler) GetLessonMetadata(c *fiber.Ctx) error {
+	metadata, err := h.contentSvc.GetPublicLessonMetadata(c.Params("lessonId"))
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", metadata)
+}
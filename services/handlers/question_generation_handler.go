@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/shared"
+)
+
+type QuestionGenerationHandler struct {
+	questionGenerationSvc QuestionGenerationServiceInterface
+}
+
+func NewQuestionGenerationHandler(questionGenerationSvc QuestionGenerationServiceInterface) *QuestionGenerationHandler {
+	return &QuestionGenerationHandler{
+		questionGenerationSvc: questionGenerationSvc,
+	}
+}
+
+// @Summary Generate Question Drafts (Admin)
+// @Description Draft multiple choice and fill blank questions from a lesson's story with the configured language model. Drafts are not saved; review them and add the ones to keep to the lesson's questions (Admin only)
+// @Tags admin,content
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param lessonId path string true "Lesson ID"
+// @Param request body dto.GenerateQuestionsRequest true "Number of questions of each type"
+// @Success 200 {object} shared.Response{data=dto.GeneratedQuestionsResponse}
+// @Failure 400 {object} shared.Response "Not configured, or the lesson has no story"
+// @Router /api/v1/admin/lessons/{lessonId}/questions/generate [post]
+func (h *QuestionGenerationHandler) GenerateQuestionDrafts(c *fiber.Ctx) error {
+	var req dto.GenerateQuestionsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.CreateValidationErrorResponse(err))
+	}
+
+	drafts, err := h.questionGenerationSvc.GenerateQuestionDrafts(c.Params("lessonId"), req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Question drafts generated", drafts)
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/shared"
+)
+
+type ResearchHandler struct {
+	researchSvc ResearchExportServiceInterface
+}
+
+func NewResearchHandler(researchSvc ResearchExportServiceInterface) *ResearchHandler {
+	return &ResearchHandler{
+		researchSvc: researchSvc,
+	}
+}
+
+// @Summary Request Research Export (Admin)
+// @Description Build an anonymized cohort dataset for a research partner in the background: completion rates and retention per registration month and score distributions per dynasty. Groups smaller than min_cohort_size are suppressed. The request is recorded in the export's audit trail (Admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param request body dto.CreateResearchExportRequest true "Export request"
+// @Success 202 {object} shared.Response{data=model.ResearchExport}
+// @Failure 400 {object} shared.Response "Invalid request"
+// @Router /api/v1/admin/research/exports [post]
+func (h *ResearchHandler) RequestExport(c *fiber.Ctx) error {
+	var req dto.CreateResearchExportRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	adminID := c.Locals(shared.UserID).(string)
+	export, err := h.researchSvc.RequestExport(adminID, shared.RequestIP(c), c.Get("User-Agent"), req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusAccepted, "Research export started", export)
+}
+
+// @Summary List Research Exports (Admin)
+// @Description Get research exports with their status, newest first (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} shared.Response{data=dto.ResearchExportListResponse}
+// @Router /api/v1/admin/research/exports [get]
+func (h *ResearchHandler) GetExports(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	exports, err := h.researchSvc.GetExports(page, limit)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", exports)
+}
+
+// @Summary Get Research Export (Admin)
+// @Description Get a research export with its audit trail of requests, download links and expiry (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param exportId path string true "Export ID"
+// @Success 200 {object} shared.Response{data=dto.ResearchExportDetailResponse}
+// @Failure 404 {object} shared.Response "Research export not found"
+// @Router /api/v1/admin/research/exports/{exportId} [get]
+func (h *ResearchHandler) GetExport(c *fiber.Ctx) error {
+	export, err := h.researchSvc.GetExport(c.Params("exportId"))
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", export)
+}
+
+// @Summary Get Research Export Download Link (Admin)
+// @Description Get a signed link to a finished research export, valid for 15 minutes. Every link is recorded in the export's audit trail (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param exportId path string true "Export ID"
+// @Success 200 {object} shared.Response{data=dto.ResearchExportDownloadResponse}
+// @Failure 404 {object} shared.Response "Research export not found"
+// @Failure 409 {object} shared.Response "Research export not ready or expired"
+// @Router /api/v1/admin/research/exports/{exportId}/download [post]
+func (h *ResearchHandler) GetDownloadURL(c *fiber.Ctx) error {
+	adminID := c.Locals(shared.UserID).(string)
+	download, err := h.researchSvc.GetDownloadURL(adminID, c.Params("exportId"), shared.RequestIP(c), c.Get("User-Agent"))
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", download)
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/shared"
+)
+
+type RetentionHandler struct {
+	retentionSvc RetentionServiceInterface
+}
+
+func NewRetentionHandler(retentionSvc RetentionServiceInterface) *RetentionHandler {
+	return &RetentionHandler{
+		retentionSvc: retentionSvc,
+	}
+}
+
+// @Summary List Retention Policies (Admin)
+// @Description Get the retention window and per-user quota of each table with pruned history (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Success 200 {object} shared.Response{data=[]model.RetentionPolicy}
+// @Router /api/v1/admin/retention/policies [get]
+func (h *RetentionHandler) GetRetentionPolicies(c *fiber.Ctx) error {
+	policies, err := h.retentionSvc.GetRetentionPolicies()
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", policies)
+}
+
+// @Summary Update Retention Policy (Admin)
+// @Description Change how long raw rows of a table are kept. Pruned rows are added to daily stats first. Omitted fields are left unchanged (Admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param table path string true "Table" Enums(user_question_answers, user_lesson_attempts, guest_lesson_attempts)
+// @Param request body dto.UpdateRetentionPolicyRequest true "Changes"
+// @Success 200 {object} shared.Response{data=model.RetentionPolicy}
+// @Router /api/v1/admin/retention/policies/{table} [put]
+func (h *RetentionHandler) UpdateRetentionPolicy(c *fiber.Ctx) error {
+	var req dto.UpdateRetentionPolicyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	adminID := c.Locals(shared.UserID).(string)
+	policy, err := h.retentionSvc.UpdateRetentionPolicy(adminID, c.Params("table"), req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Retention policy updated", policy)
+}
+
+// @Summary Start Retention Run (Admin)
+// @Description Apply the enabled retention policies now instead of waiting for the daily run (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Success 202 {object} shared.Response{data=dto.RetentionRunResponse}
+// @Router /api/v1/admin/retention/run [post]
+func (h *RetentionHandler) StartRetentionRun(c *fiber.Ctx) error {
+	job, err := h.retentionSvc.StartRetentionRun()
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusAccepted, "Retention run started", job)
+}
+
+// @Summary Get Retention Run Status (Admin)
+// @Description Get the progress of the current or latest retention run (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Success 200 {object} shared.Response{data=dto.RetentionRunResponse}
+// @Router /api/v1/admin/retention/run [get]
+func (h *RetentionHandler) GetRetentionStatus(c *fiber.Ctx) error {
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", h.retentionSvc.GetRetentionStatus())
+}
+
+// @Summary Get Table Sizes (Admin)
+// @Description Get the disk usage and estimated row count of every table, largest first (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Success 200 {object} shared.Response{data=[]dto.TableSizeResponse}
+// @Router /api/v1/admin/storage/tables [get]
+func (h *RetentionHandler) GetTableSizes(c *fiber.Ctx) error {
+	sizes, err := h.retentionSvc.GetTableSizes()
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", sizes)
+}
+
+// @Summary Get Anonymization Runs (Admin)
+// @Description Get the latest runs of the inactive account anonymization job with how many accounts each warned and anonymized, newest first (Admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Success 200 {object} shared.Response{data=[]model.AnonymizationRun}
+// @Router /api/v1/admin/retention/anonymization [get]
+func (h *RetentionHandler) GetAnonymizationRuns(c *fiber.Ctx) error {
+	runs, err := h.retentionSvc.GetAnonymizationRuns()
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", runs)
+}
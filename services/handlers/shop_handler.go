@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/shared"
+)
+
+type ShopHandler struct {
+	shopSvc ShopServiceInterface
+}
+
+func NewShopHandler(shopSvc ShopServiceInterface) *ShopHandler {
+	return &ShopHandler{
+		shopSvc: shopSvc,
+	}
+}
+
+// @Summary Get shop
+// @Description Get the items on sale for coins and the user's coin balance
+// @Tags shop
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Success 200 {object} shared.Response{data=dto.ShopResponse}
+// @Router /api/v1/shop [get]
+func (h *ShopHandler) GetShop(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	shop, err := h.shopSvc.GetShop(userID)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", shop)
+}
+
+// @Summary Buy a shop item
+// @Description Buy an item with coins. Retrying with the same idempotency key returns the first purchase without paying again.
+// @Tags shop
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param request body dto.ShopPurchaseRequest true "Item and idempotency key"
+// @Success 200 {object} shared.Response{data=dto.ShopPurchaseResponse}
+// @Failure 400 {object} shared.Response "Not enough coins, hearts full or streak freeze limit reached"
+// @Failure 409 {object} shared.Response "Cosmetic already owned or idempotency key used for another item"
+// @Router /api/v1/shop/purchase [post]
+func (h *ShopHandler) Purchase(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	var req dto.ShopPurchaseRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.CreateValidationErrorResponse(err))
+	}
+
+	purchase, err := h.shopSvc.Purchase(userID, req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", purchase)
+}
+
+// @Summary Get wallet
+// @Description Get the user's coin balance and coins earned overall
+// @Tags shop
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Success 200 {object} shared.Response{data=dto.WalletResponse}
+// @Router /api/v1/shop/wallet [get]
+func (h *ShopHandler) GetWallet(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	wallet, err := h.shopSvc.GetWallet(userID)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", wallet)
+}
+
+// @Summary Get coin transactions
+// @Description Get every coin the user earned or spent, newest first
+// @Tags shop
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} shared.Response{data=dto.CoinLedgerResponse}
+// @Router /api/v1/shop/wallet/transactions [get]
+func (h *ShopHandler) GetCoinLedger(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	ledger, err := h.shopSvc.GetCoinLedger(userID, page, limit)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", ledger)
+}
+
+// @Summary Get owned cosmetics
+// @Description Get the cosmetics the user bought in the shop
+// @Tags shop
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Success 200 {object} shared.Response{data=[]dto.UserCosmeticResponse}
+// @Router /api/v1/shop/cosmetics [get]
+func (h *ShopHandler) GetCosmetics(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	cosmetics, err := h.shopSvc.GetCosmetics(userID)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", cosmetics)
+}
+
+// @Summary List shop items (Admin)
+// @Description List every shop item, including those taken off sale (admin only)
+// @Tags admin
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Success 200 {object} shared.Response{data=[]model.ShopItem}
+// @Router /api/v1/admin/shop/items [get]
+func (h *ShopHandler) GetShopItems(c *fiber.Ctx) error {
+	items, err := h.shopSvc.GetShopItems()
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", items)
+}
+
+// @Summary Create shop item (Admin)
+// @Description Put a heart refill, streak freeze or cosmetic on sale for coins (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param request body dto.CreateShopItemRequest true "Shop item"
+// @Success 201 {object} shared.Response{data=model.ShopItem}
+// @Router /api/v1/admin/shop/items [post]
+func (h *ShopHandler) CreateShopItem(c *fiber.Ctx) error {
+	var req dto.CreateShopItemRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.CreateValidationErrorResponse(err))
+	}
+
+	item, err := h.shopSvc.CreateShopItem(req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusCreated, "Shop item created successfully", item)
+}
+
+// @Summary Update shop item (Admin)
+// @Description Change a shop item's details, price or availability (admin only)
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param itemId path string true "Item ID"
+// @Param request body dto.UpdateShopItemRequest true "Fields to change"
+// @Success 200 {object} shared.Response{data=model.ShopItem}
+// @Router /api/v1/admin/shop/items/{itemId} [put]
+func (h *ShopHandler) UpdateShopItem(c *fiber.Ctx) error {
+	var req dto.UpdateShopItemRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.CreateValidationErrorResponse(err))
+	}
+
+	item, err := h.shopSvc.UpdateShopItem(c.Params("itemId"), req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Shop item updated successfully", item)
+}
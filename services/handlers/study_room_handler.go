@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/shared"
+)
+
+type StudyRoomHandler struct {
+	studyRoomSvc StudyRoomServiceInterface
+}
+
+func NewStudyRoomHandler(studyRoomSvc StudyRoomServiceInterface) *StudyRoomHandler {
+	return &StudyRoomHandler{
+		studyRoomSvc: studyRoomSvc,
+	}
+}
+
+// @Summary Create study room
+// @Description Open a co-op study room on a lesson. Share the returned code with up to 4 friends; the host starts the room once someone joined.
+// @Tags study-rooms
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param request body dto.CreateStudyRoomRequest true "Lesson to study"
+// @Success 201 {object} shared.Response{data=dto.StudyRoomResponse}
+// @Failure 409 {object} shared.Response "Already in a study room"
+// @Router /api/v1/study-rooms [post]
+func (h *StudyRoomHandler) CreateStudyRoom(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	var req dto.CreateStudyRoomRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.CreateValidationErrorResponse(err))
+	}
+
+	room, err := h.studyRoomSvc.CreateStudyRoom(userID, req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusCreated, "Study room created", room)
+}
+
+// @Summary Join study room
+// @Description Join a friend's waiting study room with its code
+// @Tags study-rooms
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param request body dto.JoinStudyRoomRequest true "Room code"
+// @Success 200 {object} shared.Response{data=dto.StudyRoomResponse}
+// @Failure 404 {object} shared.Response "No waiting room with this code"
+// @Failure 409 {object} shared.Response "Room full or started, or already in another room"
+// @Router /api/v1/study-rooms/join [post]
+func (h *StudyRoomHandler) JoinStudyRoom(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	var req dto.JoinStudyRoomRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.CreateValidationErrorResponse(err))
+	}
+
+	room, err := h.studyRoomSvc.JoinStudyRoom(userID, req.Code)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", room)
+}
+
+// @Summary Get current study room
+// @Description Get the waiting or active study room the user is in, e.g. to reconnect after the app restarted
+// @Tags study-rooms
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Success 200 {object} shared.Response{data=dto.StudyRoomResponse}
+// @Failure 404 {object} shared.Response "Not in a study room"
+// @Router /api/v1/study-rooms/current [get]
+func (h *StudyRoomHandler) GetCurrentStudyRoom(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	room, err := h.studyRoomSvc.GetCurrentStudyRoom(userID)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", room)
+}
+
+// @Summary Get study room
+// @Description Get a study room's members, their progress and the questions the user already answered
+// @Tags study-rooms
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param roomId path string true "Study room ID"
+// @Success 200 {object} shared.Response{data=dto.StudyRoomResponse}
+// @Router /api/v1/study-rooms/{roomId} [get]
+func (h *StudyRoomHandler) GetStudyRoom(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	room, err := h.studyRoomSvc.GetStudyRoom(userID, c.Params("roomId"))
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", room)
+}
+
+// @Summary Start study room
+// @Description Start answering. Only the host can start, once at least one friend joined; nobody can join afterwards.
+// @Tags study-rooms
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param roomId path string true "Study room ID"
+// @Success 200 {object} shared.Response{data=dto.StudyRoomResponse}
+// @Router /api/v1/study-rooms/{roomId}/start [post]
+func (h *StudyRoomHandler) StartStudyRoom(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	room, err := h.studyRoomSvc.StartStudyRoom(userID, c.Params("roomId"))
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", room)
+}
+
+// @Summary Answer study room question
+// @Description Answer one of the room's questions, for clients not connected live. Each question can be answered once.
+// @Tags study-rooms
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param roomId path string true "Study room ID"
+// @Param request body dto.StudyRoomAnswerRequest true "Question and answer"
+// @Success 200 {object} shared.Response{data=dto.StudyRoomAnswerResponse}
+// @Failure 409 {object} shared.Response "Already answered or room ended"
+// @Router /api/v1/study-rooms/{roomId}/answers [post]
+func (h *StudyRoomHandler) SubmitAnswer(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	var req dto.StudyRoomAnswerRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.CreateValidationErrorResponse(err))
+	}
+
+	result, err := h.studyRoomSvc.SubmitStudyRoomAnswer(userID, c.Params("roomId"), req.QuestionID, req.Answer)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", result)
+}
+
+// @Summary Leave study room
+// @Description Leave a study room. A leaving host hands the room to the next member; leaving after the start gives up the group bonus.
+// @Tags study-rooms
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param roomId path string true "Study room ID"
+// @Success 200 {object} shared.Response
+// @Router /api/v1/study-rooms/{roomId}/leave [post]
+func (h *StudyRoomHandler) LeaveStudyRoom(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	if err := h.studyRoomSvc.LeaveStudyRoom(userID, c.Params("roomId")); err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Left study room", nil)
+}
+
+// @Summary Close study room
+// @Description Close the room for everyone before it finishes, without a bonus (host only)
+// @Tags study-rooms
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param roomId path string true "Study room ID"
+// @Success 200 {object} shared.Response
+// @Router /api/v1/study-rooms/{roomId} [delete]
+func (h *StudyRoomHandler) CloseStudyRoom(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	if err := h.studyRoomSvc.CloseStudyRoom(userID, c.Params("roomId")); err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Study room closed", nil)
+}
+
+// @Summary Connect to study room
+// @Description Upgrade to a WebSocket for a room. The server sends the room state on connect, so reconnecting clients catch up, then pushes membership, progress and presence events. Members answer by sending {"type":"answer","question_id":"...","answer":...}. Browsers can authenticate with the access token cookie.
+// @Tags study-rooms
+// @Security Bearer
+// @Param Authorization header string false "User Bearer Token" default(Bearer <user_token>)
+// @Param roomId path string true "Study room ID"
+// @Success 101 {object} dto.StudyRoomEvent
+// @Failure 404 {object} shared.Response
+// @Failure 426 {object} shared.Response
+// @Router /api/v1/study-rooms/{roomId}/live [get]
+func (h *StudyRoomHandler) LiveStudyRoom(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+	roomID := c.Params("roomId")
+
+	if _, err := h.studyRoomSvc.GetStudyRoom(userID, roomID); err != nil {
+		return err
+	}
+
+	if !isWebSocketUpgrade(c) {
+		return shared.ResponseJSON(c, fiber.StatusUpgradeRequired, "WebSocket upgrade required", nil)
+	}
+
+	return upgradeWebSocket(c, func(ws *wsConn) {
+		events, unsubscribe := h.studyRoomSvc.SubscribeStudyRoom(roomID, userID)
+		defer unsubscribe()
+
+		// Loaded after subscribing so no change falls between the state and the events
+		room, err := h.studyRoomSvc.GetStudyRoom(userID, roomID)
+		if err != nil || ws.WriteJSON(studyRoomEvent(dto.StudyRoomEventRoom, room)) != nil {
+			return
+		}
+
+		ticker := time.NewTicker(wsPingInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ws.Done():
+				return
+			case <-ticker.C:
+				if err := ws.Ping(); err != nil {
+					return
+				}
+			case event := <-events:
+				if err := ws.WriteJSON(event); err != nil {
+					return
+				}
+			case message := <-ws.Messages():
+				if err := ws.WriteJSON(h.handleStudyRoomMessage(userID, roomID, message)); err != nil {
+					return
+				}
+			}
+		}
+	})
+}
+
+// handleStudyRoomMessage answers a question sent over the live connection and returns
+// the event to send back to the member
+func (h *StudyRoomHandler) handleStudyRoomMessage(userID, roomID string, payload []byte) dto.StudyRoomEvent {
+	var message dto.StudyRoomMessage
+	if err := json.Unmarshal(payload, &message); err != nil {
+		return studyRoomError("Invalid message")
+	}
+	if message.Type != dto.StudyRoomMessageAnswer {
+		return studyRoomError("Unknown message type")
+	}
+	if err := message.Validate(); err != nil {
+		return studyRoomError("question_id and answer are required")
+	}
+
+	result, err := h.studyRoomSvc.SubmitStudyRoomAnswer(userID, roomID, message.QuestionID, message.Answer)
+	if err != nil {
+		if appErr, ok := shared.GetAppError(err); ok {
+			return studyRoomError(appErr.Message)
+		}
+		return studyRoomError("Failed to save answer")
+	}
+	return studyRoomEvent(dto.StudyRoomEventAnswer, result)
+}
+
+func studyRoomEvent(eventType string, data interface{}) dto.StudyRoomEvent {
+	return dto.StudyRoomEvent{Type: eventType, Data: data, Timestamp: time.Now()}
+}
+
+func studyRoomError(message string) dto.StudyRoomEvent {
+	return studyRoomEvent(dto.StudyRoomEventError, dto.StudyRoomError{Message: message})
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/shared"
+)
+
+type TranslationHandler struct {
+	translationSvc TranslationServiceInterface
+}
+
+func NewTranslationHandler(translationSvc TranslationServiceInterface) *TranslationHandler {
+	return &TranslationHandler{
+		translationSvc: translationSvc,
+	}
+}
+
+// @Summary Get Lesson Translation Report (Admin)
+// @Description Get translation completeness, review status and staleness for each supported locale (Admin only)
+// @Tags admin,translation
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param lessonId path string true "Lesson ID"
+// @Success 200 {object} shared.Response{data=dto.LessonTranslationReportResponse}
+// @Router /api/v1/admin/lessons/{lessonId}/translations [get]
+func (h *TranslationHandler) GetTranslationReport(c *fiber.Ctx) error {
+	report, err := h.translationSvc.GetTranslationReport(c.Params("lessonId"))
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", report)
+}
+
+// @Summary Get Lesson Translation (Admin)
+// @Description Get a lesson's translation for one locale (Admin only)
+// @Tags admin,translation
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param lessonId path string true "Lesson ID"
+// @Param locale path string true "Locale" example(en)
+// @Success 200 {object} shared.Response{data=dto.LessonTranslationResponse}
+// @Router /api/v1/admin/lessons/{lessonId}/translations/{locale} [get]
+func (h *TranslationHandler) GetTranslation(c *fiber.Ctx) error {
+	translation, err := h.translationSvc.GetTranslation(c.Params("lessonId"), c.Params("locale"))
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", translation)
+}
+
+// @Summary Machine Translate Lesson (Admin)
+// @Description Create a machine translated draft of a lesson. Translations in review or approved are not overwritten (Admin only)
+// @Tags admin,translation
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param lessonId path string true "Lesson ID"
+// @Param locale path string true "Locale" example(en)
+// @Success 200 {object} shared.Response{data=dto.LessonTranslationResponse}
+// @Router /api/v1/admin/lessons/{lessonId}/translations/{locale}/machine [post]
+func (h *TranslationHandler) MachineTranslate(c *fiber.Ctx) error {
+	adminID := c.Locals(shared.UserID).(string)
+
+	translation, err := h.translationSvc.MachineTranslate(adminID, c.Params("lessonId"), c.Params("locale"))
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Machine translation drafted", translation)
+}
+
+// @Summary Save Lesson Translation (Admin)
+// @Description Save a human edited translation as a draft. Question options must keep the order of the source (Admin only)
+// @Tags admin,translation
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param lessonId path string true "Lesson ID"
+// @Param locale path string true "Locale" example(en)
+// @Param translation body dto.UpdateLessonTranslationRequest true "Translated content"
+// @Success 200 {object} shared.Response{data=dto.LessonTranslationResponse}
+// @Router /api/v1/admin/lessons/{lessonId}/translations/{locale} [put]
+func (h *TranslationHandler) SaveTranslation(c *fiber.Ctx) error {
+	var req dto.UpdateLessonTranslationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	adminID := c.Locals(shared.UserID).(string)
+	translation, err := h.translationSvc.SaveTranslation(adminID, c.Params("lessonId"), c.Params("locale"), req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Translation saved", translation)
+}
+
+// @Summary Submit Translation for Review (Admin)
+// @Description Move a draft translation into the review queue (Admin only)
+// @Tags admin,translation
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param lessonId path string true "Lesson ID"
+// @Param locale path string true "Locale" example(en)
+// @Success 200 {object} shared.Response{data=dto.LessonTranslationResponse}
+// @Router /api/v1/admin/lessons/{lessonId}/translations/{locale}/submit [post]
+func (h *TranslationHandler) SubmitForReview(c *fiber.Ctx) error {
+	adminID := c.Locals(shared.UserID).(string)
+
+	translation, err := h.translationSvc.SubmitForReview(adminID, c.Params("lessonId"), c.Params("locale"))
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Translation submitted for review", translation)
+}
+
+// @Summary Review Translation (Admin)
+// @Description Approve or reject a translation in review. Approved translations are shown to learners (Admin only)
+// @Tags admin,translation
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param lessonId path string true "Lesson ID"
+// @Param locale path string true "Locale" example(en)
+// @Param review body dto.ReviewTranslationRequest true "Review decision"
+// @Success 200 {object} shared.Response{data=dto.LessonTranslationResponse}
+// @Router /api/v1/admin/lessons/{lessonId}/translations/{locale}/review [post]
+func (h *TranslationHandler) ReviewTranslation(c *fiber.Ctx) error {
+	var req dto.ReviewTranslationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	reviewerID := c.Locals(shared.UserID).(string)
+	translation, err := h.translationSvc.ReviewTranslation(reviewerID, c.Params("lessonId"), c.Params("locale"), req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Translation reviewed", translation)
+}
+
+// @Summary Get Lessons Missing Translations (Admin)
+// @Description List lessons without an approved translation in a locale (Admin only)
+// @Tags admin,translation
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param locale query string true "Locale" example(en)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} shared.Response{data=dto.MissingTranslationListResponse}
+// @Router /api/v1/admin/translations/missing [get]
+func (h *TranslationHandler) GetMissingTranslations(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	lessons, err := h.translationSvc.GetLessonsMissingTranslations(c.Query("locale"), page, limit)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", lessons)
+}
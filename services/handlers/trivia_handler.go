@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/shared"
+)
+
+type TriviaHandler struct {
+	triviaSvc TriviaServiceInterface
+}
+
+func NewTriviaHandler(triviaSvc TriviaServiceInterface) *TriviaHandler {
+	return &TriviaHandler{
+		triviaSvc: triviaSvc,
+	}
+}
+
+// @Summary Get daily trivia
+// @Description Get today's trivia question. Once answered, also returns the user's result and global stats.
+// @Tags trivia
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Success 200 {object} shared.Response{data=dto.DailyTriviaResponse}
+// @Router /api/v1/trivia/today [get]
+func (h *TriviaHandler) GetToday(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	trivia, err := h.triviaSvc.GetToday(userID)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", trivia)
+}
+
+// @Summary Answer daily trivia
+// @Description Answer today's trivia question. One attempt per day; a correct answer earns bonus XP.
+// @Tags trivia
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param request body dto.DailyTriviaAnswerRequest true "Answer to today's question"
+// @Success 200 {object} shared.Response{data=dto.DailyTriviaAnswerResponse}
+// @Failure 409 {object} shared.Response "Already answered today"
+// @Router /api/v1/trivia/today/answer [post]
+func (h *TriviaHandler) SubmitAnswer(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	var req dto.DailyTriviaAnswerRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.CreateValidationErrorResponse(err))
+	}
+
+	result, err := h.triviaSvc.SubmitAnswer(userID, req.Answer)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", result)
+}
+
+// @Summary Get trivia stats
+// @Description Get how many users answered a day's trivia and the percentage who got it right
+// @Tags trivia
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param date query string false "Day in YYYY-MM-DD, defaults to today"
+// @Success 200 {object} shared.Response{data=dto.TriviaStatsResponse}
+// @Router /api/v1/trivia/stats [get]
+func (h *TriviaHandler) GetStats(c *fiber.Ctx) error {
+	stats, err := h.triviaSvc.GetStats(c.Query("date"))
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", stats)
+}
+
+// @Summary Get trivia streak
+// @Description Get the user's consecutive days of trivia participation
+// @Tags trivia
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Success 200 {object} shared.Response{data=dto.TriviaStreakResponse}
+// @Router /api/v1/trivia/streak [get]
+func (h *TriviaHandler) GetStreak(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	streak, err := h.triviaSvc.GetStreak(userID)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", streak)
+}
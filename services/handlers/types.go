@@ -15,6 +15,7 @@ type AuthServiceInterface interface {
 	Logout(userID, sessionID, accessToken, clientIP, userAgent string) error
 	LogoutAllDevices(userID, sessionID, accessToken, clientIP, userAgent string) error
 	VerifyEmail(email, code string) error
+	VerifyEmailLink(token string) error
 	ResendVerificationEmail(email string) error
 	ForgotPassword(email string) error
 	ResetPassword(req dto.ResetPasswordRequest) error
@@ -22,8 +23,28 @@ type AuthServiceInterface interface {
 	GetUserDevices(userID string) ([]dto.DeviceInfo, error)
 	UpdateDeviceTrust(userID, deviceID string, trust bool) error
 	RemoveDevice(userID, deviceID string) error
+	ConfirmEmailChange(userID, code string) error
+	RevertEmailChange(token string) error
+	ApplyDeviceAction(token, clientIP, userAgent string) error
+	StartAccountRecovery(req dto.StartAccountRecoveryRequest, clientIP, userAgent string) (*dto.AccountRecoveryStartedResponse, error)
+	GetAccountRecoveryStatus(recoveryID, token string) (*dto.AccountRecoveryStatusResponse, error)
+	GetAccountRecoveries(userID string) (*dto.AccountRecoveryListResponse, error)
+	DecideAccountRecovery(userID, sessionID, recoveryID string, approve bool, clientIP, userAgent string) error
+	CancelAccountRecovery(cancelToken, clientIP, userAgent string) error
+	CompleteAccountRecovery(req dto.CompleteAccountRecoveryRequest, clientIP, userAgent string) error
+	LoginTwoFactor(req dto.TwoFactorLoginRequest, clientIP, userAgent string) (*dto.LoginResponse, error)
+	SetupTwoFactor(userID string) (*dto.EnableTwoFactorResponse, error)
+	EnableTwoFactor(userID, code, clientIP, userAgent string) (*dto.BackupCodesResponse, error)
+	DisableTwoFactor(userID, code, clientIP, userAgent string) error
+	RegenerateBackupCodes(userID, code, clientIP, userAgent string) (*dto.BackupCodesResponse, error)
+	VerifyAuditLogChain() (*dto.AuditChainVerification, error)
+	ExportAuditLogSegment(fromSequence, toSequence int64) (*dto.AuditLogSegment, error)
 	RequiredAuth() fiber.Handler
-	RequireRole(role string) fiber.Handler
+	RequireRole(roles ...string) fiber.Handler
+	ExtractAccessToken(c *fiber.Ctx) (string, error)
+	UsesCookieSession(c *fiber.Ctx) bool
+	SetSessionCookies(c *fiber.Ctx, resp *dto.LoginResponse) error
+	ClearSessionCookies(c *fiber.Ctx)
 }
 
 type JWTServiceInterface interface {
@@ -35,9 +56,13 @@ type UserServiceInterface interface {
 	CheckUsernameAvailability(username string) (bool, error)
 	GetUserProfile(userID string) (*dto.UserProfileResponse, error)
 	UpdateUserProfile(userID string, req dto.UpdateProfileRequest) (*dto.UserProfileResponse, error)
-	InitializeUserProfile(userID string, birthYear int) error
+	InitializeUserProfile(userID string, req dto.InitializeProfileRequest) error
+	GetZodiac(userID string) (*dto.ZodiacResponse, error)
+	CorrectZodiac(userID string, req dto.CorrectZodiacRequest) (*dto.ZodiacResponse, error)
 	GetUserProgress(userID string) (*dto.UserProgressResponse, error)
-	GetUserCollection(userID string) (*dto.CollectionResponse, error)
+	GetUserCollection(userID string, query dto.CollectionQuery, markViewed bool) (*dto.CollectionResponse, error)
+	FavoriteCharacter(userID, characterID string) error
+	UnfavoriteCharacter(userID, characterID string) error
 	CheckLessonAccess(userID, lessonID string) (*dto.LessonAccessResponse, error)
 	CompleteLesson(userID, lessonID string, score, timeSpent int) error
 	GetHeartStatus(userID string) (*dto.HeartStatusResponse, error)
@@ -47,52 +72,274 @@ type UserServiceInterface interface {
 	RevokeUserSession(userID, sessionID string) error
 	GetSecuritySettings(userID string) (*dto.SecuritySettings, error)
 	UpdateSecuritySettings(userID string, req dto.UpdateSecuritySettingsRequest) (*dto.SecuritySettings, error)
+	GetParentalControls(userID string) (*dto.ParentalControlsResponse, error)
+	UpdateParentalControls(userID string, req dto.UpdateParentalControlsRequest) (*dto.ParentalControlsResponse, error)
 	GetUserAuditLogs(userID string, page, limit int) (*dto.AuditLogResponse, error)
 	CreateShareContent(userID string, req dto.ShareRequest) (*dto.ShareResponse, error)
-	GetWeeklyLeaderboard(limit int, userID string) (*dto.LeaderboardResponse, error)
-	GetMonthlyLeaderboard(limit int, userID string) (*dto.LeaderboardResponse, error)
-	GetAllTimeLeaderboard(limit int, userID string) (*dto.LeaderboardResponse, error)
+	GetWeeklyLeaderboard(leaderboardType string, limit int, userID string) (*dto.LeaderboardResponse, error)
+	GetMonthlyLeaderboard(leaderboardType string, limit int, userID string) (*dto.LeaderboardResponse, error)
+	GetAllTimeLeaderboard(leaderboardType string, limit int, userID string) (*dto.LeaderboardResponse, error)
 	AdminGetUsers(page, limit int, search string) (*dto.AdminUserListResponse, error)
 	AdminUpdateUser(userID string, req dto.AdminUpdateUserRequest) (*dto.AdminUserInfo, error)
 	AdminDeleteUser(userID string) error
+	GetUserNotes(userID, tag string, page, limit int) (*dto.UserNoteListResponse, error)
+	CreateUserNote(authorID, userID string, req dto.UserNoteRequest) (*dto.UserNoteResponse, error)
+	UpdateUserNote(actor *model.User, userID, noteID string, req dto.UserNoteRequest) (*dto.UserNoteResponse, error)
+	DeleteUserNote(actor *model.User, userID, noteID string) error
+	GetXPLedger(userID string, page, limit int) (*dto.XPLedgerResponse, error)
+	GetHeartLedger(userID string, page, limit int) (*dto.HeartLedgerResponse, error)
+	GetItemLedger(userID string, page, limit int) (*dto.ItemLedgerResponse, error)
+	AdjustEconomy(adminID string, req dto.AdminEconomyAdjustRequest) (*dto.AdminEconomyAdjustResponse, error)
+	RepairUserProgress(userID string, dryRun bool) (*dto.ProgressRepairReport, error)
+	StartProgressRepair(dryRun bool) (*dto.ProgressRepairJobResponse, error)
+	GetProgressRepairStatus() *dto.ProgressRepairJobResponse
+	GetCompletionFlags(status string, page, limit int) (*dto.CompletionFlagListResponse, error)
+	ReviewCompletionFlag(adminID, flagID string, req dto.ReviewCompletionFlagRequest) (*dto.CompletionFlagResponse, error)
+	GetLeaderboardAnomalies(status string, page, limit int) (*dto.LeaderboardAnomalyListResponse, error)
+	GetLeaderboardAnomalyEvidence(anomalyID string) (*dto.LeaderboardAnomalyEvidenceResponse, error)
+	ReviewLeaderboardAnomaly(adminID, anomalyID string, req dto.ReviewLeaderboardAnomalyRequest) (*dto.LeaderboardAnomalyResponse, error)
+	GetModerationFlags(status string, page, limit int) (*dto.ModerationFlagListResponse, error)
+	ReviewModerationFlag(adminID, flagID string, req dto.ReviewModerationFlagRequest) (*dto.ModerationFlagResponse, error)
+	GetOnboardingState(userID string) (*dto.OnboardingResponse, error)
+	BookmarkLesson(userID, lessonID string) (*dto.LessonBookmarkResponse, error)
+	RemoveBookmark(userID, lessonID string) error
+	GetBookmarks(userID string, page, limit int) (*dto.LessonBookmarkListResponse, error)
+	GetGameConfig() (*model.GameConfig, error)
+	UpdateGameConfig(adminID string, req dto.UpdateGameConfigRequest) (*model.GameConfig, error)
 }
 
 type GuestServiceInterface interface {
-	CreateOrGetSession(deviceID string) (*model.GuestSession, error)
+	CreateOrGetSession(deviceID string, attestation *dto.AttestationPayload) (*model.GuestSession, error)
 	CanAccessLesson(sessionID, lessonID string) (bool, string, error)
-	CompleteLesson(sessionID, lessonID string, score, timeSpent int) error
-	AddHeartsFromAd(sessionID string) error
+	GetAttemptQuota(sessionID, ipAddress string) (*dto.GuestAttemptQuota, error)
+	CompleteLesson(sessionID, ipAddress, lessonID string, score, timeSpent int) (*dto.GuestAttemptQuota, error)
+	AddHeartsFromAd(sessionID string, attestation *dto.AttestationPayload) error
 	LoseHeart(sessionID string) error
+	IssueAttestationChallenge() (*dto.AttestationChallengeResponse, error)
 }
 
 type ContentServiceInterface interface {
 	GetTimeline() (*dto.TimelineCollectionResponse, error)
 	GetCharacters(dynasty, rarity string) (*dto.CharacterCollectionResponse, error)
 	GetCharacterDetails(characterID string) (*dto.CharacterResponse, error)
-	GetCharacterLessons(characterID string) ([]dto.LessonResponse, error)
-	GetLessonContent(lessonID string) (*dto.LessonResponse, error)
+	GetCharacterLessons(characterID, userID string) ([]dto.LessonResponse, error)
+	GetLessonContent(lessonID, locale, userID string) (*dto.LessonResponse, error)
 	ValidateLessonAnswers(lessonID string, userAnswers map[string]interface{}) (*dto.ValidateLessonResponse, error)
 	SearchContent(req dto.SearchRequest) (*dto.SearchResponse, error)
+	SuggestSearch(query string, limit int) (*dto.SearchSuggestResponse, error)
+	GetTrendingContent(entityType string, limit int, userID string) (*dto.TrendingContentResponse, error)
 	SubmitQuestionAnswer(userID, lessonID, questionID string, answer interface{}) (*dto.SubmitQuestionAnswerResponse, error)
 	CheckLessonStatus(userID, lessonID string) (*dto.CheckLessonStatusResponse, error)
+	GetLessonSession(userID, lessonID string) (*dto.LessonSessionResponse, error)
+	UpdateLessonSession(userID, lessonID string, req dto.UpdateLessonSessionRequest) (*dto.LessonSessionResponse, error)
+	RestartLessonSession(userID, lessonID string) error
+	RecordVideoProgress(userID, lessonID string, req dto.VideoProgressRequest) (*dto.VideoProgressResponse, error)
+	UseFiftyFifty(userID, lessonID, questionID string, req dto.UseLessonAidRequest) (*dto.FiftyFiftyResponse, error)
 	GetEras() ([]string, error)
 	GetDynasties() ([]string, error)
-	CreateCharacter(character *model.Character) (*dto.CharacterResponse, error)
-	CreateLessonFromRequest(req dto.CreateLessonRequest) (*dto.LessonResponse, error)
-	UpdateLessonScript(lessonID, script string) (*model.Lesson, error)
+	GetAllEras() ([]model.Era, error)
+	CreateEra(adminID string, req dto.CreateEraRequest) (*model.Era, error)
+	UpdateEra(adminID, code string, req dto.EraRequest) (*model.Era, error)
+	DeleteEra(adminID, code string) error
+	GetAllDynasties() ([]model.Dynasty, error)
+	CreateDynasty(adminID string, req dto.DynastyRequest) (*model.Dynasty, error)
+	UpdateDynasty(adminID, dynastyID string, req dto.DynastyRequest) (*model.Dynasty, error)
+	DeleteDynasty(adminID, dynastyID string) error
+	CreateCharacter(adminID string, character *model.Character) (*dto.CharacterResponse, error)
+	GetCharacterRelations(characterID string) ([]dto.RelatedCharacterResponse, error)
+	CreateCharacterRelation(adminID, characterID string, req dto.CreateCharacterRelationRequest) (*model.CharacterRelation, error)
+	UpdateCharacterRelation(adminID, relationID string, req dto.UpdateCharacterRelationRequest) (*model.CharacterRelation, error)
+	DeleteCharacterRelation(adminID, relationID string) error
+	SearchGlossary(query, era string, page, limit int) (*dto.GlossaryTermListResponse, error)
+	GetGlossaryTerm(termID string) (*model.GlossaryTerm, error)
+	CreateGlossaryTerm(adminID string, req dto.GlossaryTermRequest) (*model.GlossaryTerm, error)
+	UpdateGlossaryTerm(adminID, termID string, req dto.GlossaryTermRequest) (*model.GlossaryTerm, error)
+	DeleteGlossaryTerm(adminID, termID string) error
+	CreateLessonFromRequest(adminID string, req dto.CreateLessonRequest) (*dto.LessonResponse, error)
+	UpdateLessonScript(adminID, lessonID, script string) (*model.Lesson, error)
+	UpdateLessonContentRating(adminID, lessonID, rating string) (*model.Lesson, error)
+	UpdateLessonDifficulty(adminID, lessonID string, req dto.UpdateLessonDifficultyRequest) (*model.Lesson, error)
+	GetUnratedLessons(page, limit int) (*dto.UnratedLessonListResponse, error)
 	GetLessonProductionStatus(lessonID string) (*dto.LessonProductionStatusResponse, error)
+	LintLesson(lessonID string) (*dto.LessonLintResponse, error)
+	ReportQuestion(userID, questionID string, req dto.ReportQuestionRequest) (*dto.QuestionReportResponse, error)
+	GetLessonQuestionAnalytics(lessonID string) (*dto.LessonQuestionAnalyticsResponse, error)
+	GetDisabledQuestions(status string, page, limit int) (*dto.DisabledQuestionListResponse, error)
+	ReviewDisabledQuestion(adminID, disabledID string, req dto.ReviewDisabledQuestionRequest) (*dto.DisabledQuestionResponse, error)
 	MapLessonToResponse(lesson *model.Lesson) dto.LessonResponse
-	MarkAudioUploaded(lessonID string) error
-	MarkAnimationUploaded(lessonID string) error
+	MarkAudioUploaded(adminID, lessonID string) error
+	MarkAnimationUploaded(adminID, lessonID string) error
 	GetProgress(sessionID string) (*model.GuestProgress, error)
+	GetContentAuditLogs(entityType, entityID, adminID string, page, limit int) (*dto.ContentAuditLogListResponse, error)
+	ExportLessonQuestions(lessonID, format string) ([]byte, string, error)
+	ImportLessonQuestions(adminID, lessonID string, file *multipart.FileHeader, preview bool, baseVersion string) (*dto.QuestionImportResponse, error)
+	GetLessonVersions(lessonID string) (*dto.LessonVersionListResponse, error)
+	GetLessonVideoAnalytics(lessonID string, days int) (*dto.LessonVideoAnalyticsResponse, error)
+	GetLessonVersionDiff(lessonID string, version int) (*dto.LessonVersionDiffResponse, error)
+}
+
+type WebhookServiceInterface interface {
+	CreateWebhook(adminID string, req dto.CreateWebhookRequest) (*dto.WebhookResponse, error)
+	GetWebhooks() ([]dto.WebhookResponse, error)
+	UpdateWebhook(webhookID string, req dto.UpdateWebhookRequest) (*dto.WebhookResponse, error)
+	RotateWebhookSecret(webhookID string) (*dto.WebhookResponse, error)
+	DeleteWebhook(webhookID string) error
+	GetWebhookDeliveries(webhookID, status string, page, limit int) (*dto.WebhookDeliveryListResponse, error)
+	RedeliverWebhook(webhookID, deliveryID string) (*model.WebhookDelivery, error)
+}
+
+type RetentionServiceInterface interface {
+	GetRetentionPolicies() ([]model.RetentionPolicy, error)
+	UpdateRetentionPolicy(adminID, table string, req dto.UpdateRetentionPolicyRequest) (*model.RetentionPolicy, error)
+	StartRetentionRun() (*dto.RetentionRunResponse, error)
+	GetRetentionStatus() *dto.RetentionRunResponse
+	GetTableSizes() ([]dto.TableSizeResponse, error)
+	GetAnonymizationRuns() ([]model.AnonymizationRun, error)
+}
+
+type MaintenanceServiceInterface interface {
+	GetStatus(locale string) *dto.MaintenanceStatusResponse
+	GetState() (*dto.MaintenanceState, error)
+	SetMaintenance(adminID string, req dto.UpdateMaintenanceRequest) (*dto.MaintenanceState, error)
+	ScheduleWindow(adminID string, req dto.CreateMaintenanceWindowRequest) (*model.MaintenanceWindow, error)
+	GetWindows() ([]model.MaintenanceWindow, error)
+	CancelWindow(adminID, windowID string) error
+}
+
+type AppVersionServiceInterface interface {
+	GetVersions(platform, version string) *dto.AppVersionResponse
+	GetPolicies() ([]model.AppVersionPolicy, error)
+	UpdatePolicy(adminID, platform string, req dto.UpdateAppVersionRequest) (*model.AppVersionPolicy, error)
+}
+
+type CacheServiceInterface interface {
+	Invalidate(adminID string, req dto.InvalidateCacheRequest) (*dto.CacheInvalidationResponse, error)
+}
+
+type KillSwitchServiceInterface interface {
+	KillContent(adminID, lessonID string, req dto.KillContentRequest) (*dto.ContentIncidentResponse, error)
+	GetIncidents(status string, page, limit int) (*dto.ContentIncidentListResponse, error)
+	ResolveIncident(adminID, incidentID string, req dto.ResolveContentIncidentRequest) (*dto.ContentIncidentResponse, error)
+}
+
+type TranslationServiceInterface interface {
+	MachineTranslate(adminID, lessonID, locale string) (*dto.LessonTranslationResponse, error)
+	SaveTranslation(adminID, lessonID, locale string, req dto.UpdateLessonTranslationRequest) (*dto.LessonTranslationResponse, error)
+	SubmitForReview(adminID, lessonID, locale string) (*dto.LessonTranslationResponse, error)
+	ReviewTranslation(reviewerID, lessonID, locale string, req dto.ReviewTranslationRequest) (*dto.LessonTranslationResponse, error)
+	GetTranslation(lessonID, locale string) (*dto.LessonTranslationResponse, error)
+	GetTranslationReport(lessonID string) (*dto.LessonTranslationReportResponse, error)
+	GetLessonsMissingTranslations(locale string, page, limit int) (*dto.MissingTranslationListResponse, error)
+}
+
+type QuestionGenerationServiceInterface interface {
+	GenerateQuestionDrafts(lessonID string, req dto.GenerateQuestionsRequest) (*dto.GeneratedQuestionsResponse, error)
 }
 
 type MediaServiceInterface interface {
-	UploadLessonSubtitle(lessonID string, file *multipart.FileHeader) (*dto.MediaUploadResponse, error)
-	UploadThumbnail(lessonID string, file *multipart.FileHeader) (*dto.MediaUploadResponse, error)
+	UploadLessonSubtitle(adminID, lessonID string, file *multipart.FileHeader) (*dto.MediaUploadResponse, error)
+	UploadThumbnail(adminID, lessonID string, file *multipart.FileHeader) (*dto.MediaUploadResponse, error)
 	GetLessonMedia(lessonID string) (*dto.LessonMediaResponse, error)
-	DeleteMediaAsset(assetID string) error
-	UploadLessonAudio(lessonID string, file *multipart.FileHeader) (*dto.MediaUploadResponse, error)
-	UploadLessonAnimation(lessonID string, file *multipart.FileHeader) (*dto.MediaUploadResponse, error)
+	DeleteMediaAsset(adminID, assetID string) error
+	UploadLessonAudio(adminID, lessonID string, file *multipart.FileHeader) (*dto.MediaUploadResponse, error)
+	UploadLessonAnimation(adminID, lessonID string, file *multipart.FileHeader) (*dto.MediaUploadResponse, error)
 	GetMediaStatistics() (map[string]interface{}, error)
+	GetStorageConfig() *dto.MediaStorageConfigResponse
+	GetMediaLibrary(query dto.MediaLibraryQuery) (*dto.MediaLibraryResponse, error)
+	GetMediaAssetDetails(assetID string) (*dto.MediaLibraryItem, error)
+	GetFailedMediaProcessing(page, limit int) (*dto.MediaLibraryResponse, error)
+	RetryMediaProcessing(adminID, assetID string) (*dto.MediaLibraryItem, error)
+	UpdateMediaAsset(adminID, assetID string, req dto.UpdateMediaAssetRequest) (*dto.MediaLibraryItem, error)
+	InitUpload(adminID, lessonID string, req dto.InitUploadRequest) (*dto.UploadSessionResponse, error)
+	GetUploadStatus(uploadID string) (*dto.UploadSessionResponse, error)
+	AppendUploadChunk(uploadID string, offset int64, chunk []byte) (*dto.UploadSessionResponse, error)
+	FinalizeUpload(adminID, uploadID, checksum string) (*dto.MediaUploadResponse, error)
+	AbortUpload(uploadID string) error
+	PurgeAssetCache(assetID string) (*dto.CDNPurgeResponse, error)
+	UploadAudioDescription(adminID, lessonID string, file *multipart.FileHeader) (*dto.MediaUploadResponse, error)
+	UpdateLessonTranscript(adminID, lessonID string, req dto.UpdateLessonTranscriptRequest) (*dto.LessonAccessibility, error)
+	PrewarmLesson(adminID, lessonID string) (*dto.CDNPrewarmJobResponse, error)
+	GetLessonPrewarmStatus(lessonID string) ([]dto.CDNPrewarmJobResponse, error)
+	GetPrewarmJob(jobID string) (*dto.CDNPrewarmJobResponse, error)
+	UploadQuestionMedia(adminID, lessonID, questionID, role string, file *multipart.FileHeader) (*dto.QuestionMediaResponse, error)
+	LinkQuestionMedia(adminID, lessonID, questionID, role, assetID string) (*dto.QuestionMediaResponse, error)
+	UnlinkQuestionMedia(adminID, lessonID, questionID, role string) error
+}
+
+type NotificationServiceInterface interface {
+	GetNotifications(userID string, unreadOnly bool, page, limit int) (*dto.NotificationListResponse, error)
+	GetUnreadCount(userID string) (*dto.UnreadCountResponse, error)
+	MarkRead(userID, notificationID string) (*dto.UnreadCountResponse, error)
+	MarkAllRead(userID string) (*dto.UnreadCountResponse, error)
+
+	GetStudyReminder(userID string) (*dto.StudyReminderResponse, error)
+	SetStudyReminder(userID string, req dto.StudyReminderRequest) (*dto.StudyReminderResponse, error)
+	DeleteStudyReminder(userID string) error
+	SnoozeStudyReminder(userID string, minutes int) (*dto.StudyReminderResponse, error)
+}
+
+type SystemServiceInterface interface {
+	GetSystemStatistics() (*dto.SystemStatisticsResponse, error)
+	SubscribeOpsEvents(minSeverity string) (<-chan dto.OpsEvent, func())
+	GetSlowQueries() *dto.SlowQueryResponse
+}
+
+type BattleServiceInterface interface {
+	StartBattle(userID, dynasty string) (*dto.BattleResponse, error)
+	GetBattle(userID, battleID string) (*dto.BattleResponse, error)
+	Act(userID, battleID string, answer interface{}) (*dto.BattleActionResponse, error)
+	ResolveBattle(userID, battleID string) (*dto.BattleResultResponse, error)
+}
+
+type TriviaServiceInterface interface {
+	GetToday(userID string) (*dto.DailyTriviaResponse, error)
+	SubmitAnswer(userID string, answer interface{}) (*dto.DailyTriviaAnswerResponse, error)
+	GetStats(date string) (*dto.TriviaStatsResponse, error)
+	GetStreak(userID string) (*dto.TriviaStreakResponse, error)
+}
+
+type ShopServiceInterface interface {
+	GetShop(userID string) (*dto.ShopResponse, error)
+	Purchase(userID string, req dto.ShopPurchaseRequest) (*dto.ShopPurchaseResponse, error)
+	GetWallet(userID string) (*dto.WalletResponse, error)
+	GetCoinLedger(userID string, page, limit int) (*dto.CoinLedgerResponse, error)
+	GetCosmetics(userID string) ([]dto.UserCosmeticResponse, error)
+	GetShopItems() ([]model.ShopItem, error)
+	CreateShopItem(req dto.CreateShopItemRequest) (*model.ShopItem, error)
+	UpdateShopItem(itemID string, req dto.UpdateShopItemRequest) (*model.ShopItem, error)
+}
+
+type CertificateServiceInterface interface {
+	GetCertificates(userID string) ([]dto.CertificateResponse, error)
+	GetCertificate(userID, certificateID string) (*dto.CertificateDetailResponse, error)
+	VerifyCertificate(code string) (*dto.CertificateVerificationResponse, error)
+}
+
+type PublicContentServiceInterface interface {
+	GetPublicCharacters() (*dto.PublicCharacterListResponse, error)
+	GetPublicCharacter(characterID string) (*dto.PublicCharacterDetailResponse, error)
+	GetPublicTimeline() (*dto.PublicTimelineResponse, error)
+	GetPublicLesson(lessonID string) (*dto.PublicLessonTeaser, error)
+	GetPublicSitemap() ([]byte, error)
+	GetPublicCharacterMetadata(characterID string) (*dto.PublicPageMetadata, error)
+	GetPublicLessonMetadata(lessonID string) (*dto.PublicPageMetadata, error)
+}
+
+type ResearchExportServiceInterface interface {
+	RequestExport(adminID, ipAddress, userAgent string, req dto.CreateResearchExportRequest) (*model.ResearchExport, error)
+	GetExports(page, limit int) (*dto.ResearchExportListResponse, error)
+	GetExport(exportID string) (*dto.ResearchExportDetailResponse, error)
+	GetDownloadURL(adminID, exportID, ipAddress, userAgent string) (*dto.ResearchExportDownloadResponse, error)
+}
+
+type StudyRoomServiceInterface interface {
+	CreateStudyRoom(userID string, req dto.CreateStudyRoomRequest) (*dto.StudyRoomResponse, error)
+	JoinStudyRoom(userID, code string) (*dto.StudyRoomResponse, error)
+	GetStudyRoom(userID, roomID string) (*dto.StudyRoomResponse, error)
+	GetCurrentStudyRoom(userID string) (*dto.StudyRoomResponse, error)
+	StartStudyRoom(userID, roomID string) (*dto.StudyRoomResponse, error)
+	LeaveStudyRoom(userID, roomID string) error
+	CloseStudyRoom(userID, roomID string) error
+	SubmitStudyRoomAnswer(userID, roomID, questionID string, answer interface{}) (*dto.StudyRoomAnswerResponse, error)
+	SubscribeStudyRoom(roomID, userID string) (<-chan dto.StudyRoomEvent, func())
 }
@@ -41,8 +41,43 @@ func (h *UserHandler) GetUserProfile(c *fiber.Ctx) error {
 	return shared.ResponseJSON(c, fiber.StatusOK, "Success", profile)
 }
 
+// @Summary Confirm email change
+// @Description Confirm a pending email change with the code sent to the new address
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param confirmRequest body dto.ConfirmEmailChangeRequest true "Confirmation code"
+// @Success 200 {object} shared.Response{data=dto.UserProfileResponse}
+// @Router /api/v1/user/email/confirm [post]
+func (h *UserHandler) ConfirmEmailChange(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	var req dto.ConfirmEmailChangeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	if err := h.authSvc.ConfirmEmailChange(userID, req.Code); err != nil {
+		return err
+	}
+
+	profile, err := h.userSvc.GetUserProfile(userID)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Email changed successfully", profile)
+}
+
 // @Summary Update user profile
-// @Description Update user profile
+// @Description Update user profile. A new email is only applied after it is confirmed via POST /user/email/confirm
 // @Tags user
 // @Accept json
 // @Produce json
@@ -68,29 +103,29 @@ func (h *UserHandler) UpdateUserProfile(c *fiber.Ctx) error {
 }
 
 // @Summary Initialize user profile
-// @Description Initialize user profile
+// @Description Initialize user profile. The spirit follows the zodiac of the birth year, or of the lunar year of the birth date when given, so births before Tết get the animal of the year before
 // @Tags user
 // @Accept json
 // @Produce json
 // @Security Bearer
 // @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
-// @Param initializeRequest body map[string]int true "User profile"
+// @Param initializeRequest body dto.InitializeProfileRequest true "User profile"
 // @Success 200 {object} shared.Response{data=dto.UserProgressResponse}
 // @Router /api/v1/user/initialize [post]
 func (h *UserHandler) InitializeUserProfile(c *fiber.Ctx) error {
 	userID := c.Locals(shared.UserID).(string)
 
-	var req map[string]int
+	var req dto.InitializeProfileRequest
 	if err := c.BodyParser(&req); err != nil {
 		return shared.NewBadRequestError(err, "Invalid request")
 	}
 
-	birthYear, exists := req["birth_year"]
-	if !exists || birthYear < 1900 || birthYear > 2020 {
-		return shared.NewBadRequestError(nil, "Valid birth year is required")
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
 	}
 
-	err := h.userSvc.InitializeUserProfile(userID, birthYear)
+	err := h.userSvc.InitializeUserProfile(userID, req)
 	if err != nil {
 		return err
 	}
@@ -123,22 +158,109 @@ func (h *UserHandler) GetUserProgress(c *fiber.Ctx) error {
 	return shared.ResponseJSON(c, fiber.StatusOK, "Success", progress)
 }
 
+// @Summary Get zodiac
+// @Description Get the zodiac of the user's spirit. Without a birth date, births in January or February may belong to the lunar year before; the response then lists both possible animals
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Success 200 {object} shared.Response{data=dto.ZodiacResponse}
+// @Router /api/v1/user/zodiac [get]
+func (h *UserHandler) GetZodiac(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	zodiac, err := h.userSvc.GetZodiac(userID)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", zodiac)
+}
+
+// @Summary Correct zodiac
+// @Description Correct the zodiac once, with the exact birth date or by picking one of the two animals of the birth year. The spirit changes animal and keeps its stage and XP
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param correctRequest body dto.CorrectZodiacRequest true "Birth date or animal"
+// @Success 200 {object} shared.Response{data=dto.ZodiacResponse}
+// @Router /api/v1/user/zodiac/correct [post]
+func (h *UserHandler) CorrectZodiac(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	var req dto.CorrectZodiacRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	zodiac, err := h.userSvc.CorrectZodiac(userID, req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Zodiac corrected", zodiac)
+}
+
+// @Summary Get onboarding state
+// @Description Get the user's onboarding step. Steps already satisfied are completed automatically before responding
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Success 200 {object} shared.Response{data=dto.OnboardingResponse}
+// @Router /api/v1/user/onboarding [get]
+func (h *UserHandler) GetOnboarding(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	onboarding, err := h.userSvc.GetOnboardingState(userID)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", onboarding)
+}
+
 // @Summary Get user collection
-// @Description Get user collection
+// @Description Get user collection. Characters can be filtered and sorted; unlocks since the last visit are marked as new
 // @Tags user
 // @Accept json
 // @Produce json
 // @Security Bearer
 // @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param sort query string false "Sort by rarity, dynasty (timeline order), unlocked_at or name"
+// @Param order query string false "asc or desc" default(asc)
+// @Param era query string false "Only characters from this era"
+// @Param unlocked_only query bool false "Only unlocked characters"
+// @Param favorites_only query bool false "Only favorite characters"
 // @Success 200 {object} shared.Response{data=dto.CollectionResponse}
 // @Router /api/v1/user/collection [get]
 func (h *UserHandler) GetUserCollection(c *fiber.Ctx) error {
+	currentUserID := c.Locals(shared.UserID).(string)
 	userID := c.Query("userId")
 	if userID == "" {
-		userID = c.Locals(shared.UserID).(string)
+		userID = currentUserID
+	}
+
+	var query dto.CollectionQuery
+	if err := c.QueryParser(&query); err != nil {
+		return shared.NewBadRequestError(err, "Invalid query parameters")
+	}
+
+	if err := query.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
 	}
 
-	collection, err := h.userSvc.GetUserCollection(userID)
+	collection, err := h.userSvc.GetUserCollection(userID, query, userID == currentUserID)
 	if err != nil {
 		return err
 	}
@@ -146,6 +268,46 @@ func (h *UserHandler) GetUserCollection(c *fiber.Ctx) error {
 	return shared.ResponseJSON(c, fiber.StatusOK, "Success", collection)
 }
 
+// @Summary Favorite character
+// @Description Pin a character in the user's collection
+// @Tags user
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param characterId path string true "Character ID"
+// @Success 200 {object} shared.Response{data=nil}
+// @Router /api/v1/user/collection/favorites/{characterId} [post]
+func (h *UserHandler) FavoriteCharacter(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+	characterID := c.Params("characterId")
+
+	if err := h.userSvc.FavoriteCharacter(userID, characterID); err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Character added to favorites", nil)
+}
+
+// @Summary Unfavorite character
+// @Description Remove a character from the user's favorites
+// @Tags user
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param characterId path string true "Character ID"
+// @Success 200 {object} shared.Response{data=nil}
+// @Router /api/v1/user/collection/favorites/{characterId} [delete]
+func (h *UserHandler) UnfavoriteCharacter(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+	characterID := c.Params("characterId")
+
+	if err := h.userSvc.UnfavoriteCharacter(userID, characterID); err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Character removed from favorites", nil)
+}
+
 // @Summary Check user lesson access
 // @Description Check user lesson access
 // @Tags user
@@ -368,6 +530,58 @@ func (h *UserHandler) UpdateSecuritySettings(c *fiber.Ctx) error {
 	return shared.ResponseJSON(c, http.StatusOK, "Security settings updated successfully", settings)
 }
 
+// @Summary Get parental controls
+// @Description Get the parental content rating limit and the content ratings the user can see
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Success 200 {object} shared.Response{data=dto.ParentalControlsResponse}
+// @Router /api/v1/user/parental-controls [get]
+func (h *UserHandler) GetParentalControls(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	controls, err := h.userSvc.GetParentalControls(userID)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Success", controls)
+}
+
+// @Summary Update parental controls
+// @Description Set the content rating limit, overriding the one derived from the user's age. The first update sets the PIN, later updates must send it. An empty rating turns parental controls off.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param updateRequest body dto.UpdateParentalControlsRequest true "Parental controls"
+// @Success 200 {object} shared.Response{data=dto.ParentalControlsResponse}
+// @Failure 403 {object} shared.Response "Incorrect PIN"
+// @Router /api/v1/user/parental-controls [put]
+func (h *UserHandler) UpdateParentalControls(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	var req dto.UpdateParentalControlsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	controls, err := h.userSvc.UpdateParentalControls(userID, req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Parental controls updated successfully", controls)
+}
+
 // @Summary Get audit logs
 // @Description Get user authentication audit logs
 // @Tags user
@@ -483,6 +697,183 @@ func (h *UserHandler) RemoveUserDevice(c *fiber.Ctx) error {
 	return shared.ResponseJSON(c, http.StatusOK, "Device removed successfully", nil)
 }
 
+// @Summary Get account recovery requests
+// @Description List recent requests to recover the account, with who asked and which device answered
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Success 200 {object} shared.Response{data=dto.AccountRecoveryListResponse}
+// @Router /api/v1/user/account-recovery [get]
+func (h *UserHandler) GetAccountRecoveries(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	resp, err := h.authSvc.GetAccountRecoveries(userID)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Success", resp)
+}
+
+// @Summary Approve account recovery
+// @Description Approve a pending account recovery. Only works from a session on a trusted device
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param recoveryId path string true "Recovery ID"
+// @Success 200 {object} shared.Response
+// @Failure 403 {object} shared.Response "Not a trusted device"
+// @Router /api/v1/user/account-recovery/{recoveryId}/approve [post]
+func (h *UserHandler) ApproveAccountRecovery(c *fiber.Ctx) error {
+	return h.decideAccountRecovery(c, true)
+}
+
+// @Summary Deny account recovery
+// @Description Deny a pending account recovery. Only works from a session on a trusted device
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param recoveryId path string true "Recovery ID"
+// @Success 200 {object} shared.Response
+// @Failure 403 {object} shared.Response "Not a trusted device"
+// @Router /api/v1/user/account-recovery/{recoveryId}/deny [post]
+func (h *UserHandler) DenyAccountRecovery(c *fiber.Ctx) error {
+	return h.decideAccountRecovery(c, false)
+}
+
+func (h *UserHandler) decideAccountRecovery(c *fiber.Ctx, approve bool) error {
+	userID := c.Locals(shared.UserID).(string)
+	sessionID := c.Locals("session_id").(string)
+	recoveryID := c.Params("recoveryId")
+
+	if err := h.authSvc.DecideAccountRecovery(userID, sessionID, recoveryID, approve, shared.RequestIP(c), c.Get("User-Agent")); err != nil {
+		return err
+	}
+
+	message := "Account recovery denied"
+	if approve {
+		message = "Account recovery approved"
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, message, nil)
+}
+
+// @Summary Set up two-factor authentication
+// @Description Create an authenticator secret. Two-factor authentication stays off until a code from the authenticator is confirmed with /user/2fa/enable
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Success 200 {object} shared.Response{data=dto.EnableTwoFactorResponse}
+// @Failure 409 {object} shared.Response "Already enabled"
+// @Router /api/v1/user/2fa/setup [post]
+func (h *UserHandler) SetupTwoFactor(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	resp, err := h.authSvc.SetupTwoFactor(userID)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Success", resp)
+}
+
+// @Summary Enable two-factor authentication
+// @Description Confirm the authenticator with a code and turn two-factor authentication on. The response holds the backup codes, which are not shown again
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param request body dto.VerifyTwoFactorRequest true "Authenticator code"
+// @Success 200 {object} shared.Response{data=dto.BackupCodesResponse}
+// @Router /api/v1/user/2fa/enable [post]
+func (h *UserHandler) EnableTwoFactor(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	var req dto.VerifyTwoFactorRequest
+	if err := c.BodyParser(&req); err != nil {
+		return err
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.CreateValidationErrorResponse(err))
+	}
+
+	resp, err := h.authSvc.EnableTwoFactor(userID, req.Code, shared.RequestIP(c), c.Get("User-Agent"))
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Two-factor authentication enabled", resp)
+}
+
+// @Summary Disable two-factor authentication
+// @Description Turn two-factor authentication off with an authenticator code or an unused backup code
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param request body dto.VerifyTwoFactorRequest true "Authenticator or backup code"
+// @Success 200 {object} shared.Response
+// @Router /api/v1/user/2fa/disable [post]
+func (h *UserHandler) DisableTwoFactor(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	var req dto.VerifyTwoFactorRequest
+	if err := c.BodyParser(&req); err != nil {
+		return err
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.CreateValidationErrorResponse(err))
+	}
+
+	if err := h.authSvc.DisableTwoFactor(userID, req.Code, shared.RequestIP(c), c.Get("User-Agent")); err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Two-factor authentication disabled", nil)
+}
+
+// @Summary Regenerate backup codes
+// @Description Replace all backup codes with new ones, confirmed with an authenticator code or an unused backup code. The new codes are not shown again
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param request body dto.VerifyTwoFactorRequest true "Authenticator or backup code"
+// @Success 200 {object} shared.Response{data=dto.BackupCodesResponse}
+// @Router /api/v1/user/2fa/backup-codes [post]
+func (h *UserHandler) RegenerateBackupCodes(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+
+	var req dto.VerifyTwoFactorRequest
+	if err := c.BodyParser(&req); err != nil {
+		return err
+	}
+
+	if err := req.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(dto.CreateValidationErrorResponse(err))
+	}
+
+	resp, err := h.authSvc.RegenerateBackupCodes(userID, req.Code, shared.RequestIP(c), c.Get("User-Agent"))
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, http.StatusOK, "Backup codes regenerated", resp)
+}
+
 // @Summary Share achievement
 // @Description Share achievement
 // @Tags user
@@ -513,3 +904,74 @@ func (h *UserHandler) ShareAchievement(c *fiber.Ctx) error {
 
 	return shared.ResponseJSON(c, fiber.StatusOK, "Success", shareData)
 }
+
+// @Summary Bookmark lesson
+// @Description Save a lesson to the user's "save for later" list. Bookmarking an already saved lesson is a no-op
+// @Tags user
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param lessonId path string true "Lesson ID"
+// @Success 200 {object} shared.Response{data=dto.LessonBookmarkResponse}
+// @Router /api/v1/lessons/{lessonId}/bookmark [post]
+func (h *UserHandler) BookmarkLesson(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+	lessonID := c.Params("lessonId")
+
+	bookmark, err := h.userSvc.BookmarkLesson(userID, lessonID)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Lesson bookmarked", bookmark)
+}
+
+// @Summary Remove lesson bookmark
+// @Description Remove a lesson from the user's "save for later" list
+// @Tags user
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param lessonId path string true "Lesson ID"
+// @Success 200 {object} shared.Response{data=nil}
+// @Router /api/v1/lessons/{lessonId}/bookmark [delete]
+func (h *UserHandler) RemoveBookmark(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+	lessonID := c.Params("lessonId")
+
+	if err := h.userSvc.RemoveBookmark(userID, lessonID); err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Bookmark removed", nil)
+}
+
+// @Summary Get bookmarked lessons
+// @Description Get the user's "save for later" list, most recently bookmarked first
+// @Tags user
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "User Bearer Token" default(Bearer <user_token>)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} shared.Response{data=dto.LessonBookmarkListResponse}
+// @Router /api/v1/user/bookmarks [get]
+func (h *UserHandler) GetBookmarks(c *fiber.Ctx) error {
+	userID := c.Locals(shared.UserID).(string)
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	bookmarks, err := h.userSvc.GetBookmarks(userID, page, limit)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", bookmarks)
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/shared"
+)
+
+type WebhookHandler struct {
+	webhookSvc WebhookServiceInterface
+}
+
+func NewWebhookHandler(webhookSvc WebhookServiceInterface) *WebhookHandler {
+	return &WebhookHandler{
+		webhookSvc: webhookSvc,
+	}
+}
+
+// @Summary Create Webhook (Admin)
+// @Description Register an external endpoint, e.g. a school LMS, for lesson.completed, character.unlocked and level.up events.
+// @Description Deliveries are POSTed as JSON with X-Ven-Signature: sha256=HMAC-SHA256(secret, X-Ven-Timestamp + "." + body). The secret is only shown in this response (Admin only)
+// @Tags admin,webhooks
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param request body dto.CreateWebhookRequest true "Webhook"
+// @Success 201 {object} shared.Response{data=dto.WebhookResponse}
+// @Router /api/v1/admin/webhooks [post]
+func (h *WebhookHandler) CreateWebhook(c *fiber.Ctx) error {
+	var req dto.CreateWebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	adminID := c.Locals(shared.UserID).(string)
+	webhook, err := h.webhookSvc.CreateWebhook(adminID, req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusCreated, "Webhook created", webhook)
+}
+
+// @Summary List Webhooks (Admin)
+// @Description List registered webhook endpoints (Admin only)
+// @Tags admin,webhooks
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Success 200 {object} shared.Response{data=[]dto.WebhookResponse}
+// @Router /api/v1/admin/webhooks [get]
+func (h *WebhookHandler) GetWebhooks(c *fiber.Ctx) error {
+	webhooks, err := h.webhookSvc.GetWebhooks()
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", webhooks)
+}
+
+// @Summary Update Webhook (Admin)
+// @Description Change a webhook's name, URL, events or enable/disable it. Omitted fields are left unchanged (Admin only)
+// @Tags admin,webhooks
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param webhookId path string true "Webhook ID"
+// @Param request body dto.UpdateWebhookRequest true "Changes"
+// @Success 200 {object} shared.Response{data=dto.WebhookResponse}
+// @Router /api/v1/admin/webhooks/{webhookId} [put]
+func (h *WebhookHandler) UpdateWebhook(c *fiber.Ctx) error {
+	var req dto.UpdateWebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return shared.NewBadRequestError(err, "Invalid request")
+	}
+
+	if err := req.Validate(); err != nil {
+		validationResp := dto.CreateValidationErrorResponse(err)
+		return c.Status(fiber.StatusBadRequest).JSON(validationResp)
+	}
+
+	webhook, err := h.webhookSvc.UpdateWebhook(c.Params("webhookId"), req)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Webhook updated", webhook)
+}
+
+// @Summary Rotate Webhook Secret (Admin)
+// @Description Replace a webhook's signing secret. The new secret is only shown in this response (Admin only)
+// @Tags admin,webhooks
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param webhookId path string true "Webhook ID"
+// @Success 200 {object} shared.Response{data=dto.WebhookResponse}
+// @Router /api/v1/admin/webhooks/{webhookId}/rotate-secret [post]
+func (h *WebhookHandler) RotateWebhookSecret(c *fiber.Ctx) error {
+	webhook, err := h.webhookSvc.RotateWebhookSecret(c.Params("webhookId"))
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Webhook secret rotated", webhook)
+}
+
+// @Summary Delete Webhook (Admin)
+// @Description Delete a webhook endpoint and its delivery log (Admin only)
+// @Tags admin,webhooks
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param webhookId path string true "Webhook ID"
+// @Success 200 {object} shared.Response{data=nil}
+// @Router /api/v1/admin/webhooks/{webhookId} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *fiber.Ctx) error {
+	if err := h.webhookSvc.DeleteWebhook(c.Params("webhookId")); err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Webhook deleted", nil)
+}
+
+// @Summary Get Webhook Deliveries (Admin)
+// @Description Delivery log for a webhook, newest first, with attempt counts and the last response status or error (Admin only)
+// @Tags admin,webhooks
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param webhookId path string true "Webhook ID"
+// @Param status query string false "Filter by status (pending, delivered, failed)"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} shared.Response{data=dto.WebhookDeliveryListResponse}
+// @Router /api/v1/admin/webhooks/{webhookId}/deliveries [get]
+func (h *WebhookHandler) GetWebhookDeliveries(c *fiber.Ctx) error {
+	page, _ := strconv.Atoi(c.Query("page", "1"))
+	limit, _ := strconv.Atoi(c.Query("limit", "20"))
+	status := c.Query("status")
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	deliveries, err := h.webhookSvc.GetWebhookDeliveries(c.Params("webhookId"), status, page, limit)
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Success", deliveries)
+}
+
+// @Summary Redeliver Webhook Event (Admin)
+// @Description Queue a delivery to be sent again immediately with a fresh set of retries (Admin only)
+// @Tags admin,webhooks
+// @Produce json
+// @Security Bearer
+// @Param Authorization header string true "Admin Bearer Token" default(Bearer <admin_token>)
+// @Param webhookId path string true "Webhook ID"
+// @Param deliveryId path string true "Delivery ID"
+// @Success 200 {object} shared.Response{data=model.WebhookDelivery}
+// @Router /api/v1/admin/webhooks/{webhookId}/deliveries/{deliveryId}/redeliver [post]
+func (h *WebhookHandler) RedeliverWebhook(c *fiber.Ctx) error {
+	delivery, err := h.webhookSvc.RedeliverWebhook(c.Params("webhookId"), c.Params("deliveryId"))
+	if err != nil {
+		return err
+	}
+
+	return shared.ResponseJSON(c, fiber.StatusOK, "Delivery queued", delivery)
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Minimal RFC 6455 server side, enough to push JSON text frames to a
+// browser, answer pings and receive small unfragmented text messages.
+
+const (
+	wsGUID            = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	wsOpText          = 0x1
+	wsOpClose         = 0x8
+	wsOpPing          = 0x9
+	wsOpPong          = 0xA
+	wsMaxClientFrame  = 4096
+	wsWriteTimeout    = 10 * time.Second
+	wsPingInterval    = 30 * time.Second
+	wsCloseNormal     = 1000
+	wsCloseProtoError = 1002
+	wsMessageBuffer   = 8
+)
+
+var errWSFrameTooLarge = errors.New("websocket frame too large")
+
+func isWebSocketUpgrade(c *fiber.Ctx) bool {
+	return strings.EqualFold(c.Get(fiber.HeaderUpgrade), "websocket") &&
+		strings.Contains(strings.ToLower(c.Get(fiber.HeaderConnection)), "upgrade") &&
+		c.Get("Sec-WebSocket-Key") != ""
+}
+
+// upgradeWebSocket completes the handshake and hands the raw connection to
+// handler once Fiber has written the 101 response
+func upgradeWebSocket(c *fiber.Ctx, handler func(ws *wsConn)) error {
+	sum := sha1.Sum([]byte(c.Get("Sec-WebSocket-Key") + wsGUID))
+
+	c.Status(fiber.StatusSwitchingProtocols)
+	c.Set(fiber.HeaderUpgrade, "websocket")
+	c.Set(fiber.HeaderConnection, "Upgrade")
+	c.Set("Sec-WebSocket-Accept", base64.StdEncoding.EncodeToString(sum[:]))
+
+	c.Context().Hijack(func(conn net.Conn) {
+		ws := &wsConn{conn: conn, done: make(chan struct{}), messages: make(chan []byte, wsMessageBuffer)}
+		go ws.readLoop()
+		handler(ws)
+		ws.Close(wsCloseNormal)
+	})
+	return nil
+}
+
+type wsConn struct {
+	conn      net.Conn
+	writeMu   sync.Mutex
+	done      chan struct{}
+	closeOnce sync.Once
+
+	messages chan []byte
+}
+
+// Done is closed when the peer goes away or the connection fails
+func (ws *wsConn) Done() <-chan struct{} {
+	return ws.done
+}
+
+// Messages delivers the payload of each text frame the client sends. Reading stops
+// while the handler is behind, so handlers that never read ignore client data.
+func (ws *wsConn) Messages() <-chan []byte {
+	return ws.messages
+}
+
+func (ws *wsConn) WriteJSON(v interface{}) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return ws.writeFrame(wsOpText, payload)
+}
+
+func (ws *wsConn) Ping() error {
+	return ws.writeFrame(wsOpPing, nil)
+}
+
+func (ws *wsConn) Close(code uint16) {
+	payload := make([]byte, 2)
+	binary.BigEndian.PutUint16(payload, code)
+	_ = ws.writeFrame(wsOpClose, payload)
+	ws.shutdown()
+}
+
+func (ws *wsConn) shutdown() {
+	ws.closeOnce.Do(func() {
+		close(ws.done)
+		ws.conn.Close()
+	})
+}
+
+func (ws *wsConn) writeFrame(opcode byte, payload []byte) error {
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+
+	header := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 126, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(n))
+	default:
+		header = append(header, 127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(header[2:], uint64(n))
+	}
+
+	ws.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if _, err := ws.conn.Write(append(header, payload...)); err != nil {
+		ws.shutdown()
+		return err
+	}
+	return nil
+}
+
+// readLoop drains client frames, answering pings and honouring close
+func (ws *wsConn) readLoop() {
+	defer ws.shutdown()
+
+	header := make([]byte, 2)
+	for {
+		ws.conn.SetReadDeadline(time.Now().Add(2 * wsPingInterval))
+		if _, err := io.ReadFull(ws.conn, header); err != nil {
+			return
+		}
+
+		opcode := header[0] & 0x0F
+		masked := header[1]&0x80 != 0
+		length := uint64(header[1] & 0x7F)
+
+		if !masked {
+			ws.Close(wsCloseProtoError)
+			return
+		}
+
+		switch length {
+		case 126:
+			ext := make([]byte, 2)
+			if _, err := io.ReadFull(ws.conn, ext); err != nil {
+				return
+			}
+			length = uint64(binary.BigEndian.Uint16(ext))
+		case 127:
+			ext := make([]byte, 8)
+			if _, err := io.ReadFull(ws.conn, ext); err != nil {
+				return
+			}
+			length = binary.BigEndian.Uint64(ext)
+		}
+		if length > wsMaxClientFrame {
+			ws.Close(wsCloseProtoError)
+			return
+		}
+
+		mask := make([]byte, 4)
+		if _, err := io.ReadFull(ws.conn, mask); err != nil {
+			return
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(ws.conn, payload); err != nil {
+			return
+		}
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+
+		switch opcode {
+		case wsOpText:
+			select {
+			case ws.messages <- payload:
+			case <-ws.done:
+				return
+			}
+		case wsOpClose:
+			ws.Close(wsCloseNormal)
+			return
+		case wsOpPing:
+			if err := ws.writeFrame(wsOpPong, payload); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/cloakd/common/context"
 	serviceContext "github.com/cloakd/common/services"
@@ -16,6 +17,8 @@ import (
 	docs "github.com/lac-hong-legacy/ven_api/docs"
 	"github.com/lac-hong-legacy/ven_api/services/handlers"
 	"github.com/lac-hong-legacy/ven_api/shared"
+	"github.com/lac-hong-legacy/ven_api/shared/ids"
+	log "github.com/sirupsen/logrus"
 )
 
 type HttpService struct {
@@ -27,8 +30,26 @@ type HttpService struct {
 	contentSvc  *ContentService
 	userSvc     *UserService
 	mediaSvc    *MediaService
+	battleSvc   *BattleService
+	triviaSvc   *TriviaService
 	postgresSvc *PostgresService
 
+	notificationSvc *NotificationService
+	systemSvc       *SystemService
+	rateLimitSvc    *RateLimitService
+	translationSvc  *TranslationService
+	questionGenSvc  *QuestionGenerationService
+	webhookSvc      *WebhookService
+	retentionSvc    *RetentionService
+	maintenanceSvc  *MaintenanceService
+	appVersionSvc   *AppVersionService
+	cacheSvc        *CacheService
+	killSwitchSvc   *KillSwitchService
+	studyRoomSvc    *StudyRoomService
+	shopSvc         *ShopService
+	certificateSvc  *CertificateService
+	researchSvc     *ResearchExportService
+
 	authHandler        *handlers.AuthHandler
 	userHandler        *handlers.UserHandler
 	guestHandler       *handlers.GuestHandler
@@ -36,13 +57,93 @@ type HttpService struct {
 	leaderboardHandler *handlers.LeaderboardHandler
 	adminHandler       *handlers.AdminHandler
 	mediaHandler       *handlers.MediaHandler
+	battleHandler      *handlers.BattleHandler
+	triviaHandler      *handlers.TriviaHandler
+
+	notificationHandler *handlers.NotificationHandler
+	translationHandler  *handlers.TranslationHandler
+	questionGenHandler  *handlers.QuestionGenerationHandler
+	webhookHandler      *handlers.WebhookHandler
+	retentionHandler    *handlers.RetentionHandler
+	maintenanceHandler  *handlers.MaintenanceHandler
+	appVersionHandler   *handlers.AppVersionHandler
+	cacheHandler        *handlers.CacheHandler
+	killSwitchHandler   *handlers.KillSwitchHandler
+	studyRoomHandler    *handlers.StudyRoomHandler
+	shopHandler         *handlers.ShopHandler
+	certificateHandler  *handlers.CertificateHandler
+	researchHandler     *handlers.ResearchHandler
+	publicHandler       *handlers.PublicHandler
+
+	apiDeprecations map[string]apiVersionDeprecation
 
 	port int
 	app  *fiber.App
+
+	env         string
+	corsOrigins string
+	hstsMaxAge  int
+	csp         string
+
+	// Origins allowed to call the public content API, which needs no credentials
+	publicCorsOrigins string
+
+	clientIPs *clientIPResolver
 }
 
 const HTTP_SVC = "http_svc"
 
+// Public content API for the marketing website, see setupPublicRoutes
+const publicPathPrefix = "/public/v1"
+
+const (
+	EnvDevelopment = "development"
+	EnvStaging     = "staging"
+	EnvProduction  = "production"
+)
+
+const (
+	// maxBodyLimit is the hard cap Fiber enforces while reading any request
+	maxBodyLimit = 105 * 1024 * 1024
+	// defaultBodyLimit applies to every route not listed in routeBodyLimits
+	defaultBodyLimit = 1024 * 1024
+)
+
+type routeBodyLimit struct {
+	method string
+	prefix string
+	suffix string
+	limit  int
+}
+
+// routeBodyLimits raises the body limit for media uploads. Multipart limits allow 1MB of
+// form overhead on top of the largest file the media service accepts. Prefixes are
+// matched without the /api/<version> part, so they apply to every API version.
+var routeBodyLimits = []routeBodyLimit{
+	{fiber.MethodPost, "/admin/lessons/", "/media/image", 6 * 1024 * 1024},
+	{fiber.MethodPost, "/admin/lessons/", "/media/audio", 21 * 1024 * 1024},
+	{fiber.MethodPost, "/admin/lessons/", "/animation", 101 * 1024 * 1024},
+	{fiber.MethodPost, "/admin/lessons/", "/audio", 51 * 1024 * 1024},
+	{fiber.MethodPost, "/admin/lessons/", "/audio-description", 51 * 1024 * 1024},
+	{fiber.MethodPost, "/admin/lessons/", "/thumbnail", 3 * 1024 * 1024},
+	{fiber.MethodPost, "/admin/lessons/", "/subtitle", 5 * 1024 * 1024},
+	{fiber.MethodPatch, "/admin/uploads/", "", maxBodyLimit},
+}
+
+// Route middleware rejecting malformed IDs in path parameters with 400 before any lookup
+var (
+	validUserID      = ids.Param("userId", ids.User)
+	validLessonID    = ids.Param("lessonId", ids.Lesson)
+	validCharacterID = ids.Param("characterId", ids.Character)
+)
+
+const (
+	// API responses are JSON only, so nothing may be loaded or framed
+	defaultAPIContentSecurityPolicy = "default-src 'none'; frame-ancestors 'none'"
+	// Swagger UI needs its own scripts, inline styles and images
+	swaggerContentSecurityPolicy = "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; img-src 'self' data:; frame-ancestors 'none'"
+)
+
 func (svc HttpService) Id() string {
 	return HTTP_SVC
 }
@@ -57,27 +158,111 @@ func (svc *HttpService) Configure(ctx *context.Context) error {
 		svc.port = 8000
 	}
 
+	svc.env = strings.ToLower(os.Getenv("APP_ENV"))
+	if svc.env == "" {
+		svc.env = EnvDevelopment
+	}
+
+	// Browsers may call the API from anywhere during development; other environments
+	// only allow the origins they list
+	svc.corsOrigins = os.Getenv("CORS_ALLOWED_ORIGINS")
+	if svc.corsOrigins == "" && svc.env == EnvDevelopment {
+		svc.corsOrigins = "*"
+	}
+
+	svc.publicCorsOrigins = os.Getenv("PUBLIC_CORS_ALLOWED_ORIGINS")
+	if svc.publicCorsOrigins == "" {
+		svc.publicCorsOrigins = "*"
+	}
+
+	if svc.env != EnvDevelopment {
+		svc.hstsMaxAge = 31536000
+	}
+	if maxAge := os.Getenv("HSTS_MAX_AGE"); maxAge != "" {
+		var err error
+		if svc.hstsMaxAge, err = strconv.Atoi(maxAge); err != nil {
+			return err
+		}
+	}
+
+	svc.csp = os.Getenv("CONTENT_SECURITY_POLICY")
+	if svc.csp == "" {
+		svc.csp = defaultAPIContentSecurityPolicy
+	}
+
+	// Forwarding headers are only trusted from these proxies
+	trustedProxies := os.Getenv("TRUSTED_PROXIES")
+	if trustedProxies == "" {
+		trustedProxies = defaultTrustedProxies
+	}
+	var err error
+	if svc.clientIPs, err = newClientIPResolver(trustedProxies); err != nil {
+		return err
+	}
+
+	svc.apiDeprecations = loadAPIVersionDeprecations()
+
 	return svc.DefaultService.Configure(ctx)
 }
 
 func (svc *HttpService) Start() error {
-	svc.jwtSvc = svc.Service(JWT_SVC).(*JWTService)
-	svc.authSvc = svc.Service(AUTH_SVC).(*AuthService)
-	svc.guestSvc = svc.Service(GUEST_SVC).(*GuestService)
-	svc.userSvc = svc.Service(USER_SVC).(*UserService)
-	svc.contentSvc = svc.Service(CONTENT_SVC).(*ContentService)
-	svc.mediaSvc = svc.Service(MEDIA_SVC).(*MediaService)
-	svc.postgresSvc = svc.Service(POSTGRES_SVC).(*PostgresService)
+	deps := newDependencies(svc.Id(), svc.Service)
+	svc.jwtSvc = resolve[*JWTService](deps, JWT_SVC)
+	svc.authSvc = resolve[*AuthService](deps, AUTH_SVC)
+	svc.guestSvc = resolve[*GuestService](deps, GUEST_SVC)
+	svc.userSvc = resolve[*UserService](deps, USER_SVC)
+	svc.contentSvc = resolve[*ContentService](deps, CONTENT_SVC)
+	svc.mediaSvc = resolve[*MediaService](deps, MEDIA_SVC)
+	svc.battleSvc = resolve[*BattleService](deps, BATTLE_SVC)
+	svc.triviaSvc = resolve[*TriviaService](deps, TRIVIA_SVC)
+	svc.postgresSvc = resolve[*PostgresService](deps, POSTGRES_SVC)
+	svc.rateLimitSvc = resolve[*RateLimitService](deps, RATE_LIMIT_SVC)
+	svc.notificationSvc = resolve[*NotificationService](deps, NOTIFICATION_SVC)
+	svc.systemSvc = resolve[*SystemService](deps, SYSTEM_SVC)
+	svc.translationSvc = resolve[*TranslationService](deps, TRANSLATION_SVC)
+	svc.questionGenSvc = resolve[*QuestionGenerationService](deps, QUESTION_GENERATION_SVC)
+	svc.webhookSvc = resolve[*WebhookService](deps, WEBHOOK_SVC)
+	svc.retentionSvc = resolve[*RetentionService](deps, RETENTION_SVC)
+	svc.maintenanceSvc = resolve[*MaintenanceService](deps, MAINTENANCE_SVC)
+	svc.appVersionSvc = resolve[*AppVersionService](deps, APP_VERSION_SVC)
+	svc.cacheSvc = resolve[*CacheService](deps, CACHE_SVC)
+	svc.killSwitchSvc = resolve[*KillSwitchService](deps, KILL_SWITCH_SVC)
+	svc.studyRoomSvc = resolve[*StudyRoomService](deps, STUDY_ROOM_SVC)
+	svc.shopSvc = resolve[*ShopService](deps, SHOP_SVC)
+	svc.certificateSvc = resolve[*CertificateService](deps, CERTIFICATE_SVC)
+	svc.researchSvc = resolve[*ResearchExportService](deps, RESEARCH_EXPORT_SVC)
+	if err := deps.err(); err != nil {
+		return err
+	}
 
 	svc.authHandler = handlers.NewAuthHandler(svc.authSvc, svc.jwtSvc, svc.userSvc)
 	svc.userHandler = handlers.NewUserHandler(svc.userSvc, svc.authSvc)
 	svc.guestHandler = handlers.NewGuestHandler(svc.guestSvc, svc.contentSvc)
 	svc.contentHandler = handlers.NewContentHandler(svc.contentSvc)
 	svc.leaderboardHandler = handlers.NewLeaderboardHandler(svc.userSvc, svc.jwtSvc)
-	svc.adminHandler = handlers.NewAdminHandler(svc.userSvc, svc.contentSvc)
+	svc.adminHandler = handlers.NewAdminHandler(svc.userSvc, svc.contentSvc, svc.systemSvc, svc.authSvc)
 	svc.mediaHandler = handlers.NewMediaHandler(svc.mediaSvc, svc.contentSvc)
+	svc.battleHandler = handlers.NewBattleHandler(svc.battleSvc)
+	svc.triviaHandler = handlers.NewTriviaHandler(svc.triviaSvc)
+	svc.notificationHandler = handlers.NewNotificationHandler(svc.notificationSvc)
+	svc.translationHandler = handlers.NewTranslationHandler(svc.translationSvc)
+	svc.questionGenHandler = handlers.NewQuestionGenerationHandler(svc.questionGenSvc)
+	svc.webhookHandler = handlers.NewWebhookHandler(svc.webhookSvc)
+	svc.retentionHandler = handlers.NewRetentionHandler(svc.retentionSvc)
+	svc.maintenanceHandler = handlers.NewMaintenanceHandler(svc.maintenanceSvc)
+	svc.appVersionHandler = handlers.NewAppVersionHandler(svc.appVersionSvc)
+	svc.cacheHandler = handlers.NewCacheHandler(svc.cacheSvc)
+	svc.killSwitchHandler = handlers.NewKillSwitchHandler(svc.killSwitchSvc)
+	svc.studyRoomHandler = handlers.NewStudyRoomHandler(svc.studyRoomSvc)
+	svc.shopHandler = handlers.NewShopHandler(svc.shopSvc)
+	svc.certificateHandler = handlers.NewCertificateHandler(svc.certificateSvc)
+	svc.researchHandler = handlers.NewResearchHandler(svc.researchSvc)
+	svc.publicHandler = handlers.NewPublicHandler(svc.contentSvc)
 
 	config := fiber.Config{
+		// Large enough for single-request animation uploads (100MB) and resumable upload chunks.
+		// Tighter per-route limits are applied by bodySizeLimit.
+		BodyLimit: maxBodyLimit,
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			return svc.HandleError(c, err)
 		},
@@ -87,17 +272,35 @@ func (svc *HttpService) Start() error {
 	docs.SwaggerInfo.BasePath = ""
 
 	svc.app.Use(recover.New())
+	svc.app.Use(svc.resolveClientIP())
 
 	if os.Getenv("LOG_LEVEL") == "TRACE" {
 		svc.app.Use(logger.New())
 	}
 
-	svc.app.Use(cors.New(cors.Config{
-		AllowOrigins:     "*",
-		AllowCredentials: false,
-		AllowHeaders:     "Origin, Content-Type, Accept, Authorization",
-		AllowMethods:     "GET, POST, PUT, DELETE, OPTIONS",
-	}))
+	svc.app.Use(svc.securityHeaders())
+	svc.app.Use(svc.bodySizeLimit())
+
+	if svc.corsOrigins != "" {
+		svc.app.Use(cors.New(cors.Config{
+			// The public content API has its own policy, see setupPublicRoutes
+			Next: func(c *fiber.Ctx) bool {
+				return strings.HasPrefix(c.Path(), publicPathPrefix)
+			},
+			AllowOrigins: svc.corsOrigins,
+			// Cookie sessions need credentials, which browsers refuse for a wildcard origin
+			AllowCredentials: svc.corsOrigins != "*",
+			AllowHeaders:     "Origin, Content-Type, Accept, Authorization, X-Auth-Mode, X-CSRF-Token, X-App-Platform, X-App-Version",
+			AllowMethods:     "GET, POST, PUT, PATCH, DELETE, OPTIONS",
+			// Browser clients need to see when the API version they call is being retired
+			ExposeHeaders: "Deprecation, Sunset, Link, Retry-After",
+		}))
+	} else {
+		log.Warnf("CORS_ALLOWED_ORIGINS is not set for %s, cross-origin browser requests will be rejected", svc.env)
+	}
+
+	svc.app.Use(svc.maintenanceSvc.Guard())
+	svc.app.Use(svc.appVersionSvc.Gate())
 
 	svc.setupRoutes()
 
@@ -108,39 +311,166 @@ func (svc *HttpService) Start() error {
 	return svc.app.Listen(fmt.Sprintf(":%v", svc.port))
 }
 
+// securityHeaders sets the standard hardening headers on every response
+func (svc *HttpService) securityHeaders() fiber.Handler {
+	hsts := ""
+	if svc.hstsMaxAge > 0 {
+		hsts = fmt.Sprintf("max-age=%d; includeSubDomains", svc.hstsMaxAge)
+	}
+
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+		c.Set(fiber.HeaderXFrameOptions, "DENY")
+		c.Set(fiber.HeaderReferrerPolicy, "strict-origin-when-cross-origin")
+
+		if strings.HasPrefix(c.Path(), "/swagger") {
+			c.Set(fiber.HeaderContentSecurityPolicy, swaggerContentSecurityPolicy)
+		} else {
+			c.Set(fiber.HeaderContentSecurityPolicy, svc.csp)
+		}
+
+		if hsts != "" {
+			c.Set(fiber.HeaderStrictTransportSecurity, hsts)
+		}
+
+		return c.Next()
+	}
+}
+
+// resolveClientIP stores the client address in c.Locals(shared.ClientIP) for rate
+// limiting, lockouts and audit logs
+func (svc *HttpService) resolveClientIP() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var forwardedFor []string
+		for _, header := range c.Request().Header.PeekAll(fiber.HeaderXForwardedFor) {
+			forwardedFor = append(forwardedFor, string(header))
+		}
+		c.Locals(shared.ClientIP, svc.clientIPs.resolve(c.Context().RemoteAddr().String(), forwardedFor, c.Get("X-Real-IP")))
+		return c.Next()
+	}
+}
+
+// bodySizeLimit rejects requests whose body exceeds the limit for their route
+func (svc *HttpService) bodySizeLimit() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		limit := defaultBodyLimit
+		path := apiRoutePath(c.Path())
+		for _, rule := range routeBodyLimits {
+			if c.Method() == rule.method && strings.HasPrefix(path, rule.prefix) && strings.HasSuffix(path, rule.suffix) {
+				limit = rule.limit
+				break
+			}
+		}
+
+		size := c.Request().Header.ContentLength()
+		if bodyLen := len(c.Request().Body()); bodyLen > size {
+			size = bodyLen
+		}
+
+		if size > limit {
+			return shared.NewPayloadTooLargeError(nil, "Request body too large").WithData(map[string]interface{}{
+				"max_bytes": limit,
+			})
+		}
+
+		return c.Next()
+	}
+}
+
 func (svc *HttpService) setupRoutes() {
 	svc.app.Get("/ping", svc.ping)
 	svc.app.Get("/swagger/*", swagger.HandlerDefault)
+	svc.setupPublicRoutes()
+
+	for _, version := range APIVersions {
+		api := svc.app.Group(apiPathPrefix+version, svc.apiVersion(version), svc.authSvc.RequireCSRF())
+		api.Get("/maintenance", svc.maintenanceHandler.GetStatus)
+		api.Get("/app/version", svc.appVersionHandler.GetVersions)
+
+		svc.setupAuthRoutes(api)
+		svc.setupGuestRoutes(api)
+		svc.setupContentRoutes(api)
+		svc.setupLessonRoutes(api)
+		svc.setupUserRoutes(api)
+		svc.setupLeaderboardRoutes(api)
+		svc.setupTriviaRoutes(api)
+		svc.setupStudyRoomRoutes(api)
+		svc.setupShopRoutes(api)
+		svc.setupCertificateRoutes(api)
+		svc.setupNotificationRoutes(api)
+		svc.setupAdminRoutes(api)
+		svc.setupSupportRoutes(api)
+	}
+}
 
-	v1 := svc.app.Group("/api/v1")
+// setupPublicRoutes serves read-only content to the marketing website. It sits outside
+// the versioned API: no sign-in, no cookies, any listed origin, rate limited per IP and
+// cacheable by browsers and CDNs.
+func (svc *HttpService) setupPublicRoutes() {
+	public := svc.app.Group(publicPathPrefix,
+		cors.New(cors.Config{
+			AllowOrigins: svc.publicCorsOrigins,
+			AllowHeaders: "Origin, Accept",
+			AllowMethods: "GET, HEAD, OPTIONS",
+			MaxAge:       86400,
+		}),
+		svc.rateLimitSvc.RateLimit("public_api", 0, "10m"),
+		publicCacheHeaders(),
+	)
+	public.Get("/characters", svc.publicHandler.GetCharacters)
+	public.Get("/characters/:characterId", validCharacterID, svc.publicHandler.GetCharacter)
+	public.Get("/timeline", svc.publicHandler.GetTimeline)
+	public.Get("/lessons/:lessonId", validLessonID, svc.publicHandler.GetLesson)
+	public.Get("/sitemap.xml", svc.publicHandler.GetSitemap)
+	public.Get("/meta/characters/:characterId", validCharacterID, svc.publicHandler.GetCharacterMetadata)
+	public.Get("/meta/lessons/:lessonId", validLessonID, svc.publicHandler.GetLessonMetadata)
+}
 
-	svc.setupAuthRoutes(v1)
-	svc.setupGuestRoutes(v1)
-	svc.setupContentRoutes(v1)
-	svc.setupUserRoutes(v1)
-	svc.setupLeaderboardRoutes(v1)
-	svc.setupAdminRoutes(v1)
+// publicCacheHeaders lets browsers and CDNs cache successful public responses. The
+// content itself is rebuilt every publicCatalogTTL, so a CDN may serve it a while longer.
+func publicCacheHeaders() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return err
+		}
+		if c.Response().StatusCode() == fiber.StatusOK {
+			c.Set(fiber.HeaderCacheControl, "public, max-age=300, s-maxage=600, stale-while-revalidate=3600")
+		}
+		return nil
+	}
 }
 
 func (svc *HttpService) setupAuthRoutes(v1 fiber.Router) {
 	v1.Post("/register", svc.authHandler.Register)
 	v1.Post("/login", svc.authHandler.Login)
+	v1.Post("/login/2fa", svc.authHandler.LoginTwoFactor)
 	v1.Post("/refresh", svc.authHandler.RefreshToken)
 	v1.Post("/logout", svc.authSvc.RequiredAuth(), svc.authHandler.Logout)
 	v1.Post("/logout-all", svc.authSvc.RequiredAuth(), svc.authHandler.LogoutAll)
 	v1.Post("/verify-email", svc.authHandler.VerifyEmail)
+	v1.Get("/verify-link", svc.authHandler.VerifyEmailLink)
 	v1.Post("/resend-verification", svc.authHandler.ResendVerification)
 	v1.Post("/forgot-password", svc.authHandler.ForgotPassword)
 	v1.Post("/reset-password", svc.authHandler.ResetPassword)
 	v1.Post("/change-password", svc.authSvc.RequiredAuth(), svc.authHandler.ChangePassword)
+	v1.Get(emailChangeRevertPath, svc.authHandler.RevertEmailChangePage)
+	v1.Post(emailChangeRevertPath, svc.authHandler.RevertEmailChange)
+	v1.Get(deviceActionPath, svc.authHandler.DeviceActionPage)
+	v1.Post(deviceActionPath, svc.authHandler.ApplyDeviceAction)
+	v1.Post("/account-recovery", svc.authHandler.StartAccountRecovery)
+	v1.Post("/account-recovery/status", svc.authHandler.GetAccountRecoveryStatus)
+	v1.Post("/account-recovery/complete", svc.authHandler.CompleteAccountRecovery)
+	v1.Get(accountRecoveryCancelPath, svc.authHandler.CancelAccountRecoveryPage)
+	v1.Post(accountRecoveryCancelPath, svc.authHandler.CancelAccountRecovery)
 	v1.Get("/username/check/:username", svc.authHandler.CheckUsernameAvailability)
 }
 
 func (svc *HttpService) setupGuestRoutes(v1 fiber.Router) {
 	guest := v1.Group("/guest")
+	guest.Post("/attestation/challenge", svc.guestHandler.GetAttestationChallenge)
 	guest.Post("/session", svc.guestHandler.CreateSession)
 	guest.Get("/session/:sessionId/progress", svc.guestHandler.GetProgress)
-	guest.Get("/session/:sessionId/lesson/:lessonId/access", svc.guestHandler.CheckLessonAccess)
+	guest.Get("/session/:sessionId/lesson/:lessonId/access", validLessonID, svc.guestHandler.CheckLessonAccess)
 	guest.Post("/session/:sessionId/lesson/complete", svc.guestHandler.CompleteLesson)
 	guest.Post("/session/:sessionId/hearts/add", svc.guestHandler.AddHeartsFromAd)
 	guest.Post("/session/:sessionId/hearts/lose", svc.guestHandler.LoseHeart)
@@ -150,27 +480,56 @@ func (svc *HttpService) setupContentRoutes(v1 fiber.Router) {
 	content := v1.Group("/content")
 	content.Get("/timeline", svc.contentHandler.GetTimeline)
 	content.Get("/characters", svc.contentHandler.GetCharacters)
-	content.Get("/characters/:characterId", svc.contentHandler.GetCharacter)
-	content.Get("/characters/:characterId/lessons", svc.contentHandler.GetCharacterLessons)
-	content.Get("/lessons/:lessonId", svc.contentHandler.GetLesson)
+	content.Get("/characters/:characterId", validCharacterID, svc.contentHandler.GetCharacter)
+	content.Get("/characters/:characterId/lessons", validCharacterID, svc.authSvc.OptionalAuth(), svc.contentHandler.GetCharacterLessons)
+	content.Get("/lessons/:lessonId", validLessonID, svc.authSvc.OptionalAuth(), svc.contentHandler.GetLesson)
 	content.Post("/lessons/validate", svc.contentHandler.ValidateLessonAnswers)
 	content.Post("/lessons/questions/answer", svc.authSvc.RequiredAuth(), svc.contentHandler.SubmitQuestionAnswer)
 	content.Post("/lessons/status", svc.authSvc.RequiredAuth(), svc.contentHandler.CheckLessonStatus)
 	content.Get("/search", svc.contentHandler.SearchContent)
 	content.Get("/eras", svc.contentHandler.GetEras)
 	content.Get("/dynasties", svc.contentHandler.GetDynasties)
+	content.Get("/trending", svc.authSvc.OptionalAuth(), svc.contentHandler.GetTrendingContent)
+
+	glossary := v1.Group("/glossary")
+	glossary.Get("", svc.contentHandler.SearchGlossary)
+	glossary.Get("/:termId", svc.contentHandler.GetGlossaryTerm)
+
+	v1.Get("/search/suggest", svc.contentHandler.SuggestSearch)
+}
+
+func (svc *HttpService) setupLessonRoutes(v1 fiber.Router) {
+	lessons := v1.Group("/lessons", svc.authSvc.RequiredAuth())
+	lessons.Post("/:lessonId/bookmark", validLessonID, svc.userHandler.BookmarkLesson)
+	lessons.Delete("/:lessonId/bookmark", validLessonID, svc.userHandler.RemoveBookmark)
+
+	lessons.Get("/:lessonId/session", validLessonID, svc.contentHandler.GetLessonSession)
+	lessons.Put("/:lessonId/session", validLessonID, svc.contentHandler.UpdateLessonSession)
+	lessons.Delete("/:lessonId/session", validLessonID, svc.contentHandler.RestartLessonSession)
+	lessons.Post("/:lessonId/video-progress", validLessonID, svc.contentHandler.RecordVideoProgress)
+	lessons.Post("/:lessonId/questions/:questionId/fifty-fifty", validLessonID, svc.contentHandler.UseFiftyFifty)
+
+	questions := v1.Group("/questions", svc.authSvc.RequiredAuth())
+	questions.Post("/:id/report", svc.contentHandler.ReportQuestion)
 }
 
 func (svc *HttpService) setupUserRoutes(v1 fiber.Router) {
 	user := v1.Group("/user", svc.authSvc.RequiredAuth())
 	user.Get("/profile", svc.userHandler.GetUserProfile)
 	user.Put("/profile", svc.userHandler.UpdateUserProfile)
+	user.Post("/email/confirm", svc.userHandler.ConfirmEmailChange)
 	user.Post("/initialize", svc.userHandler.InitializeUserProfile)
+	user.Get("/zodiac", svc.userHandler.GetZodiac)
+	user.Post("/zodiac/correct", svc.userHandler.CorrectZodiac)
 
 	user.Get("/progress", svc.userHandler.GetUserProgress)
+	user.Get("/onboarding", svc.userHandler.GetOnboarding)
 	user.Get("/collection", svc.userHandler.GetUserCollection)
+	user.Post("/collection/favorites/:characterId", validCharacterID, svc.userHandler.FavoriteCharacter)
+	user.Delete("/collection/favorites/:characterId", validCharacterID, svc.userHandler.UnfavoriteCharacter)
+	user.Get("/bookmarks", svc.userHandler.GetBookmarks)
 
-	user.Get("/lesson/:lessonId/access", svc.userHandler.CheckUserLessonAccess)
+	user.Get("/lesson/:lessonId/access", validLessonID, svc.userHandler.CheckUserLessonAccess)
 	user.Post("/lesson/complete", svc.userHandler.CompleteUserLesson)
 
 	user.Get("/hearts", svc.userHandler.GetHeartStatus)
@@ -182,14 +541,28 @@ func (svc *HttpService) setupUserRoutes(v1 fiber.Router) {
 
 	user.Get("/security", svc.userHandler.GetSecuritySettings)
 	user.Put("/security", svc.userHandler.UpdateSecuritySettings)
+	user.Get("/parental-controls", svc.userHandler.GetParentalControls)
+	user.Put("/parental-controls", svc.userHandler.UpdateParentalControls)
 
 	user.Get("/audit-logs", svc.userHandler.GetAuditLogs)
 
 	user.Get("/devices", svc.userHandler.GetUserDevices)
 	user.Put("/devices/:deviceId/trust", svc.userHandler.UpdateDeviceTrust)
 	user.Delete("/devices/:deviceId", svc.userHandler.RemoveUserDevice)
+	user.Get("/account-recovery", svc.userHandler.GetAccountRecoveries)
+	user.Post("/account-recovery/:recoveryId/approve", svc.userHandler.ApproveAccountRecovery)
+	user.Post("/account-recovery/:recoveryId/deny", svc.userHandler.DenyAccountRecovery)
+	user.Post("/2fa/setup", svc.userHandler.SetupTwoFactor)
+	user.Post("/2fa/enable", svc.userHandler.EnableTwoFactor)
+	user.Post("/2fa/disable", svc.userHandler.DisableTwoFactor)
+	user.Post("/2fa/backup-codes", svc.userHandler.RegenerateBackupCodes)
 
 	user.Post("/share", svc.userHandler.ShareAchievement)
+
+	user.Post("/battles", svc.battleHandler.StartBattle)
+	user.Get("/battles/:battleId", svc.battleHandler.GetBattle)
+	user.Post("/battles/:battleId/act", svc.battleHandler.Act)
+	user.Post("/battles/:battleId/resolve", svc.battleHandler.ResolveBattle)
 }
 
 func (svc *HttpService) setupLeaderboardRoutes(v1 fiber.Router) {
@@ -199,24 +572,198 @@ func (svc *HttpService) setupLeaderboardRoutes(v1 fiber.Router) {
 	leaderboard.Get("/all-time", svc.leaderboardHandler.GetAllTimeLeaderboard)
 }
 
+func (svc *HttpService) setupTriviaRoutes(v1 fiber.Router) {
+	trivia := v1.Group("/trivia", svc.authSvc.RequiredAuth())
+	trivia.Get("/today", svc.triviaHandler.GetToday)
+	trivia.Post("/today/answer", svc.triviaHandler.SubmitAnswer)
+	trivia.Get("/stats", svc.triviaHandler.GetStats)
+	trivia.Get("/streak", svc.triviaHandler.GetStreak)
+}
+
+func (svc *HttpService) setupStudyRoomRoutes(v1 fiber.Router) {
+	rooms := v1.Group("/study-rooms", svc.authSvc.RequiredAuth())
+	rooms.Post("", svc.studyRoomHandler.CreateStudyRoom)
+	rooms.Post("/join", svc.studyRoomHandler.JoinStudyRoom)
+	rooms.Get("/current", svc.studyRoomHandler.GetCurrentStudyRoom)
+	rooms.Get("/:roomId", svc.studyRoomHandler.GetStudyRoom)
+	rooms.Delete("/:roomId", svc.studyRoomHandler.CloseStudyRoom)
+	rooms.Post("/:roomId/start", svc.studyRoomHandler.StartStudyRoom)
+	rooms.Post("/:roomId/answers", svc.studyRoomHandler.SubmitAnswer)
+	rooms.Post("/:roomId/leave", svc.studyRoomHandler.LeaveStudyRoom)
+	rooms.Get("/:roomId/live", svc.studyRoomHandler.LiveStudyRoom)
+}
+
+func (svc *HttpService) setupShopRoutes(v1 fiber.Router) {
+	shop := v1.Group("/shop", svc.authSvc.RequiredAuth())
+	shop.Get("", svc.shopHandler.GetShop)
+	shop.Post("/purchase", svc.shopHandler.Purchase)
+	shop.Get("/wallet", svc.shopHandler.GetWallet)
+	shop.Get("/wallet/transactions", svc.shopHandler.GetCoinLedger)
+	shop.Get("/cosmetics", svc.shopHandler.GetCosmetics)
+}
+
+func (svc *HttpService) setupCertificateRoutes(v1 fiber.Router) {
+	certificates := v1.Group("/certificates")
+	certificates.Get("/verify/:code", svc.certificateHandler.VerifyCertificate)
+	certificates.Get("", svc.authSvc.RequiredAuth(), svc.certificateHandler.GetCertificates)
+	certificates.Get("/:certificateId", svc.authSvc.RequiredAuth(), svc.certificateHandler.GetCertificate)
+}
+
+func (svc *HttpService) setupNotificationRoutes(v1 fiber.Router) {
+	notifications := v1.Group("/notifications", svc.authSvc.RequiredAuth())
+	notifications.Get("", svc.notificationHandler.GetNotifications)
+	notifications.Get("/unread-count", svc.notificationHandler.GetUnreadCount)
+	notifications.Post("/read-all", svc.notificationHandler.MarkAllRead)
+	notifications.Post("/:notificationId/read", svc.notificationHandler.MarkRead)
+	notifications.Get("/reminders", svc.notificationHandler.GetStudyReminder)
+	notifications.Put("/reminders", svc.notificationHandler.SetStudyReminder)
+	notifications.Delete("/reminders", svc.notificationHandler.DeleteStudyReminder)
+	notifications.Post("/reminders/snooze", svc.notificationHandler.SnoozeStudyReminder)
+}
+
 func (svc *HttpService) setupAdminRoutes(v1 fiber.Router) {
 	admin := v1.Group("/admin", svc.authSvc.RequireRole("admin"))
 	admin.Post("/characters", svc.adminHandler.CreateCharacter)
+	admin.Get("/characters/:characterId/relations", validCharacterID, svc.adminHandler.GetCharacterRelations)
+	admin.Post("/characters/:characterId/relations", validCharacterID, svc.adminHandler.CreateCharacterRelation)
+	admin.Put("/character-relations/:relationId", svc.adminHandler.UpdateCharacterRelation)
+	admin.Delete("/character-relations/:relationId", svc.adminHandler.DeleteCharacterRelation)
+	admin.Post("/glossary", svc.adminHandler.CreateGlossaryTerm)
+	admin.Put("/glossary/:termId", svc.adminHandler.UpdateGlossaryTerm)
+	admin.Delete("/glossary/:termId", svc.adminHandler.DeleteGlossaryTerm)
+	admin.Get("/eras", svc.adminHandler.GetAllEras)
+	admin.Post("/eras", svc.adminHandler.CreateEra)
+	admin.Put("/eras/:code", svc.adminHandler.UpdateEra)
+	admin.Delete("/eras/:code", svc.adminHandler.DeleteEra)
+	admin.Get("/dynasties", svc.adminHandler.GetAllDynasties)
+	admin.Post("/dynasties", svc.adminHandler.CreateDynasty)
+	admin.Put("/dynasties/:dynastyId", svc.adminHandler.UpdateDynasty)
+	admin.Delete("/dynasties/:dynastyId", svc.adminHandler.DeleteDynasty)
 	admin.Post("/lessons/new", svc.adminHandler.CreateLessonFromRequest)
-
-	admin.Put("/lessons/:lessonId/script", svc.adminHandler.UpdateLessonScript)
-	admin.Post("/lessons/:lessonId/audio", svc.mediaHandler.UploadLessonAudio)
-	admin.Post("/lessons/:lessonId/animation", svc.mediaHandler.UploadLessonAnimation)
-	admin.Get("/lessons/:lessonId/production-status", svc.adminHandler.GetLessonProductionStatus)
-
-	admin.Post("/lessons/:lessonId/subtitle", svc.mediaHandler.UploadLessonSubtitle)
-	admin.Post("/lessons/:lessonId/thumbnail", svc.mediaHandler.UploadThumbnail)
-	admin.Get("/lessons/:lessonId/media", svc.mediaHandler.GetLessonMedia)
+	admin.Get("/shop/items", svc.shopHandler.GetShopItems)
+	admin.Post("/shop/items", svc.shopHandler.CreateShopItem)
+	admin.Put("/shop/items/:itemId", svc.shopHandler.UpdateShopItem)
+
+	admin.Put("/lessons/:lessonId/script", validLessonID, svc.adminHandler.UpdateLessonScript)
+	admin.Put("/lessons/:lessonId/content-rating", validLessonID, svc.adminHandler.UpdateLessonContentRating)
+	admin.Put("/lessons/:lessonId/difficulty", validLessonID, svc.adminHandler.UpdateLessonDifficulty)
+	admin.Get("/reports/unrated-content", svc.adminHandler.GetUnratedContent)
+	admin.Post("/lessons/:lessonId/audio", validLessonID, svc.mediaHandler.UploadLessonAudio)
+	admin.Post("/lessons/:lessonId/animation", validLessonID, svc.mediaHandler.UploadLessonAnimation)
+	admin.Post("/lessons/:lessonId/uploads", validLessonID, svc.mediaHandler.InitUpload)
+	admin.Get("/uploads/:uploadId", svc.mediaHandler.GetUploadStatus)
+	admin.Patch("/uploads/:uploadId", svc.mediaHandler.AppendUploadChunk)
+	admin.Post("/uploads/:uploadId/finalize", svc.mediaHandler.FinalizeUpload)
+	admin.Delete("/uploads/:uploadId", svc.mediaHandler.AbortUpload)
+	admin.Get("/lessons/:lessonId/production-status", validLessonID, svc.adminHandler.GetLessonProductionStatus)
+	admin.Get("/lessons/:lessonId/lint", validLessonID, svc.adminHandler.LintLesson)
+	admin.Get("/lessons/:lessonId/video-analytics", validLessonID, svc.adminHandler.GetLessonVideoAnalytics)
+	admin.Get("/lessons/:lessonId/versions", validLessonID, svc.adminHandler.GetLessonVersions)
+	admin.Get("/lessons/:lessonId/versions/:version/diff", validLessonID, svc.adminHandler.GetLessonVersionDiff)
+	admin.Get("/lessons/:lessonId/questions/export", validLessonID, svc.adminHandler.ExportLessonQuestions)
+	admin.Get("/lessons/:lessonId/questions/analytics", validLessonID, svc.adminHandler.GetLessonQuestionAnalytics)
+	admin.Post("/lessons/:lessonId/questions/import", validLessonID, svc.adminHandler.ImportLessonQuestions)
+	admin.Post("/lessons/:lessonId/questions/generate", validLessonID, svc.questionGenHandler.GenerateQuestionDrafts)
+
+	admin.Post("/lessons/:lessonId/subtitle", validLessonID, svc.mediaHandler.UploadLessonSubtitle)
+	admin.Post("/lessons/:lessonId/audio-description", validLessonID, svc.mediaHandler.UploadAudioDescription)
+	admin.Put("/lessons/:lessonId/transcript", validLessonID, svc.mediaHandler.UpdateLessonTranscript)
+	admin.Post("/lessons/:lessonId/thumbnail", validLessonID, svc.mediaHandler.UploadThumbnail)
+	admin.Get("/lessons/:lessonId/media", validLessonID, svc.mediaHandler.GetLessonMedia)
+	admin.Post("/lessons/:lessonId/questions/:questionId/media/:role", validLessonID, svc.mediaHandler.UploadQuestionMedia)
+	admin.Put("/lessons/:lessonId/questions/:questionId/media/:role", validLessonID, svc.mediaHandler.LinkQuestionMedia)
+	admin.Delete("/lessons/:lessonId/questions/:questionId/media/:role", validLessonID, svc.mediaHandler.UnlinkQuestionMedia)
+	admin.Get("/media/library", svc.mediaHandler.GetMediaLibrary)
+	admin.Get("/media/assets/:assetId", svc.mediaHandler.GetMediaAsset)
+	admin.Patch("/media/assets/:assetId", svc.mediaHandler.UpdateMediaAsset)
 	admin.Delete("/media/assets/:assetId", svc.mediaHandler.DeleteMediaAsset)
+	admin.Post("/media/assets/:assetId/purge", svc.mediaHandler.PurgeAssetCache)
+	admin.Post("/lessons/:lessonId/cdn-prewarm", validLessonID, svc.mediaHandler.PrewarmLesson)
+	admin.Get("/lessons/:lessonId/cdn-prewarm", validLessonID, svc.mediaHandler.GetLessonPrewarmStatus)
+	admin.Get("/cdn-prewarm/:jobId", svc.mediaHandler.GetPrewarmJob)
+	admin.Post("/media/assets/:assetId/reprocess", svc.mediaHandler.RetryMediaProcessing)
+	admin.Get("/media/processing/failed", svc.mediaHandler.GetFailedMediaProcessing)
 	admin.Get("/media/statistics", svc.mediaHandler.GetMediaStatistics)
+	admin.Get("/media/storage", svc.mediaHandler.GetStorageConfig)
 	admin.Get("/users", svc.adminHandler.AdminGetUsers)
-	admin.Put("/users/:userId", svc.adminHandler.AdminUpdateUser)
-	admin.Delete("/users/:userId", svc.adminHandler.AdminDeleteUser)
+	admin.Put("/users/:userId", validUserID, svc.adminHandler.AdminUpdateUser)
+	admin.Delete("/users/:userId", validUserID, svc.adminHandler.AdminDeleteUser)
+	admin.Get("/users/:userId/xp-ledger", validUserID, svc.adminHandler.GetUserXPLedger)
+	admin.Get("/users/:userId/heart-ledger", validUserID, svc.adminHandler.GetUserHeartLedger)
+	admin.Get("/users/:userId/item-ledger", validUserID, svc.adminHandler.GetUserItemLedger)
+	admin.Post("/economy/adjustments", svc.adminHandler.AdjustEconomy)
+	admin.Post("/progress/repair", svc.adminHandler.RepairProgress)
+	admin.Get("/progress/repair", svc.adminHandler.GetProgressRepairStatus)
+	admin.Get("/review/completion-flags", svc.adminHandler.GetCompletionFlags)
+	admin.Post("/review/completion-flags/:flagId", svc.adminHandler.ReviewCompletionFlag)
+	admin.Get("/review/leaderboard-anomalies", svc.adminHandler.GetLeaderboardAnomalies)
+	admin.Get("/review/leaderboard-anomalies/:anomalyId", svc.adminHandler.GetLeaderboardAnomalyEvidence)
+	admin.Post("/review/leaderboard-anomalies/:anomalyId", svc.adminHandler.ReviewLeaderboardAnomaly)
+	admin.Get("/review/moderation-flags", svc.adminHandler.GetModerationFlags)
+	admin.Post("/review/moderation-flags/:flagId", svc.adminHandler.ReviewModerationFlag)
+	admin.Get("/review/disabled-questions", svc.adminHandler.GetDisabledQuestions)
+	admin.Post("/review/disabled-questions/:disabledId", svc.adminHandler.ReviewDisabledQuestion)
+
+	admin.Get("/audit/content", svc.adminHandler.GetContentAuditLogs)
+	admin.Get("/audit/auth/verify", svc.adminHandler.VerifyAuditLogChain)
+	admin.Get("/audit/auth/export", svc.adminHandler.ExportAuditLogSegment)
+	admin.Get("/stats/system", svc.adminHandler.GetSystemStatistics)
+	admin.Get("/ops/stream", svc.adminHandler.StreamOpsEvents)
+	admin.Get("/db/slow-queries", svc.adminHandler.GetSlowQueries)
+	admin.Get("/game-config", svc.adminHandler.GetGameConfig)
+	admin.Put("/game-config", svc.adminHandler.UpdateGameConfig)
+
+	admin.Get("/lessons/:lessonId/translations", validLessonID, svc.translationHandler.GetTranslationReport)
+	admin.Get("/lessons/:lessonId/translations/:locale", validLessonID, svc.translationHandler.GetTranslation)
+	admin.Put("/lessons/:lessonId/translations/:locale", validLessonID, svc.translationHandler.SaveTranslation)
+	admin.Post("/lessons/:lessonId/translations/:locale/machine", validLessonID, svc.translationHandler.MachineTranslate)
+	admin.Post("/lessons/:lessonId/translations/:locale/submit", validLessonID, svc.translationHandler.SubmitForReview)
+	admin.Post("/lessons/:lessonId/translations/:locale/review", validLessonID, svc.translationHandler.ReviewTranslation)
+	admin.Get("/translations/missing", svc.translationHandler.GetMissingTranslations)
+
+	admin.Post("/webhooks", svc.webhookHandler.CreateWebhook)
+	admin.Get("/webhooks", svc.webhookHandler.GetWebhooks)
+	admin.Put("/webhooks/:webhookId", svc.webhookHandler.UpdateWebhook)
+	admin.Delete("/webhooks/:webhookId", svc.webhookHandler.DeleteWebhook)
+	admin.Post("/webhooks/:webhookId/rotate-secret", svc.webhookHandler.RotateWebhookSecret)
+	admin.Get("/webhooks/:webhookId/deliveries", svc.webhookHandler.GetWebhookDeliveries)
+	admin.Post("/webhooks/:webhookId/deliveries/:deliveryId/redeliver", svc.webhookHandler.RedeliverWebhook)
+
+	admin.Get("/retention/policies", svc.retentionHandler.GetRetentionPolicies)
+	admin.Put("/retention/policies/:table", svc.retentionHandler.UpdateRetentionPolicy)
+	admin.Post("/retention/run", svc.retentionHandler.StartRetentionRun)
+	admin.Get("/retention/run", svc.retentionHandler.GetRetentionStatus)
+	admin.Get("/retention/anonymization", svc.retentionHandler.GetAnonymizationRuns)
+	admin.Get("/storage/tables", svc.retentionHandler.GetTableSizes)
+
+	admin.Post("/research/exports", svc.researchHandler.RequestExport)
+	admin.Get("/research/exports", svc.researchHandler.GetExports)
+	admin.Get("/research/exports/:exportId", svc.researchHandler.GetExport)
+	admin.Post("/research/exports/:exportId/download", svc.researchHandler.GetDownloadURL)
+
+	admin.Get("/maintenance", svc.maintenanceHandler.GetState)
+	admin.Put("/maintenance", svc.maintenanceHandler.SetMaintenance)
+	admin.Get("/maintenance/windows", svc.maintenanceHandler.GetWindows)
+	admin.Post("/maintenance/windows", svc.maintenanceHandler.ScheduleWindow)
+	admin.Delete("/maintenance/windows/:windowId", svc.maintenanceHandler.CancelWindow)
+
+	admin.Get("/app-versions", svc.appVersionHandler.GetPolicies)
+	admin.Put("/app-versions/:platform", svc.appVersionHandler.UpdatePolicy)
+
+	admin.Post("/cache/invalidate", svc.cacheHandler.Invalidate)
+
+	admin.Post("/lessons/:lessonId/kill-switch", validLessonID, svc.killSwitchHandler.KillContent)
+	admin.Get("/incidents", svc.killSwitchHandler.GetIncidents)
+	admin.Post("/incidents/:incidentId/resolve", svc.killSwitchHandler.ResolveIncident)
+}
+
+// setupSupportRoutes registers the account tools support agents share with admins
+func (svc *HttpService) setupSupportRoutes(v1 fiber.Router) {
+	support := v1.Group("/support", svc.authSvc.RequiredAuth(), svc.authSvc.RequireRole("admin", "mod"))
+	support.Get("/users/:userId/notes", validUserID, svc.adminHandler.GetUserNotes)
+	support.Post("/users/:userId/notes", validUserID, svc.adminHandler.CreateUserNote)
+	support.Put("/users/:userId/notes/:noteId", validUserID, svc.adminHandler.UpdateUserNote)
+	support.Delete("/users/:userId/notes/:noteId", validUserID, svc.adminHandler.DeleteUserNote)
 }
 
 func (svc *HttpService) Shutdown() {
@@ -233,8 +780,33 @@ func (svc *HttpService) HandleError(c *fiber.Ctx, err error) error {
 	}
 
 	if appErr, ok := shared.GetAppError(err); ok {
+		if appErr.StatusCode >= fiber.StatusInternalServerError {
+			svc.publishErrorEvent(c, appErr.StatusCode, err)
+		}
 		return shared.ResponseJSON(c, appErr.StatusCode, appErr.Message, appErr.Data)
 	}
 
+	// Errors raised by Fiber itself, e.g. a body over maxBodyLimit
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		if fiberErr.Code == fiber.StatusRequestEntityTooLarge {
+			return shared.ResponseJSON(c, fiberErr.Code, "Request body too large", map[string]interface{}{
+				"max_bytes": maxBodyLimit,
+			})
+		}
+		return shared.ResponseJSON(c, fiberErr.Code, fiberErr.Message, nil)
+	}
+
+	svc.publishErrorEvent(c, fiber.StatusInternalServerError, err)
 	return shared.ResponseInternalError(c, err)
 }
+
+// publishErrorEvent streams a server error to the admin ops console
+func (svc *HttpService) publishErrorEvent(c *fiber.Ctx, status int, err error) {
+	svc.systemSvc.PublishOpsEvent(OpsEventError, OpsSeverityError, map[string]interface{}{
+		"status": status,
+		"method": c.Method(),
+		"path":   c.Path(),
+		"error":  err.Error(),
+	})
+}
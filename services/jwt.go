@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	serviceContext "github.com/cloakd/common/services"
@@ -29,20 +30,35 @@ type JWTService struct {
 
 type CustomClaims struct {
 	UserID    string `json:"user_id"`
-	TokenType string `json:"token_type"` // "access" or "refresh"
+	TokenType string `json:"token_type"` // "access", "refresh", "email_verify" or "device_action"
 	SessionID string `json:"session_id,omitempty"`
+	// email_verify ties the link to one verification code, device_action to an action on one device
+	Binding string `json:"binding,omitempty"`
 	jwt.RegisteredClaims
 }
 
 const JWT_SVC = "jwt_svc"
 
+// Values cached under blacklist:<jti>
+const (
+	blacklistRevoked = "1"
+	blacklistValid   = "0"
+
+	blacklistNegativeCacheTTL = 5 * time.Minute
+)
+
 func (svc JWTService) Id() string {
 	return JWT_SVC
 }
 
 func (svc *JWTService) Configure(ctx *context.Context) error {
-	svc.sqlSvc = ctx.Service(POSTGRES_SVC).(*PostgresService)
-	svc.redisSvc = ctx.Service(REDIS_SVC).(*RedisService)
+	deps := newDependencies(svc.Id(), ctx.Service)
+	svc.sqlSvc = resolve[*PostgresService](deps, POSTGRES_SVC)
+	svc.redisSvc = resolve[*RedisService](deps, REDIS_SVC)
+	if err := deps.err(); err != nil {
+		return err
+	}
+
 	// Access tokens: 15 minutes (short-lived for security)
 	svc.AccessTokenDuration = time.Duration(15 * time.Minute)
 
@@ -155,6 +171,120 @@ func (svc *JWTService) generateRefreshToken(userID string) (string, error) {
 	return tokenString, nil
 }
 
+// GenerateEmailVerificationToken signs the token carried by the one-tap verification link.
+// It is signed with the access key but can never pass as an access token because of its type.
+func (svc *JWTService) GenerateEmailVerificationToken(userID, binding string, ttl time.Duration) (string, error) {
+	now := time.Now()
+
+	claims := &CustomClaims{
+		UserID:    userID,
+		TokenType: "email_verify",
+		Binding:   binding,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    "TechYouth",
+			Subject:   userID,
+			ID:        svc.generateJTI(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString([]byte(svc.jwtSecretKey))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign email verification token: %v", err)
+	}
+
+	return tokenString, nil
+}
+
+// VerifyEmailVerificationToken checks the signature, type and expiry of a verification link token
+func (svc *JWTService) VerifyEmailVerificationToken(tokenString string) (*CustomClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &CustomClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return svc.getAccessTokenKey(token)
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse email verification token: %v", err)
+	}
+
+	if !token.Valid {
+		return nil, errors.New("invalid email verification token")
+	}
+
+	claims, ok := token.Claims.(*CustomClaims)
+	if !ok {
+		return nil, errors.New("invalid email verification token claims")
+	}
+
+	if claims.TokenType != "email_verify" || claims.Binding == "" {
+		return nil, errors.New("invalid token type")
+	}
+
+	return claims, nil
+}
+
+// GenerateDeviceActionToken signs the token of a trust or untrust link in a login email.
+// The binding is the action and the device it applies to.
+func (svc *JWTService) GenerateDeviceActionToken(userID, action, deviceID string, ttl time.Duration) (string, error) {
+	now := time.Now()
+
+	claims := &CustomClaims{
+		UserID:    userID,
+		TokenType: "device_action",
+		Binding:   action + ":" + deviceID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    "TechYouth",
+			Subject:   userID,
+			ID:        svc.generateJTI(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString([]byte(svc.jwtSecretKey))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign device action token: %v", err)
+	}
+
+	return tokenString, nil
+}
+
+// VerifyDeviceActionToken checks a device action link token and returns the action and
+// device it is for. Used links are blacklisted, so each works once.
+func (svc *JWTService) VerifyDeviceActionToken(tokenString string) (*CustomClaims, string, string, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &CustomClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return svc.getAccessTokenKey(token)
+	})
+
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to parse device action token: %v", err)
+	}
+
+	if !token.Valid {
+		return nil, "", "", errors.New("invalid device action token")
+	}
+
+	claims, ok := token.Claims.(*CustomClaims)
+	if !ok {
+		return nil, "", "", errors.New("invalid device action token claims")
+	}
+
+	action, deviceID, found := strings.Cut(claims.Binding, ":")
+	if claims.TokenType != "device_action" || !found || deviceID == "" {
+		return nil, "", "", errors.New("invalid token type")
+	}
+
+	if svc.isTokenBlacklisted(claims.ID, claims.ExpiresAt.Time) {
+		return nil, "", "", errors.New("device action link has been used")
+	}
+
+	return claims, action, deviceID, nil
+}
+
 // Verify access token
 func (svc *JWTService) VerifyJWTToken(jwtToken string) (string, error) {
 	claims, err := svc.VerifyAndGetClaims(jwtToken)
@@ -189,7 +319,7 @@ func (svc *JWTService) VerifyAndGetClaims(jwtToken string) (*CustomClaims, error
 	}
 
 	// Check if token is blacklisted
-	if svc.isTokenBlacklisted(claims.ID) {
+	if svc.isTokenBlacklisted(claims.ID, claims.ExpiresAt.Time) {
 		return nil, errors.New("token has been revoked")
 	}
 
@@ -223,7 +353,7 @@ func (svc *JWTService) VerifyRefreshToken(refreshToken string) (string, error) {
 		return "", errors.New("invalid token type")
 	}
 
-	if svc.isTokenBlacklisted(claims.ID) {
+	if svc.isTokenBlacklisted(claims.ID, claims.ExpiresAt.Time) {
 		return "", errors.New("refresh token has been revoked")
 	}
 
@@ -270,29 +400,71 @@ func (svc *JWTService) generateJTI() string {
 	return fmt.Sprintf("jti_%d_%d", time.Now().UnixNano(), time.Now().Unix())
 }
 
-// Check if token is blacklisted
-func (svc *JWTService) isTokenBlacklisted(jti string) bool {
+// Check if token is blacklisted. Both outcomes are cached in Redis so the DB is only
+// consulted once per token; a blacklist write overwrites a cached "not revoked" entry.
+func (svc *JWTService) isTokenBlacklisted(jti string, expiresAt time.Time) bool {
 	ctx := stdContext.Background()
-	exists, err := svc.redisSvc.Exists(ctx, fmt.Sprintf("blacklist:%s", jti))
+	key := fmt.Sprintf("blacklist:%s", jti)
+
+	cached, err := svc.redisSvc.Get(ctx, key)
 	if err != nil {
 		log.WithError(err).Warnf("Redis check failed for JTI %s, falling back to DB", jti)
 		return svc.sqlSvc.userRepo.IsTokenBlacklisted(jti)
 	}
-	return exists
+
+	switch cached {
+	case blacklistRevoked:
+		return true
+	case blacklistValid:
+		return false
+	}
+
+	blacklisted := svc.sqlSvc.userRepo.IsTokenBlacklisted(jti)
+
+	ttl := time.Until(expiresAt)
+	value := blacklistRevoked
+	if !blacklisted {
+		// Keep negative entries short-lived in case a token is revoked without going through Redis
+		value = blacklistValid
+		if ttl > blacklistNegativeCacheTTL {
+			ttl = blacklistNegativeCacheTTL
+		}
+	}
+
+	if ttl > 0 {
+		if err := svc.redisSvc.Set(ctx, key, value, ttl); err != nil {
+			log.WithError(err).Warnf("Failed to cache blacklist status for JTI %s", jti)
+		}
+	}
+
+	return blacklisted
 }
 
 func (svc *JWTService) blacklistToken(jti string, expiresAt time.Time) error {
-	ctx := stdContext.Background()
 	ttl := time.Until(expiresAt)
 	if ttl <= 0 {
 		return nil
 	}
 
-	if err := svc.redisSvc.Set(ctx, fmt.Sprintf("blacklist:%s", jti), "1", ttl); err != nil {
-		return fmt.Errorf("failed to blacklist token in redis: %w", err)
+	if err := svc.sqlSvc.userRepo.BlacklistToken(jti, expiresAt); err != nil {
+		return err
 	}
 
-	return svc.sqlSvc.userRepo.BlacklistToken(jti, expiresAt)
+	ctx := stdContext.Background()
+	key := fmt.Sprintf("blacklist:%s", jti)
+	if err := svc.redisSvc.Set(ctx, key, blacklistRevoked, ttl); err != nil {
+		log.WithError(err).Warnf("Failed to cache blacklisted JTI %s, dropping cached entry", jti)
+		if err := svc.redisSvc.Delete(ctx, key); err != nil {
+			log.WithError(err).Warnf("Failed to drop cached blacklist entry for JTI %s", jti)
+		}
+	}
+
+	return nil
+}
+
+// BlacklistJTI revokes a token by its JTI, e.g. a session's refresh token
+func (svc *JWTService) BlacklistJTI(jti string, expiresAt time.Time) error {
+	return svc.blacklistToken(jti, expiresAt)
 }
 
 func (svc *JWTService) syncBlacklistToRedis() {
@@ -307,7 +479,7 @@ func (svc *JWTService) syncBlacklistToRedis() {
 	for _, token := range tokens {
 		ttl := time.Until(token.ExpiresAt)
 		if ttl > 0 {
-			if err := svc.redisSvc.Set(ctx, fmt.Sprintf("blacklist:%s", token.JTI), "1", ttl); err != nil {
+			if err := svc.redisSvc.Set(ctx, fmt.Sprintf("blacklist:%s", token.JTI), blacklistRevoked, ttl); err != nil {
 				log.WithError(err).Warnf("Failed to sync token %s to Redis", token.JTI)
 			} else {
 				synced++
@@ -0,0 +1,287 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"github.com/cloakd/common/context"
+	serviceContext "github.com/cloakd/common/services"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/services/repositories"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	log "github.com/sirupsen/logrus"
+)
+
+// KillSwitchService lets admins pull a shipped lesson, or one of its questions, the
+// moment a factual error is found. The content disappears for every learner at once:
+// the change is audited, every instance clears its content caches and learners in the
+// middle of the lesson are told why it went away. Each takedown is kept as an incident
+// until an admin restores the content or retires it.
+type KillSwitchService struct {
+	serviceContext.DefaultService
+
+	sqlSvc          *PostgresService
+	contentSvc      *ContentService
+	cacheSvc        *CacheService
+	notificationSvc *NotificationService
+	systemSvc       *SystemService
+
+	// Set from sqlSvc in Start
+	contentRepo  repositories.ContentRepo
+	progressRepo repositories.ProgressRepo
+}
+
+const KILL_SWITCH_SVC = "kill_switch_svc"
+
+func (svc *KillSwitchService) Id() string {
+	return KILL_SWITCH_SVC
+}
+
+func (svc *KillSwitchService) Configure(ctx *context.Context) error {
+	return svc.DefaultService.Configure(ctx)
+}
+
+func (svc *KillSwitchService) Start() error {
+	deps := newDependencies(svc.Id(), svc.Service)
+	svc.sqlSvc = resolve[*PostgresService](deps, POSTGRES_SVC)
+	svc.contentSvc = resolve[*ContentService](deps, CONTENT_SVC)
+	svc.cacheSvc = resolve[*CacheService](deps, CACHE_SVC)
+	svc.notificationSvc = resolve[*NotificationService](deps, NOTIFICATION_SVC)
+	svc.systemSvc = resolve[*SystemService](deps, SYSTEM_SVC)
+	if err := deps.err(); err != nil {
+		return err
+	}
+	svc.contentRepo = svc.sqlSvc.contentRepo
+	svc.progressRepo = svc.sqlSvc.contentRepo
+
+	return nil
+}
+
+// KillContent takes a lesson offline, or only one of its questions when the request
+// names one, and opens an incident for it. A pulled question waits in the disabled
+// question queue like a heavily reported one.
+func (svc *KillSwitchService) KillContent(adminID, lessonID string, req dto.KillContentRequest) (*dto.ContentIncidentResponse, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, shared.NewBadRequestError(nil, "A reason is required")
+	}
+
+	before, err := svc.contentRepo.GetLesson(lessonID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Lesson not found")
+	}
+
+	incident := &model.ContentIncident{
+		LessonID:   before.ID,
+		Reason:     reason,
+		DisabledBy: adminID,
+	}
+	if req.QuestionID == "" {
+		incident.EntityType = model.ContentIncidentLesson
+		deactivated, err := svc.contentRepo.DeactivateLesson(incident)
+		if err != nil {
+			return nil, shared.NewInternalError(err, "Failed to disable lesson")
+		}
+		if !deactivated {
+			return nil, shared.NewConflictError(nil, "Lesson is already disabled")
+		}
+	} else {
+		incident.EntityType = model.ContentIncidentQuestion
+		incident.QuestionID = req.QuestionID
+		disabled := &model.DisabledQuestion{
+			LessonID:   before.ID,
+			QuestionID: req.QuestionID,
+			Cause:      model.QuestionDisabledByKillSwitch,
+		}
+		found, err := svc.contentRepo.DisableQuestion(disabled)
+		if err != nil {
+			return nil, shared.NewInternalError(err, "Failed to disable question")
+		}
+		if !found {
+			return nil, shared.NewNotFoundError(nil, "Question not found in the lesson")
+		}
+		incident.DisabledQuestionID = disabled.ID
+		if err := svc.contentRepo.CreateContentIncident(incident); err != nil {
+			// The question is out already; the admin finds it in the disabled question queue
+			return nil, shared.NewInternalError(err, "Question disabled, but the incident could not be recorded")
+		}
+	}
+
+	if after, err := svc.contentRepo.GetLesson(before.ID); err == nil {
+		svc.contentSvc.RecordContentAudit(adminID, model.ContentEntityLesson, before.ID, model.ContentActionUpdate, before, after)
+	}
+
+	svc.cacheSvc.clearContent(CacheScopeLesson)
+	incident.CachesBroadcast = svc.cacheSvc.broadcast(CacheScopeLesson, before.ID)
+	incident.SessionsNotified = svc.notifySessions(incident, before.Title)
+	if err := svc.contentRepo.UpdateContentIncidentNotified(incident.ID, incident.SessionsNotified, incident.CachesBroadcast); err != nil {
+		log.Printf("Failed to update content incident %s: %v", incident.ID, err)
+	}
+
+	svc.systemSvc.PublishOpsEvent(OpsEventContentKillSwitch, OpsSeverityWarning, map[string]interface{}{
+		"incident_id":       incident.ID,
+		"entity_type":       incident.EntityType,
+		"lesson_id":         incident.LessonID,
+		"question_id":       incident.QuestionID,
+		"admin_id":          adminID,
+		"sessions_notified": incident.SessionsNotified,
+	})
+	log.Printf("Admin %s pulled %s from lesson %s (incident %s): %s", adminID, incident.EntityType, before.ID, incident.ID, reason)
+
+	incident.Lesson = *before
+	response := mapContentIncident(incident)
+	return &response, nil
+}
+
+// notifySessions tells the learners in the middle of the lesson why it went away, so the
+// app can leave it gracefully instead of failing on the next answer. Their sessions are
+// kept and resume if the content comes back.
+func (svc *KillSwitchService) notifySessions(incident *model.ContentIncident, lessonTitle string) int {
+	userIDs, err := svc.progressRepo.GetLessonSessionUserIDs(incident.LessonID, time.Now().Add(-lessonSessionTTL))
+	if err != nil {
+		log.Printf("Failed to list sessions of lesson %s: %v", incident.LessonID, err)
+		return 0
+	}
+
+	title := "Bài học tạm ngừng"
+	body := "Bài học \"" + lessonTitle + "\" đang được chỉnh sửa và tạm thời không thể tiếp tục. Tiến trình của bạn vẫn được giữ lại."
+	if incident.EntityType == model.ContentIncidentQuestion {
+		title = "Bài học đã được cập nhật"
+		body = "Một câu hỏi trong bài \"" + lessonTitle + "\" đã được gỡ để chỉnh sửa. Bạn có thể tiếp tục bài học như bình thường."
+	}
+	data := map[string]interface{}{
+		"incident_id": incident.ID,
+		"entity_type": incident.EntityType,
+		"lesson_id":   incident.LessonID,
+	}
+	if incident.QuestionID != "" {
+		data["question_id"] = incident.QuestionID
+	}
+
+	for _, userID := range userIDs {
+		svc.notificationSvc.Notify(userID, model.NotificationTypeContent, title, body, data)
+	}
+	return len(userIDs)
+}
+
+func (svc *KillSwitchService) GetIncidents(status string, page, limit int) (*dto.ContentIncidentListResponse, error) {
+	incidents, total, err := svc.contentRepo.GetContentIncidents(status, "", page, limit)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get content incidents")
+	}
+
+	responses := make([]dto.ContentIncidentResponse, len(incidents))
+	for i := range incidents {
+		responses[i] = mapContentIncident(&incidents[i])
+	}
+
+	return &dto.ContentIncidentListResponse{
+		Incidents: responses,
+		Total:     int(total),
+		Page:      page,
+		Limit:     limit,
+	}, nil
+}
+
+// ResolveIncident closes an open incident, putting the content back live or leaving it
+// out for good. A pulled question still waiting in the disabled question queue is
+// reviewed along with it.
+func (svc *KillSwitchService) ResolveIncident(adminID, incidentID string, req dto.ResolveContentIncidentRequest) (*dto.ContentIncidentResponse, error) {
+	incident, err := svc.contentRepo.GetContentIncident(incidentID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Content incident not found")
+	}
+	if incident.Status != model.ContentIncidentOpen {
+		return nil, shared.NewConflictError(nil, "Content incident has already been resolved")
+	}
+
+	resolution := model.ContentIncidentKeptDisabled
+	if req.Action == "restore" {
+		resolution = model.ContentIncidentRestored
+	}
+
+	if incident.EntityType == model.ContentIncidentQuestion {
+		disabled, err := svc.contentRepo.GetDisabledQuestion(incident.DisabledQuestionID)
+		if err != nil {
+			return nil, shared.NewNotFoundError(err, "Disabled question not found")
+		}
+		// Admins may have reviewed it from the queue already
+		if disabled.Status == model.DisabledQuestionPending {
+			review := dto.ReviewDisabledQuestionRequest{Action: "remove", Note: req.Note}
+			if resolution == model.ContentIncidentRestored {
+				review.Action = "restore"
+			}
+			if _, err := svc.contentSvc.ReviewDisabledQuestion(adminID, disabled.ID, review); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	before := incident.Lesson
+	resolved, err := svc.contentRepo.ResolveContentIncident(incident.ID, resolution, adminID, strings.TrimSpace(req.Note))
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to resolve content incident")
+	}
+	if !resolved {
+		return nil, shared.NewConflictError(nil, "Content incident has already been resolved")
+	}
+
+	if resolution == model.ContentIncidentRestored {
+		if incident.EntityType == model.ContentIncidentLesson {
+			if after, err := svc.contentRepo.GetLesson(incident.LessonID); err == nil {
+				svc.contentSvc.RecordContentAudit(adminID, model.ContentEntityLesson, incident.LessonID, model.ContentActionUpdate, &before, after)
+			}
+		}
+		svc.cacheSvc.clearContent(CacheScopeLesson)
+		svc.cacheSvc.broadcast(CacheScopeLesson, incident.LessonID)
+	}
+
+	svc.systemSvc.PublishOpsEvent(OpsEventContentKillSwitch, OpsSeverityInfo, map[string]interface{}{
+		"incident_id": incident.ID,
+		"lesson_id":   incident.LessonID,
+		"resolution":  resolution,
+		"admin_id":    adminID,
+	})
+	log.Printf("Admin %s resolved content incident %s: %s", adminID, incident.ID, resolution)
+
+	incident, err = svc.contentRepo.GetContentIncident(incident.ID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get content incident")
+	}
+
+	response := mapContentIncident(incident)
+	return &response, nil
+}
+
+func mapContentIncident(incident *model.ContentIncident) dto.ContentIncidentResponse {
+	return dto.ContentIncidentResponse{
+		ID:                 incident.ID,
+		EntityType:         incident.EntityType,
+		LessonID:           incident.LessonID,
+		LessonTitle:        incident.Lesson.Title,
+		QuestionID:         incident.QuestionID,
+		DisabledQuestionID: incident.DisabledQuestionID,
+		Reason:             incident.Reason,
+		DisabledBy:         incident.DisabledBy,
+		SessionsNotified:   incident.SessionsNotified,
+		CachesBroadcast:    incident.CachesBroadcast,
+		Status:             incident.Status,
+		Resolution:         incident.Resolution,
+		ResolvedBy:         incident.ResolvedBy,
+		ResolutionNote:     incident.ResolutionNote,
+		ResolvedAt:         incident.ResolvedAt,
+		CreatedAt:          incident.CreatedAt,
+	}
+}
+
+// checkLessonActive refuses learners a lesson taken offline, with its own status so
+// apps can explain it and leave the lesson rather than show a generic error
+func checkLessonActive(lesson *model.Lesson) error {
+	if lesson.IsActive {
+		return nil
+	}
+	return shared.NewGoneError(nil, "This lesson has been withdrawn for review").WithData(map[string]interface{}{
+		"lesson_id": lesson.ID,
+	})
+}
@@ -0,0 +1,34 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/services/repositories/mocks"
+	"github.com/lac-hong-legacy/ven_api/shared"
+)
+
+func TestWithdrawnLesson(t *testing.T) {
+	lesson := &model.Lesson{ID: "lesson_1", IsActive: false}
+	svc := &ContentService{contentRepo: &mocks.ContentRepo{
+		GetLessonFunc: func(id string) (*model.Lesson, error) { return lesson, nil },
+	}}
+
+	gone := func(err error) bool {
+		appErr, ok := shared.GetAppError(err)
+		return ok && appErr.StatusCode == http.StatusGone
+	}
+	if _, err := svc.GetLessonContent("lesson_1", "", "user_1"); !gone(err) {
+		t.Errorf("lesson content of a withdrawn lesson: %v", err)
+	}
+	if _, err := svc.UpdateLessonSession("user_1", "lesson_1", dto.UpdateLessonSessionRequest{}); !gone(err) {
+		t.Errorf("session checkpoint in a withdrawn lesson: %v", err)
+	}
+
+	lesson.IsActive = true
+	if err := checkLessonActive(lesson); err != nil {
+		t.Errorf("active lesson refused: %v", err)
+	}
+}
@@ -0,0 +1,276 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	leaderboardAnomalyScanInterval = 15 * time.Minute
+	// The evidence timeline also shows what the user did this long around the window
+	anomalyTimelinePadding = 6 * time.Hour
+)
+
+// Kinds of AnomalyTimelineEntry
+const (
+	anomalyTimelineXP             = "xp"
+	anomalyTimelineCompletion     = "lesson_completion"
+	anomalyTimelineCompletionFlag = "completion_flag"
+)
+
+func (svc *UserService) startLeaderboardAnomalyScanner() {
+	ticker := time.NewTicker(leaderboardAnomalyScanInterval)
+	for range ticker.C {
+		if err := svc.DetectLeaderboardAnomalies(); err != nil {
+			log.Printf("Failed to detect leaderboard anomalies: %v", err)
+		}
+	}
+}
+
+// DetectLeaderboardAnomalies looks for users who earned more XP over the last day or the
+// last hour than the game config allows. Each one gets an anomaly and is quarantined
+// from the leaderboards; users already waiting for review are skipped.
+func (svc *UserService) DetectLeaderboardAnomalies() error {
+	config, err := svc.contentRepo.GetGameConfig()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	checks := []struct {
+		reason    string
+		window    time.Duration
+		threshold int
+	}{
+		{model.AnomalyReasonDailyXP, 24 * time.Hour, config.AnomalyMaxDailyXP},
+		{model.AnomalyReasonXPJump, time.Hour, config.AnomalyMaxHourlyXP},
+	}
+
+	for _, check := range checks {
+		if check.threshold <= 0 {
+			continue
+		}
+		start := now.Add(-check.window)
+		gains, err := svc.progressRepo.GetXPGainsAbove(start, check.threshold)
+		if err != nil {
+			return err
+		}
+
+		for _, gain := range gains {
+			if pending, err := svc.progressRepo.HasPendingLeaderboardAnomaly(gain.UserID); err != nil || pending {
+				continue
+			}
+			if err := svc.progressRepo.CreateLeaderboardAnomaly(&model.LeaderboardAnomaly{
+				UserID:      gain.UserID,
+				Reason:      check.reason,
+				XPGained:    gain.XP,
+				Threshold:   check.threshold,
+				WindowStart: start,
+				WindowEnd:   now,
+			}); err != nil {
+				return err
+			}
+			log.Warnf("Leaderboard anomaly for user %s: %s, %d XP since %s (limit %d), quarantined",
+				gain.UserID, check.reason, gain.XP, start.Format(time.RFC3339), check.threshold)
+		}
+	}
+	return nil
+}
+
+// ==================== ANOMALY REVIEW ====================
+
+func (svc *UserService) GetLeaderboardAnomalies(status string, page, limit int) (*dto.LeaderboardAnomalyListResponse, error) {
+	anomalies, total, err := svc.progressRepo.GetLeaderboardAnomalies(status, page, limit)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get leaderboard anomalies")
+	}
+
+	responses := make([]dto.LeaderboardAnomalyResponse, len(anomalies))
+	for i := range anomalies {
+		responses[i] = mapLeaderboardAnomaly(&anomalies[i])
+	}
+
+	return &dto.LeaderboardAnomalyListResponse{
+		Anomalies: responses,
+		Total:     int(total),
+		Page:      page,
+		Limit:     limit,
+	}, nil
+}
+
+// GetLeaderboardAnomalyEvidence returns an anomaly with the user's XP ledger entries,
+// lesson completions and flagged completions around its window, oldest first
+func (svc *UserService) GetLeaderboardAnomalyEvidence(anomalyID string) (*dto.LeaderboardAnomalyEvidenceResponse, error) {
+	anomaly, err := svc.progressRepo.GetLeaderboardAnomaly(anomalyID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Leaderboard anomaly not found")
+	}
+
+	from := anomaly.WindowStart.Add(-anomalyTimelinePadding)
+	to := anomaly.WindowEnd.Add(anomalyTimelinePadding)
+	transactions, err := svc.progressRepo.GetXPTransactionsBetween(anomaly.UserID, from, to)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get XP history")
+	}
+	completions, err := svc.progressRepo.GetLessonCompletionsBetween(anomaly.UserID, from, to)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get lesson completions")
+	}
+	flags, err := svc.progressRepo.GetCompletionFlagsBetween(anomaly.UserID, from, to)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get completion flags")
+	}
+
+	response := &dto.LeaderboardAnomalyEvidenceResponse{
+		Anomaly:  mapLeaderboardAnomaly(anomaly),
+		Timeline: anomalyTimeline(transactions, completions, flags),
+	}
+	if progress, err := svc.progressRepo.GetUserProgress(anomaly.UserID); err == nil {
+		response.XP = progress.XP
+	}
+	return response, nil
+}
+
+// ReviewLeaderboardAnomaly clears the user or penalizes them by revoking XP. Either way
+// all their pending anomalies are closed and they return to the leaderboards.
+func (svc *UserService) ReviewLeaderboardAnomaly(adminID, anomalyID string, req dto.ReviewLeaderboardAnomalyRequest) (*dto.LeaderboardAnomalyResponse, error) {
+	anomaly, err := svc.progressRepo.GetLeaderboardAnomaly(anomalyID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Leaderboard anomaly not found")
+	}
+	if anomaly.Status != model.AnomalyStatusPending {
+		return nil, shared.NewConflictError(nil, "Leaderboard anomaly has already been reviewed")
+	}
+
+	status := model.AnomalyStatusCleared
+	penalty := 0
+	if req.Action == "penalize" {
+		status = model.AnomalyStatusPenalized
+		if penalty, err = svc.penalizeAnomalyXP(adminID, anomaly, req); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := svc.progressRepo.ResolveLeaderboardAnomalies(anomaly.ID, anomaly.UserID, status, adminID, req.Note, penalty); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to update leaderboard anomalies")
+	}
+	log.Printf("Leaderboard anomaly %s of user %s %s by %s", anomaly.ID, anomaly.UserID, status, adminID)
+
+	anomaly, err = svc.progressRepo.GetLeaderboardAnomaly(anomalyID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get leaderboard anomaly")
+	}
+
+	response := mapLeaderboardAnomaly(anomaly)
+	return &response, nil
+}
+
+// penalizeAnomalyXP revokes the requested XP, by default what was gained in the
+// anomaly's window, and returns how much was taken
+func (svc *UserService) penalizeAnomalyXP(adminID string, anomaly *model.LeaderboardAnomaly, req dto.ReviewLeaderboardAnomalyRequest) (int, error) {
+	note := fmt.Sprintf("leaderboard anomaly %s", anomaly.Reason)
+	if req.Note != "" {
+		note += ": " + req.Note
+	}
+
+	penalty := req.PenaltyXP
+	if penalty == 0 {
+		penalty = anomaly.XPGained
+	}
+	_, err := svc.progressRepo.ModifyUserProgress(anomaly.UserID, func(progress *model.UserProgress) (*model.ProgressChange, error) {
+		xp := max(progress.XP-penalty, 0)
+		penalty = progress.XP - xp
+		if penalty == 0 {
+			return nil, nil
+		}
+
+		progress.XP = xp
+		progress.Level = svc.calculateLevel(xp)
+		return &model.ProgressChange{XP: &model.XPTransaction{
+			Source:      model.XPSourceAdmin,
+			Amount:      -penalty,
+			ReferenceID: anomaly.ID,
+			ReasonCode:  model.EconomyReasonAbuse,
+			ActorID:     adminID,
+			Note:        note,
+		}}, nil
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, shared.NewNotFoundError(err, "User progress not found")
+	}
+	if err != nil {
+		return 0, shared.NewInternalError(err, "Failed to revoke XP")
+	}
+	if penalty == 0 {
+		return 0, nil
+	}
+	svc.publishProgressChanged(anomaly.UserID, "anomaly_penalty")
+
+	return penalty, nil
+}
+
+func mapLeaderboardAnomaly(anomaly *model.LeaderboardAnomaly) dto.LeaderboardAnomalyResponse {
+	return dto.LeaderboardAnomalyResponse{
+		ID:          anomaly.ID,
+		UserID:      anomaly.UserID,
+		Username:    anomaly.User.Username,
+		Reason:      anomaly.Reason,
+		XPGained:    anomaly.XPGained,
+		Threshold:   anomaly.Threshold,
+		WindowStart: anomaly.WindowStart,
+		WindowEnd:   anomaly.WindowEnd,
+		Quarantined: anomaly.User.LeaderboardQuarantined,
+		Status:      anomaly.Status,
+		PenaltyXP:   anomaly.PenaltyXP,
+		ReviewedBy:  anomaly.ReviewedBy,
+		ReviewNote:  anomaly.ReviewNote,
+		ReviewedAt:  anomaly.ReviewedAt,
+		CreatedAt:   anomaly.CreatedAt,
+	}
+}
+
+// anomalyTimeline merges XP entries, completions and flags into one list by time. Entries
+// at the same moment keep that order, so a completion's XP comes before the completion.
+func anomalyTimeline(transactions []model.XPTransaction, completions []model.UserLessonCompletion, flags []model.CompletionFlag) []dto.AnomalyTimelineEntry {
+	timeline := make([]dto.AnomalyTimelineEntry, 0, len(transactions)+len(completions)+len(flags))
+	for _, txn := range transactions {
+		timeline = append(timeline, dto.AnomalyTimelineEntry{
+			At:     txn.CreatedAt,
+			Kind:   anomalyTimelineXP,
+			Source: txn.Source,
+			Amount: txn.Amount,
+			Detail: txn.Note,
+		})
+	}
+	for _, completion := range completions {
+		timeline = append(timeline, dto.AnomalyTimelineEntry{
+			At:       completion.CompletedAt,
+			Kind:     anomalyTimelineCompletion,
+			LessonID: completion.LessonID,
+			Score:    completion.Score,
+		})
+	}
+	for _, flag := range flags {
+		timeline = append(timeline, dto.AnomalyTimelineEntry{
+			At:       flag.CreatedAt,
+			Kind:     anomalyTimelineCompletionFlag,
+			LessonID: flag.LessonID,
+			Detail: fmt.Sprintf("%s, %ds reported of %ds needed",
+				strings.ReplaceAll(flag.Reasons, ",", ", "), flag.TimeSpent, flag.MinDuration),
+		})
+	}
+
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return timeline[i].At.Before(timeline[j].At)
+	})
+	return timeline
+}
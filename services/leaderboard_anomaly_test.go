@@ -0,0 +1,37 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/model"
+)
+
+func TestAnomalyTimeline(t *testing.T) {
+	at := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	transactions := []model.XPTransaction{
+		{Source: model.XPSourceLesson, Amount: 90, CreatedAt: at},
+		{Source: model.XPSourceBattle, Amount: 40, CreatedAt: at.Add(-time.Hour)},
+	}
+	completions := []model.UserLessonCompletion{{LessonID: "l1", Score: 100, CompletedAt: at}}
+	flags := []model.CompletionFlag{{LessonID: "l1", Reasons: "reported_duration,burst", TimeSpent: 3, MinDuration: 80, CreatedAt: at.Add(-time.Minute)}}
+
+	timeline := anomalyTimeline(transactions, completions, flags)
+
+	var kinds []string
+	for _, entry := range timeline {
+		kinds = append(kinds, entry.Kind+":"+entry.Source)
+	}
+	want := []string{"xp:battle", "completion_flag:", "xp:lesson", "lesson_completion:"}
+	if len(kinds) != len(want) {
+		t.Fatalf("timeline = %v, want %v", kinds, want)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Fatalf("timeline = %v, want %v", kinds, want)
+		}
+	}
+	if detail := timeline[1].Detail; detail != "reported_duration, burst, 3s reported of 80s needed" {
+		t.Errorf("flag detail = %q", detail)
+	}
+}
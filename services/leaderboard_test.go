@@ -0,0 +1,58 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/services/repositories/mocks"
+)
+
+func TestCollectionLeaderboard(t *testing.T) {
+	entry := func(userID string, collected int, percent float64) model.LeaderboardEntry {
+		return model.LeaderboardEntry{
+			UserProgress:        model.UserProgress{UserID: userID, XP: 100},
+			SpiritStage:         2,
+			CollectedCharacters: collected,
+			CollectionPercent:   percent,
+		}
+	}
+	svc := &UserService{
+		progressRepo: &mocks.ProgressRepo{
+			GetRankedLeaderboardFunc: func(leaderboardType string, since time.Time, limit int) ([]model.LeaderboardEntry, error) {
+				if leaderboardType != model.LeaderboardTypeCollection || since.IsZero() {
+					t.Errorf("ranked %s leaderboard since %v", leaderboardType, since)
+				}
+				return []model.LeaderboardEntry{entry("u1", 20, 50), entry("u2", 10, 25)}, nil
+			},
+			GetLeaderboardProfilesFunc: func(userIDs []string) ([]model.LeaderboardProfile, error) {
+				return []model.LeaderboardProfile{
+					{UserID: "u1", Username: "ngoquyen", Visibility: model.LeaderboardVisibilityPublic, SpiritStage: 4},
+					{UserID: "u2", Username: "tranhungdao", Visibility: model.LeaderboardVisibilityAnonymous},
+					{UserID: "me", Username: "me", Visibility: model.LeaderboardVisibilityPublic},
+				}, nil
+			},
+			GetLeaderboardEntryFunc: func(leaderboardType, userID string, since time.Time) (*model.LeaderboardEntry, int, error) {
+				e := entry(userID, 4, 10)
+				return &e, 7, nil
+			},
+		},
+	}
+
+	resp, err := svc.GetWeeklyLeaderboard(model.LeaderboardTypeCollection, 2, "me")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Type != model.LeaderboardTypeCollection || len(resp.TopUsers) != 2 {
+		t.Fatalf("leaderboard = %+v", resp)
+	}
+	if top := resp.TopUsers[0]; top.CollectionPercent != 50 || top.SpiritStage != 2 || top.Rank != 1 {
+		t.Errorf("top user = %+v", top)
+	}
+	if second := resp.TopUsers[1]; !second.Anonymous || second.CollectedCharacters != 10 {
+		t.Errorf("anonymous user = %+v", second)
+	}
+	if me := resp.CurrentUser; me.Rank != 7 || me.CollectedCharacters != 4 {
+		t.Errorf("current user = %+v", me)
+	}
+}
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"errors"
+	"html"
+	"io"
+	"mime/multipart"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Subtitle files larger than this are not turned into a transcript
+const maxTranscriptSubtitleSize = 2 * 1024 * 1024
+
+var (
+	// WebVTT voice spans, <v Speaker> or <v.class Speaker>, become "Speaker: "
+	subtitleVoiceTag = regexp.MustCompile(`<v(?:\.[^ >]*)? ([^>]+)>`)
+	subtitleTag      = regexp.MustCompile(`<[^>]*>`)
+	// ASS override blocks such as {\i1} or {\an8}, which SRT files sometimes carry too
+	subtitleOverride = regexp.MustCompile(`\{\\[^}]*\}`)
+)
+
+// lessonAccessibility describes the accessibility aids a lesson has
+func lessonAccessibility(lesson *model.Lesson) dto.LessonAccessibility {
+	return dto.LessonAccessibility{
+		Captions:            lesson.SubtitleURL != "",
+		CaptionsURL:         lesson.SubtitleURL,
+		Transcript:          lesson.Transcript,
+		TranscriptSource:    lesson.TranscriptSource,
+		AudioDescription:    lesson.AudioDescriptionURL != "",
+		AudioDescriptionURL: lesson.AudioDescriptionURL,
+	}
+}
+
+// UploadLessonSubtitle stores a lesson's subtitles and, unless an admin uploaded a
+// transcript, rebuilds the transcript from them
+func (svc *MediaService) UploadLessonSubtitle(adminID, lessonID string, file *multipart.FileHeader) (*dto.MediaUploadResponse, error) {
+	if !svc.isValidSubtitleFile(file.Filename) {
+		return nil, shared.NewBadRequestError(nil, "Invalid subtitle file format. Supported: VTT, SRT")
+	}
+
+	lesson, err := svc.sqlSvc.contentRepo.GetLesson(lessonID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Lesson not found")
+	}
+	before := *lesson
+
+	response, err := svc.uploadFile(adminID, file, "subtitle", lessonID)
+	if err != nil {
+		return nil, err
+	}
+
+	svc.purgeReplacedURL(lesson.SubtitleURL)
+	lesson.SubtitleURL = response.URL
+	if lesson.TranscriptSource != model.TranscriptSourceUploaded {
+		src, err := file.Open()
+		if err != nil {
+			return nil, shared.NewInternalError(err, "Failed to read subtitle file")
+		}
+		defer src.Close()
+		setGeneratedTranscript(lesson, src, file.Filename)
+	}
+
+	if err := svc.sqlSvc.contentRepo.UpdateLesson(lesson); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to update lesson")
+	}
+	svc.contentSvc.RecordContentAudit(adminID, model.ContentEntityLesson, lesson.ID, model.ContentActionUpdate, before, lesson)
+
+	return response, nil
+}
+
+// UploadAudioDescription stores the audio description track of a lesson, a narration
+// of what happens on screen for blind and low-vision learners
+func (svc *MediaService) UploadAudioDescription(adminID, lessonID string, file *multipart.FileHeader) (*dto.MediaUploadResponse, error) {
+	if !svc.isValidAudioFile(file.Filename) {
+		return nil, shared.NewBadRequestError(nil, "Invalid audio file format. Supported: MP3, WAV, AAC, M4A")
+	}
+
+	if file.Size > 50*1024*1024 {
+		return nil, shared.NewPayloadTooLargeError(nil, "Audio description file too large. Maximum size: 50MB")
+	}
+
+	lesson, err := svc.sqlSvc.contentRepo.GetLesson(lessonID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Lesson not found")
+	}
+	before := *lesson
+
+	response, err := svc.uploadFile(adminID, file, shared.MediaTypeAudioDescription, lessonID)
+	if err != nil {
+		return nil, err
+	}
+
+	svc.purgeReplacedURL(lesson.AudioDescriptionURL)
+	lesson.AudioDescriptionURL = response.URL
+	if err := svc.sqlSvc.contentRepo.UpdateLesson(lesson); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to update lesson")
+	}
+	svc.contentSvc.RecordContentAudit(adminID, model.ContentEntityLesson, lesson.ID, model.ContentActionUpdate, before, lesson)
+
+	return response, nil
+}
+
+// UpdateLessonTranscript replaces a lesson's transcript with one written by an admin.
+// An empty transcript goes back to the one generated from the latest subtitles.
+func (svc *MediaService) UpdateLessonTranscript(adminID, lessonID string, req dto.UpdateLessonTranscriptRequest) (*dto.LessonAccessibility, error) {
+	lesson, err := svc.sqlSvc.contentRepo.GetLesson(lessonID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Lesson not found")
+	}
+	before := *lesson
+
+	if transcript := strings.TrimSpace(req.Transcript); transcript != "" {
+		lesson.Transcript = transcript
+		lesson.TranscriptSource = model.TranscriptSourceUploaded
+	} else if err := svc.regenerateTranscript(lesson); err != nil {
+		return nil, err
+	}
+
+	if err := svc.sqlSvc.contentRepo.UpdateLesson(lesson); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to update lesson")
+	}
+	svc.contentSvc.RecordContentAudit(adminID, model.ContentEntityLesson, lesson.ID, model.ContentActionUpdate, before, lesson)
+
+	accessibility := lessonAccessibility(lesson)
+	return &accessibility, nil
+}
+
+// regenerateTranscript rebuilds the transcript from the lesson's latest subtitles, or
+// clears it when the lesson has none
+func (svc *MediaService) regenerateTranscript(lesson *model.Lesson) error {
+	lesson.Transcript = ""
+	lesson.TranscriptSource = ""
+
+	subtitle, err := svc.sqlSvc.mediaRepo.GetLatestLessonMediaByType(lesson.ID, "subtitle")
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return shared.NewInternalError(err, "Failed to get lesson subtitles")
+	}
+
+	asset := subtitle.MediaAsset
+	object, err := svc.minioSvc.GetFile(asset.Bucket, asset.StoragePath)
+	if err != nil {
+		return shared.NewInternalError(err, "Failed to read subtitle file")
+	}
+	defer object.Close()
+
+	setGeneratedTranscript(lesson, object, asset.FileName)
+	return nil
+}
+
+// setGeneratedTranscript sets the transcript read from a subtitle file. Files that can't
+// be read leave the lesson without one rather than failing the upload.
+func setGeneratedTranscript(lesson *model.Lesson, src io.Reader, fileName string) {
+	data, err := io.ReadAll(io.LimitReader(src, maxTranscriptSubtitleSize+1))
+	if err != nil || len(data) > maxTranscriptSubtitleSize {
+		log.Printf("Skipped transcript of lesson %s: subtitles unreadable or too large", lesson.ID)
+		lesson.Transcript, lesson.TranscriptSource = "", ""
+		return
+	}
+
+	lesson.Transcript = subtitleTranscript(data, fileName)
+	lesson.TranscriptSource = ""
+	if lesson.Transcript != "" {
+		lesson.TranscriptSource = model.TranscriptSourceSubtitles
+	}
+}
+
+// subtitleTranscript turns a VTT, SRT, ASS or SSA file into plain text with one line
+// per cue. Timings, cue numbers, styling and repeats of the previous cue are dropped.
+func subtitleTranscript(data []byte, fileName string) string {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	text = strings.TrimPrefix(text, "\ufeff")
+
+	var cues []string
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".ass", ".ssa":
+		cues = assCues(text)
+	default:
+		cues = timedCues(text)
+	}
+
+	var lines []string
+	for _, cue := range cues {
+		cue = subtitleVoiceTag.ReplaceAllString(cue, "$1: ")
+		cue = subtitleTag.ReplaceAllString(cue, "")
+		cue = subtitleOverride.ReplaceAllString(cue, "")
+		cue = strings.Join(strings.Fields(html.UnescapeString(cue)), " ")
+		if cue != "" && (len(lines) == 0 || lines[len(lines)-1] != cue) {
+			lines = append(lines, cue)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// timedCues reads VTT and SRT cues: the text lines after each timing line
+func timedCues(text string) []string {
+	var cues []string
+	for _, block := range strings.Split(text, "\n\n") {
+		lines := strings.Split(strings.TrimSpace(block), "\n")
+		for i, line := range lines {
+			if strings.Contains(line, "-->") {
+				cues = append(cues, strings.Join(lines[i+1:], " "))
+				break
+			}
+		}
+	}
+	return cues
+}
+
+// assCues reads the text field of ASS and SSA dialogue lines, the last of ten fields
+func assCues(text string) []string {
+	var cues []string
+	for _, line := range strings.Split(text, "\n") {
+		fields, ok := strings.CutPrefix(strings.TrimSpace(line), "Dialogue:")
+		if !ok {
+			continue
+		}
+		parts := strings.SplitN(fields, ",", 10)
+		if len(parts) < 10 {
+			continue
+		}
+		cue := strings.NewReplacer(`\N`, " ", `\n`, " ", `\h`, " ").Replace(parts[9])
+		cues = append(cues, cue)
+	}
+	return cues
+}
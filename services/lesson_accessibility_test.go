@@ -0,0 +1,44 @@
+package services
+
+import "testing"
+
+func TestSubtitleTranscript(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		data     string
+		want     string
+	}{
+		{
+			name:     "vtt",
+			fileName: "lesson.vtt",
+			data: "\ufeffWEBVTT\r\n\r\nNOTE recorded in Hà Nội\r\n\r\nintro\r\n00:00.000 --> 00:03.000\r\n" +
+				"<v Người kể>Năm 938, <i>Ngô Quyền</i></v>\r\nđánh tan quân Nam Hán.\r\n\r\n" +
+				"00:03.000 --> 00:05.000\r\nTrận Bạch Đằng &amp; cọc gỗ.\r\n",
+			want: "Người kể: Năm 938, Ngô Quyền đánh tan quân Nam Hán.\nTrận Bạch Đằng & cọc gỗ.",
+		},
+		{
+			name:     "srt with repeated cue",
+			fileName: "lesson.SRT",
+			data: "1\n00:00:00,000 --> 00:00:02,000\n{\\an8}Hai Bà Trưng\n\n" +
+				"2\n00:00:02,000 --> 00:00:03,000\nHai Bà Trưng\n\n3\n00:00:03,000 --> 00:00:04,000\n<b>khởi nghĩa</b>\n",
+			want: "Hai Bà Trưng\nkhởi nghĩa",
+		},
+		{
+			name:     "ass",
+			fileName: "lesson.ass",
+			data: "[Events]\nFormat: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n" +
+				"Dialogue: 0,0:00:00.00,0:00:02.00,Default,,0,0,0,,{\\i1}Lý Thường Kiệt{\\i0}\\Nviết Nam quốc sơn hà, bài thơ\n",
+			want: "Lý Thường Kiệt viết Nam quốc sơn hà, bài thơ",
+		},
+		{"empty", "lesson.vtt", "WEBVTT\n", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := subtitleTranscript([]byte(tt.data), tt.fileName); got != tt.want {
+				t.Errorf("subtitleTranscript = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"slices"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/services/repositories"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ==================== LESSON AID METHODS ====================
+
+// UseFiftyFifty takes one wrong option out of an unanswered multiple choice question of
+// the lesson the user is playing. It costs half a heart or coins, and the game config
+// limits how many a lesson attempt may use. Asking again for the same question returns
+// the option removed the first time without charging again.
+func (svc *ContentService) UseFiftyFifty(userID, lessonID, questionID string, req dto.UseLessonAidRequest) (*dto.FiftyFiftyResponse, error) {
+	lesson, err := svc.contentRepo.GetLesson(lessonID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Lesson not found")
+	}
+	if err := checkLessonActive(lesson); err != nil {
+		return nil, err
+	}
+
+	var questions []model.Question
+	if len(lesson.Questions) > 0 {
+		if err := json.Unmarshal(lesson.Questions, &questions); err != nil {
+			return nil, shared.NewInternalError(err, "Failed to parse lesson questions")
+		}
+	}
+	index := slices.IndexFunc(questions, func(q model.Question) bool { return q.ID == questionID })
+	if index < 0 {
+		return nil, shared.NewNotFoundError(nil, "Question not found")
+	}
+	question := questions[index]
+	if question.Type != "multiple_choice" {
+		return nil, shared.NewBadRequestError(nil, "Fifty-fifty only works on multiple choice questions")
+	}
+
+	// With a single wrong option, taking it out would give the answer away
+	wrongOptions := svc.wrongOptions(question)
+	if len(wrongOptions) < 2 {
+		return nil, shared.NewBadRequestError(nil, "This question has too few options for a fifty-fifty")
+	}
+
+	answers, err := svc.progressRepo.GetUserQuestionAnswers(userID, lessonID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get lesson answers")
+	}
+	if slices.ContainsFunc(answers, func(a model.UserQuestionAnswer) bool { return a.QuestionID == questionID }) {
+		return nil, shared.NewBadRequestError(nil, "Question already answered")
+	}
+
+	config := svc.lessonAidConfig()
+	if config.FiftyFiftyPerLesson <= 0 {
+		return nil, shared.NewForbiddenError(nil, "Fifty-fifty is turned off")
+	}
+
+	session, err := svc.touchLessonSession(userID, lessonID)
+	if err != nil {
+		return nil, err
+	}
+
+	use, created, err := svc.progressRepo.UseLessonAid(&model.LessonAidUse{
+		UserID:           userID,
+		LessonID:         lessonID,
+		SessionStartedAt: session.StartedAt,
+		QuestionID:       questionID,
+		Aid:              model.LessonAidFiftyFifty,
+		PaidWith:         req.PayWith,
+		RemovedOption:    wrongOptions[rand.Intn(len(wrongOptions))],
+	}, config.FiftyFiftyPerLesson, config.FiftyFiftyCoinPrice)
+	switch {
+	case errors.Is(err, repositories.ErrAidLimitReached):
+		return nil, shared.NewBadRequestError(err, "No fifty-fifty left for this lesson")
+	case errors.Is(err, repositories.ErrNoHearts):
+		return nil, shared.NewBadRequestError(err, "Not enough hearts")
+	case errors.Is(err, repositories.ErrInsufficientCoins):
+		return nil, shared.NewBadRequestError(err, "Not enough coins")
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, shared.NewNotFoundError(err, "User progress not found")
+	case err != nil:
+		return nil, shared.NewInternalError(err, "Failed to use fifty-fifty")
+	}
+
+	if created {
+		log.Printf("User %s used a fifty-fifty on %s/%s for %d %s", userID, lessonID, questionID, use.Cost, use.PaidWith)
+		if use.PaidWith == model.AidPaymentHearts {
+			svc.eventBusSvc.Publish(&ProgressChangedEvent{UserID: userID, Reason: "lesson_aid"})
+		}
+	}
+
+	progress, err := svc.progressRepo.GetUserProgress(userID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "User progress not found")
+	}
+	wallet, err := svc.sqlSvc.shopRepo.GetWallet(userID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get wallet")
+	}
+	aids, err := svc.lessonAids(session, progress, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.FiftyFiftyResponse{
+		QuestionID:    use.QuestionID,
+		RemovedOption: use.RemovedOption,
+		PaidWith:      use.PaidWith,
+		Cost:          use.Cost,
+		Charged:       created,
+		Hearts:        progress.Hearts,
+		Coins:         wallet.Balance,
+		Aids:          *aids,
+	}, nil
+}
+
+// wrongOptions lists the options of a multiple choice question that are not its answer
+func (svc *ContentService) wrongOptions(question model.Question) []string {
+	var wrong []string
+	for _, option := range question.Options {
+		if !svc.isAnswerCorrect(question, option) {
+			wrong = append(wrong, option)
+		}
+	}
+	return wrong
+}
+
+// lessonAids reports the aids used in a lesson session and how many are left
+func (svc *ContentService) lessonAids(session *model.LessonSession, progress *model.UserProgress, config *model.GameConfig) (*dto.LessonAidsResponse, error) {
+	uses, err := svc.progressRepo.GetLessonAidUses(session.UserID, session.LessonID, session.StartedAt)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get lesson aids")
+	}
+
+	aids := &dto.LessonAidsResponse{
+		FiftyFiftyLimit:     config.FiftyFiftyPerLesson,
+		FiftyFiftyCoinPrice: config.FiftyFiftyCoinPrice,
+		HalfHeartBanked:     progress != nil && progress.HalfHeartBanked,
+		Used:                make([]dto.LessonAidUseResponse, len(uses)),
+	}
+	fiftyFifties := 0
+	for i, use := range uses {
+		if use.Aid == model.LessonAidFiftyFifty {
+			fiftyFifties++
+		}
+		aids.Used[i] = dto.LessonAidUseResponse{
+			QuestionID:    use.QuestionID,
+			Aid:           use.Aid,
+			RemovedOption: use.RemovedOption,
+		}
+	}
+	aids.FiftyFiftyRemaining = max(0, config.FiftyFiftyPerLesson-fiftyFifties)
+	return aids, nil
+}
+
+// lessonAidConfig loads the game config, falling back to the defaults so lessons keep
+// working when it can't be read
+func (svc *ContentService) lessonAidConfig() *model.GameConfig {
+	config, err := svc.contentRepo.GetGameConfig()
+	if err != nil {
+		log.WithError(err).Warn("Failed to load game config, using default lesson aid limits")
+		defaults := model.DefaultGameConfig()
+		return &defaults
+	}
+	return config
+}
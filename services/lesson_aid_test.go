@@ -0,0 +1,56 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/services/repositories/mocks"
+)
+
+func TestWrongOptions(t *testing.T) {
+	svc := &ContentService{}
+	question := model.Question{
+		Type:    "multiple_choice",
+		Options: []string{"Ngô Quyền", "Trần Hưng Đạo", "Lý Thường Kiệt", "Lê Lợi"},
+		Answer:  "trần hưng đạo",
+	}
+
+	want := []string{"Ngô Quyền", "Lý Thường Kiệt", "Lê Lợi"}
+	if got := svc.wrongOptions(question); !reflect.DeepEqual(got, want) {
+		t.Errorf("wrongOptions = %v, want %v", got, want)
+	}
+}
+
+func TestLessonAids(t *testing.T) {
+	started := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	svc := &ContentService{
+		progressRepo: &mocks.ProgressRepo{
+			GetLessonAidUsesFunc: func(userID, lessonID string, sessionStartedAt time.Time) ([]model.LessonAidUse, error) {
+				if !sessionStartedAt.Equal(started) {
+					t.Errorf("aids loaded for session started %v, want %v", sessionStartedAt, started)
+				}
+				return []model.LessonAidUse{
+					{QuestionID: "q_1", Aid: model.LessonAidFiftyFifty, RemovedOption: "Ngô Quyền"},
+				}, nil
+			},
+		},
+	}
+	config := model.DefaultGameConfig()
+	session := &model.LessonSession{UserID: "user", LessonID: "lesson", StartedAt: started}
+
+	aids, err := svc.lessonAids(session, &model.UserProgress{HalfHeartBanked: true}, &config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aids.FiftyFiftyRemaining != config.FiftyFiftyPerLesson-1 || !aids.HalfHeartBanked || len(aids.Used) != 1 {
+		t.Errorf("lessonAids = %+v", aids)
+	}
+
+	// Users without progress have nothing banked
+	aids, err = svc.lessonAids(session, nil, &config)
+	if err != nil || aids.HalfHeartBanked {
+		t.Errorf("lessonAids without progress = %+v, %v", aids, err)
+	}
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"slices"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+)
+
+const (
+	// Time a learner needs for one question, on top of watching the video
+	estimatedSecondsPerQuestion = 30
+
+	// Question load at which a lesson stops being easy or medium. Questions where the
+	// learner recalls or arranges the answer weigh more than picking an option, and
+	// every two minutes of video counts as one more question.
+	mediumLessonLoad = 6
+	hardLessonLoad   = 11
+)
+
+// questionLoadWeights is how much harder than a multiple choice question each type is
+var questionLoadWeights = map[string]float64{
+	"multiple_choice": 1,
+	"connect":         1.5,
+	"drag_drop":       1.5,
+	"fill_blank":      2,
+}
+
+// computeLessonDifficulty works out a difficulty from the questions and the video length
+func computeLessonDifficulty(questions []model.Question, videoSeconds int) string {
+	load := float64(videoSeconds) / 120
+	for _, question := range questions {
+		weight, ok := questionLoadWeights[question.Type]
+		if !ok {
+			weight = 1
+		}
+		load += weight
+	}
+
+	switch {
+	case load >= hardLessonLoad:
+		return model.LessonDifficultyHard
+	case load >= mediumLessonLoad:
+		return model.LessonDifficultyMedium
+	default:
+		return model.LessonDifficultyEasy
+	}
+}
+
+// computeLessonMinutes estimates how long a lesson takes, rounded up to whole minutes
+func computeLessonMinutes(questionCount, videoSeconds int) int {
+	seconds := videoSeconds + questionCount*estimatedSecondsPerQuestion
+	return max((seconds+59)/60, 1)
+}
+
+// lessonDifficulty returns the lesson's difficulty and estimated minutes, using the
+// admin overrides where set. The video is only looked up when something is computed.
+func (svc *ContentService) lessonDifficulty(lesson *model.Lesson, questions []model.Question) (string, int) {
+	difficulty, minutes := lesson.Difficulty, lesson.EstimatedMinutes
+	if difficulty != "" && minutes > 0 {
+		return difficulty, minutes
+	}
+
+	videoSeconds := svc.lessonVideoDuration(lesson.ID)
+	if difficulty == "" {
+		difficulty = computeLessonDifficulty(questions, videoSeconds)
+	}
+	if minutes <= 0 {
+		minutes = computeLessonMinutes(len(questions), videoSeconds)
+	}
+	return difficulty, minutes
+}
+
+// UpdateLessonDifficulty overrides a lesson's difficulty and estimated minutes. An empty
+// difficulty or zero minutes goes back to the computed value.
+func (svc *ContentService) UpdateLessonDifficulty(adminID, lessonID string, req dto.UpdateLessonDifficultyRequest) (*model.Lesson, error) {
+	if req.Difficulty != "" && !slices.Contains(model.LessonDifficulties, req.Difficulty) {
+		return nil, shared.NewBadRequestError(nil, "Invalid lesson difficulty")
+	}
+
+	lesson, err := svc.contentRepo.GetLesson(lessonID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Lesson not found")
+	}
+	before := *lesson
+
+	lesson.Difficulty = req.Difficulty
+	lesson.EstimatedMinutes = req.EstimatedMinutes
+	if err := svc.contentRepo.UpdateLesson(lesson); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to update lesson difficulty")
+	}
+
+	svc.RecordContentAudit(adminID, model.ContentEntityLesson, lesson.ID, model.ContentActionUpdate, before, lesson)
+	return lesson, nil
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/lac-hong-legacy/ven_api/model"
+)
+
+func TestComputeLessonDifficulty(t *testing.T) {
+	questions := func(types ...string) []model.Question {
+		result := make([]model.Question, len(types))
+		for i, questionType := range types {
+			result[i] = model.Question{Type: questionType}
+		}
+		return result
+	}
+
+	tests := []struct {
+		name         string
+		questions    []model.Question
+		videoSeconds int
+		want         string
+	}{
+		{"few choices", questions("multiple_choice", "multiple_choice", "multiple_choice"), 0, model.LessonDifficultyEasy},
+		{"recall questions", questions("fill_blank", "fill_blank", "fill_blank", "drag_drop"), 0, model.LessonDifficultyMedium},
+		{"long video", questions("multiple_choice", "multiple_choice"), 480, model.LessonDifficultyMedium},
+		{"many questions", questions("fill_blank", "fill_blank", "connect", "connect", "drag_drop", "drag_drop", "multiple_choice"), 120, model.LessonDifficultyHard},
+		{"no questions", nil, 0, model.LessonDifficultyEasy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := computeLessonDifficulty(tt.questions, tt.videoSeconds); got != tt.want {
+				t.Errorf("computeLessonDifficulty = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeLessonMinutes(t *testing.T) {
+	if got := computeLessonMinutes(0, 0); got != 1 {
+		t.Errorf("empty lesson = %d minutes, want 1", got)
+	}
+	// 3 minutes of video and 5 questions at 30 seconds is 5.5 minutes
+	if got := computeLessonMinutes(5, 180); got != 6 {
+		t.Errorf("computeLessonMinutes(5, 180) = %d, want 6", got)
+	}
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"math"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared/text"
+)
+
+// scoreLessonQuestions grades every question of a lesson. Each question is worth its
+// points times the weight of its category; wrong connect and drag_drop answers still
+// earn the share of pairs or positions they got right. isCorrect decides whether an
+// answer is fully right.
+func scoreLessonQuestions(questions []model.Question, userAnswers map[string]interface{}, config *model.GameConfig,
+	isCorrect func(model.Question, interface{}) bool) (float64, float64, []dto.QuestionScore) {
+	var earned, total float64
+	scores := make([]dto.QuestionScore, 0, len(questions))
+	for _, question := range questions {
+		score := dto.QuestionScore{
+			QuestionID: question.ID,
+			Type:       question.Type,
+			Category:   question.Category(),
+			Points:     question.Points,
+			Weight:     config.CategoryWeight(question.Category()),
+		}
+		score.MaxPoints = roundPoints(float64(question.Points) * score.Weight)
+
+		userAnswer, answered := userAnswers[question.ID]
+		score.Answered = answered && userAnswer != nil
+		if score.Answered {
+			if isCorrect(question, userAnswer) {
+				score.Credit = 1
+			} else {
+				score.Credit = partialCredit(question, userAnswer)
+			}
+		}
+		score.Correct = score.Credit == 1
+		score.EarnedPoints = roundPoints(score.MaxPoints * score.Credit)
+
+		earned += score.EarnedPoints
+		total += score.MaxPoints
+		scores = append(scores, score)
+	}
+	return roundPoints(earned), roundPoints(total), scores
+}
+
+// lessonScorePercent turns earned points into a whole percentage, rounded down like the
+// integer score it replaced
+func lessonScorePercent(earned, total float64) int {
+	if total <= 0 {
+		return 100
+	}
+	return int(math.Floor(earned*100/total + 1e-9))
+}
+
+// partialCredit returns the share of right pairs of a connect answer or right positions
+// of a drag_drop answer. Other types, and questions set to all or nothing, earn nothing
+// for a wrong answer.
+func partialCredit(question model.Question, userAnswer interface{}) float64 {
+	if question.AllOrNothing() {
+		return 0
+	}
+
+	switch question.Type {
+	case "drag_drop":
+		correct, ok1 := question.Answer.([]interface{})
+		given, ok2 := userAnswer.([]interface{})
+		if !ok1 || !ok2 || len(correct) == 0 {
+			return 0
+		}
+		right := 0
+		for i, item := range correct {
+			if i < len(given) && sameAnswerItem(given[i], item) {
+				right++
+			}
+		}
+		return float64(right) / float64(len(correct))
+	case "connect":
+		correct, ok1 := question.Answer.(map[string]interface{})
+		given, ok2 := userAnswer.(map[string]interface{})
+		if !ok1 || !ok2 || len(correct) == 0 {
+			return 0
+		}
+		right := 0
+		for left, target := range correct {
+			if sameAnswerItem(given[left], target) {
+				right++
+			}
+		}
+		return float64(right) / float64(len(correct))
+	}
+	return 0
+}
+
+func sameAnswerItem(given, expected interface{}) bool {
+	givenText, ok1 := given.(string)
+	expectedText, ok2 := expected.(string)
+	return ok1 && ok2 && text.Match(givenText, expectedText, text.MatchOptions{})
+}
+
+func roundPoints(points float64) float64 {
+	return math.Round(points*100) / 100
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/lac-hong-legacy/ven_api/model"
+)
+
+func TestScoreLessonQuestions(t *testing.T) {
+	var questions []model.Question
+	err := json.Unmarshal([]byte(`[
+		{"id":"mc","type":"multiple_choice","answer":"A","points":10},
+		{"id":"order","type":"drag_drop","answer":["a","b","c","d"],"points":20},
+		{"id":"pairs","type":"connect","answer":{"Văn Lang":"Vương quốc","Hùng Vương":"Vua"},"points":10,
+			"metadata":{"category":"comprehension"}},
+		{"id":"strict","type":"connect","answer":{"x":"1","y":"2"},"points":10,"metadata":{"all_or_nothing":true}}
+	]`), &questions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	answers := map[string]interface{}{
+		"mc":     "A",
+		"order":  []interface{}{"a", "c", "b", "d"},
+		"pairs":  map[string]interface{}{"Văn Lang": "vương quốc", "Hùng Vương": "Vương quốc"},
+		"strict": map[string]interface{}{"x": "1", "y": "1"},
+	}
+	config := model.DefaultGameConfig()
+	isCorrect := func(question model.Question, answer interface{}) bool {
+		encodedAnswer, _ := json.Marshal(answer)
+		encodedCorrect, _ := json.Marshal(question.Answer)
+		return string(encodedAnswer) == string(encodedCorrect)
+	}
+
+	earned, total, scores := scoreLessonQuestions(questions, answers, &config, isCorrect)
+
+	// 10 + 20*2/4 + 10*1.5/2 + 0 out of 10 + 20 + 15 + 10
+	if earned != 27.5 || total != 55 {
+		t.Errorf("earned %v of %v, want 27.5 of 55", earned, total)
+	}
+	if got := lessonScorePercent(earned, total); got != 50 {
+		t.Errorf("lessonScorePercent = %d, want 50", got)
+	}
+
+	wantCredit := map[string]float64{"mc": 1, "order": 0.5, "pairs": 0.5, "strict": 0}
+	for _, score := range scores {
+		if score.Credit != wantCredit[score.QuestionID] {
+			t.Errorf("%s credit = %v, want %v", score.QuestionID, score.Credit, wantCredit[score.QuestionID])
+		}
+		if score.Correct != (score.Credit == 1) {
+			t.Errorf("%s correct = %v with credit %v", score.QuestionID, score.Correct, score.Credit)
+		}
+	}
+	if scores[2].Category != model.QuestionCategoryComprehension || scores[2].MaxPoints != 15 {
+		t.Errorf("comprehension question = %+v, want weighted to 15 points", scores[2])
+	}
+}
+
+func TestScoreLessonQuestionsUnanswered(t *testing.T) {
+	questions := []model.Question{{ID: "q1", Type: "drag_drop", Answer: []interface{}{"a"}, Points: 10}}
+	config := model.DefaultGameConfig()
+
+	earned, total, scores := scoreLessonQuestions(questions, map[string]interface{}{}, &config,
+		func(model.Question, interface{}) bool { return true })
+	if earned != 0 || total != 10 || scores[0].Answered {
+		t.Errorf("earned %v of %v, scores %+v, want nothing for an unanswered question", earned, total, scores)
+	}
+	if got := lessonScorePercent(0, 0); got != 100 {
+		t.Errorf("lessonScorePercent of a lesson without points = %d, want 100", got)
+	}
+}
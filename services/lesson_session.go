@@ -0,0 +1,202 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	"gorm.io/gorm"
+)
+
+// A lesson left alone for longer than this starts over, its answers are discarded
+const lessonSessionTTL = 24 * time.Hour
+
+// ==================== LESSON SESSION METHODS ====================
+
+// GetLessonSession returns the lesson the user left unfinished: the questions answered so
+// far, the video position and how long the session lasts before it starts over.
+func (svc *ContentService) GetLessonSession(userID, lessonID string) (*dto.LessonSessionResponse, error) {
+	lesson, err := svc.contentRepo.GetLesson(lessonID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Lesson not found")
+	}
+	if err := checkLessonActive(lesson); err != nil {
+		return nil, err
+	}
+
+	session, err := svc.progressRepo.GetLessonSession(userID, lessonID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && lessonSessionExpired(session, time.Now())) {
+		return nil, shared.NewNotFoundError(err, "No lesson in progress")
+	}
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get lesson session")
+	}
+
+	return svc.lessonSessionResponse(session, lesson)
+}
+
+// UpdateLessonSession checkpoints the video position and time spent, starting a session
+// when the lesson has none. The position is kept within the lesson video.
+func (svc *ContentService) UpdateLessonSession(userID, lessonID string, req dto.UpdateLessonSessionRequest) (*dto.LessonSessionResponse, error) {
+	lesson, err := svc.contentRepo.GetLesson(lessonID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Lesson not found")
+	}
+	if err := checkLessonActive(lesson); err != nil {
+		return nil, err
+	}
+
+	session, err := svc.touchLessonSession(userID, lessonID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.VideoPositionSeconds != nil {
+		session.VideoPositionSeconds = *req.VideoPositionSeconds
+		if duration := svc.lessonVideoDuration(lessonID); duration > 0 && session.VideoPositionSeconds > duration {
+			session.VideoPositionSeconds = duration
+		}
+	}
+	if req.TimeSpentSeconds != nil && *req.TimeSpentSeconds > session.TimeSpentSeconds {
+		session.TimeSpentSeconds = *req.TimeSpentSeconds
+	}
+	if err := svc.progressRepo.SaveLessonSession(session); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to save lesson session")
+	}
+
+	return svc.lessonSessionResponse(session, lesson)
+}
+
+// RestartLessonSession discards the lesson in progress with its answers
+func (svc *ContentService) RestartLessonSession(userID, lessonID string) error {
+	if err := svc.progressRepo.ResetLessonSession(userID, lessonID); err != nil {
+		return shared.NewInternalError(err, "Failed to restart lesson")
+	}
+	return nil
+}
+
+// touchLessonSession marks the user active in the lesson. A session that expired is
+// reset first, so answers from an abandoned attempt don't carry over.
+func (svc *ContentService) touchLessonSession(userID, lessonID string) (*model.LessonSession, error) {
+	now := time.Now()
+	session, err := svc.progressRepo.GetLessonSession(userID, lessonID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, shared.NewInternalError(err, "Failed to get lesson session")
+	}
+
+	if err == nil && lessonSessionExpired(session, now) {
+		if err := svc.progressRepo.ResetLessonSession(userID, lessonID); err != nil {
+			return nil, shared.NewInternalError(err, "Failed to reset lesson session")
+		}
+		session = nil
+	}
+	if session == nil {
+		session = &model.LessonSession{
+			UserID:    userID,
+			LessonID:  lessonID,
+			StartedAt: now,
+		}
+	}
+	session.LastActiveAt = now
+
+	if err := svc.progressRepo.SaveLessonSession(session); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to save lesson session")
+	}
+	return session, nil
+}
+
+func (svc *ContentService) lessonSessionResponse(session *model.LessonSession, lesson *model.Lesson) (*dto.LessonSessionResponse, error) {
+	var questions []model.Question
+	if len(lesson.Questions) > 0 {
+		if err := json.Unmarshal(lesson.Questions, &questions); err != nil {
+			return nil, shared.NewInternalError(err, "Failed to parse lesson questions")
+		}
+	}
+
+	answers, err := svc.progressRepo.GetUserQuestionAnswers(session.UserID, session.LessonID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get lesson answers")
+	}
+
+	status, err := svc.CheckLessonStatus(session.UserID, session.LessonID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get lesson status")
+	}
+
+	progress, err := svc.progressRepo.GetUserProgress(session.UserID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, shared.NewInternalError(err, "Failed to get user progress")
+	}
+	aids, err := svc.lessonAids(session, progress, svc.lessonAidConfig())
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := session.LastActiveAt.Add(lessonSessionTTL)
+	sessionAnswers, nextQuestionID := lessonSessionAnswers(questions, answers)
+
+	return &dto.LessonSessionResponse{
+		LessonID:             session.LessonID,
+		StartedAt:            session.StartedAt,
+		LastActiveAt:         session.LastActiveAt,
+		ExpiresAt:            expiresAt,
+		RemainingSeconds:     max(0, int(time.Until(expiresAt).Seconds())),
+		VideoPositionSeconds: session.VideoPositionSeconds,
+		VideoDurationSeconds: svc.lessonVideoDuration(session.LessonID),
+		TimeSpentSeconds:     session.TimeSpentSeconds,
+		Answers:              sessionAnswers,
+		NextQuestionID:       nextQuestionID,
+		Status:               *status,
+		Aids:                 *aids,
+	}, nil
+}
+
+// lessonVideoDuration is the length in seconds of the lesson's animation, 0 when unknown
+func (svc *ContentService) lessonVideoDuration(lessonID string) int {
+	media, err := svc.sqlSvc.mediaRepo.GetLessonMediaByType(lessonID, "animation")
+	if err != nil {
+		return 0
+	}
+	return media.MediaAsset.Duration
+}
+
+func lessonSessionExpired(session *model.LessonSession, now time.Time) bool {
+	return now.Sub(session.LastActiveAt) > lessonSessionTTL
+}
+
+// lessonSessionAnswers lists the stored answers in question order, leaving out answers to
+// questions the lesson no longer has, and returns the first unanswered question
+func lessonSessionAnswers(questions []model.Question, answers []model.UserQuestionAnswer) ([]dto.LessonSessionAnswer, string) {
+	byQuestion := make(map[string]*model.UserQuestionAnswer, len(answers))
+	for i := range answers {
+		byQuestion[answers[i].QuestionID] = &answers[i]
+	}
+
+	result := []dto.LessonSessionAnswer{}
+	nextQuestionID := ""
+	for _, question := range questions {
+		answer, ok := byQuestion[question.ID]
+		if !ok {
+			if nextQuestionID == "" {
+				nextQuestionID = question.ID
+			}
+			continue
+		}
+
+		var given interface{}
+		if err := json.Unmarshal([]byte(answer.Answer), &given); err != nil {
+			given = answer.Answer
+		}
+		result = append(result, dto.LessonSessionAnswer{
+			QuestionID: question.ID,
+			Answer:     given,
+			Correct:    answer.IsCorrect,
+			Points:     answer.Points,
+			AnsweredAt: answer.UpdatedAt,
+		})
+	}
+	return result, nextQuestionID
+}
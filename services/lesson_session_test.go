@@ -0,0 +1,45 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/model"
+)
+
+func TestLessonSessionAnswers(t *testing.T) {
+	questions := []model.Question{{ID: "q1"}, {ID: "q2"}, {ID: "q3"}}
+	answers := []model.UserQuestionAnswer{
+		{QuestionID: "q3", Answer: `["a","b"]`, Points: 5},
+		{QuestionID: "q1", Answer: `"938"`, IsCorrect: true, Points: 10},
+		{QuestionID: "removed", Answer: `"x"`},
+	}
+
+	got, next := lessonSessionAnswers(questions, answers)
+	if next != "q2" {
+		t.Errorf("next question = %q, want q2", next)
+	}
+	if len(got) != 2 || got[0].QuestionID != "q1" || got[1].QuestionID != "q3" {
+		t.Fatalf("answers = %+v, want q1 and q3 in question order", got)
+	}
+	if got[0].Answer != "938" || !got[0].Correct {
+		t.Errorf("q1 answer = %+v, want the decoded correct answer", got[0])
+	}
+	if items, ok := got[1].Answer.([]interface{}); !ok || len(items) != 2 {
+		t.Errorf("q3 answer = %#v, want a decoded list", got[1].Answer)
+	}
+
+	if _, next := lessonSessionAnswers(questions[:1], answers); next != "" {
+		t.Errorf("next question = %q with every question answered, want none", next)
+	}
+}
+
+func TestLessonSessionExpired(t *testing.T) {
+	now := time.Now()
+	if lessonSessionExpired(&model.LessonSession{LastActiveAt: now.Add(-time.Hour)}, now) {
+		t.Error("session active an hour ago expired")
+	}
+	if !lessonSessionExpired(&model.LessonSession{LastActiveAt: now.Add(-lessonSessionTTL - time.Minute)}, now) {
+		t.Error("session past its TTL did not expire")
+	}
+}
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+)
+
+// Texts with more lines than this on both sides are diffed as a whole, the line diff
+// needs memory for every pair of lines
+const lessonDiffMaxLinePairs = 4_000_000
+
+// ==================== LESSON VERSION METHODS ====================
+
+// GetLessonVersions lists the saved states of a lesson, taken from its content audit
+// trail. Changes made before the trail existed have no version.
+func (svc *ContentService) GetLessonVersions(lessonID string) (*dto.LessonVersionListResponse, error) {
+	if _, err := svc.contentRepo.GetLesson(lessonID); err != nil {
+		return nil, shared.NewNotFoundError(err, "Lesson not found")
+	}
+
+	snapshots, err := svc.contentRepo.GetLessonAuditSnapshots(lessonID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get lesson versions")
+	}
+
+	versions := make([]dto.LessonVersion, len(snapshots))
+	for i := range snapshots {
+		versions[i] = mapLessonVersion(i+1, &snapshots[i])
+	}
+
+	return &dto.LessonVersionListResponse{
+		LessonID: lessonID,
+		Versions: versions,
+	}, nil
+}
+
+// GetLessonVersionDiff compares a version of a lesson with the live lesson, field by
+// field for the story, media and settings and question by question. Media attached to
+// individual questions is not part of the snapshots and is not compared.
+func (svc *ContentService) GetLessonVersionDiff(lessonID string, version int) (*dto.LessonVersionDiffResponse, error) {
+	live, err := svc.contentRepo.GetLesson(lessonID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Lesson not found")
+	}
+
+	snapshots, err := svc.contentRepo.GetLessonAuditSnapshots(lessonID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get lesson versions")
+	}
+	if version < 1 || version > len(snapshots) {
+		return nil, shared.NewNotFoundError(nil, "Lesson version not found")
+	}
+
+	entry := &snapshots[version-1]
+	var saved model.Lesson
+	if err := json.Unmarshal(entry.After, &saved); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to read lesson version")
+	}
+
+	questions, err := diffLessonQuestions(saved.Questions, live.Questions)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to parse lesson questions")
+	}
+
+	response := &dto.LessonVersionDiffResponse{
+		LessonID:      lessonID,
+		Version:       mapLessonVersion(version, entry),
+		LiveUpdatedAt: live.UpdatedAt,
+		Story: lessonFieldChanges(
+			lessonField{"title", saved.Title, live.Title},
+			lessonField{"story", saved.Story, live.Story},
+			lessonField{"script", saved.Script, live.Script},
+			lessonField{"script_status", saved.ScriptStatus, live.ScriptStatus},
+			lessonField{"transcript", saved.Transcript, live.Transcript},
+		),
+		Questions: questions,
+		Media: lessonFieldChanges(
+			lessonField{"audio_url", saved.AudioURL, live.AudioURL},
+			lessonField{"audio_status", saved.AudioStatus, live.AudioStatus},
+			lessonField{"animation_url", saved.AnimationURL, live.AnimationURL},
+			lessonField{"animation_status", saved.AnimationStatus, live.AnimationStatus},
+			lessonField{"subtitle_url", saved.SubtitleURL, live.SubtitleURL},
+			lessonField{"has_subtitles", saved.HasSubtitles, live.HasSubtitles},
+			lessonField{"thumbnail_url", saved.ThumbnailURL, live.ThumbnailURL},
+			lessonField{"transcript_source", saved.TranscriptSource, live.TranscriptSource},
+			lessonField{"audio_description_url", saved.AudioDescriptionURL, live.AudioDescriptionURL},
+		),
+		Settings: lessonFieldChanges(
+			lessonField{"character_id", saved.CharacterID, live.CharacterID},
+			lessonField{"order", saved.Order, live.Order},
+			lessonField{"can_skip_after", saved.CanSkipAfter, live.CanSkipAfter},
+			lessonField{"content_rating", saved.ContentRating, live.ContentRating},
+			lessonField{"xp_reward", saved.XPReward, live.XPReward},
+			lessonField{"min_score", saved.MinScore, live.MinScore},
+			lessonField{"is_active", saved.IsActive, live.IsActive},
+		),
+	}
+	response.Identical = len(response.Story)+len(response.Questions)+len(response.Media)+len(response.Settings) == 0
+
+	return response, nil
+}
+
+func mapLessonVersion(version int, entry *model.ContentAuditLog) dto.LessonVersion {
+	var changedFields []string
+	if len(entry.ChangedFields) > 0 {
+		_ = json.Unmarshal(entry.ChangedFields, &changedFields)
+	}
+
+	return dto.LessonVersion{
+		Version:       version,
+		AuditLogID:    entry.ID,
+		AdminID:       entry.AdminID,
+		Action:        entry.Action,
+		ChangedFields: changedFields,
+		CreatedAt:     entry.CreatedAt,
+	}
+}
+
+type lessonField struct {
+	name          string
+	before, after interface{}
+}
+
+// lessonFieldChanges returns the fields whose values differ. Multi-line text also gets
+// a line diff.
+func lessonFieldChanges(fields ...lessonField) []dto.LessonFieldChange {
+	changes := []dto.LessonFieldChange{}
+	for _, field := range fields {
+		if reflect.DeepEqual(field.before, field.after) {
+			continue
+		}
+
+		change := dto.LessonFieldChange{Field: field.name, Before: field.before, After: field.after}
+		before, isText := field.before.(string)
+		after, _ := field.after.(string)
+		if isText && (strings.Contains(before, "\n") || strings.Contains(after, "\n")) {
+			change.Lines = diffLines(before, after)
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// diffLessonQuestions matches questions by ID. Changed and added questions come in live
+// order, followed by removed questions in the version's order. A question counts as
+// moved only when its order relative to the other kept questions changed, so adding or
+// removing a question does not mark every later one.
+func diffLessonQuestions(beforeJSON, afterJSON json.RawMessage) ([]dto.LessonQuestionChange, error) {
+	var before, after []model.Question
+	if len(beforeJSON) > 0 && string(beforeJSON) != "null" {
+		if err := json.Unmarshal(beforeJSON, &before); err != nil {
+			return nil, err
+		}
+	}
+	if len(afterJSON) > 0 && string(afterJSON) != "null" {
+		if err := json.Unmarshal(afterJSON, &after); err != nil {
+			return nil, err
+		}
+	}
+
+	beforeByID := make(map[string]*model.Question, len(before))
+	for i := range before {
+		beforeByID[before[i].ID] = &before[i]
+	}
+	afterByID := make(map[string]*model.Question, len(after))
+	for i := range after {
+		afterByID[after[i].ID] = &after[i]
+	}
+
+	// Positions among the questions both sides have
+	keptPosition := func(questions []model.Question, others map[string]*model.Question) map[string]int {
+		positions := make(map[string]int)
+		for _, question := range questions {
+			if _, ok := others[question.ID]; ok {
+				positions[question.ID] = len(positions) + 1
+			}
+		}
+		return positions
+	}
+	beforePositions := keptPosition(before, afterByID)
+	afterPositions := keptPosition(after, beforeByID)
+
+	changes := []dto.LessonQuestionChange{}
+	for i := range after {
+		question := &after[i]
+		original, ok := beforeByID[question.ID]
+		if !ok {
+			changes = append(changes, dto.LessonQuestionChange{QuestionID: question.ID, Change: "added", After: question})
+			continue
+		}
+
+		var fieldChanges []dto.QuestionFieldChange
+		compare := func(field string, before, after interface{}) {
+			if !reflect.DeepEqual(before, after) {
+				fieldChanges = append(fieldChanges, dto.QuestionFieldChange{Field: field, Before: before, After: after})
+			}
+		}
+		compare("position", beforePositions[question.ID], afterPositions[question.ID])
+		compare("type", original.Type, question.Type)
+		compare("question", original.Question, question.Question)
+		compare("options", original.Options, question.Options)
+		compare("answer", original.Answer, question.Answer)
+		compare("points", original.Points, question.Points)
+		compare("metadata", original.Metadata, question.Metadata)
+
+		if len(fieldChanges) > 0 {
+			changes = append(changes, dto.LessonQuestionChange{
+				QuestionID: question.ID,
+				Change:     "changed",
+				Before:     original,
+				After:      question,
+				Changes:    fieldChanges,
+			})
+		}
+	}
+
+	for i := range before {
+		if _, ok := afterByID[before[i].ID]; !ok {
+			changes = append(changes, dto.LessonQuestionChange{QuestionID: before[i].ID, Change: "removed", Before: &before[i]})
+		}
+	}
+
+	return changes, nil
+}
+
+// diffLines returns the lines removed from before and added in after, using the longest
+// common subsequence of lines. Very long texts return nil; the whole values are still
+// in the field change.
+func diffLines(before, after string) []dto.TextLineChange {
+	a := strings.Split(before, "\n")
+	b := strings.Split(after, "\n")
+	if len(a)*len(b) > lessonDiffMaxLinePairs {
+		return nil
+	}
+
+	// common[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else if common[i+1][j] >= common[i][j+1] {
+				common[i][j] = common[i+1][j]
+			} else {
+				common[i][j] = common[i][j+1]
+			}
+		}
+	}
+
+	var lines []dto.TextLineChange
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i++
+			j++
+		case j < len(b) && (i == len(a) || common[i][j+1] > common[i+1][j]):
+			lines = append(lines, dto.TextLineChange{Op: "add", Line: j + 1, Text: b[j]})
+			j++
+		default:
+			lines = append(lines, dto.TextLineChange{Op: "remove", Line: i + 1, Text: a[i]})
+			i++
+		}
+	}
+	return lines
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+)
+
+func TestDiffLessonQuestions(t *testing.T) {
+	before := json.RawMessage(`[
+		{"id":"q1","type":"multiple_choice","question":"Who?","options":["A","B"],"answer":"A","points":10},
+		{"id":"q2","type":"fill_blank","question":"When?","answer":"938","points":10},
+		{"id":"q3","type":"fill_blank","question":"Where?","answer":"Bach Dang","points":10}
+	]`)
+	after := json.RawMessage(`[
+		{"id":"q4","type":"fill_blank","question":"New?","answer":"x","points":5},
+		{"id":"q1","type":"multiple_choice","question":"Who?","options":["A","B"],"answer":"B","points":10},
+		{"id":"q3","type":"fill_blank","question":"Where?","answer":"Bach Dang","points":10}
+	]`)
+
+	changes, err := diffLessonQuestions(before, after)
+	if err != nil {
+		t.Fatalf("diffLessonQuestions: %v", err)
+	}
+
+	var got []string
+	for _, change := range changes {
+		got = append(got, change.QuestionID+":"+change.Change)
+	}
+	// q3 kept its place among the kept questions, so the insertion does not move it
+	if want := []string{"q4:added", "q1:changed", "q2:removed"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("changes = %v, want %v", got, want)
+	}
+
+	fields := changes[1].Changes
+	if len(fields) != 1 || fields[0].Field != "answer" || fields[0].Before != "A" || fields[0].After != "B" {
+		t.Errorf("q1 changes = %+v, want only the answer from A to B", fields)
+	}
+}
+
+func TestDiffLessonQuestionsReordered(t *testing.T) {
+	before := json.RawMessage(`[{"id":"q1","points":10},{"id":"q2","points":10}]`)
+	after := json.RawMessage(`[{"id":"q2","points":10},{"id":"q1","points":10}]`)
+
+	changes, err := diffLessonQuestions(before, after)
+	if err != nil {
+		t.Fatalf("diffLessonQuestions: %v", err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("got %d changes, want both questions moved", len(changes))
+	}
+	for _, change := range changes {
+		if len(change.Changes) != 1 || change.Changes[0].Field != "position" {
+			t.Errorf("%s changes = %+v, want only the position", change.QuestionID, change.Changes)
+		}
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	before := "Năm 938\nNgô Quyền đánh quân Nam Hán\ntrên sông Bạch Đằng"
+	after := "Năm 938\nNgô Quyền đại phá quân Nam Hán\ntrên sông Bạch Đằng\nmở ra thời kỳ độc lập"
+
+	want := []dto.TextLineChange{
+		{Op: "remove", Line: 2, Text: "Ngô Quyền đánh quân Nam Hán"},
+		{Op: "add", Line: 2, Text: "Ngô Quyền đại phá quân Nam Hán"},
+		{Op: "add", Line: 4, Text: "mở ra thời kỳ độc lập"},
+	}
+	if got := diffLines(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffLines = %+v, want %+v", got, want)
+	}
+
+	if got := diffLines("same\ntext", "same\ntext"); len(got) != 0 {
+		t.Errorf("diffLines of equal text = %+v, want none", got)
+	}
+}
+
+func TestLessonFieldChangesSkipsEqualFields(t *testing.T) {
+	changes := lessonFieldChanges(
+		lessonField{"title", "Bạch Đằng", "Bạch Đằng"},
+		lessonField{"xp_reward", 50, 60},
+		lessonField{"story", "one line", "another line"},
+	)
+
+	if len(changes) != 2 || changes[0].Field != "xp_reward" || changes[1].Field != "story" {
+		t.Fatalf("changes = %+v, want xp_reward and story", changes)
+	}
+	if changes[1].Lines != nil {
+		t.Errorf("single line text got a line diff: %+v", changes[1].Lines)
+	}
+}
@@ -0,0 +1,477 @@
+package services
+
+import (
+	stdContext "context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloakd/common/context"
+	serviceContext "github.com/cloakd/common/services"
+	"github.com/gofiber/fiber/v2"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	"github.com/redis/go-redis/v9"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	maintenanceStateKey = "ven:maintenance"
+	// How often each instance re-reads the flag, so a toggle reaches every instance quickly
+	maintenanceRefreshInterval = 5 * time.Second
+	// How often scheduled windows are started and ended
+	maintenanceScheduleInterval = 30 * time.Second
+
+	defaultMaintenanceMessageVI = "Hệ thống đang được bảo trì. Vui lòng quay lại sau ít phút."
+	defaultMaintenanceMessageEN = "We're doing some maintenance. Please check back in a few minutes."
+)
+
+// maintenanceExemptPrefixes stay available during maintenance: health checks, docs,
+// the status endpoint, admin routes and the session endpoints admins need to reach them.
+// API routes are matched without the /api/<version> part.
+var maintenanceExemptPrefixes = []string{
+	"/ping",
+	"/swagger",
+	"/maintenance",
+	"/admin",
+	"/login",
+	"/refresh",
+	"/logout",
+}
+
+// MaintenanceService switches the API into maintenance mode, where learner endpoints
+// answer 503 with a localized message and Retry-After. The flag lives in Redis so every
+// instance follows it, and scheduled windows turn it on and off automatically.
+type MaintenanceService struct {
+	serviceContext.DefaultService
+
+	sqlSvc          *PostgresService
+	redisSvc        *RedisService
+	notificationSvc *NotificationService
+	systemSvc       *SystemService
+
+	defaultRetryAfter int
+
+	mutex    sync.RWMutex
+	state    dto.MaintenanceState
+	upcoming *model.MaintenanceWindow
+}
+
+const MAINTENANCE_SVC = "maintenance_svc"
+
+func (svc *MaintenanceService) Id() string {
+	return MAINTENANCE_SVC
+}
+
+func (svc *MaintenanceService) Configure(ctx *context.Context) error {
+	svc.defaultRetryAfter = 600
+	if v, err := strconv.Atoi(os.Getenv("MAINTENANCE_RETRY_AFTER_SECONDS")); err == nil && v > 0 {
+		svc.defaultRetryAfter = v
+	}
+	return svc.DefaultService.Configure(ctx)
+}
+
+func (svc *MaintenanceService) Start() error {
+	deps := newDependencies(svc.Id(), svc.Service)
+	svc.sqlSvc = resolve[*PostgresService](deps, POSTGRES_SVC)
+	svc.redisSvc = resolve[*RedisService](deps, REDIS_SVC)
+	svc.notificationSvc = resolve[*NotificationService](deps, NOTIFICATION_SVC)
+	svc.systemSvc = resolve[*SystemService](deps, SYSTEM_SVC)
+	if err := deps.err(); err != nil {
+		return err
+	}
+
+	svc.refreshState()
+	svc.applyWindows()
+
+	go svc.startRefresher()
+	go svc.startWindowScheduler()
+
+	return nil
+}
+
+func (svc *MaintenanceService) startRefresher() {
+	ticker := time.NewTicker(maintenanceRefreshInterval)
+	for range ticker.C {
+		svc.refreshState()
+	}
+}
+
+func (svc *MaintenanceService) startWindowScheduler() {
+	ticker := time.NewTicker(maintenanceScheduleInterval)
+	for range ticker.C {
+		svc.applyWindows()
+	}
+}
+
+// refreshState copies the flag from Redis. If Redis cannot be read the last known
+// state is kept rather than guessing.
+func (svc *MaintenanceService) refreshState() {
+	state, err := svc.readState()
+	if err != nil {
+		log.WithError(err).Warn("Failed to read maintenance state")
+		return
+	}
+
+	svc.mutex.Lock()
+	svc.state = *state
+	svc.mutex.Unlock()
+}
+
+func (svc *MaintenanceService) readState() (*dto.MaintenanceState, error) {
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), time.Second)
+	defer cancel()
+
+	var state dto.MaintenanceState
+	data, err := svc.redisSvc.GetClient().Get(ctx, maintenanceStateKey).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return &state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+func (svc *MaintenanceService) saveState(state dto.MaintenanceState) error {
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), time.Second)
+	defer cancel()
+
+	if err := svc.redisSvc.Set(ctx, maintenanceStateKey, state, 0); err != nil {
+		return err
+	}
+
+	svc.mutex.Lock()
+	svc.state = state
+	svc.mutex.Unlock()
+
+	svc.systemSvc.PublishOpsEvent(OpsEventMaintenance, OpsSeverityWarning, map[string]interface{}{
+		"enabled":    state.Enabled,
+		"window_id":  state.WindowID,
+		"updated_by": state.UpdatedBy,
+	})
+	return nil
+}
+
+func (svc *MaintenanceService) currentState() dto.MaintenanceState {
+	svc.mutex.RLock()
+	defer svc.mutex.RUnlock()
+	return svc.state
+}
+
+// Guard answers learner requests with 503 while maintenance mode is on
+func (svc *MaintenanceService) Guard() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		state := svc.currentState()
+		if !state.Enabled || isMaintenanceExempt(c.Path()) {
+			return c.Next()
+		}
+
+		retryAfter := svc.retryAfter(&state)
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+
+		// Sent directly rather than returned as an error so blocked requests are not
+		// reported to the ops console as server errors
+		return shared.ResponseJSON(c, fiber.StatusServiceUnavailable, maintenanceMessage(&state, maintenanceLocale(c)), map[string]interface{}{
+			"maintenance":         true,
+			"retry_after_seconds": retryAfter,
+			"ends_at":             state.EndsAt,
+		})
+	}
+}
+
+func isMaintenanceExempt(path string) bool {
+	path = apiRoutePath(path)
+	for _, prefix := range maintenanceExemptPrefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// maintenanceLocale picks the message language from the locale query parameter or
+// Accept-Language, like lesson content
+func maintenanceLocale(c *fiber.Ctx) string {
+	if locale := c.Query("locale"); locale != "" {
+		return locale
+	}
+	return c.AcceptsLanguages(append([]string{model.SourceLocale}, model.SupportedLocales...)...)
+}
+
+func maintenanceMessage(state *dto.MaintenanceState, locale string) string {
+	if locale == "en" {
+		if state.MessageEN != "" {
+			return state.MessageEN
+		}
+		return defaultMaintenanceMessageEN
+	}
+	if state.MessageVI != "" {
+		return state.MessageVI
+	}
+	return defaultMaintenanceMessageVI
+}
+
+// retryAfter is the time left until the expected end, or the configured delay when no
+// end is known or it has already passed
+func (svc *MaintenanceService) retryAfter(state *dto.MaintenanceState) int {
+	if state.EndsAt != nil {
+		if remaining := int(time.Until(*state.EndsAt).Seconds()); remaining > 0 {
+			return remaining
+		}
+	}
+	if state.RetryAfterSeconds > 0 {
+		return state.RetryAfterSeconds
+	}
+	return svc.defaultRetryAfter
+}
+
+// GetStatus returns the maintenance state as learner apps see it
+func (svc *MaintenanceService) GetStatus(locale string) *dto.MaintenanceStatusResponse {
+	state := svc.currentState()
+
+	resp := &dto.MaintenanceStatusResponse{Enabled: state.Enabled}
+	if state.Enabled {
+		resp.Message = maintenanceMessage(&state, locale)
+		resp.RetryAfterSeconds = svc.retryAfter(&state)
+		resp.EndsAt = state.EndsAt
+	}
+
+	svc.mutex.RLock()
+	if svc.upcoming != nil {
+		resp.UpcomingStartsAt = &svc.upcoming.StartsAt
+		resp.UpcomingEndsAt = &svc.upcoming.EndsAt
+	}
+	svc.mutex.RUnlock()
+
+	return resp
+}
+
+// GetState returns the stored maintenance flag for admins
+func (svc *MaintenanceService) GetState() (*dto.MaintenanceState, error) {
+	state, err := svc.readState()
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to read maintenance state")
+	}
+	return state, nil
+}
+
+// SetMaintenance turns maintenance mode on or off by hand. Turning it off also ends
+// the effect of an active scheduled window.
+func (svc *MaintenanceService) SetMaintenance(adminID string, req dto.UpdateMaintenanceRequest) (*dto.MaintenanceState, error) {
+	state := dto.MaintenanceState{
+		Enabled:   *req.Enabled,
+		UpdatedBy: adminID,
+	}
+	if state.Enabled {
+		now := time.Now()
+		if req.EndsAt != nil && !req.EndsAt.After(now) {
+			return nil, shared.NewBadRequestError(nil, "ends_at must be in the future")
+		}
+		state.MessageVI = req.MessageVI
+		state.MessageEN = req.MessageEN
+		state.RetryAfterSeconds = req.RetryAfterSeconds
+		state.StartedAt = &now
+		state.EndsAt = req.EndsAt
+	}
+
+	if err := svc.saveState(state); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to update maintenance state")
+	}
+
+	log.Printf("Maintenance mode %s by admin %s", onOff(state.Enabled), adminID)
+	return &state, nil
+}
+
+// ScheduleWindow plans a maintenance window and, unless told otherwise, announces it
+// in every active learner's inbox
+func (svc *MaintenanceService) ScheduleWindow(adminID string, req dto.CreateMaintenanceWindowRequest) (*model.MaintenanceWindow, error) {
+	if !req.StartsAt.After(time.Now()) {
+		return nil, shared.NewBadRequestError(nil, "starts_at must be in the future")
+	}
+	if !req.EndsAt.After(req.StartsAt) {
+		return nil, shared.NewBadRequestError(nil, "ends_at must be after starts_at")
+	}
+
+	window := &model.MaintenanceWindow{
+		StartsAt:  req.StartsAt,
+		EndsAt:    req.EndsAt,
+		Status:    model.MaintenanceWindowScheduled,
+		MessageVI: req.MessageVI,
+		MessageEN: req.MessageEN,
+		CreatedBy: adminID,
+	}
+	if err := svc.sqlSvc.maintenanceRepo.CreateMaintenanceWindow(window); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to schedule maintenance window")
+	}
+
+	if req.Announce == nil || *req.Announce {
+		svc.announceWindow(window)
+	}
+	svc.loadUpcoming()
+
+	return window, nil
+}
+
+func (svc *MaintenanceService) announceWindow(window *model.MaintenanceWindow) {
+	body := fmt.Sprintf("The app will be unavailable from %s to %s (UTC) for scheduled maintenance.",
+		window.StartsAt.UTC().Format("2006-01-02 15:04"), window.EndsAt.UTC().Format("2006-01-02 15:04"))
+
+	sent, err := svc.notificationSvc.Broadcast(model.NotificationTypeAnnouncement, "Scheduled maintenance", body, map[string]interface{}{
+		"maintenance_window_id": window.ID,
+		"starts_at":             window.StartsAt,
+		"ends_at":               window.EndsAt,
+	})
+	if err != nil {
+		log.WithError(err).WithField("window_id", window.ID).Error("Failed to announce maintenance window")
+		return
+	}
+
+	now := time.Now()
+	window.AnnouncedAt = &now
+	if err := svc.sqlSvc.maintenanceRepo.MarkMaintenanceWindowAnnounced(window.ID, now); err != nil {
+		log.WithError(err).WithField("window_id", window.ID).Error("Failed to record maintenance announcement")
+	}
+	log.Printf("Announced maintenance window %s to %d user(s)", window.ID, sent)
+}
+
+func (svc *MaintenanceService) GetWindows() ([]model.MaintenanceWindow, error) {
+	windows, err := svc.sqlSvc.maintenanceRepo.GetMaintenanceWindows(time.Now().AddDate(0, 0, -30))
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to load maintenance windows")
+	}
+	return windows, nil
+}
+
+// CancelWindow cancels a scheduled or active window. Cancelling an active window turns
+// maintenance mode off if that window turned it on.
+func (svc *MaintenanceService) CancelWindow(adminID, windowID string) error {
+	window, err := svc.sqlSvc.maintenanceRepo.GetMaintenanceWindow(windowID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return shared.NewNotFoundError(err, "Maintenance window not found")
+		}
+		return shared.NewInternalError(err, "Failed to load maintenance window")
+	}
+	if window.Status != model.MaintenanceWindowScheduled && window.Status != model.MaintenanceWindowActive {
+		return shared.NewConflictError(nil, "Maintenance window has already ended")
+	}
+
+	cancelled, err := svc.sqlSvc.maintenanceRepo.UpdateMaintenanceWindowStatus(windowID, window.Status, model.MaintenanceWindowCancelled)
+	if err != nil {
+		return shared.NewInternalError(err, "Failed to cancel maintenance window")
+	}
+	if !cancelled {
+		return shared.NewConflictError(nil, "Maintenance window changed, try again")
+	}
+
+	if window.Status == model.MaintenanceWindowActive {
+		svc.endWindow(window.ID, adminID)
+	}
+	svc.loadUpcoming()
+	return nil
+}
+
+// applyWindows starts scheduled windows that are due and ends active ones that are
+// over. Status changes are conditional, so only one instance acts on each window.
+func (svc *MaintenanceService) applyWindows() {
+	now := time.Now()
+	windows, err := svc.sqlSvc.maintenanceRepo.GetDueMaintenanceWindows(now)
+	if err != nil {
+		log.WithError(err).Error("Failed to load due maintenance windows")
+		return
+	}
+
+	for _, window := range windows {
+		switch {
+		case window.Status == model.MaintenanceWindowScheduled && window.EndsAt.After(now):
+			started, err := svc.sqlSvc.maintenanceRepo.UpdateMaintenanceWindowStatus(window.ID, model.MaintenanceWindowScheduled, model.MaintenanceWindowActive)
+			if err != nil || !started {
+				continue
+			}
+			startedAt := now
+			endsAt := window.EndsAt
+			if err := svc.saveState(dto.MaintenanceState{
+				Enabled:   true,
+				MessageVI: window.MessageVI,
+				MessageEN: window.MessageEN,
+				StartedAt: &startedAt,
+				EndsAt:    &endsAt,
+				WindowID:  window.ID,
+				UpdatedBy: window.CreatedBy,
+			}); err != nil {
+				log.WithError(err).WithField("window_id", window.ID).Error("Failed to start maintenance window")
+				continue
+			}
+			log.Printf("Maintenance window %s started", window.ID)
+
+		default:
+			// Active windows that are over, and scheduled windows missed entirely
+			ended, err := svc.sqlSvc.maintenanceRepo.UpdateMaintenanceWindowStatus(window.ID, window.Status, model.MaintenanceWindowCompleted)
+			if err != nil || !ended {
+				continue
+			}
+			if window.Status == model.MaintenanceWindowActive {
+				svc.endWindow(window.ID, window.CreatedBy)
+			}
+		}
+	}
+
+	svc.loadUpcoming()
+}
+
+// endWindow turns maintenance mode off if the window turned it on, leaving a manual
+// toggle made since then alone
+func (svc *MaintenanceService) endWindow(windowID, updatedBy string) {
+	state, err := svc.readState()
+	if err != nil {
+		log.WithError(err).WithField("window_id", windowID).Error("Failed to read maintenance state")
+		return
+	}
+	if !state.Enabled || state.WindowID != windowID {
+		return
+	}
+
+	if err := svc.saveState(dto.MaintenanceState{UpdatedBy: updatedBy}); err != nil {
+		log.WithError(err).WithField("window_id", windowID).Error("Failed to end maintenance window")
+		return
+	}
+	log.Printf("Maintenance window %s ended", windowID)
+}
+
+func (svc *MaintenanceService) loadUpcoming() {
+	windows, err := svc.sqlSvc.maintenanceRepo.GetMaintenanceWindows(time.Now())
+	if err != nil {
+		log.WithError(err).Warn("Failed to load upcoming maintenance windows")
+		return
+	}
+
+	var upcoming *model.MaintenanceWindow
+	for i := range windows {
+		if windows[i].Status == model.MaintenanceWindowScheduled {
+			upcoming = &windows[i]
+			break
+		}
+	}
+
+	svc.mutex.Lock()
+	svc.upcoming = upcoming
+	svc.mutex.Unlock()
+}
+
+func onOff(enabled bool) string {
+	if enabled {
+		return "enabled"
+	}
+	return "disabled"
+}
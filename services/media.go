@@ -1,102 +1,215 @@
 package services
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
 	"mime/multipart"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/cloakd/common/context"
+	appContext "github.com/cloakd/common/context"
 	serviceContext "github.com/cloakd/common/services"
-	"github.com/google/uuid"
 	"github.com/lac-hong-legacy/ven_api/dto"
 	"github.com/lac-hong-legacy/ven_api/model"
 	"github.com/lac-hong-legacy/ven_api/shared"
+	"github.com/lac-hong-legacy/ven_api/shared/ids"
+	"github.com/minio/minio-go/v7"
 	log "github.com/sirupsen/logrus"
 )
 
 type MediaService struct {
 	serviceContext.DefaultService
-	sqlSvc   *PostgresService
-	minioSvc *MinIOService
-	baseURL  string
+	sqlSvc     *PostgresService
+	minioSvc   *MinIOService
+	contentSvc *ContentService
+	baseURL    string
+
+	// CDN settings. When cdnBaseURL is empty media is served straight from MinIO.
+	cdnBaseURL    string
+	cdnPurgeURL   string
+	cdnPurgeToken string
+	httpClient    *http.Client
+
+	// CDN prewarming, see PrewarmLesson. prewarmAPIBaseURL is the CDN host in front of
+	// the public API, empty when public content is not cached by a CDN.
+	prewarmRegions    []cdnPrewarmRegion
+	prewarmAPIBaseURL string
+
+	// Daily upload quota in bytes per role. Roles without an entry may not upload.
+	dailyUploadQuotas map[string]int64
+
+	systemSvc  *SystemService
+	webhookSvc *WebhookService
+	emailSvc   *EmailService
+
+	// Processing failure alerts, see alertMediaProcessingFailure
+	processingAlertAfter  int
+	processingAlertEmails []string
 }
 
 const MEDIA_SVC = "media_svc"
 
+const (
+	// S3 multipart uploads require every part except the last to be at least 5 MiB
+	uploadMinChunkSize = 5 * 1024 * 1024
+	uploadMaxSize      = 2 * 1024 * 1024 * 1024
+	uploadMaxChunkSize = 100 * 1024 * 1024
+	uploadSessionTTL   = 24 * time.Hour
+)
+
+type uploadPart struct {
+	PartNumber int    `json:"part_number"`
+	ETag       string `json:"etag"`
+}
+
 func (svc MediaService) Id() string {
 	return MEDIA_SVC
 }
 
-func (svc *MediaService) Configure(ctx *context.Context) error {
+func (svc *MediaService) Configure(ctx *appContext.Context) error {
 	svc.baseURL = os.Getenv("BASE_URL")
 	if svc.baseURL == "" {
 		svc.baseURL = "http://localhost:8000"
 	}
 
+	svc.cdnBaseURL = strings.TrimRight(os.Getenv("CDN_BASE_URL"), "/")
+	svc.cdnPurgeURL = os.Getenv("CDN_PURGE_URL")
+	svc.cdnPurgeToken = os.Getenv("CDN_PURGE_TOKEN")
+	svc.httpClient = &http.Client{
+		Timeout: 10 * time.Second,
+	}
+
+	regions, err := parseCDNPrewarmRegions(os.Getenv("CDN_PREWARM_REGIONS"))
+	if err != nil {
+		return err
+	}
+	svc.prewarmRegions = regions
+	svc.prewarmAPIBaseURL = strings.TrimRight(os.Getenv("CDN_PREWARM_API_BASE_URL"), "/")
+
+	svc.dailyUploadQuotas = map[string]int64{
+		model.RoleAdmin: 5 * 1024 * 1024 * 1024,
+	}
+	if quota := os.Getenv("MEDIA_ADMIN_DAILY_QUOTA_MB"); quota != "" {
+		mb, err := strconv.ParseInt(quota, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid MEDIA_ADMIN_DAILY_QUOTA_MB: %w", err)
+		}
+		svc.dailyUploadQuotas[model.RoleAdmin] = mb * 1024 * 1024
+	}
+
+	svc.processingAlertAfter = defaultMediaProcessingAlertAfter
+	if after := os.Getenv("MEDIA_PROCESSING_ALERT_AFTER"); after != "" {
+		value, err := strconv.Atoi(after)
+		if err != nil {
+			return fmt.Errorf("invalid MEDIA_PROCESSING_ALERT_AFTER: %w", err)
+		}
+		svc.processingAlertAfter = value
+	}
+	for _, email := range strings.Split(os.Getenv("MEDIA_PROCESSING_ALERT_EMAILS"), ",") {
+		if email = strings.TrimSpace(email); email != "" {
+			svc.processingAlertEmails = append(svc.processingAlertEmails, email)
+		}
+	}
+
 	return svc.DefaultService.Configure(ctx)
 }
 
 func (svc *MediaService) Start() error {
-	svc.sqlSvc = svc.Service(POSTGRES_SVC).(*PostgresService)
-	svc.minioSvc = svc.Service(MINIO_SVC).(*MinIOService)
+	deps := newDependencies(svc.Id(), svc.Service)
+	svc.sqlSvc = resolve[*PostgresService](deps, POSTGRES_SVC)
+	svc.minioSvc = resolve[*MinIOService](deps, MINIO_SVC)
+	svc.contentSvc = resolve[*ContentService](deps, CONTENT_SVC)
+	svc.systemSvc = resolve[*SystemService](deps, SYSTEM_SVC)
+	svc.webhookSvc = resolve[*WebhookService](deps, WEBHOOK_SVC)
+	svc.emailSvc = resolve[*EmailService](deps, EMAIL_SVC)
+	if err := deps.err(); err != nil {
+		return err
+	}
+
+	go svc.startUploadCleanupScheduler()
+
 	return nil
 }
 
 // ==================== MEDIA UPLOAD METHODS ====================
 
-func (svc *MediaService) UploadLessonSubtitle(lessonID string, file *multipart.FileHeader) (*dto.MediaUploadResponse, error) {
-	if !svc.isValidSubtitleFile(file.Filename) {
-		return nil, shared.NewBadRequestError(nil, "Invalid subtitle file format. Supported: VTT, SRT")
+func (svc *MediaService) UploadThumbnail(adminID, lessonID string, file *multipart.FileHeader) (*dto.MediaUploadResponse, error) {
+	if !svc.isValidImageFile(file.Filename) {
+		return nil, shared.NewBadRequestError(nil, "Invalid image file format. Supported: JPG, PNG, WEBP")
+	}
+
+	if file.Size > 2*1024*1024 {
+		return nil, shared.NewPayloadTooLargeError(nil, "Thumbnail file too large. Maximum size: 2MB")
 	}
 
-	return svc.uploadFile(file, "subtitle", lessonID)
+	return svc.uploadFile(adminID, file, "thumbnail", lessonID)
 }
 
-func (svc *MediaService) UploadThumbnail(lessonID string, file *multipart.FileHeader) (*dto.MediaUploadResponse, error) {
-	if !svc.isValidImageFile(file.Filename) {
-		return nil, shared.NewBadRequestError(nil, "Invalid image file format. Supported: JPG, PNG, WEBP")
+// checkUploadQuota rejects an upload that would take the admin past their role's daily quota
+func (svc *MediaService) checkUploadQuota(adminID string, size int64) error {
+	user, err := svc.sqlSvc.userRepo.GetUserByID(adminID)
+	if err != nil {
+		return shared.NewInternalError(err, "Failed to check upload quota")
 	}
 
-	if file.Size > 2*1024*1024 {
-		return nil, shared.NewBadRequestError(nil, "Thumbnail file too large. Maximum size: 2MB")
+	quota := svc.dailyUploadQuotas[user.Role]
+	if quota <= 0 {
+		return shared.NewForbiddenError(nil, "Your role is not allowed to upload media")
 	}
 
-	return svc.uploadFile(file, "thumbnail", lessonID)
+	now := time.Now()
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	used, err := svc.sqlSvc.mediaRepo.GetUploadedBytesSince(adminID, startOfDay)
+	if err != nil {
+		return shared.NewInternalError(err, "Failed to check upload quota")
+	}
+
+	if used+size > quota {
+		return shared.NewPayloadTooLargeError(nil, "Daily upload quota exceeded").WithData(map[string]interface{}{
+			"quota_bytes":     quota,
+			"used_bytes":      used,
+			"requested_bytes": size,
+			"resets_at":       startOfDay.Add(24 * time.Hour),
+		})
+	}
+
+	return nil
 }
 
-func (svc *MediaService) uploadFile(file *multipart.FileHeader, fileType, lessonID string) (*dto.MediaUploadResponse, error) {
-	// Generate unique filename
-	ext := filepath.Ext(file.Filename)
-	fileName := fmt.Sprintf("%s_%s_%d%s", lessonID, fileType, time.Now().Unix(), ext)
+func (svc *MediaService) uploadFile(adminID string, file *multipart.FileHeader, fileType, lessonID string) (*dto.MediaUploadResponse, error) {
+	mediaAsset, err := svc.storeUpload(adminID, file, fileType, lessonID, lessonID)
+	if err != nil {
+		return nil, err
+	}
 
-	// Determine subdirectory based on file type
-	var subDir string
-	switch fileType {
-	case "video":
-		subDir = "videos"
-	case "subtitle":
-		subDir = "subtitles"
-	case "thumbnail":
-		subDir = "thumbnails"
-	case "audio":
-		subDir = "audio"
-	case "background_music":
-		subDir = "background_music"
-	case "voice_over":
-		subDir = "voice_over"
-	case "animation":
-		subDir = "animations"
-	case "illustration":
-		subDir = "illustrations"
-	default:
-		subDir = "misc"
+	return &dto.MediaUploadResponse{
+		ID:       mediaAsset.ID,
+		URL:      svc.PublicURL(mediaAsset),
+		FileName: mediaAsset.FileName,
+		FileType: mediaAsset.FileType,
+		FileSize: mediaAsset.FileSize,
+	}, nil
+}
+
+// storeUpload puts an uploaded file in MinIO and records the asset. namePrefix starts
+// the generated file name; the asset is linked to linkLessonID when it is not empty.
+func (svc *MediaService) storeUpload(adminID string, file *multipart.FileHeader, fileType, namePrefix, linkLessonID string) (*model.MediaAsset, error) {
+	if err := svc.checkUploadQuota(adminID, file.Size); err != nil {
+		return nil, err
 	}
 
-	// Create object name for MinIO
-	objectName := fmt.Sprintf("%s/%s", subDir, fileName)
+	fileName, objectName := svc.newObjectName(namePrefix, fileType, file.Filename)
+	bucket := svc.minioSvc.BucketFor(fileType)
 
 	// Open uploaded file
 	src, err := file.Open()
@@ -105,47 +218,75 @@ func (svc *MediaService) uploadFile(file *multipart.FileHeader, fileType, lesson
 	}
 	defer src.Close()
 
-	// Upload to MinIO
-	uploadInfo, err := svc.minioSvc.UploadFile(objectName, src, file.Size, file.Header.Get("Content-Type"))
+	// Upload to MinIO, hashing the content on the way through
+	hash := sha256.New()
+	uploadInfo, err := svc.minioSvc.UploadFile(bucket, objectName, io.TeeReader(src, hash), file.Size, file.Header.Get("Content-Type"))
 	if err != nil {
 		return nil, shared.NewInternalError(err, "Failed to upload file to storage")
 	}
 
+	mediaAsset, err := svc.createMediaAssetRecord(adminID, linkLessonID, fileType, fileName, file.Filename, file.Header.Get("Content-Type"), bucket, objectName, hex.EncodeToString(hash.Sum(nil)), file.Size)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("Successfully uploaded file %s to MinIO: %s", fileName, uploadInfo.Key)
+
+	return mediaAsset, nil
+}
+
+// newObjectName generates a unique file name and its MinIO object path for an upload
+func (svc *MediaService) newObjectName(lessonID, fileType, originalName string) (string, string) {
+	ext := filepath.Ext(originalName)
+	fileName := fmt.Sprintf("%s_%s_%d%s", lessonID, fileType, time.Now().Unix(), ext)
+
+	return fileName, fmt.Sprintf("%s/%s", mediaObjectDir(fileType), fileName)
+}
+
+// createMediaAssetRecord stores the asset row for an object already in MinIO and links it to the lesson
+func (svc *MediaService) createMediaAssetRecord(adminID, lessonID, fileType, fileName, originalName, mimeType, bucket, objectName, contentHash string, size int64) (*model.MediaAsset, error) {
 	// Generate presigned URL (valid for 24 hours)
-	fileURL, err := svc.minioSvc.GetFileURL(objectName, 24*time.Hour)
+	fileURL, err := svc.minioSvc.GetFileURL(bucket, objectName, 24*time.Hour)
 	if err != nil {
 		log.Printf("Failed to generate presigned URL: %v", err)
-		fileURL = fmt.Sprintf("%s/%s/%s", svc.baseURL, svc.minioSvc.GetBucketName(), objectName)
+		fileURL = fmt.Sprintf("%s/%s/%s", svc.baseURL, svc.minioSvc.bucket(bucket), objectName)
 	}
 
-	id, _ := uuid.NewV7()
+	id := ids.New()
 
 	// Create media asset record
 	mediaAsset := &model.MediaAsset{
-		ID:           id.String(),
+		ID:           id,
 		FileName:     fileName,
-		OriginalName: file.Filename,
+		OriginalName: originalName,
 		FileType:     fileType,
-		MimeType:     file.Header.Get("Content-Type"),
-		FileSize:     file.Size,
+		MimeType:     mimeType,
+		FileSize:     size,
 		URL:          fileURL,
 		StoragePath:  objectName,
+		Bucket:       bucket,
+		ContentHash:  contentHash,
+		UploadedBy:   adminID,
 		IsProcessed:  false,
 		CreatedAt:    time.Now(),
 		UpdatedAt:    time.Now(),
+
+		ProcessingStatus: model.MediaProcessingPending,
 	}
 
+	svc.publishToCDN(mediaAsset)
+
 	// Save to database
 	if err := svc.sqlSvc.mediaRepo.CreateMediaAsset(mediaAsset); err != nil {
 		// Clean up file if database save fails
-		svc.minioSvc.DeleteFile(objectName)
+		svc.minioSvc.DeleteFile(bucket, objectName)
 		return nil, err
 	}
 
 	// Link to lesson if lessonID provided
 	if lessonID != "" {
 		lessonMedia := &model.LessonMedia{
-			ID:           id.String(),
+			ID:           id,
 			LessonID:     lessonID,
 			MediaAssetID: mediaAsset.ID,
 			MediaType:    fileType,
@@ -158,15 +299,13 @@ func (svc *MediaService) uploadFile(file *multipart.FileHeader, fileType, lesson
 		}
 	}
 
-	log.Printf("Successfully uploaded file %s to MinIO: %s", fileName, uploadInfo.Key)
+	svc.contentSvc.RecordContentAudit(adminID, model.ContentEntityMedia, mediaAsset.ID, model.ContentActionCreate, nil, mediaAsset)
 
-	return &dto.MediaUploadResponse{
-		ID:       mediaAsset.ID,
-		URL:      mediaAsset.URL,
-		FileName: mediaAsset.FileName,
-		FileType: mediaAsset.FileType,
-		FileSize: mediaAsset.FileSize,
-	}, nil
+	if _, err := svc.startMediaProcessing(mediaAsset.ID); err != nil {
+		log.Printf("Failed to start processing media asset %s: %v", mediaAsset.ID, err)
+	}
+
+	return mediaAsset, nil
 }
 
 // ==================== MEDIA RETRIEVAL METHODS ====================
@@ -185,7 +324,7 @@ func (svc *MediaService) GetLessonMedia(lessonID string) (*dto.LessonMediaRespon
 	for _, asset := range mediaAssets {
 		response.Media[asset.MediaType] = &dto.MediaAssetResponse{
 			ID:       asset.MediaAsset.ID,
-			URL:      asset.MediaAsset.URL,
+			URL:      svc.PublicURL(&asset.MediaAsset),
 			Duration: asset.MediaAsset.Duration,
 			FileSize: asset.MediaAsset.FileSize,
 		}
@@ -194,8 +333,361 @@ func (svc *MediaService) GetLessonMedia(lessonID string) (*dto.LessonMediaRespon
 	return response, nil
 }
 
+// ==================== QUESTION MEDIA METHODS ====================
+
+// questionMediaURLTTL is how long presigned question media URLs stay valid
+const questionMediaURLTTL = time.Hour
+
+// UploadQuestionMedia stores an image or audio clip and attaches it to a question,
+// replacing whatever was attached in that role before
+func (svc *MediaService) UploadQuestionMedia(adminID, lessonID, questionID, role string, file *multipart.FileHeader) (*dto.QuestionMediaResponse, error) {
+	if err := svc.checkQuestionExists(lessonID, questionID); err != nil {
+		return nil, err
+	}
+
+	switch role {
+	case model.QuestionMediaImage:
+		if !svc.isValidImageFile(file.Filename) {
+			return nil, shared.NewBadRequestError(nil, "Invalid image file format. Supported: JPG, PNG, WEBP, GIF")
+		}
+		if file.Size > 5*1024*1024 {
+			return nil, shared.NewPayloadTooLargeError(nil, "Question image too large. Maximum size: 5MB")
+		}
+	case model.QuestionMediaAudio:
+		if !svc.isValidAudioFile(file.Filename) {
+			return nil, shared.NewBadRequestError(nil, "Invalid audio file format. Supported: MP3, WAV, AAC, M4A, OGG")
+		}
+		if file.Size > 20*1024*1024 {
+			return nil, shared.NewPayloadTooLargeError(nil, "Question audio too large. Maximum size: 20MB")
+		}
+	default:
+		return nil, shared.NewBadRequestError(nil, "Invalid media role. Supported: image, audio")
+	}
+
+	asset, err := svc.storeUpload(adminID, file, "question_"+role, lessonID+"_"+questionID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return svc.attachQuestionMedia(adminID, lessonID, questionID, role, asset)
+}
+
+// LinkQuestionMedia attaches an existing library asset to a question
+func (svc *MediaService) LinkQuestionMedia(adminID, lessonID, questionID, role, assetID string) (*dto.QuestionMediaResponse, error) {
+	if role != model.QuestionMediaImage && role != model.QuestionMediaAudio {
+		return nil, shared.NewBadRequestError(nil, "Invalid media role. Supported: image, audio")
+	}
+
+	if err := svc.checkQuestionExists(lessonID, questionID); err != nil {
+		return nil, err
+	}
+
+	asset, err := svc.sqlSvc.mediaRepo.GetMediaAsset(assetID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Media asset not found")
+	}
+
+	if role == model.QuestionMediaImage && !svc.isValidImageFile(asset.FileName) {
+		return nil, shared.NewBadRequestError(nil, "Media asset is not an image")
+	}
+	if role == model.QuestionMediaAudio && !svc.isValidAudioFile(asset.FileName) {
+		return nil, shared.NewBadRequestError(nil, "Media asset is not an audio file")
+	}
+
+	return svc.attachQuestionMedia(adminID, lessonID, questionID, role, asset)
+}
+
+// UnlinkQuestionMedia detaches the media in a role from a question. Assets uploaded
+// for the question are deleted once nothing references them.
+func (svc *MediaService) UnlinkQuestionMedia(adminID, lessonID, questionID, role string) error {
+	link, err := svc.sqlSvc.mediaRepo.GetQuestionMedia(lessonID, questionID, role)
+	if err != nil {
+		return shared.NewNotFoundError(err, "Question media not found")
+	}
+
+	if err := svc.sqlSvc.mediaRepo.DeleteQuestionMedia(link.ID); err != nil {
+		return shared.NewInternalError(err, "Failed to remove question media")
+	}
+
+	svc.contentSvc.RecordContentAudit(adminID, model.ContentEntityLesson, lessonID, model.ContentActionDelete, link, nil)
+	svc.deleteUnusedQuestionAsset(adminID, link.MediaAssetID)
+	return nil
+}
+
+func (svc *MediaService) attachQuestionMedia(adminID, lessonID, questionID, role string, asset *model.MediaAsset) (*dto.QuestionMediaResponse, error) {
+	previous, _ := svc.sqlSvc.mediaRepo.GetQuestionMedia(lessonID, questionID, role)
+
+	link := &model.QuestionMedia{
+		LessonID:     lessonID,
+		QuestionID:   questionID,
+		Role:         role,
+		MediaAssetID: asset.ID,
+	}
+	if err := svc.sqlSvc.mediaRepo.SetQuestionMedia(link); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to attach media to question")
+	}
+
+	action := model.ContentActionCreate
+	if previous != nil {
+		action = model.ContentActionUpdate
+	}
+	svc.contentSvc.RecordContentAudit(adminID, model.ContentEntityLesson, lessonID, action, previous, link)
+
+	if previous != nil && previous.MediaAssetID != asset.ID {
+		svc.deleteUnusedQuestionAsset(adminID, previous.MediaAssetID)
+	}
+
+	response := svc.mapQuestionMedia(role, asset)
+	return &response, nil
+}
+
+func (svc *MediaService) checkQuestionExists(lessonID, questionID string) error {
+	lesson, err := svc.sqlSvc.contentRepo.GetLesson(lessonID)
+	if err != nil {
+		return shared.NewNotFoundError(err, "Lesson not found")
+	}
+
+	var questions []model.Question
+	if lesson.Questions != nil {
+		if err := json.Unmarshal(lesson.Questions, &questions); err != nil {
+			return shared.NewInternalError(err, "Failed to read lesson questions")
+		}
+	}
+
+	for _, q := range questions {
+		if q.ID == questionID {
+			return nil
+		}
+	}
+	return shared.NewNotFoundError(nil, "Question not found")
+}
+
+// questionMediaForLesson returns the media of every question in a lesson keyed by
+// question ID and then role, with freshly presigned URLs
+func (svc *MediaService) questionMediaForLesson(lessonID string) map[string]map[string]dto.QuestionMediaResponse {
+	links, err := svc.sqlSvc.mediaRepo.GetLessonQuestionMedia(lessonID)
+	if err != nil {
+		log.Printf("Failed to load question media for lesson %s: %v", lessonID, err)
+		return nil
+	}
+
+	media := make(map[string]map[string]dto.QuestionMediaResponse)
+	for i := range links {
+		link := &links[i]
+		if media[link.QuestionID] == nil {
+			media[link.QuestionID] = make(map[string]dto.QuestionMediaResponse)
+		}
+		media[link.QuestionID][link.Role] = svc.mapQuestionMedia(link.Role, &link.MediaAsset)
+	}
+	return media
+}
+
+func (svc *MediaService) mapQuestionMedia(role string, asset *model.MediaAsset) dto.QuestionMediaResponse {
+	expiresAt := time.Now().Add(questionMediaURLTTL)
+	url, err := svc.minioSvc.GetFileURL(asset.Bucket, asset.StoragePath, questionMediaURLTTL)
+	if err != nil {
+		log.Printf("Failed to presign question media %s: %v", asset.ID, err)
+		url = svc.PublicURL(asset)
+	}
+
+	return dto.QuestionMediaResponse{
+		MediaAssetID: asset.ID,
+		Role:         role,
+		URL:          url,
+		MimeType:     asset.MimeType,
+		Duration:     asset.Duration,
+		ExpiresAt:    expiresAt,
+	}
+}
+
+// pruneQuestionMedia drops links to questions that were removed from their lesson
+// and deletes the question uploads nothing refers to anymore
+func (svc *MediaService) pruneQuestionMedia() {
+	assetIDs, err := svc.sqlSvc.mediaRepo.PruneQuestionMedia()
+	if err != nil {
+		log.Printf("Failed to prune question media: %v", err)
+		return
+	}
+
+	for _, assetID := range assetIDs {
+		svc.deleteUnusedQuestionAsset("system", assetID)
+	}
+}
+
+// deleteUnusedQuestionAsset removes an asset uploaded for a question once no lesson
+// or question uses it. Library assets linked from elsewhere are left alone.
+func (svc *MediaService) deleteUnusedQuestionAsset(adminID, assetID string) {
+	asset, err := svc.sqlSvc.mediaRepo.GetMediaAsset(assetID)
+	if err != nil || !strings.HasPrefix(asset.FileType, "question_") {
+		return
+	}
+
+	referenced, err := svc.sqlSvc.mediaRepo.IsMediaAssetReferenced(assetID)
+	if err != nil || referenced {
+		return
+	}
+
+	if err := svc.DeleteMediaAsset(adminID, assetID); err != nil {
+		log.Printf("Failed to delete unused question media %s: %v", assetID, err)
+	}
+}
+
 // ==================== FILE VALIDATION METHODS ====================
 
+// ==================== MEDIA LIBRARY METHODS ====================
+
+func (svc *MediaService) GetMediaLibrary(query dto.MediaLibraryQuery) (*dto.MediaLibraryResponse, error) {
+	assets, total, err := svc.sqlSvc.mediaRepo.ListMediaAssets(query)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to list media assets")
+	}
+
+	items, err := svc.buildMediaLibraryItems(assets)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.MediaLibraryResponse{
+		Items: items,
+		Total: int(total),
+		Page:  query.Page,
+		Limit: query.Limit,
+	}, nil
+}
+
+func (svc *MediaService) GetMediaAssetDetails(assetID string) (*dto.MediaLibraryItem, error) {
+	asset, err := svc.sqlSvc.mediaRepo.GetMediaAsset(assetID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Media asset not found")
+	}
+
+	items, err := svc.buildMediaLibraryItems([]model.MediaAsset{*asset})
+	if err != nil {
+		return nil, err
+	}
+	return &items[0], nil
+}
+
+// UpdateMediaAsset renames or retags an asset. The storage object is left untouched.
+func (svc *MediaService) UpdateMediaAsset(adminID, assetID string, req dto.UpdateMediaAssetRequest) (*dto.MediaLibraryItem, error) {
+	asset, err := svc.sqlSvc.mediaRepo.GetMediaAsset(assetID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Media asset not found")
+	}
+	before := *asset
+
+	if req.OriginalName != nil {
+		asset.OriginalName = strings.TrimSpace(*req.OriginalName)
+	}
+	if req.Tags != nil {
+		tags, err := json.Marshal(normalizeMediaTags(req.Tags))
+		if err != nil {
+			return nil, shared.NewBadRequestError(err, "Invalid tags")
+		}
+		asset.Tags = tags
+	}
+
+	if err := svc.sqlSvc.mediaRepo.UpdateMediaAsset(asset); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to update media asset")
+	}
+
+	svc.contentSvc.RecordContentAudit(adminID, model.ContentEntityMedia, asset.ID, model.ContentActionUpdate, before, asset)
+
+	items, err := svc.buildMediaLibraryItems([]model.MediaAsset{*asset})
+	if err != nil {
+		return nil, err
+	}
+	return &items[0], nil
+}
+
+func (svc *MediaService) buildMediaLibraryItems(assets []model.MediaAsset) ([]dto.MediaLibraryItem, error) {
+	assetIDs := make([]string, len(assets))
+	for i, asset := range assets {
+		assetIDs[i] = asset.ID
+	}
+
+	links, err := svc.sqlSvc.mediaRepo.GetLessonMediaByAssetIDs(assetIDs)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to load media usage")
+	}
+
+	usages := make(map[string][]dto.MediaUsage)
+	for _, link := range links {
+		usages[link.MediaAssetID] = append(usages[link.MediaAssetID], dto.MediaUsage{
+			LessonID:  link.LessonID,
+			MediaType: link.MediaType,
+			IsActive:  link.IsActive,
+		})
+	}
+
+	items := make([]dto.MediaLibraryItem, len(assets))
+	for i, asset := range assets {
+		previewURL, err := svc.minioSvc.GetFileURL(asset.Bucket, asset.StoragePath, time.Hour)
+		if err != nil {
+			log.Printf("Failed to generate preview URL for %s: %v", asset.ID, err)
+			previewURL = asset.URL
+		}
+
+		var tags []string
+		if err := json.Unmarshal([]byte(asset.Tags), &tags); err != nil || tags == nil {
+			tags = []string{}
+		}
+
+		assetUsages := usages[asset.ID]
+		if assetUsages == nil {
+			assetUsages = []dto.MediaUsage{}
+		}
+
+		usageCount := 0
+		for _, usage := range assetUsages {
+			if usage.IsActive {
+				usageCount++
+			}
+		}
+
+		items[i] = dto.MediaLibraryItem{
+			ID:           asset.ID,
+			FileName:     asset.FileName,
+			OriginalName: asset.OriginalName,
+			FileType:     asset.FileType,
+			MimeType:     asset.MimeType,
+			FileSize:     asset.FileSize,
+			Duration:     asset.Duration,
+			StoragePath:  asset.StoragePath,
+			Bucket:       svc.minioSvc.bucket(asset.Bucket),
+			PreviewURL:   previewURL,
+			Tags:         tags,
+			IsProcessed:  asset.IsProcessed,
+			UsageCount:   usageCount,
+			Usages:       assetUsages,
+			CreatedAt:    asset.CreatedAt,
+			UpdatedAt:    asset.UpdatedAt,
+
+			ProcessingStatus:   asset.ProcessingStatus,
+			ProcessingError:    asset.ProcessingError,
+			ProcessingAttempts: asset.ProcessingAttempts,
+			ProcessedAt:        asset.ProcessedAt,
+		}
+	}
+
+	return items, nil
+}
+
+// normalizeMediaTags lowercases, trims and de-duplicates tags while keeping their order
+func normalizeMediaTags(tags []string) []string {
+	seen := make(map[string]bool)
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
 func (svc *MediaService) isValidVideoFile(filename string) bool {
 	ext := strings.ToLower(filepath.Ext(filename))
 	validExts := []string{".mp4", ".mov", ".avi", ".mkv", ".webm"}
@@ -270,7 +762,7 @@ func (svc *MediaService) GenerateVideoThumbnail(mediaAssetID string) error {
 
 // ==================== PRODUCTION WORKFLOW METHODS ====================
 
-func (svc *MediaService) UploadLessonAudio(lessonID string, file *multipart.FileHeader) (*dto.MediaUploadResponse, error) {
+func (svc *MediaService) UploadLessonAudio(adminID, lessonID string, file *multipart.FileHeader) (*dto.MediaUploadResponse, error) {
 	lesson, err := svc.sqlSvc.contentRepo.GetLesson(lessonID)
 	if err != nil {
 		return nil, shared.NewNotFoundError(err, "Lesson not found")
@@ -285,14 +777,15 @@ func (svc *MediaService) UploadLessonAudio(lessonID string, file *multipart.File
 	}
 
 	if file.Size > 50*1024*1024 {
-		return nil, shared.NewBadRequestError(nil, "Audio file too large. Maximum size: 50MB")
+		return nil, shared.NewPayloadTooLargeError(nil, "Audio file too large. Maximum size: 50MB")
 	}
 
-	response, err := svc.uploadFile(file, "audio", lessonID)
+	response, err := svc.uploadFile(adminID, file, "audio", lessonID)
 	if err != nil {
 		return nil, err
 	}
 
+	svc.purgeReplacedURL(lesson.AudioURL)
 	lesson.AudioURL = response.URL
 	if err := svc.sqlSvc.contentRepo.UpdateLesson(lesson); err != nil {
 		return nil, err
@@ -301,7 +794,7 @@ func (svc *MediaService) UploadLessonAudio(lessonID string, file *multipart.File
 	return response, nil
 }
 
-func (svc *MediaService) UploadLessonAnimation(lessonID string, file *multipart.FileHeader) (*dto.MediaUploadResponse, error) {
+func (svc *MediaService) UploadLessonAnimation(adminID, lessonID string, file *multipart.FileHeader) (*dto.MediaUploadResponse, error) {
 	lesson, err := svc.sqlSvc.contentRepo.GetLesson(lessonID)
 	if err != nil {
 		return nil, shared.NewNotFoundError(err, "Lesson not found")
@@ -316,14 +809,15 @@ func (svc *MediaService) UploadLessonAnimation(lessonID string, file *multipart.
 	}
 
 	if file.Size > 100*1024*1024 {
-		return nil, shared.NewBadRequestError(nil, "Animation file too large. Maximum size: 100MB")
+		return nil, shared.NewPayloadTooLargeError(nil, "Animation file too large. Maximum size: 100MB")
 	}
 
-	response, err := svc.uploadFile(file, "animation", lessonID)
+	response, err := svc.uploadFile(adminID, file, "animation", lessonID)
 	if err != nil {
 		return nil, err
 	}
 
+	svc.purgeReplacedURL(lesson.AnimationURL)
 	lesson.AnimationURL = response.URL
 	if err := svc.sqlSvc.contentRepo.UpdateLesson(lesson); err != nil {
 		return nil, err
@@ -332,21 +826,454 @@ func (svc *MediaService) UploadLessonAnimation(lessonID string, file *multipart.
 	return response, nil
 }
 
+// ==================== RESUMABLE UPLOAD METHODS ====================
+
+// InitUpload starts a resumable upload for a lesson. Chunks are appended with
+// AppendUploadChunk and assembled by FinalizeUpload.
+func (svc *MediaService) InitUpload(adminID, lessonID string, req dto.InitUploadRequest) (*dto.UploadSessionResponse, error) {
+	lesson, err := svc.sqlSvc.contentRepo.GetLesson(lessonID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Lesson not found")
+	}
+
+	switch req.FileType {
+	case "audio":
+		if lesson.ScriptStatus != "finalized" {
+			return nil, shared.NewBadRequestError(nil, "Cannot upload audio: script must be finalized first")
+		}
+		if !svc.isValidAudioFile(req.FileName) {
+			return nil, shared.NewBadRequestError(nil, "Invalid audio file format. Supported: MP3, WAV, AAC, M4A")
+		}
+	case "animation":
+		if lesson.AudioStatus != "uploaded" && lesson.AudioStatus != "approved" {
+			return nil, shared.NewBadRequestError(nil, "Cannot upload animation: audio must be uploaded first")
+		}
+		fallthrough
+	default:
+		if !svc.isValidVideoFile(req.FileName) {
+			return nil, shared.NewBadRequestError(nil, "Invalid video file format. Supported: MP4, MOV, WEBM")
+		}
+	}
+
+	if req.TotalSize > uploadMaxSize {
+		return nil, shared.NewPayloadTooLargeError(nil, "File too large. Maximum size: 2GB")
+	}
+
+	if err := svc.checkUploadQuota(adminID, req.TotalSize); err != nil {
+		return nil, err
+	}
+
+	fileName, objectName := svc.newObjectName(lessonID, req.FileType, req.FileName)
+	bucket := svc.minioSvc.BucketFor(req.FileType)
+
+	multipartUploadID, err := svc.minioSvc.NewMultipartUpload(bucket, objectName, req.MimeType)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to start upload")
+	}
+
+	session := &model.MediaUploadSession{
+		AdminID:           adminID,
+		LessonID:          lessonID,
+		FileType:          req.FileType,
+		FileName:          fileName,
+		OriginalName:      req.FileName,
+		MimeType:          req.MimeType,
+		TotalSize:         req.TotalSize,
+		Bucket:            bucket,
+		ObjectName:        objectName,
+		MultipartUploadID: multipartUploadID,
+		Parts:             model.JSONB("[]"),
+		Status:            model.UploadStatusPending,
+		ExpiresAt:         time.Now().Add(uploadSessionTTL),
+	}
+
+	if err := svc.sqlSvc.mediaRepo.CreateUploadSession(session); err != nil {
+		_ = svc.minioSvc.AbortMultipartUpload(bucket, objectName, multipartUploadID)
+		return nil, shared.NewInternalError(err, "Failed to create upload session")
+	}
+
+	return svc.mapUploadSession(session), nil
+}
+
+func (svc *MediaService) GetUploadStatus(uploadID string) (*dto.UploadSessionResponse, error) {
+	session, err := svc.sqlSvc.mediaRepo.GetUploadSession(uploadID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Upload not found")
+	}
+	return svc.mapUploadSession(session), nil
+}
+
+// AppendUploadChunk writes the next chunk as a multipart part. The caller's offset must
+// match the bytes already received, so a client can resume after a dropped connection
+// by asking for the current offset and resending from there.
+func (svc *MediaService) AppendUploadChunk(uploadID string, offset int64, chunk []byte) (*dto.UploadSessionResponse, error) {
+	session, err := svc.sqlSvc.mediaRepo.GetUploadSession(uploadID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Upload not found")
+	}
+
+	if session.Status != model.UploadStatusPending {
+		return nil, shared.NewBadRequestError(nil, "Upload is no longer accepting data")
+	}
+	if offset != session.Offset {
+		return nil, shared.NewConflictError(nil, "Upload offset mismatch").WithData(svc.mapUploadSession(session))
+	}
+
+	chunkSize := int64(len(chunk))
+	if chunkSize == 0 {
+		return nil, shared.NewBadRequestError(nil, "Empty chunk")
+	}
+	if chunkSize > uploadMaxChunkSize {
+		return nil, shared.NewPayloadTooLargeError(nil, "Chunk too large. Maximum size: 100MB")
+	}
+	if offset+chunkSize > session.TotalSize {
+		return nil, shared.NewBadRequestError(nil, "Chunk exceeds declared file size")
+	}
+	if offset+chunkSize < session.TotalSize && chunkSize < uploadMinChunkSize {
+		return nil, shared.NewBadRequestError(nil, "Chunk too small. Only the final chunk may be smaller than 5MB")
+	}
+
+	var parts []uploadPart
+	if err := json.Unmarshal([]byte(session.Parts), &parts); err != nil {
+		parts = []uploadPart{}
+	}
+
+	hash := sha256.New()
+	if len(session.HashState) > 0 {
+		if err := hash.(encoding.BinaryUnmarshaler).UnmarshalBinary(session.HashState); err != nil {
+			return nil, shared.NewInternalError(err, "Failed to restore upload checksum state")
+		}
+	}
+	hash.Write(chunk)
+
+	hashState, err := hash.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to save upload checksum state")
+	}
+
+	partNumber := len(parts) + 1
+	part, err := svc.minioSvc.PutObjectPart(session.Bucket, session.ObjectName, session.MultipartUploadID, partNumber, bytes.NewReader(chunk), chunkSize)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to store chunk")
+	}
+
+	parts = append(parts, uploadPart{PartNumber: partNumber, ETag: part.ETag})
+	partsJSON, _ := json.Marshal(parts)
+
+	session.Offset = offset + chunkSize
+	session.Parts = partsJSON
+	session.HashState = hashState
+
+	advanced, err := svc.sqlSvc.mediaRepo.AdvanceUploadSession(session, offset)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to update upload session")
+	}
+	if !advanced {
+		return nil, shared.NewConflictError(nil, "Upload offset changed by a concurrent request")
+	}
+
+	return svc.mapUploadSession(session), nil
+}
+
+// FinalizeUpload assembles the uploaded parts, verifies the checksum and hands the
+// resulting asset to the regular lesson production pipeline.
+func (svc *MediaService) FinalizeUpload(adminID, uploadID, checksum string) (*dto.MediaUploadResponse, error) {
+	session, err := svc.sqlSvc.mediaRepo.GetUploadSession(uploadID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Upload not found")
+	}
+
+	if session.Status != model.UploadStatusPending {
+		return nil, shared.NewBadRequestError(nil, "Upload is no longer accepting data")
+	}
+	if session.Offset != session.TotalSize {
+		return nil, shared.NewBadRequestError(nil, "Upload is incomplete").WithData(svc.mapUploadSession(session))
+	}
+
+	hash := sha256.New()
+	if err := hash.(encoding.BinaryUnmarshaler).UnmarshalBinary(session.HashState); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to restore upload checksum state")
+	}
+
+	if !strings.EqualFold(hex.EncodeToString(hash.Sum(nil)), checksum) {
+		svc.abortUploadSession(session)
+		return nil, shared.NewBadRequestError(nil, "Checksum mismatch, upload discarded")
+	}
+
+	var parts []uploadPart
+	if err := json.Unmarshal([]byte(session.Parts), &parts); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to read upload parts")
+	}
+
+	completeParts := make([]minio.CompletePart, len(parts))
+	for i, part := range parts {
+		completeParts[i] = minio.CompletePart{PartNumber: part.PartNumber, ETag: part.ETag}
+	}
+
+	if err := svc.minioSvc.CompleteMultipartUpload(session.Bucket, session.ObjectName, session.MultipartUploadID, completeParts); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to assemble upload")
+	}
+
+	mediaAsset, err := svc.createMediaAssetRecord(adminID, session.LessonID, session.FileType, session.FileName, session.OriginalName, session.MimeType, session.Bucket, session.ObjectName, strings.ToLower(checksum), session.TotalSize)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	session.Status = model.UploadStatusCompleted
+	session.MediaAssetID = mediaAsset.ID
+	session.CompletedAt = &now
+	session.HashState = nil
+	if err := svc.sqlSvc.mediaRepo.UpdateUploadSession(session); err != nil {
+		log.Printf("Failed to mark upload session %s completed: %v", session.ID, err)
+	}
+
+	if err := svc.processFinalizedUpload(adminID, session.LessonID, mediaAsset); err != nil {
+		return nil, err
+	}
+
+	return &dto.MediaUploadResponse{
+		ID:       mediaAsset.ID,
+		URL:      svc.PublicURL(mediaAsset),
+		FileName: mediaAsset.FileName,
+		FileType: mediaAsset.FileType,
+		FileSize: mediaAsset.FileSize,
+	}, nil
+}
+
+// processFinalizedUpload applies the same lesson workflow steps as the single-request uploads
+func (svc *MediaService) processFinalizedUpload(adminID, lessonID string, mediaAsset *model.MediaAsset) error {
+	lesson, err := svc.sqlSvc.contentRepo.GetLesson(lessonID)
+	if err != nil {
+		return shared.NewNotFoundError(err, "Lesson not found")
+	}
+
+	switch mediaAsset.FileType {
+	case "audio":
+		svc.purgeReplacedURL(lesson.AudioURL)
+		lesson.AudioURL = svc.PublicURL(mediaAsset)
+		if err := svc.sqlSvc.contentRepo.UpdateLesson(lesson); err != nil {
+			return err
+		}
+		return svc.contentSvc.MarkAudioUploaded(adminID, lessonID)
+	case "animation":
+		svc.purgeReplacedURL(lesson.AnimationURL)
+		lesson.AnimationURL = svc.PublicURL(mediaAsset)
+		if err := svc.sqlSvc.contentRepo.UpdateLesson(lesson); err != nil {
+			return err
+		}
+		if err := svc.contentSvc.MarkAnimationUploaded(adminID, lessonID); err != nil {
+			return err
+		}
+	}
+
+	// Metadata and thumbnails were started in the background by createMediaAssetRecord
+	return nil
+}
+
+func (svc *MediaService) AbortUpload(uploadID string) error {
+	session, err := svc.sqlSvc.mediaRepo.GetUploadSession(uploadID)
+	if err != nil {
+		return shared.NewNotFoundError(err, "Upload not found")
+	}
+
+	if session.Status != model.UploadStatusPending {
+		return shared.NewBadRequestError(nil, "Upload is no longer accepting data")
+	}
+
+	svc.abortUploadSession(session)
+	return nil
+}
+
+func (svc *MediaService) abortUploadSession(session *model.MediaUploadSession) {
+	if err := svc.minioSvc.AbortMultipartUpload(session.Bucket, session.ObjectName, session.MultipartUploadID); err != nil {
+		log.Printf("Failed to abort multipart upload %s: %v", session.ID, err)
+	}
+
+	session.Status = model.UploadStatusAborted
+	session.HashState = nil
+	if err := svc.sqlSvc.mediaRepo.UpdateUploadSession(session); err != nil {
+		log.Printf("Failed to mark upload session %s aborted: %v", session.ID, err)
+	}
+}
+
+func (svc *MediaService) startUploadCleanupScheduler() {
+	ticker := time.NewTicker(1 * time.Hour)
+	for range ticker.C {
+		svc.pruneQuestionMedia()
+
+		sessions, err := svc.sqlSvc.mediaRepo.GetExpiredUploadSessions(time.Now())
+		if err != nil {
+			log.Printf("Failed to get expired upload sessions: %v", err)
+			continue
+		}
+
+		for i := range sessions {
+			svc.abortUploadSession(&sessions[i])
+		}
+	}
+}
+
+func (svc *MediaService) mapUploadSession(session *model.MediaUploadSession) *dto.UploadSessionResponse {
+	return &dto.UploadSessionResponse{
+		UploadID:     session.ID,
+		LessonID:     session.LessonID,
+		FileType:     session.FileType,
+		TotalSize:    session.TotalSize,
+		Offset:       session.Offset,
+		MinChunkSize: uploadMinChunkSize,
+		MaxChunkSize: uploadMaxChunkSize,
+		Status:       session.Status,
+		ExpiresAt:    session.ExpiresAt,
+	}
+}
+
+// ==================== CDN METHODS ====================
+
+// PublicURL returns the URL clients should use for an asset: the immutable CDN URL when
+// the asset has been published, the CDN mirror of its storage path when a CDN is
+// configured and the asset is in the default bucket, otherwise the MinIO URL.
+func (svc *MediaService) PublicURL(asset *model.MediaAsset) string {
+	if asset.CDNUrl != "" {
+		return asset.CDNUrl
+	}
+	if svc.cdnBaseURL != "" && asset.StoragePath != "" && svc.minioSvc.IsDefaultBucket(asset.Bucket) {
+		return fmt.Sprintf("%s/%s", svc.cdnBaseURL, asset.StoragePath)
+	}
+	return asset.URL
+}
+
+// publishToCDN copies an asset to a content-addressed key so its CDN URL can be cached
+// forever; a replaced file always gets a new URL. The copy goes to the default bucket the
+// CDN pulls from. Failures leave the asset on its regular storage path.
+func (svc *MediaService) publishToCDN(asset *model.MediaAsset) {
+	if svc.cdnBaseURL == "" || asset.ContentHash == "" {
+		return
+	}
+
+	immutablePath := fmt.Sprintf("cdn/%s%s", asset.ContentHash, strings.ToLower(filepath.Ext(asset.FileName)))
+	if err := svc.minioSvc.CopyFile(asset.Bucket, asset.StoragePath, "", immutablePath); err != nil {
+		log.Printf("Failed to publish asset %s to CDN path: %v", asset.ID, err)
+		return
+	}
+
+	asset.CDNUrl = fmt.Sprintf("%s/%s", svc.cdnBaseURL, immutablePath)
+}
+
+func (svc *MediaService) cdnURLsForAsset(asset *model.MediaAsset) []string {
+	if svc.cdnBaseURL == "" {
+		return nil
+	}
+
+	var urls []string
+	if svc.minioSvc.IsDefaultBucket(asset.Bucket) {
+		urls = append(urls, fmt.Sprintf("%s/%s", svc.cdnBaseURL, asset.StoragePath))
+	}
+	if asset.CDNUrl != "" {
+		urls = append(urls, asset.CDNUrl)
+	}
+	return urls
+}
+
+// PurgeAssetCache invalidates every CDN URL an asset may be cached under
+func (svc *MediaService) PurgeAssetCache(assetID string) (*dto.CDNPurgeResponse, error) {
+	if svc.cdnBaseURL == "" || svc.cdnPurgeURL == "" {
+		return nil, shared.NewBadRequestError(nil, "CDN purging is not configured")
+	}
+
+	asset, err := svc.sqlSvc.mediaRepo.GetMediaAsset(assetID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Media asset not found")
+	}
+
+	urls := svc.cdnURLsForAsset(asset)
+	if err := svc.purgeCDN(urls...); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to purge CDN cache")
+	}
+
+	return &dto.CDNPurgeResponse{
+		AssetID:    asset.ID,
+		PurgedURLs: urls,
+	}, nil
+}
+
+// purgeReplacedURL drops a lesson's previous media URL from the CDN when it is replaced
+func (svc *MediaService) purgeReplacedURL(url string) {
+	if url == "" || svc.cdnBaseURL == "" || !strings.HasPrefix(url, svc.cdnBaseURL) {
+		return
+	}
+
+	if err := svc.purgeCDN(url); err != nil {
+		log.Printf("Failed to purge replaced CDN URL %s: %v", url, err)
+	}
+}
+
+// purgeCDN sends a purge request in the {"files": [...]} format used by most CDN APIs
+func (svc *MediaService) purgeCDN(urls ...string) error {
+	if svc.cdnPurgeURL == "" || len(urls) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(map[string][]string{"files": urls})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, svc.cdnPurgeURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if svc.cdnPurgeToken != "" {
+		req.Header.Set("Authorization", "Bearer "+svc.cdnPurgeToken)
+	}
+
+	resp, err := svc.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("CDN purge returned status %d", resp.StatusCode)
+	}
+
+	log.Printf("Purged %d URL(s) from CDN", len(urls))
+	return nil
+}
+
 // ==================== CLEANUP METHODS ====================
 
-func (svc *MediaService) DeleteMediaAsset(mediaAssetID string) error {
+func (svc *MediaService) DeleteMediaAsset(adminID, mediaAssetID string) error {
 	asset, err := svc.sqlSvc.mediaRepo.GetMediaAsset(mediaAssetID)
 	if err != nil {
 		return err
 	}
 
 	// Delete file from MinIO
-	if err := svc.minioSvc.DeleteFile(asset.StoragePath); err != nil {
+	if err := svc.minioSvc.DeleteFile(asset.Bucket, asset.StoragePath); err != nil {
 		log.Printf("Failed to delete file from MinIO %s: %v", asset.StoragePath, err)
 	}
 
 	// Delete database records
-	return svc.sqlSvc.mediaRepo.DeleteMediaAsset(mediaAssetID)
+	if err := svc.sqlSvc.mediaRepo.DeleteMediaAsset(mediaAssetID); err != nil {
+		return err
+	}
+
+	if err := svc.purgeCDN(svc.cdnURLsForAsset(asset)...); err != nil {
+		log.Printf("Failed to purge CDN cache for %s: %v", mediaAssetID, err)
+	}
+
+	svc.contentSvc.RecordContentAudit(adminID, model.ContentEntityMedia, mediaAssetID, model.ContentActionDelete, asset, nil)
+	return nil
+}
+
+// GetStorageConfig returns where each media category is stored and its lifecycle rules
+func (svc *MediaService) GetStorageConfig() *dto.MediaStorageConfigResponse {
+	return svc.minioSvc.GetStorageConfig()
 }
 
 func (svc *MediaService) GetMediaStatistics() (map[string]interface{}, error) {
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// A processing attempt not finished after this long is assumed lost, e.g. to a
+	// restart, and may be claimed again
+	mediaProcessingStaleAfter = 30 * time.Minute
+
+	// Content admins are alerted once an asset has failed this many times
+	defaultMediaProcessingAlertAfter = 3
+)
+
+// mediaNeedsProcessing reports whether a file type has processing steps. Other assets
+// complete as soon as they are picked up.
+func mediaNeedsProcessing(fileType string) bool {
+	return fileType == "video" || fileType == "animation"
+}
+
+// startMediaProcessing claims an asset and processes it in the background. It reports
+// false if the asset is already processed or being processed.
+func (svc *MediaService) startMediaProcessing(assetID string) (bool, error) {
+	claimed, err := svc.sqlSvc.mediaRepo.ClaimMediaProcessing(assetID, time.Now().Add(-mediaProcessingStaleAfter))
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	go svc.runMediaProcessing(assetID)
+	return true, nil
+}
+
+func (svc *MediaService) runMediaProcessing(assetID string) {
+	asset, err := svc.sqlSvc.mediaRepo.GetMediaAsset(assetID)
+	if err != nil {
+		log.WithError(err).Errorf("Failed to load media asset %s for processing", assetID)
+		return
+	}
+
+	processingErr := svc.processMediaAsset(asset)
+	if processingErr == nil {
+		if err := svc.sqlSvc.mediaRepo.FinishMediaProcessing(asset.ID, ""); err != nil {
+			log.WithError(err).Errorf("Failed to mark media asset %s processed", asset.ID)
+			return
+		}
+		svc.prewarmAssetLessons(asset.ID)
+		return
+	}
+
+	log.WithError(processingErr).Warnf("Processing media asset %s failed (attempt %d)", asset.ID, asset.ProcessingAttempts)
+	if err := svc.sqlSvc.mediaRepo.FinishMediaProcessing(asset.ID, truncate(processingErr.Error(), 2000)); err != nil {
+		log.WithError(err).Errorf("Failed to record processing failure for media asset %s", asset.ID)
+	}
+
+	if svc.processingAlertAfter > 0 && asset.ProcessingAttempts >= svc.processingAlertAfter {
+		svc.alertMediaProcessingFailure(asset, processingErr)
+	}
+}
+
+func (svc *MediaService) processMediaAsset(asset *model.MediaAsset) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("processing panicked: %v", r)
+		}
+	}()
+
+	if !mediaNeedsProcessing(asset.FileType) {
+		return nil
+	}
+
+	if err := svc.ProcessVideoMetadata(asset.ID); err != nil {
+		return fmt.Errorf("extract video metadata: %w", err)
+	}
+	if err := svc.GenerateVideoThumbnail(asset.ID); err != nil {
+		return fmt.Errorf("generate thumbnail: %w", err)
+	}
+	return nil
+}
+
+// alertMediaProcessingFailure tells the ops console, subscribed webhooks and the
+// configured content admin addresses that an asset keeps failing
+func (svc *MediaService) alertMediaProcessingFailure(asset *model.MediaAsset, cause error) {
+	data := map[string]interface{}{
+		"asset_id":  asset.ID,
+		"file_name": asset.OriginalName,
+		"file_type": asset.FileType,
+		"attempts":  asset.ProcessingAttempts,
+		"error":     cause.Error(),
+	}
+
+	svc.systemSvc.PublishOpsEvent(OpsEventMediaProcessing, OpsSeverityError, data)
+	svc.webhookSvc.Publish(model.WebhookEventMediaProcessingFailed, data)
+
+	for _, email := range svc.processingAlertEmails {
+		err := svc.emailSvc.SendMediaProcessingAlertEmail(email, MediaProcessingAlertEmailData{
+			AssetID:  asset.ID,
+			FileName: asset.OriginalName,
+			FileType: asset.FileType,
+			Attempts: asset.ProcessingAttempts,
+			Error:    cause.Error(),
+		})
+		if err != nil {
+			log.WithError(err).Errorf("Failed to send media processing alert to %s", email)
+		}
+	}
+}
+
+// GetFailedMediaProcessing lists assets whose last processing attempt failed, newest first
+func (svc *MediaService) GetFailedMediaProcessing(page, limit int) (*dto.MediaLibraryResponse, error) {
+	return svc.GetMediaLibrary(dto.MediaLibraryQuery{
+		ProcessingStatus: model.MediaProcessingFailed,
+		Page:             page,
+		Limit:            limit,
+	})
+}
+
+// RetryMediaProcessing processes a failed or stuck asset again
+func (svc *MediaService) RetryMediaProcessing(adminID, assetID string) (*dto.MediaLibraryItem, error) {
+	asset, err := svc.sqlSvc.mediaRepo.GetMediaAsset(assetID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Media asset not found")
+	}
+	if asset.ProcessingStatus == model.MediaProcessingCompleted {
+		return nil, shared.NewConflictError(nil, "Media asset is already processed")
+	}
+
+	started, err := svc.startMediaProcessing(asset.ID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to start media processing")
+	}
+	if !started {
+		return nil, shared.NewConflictError(nil, "Media asset is already being processed")
+	}
+
+	log.Infof("Admin %s retried processing of media asset %s", adminID, asset.ID)
+	return svc.GetMediaAssetDetails(asset.ID)
+}
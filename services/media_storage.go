@@ -0,0 +1,90 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Storage categories group file types that share a bucket, region and lifecycle
+const (
+	MediaCategoryVideo    = "video"
+	MediaCategoryAudio    = "audio"
+	MediaCategoryImage    = "image"
+	MediaCategorySubtitle = "subtitle"
+	MediaCategoryMisc     = "misc"
+)
+
+var mediaStorageCategories = []string{
+	MediaCategoryVideo,
+	MediaCategoryAudio,
+	MediaCategoryImage,
+	MediaCategorySubtitle,
+	MediaCategoryMisc,
+}
+
+// mediaStorageLayout maps each upload file type to its object directory and storage category
+var mediaStorageLayout = map[string]struct {
+	Dir      string
+	Category string
+}{
+	"video":             {"videos", MediaCategoryVideo},
+	"animation":         {"animations", MediaCategoryVideo},
+	"audio":             {"audio", MediaCategoryAudio},
+	"background_music":  {"background_music", MediaCategoryAudio},
+	"voice_over":        {"voice_over", MediaCategoryAudio},
+	"audio_description": {"audio_descriptions", MediaCategoryAudio},
+	"question_audio":    {"questions", MediaCategoryAudio},
+	"thumbnail":         {"thumbnails", MediaCategoryImage},
+	"illustration":      {"illustrations", MediaCategoryImage},
+	"question_image":    {"questions", MediaCategoryImage},
+	"share_card":        {"share_cards", MediaCategoryImage},
+	"certificate":       {"certificates", MediaCategoryImage},
+	"research_export":   {"research_exports", MediaCategoryMisc},
+	"subtitle":          {"subtitles", MediaCategorySubtitle},
+}
+
+// mediaObjectDir returns the directory new uploads of a file type are stored under
+func mediaObjectDir(fileType string) string {
+	if layout, ok := mediaStorageLayout[fileType]; ok {
+		return layout.Dir
+	}
+	return "misc"
+}
+
+// mediaCategory returns the storage category of a file type
+func mediaCategory(fileType string) string {
+	if layout, ok := mediaStorageLayout[fileType]; ok {
+		return layout.Category
+	}
+	return MediaCategoryMisc
+}
+
+// mediaCategoryFileTypes returns the file types stored in a category, sorted
+func mediaCategoryFileTypes(category string) []string {
+	var fileTypes []string
+	for fileType, layout := range mediaStorageLayout {
+		if layout.Category == category {
+			fileTypes = append(fileTypes, fileType)
+		}
+	}
+	sort.Strings(fileTypes)
+	return fileTypes
+}
+
+// mediaCategoryPrefixes returns the object prefixes holding the raw uploads of a category.
+// Published CDN copies live under cdn/ and are never part of a category.
+func mediaCategoryPrefixes(category string) []string {
+	seen := make(map[string]bool)
+	var prefixes []string
+	for _, fileType := range mediaCategoryFileTypes(category) {
+		prefix := fmt.Sprintf("%s/", mediaObjectDir(fileType))
+		if !seen[prefix] {
+			seen[prefix] = true
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	if category == MediaCategoryMisc {
+		prefixes = append(prefixes, "misc/")
+	}
+	return prefixes
+}
@@ -5,12 +5,18 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	appcontext "github.com/cloakd/common/context"
 	serviceContext "github.com/cloakd/common/services"
+	"github.com/lac-hong-legacy/ven_api/dto"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -22,10 +28,27 @@ type MinIOService struct {
 	accessKey  string
 	secretKey  string
 	useSSL     bool
+
+	// Bucket, region and lifecycle of every media category, see mediaStorageCategories.
+	// Categories without their own settings use the default bucket and region.
+	region   string
+	coldTier string
+	buckets  map[string]mediaBucket
+}
+
+type mediaBucket struct {
+	Name   string
+	Region string
+	// Raw uploads move to the cold tier after this many days, 0 keeps them where they are
+	ColdAfterDays int
 }
 
 const MINIO_SVC = "minio_svc"
 
+// Lifecycle rules created from the media storage config carry this ID prefix. Other
+// rules on the same buckets are left alone.
+const mediaLifecycleRulePrefix = "media-cold-"
+
 func (svc MinIOService) Id() string {
 	return MINIO_SVC
 }
@@ -53,6 +76,42 @@ func (svc *MinIOService) Configure(ctx *appcontext.Context) error {
 		svc.bucketName = "ven-learning"
 	}
 
+	svc.region = os.Getenv("MINIO_REGION")
+	svc.coldTier = os.Getenv("MINIO_COLD_TIER")
+
+	svc.buckets = make(map[string]mediaBucket)
+	regions := map[string]string{svc.bucketName: svc.region}
+	for _, category := range mediaStorageCategories {
+		env := "MINIO_" + strings.ToUpper(category) + "_"
+
+		bucket := mediaBucket{
+			Name:   os.Getenv(env + "BUCKET"),
+			Region: os.Getenv(env + "REGION"),
+		}
+		if bucket.Name == "" {
+			bucket.Name = svc.bucketName
+		}
+		if bucket.Region == "" {
+			bucket.Region = svc.region
+		}
+		if days := os.Getenv(env + "COLD_AFTER_DAYS"); days != "" {
+			value, err := strconv.Atoi(days)
+			if err != nil || value < 0 {
+				return fmt.Errorf("invalid %sCOLD_AFTER_DAYS: %q", env, days)
+			}
+			bucket.ColdAfterDays = value
+		}
+		if bucket.ColdAfterDays > 0 && svc.coldTier == "" {
+			return fmt.Errorf("%sCOLD_AFTER_DAYS requires MINIO_COLD_TIER", env)
+		}
+
+		if region, ok := regions[bucket.Name]; ok && region != bucket.Region {
+			return fmt.Errorf("bucket %s is configured in regions %q and %q", bucket.Name, region, bucket.Region)
+		}
+		regions[bucket.Name] = bucket.Region
+		svc.buckets[category] = bucket
+	}
+
 	return svc.DefaultService.Configure(ctx)
 }
 
@@ -67,37 +126,225 @@ func (svc *MinIOService) Start() error {
 
 	svc.client = client
 
-	if err := svc.ensureBucket(); err != nil {
-		return fmt.Errorf("failed to ensure bucket exists: %v", err)
+	for _, name := range svc.bucketNames() {
+		if err := svc.ensureBucket(name, svc.bucketRegion(name)); err != nil {
+			return fmt.Errorf("failed to ensure bucket %s exists: %v", name, err)
+		}
+		if err := svc.applyLifecycleRules(name); err != nil {
+			return fmt.Errorf("failed to apply lifecycle rules to bucket %s: %v", name, err)
+		}
 	}
 
 	log.Printf("MinIO service started successfully with endpoint: %s", svc.endpoint)
 	return nil
 }
 
-func (svc *MinIOService) ensureBucket() error {
+func (svc *MinIOService) ensureBucket(name, region string) error {
 	ctx := context.Background()
 
-	exists, err := svc.client.BucketExists(ctx, svc.bucketName)
+	exists, err := svc.client.BucketExists(ctx, name)
 	if err != nil {
 		return fmt.Errorf("failed to check bucket existence: %v", err)
 	}
 
 	if !exists {
-		err = svc.client.MakeBucket(ctx, svc.bucketName, minio.MakeBucketOptions{})
+		err = svc.client.MakeBucket(ctx, name, minio.MakeBucketOptions{Region: region})
 		if err != nil {
 			return fmt.Errorf("failed to create bucket: %v", err)
 		}
-		log.Printf("Created MinIO bucket: %s", svc.bucketName)
+		log.Printf("Created MinIO bucket: %s", name)
 	}
 
 	return nil
 }
 
-func (svc *MinIOService) UploadFile(objectName string, reader io.Reader, objectSize int64, contentType string) (*minio.UploadInfo, error) {
+// applyLifecycleRules replaces the media lifecycle rules of a bucket with the ones of the
+// current config, so removing a COLD_AFTER_DAYS setting also removes its rules
+func (svc *MinIOService) applyLifecycleRules(name string) error {
 	ctx := context.Background()
 
-	uploadInfo, err := svc.client.PutObject(ctx, svc.bucketName, objectName, reader, objectSize, minio.PutObjectOptions{
+	config, err := svc.getLifecycle(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	var rules []lifecycle.Rule
+	managed := 0
+	for _, rule := range config.Rules {
+		if strings.HasPrefix(rule.ID, mediaLifecycleRulePrefix) {
+			managed++
+			continue
+		}
+		rules = append(rules, rule)
+	}
+
+	wanted := svc.lifecycleRules(name)
+	if managed == 0 && len(wanted) == 0 {
+		return nil
+	}
+
+	config.Rules = append(rules, wanted...)
+	if err := svc.client.SetBucketLifecycle(ctx, name, config); err != nil {
+		return err
+	}
+
+	log.Printf("Applied %d media lifecycle rule(s) to MinIO bucket: %s", len(wanted), name)
+	return nil
+}
+
+// lifecycleRules builds the cold storage transitions of a bucket. When categories sharing
+// the bucket also share a prefix, the longest delay wins.
+func (svc *MinIOService) lifecycleRules(name string) []lifecycle.Rule {
+	days := make(map[string]int)
+	for category, bucket := range svc.buckets {
+		if bucket.Name != name || bucket.ColdAfterDays == 0 {
+			continue
+		}
+		for _, prefix := range mediaCategoryPrefixes(category) {
+			if bucket.ColdAfterDays > days[prefix] {
+				days[prefix] = bucket.ColdAfterDays
+			}
+		}
+	}
+
+	prefixes := make([]string, 0, len(days))
+	for prefix := range days {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	rules := make([]lifecycle.Rule, len(prefixes))
+	for i, prefix := range prefixes {
+		rules[i] = lifecycle.Rule{
+			ID:         mediaLifecycleRulePrefix + strings.TrimSuffix(prefix, "/"),
+			Status:     "Enabled",
+			RuleFilter: lifecycle.Filter{Prefix: prefix},
+			Transition: lifecycle.Transition{
+				Days:         lifecycle.ExpirationDays(days[prefix]),
+				StorageClass: svc.coldTier,
+			},
+		}
+	}
+	return rules
+}
+
+func (svc *MinIOService) getLifecycle(ctx context.Context, name string) (*lifecycle.Configuration, error) {
+	config, err := svc.client.GetBucketLifecycle(ctx, name)
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchLifecycleConfiguration" {
+			return lifecycle.NewConfiguration(), nil
+		}
+		return nil, err
+	}
+	return config, nil
+}
+
+// bucketNames returns every configured bucket, the default bucket first
+func (svc *MinIOService) bucketNames() []string {
+	names := []string{svc.bucketName}
+	seen := map[string]bool{svc.bucketName: true}
+	for _, category := range mediaStorageCategories {
+		if name := svc.buckets[category].Name; !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (svc *MinIOService) bucketRegion(name string) string {
+	for _, bucket := range svc.buckets {
+		if bucket.Name == name {
+			return bucket.Region
+		}
+	}
+	return svc.region
+}
+
+// bucket resolves the bucket of an object. Objects stored before buckets were configured
+// per category have no bucket recorded and live in the default bucket.
+func (svc *MinIOService) bucket(name string) string {
+	if name == "" {
+		return svc.bucketName
+	}
+	return name
+}
+
+// BucketFor returns the bucket new uploads of a file type are stored in
+func (svc *MinIOService) BucketFor(fileType string) string {
+	return svc.buckets[mediaCategory(fileType)].Name
+}
+
+// IsDefaultBucket reports whether objects of a bucket are reachable through the CDN,
+// which mirrors the default bucket only
+func (svc *MinIOService) IsDefaultBucket(name string) bool {
+	return svc.bucket(name) == svc.bucketName
+}
+
+// GetStorageConfig returns the bucket, region and cold storage settings of every media
+// category together with the lifecycle rules currently set on its bucket
+func (svc *MinIOService) GetStorageConfig() *dto.MediaStorageConfigResponse {
+	ctx := context.Background()
+
+	type bucketLifecycle struct {
+		config *lifecycle.Configuration
+		err    error
+	}
+	lifecycles := make(map[string]bucketLifecycle)
+
+	response := &dto.MediaStorageConfigResponse{
+		DefaultBucket: svc.bucketName,
+		DefaultRegion: svc.region,
+		ColdTier:      svc.coldTier,
+		Categories:    make([]dto.MediaStorageCategory, len(mediaStorageCategories)),
+	}
+
+	for i, category := range mediaStorageCategories {
+		bucket := svc.buckets[category]
+		prefixes := mediaCategoryPrefixes(category)
+
+		item := dto.MediaStorageCategory{
+			Category:       category,
+			FileTypes:      mediaCategoryFileTypes(category),
+			Bucket:         bucket.Name,
+			Region:         bucket.Region,
+			Prefixes:       prefixes,
+			ColdAfterDays:  bucket.ColdAfterDays,
+			LifecycleRules: []dto.MediaLifecycleRule{},
+		}
+
+		current, ok := lifecycles[bucket.Name]
+		if !ok {
+			current.config, current.err = svc.getLifecycle(ctx, bucket.Name)
+			lifecycles[bucket.Name] = current
+		}
+		if current.err != nil {
+			item.LifecycleError = current.err.Error()
+		} else {
+			for _, rule := range current.config.Rules {
+				if !strings.HasPrefix(rule.ID, mediaLifecycleRulePrefix) || !slices.Contains(prefixes, rule.RuleFilter.Prefix) {
+					continue
+				}
+				item.LifecycleRules = append(item.LifecycleRules, dto.MediaLifecycleRule{
+					ID:           rule.ID,
+					Prefix:       rule.RuleFilter.Prefix,
+					Status:       rule.Status,
+					Days:         int(rule.Transition.Days),
+					StorageClass: rule.Transition.StorageClass,
+				})
+			}
+		}
+
+		response.Categories[i] = item
+	}
+
+	return response
+}
+
+func (svc *MinIOService) UploadFile(bucket, objectName string, reader io.Reader, objectSize int64, contentType string) (*minio.UploadInfo, error) {
+	ctx := context.Background()
+
+	uploadInfo, err := svc.client.PutObject(ctx, svc.bucket(bucket), objectName, reader, objectSize, minio.PutObjectOptions{
 		ContentType: contentType,
 	})
 	if err != nil {
@@ -107,10 +354,10 @@ func (svc *MinIOService) UploadFile(objectName string, reader io.Reader, objectS
 	return &uploadInfo, nil
 }
 
-func (svc *MinIOService) GetFileURL(objectName string, expiry time.Duration) (string, error) {
+func (svc *MinIOService) GetFileURL(bucket, objectName string, expiry time.Duration) (string, error) {
 	ctx := context.Background()
 
-	presignedURL, err := svc.client.PresignedGetObject(ctx, svc.bucketName, objectName, expiry, nil)
+	presignedURL, err := svc.client.PresignedGetObject(ctx, svc.bucket(bucket), objectName, expiry, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate presigned URL: %v", err)
 	}
@@ -118,10 +365,36 @@ func (svc *MinIOService) GetFileURL(objectName string, expiry time.Duration) (st
 	return presignedURL.String(), nil
 }
 
-func (svc *MinIOService) DeleteFile(objectName string) error {
+// GetFile opens an object for reading; the caller closes it
+func (svc *MinIOService) GetFile(bucket, objectName string) (*minio.Object, error) {
 	ctx := context.Background()
 
-	err := svc.client.RemoveObject(ctx, svc.bucketName, objectName, minio.RemoveObjectOptions{})
+	object, err := svc.client.GetObject(ctx, svc.bucket(bucket), objectName, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file from MinIO: %v", err)
+	}
+
+	return object, nil
+}
+
+func (svc *MinIOService) CopyFile(srcBucket, srcObjectName, dstBucket, dstObjectName string) error {
+	ctx := context.Background()
+
+	_, err := svc.client.CopyObject(ctx,
+		minio.CopyDestOptions{Bucket: svc.bucket(dstBucket), Object: dstObjectName},
+		minio.CopySrcOptions{Bucket: svc.bucket(srcBucket), Object: srcObjectName},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to copy file in MinIO: %v", err)
+	}
+
+	return nil
+}
+
+func (svc *MinIOService) DeleteFile(bucket, objectName string) error {
+	ctx := context.Background()
+
+	err := svc.client.RemoveObject(ctx, svc.bucket(bucket), objectName, minio.RemoveObjectOptions{})
 	if err != nil {
 		return fmt.Errorf("failed to delete file from MinIO: %v", err)
 	}
@@ -129,10 +402,10 @@ func (svc *MinIOService) DeleteFile(objectName string) error {
 	return nil
 }
 
-func (svc *MinIOService) GetFileInfo(objectName string) (*minio.ObjectInfo, error) {
+func (svc *MinIOService) GetFileInfo(bucket, objectName string) (*minio.ObjectInfo, error) {
 	ctx := context.Background()
 
-	objInfo, err := svc.client.StatObject(ctx, svc.bucketName, objectName, minio.StatObjectOptions{})
+	objInfo, err := svc.client.StatObject(ctx, svc.bucket(bucket), objectName, minio.StatObjectOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get file info: %v", err)
 	}
@@ -140,11 +413,11 @@ func (svc *MinIOService) GetFileInfo(objectName string) (*minio.ObjectInfo, erro
 	return &objInfo, nil
 }
 
-func (svc *MinIOService) ListFiles(prefix string) ([]minio.ObjectInfo, error) {
+func (svc *MinIOService) ListFiles(bucket, prefix string) ([]minio.ObjectInfo, error) {
 	ctx := context.Background()
 
 	var objects []minio.ObjectInfo
-	objectCh := svc.client.ListObjects(ctx, svc.bucketName, minio.ListObjectsOptions{
+	objectCh := svc.client.ListObjects(ctx, svc.bucket(bucket), minio.ListObjectsOptions{
 		Prefix:    prefix,
 		Recursive: true,
 	})
@@ -159,6 +432,66 @@ func (svc *MinIOService) ListFiles(prefix string) ([]minio.ObjectInfo, error) {
 	return objects, nil
 }
 
+// ==================== MULTIPART UPLOAD METHODS ====================
+
+func (svc *MinIOService) NewMultipartUpload(bucket, objectName, contentType string) (string, error) {
+	core := minio.Core{Client: svc.client}
+
+	uploadID, err := core.NewMultipartUpload(context.Background(), svc.bucket(bucket), objectName, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to start multipart upload: %v", err)
+	}
+
+	return uploadID, nil
+}
+
+func (svc *MinIOService) PutObjectPart(bucket, objectName, uploadID string, partNumber int, reader io.Reader, size int64) (minio.ObjectPart, error) {
+	core := minio.Core{Client: svc.client}
+
+	part, err := core.PutObjectPart(context.Background(), svc.bucket(bucket), objectName, uploadID, partNumber, reader, size, minio.PutObjectPartOptions{})
+	if err != nil {
+		return minio.ObjectPart{}, fmt.Errorf("failed to upload part %d: %v", partNumber, err)
+	}
+
+	return part, nil
+}
+
+func (svc *MinIOService) CompleteMultipartUpload(bucket, objectName, uploadID string, parts []minio.CompletePart) error {
+	core := minio.Core{Client: svc.client}
+
+	if _, err := core.CompleteMultipartUpload(context.Background(), svc.bucket(bucket), objectName, uploadID, parts, minio.PutObjectOptions{}); err != nil {
+		return fmt.Errorf("failed to complete multipart upload: %v", err)
+	}
+
+	return nil
+}
+
+func (svc *MinIOService) AbortMultipartUpload(bucket, objectName, uploadID string) error {
+	core := minio.Core{Client: svc.client}
+
+	if err := core.AbortMultipartUpload(context.Background(), svc.bucket(bucket), objectName, uploadID); err != nil {
+		return fmt.Errorf("failed to abort multipart upload: %v", err)
+	}
+
+	return nil
+}
+
+// Ping checks that every storage bucket is reachable
+func (svc *MinIOService) Ping(ctx context.Context) error {
+	for _, name := range svc.bucketNames() {
+		exists, err := svc.client.BucketExists(ctx, name)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("bucket %s does not exist", name)
+		}
+	}
+	return nil
+}
+
 func (svc *MinIOService) GetBucketName() string {
 	return svc.bucketName
 }
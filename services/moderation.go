@@ -0,0 +1,356 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	appContext "github.com/cloakd/common/context"
+	serviceContext "github.com/cloakd/common/services"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ModerationService screens user text for profanity (Vietnamese and English) and
+// personal data before it is stored. What happens to text that matches is configured
+// per context, see model.ModerationContextUsername and friends.
+type ModerationService struct {
+	serviceContext.DefaultService
+	sqlSvc *PostgresService
+
+	actions map[string]string
+	terms   []moderationTerm
+}
+
+const MODERATION_SVC = "moderation_svc"
+
+// Kinds of content moderation looks for
+const (
+	ModerationMatchProfanity = "profanity"
+	ModerationMatchEmail     = "email"
+	ModerationMatchPhone     = "phone"
+	ModerationMatchIDNumber  = "id_number"
+)
+
+type moderationTerm struct {
+	text []rune
+	// Also matched inside longer words. Only used for terms no harmless word contains,
+	// mostly to catch them in usernames, which have no spaces.
+	anywhere bool
+}
+
+type moderationMatch struct {
+	kind       string
+	start, end int // byte offsets in the original text
+}
+
+// ModerationResult is the outcome of moderating one piece of user text
+type ModerationResult struct {
+	Context string
+	Text    string   // text to store, masked when the action is mask
+	Action  string   // action taken, empty when nothing was found
+	Reasons []string // kinds of content found
+}
+
+// Whole words, matched case-insensitively after undoing common digit and symbol swaps
+var moderationWords = []string{
+	// English
+	"fuck", "fucker", "fucking", "motherfucker", "shit", "bullshit", "bitch", "bastard",
+	"asshole", "cunt", "dick", "pussy", "whore", "slut", "nigger", "nigga", "faggot", "retard",
+	// Vietnamese, with and without diacritics where the plain form is unambiguous
+	"địt", "đụ", "đĩ", "lồn", "buồi", "cặc", "đéo", "đm", "đmm", "đcm", "dcm", "vcl", "vkl",
+	"clgt", "địt mẹ", "đụ má", "đồ chó", "chó đẻ", "con đĩ", "mẹ mày", "dit me", "du ma",
+}
+
+// Terms matched anywhere, including inside usernames like "xfuckx"
+var moderationStems = []string{
+	"fuck", "shit", "cunt", "bitch", "nigger", "faggot", "asshole",
+	"ditme", "ditmemay", "duma", "dume", "concac", "cailon", "occho",
+}
+
+var moderationPIIPatterns = []struct {
+	kind    string
+	pattern *regexp.Regexp
+}{
+	{ModerationMatchEmail, regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)},
+	// Vietnamese mobile and landline numbers, optionally grouped
+	{ModerationMatchPhone, regexp.MustCompile(`(?:\+84|\b0)(?:[ .-]?\d){9}\b`)},
+	// Citizen ID (12 digits) and the older ID card (9 digits)
+	{ModerationMatchIDNumber, regexp.MustCompile(`\b(?:\d{12}|\d{9})\b`)},
+}
+
+var moderationLeet = map[rune]rune{
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '@': 'a', '$': 's',
+}
+
+func (svc ModerationService) Id() string {
+	return MODERATION_SVC
+}
+
+func (svc *ModerationService) Configure(ctx *appContext.Context) error {
+	defaultAction := os.Getenv("MODERATION_ACTION")
+	if defaultAction == "" {
+		defaultAction = model.ModerationActionReject
+	}
+
+	svc.actions = make(map[string]string)
+	contexts := []string{
+		model.ModerationContextUsername,
+		model.ModerationContextSpiritName,
+		model.ModerationContextLessonFeedback,
+		model.ModerationContextChat,
+	}
+	for _, context := range contexts {
+		env := "MODERATION_" + strings.ToUpper(context) + "_ACTION"
+		action := os.Getenv(env)
+		if action == "" {
+			action = defaultAction
+		}
+
+		switch action {
+		case model.ModerationActionReject, model.ModerationActionFlag:
+		case model.ModerationActionMask:
+			// A masked username would no longer be a valid or recognisable name
+			if context == model.ModerationContextUsername {
+				return fmt.Errorf("%s: usernames can't be masked, use reject or flag", env)
+			}
+		default:
+			return fmt.Errorf("invalid %s: %q", env, action)
+		}
+		svc.actions[context] = action
+	}
+
+	words := moderationWords
+	for _, word := range strings.Split(os.Getenv("MODERATION_EXTRA_WORDS"), ",") {
+		if word = strings.TrimSpace(word); word != "" {
+			words = append(words, word)
+		}
+	}
+	for _, word := range words {
+		svc.terms = append(svc.terms, moderationTerm{text: foldModerationText(word)})
+	}
+	for _, stem := range moderationStems {
+		svc.terms = append(svc.terms, moderationTerm{text: foldModerationText(stem), anywhere: true})
+	}
+
+	return svc.DefaultService.Configure(ctx)
+}
+
+func (svc *ModerationService) Start() error {
+	deps := newDependencies(svc.Id(), svc.Service)
+	svc.sqlSvc = resolve[*PostgresService](deps, POSTGRES_SVC)
+	return deps.err()
+}
+
+// Moderate checks user text and applies the action configured for its context. Rejected
+// text returns a bad request error naming what was found. Flagged text is kept as is and
+// the result must be passed to Flag once the text is saved.
+func (svc *ModerationService) Moderate(context, text string) (*ModerationResult, error) {
+	// Some Vietnamese keyboards send decomposed diacritics
+	text = norm.NFC.String(text)
+	result := &ModerationResult{Context: context, Text: text}
+
+	matches := svc.scan(text)
+	if len(matches) == 0 {
+		return result, nil
+	}
+
+	result.Action = svc.actions[context]
+	if result.Action == "" {
+		result.Action = model.ModerationActionReject
+	}
+
+	seen := make(map[string]bool)
+	for _, match := range matches {
+		if !seen[match.kind] {
+			seen[match.kind] = true
+			result.Reasons = append(result.Reasons, match.kind)
+		}
+	}
+	sort.Strings(result.Reasons)
+
+	switch result.Action {
+	case model.ModerationActionMask:
+		result.Text = maskModerationMatches(text, matches)
+	case model.ModerationActionReject:
+		return nil, shared.NewBadRequestError(errors.New("text failed moderation"),
+			"Text contains inappropriate language or personal information").WithData(map[string]interface{}{
+			"context": context,
+			"reasons": result.Reasons,
+		})
+	}
+
+	return result, nil
+}
+
+// Flag queues flagged text for review. Results of other actions are ignored.
+func (svc *ModerationService) Flag(userID string, result *ModerationResult) {
+	if result == nil || result.Action != model.ModerationActionFlag {
+		return
+	}
+
+	if err := svc.sqlSvc.userRepo.CreateModerationFlag(&model.ModerationFlag{
+		UserID:  userID,
+		Context: result.Context,
+		Text:    result.Text,
+		Reasons: strings.Join(result.Reasons, ","),
+	}); err != nil {
+		log.Printf("Failed to flag %s of user %s: %v", result.Context, userID, err)
+	}
+}
+
+// scan finds profanity and personal data in NFC normalized text
+func (svc *ModerationService) scan(text string) []moderationMatch {
+	var matches []moderationMatch
+	for _, pii := range moderationPIIPatterns {
+		for _, loc := range pii.pattern.FindAllStringIndex(text, -1) {
+			matches = append(matches, moderationMatch{kind: pii.kind, start: loc[0], end: loc[1]})
+		}
+	}
+
+	// Fold rune by rune, remembering where each rune starts, so matches map back to the text
+	folded := make([]rune, 0, len(text))
+	offsets := make([]int, 0, len(text)+1)
+	for i, r := range text {
+		folded = append(folded, foldModerationRune(r))
+		offsets = append(offsets, i)
+	}
+	offsets = append(offsets, len(text))
+
+	isWordRune := func(i int) bool {
+		return i >= 0 && i < len(folded) && (unicode.IsLetter(folded[i]) || unicode.IsDigit(folded[i]))
+	}
+
+	for _, term := range svc.terms {
+		n := len(term.text)
+		for i := 0; i+n <= len(folded); i++ {
+			if !slices.Equal(folded[i:i+n], term.text) {
+				continue
+			}
+			if !term.anywhere && (isWordRune(i-1) || isWordRune(i+n)) {
+				continue
+			}
+			matches = append(matches, moderationMatch{kind: ModerationMatchProfanity, start: offsets[i], end: offsets[i+n]})
+		}
+	}
+
+	return matches
+}
+
+// maskModerationMatches replaces every character inside a match with an asterisk
+func maskModerationMatches(text string, matches []moderationMatch) string {
+	masked := make([]bool, len(text)+1)
+	for _, match := range matches {
+		for i := match.start; i < match.end; i++ {
+			masked[i] = true
+		}
+	}
+
+	var out strings.Builder
+	for i, r := range text {
+		if masked[i] && !unicode.IsSpace(r) {
+			out.WriteRune('*')
+		} else {
+			out.WriteRune(r)
+		}
+	}
+	return out.String()
+}
+
+func foldModerationText(text string) []rune {
+	text = norm.NFC.String(text)
+	folded := make([]rune, 0, utf8.RuneCountInString(text))
+	for _, r := range text {
+		folded = append(folded, foldModerationRune(r))
+	}
+	return folded
+}
+
+func foldModerationRune(r rune) rune {
+	r = unicode.ToLower(r)
+	if plain, ok := moderationLeet[r]; ok {
+		return plain
+	}
+	return r
+}
+
+// ==================== REVIEW QUEUE ====================
+
+func (svc *UserService) GetModerationFlags(status string, page, limit int) (*dto.ModerationFlagListResponse, error) {
+	flags, total, err := svc.userRepo.GetModerationFlags(status, page, limit)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get moderation flags")
+	}
+
+	responses := make([]dto.ModerationFlagResponse, len(flags))
+	for i := range flags {
+		responses[i] = mapModerationFlag(&flags[i])
+	}
+
+	return &dto.ModerationFlagListResponse{
+		Flags: responses,
+		Total: int(total),
+		Page:  page,
+		Limit: limit,
+	}, nil
+}
+
+// ReviewModerationFlag dismisses a flag, or bans the flagged user and closes all their
+// pending moderation flags
+func (svc *UserService) ReviewModerationFlag(adminID, flagID string, req dto.ReviewModerationFlagRequest) (*dto.ModerationFlagResponse, error) {
+	flag, err := svc.userRepo.GetModerationFlag(flagID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Moderation flag not found")
+	}
+	if flag.Status != model.FlagStatusPending {
+		return nil, shared.NewConflictError(nil, "Moderation flag has already been reviewed")
+	}
+
+	switch req.Action {
+	case "ban":
+		if err := svc.banUser(flag.UserID); err != nil {
+			return nil, err
+		}
+		if _, err := svc.userRepo.ResolveModerationFlags(flag.ID, flag.UserID, model.FlagStatusBanned, adminID, req.Note); err != nil {
+			return nil, shared.NewInternalError(err, "Failed to update moderation flags")
+		}
+		log.Printf("User %s banned by %s after moderation review", flag.UserID, adminID)
+	default:
+		if _, err := svc.userRepo.ResolveModerationFlags(flag.ID, "", model.FlagStatusDismissed, adminID, req.Note); err != nil {
+			return nil, shared.NewInternalError(err, "Failed to update moderation flag")
+		}
+	}
+
+	flag, err = svc.userRepo.GetModerationFlag(flagID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get moderation flag")
+	}
+
+	response := mapModerationFlag(flag)
+	return &response, nil
+}
+
+func mapModerationFlag(flag *model.ModerationFlag) dto.ModerationFlagResponse {
+	return dto.ModerationFlagResponse{
+		ID:         flag.ID,
+		UserID:     flag.UserID,
+		Username:   flag.User.Username,
+		Context:    flag.Context,
+		Text:       flag.Text,
+		Reasons:    strings.Split(flag.Reasons, ","),
+		Status:     flag.Status,
+		ReviewedBy: flag.ReviewedBy,
+		ReviewNote: flag.ReviewNote,
+		ReviewedAt: flag.ReviewedAt,
+		CreatedAt:  flag.CreatedAt,
+	}
+}
@@ -175,6 +175,10 @@ func (svc *MonitoringService) Start() error {
 		memoryUsageBytes,
 		memoryUsagePercent,
 		traceSpanDurationSeconds,
+		dbQueryDurationSeconds,
+		dbSlowQueriesTotal,
+		progressCacheRequestsTotal,
+		apiVersionRequestsTotal,
 	)
 
 	svc.register = reg
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/cloakd/common/context"
+	serviceContext "github.com/cloakd/common/services"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	log "github.com/sirupsen/logrus"
+)
+
+type NotificationService struct {
+	serviceContext.DefaultService
+
+	sqlSvc      *PostgresService
+	eventBusSvc *EventBusService
+	outboxSvc   *OutboxService
+	emailSvc    *EmailService
+}
+
+const NOTIFICATION_SVC = "notification_svc"
+
+func (svc NotificationService) Id() string {
+	return NOTIFICATION_SVC
+}
+
+func (svc *NotificationService) Configure(ctx *context.Context) error {
+	return svc.DefaultService.Configure(ctx)
+}
+
+func (svc *NotificationService) Start() error {
+	deps := newDependencies(svc.Id(), svc.Service)
+	svc.sqlSvc = resolve[*PostgresService](deps, POSTGRES_SVC)
+	svc.eventBusSvc = resolve[*EventBusService](deps, EVENT_BUS_SVC)
+	svc.outboxSvc = resolve[*OutboxService](deps, OUTBOX_SVC)
+	svc.emailSvc = resolve[*EmailService](deps, EMAIL_SVC)
+	if err := deps.err(); err != nil {
+		return err
+	}
+
+	svc.eventBusSvc.Subscribe(EventLevelUp, NOTIFICATION_SVC, func(event DomainEvent) {
+		e := event.(*LevelUpEvent)
+		svc.Notify(e.UserID, model.NotificationTypeAchievement, "Level up!",
+			fmt.Sprintf("You reached level %d", e.Level),
+			map[string]interface{}{"level": e.Level})
+	})
+	svc.eventBusSvc.Subscribe(EventCharacterUnlocked, NOTIFICATION_SVC, func(event DomainEvent) {
+		e := event.(*CharacterUnlockedEvent)
+		svc.Notify(e.UserID, model.NotificationTypeAchievement, "New character unlocked",
+			fmt.Sprintf("%s has joined your collection", e.CharacterName),
+			map[string]interface{}{"character_id": e.CharacterID})
+	})
+
+	svc.outboxSvc.Handle(OutboxTopicStudyReminderEmail, func(payload json.RawMessage) error {
+		var email StudyReminderEmail
+		if err := json.Unmarshal(payload, &email); err != nil {
+			return err
+		}
+		return svc.emailSvc.SendStudyReminderEmail(email.Email, email.Username, email.Streak)
+	})
+
+	go svc.startStudyReminderJob()
+
+	return nil
+}
+
+// Notify adds an entry to the user's inbox. Other subsystems call this when something
+// worth telling the user happens; failures are logged so they never break the caller.
+func (svc *NotificationService) Notify(userID, notificationType, title, body string, data map[string]interface{}) {
+	notification := &model.Notification{
+		UserID: userID,
+		Type:   notificationType,
+		Title:  title,
+		Body:   body,
+	}
+
+	if len(data) > 0 {
+		payload, err := json.Marshal(data)
+		if err != nil {
+			log.Printf("Failed to marshal notification data for user %s: %v", userID, err)
+		} else {
+			notification.Data = payload
+		}
+	}
+
+	if err := svc.sqlSvc.notificationRepo.CreateNotification(notification); err != nil {
+		log.Printf("Failed to create notification for user %s: %v", userID, err)
+	}
+}
+
+// Broadcast adds an entry to the inbox of every active user, e.g. for announcements
+func (svc *NotificationService) Broadcast(notificationType, title, body string, data map[string]interface{}) (int64, error) {
+	var payload []byte
+	if len(data) > 0 {
+		var err error
+		if payload, err = json.Marshal(data); err != nil {
+			return 0, err
+		}
+	}
+
+	return svc.sqlSvc.notificationRepo.CreateBroadcastNotification(notificationType, title, body, payload)
+}
+
+func (svc *NotificationService) GetNotifications(userID string, unreadOnly bool, page, limit int) (*dto.NotificationListResponse, error) {
+	notifications, total, err := svc.sqlSvc.notificationRepo.GetUserNotifications(userID, unreadOnly, page, limit)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get notifications")
+	}
+
+	unread, err := svc.sqlSvc.notificationRepo.CountUnread(userID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to count unread notifications")
+	}
+
+	responses := make([]dto.NotificationResponse, len(notifications))
+	for i, n := range notifications {
+		responses[i] = dto.NotificationResponse{
+			ID:        n.ID,
+			Type:      n.Type,
+			Title:     n.Title,
+			Body:      n.Body,
+			Data:      n.Data,
+			IsRead:    n.IsRead,
+			ReadAt:    n.ReadAt,
+			CreatedAt: n.CreatedAt,
+		}
+	}
+
+	return &dto.NotificationListResponse{
+		Notifications: responses,
+		UnreadCount:   int(unread),
+		Total:         int(total),
+		Page:          page,
+		Limit:         limit,
+	}, nil
+}
+
+func (svc *NotificationService) GetUnreadCount(userID string) (*dto.UnreadCountResponse, error) {
+	unread, err := svc.sqlSvc.notificationRepo.CountUnread(userID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to count unread notifications")
+	}
+
+	return &dto.UnreadCountResponse{UnreadCount: int(unread)}, nil
+}
+
+func (svc *NotificationService) MarkRead(userID, notificationID string) (*dto.UnreadCountResponse, error) {
+	found, err := svc.sqlSvc.notificationRepo.MarkRead(userID, notificationID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to mark notification as read")
+	}
+	if !found {
+		return nil, shared.NewNotFoundError(nil, "Notification not found")
+	}
+
+	return svc.GetUnreadCount(userID)
+}
+
+func (svc *NotificationService) MarkAllRead(userID string) (*dto.UnreadCountResponse, error) {
+	if _, err := svc.sqlSvc.notificationRepo.MarkAllRead(userID); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to mark notifications as read")
+	}
+
+	return &dto.UnreadCountResponse{UnreadCount: 0}, nil
+}
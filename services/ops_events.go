@@ -0,0 +1,104 @@
+package services
+
+import (
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+)
+
+// Event types of the admin live operations stream
+const (
+	OpsEventLogin             = "login"
+	OpsEventRegistration      = "registration"
+	OpsEventRateLimitBlock    = "rate_limit_block"
+	OpsEventError             = "error"
+	OpsEventLessonCompletions = "lesson_completions"
+	OpsEventTableSize         = "table_size"
+	OpsEventMaintenance       = "maintenance"
+	OpsEventMediaProcessing   = "media_processing"
+	OpsEventAuditChain        = "audit_chain"
+	OpsEventCacheInvalidation = "cache_invalidation"
+	OpsEventCDNPrewarm        = "cdn_prewarm"
+	OpsEventContentKillSwitch = "content_kill_switch"
+)
+
+// Ops event severities, lowest first
+const (
+	OpsSeverityInfo    = "info"
+	OpsSeverityWarning = "warning"
+	OpsSeverityError   = "error"
+)
+
+// opsSubscriberBuffer is how many events a slow console may fall behind before
+// further events are dropped for it
+const opsSubscriberBuffer = 256
+
+var opsSeverityRank = map[string]int{
+	OpsSeverityInfo:    0,
+	OpsSeverityWarning: 1,
+	OpsSeverityError:   2,
+}
+
+// IsValidOpsSeverity reports whether severity is a known ops event severity
+func IsValidOpsSeverity(severity string) bool {
+	_, ok := opsSeverityRank[severity]
+	return ok
+}
+
+// PublishOpsEvent sends an event to every console subscribed at or below its severity.
+// It never blocks; consoles that are not keeping up miss events.
+func (svc *SystemService) PublishOpsEvent(eventType, severity string, data map[string]interface{}) {
+	event := dto.OpsEvent{
+		Type:      eventType,
+		Severity:  severity,
+		Data:      data,
+		Timestamp: time.Now(),
+	}
+
+	svc.opsMutex.RLock()
+	defer svc.opsMutex.RUnlock()
+
+	for ch, minSeverity := range svc.opsSubscribers {
+		if opsSeverityRank[severity] < opsSeverityRank[minSeverity] {
+			continue
+		}
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// SubscribeOpsEvents registers a console for events at or above minSeverity. The
+// returned function unsubscribes and closes the channel.
+func (svc *SystemService) SubscribeOpsEvents(minSeverity string) (<-chan dto.OpsEvent, func()) {
+	ch := make(chan dto.OpsEvent, opsSubscriberBuffer)
+
+	svc.opsMutex.Lock()
+	svc.opsSubscribers[ch] = minSeverity
+	svc.opsMutex.Unlock()
+
+	return ch, func() {
+		svc.opsMutex.Lock()
+		defer svc.opsMutex.Unlock()
+
+		if _, ok := svc.opsSubscribers[ch]; ok {
+			delete(svc.opsSubscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// RecordLessonCompletion counts a completed lesson towards the per-minute throughput
+func (svc *SystemService) RecordLessonCompletion() {
+	svc.lessonCompletions.Add(1)
+}
+
+func (svc *SystemService) startLessonThroughputReporter() {
+	ticker := time.NewTicker(time.Minute)
+	for range ticker.C {
+		svc.PublishOpsEvent(OpsEventLessonCompletions, OpsSeverityInfo, map[string]interface{}{
+			"per_minute": svc.lessonCompletions.Swap(0),
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cloakd/common/context"
+	serviceContext "github.com/cloakd/common/services"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared/ids"
+	log "github.com/sirupsen/logrus"
+)
+
+// Outbox topics
+const (
+	OutboxTopicVerificationEmail      = "email.verification"
+	OutboxTopicPasswordResetEmail     = "email.password_reset"
+	OutboxTopicLoginNotificationEmail = "email.login_notification"
+	OutboxTopicEmailChangeEmails      = "email.email_change"
+	OutboxTopicAccountRecoveryEmail   = "email.account_recovery"
+	OutboxTopicSecurityDigestEmail    = "email.security_digest"
+	OutboxTopicBackupCodesLowEmail    = "email.backup_codes_low"
+	OutboxTopicStudyReminderEmail     = "email.study_reminder"
+	OutboxTopicInactivityWarningEmail = "email.inactivity_warning"
+	OutboxTopicAuthAudit              = "audit.auth"
+	// Every email topic starts with this prefix
+	outboxTopicEmailPrefix = "email."
+	// Domain events use "event." followed by the event name
+	outboxTopicEventPrefix = "event."
+)
+
+const (
+	outboxPollInterval = 2 * time.Second
+	outboxBatchSize    = 100
+	// New messages and claimed batches are hidden from the worker for this long, so
+	// the relay that owns them has time to dispatch before anyone retries
+	outboxLease = time.Minute
+
+	// Retries back off from outboxBaseBackoff, doubling each time, over outboxMaxAttempts
+	outboxMaxAttempts = 12
+	outboxBaseBackoff = 10 * time.Second
+	outboxMaxBackoff  = time.Hour
+
+	outboxRetention = 7 * 24 * time.Hour
+)
+
+// OutboxService dispatches messages written by the transactional outbox. The code that
+// commits a state change relays its messages straight away; a background worker picks
+// up anything left behind by a crash or a failed handler and retries it with backoff.
+type OutboxService struct {
+	serviceContext.DefaultService
+
+	sqlSvc *PostgresService
+
+	mutex    sync.RWMutex
+	handlers map[string]func(payload json.RawMessage) error
+}
+
+const OUTBOX_SVC = "outbox_svc"
+
+func (svc *OutboxService) Id() string {
+	return OUTBOX_SVC
+}
+
+func (svc *OutboxService) Configure(ctx *context.Context) error {
+	// Created here rather than in Start so services started earlier can register handlers
+	svc.handlers = make(map[string]func(payload json.RawMessage) error)
+	return svc.DefaultService.Configure(ctx)
+}
+
+func (svc *OutboxService) Start() error {
+	deps := newDependencies(svc.Id(), svc.Service)
+	svc.sqlSvc = resolve[*PostgresService](deps, POSTGRES_SVC)
+	if err := deps.err(); err != nil {
+		return err
+	}
+
+	go svc.startRelayWorker()
+	go svc.startCleanupJob()
+
+	return nil
+}
+
+// Handle registers the handler for a topic. A handler that returns an error is retried.
+func (svc *OutboxService) Handle(topic string, handler func(payload json.RawMessage) error) {
+	svc.mutex.Lock()
+	defer svc.mutex.Unlock()
+
+	svc.handlers[topic] = handler
+}
+
+// newOutboxMessage builds a message to pass to a repository write. It starts leased so
+// the worker leaves it to the caller's Relay.
+func newOutboxMessage(topic string, payload interface{}) *model.OutboxMessage {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		// Only reachable with an unmarshalable payload type, which is a programming error
+		log.WithError(err).WithField("topic", topic).Error("Failed to marshal outbox payload")
+		data = json.RawMessage("null")
+	}
+
+	now := time.Now()
+	return &model.OutboxMessage{
+		ID:            ids.New(),
+		Topic:         topic,
+		Payload:       data,
+		Status:        model.OutboxStatusPending,
+		NextAttemptAt: now.Add(outboxLease),
+		CreatedAt:     now,
+	}
+}
+
+// Relay dispatches messages once the transaction that wrote them has committed.
+// It runs the handlers in the caller's goroutine.
+func (svc *OutboxService) Relay(messages ...*model.OutboxMessage) {
+	for _, message := range messages {
+		svc.dispatch(message)
+	}
+}
+
+func (svc *OutboxService) startRelayWorker() {
+	ticker := time.NewTicker(outboxPollInterval)
+	for range ticker.C {
+		messages, err := svc.sqlSvc.outboxRepo.ClaimDue(outboxBatchSize, outboxLease)
+		if err != nil {
+			log.WithError(err).Error("Failed to claim outbox messages")
+			continue
+		}
+
+		for i := range messages {
+			svc.dispatch(&messages[i])
+		}
+	}
+}
+
+func (svc *OutboxService) startCleanupJob() {
+	ticker := time.NewTicker(24 * time.Hour)
+	for range ticker.C {
+		deleted, err := svc.sqlSvc.outboxRepo.DeleteSentBefore(time.Now().Add(-outboxRetention))
+		if err != nil {
+			log.WithError(err).Error("Failed to clean up outbox")
+			continue
+		}
+		if deleted > 0 {
+			log.Printf("Removed %d sent outbox messages", deleted)
+		}
+	}
+}
+
+func (svc *OutboxService) dispatch(message *model.OutboxMessage) {
+	svc.mutex.RLock()
+	handler, ok := svc.handlers[message.Topic]
+	svc.mutex.RUnlock()
+
+	var err error
+	if ok {
+		err = svc.runHandler(handler, message)
+	} else {
+		err = fmt.Errorf("no handler for topic %s", message.Topic)
+	}
+
+	message.Attempts++
+	if err == nil {
+		now := time.Now()
+		message.Status = model.OutboxStatusSent
+		message.SentAt = &now
+		message.LastError = ""
+	} else {
+		message.LastError = truncate(err.Error(), 500)
+		if message.Attempts >= outboxMaxAttempts {
+			message.Status = model.OutboxStatusFailed
+			log.WithError(err).WithFields(log.Fields{
+				"message_id": message.ID,
+				"topic":      message.Topic,
+			}).Error("Outbox message failed permanently")
+		} else {
+			message.NextAttemptAt = time.Now().Add(outboxBackoff(message.Attempts))
+		}
+	}
+
+	if err := svc.sqlSvc.outboxRepo.UpdateMessage(message); err != nil {
+		log.WithError(err).WithField("message_id", message.ID).Error("Failed to update outbox message")
+	}
+}
+
+func (svc *OutboxService) runHandler(handler func(payload json.RawMessage) error, message *model.OutboxMessage) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+	return handler(message.Payload)
+}
+
+func outboxBackoff(attempts int) time.Duration {
+	backoff := outboxBaseBackoff << (attempts - 1)
+	if backoff <= 0 || backoff > outboxMaxBackoff {
+		return outboxMaxBackoff
+	}
+	return backoff
+}
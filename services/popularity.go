@@ -0,0 +1,294 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Hash of counters not yet added to the database, fields are <type>:<id>:<metric>
+	popularityPendingKey    = "ven:popularity:pending"
+	popularityFlushingKey   = "ven:popularity:flushing:"
+	popularityFlushInterval = time.Minute
+	// A flush that died halfway leaves its copy behind for this long
+	popularityFlushingTTL = 24 * time.Hour
+
+	trendingDays     = 7
+	trendingCacheTTL = 5 * time.Minute
+	// Entities loaded per type before age filtering, the most a request can ask for
+	trendingPoolSize = 50
+)
+
+// Counters kept per lesson and character
+const (
+	popularityMetricViews       = "views"
+	popularityMetricStarts      = "starts"
+	popularityMetricCompletions = "completions"
+	popularityMetricVideoPlays  = "video_plays"
+	popularityMetricVideoSkips  = "video_skips"
+)
+
+// trendingCache keeps the ranked trending pool for each entity type filter for
+// trendingCacheTTL, the ranking only changes when counters are flushed anyway
+type trendingCache struct {
+	mutex   sync.Mutex
+	entries map[string]trendingCacheEntry
+}
+
+type trendingCacheEntry struct {
+	trending []model.TrendingContent
+	since    time.Time
+	loadedAt time.Time
+}
+
+func newTrendingCache() *trendingCache {
+	return &trendingCache{entries: make(map[string]trendingCacheEntry)}
+}
+
+func (cache *trendingCache) invalidate() {
+	cache.mutex.Lock()
+	clear(cache.entries)
+	cache.mutex.Unlock()
+}
+
+// ==================== POPULARITY COUNTERS ====================
+
+// recordPopularity counts a view, start, completion, video play or video skip. Counters
+// live in Redis until the next flush; when Redis is down the count is dropped rather
+// than failing the request.
+func (svc *ContentService) recordPopularity(entityType, entityID, metric string) {
+	if entityID == "" {
+		return
+	}
+
+	field := entityType + ":" + entityID + ":" + metric
+	if err := svc.redisSvc.GetClient().HIncrBy(context.Background(), popularityPendingKey, field, 1).Err(); err != nil {
+		log.WithError(err).Debugf("Failed to count %s of %s %s", metric, entityType, entityID)
+	}
+}
+
+func (svc *ContentService) startPopularityFlushJob() {
+	ticker := time.NewTicker(popularityFlushInterval)
+	for range ticker.C {
+		svc.flushPopularity()
+	}
+}
+
+// flushPopularity moves the pending counters aside and adds them to today's totals.
+// Lesson starts and completions also count towards the lesson's character. If the
+// database write fails the counters are put back for the next flush.
+func (svc *ContentService) flushPopularity() {
+	ctx := context.Background()
+	client := svc.redisSvc.GetClient()
+
+	// Renaming is atomic, counts made from here on go to a fresh pending hash
+	flushingKey := popularityFlushingKey + uuid.NewString()
+	if err := client.Rename(ctx, popularityPendingKey, flushingKey).Err(); err != nil {
+		if !strings.Contains(err.Error(), "no such key") {
+			log.WithError(err).Warn("Failed to flush popularity counters")
+		}
+		return
+	}
+	client.Expire(ctx, flushingKey, popularityFlushingTTL)
+
+	counts, err := client.HGetAll(ctx, flushingKey).Result()
+	if err != nil {
+		log.WithError(err).Error("Failed to read popularity counters")
+		return
+	}
+
+	day := streakDay(time.Now(), time.UTC)
+	stats := make(map[string]*model.ContentPopularityStat)
+	statFor := func(entityType, entityID string) *model.ContentPopularityStat {
+		key := entityType + ":" + entityID
+		if stat, ok := stats[key]; ok {
+			return stat
+		}
+		stat := &model.ContentPopularityStat{EntityType: entityType, EntityID: entityID, Day: day}
+		stats[key] = stat
+		return stat
+	}
+
+	lessonCounts := make(map[string]*model.ContentPopularityStat)
+	for field, value := range counts {
+		first, last := strings.Index(field, ":"), strings.LastIndex(field, ":")
+		n, err := strconv.ParseInt(value, 10, 64)
+		if first < 0 || first == last || err != nil {
+			log.Warnf("Ignoring malformed popularity counter %s=%s", field, value)
+			continue
+		}
+
+		entityType, entityID, metric := field[:first], field[first+1:last], field[last+1:]
+		stat := statFor(entityType, entityID)
+		switch metric {
+		case popularityMetricViews:
+			stat.Views += n
+		case popularityMetricStarts:
+			stat.Starts += n
+		case popularityMetricCompletions:
+			stat.Completions += n
+		case popularityMetricVideoPlays:
+			stat.VideoPlays += n
+		case popularityMetricVideoSkips:
+			stat.VideoSkips += n
+		}
+		if entityType == model.PopularityEntityLesson {
+			lessonCounts[entityID] = stat
+		}
+	}
+
+	lessonIDs := make([]string, 0, len(lessonCounts))
+	for id := range lessonCounts {
+		lessonIDs = append(lessonIDs, id)
+	}
+	lessons, err := svc.contentRepo.GetLessonsByIDs(lessonIDs)
+	if err != nil {
+		log.WithError(err).Warn("Failed to load lessons for character popularity")
+	}
+	for _, lesson := range lessons {
+		lessonStat := lessonCounts[lesson.ID]
+		characterStat := statFor(model.PopularityEntityCharacter, lesson.CharacterID)
+		characterStat.Starts += lessonStat.Starts
+		characterStat.Completions += lessonStat.Completions
+	}
+
+	rows := make([]model.ContentPopularityStat, 0, len(stats))
+	for _, stat := range stats {
+		rows = append(rows, *stat)
+	}
+
+	if err := svc.contentRepo.AddContentPopularity(rows); err != nil {
+		log.WithError(err).Error("Failed to save popularity counters, retrying on the next flush")
+		pipe := client.Pipeline()
+		for field, value := range counts {
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+				pipe.HIncrBy(ctx, popularityPendingKey, field, n)
+			}
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			log.WithError(err).Error("Failed to restore popularity counters")
+			return
+		}
+	}
+
+	client.Del(ctx, flushingKey)
+}
+
+// ==================== TRENDING METHODS ====================
+
+// GetTrendingContent ranks lessons and characters by activity over the last trendingDays
+// days. Lessons rated above the viewer's age are left out; userID is empty for
+// anonymous viewers.
+func (svc *ContentService) GetTrendingContent(entityType string, limit int, userID string) (*dto.TrendingContentResponse, error) {
+	trending, since, err := svc.trendingPool(entityType)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get trending content")
+	}
+
+	var characterIDs, lessonIDs []string
+	for _, t := range trending {
+		if t.EntityType == model.PopularityEntityLesson {
+			lessonIDs = append(lessonIDs, t.EntityID)
+		} else {
+			characterIDs = append(characterIDs, t.EntityID)
+		}
+	}
+
+	characters, err := svc.contentRepo.GetCharactersByIDs(characterIDs)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to load characters")
+	}
+	charactersByID := make(map[string]*model.Character, len(characters))
+	for i := range characters {
+		charactersByID[characters[i].ID] = &characters[i]
+	}
+
+	lessons, err := svc.contentRepo.GetLessonsByIDs(lessonIDs)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to load lessons")
+	}
+	lessonsByID := make(map[string]*model.Lesson, len(lessons))
+	for i := range lessons {
+		lessonsByID[lessons[i].ID] = &lessons[i]
+	}
+
+	ageLimit := svc.viewerAgeLimit(userID)
+	items := make([]dto.TrendingContentItem, 0, limit)
+	for _, t := range trending {
+		if len(items) == limit {
+			break
+		}
+
+		item := dto.TrendingContentItem{
+			Type:        t.EntityType,
+			ID:          t.EntityID,
+			Views:       t.Views,
+			Starts:      t.Starts,
+			Completions: t.Completions,
+			Score:       t.Score,
+		}
+		switch t.EntityType {
+		case model.PopularityEntityCharacter:
+			char, ok := charactersByID[t.EntityID]
+			if !ok {
+				continue
+			}
+			response := svc.mapCharacterToResponse(char)
+			item.Title = char.Name
+			item.ImageURL = char.ImageURL
+			item.Character = &response
+
+		case model.PopularityEntityLesson:
+			lesson, ok := lessonsByID[t.EntityID]
+			if !ok || !lesson.SuitableForAge(ageLimit) {
+				continue
+			}
+			item.Title = lesson.Title
+			item.CharacterID = lesson.CharacterID
+			item.ImageURL = lesson.ThumbnailURL
+			if item.ImageURL == "" {
+				item.ImageURL = lesson.Character.ImageURL
+			}
+
+		default:
+			continue
+		}
+		items = append(items, item)
+	}
+
+	return &dto.TrendingContentResponse{
+		Items: items,
+		Since: since,
+		Days:  trendingDays,
+	}, nil
+}
+
+// trendingPool returns the top trendingPoolSize entities of the given type, or of both
+// types when entityType is empty, with the first day counted
+func (svc *ContentService) trendingPool(entityType string) ([]model.TrendingContent, time.Time, error) {
+	cache := svc.trending
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if entry, ok := cache.entries[entityType]; ok && time.Since(entry.loadedAt) < trendingCacheTTL {
+		return entry.trending, entry.since, nil
+	}
+
+	since := streakDay(time.Now(), time.UTC).AddDate(0, 0, -(trendingDays - 1))
+	trending, err := svc.contentRepo.GetTrendingContent(entityType, since, trendingPoolSize)
+	if err != nil {
+		return nil, since, err
+	}
+
+	cache.entries[entityType] = trendingCacheEntry{trending: trending, since: since, loadedAt: time.Now()}
+	return trending, since, nil
+}
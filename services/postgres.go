@@ -7,6 +7,7 @@ import (
 
 	"github.com/cloakd/common/context"
 	serviceContext "github.com/cloakd/common/services"
+	"github.com/lac-hong-legacy/ven_api/dto"
 	"github.com/lac-hong-legacy/ven_api/model"
 	"github.com/lac-hong-legacy/ven_api/services/repositories"
 
@@ -27,6 +28,20 @@ type PostgresService struct {
 	mediaRepo     *repositories.MediaRepository
 	contentRepo   *repositories.ContentRepository
 	analyticRepo  *repositories.AnalyticRepository
+
+	notificationRepo *repositories.NotificationRepository
+	webhookRepo      *repositories.WebhookRepository
+	outboxRepo       *repositories.OutboxRepository
+	retentionRepo    *repositories.RetentionRepository
+	maintenanceRepo  *repositories.MaintenanceRepository
+	appVersionRepo   *repositories.AppVersionRepository
+	triviaRepo       *repositories.TriviaRepository
+	studyRoomRepo    *repositories.StudyRoomRepository
+	shopRepo         *repositories.ShopRepository
+	certificateRepo  *repositories.CertificateRepository
+	researchRepo     *repositories.ResearchRepository
+
+	queryStats *queryInstrumentation
 }
 
 const POSTGRES_SVC = "postgres_svc"
@@ -40,6 +55,8 @@ func (ds PostgresService) Db() *gorm.DB {
 }
 
 func (ds *PostgresService) Configure(ctx *context.Context) error {
+	ds.queryStats = newQueryInstrumentation()
+
 	ds.database = os.Getenv("DATABASE_URL")
 	if ds.database == "" {
 		// Fallback to individual environment variables
@@ -118,6 +135,10 @@ func (ds *PostgresService) Start() (err error) {
 		}
 	}
 
+	if err := ds.db.Use(ds.queryStats); err != nil {
+		return err
+	}
+
 	// Initialize repositories AFTER database connection is established
 	ds.userRepo = repositories.NewUserRepository(ds.db)
 	ds.sessionRepo = repositories.NewSessionRepository(ds.db)
@@ -125,37 +146,110 @@ func (ds *PostgresService) Start() (err error) {
 	ds.mediaRepo = repositories.NewMediaRepository(ds.db)
 	ds.contentRepo = repositories.NewContentRepository(ds.db)
 	ds.analyticRepo = repositories.NewAnalyticRepository(ds.db)
+	ds.notificationRepo = repositories.NewNotificationRepository(ds.db)
+	ds.webhookRepo = repositories.NewWebhookRepository(ds.db)
+	ds.outboxRepo = repositories.NewOutboxRepository(ds.db)
+	ds.retentionRepo = repositories.NewRetentionRepository(ds.db)
+	ds.maintenanceRepo = repositories.NewMaintenanceRepository(ds.db)
+	ds.appVersionRepo = repositories.NewAppVersionRepository(ds.db)
+	ds.triviaRepo = repositories.NewTriviaRepository(ds.db)
+	ds.studyRoomRepo = repositories.NewStudyRoomRepository(ds.db)
+	ds.shopRepo = repositories.NewShopRepository(ds.db)
+	ds.certificateRepo = repositories.NewCertificateRepository(ds.db)
+	ds.researchRepo = repositories.NewResearchRepository(ds.db)
 
 	models := []interface{}{
+		&model.DataMigration{},
+
 		// Existing models
 		&model.User{},
 		&model.GuestSession{},
 		&model.GuestProgress{},
 		&model.GuestLessonAttempt{},
+		&model.DeviceAttestation{},
 		&model.RateLimit{},
 		&model.RateLimitConfig{},
 
 		// Content models
 		&model.Character{},
+		&model.CharacterRelation{},
+		&model.GlossaryTerm{},
 		&model.Lesson{},
 		&model.Timeline{},
+		&model.Era{},
+		&model.Dynasty{},
 		&model.MediaAsset{},
 		&model.LessonMedia{},
+		&model.QuestionMedia{},
+		&model.MediaUploadSession{},
+		&model.CDNPrewarmJob{},
+		&model.LessonTranslation{},
 
 		// User progress models
 		&model.UserProgress{},
+		&model.XPTransaction{},
+		&model.HeartTransaction{},
+		&model.ItemTransaction{},
+		&model.CompletionFlag{},
+		&model.LeaderboardAnomaly{},
+		&model.ModerationFlag{},
+		&model.QuestionReport{},
+		&model.DisabledQuestion{},
+		&model.ContentIncident{},
+		&model.UserNote{},
 		&model.Spirit{},
+		&model.LeaderboardProfile{},
 		&model.Achievement{},
 		&model.UserAchievement{},
+		&model.UserAchievementProgress{},
 		&model.UserLessonAttempt{},
+		&model.UserLessonCompletion{},
+		&model.UserCharacter{},
+		&model.UserFavoriteCharacter{},
+		&model.UserLessonBookmark{},
+		&model.ContentAuditLog{},
+		&model.SpiritBattle{},
 		&model.UserQuestionAnswer{},
+		&model.LessonSession{},
+		&model.LessonVideoProgress{},
+		&model.LessonAidUse{},
+		&model.Notification{},
+		&model.StudyReminder{},
+		&model.WebhookEndpoint{},
+		&model.WebhookDelivery{},
+		&model.OutboxMessage{},
+		&model.GameConfig{},
+		&model.RetentionPolicy{},
+		&model.AnonymizationRun{},
+		&model.QuestionAnswerStat{},
+		&model.LessonAttemptStat{},
+		&model.ContentPopularityStat{},
+		&model.MaintenanceWindow{},
+		&model.AppVersionPolicy{},
+		&model.DailyTrivia{},
+		&model.DailyTriviaAnswer{},
+		&model.UserTriviaStreak{},
+		&model.StudyRoom{},
+		&model.StudyRoomMember{},
+		&model.StudyRoomAnswer{},
+		&model.ShopItem{},
+		&model.Wallet{},
+		&model.CoinTransaction{},
+		&model.ShopPurchase{},
+		&model.UserCosmetic{},
+		&model.Certificate{},
+		&model.ResearchExport{},
+		&model.ResearchExportAccess{},
 
 		// New authentication models
 		&model.UserSession{},
 		&model.AuthAuditLog{},
+		&model.AuthAuditCheckpoint{},
 		&model.PasswordResetCode{},
+		&model.EmailChangeRequest{},
 		&model.BlacklistedToken{},
 		&model.TrustedDevice{},
+		&model.AccountRecoveryRequest{},
 		&model.LoginAttempt{},
 	}
 
@@ -170,6 +264,31 @@ func (ds *PostgresService) Start() (err error) {
 		return err
 	}
 
+	if err := ds.contentRepo.BackfillLessonCompletions(); err != nil {
+		log.Printf("Failed to backfill lesson completions: %v", err)
+		return err
+	}
+
+	if err := ds.contentRepo.BackfillUserCharacters(); err != nil {
+		log.Printf("Failed to backfill user characters: %v", err)
+		return err
+	}
+
+	if err := ds.contentRepo.BackfillXPLedger(); err != nil {
+		log.Printf("Failed to backfill XP ledger: %v", err)
+		return err
+	}
+
+	if err := ds.contentRepo.BackfillLeaderboardProfiles(); err != nil {
+		log.Printf("Failed to backfill leaderboard profiles: %v", err)
+		return err
+	}
+
+	if err := ds.contentRepo.BackfillErasAndDynasties(); err != nil {
+		log.Printf("Failed to backfill eras and dynasties: %v", err)
+		return err
+	}
+
 	err = ds.userRepo.SeedInitialData()
 	if err != nil {
 		log.Printf("Failed to seed initial data: %v", err)
@@ -256,6 +375,11 @@ func (ds *PostgresService) fixJSONBColumns() error {
 	return nil
 }
 
+// SlowQueries returns the slow query log collected by the query instrumentation
+func (ds *PostgresService) SlowQueries() *dto.SlowQueryResponse {
+	return ds.queryStats.SlowQueries()
+}
+
 func (ds *PostgresService) Shutdown() {
 	sqlDB, err := ds.db.DB()
 	if err == nil {
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+	log "github.com/sirupsen/logrus"
+)
+
+var progressCacheRequestsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "progress_cache_requests_total",
+		Help: "Progress snapshot lookups by result (hit, miss, shared, error)",
+	},
+	[]string{"result"},
+)
+
+const (
+	progressCacheKeyPrefix = "ven:progress:"
+	// How long a loader may hold the rebuild lock before another instance gives up waiting
+	progressCacheLockTTL = 3 * time.Second
+	// How often an instance that lost the lock checks whether the snapshot has appeared
+	progressCacheWaitStep = 50 * time.Millisecond
+	// Version keys outlive every snapshot so a reset version cannot resurrect an old one
+	progressCacheVersionTTL = 24 * time.Hour
+)
+
+// progressCache keeps composed progress snapshots in Redis. Each user has a version
+// counter and snapshots are stored under the version they were built from, so bumping
+// the version invalidates the snapshot and any load that was in flight when the write
+// happened. Concurrent misses for the same user share one load per instance, and a
+// short Redis lock keeps other instances from rebuilding it at the same time.
+type progressCache struct {
+	redisSvc *RedisService
+	ttl      time.Duration
+
+	mutex    sync.Mutex
+	inflight map[string]*progressLoad
+}
+
+type progressLoad struct {
+	done     chan struct{}
+	snapshot *dto.UserProgressResponse
+	err      error
+}
+
+func newProgressCache(redisSvc *RedisService, ttl time.Duration) *progressCache {
+	return &progressCache{
+		redisSvc: redisSvc,
+		ttl:      ttl,
+		inflight: make(map[string]*progressLoad),
+	}
+}
+
+func progressVersionKey(userID string) string {
+	return progressCacheKeyPrefix + "ver:" + userID
+}
+
+func progressSnapshotKey(userID, version string) string {
+	return fmt.Sprintf("%s%s:%s", progressCacheKeyPrefix, userID, version)
+}
+
+func progressLockKey(userID string) string {
+	return progressCacheKeyPrefix + "lock:" + userID
+}
+
+// Get returns the cached snapshot for userID, or builds it with load and caches it
+func (p *progressCache) Get(userID string, load func() (*dto.UserProgressResponse, error)) (*dto.UserProgressResponse, error) {
+	if p.ttl <= 0 {
+		return load()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	version, snapshot, err := p.read(ctx, userID)
+	if err != nil {
+		progressCacheRequestsTotal.WithLabelValues("error").Inc()
+		log.WithError(err).Debug("Progress cache read failed")
+		return load()
+	}
+	if snapshot != nil {
+		progressCacheRequestsTotal.WithLabelValues("hit").Inc()
+		return snapshot, nil
+	}
+
+	p.mutex.Lock()
+	if call, ok := p.inflight[userID]; ok {
+		p.mutex.Unlock()
+		<-call.done
+		progressCacheRequestsTotal.WithLabelValues("shared").Inc()
+		return call.snapshot, call.err
+	}
+	call := &progressLoad{done: make(chan struct{})}
+	p.inflight[userID] = call
+	p.mutex.Unlock()
+
+	defer func() {
+		p.mutex.Lock()
+		delete(p.inflight, userID)
+		p.mutex.Unlock()
+		close(call.done)
+	}()
+
+	progressCacheRequestsTotal.WithLabelValues("miss").Inc()
+	call.snapshot, call.err = p.loadLocked(userID, version, load)
+	return call.snapshot, call.err
+}
+
+// loadLocked builds the snapshot while holding the user's rebuild lock. If another
+// instance holds it, it waits for that instance's snapshot and only loads itself if
+// none appears before the lock expires.
+func (p *progressCache) loadLocked(userID, version string, load func() (*dto.UserProgressResponse, error)) (*dto.UserProgressResponse, error) {
+	client := p.redisSvc.GetClient()
+	ctx := context.Background()
+
+	locked, err := client.SetNX(ctx, progressLockKey(userID), "1", progressCacheLockTTL).Result()
+	if err == nil && !locked {
+		for waited := time.Duration(0); waited < progressCacheLockTTL; waited += progressCacheWaitStep {
+			time.Sleep(progressCacheWaitStep)
+			if _, snapshot, err := p.read(ctx, userID); err == nil && snapshot != nil {
+				return snapshot, nil
+			}
+		}
+	}
+	if locked {
+		defer client.Del(ctx, progressLockKey(userID))
+	}
+
+	snapshot, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := p.ttl
+	if snapshot.ComebackBonus != nil {
+		if remaining := time.Until(snapshot.ComebackBonus.ExpiresAt); remaining < ttl {
+			ttl = remaining
+		}
+	}
+	if ttl > 0 {
+		if err := p.redisSvc.Set(ctx, progressSnapshotKey(userID, version), snapshot, ttl); err != nil {
+			log.WithError(err).Debug("Failed to cache progress snapshot")
+		}
+	}
+
+	return snapshot, nil
+}
+
+// read returns the user's current version and the snapshot stored under it, if any
+func (p *progressCache) read(ctx context.Context, userID string) (string, *dto.UserProgressResponse, error) {
+	client := p.redisSvc.GetClient()
+
+	version, err := client.Get(ctx, progressVersionKey(userID)).Result()
+	if err == redis.Nil {
+		version = "0"
+	} else if err != nil {
+		return "", nil, err
+	}
+
+	data, err := client.Get(ctx, progressSnapshotKey(userID, version)).Bytes()
+	if err == redis.Nil {
+		return version, nil, nil
+	} else if err != nil {
+		return "", nil, err
+	}
+
+	var snapshot dto.UserProgressResponse
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return version, nil, nil
+	}
+	return version, &snapshot, nil
+}
+
+// Invalidate bumps the user's version so the current snapshot is never read again
+func (p *progressCache) Invalidate(userID string) {
+	if p.ttl <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	pipe := p.redisSvc.GetClient().TxPipeline()
+	pipe.Incr(ctx, progressVersionKey(userID))
+	pipe.Expire(ctx, progressVersionKey(userID), progressCacheVersionTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.WithError(err).WithField("user_id", userID).Warn("Failed to invalidate progress snapshot")
+	}
+}
+
+// progressCacheTTL reads PROGRESS_CACHE_TTL_SECONDS, where 0 turns the cache off
+func progressCacheTTL(value string) time.Duration {
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return 5 * time.Minute
+}
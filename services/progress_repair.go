@@ -0,0 +1,254 @@
+package services
+
+import (
+	"slices"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	log "github.com/sirupsen/logrus"
+)
+
+// Progress repair job states
+const (
+	RepairStatusIdle      = "idle"
+	RepairStatusRunning   = "running"
+	RepairStatusCompleted = "completed"
+	RepairStatusFailed    = "failed"
+)
+
+// RepairUserProgress recomputes a user's progress from the history tables: XP from the
+// XP ledger, level from XP, completions from completed attempts and lesson XP grants,
+// and character unlocks from completions. With dryRun nothing is written.
+func (svc *UserService) RepairUserProgress(userID string, dryRun bool) (*dto.ProgressRepairReport, error) {
+	progress, err := svc.progressRepo.GetUserProgress(userID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "User progress not found")
+	}
+
+	report := &dto.ProgressRepairReport{
+		UserID:      userID,
+		DryRun:      dryRun,
+		XPBefore:    progress.XP,
+		LevelBefore: progress.Level,
+	}
+
+	ledgerXP, err := svc.progressRepo.SumXPTransactions(userID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to read XP ledger")
+	}
+	report.XPAfter = ledgerXP
+	report.LevelAfter = svc.calculateLevel(ledgerXP)
+
+	missing, err := svc.findMissingCompletions(userID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to read lesson history")
+	}
+	for _, completion := range missing {
+		report.RestoredCompletions = append(report.RestoredCompletions, completion.LessonID)
+	}
+
+	report.RestoredUnlocks, err = svc.findMissingUnlocks(userID, report.RestoredCompletions)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to read character unlocks")
+	}
+
+	report.Changed = report.XPBefore != report.XPAfter || report.LevelBefore != report.LevelAfter ||
+		len(report.RestoredCompletions) > 0 || len(report.RestoredUnlocks) > 0
+	if dryRun || !report.Changed {
+		return report, nil
+	}
+
+	for i := range missing {
+		if _, err := svc.progressRepo.CreateLessonCompletion(&missing[i]); err != nil {
+			return nil, shared.NewInternalError(err, "Failed to restore lesson completion")
+		}
+	}
+
+	for _, characterID := range report.RestoredUnlocks {
+		if _, err := svc.progressRepo.CreateUserCharacter(&model.UserCharacter{
+			UserID:      userID,
+			CharacterID: characterID,
+			Source:      model.UnlockSourceRepair,
+		}); err != nil {
+			return nil, shared.NewInternalError(err, "Failed to restore character unlock")
+		}
+	}
+
+	if report.XPBefore != report.XPAfter || report.LevelBefore != report.LevelAfter {
+		_, err := svc.progressRepo.ModifyUserProgress(userID, func(progress *model.UserProgress) (*model.ProgressChange, error) {
+			progress.XP = report.XPAfter
+			progress.Level = report.LevelAfter
+			return &model.ProgressChange{}, nil
+		})
+		if err != nil {
+			return nil, shared.NewInternalError(err, "Failed to update progress")
+		}
+	}
+
+	svc.publishProgressChanged(userID, "repair")
+
+	log.Printf("Repaired progress for user %s: XP %d -> %d, %d completion(s) and %d unlock(s) restored",
+		userID, report.XPBefore, report.XPAfter, len(report.RestoredCompletions), len(report.RestoredUnlocks))
+	return report, nil
+}
+
+// findMissingCompletions returns completion rows for lessons the attempt table or the
+// XP ledger show as finished but that have no UserLessonCompletion
+func (svc *UserService) findMissingCompletions(userID string) ([]model.UserLessonCompletion, error) {
+	completedIDs, err := svc.progressRepo.GetCompletedLessonIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts, err := svc.progressRepo.GetCompletedLessonAttempts(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	grants, err := svc.progressRepo.GetXPTransactionsBySource(userID, model.XPSourceLesson)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []model.UserLessonCompletion
+	add := func(lessonID string, score int, completedAt time.Time) {
+		if lessonID == "" || slices.Contains(completedIDs, lessonID) {
+			return
+		}
+		completedIDs = append(completedIDs, lessonID)
+		missing = append(missing, model.UserLessonCompletion{
+			UserID:      userID,
+			LessonID:    lessonID,
+			Score:       score,
+			CompletedAt: completedAt,
+		})
+	}
+
+	for _, attempt := range attempts {
+		add(attempt.LessonID, attempt.Score, attempt.UpdatedAt)
+	}
+	for _, grant := range grants {
+		add(grant.ReferenceID, 0, grant.CreatedAt)
+	}
+
+	if len(missing) == 0 {
+		return nil, nil
+	}
+
+	// Skip lessons that have since been deleted
+	lessons, err := svc.contentRepo.GetLessonsByIDs(completionLessonIDs(missing))
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(missing, func(c model.UserLessonCompletion) bool {
+		return !slices.ContainsFunc(lessons, func(l model.Lesson) bool { return l.ID == c.LessonID })
+	}), nil
+}
+
+// findMissingUnlocks returns characters the user completed a lesson of, including the
+// completions about to be restored, but has not unlocked
+func (svc *UserService) findMissingUnlocks(userID string, restoredLessonIDs []string) ([]string, error) {
+	completedIDs, err := svc.progressRepo.GetCompletedLessonIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+	completedIDs = append(completedIDs, restoredLessonIDs...)
+	if len(completedIDs) == 0 {
+		return nil, nil
+	}
+
+	lessons, err := svc.contentRepo.GetLessonsByIDs(completedIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	unlockedIDs, err := svc.progressRepo.GetUnlockedCharacterIDs(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for _, lesson := range lessons {
+		if !slices.Contains(unlockedIDs, lesson.CharacterID) && !slices.Contains(missing, lesson.CharacterID) {
+			missing = append(missing, lesson.CharacterID)
+		}
+	}
+	return missing, nil
+}
+
+func completionLessonIDs(completions []model.UserLessonCompletion) []string {
+	ids := make([]string, len(completions))
+	for i, c := range completions {
+		ids[i] = c.LessonID
+	}
+	return ids
+}
+
+// StartProgressRepair runs RepairUserProgress over every user in the background. Only
+// one run happens at a time; its result is available from GetProgressRepairStatus.
+func (svc *UserService) StartProgressRepair(dryRun bool) (*dto.ProgressRepairJobResponse, error) {
+	svc.repairMutex.Lock()
+	defer svc.repairMutex.Unlock()
+
+	if svc.repairJob.Status == RepairStatusRunning {
+		return nil, shared.NewConflictError(nil, "A progress repair is already running")
+	}
+
+	now := time.Now()
+	svc.repairJob = dto.ProgressRepairJobResponse{
+		Status:    RepairStatusRunning,
+		DryRun:    dryRun,
+		StartedAt: &now,
+		Reports:   []dto.ProgressRepairReport{},
+	}
+	go svc.runProgressRepair(dryRun)
+
+	job := svc.repairJob
+	return &job, nil
+}
+
+func (svc *UserService) GetProgressRepairStatus() *dto.ProgressRepairJobResponse {
+	svc.repairMutex.Lock()
+	defer svc.repairMutex.Unlock()
+
+	job := svc.repairJob
+	if job.Status == "" {
+		job.Status = RepairStatusIdle
+	}
+	job.Reports = slices.Clone(job.Reports)
+	return &job
+}
+
+func (svc *UserService) runProgressRepair(dryRun bool) {
+	userIDs, err := svc.progressRepo.GetProgressUserIDs()
+
+	for _, userID := range userIDs {
+		report, repairErr := svc.RepairUserProgress(userID, dryRun)
+		if repairErr != nil {
+			log.Printf("Failed to repair progress for user %s: %v", userID, repairErr)
+		}
+
+		svc.repairMutex.Lock()
+		svc.repairJob.UsersScanned++
+		if report != nil && report.Changed {
+			svc.repairJob.UsersChanged++
+			svc.repairJob.Reports = append(svc.repairJob.Reports, *report)
+		}
+		svc.repairMutex.Unlock()
+	}
+
+	svc.repairMutex.Lock()
+	defer svc.repairMutex.Unlock()
+
+	now := time.Now()
+	svc.repairJob.FinishedAt = &now
+	svc.repairJob.Status = RepairStatusCompleted
+	if err != nil {
+		svc.repairJob.Status = RepairStatusFailed
+		svc.repairJob.Error = err.Error()
+	}
+	log.Printf("Progress repair finished: %d user(s) scanned, %d changed (dry run: %t)",
+		svc.repairJob.UsersScanned, svc.repairJob.UsersChanged, dryRun)
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"cmp"
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// The public catalog is rebuilt at most this often, the website's CDN caches on top
+	publicCatalogTTL = 10 * time.Minute
+	// Teasers end at a word boundary before this many characters
+	publicTeaserLength = 280
+	// Page descriptions for search results and link previews
+	publicDescriptionLength = 160
+)
+
+// publicCatalog is everything the public content API serves. It is built in one pass
+// so anonymous traffic costs three queries per instance every publicCatalogTTL.
+// Characters are published once they have an active lesson; only lessons suitable for
+// all ages get a teaser.
+type publicCatalog struct {
+	characters []dto.PublicCharacterResponse
+	details    map[string]*dto.PublicCharacterDetailResponse
+	lessons    map[string]dto.PublicLessonTeaser
+	timeline   dto.PublicTimelineResponse
+	// When each published character and lesson last changed, for the sitemap and page
+	// metadata. A character's time includes changes to its lessons.
+	characterUpdated map[string]time.Time
+	lessonUpdated    map[string]time.Time
+	updatedAt        time.Time
+}
+
+// publicCatalogCache holds the catalog. Content changes rebuild it on the next request
+// on the instance that made them, other instances catch up within publicCatalogTTL.
+type publicCatalogCache struct {
+	mutex    sync.Mutex
+	catalog  *publicCatalog
+	loadedAt time.Time
+}
+
+func (cache *publicCatalogCache) invalidate() {
+	cache.mutex.Lock()
+	cache.loadedAt = time.Time{}
+	cache.mutex.Unlock()
+}
+
+// publicContent returns the cached catalog, rebuilding it when it is too old. If the
+// rebuild fails the old catalog keeps being served.
+func (svc *ContentService) publicContent() (*publicCatalog, error) {
+	cache := svc.public
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if cache.catalog != nil && time.Since(cache.loadedAt) < publicCatalogTTL {
+		return cache.catalog, nil
+	}
+
+	catalog, err := svc.loadPublicCatalog()
+	if err != nil {
+		if cache.catalog != nil {
+			log.Printf("Failed to refresh public content, serving the previous copy: %v", err)
+			return cache.catalog, nil
+		}
+		return nil, shared.NewInternalError(err, "Failed to load public content")
+	}
+	cache.catalog = catalog
+	cache.loadedAt = time.Now()
+	return catalog, nil
+}
+
+func (svc *ContentService) loadPublicCatalog() (*publicCatalog, error) {
+	characters, err := svc.contentRepo.GetCharactersByDynasty("")
+	if err != nil {
+		return nil, err
+	}
+	lessons, err := svc.contentRepo.GetPublicLessons()
+	if err != nil {
+		return nil, err
+	}
+	timelines, err := svc.contentRepo.GetTimeline()
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := &publicCatalog{
+		characters: []dto.PublicCharacterResponse{},
+		details:    make(map[string]*dto.PublicCharacterDetailResponse),
+		lessons:    make(map[string]dto.PublicLessonTeaser),
+		timeline:   dto.PublicTimelineResponse{Dynasties: []dto.PublicDynastyResponse{}},
+
+		characterUpdated: make(map[string]time.Time),
+		lessonUpdated:    make(map[string]time.Time),
+	}
+
+	lessonCounts := make(map[string]int)
+	lessonsUpdated := make(map[string]time.Time)
+	teasers := make(map[string][]dto.PublicLessonTeaser)
+	for _, lesson := range lessons {
+		lessonCounts[lesson.CharacterID]++
+		if lesson.UpdatedAt.After(lessonsUpdated[lesson.CharacterID]) {
+			lessonsUpdated[lesson.CharacterID] = lesson.UpdatedAt
+		}
+		if !lesson.SuitableForAge(0) {
+			continue
+		}
+		teaser := dto.PublicLessonTeaser{
+			ID:           lesson.ID,
+			CharacterID:  lesson.CharacterID,
+			Title:        lesson.Title,
+			Order:        lesson.Order,
+			ThumbnailURL: lesson.ThumbnailURL,
+			Teaser:       lessonTeaser(lesson.Story),
+		}
+		teasers[lesson.CharacterID] = append(teasers[lesson.CharacterID], teaser)
+		catalog.lessons[lesson.ID] = teaser
+		catalog.lessonUpdated[lesson.ID] = lesson.UpdatedAt
+	}
+
+	slices.SortFunc(characters, func(a, b model.Character) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	for _, character := range characters {
+		if lessonCounts[character.ID] == 0 {
+			continue
+		}
+		response := dto.PublicCharacterResponse{
+			ID:          character.ID,
+			Name:        character.Name,
+			Era:         character.Era,
+			Dynasty:     character.Dynasty,
+			Rarity:      character.Rarity,
+			BirthYear:   character.BirthYear,
+			DeathYear:   character.DeathYear,
+			Description: character.Description,
+			FamousQuote: character.FamousQuote,
+			ImageURL:    character.ImageURL,
+			LessonCount: lessonCounts[character.ID],
+		}
+		catalog.characters = append(catalog.characters, response)
+
+		detail := &dto.PublicCharacterDetailResponse{
+			PublicCharacterResponse: response,
+			Lessons:                 teasers[character.ID],
+		}
+		if detail.Lessons == nil {
+			detail.Lessons = []dto.PublicLessonTeaser{}
+		}
+		catalog.details[character.ID] = detail
+
+		updated := character.UpdatedAt
+		if lessonsUpdated[character.ID].After(updated) {
+			updated = lessonsUpdated[character.ID]
+		}
+		catalog.characterUpdated[character.ID] = updated
+		if updated.After(catalog.updatedAt) {
+			catalog.updatedAt = updated
+		}
+	}
+
+	for _, timeline := range timelines {
+		var characterIDs []string
+		if timeline.CharacterIds != nil {
+			if err := json.Unmarshal(timeline.CharacterIds, &characterIDs); err != nil {
+				log.Printf("Failed to unmarshal character IDs for timeline %s: %v", timeline.Dynasty, err)
+			}
+		}
+
+		dynasty := dto.PublicDynastyResponse{
+			Era:         timeline.Era,
+			Dynasty:     timeline.Dynasty,
+			StartYear:   timeline.StartYear,
+			EndYear:     timeline.EndYear,
+			Description: timeline.Description,
+			ImageURL:    timeline.ImageURL,
+			Characters:  []dto.PublicCharacterSummary{},
+		}
+		for _, characterID := range characterIDs {
+			if detail, ok := catalog.details[characterID]; ok {
+				dynasty.Characters = append(dynasty.Characters, dto.PublicCharacterSummary{
+					ID:       detail.ID,
+					Name:     detail.Name,
+					ImageURL: detail.ImageURL,
+				})
+			}
+		}
+		catalog.timeline.Dynasties = append(catalog.timeline.Dynasties, dynasty)
+		if timeline.UpdatedAt.After(catalog.updatedAt) {
+			catalog.updatedAt = timeline.UpdatedAt
+		}
+	}
+
+	return catalog, nil
+}
+
+// lessonTeaser shortens a story to publicTeaserLength characters, cutting between words
+func lessonTeaser(story string) string {
+	return shortenText(story, publicTeaserLength)
+}
+
+// shortenText collapses whitespace and cuts s between words so that it is at most limit
+// characters before the ellipsis
+func shortenText(s string, limit int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+
+	cut := limit
+	for cut > 0 && !unicode.IsSpace(runes[cut]) {
+		cut--
+	}
+	if cut == 0 {
+		cut = limit
+	}
+	return strings.TrimRight(string(runes[:cut]), " ,;:.-") + "…"
+}
+
+// ==================== PUBLIC CONTENT METHODS ====================
+
+func (svc *ContentService) GetPublicCharacters() (*dto.PublicCharacterListResponse, error) {
+	catalog, err := svc.publicContent()
+	if err != nil {
+		return nil, err
+	}
+	return &dto.PublicCharacterListResponse{
+		Characters: catalog.characters,
+		Total:      len(catalog.characters),
+	}, nil
+}
+
+func (svc *ContentService) GetPublicCharacter(characterID string) (*dto.PublicCharacterDetailResponse, error) {
+	catalog, err := svc.publicContent()
+	if err != nil {
+		return nil, err
+	}
+	detail, ok := catalog.details[characterID]
+	if !ok {
+		return nil, shared.NewNotFoundError(nil, "Character not found")
+	}
+	return detail, nil
+}
+
+func (svc *ContentService) GetPublicTimeline() (*dto.PublicTimelineResponse, error) {
+	catalog, err := svc.publicContent()
+	if err != nil {
+		return nil, err
+	}
+	return &catalog.timeline, nil
+}
+
+func (svc *ContentService) GetPublicLesson(lessonID string) (*dto.PublicLessonTeaser, error) {
+	catalog, err := svc.publicContent()
+	if err != nil {
+		return nil, err
+	}
+	teaser, ok := catalog.lessons[lessonID]
+	if !ok {
+		return nil, shared.NewNotFoundError(nil, "Lesson not found")
+	}
+	return &teaser, nil
+}
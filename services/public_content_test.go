@@ -0,0 +1,79 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestLessonTeaser(t *testing.T) {
+	if got := lessonTeaser("  Ngô Quyền\n\nđánh tan quân Nam Hán.  "); got != "Ngô Quyền đánh tan quân Nam Hán." {
+		t.Errorf("short story = %q, want it whole with spaces collapsed", got)
+	}
+
+	story := strings.Repeat("Trận Bạch Đằng năm 938, ", 30)
+	got := lessonTeaser(story)
+	if !strings.HasSuffix(got, "…") {
+		t.Errorf("long story = %q, want it to end with an ellipsis", got)
+	}
+	if n := utf8.RuneCountInString(got); n > publicTeaserLength+1 {
+		t.Errorf("teaser has %d characters, want at most %d", n, publicTeaserLength+1)
+	}
+	if !strings.HasPrefix(story, strings.TrimSuffix(got, "…")) {
+		t.Errorf("teaser %q is not the start of the story", got)
+	}
+	if strings.HasSuffix(strings.TrimSuffix(got, "…"), ",") {
+		t.Errorf("teaser %q ends with punctuation before the ellipsis", got)
+	}
+
+	unbroken := strings.Repeat("a", publicTeaserLength*2)
+	if got := lessonTeaser(unbroken); utf8.RuneCountInString(got) != publicTeaserLength+1 {
+		t.Errorf("story without spaces gives %d characters, want %d", utf8.RuneCountInString(got), publicTeaserLength+1)
+	}
+}
+
+func TestRenderSitemap(t *testing.T) {
+	updated := time.Date(2026, 3, 8, 23, 30, 0, 0, time.FixedZone("ICT", 7*60*60))
+	sitemap, err := renderSitemap([]sitemapURL{
+		newSitemapURL("https://ven.app/", updated, "daily", "1.0"),
+		newSitemapURL("https://ven.app/lessons/a&b", time.Time{}, "monthly", "0.5"),
+	})
+	if err != nil {
+		t.Fatalf("renderSitemap: %v", err)
+	}
+
+	got := string(sitemap)
+	for _, want := range []string{
+		`<?xml version="1.0" encoding="UTF-8"?>`,
+		`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`,
+		"<loc>https://ven.app/</loc>",
+		"<lastmod>2026-03-08</lastmod>",
+		"<loc>https://ven.app/lessons/a&amp;b</loc>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("sitemap is missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Count(got, "<lastmod>") != 1 {
+		t.Errorf("sitemap = %s, want lastmod only where the time is known", got)
+	}
+}
+
+func TestPageMetadata(t *testing.T) {
+	metadata := pageMetadata("Trần Hưng Đạo", "Quốc công tiết chế", "https://ven.app/characters/1", "", "profile", time.Time{}, map[string]interface{}{})
+	if metadata.Title != "Trần Hưng Đạo | Ven" {
+		t.Errorf("title = %q", metadata.Title)
+	}
+	if _, ok := metadata.OpenGraph["og:image"]; ok || metadata.Twitter["twitter:card"] != "summary" {
+		t.Errorf("metadata without an image = %+v, want no image tags and a small card", metadata)
+	}
+
+	metadata = pageMetadata("Trần Hưng Đạo", "", "https://ven.app/characters/1", "https://ven.app/a.png", "profile", time.Now(), map[string]interface{}{})
+	if metadata.OpenGraph["og:image"] != "https://ven.app/a.png" || metadata.Twitter["twitter:card"] != "summary_large_image" {
+		t.Errorf("metadata with an image = %+v, want image tags and a large card", metadata)
+	}
+	if metadata.StructuredData["image"] != "https://ven.app/a.png" || metadata.StructuredData["dateModified"] == nil {
+		t.Errorf("structured data = %v, want the image and modified time", metadata.StructuredData)
+	}
+}
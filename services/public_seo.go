@@ -0,0 +1,227 @@
+package services
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/shared"
+)
+
+const (
+	publicSiteName = "Ven"
+	publicLocale   = "vi_VN"
+
+	sitemapNamespace  = "http://www.sitemaps.org/schemas/sitemap/0.9"
+	sitemapDateLayout = "2006-01-02"
+)
+
+// Website paths of the pages the sitemap and page metadata point at
+const (
+	publicPageHome      = "/"
+	publicPageTimeline  = "/timeline"
+	publicPageCharacter = "/characters/"
+	publicPageLesson    = "/lessons/"
+)
+
+type sitemapURLSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapURL struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod,omitempty"`
+	ChangeFreq string `xml:"changefreq,omitempty"`
+	Priority   string `xml:"priority,omitempty"`
+}
+
+func newSitemapURL(loc string, lastMod time.Time, changeFreq, priority string) sitemapURL {
+	entry := sitemapURL{Loc: loc, ChangeFreq: changeFreq, Priority: priority}
+	if !lastMod.IsZero() {
+		entry.LastMod = lastMod.UTC().Format(sitemapDateLayout)
+	}
+	return entry
+}
+
+// renderSitemap writes the entries as a sitemaps.org urlset document
+func renderSitemap(urls []sitemapURL) ([]byte, error) {
+	body, err := xml.MarshalIndent(sitemapURLSet{Xmlns: sitemapNamespace, URLs: urls}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// publicPageURL is the absolute website address of a page
+func (svc *ContentService) publicPageURL(path string) (string, error) {
+	if svc.publicSiteURL == "" {
+		return "", shared.NewInternalError(fmt.Errorf("PUBLIC_SITE_URL not set"), "Public site URL is not configured")
+	}
+	return svc.publicSiteURL + path, nil
+}
+
+// publicImageURL makes a stored image path absolute, link previews can't follow
+// relative ones. It is empty when there is no image or nothing to resolve it against.
+func (svc *ContentService) publicImageURL(path string) string {
+	if path == "" || strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	if svc.assetBaseURL == "" {
+		return ""
+	}
+	return svc.assetBaseURL + "/" + strings.TrimLeft(path, "/")
+}
+
+// pageMetadata fills in the Open Graph and Twitter tags from the page's basics
+func pageMetadata(title, description, pageURL, imageURL, ogType string, updatedAt time.Time, structured map[string]interface{}) *dto.PublicPageMetadata {
+	metadata := &dto.PublicPageMetadata{
+		Title:        title + " | " + publicSiteName,
+		Description:  description,
+		CanonicalURL: pageURL,
+		ImageURL:     imageURL,
+		OpenGraph: map[string]string{
+			"og:title":       title,
+			"og:description": description,
+			"og:type":        ogType,
+			"og:url":         pageURL,
+			"og:site_name":   publicSiteName,
+			"og:locale":      publicLocale,
+		},
+		Twitter: map[string]string{
+			"twitter:card":        "summary",
+			"twitter:title":       title,
+			"twitter:description": description,
+		},
+		StructuredData: structured,
+		UpdatedAt:      updatedAt,
+	}
+	if imageURL != "" {
+		metadata.OpenGraph["og:image"] = imageURL
+		metadata.OpenGraph["og:image:alt"] = title
+		metadata.Twitter["twitter:card"] = "summary_large_image"
+		metadata.Twitter["twitter:image"] = imageURL
+		structured["image"] = imageURL
+	}
+	if !updatedAt.IsZero() {
+		structured["dateModified"] = updatedAt.UTC().Format(time.RFC3339)
+	}
+	return metadata
+}
+
+// ==================== SEO METHODS ====================
+
+// GetPublicSitemap lists the home page, the timeline and every published character and
+// lesson teaser page. It follows the public catalog, so publishing content updates it.
+func (svc *ContentService) GetPublicSitemap() ([]byte, error) {
+	catalog, err := svc.publicContent()
+	if err != nil {
+		return nil, err
+	}
+	home, err := svc.publicPageURL(publicPageHome)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := []sitemapURL{
+		newSitemapURL(home, catalog.updatedAt, "daily", "1.0"),
+		newSitemapURL(svc.publicSiteURL+publicPageTimeline, catalog.updatedAt, "weekly", "0.8"),
+	}
+	for _, character := range catalog.characters {
+		urls = append(urls, newSitemapURL(svc.publicSiteURL+publicPageCharacter+character.ID,
+			catalog.characterUpdated[character.ID], "weekly", "0.7"))
+		for _, lesson := range catalog.details[character.ID].Lessons {
+			urls = append(urls, newSitemapURL(svc.publicSiteURL+publicPageLesson+lesson.ID,
+				catalog.lessonUpdated[lesson.ID], "monthly", "0.5"))
+		}
+	}
+
+	sitemap, err := renderSitemap(urls)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to render sitemap")
+	}
+	return sitemap, nil
+}
+
+func (svc *ContentService) GetPublicCharacterMetadata(characterID string) (*dto.PublicPageMetadata, error) {
+	catalog, err := svc.publicContent()
+	if err != nil {
+		return nil, err
+	}
+	detail, ok := catalog.details[characterID]
+	if !ok {
+		return nil, shared.NewNotFoundError(nil, "Character not found")
+	}
+	pageURL, err := svc.publicPageURL(publicPageCharacter + characterID)
+	if err != nil {
+		return nil, err
+	}
+
+	description := detail.Description
+	if description == "" {
+		description = detail.FamousQuote
+	}
+	description = shortenText(description, publicDescriptionLength)
+
+	person := map[string]interface{}{
+		"@context":    "https://schema.org",
+		"@type":       "Person",
+		"name":        detail.Name,
+		"description": description,
+		"url":         pageURL,
+	}
+	if detail.BirthYear != nil {
+		person["birthDate"] = strconv.Itoa(*detail.BirthYear)
+	}
+	if detail.DeathYear != nil {
+		person["deathDate"] = strconv.Itoa(*detail.DeathYear)
+	}
+
+	updatedAt := catalog.characterUpdated[characterID]
+	return pageMetadata(detail.Name, description, pageURL, svc.publicImageURL(detail.ImageURL), "profile", updatedAt, person), nil
+}
+
+func (svc *ContentService) GetPublicLessonMetadata(lessonID string) (*dto.PublicPageMetadata, error) {
+	catalog, err := svc.publicContent()
+	if err != nil {
+		return nil, err
+	}
+	teaser, ok := catalog.lessons[lessonID]
+	if !ok {
+		return nil, shared.NewNotFoundError(nil, "Lesson not found")
+	}
+	pageURL, err := svc.publicPageURL(publicPageLesson + lessonID)
+	if err != nil {
+		return nil, err
+	}
+
+	title := teaser.Title
+	image := teaser.ThumbnailURL
+	description := shortenText(teaser.Teaser, publicDescriptionLength)
+	resource := map[string]interface{}{
+		"@context":    "https://schema.org",
+		"@type":       "LearningResource",
+		"name":        teaser.Title,
+		"description": description,
+		"url":         pageURL,
+		"inLanguage":  "vi",
+	}
+	if character, ok := catalog.details[teaser.CharacterID]; ok {
+		title = teaser.Title + " – " + character.Name
+		if image == "" {
+			image = character.ImageURL
+		}
+		resource["about"] = map[string]interface{}{
+			"@type": "Person",
+			"name":  character.Name,
+			"url":   svc.publicSiteURL + publicPageCharacter + character.ID,
+		}
+	}
+
+	updatedAt := catalog.lessonUpdated[lessonID]
+	return pageMetadata(title, description, pageURL, svc.publicImageURL(image), "article", updatedAt, resource), nil
+}
@@ -0,0 +1,363 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Query metrics
+var (
+	dbQueryDurationSeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "db_query_duration_seconds",
+			Help:    "Database query duration in seconds",
+			Buckets: []float64{0.0005, 0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+		},
+		[]string{"operation", "table"},
+	)
+
+	dbSlowQueriesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "db_slow_queries_total",
+			Help: "Database queries slower than DB_SLOW_QUERY_MS",
+		},
+		[]string{"table", "caller"},
+	)
+)
+
+const (
+	queryStartKey = "ven:query_start"
+
+	// How many slow queries are kept for the admin endpoint
+	slowQueryLogSize = 200
+	// Query shapes are explained again after this long, in case the plan changed
+	slowQueryExplainInterval = time.Hour
+	modulePackagePrefix      = "github.com/lac-hong-legacy/ven_api/services."
+	repositoryPackagePrefix  = "github.com/lac-hong-legacy/ven_api/services/repositories."
+)
+
+var (
+	queryWhitespace  = regexp.MustCompile(`\s+`)
+	queryInList      = regexp.MustCompile(`\(\$\d+(,\s*\$\d+)*\)`)
+	queryPlaceholder = regexp.MustCompile(`\$\d+`)
+)
+
+// queryInstrumentation is a GORM plugin that times every statement, feeds the query
+// histograms and keeps a log of slow queries. Slow SELECTs are explained in the
+// background and flagged when the plan points at a missing index.
+type queryInstrumentation struct {
+	slowThreshold time.Duration
+	explain       bool
+	sqlDB         *sql.DB
+
+	mutex        sync.Mutex
+	recent       []dto.SlowQueryEntry
+	fingerprints map[string]*dto.QueryFingerprintStats
+	explainedAt  map[string]time.Time
+}
+
+func newQueryInstrumentation() *queryInstrumentation {
+	threshold := 200 * time.Millisecond
+	if ms, err := strconv.Atoi(os.Getenv("DB_SLOW_QUERY_MS")); err == nil && ms > 0 {
+		threshold = time.Duration(ms) * time.Millisecond
+	}
+
+	return &queryInstrumentation{
+		slowThreshold: threshold,
+		explain:       os.Getenv("DB_EXPLAIN_SLOW_QUERIES") != "false",
+		fingerprints:  make(map[string]*dto.QueryFingerprintStats),
+		explainedAt:   make(map[string]time.Time),
+	}
+}
+
+func (p *queryInstrumentation) Name() string {
+	return "ven:query_instrumentation"
+}
+
+func (p *queryInstrumentation) Initialize(db *gorm.DB) (err error) {
+	p.sqlDB, err = db.DB()
+	if err != nil {
+		return err
+	}
+
+	cb := db.Callback()
+	register := []struct {
+		operation string
+		before    func(string, func(*gorm.DB)) error
+		after     func(string, func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("gorm:create").Register, cb.Create().After("gorm:create").Register},
+		{"select", cb.Query().Before("gorm:query").Register, cb.Query().After("gorm:query").Register},
+		{"update", cb.Update().Before("gorm:update").Register, cb.Update().After("gorm:update").Register},
+		{"delete", cb.Delete().Before("gorm:delete").Register, cb.Delete().After("gorm:delete").Register},
+		{"row", cb.Row().Before("gorm:row").Register, cb.Row().After("gorm:row").Register},
+		{"raw", cb.Raw().Before("gorm:raw").Register, cb.Raw().After("gorm:raw").Register},
+	}
+	for _, r := range register {
+		operation := r.operation
+		if err := r.before("ven:query_start_"+operation, p.start); err != nil {
+			return err
+		}
+		if err := r.after("ven:query_end_"+operation, func(db *gorm.DB) { p.finish(db, operation) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *queryInstrumentation) start(db *gorm.DB) {
+	db.InstanceSet(queryStartKey, time.Now())
+}
+
+func (p *queryInstrumentation) finish(db *gorm.DB, operation string) {
+	value, ok := db.InstanceGet(queryStartKey)
+	if !ok {
+		return
+	}
+	duration := time.Since(value.(time.Time))
+
+	table := db.Statement.Table
+	if table == "" {
+		table = "unknown"
+	}
+	query := db.Statement.SQL.String()
+	if operation == "raw" || operation == "row" {
+		operation = queryOperation(query)
+	}
+
+	dbQueryDurationSeconds.WithLabelValues(operation, table).Observe(duration.Seconds())
+
+	if duration < p.slowThreshold {
+		return
+	}
+
+	caller, repository := queryCaller()
+	dbSlowQueriesTotal.WithLabelValues(table, caller).Inc()
+
+	entry := dto.SlowQueryEntry{
+		SQL:        truncate(query, 2000),
+		Operation:  operation,
+		Table:      table,
+		Caller:     caller,
+		Repository: repository,
+		DurationMs: float64(duration.Microseconds()) / 1000,
+		Rows:       db.Statement.RowsAffected,
+		At:         time.Now(),
+	}
+	if db.Error != nil {
+		entry.Error = db.Error.Error()
+	}
+	fingerprint := queryFingerprint(query)
+
+	log.WithFields(log.Fields{
+		"duration_ms": entry.DurationMs,
+		"table":       table,
+		"caller":      caller,
+		"rows":        entry.Rows,
+	}).Warnf("Slow query: %s", truncate(fingerprint, 500))
+
+	explain := p.record(entry, fingerprint)
+	if explain && operation == "select" {
+		vars := slices.Clone(db.Statement.Vars)
+		go p.explainQuery(fingerprint, query, vars)
+	}
+}
+
+// record adds a slow query to the log and its fingerprint's totals. It reports whether
+// the query shape is due to be explained.
+func (p *queryInstrumentation) record(entry dto.SlowQueryEntry, fingerprint string) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.recent = append(p.recent, entry)
+	if len(p.recent) > slowQueryLogSize {
+		p.recent = p.recent[len(p.recent)-slowQueryLogSize:]
+	}
+
+	stats, ok := p.fingerprints[fingerprint]
+	if !ok {
+		stats = &dto.QueryFingerprintStats{
+			Fingerprint: truncate(fingerprint, 2000),
+			Table:       entry.Table,
+			Caller:      entry.Caller,
+		}
+		p.fingerprints[fingerprint] = stats
+	}
+	stats.Count++
+	stats.TotalMs += entry.DurationMs
+	if entry.DurationMs > stats.MaxMs {
+		stats.MaxMs = entry.DurationMs
+	}
+	stats.LastSeen = entry.At
+
+	if !p.explain || time.Since(p.explainedAt[fingerprint]) < slowQueryExplainInterval {
+		return false
+	}
+	p.explainedAt[fingerprint] = time.Now()
+	return true
+}
+
+// explainQuery runs EXPLAIN on a slow SELECT and flags plans that scan a whole table to
+// filter or sort it, which usually means an index is missing. EXPLAIN without ANALYZE
+// plans the query without running it.
+func (p *queryInstrumentation) explainQuery(fingerprint, query string, vars []interface{}) {
+	var plan string
+	if err := p.sqlDB.QueryRow("EXPLAIN (FORMAT JSON) "+query, vars...).Scan(&plan); err != nil {
+		log.WithError(err).Debug("Failed to explain slow query")
+		return
+	}
+
+	var plans []struct {
+		Plan queryPlanNode `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(plan), &plans); err != nil || len(plans) == 0 {
+		return
+	}
+
+	hint := missingIndexHint(&plans[0].Plan, nil)
+	if hint == "" {
+		return
+	}
+
+	p.mutex.Lock()
+	if stats, ok := p.fingerprints[fingerprint]; ok {
+		stats.MissingIndex = true
+		stats.IndexHint = hint
+	}
+	p.mutex.Unlock()
+
+	log.WithField("hint", hint).Warnf("Slow query may be missing an index: %s", truncate(fingerprint, 500))
+}
+
+type queryPlanNode struct {
+	NodeType     string          `json:"Node Type"`
+	RelationName string          `json:"Relation Name"`
+	Filter       string          `json:"Filter"`
+	SortKey      []string        `json:"Sort Key"`
+	Plans        []queryPlanNode `json:"Plans"`
+}
+
+// missingIndexHint looks for a sequential scan that is filtered, or that feeds a sort,
+// and describes the columns an index would need
+func missingIndexHint(node, parent *queryPlanNode) string {
+	if node.NodeType == "Seq Scan" {
+		if node.Filter != "" {
+			return fmt.Sprintf("sequential scan of %s filtered by %s", node.RelationName, node.Filter)
+		}
+		if parent != nil && len(parent.SortKey) > 0 {
+			return fmt.Sprintf("sequential scan of %s sorted by %s", node.RelationName, strings.Join(parent.SortKey, ", "))
+		}
+	}
+
+	for i := range node.Plans {
+		if hint := missingIndexHint(&node.Plans[i], node); hint != "" {
+			return hint
+		}
+	}
+	return ""
+}
+
+// SlowQueries returns the recent slow queries, newest first, and the query shapes they
+// came from, slowest in total first
+func (p *queryInstrumentation) SlowQueries() *dto.SlowQueryResponse {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	recent := slices.Clone(p.recent)
+	slices.Reverse(recent)
+
+	fingerprints := make([]dto.QueryFingerprintStats, 0, len(p.fingerprints))
+	for _, stats := range p.fingerprints {
+		fingerprints = append(fingerprints, *stats)
+	}
+	slices.SortFunc(fingerprints, func(a, b dto.QueryFingerprintStats) int {
+		switch {
+		case a.TotalMs > b.TotalMs:
+			return -1
+		case a.TotalMs < b.TotalMs:
+			return 1
+		}
+		return 0
+	})
+
+	return &dto.SlowQueryResponse{
+		ThresholdMs:  p.slowThreshold.Milliseconds(),
+		Recent:       recent,
+		Fingerprints: fingerprints,
+	}
+}
+
+// queryCaller walks the stack for the service method and repository method that issued
+// the query, e.g. "UserService.CompleteLesson" and "ContentRepository.GetUserProgress"
+func queryCaller() (caller, repository string) {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		switch {
+		case strings.HasPrefix(frame.Function, repositoryPackagePrefix):
+			if repository == "" {
+				repository = shortFuncName(strings.TrimPrefix(frame.Function, repositoryPackagePrefix))
+			}
+		case strings.HasPrefix(frame.Function, modulePackagePrefix):
+			name := shortFuncName(strings.TrimPrefix(frame.Function, modulePackagePrefix))
+			if !strings.HasPrefix(name, "queryInstrumentation.") {
+				return name, repository
+			}
+		}
+		if !more {
+			break
+		}
+	}
+	if caller == "" {
+		caller = "unknown"
+	}
+	return caller, repository
+}
+
+// shortFuncName turns "(*UserService).CompleteLesson.func1" into "UserService.CompleteLesson"
+func shortFuncName(name string) string {
+	name = strings.NewReplacer("(*", "", ")", "").Replace(name)
+	parts := strings.Split(name, ".")
+	for len(parts) > 2 && strings.HasPrefix(parts[len(parts)-1], "func") {
+		parts = parts[:len(parts)-1]
+	}
+	return strings.Join(parts, ".")
+}
+
+// queryFingerprint normalizes a statement so executions with different arguments and
+// IN list lengths group together
+func queryFingerprint(query string) string {
+	query = queryWhitespace.ReplaceAllString(strings.TrimSpace(query), " ")
+	query = queryInList.ReplaceAllString(query, "(...)")
+	return queryPlaceholder.ReplaceAllString(query, "?")
+}
+
+func queryOperation(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "raw"
+	}
+	switch verb := strings.ToLower(fields[0]); verb {
+	case "select", "insert", "update", "delete", "with":
+		if verb == "insert" {
+			return "create"
+		}
+		return verb
+	}
+	return "raw"
+}
@@ -0,0 +1,331 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cloakd/common/context"
+	serviceContext "github.com/cloakd/common/services"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	"github.com/lac-hong-legacy/ven_api/shared/ids"
+	"github.com/lac-hong-legacy/ven_api/shared/text"
+)
+
+const (
+	defaultQuestionGenerationModel  = "gpt-4o-mini"
+	defaultGeneratedQuestionPoints  = 10
+	questionGenerationMaxStoryRunes = 20000
+	generatedQuestionMaxOptions     = 6
+)
+
+type QuestionGenerationService struct {
+	serviceContext.DefaultService
+
+	sqlSvc *PostgresService
+
+	// OpenAI-compatible chat completions endpoint used to draft questions. Optional.
+	generationURL    string
+	generationAPIKey string
+	generationModel  string
+	httpClient       *http.Client
+}
+
+const QUESTION_GENERATION_SVC = "question_generation_svc"
+
+func (svc QuestionGenerationService) Id() string {
+	return QUESTION_GENERATION_SVC
+}
+
+func (svc *QuestionGenerationService) Configure(ctx *context.Context) error {
+	svc.generationURL = strings.TrimRight(os.Getenv("QUESTION_GENERATION_API_URL"), "/")
+	svc.generationAPIKey = os.Getenv("QUESTION_GENERATION_API_KEY")
+	svc.generationModel = os.Getenv("QUESTION_GENERATION_MODEL")
+	if svc.generationModel == "" {
+		svc.generationModel = defaultQuestionGenerationModel
+	}
+	svc.httpClient = &http.Client{
+		Timeout: 90 * time.Second,
+	}
+
+	return svc.DefaultService.Configure(ctx)
+}
+
+func (svc *QuestionGenerationService) Start() error {
+	deps := newDependencies(svc.Id(), svc.Service)
+	svc.sqlSvc = resolve[*PostgresService](deps, POSTGRES_SVC)
+	return deps.err()
+}
+
+// GenerateQuestionDrafts asks the language model provider for multiple choice and fill
+// blank questions about a lesson's story. Nothing is saved: drafts that fit the question
+// schema are returned for an admin to review, the others are listed as rejected.
+func (svc *QuestionGenerationService) GenerateQuestionDrafts(lessonID string, req dto.GenerateQuestionsRequest) (*dto.GeneratedQuestionsResponse, error) {
+	if svc.generationURL == "" {
+		return nil, shared.NewBadRequestError(nil, "Question generation is not configured")
+	}
+	if req.MultipleChoice+req.FillBlank == 0 {
+		return nil, shared.NewBadRequestError(nil, "Ask for at least one question")
+	}
+	if req.Points == 0 {
+		req.Points = defaultGeneratedQuestionPoints
+	}
+
+	lesson, err := svc.sqlSvc.contentRepo.GetLesson(lessonID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Lesson not found")
+	}
+	story := strings.TrimSpace(lesson.Story)
+	if story == "" {
+		return nil, shared.NewBadRequestError(nil, "Lesson has no story to generate questions from")
+	}
+	if utf8.RuneCountInString(story) > questionGenerationMaxStoryRunes {
+		return nil, shared.NewBadRequestError(nil, fmt.Sprintf("Story is longer than %d characters", questionGenerationMaxStoryRunes))
+	}
+
+	var existing []model.Question
+	if len(lesson.Questions) > 0 {
+		if err := json.Unmarshal(lesson.Questions, &existing); err != nil {
+			return nil, shared.NewInternalError(err, "Failed to parse lesson questions")
+		}
+	}
+
+	content, err := svc.complete(questionGenerationPrompt(lesson.Title, story, existing, req))
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Question generation failed")
+	}
+
+	drafts, rejected, err := parseGeneratedQuestions(content, existing, req)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Question generation returned an unreadable response")
+	}
+
+	return &dto.GeneratedQuestionsResponse{
+		LessonID: lessonID,
+		Model:    svc.generationModel,
+		Drafts:   drafts,
+		Rejected: rejected,
+	}, nil
+}
+
+const questionGenerationInstructions = `You write quiz questions for a Vietnamese history learning app.
+Use only facts stated in the story. Write in the language of the story.
+Reply with a JSON object {"questions": [...]} where each question is one of:
+{"type": "multiple_choice", "question": "...", "options": ["...", "..."], "answer": "<one of the options, copied exactly>", "explanation": "..."}
+{"type": "fill_blank", "question": "<sentence with ___ where the answer goes>", "answer": "<a short word, name or year>", "explanation": "..."}
+Multiple choice questions have 3 or 4 options and exactly one correct one.
+The explanation is one sentence shown after answering.`
+
+func questionGenerationPrompt(title, story string, existing []model.Question, req dto.GenerateQuestionsRequest) []chatCompletionMessage {
+	var user strings.Builder
+	fmt.Fprintf(&user, "Write %d multiple_choice and %d fill_blank questions.\n\n", req.MultipleChoice, req.FillBlank)
+	fmt.Fprintf(&user, "Lesson: %s\n\nStory:\n%s\n", title, story)
+	if len(existing) > 0 {
+		user.WriteString("\nThe lesson already has these questions, do not repeat them:\n")
+		for _, question := range existing {
+			fmt.Fprintf(&user, "- %s\n", question.Question)
+		}
+	}
+
+	return []chatCompletionMessage{
+		{Role: "system", Content: questionGenerationInstructions},
+		{Role: "user", Content: user.String()},
+	}
+}
+
+type generatedQuestion struct {
+	Type        string      `json:"type"`
+	Question    string      `json:"question"`
+	Options     []string    `json:"options"`
+	Answer      interface{} `json:"answer"`
+	Explanation string      `json:"explanation"`
+}
+
+// parseGeneratedQuestions turns the provider's reply into questions, keeping at most the
+// requested number of each type. Drafts that don't fit the schema or repeat a question
+// are rejected with a reason, indexed by their position in the reply.
+func parseGeneratedQuestions(content string, existing []model.Question, req dto.GenerateQuestionsRequest) ([]model.Question, []dto.RejectedQuestionDraft, error) {
+	var reply struct {
+		Questions []generatedQuestion `json:"questions"`
+	}
+	if err := json.Unmarshal([]byte(content), &reply); err != nil {
+		return nil, nil, err
+	}
+
+	seen := make(map[string]bool, len(existing)+len(reply.Questions))
+	for _, question := range existing {
+		seen[normalizeQuestionText(question.Question)] = true
+	}
+	remaining := map[string]int{"multiple_choice": req.MultipleChoice, "fill_blank": req.FillBlank}
+
+	drafts := []model.Question{}
+	rejected := []dto.RejectedQuestionDraft{}
+	for i, generated := range reply.Questions {
+		question, reason := buildGeneratedQuestion(generated, req.Points)
+		switch {
+		case reason != "":
+		case seen[normalizeQuestionText(question.Question)]:
+			reason = "repeats another question"
+		case remaining[question.Type] == 0:
+			reason = fmt.Sprintf("more %s questions than requested", question.Type)
+		}
+		if reason != "" {
+			rejected = append(rejected, dto.RejectedQuestionDraft{Index: i, Reason: reason})
+			continue
+		}
+
+		seen[normalizeQuestionText(question.Question)] = true
+		remaining[question.Type]--
+		drafts = append(drafts, question)
+	}
+
+	return drafts, rejected, nil
+}
+
+// buildGeneratedQuestion checks a draft against the limits admins get when creating
+// questions and returns the reason it can't be used, if any
+func buildGeneratedQuestion(generated generatedQuestion, points int) (model.Question, string) {
+	wording := strings.TrimSpace(generated.Question)
+	if wording == "" {
+		return model.Question{}, "question is empty"
+	}
+	if utf8.RuneCountInString(wording) > 1000 {
+		return model.Question{}, "question is longer than 1000 characters"
+	}
+	answer, ok := generated.Answer.(string)
+	answer = strings.TrimSpace(answer)
+	if !ok || answer == "" {
+		return model.Question{}, "answer must be a non-empty text"
+	}
+
+	question := model.Question{
+		ID:       ids.New(),
+		Type:     generated.Type,
+		Question: wording,
+		Points:   points,
+	}
+
+	switch generated.Type {
+	case "multiple_choice":
+		if len(generated.Options) < 2 || len(generated.Options) > generatedQuestionMaxOptions {
+			return model.Question{}, fmt.Sprintf("needs 2 to %d options", generatedQuestionMaxOptions)
+		}
+		options := make(map[string]bool, len(generated.Options))
+		for _, option := range generated.Options {
+			option = strings.TrimSpace(option)
+			if option == "" || utf8.RuneCountInString(option) > 200 {
+				return model.Question{}, "options must be 1 to 200 characters"
+			}
+			key := strings.ToLower(option)
+			if options[key] {
+				return model.Question{}, "options repeat"
+			}
+			options[key] = true
+			question.Options = append(question.Options, option)
+			// The grader compares case-insensitively, store the option's own spelling
+			if text.Match(option, answer, text.MatchOptions{}) {
+				question.Answer = option
+			}
+		}
+		if question.Answer == nil {
+			return model.Question{}, "answer is not one of the options"
+		}
+	case "fill_blank":
+		if len(generated.Options) > 0 {
+			return model.Question{}, "fill_blank questions have no options"
+		}
+		if utf8.RuneCountInString(answer) > 200 {
+			return model.Question{}, "answer is longer than 200 characters"
+		}
+		question.Answer = answer
+	default:
+		return model.Question{}, fmt.Sprintf("unsupported question type %q", generated.Type)
+	}
+
+	if explanation := strings.TrimSpace(generated.Explanation); explanation != "" {
+		question.Metadata = map[string]interface{}{"explanation": explanation}
+	}
+	if errs := question.ValidateMetadata(); len(errs) > 0 {
+		return model.Question{}, errs[0].Error()
+	}
+
+	return question, ""
+}
+
+func normalizeQuestionText(text string) string {
+	return strings.ToLower(strings.Join(strings.Fields(text), " "))
+}
+
+type chatCompletionMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatCompletionRequest struct {
+	Model          string                  `json:"model"`
+	Messages       []chatCompletionMessage `json:"messages"`
+	Temperature    float64                 `json:"temperature"`
+	ResponseFormat map[string]string       `json:"response_format"`
+}
+
+type chatCompletionResponse struct {
+	Choices []struct {
+		Message chatCompletionMessage `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// complete sends a chat to the OpenAI-compatible provider and returns the reply, asking
+// for a JSON object
+func (svc *QuestionGenerationService) complete(messages []chatCompletionMessage) (string, error) {
+	body, err := json.Marshal(chatCompletionRequest{
+		Model:          svc.generationModel,
+		Messages:       messages,
+		Temperature:    0.4,
+		ResponseFormat: map[string]string{"type": "json_object"},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, svc.generationURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if svc.generationAPIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+svc.generationAPIKey)
+	}
+
+	resp, err := svc.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var payload chatCompletionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return "", fmt.Errorf("failed to decode generation response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		message := ""
+		if payload.Error != nil {
+			message = payload.Error.Message
+		}
+		return "", fmt.Errorf("generation provider returned %d: %s", resp.StatusCode, message)
+	}
+	if len(payload.Choices) == 0 {
+		return "", fmt.Errorf("generation provider returned no choices")
+	}
+
+	return payload.Choices[0].Message.Content, nil
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+)
+
+func TestParseGeneratedQuestions(t *testing.T) {
+	content := `{"questions": [
+		{"type": "multiple_choice", "question": "Ai đánh thắng quân Nam Hán?", "options": ["Ngô Quyền", "Lý Bí", "Đinh Bộ Lĩnh"], "answer": "ngô quyền", "explanation": "Trận Bạch Đằng năm 938."},
+		{"type": "multiple_choice", "question": "Trận đánh diễn ra trên sông nào?", "options": ["Hồng", "Cầu"], "answer": "Bạch Đằng"},
+		{"type": "fill_blank", "question": "Trận Bạch Đằng diễn ra năm ___.", "answer": 938},
+		{"type": "fill_blank", "question": "Ngô Quyền  xưng vương năm ___.", "answer": "939"},
+		{"type": "fill_blank", "question": "Ngô Quyền đóng đô ở ___.", "answer": "Cổ Loa"},
+		{"type": "drag_drop", "question": "Sắp xếp các sự kiện", "answer": "x"},
+		{"type": "multiple_choice", "question": "Ai là vua đầu tiên?", "options": ["A", "a"], "answer": "A"}
+	]}`
+	existing := []model.Question{{ID: "q1", Question: "Ngô Quyền xưng vương năm ___."}}
+	req := dto.GenerateQuestionsRequest{MultipleChoice: 2, FillBlank: 1, Points: 10}
+
+	drafts, rejected, err := parseGeneratedQuestions(content, existing, req)
+	if err != nil {
+		t.Fatalf("parseGeneratedQuestions: %v", err)
+	}
+
+	if len(drafts) != 2 {
+		t.Fatalf("got %d drafts, want 2: %+v", len(drafts), drafts)
+	}
+	if drafts[0].Answer != "Ngô Quyền" || drafts[0].Metadata["explanation"] != "Trận Bạch Đằng năm 938." {
+		t.Errorf("multiple choice draft = %+v, want the option's spelling and the explanation", drafts[0])
+	}
+	if drafts[1].Type != "fill_blank" || drafts[1].Answer != "Cổ Loa" || drafts[1].Points != 10 || drafts[1].ID == "" {
+		t.Errorf("fill blank draft = %+v", drafts[1])
+	}
+
+	wantRejected := []int{1, 2, 3, 5, 6}
+	if len(rejected) != len(wantRejected) {
+		t.Fatalf("rejected = %+v, want indexes %v", rejected, wantRejected)
+	}
+	for i, index := range wantRejected {
+		if rejected[i].Index != index {
+			t.Errorf("rejected[%d] = %+v, want index %d", i, rejected[i], index)
+		}
+	}
+}
+
+func TestParseGeneratedQuestionsLimitsCount(t *testing.T) {
+	content := `{"questions": [
+		{"type": "fill_blank", "question": "One ___", "answer": "1"},
+		{"type": "fill_blank", "question": "Two ___", "answer": "2"}
+	]}`
+
+	drafts, rejected, err := parseGeneratedQuestions(content, nil, dto.GenerateQuestionsRequest{FillBlank: 1, Points: 5})
+	if err != nil {
+		t.Fatalf("parseGeneratedQuestions: %v", err)
+	}
+	if len(drafts) != 1 || len(rejected) != 1 || rejected[0].Index != 1 {
+		t.Errorf("drafts = %+v, rejected = %+v, want the second question rejected", drafts, rejected)
+	}
+}
@@ -0,0 +1,301 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/services/repositories"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	log "github.com/sirupsen/logrus"
+)
+
+// Status of a question in the admin question analytics
+const (
+	questionStatusActive   = "active"
+	questionStatusDisabled = "disabled"
+)
+
+// ReportQuestion records a learner's report that a question is wrong or confusing. A
+// learner's later reports of the same question are ignored. Once the question has
+// QuestionReportThreshold unreviewed reports it is taken out of the lesson for review.
+func (svc *ContentService) ReportQuestion(userID, questionID string, req dto.ReportQuestionRequest) (*dto.QuestionReportResponse, error) {
+	lesson, err := svc.contentRepo.GetLesson(req.LessonID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Lesson not found")
+	}
+
+	var questions []model.Question
+	if len(lesson.Questions) > 0 {
+		if err := json.Unmarshal(lesson.Questions, &questions); err != nil {
+			return nil, shared.NewInternalError(err, "Failed to parse lesson questions")
+		}
+	}
+	if !slices.ContainsFunc(questions, func(q model.Question) bool { return q.ID == questionID }) {
+		return nil, shared.NewNotFoundError(nil, "Question not found")
+	}
+
+	report := &model.QuestionReport{
+		LessonID:   lesson.ID,
+		QuestionID: questionID,
+		UserID:     userID,
+		Reason:     req.Reason,
+		Comment:    strings.TrimSpace(req.Comment),
+	}
+	created, err := svc.contentRepo.CreateQuestionReport(report)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to save question report")
+	}
+	if created {
+		svc.disableReportedQuestion(lesson, questionID)
+	}
+
+	return &dto.QuestionReportResponse{
+		LessonID:        lesson.ID,
+		QuestionID:      questionID,
+		Reason:          req.Reason,
+		AlreadyReported: !created,
+	}, nil
+}
+
+// disableReportedQuestion takes a question out of its lesson once it has reached the
+// report threshold. Failures are logged; the report itself is already saved.
+func (svc *ContentService) disableReportedQuestion(lesson *model.Lesson, questionID string) {
+	config, err := svc.contentRepo.GetGameConfig()
+	if err != nil {
+		log.Printf("Failed to load game config for question reports: %v", err)
+		return
+	}
+	if config.QuestionReportThreshold <= 0 {
+		return
+	}
+
+	reports, err := svc.contentRepo.CountPendingQuestionReports(lesson.ID, questionID)
+	if err != nil {
+		log.Printf("Failed to count reports of question %s in lesson %s: %v", questionID, lesson.ID, err)
+		return
+	}
+	if reports < int64(config.QuestionReportThreshold) {
+		return
+	}
+
+	disabled := &model.DisabledQuestion{
+		LessonID:   lesson.ID,
+		QuestionID: questionID,
+		Cause:      model.QuestionDisabledByReports,
+		Reports:    int(reports),
+	}
+	ok, err := svc.contentRepo.DisableQuestion(disabled)
+	if err != nil {
+		log.Printf("Failed to disable question %s in lesson %s: %v", questionID, lesson.ID, err)
+		return
+	}
+	if !ok {
+		return
+	}
+	log.Printf("Question %s in lesson %s disabled for review after %d reports", questionID, lesson.ID, reports)
+
+	if after, err := svc.contentRepo.GetLesson(lesson.ID); err == nil {
+		svc.RecordContentAudit("system", model.ContentEntityLesson, lesson.ID, model.ContentActionUpdate, lesson, after)
+	}
+}
+
+// GetLessonQuestionAnalytics shows how learners answer each question of a lesson and
+// what they report about it, followed by the lesson's questions waiting for review
+func (svc *ContentService) GetLessonQuestionAnalytics(lessonID string) (*dto.LessonQuestionAnalyticsResponse, error) {
+	lesson, err := svc.contentRepo.GetLesson(lessonID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Lesson not found")
+	}
+
+	var questions []model.Question
+	if len(lesson.Questions) > 0 {
+		if err := json.Unmarshal(lesson.Questions, &questions); err != nil {
+			return nil, shared.NewInternalError(err, "Failed to parse lesson questions")
+		}
+	}
+
+	answerCounts, err := svc.contentRepo.GetQuestionAnswerCounts(lessonID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to count question answers")
+	}
+	reportCounts, err := svc.contentRepo.GetQuestionReportCounts(lessonID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to count question reports")
+	}
+	disabled, _, err := svc.contentRepo.GetDisabledQuestions(model.DisabledQuestionPending, lessonID, 1, 100)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get disabled questions")
+	}
+
+	threshold := model.DefaultGameConfig().QuestionReportThreshold
+	if config, err := svc.contentRepo.GetGameConfig(); err == nil {
+		threshold = config.QuestionReportThreshold
+	}
+
+	response := &dto.LessonQuestionAnalyticsResponse{
+		LessonID:        lesson.ID,
+		Title:           lesson.Title,
+		ReportThreshold: threshold,
+		Questions:       make([]dto.QuestionAnalyticsResponse, 0, len(questions)+len(disabled)),
+	}
+	for _, q := range questions {
+		response.Questions = append(response.Questions, dto.QuestionAnalyticsResponse{
+			QuestionID: q.ID,
+			Question:   q.Question,
+			Status:     questionStatusActive,
+		})
+	}
+	for _, d := range disabled {
+		var q model.Question
+		_ = json.Unmarshal(d.Question, &q)
+		response.Questions = append(response.Questions, dto.QuestionAnalyticsResponse{
+			QuestionID: d.QuestionID,
+			Question:   q.Question,
+			Status:     questionStatusDisabled,
+			DisabledID: d.ID,
+		})
+	}
+	addQuestionCounts(response.Questions, answerCounts, reportCounts)
+
+	return response, nil
+}
+
+// addQuestionCounts fills in the answer and report counts of analytics entries
+func addQuestionCounts(entries []dto.QuestionAnalyticsResponse, answers []model.QuestionAnswerCount, reports []model.QuestionReportCount) {
+	byID := make(map[string]*dto.QuestionAnalyticsResponse, len(entries))
+	for i := range entries {
+		entries[i].ReportsByReason = map[string]int{}
+		byID[entries[i].QuestionID] = &entries[i]
+	}
+
+	for _, count := range answers {
+		if entry, ok := byID[count.QuestionID]; ok {
+			entry.Answers = count.Answers
+			entry.Correct = count.Correct
+			if count.Answers > 0 {
+				entry.CorrectPct = math.Round(float64(count.Correct)*1000/float64(count.Answers)) / 10
+			}
+		}
+	}
+	for _, count := range reports {
+		if entry, ok := byID[count.QuestionID]; ok {
+			entry.ReportsByReason[count.Reason] += count.Count
+			if count.Status == model.QuestionReportPending {
+				entry.PendingReports += count.Count
+			}
+		}
+	}
+}
+
+func (svc *ContentService) GetDisabledQuestions(status string, page, limit int) (*dto.DisabledQuestionListResponse, error) {
+	disabled, total, err := svc.contentRepo.GetDisabledQuestions(status, "", page, limit)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get disabled questions")
+	}
+
+	responses := make([]dto.DisabledQuestionResponse, len(disabled))
+	for i := range disabled {
+		responses[i] = mapDisabledQuestion(&disabled[i])
+	}
+
+	return &dto.DisabledQuestionListResponse{
+		Questions: responses,
+		Total:     int(total),
+		Page:      page,
+		Limit:     limit,
+	}, nil
+}
+
+// ReviewDisabledQuestion puts a disabled question back in its lesson, corrected if the
+// admin sent a new version, or removes it for good. Either way its reports are closed.
+func (svc *ContentService) ReviewDisabledQuestion(adminID, disabledID string, req dto.ReviewDisabledQuestionRequest) (*dto.DisabledQuestionResponse, error) {
+	disabled, err := svc.contentRepo.GetDisabledQuestion(disabledID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Disabled question not found")
+	}
+	if disabled.Status != model.DisabledQuestionPending {
+		return nil, shared.NewConflictError(nil, "Disabled question has already been reviewed")
+	}
+
+	status := model.DisabledQuestionRemoved
+	question := disabled.Question
+	if req.Action == "restore" {
+		status = model.DisabledQuestionRestored
+		if q := req.Question; q != nil {
+			corrected := model.Question{
+				ID:       disabled.QuestionID,
+				Type:     q.Type,
+				Question: q.Question,
+				Options:  q.Options,
+				Answer:   q.Answer,
+				Points:   q.Points,
+				Metadata: q.Metadata,
+			}
+			if errs := model.ValidateQuestions([]model.Question{corrected}); len(errs) > 0 {
+				return nil, shared.NewBadRequestError(errs[0], "Invalid question metadata").WithData(map[string]interface{}{
+					"errors": errs,
+				})
+			}
+			if question, err = json.Marshal(corrected); err != nil {
+				return nil, shared.NewInternalError(err, "Failed to encode question")
+			}
+		}
+	}
+
+	before, err := svc.contentRepo.GetLesson(disabled.LessonID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Lesson not found")
+	}
+
+	closed, err := svc.contentRepo.CloseDisabledQuestion(disabled.ID, status, adminID, req.Note, question)
+	if errors.Is(err, repositories.ErrQuestionInLesson) {
+		return nil, shared.NewConflictError(err, "The lesson has another question with this ID")
+	}
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to review disabled question")
+	}
+	if !closed {
+		return nil, shared.NewConflictError(nil, "Disabled question has already been reviewed")
+	}
+
+	if status == model.DisabledQuestionRestored {
+		if after, err := svc.contentRepo.GetLesson(disabled.LessonID); err == nil {
+			svc.RecordContentAudit(adminID, model.ContentEntityLesson, disabled.LessonID, model.ContentActionUpdate, before, after)
+		}
+	}
+
+	disabled, err = svc.contentRepo.GetDisabledQuestion(disabledID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get disabled question")
+	}
+
+	response := mapDisabledQuestion(disabled)
+	return &response, nil
+}
+
+func mapDisabledQuestion(disabled *model.DisabledQuestion) dto.DisabledQuestionResponse {
+	var question model.Question
+	if err := json.Unmarshal(disabled.Question, &question); err != nil {
+		log.Printf("Failed to unmarshal disabled question %s: %v", disabled.ID, err)
+	}
+
+	return dto.DisabledQuestionResponse{
+		ID:          disabled.ID,
+		LessonID:    disabled.LessonID,
+		LessonTitle: disabled.Lesson.Title,
+		QuestionID:  disabled.QuestionID,
+		Question:    question,
+		Cause:       disabled.Cause,
+		Reports:     disabled.Reports,
+		Status:      disabled.Status,
+		ReviewedBy:  disabled.ReviewedBy,
+		ReviewNote:  disabled.ReviewNote,
+		ReviewedAt:  disabled.ReviewedAt,
+		CreatedAt:   disabled.CreatedAt,
+	}
+}
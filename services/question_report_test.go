@@ -0,0 +1,71 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/services/repositories/mocks"
+)
+
+func TestReportQuestion(t *testing.T) {
+	lesson := &model.Lesson{ID: "lesson_1", Questions: json.RawMessage(`[{"id":"q1","question":"Năm nào?"}]`)}
+	created := true
+	var disabled *model.DisabledQuestion
+	repo := &mocks.ContentRepo{
+		GetLessonFunc: func(id string) (*model.Lesson, error) { return lesson, nil },
+		CreateQuestionReportFunc: func(report *model.QuestionReport) (bool, error) {
+			return created, nil
+		},
+		GetGameConfigFunc: func() (*model.GameConfig, error) {
+			config := model.DefaultGameConfig()
+			config.QuestionReportThreshold = 3
+			return &config, nil
+		},
+		CountPendingQuestionReportsFunc: func(lessonID, questionID string) (int64, error) { return 3, nil },
+		DisableQuestionFunc: func(d *model.DisabledQuestion) (bool, error) {
+			disabled = d
+			return true, nil
+		},
+		CreateContentAuditLogFunc: func(auditLog *model.ContentAuditLog) error { return nil },
+	}
+	svc := &ContentService{contentRepo: repo, suggest: newSuggestIndex(), public: &publicCatalogCache{}}
+	req := dto.ReportQuestionRequest{LessonID: "lesson_1", Reason: model.QuestionReportWrongAnswer}
+
+	resp, err := svc.ReportQuestion("user_1", "q1", req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.AlreadyReported || disabled == nil || disabled.Reports != 3 || disabled.Cause != model.QuestionDisabledByReports {
+		t.Errorf("report at the threshold = %+v, disabled %+v", resp, disabled)
+	}
+
+	// A second report by the same learner is not counted again
+	created, disabled = false, nil
+	resp, err = svc.ReportQuestion("user_1", "q1", req)
+	if err != nil || !resp.AlreadyReported || disabled != nil {
+		t.Errorf("repeated report = %+v, %v, disabled %+v", resp, err, disabled)
+	}
+
+	if _, err := svc.ReportQuestion("user_1", "q9", req); err == nil {
+		t.Error("report of an unknown question accepted")
+	}
+}
+
+func TestAddQuestionCounts(t *testing.T) {
+	entries := []dto.QuestionAnalyticsResponse{{QuestionID: "q1"}, {QuestionID: "q2"}}
+	addQuestionCounts(entries,
+		[]model.QuestionAnswerCount{{QuestionID: "q1", Answers: 3, Correct: 1}, {QuestionID: "gone", Answers: 5}},
+		[]model.QuestionReportCount{
+			{QuestionID: "q1", Reason: model.QuestionReportTypo, Status: model.QuestionReportPending, Count: 2},
+			{QuestionID: "q1", Reason: model.QuestionReportTypo, Status: model.QuestionReportReviewed, Count: 1},
+		})
+
+	if q1 := entries[0]; q1.CorrectPct != 33.3 || q1.PendingReports != 2 || q1.ReportsByReason[model.QuestionReportTypo] != 3 {
+		t.Errorf("q1 = %+v", q1)
+	}
+	if q2 := entries[1]; q2.Answers != 0 || q2.ReportsByReason == nil {
+		t.Errorf("q2 = %+v", q2)
+	}
+}
@@ -0,0 +1,334 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lac-hong-legacy/ven_api/model"
+)
+
+// Spreadsheet formats supported by the question bulk editor
+const (
+	QuestionSheetCSV  = "csv"
+	QuestionSheetXLSX = "xlsx"
+)
+
+// questionSheetHeader is the column layout of exported question sheets. Options are
+// one per line inside their cell. Answers are plain text for multiple_choice and
+// fill_blank questions and JSON for the array/object based types.
+var questionSheetHeader = []string{"id", "type", "question", "options", "answer", "points"}
+
+// questionSheetRow is one parsed data row together with its 1-based sheet row number
+type questionSheetRow struct {
+	Row    int
+	Values []string
+}
+
+func questionToSheetRow(q model.Question) []string {
+	return []string{
+		q.ID,
+		q.Type,
+		q.Question,
+		strings.Join(q.Options, "\n"),
+		encodeSheetAnswer(q.Type, q.Answer),
+		strconv.Itoa(q.Points),
+	}
+}
+
+func encodeSheetAnswer(questionType string, answer interface{}) string {
+	if s, ok := answer.(string); ok && sheetAnswerIsText(questionType) {
+		return s
+	}
+	if answer == nil {
+		return ""
+	}
+	encoded, err := json.Marshal(answer)
+	if err != nil {
+		return fmt.Sprint(answer)
+	}
+	return string(encoded)
+}
+
+func decodeSheetAnswer(questionType, cell string) (interface{}, error) {
+	cell = strings.TrimSpace(cell)
+	if sheetAnswerIsText(questionType) {
+		return cell, nil
+	}
+
+	var answer interface{}
+	if err := json.Unmarshal([]byte(cell), &answer); err != nil {
+		return nil, fmt.Errorf("answer must be JSON for %s questions", questionType)
+	}
+	return answer, nil
+}
+
+func sheetAnswerIsText(questionType string) bool {
+	return questionType == "multiple_choice" || questionType == "fill_blank" || questionType == "true_false"
+}
+
+func splitSheetOptions(cell string) []string {
+	var options []string
+	for _, line := range strings.Split(strings.ReplaceAll(cell, "\r\n", "\n"), "\n") {
+		if option := strings.TrimSpace(line); option != "" {
+			options = append(options, option)
+		}
+	}
+	return options
+}
+
+// ==================== CSV ====================
+
+func writeQuestionsCSV(questions []model.Question) ([]byte, error) {
+	var buf bytes.Buffer
+	// UTF-8 BOM so spreadsheet apps detect the encoding of Vietnamese text
+	buf.WriteString("\ufeff")
+
+	w := csv.NewWriter(&buf)
+	if err := w.Write(questionSheetHeader); err != nil {
+		return nil, err
+	}
+	for _, q := range questions {
+		if err := w.Write(questionToSheetRow(q)); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func readQuestionsCSV(data []byte) ([]questionSheetRow, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	r.FieldsPerRecord = -1
+
+	var rows []questionSheetRow
+	for i := 1; ; i++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, questionSheetRow{Row: i, Values: record})
+	}
+	return rows, nil
+}
+
+// ==================== XLSX ====================
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+
+const xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Questions" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`
+
+// writeQuestionsXLSX builds a single-sheet workbook using inline strings, which
+// every spreadsheet app reads without a shared string table or styles part.
+func writeQuestionsXLSX(questions []model.Question) ([]byte, error) {
+	var sheet bytes.Buffer
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`)
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	rows := [][]string{questionSheetHeader}
+	for _, q := range questions {
+		rows = append(rows, questionToSheetRow(q))
+	}
+
+	for i, row := range rows {
+		fmt.Fprintf(&sheet, `<row r="%d">`, i+1)
+		for j, value := range row {
+			ref := xlsxColumnName(j) + strconv.Itoa(i+1)
+			if i > 0 && questionSheetHeader[j] == "points" {
+				fmt.Fprintf(&sheet, `<c r="%s"><v>%s</v></c>`, ref, value)
+				continue
+			}
+			fmt.Fprintf(&sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			if err := xml.EscapeText(&sheet, []byte(value)); err != nil {
+				return nil, err
+			}
+			sheet.WriteString(`</t></is></c>`)
+		}
+		sheet.WriteString(`</row>`)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	parts := []struct {
+		name    string
+		content []byte
+	}{
+		{"[Content_Types].xml", []byte(xlsxContentTypes)},
+		{"_rels/.rels", []byte(xlsxRootRels)},
+		{"xl/workbook.xml", []byte(xlsxWorkbook)},
+		{"xl/_rels/workbook.xml.rels", []byte(xlsxWorkbookRels)},
+		{"xl/worksheets/sheet1.xml", sheet.Bytes()},
+	}
+	for _, part := range parts {
+		w, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(part.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	for _, run := range t.Runs {
+		b.WriteString(run.T)
+	}
+	return b.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Ref   int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// readQuestionsXLSX reads the first worksheet of a workbook as rows of strings
+func readQuestionsXLSX(data []byte) ([]questionSheetRow, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("not a valid xlsx file: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	var sheetNames []string
+	for _, f := range zr.File {
+		files[f.Name] = f
+		if path.Dir(f.Name) == "xl/worksheets" && strings.HasSuffix(f.Name, ".xml") {
+			sheetNames = append(sheetNames, f.Name)
+		}
+	}
+	if len(sheetNames) == 0 {
+		return nil, fmt.Errorf("workbook has no worksheets")
+	}
+	sheetName := "xl/worksheets/sheet1.xml"
+	if _, ok := files[sheetName]; !ok {
+		sort.Strings(sheetNames)
+		sheetName = sheetNames[0]
+	}
+
+	var shared xlsxSharedStrings
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeZipXML(f, &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	var sheet xlsxWorksheet
+	if err := decodeZipXML(files[sheetName], &sheet); err != nil {
+		return nil, err
+	}
+
+	rows := make([]questionSheetRow, 0, len(sheet.Rows))
+	for i, row := range sheet.Rows {
+		rowNumber := row.Ref
+		if rowNumber == 0 {
+			rowNumber = i + 1
+		}
+
+		var values []string
+		for j, cell := range row.Cells {
+			col := j
+			if cell.Ref != "" {
+				col = xlsxColumnIndex(cell.Ref)
+			}
+			for len(values) <= col {
+				values = append(values, "")
+			}
+
+			switch cell.Type {
+			case "s":
+				idx, err := strconv.Atoi(cell.Value)
+				if err != nil || idx < 0 || idx >= len(shared.Items) {
+					return nil, fmt.Errorf("invalid shared string in cell %s", cell.Ref)
+				}
+				values[col] = shared.Items[idx].String()
+			case "inlineStr":
+				values[col] = cell.Inline.String()
+			default:
+				values[col] = cell.Value
+			}
+		}
+		rows = append(rows, questionSheetRow{Row: rowNumber, Values: values})
+	}
+	return rows, nil
+}
+
+func decodeZipXML(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(rc).Decode(v)
+}
+
+// xlsxColumnName converts a 0-based column index to its letter name (0 -> A, 26 -> AA)
+func xlsxColumnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// xlsxColumnIndex returns the 0-based column of a cell reference such as "C12"
+func xlsxColumnIndex(ref string) int {
+	index := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		index = index*26 + int(r-'A'+1)
+	}
+	return index - 1
+}
@@ -2,10 +2,8 @@ package services
 
 import (
 	"fmt"
-	"net"
 	"net/http"
 	"strconv"
-	"strings"
 	"sync"
 	"time"
 
@@ -24,7 +22,11 @@ type RateLimitService struct {
 	configs map[string]*RateLimitConfig
 	mutex   sync.RWMutex
 
-	sqlSvc *PostgresService
+	sqlSvc    *PostgresService
+	systemSvc *SystemService
+
+	// All time-based decisions read this clock so tests can control it
+	clock shared.Clock
 }
 
 // RateLimitConfig represents rate limiting configuration
@@ -46,11 +48,19 @@ func (svc *RateLimitService) Id() string {
 func (svc *RateLimitService) Configure(ctx *context.Context) error {
 	svc.configs = make(map[string]*RateLimitConfig)
 	svc.mutex = sync.RWMutex{}
+	if svc.clock == nil {
+		svc.clock = shared.SystemClock
+	}
 	return svc.DefaultService.Configure(ctx)
 }
 
 func (svc *RateLimitService) Start() error {
-	svc.sqlSvc = svc.Service(POSTGRES_SVC).(*PostgresService)
+	deps := newDependencies(svc.Id(), svc.Service)
+	svc.sqlSvc = resolve[*PostgresService](deps, POSTGRES_SVC)
+	svc.systemSvc = resolve[*SystemService](deps, SYSTEM_SVC)
+	if err := deps.err(); err != nil {
+		return err
+	}
 	svc.initDefaultConfigs()
 
 	// Start background cleanup job
@@ -151,6 +161,14 @@ func (svc *RateLimitService) initDefaultConfigs() {
 			Description:  "General API rate limit per IP",
 			IsActive:     true,
 		},
+		"public_api": {
+			EndpointType: "public_api",
+			MaxRequests:  600,
+			WindowSize:   10 * time.Minute,
+			BlockTime:    15 * time.Minute,
+			Description:  "Public content API rate limit per IP",
+			IsActive:     true,
+		},
 		"api_strict": {
 			EndpointType: "api_strict",
 			MaxRequests:  100,
@@ -190,6 +208,8 @@ func (svc *RateLimitService) initDefaultConfigs() {
 
 // ==================== CORE RATE LIMITING LOGIC ====================
 
+// IsAllowed counts a request from identifier against the sliding window limit of
+// endpointType, see applySlidingWindow.
 func (svc *RateLimitService) IsAllowed(identifier, endpointType string) (bool, *dto.RateLimitInfo, error) {
 	svc.mutex.RLock()
 	config, exists := svc.configs[endpointType]
@@ -203,82 +223,36 @@ func (svc *RateLimitService) IsAllowed(identifier, endpointType string) (bool, *
 		}, nil
 	}
 
-	now := time.Now()
-	windowStart := now.Add(-config.WindowSize)
+	now := svc.clock.Now()
 
-	// Get current rate limit record
 	rateLimit, err := svc.sqlSvc.rateLimitRepo.GetRateLimit(identifier, endpointType)
 	if err != nil {
 		return false, nil, err
 	}
 
-	// Check if currently blocked
-	if rateLimit != nil && rateLimit.BlockedUntil != nil && now.Before(*rateLimit.BlockedUntil) {
-		return false, &dto.RateLimitInfo{
-			Allowed:      false,
-			Remaining:    0,
-			ResetTime:    rateLimit.BlockedUntil,
-			BlockedUntil: rateLimit.BlockedUntil,
-		}, nil
-	}
-
-	// If no existing record or window has passed, create/reset
-	if rateLimit == nil || rateLimit.WindowStart.Before(windowStart) {
+	if rateLimit == nil {
 		rateLimit = &model.RateLimit{
 			Identifier:   identifier,
 			EndpointType: endpointType,
-			RequestCount: 1,
 			WindowStart:  now,
-			BlockedUntil: nil,
 			CreatedAt:    now,
 			UpdatedAt:    now,
 		}
-
-		if err := svc.sqlSvc.rateLimitRepo.SaveRateLimit(rateLimit); err != nil {
-			return false, nil, err
-		}
-
-		resetTime := now.Add(config.WindowSize)
-		return true, &dto.RateLimitInfo{
-			Allowed:   true,
-			Remaining: config.MaxRequests - 1,
-			ResetTime: &resetTime,
-		}, nil
 	}
 
-	// Check if limit exceeded
-	if rateLimit.RequestCount >= config.MaxRequests {
-		// Block the identifier
-		blockedUntil := now.Add(config.BlockTime)
-		rateLimit.BlockedUntil = &blockedUntil
-		rateLimit.UpdatedAt = now
-
-		if err := svc.sqlSvc.rateLimitRepo.UpdateRateLimit(rateLimit); err != nil {
+	info, changed := applySlidingWindow(rateLimit, config, now)
+	if changed {
+		if rateLimit.ID == "" {
+			err = svc.sqlSvc.rateLimitRepo.SaveRateLimit(rateLimit)
+		} else {
+			err = svc.sqlSvc.rateLimitRepo.UpdateRateLimit(rateLimit)
+		}
+		if err != nil {
 			return false, nil, err
 		}
-
-		return false, &dto.RateLimitInfo{
-			Allowed:      false,
-			Remaining:    0,
-			ResetTime:    &blockedUntil,
-			BlockedUntil: &blockedUntil,
-		}, nil
-	}
-
-	// Increment request count
-	rateLimit.RequestCount++
-	rateLimit.UpdatedAt = now
-
-	if err := svc.sqlSvc.rateLimitRepo.UpdateRateLimit(rateLimit); err != nil {
-		return false, nil, err
 	}
 
-	resetTime := rateLimit.WindowStart.Add(config.WindowSize)
-	return true, &dto.RateLimitInfo{
-		Allowed:   true,
-		Remaining: config.MaxRequests - rateLimit.RequestCount,
-		ResetTime: &resetTime,
-	}, nil
+	return info.Allowed, info, nil
 }
 
 // ==================== MIDDLEWARE FUNCTIONS ====================
@@ -476,7 +450,7 @@ func (svc *RateLimitService) addRateLimitHeaders(c *fiber.Ctx, info *dto.RateLim
 	}
 
 	if info.BlockedUntil != nil {
-		retryAfter := int(time.Until(*info.BlockedUntil).Seconds())
+		retryAfter := int(info.BlockedUntil.Sub(svc.clock.Now()).Seconds())
 		if retryAfter > 0 {
 			c.Set("Retry-After", strconv.Itoa(retryAfter))
 		}
@@ -486,6 +460,12 @@ func (svc *RateLimitService) addRateLimitHeaders(c *fiber.Ctx, info *dto.RateLim
 func (svc *RateLimitService) handleRateLimitExceeded(c *fiber.Ctx, endpointType string, info *dto.RateLimitInfo) error {
 	message := svc.getRateLimitMessage(endpointType)
 
+	svc.systemSvc.PublishOpsEvent(OpsEventRateLimitBlock, OpsSeverityWarning, map[string]interface{}{
+		"endpoint_type": endpointType,
+		"ip":            getClientIP(c),
+		"path":          c.Path(),
+	})
+
 	response := map[string]interface{}{
 		"error":   "Rate limit exceeded",
 		"message": message,
@@ -493,7 +473,7 @@ func (svc *RateLimitService) handleRateLimitExceeded(c *fiber.Ctx, endpointType
 
 	if info.BlockedUntil != nil {
 		response["blocked_until"] = info.BlockedUntil.Unix()
-		response["retry_after"] = int(time.Until(*info.BlockedUntil).Seconds())
+		response["retry_after"] = int(info.BlockedUntil.Sub(svc.clock.Now()).Seconds())
 	}
 
 	return shared.ResponseJSON(c, http.StatusTooManyRequests, message, response)
@@ -527,37 +507,7 @@ func (svc *RateLimitService) getRateLimitMessage(endpointType string) string {
 // ==================== UTILITY FUNCTIONS ====================
 
 func getClientIP(c *fiber.Ctx) string {
-	// Check for forwarded IP first (for load balancers/proxies)
-	forwarded := c.Get("X-Forwarded-For")
-	if forwarded != "" {
-		ips := strings.Split(forwarded, ",")
-		if len(ips) > 0 {
-			ip := strings.TrimSpace(ips[0])
-			if ip != "" {
-				return ip
-			}
-		}
-	}
-
-	// Check for real IP header
-	realIP := c.Get("X-Real-IP")
-	if realIP != "" {
-		return realIP
-	}
-
-	// Check Cloudflare header
-	cfIP := c.Get("CF-Connecting-IP")
-	if cfIP != "" {
-		return cfIP
-	}
-
-	// Fall back to remote address
-	ip, _, err := net.SplitHostPort(c.Context().RemoteAddr().String())
-	if err != nil {
-		return c.Context().RemoteAddr().String()
-	}
-
-	return ip
+	return shared.RequestIP(c)
 }
 
 func getDeviceIDFromRequest(c *fiber.Ctx) string {
@@ -604,14 +554,14 @@ func (svc *RateLimitService) GetRateLimitStats() fiber.Handler {
 		// Get blocked records count
 		var blockedRecords int64
 		svc.sqlSvc.Db().Model(&model.RateLimit{}).
-			Where("blocked_until > ?", time.Now()).
+			Where("blocked_until > ?", svc.clock.Now()).
 			Count(&blockedRecords)
 
 		stats := map[string]interface{}{
 			"configs":         configs,
 			"total_records":   totalRecords,
 			"blocked_records": blockedRecords,
-			"timestamp":       time.Now(),
+			"timestamp":       svc.clock.Now(),
 		}
 
 		return shared.ResponseJSON(c, http.StatusOK, "Rate limit statistics", stats)
@@ -700,7 +650,7 @@ func (svc *RateLimitService) UpdateConfig() fiber.Handler {
 // ==================== BACKGROUND JOBS ====================
 
 func (svc *RateLimitService) CleanupOldRecords() error {
-	return svc.sqlSvc.rateLimitRepo.CleanupOldRecords()
+	return svc.sqlSvc.rateLimitRepo.CleanupOldRecords(svc.clock.Now())
 }
 
 func (svc *RateLimitService) startCleanupJob() {
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"math"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+)
+
+// applySlidingWindow decides a request at now against the record of one identifier and
+// updates the record in place. It reports whether the record changed and needs saving.
+//
+// Requests are counted per fixed window starting at record.WindowStart, but the count of
+// the previous window is weighted by how much of it still overlaps the window ending at
+// now. A burst at the end of one window therefore still counts at the start of the next
+// one, so bursts can't straddle a boundary to get twice the limit through: any span of
+// length L <= WindowSize allows at most MaxRequests * (1 + L/WindowSize).
+//
+// A request over the limit blocks the identifier for BlockTime. Denied requests are not
+// counted, and once a block expires the window carries on where it was.
+func applySlidingWindow(record *model.RateLimit, config *RateLimitConfig, now time.Time) (*dto.RateLimitInfo, bool) {
+	if record.BlockedUntil != nil {
+		if now.Before(*record.BlockedUntil) {
+			blockedUntil := *record.BlockedUntil
+			return &dto.RateLimitInfo{
+				Allowed:      false,
+				Remaining:    0,
+				ResetTime:    &blockedUntil,
+				BlockedUntil: &blockedUntil,
+			}, false
+		}
+		record.BlockedUntil = nil
+	}
+
+	rollWindow(record, config.WindowSize, now)
+
+	estimate := slidingWindowEstimate(record, config.WindowSize, now)
+	record.UpdatedAt = now
+
+	if estimate+1 > float64(config.MaxRequests) {
+		blockedUntil := now.Add(config.BlockTime)
+		record.BlockedUntil = &blockedUntil
+
+		return &dto.RateLimitInfo{
+			Allowed:      false,
+			Remaining:    0,
+			ResetTime:    &blockedUntil,
+			BlockedUntil: &blockedUntil,
+		}, true
+	}
+
+	record.RequestCount++
+
+	// Requests of the current window are weighted until the end of the next one
+	resetTime := record.WindowStart.Add(2 * config.WindowSize)
+	return &dto.RateLimitInfo{
+		Allowed:   true,
+		Remaining: int(math.Max(0, math.Floor(float64(config.MaxRequests)-estimate-1))),
+		ResetTime: &resetTime,
+	}, true
+}
+
+// rollWindow moves the record to the fixed window containing now. The count of the
+// window just before it becomes PreviousCount; older counts are dropped.
+func rollWindow(record *model.RateLimit, windowSize time.Duration, now time.Time) {
+	if record.WindowStart.IsZero() {
+		record.WindowStart = now
+		return
+	}
+
+	// Another instance may have started the window slightly in our future
+	if now.Before(record.WindowStart) {
+		return
+	}
+
+	elapsed := now.Sub(record.WindowStart) / windowSize
+	switch {
+	case elapsed == 0:
+		return
+	case elapsed == 1:
+		record.PreviousCount = record.RequestCount
+	default:
+		record.PreviousCount = 0
+	}
+	record.RequestCount = 0
+	record.WindowStart = record.WindowStart.Add(elapsed * windowSize)
+}
+
+// slidingWindowEstimate is the number of requests counted against the window ending at now
+func slidingWindowEstimate(record *model.RateLimit, windowSize time.Duration, now time.Time) float64 {
+	progress := float64(now.Sub(record.WindowStart)) / float64(windowSize)
+	progress = math.Min(math.Max(progress, 0), 1)
+	return float64(record.PreviousCount)*(1-progress) + float64(record.RequestCount)
+}
@@ -0,0 +1,290 @@
+package services
+
+import (
+	"math/rand"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+)
+
+var rateLimitTestStart = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newTestRateLimit(now time.Time) *model.RateLimit {
+	return &model.RateLimit{
+		Identifier:   "127.0.0.1",
+		EndpointType: "test",
+		WindowStart:  now,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+}
+
+// sendRequests sends n requests at the clock's current time and returns how many were allowed
+func sendRequests(record *model.RateLimit, config *RateLimitConfig, clock *shared.FakeClock, n int) int {
+	allowed := 0
+	for i := 0; i < n; i++ {
+		if info, _ := applySlidingWindow(record, config, clock.Now()); info.Allowed {
+			allowed++
+		}
+	}
+	return allowed
+}
+
+func TestSlidingWindowAllowsLimitThenBlocks(t *testing.T) {
+	clock := shared.NewFakeClock(rateLimitTestStart)
+	config := &RateLimitConfig{MaxRequests: 5, WindowSize: time.Minute, BlockTime: 5 * time.Minute}
+	record := newTestRateLimit(clock.Now())
+
+	if allowed := sendRequests(record, config, clock, 5); allowed != 5 {
+		t.Fatalf("allowed %d of the first 5 requests, want 5", allowed)
+	}
+
+	info, changed := applySlidingWindow(record, config, clock.Now())
+	if info.Allowed {
+		t.Fatal("request over the limit was allowed")
+	}
+	if !changed {
+		t.Error("block was not reported as a change")
+	}
+	if want := clock.Now().Add(config.BlockTime); info.BlockedUntil == nil || !info.BlockedUntil.Equal(want) {
+		t.Errorf("blocked until %v, want %v", info.BlockedUntil, want)
+	}
+}
+
+func TestSlidingWindowRejectsBurstAcrossBoundary(t *testing.T) {
+	clock := shared.NewFakeClock(rateLimitTestStart)
+	config := &RateLimitConfig{MaxRequests: 10, WindowSize: time.Minute, BlockTime: time.Minute}
+	record := newTestRateLimit(clock.Now())
+
+	sendRequests(record, config, clock, 1)
+	clock.Advance(59 * time.Second)
+	if allowed := sendRequests(record, config, clock, 9); allowed != 9 {
+		t.Fatalf("allowed %d requests before the boundary, want 9", allowed)
+	}
+
+	// A fixed window would let another 10 through right after the boundary
+	clock.Advance(2 * time.Second)
+	if allowed := sendRequests(record, config, clock, 10); allowed != 0 {
+		t.Errorf("allowed %d requests just after the boundary, want 0", allowed)
+	}
+}
+
+func TestSlidingWindowPreviousWindowFades(t *testing.T) {
+	clock := shared.NewFakeClock(rateLimitTestStart)
+	config := &RateLimitConfig{MaxRequests: 10, WindowSize: time.Minute, BlockTime: time.Second}
+	record := newTestRateLimit(clock.Now())
+
+	sendRequests(record, config, clock, 10)
+
+	// Halfway through the next window half of the previous count still applies
+	clock.Advance(90 * time.Second)
+	if allowed := sendRequests(record, config, clock, 10); allowed != 5 {
+		t.Errorf("allowed %d requests halfway through the next window, want 5", allowed)
+	}
+}
+
+func TestSlidingWindowBlockExpiry(t *testing.T) {
+	clock := shared.NewFakeClock(rateLimitTestStart)
+	config := &RateLimitConfig{MaxRequests: 2, WindowSize: time.Minute, BlockTime: 5 * time.Minute}
+	record := newTestRateLimit(clock.Now())
+
+	sendRequests(record, config, clock, 3)
+	blockedUntil := *record.BlockedUntil
+
+	clock.Set(blockedUntil.Add(-time.Nanosecond))
+	info, changed := applySlidingWindow(record, config, clock.Now())
+	if info.Allowed {
+		t.Fatal("request allowed before the block expired")
+	}
+	if changed || !record.BlockedUntil.Equal(blockedUntil) {
+		t.Error("request during a block extended the block")
+	}
+
+	clock.Set(blockedUntil)
+	info, _ = applySlidingWindow(record, config, clock.Now())
+	if !info.Allowed {
+		t.Fatal("request denied once the block expired")
+	}
+	if info.BlockedUntil != nil || record.BlockedUntil != nil {
+		t.Error("expired block was kept")
+	}
+	if info.Remaining != 1 {
+		t.Errorf("remaining %d after the block expired, want 1", info.Remaining)
+	}
+}
+
+func TestSlidingWindowBlockShorterThanWindow(t *testing.T) {
+	clock := shared.NewFakeClock(rateLimitTestStart)
+	config := &RateLimitConfig{MaxRequests: 2, WindowSize: time.Minute, BlockTime: 10 * time.Second}
+	record := newTestRateLimit(clock.Now())
+
+	sendRequests(record, config, clock, 3)
+
+	// The block is over but the window is still full
+	clock.Advance(config.BlockTime)
+	info, _ := applySlidingWindow(record, config, clock.Now())
+	if info.Allowed {
+		t.Fatal("request allowed while the window was still full")
+	}
+	if want := clock.Now().Add(config.BlockTime); !info.BlockedUntil.Equal(want) {
+		t.Errorf("blocked until %v, want %v", info.BlockedUntil, want)
+	}
+}
+
+func TestSlidingWindowClockSkew(t *testing.T) {
+	clock := shared.NewFakeClock(rateLimitTestStart)
+	config := &RateLimitConfig{MaxRequests: 2, WindowSize: time.Minute, BlockTime: time.Minute}
+	record := newTestRateLimit(clock.Now().Add(time.Second))
+
+	if allowed := sendRequests(record, config, clock, 3); allowed != 2 {
+		t.Errorf("allowed %d requests before the window start, want 2", allowed)
+	}
+}
+
+// rateLimitScenario is a random limit and a random sequence of requests, mixing bursts
+// with long pauses so windows are crossed at all offsets
+type rateLimitScenario struct {
+	Config *RateLimitConfig
+	Gaps   []time.Duration
+}
+
+func (rateLimitScenario) Generate(r *rand.Rand, size int) reflect.Value {
+	window := time.Duration(1+r.Intn(60)) * time.Minute
+	config := &RateLimitConfig{
+		MaxRequests: 1 + r.Intn(20),
+		WindowSize:  window,
+		BlockTime:   time.Duration(r.Int63n(int64(2 * window))),
+	}
+
+	gaps := make([]time.Duration, 50+r.Intn(250))
+	for i := range gaps {
+		switch r.Intn(4) {
+		case 0:
+			gaps[i] = 0
+		case 1:
+			gaps[i] = time.Duration(r.Int63n(int64(time.Second)))
+		case 2:
+			gaps[i] = time.Duration(r.Int63n(int64(window)/int64(config.MaxRequests) + 1))
+		default:
+			gaps[i] = time.Duration(r.Int63n(int64(2 * window)))
+		}
+	}
+
+	return reflect.ValueOf(rateLimitScenario{Config: config, Gaps: gaps})
+}
+
+type rateLimitOutcome struct {
+	At   time.Time
+	Info *dto.RateLimitInfo
+}
+
+func (s rateLimitScenario) run() []rateLimitOutcome {
+	clock := shared.NewFakeClock(rateLimitTestStart)
+	record := newTestRateLimit(clock.Now())
+
+	outcomes := make([]rateLimitOutcome, len(s.Gaps))
+	for i, gap := range s.Gaps {
+		clock.Advance(gap)
+		info, _ := applySlidingWindow(record, s.Config, clock.Now())
+		outcomes[i] = rateLimitOutcome{At: clock.Now(), Info: info}
+	}
+	return outcomes
+}
+
+var rateLimitQuickConfig = &quick.Config{
+	MaxCount: 500,
+	Rand:     rand.New(rand.NewSource(1)),
+}
+
+func TestSlidingWindowBurstBound(t *testing.T) {
+	property := func(s rateLimitScenario) bool {
+		var allowed []time.Time
+		for _, outcome := range s.run() {
+			if outcome.Info.Allowed {
+				allowed = append(allowed, outcome.At)
+			}
+		}
+
+		// Any span of L <= WindowSize holds at most MaxRequests * (1 + L/WindowSize)
+		for i := range allowed {
+			for j := i; j < len(allowed); j++ {
+				span := allowed[j].Sub(allowed[i])
+				if span > s.Config.WindowSize {
+					break
+				}
+				bound := float64(s.Config.MaxRequests) * (1 + float64(span)/float64(s.Config.WindowSize))
+				if float64(j-i+1) > bound+1e-9 {
+					t.Logf("%d requests allowed within %v (limit %d per %v)", j-i+1, span, s.Config.MaxRequests, s.Config.WindowSize)
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	if err := quick.Check(property, rateLimitQuickConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSlidingWindowBlocksHold(t *testing.T) {
+	property := func(s rateLimitScenario) bool {
+		var blockedUntil time.Time
+		for _, outcome := range s.run() {
+			info := outcome.Info
+			if info.Allowed {
+				if outcome.At.Before(blockedUntil) {
+					t.Logf("request at %v allowed while blocked until %v", outcome.At, blockedUntil)
+					return false
+				}
+				if info.Remaining < 0 || info.Remaining >= s.Config.MaxRequests {
+					t.Logf("remaining %d outside [0, %d)", info.Remaining, s.Config.MaxRequests)
+					return false
+				}
+				continue
+			}
+
+			if info.BlockedUntil == nil || !info.BlockedUntil.After(outcome.At) && s.Config.BlockTime > 0 {
+				t.Logf("denied request at %v without an active block", outcome.At)
+				return false
+			}
+			if info.BlockedUntil.After(blockedUntil) {
+				blockedUntil = *info.BlockedUntil
+			}
+		}
+		return true
+	}
+
+	if err := quick.Check(property, rateLimitQuickConfig); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRateLimitHeadersUseClock(t *testing.T) {
+	clock := shared.NewFakeClock(rateLimitTestStart)
+	svc := &RateLimitService{clock: clock}
+
+	blockedUntil := clock.Now().Add(90 * time.Second)
+	info := &dto.RateLimitInfo{Remaining: 0, ResetTime: &blockedUntil, BlockedUntil: &blockedUntil}
+
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		svc.addRateLimitHeaders(c, info)
+		return nil
+	})
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.Header.Get("Retry-After"); got != "90" {
+		t.Errorf("Retry-After %q, want %q", got, "90")
+	}
+}
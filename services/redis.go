@@ -70,6 +70,13 @@ func (svc *RedisService) initRedisClient() {
 	})
 }
 
+func (svc *RedisService) Ping(ctx context.Context) error {
+	if svc.redis == nil {
+		return fmt.Errorf("redis client not initialized")
+	}
+	return svc.redis.Ping(ctx).Err()
+}
+
 func (svc *RedisService) GetClient() *redis.Client {
 	return svc.redis
 }
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/cloakd/common/context"
+)
+
+// startDependencies lists, for every service, the services whose Start must have run
+// before its own: the connections it uses while starting and, for the HTTP service,
+// everything it serves. Services that are only referenced are left out, since every
+// service is configured before any is started.
+var startDependencies = map[string][]string{
+	POSTGRES_SVC:  nil,
+	REDIS_SVC:     nil,
+	EVENT_BUS_SVC: {REDIS_SVC},
+	MINIO_SVC:     nil,
+	JWT_SVC:       {POSTGRES_SVC, REDIS_SVC},
+
+	RATE_LIMIT_SVC:  {POSTGRES_SVC},
+	GEOLOCATION_SVC: {REDIS_SVC},
+	ATTESTATION_SVC: {POSTGRES_SVC, REDIS_SVC},
+	MODERATION_SVC:  {POSTGRES_SVC},
+	MONITORING_SVC:  nil,
+	AUTH_SVC:        {POSTGRES_SVC},
+	GUEST_SVC:       {POSTGRES_SVC},
+	CONTENT_SVC:     {POSTGRES_SVC, REDIS_SVC, EVENT_BUS_SVC},
+
+	TRANSLATION_SVC:         {POSTGRES_SVC},
+	QUESTION_GENERATION_SVC: {POSTGRES_SVC},
+	MEDIA_SVC:               {POSTGRES_SVC, MINIO_SVC},
+	NOTIFICATION_SVC:        {POSTGRES_SVC, EVENT_BUS_SVC},
+	WEBHOOK_SVC:             {POSTGRES_SVC, EVENT_BUS_SVC},
+	ACHIEVEMENT_SVC:         {POSTGRES_SVC, EVENT_BUS_SVC},
+	USER_SVC:                {POSTGRES_SVC, REDIS_SVC, MINIO_SVC, EVENT_BUS_SVC},
+	BATTLE_SVC:              {POSTGRES_SVC},
+	TRIVIA_SVC:              {POSTGRES_SVC},
+	STUDY_ROOM_SVC:          {POSTGRES_SVC},
+	SHOP_SVC:                {POSTGRES_SVC, EVENT_BUS_SVC},
+	CERTIFICATE_SVC:         {POSTGRES_SVC, MINIO_SVC, EVENT_BUS_SVC},
+	EMAIL_SVC:               nil,
+	SYSTEM_SVC:              {POSTGRES_SVC, REDIS_SVC, MINIO_SVC},
+	OUTBOX_SVC:              {POSTGRES_SVC},
+	RETENTION_SVC:           {POSTGRES_SVC},
+	RESEARCH_EXPORT_SVC:     {POSTGRES_SVC, MINIO_SVC},
+	MAINTENANCE_SVC:         {POSTGRES_SVC, REDIS_SVC},
+	APP_VERSION_SVC:         {POSTGRES_SVC},
+	CACHE_SVC:               {POSTGRES_SVC, REDIS_SVC, CONTENT_SVC, USER_SVC},
+	KILL_SWITCH_SVC:         {POSTGRES_SVC, CONTENT_SVC, CACHE_SVC, NOTIFICATION_SVC, SYSTEM_SVC},
+
+	HTTP_SVC: {
+		POSTGRES_SVC, JWT_SVC, RATE_LIMIT_SVC, AUTH_SVC, GUEST_SVC, CONTENT_SVC,
+		TRANSLATION_SVC, QUESTION_GENERATION_SVC, MEDIA_SVC, NOTIFICATION_SVC, WEBHOOK_SVC,
+		USER_SVC, BATTLE_SVC, TRIVIA_SVC, STUDY_ROOM_SVC, SHOP_SVC, CERTIFICATE_SVC,
+		SYSTEM_SVC, RETENTION_SVC, RESEARCH_EXPORT_SVC, MAINTENANCE_SVC, APP_VERSION_SVC, CACHE_SVC,
+		KILL_SWITCH_SVC,
+	},
+}
+
+// NewContext checks the start order of services before handing them to the service
+// context, so wiring mistakes fail at launch with a description instead of as a nil
+// pointer on the first request
+func NewContext(list ...context.Service) (*context.Context, error) {
+	if err := checkStartOrder(list); err != nil {
+		return nil, fmt.Errorf("invalid service order: %w", err)
+	}
+	return context.NewContext(list...)
+}
+
+// checkStartOrder reports every service without declared start dependencies and every
+// dependency that is missing or registered after the service needing it. Services start
+// in registration order.
+func checkStartOrder(list []context.Service) error {
+	position := make(map[string]int, len(list))
+	for i, service := range list {
+		position[service.Id()] = i
+	}
+
+	var errs []error
+	for i, service := range list {
+		id := service.Id()
+		dependencies, declared := startDependencies[id]
+		if !declared {
+			errs = append(errs, fmt.Errorf("%s has no entry in startDependencies", id))
+			continue
+		}
+		for _, dependency := range dependencies {
+			at, registered := position[dependency]
+			switch {
+			case !registered:
+				errs = append(errs, fmt.Errorf("%s needs %s, which is not registered", id, dependency))
+			case at > i:
+				errs = append(errs, fmt.Errorf("%s needs %s started first, but it is registered after it", id, dependency))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// dependencies resolves the services another one uses. Instead of panicking on the first
+// missing or mistyped service it collects them all, for Start to return at once.
+type dependencies struct {
+	of     string
+	lookup func(id string) context.Service
+	errs   []error
+}
+
+func newDependencies(of string, lookup func(id string) context.Service) *dependencies {
+	return &dependencies{of: of, lookup: lookup}
+}
+
+// resolve returns the service registered under id as T. T may be an interface the
+// service satisfies, so tests can hand a service a fake of just what it calls.
+func resolve[T any](deps *dependencies, id string) T {
+	var zero T
+	service := deps.lookup(id)
+	if service == nil {
+		deps.errs = append(deps.errs, fmt.Errorf("%s: service %s is not registered", deps.of, id))
+		return zero
+	}
+	typed, ok := service.(T)
+	if !ok {
+		deps.errs = append(deps.errs, fmt.Errorf("%s: service %s is %T, which is not %v", deps.of, id, service, reflect.TypeFor[T]()))
+		return zero
+	}
+	return typed
+}
+
+func (deps *dependencies) err() error {
+	return errors.Join(deps.errs...)
+}
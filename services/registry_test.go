@@ -0,0 +1,67 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cloakd/common/context"
+)
+
+type stubService struct{ id string }
+
+func (s stubService) Id() string                       { return s.id }
+func (s stubService) Configure(*context.Context) error { return nil }
+func (s stubService) Start() error                     { return nil }
+
+func stubs(ids ...string) []context.Service {
+	list := make([]context.Service, len(ids))
+	for i, id := range ids {
+		list[i] = stubService{id}
+	}
+	return list
+}
+
+func TestCheckStartOrder(t *testing.T) {
+	if err := checkStartOrder(stubs(POSTGRES_SVC, REDIS_SVC, EVENT_BUS_SVC, CONTENT_SVC)); err != nil {
+		t.Errorf("valid order rejected: %v", err)
+	}
+
+	tests := map[string]struct {
+		list []context.Service
+		want string
+	}{
+		"registered late": {stubs(REDIS_SVC, EVENT_BUS_SVC, CONTENT_SVC, POSTGRES_SVC), "content_svc needs postgres_svc started first"},
+		"not registered":  {stubs(POSTGRES_SVC, GEOLOCATION_SVC), "geolocation_svc needs redis_svc, which is not registered"},
+		"undeclared":      {stubs(POSTGRES_SVC, "mystery_svc"), "mystery_svc has no entry"},
+	}
+	for name, tt := range tests {
+		err := checkStartOrder(tt.list)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: got %v, want an error containing %q", name, err, tt.want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	registered := map[string]context.Service{POSTGRES_SVC: &PostgresService{}, REDIS_SVC: &RedisService{}}
+	deps := newDependencies(GUEST_SVC, func(id string) context.Service { return registered[id] })
+
+	if resolve[*PostgresService](deps, POSTGRES_SVC) == nil {
+		t.Error("registered service not resolved")
+	}
+	if err := deps.err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	resolve[*PostgresService](deps, REDIS_SVC)
+	resolve[*ContentService](deps, CONTENT_SVC)
+	err := deps.err()
+	if err == nil {
+		t.Fatal("expected errors for mistyped and missing services")
+	}
+	for _, want := range []string{"service redis_svc is *services.RedisService, which is not *services.PostgresService", "service content_svc is not registered"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	studyReminderInterval = time.Minute
+	studyReminderBatch    = 500
+
+	// Reminders missed by more than this, e.g. while the API was down, are skipped
+	// rather than sent late
+	studyReminderMaxDelay = time.Hour
+)
+
+type StudyReminderEmail struct {
+	Email    string
+	Username string
+	Streak   int
+}
+
+// ==================== STUDY REMINDER METHODS ====================
+
+func (svc *NotificationService) GetStudyReminder(userID string) (*dto.StudyReminderResponse, error) {
+	reminder, err := svc.sqlSvc.notificationRepo.GetStudyReminder(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, shared.NewNotFoundError(err, "No study reminder set")
+	}
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get study reminder")
+	}
+
+	return svc.mapStudyReminder(userID, reminder)
+}
+
+// SetStudyReminder creates or replaces the user's reminder schedule. Fields left out of
+// the request keep their current value.
+func (svc *NotificationService) SetStudyReminder(userID string, req dto.StudyReminderRequest) (*dto.StudyReminderResponse, error) {
+	reminder, err := svc.sqlSvc.notificationRepo.GetStudyReminder(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		reminder = &model.StudyReminder{UserID: userID, Push: true, Enabled: true}
+	} else if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get study reminder")
+	}
+
+	reminder.Days = model.StudyReminderDayMask(req.Days)
+	reminder.TimeOfDay = req.Time
+	if req.Push != nil {
+		reminder.Push = *req.Push
+	}
+	if req.Email != nil {
+		reminder.Email = *req.Email
+	}
+	if req.Enabled != nil {
+		reminder.Enabled = *req.Enabled
+	}
+	if !reminder.Push && !reminder.Email {
+		return nil, shared.NewBadRequestError(errors.New("no channel"), "Enable push or email reminders")
+	}
+
+	location, err := svc.userLocation(userID)
+	if err != nil {
+		return nil, err
+	}
+	reminder.SnoozedUntil = nil
+	reminder.NextReminderAt = nil
+	if reminder.Enabled {
+		reminder.NextReminderAt = nextStudyReminder(reminder, time.Now(), location)
+	}
+
+	if err := svc.sqlSvc.notificationRepo.SaveStudyReminder(reminder); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to save study reminder")
+	}
+
+	return svc.mapStudyReminder(userID, reminder)
+}
+
+func (svc *NotificationService) DeleteStudyReminder(userID string) error {
+	found, err := svc.sqlSvc.notificationRepo.DeleteStudyReminder(userID)
+	if err != nil {
+		return shared.NewInternalError(err, "Failed to delete study reminder")
+	}
+	if !found {
+		return shared.NewNotFoundError(nil, "No study reminder set")
+	}
+	return nil
+}
+
+// SnoozeStudyReminder reminds the user again after the given minutes. The regular
+// schedule resumes after that reminder.
+func (svc *NotificationService) SnoozeStudyReminder(userID string, minutes int) (*dto.StudyReminderResponse, error) {
+	reminder, err := svc.sqlSvc.notificationRepo.GetStudyReminder(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, shared.NewNotFoundError(err, "No study reminder set")
+	}
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get study reminder")
+	}
+	if !reminder.Enabled {
+		return nil, shared.NewConflictError(errors.New("reminder disabled"), "Study reminders are turned off")
+	}
+
+	snoozedUntil := time.Now().Add(time.Duration(minutes) * time.Minute)
+	reminder.SnoozedUntil = &snoozedUntil
+	reminder.NextReminderAt = &snoozedUntil
+
+	if err := svc.sqlSvc.notificationRepo.SaveStudyReminder(reminder); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to snooze study reminder")
+	}
+
+	return svc.mapStudyReminder(userID, reminder)
+}
+
+// RescheduleStudyReminder recomputes the next reminder, e.g. after the user changed
+// timezone. A pending snooze is kept.
+func (svc *NotificationService) RescheduleStudyReminder(userID string) {
+	reminder, err := svc.sqlSvc.notificationRepo.GetStudyReminder(userID)
+	if err != nil || !reminder.Enabled || reminder.SnoozedUntil != nil {
+		return
+	}
+
+	location, err := svc.userLocation(userID)
+	if err != nil {
+		log.Printf("Failed to reschedule study reminder of user %s: %v", userID, err)
+		return
+	}
+
+	reminder.NextReminderAt = nextStudyReminder(reminder, time.Now(), location)
+	if err := svc.sqlSvc.notificationRepo.SaveStudyReminder(reminder); err != nil {
+		log.Printf("Failed to reschedule study reminder of user %s: %v", userID, err)
+	}
+}
+
+func (svc *NotificationService) startStudyReminderJob() {
+	ticker := time.NewTicker(studyReminderInterval)
+	for range ticker.C {
+		svc.sendDueStudyReminders()
+	}
+}
+
+// sendDueStudyReminders sends every reminder that is due and moves it on to its next
+// time. Reminders are skipped on days the user already completed a lesson.
+func (svc *NotificationService) sendDueStudyReminders() {
+	now := time.Now()
+	reminders, err := svc.sqlSvc.notificationRepo.GetDueStudyReminders(now, studyReminderBatch)
+	if err != nil {
+		log.WithError(err).Error("Failed to load due study reminders")
+		return
+	}
+
+	for i := range reminders {
+		reminder := &reminders[i]
+		user := &reminder.User
+		location := streakLocation(user.Timezone)
+
+		progress, err := svc.sqlSvc.contentRepo.GetUserProgress(user.ID)
+		if err != nil {
+			log.WithError(err).Errorf("Failed to load progress for study reminder of user %s", user.ID)
+			continue
+		}
+
+		send := now.Sub(*reminder.NextReminderAt) <= studyReminderMaxDelay
+		if progress.LastActivityDate != nil && streakDay(*progress.LastActivityDate, location).Equal(streakDay(now, location)) {
+			send = false
+		}
+
+		var sentAt *time.Time
+		var messages []*model.OutboxMessage
+		if send {
+			sentAt = &now
+			if reminder.Email && user.EmailVerified {
+				messages = append(messages, newOutboxMessage(OutboxTopicStudyReminderEmail, StudyReminderEmail{
+					Email:    user.Email,
+					Username: user.Username,
+					Streak:   progress.Streak,
+				}))
+			}
+		}
+
+		next := nextStudyReminder(reminder, now, location)
+		claimed, err := svc.sqlSvc.notificationRepo.ClaimStudyReminder(reminder.ID, *reminder.NextReminderAt, next, sentAt, messages...)
+		if err != nil {
+			log.WithError(err).Errorf("Failed to queue study reminder for user %s", user.ID)
+			continue
+		}
+		if !claimed || !send {
+			continue
+		}
+
+		if reminder.Push {
+			svc.Notify(user.ID, model.NotificationTypeReminder, "Time to study!",
+				studyReminderBody(progress.Streak), map[string]interface{}{"streak": progress.Streak})
+		}
+		if len(messages) > 0 {
+			go svc.outboxSvc.Relay(messages...)
+		}
+	}
+}
+
+func (svc *NotificationService) userLocation(userID string) (*time.Location, error) {
+	user, err := svc.sqlSvc.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get user")
+	}
+	return streakLocation(user.Timezone), nil
+}
+
+func (svc *NotificationService) mapStudyReminder(userID string, reminder *model.StudyReminder) (*dto.StudyReminderResponse, error) {
+	location, err := svc.userLocation(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.StudyReminderResponse{
+		Days:           reminder.DayNames(),
+		Time:           reminder.TimeOfDay,
+		Timezone:       location.String(),
+		Push:           reminder.Push,
+		Email:          reminder.Email,
+		Enabled:        reminder.Enabled,
+		NextReminderAt: reminder.NextReminderAt,
+		SnoozedUntil:   reminder.SnoozedUntil,
+		LastSentAt:     reminder.LastSentAt,
+	}, nil
+}
+
+// nextStudyReminder returns the first scheduled reminder time after after, or nil if the
+// schedule has no days
+func nextStudyReminder(reminder *model.StudyReminder, after time.Time, location *time.Location) *time.Time {
+	clock, err := time.Parse("15:04", reminder.TimeOfDay)
+	if err != nil || reminder.Days == 0 {
+		return nil
+	}
+
+	local := after.In(location)
+	for i := 0; i <= 7; i++ {
+		day := local.AddDate(0, 0, i)
+		at := time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), 0, 0, location)
+		if at.After(after) && reminder.Days&(1<<int(at.Weekday())) != 0 {
+			return &at
+		}
+	}
+	return nil
+}
+
+func studyReminderBody(streak int) string {
+	if streak > 1 {
+		return fmt.Sprintf("Keep your %d day streak going with a lesson today.", streak)
+	}
+	return "A few minutes of history a day adds up. Start a lesson now."
+}
@@ -1,6 +1,9 @@
 package repositories
 
-import "gorm.io/gorm"
+import (
+	"github.com/lac-hong-legacy/ven_api/model"
+	"gorm.io/gorm"
+)
 
 type AnalyticRepository struct {
 	BaseRepository
@@ -11,3 +14,29 @@ func NewAnalyticRepository(db *gorm.DB) *AnalyticRepository {
 		BaseRepository: NewBaseRepository(db),
 	}
 }
+
+// ==================== LESSON POPULARITY ====================
+
+func (ds *AnalyticRepository) CountLessonBookmarks(lessonID string) (int64, error) {
+	var count int64
+	if err := ds.db.Model(&model.UserLessonBookmark{}).
+		Where("lesson_id = ?", lessonID).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetMostBookmarkedLessons ranks lessons by how many users saved them for later
+func (ds *AnalyticRepository) GetMostBookmarkedLessons(limit int) ([]model.LessonBookmarkCount, error) {
+	var counts []model.LessonBookmarkCount
+	if err := ds.db.Model(&model.UserLessonBookmark{}).
+		Select("lesson_id, COUNT(*) AS bookmark_count").
+		Group("lesson_id").
+		Order("bookmark_count DESC").
+		Limit(limit).
+		Scan(&counts).Error; err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
@@ -0,0 +1,31 @@
+package repositories
+
+import (
+	"github.com/lac-hong-legacy/ven_api/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AppVersionRepository struct {
+	BaseRepository
+}
+
+func NewAppVersionRepository(db *gorm.DB) *AppVersionRepository {
+	return &AppVersionRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+func (ds *AppVersionRepository) GetAppVersionPolicies() ([]model.AppVersionPolicy, error) {
+	var policies []model.AppVersionPolicy
+	err := ds.db.Order("platform").Find(&policies).Error
+	return policies, err
+}
+
+// SaveAppVersionPolicy creates or replaces the policy for its platform
+func (ds *AppVersionRepository) SaveAppVersionPolicy(policy *model.AppVersionPolicy) error {
+	return ds.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "platform"}},
+		DoUpdates: clause.AssignmentColumns([]string{"min_version", "latest_version", "store_url", "updated_by", "updated_at"}),
+	}).Create(policy).Error
+}
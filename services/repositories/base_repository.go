@@ -1,7 +1,11 @@
 package repositories
 
 import (
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // BaseRepository provides common database functionality
@@ -17,3 +21,17 @@ func NewBaseRepository(db *gorm.DB) BaseRepository {
 func (r *BaseRepository) DB() *gorm.DB {
 	return r.db
 }
+
+// runDataMigration applies a one-off data migration in the transaction that records it,
+// and skips it once recorded. Instances starting together wait on the record of the
+// first one and skip when it commits.
+func (r *BaseRepository) runDataMigration(name string, migrate func(tx *gorm.DB) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&model.DataMigration{Name: name, AppliedAt: time.Now()})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return migrate(tx)
+	})
+}
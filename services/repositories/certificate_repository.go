@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared/ids"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CertificateRepository struct {
+	BaseRepository
+}
+
+func NewCertificateRepository(db *gorm.DB) *CertificateRepository {
+	return &CertificateRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// CreateCertificate stores a certificate and reports whether it is new. A user gets one
+// certificate per dynasty; a second one is dropped.
+func (ds *CertificateRepository) CreateCertificate(certificate *model.Certificate) (bool, error) {
+	if certificate.ID == "" {
+		certificate.ID = ids.New()
+	}
+	certificate.CreatedAt = time.Now()
+
+	result := ds.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "dynasty"}},
+		DoNothing: true,
+	}).Create(certificate)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (ds *CertificateRepository) GetUserCertificates(userID string) ([]model.Certificate, error) {
+	var certificates []model.Certificate
+	err := ds.db.Where("user_id = ?", userID).Order("completed_at ASC").Find(&certificates).Error
+	return certificates, err
+}
+
+func (ds *CertificateRepository) GetUserCertificate(userID, certificateID string) (*model.Certificate, error) {
+	var certificate model.Certificate
+	if err := ds.db.Where("id = ? AND user_id = ?", certificateID, userID).First(&certificate).Error; err != nil {
+		return nil, err
+	}
+	return &certificate, nil
+}
+
+func (ds *CertificateRepository) GetCertificateByCode(code string) (*model.Certificate, error) {
+	var certificate model.Certificate
+	if err := ds.db.Where("code = ?", code).First(&certificate).Error; err != nil {
+		return nil, err
+	}
+	return &certificate, nil
+}
+
+func (ds *CertificateRepository) CertificateCodeInUse(code string) (bool, error) {
+	var count int64
+	err := ds.db.Model(&model.Certificate{}).Where("code = ?", code).Count(&count).Error
+	return count > 0, err
+}
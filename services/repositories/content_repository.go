@@ -1,12 +1,17 @@
 package repositories
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared/ids"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ContentRepository struct {
@@ -28,8 +33,7 @@ func (ds *ContentRepository) GetProgress(sessionID string) (*model.GuestProgress
 }
 
 func (ds *ContentRepository) CreateProgress(progress *model.GuestProgress) (*model.GuestProgress, error) {
-	id, _ := uuid.NewV7()
-	progress.ID = id.String()
+	progress.ID = ids.New()
 	if err := ds.db.Create(progress).Error; err != nil {
 		return nil, err
 	}
@@ -44,18 +48,30 @@ func (ds *ContentRepository) UpdateProgress(progress *model.GuestProgress) error
 }
 
 func (ds *ContentRepository) CreateLessonAttempt(attempt *model.GuestLessonAttempt) error {
-	id, _ := uuid.NewV7()
-	attempt.ID = id.String()
+	attempt.ID = ids.New()
 	if err := ds.db.Create(attempt).Error; err != nil {
 		return err
 	}
 	return nil
 }
 
+// CountGuestAttemptsSince counts the guest lesson attempts made since a time from a
+// device and from an IP address. An empty IP address is not counted.
+func (ds *ContentRepository) CountGuestAttemptsSince(deviceID, ipAddress string, since time.Time) (byDevice, byIP int64, err error) {
+	var counts struct {
+		ByDevice int64
+		ByIP     int64
+	}
+	err = ds.db.Model(&model.GuestLessonAttempt{}).
+		Select("COUNT(*) FILTER (WHERE device_id = ?) AS by_device, COUNT(*) FILTER (WHERE ip_address = ? AND ip_address <> '') AS by_ip", deviceID, ipAddress).
+		Where("created_at >= ? AND (device_id = ? OR ip_address = ?)", since, deviceID, ipAddress).
+		Scan(&counts).Error
+	return counts.ByDevice, counts.ByIP, err
+}
+
 func (ds *ContentRepository) CreateCharacter(character *model.Character) (*model.Character, error) {
 	if character.ID == "" {
-		id, _ := uuid.NewV7()
-		character.ID = id.String()
+		character.ID = ids.NewPrefixed(ids.Character)
 	}
 	character.CreatedAt = time.Now()
 	character.UpdatedAt = time.Now()
@@ -108,8 +124,7 @@ func (ds *ContentRepository) UpdateCharacter(character *model.Character) error {
 
 func (ds *ContentRepository) CreateLesson(lesson *model.Lesson) (*model.Lesson, error) {
 	if lesson.ID == "" {
-		id, _ := uuid.NewV7()
-		lesson.ID = id.String()
+		lesson.ID = ids.NewPrefixed(ids.Lesson)
 	}
 	lesson.CreatedAt = time.Now()
 	lesson.UpdatedAt = time.Now()
@@ -137,6 +152,30 @@ func (ds *ContentRepository) GetLessonsByCharacter(characterID string) ([]model.
 	return lessons, nil
 }
 
+// GetPublicLessons returns every active lesson without its questions, script or media,
+// for the public content API
+func (ds *ContentRepository) GetPublicLessons() ([]model.Lesson, error) {
+	var lessons []model.Lesson
+	err := ds.db.Select("id", "character_id", "title", "order", "story", "thumbnail_url", "content_rating", "updated_at").
+		Where("is_active = ?", true).
+		Order("character_id ASC, \"order\" ASC").
+		Find(&lessons).Error
+	return lessons, err
+}
+
+func (ds *ContentRepository) GetLessonsByIDs(ids []string) ([]model.Lesson, error) {
+	var lessons []model.Lesson
+	if len(ids) == 0 {
+		return lessons, nil
+	}
+
+	if err := ds.db.Preload("Character").Where("id IN ? AND is_active = ?", ids, true).
+		Find(&lessons).Error; err != nil {
+		return nil, err
+	}
+	return lessons, nil
+}
+
 func (ds *ContentRepository) UpdateLesson(lesson *model.Lesson) error {
 	lesson.UpdatedAt = time.Now()
 	if err := ds.db.Save(lesson).Error; err != nil {
@@ -145,12 +184,40 @@ func (ds *ContentRepository) UpdateLesson(lesson *model.Lesson) error {
 	return nil
 }
 
+// UpdateLessonQuestions replaces a lesson's questions in one transaction, but only if
+// they still match baseVersion. It reports false when someone else changed them first.
+func (ds *ContentRepository) UpdateLessonQuestions(lessonID, baseVersion string, questions json.RawMessage) (bool, error) {
+	updated := false
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		var lesson model.Lesson
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", lessonID).First(&lesson).Error; err != nil {
+			return err
+		}
+
+		if lesson.QuestionsVersion() != baseVersion {
+			return nil
+		}
+
+		if err := tx.Model(&model.Lesson{}).Where("id = ?", lessonID).
+			Updates(map[string]interface{}{
+				"questions":  questions,
+				"updated_at": time.Now(),
+			}).Error; err != nil {
+			return err
+		}
+
+		updated = true
+		return nil
+	})
+	return updated, err
+}
+
 // ==================== TIMELINE METHODS ====================
 
 func (ds *ContentRepository) CreateTimeline(timeline *model.Timeline) (*model.Timeline, error) {
 	if timeline.ID == "" {
-		id, _ := uuid.NewV7()
-		timeline.ID = id.String()
+		timeline.ID = ids.New()
 	}
 	timeline.CreatedAt = time.Now()
 	timeline.UpdatedAt = time.Now()
@@ -181,8 +248,7 @@ func (ds *ContentRepository) GetTimelineByEra(era string) ([]model.Timeline, err
 
 func (ds *ContentRepository) CreateUserProgress(progress *model.UserProgress) (*model.UserProgress, error) {
 	if progress.ID == "" {
-		id, _ := uuid.NewV7()
-		progress.ID = id.String()
+		progress.ID = ids.New()
 	}
 	progress.CreatedAt = time.Now()
 	progress.UpdatedAt = time.Now()
@@ -201,12 +267,41 @@ func (ds *ContentRepository) GetUserProgress(userID string) (*model.UserProgress
 	return &progress, nil
 }
 
-func (ds *ContentRepository) UpdateUserProgress(progress *model.UserProgress) error {
-	progress.UpdatedAt = time.Now()
-	if err := ds.db.Save(progress).Error; err != nil {
-		return err
+// ModifyUserProgress applies modify to the user's progress and stores the result in one
+// transaction. The row is locked in between, so writes that land meanwhile, such as
+// shop purchases, are not overwritten. A nil change leaves the progress as it was.
+func (ds *ContentRepository) ModifyUserProgress(userID string, modify func(progress *model.UserProgress) (*model.ProgressChange, error)) (*model.UserProgress, error) {
+	var progress model.UserProgress
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockUserProgress(tx, userID, &progress); err != nil {
+			return err
+		}
+
+		change, err := modify(&progress)
+		if err != nil || change == nil {
+			return err
+		}
+		return saveProgress(tx, &progress, change)
+	})
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	return &progress, nil
+}
+
+func lockUserProgress(tx *gorm.DB, userID string, progress *model.UserProgress) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ?", userID).First(progress).Error
+}
+
+func (ds *ContentRepository) GetProgressUserIDs() ([]string, error) {
+	var userIDs []string
+	if err := ds.db.Model(&model.UserProgress{}).
+		Order("user_id").
+		Pluck("user_id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+	return userIDs, nil
 }
 
 func (ds *ContentRepository) GetUsersForHeartReset(since time.Time) ([]model.UserProgress, error) {
@@ -218,214 +313,2405 @@ func (ds *ContentRepository) GetUsersForHeartReset(since time.Time) ([]model.Use
 	return users, nil
 }
 
-// ==================== SPIRIT METHODS ====================
+// ==================== LESSON COMPLETION METHODS ====================
 
-func (ds *ContentRepository) CreateSpirit(spirit *model.Spirit) (*model.Spirit, error) {
-	if spirit.ID == "" {
-		id, _ := uuid.NewV7()
-		spirit.ID = id.String()
+// CreateLessonCompletion inserts a completion row and reports whether it was new.
+// The unique (user_id, lesson_id) index makes concurrent completions idempotent.
+func (ds *ContentRepository) CreateLessonCompletion(completion *model.UserLessonCompletion) (bool, error) {
+	if completion.ID == "" {
+		completion.ID = ids.New()
 	}
-	spirit.CreatedAt = time.Now()
-	spirit.UpdatedAt = time.Now()
+	if completion.CompletedAt.IsZero() {
+		completion.CompletedAt = time.Now()
+	}
+	completion.CreatedAt = time.Now()
 
-	if err := ds.db.Create(spirit).Error; err != nil {
-		return nil, err
+	result := ds.db.Clauses(clause.OnConflict{DoNothing: true}).Create(completion)
+	if result.Error != nil {
+		return false, result.Error
 	}
-	return spirit, nil
+	return result.RowsAffected > 0, nil
 }
 
-func (ds *ContentRepository) GetUserSpirit(userID string) (*model.Spirit, error) {
-	var spirit model.Spirit
-	if err := ds.db.Where("user_id = ?", userID).First(&spirit).Error; err != nil {
-		return nil, err
+// CompleteLesson records a completion and the progress it earns in one transaction,
+// reporting whether the completion is new. complete applies the completion to the
+// stored progress, locked like in ModifyUserProgress, and returns what to store with
+// it. Nothing is stored if any write fails, so a retry earns the XP again.
+func (ds *ContentRepository) CompleteLesson(completion *model.UserLessonCompletion, complete func(progress *model.UserProgress, firstCompletion bool) *model.ProgressChange) (bool, error) {
+	if completion.ID == "" {
+		completion.ID = ids.New()
 	}
-	return &spirit, nil
+	if completion.CompletedAt.IsZero() {
+		completion.CompletedAt = time.Now()
+	}
+	completion.CreatedAt = time.Now()
+
+	var created bool
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		var progress model.UserProgress
+		if err := lockUserProgress(tx, completion.UserID, &progress); err != nil {
+			return err
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(completion)
+		if result.Error != nil {
+			return result.Error
+		}
+		created = result.RowsAffected > 0
+
+		return saveProgress(tx, &progress, complete(&progress, created))
+	})
+	return created, err
 }
 
-func (ds *ContentRepository) UpdateSpirit(spirit *model.Spirit) error {
-	spirit.UpdatedAt = time.Now()
-	if err := ds.db.Save(spirit).Error; err != nil {
-		return err
+func (ds *ContentRepository) HasCompletedLesson(userID, lessonID string) (bool, error) {
+	var count int64
+	if err := ds.db.Model(&model.UserLessonCompletion{}).
+		Where("user_id = ? AND lesson_id = ?", userID, lessonID).
+		Count(&count).Error; err != nil {
+		return false, err
 	}
-	return nil
+	return count > 0, nil
 }
 
-// ==================== ACHIEVEMENT METHODS ====================
+func (ds *ContentRepository) GetCompletedLessonIDs(userID string) ([]string, error) {
+	var lessonIDs []string
+	if err := ds.db.Model(&model.UserLessonCompletion{}).
+		Where("user_id = ?", userID).
+		Order("completed_at ASC").
+		Pluck("lesson_id", &lessonIDs).Error; err != nil {
+		return nil, err
+	}
+	return lessonIDs, nil
+}
 
-func (ds *ContentRepository) CreateAchievement(achievement *model.Achievement) (*model.Achievement, error) {
-	if achievement.ID == "" {
-		id, _ := uuid.NewV7()
-		achievement.ID = id.String()
+// GetDynastyLessons returns the active lessons of a dynasty's characters, in order
+func (ds *ContentRepository) GetDynastyLessons(dynasty string) ([]model.Lesson, error) {
+	var lessons []model.Lesson
+	err := ds.db.Joins("Character").
+		Where("\"Character\".dynasty = ? AND lessons.is_active = ?", dynasty, true).
+		Order("lessons.character_id ASC, lessons.\"order\" ASC").
+		Find(&lessons).Error
+	return lessons, err
+}
+
+// GetLessonCompletions returns the user's completions of the given lessons
+func (ds *ContentRepository) GetLessonCompletions(userID string, lessonIDs []string) ([]model.UserLessonCompletion, error) {
+	var completions []model.UserLessonCompletion
+	if len(lessonIDs) == 0 {
+		return completions, nil
 	}
-	achievement.CreatedAt = time.Now()
-	achievement.UpdatedAt = time.Now()
+	err := ds.db.Where("user_id = ? AND lesson_id IN ?", userID, lessonIDs).
+		Order("completed_at ASC").
+		Find(&completions).Error
+	return completions, err
+}
 
-	if err := ds.db.Create(achievement).Error; err != nil {
+// GetLastLessonCompletion returns the user's most recent lesson completion
+func (ds *ContentRepository) GetLastLessonCompletion(userID string) (*model.UserLessonCompletion, error) {
+	var completion model.UserLessonCompletion
+	if err := ds.db.Where("user_id = ?", userID).
+		Order("completed_at DESC").
+		First(&completion).Error; err != nil {
 		return nil, err
 	}
-	return achievement, nil
+	return &completion, nil
 }
 
-func (ds *ContentRepository) GetActiveAchievements() ([]model.Achievement, error) {
-	var achievements []model.Achievement
-	if err := ds.db.Where("is_active = ?", true).Find(&achievements).Error; err != nil {
+func (ds *ContentRepository) CountLessonCompletionsSince(userID string, since time.Time) (int64, error) {
+	var count int64
+	if err := ds.db.Model(&model.UserLessonCompletion{}).
+		Where("user_id = ? AND completed_at >= ?", userID, since).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetCompletedLessonAttempts returns the user's lesson attempts that reached completion
+func (ds *ContentRepository) GetCompletedLessonAttempts(userID string) ([]model.UserLessonAttempt, error) {
+	var attempts []model.UserLessonAttempt
+	if err := ds.db.Where("user_id = ? AND is_completed = ?", userID, true).
+		Order("updated_at ASC").
+		Find(&attempts).Error; err != nil {
 		return nil, err
 	}
-	return achievements, nil
+	return attempts, nil
 }
 
-func (ds *ContentRepository) CreateUserAchievement(userAchievement *model.UserAchievement) error {
-	if userAchievement.ID == "" {
-		id, _ := uuid.NewV7()
-		userAchievement.ID = id.String()
+func (ds *ContentRepository) CountCompletedLessons(userID string) (int64, error) {
+	var count int64
+	if err := ds.db.Model(&model.UserLessonCompletion{}).
+		Where("user_id = ?", userID).
+		Count(&count).Error; err != nil {
+		return 0, err
 	}
-	userAchievement.CreatedAt = time.Now()
-	userAchievement.UnlockedAt = time.Now()
+	return count, nil
+}
 
-	if err := ds.db.Create(userAchievement).Error; err != nil {
-		return err
+// BackfillLessonCompletions copies the legacy CompletedLessons JSON arrays into
+// UserLessonCompletion rows. It runs once, so completions deleted afterwards stay deleted.
+func (ds *ContentRepository) BackfillLessonCompletions() error {
+	return ds.runDataMigration("backfill_lesson_completions", func(tx *gorm.DB) error {
+		return tx.Exec(`
+			INSERT INTO user_lesson_completions (id, user_id, lesson_id, completed_at, created_at)
+			SELECT gen_random_uuid()::text, p.user_id, l.lesson_id, p.updated_at, NOW()
+			FROM user_progresses p, jsonb_array_elements_text(p.completed_lessons) AS l(lesson_id)
+			WHERE jsonb_typeof(p.completed_lessons) = 'array'
+			ON CONFLICT DO NOTHING
+		`).Error
+	})
+}
+
+// ==================== USER CHARACTER METHODS ====================
+
+// CreateUserCharacter unlocks a character for a user and reports whether it was new.
+// The outbox messages are only written for a new unlock.
+func (ds *ContentRepository) CreateUserCharacter(userCharacter *model.UserCharacter, outbox ...*model.OutboxMessage) (bool, error) {
+	if userCharacter.ID == "" {
+		userCharacter.ID = ids.New()
 	}
-	return nil
+	if userCharacter.UnlockedAt.IsZero() {
+		userCharacter.UnlockedAt = time.Now()
+	}
+	userCharacter.CreatedAt = time.Now()
+
+	var created bool
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(userCharacter)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		created = true
+		return insertOutbox(tx, outbox)
+	})
+	return created, err
 }
 
-func (ds *ContentRepository) GetUserAchievements(userID string) ([]model.UserAchievement, error) {
-	var userAchievements []model.UserAchievement
-	if err := ds.db.Preload("Achievement").Where("user_id = ?", userID).
-		Find(&userAchievements).Error; err != nil {
+// CreateFavoriteCharacter marks a character as a favorite and reports whether it was new
+func (ds *ContentRepository) CreateFavoriteCharacter(favorite *model.UserFavoriteCharacter) (bool, error) {
+	if favorite.ID == "" {
+		favorite.ID = ids.New()
+	}
+	favorite.CreatedAt = time.Now()
+
+	result := ds.db.Clauses(clause.OnConflict{DoNothing: true}).Create(favorite)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteFavoriteCharacter removes a favorite and reports whether it existed
+func (ds *ContentRepository) DeleteFavoriteCharacter(userID, characterID string) (bool, error) {
+	result := ds.db.Where("user_id = ? AND character_id = ?", userID, characterID).Delete(&model.UserFavoriteCharacter{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (ds *ContentRepository) GetFavoriteCharacterIDs(userID string) ([]string, error) {
+	var characterIDs []string
+	if err := ds.db.Model(&model.UserFavoriteCharacter{}).
+		Where("user_id = ?", userID).
+		Pluck("character_id", &characterIDs).Error; err != nil {
 		return nil, err
 	}
-	return userAchievements, nil
+	return characterIDs, nil
 }
 
-// ==================== LEADERBOARD METHODS ====================
+// MarkCollectionViewed records when the user last opened their collection. It skips
+// updated_at so that browsing doesn't count as activity on the leaderboards.
+func (ds *ContentRepository) MarkCollectionViewed(userID string, viewedAt time.Time) error {
+	return ds.db.Model(&model.UserProgress{}).
+		Where("user_id = ?", userID).
+		UpdateColumn("collection_viewed_at", viewedAt).Error
+}
 
-func (ds *ContentRepository) GetWeeklyLeaderboard(limit int) ([]model.UserProgress, error) {
-	var users []model.UserProgress
-	weekAgo := time.Now().AddDate(0, 0, -7)
+// CreateLessonBookmark saves a lesson for later and reports whether it was new
+func (ds *ContentRepository) CreateLessonBookmark(bookmark *model.UserLessonBookmark) (bool, error) {
+	if bookmark.ID == "" {
+		bookmark.ID = ids.New()
+	}
+	bookmark.CreatedAt = time.Now()
 
-	if err := ds.db.Where("updated_at >= ?", weekAgo).
-		Order("xp DESC").Limit(limit).Find(&users).Error; err != nil {
-		return nil, err
+	result := ds.db.Clauses(clause.OnConflict{DoNothing: true}).Create(bookmark)
+	if result.Error != nil {
+		return false, result.Error
 	}
-	return users, nil
+	return result.RowsAffected > 0, nil
 }
 
-func (ds *ContentRepository) GetMonthlyLeaderboard(limit int) ([]model.UserProgress, error) {
-	var users []model.UserProgress
-	monthAgo := time.Now().AddDate(0, -1, 0)
+// DeleteLessonBookmark removes a bookmark and reports whether it existed
+func (ds *ContentRepository) DeleteLessonBookmark(userID, lessonID string) (bool, error) {
+	result := ds.db.Where("user_id = ? AND lesson_id = ?", userID, lessonID).Delete(&model.UserLessonBookmark{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
 
-	if err := ds.db.Where("updated_at >= ?", monthAgo).
-		Order("xp DESC").Limit(limit).Find(&users).Error; err != nil {
-		return nil, err
+func (ds *ContentRepository) GetUserLessonBookmarks(userID string, page, limit int) ([]model.UserLessonBookmark, int64, error) {
+	var bookmarks []model.UserLessonBookmark
+	var total int64
+
+	query := ds.db.Model(&model.UserLessonBookmark{}).Where("user_id = ?", userID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
 	}
-	return users, nil
+
+	offset := (page - 1) * limit
+	if err := query.Preload("Lesson.Character").
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&bookmarks).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return bookmarks, total, nil
 }
 
-func (ds *ContentRepository) GetAllTimeLeaderboard(limit int) ([]model.UserProgress, error) {
-	var users []model.UserProgress
-	if err := ds.db.Order("xp DESC").Limit(limit).Find(&users).Error; err != nil {
+func (ds *ContentRepository) GetUserCharacters(userID string) ([]model.UserCharacter, error) {
+	var userCharacters []model.UserCharacter
+	if err := ds.db.Where("user_id = ?", userID).
+		Order("unlocked_at ASC").
+		Find(&userCharacters).Error; err != nil {
 		return nil, err
 	}
-	return users, nil
+	return userCharacters, nil
 }
 
-func (ds *ContentRepository) GetUserRank(userID string) (int, error) {
-	var rank int64
-	userProgress, err := ds.GetUserProgress(userID)
-	if err != nil {
-		return 0, err
+func (ds *ContentRepository) GetUnlockedCharacterIDs(userID string) ([]string, error) {
+	var characterIDs []string
+	if err := ds.db.Model(&model.UserCharacter{}).
+		Where("user_id = ?", userID).
+		Order("unlocked_at ASC").
+		Pluck("character_id", &characterIDs).Error; err != nil {
+		return nil, err
 	}
+	return characterIDs, nil
+}
 
-	if err := ds.db.Model(&model.UserProgress{}).
-		Where("xp > ?", userProgress.XP).Count(&rank).Error; err != nil {
+func (ds *ContentRepository) CountUnlockedCharacters(userID string) (int64, error) {
+	var count int64
+	if err := ds.db.Model(&model.UserCharacter{}).
+		Where("user_id = ?", userID).
+		Count(&count).Error; err != nil {
 		return 0, err
 	}
+	return count, nil
+}
 
-	return int(rank + 1), nil // +1 because rank is 0-indexed
+// BackfillUserCharacters copies the legacy UnlockedCharacters JSON arrays into
+// UserCharacter rows. It runs once, so characters removed afterwards stay removed.
+func (ds *ContentRepository) BackfillUserCharacters() error {
+	return ds.runDataMigration("backfill_user_characters", func(tx *gorm.DB) error {
+		return tx.Exec(`
+			INSERT INTO user_characters (id, user_id, character_id, unlocked_at, source, created_at)
+			SELECT gen_random_uuid()::text, p.user_id, c.character_id, p.updated_at, ?, NOW()
+			FROM user_progresses p, jsonb_array_elements_text(p.unlocked_characters) AS c(character_id)
+			WHERE jsonb_typeof(p.unlocked_characters) = 'array'
+			ON CONFLICT DO NOTHING
+		`, model.UnlockSourceBackfill).Error
+	})
 }
 
-// ==================== CONTENT SEARCH AND FILTERING ====================
+// ==================== COMPLETION FLAG METHODS ====================
 
-func (ds *ContentRepository) SearchCharacters(query string, era string, dynasty string, rarity string, limit int) ([]model.Character, error) {
-	var characters []model.Character
-	dbQuery := ds.db.Model(&model.Character{})
+func (ds *ContentRepository) CreateCompletionFlag(flag *model.CompletionFlag) error {
+	if flag.ID == "" {
+		flag.ID = ids.New()
+	}
+	if flag.Status == "" {
+		flag.Status = model.FlagStatusPending
+	}
+	flag.CreatedAt = time.Now()
+	return ds.db.Create(flag).Error
+}
 
-	if query != "" {
-		dbQuery = dbQuery.Where("name LIKE ? OR description LIKE ?", "%"+query+"%", "%"+query+"%")
+func (ds *ContentRepository) GetCompletionFlag(id string) (*model.CompletionFlag, error) {
+	var flag model.CompletionFlag
+	if err := ds.db.Preload("User").Where("id = ?", id).First(&flag).Error; err != nil {
+		return nil, err
 	}
+	return &flag, nil
+}
 
-	if era != "" {
-		dbQuery = dbQuery.Where("era = ?", era)
+// GetCompletionFlags lists flags oldest first so the review queue is worked in order
+func (ds *ContentRepository) GetCompletionFlags(status string, page, limit int) ([]model.CompletionFlag, int64, error) {
+	var flags []model.CompletionFlag
+	var total int64
+
+	db := ds.db.Model(&model.CompletionFlag{})
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
 	}
 
-	if dynasty != "" {
-		dbQuery = dbQuery.Where("dynasty = ?", dynasty)
+	if err := db.Preload("User").
+		Order("created_at ASC").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		Find(&flags).Error; err != nil {
+		return nil, 0, err
 	}
+	return flags, total, nil
+}
 
-	if rarity != "" {
-		dbQuery = dbQuery.Where("rarity = ?", rarity)
+// ResolveCompletionFlags closes the given flag, or with userID every pending flag of
+// that user, and returns how many were closed
+func (ds *ContentRepository) ResolveCompletionFlags(flagID, userID, status, reviewerID, note string) (int64, error) {
+	db := ds.db.Model(&model.CompletionFlag{}).Where("status = ?", model.FlagStatusPending)
+	if userID != "" {
+		db = db.Where("id = ? OR user_id = ?", flagID, userID)
+	} else {
+		db = db.Where("id = ?", flagID)
 	}
 
-	if limit > 0 {
-		dbQuery = dbQuery.Limit(limit)
+	result := db.Updates(map[string]interface{}{
+		"status":      status,
+		"reviewed_by": reviewerID,
+		"review_note": note,
+		"reviewed_at": time.Now(),
+	})
+	return result.RowsAffected, result.Error
+}
+
+// ==================== LEADERBOARD ANOMALY METHODS ====================
+
+// GetXPGainsAbove sums the XP each user earned since the given time and returns those
+// above the threshold. Admin grants and ledger corrections are not earnings.
+func (ds *ContentRepository) GetXPGainsAbove(since time.Time, threshold int) ([]model.XPGain, error) {
+	var gains []model.XPGain
+	err := ds.db.Model(&model.XPTransaction{}).
+		Select("user_id, sum(amount) AS xp").
+		Where("created_at >= ? AND amount > 0", since).
+		Where("source NOT IN ?", []string{model.XPSourceAdmin, model.XPSourceOpeningBalance, model.XPSourceReconcile}).
+		Group("user_id").
+		Having("sum(amount) > ?", threshold).
+		Scan(&gains).Error
+	return gains, err
+}
+
+// CreateLeaderboardAnomaly records the anomaly and quarantines its user
+func (ds *ContentRepository) CreateLeaderboardAnomaly(anomaly *model.LeaderboardAnomaly) error {
+	if anomaly.ID == "" {
+		anomaly.ID = ids.New()
 	}
+	anomaly.Status = model.AnomalyStatusPending
+	anomaly.CreatedAt = time.Now()
+
+	return ds.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(anomaly).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.User{}).Where("id = ?", anomaly.UserID).
+			Update("leaderboard_quarantined", true).Error
+	})
+}
 
-	if err := dbQuery.Find(&characters).Error; err != nil {
+func (ds *ContentRepository) HasPendingLeaderboardAnomaly(userID string) (bool, error) {
+	var count int64
+	err := ds.db.Model(&model.LeaderboardAnomaly{}).
+		Where("user_id = ? AND status = ?", userID, model.AnomalyStatusPending).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (ds *ContentRepository) GetLeaderboardAnomaly(id string) (*model.LeaderboardAnomaly, error) {
+	var anomaly model.LeaderboardAnomaly
+	if err := ds.db.Preload("User").Where("id = ?", id).First(&anomaly).Error; err != nil {
 		return nil, err
 	}
-	return characters, nil
+	return &anomaly, nil
 }
 
-func (ds *ContentRepository) SaveUserQuestionAnswer(answer *model.UserQuestionAnswer) error {
-	if answer.ID == "" {
-		id, _ := uuid.NewV7()
-		answer.ID = id.String()
+// GetLeaderboardAnomalies lists anomalies oldest first so the review queue is worked in
+// order
+func (ds *ContentRepository) GetLeaderboardAnomalies(status string, page, limit int) ([]model.LeaderboardAnomaly, int64, error) {
+	var anomalies []model.LeaderboardAnomaly
+	var total int64
+
+	db := ds.db.Model(&model.LeaderboardAnomaly{})
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
 	}
-	answer.CreatedAt = time.Now()
-	answer.UpdatedAt = time.Now()
 
-	// Check if answer already exists for this user/lesson/question
-	var existing model.UserQuestionAnswer
-	err := ds.db.Where("user_id = ? AND lesson_id = ? AND question_id = ?",
-		answer.UserID, answer.LessonID, answer.QuestionID).First(&existing).Error
+	if err := db.Preload("User").
+		Order("created_at ASC").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		Find(&anomalies).Error; err != nil {
+		return nil, 0, err
+	}
+	return anomalies, total, nil
+}
 
-	if err == nil {
-		// Update existing answer
-		existing.Answer = answer.Answer
-		existing.IsCorrect = answer.IsCorrect
-		existing.Points = answer.Points
-		existing.UpdatedAt = time.Now()
-		return ds.db.Save(&existing).Error
-	} else if errors.Is(err, gorm.ErrRecordNotFound) {
-		// Create new answer
-		return ds.db.Create(answer).Error
+// ResolveLeaderboardAnomalies closes every pending anomaly of a user, records the
+// penalty on the reviewed one and lifts the quarantine
+func (ds *ContentRepository) ResolveLeaderboardAnomalies(anomalyID, userID, status, reviewerID, note string, penaltyXP int) error {
+	return ds.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.LeaderboardAnomaly{}).
+			Where("user_id = ? AND status = ?", userID, model.AnomalyStatusPending).
+			Updates(map[string]interface{}{
+				"status":      status,
+				"reviewed_by": reviewerID,
+				"review_note": note,
+				"reviewed_at": time.Now(),
+			}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.LeaderboardAnomaly{}).Where("id = ?", anomalyID).
+			Update("penalty_xp", penaltyXP).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.User{}).Where("id = ?", userID).
+			Update("leaderboard_quarantined", false).Error
+	})
+}
+
+// GetXPTransactionsBetween returns a user's ledger entries in a time range, oldest first
+func (ds *ContentRepository) GetXPTransactionsBetween(userID string, from, to time.Time) ([]model.XPTransaction, error) {
+	var transactions []model.XPTransaction
+	err := ds.db.Where("user_id = ? AND created_at BETWEEN ? AND ?", userID, from, to).
+		Order("created_at ASC").
+		Find(&transactions).Error
+	return transactions, err
+}
+
+// GetLessonCompletionsBetween returns a user's lesson completions in a time range
+func (ds *ContentRepository) GetLessonCompletionsBetween(userID string, from, to time.Time) ([]model.UserLessonCompletion, error) {
+	var completions []model.UserLessonCompletion
+	err := ds.db.Where("user_id = ? AND completed_at BETWEEN ? AND ?", userID, from, to).
+		Order("completed_at ASC").
+		Find(&completions).Error
+	return completions, err
+}
+
+// GetCompletionFlagsBetween returns the completion flags raised for a user in a time range
+func (ds *ContentRepository) GetCompletionFlagsBetween(userID string, from, to time.Time) ([]model.CompletionFlag, error) {
+	var flags []model.CompletionFlag
+	err := ds.db.Where("user_id = ? AND created_at BETWEEN ? AND ?", userID, from, to).
+		Order("created_at ASC").
+		Find(&flags).Error
+	return flags, err
+}
+
+// ==================== XP LEDGER METHODS ====================
+
+// saveProgress stores the progress with the ledger entries and outbox messages of the change
+func saveProgress(tx *gorm.DB, progress *model.UserProgress, change *model.ProgressChange) error {
+	progress.UpdatedAt = time.Now()
+	if err := tx.Save(progress).Error; err != nil {
+		return err
 	}
 
-	return err
+	if change.XP != nil {
+		change.XP.UserID = progress.UserID
+		change.XP.BalanceAfter = progress.XP
+		if err := createXPTransaction(tx, change.XP); err != nil {
+			return err
+		}
+	}
+	if change.Hearts != nil {
+		if change.Hearts.ID == "" {
+			change.Hearts.ID = ids.New()
+		}
+		change.Hearts.UserID = progress.UserID
+		change.Hearts.BalanceAfter = progress.Hearts
+		change.Hearts.CreatedAt = time.Now()
+		if err := tx.Create(change.Hearts).Error; err != nil {
+			return err
+		}
+	}
+	return insertOutbox(tx, change.Outbox)
 }
 
-func (ds *ContentRepository) GetUserQuestionAnswers(userID, lessonID string) ([]model.UserQuestionAnswer, error) {
-	var answers []model.UserQuestionAnswer
-	if err := ds.db.Where("user_id = ? AND lesson_id = ?", userID, lessonID).
-		Find(&answers).Error; err != nil {
+func (ds *ContentRepository) CreateXPTransaction(txn *model.XPTransaction) error {
+	return createXPTransaction(ds.db, txn)
+}
+
+func createXPTransaction(db *gorm.DB, txn *model.XPTransaction) error {
+	if txn.ID == "" {
+		txn.ID = ids.New()
+	}
+	if txn.CreatedAt.IsZero() {
+		txn.CreatedAt = time.Now()
+	}
+	return db.Create(txn).Error
+}
+
+func (ds *ContentRepository) GetXPTransactions(userID string, page, limit int) ([]model.XPTransaction, int64, error) {
+	var txns []model.XPTransaction
+	var total int64
+
+	db := ds.db.Model(&model.XPTransaction{}).Where("user_id = ?", userID)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := db.Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		Find(&txns).Error; err != nil {
+		return nil, 0, err
+	}
+	return txns, total, nil
+}
+
+func (ds *ContentRepository) SumXPTransactions(userID string) (int, error) {
+	var sum int
+	if err := ds.db.Model(&model.XPTransaction{}).
+		Where("user_id = ?", userID).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&sum).Error; err != nil {
+		return 0, err
+	}
+	return sum, nil
+}
+
+func (ds *ContentRepository) GetXPTransactionsBySource(userID, source string) ([]model.XPTransaction, error) {
+	var txns []model.XPTransaction
+	if err := ds.db.Where("user_id = ? AND source = ?", userID, source).
+		Order("created_at ASC").
+		Find(&txns).Error; err != nil {
 		return nil, err
 	}
-	return answers, nil
+	return txns, nil
 }
 
-func (ds *ContentRepository) GetUserQuestionAnswer(userID, lessonID, questionID string) (*model.UserQuestionAnswer, error) {
-	var answer model.UserQuestionAnswer
-	if err := ds.db.Where("user_id = ? AND lesson_id = ? AND question_id = ?",
-		userID, lessonID, questionID).First(&answer).Error; err != nil {
+// ==================== HEART AND ITEM LEDGER METHODS ====================
+
+func (ds *ContentRepository) GetHeartTransactions(userID string, page, limit int) ([]model.HeartTransaction, int64, error) {
+	var txns []model.HeartTransaction
+	var total int64
+
+	db := ds.db.Model(&model.HeartTransaction{}).Where("user_id = ?", userID)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := db.Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		Find(&txns).Error; err != nil {
+		return nil, 0, err
+	}
+	return txns, total, nil
+}
+
+// GrantUserItem unlocks a character and records the grant, reporting whether the user
+// did not already own it
+func (ds *ContentRepository) GrantUserItem(txn *model.ItemTransaction) (bool, error) {
+	granted := false
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.UserCharacter{
+			ID:          ids.New(),
+			UserID:      txn.UserID,
+			CharacterID: txn.ItemID,
+			UnlockedAt:  now,
+			Source:      model.UnlockSourceAdmin,
+			CreatedAt:   now,
+		})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		granted = true
+		return createItemTransaction(tx, txn)
+	})
+	return granted, err
+}
+
+// RevokeUserItem removes an unlocked character and records the revocation, reporting
+// whether the user owned it
+func (ds *ContentRepository) RevokeUserItem(txn *model.ItemTransaction) (bool, error) {
+	revoked := false
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("user_id = ? AND character_id = ?", txn.UserID, txn.ItemID).Delete(&model.UserCharacter{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		revoked = true
+		return createItemTransaction(tx, txn)
+	})
+	return revoked, err
+}
+
+func createItemTransaction(db *gorm.DB, txn *model.ItemTransaction) error {
+	txn.ID = ids.New()
+	txn.CreatedAt = time.Now()
+	return db.Create(txn).Error
+}
+
+func (ds *ContentRepository) GetItemTransactions(userID string, page, limit int) ([]model.ItemTransaction, int64, error) {
+	var txns []model.ItemTransaction
+	var total int64
+
+	db := ds.db.Model(&model.ItemTransaction{}).Where("user_id = ?", userID)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := db.Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		Find(&txns).Error; err != nil {
+		return nil, 0, err
+	}
+	return txns, total, nil
+}
+
+// HasUserCharacter reports whether a user has unlocked a character
+func (ds *ContentRepository) HasUserCharacter(userID, characterID string) (bool, error) {
+	var count int64
+	err := ds.db.Model(&model.UserCharacter{}).
+		Where("user_id = ? AND character_id = ?", userID, characterID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetXPLedgerMismatches returns users whose stored XP differs from their ledger total
+func (ds *ContentRepository) GetXPLedgerMismatches() ([]model.XPLedgerMismatch, error) {
+	var mismatches []model.XPLedgerMismatch
+	if err := ds.db.Raw(`
+		SELECT p.user_id, p.xp, COALESCE(SUM(t.amount), 0) AS ledger_xp
+		FROM user_progresses p
+		LEFT JOIN xp_transactions t ON t.user_id = p.user_id
+		GROUP BY p.user_id, p.xp
+		HAVING p.xp <> COALESCE(SUM(t.amount), 0)
+	`).Scan(&mismatches).Error; err != nil {
 		return nil, err
 	}
-	return &answer, nil
+	return mismatches, nil
 }
 
-func (ds *ContentRepository) DeleteUserQuestionAnswers(userID, lessonID string) error {
-	if err := ds.db.Where("user_id = ? AND lesson_id = ?", userID, lessonID).
-		Delete(&model.UserQuestionAnswer{}).Error; err != nil {
+// BackfillXPLedger records an opening balance for users who earned XP before the
+// ledger existed. It is safe to run on every start.
+func (ds *ContentRepository) BackfillXPLedger() error {
+	var progresses []model.UserProgress
+	if err := ds.db.Select("user_id", "xp").
+		Where("xp <> 0").
+		Where("user_id NOT IN (?)", ds.db.Model(&model.XPTransaction{}).Select("user_id")).
+		Find(&progresses).Error; err != nil {
 		return err
 	}
+
+	for _, progress := range progresses {
+		if err := ds.CreateXPTransaction(&model.XPTransaction{
+			UserID:       progress.UserID,
+			Source:       model.XPSourceOpeningBalance,
+			Amount:       progress.XP,
+			BalanceAfter: progress.XP,
+		}); err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// ==================== CONTENT AUDIT METHODS ====================
+
+func (ds *ContentRepository) CreateContentAuditLog(auditLog *model.ContentAuditLog) error {
+	if auditLog.ID == "" {
+		auditLog.ID = ids.New()
+	}
+	auditLog.CreatedAt = time.Now()
+
+	return ds.db.Create(auditLog).Error
+}
+
+// GetContentAuditLogs returns audit entries filtered by entity and/or admin, newest first.
+// Empty filter values are ignored.
+func (ds *ContentRepository) GetContentAuditLogs(entityType, entityID, adminID string, page, limit int) ([]model.ContentAuditLog, int64, error) {
+	var logs []model.ContentAuditLog
+	var total int64
+
+	query := ds.db.Model(&model.ContentAuditLog{})
+	if entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+	if entityID != "" {
+		query = query.Where("entity_id = ?", entityID)
+	}
+	if adminID != "" {
+		query = query.Where("admin_id = ?", adminID)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&logs).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return logs, total, nil
+}
+
+// GetLessonAuditSnapshots returns the audit entries that saved a whole lesson, oldest
+// first. Entries for media linked to the lesson are logged under the lesson too but
+// snapshot the link, which carries its own ID.
+func (ds *ContentRepository) GetLessonAuditSnapshots(lessonID string) ([]model.ContentAuditLog, error) {
+	var logs []model.ContentAuditLog
+	err := ds.db.Where("entity_type = ? AND entity_id = ?", model.ContentEntityLesson, lessonID).
+		Where("after IS NOT NULL AND after->>'id' = ?", lessonID).
+		Order("created_at ASC, id ASC").
+		Find(&logs).Error
+	return logs, err
+}
+
+// ==================== SPIRIT METHODS ====================
+
+func (ds *ContentRepository) CreateSpirit(spirit *model.Spirit) (*model.Spirit, error) {
+	if spirit.ID == "" {
+		spirit.ID = ids.New()
+	}
+	spirit.CreatedAt = time.Now()
+	spirit.UpdatedAt = time.Now()
+
+	if err := ds.db.Create(spirit).Error; err != nil {
+		return nil, err
+	}
+	return spirit, nil
+}
+
+func (ds *ContentRepository) GetUserSpirit(userID string) (*model.Spirit, error) {
+	var spirit model.Spirit
+	if err := ds.db.Where("user_id = ?", userID).First(&spirit).Error; err != nil {
+		return nil, err
+	}
+	return &spirit, nil
+}
+
+func (ds *ContentRepository) UpdateSpirit(spirit *model.Spirit) error {
+	spirit.UpdatedAt = time.Now()
+	if err := ds.db.Save(spirit).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// MigrateSpirit saves a spirit whose zodiac changed together with the user fields
+// recording the correction
+func (ds *ContentRepository) MigrateSpirit(spirit *model.Spirit, userUpdates map[string]interface{}) error {
+	now := time.Now()
+	spirit.UpdatedAt = now
+	userUpdates["updated_at"] = now
+
+	return ds.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(spirit).Error; err != nil {
+			return err
+		}
+		return tx.Model(&model.User{}).Where("id = ?", spirit.UserID).Updates(userUpdates).Error
+	})
+}
+
+// ==================== BATTLE METHODS ====================
+
+func (ds *ContentRepository) CreateBattle(battle *model.SpiritBattle) (*model.SpiritBattle, error) {
+	if battle.ID == "" {
+		battle.ID = ids.New()
+	}
+	battle.CreatedAt = time.Now()
+	battle.UpdatedAt = time.Now()
+
+	if err := ds.db.Create(battle).Error; err != nil {
+		return nil, err
+	}
+	return battle, nil
+}
+
+func (ds *ContentRepository) GetUserBattle(userID, battleID string) (*model.SpiritBattle, error) {
+	var battle model.SpiritBattle
+	if err := ds.db.Where("id = ? AND user_id = ?", battleID, userID).First(&battle).Error; err != nil {
+		return nil, err
+	}
+	return &battle, nil
+}
+
+func (ds *ContentRepository) GetActiveBattle(userID string) (*model.SpiritBattle, error) {
+	var battle model.SpiritBattle
+	if err := ds.db.Where("user_id = ? AND status = ?", userID, model.BattleStatusActive).
+		Order("created_at DESC").
+		First(&battle).Error; err != nil {
+		return nil, err
+	}
+	return &battle, nil
+}
+
+func (ds *ContentRepository) UpdateBattle(battle *model.SpiritBattle) error {
+	battle.UpdatedAt = time.Now()
+	if err := ds.db.Save(battle).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// MarkBattleRewardPaid flags a won battle as paid out. It reports false if the reward
+// was already claimed, so concurrent resolve calls pay at most once.
+func (ds *ContentRepository) MarkBattleRewardPaid(battleID string) (bool, error) {
+	result := ds.db.Model(&model.SpiritBattle{}).
+		Where("id = ? AND status = ? AND reward_paid = ?", battleID, model.BattleStatusWon, false).
+		Updates(map[string]interface{}{
+			"reward_paid": true,
+			"updated_at":  time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// ==================== ACHIEVEMENT METHODS ====================
+
+func (ds *ContentRepository) CreateAchievement(achievement *model.Achievement) (*model.Achievement, error) {
+	if achievement.ID == "" {
+		achievement.ID = ids.New()
+	}
+	achievement.CreatedAt = time.Now()
+	achievement.UpdatedAt = time.Now()
+
+	if err := ds.db.Create(achievement).Error; err != nil {
+		return nil, err
+	}
+	return achievement, nil
+}
+
+func (ds *ContentRepository) GetActiveAchievements() ([]model.Achievement, error) {
+	var achievements []model.Achievement
+	if err := ds.db.Where("is_active = ?", true).Find(&achievements).Error; err != nil {
+		return nil, err
+	}
+	return achievements, nil
+}
+
+func (ds *ContentRepository) CreateUserAchievement(userAchievement *model.UserAchievement) error {
+	if userAchievement.ID == "" {
+		userAchievement.ID = ids.New()
+	}
+	userAchievement.CreatedAt = time.Now()
+	userAchievement.UnlockedAt = time.Now()
+
+	if err := ds.db.Create(userAchievement).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (ds *ContentRepository) GetUserAchievements(userID string) ([]model.UserAchievement, error) {
+	var userAchievements []model.UserAchievement
+	if err := ds.db.Preload("Achievement").Where("user_id = ?", userID).
+		Find(&userAchievements).Error; err != nil {
+		return nil, err
+	}
+	return userAchievements, nil
+}
+
+// EnsureAchievement creates a built-in achievement if it doesn't exist yet. Existing rows
+// are left alone so admins can tune them.
+func (ds *ContentRepository) EnsureAchievement(achievement *model.Achievement) error {
+	achievement.CreatedAt = time.Now()
+	achievement.UpdatedAt = time.Now()
+
+	return ds.db.Clauses(clause.OnConflict{DoNothing: true}).Create(achievement).Error
+}
+
+func (ds *ContentRepository) GetActiveAchievementsByMetric(metric string) ([]model.Achievement, error) {
+	var achievements []model.Achievement
+	if err := ds.db.Where("is_active = ? AND metric = ?", true, metric).Find(&achievements).Error; err != nil {
+		return nil, err
+	}
+	return achievements, nil
+}
+
+func (ds *ContentRepository) GetTieredAchievements() ([]model.Achievement, error) {
+	var achievements []model.Achievement
+	if err := ds.db.Where("is_active = ? AND metric <> ''", true).Order("category, name").Find(&achievements).Error; err != nil {
+		return nil, err
+	}
+	return achievements, nil
+}
+
+func (ds *ContentRepository) GetAchievementProgress(userID, achievementID string) (*model.UserAchievementProgress, error) {
+	var progress model.UserAchievementProgress
+	if err := ds.db.Where("user_id = ? AND achievement_id = ?", userID, achievementID).First(&progress).Error; err != nil {
+		return nil, err
+	}
+	return &progress, nil
+}
+
+func (ds *ContentRepository) GetUserAchievementProgress(userID string) ([]model.UserAchievementProgress, error) {
+	var progress []model.UserAchievementProgress
+	if err := ds.db.Where("user_id = ?", userID).Find(&progress).Error; err != nil {
+		return nil, err
+	}
+	return progress, nil
+}
+
+// CreateAchievementProgress inserts a progress row, returning false if one already exists
+func (ds *ContentRepository) CreateAchievementProgress(progress *model.UserAchievementProgress) (bool, error) {
+	if progress.ID == "" {
+		progress.ID = ids.New()
+	}
+	progress.CreatedAt = time.Now()
+	progress.UpdatedAt = time.Now()
+
+	result := ds.db.Clauses(clause.OnConflict{DoNothing: true}).Create(progress)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// IncrementAchievementProgress atomically adds delta to a progress counter and returns the new value
+func (ds *ContentRepository) IncrementAchievementProgress(progressID string, delta int) (int, error) {
+	var current int
+	err := ds.db.Raw(`
+		UPDATE user_achievement_progresses
+		SET current = current + ?, updated_at = ?
+		WHERE id = ?
+		RETURNING current
+	`, delta, time.Now(), progressID).Scan(&current).Error
+	return current, err
+}
+
+// RaiseAchievementProgress atomically raises a progress counter to value if it is higher
+// and returns the resulting value
+func (ds *ContentRepository) RaiseAchievementProgress(progressID string, value int) (int, error) {
+	var current int
+	err := ds.db.Raw(`
+		UPDATE user_achievement_progresses
+		SET current = GREATEST(current, ?), updated_at = ?
+		WHERE id = ?
+		RETURNING current
+	`, value, time.Now(), progressID).Scan(&current).Error
+	return current, err
+}
+
+// AdvanceAchievementTier moves a progress row from one tier to the next. It returns false
+// if another request already advanced it, so tier rewards are only paid once.
+func (ds *ContentRepository) AdvanceAchievementTier(progressID, fromTier, toTier string) (bool, error) {
+	result := ds.db.Model(&model.UserAchievementProgress{}).
+		Where("id = ? AND tier_reached = ?", progressID, fromTier).
+		Updates(map[string]interface{}{
+			"tier_reached": toTier,
+			"updated_at":   time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CountAchievementMetric computes a metric from history. It is only used to seed a new
+// progress row; afterwards the row is updated incrementally.
+func (ds *ContentRepository) CountAchievementMetric(userID, metric string) (int, error) {
+	var count int64
+	var err error
+
+	switch metric {
+	case model.AchievementMetricLessonsCompleted:
+		err = ds.db.Model(&model.UserLessonCompletion{}).Where("user_id = ?", userID).Count(&count).Error
+	case model.AchievementMetricPerfectLessons:
+		err = ds.db.Model(&model.UserLessonCompletion{}).Where("user_id = ? AND score >= 100", userID).Count(&count).Error
+	case model.AchievementMetricCharactersUnlocked:
+		err = ds.db.Model(&model.UserCharacter{}).Where("user_id = ?", userID).Count(&count).Error
+	case model.AchievementMetricBattlesWon:
+		err = ds.db.Model(&model.SpiritBattle{}).Where("user_id = ? AND status = ?", userID, model.BattleStatusWon).Count(&count).Error
+	case model.AchievementMetricStreakDays:
+		var progress model.UserProgress
+		if err := ds.db.Where("user_id = ?", userID).First(&progress).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return 0, nil
+			}
+			return 0, err
+		}
+		return progress.Streak, nil
+	}
+
+	return int(count), err
+}
+
+// ==================== GAME CONFIG METHODS ====================
+
+// GetGameConfig returns the game config, creating it with defaults on first use
+func (ds *ContentRepository) GetGameConfig() (*model.GameConfig, error) {
+	config := model.DefaultGameConfig()
+	if err := ds.db.Where("id = ?", model.GameConfigID).FirstOrCreate(&config).Error; err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func (ds *ContentRepository) UpdateGameConfig(config *model.GameConfig) error {
+	config.ID = model.GameConfigID
+	config.UpdatedAt = time.Now()
+	return ds.db.Save(config).Error
+}
+
+// ==================== LEADERBOARD METHODS ====================
+
+// leaderboardVisible drops users who opted out of leaderboards or are quarantined for
+// review. Anonymous users stay in the ranking; their alias is applied when building
+// responses.
+func leaderboardVisible(db *gorm.DB) *gorm.DB {
+	return db.Where("user_id NOT IN (?)",
+		db.Session(&gorm.Session{NewDB: true}).Model(&model.User{}).Select("id").
+			Where("leaderboard_visibility = ? OR leaderboard_quarantined", model.LeaderboardVisibilityHidden))
+}
+
+func (ds *ContentRepository) GetWeeklyLeaderboard(limit int) ([]model.UserProgress, error) {
+	var users []model.UserProgress
+	weekAgo := time.Now().AddDate(0, 0, -7)
+
+	if err := ds.db.Scopes(leaderboardVisible).Where("updated_at >= ?", weekAgo).
+		Order("xp DESC").Limit(limit).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (ds *ContentRepository) GetMonthlyLeaderboard(limit int) ([]model.UserProgress, error) {
+	var users []model.UserProgress
+	monthAgo := time.Now().AddDate(0, -1, 0)
+
+	if err := ds.db.Scopes(leaderboardVisible).Where("updated_at >= ?", monthAgo).
+		Order("xp DESC").Limit(limit).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (ds *ContentRepository) GetAllTimeLeaderboard(limit int) ([]model.UserProgress, error) {
+	var users []model.UserProgress
+	if err := ds.db.Scopes(leaderboardVisible).Order("xp DESC").Limit(limit).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (ds *ContentRepository) GetUserRank(userID string) (int, error) {
+	var rank int64
+	userProgress, err := ds.GetUserProgress(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := ds.db.Model(&model.UserProgress{}).Scopes(leaderboardVisible).
+		Where("xp > ?", userProgress.XP).Count(&rank).Error; err != nil {
+		return 0, err
+	}
+
+	return int(rank + 1), nil // +1 because rank is 0-indexed
+}
+
+// leaderboardScores is the column users are ranked by on each leaderboard type other
+// than XP. Ties go to the user with more XP.
+var leaderboardScores = map[string]string{
+	model.LeaderboardTypeSpirit:     "spirit_stage",
+	model.LeaderboardTypeCollection: "collected_characters",
+}
+
+// leaderboardEntries selects the spirit stage and collection of visible users active
+// since the given time, or of all visible users when it is zero
+func (ds *ContentRepository) leaderboardEntries(since time.Time) *gorm.DB {
+	query := ds.db.Table("user_progresses p").
+		Select(`p.*, COALESCE(lp.spirit_stage, 1) AS spirit_stage, c.collected AS collected_characters,
+			COALESCE(ROUND(c.collected * 100.0 / NULLIF((SELECT COUNT(*) FROM characters), 0), 1), 0) AS collection_percent`).
+		Joins("LEFT JOIN leaderboard_profiles lp ON lp.user_id = p.user_id").
+		Joins(`CROSS JOIN LATERAL (
+			SELECT COUNT(*) AS collected FROM user_characters uc
+			JOIN characters ch ON ch.id = uc.character_id
+			WHERE uc.user_id = p.user_id
+		) c`).
+		Where("p.user_id NOT IN (?)", ds.db.Model(&model.User{}).Select("id").
+			Where("leaderboard_visibility = ? OR leaderboard_quarantined", model.LeaderboardVisibilityHidden))
+	if !since.IsZero() {
+		query = query.Where("p.updated_at >= ?", since)
+	}
+	return ds.db.Table("(?) AS e", query)
+}
+
+// GetRankedLeaderboard ranks users on a spirit or collection leaderboard
+func (ds *ContentRepository) GetRankedLeaderboard(leaderboardType string, since time.Time, limit int) ([]model.LeaderboardEntry, error) {
+	score, ok := leaderboardScores[leaderboardType]
+	if !ok {
+		return nil, fmt.Errorf("unknown leaderboard type %q", leaderboardType)
+	}
+
+	var entries []model.LeaderboardEntry
+	if err := ds.leaderboardEntries(since).Order(score + " DESC, xp DESC").Limit(limit).
+		Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// GetLeaderboardEntry returns a user's entry and rank on a spirit or collection
+// leaderboard. Users outside the period or hidden from leaderboards are not found.
+func (ds *ContentRepository) GetLeaderboardEntry(leaderboardType, userID string, since time.Time) (*model.LeaderboardEntry, int, error) {
+	score, ok := leaderboardScores[leaderboardType]
+	if !ok {
+		return nil, 0, fmt.Errorf("unknown leaderboard type %q", leaderboardType)
+	}
+
+	var entry model.LeaderboardEntry
+	if err := ds.leaderboardEntries(since).Where("user_id = ?", userID).Take(&entry).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var ahead int64
+	value := entry.SpiritStage
+	if leaderboardType == model.LeaderboardTypeCollection {
+		value = entry.CollectedCharacters
+	}
+	if err := ds.leaderboardEntries(since).
+		Where(score+" > ? OR ("+score+" = ? AND xp > ?)", value, value, entry.XP).
+		Count(&ahead).Error; err != nil {
+		return nil, 0, err
+	}
+	return &entry, int(ahead + 1), nil
+}
+
+// GetSpiritsByUserIDs loads the spirits of the given users in one query
+func (ds *ContentRepository) GetSpiritsByUserIDs(userIDs []string) ([]model.Spirit, error) {
+	var spirits []model.Spirit
+	if len(userIDs) == 0 {
+		return spirits, nil
+	}
+	if err := ds.db.Where("user_id IN ?", userIDs).Find(&spirits).Error; err != nil {
+		return nil, err
+	}
+	return spirits, nil
+}
+
+// GetLeaderboardProfiles loads the leaderboard projection rows of the given users
+func (ds *ContentRepository) GetLeaderboardProfiles(userIDs []string) ([]model.LeaderboardProfile, error) {
+	var profiles []model.LeaderboardProfile
+	if len(userIDs) == 0 {
+		return profiles, nil
+	}
+	if err := ds.db.Where("user_id IN ?", userIDs).Find(&profiles).Error; err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+func (ds *ContentRepository) SaveLeaderboardProfiles(profiles []model.LeaderboardProfile) error {
+	if len(profiles) == 0 {
+		return nil
+	}
+	return ds.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&profiles).Error
+}
+
+// leaderboardProfileSource selects projection rows from the users and spirits tables
+const leaderboardProfileSource = `
+	SELECT u.id, u.username, u.leaderboard_visibility, COALESCE(s.type, 'unknown'), COALESCE(s.stage, 1), now()
+	FROM users u
+	LEFT JOIN LATERAL (
+		SELECT type, stage FROM spirits WHERE spirits.user_id = u.id ORDER BY updated_at DESC LIMIT 1
+	) s ON true`
+
+// RefreshLeaderboardProfile rebuilds a user's projection row from the source tables
+func (ds *ContentRepository) RefreshLeaderboardProfile(userID string) error {
+	return ds.db.Exec(`
+		INSERT INTO leaderboard_profiles (user_id, username, visibility, spirit_type, spirit_stage, updated_at)
+		`+leaderboardProfileSource+` WHERE u.id = ?
+		ON CONFLICT (user_id) DO UPDATE SET
+			username = EXCLUDED.username,
+			visibility = EXCLUDED.visibility,
+			spirit_type = EXCLUDED.spirit_type,
+			spirit_stage = EXCLUDED.spirit_stage,
+			updated_at = EXCLUDED.updated_at`, userID).Error
+}
+
+// BackfillLeaderboardProfiles creates the projection rows of users with progress that
+// do not have one yet
+func (ds *ContentRepository) BackfillLeaderboardProfiles() error {
+	return ds.db.Exec(`
+		INSERT INTO leaderboard_profiles (user_id, username, visibility, spirit_type, spirit_stage, updated_at)
+		` + leaderboardProfileSource + `
+		WHERE EXISTS (SELECT 1 FROM user_progresses p WHERE p.user_id = u.id)
+		ON CONFLICT (user_id) DO NOTHING`).Error
+}
+
+// ==================== CONTENT SEARCH AND FILTERING ====================
+
+func (ds *ContentRepository) SearchCharacters(query string, era string, dynasty string, rarity string, limit int) ([]model.Character, error) {
+	var characters []model.Character
+	dbQuery := ds.db.Model(&model.Character{})
+
+	if query != "" {
+		dbQuery = dbQuery.Where("name LIKE ? OR description LIKE ?", "%"+query+"%", "%"+query+"%")
+	}
+
+	if era != "" {
+		dbQuery = dbQuery.Where("era = ?", era)
+	}
+
+	if dynasty != "" {
+		dbQuery = dbQuery.Where("dynasty = ?", dynasty)
+	}
+
+	if rarity != "" {
+		dbQuery = dbQuery.Where("rarity = ?", rarity)
+	}
+
+	if limit > 0 {
+		dbQuery = dbQuery.Limit(limit)
+	}
+
+	if err := dbQuery.Find(&characters).Error; err != nil {
+		return nil, err
+	}
+	return characters, nil
+}
+
+func (ds *ContentRepository) GetCharactersByIDs(ids []string) ([]model.Character, error) {
+	var characters []model.Character
+	if len(ids) == 0 {
+		return characters, nil
+	}
+
+	if err := ds.db.Where("id IN ?", ids).Find(&characters).Error; err != nil {
+		return nil, err
+	}
+	return characters, nil
+}
+
+// ==================== CHARACTER RELATION METHODS ====================
+
+func (ds *ContentRepository) CreateCharacterRelation(relation *model.CharacterRelation) error {
+	if relation.ID == "" {
+		relation.ID = ids.New()
+	}
+	return ds.db.Create(relation).Error
+}
+
+func (ds *ContentRepository) GetCharacterRelation(relationID string) (*model.CharacterRelation, error) {
+	var relation model.CharacterRelation
+	if err := ds.db.Where("id = ?", relationID).First(&relation).Error; err != nil {
+		return nil, err
+	}
+	return &relation, nil
+}
+
+// GetCharacterRelations returns the relations a character takes part in, on either side
+func (ds *ContentRepository) GetCharacterRelations(characterID string) ([]model.CharacterRelation, error) {
+	var relations []model.CharacterRelation
+	err := ds.db.Where("character_id = ? OR related_character_id = ?", characterID, characterID).
+		Order("created_at").Find(&relations).Error
+	return relations, err
+}
+
+// CharacterRelationExists reports whether two characters are already related, in
+// either direction
+func (ds *ContentRepository) CharacterRelationExists(characterID, relatedCharacterID string) (bool, error) {
+	var count int64
+	err := ds.db.Model(&model.CharacterRelation{}).
+		Where("(character_id = ? AND related_character_id = ?) OR (character_id = ? AND related_character_id = ?)",
+			characterID, relatedCharacterID, relatedCharacterID, characterID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (ds *ContentRepository) UpdateCharacterRelation(relation *model.CharacterRelation) error {
+	relation.UpdatedAt = time.Now()
+	return ds.db.Save(relation).Error
+}
+
+func (ds *ContentRepository) DeleteCharacterRelation(relationID string) error {
+	return ds.db.Where("id = ?", relationID).Delete(&model.CharacterRelation{}).Error
+}
+
+// ==================== GLOSSARY METHODS ====================
+
+func (ds *ContentRepository) CreateGlossaryTerm(term *model.GlossaryTerm) error {
+	if term.ID == "" {
+		term.ID = ids.New()
+	}
+	return ds.db.Create(term).Error
+}
+
+func (ds *ContentRepository) GetGlossaryTerm(termID string) (*model.GlossaryTerm, error) {
+	var term model.GlossaryTerm
+	if err := ds.db.Where("id = ?", termID).First(&term).Error; err != nil {
+		return nil, err
+	}
+	return &term, nil
+}
+
+// GetGlossaryTermByTerm looks a term up case-insensitively
+func (ds *ContentRepository) GetGlossaryTermByTerm(term string) (*model.GlossaryTerm, error) {
+	var glossaryTerm model.GlossaryTerm
+	if err := ds.db.Where("LOWER(term) = LOWER(?)", term).First(&glossaryTerm).Error; err != nil {
+		return nil, err
+	}
+	return &glossaryTerm, nil
+}
+
+// SearchGlossaryTerms matches the term, its aliases and its definition, alphabetically
+func (ds *ContentRepository) SearchGlossaryTerms(query, era string, page, limit int) ([]model.GlossaryTerm, int64, error) {
+	var terms []model.GlossaryTerm
+	var total int64
+
+	dbQuery := ds.db.Model(&model.GlossaryTerm{})
+	if query != "" {
+		pattern := "%" + query + "%"
+		dbQuery = dbQuery.Where("term ILIKE ? OR definition ILIKE ? OR aliases::text ILIKE ?", pattern, pattern, pattern)
+	}
+	if era != "" {
+		dbQuery = dbQuery.Where("era = ?", era)
+	}
+
+	if err := dbQuery.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := dbQuery.Order("term").Limit(limit).Offset(offset).Find(&terms).Error; err != nil {
+		return nil, 0, err
+	}
+	return terms, total, nil
+}
+
+// GetAllGlossaryTerms loads the whole glossary for linking terms in lesson stories
+func (ds *ContentRepository) GetAllGlossaryTerms() ([]model.GlossaryTerm, error) {
+	var terms []model.GlossaryTerm
+	err := ds.db.Order("term").Find(&terms).Error
+	return terms, err
+}
+
+func (ds *ContentRepository) UpdateGlossaryTerm(term *model.GlossaryTerm) error {
+	term.UpdatedAt = time.Now()
+	return ds.db.Save(term).Error
+}
+
+func (ds *ContentRepository) DeleteGlossaryTerm(termID string) error {
+	return ds.db.Where("id = ?", termID).Delete(&model.GlossaryTerm{}).Error
+}
+
+// ==================== ERA AND DYNASTY METHODS ====================
+
+// GetEras returns the eras in display order
+func (ds *ContentRepository) GetEras() ([]model.Era, error) {
+	var eras []model.Era
+	err := ds.db.Order("\"order\" ASC, code ASC").Find(&eras).Error
+	return eras, err
+}
+
+func (ds *ContentRepository) GetEra(code string) (*model.Era, error) {
+	var era model.Era
+	if err := ds.db.Where("code = ?", code).First(&era).Error; err != nil {
+		return nil, err
+	}
+	return &era, nil
+}
+
+func (ds *ContentRepository) CreateEra(era *model.Era) error {
+	return ds.db.Create(era).Error
+}
+
+func (ds *ContentRepository) UpdateEra(era *model.Era) error {
+	era.UpdatedAt = time.Now()
+	return ds.db.Save(era).Error
+}
+
+func (ds *ContentRepository) DeleteEra(code string) error {
+	return ds.db.Where("code = ?", code).Delete(&model.Era{}).Error
+}
+
+// CountEraReferences counts the dynasties, characters, timeline entries and glossary
+// terms filed under an era
+func (ds *ContentRepository) CountEraReferences(code string) (int64, error) {
+	var count int64
+	err := ds.db.Raw(`
+		SELECT (SELECT COUNT(*) FROM dynasties WHERE era = ?)
+			+ (SELECT COUNT(*) FROM characters WHERE era = ?)
+			+ (SELECT COUNT(*) FROM timelines WHERE era = ?)
+			+ (SELECT COUNT(*) FROM glossary_terms WHERE era = ?)
+	`, code, code, code, code).Scan(&count).Error
+	return count, err
+}
+
+// GetDynasties returns the dynasties in display order
+func (ds *ContentRepository) GetDynasties() ([]model.Dynasty, error) {
+	var dynasties []model.Dynasty
+	err := ds.db.Order("\"order\" ASC, name ASC").Find(&dynasties).Error
+	return dynasties, err
+}
+
+func (ds *ContentRepository) GetDynasty(dynastyID string) (*model.Dynasty, error) {
+	var dynasty model.Dynasty
+	if err := ds.db.Where("id = ?", dynastyID).First(&dynasty).Error; err != nil {
+		return nil, err
+	}
+	return &dynasty, nil
+}
+
+func (ds *ContentRepository) CreateDynasty(dynasty *model.Dynasty) error {
+	if dynasty.ID == "" {
+		dynasty.ID = ids.New()
+	}
+	return ds.db.Create(dynasty).Error
+}
+
+// UpdateDynasty saves a dynasty. A new name is carried over to the characters, timeline
+// entries and certificates that use the old one in the same transaction.
+func (ds *ContentRepository) UpdateDynasty(dynasty *model.Dynasty, oldName string) error {
+	dynasty.UpdatedAt = time.Now()
+	return ds.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(dynasty).Error; err != nil {
+			return err
+		}
+		if dynasty.Name == oldName {
+			return nil
+		}
+		for _, table := range []interface{}{&model.Character{}, &model.Timeline{}, &model.Certificate{}} {
+			if err := tx.Model(table).Where("dynasty = ?", oldName).Update("dynasty", dynasty.Name).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (ds *ContentRepository) DeleteDynasty(dynastyID string) error {
+	return ds.db.Where("id = ?", dynastyID).Delete(&model.Dynasty{}).Error
+}
+
+// CountDynastyReferences counts the characters and timeline entries of a dynasty
+func (ds *ContentRepository) CountDynastyReferences(name string) (int64, error) {
+	var count int64
+	err := ds.db.Raw(`
+		SELECT (SELECT COUNT(*) FROM characters WHERE dynasty = ?)
+			+ (SELECT COUNT(*) FROM timelines WHERE dynasty = ?)
+	`, name, name).Scan(&count).Error
+	return count, err
+}
+
+// BackfillErasAndDynasties fills the reference tables. Default eras are created once, on
+// an empty table; every era and dynasty content already uses is then added if missing,
+// dynasties from the timeline first so they keep its years and order.
+func (ds *ContentRepository) BackfillErasAndDynasties() error {
+	var eras int64
+	if err := ds.db.Model(&model.Era{}).Count(&eras).Error; err != nil {
+		return err
+	}
+	if eras == 0 {
+		if err := ds.db.Clauses(clause.OnConflict{DoNothing: true}).Create(model.DefaultEras()).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := ds.db.Exec(`
+		INSERT INTO eras (code, name, "order", created_at, updated_at)
+		SELECT era, era, (SELECT COALESCE(MAX("order"), 0) + 1 FROM eras), NOW(), NOW()
+		FROM (
+			SELECT era FROM characters UNION SELECT era FROM timelines UNION SELECT era FROM glossary_terms
+		) used
+		WHERE era IS NOT NULL AND era <> ''
+		ON CONFLICT (code) DO NOTHING
+	`).Error; err != nil {
+		return err
+	}
+
+	if err := ds.db.Exec(`
+		INSERT INTO dynasties (id, name, era, start_year, end_year, "order", created_at, updated_at)
+		SELECT DISTINCT ON (dynasty) gen_random_uuid()::text, dynasty, COALESCE(era, ''), start_year, end_year, "order", NOW(), NOW()
+		FROM timelines
+		WHERE dynasty IS NOT NULL AND dynasty <> ''
+		ORDER BY dynasty, "order"
+		ON CONFLICT (name) DO NOTHING
+	`).Error; err != nil {
+		return err
+	}
+
+	return ds.db.Exec(`
+		INSERT INTO dynasties (id, name, era, "order", created_at, updated_at)
+		SELECT DISTINCT ON (dynasty) gen_random_uuid()::text, dynasty, COALESCE(era, ''), (SELECT COALESCE(MAX("order"), 0) + 1 FROM dynasties), NOW(), NOW()
+		FROM characters
+		WHERE dynasty IS NOT NULL AND dynasty <> ''
+		ORDER BY dynasty, created_at
+		ON CONFLICT (name) DO NOTHING
+	`).Error
+}
+
+// SearchContent matches characters by name and description, and active lessons by
+// title, story and question text. Era, dynasty and rarity filters apply to a lesson
+// through its character. Title matches rank first; results are paginated across
+// both entity types.
+func (ds *ContentRepository) SearchContent(query, entityType, era, dynasty, rarity string, page, limit int) ([]model.SearchHit, int64, error) {
+	pattern := "%" + query + "%"
+
+	characterFilter := func(alias string) (string, []interface{}) {
+		var clauses []string
+		var args []interface{}
+		if era != "" {
+			clauses = append(clauses, alias+".era = ?")
+			args = append(args, era)
+		}
+		if dynasty != "" {
+			clauses = append(clauses, alias+".dynasty = ?")
+			args = append(args, dynasty)
+		}
+		if rarity != "" {
+			clauses = append(clauses, alias+".rarity = ?")
+			args = append(args, rarity)
+		}
+		if len(clauses) == 0 {
+			return "", nil
+		}
+		return " AND " + strings.Join(clauses, " AND "), args
+	}
+
+	var parts []string
+	var args []interface{}
+
+	if entityType == "" || entityType == model.SearchEntityCharacter {
+		filter, filterArgs := characterFilter("c")
+		parts = append(parts, `
+			SELECT 'character' AS entity_type, c.id AS entity_id,
+				CASE WHEN c.name ILIKE ? THEN 0 ELSE 1 END AS rank, c.name AS title
+			FROM characters c
+			WHERE (c.name ILIKE ? OR c.description ILIKE ?)`+filter)
+		args = append(args, pattern, pattern, pattern)
+		args = append(args, filterArgs...)
+	}
+
+	if entityType == "" || entityType == model.SearchEntityLesson {
+		filter, filterArgs := characterFilter("c")
+		parts = append(parts, `
+			SELECT 'lesson' AS entity_type, l.id AS entity_id,
+				CASE WHEN l.title ILIKE ? THEN 0 ELSE 1 END AS rank, l.title AS title
+			FROM lessons l
+			JOIN characters c ON c.id = l.character_id
+			WHERE l.is_active = true AND (l.title ILIKE ? OR l.story ILIKE ? OR EXISTS (
+				SELECT 1 FROM jsonb_array_elements(COALESCE(l.questions, '[]'::jsonb)) q
+				WHERE q->>'question' ILIKE ?
+			))`+filter)
+		args = append(args, pattern, pattern, pattern, pattern)
+		args = append(args, filterArgs...)
+	}
+
+	union := strings.Join(parts, " UNION ALL ")
+
+	var total int64
+	if err := ds.db.Raw("SELECT COUNT(*) FROM ("+union+") AS results", args...).Scan(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var hits []model.SearchHit
+	offset := (page - 1) * limit
+	pageArgs := append(append([]interface{}{}, args...), limit, offset)
+	if err := ds.db.Raw("SELECT entity_type, entity_id, rank FROM ("+union+") AS results "+
+		"ORDER BY rank ASC, entity_type ASC, title ASC LIMIT ? OFFSET ?", pageArgs...).
+		Scan(&hits).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return hits, total, nil
+}
+
+// GetSearchSuggestionSources loads character names, the dynasties they belong to and
+// active lesson titles for the search suggestion index
+func (ds *ContentRepository) GetSearchSuggestionSources() ([]model.SearchSuggestionSource, error) {
+	var sources []model.SearchSuggestionSource
+	err := ds.db.Raw(`
+		SELECT 'character' AS entity_type, c.id AS entity_id, c.name AS text, c.id AS character_id
+		FROM characters c
+		UNION ALL
+		SELECT DISTINCT 'dynasty', c.dynasty, c.dynasty, ''
+		FROM characters c
+		WHERE c.dynasty <> ''
+		UNION ALL
+		SELECT 'lesson', l.id, l.title, l.character_id
+		FROM lessons l
+		WHERE l.is_active = true`).Scan(&sources).Error
+	return sources, err
+}
+
+// ==================== CONTENT POPULARITY METHODS ====================
+
+// AddContentPopularity adds counters to the daily popularity totals
+func (ds *ContentRepository) AddContentPopularity(stats []model.ContentPopularityStat) error {
+	if len(stats) == 0 {
+		return nil
+	}
+
+	return ds.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "entity_type"}, {Name: "entity_id"}, {Name: "day"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"views":       gorm.Expr("content_popularity_stats.views + EXCLUDED.views"),
+			"starts":      gorm.Expr("content_popularity_stats.starts + EXCLUDED.starts"),
+			"completions": gorm.Expr("content_popularity_stats.completions + EXCLUDED.completions"),
+			"video_plays": gorm.Expr("content_popularity_stats.video_plays + EXCLUDED.video_plays"),
+			"video_skips": gorm.Expr("content_popularity_stats.video_skips + EXCLUDED.video_skips"),
+		}),
+	}).Create(&stats).Error
+}
+
+// GetTrendingContent sums popularity since the given day and returns the highest scoring
+// entities. A completion weighs more than a start, a start more than a view. An empty
+// entityType includes lessons and characters.
+func (ds *ContentRepository) GetTrendingContent(entityType string, since time.Time, limit int) ([]model.TrendingContent, error) {
+	query := ds.db.Model(&model.ContentPopularityStat{}).
+		Select(`entity_type, entity_id, sum(views) AS views, sum(starts) AS starts, sum(completions) AS completions,
+			sum(views + 3 * starts + 10 * completions) AS score`).
+		Where("day >= ?", since)
+	if entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+
+	var trending []model.TrendingContent
+	err := query.Group("entity_type, entity_id").
+		Order("score DESC, entity_id").
+		Limit(limit).
+		Scan(&trending).Error
+	return trending, err
+}
+
+// GetContentPopularityDays returns the daily totals of one entity since the given day,
+// oldest first
+func (ds *ContentRepository) GetContentPopularityDays(entityType, entityID string, since time.Time) ([]model.ContentPopularityStat, error) {
+	var stats []model.ContentPopularityStat
+	err := ds.db.Where("entity_type = ? AND entity_id = ? AND day >= ?", entityType, entityID, since).
+		Order("day").
+		Find(&stats).Error
+	return stats, err
+}
+
+// ==================== VIDEO PROGRESS METHODS ====================
+
+func (ds *ContentRepository) GetLessonVideoProgress(userID, lessonID string) (*model.LessonVideoProgress, error) {
+	var progress model.LessonVideoProgress
+	if err := ds.db.Where("user_id = ? AND lesson_id = ?", userID, lessonID).First(&progress).Error; err != nil {
+		return nil, err
+	}
+	return &progress, nil
+}
+
+func (ds *ContentRepository) SaveLessonVideoProgress(progress *model.LessonVideoProgress) error {
+	return ds.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(progress).Error
+}
+
+// GetLessonVideoWatchStats averages how much of a lesson's video its viewers watched and
+// where those who skipped did so
+func (ds *ContentRepository) GetLessonVideoWatchStats(lessonID string) (*model.LessonVideoWatchStats, error) {
+	var stats model.LessonVideoWatchStats
+	err := ds.db.Model(&model.LessonVideoProgress{}).
+		Select(`count(*) AS viewers, count(*) FILTER (WHERE skipped) AS skipped,
+			coalesce(avg(watch_percent), 0) AS average_watch_percent,
+			coalesce(avg(skipped_at_seconds) FILTER (WHERE skipped), 0) AS average_skip_second`).
+		Where("lesson_id = ?", lessonID).
+		Scan(&stats).Error
+	return &stats, err
+}
+
+func (ds *ContentRepository) SaveUserQuestionAnswer(answer *model.UserQuestionAnswer) error {
+	if answer.ID == "" {
+		answer.ID = ids.New()
+	}
+	answer.CreatedAt = time.Now()
+	answer.UpdatedAt = time.Now()
+
+	// Check if answer already exists for this user/lesson/question
+	var existing model.UserQuestionAnswer
+	err := ds.db.Where("user_id = ? AND lesson_id = ? AND question_id = ?",
+		answer.UserID, answer.LessonID, answer.QuestionID).First(&existing).Error
+
+	if err == nil {
+		// Update existing answer
+		existing.Answer = answer.Answer
+		existing.IsCorrect = answer.IsCorrect
+		existing.Points = answer.Points
+		existing.UpdatedAt = time.Now()
+		return ds.db.Save(&existing).Error
+	} else if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Create new answer
+		return ds.db.Create(answer).Error
+	}
+
+	return err
+}
+
+func (ds *ContentRepository) GetUserQuestionAnswers(userID, lessonID string) ([]model.UserQuestionAnswer, error) {
+	var answers []model.UserQuestionAnswer
+	if err := ds.db.Where("user_id = ? AND lesson_id = ?", userID, lessonID).
+		Find(&answers).Error; err != nil {
+		return nil, err
+	}
+	return answers, nil
+}
+
+func (ds *ContentRepository) GetUserQuestionAnswer(userID, lessonID, questionID string) (*model.UserQuestionAnswer, error) {
+	var answer model.UserQuestionAnswer
+	if err := ds.db.Where("user_id = ? AND lesson_id = ? AND question_id = ?",
+		userID, lessonID, questionID).First(&answer).Error; err != nil {
+		return nil, err
+	}
+	return &answer, nil
+}
+
+func (ds *ContentRepository) DeleteUserQuestionAnswers(userID, lessonID string) error {
+	if err := ds.db.Where("user_id = ? AND lesson_id = ?", userID, lessonID).
+		Delete(&model.UserQuestionAnswer{}).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// ==================== LESSON SESSION METHODS ====================
+
+func (ds *ContentRepository) GetLessonSession(userID, lessonID string) (*model.LessonSession, error) {
+	var session model.LessonSession
+	if err := ds.db.Where("user_id = ? AND lesson_id = ?", userID, lessonID).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// SaveLessonSession creates the session or overwrites the stored one
+func (ds *ContentRepository) SaveLessonSession(session *model.LessonSession) error {
+	return ds.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(session).Error
+}
+
+// ResetLessonSession deletes a session together with the answers given in it
+func (ds *ContentRepository) ResetLessonSession(userID, lessonID string) error {
+	return ds.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND lesson_id = ?", userID, lessonID).
+			Delete(&model.UserQuestionAnswer{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ? AND lesson_id = ?", userID, lessonID).Delete(&model.LessonSession{}).Error
+	})
+}
+
+func (ds *ContentRepository) DeleteLessonSession(userID, lessonID string) error {
+	return ds.db.Where("user_id = ? AND lesson_id = ?", userID, lessonID).Delete(&model.LessonSession{}).Error
+}
+
+// GetLessonSessionUserIDs lists the users with a session in the lesson active since the
+// given time
+func (ds *ContentRepository) GetLessonSessionUserIDs(lessonID string, activeSince time.Time) ([]string, error) {
+	var userIDs []string
+	if err := ds.db.Model(&model.LessonSession{}).
+		Where("lesson_id = ? AND last_active_at >= ?", lessonID, activeSince).
+		Pluck("user_id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+// ==================== LESSON AID METHODS ====================
+
+// Reasons a lesson aid is refused, found inside its transaction so concurrent requests
+// can't go over the limit or spend the same heart twice
+var (
+	ErrAidLimitReached = errors.New("lesson aid limit reached")
+	ErrNoHearts        = errors.New("no hearts left")
+)
+
+// GetLessonAidUses returns the aids used in one lesson session, oldest first
+func (ds *ContentRepository) GetLessonAidUses(userID, lessonID string, sessionStartedAt time.Time) ([]model.LessonAidUse, error) {
+	var uses []model.LessonAidUse
+	err := ds.db.Where("user_id = ? AND lesson_id = ? AND session_started_at = ?", userID, lessonID, sessionStartedAt).
+		Order("created_at ASC").
+		Find(&uses).Error
+	return uses, err
+}
+
+// UseLessonAid records an aid and pays for it in one transaction, and reports whether the
+// use is new. If the aid was already used on the question in this session, that use is
+// returned and nothing is charged. Half a heart takes a whole heart and banks the other
+// half, or spends the banked half; coins cost coinPrice.
+func (ds *ContentRepository) UseLessonAid(use *model.LessonAidUse, limit, coinPrice int) (*model.LessonAidUse, bool, error) {
+	if use.ID == "" {
+		use.ID = ids.New()
+	}
+	if use.PaidWith == model.AidPaymentCoins {
+		use.Cost = coinPrice
+	}
+	now := time.Now()
+	use.CreatedAt = now
+
+	var created bool
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		// Concurrent uses of the learner queue on their progress row, so each one counts
+		// the uses committed before it and the limit holds
+		var progress model.UserProgress
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", use.UserID).First(&progress).Error; err != nil {
+			return err
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(use)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		created = true
+
+		var used int64
+		if err := tx.Model(&model.LessonAidUse{}).
+			Where("user_id = ? AND lesson_id = ? AND session_started_at = ? AND aid = ?",
+				use.UserID, use.LessonID, use.SessionStartedAt, use.Aid).
+			Count(&used).Error; err != nil {
+			return err
+		}
+		if aidLimitReached(used, limit) {
+			return ErrAidLimitReached
+		}
+
+		if use.PaidWith == model.AidPaymentCoins {
+			return payAidWithCoins(tx, use, now)
+		}
+		return payAidWithHalfHeart(tx, use, now)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !created {
+		var existing model.LessonAidUse
+		err := ds.db.Where("user_id = ? AND lesson_id = ? AND session_started_at = ? AND question_id = ? AND aid = ?",
+			use.UserID, use.LessonID, use.SessionStartedAt, use.QuestionID, use.Aid).First(&existing).Error
+		return &existing, false, err
+	}
+	return use, true, nil
+}
+
+// aidLimitReached tells whether the uses counted, the new one included, go over the limit
+func aidLimitReached(used int64, limit int) bool {
+	return used > int64(limit)
+}
+
+func payAidWithCoins(tx *gorm.DB, use *model.LessonAidUse, now time.Time) error {
+	result := tx.Model(&model.Wallet{}).
+		Where("user_id = ? AND balance >= ?", use.UserID, use.Cost).
+		Updates(map[string]interface{}{
+			"balance":    gorm.Expr("balance - ?", use.Cost),
+			"updated_at": now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInsufficientCoins
+	}
+
+	var wallet model.Wallet
+	if err := tx.Where("user_id = ?", use.UserID).First(&wallet).Error; err != nil {
+		return err
+	}
+	return tx.Create(&model.CoinTransaction{
+		ID:           ids.New(),
+		UserID:       use.UserID,
+		Source:       model.CoinSourceLessonAid,
+		Amount:       -use.Cost,
+		BalanceAfter: wallet.Balance,
+		ReferenceID:  use.ID,
+		Note:         use.Aid,
+		CreatedAt:    now,
+	}).Error
+}
+
+func payAidWithHalfHeart(tx *gorm.DB, use *model.LessonAidUse, now time.Time) error {
+	var progress model.UserProgress
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ?", use.UserID).First(&progress).Error; err != nil {
+		return err
+	}
+
+	note := use.Aid + ": banked half heart spent"
+	if progress.HalfHeartBanked {
+		progress.HalfHeartBanked = false
+	} else {
+		if progress.Hearts < 1 {
+			return ErrNoHearts
+		}
+		progress.Hearts--
+		progress.HalfHeartBanked = true
+		use.Cost = 1
+		note = use.Aid + ": half heart banked"
+	}
+
+	if err := tx.Model(&progress).Updates(map[string]interface{}{
+		"hearts":            progress.Hearts,
+		"half_heart_banked": progress.HalfHeartBanked,
+		"updated_at":        now,
+	}).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(use).Update("cost", use.Cost).Error; err != nil {
+		return err
+	}
+
+	return tx.Create(&model.HeartTransaction{
+		ID:           ids.New(),
+		UserID:       use.UserID,
+		Source:       model.HeartSourceLessonAid,
+		Amount:       -use.Cost,
+		BalanceAfter: progress.Hearts,
+		ReferenceID:  use.ID,
+		Note:         note,
+		CreatedAt:    now,
+	}).Error
+}
+
+// ==================== TRANSLATION METHODS ====================
+
+func (ds *ContentRepository) GetLessonTranslation(lessonID, locale string) (*model.LessonTranslation, error) {
+	var translation model.LessonTranslation
+	if err := ds.db.Where("lesson_id = ? AND locale = ?", lessonID, locale).First(&translation).Error; err != nil {
+		return nil, err
+	}
+	return &translation, nil
+}
+
+func (ds *ContentRepository) GetLessonTranslations(lessonID string) ([]model.LessonTranslation, error) {
+	var translations []model.LessonTranslation
+	if err := ds.db.Where("lesson_id = ?", lessonID).Order("locale").Find(&translations).Error; err != nil {
+		return nil, err
+	}
+	return translations, nil
+}
+
+func (ds *ContentRepository) GetApprovedTranslations(lessonID string) ([]model.LessonTranslation, error) {
+	var translations []model.LessonTranslation
+	if err := ds.db.Where("lesson_id = ? AND status = ?", lessonID, model.TranslationStatusApproved).
+		Find(&translations).Error; err != nil {
+		return nil, err
+	}
+	return translations, nil
+}
+
+func (ds *ContentRepository) SaveLessonTranslation(translation *model.LessonTranslation) error {
+	if translation.ID == "" {
+		translation.ID = ids.New()
+		translation.CreatedAt = time.Now()
+	}
+	translation.UpdatedAt = time.Now()
+	return ds.db.Save(translation).Error
+}
+
+// GetLessonsMissingApprovedTranslation lists active lessons with no approved translation
+// for the locale, along with the status of any translation in progress.
+func (ds *ContentRepository) GetLessonsMissingApprovedTranslation(locale string, page, limit int) ([]model.Lesson, map[string]string, int64, error) {
+	var lessons []model.Lesson
+	var total int64
+
+	query := ds.db.Model(&model.Lesson{}).
+		Where("is_active = ?", true).
+		Where("NOT EXISTS (SELECT 1 FROM lesson_translations lt WHERE lt.lesson_id = lessons.id AND lt.locale = ? AND lt.status = ?)",
+			locale, model.TranslationStatusApproved)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Order("character_id, \"order\"").Limit(limit).Offset(offset).Find(&lessons).Error; err != nil {
+		return nil, nil, 0, err
+	}
+
+	lessonIDs := make([]string, len(lessons))
+	for i, lesson := range lessons {
+		lessonIDs[i] = lesson.ID
+	}
+
+	var translations []model.LessonTranslation
+	if len(lessonIDs) > 0 {
+		if err := ds.db.Select("lesson_id", "status").
+			Where("lesson_id IN ? AND locale = ?", lessonIDs, locale).
+			Find(&translations).Error; err != nil {
+			return nil, nil, 0, err
+		}
+	}
+
+	statuses := make(map[string]string, len(translations))
+	for _, t := range translations {
+		statuses[t.LessonID] = t.Status
+	}
+
+	return lessons, statuses, total, nil
+}
+
+// GetUnratedLessons lists active lessons no admin has given a content rating yet,
+// oldest first
+func (ds *ContentRepository) GetUnratedLessons(page, limit int) ([]model.Lesson, int64, error) {
+	var lessons []model.Lesson
+	var total int64
+
+	query := ds.db.Model(&model.Lesson{}).
+		Where("is_active = ? AND (content_rating IS NULL OR content_rating = ?)", true, model.ContentRatingUnrated)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Preload("Character").Order("created_at ASC").Limit(limit).Offset(offset).Find(&lessons).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return lessons, total, nil
+}
+
+// ==================== QUESTION REPORT METHODS ====================
+
+// ErrQuestionInLesson refuses to restore a question whose ID is in use in its lesson again
+var ErrQuestionInLesson = errors.New("question ID already in lesson")
+
+// CreateQuestionReport stores a report and reports whether it is new. A learner who
+// already reported the question gets false.
+func (ds *ContentRepository) CreateQuestionReport(report *model.QuestionReport) (bool, error) {
+	if report.ID == "" {
+		report.ID = ids.New()
+	}
+	report.Status = model.QuestionReportPending
+	report.CreatedAt = time.Now()
+
+	result := ds.db.Clauses(clause.OnConflict{DoNothing: true}).Create(report)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CountPendingQuestionReports counts the reports of a question no admin has reviewed
+func (ds *ContentRepository) CountPendingQuestionReports(lessonID, questionID string) (int64, error) {
+	var count int64
+	err := ds.db.Model(&model.QuestionReport{}).
+		Where("lesson_id = ? AND question_id = ? AND status = ?", lessonID, questionID, model.QuestionReportPending).
+		Count(&count).Error
+	return count, err
+}
+
+// GetQuestionReportCounts counts the reports of each question of a lesson by reason
+// and review state
+func (ds *ContentRepository) GetQuestionReportCounts(lessonID string) ([]model.QuestionReportCount, error) {
+	var counts []model.QuestionReportCount
+	err := ds.db.Model(&model.QuestionReport{}).
+		Select("question_id, reason, status, count(*) AS count").
+		Where("lesson_id = ?", lessonID).
+		Group("question_id, reason, status").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// GetQuestionAnswerCounts counts the answers to each question of a lesson
+func (ds *ContentRepository) GetQuestionAnswerCounts(lessonID string) ([]model.QuestionAnswerCount, error) {
+	var counts []model.QuestionAnswerCount
+	err := ds.db.Model(&model.UserQuestionAnswer{}).
+		Select("question_id, count(*) AS answers, count(*) FILTER (WHERE is_correct) AS correct").
+		Where("lesson_id = ?", lessonID).
+		Group("question_id").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// questionID reads the ID of a question stored in a lesson's JSON
+func questionID(question json.RawMessage) string {
+	var q struct {
+		ID string `json:"id"`
+	}
+	_ = json.Unmarshal(question, &q)
+	return q.ID
+}
+
+// DisableQuestion takes a question out of its lesson and holds it in disabled, in one
+// transaction. It returns false if the lesson has no question with that ID, for example
+// because it was disabled already.
+func (ds *ContentRepository) DisableQuestion(disabled *model.DisabledQuestion) (bool, error) {
+	found := false
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		var lesson model.Lesson
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", disabled.LessonID).First(&lesson).Error; err != nil {
+			return err
+		}
+
+		var questions []json.RawMessage
+		if len(lesson.Questions) > 0 {
+			if err := json.Unmarshal(lesson.Questions, &questions); err != nil {
+				return err
+			}
+		}
+		position := slices.IndexFunc(questions, func(q json.RawMessage) bool {
+			return questionID(q) == disabled.QuestionID
+		})
+		if position < 0 {
+			return nil
+		}
+
+		disabled.Position = position
+		disabled.Question = questions[position]
+		remaining, err := json.Marshal(slices.Delete(questions, position, position+1))
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&model.Lesson{}).Where("id = ?", lesson.ID).
+			Updates(map[string]interface{}{
+				"questions":  json.RawMessage(remaining),
+				"updated_at": time.Now(),
+			}).Error; err != nil {
+			return err
+		}
+
+		if disabled.ID == "" {
+			disabled.ID = ids.New()
+		}
+		disabled.Status = model.DisabledQuestionPending
+		disabled.CreatedAt = time.Now()
+		if err := tx.Create(disabled).Error; err != nil {
+			return err
+		}
+
+		found = true
+		return nil
+	})
+	return found, err
+}
+
+func (ds *ContentRepository) GetDisabledQuestion(id string) (*model.DisabledQuestion, error) {
+	var disabled model.DisabledQuestion
+	if err := ds.db.Preload("Lesson").Where("id = ?", id).First(&disabled).Error; err != nil {
+		return nil, err
+	}
+	return &disabled, nil
+}
+
+// GetDisabledQuestions lists disabled questions, oldest first. Empty filters match all.
+func (ds *ContentRepository) GetDisabledQuestions(status, lessonID string, page, limit int) ([]model.DisabledQuestion, int64, error) {
+	var disabled []model.DisabledQuestion
+	var total int64
+
+	db := ds.db.Model(&model.DisabledQuestion{})
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+	if lessonID != "" {
+		db = db.Where("lesson_id = ?", lessonID)
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := db.Preload("Lesson").
+		Order("created_at ASC").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		Find(&disabled).Error; err != nil {
+		return nil, 0, err
+	}
+	return disabled, total, nil
+}
+
+// CloseDisabledQuestion records the review of a pending disabled question and closes the
+// question's pending reports. With DisabledQuestionRestored, question goes back into the
+// lesson at its old position. It returns false if the question was reviewed already.
+func (ds *ContentRepository) CloseDisabledQuestion(id, status, reviewerID, note string, question json.RawMessage) (bool, error) {
+	closed := false
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		var disabled model.DisabledQuestion
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND status = ?", id, model.DisabledQuestionPending).First(&disabled).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		if status == model.DisabledQuestionRestored {
+			var lesson model.Lesson
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id = ?", disabled.LessonID).First(&lesson).Error; err != nil {
+				return err
+			}
+
+			var questions []json.RawMessage
+			if len(lesson.Questions) > 0 {
+				if err := json.Unmarshal(lesson.Questions, &questions); err != nil {
+					return err
+				}
+			}
+			restoredID := questionID(question)
+			if slices.ContainsFunc(questions, func(q json.RawMessage) bool { return questionID(q) == restoredID }) {
+				return ErrQuestionInLesson
+			}
+
+			position := min(disabled.Position, len(questions))
+			encoded, err := json.Marshal(slices.Insert(questions, position, question))
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&model.Lesson{}).Where("id = ?", lesson.ID).
+				Updates(map[string]interface{}{
+					"questions":  json.RawMessage(encoded),
+					"updated_at": time.Now(),
+				}).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&disabled).Updates(map[string]interface{}{
+			"status":      status,
+			"reviewed_by": reviewerID,
+			"review_note": note,
+			"reviewed_at": time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&model.QuestionReport{}).
+			Where("lesson_id = ? AND question_id = ? AND status = ?", disabled.LessonID, disabled.QuestionID, model.QuestionReportPending).
+			Update("status", model.QuestionReportReviewed).Error; err != nil {
+			return err
+		}
+
+		closed = true
+		return nil
+	})
+	return closed, err
+}
+
+// ==================== CONTENT INCIDENT METHODS ====================
+
+// DeactivateLesson takes a live lesson down and opens its incident in one transaction.
+// It returns false if the lesson was not active.
+func (ds *ContentRepository) DeactivateLesson(incident *model.ContentIncident) (bool, error) {
+	deactivated := false
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.Lesson{}).
+			Where("id = ? AND is_active = ?", incident.LessonID, true).
+			Updates(map[string]interface{}{
+				"is_active":  false,
+				"updated_at": time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		if err := tx.Create(newContentIncident(incident)).Error; err != nil {
+			return err
+		}
+		deactivated = true
+		return nil
+	})
+	return deactivated, err
+}
+
+func (ds *ContentRepository) CreateContentIncident(incident *model.ContentIncident) error {
+	return ds.db.Create(newContentIncident(incident)).Error
+}
+
+func newContentIncident(incident *model.ContentIncident) *model.ContentIncident {
+	if incident.ID == "" {
+		incident.ID = ids.New()
+	}
+	incident.Status = model.ContentIncidentOpen
+	incident.CreatedAt = time.Now()
+	return incident
+}
+
+// UpdateContentIncidentNotified records how many learners were told about the incident
+// and whether the other instances cleared their caches
+func (ds *ContentRepository) UpdateContentIncidentNotified(id string, sessionsNotified int, cachesBroadcast bool) error {
+	return ds.db.Model(&model.ContentIncident{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"sessions_notified": sessionsNotified,
+			"caches_broadcast":  cachesBroadcast,
+		}).Error
+}
+
+func (ds *ContentRepository) GetContentIncident(id string) (*model.ContentIncident, error) {
+	var incident model.ContentIncident
+	if err := ds.db.Preload("Lesson").Where("id = ?", id).First(&incident).Error; err != nil {
+		return nil, err
+	}
+	return &incident, nil
+}
+
+// GetContentIncidents lists incidents, newest first. Empty filters match all.
+func (ds *ContentRepository) GetContentIncidents(status, lessonID string, page, limit int) ([]model.ContentIncident, int64, error) {
+	var incidents []model.ContentIncident
+	var total int64
+
+	db := ds.db.Model(&model.ContentIncident{})
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+	if lessonID != "" {
+		db = db.Where("lesson_id = ?", lessonID)
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := db.Preload("Lesson").
+		Order("created_at DESC").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		Find(&incidents).Error; err != nil {
+		return nil, 0, err
+	}
+	return incidents, total, nil
+}
+
+// ResolveContentIncident closes an open incident. Restoring a lesson incident puts the
+// lesson back live in the same transaction. It returns false if the incident was
+// resolved already.
+func (ds *ContentRepository) ResolveContentIncident(id, resolution, resolverID, note string) (bool, error) {
+	resolved := false
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		var incident model.ContentIncident
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND status = ?", id, model.ContentIncidentOpen).First(&incident).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		if incident.EntityType == model.ContentIncidentLesson && resolution == model.ContentIncidentRestored {
+			if err := tx.Model(&model.Lesson{}).Where("id = ?", incident.LessonID).
+				Updates(map[string]interface{}{
+					"is_active":  true,
+					"updated_at": time.Now(),
+				}).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&incident).Updates(map[string]interface{}{
+			"status":          model.ContentIncidentResolved,
+			"resolution":      resolution,
+			"resolved_by":     resolverID,
+			"resolution_note": note,
+			"resolved_at":     time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		resolved = true
+		return nil
+	})
+	return resolved, err
+}
@@ -0,0 +1,35 @@
+package repositories
+
+import (
+	"sync"
+	"testing"
+)
+
+// Concurrent aid uses take the progress row lock in turn, each counting the uses
+// committed before it, so exactly limit of them go through however they interleave
+func TestConcurrentAidUsesStayWithinLimit(t *testing.T) {
+	for _, limit := range []int{0, 1, 2, 3} {
+		var progressRow sync.Mutex
+		var committed int64
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				progressRow.Lock()
+				defer progressRow.Unlock()
+
+				// The insert followed by the count, rolled back when over the limit
+				if aidLimitReached(committed+1, limit) {
+					return
+				}
+				committed++
+			}()
+		}
+		wg.Wait()
+
+		if committed != int64(limit) {
+			t.Errorf("limit %d: %d concurrent uses went through", limit, committed)
+		}
+	}
+}
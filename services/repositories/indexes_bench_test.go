@@ -0,0 +1,185 @@
+//go:build dbbench
+
+// Benchmarks for the hot-path composite indexes. They need a migrated database with
+// realistic data, e.g. one filled by `go run ./seed -type loadtest -users 50000`:
+//
+//	DATABASE_URL=postgres://... go test -tags dbbench -run '^$' -bench . ./services/repositories/
+//
+// Each query runs once with its indexes and once with them dropped inside a transaction
+// that is rolled back, so the "without" numbers match the schema before the indexes
+// were added.
+package repositories
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type indexBenchCase struct {
+	name    string
+	indexes []string
+	query   string
+	// sample picks real argument values for query from the database
+	sample string
+	// extra arguments appended after the sampled ones
+	extra []interface{}
+}
+
+var indexBenchCases = []indexBenchCase{
+	{
+		name:    "session_lookup",
+		indexes: []string{"idx_user_sessions_lookup", "idx_user_sessions_active"},
+		query:   `SELECT * FROM user_sessions WHERE user_id = ? AND token_hash = ? AND is_active = ? AND expires_at > ? LIMIT 1`,
+		sample:  `SELECT user_id, token_hash, true FROM user_sessions LIMIT 1`,
+		extra:   []interface{}{time.Now()},
+	},
+	{
+		name:    "active_sessions",
+		indexes: []string{"idx_user_sessions_lookup", "idx_user_sessions_active"},
+		query:   `SELECT count(*) FROM user_sessions WHERE user_id = ? AND is_active = ? AND expires_at > ?`,
+		sample:  `SELECT user_id, true FROM user_sessions LIMIT 1`,
+		extra:   []interface{}{time.Now()},
+	},
+	{
+		name:    "rate_limit_lookup",
+		indexes: []string{"idx_rate_limits_lookup"},
+		query:   `SELECT * FROM rate_limits WHERE identifier = ? AND endpoint_type = ? LIMIT 1`,
+		sample:  `SELECT identifier, endpoint_type FROM rate_limits LIMIT 1`,
+	},
+	{
+		name:    "lesson_answers",
+		indexes: []string{"idx_user_question_answers_lookup"},
+		query:   `SELECT * FROM user_question_answers WHERE user_id = ? AND lesson_id = ?`,
+		sample:  `SELECT user_id, lesson_id FROM user_question_answers LIMIT 1`,
+	},
+	{
+		name:    "lesson_attempt",
+		indexes: []string{"idx_user_lesson_attempts_lookup"},
+		query:   `SELECT * FROM user_lesson_attempts WHERE user_id = ? AND lesson_id = ? LIMIT 1`,
+		sample:  `SELECT user_id, lesson_id FROM user_lesson_attempts LIMIT 1`,
+	},
+	{
+		name:    "progress_by_user",
+		indexes: []string{"idx_user_progresses_user_id"},
+		query:   `SELECT * FROM user_progresses WHERE user_id = ? LIMIT 1`,
+		sample:  `SELECT user_id FROM user_progresses LIMIT 1`,
+	},
+	{
+		name:    "leaderboard_all_time",
+		indexes: []string{"idx_user_progress_xp"},
+		query:   `SELECT * FROM user_progresses ORDER BY xp DESC LIMIT 50`,
+	},
+	{
+		name:    "leaderboard_weekly",
+		indexes: []string{"idx_user_progress_xp", "idx_user_progresses_updated_at"},
+		query:   `SELECT * FROM user_progresses WHERE updated_at >= ? ORDER BY xp DESC LIMIT 50`,
+		extra:   []interface{}{time.Now().AddDate(0, 0, -7)},
+	},
+	{
+		name:    "user_rank",
+		indexes: []string{"idx_user_progress_xp"},
+		query:   `SELECT count(*) FROM user_progresses WHERE xp > ?`,
+		sample:  `SELECT xp FROM user_progresses ORDER BY xp DESC OFFSET 100 LIMIT 1`,
+	},
+	{
+		name:    "auth_audit_by_user",
+		indexes: []string{"idx_auth_audit_user_time"},
+		query:   `SELECT * FROM auth_audit_logs WHERE user_id = ? ORDER BY timestamp DESC LIMIT 20`,
+		sample:  `SELECT user_id FROM auth_audit_logs WHERE user_id IS NOT NULL LIMIT 1`,
+	},
+	{
+		name:    "auth_audit_by_action",
+		indexes: []string{"idx_auth_audit_action_time"},
+		query:   `SELECT * FROM auth_audit_logs WHERE action = ? ORDER BY timestamp DESC LIMIT 20`,
+		extra:   []interface{}{"login"},
+	},
+	{
+		name:    "content_audit_by_entity",
+		indexes: []string{"idx_content_audit_entity_time"},
+		query:   `SELECT * FROM content_audit_logs WHERE entity_type = ? AND entity_id = ? ORDER BY created_at DESC LIMIT 20`,
+		sample:  `SELECT entity_type, entity_id FROM content_audit_logs LIMIT 1`,
+	},
+}
+
+func BenchmarkHotPathIndexes(b *testing.B) {
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		b.Skip("DATABASE_URL is not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		b.Fatalf("connect: %v", err)
+	}
+
+	for _, c := range indexBenchCases {
+		args, ok := sampleArgs(b, db, c)
+		if !ok {
+			b.Logf("%s: no sample rows, skipping", c.name)
+			continue
+		}
+
+		b.Run(c.name+"/with_index", func(b *testing.B) {
+			runIndexBench(b, db, c.query, args)
+		})
+
+		b.Run(c.name+"/without_index", func(b *testing.B) {
+			tx := db.Begin()
+			defer tx.Rollback()
+
+			for _, index := range c.indexes {
+				if err := tx.Exec("DROP INDEX IF EXISTS " + index).Error; err != nil {
+					b.Fatalf("drop %s: %v", index, err)
+				}
+			}
+			runIndexBench(b, tx, c.query, args)
+		})
+	}
+}
+
+func runIndexBench(b *testing.B, db *gorm.DB, query string, args []interface{}) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rows, err := db.Raw(query, args...).Rows()
+		if err != nil {
+			b.Fatalf("%s: %v", strings.Fields(query)[0], err)
+		}
+		for rows.Next() {
+		}
+		rows.Close()
+	}
+}
+
+func sampleArgs(b *testing.B, db *gorm.DB, c indexBenchCase) ([]interface{}, bool) {
+	if c.sample == "" {
+		return c.extra, true
+	}
+
+	rows, err := db.Raw(c.sample).Rows()
+	if err != nil {
+		b.Fatalf("%s: sample: %v", c.name, err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, false
+	}
+
+	columns, _ := rows.Columns()
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	if err := rows.Scan(pointers...); err != nil {
+		b.Fatalf("%s: sample: %v", c.name, err)
+	}
+
+	return append(values, c.extra...), true
+}
@@ -0,0 +1,252 @@
+package repositories
+
+//go:generate go run ../../tools/repomock -source interfaces.go -out mocks/repositories.go -import github.com/lac-hong-legacy/ven_api/services/repositories
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+)
+
+// The repository methods AuthService, UserService and ContentService use, so they can be
+// unit tested against the fakes in the mocks package instead of a database. Add a method
+// here when one of those services starts calling it and regenerate the mocks.
+
+// UserRepo stores accounts, sessions, security settings and support notes
+type UserRepo interface {
+	AdminDeleteUser(userID string) error
+	AdminGetUsers(page, limit int, search string) ([]model.User, int64, error)
+	AdminUpdateUser(userID string, updates map[string]interface{}) error
+	AdvanceOnboarding(userID, fromStep, toStep string) (bool, error)
+	ClaimSecurityDigest(userID string, dueBefore, sentAt time.Time, outbox ...*model.OutboxMessage) (bool, error)
+	ClaimTwoFactorStep(userID string, step int64) (bool, error)
+	CompleteAccountRecovery(req *model.AccountRecoveryRequest, hashedPassword, verificationCode string, outbox ...*model.OutboxMessage) (bool, error)
+	ConfirmEmailChange(req *model.EmailChangeRequest) error
+	ConsumeBackupCode(userID, codeHash string, outbox ...*model.OutboxMessage) (int, bool, error)
+	CountUserNotes(userIDs []string) (map[string]int, error)
+	CreateAccountRecoveryRequest(req *model.AccountRecoveryRequest, outbox ...*model.OutboxMessage) error
+	CreateAuthAuditLog(log dto.AuthAuditLog) error
+	CreateEmailChangeRequest(req *model.EmailChangeRequest, outbox ...*model.OutboxMessage) error
+	CreatePasswordResetCode(userID, code string, expiresAt time.Time, outbox ...*model.OutboxMessage) error
+	CreateTrustedDevice(device *model.TrustedDevice) error
+	CreateUser(userID string, req dto.RegisterRequest, verificationCode string, outbox ...*model.OutboxMessage) (*model.User, error)
+	CreateUserNote(note *model.UserNote) error
+	CreateUserSession(session dto.UserSession, outbox ...*model.OutboxMessage) (string, error)
+	DeactivateAllUserSessions(userID, exceptSessionID string) error
+	DeactivateIdleSessions(userID string, idleSince time.Time) (int64, error)
+	DeactivateSession(sessionID, userID string) error
+	DeleteUserNote(noteID string) error
+	DisableTwoFactor(userID string, outbox ...*model.OutboxMessage) error
+	EnableTwoFactor(userID string, backupCodeHashes []string, outbox ...*model.OutboxMessage) (bool, error)
+	FindCohortUserIDs(filter dto.AdminCohortFilter, limit int) ([]string, error)
+	GetAccountRecoveryByCancelToken(tokenHash string) (*model.AccountRecoveryRequest, error)
+	GetAccountRecoveryRequest(recoveryID string) (*model.AccountRecoveryRequest, error)
+	GetActiveSession(userID, tokenHash string) (*model.UserSession, error)
+	GetAuthAuditChain(fromSequence, toSequence int64, limit int) ([]model.AuthAuditLog, error)
+	GetEmailChangeByRevertToken(tokenHash string) (*model.EmailChangeRequest, error)
+	GetLatestAuthAuditCheckpoint() (*model.AuthAuditCheckpoint, error)
+	GetModerationFlag(id string) (*model.ModerationFlag, error)
+	GetModerationFlags(status string, page, limit int) ([]model.ModerationFlag, int64, error)
+	GetPasswordResetCode(code string) (*model.PasswordResetCode, error)
+	GetPendingEmailChange(userID string) (*model.EmailChangeRequest, error)
+	GetSecuritySettings(userID string) (*dto.SecuritySettings, error)
+	GetSessionByID(sessionID string) (*model.UserSession, error)
+	GetTrustedDevice(userID, deviceID string) (*model.TrustedDevice, error)
+	GetUser(userID string) (*model.User, error)
+	GetUserAccountRecoveries(userID string, limit int) ([]model.AccountRecoveryRequest, error)
+	GetUserActiveSessions(userID string) ([]model.UserSession, error)
+	GetUserAuditLogs(userID string, page, limit int) ([]model.AuthAuditLog, int64, error)
+	GetUserAuditLogsBetween(userID string, from, to time.Time, limit int) ([]model.AuthAuditLog, int64, error)
+	GetUserByEmail(email string) (*model.User, error)
+	GetUserByEmailOrUsername(emailOrUsername string) (*model.User, error)
+	GetUserByID(userID string) (*model.User, error)
+	GetUserByUsername(username string) (*model.User, error)
+	GetUserByVerificationCode(email, code string) (*model.User, error)
+	GetUserNote(userID, noteID string) (*model.UserNote, error)
+	GetUserNotes(userID, tag string, page, limit int) ([]model.UserNote, int64, error)
+	GetUserProfile(userID string) (*model.User, error)
+	GetUserSessions(userID string) ([]model.UserSession, error)
+	GetUserStats(userID string) (*dto.UserStats, error)
+	GetUserTrustedDevices(userID string) ([]model.TrustedDevice, error)
+	GetUsersByIDs(userIDs []string) ([]model.User, error)
+	GetUsersDueSecurityDigest(dueBefore time.Time, limit int) ([]model.User, error)
+	IncrementFailedAttempts(userID string) (int, error)
+	InvalidatePasswordResetCode(code string) error
+	IsEmailAvailable(email string) (bool, error)
+	LockAccount(userID string, lockUntil time.Time) error
+	RemoveTrustedDevice(userID, deviceID string) error
+	ReplaceBackupCodes(userID string, backupCodeHashes []string, outbox ...*model.OutboxMessage) error
+	ResetFailedAttempts(userID string) error
+	ResolveModerationFlags(flagID, userID, status, reviewerID, note string) (int64, error)
+	RevertEmailChange(req *model.EmailChangeRequest) error
+	SetTwoFactorSecret(userID, secret string) (bool, error)
+	UpdateAccountRecoveryStatus(req *model.AccountRecoveryRequest, from []string, outbox ...*model.OutboxMessage) (bool, error)
+	UpdateLastLogin(userID, ip string) error
+	UpdateSecuritySettings(userID string, settings dto.UpdateSecuritySettingsRequest) error
+	UpdateSessionLastUsed(sessionID string) error
+	UpdateSessionToken(sessionID, newTokenHash string) error
+	UpdateTrustedDevice(device *model.TrustedDevice) error
+	UpdateUserNote(note *model.UserNote) error
+	UpdateUserPassword(userID, hashedPassword string) error
+	UpdateUserProfile(userID string, updates map[string]interface{}) error
+	UpdateVerificationCode(userID, code string, outbox ...*model.OutboxMessage) error
+	VerifyUserEmail(userID string) error
+}
+
+// ContentRepo stores the lesson catalog: characters, lessons, timeline, eras and dynasties,
+// the glossary, translations and the content audit log
+type ContentRepo interface {
+	AddContentPopularity(stats []model.ContentPopularityStat) error
+	CharacterRelationExists(characterID, relatedCharacterID string) (bool, error)
+	CloseDisabledQuestion(id, status, reviewerID, note string, question json.RawMessage) (bool, error)
+	CountDynastyReferences(name string) (int64, error)
+	CountEraReferences(code string) (int64, error)
+	CountPendingQuestionReports(lessonID, questionID string) (int64, error)
+	CreateCharacter(character *model.Character) (*model.Character, error)
+	CreateCharacterRelation(relation *model.CharacterRelation) error
+	CreateContentAuditLog(auditLog *model.ContentAuditLog) error
+	CreateContentIncident(incident *model.ContentIncident) error
+	CreateDynasty(dynasty *model.Dynasty) error
+	CreateEra(era *model.Era) error
+	CreateGlossaryTerm(term *model.GlossaryTerm) error
+	CreateLesson(lesson *model.Lesson) (*model.Lesson, error)
+	CreateQuestionReport(report *model.QuestionReport) (bool, error)
+	DeactivateLesson(incident *model.ContentIncident) (bool, error)
+	DeleteCharacterRelation(relationID string) error
+	DeleteDynasty(dynastyID string) error
+	DeleteEra(code string) error
+	DeleteGlossaryTerm(termID string) error
+	DisableQuestion(disabled *model.DisabledQuestion) (bool, error)
+	GetAllGlossaryTerms() ([]model.GlossaryTerm, error)
+	GetApprovedTranslations(lessonID string) ([]model.LessonTranslation, error)
+	GetCharacter(id string) (*model.Character, error)
+	GetCharacterRelation(relationID string) (*model.CharacterRelation, error)
+	GetCharacterRelations(characterID string) ([]model.CharacterRelation, error)
+	GetCharactersByDynasty(dynasty string) ([]model.Character, error)
+	GetCharactersByIDs(ids []string) ([]model.Character, error)
+	GetCharactersByRarity(rarity string) ([]model.Character, error)
+	GetContentAuditLogs(entityType, entityID, adminID string, page, limit int) ([]model.ContentAuditLog, int64, error)
+	GetContentIncident(id string) (*model.ContentIncident, error)
+	GetContentIncidents(status, lessonID string, page, limit int) ([]model.ContentIncident, int64, error)
+	GetContentPopularityDays(entityType, entityID string, since time.Time) ([]model.ContentPopularityStat, error)
+	GetDisabledQuestion(id string) (*model.DisabledQuestion, error)
+	GetDisabledQuestions(status, lessonID string, page, limit int) ([]model.DisabledQuestion, int64, error)
+	GetDynasties() ([]model.Dynasty, error)
+	GetDynasty(dynastyID string) (*model.Dynasty, error)
+	GetEra(code string) (*model.Era, error)
+	GetEras() ([]model.Era, error)
+	GetGameConfig() (*model.GameConfig, error)
+	GetGlossaryTerm(termID string) (*model.GlossaryTerm, error)
+	GetGlossaryTermByTerm(term string) (*model.GlossaryTerm, error)
+	GetLesson(id string) (*model.Lesson, error)
+	GetLessonAuditSnapshots(lessonID string) ([]model.ContentAuditLog, error)
+	GetLessonTranslation(lessonID, locale string) (*model.LessonTranslation, error)
+	GetLessonVideoWatchStats(lessonID string) (*model.LessonVideoWatchStats, error)
+	GetLessonsByCharacter(characterID string) ([]model.Lesson, error)
+	GetLessonsByIDs(ids []string) ([]model.Lesson, error)
+	GetPublicLessons() ([]model.Lesson, error)
+	GetQuestionAnswerCounts(lessonID string) ([]model.QuestionAnswerCount, error)
+	GetQuestionReportCounts(lessonID string) ([]model.QuestionReportCount, error)
+	GetSearchSuggestionSources() ([]model.SearchSuggestionSource, error)
+	GetTimeline() ([]model.Timeline, error)
+	GetTrendingContent(entityType string, since time.Time, limit int) ([]model.TrendingContent, error)
+	GetUnratedLessons(page, limit int) ([]model.Lesson, int64, error)
+	ResolveContentIncident(id, resolution, resolverID, note string) (bool, error)
+	SearchContent(query, entityType, era, dynasty, rarity string, page, limit int) ([]model.SearchHit, int64, error)
+	SearchGlossaryTerms(query, era string, page, limit int) ([]model.GlossaryTerm, int64, error)
+	UpdateCharacterRelation(relation *model.CharacterRelation) error
+	UpdateContentIncidentNotified(id string, sessionsNotified int, cachesBroadcast bool) error
+	UpdateDynasty(dynasty *model.Dynasty, oldName string) error
+	UpdateEra(era *model.Era) error
+	UpdateGameConfig(config *model.GameConfig) error
+	UpdateGlossaryTerm(term *model.GlossaryTerm) error
+	UpdateLesson(lesson *model.Lesson) error
+	UpdateLessonQuestions(lessonID, baseVersion string, questions json.RawMessage) (bool, error)
+}
+
+// ProgressRepo stores learner state: progress, completions, XP and heart ledgers,
+// collections, bookmarks, lesson sessions and leaderboards
+type ProgressRepo interface {
+	CompleteLesson(completion *model.UserLessonCompletion, complete func(progress *model.UserProgress, firstCompletion bool) *model.ProgressChange) (bool, error)
+	CountCompletedLessons(userID string) (int64, error)
+	CountLessonCompletionsSince(userID string, since time.Time) (int64, error)
+	CountUnlockedCharacters(userID string) (int64, error)
+	CreateCompletionFlag(flag *model.CompletionFlag) error
+	CreateFavoriteCharacter(favorite *model.UserFavoriteCharacter) (bool, error)
+	CreateLeaderboardAnomaly(anomaly *model.LeaderboardAnomaly) error
+	CreateLessonBookmark(bookmark *model.UserLessonBookmark) (bool, error)
+	CreateLessonCompletion(completion *model.UserLessonCompletion) (bool, error)
+	CreateSpirit(spirit *model.Spirit) (*model.Spirit, error)
+	CreateUserCharacter(userCharacter *model.UserCharacter, outbox ...*model.OutboxMessage) (bool, error)
+	CreateUserProgress(progress *model.UserProgress) (*model.UserProgress, error)
+	CreateXPTransaction(txn *model.XPTransaction) error
+	DeleteFavoriteCharacter(userID, characterID string) (bool, error)
+	DeleteLessonBookmark(userID, lessonID string) (bool, error)
+	DeleteLessonSession(userID, lessonID string) error
+	GetAllTimeLeaderboard(limit int) ([]model.UserProgress, error)
+	GetCompletedLessonAttempts(userID string) ([]model.UserLessonAttempt, error)
+	GetCompletedLessonIDs(userID string) ([]string, error)
+	GetCompletionFlag(id string) (*model.CompletionFlag, error)
+	GetCompletionFlags(status string, page, limit int) ([]model.CompletionFlag, int64, error)
+	GetCompletionFlagsBetween(userID string, from, to time.Time) ([]model.CompletionFlag, error)
+	GetFavoriteCharacterIDs(userID string) ([]string, error)
+	GetHeartTransactions(userID string, page, limit int) ([]model.HeartTransaction, int64, error)
+	GetItemTransactions(userID string, page, limit int) ([]model.ItemTransaction, int64, error)
+	GetLastLessonCompletion(userID string) (*model.UserLessonCompletion, error)
+	GetLeaderboardAnomalies(status string, page, limit int) ([]model.LeaderboardAnomaly, int64, error)
+	GetLeaderboardAnomaly(id string) (*model.LeaderboardAnomaly, error)
+	GetLeaderboardEntry(leaderboardType, userID string, since time.Time) (*model.LeaderboardEntry, int, error)
+	GetLeaderboardProfiles(userIDs []string) ([]model.LeaderboardProfile, error)
+	GetLessonAidUses(userID, lessonID string, sessionStartedAt time.Time) ([]model.LessonAidUse, error)
+	GetLessonCompletionsBetween(userID string, from, to time.Time) ([]model.UserLessonCompletion, error)
+	GetLessonSession(userID, lessonID string) (*model.LessonSession, error)
+	GetLessonSessionUserIDs(lessonID string, activeSince time.Time) ([]string, error)
+	GetLessonVideoProgress(userID, lessonID string) (*model.LessonVideoProgress, error)
+	GetMonthlyLeaderboard(limit int) ([]model.UserProgress, error)
+	GetProgress(sessionID string) (*model.GuestProgress, error)
+	GetProgressUserIDs() ([]string, error)
+	GetRankedLeaderboard(leaderboardType string, since time.Time, limit int) ([]model.LeaderboardEntry, error)
+	GetSpiritsByUserIDs(userIDs []string) ([]model.Spirit, error)
+	GetUnlockedCharacterIDs(userID string) ([]string, error)
+	GetUserAchievements(userID string) ([]model.UserAchievement, error)
+	GetUserCharacters(userID string) ([]model.UserCharacter, error)
+	GetUserLessonBookmarks(userID string, page, limit int) ([]model.UserLessonBookmark, int64, error)
+	GetUserProgress(userID string) (*model.UserProgress, error)
+	GetUserQuestionAnswers(userID, lessonID string) ([]model.UserQuestionAnswer, error)
+	GetUserRank(userID string) (int, error)
+	GetUserSpirit(userID string) (*model.Spirit, error)
+	GetUsersForHeartReset(since time.Time) ([]model.UserProgress, error)
+	GetWeeklyLeaderboard(limit int) ([]model.UserProgress, error)
+	GetXPGainsAbove(since time.Time, threshold int) ([]model.XPGain, error)
+	GetXPLedgerMismatches() ([]model.XPLedgerMismatch, error)
+	GetXPTransactions(userID string, page, limit int) ([]model.XPTransaction, int64, error)
+	GetXPTransactionsBetween(userID string, from, to time.Time) ([]model.XPTransaction, error)
+	GetXPTransactionsBySource(userID, source string) ([]model.XPTransaction, error)
+	GrantUserItem(txn *model.ItemTransaction) (bool, error)
+	HasCompletedLesson(userID, lessonID string) (bool, error)
+	HasPendingLeaderboardAnomaly(userID string) (bool, error)
+	HasUserCharacter(userID, characterID string) (bool, error)
+	MarkCollectionViewed(userID string, viewedAt time.Time) error
+	MigrateSpirit(spirit *model.Spirit, userUpdates map[string]interface{}) error
+	ModifyUserProgress(userID string, modify func(progress *model.UserProgress) (*model.ProgressChange, error)) (*model.UserProgress, error)
+	RefreshLeaderboardProfile(userID string) error
+	ResetLessonSession(userID, lessonID string) error
+	ResolveCompletionFlags(flagID, userID, status, reviewerID, note string) (int64, error)
+	ResolveLeaderboardAnomalies(anomalyID, userID, status, reviewerID, note string, penaltyXP int) error
+	RevokeUserItem(txn *model.ItemTransaction) (bool, error)
+	SaveLeaderboardProfiles(profiles []model.LeaderboardProfile) error
+	SaveLessonSession(session *model.LessonSession) error
+	SaveLessonVideoProgress(progress *model.LessonVideoProgress) error
+	SaveUserQuestionAnswer(answer *model.UserQuestionAnswer) error
+	SumXPTransactions(userID string) (int, error)
+	UpdateSpirit(spirit *model.Spirit) error
+	UseLessonAid(use *model.LessonAidUse, limit, coinPrice int) (*model.LessonAidUse, bool, error)
+}
+
+var (
+	_ UserRepo     = (*UserRepository)(nil)
+	_ ContentRepo  = (*ContentRepository)(nil)
+	_ ProgressRepo = (*ContentRepository)(nil)
+)
@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared/ids"
+	"gorm.io/gorm"
+)
+
+type MaintenanceRepository struct {
+	BaseRepository
+}
+
+func NewMaintenanceRepository(db *gorm.DB) *MaintenanceRepository {
+	return &MaintenanceRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+func (ds *MaintenanceRepository) CreateMaintenanceWindow(window *model.MaintenanceWindow) error {
+	if window.ID == "" {
+		window.ID = ids.New()
+	}
+	return ds.db.Create(window).Error
+}
+
+func (ds *MaintenanceRepository) GetMaintenanceWindow(windowID string) (*model.MaintenanceWindow, error) {
+	var window model.MaintenanceWindow
+	if err := ds.db.Where("id = ?", windowID).First(&window).Error; err != nil {
+		return nil, err
+	}
+	return &window, nil
+}
+
+// GetMaintenanceWindows returns scheduled and active windows, soonest first, followed
+// by windows that ended or were cancelled after since
+func (ds *MaintenanceRepository) GetMaintenanceWindows(since time.Time) ([]model.MaintenanceWindow, error) {
+	var windows []model.MaintenanceWindow
+	err := ds.db.Where("status IN ? OR ends_at >= ?",
+		[]string{model.MaintenanceWindowScheduled, model.MaintenanceWindowActive}, since).
+		Order("starts_at").Find(&windows).Error
+	return windows, err
+}
+
+// GetDueMaintenanceWindows returns scheduled windows that should have started by now
+// and active windows that should have ended
+func (ds *MaintenanceRepository) GetDueMaintenanceWindows(now time.Time) ([]model.MaintenanceWindow, error) {
+	var windows []model.MaintenanceWindow
+	err := ds.db.Where("(status = ? AND starts_at <= ?) OR (status = ? AND ends_at <= ?)",
+		model.MaintenanceWindowScheduled, now, model.MaintenanceWindowActive, now).
+		Order("starts_at").Find(&windows).Error
+	return windows, err
+}
+
+// UpdateMaintenanceWindowStatus moves a window from one status to another. It reports
+// false if the window was no longer in the from status, e.g. another instance got there first.
+func (ds *MaintenanceRepository) UpdateMaintenanceWindowStatus(windowID, from, to string) (bool, error) {
+	result := ds.db.Model(&model.MaintenanceWindow{}).
+		Where("id = ? AND status = ?", windowID, from).
+		Updates(map[string]interface{}{"status": to, "updated_at": time.Now()})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (ds *MaintenanceRepository) MarkMaintenanceWindowAnnounced(windowID string, at time.Time) error {
+	return ds.db.Model(&model.MaintenanceWindow{}).Where("id = ?", windowID).
+		Update("announced_at", at).Error
+}
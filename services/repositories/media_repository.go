@@ -4,9 +4,11 @@ import (
 	"log"
 	"time"
 
-	"github.com/google/uuid"
+	"github.com/lac-hong-legacy/ven_api/dto"
 	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared/ids"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type MediaRepository struct {
@@ -21,8 +23,7 @@ func NewMediaRepository(db *gorm.DB) *MediaRepository {
 
 func (ds *MediaRepository) CreateMediaAsset(asset *model.MediaAsset) error {
 	if asset.ID == "" {
-		id, _ := uuid.NewV7()
-		asset.ID = id.String()
+		asset.ID = ids.New()
 	}
 	asset.CreatedAt = time.Now()
 	asset.UpdatedAt = time.Now()
@@ -54,6 +55,9 @@ func (ds *MediaRepository) DeleteMediaAsset(id string) error {
 	if err := ds.db.Where("media_asset_id = ?", id).Delete(&model.LessonMedia{}).Error; err != nil {
 		return err
 	}
+	if err := ds.db.Where("media_asset_id = ?", id).Delete(&model.QuestionMedia{}).Error; err != nil {
+		return err
+	}
 
 	// Delete the media asset
 	if err := ds.db.Where("id = ?", id).Delete(&model.MediaAsset{}).Error; err != nil {
@@ -78,12 +82,95 @@ func (ds *MediaRepository) GetUnprocessedMediaAssets() ([]model.MediaAsset, erro
 	return assets, nil
 }
 
+// ClaimMediaProcessing marks an asset as processing and counts the attempt. It reports
+// false when the asset is completed or another worker started on it after staleBefore.
+func (ds *MediaRepository) ClaimMediaProcessing(id string, staleBefore time.Time) (bool, error) {
+	now := time.Now()
+	result := ds.db.Model(&model.MediaAsset{}).
+		Where("id = ?", id).
+		Where("processing_status IN ? OR (processing_status = ? AND processing_started_at < ?)",
+			[]string{model.MediaProcessingPending, model.MediaProcessingFailed}, model.MediaProcessingRunning, staleBefore).
+		Updates(map[string]interface{}{
+			"processing_status":     model.MediaProcessingRunning,
+			"processing_attempts":   gorm.Expr("processing_attempts + 1"),
+			"processing_started_at": now,
+			"updated_at":            now,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// FinishMediaProcessing records the outcome of a processing attempt. An empty
+// processingError means it succeeded.
+func (ds *MediaRepository) FinishMediaProcessing(id, processingError string) error {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"processing_status": model.MediaProcessingCompleted,
+		"processing_error":  "",
+		"is_processed":      true,
+		"processed_at":      now,
+		"updated_at":        now,
+	}
+	if processingError != "" {
+		updates = map[string]interface{}{
+			"processing_status": model.MediaProcessingFailed,
+			"processing_error":  processingError,
+			"updated_at":        now,
+		}
+	}
+	return ds.db.Model(&model.MediaAsset{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// ListMediaAssets returns a filtered, paginated page of the media library, newest first.
+func (ds *MediaRepository) ListMediaAssets(query dto.MediaLibraryQuery) ([]model.MediaAsset, int64, error) {
+	var assets []model.MediaAsset
+	var total int64
+
+	db := ds.db.Model(&model.MediaAsset{})
+	if query.FileType != "" {
+		db = db.Where("file_type = ?", query.FileType)
+	}
+	if query.Processed != nil {
+		db = db.Where("is_processed = ?", *query.Processed)
+	}
+	if query.ProcessingStatus != "" {
+		db = db.Where("processing_status = ?", query.ProcessingStatus)
+	}
+	if query.Search != "" {
+		search := "%" + query.Search + "%"
+		db = db.Where("file_name ILIKE ? OR original_name ILIKE ?", search, search)
+	}
+	if query.LessonID != "" {
+		db = db.Where("id IN (?)", ds.db.Model(&model.LessonMedia{}).
+			Select("media_asset_id").
+			Where("lesson_id = ?", query.LessonID))
+	}
+	if query.Orphaned {
+		db = db.Where("id NOT IN (?)", ds.db.Model(&model.LessonMedia{}).
+			Select("media_asset_id").
+			Where("is_active = ?", true)).
+			Where("id NOT IN (?)", ds.db.Model(&model.QuestionMedia{}).Select("media_asset_id"))
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (query.Page - 1) * query.Limit
+	if err := db.Order("created_at DESC").
+		Limit(query.Limit).
+		Offset(offset).
+		Find(&assets).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return assets, total, nil
+}
+
 // ==================== LESSON MEDIA METHODS ====================
 
 func (ds *MediaRepository) CreateLessonMedia(lessonMedia *model.LessonMedia) error {
 	if lessonMedia.ID == "" {
-		id, _ := uuid.NewV7()
-		lessonMedia.ID = id.String()
+		lessonMedia.ID = ids.New()
 	}
 	lessonMedia.CreatedAt = time.Now()
 
@@ -113,6 +200,33 @@ func (ds *MediaRepository) GetLessonMediaByType(lessonID, mediaType string) (*mo
 	return &lessonMedia, nil
 }
 
+// GetLatestLessonMediaByType returns the most recently added active media of a type
+func (ds *MediaRepository) GetLatestLessonMediaByType(lessonID, mediaType string) (*model.LessonMedia, error) {
+	var lessonMedia model.LessonMedia
+	if err := ds.db.Where("lesson_id = ? AND media_type = ? AND is_active = ?", lessonID, mediaType, true).
+		Preload("MediaAsset").
+		Order("created_at DESC").
+		First(&lessonMedia).Error; err != nil {
+		return nil, err
+	}
+	return &lessonMedia, nil
+}
+
+// GetLessonMediaByAssetIDs loads every lesson link for the given assets in one query
+func (ds *MediaRepository) GetLessonMediaByAssetIDs(assetIDs []string) ([]model.LessonMedia, error) {
+	var lessonMedia []model.LessonMedia
+	if len(assetIDs) == 0 {
+		return lessonMedia, nil
+	}
+
+	if err := ds.db.Where("media_asset_id IN ?", assetIDs).
+		Order("created_at ASC").
+		Find(&lessonMedia).Error; err != nil {
+		return nil, err
+	}
+	return lessonMedia, nil
+}
+
 func (ds *MediaRepository) UpdateLessonMedia(lessonMedia *model.LessonMedia) error {
 	if err := ds.db.Save(lessonMedia).Error; err != nil {
 		return err
@@ -136,6 +250,75 @@ func (ds *MediaRepository) DeactivateLessonMediaByType(lessonID, mediaType strin
 	return nil
 }
 
+// ==================== QUESTION MEDIA ====================
+
+// SetQuestionMedia attaches an asset to a question, replacing any asset already in that role
+func (ds *MediaRepository) SetQuestionMedia(link *model.QuestionMedia) error {
+	if link.ID == "" {
+		link.ID = ids.New()
+	}
+	link.CreatedAt = time.Now()
+
+	return ds.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "lesson_id"}, {Name: "question_id"}, {Name: "role"}},
+		DoUpdates: clause.AssignmentColumns([]string{"media_asset_id", "created_at"}),
+	}).Create(link).Error
+}
+
+func (ds *MediaRepository) GetQuestionMedia(lessonID, questionID, role string) (*model.QuestionMedia, error) {
+	var link model.QuestionMedia
+	if err := ds.db.Where("lesson_id = ? AND question_id = ? AND role = ?", lessonID, questionID, role).
+		First(&link).Error; err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func (ds *MediaRepository) GetLessonQuestionMedia(lessonID string) ([]model.QuestionMedia, error) {
+	var links []model.QuestionMedia
+	if err := ds.db.Preload("MediaAsset").
+		Where("lesson_id = ?", lessonID).
+		Find(&links).Error; err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+func (ds *MediaRepository) DeleteQuestionMedia(id string) error {
+	return ds.db.Where("id = ?", id).Delete(&model.QuestionMedia{}).Error
+}
+
+// PruneQuestionMedia removes links to questions that no longer exist in their
+// lesson, or whose lesson is gone, and returns the asset IDs that were unlinked.
+// Questions disabled pending review keep their media.
+func (ds *MediaRepository) PruneQuestionMedia() ([]string, error) {
+	var assetIDs []string
+	err := ds.db.Raw(`
+		DELETE FROM question_media m
+		WHERE NOT EXISTS (
+			SELECT 1 FROM lessons l, jsonb_array_elements(COALESCE(l.questions, '[]'::jsonb)) q
+			WHERE l.id = m.lesson_id AND q->>'id' = m.question_id
+		) AND NOT EXISTS (
+			SELECT 1 FROM disabled_questions d
+			WHERE d.lesson_id = m.lesson_id AND d.question_id = m.question_id AND d.status = 'pending'
+		)
+		RETURNING m.media_asset_id
+	`).Scan(&assetIDs).Error
+	return assetIDs, err
+}
+
+// IsMediaAssetReferenced reports whether any lesson or question still uses an asset
+func (ds *MediaRepository) IsMediaAssetReferenced(assetID string) (bool, error) {
+	var lessonRefs, questionRefs int64
+	if err := ds.db.Model(&model.LessonMedia{}).Where("media_asset_id = ?", assetID).Count(&lessonRefs).Error; err != nil {
+		return false, err
+	}
+	if err := ds.db.Model(&model.QuestionMedia{}).Where("media_asset_id = ?", assetID).Count(&questionRefs).Error; err != nil {
+		return false, err
+	}
+	return lessonRefs+questionRefs > 0, nil
+}
+
 func (ds *MediaRepository) GetMediaStatistics() (map[string]interface{}, error) {
 	stats := make(map[string]interface{})
 
@@ -193,8 +376,81 @@ func (ds *MediaRepository) GetLessonsWithoutMedia() ([]model.Lesson, error) {
 	return lessons, nil
 }
 
+// ==================== UPLOAD SESSION METHODS ====================
+
+func (ds *MediaRepository) CreateUploadSession(session *model.MediaUploadSession) error {
+	if session.ID == "" {
+		session.ID = ids.New()
+	}
+	session.CreatedAt = time.Now()
+	session.UpdatedAt = time.Now()
+
+	return ds.db.Create(session).Error
+}
+
+func (ds *MediaRepository) GetUploadSession(id string) (*model.MediaUploadSession, error) {
+	var session model.MediaUploadSession
+	if err := ds.db.Where("id = ?", id).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// AdvanceUploadSession saves a received chunk only if no other request has moved the
+// offset in the meantime. It reports false when the expected offset no longer matches.
+func (ds *MediaRepository) AdvanceUploadSession(session *model.MediaUploadSession, expectedOffset int64) (bool, error) {
+	session.UpdatedAt = time.Now()
+
+	result := ds.db.Model(&model.MediaUploadSession{}).
+		Where("id = ? AND \"offset\" = ? AND status = ?", session.ID, expectedOffset, model.UploadStatusPending).
+		Updates(map[string]interface{}{
+			"offset":     session.Offset,
+			"parts":      session.Parts,
+			"hash_state": session.HashState,
+			"updated_at": session.UpdatedAt,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (ds *MediaRepository) UpdateUploadSession(session *model.MediaUploadSession) error {
+	session.UpdatedAt = time.Now()
+	return ds.db.Save(session).Error
+}
+
+func (ds *MediaRepository) GetExpiredUploadSessions(now time.Time) ([]model.MediaUploadSession, error) {
+	var sessions []model.MediaUploadSession
+	if err := ds.db.Where("status = ? AND expires_at < ?", model.UploadStatusPending, now).
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
 // ==================== BULK OPERATIONS ====================
 
+// GetUploadedBytesSince sums what an admin has stored since the given time, counting
+// pending resumable uploads at their declared size so quotas can't be dodged by chunking.
+func (ds *MediaRepository) GetUploadedBytesSince(adminID string, since time.Time) (int64, error) {
+	var assetBytes int64
+	if err := ds.db.Model(&model.MediaAsset{}).
+		Where("uploaded_by = ? AND created_at >= ?", adminID, since).
+		Select("COALESCE(SUM(file_size), 0)").Scan(&assetBytes).Error; err != nil {
+		return 0, err
+	}
+
+	var pendingBytes int64
+	if err := ds.db.Model(&model.MediaUploadSession{}).
+		Where("admin_id = ? AND status = ? AND created_at >= ?", adminID, model.UploadStatusPending, since).
+		Select("COALESCE(SUM(total_size), 0)").Scan(&pendingBytes).Error; err != nil {
+		return 0, err
+	}
+
+	return assetBytes + pendingBytes, nil
+}
+
 func (ds *MediaRepository) BulkCreateMediaAssets(assets []model.MediaAsset) error {
 	if len(assets) == 0 {
 		return nil
@@ -204,8 +460,7 @@ func (ds *MediaRepository) BulkCreateMediaAssets(assets []model.MediaAsset) erro
 	now := time.Now()
 	for i := range assets {
 		if assets[i].ID == "" {
-			id, _ := uuid.NewV7()
-			assets[i].ID = id.String()
+			assets[i].ID = ids.New()
 		}
 		assets[i].CreatedAt = now
 		assets[i].UpdatedAt = now
@@ -226,8 +481,7 @@ func (ds *MediaRepository) BulkCreateLessonMedia(lessonMedia []model.LessonMedia
 	now := time.Now()
 	for i := range lessonMedia {
 		if lessonMedia[i].ID == "" {
-			id, _ := uuid.NewV7()
-			lessonMedia[i].ID = id.String()
+			lessonMedia[i].ID = ids.New()
 		}
 		lessonMedia[i].CreatedAt = now
 	}
@@ -273,3 +527,34 @@ func (ds *MediaRepository) GetLessonsWithMediaByCharacter(characterID string) ([
 
 	return lessons, mediaMap, nil
 }
+
+// ==================== CDN PREWARM METHODS ====================
+
+func (ds *MediaRepository) CreateCDNPrewarmJob(job *model.CDNPrewarmJob) error {
+	if job.ID == "" {
+		job.ID = ids.New()
+	}
+	return ds.db.Create(job).Error
+}
+
+func (ds *MediaRepository) UpdateCDNPrewarmJob(job *model.CDNPrewarmJob) error {
+	return ds.db.Save(job).Error
+}
+
+func (ds *MediaRepository) GetCDNPrewarmJob(id string) (*model.CDNPrewarmJob, error) {
+	var job model.CDNPrewarmJob
+	if err := ds.db.Where("id = ?", id).First(&job).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// GetLessonCDNPrewarmJobs returns a lesson's most recent prewarm jobs, newest first
+func (ds *MediaRepository) GetLessonCDNPrewarmJobs(lessonID string, limit int) ([]model.CDNPrewarmJob, error) {
+	var jobs []model.CDNPrewarmJob
+	err := ds.db.Where("lesson_id = ?", lessonID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&jobs).Error
+	return jobs, err
+}
@@ -0,0 +1,1766 @@
+// Code generated by repomock from interfaces.go. DO NOT EDIT.
+
+package mocks
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/services/repositories"
+)
+
+// UserRepo is a fake repositories.UserRepo
+type UserRepo struct {
+	AdminDeleteUserFunc                 func(userID string) error
+	AdminGetUsersFunc                   func(page, limit int, search string) ([]model.User, int64, error)
+	AdminUpdateUserFunc                 func(userID string, updates map[string]interface{}) error
+	AdvanceOnboardingFunc               func(userID, fromStep, toStep string) (bool, error)
+	ClaimSecurityDigestFunc             func(userID string, dueBefore, sentAt time.Time, outbox ...*model.OutboxMessage) (bool, error)
+	ClaimTwoFactorStepFunc              func(userID string, step int64) (bool, error)
+	CompleteAccountRecoveryFunc         func(req *model.AccountRecoveryRequest, hashedPassword, verificationCode string, outbox ...*model.OutboxMessage) (bool, error)
+	ConfirmEmailChangeFunc              func(req *model.EmailChangeRequest) error
+	ConsumeBackupCodeFunc               func(userID, codeHash string, outbox ...*model.OutboxMessage) (int, bool, error)
+	CountUserNotesFunc                  func(userIDs []string) (map[string]int, error)
+	CreateAccountRecoveryRequestFunc    func(req *model.AccountRecoveryRequest, outbox ...*model.OutboxMessage) error
+	CreateAuthAuditLogFunc              func(log dto.AuthAuditLog) error
+	CreateEmailChangeRequestFunc        func(req *model.EmailChangeRequest, outbox ...*model.OutboxMessage) error
+	CreatePasswordResetCodeFunc         func(userID, code string, expiresAt time.Time, outbox ...*model.OutboxMessage) error
+	CreateTrustedDeviceFunc             func(device *model.TrustedDevice) error
+	CreateUserFunc                      func(userID string, req dto.RegisterRequest, verificationCode string, outbox ...*model.OutboxMessage) (*model.User, error)
+	CreateUserNoteFunc                  func(note *model.UserNote) error
+	CreateUserSessionFunc               func(session dto.UserSession, outbox ...*model.OutboxMessage) (string, error)
+	DeactivateAllUserSessionsFunc       func(userID, exceptSessionID string) error
+	DeactivateIdleSessionsFunc          func(userID string, idleSince time.Time) (int64, error)
+	DeactivateSessionFunc               func(sessionID, userID string) error
+	DeleteUserNoteFunc                  func(noteID string) error
+	DisableTwoFactorFunc                func(userID string, outbox ...*model.OutboxMessage) error
+	EnableTwoFactorFunc                 func(userID string, backupCodeHashes []string, outbox ...*model.OutboxMessage) (bool, error)
+	FindCohortUserIDsFunc               func(filter dto.AdminCohortFilter, limit int) ([]string, error)
+	GetAccountRecoveryByCancelTokenFunc func(tokenHash string) (*model.AccountRecoveryRequest, error)
+	GetAccountRecoveryRequestFunc       func(recoveryID string) (*model.AccountRecoveryRequest, error)
+	GetActiveSessionFunc                func(userID, tokenHash string) (*model.UserSession, error)
+	GetAuthAuditChainFunc               func(fromSequence, toSequence int64, limit int) ([]model.AuthAuditLog, error)
+	GetEmailChangeByRevertTokenFunc     func(tokenHash string) (*model.EmailChangeRequest, error)
+	GetLatestAuthAuditCheckpointFunc    func() (*model.AuthAuditCheckpoint, error)
+	GetModerationFlagFunc               func(id string) (*model.ModerationFlag, error)
+	GetModerationFlagsFunc              func(status string, page, limit int) ([]model.ModerationFlag, int64, error)
+	GetPasswordResetCodeFunc            func(code string) (*model.PasswordResetCode, error)
+	GetPendingEmailChangeFunc           func(userID string) (*model.EmailChangeRequest, error)
+	GetSecuritySettingsFunc             func(userID string) (*dto.SecuritySettings, error)
+	GetSessionByIDFunc                  func(sessionID string) (*model.UserSession, error)
+	GetTrustedDeviceFunc                func(userID, deviceID string) (*model.TrustedDevice, error)
+	GetUserFunc                         func(userID string) (*model.User, error)
+	GetUserAccountRecoveriesFunc        func(userID string, limit int) ([]model.AccountRecoveryRequest, error)
+	GetUserActiveSessionsFunc           func(userID string) ([]model.UserSession, error)
+	GetUserAuditLogsFunc                func(userID string, page, limit int) ([]model.AuthAuditLog, int64, error)
+	GetUserAuditLogsBetweenFunc         func(userID string, from, to time.Time, limit int) ([]model.AuthAuditLog, int64, error)
+	GetUserByEmailFunc                  func(email string) (*model.User, error)
+	GetUserByEmailOrUsernameFunc        func(emailOrUsername string) (*model.User, error)
+	GetUserByIDFunc                     func(userID string) (*model.User, error)
+	GetUserByUsernameFunc               func(username string) (*model.User, error)
+	GetUserByVerificationCodeFunc       func(email, code string) (*model.User, error)
+	GetUserNoteFunc                     func(userID, noteID string) (*model.UserNote, error)
+	GetUserNotesFunc                    func(userID, tag string, page, limit int) ([]model.UserNote, int64, error)
+	GetUserProfileFunc                  func(userID string) (*model.User, error)
+	GetUserSessionsFunc                 func(userID string) ([]model.UserSession, error)
+	GetUserStatsFunc                    func(userID string) (*dto.UserStats, error)
+	GetUserTrustedDevicesFunc           func(userID string) ([]model.TrustedDevice, error)
+	GetUsersByIDsFunc                   func(userIDs []string) ([]model.User, error)
+	GetUsersDueSecurityDigestFunc       func(dueBefore time.Time, limit int) ([]model.User, error)
+	IncrementFailedAttemptsFunc         func(userID string) (int, error)
+	InvalidatePasswordResetCodeFunc     func(code string) error
+	IsEmailAvailableFunc                func(email string) (bool, error)
+	LockAccountFunc                     func(userID string, lockUntil time.Time) error
+	RemoveTrustedDeviceFunc             func(userID, deviceID string) error
+	ReplaceBackupCodesFunc              func(userID string, backupCodeHashes []string, outbox ...*model.OutboxMessage) error
+	ResetFailedAttemptsFunc             func(userID string) error
+	ResolveModerationFlagsFunc          func(flagID, userID, status, reviewerID, note string) (int64, error)
+	RevertEmailChangeFunc               func(req *model.EmailChangeRequest) error
+	SetTwoFactorSecretFunc              func(userID, secret string) (bool, error)
+	UpdateAccountRecoveryStatusFunc     func(req *model.AccountRecoveryRequest, from []string, outbox ...*model.OutboxMessage) (bool, error)
+	UpdateLastLoginFunc                 func(userID, ip string) error
+	UpdateSecuritySettingsFunc          func(userID string, settings dto.UpdateSecuritySettingsRequest) error
+	UpdateSessionLastUsedFunc           func(sessionID string) error
+	UpdateSessionTokenFunc              func(sessionID, newTokenHash string) error
+	UpdateTrustedDeviceFunc             func(device *model.TrustedDevice) error
+	UpdateUserNoteFunc                  func(note *model.UserNote) error
+	UpdateUserPasswordFunc              func(userID, hashedPassword string) error
+	UpdateUserProfileFunc               func(userID string, updates map[string]interface{}) error
+	UpdateVerificationCodeFunc          func(userID, code string, outbox ...*model.OutboxMessage) error
+	VerifyUserEmailFunc                 func(userID string) error
+}
+
+var _ repositories.UserRepo = (*UserRepo)(nil)
+
+func (m *UserRepo) AdminDeleteUser(userID string) error {
+	if m.AdminDeleteUserFunc == nil {
+		panic("UserRepo.AdminDeleteUser called but AdminDeleteUserFunc is not set")
+	}
+	return m.AdminDeleteUserFunc(userID)
+}
+
+func (m *UserRepo) AdminGetUsers(page, limit int, search string) ([]model.User, int64, error) {
+	if m.AdminGetUsersFunc == nil {
+		panic("UserRepo.AdminGetUsers called but AdminGetUsersFunc is not set")
+	}
+	return m.AdminGetUsersFunc(page, limit, search)
+}
+
+func (m *UserRepo) AdminUpdateUser(userID string, updates map[string]interface{}) error {
+	if m.AdminUpdateUserFunc == nil {
+		panic("UserRepo.AdminUpdateUser called but AdminUpdateUserFunc is not set")
+	}
+	return m.AdminUpdateUserFunc(userID, updates)
+}
+
+func (m *UserRepo) AdvanceOnboarding(userID, fromStep, toStep string) (bool, error) {
+	if m.AdvanceOnboardingFunc == nil {
+		panic("UserRepo.AdvanceOnboarding called but AdvanceOnboardingFunc is not set")
+	}
+	return m.AdvanceOnboardingFunc(userID, fromStep, toStep)
+}
+
+func (m *UserRepo) ClaimSecurityDigest(userID string, dueBefore, sentAt time.Time, outbox ...*model.OutboxMessage) (bool, error) {
+	if m.ClaimSecurityDigestFunc == nil {
+		panic("UserRepo.ClaimSecurityDigest called but ClaimSecurityDigestFunc is not set")
+	}
+	return m.ClaimSecurityDigestFunc(userID, dueBefore, sentAt, outbox...)
+}
+
+func (m *UserRepo) ClaimTwoFactorStep(userID string, step int64) (bool, error) {
+	if m.ClaimTwoFactorStepFunc == nil {
+		panic("UserRepo.ClaimTwoFactorStep called but ClaimTwoFactorStepFunc is not set")
+	}
+	return m.ClaimTwoFactorStepFunc(userID, step)
+}
+
+func (m *UserRepo) CompleteAccountRecovery(req *model.AccountRecoveryRequest, hashedPassword, verificationCode string, outbox ...*model.OutboxMessage) (bool, error) {
+	if m.CompleteAccountRecoveryFunc == nil {
+		panic("UserRepo.CompleteAccountRecovery called but CompleteAccountRecoveryFunc is not set")
+	}
+	return m.CompleteAccountRecoveryFunc(req, hashedPassword, verificationCode, outbox...)
+}
+
+func (m *UserRepo) ConfirmEmailChange(req *model.EmailChangeRequest) error {
+	if m.ConfirmEmailChangeFunc == nil {
+		panic("UserRepo.ConfirmEmailChange called but ConfirmEmailChangeFunc is not set")
+	}
+	return m.ConfirmEmailChangeFunc(req)
+}
+
+func (m *UserRepo) ConsumeBackupCode(userID, codeHash string, outbox ...*model.OutboxMessage) (int, bool, error) {
+	if m.ConsumeBackupCodeFunc == nil {
+		panic("UserRepo.ConsumeBackupCode called but ConsumeBackupCodeFunc is not set")
+	}
+	return m.ConsumeBackupCodeFunc(userID, codeHash, outbox...)
+}
+
+func (m *UserRepo) CountUserNotes(userIDs []string) (map[string]int, error) {
+	if m.CountUserNotesFunc == nil {
+		panic("UserRepo.CountUserNotes called but CountUserNotesFunc is not set")
+	}
+	return m.CountUserNotesFunc(userIDs)
+}
+
+func (m *UserRepo) CreateAccountRecoveryRequest(req *model.AccountRecoveryRequest, outbox ...*model.OutboxMessage) error {
+	if m.CreateAccountRecoveryRequestFunc == nil {
+		panic("UserRepo.CreateAccountRecoveryRequest called but CreateAccountRecoveryRequestFunc is not set")
+	}
+	return m.CreateAccountRecoveryRequestFunc(req, outbox...)
+}
+
+func (m *UserRepo) CreateAuthAuditLog(log dto.AuthAuditLog) error {
+	if m.CreateAuthAuditLogFunc == nil {
+		panic("UserRepo.CreateAuthAuditLog called but CreateAuthAuditLogFunc is not set")
+	}
+	return m.CreateAuthAuditLogFunc(log)
+}
+
+func (m *UserRepo) CreateEmailChangeRequest(req *model.EmailChangeRequest, outbox ...*model.OutboxMessage) error {
+	if m.CreateEmailChangeRequestFunc == nil {
+		panic("UserRepo.CreateEmailChangeRequest called but CreateEmailChangeRequestFunc is not set")
+	}
+	return m.CreateEmailChangeRequestFunc(req, outbox...)
+}
+
+func (m *UserRepo) CreatePasswordResetCode(userID, code string, expiresAt time.Time, outbox ...*model.OutboxMessage) error {
+	if m.CreatePasswordResetCodeFunc == nil {
+		panic("UserRepo.CreatePasswordResetCode called but CreatePasswordResetCodeFunc is not set")
+	}
+	return m.CreatePasswordResetCodeFunc(userID, code, expiresAt, outbox...)
+}
+
+func (m *UserRepo) CreateTrustedDevice(device *model.TrustedDevice) error {
+	if m.CreateTrustedDeviceFunc == nil {
+		panic("UserRepo.CreateTrustedDevice called but CreateTrustedDeviceFunc is not set")
+	}
+	return m.CreateTrustedDeviceFunc(device)
+}
+
+func (m *UserRepo) CreateUser(userID string, req dto.RegisterRequest, verificationCode string, outbox ...*model.OutboxMessage) (*model.User, error) {
+	if m.CreateUserFunc == nil {
+		panic("UserRepo.CreateUser called but CreateUserFunc is not set")
+	}
+	return m.CreateUserFunc(userID, req, verificationCode, outbox...)
+}
+
+func (m *UserRepo) CreateUserNote(note *model.UserNote) error {
+	if m.CreateUserNoteFunc == nil {
+		panic("UserRepo.CreateUserNote called but CreateUserNoteFunc is not set")
+	}
+	return m.CreateUserNoteFunc(note)
+}
+
+func (m *UserRepo) CreateUserSession(session dto.UserSession, outbox ...*model.OutboxMessage) (string, error) {
+	if m.CreateUserSessionFunc == nil {
+		panic("UserRepo.CreateUserSession called but CreateUserSessionFunc is not set")
+	}
+	return m.CreateUserSessionFunc(session, outbox...)
+}
+
+func (m *UserRepo) DeactivateAllUserSessions(userID, exceptSessionID string) error {
+	if m.DeactivateAllUserSessionsFunc == nil {
+		panic("UserRepo.DeactivateAllUserSessions called but DeactivateAllUserSessionsFunc is not set")
+	}
+	return m.DeactivateAllUserSessionsFunc(userID, exceptSessionID)
+}
+
+func (m *UserRepo) DeactivateIdleSessions(userID string, idleSince time.Time) (int64, error) {
+	if m.DeactivateIdleSessionsFunc == nil {
+		panic("UserRepo.DeactivateIdleSessions called but DeactivateIdleSessionsFunc is not set")
+	}
+	return m.DeactivateIdleSessionsFunc(userID, idleSince)
+}
+
+func (m *UserRepo) DeactivateSession(sessionID, userID string) error {
+	if m.DeactivateSessionFunc == nil {
+		panic("UserRepo.DeactivateSession called but DeactivateSessionFunc is not set")
+	}
+	return m.DeactivateSessionFunc(sessionID, userID)
+}
+
+func (m *UserRepo) DeleteUserNote(noteID string) error {
+	if m.DeleteUserNoteFunc == nil {
+		panic("UserRepo.DeleteUserNote called but DeleteUserNoteFunc is not set")
+	}
+	return m.DeleteUserNoteFunc(noteID)
+}
+
+func (m *UserRepo) DisableTwoFactor(userID string, outbox ...*model.OutboxMessage) error {
+	if m.DisableTwoFactorFunc == nil {
+		panic("UserRepo.DisableTwoFactor called but DisableTwoFactorFunc is not set")
+	}
+	return m.DisableTwoFactorFunc(userID, outbox...)
+}
+
+func (m *UserRepo) EnableTwoFactor(userID string, backupCodeHashes []string, outbox ...*model.OutboxMessage) (bool, error) {
+	if m.EnableTwoFactorFunc == nil {
+		panic("UserRepo.EnableTwoFactor called but EnableTwoFactorFunc is not set")
+	}
+	return m.EnableTwoFactorFunc(userID, backupCodeHashes, outbox...)
+}
+
+func (m *UserRepo) FindCohortUserIDs(filter dto.AdminCohortFilter, limit int) ([]string, error) {
+	if m.FindCohortUserIDsFunc == nil {
+		panic("UserRepo.FindCohortUserIDs called but FindCohortUserIDsFunc is not set")
+	}
+	return m.FindCohortUserIDsFunc(filter, limit)
+}
+
+func (m *UserRepo) GetAccountRecoveryByCancelToken(tokenHash string) (*model.AccountRecoveryRequest, error) {
+	if m.GetAccountRecoveryByCancelTokenFunc == nil {
+		panic("UserRepo.GetAccountRecoveryByCancelToken called but GetAccountRecoveryByCancelTokenFunc is not set")
+	}
+	return m.GetAccountRecoveryByCancelTokenFunc(tokenHash)
+}
+
+func (m *UserRepo) GetAccountRecoveryRequest(recoveryID string) (*model.AccountRecoveryRequest, error) {
+	if m.GetAccountRecoveryRequestFunc == nil {
+		panic("UserRepo.GetAccountRecoveryRequest called but GetAccountRecoveryRequestFunc is not set")
+	}
+	return m.GetAccountRecoveryRequestFunc(recoveryID)
+}
+
+func (m *UserRepo) GetActiveSession(userID, tokenHash string) (*model.UserSession, error) {
+	if m.GetActiveSessionFunc == nil {
+		panic("UserRepo.GetActiveSession called but GetActiveSessionFunc is not set")
+	}
+	return m.GetActiveSessionFunc(userID, tokenHash)
+}
+
+func (m *UserRepo) GetAuthAuditChain(fromSequence, toSequence int64, limit int) ([]model.AuthAuditLog, error) {
+	if m.GetAuthAuditChainFunc == nil {
+		panic("UserRepo.GetAuthAuditChain called but GetAuthAuditChainFunc is not set")
+	}
+	return m.GetAuthAuditChainFunc(fromSequence, toSequence, limit)
+}
+
+func (m *UserRepo) GetEmailChangeByRevertToken(tokenHash string) (*model.EmailChangeRequest, error) {
+	if m.GetEmailChangeByRevertTokenFunc == nil {
+		panic("UserRepo.GetEmailChangeByRevertToken called but GetEmailChangeByRevertTokenFunc is not set")
+	}
+	return m.GetEmailChangeByRevertTokenFunc(tokenHash)
+}
+
+func (m *UserRepo) GetLatestAuthAuditCheckpoint() (*model.AuthAuditCheckpoint, error) {
+	if m.GetLatestAuthAuditCheckpointFunc == nil {
+		panic("UserRepo.GetLatestAuthAuditCheckpoint called but GetLatestAuthAuditCheckpointFunc is not set")
+	}
+	return m.GetLatestAuthAuditCheckpointFunc()
+}
+
+func (m *UserRepo) GetModerationFlag(id string) (*model.ModerationFlag, error) {
+	if m.GetModerationFlagFunc == nil {
+		panic("UserRepo.GetModerationFlag called but GetModerationFlagFunc is not set")
+	}
+	return m.GetModerationFlagFunc(id)
+}
+
+func (m *UserRepo) GetModerationFlags(status string, page, limit int) ([]model.ModerationFlag, int64, error) {
+	if m.GetModerationFlagsFunc == nil {
+		panic("UserRepo.GetModerationFlags called but GetModerationFlagsFunc is not set")
+	}
+	return m.GetModerationFlagsFunc(status, page, limit)
+}
+
+func (m *UserRepo) GetPasswordResetCode(code string) (*model.PasswordResetCode, error) {
+	if m.GetPasswordResetCodeFunc == nil {
+		panic("UserRepo.GetPasswordResetCode called but GetPasswordResetCodeFunc is not set")
+	}
+	return m.GetPasswordResetCodeFunc(code)
+}
+
+func (m *UserRepo) GetPendingEmailChange(userID string) (*model.EmailChangeRequest, error) {
+	if m.GetPendingEmailChangeFunc == nil {
+		panic("UserRepo.GetPendingEmailChange called but GetPendingEmailChangeFunc is not set")
+	}
+	return m.GetPendingEmailChangeFunc(userID)
+}
+
+func (m *UserRepo) GetSecuritySettings(userID string) (*dto.SecuritySettings, error) {
+	if m.GetSecuritySettingsFunc == nil {
+		panic("UserRepo.GetSecuritySettings called but GetSecuritySettingsFunc is not set")
+	}
+	return m.GetSecuritySettingsFunc(userID)
+}
+
+func (m *UserRepo) GetSessionByID(sessionID string) (*model.UserSession, error) {
+	if m.GetSessionByIDFunc == nil {
+		panic("UserRepo.GetSessionByID called but GetSessionByIDFunc is not set")
+	}
+	return m.GetSessionByIDFunc(sessionID)
+}
+
+func (m *UserRepo) GetTrustedDevice(userID, deviceID string) (*model.TrustedDevice, error) {
+	if m.GetTrustedDeviceFunc == nil {
+		panic("UserRepo.GetTrustedDevice called but GetTrustedDeviceFunc is not set")
+	}
+	return m.GetTrustedDeviceFunc(userID, deviceID)
+}
+
+func (m *UserRepo) GetUser(userID string) (*model.User, error) {
+	if m.GetUserFunc == nil {
+		panic("UserRepo.GetUser called but GetUserFunc is not set")
+	}
+	return m.GetUserFunc(userID)
+}
+
+func (m *UserRepo) GetUserAccountRecoveries(userID string, limit int) ([]model.AccountRecoveryRequest, error) {
+	if m.GetUserAccountRecoveriesFunc == nil {
+		panic("UserRepo.GetUserAccountRecoveries called but GetUserAccountRecoveriesFunc is not set")
+	}
+	return m.GetUserAccountRecoveriesFunc(userID, limit)
+}
+
+func (m *UserRepo) GetUserActiveSessions(userID string) ([]model.UserSession, error) {
+	if m.GetUserActiveSessionsFunc == nil {
+		panic("UserRepo.GetUserActiveSessions called but GetUserActiveSessionsFunc is not set")
+	}
+	return m.GetUserActiveSessionsFunc(userID)
+}
+
+func (m *UserRepo) GetUserAuditLogs(userID string, page, limit int) ([]model.AuthAuditLog, int64, error) {
+	if m.GetUserAuditLogsFunc == nil {
+		panic("UserRepo.GetUserAuditLogs called but GetUserAuditLogsFunc is not set")
+	}
+	return m.GetUserAuditLogsFunc(userID, page, limit)
+}
+
+func (m *UserRepo) GetUserAuditLogsBetween(userID string, from, to time.Time, limit int) ([]model.AuthAuditLog, int64, error) {
+	if m.GetUserAuditLogsBetweenFunc == nil {
+		panic("UserRepo.GetUserAuditLogsBetween called but GetUserAuditLogsBetweenFunc is not set")
+	}
+	return m.GetUserAuditLogsBetweenFunc(userID, from, to, limit)
+}
+
+func (m *UserRepo) GetUserByEmail(email string) (*model.User, error) {
+	if m.GetUserByEmailFunc == nil {
+		panic("UserRepo.GetUserByEmail called but GetUserByEmailFunc is not set")
+	}
+	return m.GetUserByEmailFunc(email)
+}
+
+func (m *UserRepo) GetUserByEmailOrUsername(emailOrUsername string) (*model.User, error) {
+	if m.GetUserByEmailOrUsernameFunc == nil {
+		panic("UserRepo.GetUserByEmailOrUsername called but GetUserByEmailOrUsernameFunc is not set")
+	}
+	return m.GetUserByEmailOrUsernameFunc(emailOrUsername)
+}
+
+func (m *UserRepo) GetUserByID(userID string) (*model.User, error) {
+	if m.GetUserByIDFunc == nil {
+		panic("UserRepo.GetUserByID called but GetUserByIDFunc is not set")
+	}
+	return m.GetUserByIDFunc(userID)
+}
+
+func (m *UserRepo) GetUserByUsername(username string) (*model.User, error) {
+	if m.GetUserByUsernameFunc == nil {
+		panic("UserRepo.GetUserByUsername called but GetUserByUsernameFunc is not set")
+	}
+	return m.GetUserByUsernameFunc(username)
+}
+
+func (m *UserRepo) GetUserByVerificationCode(email, code string) (*model.User, error) {
+	if m.GetUserByVerificationCodeFunc == nil {
+		panic("UserRepo.GetUserByVerificationCode called but GetUserByVerificationCodeFunc is not set")
+	}
+	return m.GetUserByVerificationCodeFunc(email, code)
+}
+
+func (m *UserRepo) GetUserNote(userID, noteID string) (*model.UserNote, error) {
+	if m.GetUserNoteFunc == nil {
+		panic("UserRepo.GetUserNote called but GetUserNoteFunc is not set")
+	}
+	return m.GetUserNoteFunc(userID, noteID)
+}
+
+func (m *UserRepo) GetUserNotes(userID, tag string, page, limit int) ([]model.UserNote, int64, error) {
+	if m.GetUserNotesFunc == nil {
+		panic("UserRepo.GetUserNotes called but GetUserNotesFunc is not set")
+	}
+	return m.GetUserNotesFunc(userID, tag, page, limit)
+}
+
+func (m *UserRepo) GetUserProfile(userID string) (*model.User, error) {
+	if m.GetUserProfileFunc == nil {
+		panic("UserRepo.GetUserProfile called but GetUserProfileFunc is not set")
+	}
+	return m.GetUserProfileFunc(userID)
+}
+
+func (m *UserRepo) GetUserSessions(userID string) ([]model.UserSession, error) {
+	if m.GetUserSessionsFunc == nil {
+		panic("UserRepo.GetUserSessions called but GetUserSessionsFunc is not set")
+	}
+	return m.GetUserSessionsFunc(userID)
+}
+
+func (m *UserRepo) GetUserStats(userID string) (*dto.UserStats, error) {
+	if m.GetUserStatsFunc == nil {
+		panic("UserRepo.GetUserStats called but GetUserStatsFunc is not set")
+	}
+	return m.GetUserStatsFunc(userID)
+}
+
+func (m *UserRepo) GetUserTrustedDevices(userID string) ([]model.TrustedDevice, error) {
+	if m.GetUserTrustedDevicesFunc == nil {
+		panic("UserRepo.GetUserTrustedDevices called but GetUserTrustedDevicesFunc is not set")
+	}
+	return m.GetUserTrustedDevicesFunc(userID)
+}
+
+func (m *UserRepo) GetUsersByIDs(userIDs []string) ([]model.User, error) {
+	if m.GetUsersByIDsFunc == nil {
+		panic("UserRepo.GetUsersByIDs called but GetUsersByIDsFunc is not set")
+	}
+	return m.GetUsersByIDsFunc(userIDs)
+}
+
+func (m *UserRepo) GetUsersDueSecurityDigest(dueBefore time.Time, limit int) ([]model.User, error) {
+	if m.GetUsersDueSecurityDigestFunc == nil {
+		panic("UserRepo.GetUsersDueSecurityDigest called but GetUsersDueSecurityDigestFunc is not set")
+	}
+	return m.GetUsersDueSecurityDigestFunc(dueBefore, limit)
+}
+
+func (m *UserRepo) IncrementFailedAttempts(userID string) (int, error) {
+	if m.IncrementFailedAttemptsFunc == nil {
+		panic("UserRepo.IncrementFailedAttempts called but IncrementFailedAttemptsFunc is not set")
+	}
+	return m.IncrementFailedAttemptsFunc(userID)
+}
+
+func (m *UserRepo) InvalidatePasswordResetCode(code string) error {
+	if m.InvalidatePasswordResetCodeFunc == nil {
+		panic("UserRepo.InvalidatePasswordResetCode called but InvalidatePasswordResetCodeFunc is not set")
+	}
+	return m.InvalidatePasswordResetCodeFunc(code)
+}
+
+func (m *UserRepo) IsEmailAvailable(email string) (bool, error) {
+	if m.IsEmailAvailableFunc == nil {
+		panic("UserRepo.IsEmailAvailable called but IsEmailAvailableFunc is not set")
+	}
+	return m.IsEmailAvailableFunc(email)
+}
+
+func (m *UserRepo) LockAccount(userID string, lockUntil time.Time) error {
+	if m.LockAccountFunc == nil {
+		panic("UserRepo.LockAccount called but LockAccountFunc is not set")
+	}
+	return m.LockAccountFunc(userID, lockUntil)
+}
+
+func (m *UserRepo) RemoveTrustedDevice(userID, deviceID string) error {
+	if m.RemoveTrustedDeviceFunc == nil {
+		panic("UserRepo.RemoveTrustedDevice called but RemoveTrustedDeviceFunc is not set")
+	}
+	return m.RemoveTrustedDeviceFunc(userID, deviceID)
+}
+
+func (m *UserRepo) ReplaceBackupCodes(userID string, backupCodeHashes []string, outbox ...*model.OutboxMessage) error {
+	if m.ReplaceBackupCodesFunc == nil {
+		panic("UserRepo.ReplaceBackupCodes called but ReplaceBackupCodesFunc is not set")
+	}
+	return m.ReplaceBackupCodesFunc(userID, backupCodeHashes, outbox...)
+}
+
+func (m *UserRepo) ResetFailedAttempts(userID string) error {
+	if m.ResetFailedAttemptsFunc == nil {
+		panic("UserRepo.ResetFailedAttempts called but ResetFailedAttemptsFunc is not set")
+	}
+	return m.ResetFailedAttemptsFunc(userID)
+}
+
+func (m *UserRepo) ResolveModerationFlags(flagID, userID, status, reviewerID, note string) (int64, error) {
+	if m.ResolveModerationFlagsFunc == nil {
+		panic("UserRepo.ResolveModerationFlags called but ResolveModerationFlagsFunc is not set")
+	}
+	return m.ResolveModerationFlagsFunc(flagID, userID, status, reviewerID, note)
+}
+
+func (m *UserRepo) RevertEmailChange(req *model.EmailChangeRequest) error {
+	if m.RevertEmailChangeFunc == nil {
+		panic("UserRepo.RevertEmailChange called but RevertEmailChangeFunc is not set")
+	}
+	return m.RevertEmailChangeFunc(req)
+}
+
+func (m *UserRepo) SetTwoFactorSecret(userID, secret string) (bool, error) {
+	if m.SetTwoFactorSecretFunc == nil {
+		panic("UserRepo.SetTwoFactorSecret called but SetTwoFactorSecretFunc is not set")
+	}
+	return m.SetTwoFactorSecretFunc(userID, secret)
+}
+
+func (m *UserRepo) UpdateAccountRecoveryStatus(req *model.AccountRecoveryRequest, from []string, outbox ...*model.OutboxMessage) (bool, error) {
+	if m.UpdateAccountRecoveryStatusFunc == nil {
+		panic("UserRepo.UpdateAccountRecoveryStatus called but UpdateAccountRecoveryStatusFunc is not set")
+	}
+	return m.UpdateAccountRecoveryStatusFunc(req, from, outbox...)
+}
+
+func (m *UserRepo) UpdateLastLogin(userID, ip string) error {
+	if m.UpdateLastLoginFunc == nil {
+		panic("UserRepo.UpdateLastLogin called but UpdateLastLoginFunc is not set")
+	}
+	return m.UpdateLastLoginFunc(userID, ip)
+}
+
+func (m *UserRepo) UpdateSecuritySettings(userID string, settings dto.UpdateSecuritySettingsRequest) error {
+	if m.UpdateSecuritySettingsFunc == nil {
+		panic("UserRepo.UpdateSecuritySettings called but UpdateSecuritySettingsFunc is not set")
+	}
+	return m.UpdateSecuritySettingsFunc(userID, settings)
+}
+
+func (m *UserRepo) UpdateSessionLastUsed(sessionID string) error {
+	if m.UpdateSessionLastUsedFunc == nil {
+		panic("UserRepo.UpdateSessionLastUsed called but UpdateSessionLastUsedFunc is not set")
+	}
+	return m.UpdateSessionLastUsedFunc(sessionID)
+}
+
+func (m *UserRepo) UpdateSessionToken(sessionID, newTokenHash string) error {
+	if m.UpdateSessionTokenFunc == nil {
+		panic("UserRepo.UpdateSessionToken called but UpdateSessionTokenFunc is not set")
+	}
+	return m.UpdateSessionTokenFunc(sessionID, newTokenHash)
+}
+
+func (m *UserRepo) UpdateTrustedDevice(device *model.TrustedDevice) error {
+	if m.UpdateTrustedDeviceFunc == nil {
+		panic("UserRepo.UpdateTrustedDevice called but UpdateTrustedDeviceFunc is not set")
+	}
+	return m.UpdateTrustedDeviceFunc(device)
+}
+
+func (m *UserRepo) UpdateUserNote(note *model.UserNote) error {
+	if m.UpdateUserNoteFunc == nil {
+		panic("UserRepo.UpdateUserNote called but UpdateUserNoteFunc is not set")
+	}
+	return m.UpdateUserNoteFunc(note)
+}
+
+func (m *UserRepo) UpdateUserPassword(userID, hashedPassword string) error {
+	if m.UpdateUserPasswordFunc == nil {
+		panic("UserRepo.UpdateUserPassword called but UpdateUserPasswordFunc is not set")
+	}
+	return m.UpdateUserPasswordFunc(userID, hashedPassword)
+}
+
+func (m *UserRepo) UpdateUserProfile(userID string, updates map[string]interface{}) error {
+	if m.UpdateUserProfileFunc == nil {
+		panic("UserRepo.UpdateUserProfile called but UpdateUserProfileFunc is not set")
+	}
+	return m.UpdateUserProfileFunc(userID, updates)
+}
+
+func (m *UserRepo) UpdateVerificationCode(userID, code string, outbox ...*model.OutboxMessage) error {
+	if m.UpdateVerificationCodeFunc == nil {
+		panic("UserRepo.UpdateVerificationCode called but UpdateVerificationCodeFunc is not set")
+	}
+	return m.UpdateVerificationCodeFunc(userID, code, outbox...)
+}
+
+func (m *UserRepo) VerifyUserEmail(userID string) error {
+	if m.VerifyUserEmailFunc == nil {
+		panic("UserRepo.VerifyUserEmail called but VerifyUserEmailFunc is not set")
+	}
+	return m.VerifyUserEmailFunc(userID)
+}
+
+// ContentRepo is a fake repositories.ContentRepo
+type ContentRepo struct {
+	AddContentPopularityFunc          func(stats []model.ContentPopularityStat) error
+	CharacterRelationExistsFunc       func(characterID, relatedCharacterID string) (bool, error)
+	CloseDisabledQuestionFunc         func(id, status, reviewerID, note string, question json.RawMessage) (bool, error)
+	CountDynastyReferencesFunc        func(name string) (int64, error)
+	CountEraReferencesFunc            func(code string) (int64, error)
+	CountPendingQuestionReportsFunc   func(lessonID, questionID string) (int64, error)
+	CreateCharacterFunc               func(character *model.Character) (*model.Character, error)
+	CreateCharacterRelationFunc       func(relation *model.CharacterRelation) error
+	CreateContentAuditLogFunc         func(auditLog *model.ContentAuditLog) error
+	CreateContentIncidentFunc         func(incident *model.ContentIncident) error
+	CreateDynastyFunc                 func(dynasty *model.Dynasty) error
+	CreateEraFunc                     func(era *model.Era) error
+	CreateGlossaryTermFunc            func(term *model.GlossaryTerm) error
+	CreateLessonFunc                  func(lesson *model.Lesson) (*model.Lesson, error)
+	CreateQuestionReportFunc          func(report *model.QuestionReport) (bool, error)
+	DeactivateLessonFunc              func(incident *model.ContentIncident) (bool, error)
+	DeleteCharacterRelationFunc       func(relationID string) error
+	DeleteDynastyFunc                 func(dynastyID string) error
+	DeleteEraFunc                     func(code string) error
+	DeleteGlossaryTermFunc            func(termID string) error
+	DisableQuestionFunc               func(disabled *model.DisabledQuestion) (bool, error)
+	GetAllGlossaryTermsFunc           func() ([]model.GlossaryTerm, error)
+	GetApprovedTranslationsFunc       func(lessonID string) ([]model.LessonTranslation, error)
+	GetCharacterFunc                  func(id string) (*model.Character, error)
+	GetCharacterRelationFunc          func(relationID string) (*model.CharacterRelation, error)
+	GetCharacterRelationsFunc         func(characterID string) ([]model.CharacterRelation, error)
+	GetCharactersByDynastyFunc        func(dynasty string) ([]model.Character, error)
+	GetCharactersByIDsFunc            func(ids []string) ([]model.Character, error)
+	GetCharactersByRarityFunc         func(rarity string) ([]model.Character, error)
+	GetContentAuditLogsFunc           func(entityType, entityID, adminID string, page, limit int) ([]model.ContentAuditLog, int64, error)
+	GetContentIncidentFunc            func(id string) (*model.ContentIncident, error)
+	GetContentIncidentsFunc           func(status, lessonID string, page, limit int) ([]model.ContentIncident, int64, error)
+	GetContentPopularityDaysFunc      func(entityType, entityID string, since time.Time) ([]model.ContentPopularityStat, error)
+	GetDisabledQuestionFunc           func(id string) (*model.DisabledQuestion, error)
+	GetDisabledQuestionsFunc          func(status, lessonID string, page, limit int) ([]model.DisabledQuestion, int64, error)
+	GetDynastiesFunc                  func() ([]model.Dynasty, error)
+	GetDynastyFunc                    func(dynastyID string) (*model.Dynasty, error)
+	GetEraFunc                        func(code string) (*model.Era, error)
+	GetErasFunc                       func() ([]model.Era, error)
+	GetGameConfigFunc                 func() (*model.GameConfig, error)
+	GetGlossaryTermFunc               func(termID string) (*model.GlossaryTerm, error)
+	GetGlossaryTermByTermFunc         func(term string) (*model.GlossaryTerm, error)
+	GetLessonFunc                     func(id string) (*model.Lesson, error)
+	GetLessonAuditSnapshotsFunc       func(lessonID string) ([]model.ContentAuditLog, error)
+	GetLessonTranslationFunc          func(lessonID, locale string) (*model.LessonTranslation, error)
+	GetLessonVideoWatchStatsFunc      func(lessonID string) (*model.LessonVideoWatchStats, error)
+	GetLessonsByCharacterFunc         func(characterID string) ([]model.Lesson, error)
+	GetLessonsByIDsFunc               func(ids []string) ([]model.Lesson, error)
+	GetPublicLessonsFunc              func() ([]model.Lesson, error)
+	GetQuestionAnswerCountsFunc       func(lessonID string) ([]model.QuestionAnswerCount, error)
+	GetQuestionReportCountsFunc       func(lessonID string) ([]model.QuestionReportCount, error)
+	GetSearchSuggestionSourcesFunc    func() ([]model.SearchSuggestionSource, error)
+	GetTimelineFunc                   func() ([]model.Timeline, error)
+	GetTrendingContentFunc            func(entityType string, since time.Time, limit int) ([]model.TrendingContent, error)
+	GetUnratedLessonsFunc             func(page, limit int) ([]model.Lesson, int64, error)
+	ResolveContentIncidentFunc        func(id, resolution, resolverID, note string) (bool, error)
+	SearchContentFunc                 func(query, entityType, era, dynasty, rarity string, page, limit int) ([]model.SearchHit, int64, error)
+	SearchGlossaryTermsFunc           func(query, era string, page, limit int) ([]model.GlossaryTerm, int64, error)
+	UpdateCharacterRelationFunc       func(relation *model.CharacterRelation) error
+	UpdateContentIncidentNotifiedFunc func(id string, sessionsNotified int, cachesBroadcast bool) error
+	UpdateDynastyFunc                 func(dynasty *model.Dynasty, oldName string) error
+	UpdateEraFunc                     func(era *model.Era) error
+	UpdateGameConfigFunc              func(config *model.GameConfig) error
+	UpdateGlossaryTermFunc            func(term *model.GlossaryTerm) error
+	UpdateLessonFunc                  func(lesson *model.Lesson) error
+	UpdateLessonQuestionsFunc         func(lessonID, baseVersion string, questions json.RawMessage) (bool, error)
+}
+
+var _ repositories.ContentRepo = (*ContentRepo)(nil)
+
+func (m *ContentRepo) AddContentPopularity(stats []model.ContentPopularityStat) error {
+	if m.AddContentPopularityFunc == nil {
+		panic("ContentRepo.AddContentPopularity called but AddContentPopularityFunc is not set")
+	}
+	return m.AddContentPopularityFunc(stats)
+}
+
+func (m *ContentRepo) CharacterRelationExists(characterID, relatedCharacterID string) (bool, error) {
+	if m.CharacterRelationExistsFunc == nil {
+		panic("ContentRepo.CharacterRelationExists called but CharacterRelationExistsFunc is not set")
+	}
+	return m.CharacterRelationExistsFunc(characterID, relatedCharacterID)
+}
+
+func (m *ContentRepo) CloseDisabledQuestion(id, status, reviewerID, note string, question json.RawMessage) (bool, error) {
+	if m.CloseDisabledQuestionFunc == nil {
+		panic("ContentRepo.CloseDisabledQuestion called but CloseDisabledQuestionFunc is not set")
+	}
+	return m.CloseDisabledQuestionFunc(id, status, reviewerID, note, question)
+}
+
+func (m *ContentRepo) CountDynastyReferences(name string) (int64, error) {
+	if m.CountDynastyReferencesFunc == nil {
+		panic("ContentRepo.CountDynastyReferences called but CountDynastyReferencesFunc is not set")
+	}
+	return m.CountDynastyReferencesFunc(name)
+}
+
+func (m *ContentRepo) CountEraReferences(code string) (int64, error) {
+	if m.CountEraReferencesFunc == nil {
+		panic("ContentRepo.CountEraReferences called but CountEraReferencesFunc is not set")
+	}
+	return m.CountEraReferencesFunc(code)
+}
+
+func (m *ContentRepo) CountPendingQuestionReports(lessonID, questionID string) (int64, error) {
+	if m.CountPendingQuestionReportsFunc == nil {
+		panic("ContentRepo.CountPendingQuestionReports called but CountPendingQuestionReportsFunc is not set")
+	}
+	return m.CountPendingQuestionReportsFunc(lessonID, questionID)
+}
+
+func (m *ContentRepo) CreateCharacter(character *model.Character) (*model.Character, error) {
+	if m.CreateCharacterFunc == nil {
+		panic("ContentRepo.CreateCharacter called but CreateCharacterFunc is not set")
+	}
+	return m.CreateCharacterFunc(character)
+}
+
+func (m *ContentRepo) CreateCharacterRelation(relation *model.CharacterRelation) error {
+	if m.CreateCharacterRelationFunc == nil {
+		panic("ContentRepo.CreateCharacterRelation called but CreateCharacterRelationFunc is not set")
+	}
+	return m.CreateCharacterRelationFunc(relation)
+}
+
+func (m *ContentRepo) CreateContentAuditLog(auditLog *model.ContentAuditLog) error {
+	if m.CreateContentAuditLogFunc == nil {
+		panic("ContentRepo.CreateContentAuditLog called but CreateContentAuditLogFunc is not set")
+	}
+	return m.CreateContentAuditLogFunc(auditLog)
+}
+
+func (m *ContentRepo) CreateContentIncident(incident *model.ContentIncident) error {
+	if m.CreateContentIncidentFunc == nil {
+		panic("ContentRepo.CreateContentIncident called but CreateContentIncidentFunc is not set")
+	}
+	return m.CreateContentIncidentFunc(incident)
+}
+
+func (m *ContentRepo) CreateDynasty(dynasty *model.Dynasty) error {
+	if m.CreateDynastyFunc == nil {
+		panic("ContentRepo.CreateDynasty called but CreateDynastyFunc is not set")
+	}
+	return m.CreateDynastyFunc(dynasty)
+}
+
+func (m *ContentRepo) CreateEra(era *model.Era) error {
+	if m.CreateEraFunc == nil {
+		panic("ContentRepo.CreateEra called but CreateEraFunc is not set")
+	}
+	return m.CreateEraFunc(era)
+}
+
+func (m *ContentRepo) CreateGlossaryTerm(term *model.GlossaryTerm) error {
+	if m.CreateGlossaryTermFunc == nil {
+		panic("ContentRepo.CreateGlossaryTerm called but CreateGlossaryTermFunc is not set")
+	}
+	return m.CreateGlossaryTermFunc(term)
+}
+
+func (m *ContentRepo) CreateLesson(lesson *model.Lesson) (*model.Lesson, error) {
+	if m.CreateLessonFunc == nil {
+		panic("ContentRepo.CreateLesson called but CreateLessonFunc is not set")
+	}
+	return m.CreateLessonFunc(lesson)
+}
+
+func (m *ContentRepo) CreateQuestionReport(report *model.QuestionReport) (bool, error) {
+	if m.CreateQuestionReportFunc == nil {
+		panic("ContentRepo.CreateQuestionReport called but CreateQuestionReportFunc is not set")
+	}
+	return m.CreateQuestionReportFunc(report)
+}
+
+func (m *ContentRepo) DeactivateLesson(incident *model.ContentIncident) (bool, error) {
+	if m.DeactivateLessonFunc == nil {
+		panic("ContentRepo.DeactivateLesson called but DeactivateLessonFunc is not set")
+	}
+	return m.DeactivateLessonFunc(incident)
+}
+
+func (m *ContentRepo) DeleteCharacterRelation(relationID string) error {
+	if m.DeleteCharacterRelationFunc == nil {
+		panic("ContentRepo.DeleteCharacterRelation called but DeleteCharacterRelationFunc is not set")
+	}
+	return m.DeleteCharacterRelationFunc(relationID)
+}
+
+func (m *ContentRepo) DeleteDynasty(dynastyID string) error {
+	if m.DeleteDynastyFunc == nil {
+		panic("ContentRepo.DeleteDynasty called but DeleteDynastyFunc is not set")
+	}
+	return m.DeleteDynastyFunc(dynastyID)
+}
+
+func (m *ContentRepo) DeleteEra(code string) error {
+	if m.DeleteEraFunc == nil {
+		panic("ContentRepo.DeleteEra called but DeleteEraFunc is not set")
+	}
+	return m.DeleteEraFunc(code)
+}
+
+func (m *ContentRepo) DeleteGlossaryTerm(termID string) error {
+	if m.DeleteGlossaryTermFunc == nil {
+		panic("ContentRepo.DeleteGlossaryTerm called but DeleteGlossaryTermFunc is not set")
+	}
+	return m.DeleteGlossaryTermFunc(termID)
+}
+
+func (m *ContentRepo) DisableQuestion(disabled *model.DisabledQuestion) (bool, error) {
+	if m.DisableQuestionFunc == nil {
+		panic("ContentRepo.DisableQuestion called but DisableQuestionFunc is not set")
+	}
+	return m.DisableQuestionFunc(disabled)
+}
+
+func (m *ContentRepo) GetAllGlossaryTerms() ([]model.GlossaryTerm, error) {
+	if m.GetAllGlossaryTermsFunc == nil {
+		panic("ContentRepo.GetAllGlossaryTerms called but GetAllGlossaryTermsFunc is not set")
+	}
+	return m.GetAllGlossaryTermsFunc()
+}
+
+func (m *ContentRepo) GetApprovedTranslations(lessonID string) ([]model.LessonTranslation, error) {
+	if m.GetApprovedTranslationsFunc == nil {
+		panic("ContentRepo.GetApprovedTranslations called but GetApprovedTranslationsFunc is not set")
+	}
+	return m.GetApprovedTranslationsFunc(lessonID)
+}
+
+func (m *ContentRepo) GetCharacter(id string) (*model.Character, error) {
+	if m.GetCharacterFunc == nil {
+		panic("ContentRepo.GetCharacter called but GetCharacterFunc is not set")
+	}
+	return m.GetCharacterFunc(id)
+}
+
+func (m *ContentRepo) GetCharacterRelation(relationID string) (*model.CharacterRelation, error) {
+	if m.GetCharacterRelationFunc == nil {
+		panic("ContentRepo.GetCharacterRelation called but GetCharacterRelationFunc is not set")
+	}
+	return m.GetCharacterRelationFunc(relationID)
+}
+
+func (m *ContentRepo) GetCharacterRelations(characterID string) ([]model.CharacterRelation, error) {
+	if m.GetCharacterRelationsFunc == nil {
+		panic("ContentRepo.GetCharacterRelations called but GetCharacterRelationsFunc is not set")
+	}
+	return m.GetCharacterRelationsFunc(characterID)
+}
+
+func (m *ContentRepo) GetCharactersByDynasty(dynasty string) ([]model.Character, error) {
+	if m.GetCharactersByDynastyFunc == nil {
+		panic("ContentRepo.GetCharactersByDynasty called but GetCharactersByDynastyFunc is not set")
+	}
+	return m.GetCharactersByDynastyFunc(dynasty)
+}
+
+func (m *ContentRepo) GetCharactersByIDs(ids []string) ([]model.Character, error) {
+	if m.GetCharactersByIDsFunc == nil {
+		panic("ContentRepo.GetCharactersByIDs called but GetCharactersByIDsFunc is not set")
+	}
+	return m.GetCharactersByIDsFunc(ids)
+}
+
+func (m *ContentRepo) GetCharactersByRarity(rarity string) ([]model.Character, error) {
+	if m.GetCharactersByRarityFunc == nil {
+		panic("ContentRepo.GetCharactersByRarity called but GetCharactersByRarityFunc is not set")
+	}
+	return m.GetCharactersByRarityFunc(rarity)
+}
+
+func (m *ContentRepo) GetContentAuditLogs(entityType, entityID, adminID string, page, limit int) ([]model.ContentAuditLog, int64, error) {
+	if m.GetContentAuditLogsFunc == nil {
+		panic("ContentRepo.GetContentAuditLogs called but GetContentAuditLogsFunc is not set")
+	}
+	return m.GetContentAuditLogsFunc(entityType, entityID, adminID, page, limit)
+}
+
+func (m *ContentRepo) GetContentIncident(id string) (*model.ContentIncident, error) {
+	if m.GetContentIncidentFunc == nil {
+		panic("ContentRepo.GetContentIncident called but GetContentIncidentFunc is not set")
+	}
+	return m.GetContentIncidentFunc(id)
+}
+
+func (m *ContentRepo) GetContentIncidents(status, lessonID string, page, limit int) ([]model.ContentIncident, int64, error) {
+	if m.GetContentIncidentsFunc == nil {
+		panic("ContentRepo.GetContentIncidents called but GetContentIncidentsFunc is not set")
+	}
+	return m.GetContentIncidentsFunc(status, lessonID, page, limit)
+}
+
+func (m *ContentRepo) GetContentPopularityDays(entityType, entityID string, since time.Time) ([]model.ContentPopularityStat, error) {
+	if m.GetContentPopularityDaysFunc == nil {
+		panic("ContentRepo.GetContentPopularityDays called but GetContentPopularityDaysFunc is not set")
+	}
+	return m.GetContentPopularityDaysFunc(entityType, entityID, since)
+}
+
+func (m *ContentRepo) GetDisabledQuestion(id string) (*model.DisabledQuestion, error) {
+	if m.GetDisabledQuestionFunc == nil {
+		panic("ContentRepo.GetDisabledQuestion called but GetDisabledQuestionFunc is not set")
+	}
+	return m.GetDisabledQuestionFunc(id)
+}
+
+func (m *ContentRepo) GetDisabledQuestions(status, lessonID string, page, limit int) ([]model.DisabledQuestion, int64, error) {
+	if m.GetDisabledQuestionsFunc == nil {
+		panic("ContentRepo.GetDisabledQuestions called but GetDisabledQuestionsFunc is not set")
+	}
+	return m.GetDisabledQuestionsFunc(status, lessonID, page, limit)
+}
+
+func (m *ContentRepo) GetDynasties() ([]model.Dynasty, error) {
+	if m.GetDynastiesFunc == nil {
+		panic("ContentRepo.GetDynasties called but GetDynastiesFunc is not set")
+	}
+	return m.GetDynastiesFunc()
+}
+
+func (m *ContentRepo) GetDynasty(dynastyID string) (*model.Dynasty, error) {
+	if m.GetDynastyFunc == nil {
+		panic("ContentRepo.GetDynasty called but GetDynastyFunc is not set")
+	}
+	return m.GetDynastyFunc(dynastyID)
+}
+
+func (m *ContentRepo) GetEra(code string) (*model.Era, error) {
+	if m.GetEraFunc == nil {
+		panic("ContentRepo.GetEra called but GetEraFunc is not set")
+	}
+	return m.GetEraFunc(code)
+}
+
+func (m *ContentRepo) GetEras() ([]model.Era, error) {
+	if m.GetErasFunc == nil {
+		panic("ContentRepo.GetEras called but GetErasFunc is not set")
+	}
+	return m.GetErasFunc()
+}
+
+func (m *ContentRepo) GetGameConfig() (*model.GameConfig, error) {
+	if m.GetGameConfigFunc == nil {
+		panic("ContentRepo.GetGameConfig called but GetGameConfigFunc is not set")
+	}
+	return m.GetGameConfigFunc()
+}
+
+func (m *ContentRepo) GetGlossaryTerm(termID string) (*model.GlossaryTerm, error) {
+	if m.GetGlossaryTermFunc == nil {
+		panic("ContentRepo.GetGlossaryTerm called but GetGlossaryTermFunc is not set")
+	}
+	return m.GetGlossaryTermFunc(termID)
+}
+
+func (m *ContentRepo) GetGlossaryTermByTerm(term string) (*model.GlossaryTerm, error) {
+	if m.GetGlossaryTermByTermFunc == nil {
+		panic("ContentRepo.GetGlossaryTermByTerm called but GetGlossaryTermByTermFunc is not set")
+	}
+	return m.GetGlossaryTermByTermFunc(term)
+}
+
+func (m *ContentRepo) GetLesson(id string) (*model.Lesson, error) {
+	if m.GetLessonFunc == nil {
+		panic("ContentRepo.GetLesson called but GetLessonFunc is not set")
+	}
+	return m.GetLessonFunc(id)
+}
+
+func (m *ContentRepo) GetLessonAuditSnapshots(lessonID string) ([]model.ContentAuditLog, error) {
+	if m.GetLessonAuditSnapshotsFunc == nil {
+		panic("ContentRepo.GetLessonAuditSnapshots called but GetLessonAuditSnapshotsFunc is not set")
+	}
+	return m.GetLessonAuditSnapshotsFunc(lessonID)
+}
+
+func (m *ContentRepo) GetLessonTranslation(lessonID, locale string) (*model.LessonTranslation, error) {
+	if m.GetLessonTranslationFunc == nil {
+		panic("ContentRepo.GetLessonTranslation called but GetLessonTranslationFunc is not set")
+	}
+	return m.GetLessonTranslationFunc(lessonID, locale)
+}
+
+func (m *ContentRepo) GetLessonVideoWatchStats(lessonID string) (*model.LessonVideoWatchStats, error) {
+	if m.GetLessonVideoWatchStatsFunc == nil {
+		panic("ContentRepo.GetLessonVideoWatchStats called but GetLessonVideoWatchStatsFunc is not set")
+	}
+	return m.GetLessonVideoWatchStatsFunc(lessonID)
+}
+
+func (m *ContentRepo) GetLessonsByCharacter(characterID string) ([]model.Lesson, error) {
+	if m.GetLessonsByCharacterFunc == nil {
+		panic("ContentRepo.GetLessonsByCharacter called but GetLessonsByCharacterFunc is not set")
+	}
+	return m.GetLessonsByCharacterFunc(characterID)
+}
+
+func (m *ContentRepo) GetLessonsByIDs(ids []string) ([]model.Lesson, error) {
+	if m.GetLessonsByIDsFunc == nil {
+		panic("ContentRepo.GetLessonsByIDs called but GetLessonsByIDsFunc is not set")
+	}
+	return m.GetLessonsByIDsFunc(ids)
+}
+
+func (m *ContentRepo) GetPublicLessons() ([]model.Lesson, error) {
+	if m.GetPublicLessonsFunc == nil {
+		panic("ContentRepo.GetPublicLessons called but GetPublicLessonsFunc is not set")
+	}
+	return m.GetPublicLessonsFunc()
+}
+
+func (m *ContentRepo) GetQuestionAnswerCounts(lessonID string) ([]model.QuestionAnswerCount, error) {
+	if m.GetQuestionAnswerCountsFunc == nil {
+		panic("ContentRepo.GetQuestionAnswerCounts called but GetQuestionAnswerCountsFunc is not set")
+	}
+	return m.GetQuestionAnswerCountsFunc(lessonID)
+}
+
+func (m *ContentRepo) GetQuestionReportCounts(lessonID string) ([]model.QuestionReportCount, error) {
+	if m.GetQuestionReportCountsFunc == nil {
+		panic("ContentRepo.GetQuestionReportCounts called but GetQuestionReportCountsFunc is not set")
+	}
+	return m.GetQuestionReportCountsFunc(lessonID)
+}
+
+func (m *ContentRepo) GetSearchSuggestionSources() ([]model.SearchSuggestionSource, error) {
+	if m.GetSearchSuggestionSourcesFunc == nil {
+		panic("ContentRepo.GetSearchSuggestionSources called but GetSearchSuggestionSourcesFunc is not set")
+	}
+	return m.GetSearchSuggestionSourcesFunc()
+}
+
+func (m *ContentRepo) GetTimeline() ([]model.Timeline, error) {
+	if m.GetTimelineFunc == nil {
+		panic("ContentRepo.GetTimeline called but GetTimelineFunc is not set")
+	}
+	return m.GetTimelineFunc()
+}
+
+func (m *ContentRepo) GetTrendingContent(entityType string, since time.Time, limit int) ([]model.TrendingContent, error) {
+	if m.GetTrendingContentFunc == nil {
+		panic("ContentRepo.GetTrendingContent called but GetTrendingContentFunc is not set")
+	}
+	return m.GetTrendingContentFunc(entityType, since, limit)
+}
+
+func (m *ContentRepo) GetUnratedLessons(page, limit int) ([]model.Lesson, int64, error) {
+	if m.GetUnratedLessonsFunc == nil {
+		panic("ContentRepo.GetUnratedLessons called but GetUnratedLessonsFunc is not set")
+	}
+	return m.GetUnratedLessonsFunc(page, limit)
+}
+
+func (m *ContentRepo) ResolveContentIncident(id, resolution, resolverID, note string) (bool, error) {
+	if m.ResolveContentIncidentFunc == nil {
+		panic("ContentRepo.ResolveContentIncident called but ResolveContentIncidentFunc is not set")
+	}
+	return m.ResolveContentIncidentFunc(id, resolution, resolverID, note)
+}
+
+func (m *ContentRepo) SearchContent(query, entityType, era, dynasty, rarity string, page, limit int) ([]model.SearchHit, int64, error) {
+	if m.SearchContentFunc == nil {
+		panic("ContentRepo.SearchContent called but SearchContentFunc is not set")
+	}
+	return m.SearchContentFunc(query, entityType, era, dynasty, rarity, page, limit)
+}
+
+func (m *ContentRepo) SearchGlossaryTerms(query, era string, page, limit int) ([]model.GlossaryTerm, int64, error) {
+	if m.SearchGlossaryTermsFunc == nil {
+		panic("ContentRepo.SearchGlossaryTerms called but SearchGlossaryTermsFunc is not set")
+	}
+	return m.SearchGlossaryTermsFunc(query, era, page, limit)
+}
+
+func (m *ContentRepo) UpdateCharacterRelation(relation *model.CharacterRelation) error {
+	if m.UpdateCharacterRelationFunc == nil {
+		panic("ContentRepo.UpdateCharacterRelation called but UpdateCharacterRelationFunc is not set")
+	}
+	return m.UpdateCharacterRelationFunc(relation)
+}
+
+func (m *ContentRepo) UpdateContentIncidentNotified(id string, sessionsNotified int, cachesBroadcast bool) error {
+	if m.UpdateContentIncidentNotifiedFunc == nil {
+		panic("ContentRepo.UpdateContentIncidentNotified called but UpdateContentIncidentNotifiedFunc is not set")
+	}
+	return m.UpdateContentIncidentNotifiedFunc(id, sessionsNotified, cachesBroadcast)
+}
+
+func (m *ContentRepo) UpdateDynasty(dynasty *model.Dynasty, oldName string) error {
+	if m.UpdateDynastyFunc == nil {
+		panic("ContentRepo.UpdateDynasty called but UpdateDynastyFunc is not set")
+	}
+	return m.UpdateDynastyFunc(dynasty, oldName)
+}
+
+func (m *ContentRepo) UpdateEra(era *model.Era) error {
+	if m.UpdateEraFunc == nil {
+		panic("ContentRepo.UpdateEra called but UpdateEraFunc is not set")
+	}
+	return m.UpdateEraFunc(era)
+}
+
+func (m *ContentRepo) UpdateGameConfig(config *model.GameConfig) error {
+	if m.UpdateGameConfigFunc == nil {
+		panic("ContentRepo.UpdateGameConfig called but UpdateGameConfigFunc is not set")
+	}
+	return m.UpdateGameConfigFunc(config)
+}
+
+func (m *ContentRepo) UpdateGlossaryTerm(term *model.GlossaryTerm) error {
+	if m.UpdateGlossaryTermFunc == nil {
+		panic("ContentRepo.UpdateGlossaryTerm called but UpdateGlossaryTermFunc is not set")
+	}
+	return m.UpdateGlossaryTermFunc(term)
+}
+
+func (m *ContentRepo) UpdateLesson(lesson *model.Lesson) error {
+	if m.UpdateLessonFunc == nil {
+		panic("ContentRepo.UpdateLesson called but UpdateLessonFunc is not set")
+	}
+	return m.UpdateLessonFunc(lesson)
+}
+
+func (m *ContentRepo) UpdateLessonQuestions(lessonID, baseVersion string, questions json.RawMessage) (bool, error) {
+	if m.UpdateLessonQuestionsFunc == nil {
+		panic("ContentRepo.UpdateLessonQuestions called but UpdateLessonQuestionsFunc is not set")
+	}
+	return m.UpdateLessonQuestionsFunc(lessonID, baseVersion, questions)
+}
+
+// ProgressRepo is a fake repositories.ProgressRepo
+type ProgressRepo struct {
+	CompleteLessonFunc               func(completion *model.UserLessonCompletion, complete func(progress *model.UserProgress, firstCompletion bool) *model.ProgressChange) (bool, error)
+	CountCompletedLessonsFunc        func(userID string) (int64, error)
+	CountLessonCompletionsSinceFunc  func(userID string, since time.Time) (int64, error)
+	CountUnlockedCharactersFunc      func(userID string) (int64, error)
+	CreateCompletionFlagFunc         func(flag *model.CompletionFlag) error
+	CreateFavoriteCharacterFunc      func(favorite *model.UserFavoriteCharacter) (bool, error)
+	CreateLeaderboardAnomalyFunc     func(anomaly *model.LeaderboardAnomaly) error
+	CreateLessonBookmarkFunc         func(bookmark *model.UserLessonBookmark) (bool, error)
+	CreateLessonCompletionFunc       func(completion *model.UserLessonCompletion) (bool, error)
+	CreateSpiritFunc                 func(spirit *model.Spirit) (*model.Spirit, error)
+	CreateUserCharacterFunc          func(userCharacter *model.UserCharacter, outbox ...*model.OutboxMessage) (bool, error)
+	CreateUserProgressFunc           func(progress *model.UserProgress) (*model.UserProgress, error)
+	CreateXPTransactionFunc          func(txn *model.XPTransaction) error
+	DeleteFavoriteCharacterFunc      func(userID, characterID string) (bool, error)
+	DeleteLessonBookmarkFunc         func(userID, lessonID string) (bool, error)
+	DeleteLessonSessionFunc          func(userID, lessonID string) error
+	GetAllTimeLeaderboardFunc        func(limit int) ([]model.UserProgress, error)
+	GetCompletedLessonAttemptsFunc   func(userID string) ([]model.UserLessonAttempt, error)
+	GetCompletedLessonIDsFunc        func(userID string) ([]string, error)
+	GetCompletionFlagFunc            func(id string) (*model.CompletionFlag, error)
+	GetCompletionFlagsFunc           func(status string, page, limit int) ([]model.CompletionFlag, int64, error)
+	GetCompletionFlagsBetweenFunc    func(userID string, from, to time.Time) ([]model.CompletionFlag, error)
+	GetFavoriteCharacterIDsFunc      func(userID string) ([]string, error)
+	GetHeartTransactionsFunc         func(userID string, page, limit int) ([]model.HeartTransaction, int64, error)
+	GetItemTransactionsFunc          func(userID string, page, limit int) ([]model.ItemTransaction, int64, error)
+	GetLastLessonCompletionFunc      func(userID string) (*model.UserLessonCompletion, error)
+	GetLeaderboardAnomaliesFunc      func(status string, page, limit int) ([]model.LeaderboardAnomaly, int64, error)
+	GetLeaderboardAnomalyFunc        func(id string) (*model.LeaderboardAnomaly, error)
+	GetLeaderboardEntryFunc          func(leaderboardType, userID string, since time.Time) (*model.LeaderboardEntry, int, error)
+	GetLeaderboardProfilesFunc       func(userIDs []string) ([]model.LeaderboardProfile, error)
+	GetLessonAidUsesFunc             func(userID, lessonID string, sessionStartedAt time.Time) ([]model.LessonAidUse, error)
+	GetLessonCompletionsBetweenFunc  func(userID string, from, to time.Time) ([]model.UserLessonCompletion, error)
+	GetLessonSessionFunc             func(userID, lessonID string) (*model.LessonSession, error)
+	GetLessonSessionUserIDsFunc      func(lessonID string, activeSince time.Time) ([]string, error)
+	GetLessonVideoProgressFunc       func(userID, lessonID string) (*model.LessonVideoProgress, error)
+	GetMonthlyLeaderboardFunc        func(limit int) ([]model.UserProgress, error)
+	GetProgressFunc                  func(sessionID string) (*model.GuestProgress, error)
+	GetProgressUserIDsFunc           func() ([]string, error)
+	GetRankedLeaderboardFunc         func(leaderboardType string, since time.Time, limit int) ([]model.LeaderboardEntry, error)
+	GetSpiritsByUserIDsFunc          func(userIDs []string) ([]model.Spirit, error)
+	GetUnlockedCharacterIDsFunc      func(userID string) ([]string, error)
+	GetUserAchievementsFunc          func(userID string) ([]model.UserAchievement, error)
+	GetUserCharactersFunc            func(userID string) ([]model.UserCharacter, error)
+	GetUserLessonBookmarksFunc       func(userID string, page, limit int) ([]model.UserLessonBookmark, int64, error)
+	GetUserProgressFunc              func(userID string) (*model.UserProgress, error)
+	GetUserQuestionAnswersFunc       func(userID, lessonID string) ([]model.UserQuestionAnswer, error)
+	GetUserRankFunc                  func(userID string) (int, error)
+	GetUserSpiritFunc                func(userID string) (*model.Spirit, error)
+	GetUsersForHeartResetFunc        func(since time.Time) ([]model.UserProgress, error)
+	GetWeeklyLeaderboardFunc         func(limit int) ([]model.UserProgress, error)
+	GetXPGainsAboveFunc              func(since time.Time, threshold int) ([]model.XPGain, error)
+	GetXPLedgerMismatchesFunc        func() ([]model.XPLedgerMismatch, error)
+	GetXPTransactionsFunc            func(userID string, page, limit int) ([]model.XPTransaction, int64, error)
+	GetXPTransactionsBetweenFunc     func(userID string, from, to time.Time) ([]model.XPTransaction, error)
+	GetXPTransactionsBySourceFunc    func(userID, source string) ([]model.XPTransaction, error)
+	GrantUserItemFunc                func(txn *model.ItemTransaction) (bool, error)
+	HasCompletedLessonFunc           func(userID, lessonID string) (bool, error)
+	HasPendingLeaderboardAnomalyFunc func(userID string) (bool, error)
+	HasUserCharacterFunc             func(userID, characterID string) (bool, error)
+	MarkCollectionViewedFunc         func(userID string, viewedAt time.Time) error
+	MigrateSpiritFunc                func(spirit *model.Spirit, userUpdates map[string]interface{}) error
+	ModifyUserProgressFunc           func(userID string, modify func(progress *model.UserProgress) (*model.ProgressChange, error)) (*model.UserProgress, error)
+	RefreshLeaderboardProfileFunc    func(userID string) error
+	ResetLessonSessionFunc           func(userID, lessonID string) error
+	ResolveCompletionFlagsFunc       func(flagID, userID, status, reviewerID, note string) (int64, error)
+	ResolveLeaderboardAnomaliesFunc  func(anomalyID, userID, status, reviewerID, note string, penaltyXP int) error
+	RevokeUserItemFunc               func(txn *model.ItemTransaction) (bool, error)
+	SaveLeaderboardProfilesFunc      func(profiles []model.LeaderboardProfile) error
+	SaveLessonSessionFunc            func(session *model.LessonSession) error
+	SaveLessonVideoProgressFunc      func(progress *model.LessonVideoProgress) error
+	SaveUserQuestionAnswerFunc       func(answer *model.UserQuestionAnswer) error
+	SumXPTransactionsFunc            func(userID string) (int, error)
+	UpdateSpiritFunc                 func(spirit *model.Spirit) error
+	UseLessonAidFunc                 func(use *model.LessonAidUse, limit, coinPrice int) (*model.LessonAidUse, bool, error)
+}
+
+var _ repositories.ProgressRepo = (*ProgressRepo)(nil)
+
+func (m *ProgressRepo) CompleteLesson(completion *model.UserLessonCompletion, complete func(progress *model.UserProgress, firstCompletion bool) *model.ProgressChange) (bool, error) {
+	if m.CompleteLessonFunc == nil {
+		panic("ProgressRepo.CompleteLesson called but CompleteLessonFunc is not set")
+	}
+	return m.CompleteLessonFunc(completion, complete)
+}
+
+func (m *ProgressRepo) CountCompletedLessons(userID string) (int64, error) {
+	if m.CountCompletedLessonsFunc == nil {
+		panic("ProgressRepo.CountCompletedLessons called but CountCompletedLessonsFunc is not set")
+	}
+	return m.CountCompletedLessonsFunc(userID)
+}
+
+func (m *ProgressRepo) CountLessonCompletionsSince(userID string, since time.Time) (int64, error) {
+	if m.CountLessonCompletionsSinceFunc == nil {
+		panic("ProgressRepo.CountLessonCompletionsSince called but CountLessonCompletionsSinceFunc is not set")
+	}
+	return m.CountLessonCompletionsSinceFunc(userID, since)
+}
+
+func (m *ProgressRepo) CountUnlockedCharacters(userID string) (int64, error) {
+	if m.CountUnlockedCharactersFunc == nil {
+		panic("ProgressRepo.CountUnlockedCharacters called but CountUnlockedCharactersFunc is not set")
+	}
+	return m.CountUnlockedCharactersFunc(userID)
+}
+
+func (m *ProgressRepo) CreateCompletionFlag(flag *model.CompletionFlag) error {
+	if m.CreateCompletionFlagFunc == nil {
+		panic("ProgressRepo.CreateCompletionFlag called but CreateCompletionFlagFunc is not set")
+	}
+	return m.CreateCompletionFlagFunc(flag)
+}
+
+func (m *ProgressRepo) CreateFavoriteCharacter(favorite *model.UserFavoriteCharacter) (bool, error) {
+	if m.CreateFavoriteCharacterFunc == nil {
+		panic("ProgressRepo.CreateFavoriteCharacter called but CreateFavoriteCharacterFunc is not set")
+	}
+	return m.CreateFavoriteCharacterFunc(favorite)
+}
+
+func (m *ProgressRepo) CreateLeaderboardAnomaly(anomaly *model.LeaderboardAnomaly) error {
+	if m.CreateLeaderboardAnomalyFunc == nil {
+		panic("ProgressRepo.CreateLeaderboardAnomaly called but CreateLeaderboardAnomalyFunc is not set")
+	}
+	return m.CreateLeaderboardAnomalyFunc(anomaly)
+}
+
+func (m *ProgressRepo) CreateLessonBookmark(bookmark *model.UserLessonBookmark) (bool, error) {
+	if m.CreateLessonBookmarkFunc == nil {
+		panic("ProgressRepo.CreateLessonBookmark called but CreateLessonBookmarkFunc is not set")
+	}
+	return m.CreateLessonBookmarkFunc(bookmark)
+}
+
+func (m *ProgressRepo) CreateLessonCompletion(completion *model.UserLessonCompletion) (bool, error) {
+	if m.CreateLessonCompletionFunc == nil {
+		panic("ProgressRepo.CreateLessonCompletion called but CreateLessonCompletionFunc is not set")
+	}
+	return m.CreateLessonCompletionFunc(completion)
+}
+
+func (m *ProgressRepo) CreateSpirit(spirit *model.Spirit) (*model.Spirit, error) {
+	if m.CreateSpiritFunc == nil {
+		panic("ProgressRepo.CreateSpirit called but CreateSpiritFunc is not set")
+	}
+	return m.CreateSpiritFunc(spirit)
+}
+
+func (m *ProgressRepo) CreateUserCharacter(userCharacter *model.UserCharacter, outbox ...*model.OutboxMessage) (bool, error) {
+	if m.CreateUserCharacterFunc == nil {
+		panic("ProgressRepo.CreateUserCharacter called but CreateUserCharacterFunc is not set")
+	}
+	return m.CreateUserCharacterFunc(userCharacter, outbox...)
+}
+
+func (m *ProgressRepo) CreateUserProgress(progress *model.UserProgress) (*model.UserProgress, error) {
+	if m.CreateUserProgressFunc == nil {
+		panic("ProgressRepo.CreateUserProgress called but CreateUserProgressFunc is not set")
+	}
+	return m.CreateUserProgressFunc(progress)
+}
+
+func (m *ProgressRepo) CreateXPTransaction(txn *model.XPTransaction) error {
+	if m.CreateXPTransactionFunc == nil {
+		panic("ProgressRepo.CreateXPTransaction called but CreateXPTransactionFunc is not set")
+	}
+	return m.CreateXPTransactionFunc(txn)
+}
+
+func (m *ProgressRepo) DeleteFavoriteCharacter(userID, characterID string) (bool, error) {
+	if m.DeleteFavoriteCharacterFunc == nil {
+		panic("ProgressRepo.DeleteFavoriteCharacter called but DeleteFavoriteCharacterFunc is not set")
+	}
+	return m.DeleteFavoriteCharacterFunc(userID, characterID)
+}
+
+func (m *ProgressRepo) DeleteLessonBookmark(userID, lessonID string) (bool, error) {
+	if m.DeleteLessonBookmarkFunc == nil {
+		panic("ProgressRepo.DeleteLessonBookmark called but DeleteLessonBookmarkFunc is not set")
+	}
+	return m.DeleteLessonBookmarkFunc(userID, lessonID)
+}
+
+func (m *ProgressRepo) DeleteLessonSession(userID, lessonID string) error {
+	if m.DeleteLessonSessionFunc == nil {
+		panic("ProgressRepo.DeleteLessonSession called but DeleteLessonSessionFunc is not set")
+	}
+	return m.DeleteLessonSessionFunc(userID, lessonID)
+}
+
+func (m *ProgressRepo) GetAllTimeLeaderboard(limit int) ([]model.UserProgress, error) {
+	if m.GetAllTimeLeaderboardFunc == nil {
+		panic("ProgressRepo.GetAllTimeLeaderboard called but GetAllTimeLeaderboardFunc is not set")
+	}
+	return m.GetAllTimeLeaderboardFunc(limit)
+}
+
+func (m *ProgressRepo) GetCompletedLessonAttempts(userID string) ([]model.UserLessonAttempt, error) {
+	if m.GetCompletedLessonAttemptsFunc == nil {
+		panic("ProgressRepo.GetCompletedLessonAttempts called but GetCompletedLessonAttemptsFunc is not set")
+	}
+	return m.GetCompletedLessonAttemptsFunc(userID)
+}
+
+func (m *ProgressRepo) GetCompletedLessonIDs(userID string) ([]string, error) {
+	if m.GetCompletedLessonIDsFunc == nil {
+		panic("ProgressRepo.GetCompletedLessonIDs called but GetCompletedLessonIDsFunc is not set")
+	}
+	return m.GetCompletedLessonIDsFunc(userID)
+}
+
+func (m *ProgressRepo) GetCompletionFlag(id string) (*model.CompletionFlag, error) {
+	if m.GetCompletionFlagFunc == nil {
+		panic("ProgressRepo.GetCompletionFlag called but GetCompletionFlagFunc is not set")
+	}
+	return m.GetCompletionFlagFunc(id)
+}
+
+func (m *ProgressRepo) GetCompletionFlags(status string, page, limit int) ([]model.CompletionFlag, int64, error) {
+	if m.GetCompletionFlagsFunc == nil {
+		panic("ProgressRepo.GetCompletionFlags called but GetCompletionFlagsFunc is not set")
+	}
+	return m.GetCompletionFlagsFunc(status, page, limit)
+}
+
+func (m *ProgressRepo) GetCompletionFlagsBetween(userID string, from, to time.Time) ([]model.CompletionFlag, error) {
+	if m.GetCompletionFlagsBetweenFunc == nil {
+		panic("ProgressRepo.GetCompletionFlagsBetween called but GetCompletionFlagsBetweenFunc is not set")
+	}
+	return m.GetCompletionFlagsBetweenFunc(userID, from, to)
+}
+
+func (m *ProgressRepo) GetFavoriteCharacterIDs(userID string) ([]string, error) {
+	if m.GetFavoriteCharacterIDsFunc == nil {
+		panic("ProgressRepo.GetFavoriteCharacterIDs called but GetFavoriteCharacterIDsFunc is not set")
+	}
+	return m.GetFavoriteCharacterIDsFunc(userID)
+}
+
+func (m *ProgressRepo) GetHeartTransactions(userID string, page, limit int) ([]model.HeartTransaction, int64, error) {
+	if m.GetHeartTransactionsFunc == nil {
+		panic("ProgressRepo.GetHeartTransactions called but GetHeartTransactionsFunc is not set")
+	}
+	return m.GetHeartTransactionsFunc(userID, page, limit)
+}
+
+func (m *ProgressRepo) GetItemTransactions(userID string, page, limit int) ([]model.ItemTransaction, int64, error) {
+	if m.GetItemTransactionsFunc == nil {
+		panic("ProgressRepo.GetItemTransactions called but GetItemTransactionsFunc is not set")
+	}
+	return m.GetItemTransactionsFunc(userID, page, limit)
+}
+
+func (m *ProgressRepo) GetLastLessonCompletion(userID string) (*model.UserLessonCompletion, error) {
+	if m.GetLastLessonCompletionFunc == nil {
+		panic("ProgressRepo.GetLastLessonCompletion called but GetLastLessonCompletionFunc is not set")
+	}
+	return m.GetLastLessonCompletionFunc(userID)
+}
+
+func (m *ProgressRepo) GetLeaderboardAnomalies(status string, page, limit int) ([]model.LeaderboardAnomaly, int64, error) {
+	if m.GetLeaderboardAnomaliesFunc == nil {
+		panic("ProgressRepo.GetLeaderboardAnomalies called but GetLeaderboardAnomaliesFunc is not set")
+	}
+	return m.GetLeaderboardAnomaliesFunc(status, page, limit)
+}
+
+func (m *ProgressRepo) GetLeaderboardAnomaly(id string) (*model.LeaderboardAnomaly, error) {
+	if m.GetLeaderboardAnomalyFunc == nil {
+		panic("ProgressRepo.GetLeaderboardAnomaly called but GetLeaderboardAnomalyFunc is not set")
+	}
+	return m.GetLeaderboardAnomalyFunc(id)
+}
+
+func (m *ProgressRepo) GetLeaderboardEntry(leaderboardType, userID string, since time.Time) (*model.LeaderboardEntry, int, error) {
+	if m.GetLeaderboardEntryFunc == nil {
+		panic("ProgressRepo.GetLeaderboardEntry called but GetLeaderboardEntryFunc is not set")
+	}
+	return m.GetLeaderboardEntryFunc(leaderboardType, userID, since)
+}
+
+func (m *ProgressRepo) GetLeaderboardProfiles(userIDs []string) ([]model.LeaderboardProfile, error) {
+	if m.GetLeaderboardProfilesFunc == nil {
+		panic("ProgressRepo.GetLeaderboardProfiles called but GetLeaderboardProfilesFunc is not set")
+	}
+	return m.GetLeaderboardProfilesFunc(userIDs)
+}
+
+func (m *ProgressRepo) GetLessonAidUses(userID, lessonID string, sessionStartedAt time.Time) ([]model.LessonAidUse, error) {
+	if m.GetLessonAidUsesFunc == nil {
+		panic("ProgressRepo.GetLessonAidUses called but GetLessonAidUsesFunc is not set")
+	}
+	return m.GetLessonAidUsesFunc(userID, lessonID, sessionStartedAt)
+}
+
+func (m *ProgressRepo) GetLessonCompletionsBetween(userID string, from, to time.Time) ([]model.UserLessonCompletion, error) {
+	if m.GetLessonCompletionsBetweenFunc == nil {
+		panic("ProgressRepo.GetLessonCompletionsBetween called but GetLessonCompletionsBetweenFunc is not set")
+	}
+	return m.GetLessonCompletionsBetweenFunc(userID, from, to)
+}
+
+func (m *ProgressRepo) GetLessonSession(userID, lessonID string) (*model.LessonSession, error) {
+	if m.GetLessonSessionFunc == nil {
+		panic("ProgressRepo.GetLessonSession called but GetLessonSessionFunc is not set")
+	}
+	return m.GetLessonSessionFunc(userID, lessonID)
+}
+
+func (m *ProgressRepo) GetLessonSessionUserIDs(lessonID string, activeSince time.Time) ([]string, error) {
+	if m.GetLessonSessionUserIDsFunc == nil {
+		panic("ProgressRepo.GetLessonSessionUserIDs called but GetLessonSessionUserIDsFunc is not set")
+	}
+	return m.GetLessonSessionUserIDsFunc(lessonID, activeSince)
+}
+
+func (m *ProgressRepo) GetLessonVideoProgress(userID, lessonID string) (*model.LessonVideoProgress, error) {
+	if m.GetLessonVideoProgressFunc == nil {
+		panic("ProgressRepo.GetLessonVideoProgress called but GetLessonVideoProgressFunc is not set")
+	}
+	return m.GetLessonVideoProgressFunc(userID, lessonID)
+}
+
+func (m *ProgressRepo) GetMonthlyLeaderboard(limit int) ([]model.UserProgress, error) {
+	if m.GetMonthlyLeaderboardFunc == nil {
+		panic("ProgressRepo.GetMonthlyLeaderboard called but GetMonthlyLeaderboardFunc is not set")
+	}
+	return m.GetMonthlyLeaderboardFunc(limit)
+}
+
+func (m *ProgressRepo) GetProgress(sessionID string) (*model.GuestProgress, error) {
+	if m.GetProgressFunc == nil {
+		panic("ProgressRepo.GetProgress called but GetProgressFunc is not set")
+	}
+	return m.GetProgressFunc(sessionID)
+}
+
+func (m *ProgressRepo) GetProgressUserIDs() ([]string, error) {
+	if m.GetProgressUserIDsFunc == nil {
+		panic("ProgressRepo.GetProgressUserIDs called but GetProgressUserIDsFunc is not set")
+	}
+	return m.GetProgressUserIDsFunc()
+}
+
+func (m *ProgressRepo) GetRankedLeaderboard(leaderboardType string, since time.Time, limit int) ([]model.LeaderboardEntry, error) {
+	if m.GetRankedLeaderboardFunc == nil {
+		panic("ProgressRepo.GetRankedLeaderboard called but GetRankedLeaderboardFunc is not set")
+	}
+	return m.GetRankedLeaderboardFunc(leaderboardType, since, limit)
+}
+
+func (m *ProgressRepo) GetSpiritsByUserIDs(userIDs []string) ([]model.Spirit, error) {
+	if m.GetSpiritsByUserIDsFunc == nil {
+		panic("ProgressRepo.GetSpiritsByUserIDs called but GetSpiritsByUserIDsFunc is not set")
+	}
+	return m.GetSpiritsByUserIDsFunc(userIDs)
+}
+
+func (m *ProgressRepo) GetUnlockedCharacterIDs(userID string) ([]string, error) {
+	if m.GetUnlockedCharacterIDsFunc == nil {
+		panic("ProgressRepo.GetUnlockedCharacterIDs called but GetUnlockedCharacterIDsFunc is not set")
+	}
+	return m.GetUnlockedCharacterIDsFunc(userID)
+}
+
+func (m *ProgressRepo) GetUserAchievements(userID string) ([]model.UserAchievement, error) {
+	if m.GetUserAchievementsFunc == nil {
+		panic("ProgressRepo.GetUserAchievements called but GetUserAchievementsFunc is not set")
+	}
+	return m.GetUserAchievementsFunc(userID)
+}
+
+func (m *ProgressRepo) GetUserCharacters(userID string) ([]model.UserCharacter, error) {
+	if m.GetUserCharactersFunc == nil {
+		panic("ProgressRepo.GetUserCharacters called but GetUserCharactersFunc is not set")
+	}
+	return m.GetUserCharactersFunc(userID)
+}
+
+func (m *ProgressRepo) GetUserLessonBookmarks(userID string, page, limit int) ([]model.UserLessonBookmark, int64, error) {
+	if m.GetUserLessonBookmarksFunc == nil {
+		panic("ProgressRepo.GetUserLessonBookmarks called but GetUserLessonBookmarksFunc is not set")
+	}
+	return m.GetUserLessonBookmarksFunc(userID, page, limit)
+}
+
+func (m *ProgressRepo) GetUserProgress(userID string) (*model.UserProgress, error) {
+	if m.GetUserProgressFunc == nil {
+		panic("ProgressRepo.GetUserProgress called but GetUserProgressFunc is not set")
+	}
+	return m.GetUserProgressFunc(userID)
+}
+
+func (m *ProgressRepo) GetUserQuestionAnswers(userID, lessonID string) ([]model.UserQuestionAnswer, error) {
+	if m.GetUserQuestionAnswersFunc == nil {
+		panic("ProgressRepo.GetUserQuestionAnswers called but GetUserQuestionAnswersFunc is not set")
+	}
+	return m.GetUserQuestionAnswersFunc(userID, lessonID)
+}
+
+func (m *ProgressRepo) GetUserRank(userID string) (int, error) {
+	if m.GetUserRankFunc == nil {
+		panic("ProgressRepo.GetUserRank called but GetUserRankFunc is not set")
+	}
+	return m.GetUserRankFunc(userID)
+}
+
+func (m *ProgressRepo) GetUserSpirit(userID string) (*model.Spirit, error) {
+	if m.GetUserSpiritFunc == nil {
+		panic("ProgressRepo.GetUserSpirit called but GetUserSpiritFunc is not set")
+	}
+	return m.GetUserSpiritFunc(userID)
+}
+
+func (m *ProgressRepo) GetUsersForHeartReset(since time.Time) ([]model.UserProgress, error) {
+	if m.GetUsersForHeartResetFunc == nil {
+		panic("ProgressRepo.GetUsersForHeartReset called but GetUsersForHeartResetFunc is not set")
+	}
+	return m.GetUsersForHeartResetFunc(since)
+}
+
+func (m *ProgressRepo) GetWeeklyLeaderboard(limit int) ([]model.UserProgress, error) {
+	if m.GetWeeklyLeaderboardFunc == nil {
+		panic("ProgressRepo.GetWeeklyLeaderboard called but GetWeeklyLeaderboardFunc is not set")
+	}
+	return m.GetWeeklyLeaderboardFunc(limit)
+}
+
+func (m *ProgressRepo) GetXPGainsAbove(since time.Time, threshold int) ([]model.XPGain, error) {
+	if m.GetXPGainsAboveFunc == nil {
+		panic("ProgressRepo.GetXPGainsAbove called but GetXPGainsAboveFunc is not set")
+	}
+	return m.GetXPGainsAboveFunc(since, threshold)
+}
+
+func (m *ProgressRepo) GetXPLedgerMismatches() ([]model.XPLedgerMismatch, error) {
+	if m.GetXPLedgerMismatchesFunc == nil {
+		panic("ProgressRepo.GetXPLedgerMismatches called but GetXPLedgerMismatchesFunc is not set")
+	}
+	return m.GetXPLedgerMismatchesFunc()
+}
+
+func (m *ProgressRepo) GetXPTransactions(userID string, page, limit int) ([]model.XPTransaction, int64, error) {
+	if m.GetXPTransactionsFunc == nil {
+		panic("ProgressRepo.GetXPTransactions called but GetXPTransactionsFunc is not set")
+	}
+	return m.GetXPTransactionsFunc(userID, page, limit)
+}
+
+func (m *ProgressRepo) GetXPTransactionsBetween(userID string, from, to time.Time) ([]model.XPTransaction, error) {
+	if m.GetXPTransactionsBetweenFunc == nil {
+		panic("ProgressRepo.GetXPTransactionsBetween called but GetXPTransactionsBetweenFunc is not set")
+	}
+	return m.GetXPTransactionsBetweenFunc(userID, from, to)
+}
+
+func (m *ProgressRepo) GetXPTransactionsBySource(userID, source string) ([]model.XPTransaction, error) {
+	if m.GetXPTransactionsBySourceFunc == nil {
+		panic("ProgressRepo.GetXPTransactionsBySource called but GetXPTransactionsBySourceFunc is not set")
+	}
+	return m.GetXPTransactionsBySourceFunc(userID, source)
+}
+
+func (m *ProgressRepo) GrantUserItem(txn *model.ItemTransaction) (bool, error) {
+	if m.GrantUserItemFunc == nil {
+		panic("ProgressRepo.GrantUserItem called but GrantUserItemFunc is not set")
+	}
+	return m.GrantUserItemFunc(txn)
+}
+
+func (m *ProgressRepo) HasCompletedLesson(userID, lessonID string) (bool, error) {
+	if m.HasCompletedLessonFunc == nil {
+		panic("ProgressRepo.HasCompletedLesson called but HasCompletedLessonFunc is not set")
+	}
+	return m.HasCompletedLessonFunc(userID, lessonID)
+}
+
+func (m *ProgressRepo) HasPendingLeaderboardAnomaly(userID string) (bool, error) {
+	if m.HasPendingLeaderboardAnomalyFunc == nil {
+		panic("ProgressRepo.HasPendingLeaderboardAnomaly called but HasPendingLeaderboardAnomalyFunc is not set")
+	}
+	return m.HasPendingLeaderboardAnomalyFunc(userID)
+}
+
+func (m *ProgressRepo) HasUserCharacter(userID, characterID string) (bool, error) {
+	if m.HasUserCharacterFunc == nil {
+		panic("ProgressRepo.HasUserCharacter called but HasUserCharacterFunc is not set")
+	}
+	return m.HasUserCharacterFunc(userID, characterID)
+}
+
+func (m *ProgressRepo) MarkCollectionViewed(userID string, viewedAt time.Time) error {
+	if m.MarkCollectionViewedFunc == nil {
+		panic("ProgressRepo.MarkCollectionViewed called but MarkCollectionViewedFunc is not set")
+	}
+	return m.MarkCollectionViewedFunc(userID, viewedAt)
+}
+
+func (m *ProgressRepo) MigrateSpirit(spirit *model.Spirit, userUpdates map[string]interface{}) error {
+	if m.MigrateSpiritFunc == nil {
+		panic("ProgressRepo.MigrateSpirit called but MigrateSpiritFunc is not set")
+	}
+	return m.MigrateSpiritFunc(spirit, userUpdates)
+}
+
+func (m *ProgressRepo) ModifyUserProgress(userID string, modify func(progress *model.UserProgress) (*model.ProgressChange, error)) (*model.UserProgress, error) {
+	if m.ModifyUserProgressFunc == nil {
+		panic("ProgressRepo.ModifyUserProgress called but ModifyUserProgressFunc is not set")
+	}
+	return m.ModifyUserProgressFunc(userID, modify)
+}
+
+func (m *ProgressRepo) RefreshLeaderboardProfile(userID string) error {
+	if m.RefreshLeaderboardProfileFunc == nil {
+		panic("ProgressRepo.RefreshLeaderboardProfile called but RefreshLeaderboardProfileFunc is not set")
+	}
+	return m.RefreshLeaderboardProfileFunc(userID)
+}
+
+func (m *ProgressRepo) ResetLessonSession(userID, lessonID string) error {
+	if m.ResetLessonSessionFunc == nil {
+		panic("ProgressRepo.ResetLessonSession called but ResetLessonSessionFunc is not set")
+	}
+	return m.ResetLessonSessionFunc(userID, lessonID)
+}
+
+func (m *ProgressRepo) ResolveCompletionFlags(flagID, userID, status, reviewerID, note string) (int64, error) {
+	if m.ResolveCompletionFlagsFunc == nil {
+		panic("ProgressRepo.ResolveCompletionFlags called but ResolveCompletionFlagsFunc is not set")
+	}
+	return m.ResolveCompletionFlagsFunc(flagID, userID, status, reviewerID, note)
+}
+
+func (m *ProgressRepo) ResolveLeaderboardAnomalies(anomalyID, userID, status, reviewerID, note string, penaltyXP int) error {
+	if m.ResolveLeaderboardAnomaliesFunc == nil {
+		panic("ProgressRepo.ResolveLeaderboardAnomalies called but ResolveLeaderboardAnomaliesFunc is not set")
+	}
+	return m.ResolveLeaderboardAnomaliesFunc(anomalyID, userID, status, reviewerID, note, penaltyXP)
+}
+
+func (m *ProgressRepo) RevokeUserItem(txn *model.ItemTransaction) (bool, error) {
+	if m.RevokeUserItemFunc == nil {
+		panic("ProgressRepo.RevokeUserItem called but RevokeUserItemFunc is not set")
+	}
+	return m.RevokeUserItemFunc(txn)
+}
+
+func (m *ProgressRepo) SaveLeaderboardProfiles(profiles []model.LeaderboardProfile) error {
+	if m.SaveLeaderboardProfilesFunc == nil {
+		panic("ProgressRepo.SaveLeaderboardProfiles called but SaveLeaderboardProfilesFunc is not set")
+	}
+	return m.SaveLeaderboardProfilesFunc(profiles)
+}
+
+func (m *ProgressRepo) SaveLessonSession(session *model.LessonSession) error {
+	if m.SaveLessonSessionFunc == nil {
+		panic("ProgressRepo.SaveLessonSession called but SaveLessonSessionFunc is not set")
+	}
+	return m.SaveLessonSessionFunc(session)
+}
+
+func (m *ProgressRepo) SaveLessonVideoProgress(progress *model.LessonVideoProgress) error {
+	if m.SaveLessonVideoProgressFunc == nil {
+		panic("ProgressRepo.SaveLessonVideoProgress called but SaveLessonVideoProgressFunc is not set")
+	}
+	return m.SaveLessonVideoProgressFunc(progress)
+}
+
+func (m *ProgressRepo) SaveUserQuestionAnswer(answer *model.UserQuestionAnswer) error {
+	if m.SaveUserQuestionAnswerFunc == nil {
+		panic("ProgressRepo.SaveUserQuestionAnswer called but SaveUserQuestionAnswerFunc is not set")
+	}
+	return m.SaveUserQuestionAnswerFunc(answer)
+}
+
+func (m *ProgressRepo) SumXPTransactions(userID string) (int, error) {
+	if m.SumXPTransactionsFunc == nil {
+		panic("ProgressRepo.SumXPTransactions called but SumXPTransactionsFunc is not set")
+	}
+	return m.SumXPTransactionsFunc(userID)
+}
+
+func (m *ProgressRepo) UpdateSpirit(spirit *model.Spirit) error {
+	if m.UpdateSpiritFunc == nil {
+		panic("ProgressRepo.UpdateSpirit called but UpdateSpiritFunc is not set")
+	}
+	return m.UpdateSpiritFunc(spirit)
+}
+
+func (m *ProgressRepo) UseLessonAid(use *model.LessonAidUse, limit, coinPrice int) (*model.LessonAidUse, bool, error) {
+	if m.UseLessonAidFunc == nil {
+		panic("ProgressRepo.UseLessonAid called but UseLessonAidFunc is not set")
+	}
+	return m.UseLessonAidFunc(use, limit, coinPrice)
+}
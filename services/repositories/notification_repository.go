@@ -0,0 +1,178 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared/ids"
+	"gorm.io/gorm"
+)
+
+type NotificationRepository struct {
+	BaseRepository
+}
+
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+func (ds *NotificationRepository) CreateNotification(notification *model.Notification) error {
+	if notification.ID == "" {
+		notification.ID = ids.New()
+	}
+	notification.CreatedAt = time.Now()
+
+	return ds.db.Create(notification).Error
+}
+
+func (ds *NotificationRepository) GetUserNotifications(userID string, unreadOnly bool, page, limit int) ([]model.Notification, int64, error) {
+	var notifications []model.Notification
+	var total int64
+
+	query := ds.db.Model(&model.Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("is_read = ?", false)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	if err := query.Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&notifications).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return notifications, total, nil
+}
+
+func (ds *NotificationRepository) CountUnread(userID string) (int64, error) {
+	var count int64
+	if err := ds.db.Model(&model.Notification{}).
+		Where("user_id = ? AND is_read = ?", userID, false).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// MarkRead marks one of the user's notifications as read and reports whether it exists
+func (ds *NotificationRepository) MarkRead(userID, notificationID string) (bool, error) {
+	var notification model.Notification
+	if err := ds.db.Where("id = ? AND user_id = ?", notificationID, userID).First(&notification).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if notification.IsRead {
+		return true, nil
+	}
+
+	now := time.Now()
+	if err := ds.db.Model(&notification).Updates(map[string]interface{}{
+		"is_read": true,
+		"read_at": now,
+	}).Error; err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (ds *NotificationRepository) MarkAllRead(userID string) (int64, error) {
+	result := ds.db.Model(&model.Notification{}).
+		Where("user_id = ? AND is_read = ?", userID, false).
+		Updates(map[string]interface{}{
+			"is_read": true,
+			"read_at": time.Now(),
+		})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
+
+// CreateBroadcastNotification adds the same inbox entry for every active user in one
+// statement and returns how many users received it
+func (ds *NotificationRepository) CreateBroadcastNotification(notificationType, title, body string, data []byte) (int64, error) {
+	result := ds.db.Exec(`
+		INSERT INTO notifications (id, user_id, type, title, body, data, is_read, created_at)
+		SELECT gen_random_uuid()::text, id, ?, ?, ?, ?, false, ?
+		FROM users WHERE is_active = true AND deleted_at IS NULL`,
+		notificationType, title, body, data, time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// ==================== STUDY REMINDER METHODS ====================
+
+func (ds *NotificationRepository) GetStudyReminder(userID string) (*model.StudyReminder, error) {
+	var reminder model.StudyReminder
+	if err := ds.db.Where("user_id = ?", userID).First(&reminder).Error; err != nil {
+		return nil, err
+	}
+	return &reminder, nil
+}
+
+func (ds *NotificationRepository) SaveStudyReminder(reminder *model.StudyReminder) error {
+	reminder.UpdatedAt = time.Now()
+	if reminder.ID == "" {
+		reminder.ID = ids.New()
+		reminder.CreatedAt = reminder.UpdatedAt
+		return ds.db.Create(reminder).Error
+	}
+	return ds.db.Save(reminder).Error
+}
+
+func (ds *NotificationRepository) DeleteStudyReminder(userID string) (bool, error) {
+	result := ds.db.Where("user_id = ?", userID).Delete(&model.StudyReminder{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetDueStudyReminders returns enabled reminders of active users that are due at now,
+// oldest first, with their user
+func (ds *NotificationRepository) GetDueStudyReminders(now time.Time, limit int) ([]model.StudyReminder, error) {
+	var reminders []model.StudyReminder
+	err := ds.db.Preload("User").
+		Joins("JOIN users ON users.id = study_reminders.user_id").
+		Where("study_reminders.enabled = ? AND study_reminders.next_reminder_at <= ?", true, now).
+		Where("users.is_active = ? AND users.deleted_at IS NULL", true).
+		Order("study_reminders.next_reminder_at").
+		Limit(limit).
+		Find(&reminders).Error
+	return reminders, err
+}
+
+// ClaimStudyReminder moves a reminder due at dueAt on to its next time, together with
+// the outbox messages that deliver it. sentAt is nil when the reminder was skipped. It
+// reports false if another instance claimed it first.
+func (ds *NotificationRepository) ClaimStudyReminder(reminderID string, dueAt time.Time, next, sentAt *time.Time, outbox ...*model.OutboxMessage) (bool, error) {
+	claimed := false
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"next_reminder_at": next,
+			"snoozed_until":    nil,
+			"updated_at":       time.Now(),
+		}
+		if sentAt != nil {
+			updates["last_sent_at"] = sentAt
+		}
+
+		result := tx.Model(&model.StudyReminder{}).
+			Where("id = ? AND next_reminder_at = ?", reminderID, dueAt).
+			Updates(updates)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		claimed = true
+
+		return insertOutbox(tx, outbox)
+	})
+	return claimed, err
+}
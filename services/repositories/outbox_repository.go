@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/model"
+	"gorm.io/gorm"
+)
+
+type OutboxRepository struct {
+	BaseRepository
+}
+
+func NewOutboxRepository(db *gorm.DB) *OutboxRepository {
+	return &OutboxRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// insertOutbox writes messages as part of tx. Repository methods that change state
+// take the messages describing the change and call this inside their transaction.
+func insertOutbox(tx *gorm.DB, messages []*model.OutboxMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	return tx.Create(messages).Error
+}
+
+// ClaimDue returns pending messages whose next attempt is due, pushing their next
+// attempt back by lease so a slow dispatch is not picked up twice
+func (ds *OutboxRepository) ClaimDue(limit int, lease time.Duration) ([]model.OutboxMessage, error) {
+	var messages []model.OutboxMessage
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw(`
+			SELECT * FROM outbox_messages
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED`, model.OutboxStatusPending, time.Now(), limit).
+			Scan(&messages).Error; err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+
+		ids := make([]string, len(messages))
+		for i, m := range messages {
+			ids[i] = m.ID
+		}
+		return tx.Model(&model.OutboxMessage{}).Where("id IN ?", ids).
+			Update("next_attempt_at", time.Now().Add(lease)).Error
+	})
+	return messages, err
+}
+
+func (ds *OutboxRepository) UpdateMessage(message *model.OutboxMessage) error {
+	return ds.db.Model(message).Select("status", "attempts", "next_attempt_at", "last_error", "sent_at").
+		Updates(message).Error
+}
+
+// CountPending counts undelivered messages whose topic starts with prefix
+func (ds *OutboxRepository) CountPending(topicPrefix string) (int64, error) {
+	var count int64
+	err := ds.db.Model(&model.OutboxMessage{}).
+		Where("status = ? AND topic LIKE ?", model.OutboxStatusPending, topicPrefix+"%").
+		Count(&count).Error
+	return count, err
+}
+
+// DeleteSentBefore removes dispatched messages older than cutoff and returns how many went
+func (ds *OutboxRepository) DeleteSentBefore(cutoff time.Time) (int64, error) {
+	result := ds.db.Where("status = ? AND sent_at < ?", model.OutboxStatusSent, cutoff).
+		Delete(&model.OutboxMessage{})
+	return result.RowsAffected, result.Error
+}
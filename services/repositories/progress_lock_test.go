@@ -0,0 +1,81 @@
+//go:build dbtest
+
+package repositories
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lac-hong-legacy/ven_api/model"
+)
+
+// A heart refill bought while a lesson completion holds the user's progress is applied
+// after the completion is stored, rather than overwritten by it with the coins spent
+func TestPurchaseDuringLessonCompletion(t *testing.T) {
+	db := openTestDB(t)
+	contentRepo := NewContentRepository(db)
+	shopRepo := NewShopRepository(db)
+
+	id := uuid.NewString()
+	user := model.User{ID: id, Username: "progress_" + id[:8], Email: "progress_" + id[:8] + "@example.com", Password: "x"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	t.Cleanup(func() {
+		for _, table := range []interface{}{&model.UserLessonCompletion{}, &model.XPTransaction{}, &model.HeartTransaction{},
+			&model.CoinTransaction{}, &model.ShopPurchase{}, &model.Wallet{}, &model.UserProgress{}} {
+			db.Where("user_id = ?", id).Delete(table)
+		}
+		db.Delete(&model.User{}, "id = ?", id)
+	})
+	if err := db.Create(&model.UserProgress{ID: uuid.NewString(), UserID: id, Hearts: 1, MaxHearts: 5, Level: 1}).Error; err != nil {
+		t.Fatalf("create progress: %v", err)
+	}
+	if err := db.Create(&model.Wallet{UserID: id, Balance: 100}).Error; err != nil {
+		t.Fatalf("create wallet: %v", err)
+	}
+
+	completing := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, err := contentRepo.CompleteLesson(&model.UserLessonCompletion{UserID: id, LessonID: "lesson_1", Score: 80},
+			func(progress *model.UserProgress, firstCompletion bool) *model.ProgressChange {
+				// Let the purchase run against the progress read here
+				close(completing)
+				time.Sleep(200 * time.Millisecond)
+				progress.XP += 50
+				return &model.ProgressChange{XP: &model.XPTransaction{Source: model.XPSourceLesson, Amount: 50, ReferenceID: "lesson_1"}}
+			})
+		if err != nil {
+			t.Errorf("complete lesson: %v", err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		<-completing
+		_, _, err := shopRepo.Purchase(&model.ShopPurchase{
+			UserID:         id,
+			IdempotencyKey: "refill_1",
+			ItemID:         "heart_refill",
+			Kind:           model.ShopItemHeartRefill,
+			Quantity:       3,
+			Price:          30,
+		}, 3)
+		if err != nil {
+			t.Errorf("purchase: %v", err)
+		}
+	}()
+	wg.Wait()
+
+	var progress model.UserProgress
+	if err := db.Where("user_id = ?", id).First(&progress).Error; err != nil {
+		t.Fatal(err)
+	}
+	if progress.Hearts != 4 || progress.XP != 50 {
+		t.Errorf("stored %d hearts and %d XP, want 4 hearts and 50 XP", progress.Hearts, progress.XP)
+	}
+}
@@ -4,8 +4,8 @@ import (
 	"errors"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared/ids"
 	"gorm.io/gorm"
 )
 
@@ -36,8 +36,7 @@ func (s *RateLimitRepository) GetRateLimit(identifier, endpointType string) (*mo
 func (s *RateLimitRepository) SaveRateLimit(rateLimit *model.RateLimit) error {
 	// Generate ID if not set
 	if rateLimit.ID == "" {
-		id, _ := uuid.NewV7()
-		rateLimit.ID = id.String()
+		rateLimit.ID = ids.New()
 	}
 
 	// Set timestamps if not set
@@ -57,19 +56,26 @@ func (s *RateLimitRepository) SaveRateLimit(rateLimit *model.RateLimit) error {
 func (s *RateLimitRepository) UpdateRateLimit(rateLimit *model.RateLimit) error {
 	// Update specific fields using GORM's Updates method
 	err := s.db.Model(rateLimit).Where("id = ?", rateLimit.ID).Updates(map[string]interface{}{
-		"request_count": rateLimit.RequestCount,
-		"blocked_until": rateLimit.BlockedUntil,
-		"updated_at":    rateLimit.UpdatedAt,
+		"request_count":  rateLimit.RequestCount,
+		"previous_count": rateLimit.PreviousCount,
+		"window_start":   rateLimit.WindowStart,
+		"blocked_until":  rateLimit.BlockedUntil,
+		"updated_at":     rateLimit.UpdatedAt,
 	}).Error
 
 	return err
 }
 
+func (s *RateLimitRepository) CountBlocked() (int64, error) {
+	var count int64
+	err := s.db.Model(&model.RateLimit{}).Where("blocked_until > ?", time.Now()).Count(&count).Error
+	return count, err
+}
+
 // Cleanup old rate limit records
-func (s *RateLimitRepository) CleanupOldRecords() error {
+func (s *RateLimitRepository) CleanupOldRecords(now time.Time) error {
 	// Remove records older than 7 days and not currently blocked
-	cutoff := time.Now().Add(-7 * 24 * time.Hour)
-	now := time.Now()
+	cutoff := now.Add(-7 * 24 * time.Hour)
 
 	err := s.db.Where("created_at < ? AND (blocked_until IS NULL OR blocked_until < ?)", cutoff, now).
 		Delete(&model.RateLimit{}).Error
@@ -0,0 +1,140 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared/ids"
+	"gorm.io/gorm"
+)
+
+type ResearchRepository struct {
+	BaseRepository
+}
+
+func NewResearchRepository(db *gorm.DB) *ResearchRepository {
+	return &ResearchRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// ==================== EXPORT METHODS ====================
+
+func (ds *ResearchRepository) CreateResearchExport(export *model.ResearchExport) error {
+	if export.ID == "" {
+		export.ID = ids.New()
+	}
+	return ds.db.Create(export).Error
+}
+
+func (ds *ResearchRepository) UpdateResearchExport(export *model.ResearchExport) error {
+	return ds.db.Save(export).Error
+}
+
+func (ds *ResearchRepository) GetResearchExport(exportID string) (*model.ResearchExport, error) {
+	var export model.ResearchExport
+	if err := ds.db.Where("id = ?", exportID).First(&export).Error; err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (ds *ResearchRepository) GetResearchExports(page, limit int) ([]model.ResearchExport, int64, error) {
+	var exports []model.ResearchExport
+	var total int64
+
+	db := ds.db.Model(&model.ResearchExport{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := db.Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		Find(&exports).Error; err != nil {
+		return nil, 0, err
+	}
+	return exports, total, nil
+}
+
+// GetExpiredResearchExports returns completed exports whose archive is past its expiry
+func (ds *ResearchRepository) GetExpiredResearchExports(now time.Time) ([]model.ResearchExport, error) {
+	var exports []model.ResearchExport
+	err := ds.db.Where("status = ? AND expires_at <= ?", model.ResearchExportCompleted, now).
+		Find(&exports).Error
+	return exports, err
+}
+
+func (ds *ResearchRepository) CreateResearchExportAccess(access *model.ResearchExportAccess) error {
+	if access.ID == "" {
+		access.ID = ids.New()
+	}
+	return ds.db.Create(access).Error
+}
+
+// GetResearchExportAccesses returns the audit trail of an export, oldest first
+func (ds *ResearchRepository) GetResearchExportAccesses(exportID string) ([]model.ResearchExportAccess, error) {
+	var accesses []model.ResearchExportAccess
+	err := ds.db.Where("export_id = ?", exportID).Order("created_at ASC, id ASC").Find(&accesses).Error
+	return accesses, err
+}
+
+// ==================== COHORT AGGREGATES ====================
+
+// GetCohortActivity groups learners registered in [from, to) by registration month and
+// counts how many completed lessons and how many still completed one 1, 7 and 30 days
+// after registering. Staff and deleted accounts are left out.
+func (ds *ResearchRepository) GetCohortActivity(from, to, now time.Time) ([]model.CohortActivityRow, error) {
+	var rows []model.CohortActivityRow
+	err := ds.db.Raw(`
+		SELECT to_char(date_trunc('month', u.created_at), 'YYYY-MM') AS cohort,
+			COUNT(*) AS learners,
+			COUNT(c.user_id) AS active_learners,
+			COALESCE(SUM(c.completions), 0) AS completions,
+			COUNT(*) FILTER (WHERE u.created_at <= ?) AS eligible_day1,
+			COUNT(*) FILTER (WHERE u.created_at <= ? AND c.last_completed_at >= u.created_at + INTERVAL '1 day') AS retained_day1,
+			COUNT(*) FILTER (WHERE u.created_at <= ?) AS eligible_day7,
+			COUNT(*) FILTER (WHERE u.created_at <= ? AND c.last_completed_at >= u.created_at + INTERVAL '7 days') AS retained_day7,
+			COUNT(*) FILTER (WHERE u.created_at <= ?) AS eligible_day30,
+			COUNT(*) FILTER (WHERE u.created_at <= ? AND c.last_completed_at >= u.created_at + INTERVAL '30 days') AS retained_day30
+		FROM users u
+		LEFT JOIN (
+			SELECT user_id, COUNT(*) AS completions, MAX(completed_at) AS last_completed_at
+			FROM user_lesson_completions
+			GROUP BY user_id
+		) c ON c.user_id = u.id
+		WHERE u.role = ? AND u.deleted_at IS NULL AND u.created_at >= ? AND u.created_at < ?
+		GROUP BY 1
+		ORDER BY 1
+	`,
+		now.AddDate(0, 0, -1), now.AddDate(0, 0, -1),
+		now.AddDate(0, 0, -7), now.AddDate(0, 0, -7),
+		now.AddDate(0, 0, -30), now.AddDate(0, 0, -30),
+		model.RoleUser, from, to,
+	).Scan(&rows).Error
+	return rows, err
+}
+
+// GetDynastyScores returns the score distribution of lesson completions per dynasty, for
+// learners registered in [from, to)
+func (ds *ResearchRepository) GetDynastyScores(from, to time.Time) ([]model.DynastyScoreRow, error) {
+	var rows []model.DynastyScoreRow
+	err := ds.db.Raw(`
+		SELECT ch.dynasty,
+			COUNT(DISTINCT c.user_id) AS learners,
+			COUNT(*) AS completions,
+			AVG(c.score) AS average_score,
+			COUNT(*) FILTER (WHERE c.score < 50) AS score0_to49,
+			COUNT(*) FILTER (WHERE c.score >= 50 AND c.score < 70) AS score50_to69,
+			COUNT(*) FILTER (WHERE c.score >= 70 AND c.score < 90) AS score70_to89,
+			COUNT(*) FILTER (WHERE c.score >= 90) AS score90_to100
+		FROM user_lesson_completions c
+		JOIN users u ON u.id = c.user_id
+		JOIN lessons l ON l.id = c.lesson_id
+		JOIN characters ch ON ch.id = l.character_id
+		WHERE u.role = ? AND u.deleted_at IS NULL AND u.created_at >= ? AND u.created_at < ?
+		GROUP BY ch.dynasty
+		ORDER BY ch.dynasty
+	`, model.RoleUser, from, to).Scan(&rows).Error
+	return rows, err
+}
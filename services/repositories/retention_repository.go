@@ -0,0 +1,268 @@
+package repositories
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared/ids"
+	"gorm.io/gorm"
+)
+
+type RetentionRepository struct {
+	BaseRepository
+}
+
+func NewRetentionRepository(db *gorm.DB) *RetentionRepository {
+	return &RetentionRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// GetRetentionPolicies returns the policy of every retention table, creating missing
+// ones with defaults
+func (ds *RetentionRepository) GetRetentionPolicies() ([]model.RetentionPolicy, error) {
+	policies := make([]model.RetentionPolicy, 0, len(model.RetentionTables))
+	for _, table := range model.RetentionTables {
+		policy, err := ds.GetRetentionPolicy(table)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, *policy)
+	}
+	return policies, nil
+}
+
+func (ds *RetentionRepository) GetRetentionPolicy(table string) (*model.RetentionPolicy, error) {
+	policy := model.DefaultRetentionPolicy(table)
+	if err := ds.db.Where("table_name = ?", table).FirstOrCreate(&policy).Error; err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func (ds *RetentionRepository) UpdateRetentionPolicy(policy *model.RetentionPolicy) error {
+	return ds.db.Save(policy).Error
+}
+
+// PruneOlderThan folds up to limit rows of table last updated before cutoff into the
+// daily stats and deletes them, returning how many were deleted
+func (ds *RetentionRepository) PruneOlderThan(table string, cutoff time.Time, limit int) (int64, error) {
+	return ds.prune(table,
+		fmt.Sprintf(`SELECT id FROM %s WHERE updated_at < ? ORDER BY updated_at LIMIT ?`, table),
+		cutoff, limit)
+}
+
+// PruneOverQuota folds and deletes up to limit rows of table that are older than each
+// user's newest maxPerUser rows
+func (ds *RetentionRepository) PruneOverQuota(table string, maxPerUser, limit int) (int64, error) {
+	return ds.prune(table,
+		fmt.Sprintf(`SELECT id FROM (
+			SELECT id, row_number() OVER (PARTITION BY user_id ORDER BY updated_at DESC) AS rn FROM %s
+		) ranked WHERE rn > ? LIMIT ?`, table),
+		maxPerUser, limit)
+}
+
+// prune deletes the rows selected by idQuery and adds them to the stats table in the
+// same statement, so a crash cannot lose rows or count them twice
+func (ds *RetentionRepository) prune(table, idQuery string, args ...interface{}) (int64, error) {
+	var query string
+	switch table {
+	case model.RetentionTableQuestionAnswers:
+		query = fmt.Sprintf(`
+			WITH pruned AS (
+				DELETE FROM user_question_answers WHERE id IN (%s)
+				RETURNING question_id, lesson_id, updated_at, is_correct, points
+			), folded AS (
+				INSERT INTO question_answer_stats (question_id, day, lesson_id, answers, correct, points)
+				SELECT question_id, updated_at::date, min(lesson_id), count(*), count(*) FILTER (WHERE is_correct), sum(points)
+				FROM pruned GROUP BY question_id, updated_at::date
+				ON CONFLICT (question_id, day) DO UPDATE SET
+					answers = question_answer_stats.answers + EXCLUDED.answers,
+					correct = question_answer_stats.correct + EXCLUDED.correct,
+					points = question_answer_stats.points + EXCLUDED.points
+			)
+			SELECT count(*) FROM pruned`, idQuery)
+	case model.RetentionTableLessonAttempts, model.RetentionTableGuestLessonAttempts:
+		source := model.AttemptSourceUser
+		if table == model.RetentionTableGuestLessonAttempts {
+			source = model.AttemptSourceGuest
+		}
+		query = fmt.Sprintf(`
+			WITH pruned AS (
+				DELETE FROM %s WHERE id IN (%s)
+				RETURNING lesson_id, updated_at, is_completed, score, time_spent, attempts_count
+			), folded AS (
+				INSERT INTO lesson_attempt_stats (lesson_id, day, source, attempts, completed, total_score, total_time_spent)
+				SELECT lesson_id, updated_at::date, ?, sum(attempts_count), count(*) FILTER (WHERE is_completed), sum(score), sum(time_spent)
+				FROM pruned GROUP BY lesson_id, updated_at::date
+				ON CONFLICT (lesson_id, day, source) DO UPDATE SET
+					attempts = lesson_attempt_stats.attempts + EXCLUDED.attempts,
+					completed = lesson_attempt_stats.completed + EXCLUDED.completed,
+					total_score = lesson_attempt_stats.total_score + EXCLUDED.total_score,
+					total_time_spent = lesson_attempt_stats.total_time_spent + EXCLUDED.total_time_spent
+			)
+			SELECT count(*) FROM pruned`, table, idQuery)
+		args = append(args, source)
+	default:
+		return 0, fmt.Errorf("no retention policy for table %s", table)
+	}
+
+	var deleted int64
+	err := ds.db.Raw(query, args...).Scan(&deleted).Error
+	return deleted, err
+}
+
+// GetTableSizes returns the on-disk size of every table in the schema, largest first
+func (ds *RetentionRepository) GetTableSizes() ([]dto.TableSizeResponse, error) {
+	var sizes []dto.TableSizeResponse
+	err := ds.db.Raw(`
+		SELECT c.relname AS "table",
+			pg_total_relation_size(c.oid) AS total_bytes,
+			pg_relation_size(c.oid) AS table_bytes,
+			pg_indexes_size(c.oid) AS index_bytes,
+			GREATEST(c.reltuples, 0)::bigint AS estimated_rows
+		FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = current_schema() AND c.relkind = 'r'
+		ORDER BY total_bytes DESC`).Scan(&sizes).Error
+	return sizes, err
+}
+
+// ==================== ANONYMIZATION METHODS ====================
+
+// GetInactiveUsers returns up to limit regular accounts with an ID after afterID that
+// have not logged in, used a session or played since inactiveSince, ordered by ID.
+// Staff accounts and accounts already anonymized are skipped.
+func (ds *RetentionRepository) GetInactiveUsers(inactiveSince time.Time, afterID string, limit int) ([]model.InactiveUser, error) {
+	var users []model.InactiveUser
+	err := ds.db.Raw(`
+		SELECT id, email, username, last_active_at,
+			CASE WHEN inactivity_warned_at > last_active_at THEN inactivity_warnings ELSE 0 END AS warnings,
+			inactivity_warned_at AS warned_at
+		FROM (
+			SELECT u.id, u.email, u.username, u.inactivity_warnings, u.inactivity_warned_at,
+				GREATEST(u.created_at, u.last_login_at, p.last_activity_date,
+					(SELECT max(s.last_used) FROM user_sessions s WHERE s.user_id = u.id)) AS last_active_at
+			FROM users u
+			LEFT JOIN user_progresses p ON p.user_id = u.id
+			WHERE u.role = ? AND u.anonymized_at IS NULL AND u.deleted_at IS NULL AND u.id > ?
+		) candidates
+		WHERE last_active_at < ?
+		ORDER BY id
+		LIMIT ?`, model.RoleUser, afterID, inactiveSince, limit).Scan(&users).Error
+	return users, err
+}
+
+// ClaimInactivityWarning records that another warning was sent and queues its email.
+// It returns false when the user's warnings changed since they were read, e.g. because
+// another instance warned them first.
+func (ds *RetentionRepository) ClaimInactivityWarning(user *model.InactiveUser, warnings int, outbox ...*model.OutboxMessage) (bool, error) {
+	claimed := false
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.User{}).
+			Where("id = ? AND anonymized_at IS NULL AND inactivity_warned_at IS NOT DISTINCT FROM ?", user.ID, user.WarnedAt).
+			Updates(map[string]interface{}{
+				"inactivity_warnings":  warnings,
+				"inactivity_warned_at": time.Now(),
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		claimed = true
+
+		return insertOutbox(tx, outbox)
+	})
+	return claimed, err
+}
+
+// AnonymizeUser scrubs an account's email, username and credentials, and deletes its
+// sessions, devices, login attempts and other personal records. Progress, completions
+// and other aggregate data are kept. Auth audit entries keep their IP until audit
+// retention removes them, editing them would break the audit hash chain.
+func (ds *RetentionRepository) AnonymizeUser(user *model.InactiveUser, username, email string) (bool, error) {
+	anonymized := false
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&model.User{}).
+			Where("id = ? AND anonymized_at IS NULL", user.ID).
+			Updates(map[string]interface{}{
+				"username":           username,
+				"email":              email,
+				"pending_email":      "",
+				"password":           "",
+				"birth_year":         0,
+				"birth_date":         nil,
+				"is_active":          false,
+				"email_verified":     false,
+				"verification_code":  "",
+				"last_login_ip":      "",
+				"two_factor_enabled": false,
+				"two_factor_secret":  "",
+				"backup_codes":       "",
+				"parental_pin":       "",
+				"timezone":           "",
+				"anonymized_at":      now,
+				"updated_at":         now,
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		anonymized = true
+
+		for _, record := range []interface{}{
+			&model.UserSession{},
+			&model.TrustedDevice{},
+			&model.PasswordResetCode{},
+			&model.EmailChangeRequest{},
+			&model.AccountRecoveryRequest{},
+			&model.StudyReminder{},
+			&model.UserNote{},
+			&model.Certificate{},
+		} {
+			if err := tx.Where("user_id = ?", user.ID).Delete(record).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("email = ?", user.Email).Delete(&model.LoginAttempt{}).Error; err != nil {
+			return err
+		}
+
+		// The chain hashes the client details through a keyed digest, so clearing them
+		// with the key leaves the chain intact
+		if err := tx.Model(&model.AuthAuditLog{}).
+			Where("user_id = ? AND (ip IS NOT NULL OR user_agent IS NOT NULL)", user.ID).
+			Updates(map[string]interface{}{
+				"ip":          nil,
+				"user_agent":  nil,
+				"client_key":  nil,
+				"redacted_at": now,
+			}).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&model.LeaderboardProfile{}).
+			Where("user_id = ?", user.ID).
+			Update("username", username).Error
+	})
+	return anonymized, err
+}
+
+func (ds *RetentionRepository) CreateAnonymizationRun(run *model.AnonymizationRun) error {
+	if run.ID == "" {
+		run.ID = ids.New()
+	}
+	return ds.db.Create(run).Error
+}
+
+func (ds *RetentionRepository) UpdateAnonymizationRun(run *model.AnonymizationRun) error {
+	return ds.db.Save(run).Error
+}
+
+// GetAnonymizationRuns returns the most recent runs first
+func (ds *RetentionRepository) GetAnonymizationRuns(limit int) ([]model.AnonymizationRun, error) {
+	var runs []model.AnonymizationRun
+	err := ds.db.Order("started_at DESC").Limit(limit).Find(&runs).Error
+	return runs, err
+}
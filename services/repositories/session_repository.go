@@ -1,8 +1,8 @@
 package repositories
 
 import (
-	"github.com/google/uuid"
 	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared/ids"
 	"gorm.io/gorm"
 )
 
@@ -26,8 +26,7 @@ func (ds *SessionRepository) GetSessionByDeviceID(deviceID string) (*model.Guest
 }
 
 func (ds *SessionRepository) CreateSession(session *model.GuestSession) (*model.GuestSession, error) {
-	id, _ := uuid.NewV7()
-	session.ID = id.String()
+	session.ID = ids.New()
 	if err := ds.db.Create(session).Error; err != nil {
 		return nil, err
 	}
@@ -40,3 +39,26 @@ func (ds *SessionRepository) UpdateSession(session *model.GuestSession) error {
 	}
 	return nil
 }
+
+func (ds *SessionRepository) GetSessionByID(sessionID string) (*model.GuestSession, error) {
+	var session model.GuestSession
+	if err := ds.db.Where("id = ?", sessionID).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (ds *SessionRepository) GetDeviceAttestation(deviceID string) (*model.DeviceAttestation, error) {
+	var attestation model.DeviceAttestation
+	if err := ds.db.Where("device_id = ?", deviceID).First(&attestation).Error; err != nil {
+		return nil, err
+	}
+	return &attestation, nil
+}
+
+func (ds *SessionRepository) SaveDeviceAttestation(attestation *model.DeviceAttestation) error {
+	if attestation.ID == "" {
+		attestation.ID = ids.New()
+	}
+	return ds.db.Save(attestation).Error
+}
@@ -0,0 +1,285 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared/ids"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Reasons a purchase is refused, found inside its transaction so concurrent purchases
+// can't overspend or overfill
+var (
+	ErrInsufficientCoins = errors.New("insufficient coins")
+	ErrHeartsFull        = errors.New("hearts are full")
+	ErrStreakFreezeLimit = errors.New("streak freeze limit reached")
+	ErrCosmeticOwned     = errors.New("cosmetic already owned")
+)
+
+type ShopRepository struct {
+	BaseRepository
+}
+
+func NewShopRepository(db *gorm.DB) *ShopRepository {
+	return &ShopRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+// ==================== SHOP ITEM METHODS ====================
+
+func (ds *ShopRepository) GetShopItems(activeOnly bool) ([]model.ShopItem, error) {
+	var items []model.ShopItem
+	db := ds.db.Model(&model.ShopItem{})
+	if activeOnly {
+		db = db.Where("is_active = ?", true)
+	}
+	err := db.Order("sort_order ASC, price ASC").Find(&items).Error
+	return items, err
+}
+
+func (ds *ShopRepository) GetShopItem(itemID string) (*model.ShopItem, error) {
+	var item model.ShopItem
+	if err := ds.db.Where("id = ?", itemID).First(&item).Error; err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (ds *ShopRepository) CreateShopItem(item *model.ShopItem) error {
+	if item.ID == "" {
+		item.ID = ids.New()
+	}
+	item.CreatedAt = time.Now()
+	item.UpdatedAt = time.Now()
+	return ds.db.Create(item).Error
+}
+
+func (ds *ShopRepository) UpdateShopItem(item *model.ShopItem) error {
+	item.UpdatedAt = time.Now()
+	return ds.db.Save(item).Error
+}
+
+// ==================== WALLET METHODS ====================
+
+// GetWallet returns the user's wallet, empty if they never earned coins
+func (ds *ShopRepository) GetWallet(userID string) (*model.Wallet, error) {
+	var wallet model.Wallet
+	err := ds.db.Where("user_id = ?", userID).First(&wallet).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &model.Wallet{UserID: userID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &wallet, nil
+}
+
+// CreditCoins pays txn.Amount into the user's wallet and reports whether it was paid.
+// Nothing is paid if the user already has an entry with the same source and reference.
+func (ds *ShopRepository) CreditCoins(txn *model.CoinTransaction) (bool, error) {
+	if txn.ID == "" {
+		txn.ID = ids.New()
+	}
+	now := time.Now()
+	txn.CreatedAt = now
+
+	var credited bool
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(txn)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		credited = true
+
+		wallet := model.Wallet{
+			UserID:         txn.UserID,
+			Balance:        txn.Amount,
+			LifetimeEarned: txn.Amount,
+			UpdatedAt:      now,
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"balance":         gorm.Expr("wallets.balance + ?", txn.Amount),
+				"lifetime_earned": gorm.Expr("wallets.lifetime_earned + ?", txn.Amount),
+				"updated_at":      now,
+			}),
+		}).Create(&wallet).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("user_id = ?", txn.UserID).First(&wallet).Error; err != nil {
+			return err
+		}
+		txn.BalanceAfter = wallet.Balance
+		return tx.Model(txn).Update("balance_after", wallet.Balance).Error
+	})
+	return credited, err
+}
+
+func (ds *ShopRepository) GetCoinTransactions(userID string, page, limit int) ([]model.CoinTransaction, int64, error) {
+	var txns []model.CoinTransaction
+	var total int64
+
+	db := ds.db.Model(&model.CoinTransaction{}).Where("user_id = ?", userID)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := db.Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		Find(&txns).Error; err != nil {
+		return nil, 0, err
+	}
+	return txns, total, nil
+}
+
+// ==================== PURCHASE METHODS ====================
+
+func (ds *ShopRepository) GetShopPurchaseByKey(userID, idempotencyKey string) (*model.ShopPurchase, error) {
+	var purchase model.ShopPurchase
+	if err := ds.db.Where("user_id = ? AND idempotency_key = ?", userID, idempotencyKey).First(&purchase).Error; err != nil {
+		return nil, err
+	}
+	return &purchase, nil
+}
+
+// Purchase pays for an item and delivers it in one transaction, and reports whether the
+// purchase is new. If the user already made a purchase with the same idempotency key,
+// that one is returned and nothing is charged.
+func (ds *ShopRepository) Purchase(purchase *model.ShopPurchase, maxStreakFreezes int) (*model.ShopPurchase, bool, error) {
+	if purchase.ID == "" {
+		purchase.ID = ids.New()
+	}
+	now := time.Now()
+	purchase.CreatedAt = now
+
+	var created bool
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(purchase)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		created = true
+
+		result = tx.Model(&model.Wallet{}).
+			Where("user_id = ? AND balance >= ?", purchase.UserID, purchase.Price).
+			Updates(map[string]interface{}{
+				"balance":    gorm.Expr("balance - ?", purchase.Price),
+				"updated_at": now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInsufficientCoins
+		}
+
+		var wallet model.Wallet
+		if err := tx.Where("user_id = ?", purchase.UserID).First(&wallet).Error; err != nil {
+			return err
+		}
+		purchase.BalanceAfter = wallet.Balance
+		if err := tx.Model(purchase).Update("balance_after", wallet.Balance).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Create(&model.CoinTransaction{
+			ID:           ids.New(),
+			UserID:       purchase.UserID,
+			Source:       model.CoinSourcePurchase,
+			Amount:       -purchase.Price,
+			BalanceAfter: wallet.Balance,
+			ReferenceID:  purchase.ID,
+			CreatedAt:    now,
+		}).Error; err != nil {
+			return err
+		}
+
+		return deliverPurchase(tx, purchase, maxStreakFreezes)
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !created {
+		existing, err := ds.GetShopPurchaseByKey(purchase.UserID, purchase.IdempotencyKey)
+		return existing, false, err
+	}
+	return purchase, true, nil
+}
+
+// deliverPurchase gives the user what they bought: hearts up to their maximum, streak
+// freezes up to maxStreakFreezes, or the cosmetic
+func deliverPurchase(tx *gorm.DB, purchase *model.ShopPurchase, maxStreakFreezes int) error {
+	if purchase.Kind == model.ShopItemCosmetic {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.UserCosmetic{
+			UserID:      purchase.UserID,
+			ItemID:      purchase.ItemID,
+			PurchasedAt: purchase.CreatedAt,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrCosmeticOwned
+		}
+		return nil
+	}
+
+	var progress model.UserProgress
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ?", purchase.UserID).First(&progress).Error; err != nil {
+		return err
+	}
+
+	switch purchase.Kind {
+	case model.ShopItemHeartRefill:
+		added := min(progress.Hearts+purchase.Quantity, progress.MaxHearts) - progress.Hearts
+		if added <= 0 {
+			return ErrHeartsFull
+		}
+		progress.Hearts += added
+		if err := tx.Model(&progress).Updates(map[string]interface{}{
+			"hearts":     progress.Hearts,
+			"updated_at": purchase.CreatedAt,
+		}).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&model.HeartTransaction{
+			ID:           ids.New(),
+			UserID:       purchase.UserID,
+			Source:       model.HeartSourceShop,
+			Amount:       added,
+			BalanceAfter: progress.Hearts,
+			ReferenceID:  purchase.ID,
+			CreatedAt:    purchase.CreatedAt,
+		}).Error
+	case model.ShopItemStreakFreeze:
+		if progress.StreakFreezes+purchase.Quantity > maxStreakFreezes {
+			return ErrStreakFreezeLimit
+		}
+		return tx.Model(&progress).Updates(map[string]interface{}{
+			"streak_freezes": progress.StreakFreezes + purchase.Quantity,
+			"updated_at":     purchase.CreatedAt,
+		}).Error
+	}
+	return nil
+}
+
+// ==================== COSMETIC METHODS ====================
+
+func (ds *ShopRepository) GetUserCosmetics(userID string) ([]model.UserCosmetic, error) {
+	var cosmetics []model.UserCosmetic
+	err := ds.db.Preload("Item").
+		Where("user_id = ?", userID).
+		Order("purchased_at DESC").
+		Find(&cosmetics).Error
+	return cosmetics, err
+}
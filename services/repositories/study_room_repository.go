@@ -0,0 +1,272 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared/ids"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StudyRoomRepository struct {
+	BaseRepository
+}
+
+func NewStudyRoomRepository(db *gorm.DB) *StudyRoomRepository {
+	return &StudyRoomRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+var openStudyRoomStatuses = []string{model.StudyRoomStatusWaiting, model.StudyRoomStatusActive}
+
+// CreateStudyRoom stores a new room with its host as the first member
+func (ds *StudyRoomRepository) CreateStudyRoom(room *model.StudyRoom) error {
+	if room.ID == "" {
+		room.ID = ids.New()
+	}
+	now := time.Now()
+	room.LastActivityAt = now
+
+	return ds.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(room).Error; err != nil {
+			return err
+		}
+		return tx.Create(&model.StudyRoomMember{RoomID: room.ID, UserID: room.HostID, JoinedAt: now}).Error
+	})
+}
+
+// GetStudyRoom loads a room with all its members, including those who left, in the
+// order they joined
+func (ds *StudyRoomRepository) GetStudyRoom(roomID string) (*model.StudyRoom, error) {
+	var room model.StudyRoom
+	err := ds.db.Preload("Members", func(db *gorm.DB) *gorm.DB {
+		return db.Order("joined_at ASC")
+	}).Preload("Members.User").Where("id = ?", roomID).First(&room).Error
+	if err != nil {
+		return nil, err
+	}
+	return &room, nil
+}
+
+// GetWaitingStudyRoomByCode returns the room still accepting members under a code
+func (ds *StudyRoomRepository) GetWaitingStudyRoomByCode(code string) (*model.StudyRoom, error) {
+	var room model.StudyRoom
+	err := ds.db.Where("code = ? AND status = ?", code, model.StudyRoomStatusWaiting).
+		Order("created_at DESC").First(&room).Error
+	if err != nil {
+		return nil, err
+	}
+	return &room, nil
+}
+
+// GetOpenStudyRoomForUser returns the waiting or active room the user is in
+func (ds *StudyRoomRepository) GetOpenStudyRoomForUser(userID string) (*model.StudyRoom, error) {
+	var room model.StudyRoom
+	err := ds.db.Joins("JOIN study_room_members m ON m.room_id = study_rooms.id").
+		Where("m.user_id = ? AND m.left_at IS NULL AND study_rooms.status IN ?", userID, openStudyRoomStatuses).
+		Order("study_rooms.created_at DESC").First(&room).Error
+	if err != nil {
+		return nil, err
+	}
+	return ds.GetStudyRoom(room.ID)
+}
+
+// OpenStudyRoomCodeInUse reports whether a waiting or active room has the code
+func (ds *StudyRoomRepository) OpenStudyRoomCodeInUse(code string) (bool, error) {
+	var count int64
+	err := ds.db.Model(&model.StudyRoom{}).
+		Where("code = ? AND status IN ?", code, openStudyRoomStatuses).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// JoinStudyRoom adds the user to a waiting room. It reports false if the room started,
+// closed or already has maxMembers members.
+func (ds *StudyRoomRepository) JoinStudyRoom(roomID, userID string, maxMembers int) (bool, error) {
+	var joined bool
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		var room model.StudyRoom
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND status = ?", roomID, model.StudyRoomStatusWaiting).First(&room).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var members int64
+		if err := tx.Model(&model.StudyRoomMember{}).Where("room_id = ?", roomID).Count(&members).Error; err != nil {
+			return err
+		}
+		if members >= int64(maxMembers) {
+			return nil
+		}
+
+		now := time.Now()
+		member := &model.StudyRoomMember{RoomID: roomID, UserID: userID, JoinedAt: now}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(member).Error; err != nil {
+			return err
+		}
+		joined = true
+
+		return tx.Model(&room).Update("last_activity_at", now).Error
+	})
+	return joined, err
+}
+
+// LeaveStudyRoom takes the user out of an open room. Members of a waiting room are
+// removed, members of an active room keep their progress with LeftAt set. A leaving
+// host hands the room to the member who joined next, and a room left empty is closed.
+// It reports false if the user was not in the room.
+func (ds *StudyRoomRepository) LeaveStudyRoom(roomID, userID string) (bool, error) {
+	var left bool
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		var room model.StudyRoom
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND status IN ?", roomID, openStudyRoomStatuses).First(&room).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		member := tx.Model(&model.StudyRoomMember{}).Where("room_id = ? AND user_id = ? AND left_at IS NULL", roomID, userID)
+		var result *gorm.DB
+		if room.Status == model.StudyRoomStatusWaiting {
+			result = member.Delete(&model.StudyRoomMember{})
+		} else {
+			result = member.Update("left_at", now)
+		}
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		left = true
+
+		updates := map[string]interface{}{"last_activity_at": now}
+		if room.HostID == userID {
+			var next model.StudyRoomMember
+			err := tx.Where("room_id = ? AND left_at IS NULL", roomID).Order("joined_at ASC").First(&next).Error
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				updates["status"] = model.StudyRoomStatusClosed
+				updates["ended_at"] = now
+			case err != nil:
+				return err
+			default:
+				updates["host_id"] = next.UserID
+			}
+		}
+		return tx.Model(&room).Updates(updates).Error
+	})
+	return left, err
+}
+
+// StartStudyRoom moves a waiting room to active. It reports false unless hostID hosts
+// the room and it has at least minMembers members.
+func (ds *StudyRoomRepository) StartStudyRoom(roomID, hostID string, minMembers int) (bool, error) {
+	now := time.Now()
+	result := ds.db.Model(&model.StudyRoom{}).
+		Where("id = ? AND host_id = ? AND status = ?", roomID, hostID, model.StudyRoomStatusWaiting).
+		Where("(SELECT COUNT(*) FROM study_room_members WHERE room_id = study_rooms.id) >= ?", minMembers).
+		Updates(map[string]interface{}{
+			"status":           model.StudyRoomStatusActive,
+			"started_at":       now,
+			"last_activity_at": now,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// RecordStudyRoomAnswer saves a member's answer and adds it to their progress. A member
+// who answered all totalQuestions is finished, and passed with at least pointsToPass
+// points. It reports false, leaving progress alone, if the question was already
+// answered or the user is no longer answering in an active room.
+func (ds *StudyRoomRepository) RecordStudyRoomAnswer(answer *model.StudyRoomAnswer, totalQuestions, pointsToPass int) (bool, *model.StudyRoomMember, error) {
+	var recorded bool
+	var member model.StudyRoomMember
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("room_id = ? AND user_id = ? AND left_at IS NULL AND finished_at IS NULL", answer.RoomID, answer.UserID).
+			Where("EXISTS (SELECT 1 FROM study_rooms WHERE id = room_id AND status = ?)", model.StudyRoomStatusActive).
+			First(&member).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(answer)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		recorded = true
+
+		now := time.Now()
+		member.Answered++
+		member.EarnedPoints += answer.Points
+		if member.Answered >= totalQuestions {
+			member.FinishedAt = &now
+			member.Passed = member.EarnedPoints >= pointsToPass
+		}
+		err = tx.Model(&member).Updates(map[string]interface{}{
+			"answered":      member.Answered,
+			"earned_points": member.EarnedPoints,
+			"finished_at":   member.FinishedAt,
+			"passed":        member.Passed,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&model.StudyRoom{}).Where("id = ?", answer.RoomID).Update("last_activity_at", now).Error
+	})
+	return recorded, &member, err
+}
+
+// GetStudyRoomAnsweredQuestionIDs returns the questions the user answered in a room
+func (ds *StudyRoomRepository) GetStudyRoomAnsweredQuestionIDs(roomID, userID string) ([]string, error) {
+	var ids []string
+	err := ds.db.Model(&model.StudyRoomAnswer{}).
+		Where("room_id = ? AND user_id = ?", roomID, userID).
+		Order("created_at ASC").Pluck("question_id", &ids).Error
+	return ids, err
+}
+
+// FinishStudyRoom ends an active room with the group bonus each member gets. It reports
+// false if the room was not active, so only one caller pays the bonus.
+func (ds *StudyRoomRepository) FinishStudyRoom(roomID string, bonusXP int) (bool, error) {
+	result := ds.db.Model(&model.StudyRoom{}).
+		Where("id = ? AND status = ?", roomID, model.StudyRoomStatusActive).
+		Updates(map[string]interface{}{
+			"status":   model.StudyRoomStatusFinished,
+			"bonus_xp": bonusXP,
+			"ended_at": time.Now(),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// CloseStudyRoom ends a waiting or active room without a bonus. It reports false if the
+// room had already ended.
+func (ds *StudyRoomRepository) CloseStudyRoom(roomID string) (bool, error) {
+	result := ds.db.Model(&model.StudyRoom{}).
+		Where("id = ? AND status IN ?", roomID, openStudyRoomStatuses).
+		Updates(map[string]interface{}{
+			"status":   model.StudyRoomStatusClosed,
+			"ended_at": time.Now(),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// GetIdleStudyRooms returns open rooms with no activity since idleSince
+func (ds *StudyRoomRepository) GetIdleStudyRooms(idleSince time.Time, limit int) ([]model.StudyRoom, error) {
+	var rooms []model.StudyRoom
+	err := ds.db.Where("status IN ? AND last_activity_at < ?", openStudyRoomStatuses, idleSince).
+		Order("last_activity_at ASC").Limit(limit).Find(&rooms).Error
+	return rooms, err
+}
@@ -0,0 +1,133 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared/ids"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TriviaRepository struct {
+	BaseRepository
+}
+
+func NewTriviaRepository(db *gorm.DB) *TriviaRepository {
+	return &TriviaRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+func (ds *TriviaRepository) GetDailyTrivia(date string) (*model.DailyTrivia, error) {
+	var trivia model.DailyTrivia
+	if err := ds.db.Where("date = ?", date).First(&trivia).Error; err != nil {
+		return nil, err
+	}
+	return &trivia, nil
+}
+
+// CreateDailyTrivia stores the day's question unless another instance picked one first,
+// and returns the question that won
+func (ds *TriviaRepository) CreateDailyTrivia(trivia *model.DailyTrivia) (*model.DailyTrivia, error) {
+	if err := ds.db.Clauses(clause.OnConflict{DoNothing: true}).Create(trivia).Error; err != nil {
+		return nil, err
+	}
+	return ds.GetDailyTrivia(trivia.Date)
+}
+
+// GetRecentTriviaQuestions returns the lesson/question pairs used since the given date,
+// keyed "lessonID/questionID"
+func (ds *TriviaRepository) GetRecentTriviaQuestions(since string) (map[string]bool, error) {
+	var trivia []model.DailyTrivia
+	if err := ds.db.Where("date >= ?", since).Find(&trivia).Error; err != nil {
+		return nil, err
+	}
+
+	used := make(map[string]bool, len(trivia))
+	for _, t := range trivia {
+		used[t.LessonID+"/"+t.QuestionID] = true
+	}
+	return used, nil
+}
+
+// GetRandomQuestionLessons returns a few random active lessons that have questions.
+// Everyone gets the same trivia question, so only lessons suitable for all ages qualify.
+func (ds *TriviaRepository) GetRandomQuestionLessons(limit int) ([]model.Lesson, error) {
+	var lessons []model.Lesson
+	err := ds.db.Where("is_active = ? AND questions IS NOT NULL AND jsonb_typeof(questions) = 'array' AND jsonb_array_length(questions) > 0", true).
+		Where("content_rating IS NULL OR content_rating IN ?", []string{model.ContentRatingUnrated, model.ContentRatingAll}).
+		Order("random()").Limit(limit).Find(&lessons).Error
+	return lessons, err
+}
+
+func (ds *TriviaRepository) GetDailyTriviaAnswer(userID, date string) (*model.DailyTriviaAnswer, error) {
+	var answer model.DailyTriviaAnswer
+	if err := ds.db.Where("user_id = ? AND date = ?", userID, date).First(&answer).Error; err != nil {
+		return nil, err
+	}
+	return &answer, nil
+}
+
+// RecordDailyTriviaAnswer saves a user's answer and advances their trivia streak in one
+// transaction. It reports false, leaving the streak alone, if the user already answered
+// that day. previousDate is the day before answer.Date.
+func (ds *TriviaRepository) RecordDailyTriviaAnswer(answer *model.DailyTriviaAnswer, previousDate string) (bool, *model.UserTriviaStreak, error) {
+	if answer.ID == "" {
+		answer.ID = ids.New()
+	}
+
+	var recorded bool
+	streak := &model.UserTriviaStreak{UserID: answer.UserID}
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(answer)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		recorded = true
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", answer.UserID).First(streak).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if streak.LastAnsweredDate == previousDate {
+			streak.CurrentStreak++
+		} else if streak.LastAnsweredDate != answer.Date {
+			streak.CurrentStreak = 1
+		}
+		streak.LongestStreak = max(streak.LongestStreak, streak.CurrentStreak)
+		streak.LastAnsweredDate = answer.Date
+		streak.UpdatedAt = time.Now()
+
+		return tx.Save(streak).Error
+	})
+	return recorded, streak, err
+}
+
+func (ds *TriviaRepository) GetUserTriviaStreak(userID string) (*model.UserTriviaStreak, error) {
+	var streak model.UserTriviaStreak
+	err := ds.db.Where("user_id = ?", userID).First(&streak).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &model.UserTriviaStreak{UserID: userID}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &streak, nil
+}
+
+func (ds *TriviaRepository) SetDailyTriviaXPAwarded(answerID string, xp int) error {
+	return ds.db.Model(&model.DailyTriviaAnswer{}).Where("id = ?", answerID).Update("xp_awarded", xp).Error
+}
+
+func (ds *TriviaRepository) GetDailyTriviaStats(date string) (*model.DailyTriviaStats, error) {
+	stats := &model.DailyTriviaStats{Date: date}
+	err := ds.db.Model(&model.DailyTriviaAnswer{}).
+		Select("COUNT(*) AS total_answers, COUNT(*) FILTER (WHERE is_correct) AS correct_answers").
+		Where("date = ?", date).
+		Scan(stats).Error
+	return stats, err
+}
@@ -0,0 +1,76 @@
+//go:build dbtest
+
+// Tests against a real database, e.g.:
+//
+//	DATABASE_URL=postgres://... go test -tags dbtest -run FailedAttempts ./services/repositories/
+package repositories
+
+import (
+	"os"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	dsn := os.Getenv("DATABASE_URL")
+	if dsn == "" {
+		t.Skip("DATABASE_URL is not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	return db
+}
+
+func TestIncrementFailedAttemptsConcurrent(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewUserRepository(db)
+
+	id := uuid.NewString()
+	user := model.User{ID: id, Username: "lockout_" + id[:8], Email: "lockout_" + id[:8] + "@example.com", Password: "x"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	t.Cleanup(func() { db.Delete(&model.User{}, "id = ?", id) })
+
+	const failures = 40
+	counts := make([]int, failures)
+	var wg sync.WaitGroup
+	for i := 0; i < failures; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			attempts, err := repo.IncrementFailedAttempts(id)
+			if err != nil {
+				t.Errorf("increment: %v", err)
+			}
+			counts[i] = attempts
+		}(i)
+	}
+	wg.Wait()
+
+	// Every failure sees its own count, none is lost or seen twice
+	sort.Ints(counts)
+	for i, count := range counts {
+		if count != i+1 {
+			t.Fatalf("counts %v, want 1..%d", counts, failures)
+		}
+	}
+
+	var stored model.User
+	if err := db.First(&stored, "id = ?", id).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.FailedAttempts != failures {
+		t.Errorf("stored %d failed attempts, want %d", stored.FailedAttempts, failures)
+	}
+}
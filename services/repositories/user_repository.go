@@ -1,15 +1,18 @@
 package repositories
 
 import (
+	"crypto/subtle"
+	"encoding/json"
 	"strings"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/lac-hong-legacy/ven_api/dto"
 	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared/ids"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // UserRepository handles user-related database operations
@@ -55,6 +58,18 @@ func (ds *UserRepository) GetUser(userID string) (*model.User, error) {
 	return &user, nil
 }
 
+// GetUsersByIDs loads the given users in one query. Missing IDs are left out.
+func (ds *UserRepository) GetUsersByIDs(userIDs []string) ([]model.User, error) {
+	var users []model.User
+	if len(userIDs) == 0 {
+		return users, nil
+	}
+	if err := ds.db.Where("id IN ?", userIDs).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
 func (ds *UserRepository) UpdateUser(user *model.User) error {
 	user.UpdatedAt = time.Now()
 	if err := ds.db.Save(user).Error; err != nil {
@@ -63,10 +78,10 @@ func (ds *UserRepository) UpdateUser(user *model.User) error {
 	return nil
 }
 
-func (ds *UserRepository) CreateUser(req dto.RegisterRequest, verificationCode string) (*model.User, error) {
+func (ds *UserRepository) CreateUser(userID string, req dto.RegisterRequest, verificationCode string, outbox ...*model.OutboxMessage) (*model.User, error) {
 	codeExpiry := time.Now().Add(15 * time.Minute) // Code expires in 15 minutes
 	user := &model.User{
-		ID:                     uuid.New().String(),
+		ID:                     userID,
 		Username:               req.Username,
 		Email:                  req.Email,
 		Password:               req.Password,
@@ -82,7 +97,13 @@ func (ds *UserRepository) CreateUser(req dto.RegisterRequest, verificationCode s
 		UpdatedAt:              time.Now(),
 	}
 
-	if err := ds.db.Create(user).Error; err != nil {
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return insertOutbox(tx, outbox)
+	})
+	if err != nil {
 		return nil, err
 	}
 	return user, nil
@@ -124,11 +145,17 @@ func (ds *UserRepository) UpdateLastLogin(userID, ip string) error {
 	}).Error
 }
 
-func (ds *UserRepository) IncrementFailedAttempts(userID string) error {
-	return ds.db.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
-		"failed_attempts": gorm.Expr("failed_attempts + 1"),
-		"updated_at":      time.Now(),
-	}).Error
+// IncrementFailedAttempts atomically counts a failed login and returns the new count, so
+// concurrent failures each see their own count
+func (ds *UserRepository) IncrementFailedAttempts(userID string) (int, error) {
+	var attempts int
+	err := ds.db.Raw(`
+		UPDATE users
+		SET failed_attempts = failed_attempts + 1, updated_at = ?
+		WHERE id = ?
+		RETURNING failed_attempts
+	`, time.Now(), userID).Scan(&attempts).Error
+	return attempts, err
 }
 
 func (ds *UserRepository) ResetFailedAttempts(userID string) error {
@@ -155,13 +182,18 @@ func (ds *UserRepository) VerifyUserEmail(userID string) error {
 	}).Error
 }
 
-func (ds *UserRepository) UpdateVerificationCode(userID, code string) error {
+func (ds *UserRepository) UpdateVerificationCode(userID, code string, outbox ...*model.OutboxMessage) error {
 	codeExpiry := time.Now().Add(15 * time.Minute) // Code expires in 15 minutes
-	return ds.db.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
-		"verification_code":        code,
-		"verification_code_expiry": &codeExpiry,
-		"updated_at":               time.Now(),
-	}).Error
+	return ds.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"verification_code":        code,
+			"verification_code_expiry": &codeExpiry,
+			"updated_at":               time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+		return insertOutbox(tx, outbox)
+	})
 }
 
 func (ds *UserRepository) IsUsernameAvailable(username string) (bool, error) {
@@ -182,9 +214,9 @@ func (ds *UserRepository) IsEmailAvailable(email string) (bool, error) {
 	return count == 0, nil
 }
 
-func (ds *UserRepository) CreateUserSession(session dto.UserSession) (string, error) {
+func (ds *UserRepository) CreateUserSession(session dto.UserSession, outbox ...*model.OutboxMessage) (string, error) {
 	dbSession := &model.UserSession{
-		ID:               uuid.New().String(),
+		ID:               ids.New(),
 		UserID:           session.UserID,
 		TokenHash:        session.TokenHash,
 		RefreshTokenJTI:  session.RefreshTokenJTI,
@@ -198,7 +230,13 @@ func (ds *UserRepository) CreateUserSession(session dto.UserSession) (string, er
 		ExpiresAt:        session.CreatedAt.Add(7 * 24 * time.Hour), // 7 days
 	}
 
-	if err := ds.db.Create(dbSession).Error; err != nil {
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(dbSession).Error; err != nil {
+			return err
+		}
+		return insertOutbox(tx, outbox)
+	})
+	if err != nil {
 		return "", err
 	}
 	return dbSession.ID, nil
@@ -244,6 +282,14 @@ func (ds *UserRepository) DeactivateAllUserSessions(userID, exceptSessionID stri
 	}).Error
 }
 
+// DeactivateIdleSessions ends the user's active sessions that have not been used since idleSince.
+func (ds *UserRepository) DeactivateIdleSessions(userID string, idleSince time.Time) (int64, error) {
+	result := ds.db.Model(&model.UserSession{}).
+		Where("user_id = ? AND is_active = ? AND last_used < ?", userID, true, idleSince).
+		Update("is_active", false)
+	return result.RowsAffected, result.Error
+}
+
 func (ds *UserRepository) GetSessionByID(sessionID string) (*model.UserSession, error) {
 	var session model.UserSession
 	err := ds.db.Where("id = ?", sessionID).First(&session).Error
@@ -281,9 +327,9 @@ func (ds *UserRepository) CleanupExpiredSessions() error {
 
 // ==================== PASSWORD RESET METHODS ====================
 
-func (ds *UserRepository) CreatePasswordResetCode(userID, code string, expiresAt time.Time) error {
+func (ds *UserRepository) CreatePasswordResetCode(userID, code string, expiresAt time.Time, outbox ...*model.OutboxMessage) error {
 	resetToken := &model.PasswordResetCode{
-		ID:        uuid.New().String(),
+		ID:        ids.New(),
 		UserID:    userID,
 		Code:      code,
 		ExpiresAt: expiresAt,
@@ -291,7 +337,12 @@ func (ds *UserRepository) CreatePasswordResetCode(userID, code string, expiresAt
 		CreatedAt: time.Now(),
 	}
 
-	return ds.db.Create(resetToken).Error
+	return ds.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(resetToken).Error; err != nil {
+			return err
+		}
+		return insertOutbox(tx, outbox)
+	})
 }
 
 func (ds *UserRepository) GetPasswordResetCode(code string) (*model.PasswordResetCode, error) {
@@ -307,6 +358,210 @@ func (ds *UserRepository) InvalidatePasswordResetCode(code string) error {
 	return ds.db.Model(&model.PasswordResetCode{}).Where("code = ?", code).Update("used", true).Error
 }
 
+// CreateEmailChangeRequest stores a new email change and marks it as the user's pending
+// email. Any earlier open request is cancelled.
+func (ds *UserRepository) CreateEmailChangeRequest(req *model.EmailChangeRequest, outbox ...*model.OutboxMessage) error {
+	if req.ID == "" {
+		req.ID = ids.New()
+	}
+	req.CreatedAt = time.Now()
+
+	return ds.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.EmailChangeRequest{}).
+			Where("user_id = ? AND confirmed_at IS NULL AND cancelled_at IS NULL AND reverted_at IS NULL", req.UserID).
+			Update("cancelled_at", time.Now()).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Create(req).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&model.User{}).Where("id = ?", req.UserID).
+			Updates(map[string]interface{}{
+				"pending_email": req.NewEmail,
+				"updated_at":    time.Now(),
+			}).Error; err != nil {
+			return err
+		}
+
+		return insertOutbox(tx, outbox)
+	})
+}
+
+func (ds *UserRepository) GetPendingEmailChange(userID string) (*model.EmailChangeRequest, error) {
+	var req model.EmailChangeRequest
+	if err := ds.db.Where("user_id = ? AND confirmed_at IS NULL AND cancelled_at IS NULL AND reverted_at IS NULL", userID).
+		Order("created_at DESC").First(&req).Error; err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func (ds *UserRepository) GetEmailChangeByRevertToken(tokenHash string) (*model.EmailChangeRequest, error) {
+	var req model.EmailChangeRequest
+	if err := ds.db.Where("revert_token_hash = ?", tokenHash).First(&req).Error; err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// ConfirmEmailChange switches the user to the new address, which is verified by the code
+func (ds *UserRepository) ConfirmEmailChange(req *model.EmailChangeRequest) error {
+	now := time.Now()
+	return ds.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(req).Update("confirmed_at", now).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&model.User{}).Where("id = ?", req.UserID).
+			Updates(map[string]interface{}{
+				"email":          req.NewEmail,
+				"email_verified": true,
+				"pending_email":  "",
+				"updated_at":     now,
+			}).Error
+	})
+}
+
+// RevertEmailChange cancels a pending change or restores the old address of a confirmed one
+func (ds *UserRepository) RevertEmailChange(req *model.EmailChangeRequest) error {
+	now := time.Now()
+	return ds.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(req).Update("reverted_at", now).Error; err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{
+			"pending_email": "",
+			"updated_at":    now,
+		}
+		if req.ConfirmedAt != nil {
+			updates["email"] = req.OldEmail
+			updates["email_verified"] = true
+		}
+
+		return tx.Model(&model.User{}).Where("id = ?", req.UserID).Updates(updates).Error
+	})
+}
+
+// CreateAccountRecoveryRequest stores a new recovery request. Any earlier open request of
+// the user is cancelled so only one can be approved.
+func (ds *UserRepository) CreateAccountRecoveryRequest(req *model.AccountRecoveryRequest, outbox ...*model.OutboxMessage) error {
+	if req.ID == "" {
+		req.ID = ids.New()
+	}
+	req.CreatedAt = time.Now()
+
+	return ds.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.AccountRecoveryRequest{}).
+			Where("user_id = ? AND status IN ?", req.UserID, []string{model.AccountRecoveryPending, model.AccountRecoveryApproved}).
+			Update("status", model.AccountRecoveryCancelled).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Create(req).Error; err != nil {
+			return err
+		}
+
+		return insertOutbox(tx, outbox)
+	})
+}
+
+func (ds *UserRepository) GetAccountRecoveryRequest(recoveryID string) (*model.AccountRecoveryRequest, error) {
+	var req model.AccountRecoveryRequest
+	if err := ds.db.Where("id = ?", recoveryID).First(&req).Error; err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func (ds *UserRepository) GetAccountRecoveryByCancelToken(tokenHash string) (*model.AccountRecoveryRequest, error) {
+	var req model.AccountRecoveryRequest
+	if err := ds.db.Where("cancel_token_hash = ?", tokenHash).First(&req).Error; err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// GetUserAccountRecoveries returns the user's most recent recovery requests, newest first
+func (ds *UserRepository) GetUserAccountRecoveries(userID string, limit int) ([]model.AccountRecoveryRequest, error) {
+	var requests []model.AccountRecoveryRequest
+	err := ds.db.Where("user_id = ?", userID).Order("created_at DESC").Limit(limit).Find(&requests).Error
+	if err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// UpdateAccountRecoveryStatus moves a request on from one of the expected statuses. It
+// reports false if the request was changed by someone else in the meantime.
+func (ds *UserRepository) UpdateAccountRecoveryStatus(req *model.AccountRecoveryRequest, from []string, outbox ...*model.OutboxMessage) (bool, error) {
+	updated := false
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.AccountRecoveryRequest{}).
+			Where("id = ? AND status IN ?", req.ID, from).
+			Updates(map[string]interface{}{
+				"status":            req.Status,
+				"decided_by_device": req.DecidedByDevice,
+				"decided_at":        req.DecidedAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		updated = true
+
+		return insertOutbox(tx, outbox)
+	})
+	return updated, err
+}
+
+// CompleteAccountRecovery moves the user to the recovered address with a new password.
+// The address still has to be verified with the code stored alongside it.
+func (ds *UserRepository) CompleteAccountRecovery(req *model.AccountRecoveryRequest, hashedPassword, verificationCode string, outbox ...*model.OutboxMessage) (bool, error) {
+	now := time.Now()
+	codeExpiry := now.Add(15 * time.Minute)
+	completed := false
+
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.AccountRecoveryRequest{}).
+			Where("id = ? AND status IN ?", req.ID, []string{model.AccountRecoveryPending, model.AccountRecoveryApproved}).
+			Updates(map[string]interface{}{
+				"status":       model.AccountRecoveryCompleted,
+				"completed_at": now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		completed = true
+
+		if err := tx.Model(&model.User{}).Where("id = ?", req.UserID).
+			Updates(map[string]interface{}{
+				"email":                    req.NewEmail,
+				"email_verified":           false,
+				"pending_email":            "",
+				"verification_code":        verificationCode,
+				"verification_code_expiry": &codeExpiry,
+				"password":                 hashedPassword,
+				"last_password_change":     &now,
+				"failed_attempts":          0,
+				"locked_until":             nil,
+				"updated_at":               now,
+			}).Error; err != nil {
+			return err
+		}
+
+		return insertOutbox(tx, outbox)
+	})
+	return completed, err
+}
+
 func (ds *UserRepository) CleanupExpiredPasswordCodes() error {
 	return ds.db.Where("expires_at < ?", time.Now()).Delete(&model.PasswordResetCode{}).Error
 }
@@ -339,13 +594,17 @@ func (ds *UserRepository) CleanupExpiredBlacklistedTokens() error {
 
 // ==================== AUDIT LOG METHODS ====================
 
+// auditChainLockKey is the advisory lock that serializes appends to the audit log chain
+const auditChainLockKey = 7_301_245_001
+
+// CreateAuthAuditLog appends an entry to the audit log hash chain
 func (ds *UserRepository) CreateAuthAuditLog(log dto.AuthAuditLog) error {
 	auditLog := &model.AuthAuditLog{
-		ID:        uuid.New().String(),
+		ID:        ids.New(),
 		Action:    log.Action,
 		IP:        log.IP,
 		UserAgent: log.UserAgent,
-		Timestamp: log.Timestamp,
+		Timestamp: log.Timestamp.UTC().Truncate(time.Microsecond),
 		Success:   log.Success,
 		Details:   log.Details,
 	}
@@ -353,8 +612,67 @@ func (ds *UserRepository) CreateAuthAuditLog(log dto.AuthAuditLog) error {
 	if log.UserID != "" {
 		auditLog.UserID = log.UserID
 	}
+	if err := auditLog.SealClientDetails(); err != nil {
+		return err
+	}
+
+	return ds.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditChainLockKey).Error; err != nil {
+			return err
+		}
 
-	return ds.db.Create(auditLog).Error
+		sequence, hash, err := auditChainHead(tx)
+		if err != nil {
+			return err
+		}
+		auditLog.Sequence = sequence + 1
+		auditLog.PrevHash = hash
+		auditLog.Hash = auditLog.ComputeHash()
+
+		return tx.Create(auditLog).Error
+	})
+}
+
+// auditChainHead is the sequence and hash of the last chained entry, or of the latest
+// checkpoint if cleanup removed every entry
+func auditChainHead(tx *gorm.DB) (int64, string, error) {
+	var head model.AuthAuditLog
+	result := tx.Select("sequence", "hash").Where("sequence IS NOT NULL").Order("sequence DESC").Limit(1).Find(&head)
+	if result.Error != nil {
+		return 0, "", result.Error
+	}
+	if result.RowsAffected > 0 {
+		return head.Sequence, head.Hash, nil
+	}
+
+	var checkpoint model.AuthAuditCheckpoint
+	result = tx.Order("sequence DESC").Limit(1).Find(&checkpoint)
+	return checkpoint.Sequence, checkpoint.Hash, result.Error
+}
+
+// GetAuthAuditChain returns up to limit chained entries from fromSequence on, in chain order.
+// toSequence 0 means no upper bound.
+func (ds *UserRepository) GetAuthAuditChain(fromSequence, toSequence int64, limit int) ([]model.AuthAuditLog, error) {
+	var logs []model.AuthAuditLog
+	query := ds.db.Where("sequence >= ?", fromSequence)
+	if toSequence > 0 {
+		query = query.Where("sequence <= ?", toSequence)
+	}
+	err := query.Order("sequence ASC").Limit(limit).Find(&logs).Error
+	return logs, err
+}
+
+// GetLatestAuthAuditCheckpoint returns the checkpoint the chain continues from, or nil if
+// cleanup never removed chained entries
+func (ds *UserRepository) GetLatestAuthAuditCheckpoint() (*model.AuthAuditCheckpoint, error) {
+	var checkpoints []model.AuthAuditCheckpoint
+	if err := ds.db.Order("sequence DESC").Limit(1).Find(&checkpoints).Error; err != nil {
+		return nil, err
+	}
+	if len(checkpoints) == 0 {
+		return nil, nil
+	}
+	return &checkpoints[0], nil
 }
 
 func (ds *UserRepository) GetUserAuditLogs(userID string, page, limit int) ([]model.AuthAuditLog, int64, error) {
@@ -379,6 +697,22 @@ func (ds *UserRepository) GetUserAuditLogs(userID string, page, limit int) ([]mo
 	return logs, total, nil
 }
 
+// GetUserAuditLogsBetween returns up to limit of the user's audit logs in [from, to), oldest first
+func (ds *UserRepository) GetUserAuditLogsBetween(userID string, from, to time.Time, limit int) ([]model.AuthAuditLog, int64, error) {
+	var logs []model.AuthAuditLog
+	var total int64
+
+	between := func() *gorm.DB {
+		return ds.db.Model(&model.AuthAuditLog{}).Where("user_id = ? AND timestamp >= ? AND timestamp < ?", userID, from, to)
+	}
+	if err := between().Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := between().Order("timestamp ASC").Limit(limit).Find(&logs).Error
+	return logs, total, err
+}
+
 func (ds *UserRepository) GetAuditLogs(page, limit int, userID, action string) ([]model.AuthAuditLog, int64, error) {
 	var logs []model.AuthAuditLog
 	var total int64
@@ -409,14 +743,47 @@ func (ds *UserRepository) GetAuditLogs(page, limit int, userID, action string) (
 	return logs, total, nil
 }
 
+// CleanupOldAuditLogs removes audit logs older than olderThan. Chained entries are only
+// cut from the start of the chain, up to the first entry that is kept, and the last one
+// removed becomes a checkpoint, so what's left still verifies.
 func (ds *UserRepository) CleanupOldAuditLogs(olderThan time.Time) error {
-	return ds.db.Where("timestamp < ?", olderThan).Delete(&model.AuthAuditLog{}).Error
+	return ds.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", auditChainLockKey).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("sequence IS NULL AND timestamp < ?", olderThan).Delete(&model.AuthAuditLog{}).Error; err != nil {
+			return err
+		}
+
+		var firstKept *int64
+		if err := tx.Model(&model.AuthAuditLog{}).
+			Where("sequence IS NOT NULL AND timestamp >= ?", olderThan).
+			Select("MIN(sequence)").Scan(&firstKept).Error; err != nil {
+			return err
+		}
+
+		var last model.AuthAuditLog
+		query := tx.Select("sequence", "hash").Where("sequence IS NOT NULL")
+		if firstKept != nil {
+			query = query.Where("sequence < ?", *firstKept)
+		}
+		result := query.Order("sequence DESC").Limit(1).Find(&last)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		if err := tx.Create(&model.AuthAuditCheckpoint{Sequence: last.Sequence, Hash: last.Hash}).Error; err != nil {
+			return err
+		}
+		return tx.Where("sequence <= ?", last.Sequence).Delete(&model.AuthAuditLog{}).Error
+	})
 }
 
 // ==================== TRUSTED DEVICE METHODS ====================
 
 func (ds *UserRepository) CreateTrustedDevice(device *model.TrustedDevice) error {
-	device.ID = uuid.New().String()
+	device.ID = ids.New()
 	device.CreatedAt = time.Now()
 	device.LastUsed = time.Now()
 
@@ -450,11 +817,142 @@ func (ds *UserRepository) RemoveTrustedDevice(userID, deviceID string) error {
 	return ds.db.Where("user_id = ? AND device_id = ?", userID, deviceID).Delete(&model.TrustedDevice{}).Error
 }
 
+// ==================== TWO-FACTOR METHODS ====================
+
+// SetTwoFactorSecret stores the secret of a 2FA setup that still has to be confirmed.
+// Users who already have 2FA on keep their secret.
+func (ds *UserRepository) SetTwoFactorSecret(userID, secret string) (bool, error) {
+	result := ds.db.Model(&model.User{}).
+		Where("id = ? AND two_factor_enabled = ?", userID, false).
+		Updates(map[string]interface{}{
+			"two_factor_secret":    secret,
+			"two_factor_last_step": 0,
+			"updated_at":           time.Now(),
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ClaimTwoFactorStep records step as the user's last accepted authenticator time step,
+// reporting false when a code of that step or a later one was accepted already
+func (ds *UserRepository) ClaimTwoFactorStep(userID string, step int64) (bool, error) {
+	result := ds.db.Model(&model.User{}).
+		Where("id = ? AND two_factor_last_step < ?", userID, step).
+		Update("two_factor_last_step", step)
+	return result.RowsAffected > 0, result.Error
+}
+
+// EnableTwoFactor turns on 2FA with the pending secret, storing the backup code hashes
+func (ds *UserRepository) EnableTwoFactor(userID string, backupCodeHashes []string, outbox ...*model.OutboxMessage) (bool, error) {
+	codes, err := json.Marshal(backupCodeHashes)
+	if err != nil {
+		return false, err
+	}
+
+	enabled := false
+	err = ds.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.User{}).
+			Where("id = ? AND two_factor_enabled = ? AND two_factor_secret <> ''", userID, false).
+			Updates(map[string]interface{}{
+				"two_factor_enabled": true,
+				"backup_codes":       string(codes),
+				"updated_at":         time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		enabled = true
+		return insertOutbox(tx, outbox)
+	})
+	return enabled, err
+}
+
+func (ds *UserRepository) DisableTwoFactor(userID string, outbox ...*model.OutboxMessage) error {
+	return ds.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.User{}).Where("id = ?", userID).
+			Updates(map[string]interface{}{
+				"two_factor_enabled": false,
+				"two_factor_secret":  "",
+				"backup_codes":       "",
+				"updated_at":         time.Now(),
+			}).Error; err != nil {
+			return err
+		}
+		return insertOutbox(tx, outbox)
+	})
+}
+
+// ReplaceBackupCodes swaps all backup codes of the user for a new set
+func (ds *UserRepository) ReplaceBackupCodes(userID string, backupCodeHashes []string, outbox ...*model.OutboxMessage) error {
+	codes, err := json.Marshal(backupCodeHashes)
+	if err != nil {
+		return err
+	}
+
+	return ds.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.User{}).Where("id = ? AND two_factor_enabled = ?", userID, true).
+			Updates(map[string]interface{}{
+				"backup_codes": string(codes),
+				"updated_at":   time.Now(),
+			}).Error; err != nil {
+			return err
+		}
+		return insertOutbox(tx, outbox)
+	})
+}
+
+// ConsumeBackupCode removes the backup code with codeHash from the user, reporting
+// whether it was there and how many codes are left. The user row is locked so a code
+// can't be used twice by concurrent logins. outbox is only written if the code was used.
+func (ds *UserRepository) ConsumeBackupCode(userID, codeHash string, outbox ...*model.OutboxMessage) (int, bool, error) {
+	remaining := 0
+	consumed := false
+
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		var user model.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "backup_codes").
+			Where("id = ?", userID).
+			First(&user).Error; err != nil {
+			return err
+		}
+
+		hashes := user.BackupCodeHashes()
+		remaining = len(hashes)
+		for i, hash := range hashes {
+			if subtle.ConstantTimeCompare([]byte(hash), []byte(codeHash)) != 1 {
+				continue
+			}
+
+			hashes = append(hashes[:i], hashes[i+1:]...)
+			codes, err := json.Marshal(hashes)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&model.User{}).Where("id = ?", userID).
+				Updates(map[string]interface{}{
+					"backup_codes": string(codes),
+					"updated_at":   time.Now(),
+				}).Error; err != nil {
+				return err
+			}
+
+			remaining = len(hashes)
+			consumed = true
+			return insertOutbox(tx, outbox)
+		}
+		return nil
+	})
+	return remaining, consumed, err
+}
+
 // ==================== LOGIN ATTEMPT METHODS ====================
 
 func (ds *UserRepository) RecordLoginAttempt(ip, email, userAgent string, success bool) error {
 	attempt := &model.LoginAttempt{
-		ID:        uuid.New().String(),
+		ID:        ids.New(),
 		IP:        ip,
 		Email:     email,
 		Success:   success,
@@ -509,6 +1007,34 @@ func (ds *UserRepository) AdminGetUsers(page, limit int, search string) ([]model
 	return users, total, nil
 }
 
+// FindCohortUserIDs returns active users with progress matching the filter, at most limit
+func (ds *UserRepository) FindCohortUserIDs(filter dto.AdminCohortFilter, limit int) ([]string, error) {
+	query := ds.db.Table("users").
+		Joins("JOIN user_progresses ON user_progresses.user_id = users.id").
+		Where("users.deleted_at IS NULL AND users.is_active = ?", true)
+
+	if filter.MinLevel != nil {
+		query = query.Where("user_progresses.level >= ?", *filter.MinLevel)
+	}
+	if filter.MaxLevel != nil {
+		query = query.Where("user_progresses.level <= ?", *filter.MaxLevel)
+	}
+	if filter.RegisteredAfter != nil {
+		query = query.Where("users.created_at >= ?", *filter.RegisteredAfter)
+	}
+	if filter.RegisteredBefore != nil {
+		query = query.Where("users.created_at < ?", *filter.RegisteredBefore)
+	}
+	if filter.ActiveWithinDays > 0 {
+		since := time.Now().AddDate(0, 0, -filter.ActiveWithinDays)
+		query = query.Where("user_progresses.last_activity_date >= ?", since)
+	}
+
+	var userIDs []string
+	err := query.Order("users.id").Limit(limit).Pluck("users.id", &userIDs).Error
+	return userIDs, err
+}
+
 func (ds *UserRepository) AdminUpdateUser(userID string, updates map[string]interface{}) error {
 	updates["updated_at"] = time.Now()
 	return ds.db.Model(&model.User{}).Where("id = ?", userID).Updates(updates).Error
@@ -539,6 +1065,26 @@ func (ds *UserRepository) UpdateUserProfile(userID string, updates map[string]in
 	return ds.db.Model(&model.User{}).Where("id = ?", userID).Updates(updates).Error
 }
 
+// AdvanceOnboarding moves a user from one onboarding step to another. It returns false if
+// the user is no longer on fromStep.
+func (ds *UserRepository) AdvanceOnboarding(userID, fromStep, toStep string) (bool, error) {
+	updates := map[string]interface{}{
+		"onboarding_step": toStep,
+		"updated_at":      time.Now(),
+	}
+	if toStep == model.OnboardingStepCompleted {
+		updates["onboarding_completed_at"] = time.Now()
+	}
+
+	result := ds.db.Model(&model.User{}).
+		Where("id = ? AND onboarding_step = ?", userID, fromStep).
+		Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
 func (ds *UserRepository) GetSecuritySettings(userID string) (*dto.SecuritySettings, error) {
 	var user model.User
 	err := ds.db.Where("id = ?", userID).First(&user).Error
@@ -547,11 +1093,19 @@ func (ds *UserRepository) GetSecuritySettings(userID string) (*dto.SecuritySetti
 	}
 
 	settings := &dto.SecuritySettings{
-		TwoFactorEnabled:     user.TwoFactorEnabled,
-		BackupCodesGenerated: user.BackupCodes != "",
-		LastPasswordChange:   user.LastPasswordChange,
-		LoginNotifications:   user.LoginNotifications,
-		SessionTimeout:       user.SessionTimeout,
+		TwoFactorEnabled:      user.TwoFactorEnabled,
+		BackupCodesGenerated:  user.BackupCodes != "",
+		BackupCodesRemaining:  len(user.BackupCodeHashes()),
+		LastPasswordChange:    user.LastPasswordChange,
+		LoginNotifications:    user.LoginNotifications,
+		SessionTimeout:        user.SessionTimeout,
+		MaxActiveSessions:     user.MaxActiveSessions,
+		SessionLimitPolicy:    user.SessionLimitPolicy,
+		LeaderboardVisibility: user.LeaderboardVisibility,
+
+		LoginEmails:          user.SecurityEmailMode(model.SecurityEventLogin),
+		SessionEvictedEmails: user.SecurityEmailMode(model.SecurityEventSessionEvicted),
+		SecurityDigest:       user.SecurityDigest,
 	}
 
 	return settings, nil
@@ -561,16 +1115,215 @@ func (ds *UserRepository) UpdateSecuritySettings(userID string, settings dto.Upd
 	updates := make(map[string]interface{})
 	updates["updated_at"] = time.Now()
 
+	// LoginNotifications is the on/off switch from before login email modes
 	if settings.LoginNotifications != nil {
 		updates["login_notifications"] = *settings.LoginNotifications
+		if *settings.LoginNotifications {
+			updates["login_email_mode"] = model.SecurityEmailAlways
+		} else {
+			updates["login_email_mode"] = model.SecurityEmailNever
+		}
+	}
+	if settings.LoginEmails != nil {
+		updates["login_email_mode"] = *settings.LoginEmails
+		updates["login_notifications"] = *settings.LoginEmails != model.SecurityEmailNever
+	}
+	if settings.SessionEvictedEmails != nil {
+		updates["session_evicted_email_mode"] = *settings.SessionEvictedEmails
+	}
+	if settings.SecurityDigest != nil {
+		updates["security_digest"] = *settings.SecurityDigest
+		// The first digest covers the week after it was turned on
+		if *settings.SecurityDigest {
+			updates["last_security_digest_at"] = time.Now()
+		}
 	}
 	if settings.SessionTimeout != nil {
 		updates["session_timeout"] = *settings.SessionTimeout
 	}
+	if settings.MaxActiveSessions != nil {
+		updates["max_active_sessions"] = *settings.MaxActiveSessions
+	}
+	if settings.SessionLimitPolicy != nil {
+		updates["session_limit_policy"] = *settings.SessionLimitPolicy
+	}
+	if settings.LeaderboardVisibility != nil {
+		updates["leaderboard_visibility"] = *settings.LeaderboardVisibility
+	}
 
 	return ds.db.Model(&model.User{}).Where("id = ?", userID).Updates(updates).Error
 }
 
+// GetUsersDueSecurityDigest returns active users with the weekly digest turned on whose
+// last digest is older than dueBefore
+func (ds *UserRepository) GetUsersDueSecurityDigest(dueBefore time.Time, limit int) ([]model.User, error) {
+	var users []model.User
+	err := ds.db.Where("security_digest = ? AND is_active = ?", true, true).
+		Where("last_security_digest_at IS NULL OR last_security_digest_at < ?", dueBefore).
+		Order("last_security_digest_at ASC NULLS FIRST").
+		Limit(limit).Find(&users).Error
+	return users, err
+}
+
+// ClaimSecurityDigest marks the user's digest as sent, together with the outbox message
+// that sends it. It reports false if another instance claimed it first.
+func (ds *UserRepository) ClaimSecurityDigest(userID string, dueBefore, sentAt time.Time, outbox ...*model.OutboxMessage) (bool, error) {
+	claimed := false
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.User{}).
+			Where("id = ? AND (last_security_digest_at IS NULL OR last_security_digest_at < ?)", userID, dueBefore).
+			Update("last_security_digest_at", sentAt)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		claimed = true
+
+		return insertOutbox(tx, outbox)
+	})
+	return claimed, err
+}
+
+// ==================== MODERATION FLAG METHODS ====================
+
+func (ds *UserRepository) CreateModerationFlag(flag *model.ModerationFlag) error {
+	if flag.ID == "" {
+		flag.ID = ids.New()
+	}
+	if flag.Status == "" {
+		flag.Status = model.FlagStatusPending
+	}
+	flag.CreatedAt = time.Now()
+	return ds.db.Create(flag).Error
+}
+
+func (ds *UserRepository) GetModerationFlag(id string) (*model.ModerationFlag, error) {
+	var flag model.ModerationFlag
+	if err := ds.db.Preload("User").Where("id = ?", id).First(&flag).Error; err != nil {
+		return nil, err
+	}
+	return &flag, nil
+}
+
+// GetModerationFlags lists flags oldest first so the review queue is worked in order
+func (ds *UserRepository) GetModerationFlags(status string, page, limit int) ([]model.ModerationFlag, int64, error) {
+	var flags []model.ModerationFlag
+	var total int64
+
+	db := ds.db.Model(&model.ModerationFlag{})
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := db.Preload("User").
+		Order("created_at ASC").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		Find(&flags).Error; err != nil {
+		return nil, 0, err
+	}
+	return flags, total, nil
+}
+
+// ResolveModerationFlags closes the given flag, or with userID every pending flag of
+// that user, and returns how many were closed
+func (ds *UserRepository) ResolveModerationFlags(flagID, userID, status, reviewerID, note string) (int64, error) {
+	db := ds.db.Model(&model.ModerationFlag{}).Where("status = ?", model.FlagStatusPending)
+	if userID != "" {
+		db = db.Where("id = ? OR user_id = ?", flagID, userID)
+	} else {
+		db = db.Where("id = ?", flagID)
+	}
+
+	result := db.Updates(map[string]interface{}{
+		"status":      status,
+		"reviewed_by": reviewerID,
+		"review_note": note,
+		"reviewed_at": time.Now(),
+	})
+	return result.RowsAffected, result.Error
+}
+
+// ==================== USER NOTE METHODS ====================
+
+func (ds *UserRepository) CreateUserNote(note *model.UserNote) error {
+	if note.ID == "" {
+		note.ID = ids.New()
+	}
+	now := time.Now()
+	note.CreatedAt = now
+	note.UpdatedAt = now
+	return ds.db.Create(note).Error
+}
+
+func (ds *UserRepository) GetUserNote(userID, noteID string) (*model.UserNote, error) {
+	var note model.UserNote
+	if err := ds.db.Preload("Author").Where("id = ? AND user_id = ?", noteID, userID).First(&note).Error; err != nil {
+		return nil, err
+	}
+	return &note, nil
+}
+
+// GetUserNotes lists a user's notes newest first, optionally only those with a tag
+func (ds *UserRepository) GetUserNotes(userID, tag string, page, limit int) ([]model.UserNote, int64, error) {
+	var notes []model.UserNote
+	var total int64
+
+	db := ds.db.Model(&model.UserNote{}).Where("user_id = ?", userID)
+	if tag != "" {
+		db = db.Where("? = ANY(string_to_array(tags, ','))", tag)
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := db.Preload("Author").
+		Order("created_at DESC").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		Find(&notes).Error; err != nil {
+		return nil, 0, err
+	}
+	return notes, total, nil
+}
+
+func (ds *UserRepository) UpdateUserNote(note *model.UserNote) error {
+	note.UpdatedAt = time.Now()
+	return ds.db.Model(note).Select("note", "tags", "ticket_id", "updated_at").Updates(note).Error
+}
+
+func (ds *UserRepository) DeleteUserNote(noteID string) error {
+	return ds.db.Where("id = ?", noteID).Delete(&model.UserNote{}).Error
+}
+
+// CountUserNotes returns the number of notes per user for the given users. Users
+// without notes are left out.
+func (ds *UserRepository) CountUserNotes(userIDs []string) (map[string]int, error) {
+	counts := make(map[string]int)
+	if len(userIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		UserID string
+		Count  int
+	}
+	if err := ds.db.Model(&model.UserNote{}).
+		Select("user_id, count(*) AS count").
+		Where("user_id IN ?", userIDs).
+		Group("user_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.UserID] = row.Count
+	}
+	return counts, nil
+}
+
 // ==================== CLEANUP AND MAINTENANCE ====================
 
 func (ds *UserRepository) CleanupExpiredData() error {
@@ -591,6 +1344,39 @@ func (ds *UserRepository) CleanupExpiredData() error {
 
 // ==================== STATISTICS METHODS ====================
 
+func (ds *UserRepository) GetUserStatistics() (*dto.UserStatisticsResponse, error) {
+	stats := &dto.UserStatisticsResponse{}
+
+	err := ds.db.Model(&model.User{}).Where("deleted_at IS NULL").
+		Select("COUNT(*) AS total_users, "+
+			"COUNT(*) FILTER (WHERE is_active) AS active_users, "+
+			"COUNT(*) FILTER (WHERE email_verified) AS verified_users, "+
+			"COUNT(*) FILTER (WHERE role = ?) AS admin_users", model.RoleAdmin).
+		Scan(stats).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if stats.TotalUsers > 0 {
+		stats.VerificationRate = float64(stats.VerifiedUsers) / float64(stats.TotalUsers) * 100
+	}
+	return stats, nil
+}
+
+func (ds *UserRepository) CountActiveSessions() (int64, error) {
+	var count int64
+	err := ds.db.Model(&model.UserSession{}).
+		Where("is_active = ? AND expires_at > ?", true, time.Now()).Count(&count).Error
+	return count, err
+}
+
+func (ds *UserRepository) CountAuthEventsSince(actions []string, since time.Time) (int64, error) {
+	var count int64
+	err := ds.db.Model(&model.AuthAuditLog{}).
+		Where("action IN ? AND timestamp >= ?", actions, since).Count(&count).Error
+	return count, err
+}
+
 func (ds *UserRepository) GetUserStats(userID string) (*dto.UserStats, error) {
 	var user model.User
 	err := ds.db.Where("id = ?", userID).First(&user).Error
@@ -640,7 +1426,7 @@ func (ds *UserRepository) createDefaultAdmin() error {
 		}
 
 		admin := &model.User{
-			ID:                 "admin-" + time.Now().Format("20060102150405"),
+			ID:                 ids.NewPrefixed(ids.User),
 			Username:           "admin",
 			Email:              "admin@techyouth.com",
 			Password:           string(hashedPassword),
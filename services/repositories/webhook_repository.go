@@ -0,0 +1,149 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared/ids"
+	"gorm.io/gorm"
+)
+
+type WebhookRepository struct {
+	BaseRepository
+}
+
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{
+		BaseRepository: NewBaseRepository(db),
+	}
+}
+
+func (ds *WebhookRepository) CreateEndpoint(endpoint *model.WebhookEndpoint) error {
+	if endpoint.ID == "" {
+		endpoint.ID = ids.New()
+	}
+	endpoint.CreatedAt = time.Now()
+	endpoint.UpdatedAt = time.Now()
+	return ds.db.Create(endpoint).Error
+}
+
+func (ds *WebhookRepository) GetEndpoint(id string) (*model.WebhookEndpoint, error) {
+	var endpoint model.WebhookEndpoint
+	if err := ds.db.Where("id = ?", id).First(&endpoint).Error; err != nil {
+		return nil, err
+	}
+	return &endpoint, nil
+}
+
+func (ds *WebhookRepository) GetEndpoints() ([]model.WebhookEndpoint, error) {
+	var endpoints []model.WebhookEndpoint
+	if err := ds.db.Order("created_at DESC").Find(&endpoints).Error; err != nil {
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+// GetActiveEndpointsForEvent returns active endpoints of the tenant subscribed to the
+// event, or those without a tenant when tenantID is empty
+func (ds *WebhookRepository) GetActiveEndpointsForEvent(event, tenantID string) ([]model.WebhookEndpoint, error) {
+	var endpoints []model.WebhookEndpoint
+	if err := ds.db.Where("is_active = ? AND tenant_id = ? AND ? = ANY(string_to_array(events, ','))", true, tenantID, event).
+		Find(&endpoints).Error; err != nil {
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+func (ds *WebhookRepository) UpdateEndpoint(endpoint *model.WebhookEndpoint) error {
+	endpoint.UpdatedAt = time.Now()
+	return ds.db.Save(endpoint).Error
+}
+
+// DeleteEndpoint removes an endpoint together with its delivery log
+func (ds *WebhookRepository) DeleteEndpoint(id string) (bool, error) {
+	deleted := false
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ?", id).Delete(&model.WebhookEndpoint{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		deleted = true
+		return tx.Where("endpoint_id = ?", id).Delete(&model.WebhookDelivery{}).Error
+	})
+	return deleted, err
+}
+
+func (ds *WebhookRepository) CreateDeliveries(deliveries []model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	now := time.Now()
+	for i := range deliveries {
+		if deliveries[i].ID == "" {
+			deliveries[i].ID = ids.New()
+		}
+		deliveries[i].CreatedAt = now
+	}
+	return ds.db.Create(&deliveries).Error
+}
+
+// ClaimDueDeliveries returns pending deliveries whose next attempt is due, pushing their
+// next attempt back by lease so a slow send is not picked up twice
+func (ds *WebhookRepository) ClaimDueDeliveries(limit int, lease time.Duration) ([]model.WebhookDelivery, error) {
+	var deliveries []model.WebhookDelivery
+	err := ds.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Raw(`
+			SELECT * FROM webhook_deliveries
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED`, model.WebhookDeliveryPending, time.Now(), limit).
+			Scan(&deliveries).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+
+		ids := make([]string, len(deliveries))
+		for i, d := range deliveries {
+			ids[i] = d.ID
+		}
+		return tx.Model(&model.WebhookDelivery{}).Where("id IN ?", ids).
+			Update("next_attempt_at", time.Now().Add(lease)).Error
+	})
+	return deliveries, err
+}
+
+func (ds *WebhookRepository) UpdateDelivery(delivery *model.WebhookDelivery) error {
+	return ds.db.Save(delivery).Error
+}
+
+func (ds *WebhookRepository) GetDelivery(endpointID, deliveryID string) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	if err := ds.db.Where("id = ? AND endpoint_id = ?", deliveryID, endpointID).First(&delivery).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (ds *WebhookRepository) GetDeliveries(endpointID, status string, page, limit int) ([]model.WebhookDelivery, int64, error) {
+	var deliveries []model.WebhookDelivery
+	var total int64
+
+	query := ds.db.Model(&model.WebhookDelivery{}).Where("endpoint_id = ?", endpointID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if err := query.Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		Find(&deliveries).Error; err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
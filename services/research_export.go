@@ -0,0 +1,487 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/cloakd/common/context"
+	serviceContext "github.com/cloakd/common/services"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ResearchExportService prepares anonymized cohort datasets for research partners. An
+// export holds only registration-month and dynasty aggregates with every group smaller
+// than the k-anonymity threshold suppressed. Archives are kept in MinIO for a limited
+// time and handed out as short-lived signed links; requests and links are audited.
+type ResearchExportService struct {
+	serviceContext.DefaultService
+
+	sqlSvc   *PostgresService
+	minioSvc *MinIOService
+
+	// Smallest k an export may use
+	minCohortSize int
+	retentionDays int
+}
+
+const RESEARCH_EXPORT_SVC = "research_export_svc"
+
+const (
+	defaultResearchMinCohortSize = 10
+	defaultResearchRetentionDays = 30
+
+	researchExportURLTTL = 15 * time.Minute
+	researchDateLayout   = "2006-01-02"
+)
+
+func (svc *ResearchExportService) Id() string {
+	return RESEARCH_EXPORT_SVC
+}
+
+func (svc *ResearchExportService) Configure(ctx *context.Context) error {
+	svc.minCohortSize = defaultResearchMinCohortSize
+	if v := os.Getenv("RESEARCH_EXPORT_MIN_COHORT_SIZE"); v != "" {
+		k, err := strconv.Atoi(v)
+		if err != nil || k < 2 {
+			return fmt.Errorf("invalid RESEARCH_EXPORT_MIN_COHORT_SIZE: %q", v)
+		}
+		svc.minCohortSize = k
+	}
+
+	svc.retentionDays = defaultResearchRetentionDays
+	if v := os.Getenv("RESEARCH_EXPORT_RETENTION_DAYS"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 1 {
+			return fmt.Errorf("invalid RESEARCH_EXPORT_RETENTION_DAYS: %q", v)
+		}
+		svc.retentionDays = days
+	}
+
+	return svc.DefaultService.Configure(ctx)
+}
+
+func (svc *ResearchExportService) Start() error {
+	deps := newDependencies(svc.Id(), svc.Service)
+	svc.sqlSvc = resolve[*PostgresService](deps, POSTGRES_SVC)
+	svc.minioSvc = resolve[*MinIOService](deps, MINIO_SVC)
+	if err := deps.err(); err != nil {
+		return err
+	}
+
+	go svc.startExpiryJob()
+
+	return nil
+}
+
+func (svc *ResearchExportService) startExpiryJob() {
+	ticker := time.NewTicker(time.Hour)
+	for range ticker.C {
+		svc.expireExports()
+	}
+}
+
+// ==================== EXPORT METHODS ====================
+
+// RequestExport records the request and builds the export in the background
+func (svc *ResearchExportService) RequestExport(adminID, ipAddress, userAgent string, req dto.CreateResearchExportRequest) (*model.ResearchExport, error) {
+	from, err := time.Parse(researchDateLayout, req.CohortFrom)
+	if err != nil {
+		return nil, shared.NewBadRequestError(err, "Invalid cohort_from")
+	}
+	to, err := time.Parse(researchDateLayout, req.CohortTo)
+	if err != nil {
+		return nil, shared.NewBadRequestError(err, "Invalid cohort_to")
+	}
+	if !from.Before(to) {
+		return nil, shared.NewBadRequestError(nil, "cohort_from must be before cohort_to")
+	}
+
+	k := req.MinCohortSize
+	if k == 0 {
+		k = svc.minCohortSize
+	}
+	if k < svc.minCohortSize {
+		return nil, shared.NewBadRequestError(nil, fmt.Sprintf("min_cohort_size can't be below %d", svc.minCohortSize))
+	}
+
+	export := &model.ResearchExport{
+		RequestedBy:   adminID,
+		Partner:       req.Partner,
+		Purpose:       req.Purpose,
+		CohortFrom:    from,
+		CohortTo:      to,
+		MinCohortSize: k,
+		Status:        model.ResearchExportPending,
+	}
+	if err := svc.sqlSvc.researchRepo.CreateResearchExport(export); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to create research export")
+	}
+	if err := svc.recordAccess(export.ID, adminID, model.ResearchExportActionRequested, ipAddress, userAgent); err != nil {
+		return nil, err
+	}
+
+	go svc.runExport(*export)
+
+	return export, nil
+}
+
+func (svc *ResearchExportService) GetExports(page, limit int) (*dto.ResearchExportListResponse, error) {
+	exports, total, err := svc.sqlSvc.researchRepo.GetResearchExports(page, limit)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get research exports")
+	}
+	return &dto.ResearchExportListResponse{
+		Exports: exports,
+		Total:   int(total),
+		Page:    page,
+		Limit:   limit,
+	}, nil
+}
+
+func (svc *ResearchExportService) GetExport(exportID string) (*dto.ResearchExportDetailResponse, error) {
+	export, err := svc.getExport(exportID)
+	if err != nil {
+		return nil, err
+	}
+	accesses, err := svc.sqlSvc.researchRepo.GetResearchExportAccesses(exportID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get research export audit trail")
+	}
+	return &dto.ResearchExportDetailResponse{ResearchExport: *export, AuditTrail: accesses}, nil
+}
+
+// GetDownloadURL hands out a signed link to a finished export. The link is only issued
+// once it is in the audit trail.
+func (svc *ResearchExportService) GetDownloadURL(adminID, exportID, ipAddress, userAgent string) (*dto.ResearchExportDownloadResponse, error) {
+	export, err := svc.getExport(exportID)
+	if err != nil {
+		return nil, err
+	}
+	switch export.Status {
+	case model.ResearchExportCompleted:
+	case model.ResearchExportExpired:
+		return nil, shared.NewConflictError(nil, "Research export has expired")
+	default:
+		return nil, shared.NewConflictError(nil, "Research export is not ready")
+	}
+
+	if err := svc.recordAccess(export.ID, adminID, model.ResearchExportActionDownload, ipAddress, userAgent); err != nil {
+		return nil, err
+	}
+
+	url, err := svc.minioSvc.GetFileURL(svc.minioSvc.BucketFor("research_export"), export.ObjectName, researchExportURLTTL)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to sign research export link")
+	}
+	return &dto.ResearchExportDownloadResponse{
+		URL:       url,
+		ExpiresAt: time.Now().Add(researchExportURLTTL),
+		Checksum:  export.Checksum,
+	}, nil
+}
+
+func (svc *ResearchExportService) getExport(exportID string) (*model.ResearchExport, error) {
+	export, err := svc.sqlSvc.researchRepo.GetResearchExport(exportID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, shared.NewNotFoundError(err, "Research export not found")
+		}
+		return nil, shared.NewInternalError(err, "Failed to get research export")
+	}
+	return export, nil
+}
+
+func (svc *ResearchExportService) recordAccess(exportID, adminID, action, ipAddress, userAgent string) error {
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+	access := &model.ResearchExportAccess{
+		ExportID:  exportID,
+		AdminID:   adminID,
+		Action:    action,
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
+	}
+	if err := svc.sqlSvc.researchRepo.CreateResearchExportAccess(access); err != nil {
+		return shared.NewInternalError(err, "Failed to record research export access")
+	}
+	return nil
+}
+
+// runExport queries the cohort aggregates, anonymizes them and stores the archive
+func (svc *ResearchExportService) runExport(export model.ResearchExport) {
+	export.Status = model.ResearchExportRunning
+	if err := svc.sqlSvc.researchRepo.UpdateResearchExport(&export); err != nil {
+		log.Errorf("Failed to start research export %s: %v", export.ID, err)
+		return
+	}
+
+	if err := svc.buildExport(&export); err != nil {
+		log.Errorf("Research export %s failed: %v", export.ID, err)
+		export.Status = model.ResearchExportFailed
+		export.Error = err.Error()
+	} else {
+		now := time.Now()
+		expiresAt := now.AddDate(0, 0, svc.retentionDays)
+		export.Status = model.ResearchExportCompleted
+		export.CompletedAt = &now
+		export.ExpiresAt = &expiresAt
+	}
+
+	if err := svc.sqlSvc.researchRepo.UpdateResearchExport(&export); err != nil {
+		log.Errorf("Failed to save research export %s: %v", export.ID, err)
+	}
+}
+
+func (svc *ResearchExportService) buildExport(export *model.ResearchExport) error {
+	activity, err := svc.sqlSvc.researchRepo.GetCohortActivity(export.CohortFrom, export.CohortTo, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to load cohort activity: %w", err)
+	}
+	scores, err := svc.sqlSvc.researchRepo.GetDynastyScores(export.CohortFrom, export.CohortTo)
+	if err != nil {
+		return fmt.Errorf("failed to load dynasty scores: %w", err)
+	}
+
+	archive, err := buildResearchArchive(export, activity, scores, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to build archive: %w", err)
+	}
+
+	objectName := fmt.Sprintf("%s/%s.zip", mediaObjectDir("research_export"), export.ID)
+	if _, err := svc.minioSvc.UploadFile(svc.minioSvc.BucketFor("research_export"), objectName,
+		bytes.NewReader(archive.data), int64(len(archive.data)), "application/zip"); err != nil {
+		return fmt.Errorf("failed to store archive: %w", err)
+	}
+
+	sum := sha256.Sum256(archive.data)
+	export.ObjectName = objectName
+	export.Checksum = hex.EncodeToString(sum[:])
+	export.Size = int64(len(archive.data))
+	export.Rows = archive.rows
+	export.SuppressedRows = archive.suppressedRows
+	return nil
+}
+
+// expireExports deletes archives past their expiry. The export record and its audit
+// trail are kept.
+func (svc *ResearchExportService) expireExports() {
+	exports, err := svc.sqlSvc.researchRepo.GetExpiredResearchExports(time.Now())
+	if err != nil {
+		log.Errorf("Failed to load expired research exports: %v", err)
+		return
+	}
+
+	for i := range exports {
+		export := &exports[i]
+		if err := svc.minioSvc.DeleteFile(svc.minioSvc.BucketFor("research_export"), export.ObjectName); err != nil {
+			log.Errorf("Failed to delete research export %s: %v", export.ID, err)
+			continue
+		}
+		export.Status = model.ResearchExportExpired
+		export.ObjectName = ""
+		if err := svc.sqlSvc.researchRepo.UpdateResearchExport(export); err != nil {
+			log.Errorf("Failed to expire research export %s: %v", export.ID, err)
+			continue
+		}
+		if err := svc.recordAccess(export.ID, "", model.ResearchExportActionExpired, "", ""); err != nil {
+			log.Errorf("Failed to audit expiry of research export %s: %v", export.ID, err)
+		}
+	}
+}
+
+// ==================== ANONYMIZATION ====================
+
+type researchArchive struct {
+	data           []byte
+	rows           int
+	suppressedRows int
+}
+
+// researchManifest describes the archive to the partner
+type researchManifest struct {
+	ExportID      string            `json:"export_id"`
+	Partner       string            `json:"partner"`
+	Purpose       string            `json:"purpose"`
+	CohortFrom    string            `json:"cohort_from"`
+	CohortTo      string            `json:"cohort_to"`
+	MinCohortSize int               `json:"min_cohort_size"`
+	GeneratedAt   time.Time         `json:"generated_at"`
+	Files         map[string]string `json:"files"`
+	Suppression   string            `json:"suppression"`
+}
+
+var (
+	researchCohortHeader    = []string{"cohort", "learners", "active_learners", "completion_rate", "lessons_per_learner"}
+	researchRetentionHeader = []string{"cohort", "eligible_day1", "retained_day1", "retention_day1",
+		"eligible_day7", "retained_day7", "retention_day7", "eligible_day30", "retained_day30", "retention_day30"}
+	researchScoreHeader = []string{"dynasty", "learners", "completions", "average_score",
+		"score_0_49", "score_50_69", "score_70_89", "score_90_100"}
+)
+
+// buildResearchArchive writes the anonymized tables and a manifest into a zip archive
+func buildResearchArchive(export *model.ResearchExport, activity []model.CohortActivityRow, scores []model.DynastyScoreRow, now time.Time) (*researchArchive, error) {
+	k := export.MinCohortSize
+	cohorts, retention, suppressedCohorts := anonymizeCohorts(activity, k)
+	dynasties, suppressedDynasties := anonymizeDynastyScores(scores, k)
+
+	manifest := researchManifest{
+		ExportID:      export.ID,
+		Partner:       export.Partner,
+		Purpose:       export.Purpose,
+		CohortFrom:    export.CohortFrom.Format(researchDateLayout),
+		CohortTo:      export.CohortTo.Format(researchDateLayout),
+		MinCohortSize: k,
+		GeneratedAt:   now.UTC(),
+		Files: map[string]string{
+			"cohorts.csv":        "Learners per registration month and how many completed a lesson",
+			"retention.csv":      "Share of each cohort still completing lessons 1, 7 and 30 days after registering, among learners registered that long ago",
+			"dynasty_scores.csv": "Distribution of lesson completion scores per dynasty",
+		},
+		Suppression: fmt.Sprintf("Rows describing fewer than %d learners are left out. Counts below %[1]d, or "+
+			"leaving fewer than %[1]d in the rest of their group, are blank along with the rates derived from them.", k),
+	}
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	files := []struct {
+		name string
+		data func() ([]byte, error)
+	}{
+		{"manifest.json", func() ([]byte, error) { return manifestJSON, nil }},
+		{"cohorts.csv", func() ([]byte, error) { return writeResearchCSV(researchCohortHeader, cohorts) }},
+		{"retention.csv", func() ([]byte, error) { return writeResearchCSV(researchRetentionHeader, retention) }},
+		{"dynasty_scores.csv", func() ([]byte, error) { return writeResearchCSV(researchScoreHeader, dynasties) }},
+	}
+	for _, file := range files {
+		data, err := file.data()
+		if err != nil {
+			return nil, err
+		}
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: now})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return &researchArchive{
+		data:           buf.Bytes(),
+		rows:           len(cohorts) + len(dynasties),
+		suppressedRows: suppressedCohorts + suppressedDynasties,
+	}, nil
+}
+
+// anonymizeCohorts turns the cohort aggregates into cohort and retention rows, leaving
+// out cohorts of fewer than k learners
+func anonymizeCohorts(activity []model.CohortActivityRow, k int) (cohorts, retention [][]string, suppressed int) {
+	for _, row := range activity {
+		if row.Learners < int64(k) {
+			suppressed++
+			continue
+		}
+
+		activeOK := kAnonymous(row.ActiveLearners, row.Learners, k)
+		cohorts = append(cohorts, []string{
+			row.Cohort,
+			strconv.FormatInt(row.Learners, 10),
+			formatResearchCount(row.ActiveLearners, activeOK),
+			formatResearchRate(row.ActiveLearners, row.Learners, activeOK),
+			formatResearchRate(row.Completions, row.Learners, true),
+		})
+
+		retentionRow := []string{row.Cohort}
+		for _, day := range []struct{ eligible, retained int64 }{
+			{row.EligibleDay1, row.RetainedDay1},
+			{row.EligibleDay7, row.RetainedDay7},
+			{row.EligibleDay30, row.RetainedDay30},
+		} {
+			eligibleOK := kAnonymous(day.eligible, row.Learners, k)
+			retainedOK := eligibleOK && kAnonymous(day.retained, day.eligible, k)
+			retentionRow = append(retentionRow,
+				formatResearchCount(day.eligible, eligibleOK),
+				formatResearchCount(day.retained, retainedOK),
+				formatResearchRate(day.retained, day.eligible, retainedOK))
+		}
+		retention = append(retention, retentionRow)
+	}
+	return cohorts, retention, suppressed
+}
+
+// anonymizeDynastyScores leaves out dynasties completed by fewer than k learners and
+// blanks small score buckets
+func anonymizeDynastyScores(scores []model.DynastyScoreRow, k int) (rows [][]string, suppressed int) {
+	for _, row := range scores {
+		if row.Learners < int64(k) {
+			suppressed++
+			continue
+		}
+
+		cells := []string{
+			row.Dynasty,
+			strconv.FormatInt(row.Learners, 10),
+			strconv.FormatInt(row.Completions, 10),
+			strconv.FormatFloat(row.AverageScore, 'f', 1, 64),
+		}
+		for _, bucket := range []int64{row.Score0To49, row.Score50To69, row.Score70To89, row.Score90To100} {
+			cells = append(cells, formatResearchCount(bucket, kAnonymous(bucket, row.Completions, k)))
+		}
+		rows = append(rows, cells)
+	}
+	return rows, suppressed
+}
+
+// kAnonymous reports whether a count out of total can be published: neither it nor the
+// rest of the group may be a non-empty group of fewer than k
+func kAnonymous(count, total int64, k int) bool {
+	rest := total - count
+	return !(count > 0 && count < int64(k)) && !(rest > 0 && rest < int64(k))
+}
+
+func formatResearchCount(count int64, ok bool) string {
+	if !ok {
+		return ""
+	}
+	return strconv.FormatInt(count, 10)
+}
+
+func formatResearchRate(count, total int64, ok bool) string {
+	if !ok || total == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(float64(count)/float64(total), 'f', 4, 64)
+}
+
+func writeResearchCSV(header []string, rows [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(header); err != nil {
+		return nil, err
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/lac-hong-legacy/ven_api/model"
+)
+
+func TestKAnonymous(t *testing.T) {
+	tests := []struct {
+		count, total int64
+		want         bool
+	}{
+		{0, 50, true},
+		{50, 50, true},
+		{20, 50, true},
+		{3, 50, false},  // small group
+		{47, 50, false}, // small rest of the group
+		{0, 0, true},
+	}
+	for _, tt := range tests {
+		if got := kAnonymous(tt.count, tt.total, 10); got != tt.want {
+			t.Errorf("kAnonymous(%d, %d, 10) = %v, want %v", tt.count, tt.total, got, tt.want)
+		}
+	}
+}
+
+func TestAnonymizeCohorts(t *testing.T) {
+	activity := []model.CohortActivityRow{
+		{Cohort: "2025-01", Learners: 4, ActiveLearners: 4, Completions: 12},
+		{
+			Cohort: "2025-02", Learners: 40, ActiveLearners: 25, Completions: 100,
+			EligibleDay1: 40, RetainedDay1: 30,
+			EligibleDay7: 40, RetainedDay7: 5,
+			EligibleDay30: 0, RetainedDay30: 0,
+		},
+	}
+
+	cohorts, retention, suppressed := anonymizeCohorts(activity, 10)
+	if suppressed != 1 {
+		t.Errorf("suppressed = %d, want the cohort of 4 left out", suppressed)
+	}
+	if want := [][]string{{"2025-02", "40", "25", "0.6250", "2.5000"}}; !reflect.DeepEqual(cohorts, want) {
+		t.Errorf("cohorts = %v, want %v", cohorts, want)
+	}
+	want := [][]string{{"2025-02", "40", "30", "0.7500", "40", "", "", "0", "0", ""}}
+	if !reflect.DeepEqual(retention, want) {
+		t.Errorf("retention = %v, want %v", retention, want)
+	}
+}
+
+func TestAnonymizeDynastyScores(t *testing.T) {
+	scores := []model.DynastyScoreRow{
+		{Dynasty: "Nhà Lý", Learners: 9, Completions: 30},
+		{Dynasty: "Nhà Trần", Learners: 30, Completions: 60, AverageScore: 81.4,
+			Score0To49: 2, Score50To69: 10, Score70To89: 28, Score90To100: 20},
+	}
+
+	rows, suppressed := anonymizeDynastyScores(scores, 10)
+	if suppressed != 1 {
+		t.Errorf("suppressed = %d, want the dynasty with 9 learners left out", suppressed)
+	}
+	want := [][]string{{"Nhà Trần", "30", "60", "81.4", "", "10", "28", "20"}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %v, want %v", rows, want)
+	}
+}
@@ -0,0 +1,326 @@
+package services
+
+import (
+	"encoding/json"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cloakd/common/context"
+	serviceContext "github.com/cloakd/common/services"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	log "github.com/sirupsen/logrus"
+)
+
+// Retention run states
+const (
+	RetentionStatusIdle      = "idle"
+	RetentionStatusRunning   = "running"
+	RetentionStatusCompleted = "completed"
+	RetentionStatusFailed    = "failed"
+)
+
+// retentionBatchPause spaces out delete batches so pruning does not starve live traffic
+const retentionBatchPause = 200 * time.Millisecond
+
+// RetentionService prunes raw answer and attempt rows according to the admin-configured
+// retention policies, folding them into daily stats first, and watches table sizes
+type RetentionService struct {
+	serviceContext.DefaultService
+
+	sqlSvc    *PostgresService
+	systemSvc *SystemService
+
+	batchSize      int
+	tableWarnBytes int64
+
+	mutex sync.Mutex
+	job   dto.RetentionRunResponse
+
+	outboxSvc *OutboxService
+	emailSvc  *EmailService
+
+	anonymizeEnabled      bool
+	anonymizeInactiveDays int
+	anonymizeWarningDays  []int
+}
+
+const RETENTION_SVC = "retention_svc"
+
+func (svc *RetentionService) Id() string {
+	return RETENTION_SVC
+}
+
+func (svc *RetentionService) Configure(ctx *context.Context) error {
+	svc.batchSize = 5000
+	if v, err := strconv.Atoi(os.Getenv("RETENTION_BATCH_SIZE")); err == nil && v > 0 {
+		svc.batchSize = v
+	}
+
+	warnMB := int64(1024)
+	if v, err := strconv.ParseInt(os.Getenv("RETENTION_TABLE_WARN_MB"), 10, 64); err == nil && v > 0 {
+		warnMB = v
+	}
+	svc.tableWarnBytes = warnMB * 1024 * 1024
+
+	if err := svc.configureAnonymization(); err != nil {
+		return err
+	}
+
+	return svc.DefaultService.Configure(ctx)
+}
+
+func (svc *RetentionService) Start() error {
+	deps := newDependencies(svc.Id(), svc.Service)
+	svc.sqlSvc = resolve[*PostgresService](deps, POSTGRES_SVC)
+	svc.systemSvc = resolve[*SystemService](deps, SYSTEM_SVC)
+	svc.outboxSvc = resolve[*OutboxService](deps, OUTBOX_SVC)
+	svc.emailSvc = resolve[*EmailService](deps, EMAIL_SVC)
+	if err := deps.err(); err != nil {
+		return err
+	}
+
+	svc.outboxSvc.Handle(OutboxTopicInactivityWarningEmail, func(payload json.RawMessage) error {
+		var email InactivityWarningEmail
+		if err := json.Unmarshal(payload, &email); err != nil {
+			return err
+		}
+		return svc.emailSvc.SendInactivityWarningEmail(email.Email, email.Username, email.AnonymizeOn)
+	})
+
+	go svc.startRetentionScheduler()
+
+	return nil
+}
+
+func (svc *RetentionService) startRetentionScheduler() {
+	ticker := time.NewTicker(24 * time.Hour)
+	for range ticker.C {
+		if _, err := svc.StartRetentionRun(); err != nil {
+			log.WithError(err).Warn("Skipped scheduled retention run")
+		}
+	}
+}
+
+func (svc *RetentionService) GetRetentionPolicies() ([]model.RetentionPolicy, error) {
+	policies, err := svc.sqlSvc.retentionRepo.GetRetentionPolicies()
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to load retention policies")
+	}
+	return policies, nil
+}
+
+func (svc *RetentionService) UpdateRetentionPolicy(adminID, table string, req dto.UpdateRetentionPolicyRequest) (*model.RetentionPolicy, error) {
+	if !slices.Contains(model.RetentionTables, table) {
+		return nil, shared.NewNotFoundError(nil, "No retention policy for this table")
+	}
+
+	policy, err := svc.sqlSvc.retentionRepo.GetRetentionPolicy(table)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to load retention policy")
+	}
+
+	if req.Enabled != nil {
+		policy.Enabled = *req.Enabled
+	}
+	if req.RetentionMonths != nil {
+		policy.RetentionMonths = *req.RetentionMonths
+	}
+	if req.MaxRowsPerUser != nil {
+		if *req.MaxRowsPerUser > 0 && table == model.RetentionTableGuestLessonAttempts {
+			return nil, shared.NewBadRequestError(nil, "Per-user quotas do not apply to guest attempts")
+		}
+		policy.MaxRowsPerUser = *req.MaxRowsPerUser
+	}
+	policy.UpdatedBy = adminID
+
+	if err := svc.sqlSvc.retentionRepo.UpdateRetentionPolicy(policy); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to update retention policy")
+	}
+
+	return policy, nil
+}
+
+func (svc *RetentionService) GetTableSizes() ([]dto.TableSizeResponse, error) {
+	sizes, err := svc.sqlSvc.retentionRepo.GetTableSizes()
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to read table sizes")
+	}
+	return sizes, nil
+}
+
+// StartRetentionRun applies every enabled policy in the background, then warns and
+// anonymizes inactive accounts when that is enabled. Only one run happens at a time; its
+// progress is available from GetRetentionStatus.
+func (svc *RetentionService) StartRetentionRun() (*dto.RetentionRunResponse, error) {
+	svc.mutex.Lock()
+	defer svc.mutex.Unlock()
+
+	if svc.job.Status == RetentionStatusRunning {
+		return nil, shared.NewConflictError(nil, "A retention run is already in progress")
+	}
+
+	now := time.Now()
+	svc.job = dto.RetentionRunResponse{
+		Status:    RetentionStatusRunning,
+		StartedAt: &now,
+		Tables:    []dto.RetentionTableReport{},
+	}
+	go svc.runRetention()
+
+	job := svc.job
+	return &job, nil
+}
+
+func (svc *RetentionService) GetRetentionStatus() *dto.RetentionRunResponse {
+	svc.mutex.Lock()
+	defer svc.mutex.Unlock()
+
+	job := svc.job
+	if job.Status == "" {
+		job.Status = RetentionStatusIdle
+	}
+	job.Tables = slices.Clone(job.Tables)
+	return &job
+}
+
+func (svc *RetentionService) runRetention() {
+	policies, err := svc.sqlSvc.retentionRepo.GetRetentionPolicies()
+
+	for i := range policies {
+		if !policies[i].Enabled {
+			continue
+		}
+
+		svc.mutex.Lock()
+		svc.job.Tables = append(svc.job.Tables, dto.RetentionTableReport{Table: policies[i].Table})
+		svc.mutex.Unlock()
+
+		svc.applyPolicy(&policies[i])
+	}
+
+	svc.checkTableSizes()
+
+	var anonymization *model.AnonymizationRun
+	if svc.anonymizeEnabled {
+		anonymization = svc.anonymizeInactiveUsers()
+	}
+
+	svc.mutex.Lock()
+	defer svc.mutex.Unlock()
+
+	now := time.Now()
+	svc.job.FinishedAt = &now
+	svc.job.Status = RetentionStatusCompleted
+	svc.job.Anonymization = anonymization
+	if anonymization != nil && anonymization.Error != "" {
+		svc.job.Status = RetentionStatusFailed
+	}
+	if err != nil {
+		svc.job.Status = RetentionStatusFailed
+		log.WithError(err).Error("Failed to load retention policies")
+	}
+	for _, report := range svc.job.Tables {
+		if report.Error != "" {
+			svc.job.Status = RetentionStatusFailed
+		}
+	}
+	log.Printf("Retention run finished in %s", now.Sub(*svc.job.StartedAt).Round(time.Second))
+}
+
+// applyPolicy prunes a table in batches, first rows past the retention window and then
+// rows over the per-user quota, updating the job report as it goes
+func (svc *RetentionService) applyPolicy(policy *model.RetentionPolicy) {
+	cutoff := time.Now().AddDate(0, -policy.RetentionMonths, 0)
+	log.Printf("Retention: pruning %s rows not updated since %s", policy.Table, cutoff.Format("2006-01-02"))
+
+	aged, err := svc.pruneInBatches(policy.Table, "aged", func() (int64, error) {
+		return svc.sqlSvc.retentionRepo.PruneOlderThan(policy.Table, cutoff, svc.batchSize)
+	})
+
+	var quota int64
+	if err == nil && policy.MaxRowsPerUser > 0 && policy.Table != model.RetentionTableGuestLessonAttempts {
+		log.Printf("Retention: pruning %s rows beyond %d per user", policy.Table, policy.MaxRowsPerUser)
+		quota, err = svc.pruneInBatches(policy.Table, "quota", func() (int64, error) {
+			return svc.sqlSvc.retentionRepo.PruneOverQuota(policy.Table, policy.MaxRowsPerUser, svc.batchSize)
+		})
+	}
+
+	if err != nil {
+		log.WithError(err).WithField("table", policy.Table).Error("Retention run failed")
+		svc.updateReport(policy.Table, func(r *dto.RetentionTableReport) { r.Error = err.Error() })
+	}
+
+	now := time.Now()
+	policy.LastRunAt = &now
+	policy.LastPrunedRows = aged + quota
+	if err := svc.sqlSvc.retentionRepo.UpdateRetentionPolicy(policy); err != nil {
+		log.WithError(err).WithField("table", policy.Table).Error("Failed to record retention run")
+	}
+
+	log.Printf("Retention: pruned %d row(s) from %s (%d aged, %d over quota)", aged+quota, policy.Table, aged, quota)
+}
+
+func (svc *RetentionService) pruneInBatches(table, kind string, prune func() (int64, error)) (int64, error) {
+	var total int64
+	for {
+		deleted, err := prune()
+		if err != nil {
+			return total, err
+		}
+		total += deleted
+
+		svc.updateReport(table, func(r *dto.RetentionTableReport) {
+			if kind == "quota" {
+				r.PrunedQuota = total
+			} else {
+				r.PrunedAged = total
+			}
+		})
+
+		if deleted < int64(svc.batchSize) {
+			return total, nil
+		}
+		log.Printf("Retention: %d %s row(s) pruned from %s so far", total, kind, table)
+		time.Sleep(retentionBatchPause)
+	}
+}
+
+func (svc *RetentionService) updateReport(table string, update func(r *dto.RetentionTableReport)) {
+	svc.mutex.Lock()
+	defer svc.mutex.Unlock()
+
+	for i := range svc.job.Tables {
+		if svc.job.Tables[i].Table == table {
+			update(&svc.job.Tables[i])
+		}
+	}
+}
+
+// checkTableSizes logs the size of the retention tables and warns the ops stream about
+// any table over RETENTION_TABLE_WARN_MB
+func (svc *RetentionService) checkTableSizes() {
+	sizes, err := svc.sqlSvc.retentionRepo.GetTableSizes()
+	if err != nil {
+		log.WithError(err).Error("Failed to read table sizes")
+		return
+	}
+
+	for _, size := range sizes {
+		if slices.Contains(model.RetentionTables, size.Table) {
+			log.Printf("Table %s: %d MB, ~%d rows", size.Table, size.TotalBytes/(1024*1024), size.EstimatedRows)
+		}
+		if size.TotalBytes > svc.tableWarnBytes {
+			log.Warnf("Table %s is %d MB, over the %d MB warning size", size.Table, size.TotalBytes/(1024*1024), svc.tableWarnBytes/(1024*1024))
+			svc.systemSvc.PublishOpsEvent(OpsEventTableSize, OpsSeverityWarning, map[string]interface{}{
+				"table":       size.Table,
+				"total_bytes": size.TotalBytes,
+				"rows":        size.EstimatedRows,
+			})
+		}
+	}
+}
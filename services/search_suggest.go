@@ -0,0 +1,182 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	"github.com/lac-hong-legacy/ven_api/shared/text"
+	log "github.com/sirupsen/logrus"
+)
+
+// How long an instance serves suggestions from its copy of the index. Content changes
+// rebuild it at once on the instance that made them.
+const searchSuggestTTL = 5 * time.Minute
+
+// Suggestion types listed first win ties
+var searchSuggestTypeOrder = map[string]int{
+	model.SearchEntityCharacter: 0,
+	model.SearchEntityDynasty:   1,
+	model.SearchEntityLesson:    2,
+}
+
+// suggestIndex is a trie over the folded words of every character name, dynasty and
+// lesson title. A name is inserted once from each of its words, so "hung dao" finds
+// "Trần Hưng Đạo"; every node lists the suggestions below it.
+type suggestIndex struct {
+	mutex       sync.Mutex
+	root        *suggestNode
+	suggestions []dto.SearchSuggestion
+	loadedAt    time.Time
+}
+
+type suggestNode struct {
+	children map[rune]*suggestNode
+	hits     []suggestHit
+}
+
+type suggestHit struct {
+	suggestion int
+	word       int // index of the word the match starts at
+}
+
+func newSuggestIndex() *suggestIndex {
+	return &suggestIndex{}
+}
+
+func (idx *suggestIndex) invalidate() {
+	idx.mutex.Lock()
+	idx.loadedAt = time.Time{}
+	idx.mutex.Unlock()
+}
+
+// suggestEntries returns the trie and its suggestions, rebuilding them when older than
+// searchSuggestTTL. If the rebuild fails the previous index is kept.
+func (svc *ContentService) suggestEntries() (*suggestNode, []dto.SearchSuggestion) {
+	idx := svc.suggest
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+
+	if idx.root != nil && time.Since(idx.loadedAt) < searchSuggestTTL {
+		return idx.root, idx.suggestions
+	}
+
+	sources, err := svc.contentRepo.GetSearchSuggestionSources()
+	if err != nil {
+		log.Printf("Failed to load search suggestions: %v", err)
+		return idx.root, idx.suggestions
+	}
+
+	root := &suggestNode{}
+	suggestions := make([]dto.SearchSuggestion, 0, len(sources))
+	for _, source := range sources {
+		words := strings.Fields(text.Fold(source.Text))
+		if len(words) == 0 {
+			continue
+		}
+
+		i := len(suggestions)
+		suggestions = append(suggestions, dto.SearchSuggestion{
+			Type:        source.EntityType,
+			ID:          source.EntityID,
+			Text:        source.Text,
+			CharacterID: source.CharacterID,
+		})
+		for w := range words {
+			root.insert(strings.Join(words[w:], " "), suggestHit{suggestion: i, word: w})
+		}
+	}
+
+	idx.root = root
+	idx.suggestions = suggestions
+	idx.loadedAt = time.Now()
+	return root, suggestions
+}
+
+func (n *suggestNode) insert(key string, hit suggestHit) {
+	node := n
+	for _, r := range key {
+		child, ok := node.children[r]
+		if !ok {
+			if node.children == nil {
+				node.children = make(map[rune]*suggestNode)
+			}
+			child = &suggestNode{}
+			node.children[r] = child
+		}
+		child.hits = append(child.hits, hit)
+		node = child
+	}
+}
+
+func (n *suggestNode) find(prefix string) *suggestNode {
+	node := n
+	for _, r := range prefix {
+		if node = node.children[r]; node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// ==================== SEARCH SUGGESTION METHODS ====================
+
+// SuggestSearch returns names and titles with a word starting with the query, ignoring
+// case and diacritics. Names that start with the query rank first, then shorter ones.
+func (svc *ContentService) SuggestSearch(query string, limit int) (*dto.SearchSuggestResponse, error) {
+	response := &dto.SearchSuggestResponse{Query: query, Suggestions: []dto.SearchSuggestion{}}
+
+	prefix := text.Fold(query)
+	if prefix == "" {
+		return response, nil
+	}
+
+	root, suggestions := svc.suggestEntries()
+	if root == nil {
+		return nil, shared.NewInternalError(errors.New("suggestion index not loaded"), "Search suggestions are unavailable")
+	}
+
+	node := root.find(prefix)
+	if node == nil {
+		return response, nil
+	}
+
+	// A name can match from several of its words; keep its earliest
+	firstWord := make(map[int]int, len(node.hits))
+	for _, hit := range node.hits {
+		if word, ok := firstWord[hit.suggestion]; !ok || hit.word < word {
+			firstWord[hit.suggestion] = hit.word
+		}
+	}
+
+	matched := make([]int, 0, len(firstWord))
+	for i := range firstWord {
+		matched = append(matched, i)
+	}
+	sort.Slice(matched, func(a, b int) bool {
+		i, j := matched[a], matched[b]
+		if (firstWord[i] == 0) != (firstWord[j] == 0) {
+			return firstWord[i] == 0
+		}
+		if suggestions[i].Type != suggestions[j].Type {
+			return searchSuggestTypeOrder[suggestions[i].Type] < searchSuggestTypeOrder[suggestions[j].Type]
+		}
+		if len(suggestions[i].Text) != len(suggestions[j].Text) {
+			return len(suggestions[i].Text) < len(suggestions[j].Text)
+		}
+		return suggestions[i].Text < suggestions[j].Text
+	})
+
+	if len(matched) > limit {
+		matched = matched[:limit]
+	}
+	for _, i := range matched {
+		response.Suggestions = append(response.Suggestions, suggestions[i])
+	}
+	return response, nil
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared/useragent"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	securityDigestInterval = 7 * 24 * time.Hour
+	securityDigestBatch    = 100
+
+	// Events listed in one digest; the rest are only counted
+	securityDigestMaxEvents = 50
+)
+
+type SecurityDigestEmail struct {
+	Email string
+	Data  SecurityDigestEmailData
+}
+
+// shouldEmailSecurityEvent applies a security email mode to an event caused by a login
+func shouldEmailSecurityEvent(mode string, newDevice bool) bool {
+	switch mode {
+	case model.SecurityEmailNever:
+		return false
+	case model.SecurityEmailNewDevice:
+		return newDevice
+	default:
+		return true
+	}
+}
+
+// shouldEmailLogin decides the login email. The first login from a device is always
+// reported so it can be trusted or signed out; trusted devices are never reported.
+func shouldEmailLogin(mode string, newDevice, trustedDevice bool) bool {
+	if newDevice {
+		return true
+	}
+	if trustedDevice {
+		return false
+	}
+	return shouldEmailSecurityEvent(mode, false)
+}
+
+// isNewDevice reports whether the user hasn't logged in from deviceID before. Logins
+// without a device ID can't be recognised and count as new.
+func (svc *AuthService) isNewDevice(userID, deviceID string) bool {
+	if deviceID == "" {
+		return true
+	}
+	_, err := svc.userRepo.GetTrustedDevice(userID, deviceID)
+	return err != nil
+}
+
+// isTrustedDevice reports whether the user marked deviceID as trusted
+func (svc *AuthService) isTrustedDevice(userID, deviceID string) bool {
+	if deviceID == "" {
+		return false
+	}
+	device, err := svc.userRepo.GetTrustedDevice(userID, deviceID)
+	return err == nil && device.IsTrusted
+}
+
+// deviceActionToken signs a device link for a login email, or returns "" if signing
+// fails so the email still goes out without it
+func (svc *AuthService) deviceActionToken(userID, action, deviceID string) string {
+	token, err := svc.jwtSvc.GenerateDeviceActionToken(userID, action, deviceID, deviceActionLinkTTL)
+	if err != nil {
+		log.WithError(err).Errorf("Failed to sign %s device link", action)
+		return ""
+	}
+	return token
+}
+
+// deviceLabel describes a device in security emails, like "Chrome 120 on Windows 10 (desktop)"
+func deviceLabel(device useragent.Info) string {
+	switch device.DeviceType {
+	case useragent.TypeMobile, useragent.TypeTablet, useragent.TypeDesktop:
+		return device.String() + " (" + device.DeviceType + ")"
+	}
+	return device.String()
+}
+
+func (svc *AuthService) startSecurityDigestJob() {
+	ticker := time.NewTicker(time.Hour)
+	for range ticker.C {
+		svc.sendDueSecurityDigests()
+	}
+}
+
+// sendDueSecurityDigests queues the weekly digest of every user whose last one is a week
+// old. Weeks without security events are skipped but still count as sent.
+func (svc *AuthService) sendDueSecurityDigests() {
+	now := time.Now()
+	dueBefore := now.Add(-securityDigestInterval)
+
+	users, err := svc.userRepo.GetUsersDueSecurityDigest(dueBefore, securityDigestBatch)
+	if err != nil {
+		log.WithError(err).Error("Failed to load users due a security digest")
+		return
+	}
+
+	for _, user := range users {
+		from := dueBefore
+		if user.LastSecurityDigestAt != nil {
+			from = *user.LastSecurityDigestAt
+		}
+
+		logs, total, err := svc.userRepo.GetUserAuditLogsBetween(user.ID, from, now, securityDigestMaxEvents)
+		if err != nil {
+			log.WithError(err).Errorf("Failed to load security events for user %s", user.ID)
+			continue
+		}
+
+		var messages []*model.OutboxMessage
+		if total > 0 {
+			messages = append(messages, newOutboxMessage(OutboxTopicSecurityDigestEmail, SecurityDigestEmail{
+				Email: user.Email,
+				Data:  buildSecurityDigest(&user, logs, int(total), from, now),
+			}))
+		}
+
+		claimed, err := svc.userRepo.ClaimSecurityDigest(user.ID, dueBefore, now, messages...)
+		if err != nil {
+			log.WithError(err).Errorf("Failed to queue security digest for user %s", user.ID)
+			continue
+		}
+		if claimed && len(messages) > 0 {
+			go svc.outboxSvc.Relay(messages...)
+		}
+	}
+}
+
+func buildSecurityDigest(user *model.User, logs []model.AuthAuditLog, total int, from, to time.Time) SecurityDigestEmailData {
+	events := make([]SecurityDigestEvent, len(logs))
+	for i, entry := range logs {
+		event := describeAuditAction(entry.Action)
+		if !entry.Success {
+			event += " (failed)"
+		}
+		events[i] = SecurityDigestEvent{
+			Time:    entry.Timestamp.Local().Format("2006-01-02 15:04"),
+			Event:   event,
+			IP:      entry.IP,
+			Details: entry.Details,
+		}
+	}
+
+	return SecurityDigestEmailData{
+		Username:    user.Username,
+		PeriodStart: from.Local().Format("2006-01-02"),
+		PeriodEnd:   to.Local().Format("2006-01-02"),
+		Events:      events,
+		MoreEvents:  total - len(events),
+	}
+}
+
+// describeAuditAction turns an audit action like "password_reset" into "Password reset"
+func describeAuditAction(action string) string {
+	text := strings.ReplaceAll(action, "_", " ")
+	if text == "" {
+		return text
+	}
+	return strings.ToUpper(text[:1]) + text[1:]
+}
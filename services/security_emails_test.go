@@ -0,0 +1,27 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/lac-hong-legacy/ven_api/model"
+)
+
+func TestShouldEmailLogin(t *testing.T) {
+	cases := []struct {
+		mode                     string
+		newDevice, trustedDevice bool
+		want                     bool
+	}{
+		{model.SecurityEmailNever, true, false, true},
+		{model.SecurityEmailNewDevice, true, false, true},
+		{model.SecurityEmailAlways, false, false, true},
+		{model.SecurityEmailAlways, false, true, false},
+		{model.SecurityEmailNewDevice, false, false, false},
+		{model.SecurityEmailNever, false, false, false},
+	}
+	for _, c := range cases {
+		if got := shouldEmailLogin(c.mode, c.newDevice, c.trustedDevice); got != c.want {
+			t.Errorf("shouldEmailLogin(%q, %v, %v) = %v, want %v", c.mode, c.newDevice, c.trustedDevice, got, c.want)
+		}
+	}
+}
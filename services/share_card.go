@@ -0,0 +1,287 @@
+package services
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/lac-hong-legacy/ven_api/model"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Open Graph image size, which every share target crops well
+	shareCardWidth  = 1200
+	shareCardHeight = 630
+
+	// Bump when the layout changes so cached cards are rendered again
+	shareCardVersion = "v1"
+
+	shareCardURLTTL      = 24 * time.Hour
+	shareArtFetchTimeout = 5 * time.Second
+	shareArtMaxBytes     = 5 << 20
+)
+
+// Background gradient of each share type, top to bottom
+var shareCardColors = map[string][2]color.RGBA{
+	"achievement":      {{0xb8, 0x86, 0x0b, 0xff}, {0x5c, 0x3d, 0x00, 0xff}},
+	"character_unlock": {{0xb7, 0x1c, 0x1c, 0xff}, {0x4a, 0x08, 0x08, 0xff}},
+	"level_up":         {{0x15, 0x65, 0xc0, 0xff}, {0x0a, 0x23, 0x4f, 0xff}},
+}
+
+var shareCardDefaultColors = [2]color.RGBA{{0x2e, 0x7d, 0x32, 0xff}, {0x0f, 0x33, 0x12, 0xff}}
+
+// shareCard is what a share image shows. Art fields are image paths as stored on the
+// spirit and character, relative to SHARE_CARD_ASSET_BASE_URL unless absolute.
+type shareCard struct {
+	Type         string
+	Username     string
+	Level        int
+	SpiritArt    string
+	CharacterArt string
+}
+
+// objectName derives the cache key from everything drawn on the card, so a card is
+// rendered once and a changed username or level gets a new one
+func (card shareCard) objectName() string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		shareCardVersion, card.Type, card.Username, fmt.Sprint(card.Level), card.SpiritArt, card.CharacterArt,
+	}, "\x00")))
+	return fmt.Sprintf("%s/%s.png", mediaObjectDir("share_card"), hex.EncodeToString(sum[:]))
+}
+
+// shareCardURL returns a presigned URL of the card, rendering and storing it first if no
+// cached copy exists
+func (svc *UserService) shareCardURL(card shareCard) (string, error) {
+	bucket := svc.minioSvc.BucketFor("share_card")
+	objectName := card.objectName()
+
+	if _, err := svc.minioSvc.GetFileInfo(bucket, objectName); err != nil {
+		rendered, err := svc.renderShareCard(card)
+		if err != nil {
+			return "", err
+		}
+		if _, err := svc.minioSvc.UploadFile(bucket, objectName, bytes.NewReader(rendered), int64(len(rendered)), "image/png"); err != nil {
+			return "", fmt.Errorf("failed to store share card: %w", err)
+		}
+	}
+
+	return svc.minioSvc.GetFileURL(bucket, objectName, shareCardURLTTL)
+}
+
+// renderShareCard draws the username and level over the share type's background, with
+// the spirit on the left and the character on the right. Art that can't be loaded is
+// drawn as an empty frame.
+func (svc *UserService) renderShareCard(card shareCard) ([]byte, error) {
+	canvas := image.NewRGBA(image.Rect(0, 0, shareCardWidth, shareCardHeight))
+
+	colors, ok := shareCardColors[card.Type]
+	if !ok {
+		colors = shareCardDefaultColors
+	}
+	for y := 0; y < shareCardHeight; y++ {
+		line := blendShareColor(colors[0], colors[1], float64(y)/float64(shareCardHeight-1))
+		draw.Draw(canvas, image.Rect(0, y, shareCardWidth, y+1), image.NewUniform(line), image.Point{}, draw.Src)
+	}
+
+	white := color.RGBA{0xff, 0xff, 0xff, 0xff}
+	drawShareTextCentered(canvas, 50, 8, card.Username, white)
+	drawShareTextCentered(canvas, 140, 6, fmt.Sprintf("LEVEL %d", card.Level), white)
+
+	spiritBox := image.Rect(200, 230, 560, 590)
+	if card.CharacterArt == "" {
+		spiritBox = image.Rect(420, 230, 780, 590)
+	}
+	svc.drawShareArt(canvas, spiritBox, card.SpiritArt)
+	if card.CharacterArt != "" {
+		svc.drawShareArt(canvas, image.Rect(640, 230, 1000, 590), card.CharacterArt)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, canvas); err != nil {
+		return nil, fmt.Errorf("failed to encode share card: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (svc *UserService) drawShareArt(canvas *image.RGBA, box image.Rectangle, path string) {
+	frame := color.NRGBA{0xff, 0xff, 0xff, 0x30}
+	draw.Draw(canvas, box, image.NewUniform(frame), image.Point{}, draw.Over)
+
+	art, err := svc.loadShareArt(path)
+	if err != nil {
+		log.Printf("Failed to load share card art %s: %v", path, err)
+		return
+	}
+
+	// Fit inside the box keeping the aspect ratio, nearest neighbour is enough at this size
+	bounds := art.Bounds()
+	scale := math.Min(float64(box.Dx())/float64(bounds.Dx()), float64(box.Dy())/float64(bounds.Dy()))
+	width, height := int(float64(bounds.Dx())*scale), int(float64(bounds.Dy())*scale)
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			scaled.Set(x, y, art.At(bounds.Min.X+int(float64(x)/scale), bounds.Min.Y+int(float64(y)/scale)))
+		}
+	}
+
+	offset := box.Min.Add(image.Pt((box.Dx()-width)/2, (box.Dy()-height)/2))
+	draw.Draw(canvas, scaled.Bounds().Add(offset), scaled, image.Point{}, draw.Over)
+}
+
+func (svc *UserService) loadShareArt(path string) (image.Image, error) {
+	if path == "" {
+		return nil, fmt.Errorf("no art")
+	}
+
+	url := path
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		if svc.shareAssetBaseURL == "" {
+			return nil, fmt.Errorf("SHARE_CARD_ASSET_BASE_URL not set")
+		}
+		url = strings.TrimRight(svc.shareAssetBaseURL, "/") + "/" + strings.TrimLeft(path, "/")
+	}
+
+	client := &http.Client{Timeout: shareArtFetchTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	art, _, err := image.Decode(io.LimitReader(resp.Body, shareArtMaxBytes))
+	if err != nil {
+		return nil, err
+	}
+	if art.Bounds().Empty() {
+		return nil, fmt.Errorf("empty image")
+	}
+	return art, nil
+}
+
+// shareCardCharacter picks the character shown on a card: the shared one for character
+// unlocks, otherwise the user's latest unlock. It returns nil when there is none.
+func (svc *UserService) shareCardCharacter(userID, shareType, itemID string) *model.Character {
+	characterID := ""
+	if shareType == "character_unlock" {
+		if owned, err := svc.progressRepo.HasUserCharacter(userID, itemID); err == nil && owned {
+			characterID = itemID
+		}
+	}
+	if characterID == "" {
+		unlocked, err := svc.progressRepo.GetUserCharacters(userID)
+		if err != nil || len(unlocked) == 0 {
+			return nil
+		}
+		characterID = unlocked[len(unlocked)-1].CharacterID
+	}
+
+	character, err := svc.contentRepo.GetCharacter(characterID)
+	if err != nil {
+		return nil
+	}
+	return character
+}
+
+func blendShareColor(from, to color.RGBA, t float64) color.RGBA {
+	mix := func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*t)
+	}
+	return color.RGBA{mix(from.R, to.R), mix(from.G, to.G), mix(from.B, to.B), 0xff}
+}
+
+// ==================== SHARE CARD FONT ====================
+
+// 5x7 pixel glyphs, enough for usernames (letters, digits and underscores), levels and
+// the dates, scores and codes on certificates. Letters are drawn in capitals.
+var shareCardGlyphs = map[rune][7]string{
+	'A': {"01110", "10001", "10001", "11111", "10001", "10001", "10001"},
+	'B': {"11110", "10001", "10001", "11110", "10001", "10001", "11110"},
+	'C': {"01110", "10001", "10000", "10000", "10000", "10001", "01110"},
+	'D': {"11110", "10001", "10001", "10001", "10001", "10001", "11110"},
+	'E': {"11111", "10000", "10000", "11110", "10000", "10000", "11111"},
+	'F': {"11111", "10000", "10000", "11110", "10000", "10000", "10000"},
+	'G': {"01110", "10001", "10000", "10111", "10001", "10001", "01111"},
+	'H': {"10001", "10001", "10001", "11111", "10001", "10001", "10001"},
+	'I': {"01110", "00100", "00100", "00100", "00100", "00100", "01110"},
+	'J': {"00111", "00010", "00010", "00010", "00010", "10010", "01100"},
+	'K': {"10001", "10010", "10100", "11000", "10100", "10010", "10001"},
+	'L': {"10000", "10000", "10000", "10000", "10000", "10000", "11111"},
+	'M': {"10001", "11011", "10101", "10101", "10001", "10001", "10001"},
+	'N': {"10001", "10001", "11001", "10101", "10011", "10001", "10001"},
+	'O': {"01110", "10001", "10001", "10001", "10001", "10001", "01110"},
+	'P': {"11110", "10001", "10001", "11110", "10000", "10000", "10000"},
+	'Q': {"01110", "10001", "10001", "10001", "10101", "10010", "01101"},
+	'R': {"11110", "10001", "10001", "11110", "10100", "10010", "10001"},
+	'S': {"01111", "10000", "10000", "01110", "00001", "00001", "11110"},
+	'T': {"11111", "00100", "00100", "00100", "00100", "00100", "00100"},
+	'U': {"10001", "10001", "10001", "10001", "10001", "10001", "01110"},
+	'V': {"10001", "10001", "10001", "10001", "10001", "01010", "00100"},
+	'W': {"10001", "10001", "10001", "10101", "10101", "10101", "01010"},
+	'X': {"10001", "10001", "01010", "00100", "01010", "10001", "10001"},
+	'Y': {"10001", "10001", "01010", "00100", "00100", "00100", "00100"},
+	'Z': {"11111", "00001", "00010", "00100", "01000", "10000", "11111"},
+	'0': {"01110", "10001", "10011", "10101", "11001", "10001", "01110"},
+	'1': {"00100", "01100", "00100", "00100", "00100", "00100", "01110"},
+	'2': {"01110", "10001", "00001", "00010", "00100", "01000", "11111"},
+	'3': {"11111", "00010", "00100", "00010", "00001", "10001", "01110"},
+	'4': {"00010", "00110", "01010", "10010", "11111", "00010", "00010"},
+	'5': {"11111", "10000", "11110", "00001", "00001", "10001", "01110"},
+	'6': {"00110", "01000", "10000", "11110", "10001", "10001", "01110"},
+	'7': {"11111", "00001", "00010", "00100", "01000", "01000", "01000"},
+	'8': {"01110", "10001", "10001", "01110", "10001", "10001", "01110"},
+	'9': {"01110", "10001", "10001", "01111", "00001", "00010", "01100"},
+	'_': {"00000", "00000", "00000", "00000", "00000", "00000", "11111"},
+	'-': {"00000", "00000", "00000", "11111", "00000", "00000", "00000"},
+	'/': {"00000", "00001", "00010", "00100", "01000", "10000", "00000"},
+	'.': {"00000", "00000", "00000", "00000", "00000", "01100", "01100"},
+	':': {"00000", "01100", "01100", "00000", "01100", "01100", "00000"},
+	'%': {"11000", "11001", "00010", "00100", "01000", "10011", "00011"},
+	'?': {"01110", "10001", "00001", "00010", "00100", "00000", "00100"},
+}
+
+// drawShareTextCentered draws text horizontally centered with its top at y, each glyph
+// pixel scale pixels wide. Long text is drawn smaller to keep a margin on both sides.
+func drawShareTextCentered(canvas *image.RGBA, y, scale int, text string, c color.Color) {
+	runes := []rune(strings.ToUpper(text))
+	if len(runes) == 0 {
+		return
+	}
+	scale = max(1, min(scale, (canvas.Bounds().Dx()-80)/(len(runes)*6)))
+	width := len(runes)*6*scale - scale
+	x := (canvas.Bounds().Dx() - width) / 2
+
+	fill := image.NewUniform(c)
+	for _, r := range runes {
+		glyph, ok := shareCardGlyphs[r]
+		if !ok && !unicode.IsSpace(r) {
+			glyph = shareCardGlyphs['?']
+		}
+		for row, bits := range glyph {
+			for col, bit := range bits {
+				if bit != '1' {
+					continue
+				}
+				pixel := image.Rect(x+col*scale, y+row*scale, x+(col+1)*scale, y+(row+1)*scale)
+				draw.Draw(canvas, pixel, fill, image.Point{}, draw.Src)
+			}
+		}
+		x += 6 * scale
+	}
+}
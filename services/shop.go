@@ -0,0 +1,348 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cloakd/common/context"
+	serviceContext "github.com/cloakd/common/services"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/services/repositories"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ShopService pays out coins for playing and sells hearts, streak freezes and cosmetics
+// for them. Coins can't be bought; real-money purchases go through AddHearts.
+type ShopService struct {
+	serviceContext.DefaultService
+
+	sqlSvc      *PostgresService
+	userSvc     *UserService
+	eventBusSvc *EventBusService
+}
+
+const SHOP_SVC = "shop_svc"
+
+// A user can hold at most this many streak freezes
+const maxStreakFreezes = 5
+
+func (svc *ShopService) Id() string {
+	return SHOP_SVC
+}
+
+func (svc *ShopService) Configure(ctx *context.Context) error {
+	return svc.DefaultService.Configure(ctx)
+}
+
+func (svc *ShopService) Start() error {
+	deps := newDependencies(svc.Id(), svc.Service)
+	svc.sqlSvc = resolve[*PostgresService](deps, POSTGRES_SVC)
+	svc.userSvc = resolve[*UserService](deps, USER_SVC)
+	svc.eventBusSvc = resolve[*EventBusService](deps, EVENT_BUS_SVC)
+	if err := deps.err(); err != nil {
+		return err
+	}
+
+	svc.eventBusSvc.Subscribe(EventLessonCompleted, SHOP_SVC, func(event DomainEvent) {
+		e := event.(*LessonCompletedEvent)
+		if !e.FirstCompletion {
+			return
+		}
+		config, err := svc.userSvc.GetGameConfig()
+		if err != nil {
+			log.Printf("Failed to load game config for lesson coins: %v", err)
+			return
+		}
+		svc.creditCoins(e.UserID, model.CoinSourceLesson, e.LessonID, config.CoinsPerLesson)
+	})
+
+	return nil
+}
+
+// AwardAchievementCoins pays for one unlocked achievement tier, once per achievement and tier
+func (svc *ShopService) AwardAchievementCoins(userID, achievementID, tier string) {
+	config, err := svc.userSvc.GetGameConfig()
+	if err != nil {
+		log.Printf("Failed to load game config for achievement coins: %v", err)
+		return
+	}
+	svc.creditCoins(userID, model.CoinSourceAchievement, achievementID+":"+tier, config.CoinsPerAchievementTier)
+}
+
+func (svc *ShopService) creditCoins(userID, source, referenceID string, amount int) {
+	if amount <= 0 {
+		return
+	}
+	credited, err := svc.sqlSvc.shopRepo.CreditCoins(&model.CoinTransaction{
+		UserID:      userID,
+		Source:      source,
+		Amount:      amount,
+		ReferenceID: referenceID,
+	})
+	if err != nil {
+		log.Printf("Failed to credit %d coins to user %s for %s %s: %v", amount, userID, source, referenceID, err)
+		return
+	}
+	if credited {
+		log.Printf("User %s earned %d coins for %s %s", userID, amount, source, referenceID)
+	}
+}
+
+// ==================== SHOP METHODS ====================
+
+// GetShop lists the items on sale with the user's balance
+func (svc *ShopService) GetShop(userID string) (*dto.ShopResponse, error) {
+	items, err := svc.sqlSvc.shopRepo.GetShopItems(true)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get shop items")
+	}
+	wallet, err := svc.sqlSvc.shopRepo.GetWallet(userID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get wallet")
+	}
+	cosmetics, err := svc.sqlSvc.shopRepo.GetUserCosmetics(userID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get cosmetics")
+	}
+
+	owned := make(map[string]bool, len(cosmetics))
+	for _, cosmetic := range cosmetics {
+		owned[cosmetic.ItemID] = true
+	}
+
+	responses := make([]dto.ShopItemResponse, len(items))
+	for i, item := range items {
+		responses[i] = dto.ShopItemResponse{
+			ID:          item.ID,
+			Kind:        item.Kind,
+			Name:        item.Name,
+			Description: item.Description,
+			ImageURL:    item.ImageURL,
+			Price:       item.Price,
+			Quantity:    item.Quantity,
+			Owned:       owned[item.ID],
+		}
+	}
+
+	return &dto.ShopResponse{
+		Balance: wallet.Balance,
+		Items:   responses,
+	}, nil
+}
+
+// Purchase buys an item with coins. Retrying with the same idempotency key returns the
+// first purchase without paying again; using it for another item is a conflict.
+func (svc *ShopService) Purchase(userID string, req dto.ShopPurchaseRequest) (*dto.ShopPurchaseResponse, error) {
+	existing, err := svc.sqlSvc.shopRepo.GetShopPurchaseByKey(userID, req.IdempotencyKey)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, shared.NewInternalError(err, "Failed to check purchase")
+	}
+	if existing != nil {
+		return svc.purchaseResponse(existing, req.ItemID, false)
+	}
+
+	item, err := svc.sqlSvc.shopRepo.GetShopItem(req.ItemID)
+	if err != nil || !item.IsActive {
+		return nil, shared.NewNotFoundError(err, "Item not found")
+	}
+
+	quantity := max(item.Quantity, 1)
+	if item.Kind == model.ShopItemCosmetic {
+		quantity = 1
+	}
+
+	purchase, created, err := svc.sqlSvc.shopRepo.Purchase(&model.ShopPurchase{
+		UserID:         userID,
+		IdempotencyKey: req.IdempotencyKey,
+		ItemID:         item.ID,
+		Kind:           item.Kind,
+		Quantity:       quantity,
+		Price:          item.Price,
+	}, maxStreakFreezes)
+	switch {
+	case errors.Is(err, repositories.ErrInsufficientCoins):
+		return nil, shared.NewBadRequestError(err, "Not enough coins")
+	case errors.Is(err, repositories.ErrHeartsFull):
+		return nil, shared.NewBadRequestError(err, "Hearts are already full")
+	case errors.Is(err, repositories.ErrStreakFreezeLimit):
+		return nil, shared.NewBadRequestError(err, fmt.Sprintf("You can hold at most %d streak freezes", maxStreakFreezes))
+	case errors.Is(err, repositories.ErrCosmeticOwned):
+		return nil, shared.NewConflictError(err, "You already own this item")
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, shared.NewNotFoundError(err, "User progress not found")
+	case err != nil:
+		return nil, shared.NewInternalError(err, "Failed to complete purchase")
+	}
+
+	if created {
+		log.Printf("User %s bought %s (%d coins), balance %d", userID, item.ID, purchase.Price, purchase.BalanceAfter)
+		if purchase.Kind != model.ShopItemCosmetic {
+			svc.userSvc.publishProgressChanged(userID, "shop_"+purchase.Kind)
+		}
+	}
+	return svc.purchaseResponse(purchase, req.ItemID, created)
+}
+
+func (svc *ShopService) purchaseResponse(purchase *model.ShopPurchase, itemID string, created bool) (*dto.ShopPurchaseResponse, error) {
+	if purchase.ItemID != itemID {
+		return nil, shared.NewConflictError(nil, "Idempotency key was already used for another item")
+	}
+
+	wallet, err := svc.sqlSvc.shopRepo.GetWallet(purchase.UserID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get wallet")
+	}
+	progress, err := svc.sqlSvc.contentRepo.GetUserProgress(purchase.UserID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "User progress not found")
+	}
+
+	return &dto.ShopPurchaseResponse{
+		PurchaseID:    purchase.ID,
+		ItemID:        purchase.ItemID,
+		Kind:          purchase.Kind,
+		Quantity:      purchase.Quantity,
+		Price:         purchase.Price,
+		Balance:       wallet.Balance,
+		Created:       created,
+		Hearts:        progress.Hearts,
+		StreakFreezes: progress.StreakFreezes,
+		PurchasedAt:   purchase.CreatedAt,
+	}, nil
+}
+
+// ==================== WALLET METHODS ====================
+
+func (svc *ShopService) GetWallet(userID string) (*dto.WalletResponse, error) {
+	wallet, err := svc.sqlSvc.shopRepo.GetWallet(userID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get wallet")
+	}
+	return &dto.WalletResponse{
+		Balance:        wallet.Balance,
+		LifetimeEarned: wallet.LifetimeEarned,
+		UpdatedAt:      wallet.UpdatedAt,
+	}, nil
+}
+
+// GetCoinLedger returns the user's coin history, newest first
+func (svc *ShopService) GetCoinLedger(userID string, page, limit int) (*dto.CoinLedgerResponse, error) {
+	wallet, err := svc.sqlSvc.shopRepo.GetWallet(userID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get wallet")
+	}
+
+	txns, total, err := svc.sqlSvc.shopRepo.GetCoinTransactions(userID, page, limit)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get coin transactions")
+	}
+
+	responses := make([]dto.CoinTransactionResponse, len(txns))
+	for i, txn := range txns {
+		responses[i] = dto.CoinTransactionResponse{
+			ID:           txn.ID,
+			Source:       txn.Source,
+			Amount:       txn.Amount,
+			BalanceAfter: txn.BalanceAfter,
+			ReferenceID:  txn.ReferenceID,
+			Note:         txn.Note,
+			CreatedAt:    txn.CreatedAt,
+		}
+	}
+
+	return &dto.CoinLedgerResponse{
+		Balance:      wallet.Balance,
+		Transactions: responses,
+		Total:        int(total),
+		Page:         page,
+		Limit:        limit,
+	}, nil
+}
+
+func (svc *ShopService) GetCosmetics(userID string) ([]dto.UserCosmeticResponse, error) {
+	cosmetics, err := svc.sqlSvc.shopRepo.GetUserCosmetics(userID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get cosmetics")
+	}
+
+	responses := make([]dto.UserCosmeticResponse, len(cosmetics))
+	for i, cosmetic := range cosmetics {
+		responses[i] = dto.UserCosmeticResponse{
+			ItemID:      cosmetic.ItemID,
+			Name:        cosmetic.Item.Name,
+			ImageURL:    cosmetic.Item.ImageURL,
+			PurchasedAt: cosmetic.PurchasedAt,
+		}
+	}
+	return responses, nil
+}
+
+// ==================== ADMIN METHODS ====================
+
+// GetShopItems lists every item, including those taken off sale
+func (svc *ShopService) GetShopItems() ([]model.ShopItem, error) {
+	items, err := svc.sqlSvc.shopRepo.GetShopItems(false)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get shop items")
+	}
+	return items, nil
+}
+
+func (svc *ShopService) CreateShopItem(req dto.CreateShopItemRequest) (*model.ShopItem, error) {
+	item := &model.ShopItem{
+		Kind:        req.Kind,
+		Name:        req.Name,
+		Description: req.Description,
+		ImageURL:    req.ImageURL,
+		Price:       req.Price,
+		Quantity:    max(req.Quantity, 1),
+		IsActive:    req.IsActive,
+		SortOrder:   req.SortOrder,
+	}
+	if item.Kind == model.ShopItemCosmetic {
+		item.Quantity = 1
+	}
+
+	if err := svc.sqlSvc.shopRepo.CreateShopItem(item); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to create shop item")
+	}
+	return item, nil
+}
+
+// UpdateShopItem changes an item. Its kind is fixed; past purchases keep the price paid.
+func (svc *ShopService) UpdateShopItem(itemID string, req dto.UpdateShopItemRequest) (*model.ShopItem, error) {
+	item, err := svc.sqlSvc.shopRepo.GetShopItem(itemID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Item not found")
+	}
+
+	if req.Name != nil {
+		item.Name = *req.Name
+	}
+	if req.Description != nil {
+		item.Description = *req.Description
+	}
+	if req.ImageURL != nil {
+		item.ImageURL = *req.ImageURL
+	}
+	if req.Price != nil {
+		item.Price = *req.Price
+	}
+	if req.Quantity != nil && item.Kind != model.ShopItemCosmetic {
+		item.Quantity = *req.Quantity
+	}
+	if req.IsActive != nil {
+		item.IsActive = *req.IsActive
+	}
+	if req.SortOrder != nil {
+		item.SortOrder = *req.SortOrder
+	}
+
+	if err := svc.sqlSvc.shopRepo.UpdateShopItem(item); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to update shop item")
+	}
+	return item, nil
+}
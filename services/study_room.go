@@ -0,0 +1,639 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloakd/common/context"
+	serviceContext "github.com/cloakd/common/services"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// StudyRoomService runs co-op study rooms. Room state lives in the database; live
+// events go to the members connected to this instance.
+type StudyRoomService struct {
+	serviceContext.DefaultService
+
+	sqlSvc     *PostgresService
+	contentSvc *ContentService
+	userSvc    *UserService
+
+	// Live connections per room and the member each belongs to
+	mutex       sync.RWMutex
+	subscribers map[string]map[chan dto.StudyRoomEvent]string
+}
+
+const STUDY_ROOM_SVC = "study_room_svc"
+
+const (
+	studyRoomMaxMembers = 5
+	studyRoomMinMembers = 2
+	studyRoomBonusXP    = 25
+
+	studyRoomCodeLength   = 6
+	studyRoomCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+	// Rooms nobody joined, started or answered in for this long are closed, which also
+	// ends rooms whose members all disconnected for good
+	studyRoomIdleTimeout    = 30 * time.Minute
+	studyRoomExpiryInterval = time.Minute
+	studyRoomExpiryBatch    = 100
+
+	// How many events a slow connection may fall behind before further events are
+	// dropped for it; the room event sent on reconnect brings it back in sync
+	studyRoomSubscriberBuffer = 64
+)
+
+func (svc *StudyRoomService) Id() string {
+	return STUDY_ROOM_SVC
+}
+
+func (svc *StudyRoomService) Configure(ctx *context.Context) error {
+	svc.subscribers = make(map[string]map[chan dto.StudyRoomEvent]string)
+	return svc.DefaultService.Configure(ctx)
+}
+
+func (svc *StudyRoomService) Start() error {
+	deps := newDependencies(svc.Id(), svc.Service)
+	svc.sqlSvc = resolve[*PostgresService](deps, POSTGRES_SVC)
+	svc.contentSvc = resolve[*ContentService](deps, CONTENT_SVC)
+	svc.userSvc = resolve[*UserService](deps, USER_SVC)
+	if err := deps.err(); err != nil {
+		return err
+	}
+
+	go svc.startExpiryJob()
+
+	return nil
+}
+
+// ==================== ROOM LIFECYCLE ====================
+
+// CreateStudyRoom opens a room on a lesson with the user as host. Users can be in one
+// open room at a time.
+func (svc *StudyRoomService) CreateStudyRoom(userID string, req dto.CreateStudyRoomRequest) (*dto.StudyRoomResponse, error) {
+	if err := svc.ensureNotInRoom(userID); err != nil {
+		return nil, err
+	}
+
+	lesson, questions, err := svc.roomLesson(req.LessonID)
+	if err != nil {
+		return nil, err
+	}
+	if !lesson.IsActive || len(questions) == 0 {
+		return nil, shared.NewBadRequestError(nil, "This lesson has no questions to study together")
+	}
+	if !lesson.SuitableForAge(svc.contentSvc.viewerAgeLimit(userID)) {
+		return nil, shared.NewForbiddenError(nil, "This lesson is not available for your age")
+	}
+
+	code, err := svc.newRoomCode()
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to create study room")
+	}
+
+	room := &model.StudyRoom{
+		Code:     code,
+		HostID:   userID,
+		LessonID: lesson.ID,
+		Status:   model.StudyRoomStatusWaiting,
+	}
+	if err := svc.sqlSvc.studyRoomRepo.CreateStudyRoom(room); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to create study room")
+	}
+
+	return svc.GetStudyRoom(userID, room.ID)
+}
+
+// JoinStudyRoom adds the user to the waiting room with the given code
+func (svc *StudyRoomService) JoinStudyRoom(userID, code string) (*dto.StudyRoomResponse, error) {
+	room, err := svc.sqlSvc.studyRoomRepo.GetWaitingStudyRoomByCode(strings.ToUpper(code))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, shared.NewNotFoundError(err, "No study room is waiting with this code")
+	}
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get study room")
+	}
+
+	if current, err := svc.sqlSvc.studyRoomRepo.GetOpenStudyRoomForUser(userID); err == nil {
+		if current.ID == room.ID {
+			return svc.GetStudyRoom(userID, room.ID)
+		}
+		return nil, shared.NewConflictError(nil, "Leave your current study room first")
+	}
+
+	lesson, _, err := svc.roomLesson(room.LessonID)
+	if err != nil {
+		return nil, err
+	}
+	if !lesson.SuitableForAge(svc.contentSvc.viewerAgeLimit(userID)) {
+		return nil, shared.NewForbiddenError(nil, "This lesson is not available for your age")
+	}
+
+	joined, err := svc.sqlSvc.studyRoomRepo.JoinStudyRoom(room.ID, userID, studyRoomMaxMembers)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to join study room")
+	}
+	if !joined {
+		return nil, shared.NewConflictError(nil, "The study room is full or has already started")
+	}
+
+	svc.publishRoom(room.ID)
+	return svc.GetStudyRoom(userID, room.ID)
+}
+
+// GetStudyRoom returns a room the user is or was a member of, with the questions they
+// already answered
+func (svc *StudyRoomService) GetStudyRoom(userID, roomID string) (*dto.StudyRoomResponse, error) {
+	room, _, err := svc.memberRoom(userID, roomID)
+	if err != nil {
+		return nil, err
+	}
+	return svc.mapStudyRoom(room, userID)
+}
+
+// GetCurrentStudyRoom returns the open room the user is in, for clients reconnecting
+// without the room ID
+func (svc *StudyRoomService) GetCurrentStudyRoom(userID string) (*dto.StudyRoomResponse, error) {
+	room, err := svc.sqlSvc.studyRoomRepo.GetOpenStudyRoomForUser(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, shared.NewNotFoundError(err, "You are not in a study room")
+	}
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get study room")
+	}
+	return svc.mapStudyRoom(room, userID)
+}
+
+// StartStudyRoom lets the host start answering once at least two members are in
+func (svc *StudyRoomService) StartStudyRoom(userID, roomID string) (*dto.StudyRoomResponse, error) {
+	room, _, err := svc.memberRoom(userID, roomID)
+	if err != nil {
+		return nil, err
+	}
+	if room.HostID != userID {
+		return nil, shared.NewForbiddenError(nil, "Only the host can start the study room")
+	}
+	if room.Status != model.StudyRoomStatusWaiting {
+		return nil, shared.NewConflictError(nil, "The study room has already started")
+	}
+
+	started, err := svc.sqlSvc.studyRoomRepo.StartStudyRoom(roomID, userID, studyRoomMinMembers)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to start study room")
+	}
+	if !started {
+		return nil, shared.NewBadRequestError(nil, "Wait for at least one friend to join")
+	}
+
+	svc.publishRoom(roomID)
+	return svc.GetStudyRoom(userID, roomID)
+}
+
+// LeaveStudyRoom takes the user out of a room. Leaving a room that started gives up the
+// user's part in the group bonus; the others can still earn it without them.
+func (svc *StudyRoomService) LeaveStudyRoom(userID, roomID string) error {
+	if _, _, err := svc.memberRoom(userID, roomID); err != nil {
+		return err
+	}
+
+	left, err := svc.sqlSvc.studyRoomRepo.LeaveStudyRoom(roomID, userID)
+	if err != nil {
+		return shared.NewInternalError(err, "Failed to leave study room")
+	}
+	if !left {
+		return shared.NewConflictError(nil, "You are no longer in this study room")
+	}
+
+	svc.finishIfComplete(roomID)
+	svc.publishRoom(roomID)
+	return nil
+}
+
+// CloseStudyRoom lets the host end a room before it finishes, without a bonus
+func (svc *StudyRoomService) CloseStudyRoom(userID, roomID string) error {
+	room, _, err := svc.memberRoom(userID, roomID)
+	if err != nil {
+		return err
+	}
+	if room.HostID != userID {
+		return shared.NewForbiddenError(nil, "Only the host can close the study room")
+	}
+
+	closed, err := svc.sqlSvc.studyRoomRepo.CloseStudyRoom(roomID)
+	if err != nil {
+		return shared.NewInternalError(err, "Failed to close study room")
+	}
+	if !closed {
+		return shared.NewConflictError(nil, "The study room has already ended")
+	}
+
+	svc.publishRoom(roomID)
+	return nil
+}
+
+// ==================== ANSWERS ====================
+
+// SubmitStudyRoomAnswer answers one of the room's questions. Each member answers every
+// question once, in any order. Room answers count towards the group result only, not
+// the member's own lesson progress.
+func (svc *StudyRoomService) SubmitStudyRoomAnswer(userID, roomID, questionID string, answer interface{}) (*dto.StudyRoomAnswerResponse, error) {
+	room, member, err := svc.memberRoom(userID, roomID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case room.Status == model.StudyRoomStatusWaiting:
+		return nil, shared.NewBadRequestError(nil, "The study room has not started yet")
+	case !room.IsOpen():
+		return nil, shared.NewConflictError(nil, "The study room has already ended")
+	case member.LeftAt != nil:
+		return nil, shared.NewForbiddenError(nil, "You left this study room")
+	}
+
+	lesson, questions, err := svc.roomLesson(room.LessonID)
+	if err != nil {
+		return nil, err
+	}
+
+	var question *model.Question
+	for i := range questions {
+		if questions[i].ID == questionID {
+			question = &questions[i]
+		}
+	}
+	if question == nil {
+		return nil, shared.NewNotFoundError(nil, "Question not found in this lesson")
+	}
+
+	variants := svc.contentSvc.getTranslatedQuestions(lesson.ID)
+	correct := svc.contentSvc.isLocalizedAnswerCorrect(*question, variants[questionID], answer)
+
+	answerJSON, err := json.Marshal(answer)
+	if err != nil {
+		return nil, shared.NewBadRequestError(err, "Invalid answer")
+	}
+
+	record := &model.StudyRoomAnswer{
+		RoomID:     roomID,
+		UserID:     userID,
+		QuestionID: questionID,
+		Answer:     string(answerJSON),
+		IsCorrect:  correct,
+	}
+	if correct {
+		record.Points = question.Points
+	}
+
+	_, pointsToPass := lessonPoints(lesson, questions)
+	recorded, updated, err := svc.sqlSvc.studyRoomRepo.RecordStudyRoomAnswer(record, len(questions), pointsToPass)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to save answer")
+	}
+	if !recorded {
+		return nil, shared.NewConflictError(nil, "You already answered this question")
+	}
+
+	updated.User = member.User
+	progress := svc.mapMember(room, updated, svc.onlineMembers(roomID))
+	svc.publish(roomID, dto.StudyRoomEventProgress, progress)
+
+	if updated.FinishedAt != nil {
+		svc.finishIfComplete(roomID)
+	}
+
+	return &dto.StudyRoomAnswerResponse{
+		QuestionID: questionID,
+		Correct:    correct,
+		Points:     record.Points,
+		Member:     progress,
+	}, nil
+}
+
+// finishIfComplete ends an active room once every member still in it has answered
+// every question. If at least two of them are left and all passed, each gets the
+// group bonus.
+func (svc *StudyRoomService) finishIfComplete(roomID string) {
+	room, err := svc.sqlSvc.studyRoomRepo.GetStudyRoom(roomID)
+	if err != nil || room.Status != model.StudyRoomStatusActive {
+		return
+	}
+
+	var members []model.StudyRoomMember
+	allPassed := true
+	for _, member := range room.Members {
+		if member.LeftAt != nil {
+			continue
+		}
+		if member.FinishedAt == nil {
+			return
+		}
+		members = append(members, member)
+		allPassed = allPassed && member.Passed
+	}
+	if len(members) == 0 {
+		return
+	}
+
+	bonusXP := 0
+	if allPassed && len(members) >= studyRoomMinMembers {
+		bonusXP = studyRoomBonusXP
+	}
+
+	finished, err := svc.sqlSvc.studyRoomRepo.FinishStudyRoom(roomID, bonusXP)
+	if err != nil {
+		log.WithError(err).Errorf("Failed to finish study room %s", roomID)
+		return
+	}
+	if !finished {
+		return
+	}
+
+	if bonusXP > 0 {
+		for _, member := range members {
+			if err := svc.userSvc.awardXP(member.UserID, bonusXP, model.XPSourceStudyRoom, roomID); err != nil {
+				log.WithError(err).Errorf("Failed to award study room bonus to user %s", member.UserID)
+			}
+		}
+	}
+	log.Printf("Study room %s finished by %d member(s), bonus %d XP", roomID, len(members), bonusXP)
+
+	svc.publishRoom(roomID)
+}
+
+// ==================== LIVE EVENTS ====================
+
+// SubscribeStudyRoom registers a member's live connection to a room. The returned
+// function unsubscribes and closes the channel. Other members see the member come
+// online on their first connection and go offline when the last one closes.
+func (svc *StudyRoomService) SubscribeStudyRoom(roomID, userID string) (<-chan dto.StudyRoomEvent, func()) {
+	ch := make(chan dto.StudyRoomEvent, studyRoomSubscriberBuffer)
+
+	svc.mutex.Lock()
+	if svc.subscribers[roomID] == nil {
+		svc.subscribers[roomID] = make(map[chan dto.StudyRoomEvent]string)
+	}
+	firstConnection := !svc.connectedLocked(roomID, userID)
+	svc.subscribers[roomID][ch] = userID
+	svc.mutex.Unlock()
+
+	if firstConnection {
+		svc.publish(roomID, dto.StudyRoomEventPresence, dto.StudyRoomPresence{UserID: userID, Online: true})
+	}
+
+	return ch, func() {
+		svc.mutex.Lock()
+		if _, ok := svc.subscribers[roomID][ch]; !ok {
+			svc.mutex.Unlock()
+			return
+		}
+		delete(svc.subscribers[roomID], ch)
+		close(ch)
+		if len(svc.subscribers[roomID]) == 0 {
+			delete(svc.subscribers, roomID)
+		}
+		lastConnection := !svc.connectedLocked(roomID, userID)
+		svc.mutex.Unlock()
+
+		if lastConnection {
+			svc.publish(roomID, dto.StudyRoomEventPresence, dto.StudyRoomPresence{UserID: userID, Online: false})
+		}
+	}
+}
+
+func (svc *StudyRoomService) connectedLocked(roomID, userID string) bool {
+	for _, subscriber := range svc.subscribers[roomID] {
+		if subscriber == userID {
+			return true
+		}
+	}
+	return false
+}
+
+func (svc *StudyRoomService) onlineMembers(roomID string) map[string]bool {
+	svc.mutex.RLock()
+	defer svc.mutex.RUnlock()
+
+	online := make(map[string]bool)
+	for _, userID := range svc.subscribers[roomID] {
+		online[userID] = true
+	}
+	return online
+}
+
+// publish sends an event to every connection to the room. It never blocks; connections
+// that are not keeping up miss events.
+func (svc *StudyRoomService) publish(roomID, eventType string, data interface{}) {
+	event := dto.StudyRoomEvent{Type: eventType, Data: data, Timestamp: time.Now()}
+
+	svc.mutex.RLock()
+	defer svc.mutex.RUnlock()
+
+	for ch := range svc.subscribers[roomID] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// publishRoom sends the room's current state to its connections
+func (svc *StudyRoomService) publishRoom(roomID string) {
+	svc.mutex.RLock()
+	connected := len(svc.subscribers[roomID]) > 0
+	svc.mutex.RUnlock()
+	if !connected {
+		return
+	}
+
+	room, err := svc.sqlSvc.studyRoomRepo.GetStudyRoom(roomID)
+	if err != nil {
+		log.WithError(err).Warnf("Failed to load study room %s for its members", roomID)
+		return
+	}
+	response, err := svc.mapStudyRoom(room, "")
+	if err != nil {
+		log.WithError(err).Warnf("Failed to load study room %s for its members", roomID)
+		return
+	}
+	svc.publish(roomID, dto.StudyRoomEventRoom, response)
+}
+
+// ==================== EXPIRY ====================
+
+func (svc *StudyRoomService) startExpiryJob() {
+	ticker := time.NewTicker(studyRoomExpiryInterval)
+	for range ticker.C {
+		svc.closeIdleRooms()
+	}
+}
+
+func (svc *StudyRoomService) closeIdleRooms() {
+	rooms, err := svc.sqlSvc.studyRoomRepo.GetIdleStudyRooms(time.Now().Add(-studyRoomIdleTimeout), studyRoomExpiryBatch)
+	if err != nil {
+		log.WithError(err).Error("Failed to load idle study rooms")
+		return
+	}
+
+	for _, room := range rooms {
+		closed, err := svc.sqlSvc.studyRoomRepo.CloseStudyRoom(room.ID)
+		if err != nil {
+			log.WithError(err).Errorf("Failed to close idle study room %s", room.ID)
+			continue
+		}
+		if closed {
+			svc.publishRoom(room.ID)
+		}
+	}
+}
+
+// ==================== HELPERS ====================
+
+// memberRoom loads a room and the user's membership, failing with not found for rooms
+// the user never joined
+func (svc *StudyRoomService) memberRoom(userID, roomID string) (*model.StudyRoom, *model.StudyRoomMember, error) {
+	room, err := svc.sqlSvc.studyRoomRepo.GetStudyRoom(roomID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, shared.NewNotFoundError(err, "Study room not found")
+	}
+	if err != nil {
+		return nil, nil, shared.NewInternalError(err, "Failed to get study room")
+	}
+
+	for i := range room.Members {
+		if room.Members[i].UserID == userID {
+			return room, &room.Members[i], nil
+		}
+	}
+	return nil, nil, shared.NewNotFoundError(nil, "Study room not found")
+}
+
+func (svc *StudyRoomService) ensureNotInRoom(userID string) error {
+	_, err := svc.sqlSvc.studyRoomRepo.GetOpenStudyRoomForUser(userID)
+	if err == nil {
+		return shared.NewConflictError(nil, "Leave your current study room first")
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return shared.NewInternalError(err, "Failed to get study room")
+	}
+	return nil
+}
+
+func (svc *StudyRoomService) roomLesson(lessonID string) (*model.Lesson, []model.Question, error) {
+	lesson, err := svc.sqlSvc.contentRepo.GetLesson(lessonID)
+	if err != nil {
+		return nil, nil, shared.NewNotFoundError(err, "Lesson not found")
+	}
+
+	var questions []model.Question
+	if len(lesson.Questions) > 0 {
+		if err := json.Unmarshal(lesson.Questions, &questions); err != nil {
+			return nil, nil, shared.NewInternalError(err, "Failed to parse lesson questions")
+		}
+	}
+	return lesson, questions, nil
+}
+
+// newRoomCode picks a code no open room uses. Codes skip look-alike characters so they
+// are easy to read out to friends.
+func (svc *StudyRoomService) newRoomCode() (string, error) {
+	max := big.NewInt(int64(len(studyRoomCodeAlphabet)))
+	for {
+		var code strings.Builder
+		for i := 0; i < studyRoomCodeLength; i++ {
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return "", err
+			}
+			code.WriteByte(studyRoomCodeAlphabet[n.Int64()])
+		}
+
+		inUse, err := svc.sqlSvc.studyRoomRepo.OpenStudyRoomCodeInUse(code.String())
+		if err != nil {
+			return "", err
+		}
+		if !inUse {
+			return code.String(), nil
+		}
+	}
+}
+
+// lessonPoints returns the lesson's total points and the points needed to pass,
+// rounded up as for solo lessons
+func lessonPoints(lesson *model.Lesson, questions []model.Question) (int, int) {
+	total := 0
+	for _, question := range questions {
+		total += question.Points
+	}
+	return total, (lesson.MinScore*total + 99) / 100
+}
+
+// mapStudyRoom lists the members still in the room. viewerID adds the viewer's answered
+// questions; it is empty for events broadcast to the room.
+func (svc *StudyRoomService) mapStudyRoom(room *model.StudyRoom, viewerID string) (*dto.StudyRoomResponse, error) {
+	lesson, questions, err := svc.roomLesson(room.LessonID)
+	if err != nil {
+		return nil, err
+	}
+	totalPoints, pointsToPass := lessonPoints(lesson, questions)
+
+	response := &dto.StudyRoomResponse{
+		ID:             room.ID,
+		Code:           room.Code,
+		HostID:         room.HostID,
+		LessonID:       room.LessonID,
+		LessonTitle:    lesson.Title,
+		Status:         room.Status,
+		MaxMembers:     studyRoomMaxMembers,
+		QuestionsTotal: len(questions),
+		TotalPoints:    totalPoints,
+		PointsToPass:   pointsToPass,
+		GroupBonusXP:   studyRoomBonusXP,
+		BonusAwarded:   room.BonusXP > 0,
+		Members:        []dto.StudyRoomMemberResponse{},
+		StartedAt:      room.StartedAt,
+		EndedAt:        room.EndedAt,
+		CreatedAt:      room.CreatedAt,
+	}
+
+	online := svc.onlineMembers(room.ID)
+	for i := range room.Members {
+		if room.Members[i].LeftAt == nil {
+			response.Members = append(response.Members, svc.mapMember(room, &room.Members[i], online))
+		}
+	}
+
+	if viewerID != "" {
+		answered, err := svc.sqlSvc.studyRoomRepo.GetStudyRoomAnsweredQuestionIDs(room.ID, viewerID)
+		if err != nil {
+			return nil, shared.NewInternalError(err, "Failed to get study room answers")
+		}
+		response.AnsweredQuestionIDs = answered
+	}
+
+	return response, nil
+}
+
+func (svc *StudyRoomService) mapMember(room *model.StudyRoom, member *model.StudyRoomMember, online map[string]bool) dto.StudyRoomMemberResponse {
+	return dto.StudyRoomMemberResponse{
+		UserID:       member.UserID,
+		Username:     member.User.Username,
+		IsHost:       member.UserID == room.HostID,
+		Online:       online[member.UserID],
+		Answered:     member.Answered,
+		EarnedPoints: member.EarnedPoints,
+		Finished:     member.FinishedAt != nil,
+		Passed:       member.Passed,
+		FinishedAt:   member.FinishedAt,
+		JoinedAt:     member.JoinedAt,
+	}
+}
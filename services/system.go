@@ -0,0 +1,134 @@
+package services
+
+import (
+	stdContext "context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cloakd/common/context"
+	serviceContext "github.com/cloakd/common/services"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/shared"
+)
+
+type SystemService struct {
+	serviceContext.DefaultService
+
+	sqlSvc   *PostgresService
+	redisSvc *RedisService
+	minioSvc *MinIOService
+	authSvc  *AuthService
+
+	// Live ops console subscribers and their minimum severity
+	opsMutex          sync.RWMutex
+	opsSubscribers    map[chan dto.OpsEvent]string
+	lessonCompletions atomic.Int64
+}
+
+const SYSTEM_SVC = "system_svc"
+
+const healthCheckTimeout = 3 * time.Second
+
+func (svc *SystemService) Id() string {
+	return SYSTEM_SVC
+}
+
+func (svc *SystemService) Configure(ctx *context.Context) error {
+	svc.opsSubscribers = make(map[chan dto.OpsEvent]string)
+	return svc.DefaultService.Configure(ctx)
+}
+
+func (svc *SystemService) Start() error {
+	deps := newDependencies(svc.Id(), svc.Service)
+	svc.sqlSvc = resolve[*PostgresService](deps, POSTGRES_SVC)
+	svc.redisSvc = resolve[*RedisService](deps, REDIS_SVC)
+	svc.minioSvc = resolve[*MinIOService](deps, MINIO_SVC)
+	svc.authSvc = resolve[*AuthService](deps, AUTH_SVC)
+	if err := deps.err(); err != nil {
+		return err
+	}
+
+	go svc.startLessonThroughputReporter()
+
+	return nil
+}
+
+// GetSystemStatistics aggregates user, session, login and infrastructure health figures
+// for the ops dashboard.
+func (svc *SystemService) GetSystemStatistics() (*dto.SystemStatisticsResponse, error) {
+	users, err := svc.sqlSvc.userRepo.GetUserStatistics()
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get user statistics")
+	}
+
+	activeSessions, err := svc.sqlSvc.userRepo.CountActiveSessions()
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to count active sessions")
+	}
+
+	since := time.Now().Add(-24 * time.Hour)
+	recentLogins, err := svc.sqlSvc.userRepo.CountAuthEventsSince([]string{"login"}, since)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to count recent logins")
+	}
+
+	failedLogins, err := svc.sqlSvc.userRepo.CountAuthEventsSince([]string{"failed_login", "failed_login_locked", "failed_login_session_limit"}, since)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to count failed logins")
+	}
+
+	blocked, err := svc.sqlSvc.rateLimitRepo.CountBlocked()
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to count rate limit blocks")
+	}
+
+	return &dto.SystemStatisticsResponse{
+		Users:           *users,
+		ActiveSessions:  activeSessions,
+		RecentLogins24h: recentLogins,
+		FailedLogins24h: failedLogins,
+		RateLimitBlocks: blocked,
+		EmailQueueDepth: svc.authSvc.EmailQueueDepth(),
+		Health:          svc.checkHealth(),
+		GeneratedAt:     time.Now(),
+	}, nil
+}
+
+func (svc *SystemService) GetSlowQueries() *dto.SlowQueryResponse {
+	return svc.sqlSvc.SlowQueries()
+}
+
+func (svc *SystemService) checkHealth() map[string]dto.ComponentHealth {
+	checks := map[string]func(ctx stdContext.Context) error{
+		"database": func(ctx stdContext.Context) error {
+			sqlDB, err := svc.sqlSvc.Db().DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.PingContext(ctx)
+		},
+		"redis": svc.redisSvc.Ping,
+		"minio": svc.minioSvc.Ping,
+	}
+
+	health := make(map[string]dto.ComponentHealth, len(checks))
+	for name, check := range checks {
+		ctx, cancel := stdContext.WithTimeout(stdContext.Background(), healthCheckTimeout)
+		start := time.Now()
+		err := check(ctx)
+		cancel()
+
+		component := dto.ComponentHealth{
+			Status:    "healthy",
+			LatencyMs: time.Since(start).Milliseconds(),
+		}
+		if err != nil {
+			component.Status = "unhealthy"
+			component.Error = err.Error()
+		}
+		health[name] = component
+	}
+
+	return health
+}
@@ -0,0 +1,524 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cloakd/common/context"
+	serviceContext "github.com/cloakd/common/services"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	"gorm.io/gorm"
+)
+
+type TranslationService struct {
+	serviceContext.DefaultService
+
+	sqlSvc     *PostgresService
+	contentSvc *ContentService
+
+	// LibreTranslate-compatible endpoint used for machine drafts. Optional.
+	translateURL    string
+	translateAPIKey string
+	httpClient      *http.Client
+}
+
+const TRANSLATION_SVC = "translation_svc"
+
+func (svc TranslationService) Id() string {
+	return TRANSLATION_SVC
+}
+
+func (svc *TranslationService) Configure(ctx *context.Context) error {
+	svc.translateURL = strings.TrimRight(os.Getenv("TRANSLATION_API_URL"), "/")
+	svc.translateAPIKey = os.Getenv("TRANSLATION_API_KEY")
+	svc.httpClient = &http.Client{
+		Timeout: 30 * time.Second,
+	}
+
+	return svc.DefaultService.Configure(ctx)
+}
+
+func (svc *TranslationService) Start() error {
+	deps := newDependencies(svc.Id(), svc.Service)
+	svc.sqlSvc = resolve[*PostgresService](deps, POSTGRES_SVC)
+	svc.contentSvc = resolve[*ContentService](deps, CONTENT_SVC)
+	return deps.err()
+}
+
+// MachineTranslate creates or replaces a lesson's draft translation using the machine
+// translation provider. Translations under review or already approved are left alone.
+func (svc *TranslationService) MachineTranslate(adminID, lessonID, locale string) (*dto.LessonTranslationResponse, error) {
+	if err := svc.validateLocale(locale); err != nil {
+		return nil, err
+	}
+	if svc.translateURL == "" {
+		return nil, shared.NewBadRequestError(nil, "Machine translation is not configured")
+	}
+
+	lesson, questions, err := svc.getLessonWithQuestions(lessonID)
+	if err != nil {
+		return nil, err
+	}
+
+	translation, err := svc.getOrNewTranslation(lessonID, locale)
+	if err != nil {
+		return nil, err
+	}
+	if translation.Status == model.TranslationStatusInReview || translation.Status == model.TranslationStatusApproved {
+		return nil, shared.NewConflictError(nil, fmt.Sprintf("Translation is %s and can't be overwritten by a machine draft", translation.Status))
+	}
+
+	// Translate everything in one request: title, story, script, then each question
+	// followed by its options
+	texts := []string{lesson.Title, lesson.Story, lesson.Script}
+	for _, q := range questions {
+		texts = append(texts, q.Question)
+		texts = append(texts, q.Options...)
+	}
+
+	translated, err := svc.machineTranslate(texts, locale)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Machine translation failed")
+	}
+
+	translatedQuestions := make([]model.TranslatedQuestion, len(questions))
+	next := 3
+	for i, q := range questions {
+		tq := model.TranslatedQuestion{ID: q.ID, Question: translated[next]}
+		next++
+		if len(q.Options) > 0 {
+			tq.Options = translated[next : next+len(q.Options)]
+			next += len(q.Options)
+		}
+		translatedQuestions[i] = tq
+	}
+
+	before := *translation
+	translation.Title = translated[0]
+	translation.Story = translated[1]
+	translation.Script = translated[2]
+	translation.Status = model.TranslationStatusMachineDraft
+	translation.Source = model.TranslationSourceMachine
+	translation.TranslatedBy = adminID
+	translation.SourceUpdatedAt = lesson.UpdatedAt
+	translation.ReviewerID = ""
+	translation.ReviewNote = ""
+	translation.ReviewedAt = nil
+	if translation.Questions, err = json.Marshal(translatedQuestions); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to encode translated questions")
+	}
+
+	return svc.saveTranslation(adminID, &before, translation, lesson)
+}
+
+// SaveTranslation stores a human edit of a translation as a draft
+func (svc *TranslationService) SaveTranslation(adminID, lessonID, locale string, req dto.UpdateLessonTranslationRequest) (*dto.LessonTranslationResponse, error) {
+	if err := svc.validateLocale(locale); err != nil {
+		return nil, err
+	}
+
+	lesson, questions, err := svc.getLessonWithQuestions(lessonID)
+	if err != nil {
+		return nil, err
+	}
+
+	sourceQuestions := make(map[string]model.Question, len(questions))
+	for _, q := range questions {
+		sourceQuestions[q.ID] = q
+	}
+
+	translatedQuestions := make([]model.TranslatedQuestion, 0, len(req.Questions))
+	for _, q := range req.Questions {
+		source, exists := sourceQuestions[q.ID]
+		if !exists {
+			return nil, shared.NewBadRequestError(nil, fmt.Sprintf("Question %s does not exist in this lesson", q.ID))
+		}
+		if len(q.Options) > 0 && len(q.Options) != len(source.Options) {
+			return nil, shared.NewBadRequestError(nil, fmt.Sprintf("Question %s must have %d options in the same order as the source", q.ID, len(source.Options)))
+		}
+		translatedQuestions = append(translatedQuestions, model.TranslatedQuestion{
+			ID:       q.ID,
+			Question: q.Question,
+			Options:  q.Options,
+			Answer:   q.Answer,
+		})
+	}
+
+	translation, err := svc.getOrNewTranslation(lessonID, locale)
+	if err != nil {
+		return nil, err
+	}
+	if translation.Status == model.TranslationStatusInReview {
+		return nil, shared.NewConflictError(nil, "Translation is in review and can't be edited")
+	}
+
+	before := *translation
+	translation.Title = req.Title
+	translation.Story = req.Story
+	translation.Script = req.Script
+	translation.Status = model.TranslationStatusDraft
+	translation.Source = model.TranslationSourceHuman
+	translation.TranslatedBy = adminID
+	translation.SourceUpdatedAt = lesson.UpdatedAt
+	if translation.Questions, err = json.Marshal(translatedQuestions); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to encode translated questions")
+	}
+
+	return svc.saveTranslation(adminID, &before, translation, lesson)
+}
+
+// SubmitForReview moves a draft translation into the review queue
+func (svc *TranslationService) SubmitForReview(adminID, lessonID, locale string) (*dto.LessonTranslationResponse, error) {
+	translation, lesson, err := svc.getExistingTranslation(lessonID, locale)
+	if err != nil {
+		return nil, err
+	}
+
+	switch translation.Status {
+	case model.TranslationStatusMachineDraft, model.TranslationStatusDraft, model.TranslationStatusRejected:
+	default:
+		return nil, shared.NewConflictError(nil, fmt.Sprintf("Translation is %s and can't be submitted for review", translation.Status))
+	}
+
+	before := *translation
+	translation.Status = model.TranslationStatusInReview
+	return svc.saveTranslation(adminID, &before, translation, lesson)
+}
+
+// ReviewTranslation approves or rejects a translation in review. Reviewers can't approve
+// their own translations.
+func (svc *TranslationService) ReviewTranslation(reviewerID, lessonID, locale string, req dto.ReviewTranslationRequest) (*dto.LessonTranslationResponse, error) {
+	translation, lesson, err := svc.getExistingTranslation(lessonID, locale)
+	if err != nil {
+		return nil, err
+	}
+
+	if translation.Status != model.TranslationStatusInReview {
+		return nil, shared.NewConflictError(nil, "Translation is not in review")
+	}
+	if *req.Approve && translation.Source == model.TranslationSourceHuman && translation.TranslatedBy == reviewerID {
+		return nil, shared.NewForbiddenError(nil, "Translations must be approved by a different reviewer")
+	}
+
+	now := time.Now()
+	before := *translation
+	translation.Status = model.TranslationStatusRejected
+	if *req.Approve {
+		translation.Status = model.TranslationStatusApproved
+	}
+	translation.ReviewerID = reviewerID
+	translation.ReviewNote = req.Note
+	translation.ReviewedAt = &now
+
+	return svc.saveTranslation(reviewerID, &before, translation, lesson)
+}
+
+func (svc *TranslationService) GetTranslation(lessonID, locale string) (*dto.LessonTranslationResponse, error) {
+	translation, lesson, err := svc.getExistingTranslation(lessonID, locale)
+	if err != nil {
+		return nil, err
+	}
+	return svc.mapTranslationToResponse(translation, lesson), nil
+}
+
+// GetTranslationReport reports how complete each supported locale's translation of a lesson is
+func (svc *TranslationService) GetTranslationReport(lessonID string) (*dto.LessonTranslationReportResponse, error) {
+	lesson, questions, err := svc.getLessonWithQuestions(lessonID)
+	if err != nil {
+		return nil, err
+	}
+
+	translations, err := svc.sqlSvc.contentRepo.GetLessonTranslations(lessonID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to load translations")
+	}
+
+	byLocale := make(map[string]*model.LessonTranslation, len(translations))
+	for i := range translations {
+		byLocale[translations[i].Locale] = &translations[i]
+	}
+
+	report := &dto.LessonTranslationReportResponse{
+		LessonID:     lessonID,
+		SourceLocale: model.SourceLocale,
+		Locales:      make([]dto.TranslationCompleteness, 0, len(model.SupportedLocales)),
+	}
+
+	for _, locale := range model.SupportedLocales {
+		report.Locales = append(report.Locales, svc.measureCompleteness(locale, lesson, questions, byLocale[locale]))
+	}
+
+	return report, nil
+}
+
+func (svc *TranslationService) measureCompleteness(locale string, lesson *model.Lesson, questions []model.Question, translation *model.LessonTranslation) dto.TranslationCompleteness {
+	result := dto.TranslationCompleteness{
+		Locale:             locale,
+		Status:             "missing",
+		MissingFields:      []string{},
+		MissingQuestionIDs: []string{},
+	}
+
+	translated := map[string]model.TranslatedQuestion{}
+	fields := map[string]string{}
+	if translation != nil {
+		result.Status = translation.Status
+		result.IsStale = lesson.UpdatedAt.After(translation.SourceUpdatedAt)
+		fields["title"] = translation.Title
+		fields["story"] = translation.Story
+		fields["script"] = translation.Script
+
+		var tqs []model.TranslatedQuestion
+		_ = json.Unmarshal(translation.Questions, &tqs)
+		for _, tq := range tqs {
+			translated[tq.ID] = tq
+		}
+	}
+
+	// Only fields with source text count towards completeness
+	total, done := 0, 0
+	for name, source := range map[string]string{"title": lesson.Title, "story": lesson.Story, "script": lesson.Script} {
+		if source == "" {
+			continue
+		}
+		total++
+		if fields[name] != "" {
+			done++
+		} else {
+			result.MissingFields = append(result.MissingFields, name)
+		}
+	}
+	slices.Sort(result.MissingFields)
+
+	for _, q := range questions {
+		total++
+		tq, exists := translated[q.ID]
+		if exists && tq.Question != "" && len(tq.Options) == len(q.Options) {
+			done++
+		} else {
+			result.MissingQuestionIDs = append(result.MissingQuestionIDs, q.ID)
+		}
+	}
+
+	if total > 0 {
+		result.Completeness = float64(done) / float64(total) * 100
+	}
+	return result
+}
+
+// GetLessonsMissingTranslations lists lessons learners can't yet see in the locale
+func (svc *TranslationService) GetLessonsMissingTranslations(locale string, page, limit int) (*dto.MissingTranslationListResponse, error) {
+	if err := svc.validateLocale(locale); err != nil {
+		return nil, err
+	}
+
+	lessons, statuses, total, err := svc.sqlSvc.contentRepo.GetLessonsMissingApprovedTranslation(locale, page, limit)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to load lessons missing translations")
+	}
+
+	items := make([]dto.MissingTranslationItem, len(lessons))
+	for i, lesson := range lessons {
+		status, exists := statuses[lesson.ID]
+		if !exists {
+			status = "missing"
+		}
+		items[i] = dto.MissingTranslationItem{
+			LessonID:    lesson.ID,
+			CharacterID: lesson.CharacterID,
+			Title:       lesson.Title,
+			Status:      status,
+		}
+	}
+
+	return &dto.MissingTranslationListResponse{
+		Locale:  locale,
+		Lessons: items,
+		Total:   int(total),
+		Page:    page,
+		Limit:   limit,
+	}, nil
+}
+
+func (svc *TranslationService) validateLocale(locale string) error {
+	if !slices.Contains(model.SupportedLocales, locale) {
+		return shared.NewBadRequestError(nil, fmt.Sprintf("Unsupported locale %q. Supported: %s", locale, strings.Join(model.SupportedLocales, ", ")))
+	}
+	return nil
+}
+
+func (svc *TranslationService) getLessonWithQuestions(lessonID string) (*model.Lesson, []model.Question, error) {
+	lesson, err := svc.sqlSvc.contentRepo.GetLesson(lessonID)
+	if err != nil {
+		return nil, nil, shared.NewNotFoundError(err, "Lesson not found")
+	}
+
+	var questions []model.Question
+	if len(lesson.Questions) > 0 {
+		if err := json.Unmarshal(lesson.Questions, &questions); err != nil {
+			return nil, nil, shared.NewInternalError(err, "Failed to parse lesson questions")
+		}
+	}
+
+	return lesson, questions, nil
+}
+
+func (svc *TranslationService) getOrNewTranslation(lessonID, locale string) (*model.LessonTranslation, error) {
+	translation, err := svc.sqlSvc.contentRepo.GetLessonTranslation(lessonID, locale)
+	if err == nil {
+		return translation, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, shared.NewInternalError(err, "Failed to load translation")
+	}
+
+	return &model.LessonTranslation{
+		LessonID:  lessonID,
+		Locale:    locale,
+		Questions: json.RawMessage("[]"),
+	}, nil
+}
+
+func (svc *TranslationService) getExistingTranslation(lessonID, locale string) (*model.LessonTranslation, *model.Lesson, error) {
+	if err := svc.validateLocale(locale); err != nil {
+		return nil, nil, err
+	}
+
+	lesson, err := svc.sqlSvc.contentRepo.GetLesson(lessonID)
+	if err != nil {
+		return nil, nil, shared.NewNotFoundError(err, "Lesson not found")
+	}
+
+	translation, err := svc.sqlSvc.contentRepo.GetLessonTranslation(lessonID, locale)
+	if err != nil {
+		return nil, nil, shared.NewNotFoundError(err, "Translation not found")
+	}
+
+	return translation, lesson, nil
+}
+
+func (svc *TranslationService) saveTranslation(adminID string, before, translation *model.LessonTranslation, lesson *model.Lesson) (*dto.LessonTranslationResponse, error) {
+	action := model.ContentActionUpdate
+	if translation.ID == "" {
+		action = model.ContentActionCreate
+	}
+
+	if err := svc.sqlSvc.contentRepo.SaveLessonTranslation(translation); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to save translation")
+	}
+
+	if action == model.ContentActionCreate {
+		before = nil
+	} else if translation.Status == model.TranslationStatusApproved {
+		action = model.ContentActionPublish
+	}
+	svc.contentSvc.RecordContentAudit(adminID, model.ContentEntityTranslation, translation.ID, action, before, translation)
+
+	return svc.mapTranslationToResponse(translation, lesson), nil
+}
+
+func (svc *TranslationService) mapTranslationToResponse(translation *model.LessonTranslation, lesson *model.Lesson) *dto.LessonTranslationResponse {
+	var tqs []model.TranslatedQuestion
+	_ = json.Unmarshal(translation.Questions, &tqs)
+
+	questions := make([]dto.TranslatedQuestion, len(tqs))
+	for i, tq := range tqs {
+		questions[i] = dto.TranslatedQuestion{
+			ID:       tq.ID,
+			Question: tq.Question,
+			Options:  tq.Options,
+			Answer:   tq.Answer,
+		}
+	}
+
+	return &dto.LessonTranslationResponse{
+		ID:           translation.ID,
+		LessonID:     translation.LessonID,
+		Locale:       translation.Locale,
+		Title:        translation.Title,
+		Story:        translation.Story,
+		Script:       translation.Script,
+		Questions:    questions,
+		Status:       translation.Status,
+		Source:       translation.Source,
+		TranslatedBy: translation.TranslatedBy,
+		ReviewerID:   translation.ReviewerID,
+		ReviewNote:   translation.ReviewNote,
+		ReviewedAt:   translation.ReviewedAt,
+		IsStale:      lesson.UpdatedAt.After(translation.SourceUpdatedAt),
+		UpdatedAt:    translation.UpdatedAt,
+	}
+}
+
+type machineTranslateRequest struct {
+	Q      []string `json:"q"`
+	Source string   `json:"source"`
+	Target string   `json:"target"`
+	Format string   `json:"format"`
+	APIKey string   `json:"api_key,omitempty"`
+}
+
+type machineTranslateResponse struct {
+	TranslatedText []string `json:"translatedText"`
+	Error          string   `json:"error,omitempty"`
+}
+
+// machineTranslate sends a batch of texts to the LibreTranslate-compatible provider.
+// Empty strings are passed through untouched.
+func (svc *TranslationService) machineTranslate(texts []string, locale string) ([]string, error) {
+	result := make([]string, len(texts))
+	batch := make([]string, 0, len(texts))
+	positions := make([]int, 0, len(texts))
+	for i, text := range texts {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		batch = append(batch, text)
+		positions = append(positions, i)
+	}
+	if len(batch) == 0 {
+		return result, nil
+	}
+
+	body, err := json.Marshal(machineTranslateRequest{
+		Q:      batch,
+		Source: model.SourceLocale,
+		Target: locale,
+		Format: "text",
+		APIKey: svc.translateAPIKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := svc.httpClient.Post(svc.translateURL+"/translate", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var payload machineTranslateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("failed to decode translation response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("translation provider returned %d: %s", resp.StatusCode, payload.Error)
+	}
+	if len(payload.TranslatedText) != len(batch) {
+		return nil, fmt.Errorf("translation provider returned %d texts, expected %d", len(payload.TranslatedText), len(batch))
+	}
+
+	for i, pos := range positions {
+		result[pos] = payload.TranslatedText[i]
+	}
+	return result, nil
+}
@@ -0,0 +1,333 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/rand"
+	"os"
+	"time"
+
+	"github.com/cloakd/common/context"
+	serviceContext "github.com/cloakd/common/services"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+type TriviaService struct {
+	serviceContext.DefaultService
+
+	sqlSvc     *PostgresService
+	contentSvc *ContentService
+	userSvc    *UserService
+
+	// The day rolls over at midnight in this location, the same for every user
+	location *time.Location
+}
+
+const TRIVIA_SVC = "trivia_svc"
+
+const (
+	triviaDateFormat  = "2006-01-02"
+	triviaXPReward    = 20
+	triviaRepeatDays  = 60 // a question is not reused within this many days
+	triviaLessonPicks = 10 // lessons sampled when picking the day's question
+)
+
+func (svc *TriviaService) Id() string {
+	return TRIVIA_SVC
+}
+
+func (svc *TriviaService) Configure(ctx *context.Context) error {
+	svc.location = triviaLocation(os.Getenv("TRIVIA_TIMEZONE"))
+	return svc.DefaultService.Configure(ctx)
+}
+
+func (svc *TriviaService) Start() error {
+	deps := newDependencies(svc.Id(), svc.Service)
+	svc.sqlSvc = resolve[*PostgresService](deps, POSTGRES_SVC)
+	svc.contentSvc = resolve[*ContentService](deps, CONTENT_SVC)
+	svc.userSvc = resolve[*UserService](deps, USER_SVC)
+	return deps.err()
+}
+
+// triviaLocation defaults to Vietnam time. The fixed offset covers hosts without tzdata;
+// Vietnam has no daylight saving.
+func triviaLocation(name string) *time.Location {
+	if name == "" {
+		name = "Asia/Ho_Chi_Minh"
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		log.WithError(err).Warnf("Unknown TRIVIA_TIMEZONE %q, using UTC+7", name)
+		return time.FixedZone("ICT", 7*60*60)
+	}
+	return location
+}
+
+func (svc *TriviaService) today() string {
+	return time.Now().In(svc.location).Format(triviaDateFormat)
+}
+
+// GetToday returns today's question. Once the user has answered it also carries their
+// result and the global stats, which are hidden before so they cannot sway the answer.
+func (svc *TriviaService) GetToday(userID string) (*dto.DailyTriviaResponse, error) {
+	trivia, question, err := svc.todayQuestion()
+	if err != nil {
+		return nil, err
+	}
+
+	streak, err := svc.sqlSvc.triviaRepo.GetUserTriviaStreak(userID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get trivia streak")
+	}
+
+	response := &dto.DailyTriviaResponse{
+		Date: trivia.Date,
+		Question: dto.QuestionResponse{
+			ID:       question.ID,
+			Type:     question.Type,
+			Question: question.Question,
+			Options:  question.Options,
+			Points:   question.Points,
+			Metadata: question.Metadata,
+		},
+		LessonID: trivia.LessonID,
+		XPReward: trivia.XPReward,
+		Streak:   svc.mapStreak(streak, trivia.Date),
+	}
+
+	answer, err := svc.sqlSvc.triviaRepo.GetDailyTriviaAnswer(userID, trivia.Date)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, shared.NewInternalError(err, "Failed to get trivia answer")
+	}
+	if answer != nil {
+		stats, err := svc.GetStats(trivia.Date)
+		if err != nil {
+			return nil, err
+		}
+		response.Answered = true
+		response.Correct = &answer.IsCorrect
+		response.XPAwarded = answer.XPAwarded
+		response.Stats = stats
+	}
+
+	return response, nil
+}
+
+// SubmitAnswer answers today's question. Each user gets one attempt per day; a correct
+// answer earns the day's bonus XP, and any answer keeps the trivia streak going.
+func (svc *TriviaService) SubmitAnswer(userID string, answer interface{}) (*dto.DailyTriviaAnswerResponse, error) {
+	trivia, question, err := svc.todayQuestion()
+	if err != nil {
+		return nil, err
+	}
+
+	variants := svc.contentSvc.getTranslatedQuestions(trivia.LessonID)
+	correct := svc.contentSvc.isLocalizedAnswerCorrect(*question, variants[question.ID], answer)
+
+	answerJSON, err := json.Marshal(answer)
+	if err != nil {
+		return nil, shared.NewBadRequestError(err, "Invalid answer")
+	}
+
+	xp := 0
+	if correct {
+		xp = trivia.XPReward
+	}
+
+	record := &model.DailyTriviaAnswer{
+		UserID:    userID,
+		Date:      trivia.Date,
+		Answer:    string(answerJSON),
+		IsCorrect: correct,
+		XPAwarded: xp,
+	}
+	previousDate := svc.previousDate(trivia.Date)
+	recorded, streak, err := svc.sqlSvc.triviaRepo.RecordDailyTriviaAnswer(record, previousDate)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to save trivia answer")
+	}
+	if !recorded {
+		return nil, shared.NewConflictError(nil, "You already answered today's trivia")
+	}
+
+	if xp > 0 {
+		if err := svc.userSvc.awardXP(userID, xp, model.XPSourceTrivia, trivia.Date); err != nil {
+			log.WithError(err).Errorf("Failed to award trivia XP to user %s", userID)
+			if err := svc.sqlSvc.triviaRepo.SetDailyTriviaXPAwarded(record.ID, 0); err != nil {
+				log.WithError(err).Errorf("Failed to reset trivia XP for answer %s", record.ID)
+			}
+			xp = 0
+		}
+	}
+
+	stats, err := svc.GetStats(trivia.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.DailyTriviaAnswerResponse{
+		Correct:   correct,
+		XPAwarded: xp,
+		Stats:     *stats,
+		Streak:    svc.mapStreak(streak, trivia.Date),
+	}, nil
+}
+
+// GetStats returns how many users answered a day's trivia and how many got it right.
+// An empty date means today.
+func (svc *TriviaService) GetStats(date string) (*dto.TriviaStatsResponse, error) {
+	if date == "" {
+		date = svc.today()
+	} else if _, err := time.ParseInLocation(triviaDateFormat, date, svc.location); err != nil {
+		return nil, shared.NewBadRequestError(err, "Invalid date, expected YYYY-MM-DD")
+	}
+
+	stats, err := svc.sqlSvc.triviaRepo.GetDailyTriviaStats(date)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get trivia stats")
+	}
+
+	response := &dto.TriviaStatsResponse{
+		Date:           stats.Date,
+		TotalAnswers:   stats.TotalAnswers,
+		CorrectAnswers: stats.CorrectAnswers,
+	}
+	if stats.TotalAnswers > 0 {
+		percentage := float64(stats.CorrectAnswers) * 100 / float64(stats.TotalAnswers)
+		response.CorrectPercentage = math.Round(percentage*10) / 10
+	}
+	return response, nil
+}
+
+// GetStreak returns the user's trivia participation streak
+func (svc *TriviaService) GetStreak(userID string) (*dto.TriviaStreakResponse, error) {
+	streak, err := svc.sqlSvc.triviaRepo.GetUserTriviaStreak(userID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get trivia streak")
+	}
+
+	response := svc.mapStreak(streak, svc.today())
+	return &response, nil
+}
+
+// mapStreak reports a streak that was broken before today as zero, since the stored
+// value only changes when the user answers again
+func (svc *TriviaService) mapStreak(streak *model.UserTriviaStreak, today string) dto.TriviaStreakResponse {
+	current := streak.CurrentStreak
+	if streak.LastAnsweredDate != today && streak.LastAnsweredDate != svc.previousDate(today) {
+		current = 0
+	}
+	return dto.TriviaStreakResponse{
+		CurrentStreak:    current,
+		LongestStreak:    streak.LongestStreak,
+		LastAnsweredDate: streak.LastAnsweredDate,
+	}
+}
+
+func (svc *TriviaService) previousDate(date string) string {
+	day, err := time.ParseInLocation(triviaDateFormat, date, svc.location)
+	if err != nil {
+		return ""
+	}
+	return day.AddDate(0, 0, -1).Format(triviaDateFormat)
+}
+
+// todayQuestion returns today's trivia and its question, picking one if nobody has
+// asked for it yet today
+func (svc *TriviaService) todayQuestion() (*model.DailyTrivia, *model.Question, error) {
+	date := svc.today()
+
+	trivia, err := svc.sqlSvc.triviaRepo.GetDailyTrivia(date)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		trivia, err = svc.pickDailyTrivia(date)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	question, err := svc.getTriviaQuestion(trivia)
+	if err != nil {
+		return nil, nil, err
+	}
+	return trivia, question, nil
+}
+
+// pickDailyTrivia chooses a random question from the active lessons, avoiding questions
+// used recently. When every sampled question was used recently one of them is reused.
+func (svc *TriviaService) pickDailyTrivia(date string) (*model.DailyTrivia, error) {
+	since := ""
+	if day, err := time.ParseInLocation(triviaDateFormat, date, svc.location); err == nil {
+		since = day.AddDate(0, 0, -triviaRepeatDays).Format(triviaDateFormat)
+	}
+	used, err := svc.sqlSvc.triviaRepo.GetRecentTriviaQuestions(since)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to load recent trivia")
+	}
+
+	lessons, err := svc.sqlSvc.triviaRepo.GetRandomQuestionLessons(triviaLessonPicks)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to pick trivia question")
+	}
+
+	var fallback *model.DailyTrivia
+	for _, lesson := range lessons {
+		var questions []model.Question
+		if err := json.Unmarshal(lesson.Questions, &questions); err != nil {
+			log.Printf("Failed to unmarshal questions for lesson %s: %v", lesson.ID, err)
+			continue
+		}
+
+		for _, i := range rand.Perm(len(questions)) {
+			candidate := &model.DailyTrivia{
+				Date:       date,
+				LessonID:   lesson.ID,
+				QuestionID: questions[i].ID,
+				XPReward:   triviaXPReward,
+			}
+			if !used[lesson.ID+"/"+questions[i].ID] {
+				return svc.saveDailyTrivia(candidate)
+			}
+			if fallback == nil {
+				fallback = candidate
+			}
+		}
+	}
+
+	if fallback == nil {
+		return nil, shared.NewNotFoundError(nil, "No trivia question available today")
+	}
+	return svc.saveDailyTrivia(fallback)
+}
+
+func (svc *TriviaService) saveDailyTrivia(trivia *model.DailyTrivia) (*model.DailyTrivia, error) {
+	saved, err := svc.sqlSvc.triviaRepo.CreateDailyTrivia(trivia)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to save daily trivia")
+	}
+	return saved, nil
+}
+
+func (svc *TriviaService) getTriviaQuestion(trivia *model.DailyTrivia) (*model.Question, error) {
+	lesson, err := svc.sqlSvc.contentRepo.GetLesson(trivia.LessonID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Today's trivia question is no longer available")
+	}
+
+	var questions []model.Question
+	if err := json.Unmarshal(lesson.Questions, &questions); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to parse lesson questions")
+	}
+
+	for i := range questions {
+		if questions[i].ID == trivia.QuestionID {
+			return &questions[i], nil
+		}
+	}
+
+	return nil, shared.NewNotFoundError(nil, "Today's trivia question is no longer available")
+}
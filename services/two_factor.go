@@ -0,0 +1,329 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+)
+
+const (
+	totpIssuer = "TechYouth"
+	totpPeriod = 30
+	totpDigits = 6
+	// Codes from one period before or after are accepted to allow for clock drift
+	totpSkew = 1
+
+	backupCodeCount  = 10
+	backupCodeLength = 10
+	// Letters and digits that can't be mistaken for each other
+	backupCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+	// Users are warned once they have this many backup codes or fewer left
+	backupCodesLowThreshold = 3
+)
+
+type BackupCodesLowEmail struct {
+	Email     string
+	Username  string
+	IP        string
+	Remaining int
+}
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// SetupTwoFactor creates a new authenticator secret for the user. 2FA stays off until
+// EnableTwoFactor confirms a code generated from it.
+func (svc *AuthService) SetupTwoFactor(userID string) (*dto.EnableTwoFactorResponse, error) {
+	user, err := svc.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "User not found")
+	}
+	if user.TwoFactorEnabled {
+		return nil, shared.NewConflictError(errors.New("2fa enabled"), "Two-factor authentication is already enabled")
+	}
+
+	key := make([]byte, 20)
+	if _, err := rand.Read(key); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to generate two-factor secret")
+	}
+	secret := totpEncoding.EncodeToString(key)
+
+	updated, err := svc.userRepo.SetTwoFactorSecret(userID, secret)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to save two-factor secret")
+	}
+	if !updated {
+		return nil, shared.NewConflictError(errors.New("2fa enabled"), "Two-factor authentication is already enabled")
+	}
+
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", totpIssuer)
+	return &dto.EnableTwoFactorResponse{
+		Secret:     secret,
+		OTPAuthURL: fmt.Sprintf("otpauth://totp/%s:%s?%s", totpIssuer, url.PathEscape(user.Email), query.Encode()),
+	}, nil
+}
+
+// EnableTwoFactor turns 2FA on once the user proves their authenticator works, returning
+// the backup codes. This is the only time the codes are shown.
+func (svc *AuthService) EnableTwoFactor(userID, code, clientIP, userAgent string) (*dto.BackupCodesResponse, error) {
+	user, err := svc.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "User not found")
+	}
+	if user.TwoFactorEnabled {
+		return nil, shared.NewConflictError(errors.New("2fa enabled"), "Two-factor authentication is already enabled")
+	}
+	if user.TwoFactorSecret == "" {
+		return nil, shared.NewBadRequestError(errors.New("no 2fa secret"), "Set up two-factor authentication first")
+	}
+	accepted, err := svc.acceptTOTP(user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !accepted {
+		return nil, shared.NewBadRequestError(errors.New("invalid totp code"), "Invalid two-factor code")
+	}
+
+	codes, hashes, err := svc.generateBackupCodes(userID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to generate backup codes")
+	}
+
+	message := newOutboxMessage(OutboxTopicAuthAudit, svc.twoFactorAudit(userID, "two_factor_enabled", clientIP, userAgent))
+	enabled, err := svc.userRepo.EnableTwoFactor(userID, hashes, message)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to enable two-factor authentication")
+	}
+	if !enabled {
+		return nil, shared.NewConflictError(errors.New("2fa enabled"), "Two-factor authentication is already enabled")
+	}
+	go svc.outboxSvc.Relay(message)
+
+	return &dto.BackupCodesResponse{BackupCodes: codes}, nil
+}
+
+// DisableTwoFactor turns 2FA off. An authenticator code or an unused backup code is
+// required, so a stolen session alone can't remove it.
+func (svc *AuthService) DisableTwoFactor(userID, code, clientIP, userAgent string) error {
+	user, err := svc.requireTwoFactorCode(userID, code, clientIP, userAgent)
+	if err != nil {
+		return err
+	}
+
+	message := newOutboxMessage(OutboxTopicAuthAudit, svc.twoFactorAudit(user.ID, "two_factor_disabled", clientIP, userAgent))
+	if err := svc.userRepo.DisableTwoFactor(user.ID, message); err != nil {
+		return shared.NewInternalError(err, "Failed to disable two-factor authentication")
+	}
+	go svc.outboxSvc.Relay(message)
+
+	return nil
+}
+
+// RegenerateBackupCodes replaces all backup codes of the user, used or not
+func (svc *AuthService) RegenerateBackupCodes(userID, code, clientIP, userAgent string) (*dto.BackupCodesResponse, error) {
+	user, err := svc.requireTwoFactorCode(userID, code, clientIP, userAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := svc.generateBackupCodes(user.ID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to generate backup codes")
+	}
+
+	message := newOutboxMessage(OutboxTopicAuthAudit, svc.twoFactorAudit(user.ID, "backup_codes_regenerated", clientIP, userAgent))
+	if err := svc.userRepo.ReplaceBackupCodes(user.ID, hashes, message); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to save backup codes")
+	}
+	go svc.outboxSvc.Relay(message)
+
+	return &dto.BackupCodesResponse{BackupCodes: codes}, nil
+}
+
+// requireTwoFactorCode checks the second factor before a change to 2FA itself. Codes are
+// used up as on login, and wrong codes count towards the same lockout as failed logins,
+// so the code can't be guessed from a stolen session.
+func (svc *AuthService) requireTwoFactorCode(userID, code, clientIP, userAgent string) (*model.User, error) {
+	user, err := svc.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "User not found")
+	}
+	if !user.TwoFactorEnabled {
+		return nil, shared.NewBadRequestError(errors.New("2fa disabled"), "Two-factor authentication is not enabled")
+	}
+	if user.LockedUntil != nil && user.LockedUntil.After(time.Now()) {
+		return nil, shared.NewTooManyRequestsError(errors.New("account locked"), "Too many wrong two-factor codes, try again later")
+	}
+
+	_, ok, err := svc.verifyTwoFactorCode(user, code, clientIP, userAgent)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		svc.recordFailedLogin(user, "failed_2fa_check", clientIP, userAgent)
+		return nil, shared.NewBadRequestError(errors.New("invalid 2fa code"), "Invalid two-factor code")
+	}
+
+	if user.FailedAttempts > 0 {
+		svc.dbOperationCh <- func() {
+			svc.userRepo.ResetFailedAttempts(user.ID)
+		}
+	}
+	return user, nil
+}
+
+// verifyTwoFactorCode checks the second factor of a login or of a change to 2FA. An
+// authenticator code is accepted once; a backup code is used up and the number of codes
+// left is returned, and users running low are warned by email and in the app.
+func (svc *AuthService) verifyTwoFactorCode(user *model.User, code, clientIP, userAgent string) (*int, bool, error) {
+	accepted, err := svc.acceptTOTP(user, code)
+	if err != nil {
+		return nil, false, err
+	}
+	if accepted {
+		return nil, true, nil
+	}
+
+	// Messages are built from the codes loaded with the user; the repository only
+	// writes them if the code was still unused under lock
+	remaining := len(user.BackupCodeHashes()) - 1
+	if remaining < 0 {
+		return nil, false, nil
+	}
+
+	audit := svc.twoFactorAudit(user.ID, "backup_code_used", clientIP, userAgent)
+	audit.Details = fmt.Sprintf("%d backup codes left", remaining)
+	messages := []*model.OutboxMessage{newOutboxMessage(OutboxTopicAuthAudit, audit)}
+	if remaining <= backupCodesLowThreshold {
+		messages = append(messages, newOutboxMessage(OutboxTopicBackupCodesLowEmail, BackupCodesLowEmail{
+			Email:     user.Email,
+			Username:  user.Username,
+			IP:        clientIP,
+			Remaining: remaining,
+		}))
+	}
+
+	left, consumed, err := svc.userRepo.ConsumeBackupCode(user.ID, svc.hashBackupCode(user.ID, code), messages...)
+	if err != nil {
+		return nil, false, shared.NewInternalError(err, "Failed to verify backup code")
+	}
+	if !consumed {
+		return nil, false, nil
+	}
+	go svc.outboxSvc.Relay(messages...)
+
+	if left <= backupCodesLowThreshold {
+		svc.notificationSvc.Notify(user.ID, model.NotificationTypeSecurity, "Running out of backup codes",
+			fmt.Sprintf("You have %d backup codes left. Create new ones in your security settings.", left),
+			map[string]interface{}{
+				"backup_codes_remaining": left,
+			})
+	}
+
+	return &left, true, nil
+}
+
+// generateBackupCodes returns new backup codes and the hashes to store for them
+func (svc *AuthService) generateBackupCodes(userID string) ([]string, []string, error) {
+	codes := make([]string, backupCodeCount)
+	hashes := make([]string, backupCodeCount)
+	max := big.NewInt(int64(len(backupCodeAlphabet)))
+
+	for i := range codes {
+		var code strings.Builder
+		for j := 0; j < backupCodeLength; j++ {
+			if j == backupCodeLength/2 {
+				code.WriteByte('-')
+			}
+			n, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return nil, nil, err
+			}
+			code.WriteByte(backupCodeAlphabet[n.Int64()])
+		}
+		codes[i] = code.String()
+		hashes[i] = svc.hashBackupCode(userID, codes[i])
+	}
+	return codes, hashes, nil
+}
+
+// hashBackupCode hashes a backup code as typed by the user. Dashes, spaces and case don't
+// matter, and the user ID keeps equal codes of different users apart.
+func (svc *AuthService) hashBackupCode(userID, code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return svc.hashToken(userID + ":" + normalized)
+}
+
+func (svc *AuthService) twoFactorAudit(userID, action, clientIP, userAgent string) dto.AuthAuditLog {
+	return dto.AuthAuditLog{
+		UserID:    userID,
+		Action:    action,
+		IP:        clientIP,
+		UserAgent: userAgent,
+		Timestamp: time.Now(),
+		Success:   true,
+	}
+}
+
+// acceptTOTP checks an authenticator code of the user and claims its time step, so the
+// code, or an earlier one, can't be replayed within the drift window
+func (svc *AuthService) acceptTOTP(user *model.User, code string) (bool, error) {
+	step, ok := matchTOTP(user.TwoFactorSecret, code, time.Now())
+	if !ok || step <= user.TwoFactorLastStep {
+		return false, nil
+	}
+
+	claimed, err := svc.userRepo.ClaimTwoFactorStep(user.ID, step)
+	if err != nil {
+		return false, shared.NewInternalError(err, "Failed to verify two-factor code")
+	}
+	return claimed, nil
+}
+
+// matchTOTP checks an authenticator code against a base32 secret (RFC 6238, SHA-1),
+// returning the time step the code belongs to
+func matchTOTP(secret, code string, now time.Time) (int64, bool) {
+	if secret == "" || len(code) != totpDigits {
+		return 0, false
+	}
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return 0, false
+	}
+
+	step := now.Unix() / totpPeriod
+	for skew := int64(-totpSkew); skew <= totpSkew; skew++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step+skew)), []byte(code)) == 1 {
+			return step + skew, true
+		}
+	}
+	return 0, false
+}
+
+func totpCode(key []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/services/repositories/mocks"
+	"github.com/lac-hong-legacy/ven_api/shared"
+)
+
+const testTOTPSecret = "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"
+
+func newTwoFactorTestService(user *model.User, failures *int) *AuthService {
+	return &AuthService{
+		maxLoginAttempts: 5,
+		lockoutDuration:  time.Minute,
+		logAuthEventCh:   make(chan dto.AuthAuditLog, 10),
+		userRepo: &mocks.UserRepo{
+			GetUserByIDFunc: func(userID string) (*model.User, error) { return user, nil },
+			ClaimTwoFactorStepFunc: func(userID string, step int64) (bool, error) {
+				if step <= user.TwoFactorLastStep {
+					return false, nil
+				}
+				user.TwoFactorLastStep = step
+				return true, nil
+			},
+			// Every backup code of the user was used up by an earlier request
+			ConsumeBackupCodeFunc: func(userID, codeHash string, outbox ...*model.OutboxMessage) (int, bool, error) {
+				return 0, false, nil
+			},
+			IncrementFailedAttemptsFunc: func(userID string) (int, error) {
+				*failures++
+				return *failures, nil
+			},
+		},
+	}
+}
+
+// An authenticator code is accepted once: replaying it, or a code of an earlier step,
+// within the drift window fails and counts as a failed attempt
+func TestRequireTwoFactorCodeRejectsReplayedTOTP(t *testing.T) {
+	key, _ := totpEncoding.DecodeString(testTOTPSecret)
+	step := time.Now().Unix() / totpPeriod
+	user := &model.User{ID: "user_1", TwoFactorEnabled: true, TwoFactorSecret: testTOTPSecret}
+	var failures int
+	svc := newTwoFactorTestService(user, &failures)
+
+	if _, err := svc.requireTwoFactorCode(user.ID, totpCode(key, step), "203.0.113.7", "test"); err != nil {
+		t.Fatalf("fresh code: %v", err)
+	}
+	for _, code := range []string{totpCode(key, step), totpCode(key, step-1)} {
+		if _, err := svc.requireTwoFactorCode(user.ID, code, "203.0.113.7", "test"); err == nil {
+			t.Errorf("code %s was accepted again", code)
+		}
+	}
+	if failures != 2 {
+		t.Errorf("counted %d failed attempts, want 2", failures)
+	}
+}
+
+// A backup code still listed on the loaded user is refused once the repository reports
+// it used, so a leaked code works at most once
+func TestRequireTwoFactorCodeConsumesBackupCodes(t *testing.T) {
+	svc := &AuthService{}
+	hashes, _ := json.Marshal([]string{svc.hashBackupCode("user_1", "abcde-fghjk")})
+	user := &model.User{ID: "user_1", TwoFactorEnabled: true, TwoFactorSecret: testTOTPSecret, BackupCodes: string(hashes)}
+	var failures int
+	svc = newTwoFactorTestService(user, &failures)
+
+	if _, err := svc.requireTwoFactorCode(user.ID, "abcde-fghjk", "203.0.113.7", "test"); err == nil {
+		t.Error("used backup code was accepted")
+	}
+	if failures != 1 {
+		t.Errorf("counted %d failed attempts, want 1", failures)
+	}
+}
+
+// A locked account is refused before the code is looked at
+func TestRequireTwoFactorCodeLocked(t *testing.T) {
+	key, _ := totpEncoding.DecodeString(testTOTPSecret)
+	lockedUntil := time.Now().Add(time.Minute)
+	user := &model.User{ID: "user_1", TwoFactorEnabled: true, TwoFactorSecret: testTOTPSecret, LockedUntil: &lockedUntil}
+	var failures int
+	svc := newTwoFactorTestService(user, &failures)
+
+	_, err := svc.requireTwoFactorCode(user.ID, totpCode(key, time.Now().Unix()/totpPeriod), "203.0.113.7", "test")
+	if appErr, ok := shared.GetAppError(err); !ok || appErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("got %v, want too many requests", err)
+	}
+}
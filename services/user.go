@@ -2,42 +2,103 @@
 package services
 
 import (
-	"encoding/json"
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"math"
+	"os"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/cloakd/common/context"
 	serviceContext "github.com/cloakd/common/services"
-	"github.com/google/uuid"
 	"github.com/lac-hong-legacy/ven_api/dto"
 	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/services/repositories"
 	"github.com/lac-hong-legacy/ven_api/shared"
+	"github.com/lac-hong-legacy/ven_api/shared/ids"
+	"github.com/lac-hong-legacy/ven_api/shared/text"
 	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 )
 
 type UserService struct {
 	serviceContext.DefaultService
 
-	contentSvc *ContentService
-	sqlSvc     *PostgresService
+	contentSvc      *ContentService
+	sqlSvc          *PostgresService
+	notificationSvc *NotificationService
+	achievementSvc  *AchievementService
+	authSvc         *AuthService
+	systemSvc       *SystemService
+	eventBusSvc     *EventBusService
+	outboxSvc       *OutboxService
+	redisSvc        *RedisService
+	moderationSvc   *ModerationService
+	minioSvc        *MinIOService
+
+	// Repositories behind interfaces so tests can use the fakes in repositories/mocks
+	userRepo     repositories.UserRepo
+	contentRepo  repositories.ContentRepo
+	progressRepo repositories.ProgressRepo
+
+	progressCache *progressCache
+
+	// Where relative spirit and character image paths are fetched from for share cards
+	shareAssetBaseURL string
+
+	// Latest progress repair run over all users
+	repairMutex sync.Mutex
+	repairJob   dto.ProgressRepairJobResponse
 }
 
 const USER_SVC = "user_svc"
 
-func (svc UserService) Id() string {
+func (svc *UserService) Id() string {
 	return USER_SVC
 }
 
 func (svc *UserService) Configure(ctx *context.Context) error {
+	svc.shareAssetBaseURL = os.Getenv("SHARE_CARD_ASSET_BASE_URL")
 	return svc.DefaultService.Configure(ctx)
 }
 
 func (svc *UserService) Start() error {
-	svc.sqlSvc = svc.Service(POSTGRES_SVC).(*PostgresService)
-	svc.contentSvc = svc.Service(CONTENT_SVC).(*ContentService)
+	deps := newDependencies(svc.Id(), svc.Service)
+	svc.sqlSvc = resolve[*PostgresService](deps, POSTGRES_SVC)
+	svc.contentSvc = resolve[*ContentService](deps, CONTENT_SVC)
+	svc.notificationSvc = resolve[*NotificationService](deps, NOTIFICATION_SVC)
+	svc.achievementSvc = resolve[*AchievementService](deps, ACHIEVEMENT_SVC)
+	svc.authSvc = resolve[*AuthService](deps, AUTH_SVC)
+	svc.systemSvc = resolve[*SystemService](deps, SYSTEM_SVC)
+	svc.eventBusSvc = resolve[*EventBusService](deps, EVENT_BUS_SVC)
+	svc.outboxSvc = resolve[*OutboxService](deps, OUTBOX_SVC)
+	svc.redisSvc = resolve[*RedisService](deps, REDIS_SVC)
+	svc.moderationSvc = resolve[*ModerationService](deps, MODERATION_SVC)
+	svc.minioSvc = resolve[*MinIOService](deps, MINIO_SVC)
+	if err := deps.err(); err != nil {
+		return err
+	}
+	svc.userRepo = svc.sqlSvc.userRepo
+	svc.contentRepo = svc.sqlSvc.contentRepo
+	svc.progressRepo = svc.sqlSvc.contentRepo
+
+	svc.progressCache = newProgressCache(svc.redisSvc, progressCacheTTL(os.Getenv("PROGRESS_CACHE_TTL_SECONDS")))
+
+	svc.eventBusSvc.Subscribe(EventLessonCompleted, USER_SVC, svc.onLessonCompleted)
+
+	// Subscribed after onLessonCompleted so the snapshot is dropped once its effects are saved
+	for _, event := range []string{EventLessonCompleted, EventLevelUp, EventStreakBroken, EventCharacterUnlocked, EventProgressChanged} {
+		svc.eventBusSvc.Subscribe(event, USER_SVC, svc.invalidateProgressSnapshot)
+	}
 
 	go svc.startHeartResetScheduler()
+	go svc.startXPReconcileScheduler()
+	go svc.startLeaderboardAnomalyScanner()
 
 	return nil
 }
@@ -60,20 +121,48 @@ func (svc *UserService) startHeartResetScheduler() {
 	}
 }
 
-// Initialize user profile after registration
-func (svc *UserService) InitializeUserProfile(userID string, birthYear int) error {
+// Initialize user profile after registration. Once the birth year is set, calling it
+// again with other birth details fails; the zodiac then only changes by CorrectZodiac.
+func (svc *UserService) InitializeUserProfile(userID string, req dto.InitializeProfileRequest) error {
+	birthDate, err := parseBirthDate(req.BirthDate, req.BirthYear)
+	if err != nil {
+		return err
+	}
+
+	user, err := svc.userRepo.GetUserByID(userID)
+	if err != nil {
+		return shared.NewNotFoundError(err, "User not found")
+	}
+	if user.BirthYear > 0 && (user.BirthYear != req.BirthYear ||
+		(birthDate != nil && user.BirthDate != nil && !birthDate.Equal(*user.BirthDate))) {
+		return shared.NewConflictError(nil, "Birth details are already set. Correct your zodiac instead")
+	}
+
+	updates := map[string]interface{}{"birth_year": req.BirthYear}
+	if birthDate != nil {
+		updates["birth_date"] = *birthDate
+	} else {
+		birthDate = user.BirthDate
+	}
+	if err := svc.userRepo.UpdateUserProfile(userID, updates); err != nil {
+		return err
+	}
+	defer svc.AdvanceOnboarding(userID)
+
+	spiritType := zodiacAnimal(zodiacLunarYear(req.BirthYear, birthDate))
+
 	// Check if user already has progress
-	existingProgress, err := svc.sqlSvc.contentRepo.GetUserProgress(userID)
+	existingProgress, err := svc.progressRepo.GetUserProgress(userID)
 	if err == nil && existingProgress != nil {
-		// Progress already exists, check if we need to update the spirit
-		return svc.updateUserSpirit(userID, birthYear)
+		// Progress already exists, check if we need to update the spirit. A corrected
+		// zodiac is kept.
+		return svc.updateUserSpirit(userID, spiritType, user.ZodiacCorrectedAt == nil)
 	}
 
-	progressID, _ := uuid.NewV7()
 	emptyArray := model.JSONB("[]")
 	now := time.Now()
 	progress := &model.UserProgress{
-		ID:                 progressID.String(),
+		ID:                 ids.New(),
 		UserID:             userID,
 		Hearts:             5,
 		MaxHearts:          5,
@@ -89,14 +178,17 @@ func (svc *UserService) InitializeUserProfile(userID string, birthYear int) erro
 		UpdatedAt:          now,
 	}
 
-	if _, err := svc.sqlSvc.contentRepo.CreateUserProgress(progress); err != nil {
+	if _, err := svc.progressRepo.CreateUserProgress(progress); err != nil {
 		return err
 	}
 
-	spiritType := svc.getZodiacAnimal(birthYear)
-	spiritID, _ := uuid.NewV7()
+	return svc.createSpirit(userID, spiritType)
+}
+
+func (svc *UserService) createSpirit(userID, spiritType string) error {
+	now := time.Now()
 	spirit := &model.Spirit{
-		ID:        spiritID.String(),
+		ID:        ids.New(),
 		UserID:    userID,
 		Type:      spiritType,
 		Stage:     1,
@@ -108,21 +200,14 @@ func (svc *UserService) InitializeUserProfile(userID string, birthYear int) erro
 		UpdatedAt: now,
 	}
 
-	if _, err := svc.sqlSvc.contentRepo.CreateSpirit(spirit); err != nil {
+	if _, err := svc.progressRepo.CreateSpirit(spirit); err != nil {
 		return err
 	}
+	svc.refreshLeaderboardProfile(userID)
 
 	return nil
 }
 
-func (svc *UserService) getZodiacAnimal(birthYear int) string {
-	zodiacAnimals := []string{
-		"rat", "ox", "tiger", "rabbit", "dragon", "snake",
-		"horse", "goat", "monkey", "rooster", "dog", "pig",
-	}
-	return zodiacAnimals[(birthYear-4)%12]
-}
-
 func (svc *UserService) getSpiritImageURL(spiritType string, stage int) string {
 	return fmt.Sprintf("/assets/spirits/%s_stage_%d.png", spiritType, stage)
 }
@@ -133,7 +218,7 @@ func (svc *UserService) ResetDailyHearts() error {
 	now := time.Now()
 	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 
-	users, err := svc.sqlSvc.contentRepo.GetUsersForHeartReset(startOfDay)
+	users, err := svc.progressRepo.GetUsersForHeartReset(startOfDay)
 	if err != nil {
 		return err
 	}
@@ -148,87 +233,225 @@ func (svc *UserService) ResetDailyHearts() error {
 }
 
 func (svc *UserService) resetUserHearts(userID string) error {
-	progress, err := svc.sqlSvc.contentRepo.GetUserProgress(userID)
+	_, err := svc.progressRepo.ModifyUserProgress(userID, func(progress *model.UserProgress) (*model.ProgressChange, error) {
+		progress.Hearts = progress.MaxHearts
+		now := time.Now()
+		progress.LastHeartReset = &now
+		return &model.ProgressChange{}, nil
+	})
 	if err != nil {
 		return err
 	}
 
-	progress.Hearts = progress.MaxHearts
-	now := time.Now()
-	progress.LastHeartReset = &now
-	progress.UpdatedAt = now
-
-	return svc.sqlSvc.contentRepo.UpdateUserProgress(progress)
+	svc.publishProgressChanged(userID, "hearts_reset")
+	return nil
 }
 
 // Complete lesson for registered user
 func (svc *UserService) CompleteLesson(userID, lessonID string, score, timeSpent int) error {
-	progress, err := svc.sqlSvc.contentRepo.GetUserProgress(userID)
-	if err != nil {
+	now := time.Now()
+	if err := svc.checkCompletionSpeed(userID, lessonID, timeSpent, now); err != nil {
 		return err
 	}
 
-	// Parse completed lessons
-	var completedLessons []string
-	if err := json.Unmarshal([]byte(progress.CompletedLessons), &completedLessons); err != nil {
-		return err
-	}
+	// Award XP, the score bonus only if enough of the video was watched
+	watchedEnough, watchPercent := svc.watchedEnoughForBonus(userID, lessonID)
 
-	// Check if already completed
-	isNewCompletion := true
-	for _, completedID := range completedLessons {
-		if completedID == lessonID {
-			isNewCompletion = false
-			break
-		}
-	}
+	// The completion, the progress and the XP ledger are stored together, so a failed
+	// write leaves the lesson to be completed again rather than completed without XP
+	var progress *model.UserProgress
+	var events []*model.OutboxMessage
+	comebackActivated := false
+	_, err := svc.progressRepo.CompleteLesson(&model.UserLessonCompletion{
+		UserID:   userID,
+		LessonID: lessonID,
+		Score:    score,
+	}, func(stored *model.UserProgress, isNewCompletion bool) *model.ProgressChange {
+		progress = stored
+		comebackActivated = svc.activateComebackBonus(progress, now)
 
-	if isNewCompletion {
-		// Add to completed lessons
-		completedLessons = append(completedLessons, lessonID)
-		completedLessonsJSON, err := json.Marshal(completedLessons)
-		if err != nil {
-			return err
+		var xpTxn *model.XPTransaction
+		oldLevel := progress.Level
+		if isNewCompletion {
+			xpGained := svc.calculateXP(score, watchedEnough)
+			xpTxn = &model.XPTransaction{
+				Source:      model.XPSourceLesson,
+				Amount:      xpGained,
+				ReferenceID: lessonID,
+			}
+			var notes []string
+			if !watchedEnough && xpGained < svc.calculateXP(score, true) {
+				notes = append(notes, fmt.Sprintf("no score bonus, watched %d%% of the video", watchPercent))
+			}
+			if progress.HasComebackBonus(now) {
+				xpGained = int(math.Round(float64(xpGained) * progress.ComebackMultiplier))
+				xpTxn.Amount = xpGained
+				notes = append(notes, fmt.Sprintf("comeback bonus x%.1f", progress.ComebackMultiplier))
+			}
+			xpTxn.Note = strings.Join(notes, "; ")
+			progress.XP += xpGained
+			progress.Level = svc.calculateLevel(progress.XP)
 		}
-		progress.CompletedLessons = model.JSONB(completedLessonsJSON)
 
-		// Award XP
-		xpGained := svc.calculateXP(score)
-		progress.XP += xpGained
-		oldLevel := progress.Level
-		progress.Level = svc.calculateLevel(progress.XP)
+		// Update play time
+		progress.TotalPlayTime += timeSpent / 60
 
-		// Update spirit XP
-		if err := svc.updateSpiritXP(userID, xpGained); err != nil {
-			log.Printf("Failed to update spirit XP: %v", err)
+		// Events are saved with the progress they describe and relayed once it is stored,
+		// since listeners load and save progress themselves
+		xpGained := 0
+		if xpTxn != nil {
+			xpGained = xpTxn.Amount
 		}
-
-		// Check for level up
+		events = []*model.OutboxMessage{eventMessage(&LessonCompletedEvent{
+			UserID:          userID,
+			LessonID:        lessonID,
+			Score:           score,
+			TimeSpent:       timeSpent,
+			XPGained:        xpGained,
+			FirstCompletion: isNewCompletion,
+			CompletedAt:     now.UTC(),
+		})}
 		if progress.Level > oldLevel {
 			log.Printf("User %s leveled up to %d", userID, progress.Level)
-			// TODO: Trigger level up rewards/notifications
+			events = append(events, eventMessage(&LevelUpEvent{
+				UserID:        userID,
+				Level:         progress.Level,
+				PreviousLevel: oldLevel,
+				XP:            progress.XP,
+			}))
+		}
+		return &model.ProgressChange{XP: xpTxn, Outbox: events}
+	})
+	if err != nil {
+		return err
+	}
+	svc.systemSvc.RecordLessonCompletion()
+
+	if comebackActivated {
+		svc.notificationSvc.Notify(userID, model.NotificationTypeReward, "Welcome back!",
+			fmt.Sprintf("You earn %.1fx XP until %s", progress.ComebackMultiplier, progress.ComebackUntil.Format(time.RFC1123)),
+			map[string]interface{}{"xp_multiplier": progress.ComebackMultiplier, "expires_at": progress.ComebackUntil})
+	}
+
+	svc.outboxSvc.Relay(events...)
+
+	return nil
+}
+
+// onLessonCompleted applies the learner-side effects of a completion: spirit growth,
+// character unlocks, the daily streak and onboarding, in that order
+func (svc *UserService) onLessonCompleted(event DomainEvent) {
+	e := event.(*LessonCompletedEvent)
+
+	if e.FirstCompletion {
+		if e.XPGained > 0 {
+			if err := svc.updateSpiritXP(e.UserID, e.XPGained); err != nil {
+				log.Printf("Failed to update spirit XP: %v", err)
+			}
 		}
 
-		// Check if character should be unlocked
-		if err := svc.checkCharacterUnlock(userID, lessonID); err != nil {
+		if err := svc.checkCharacterUnlock(e.UserID, e.LessonID); err != nil {
 			log.Printf("Failed to check character unlock: %v", err)
 		}
 	}
 
-	// Update play time
-	progress.TotalPlayTime += timeSpent / 60
-	progress.UpdatedAt = time.Now()
-
-	// Update streak
-	if err := svc.updateStreak(userID); err != nil {
+	if err := svc.updateStreak(e.UserID); err != nil {
 		log.Printf("Failed to update streak: %v", err)
 	}
 
-	return svc.sqlSvc.contentRepo.UpdateUserProgress(progress)
+	svc.AdvanceOnboarding(e.UserID)
+}
+
+// awardXP adds bonus XP outside of lesson completion, e.g. battle and achievement rewards.
+// referenceID identifies what the XP was earned for in the ledger.
+func (svc *UserService) awardXP(userID string, xp int, source, referenceID string) error {
+	_, err := svc.progressRepo.ModifyUserProgress(userID, func(progress *model.UserProgress) (*model.ProgressChange, error) {
+		progress.XP += xp
+		progress.Level = svc.calculateLevel(progress.XP)
+		return &model.ProgressChange{XP: &model.XPTransaction{
+			Source:      source,
+			Amount:      xp,
+			ReferenceID: referenceID,
+		}}, nil
+	})
+	if err != nil {
+		return err
+	}
+	defer svc.publishProgressChanged(userID, source)
+
+	return svc.updateSpiritXP(userID, xp)
+}
+
+// activateComebackBonus starts the comeback bonus when a user returns after the configured
+// number of inactive days. It returns true if the bonus was activated.
+func (svc *UserService) activateComebackBonus(progress *model.UserProgress, now time.Time) bool {
+	if progress.LastActivityDate == nil || progress.HasComebackBonus(now) {
+		return false
+	}
+
+	config, err := svc.contentRepo.GetGameConfig()
+	if err != nil {
+		log.Printf("Failed to load game config: %v", err)
+		return false
+	}
+
+	inactiveFor := now.Sub(*progress.LastActivityDate)
+	if inactiveFor < time.Duration(config.ComebackInactiveDays)*24*time.Hour {
+		return false
+	}
+
+	until := now.Add(time.Duration(config.ComebackDurationHours) * time.Hour)
+	progress.ComebackMultiplier = config.ComebackXPMultiplier
+	progress.ComebackUntil = &until
+	if config.ComebackRestoresHearts {
+		progress.Hearts = progress.MaxHearts
+	}
+
+	log.Printf("User %s returned after %d days, comeback bonus active until %s", progress.UserID, int(inactiveFor.Hours()/24), until)
+	return true
+}
+
+func (svc *UserService) startXPReconcileScheduler() {
+	ticker := time.NewTicker(24 * time.Hour)
+	for range ticker.C {
+		if err := svc.ReconcileXPLedger(); err != nil {
+			log.Printf("Failed to reconcile XP ledger: %v", err)
+		}
+	}
+}
+
+// ReconcileXPLedger finds users whose stored XP drifted from their ledger, e.g. after a
+// manual database fix, and records the difference so the ledger explains the total
+func (svc *UserService) ReconcileXPLedger() error {
+	mismatches, err := svc.progressRepo.GetXPLedgerMismatches()
+	if err != nil {
+		return err
+	}
+
+	for _, mismatch := range mismatches {
+		log.Warnf("XP drift for user %s: stored %d, ledger %d", mismatch.UserID, mismatch.XP, mismatch.LedgerXP)
+		if err := svc.progressRepo.CreateXPTransaction(&model.XPTransaction{
+			UserID:       mismatch.UserID,
+			Source:       model.XPSourceReconcile,
+			Amount:       mismatch.XP - mismatch.LedgerXP,
+			BalanceAfter: mismatch.XP,
+			Note:         fmt.Sprintf("stored XP %d, ledger XP %d", mismatch.XP, mismatch.LedgerXP),
+		}); err != nil {
+			return err
+		}
+	}
+
+	if len(mismatches) > 0 {
+		log.Printf("Reconciled XP ledger for %d user(s)", len(mismatches))
+	}
+	return nil
 }
 
-func (svc *UserService) calculateXP(score int) int {
+func (svc *UserService) calculateXP(score int, scoreBonus bool) int {
 	baseXP := 50
+	if !scoreBonus {
+		return baseXP
+	}
 	bonusXP := max(0, (score-60)/10*10) // Bonus for scores above 60%
 	return baseXP + bonusXP
 }
@@ -247,12 +470,13 @@ func (svc *UserService) calculateLevel(totalXP int) int {
 }
 
 func (svc *UserService) updateSpiritXP(userID string, xpGained int) error {
-	spirit, err := svc.sqlSvc.contentRepo.GetUserSpirit(userID)
+	spirit, err := svc.progressRepo.GetUserSpirit(userID)
 	if err != nil {
 		return err
 	}
 
 	spirit.XP += xpGained
+	previousStage := spirit.Stage
 
 	// Check for spirit evolution
 	for spirit.XP >= spirit.XPToNext && spirit.Stage < 5 {
@@ -262,10 +486,18 @@ func (svc *UserService) updateSpiritXP(userID string, xpGained int) error {
 		spirit.ImageURL = svc.getSpiritImageURL(spirit.Type, spirit.Stage)
 
 		log.Printf("Spirit evolved to stage %d for user %s", spirit.Stage, userID)
-		// TODO: Trigger evolution animation/notification
+		svc.notificationSvc.Notify(userID, model.NotificationTypeAchievement, "Your spirit evolved!",
+			fmt.Sprintf("Your spirit reached stage %d", spirit.Stage),
+			map[string]interface{}{"spirit_id": spirit.ID, "stage": spirit.Stage})
 	}
 
-	return svc.sqlSvc.contentRepo.UpdateSpirit(spirit)
+	if err := svc.progressRepo.UpdateSpirit(spirit); err != nil {
+		return err
+	}
+	if spirit.Stage != previousStage {
+		svc.refreshLeaderboardProfile(userID)
+	}
+	return nil
 }
 
 func (svc *UserService) getNextStageXPRequirement(stage int) int {
@@ -282,45 +514,124 @@ func (svc *UserService) getNextStageXPRequirement(stage int) int {
 }
 
 func (svc *UserService) updateStreak(userID string) error {
-	progress, err := svc.sqlSvc.contentRepo.GetUserProgress(userID)
+	user, err := svc.userRepo.GetUserByID(userID)
 	if err != nil {
 		return err
 	}
 
 	now := time.Now()
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	location := streakLocation(user.Timezone)
 
-	if progress.LastActivityDate == nil {
-		progress.Streak = 1
-	} else {
-		lastActivityDay := time.Date(
-			progress.LastActivityDate.Year(),
-			progress.LastActivityDate.Month(),
-			progress.LastActivityDate.Day(),
-			0, 0, 0, 0, progress.LastActivityDate.Location(),
-		)
-
-		daysDiff := int(today.Sub(lastActivityDay).Hours() / 24)
-
-		switch daysDiff {
-		case 0:
-			// Same day, no change to streak
-		case 1:
-			// Next day, increment streak
-			progress.Streak++
-		default:
-			// Missed day(s), reset streak
+	var events []*model.OutboxMessage
+	progress, err := svc.progressRepo.ModifyUserProgress(userID, func(progress *model.UserProgress) (*model.ProgressChange, error) {
+		events = nil
+		if progress.LastActivityDate == nil {
 			progress.Streak = 1
+		} else {
+			lastActivityDay := streakDay(*progress.LastActivityDate, location)
+			daysDiff := int(streakDay(now, location).Sub(lastActivityDay).Hours() / 24)
+
+			switch {
+			case daysDiff <= 0:
+				// Same day (or earlier, after a timezone change), no change to streak
+			case daysDiff == 1:
+				// Next day, increment streak
+				progress.Streak++
+			case user.WeekendAmulet && onlyWeekendsBetween(lastActivityDay, daysDiff):
+				// The missed days were all Saturdays and Sundays
+				progress.Streak++
+				progress.StreakProtectedDays += daysDiff - 1
+			case progress.StreakFreezes >= daysDiff-1:
+				// Each missed day is covered by a streak freeze from the shop
+				progress.Streak++
+				progress.StreakFreezes -= daysDiff - 1
+				progress.StreakProtectedDays += daysDiff - 1
+			default:
+				// Missed day(s), reset streak
+				if progress.Streak > 1 {
+					events = append(events, eventMessage(&StreakBrokenEvent{
+						UserID:         userID,
+						PreviousStreak: progress.Streak,
+						LastActivityAt: *progress.LastActivityDate,
+					}))
+				}
+				progress.Streak = 1
+				progress.StreakProtectedDays = 0
+			}
 		}
+
+		progress.LastActivityDate = &now
+		return &model.ProgressChange{Outbox: events}, nil
+	})
+	if err != nil {
+		return err
 	}
 
-	progress.LastActivityDate = &now
-	return svc.sqlSvc.contentRepo.UpdateUserProgress(progress)
+	svc.achievementSvc.Observe(userID, model.AchievementMetricStreakDays, progress.Streak)
+	svc.outboxSvc.Relay(events...)
+	return nil
+}
+
+// streakLocation loads a user's timezone, defaulting to Vietnam time. The fixed offset
+// covers hosts without tzdata; Vietnam has no daylight saving.
+func streakLocation(name string) *time.Location {
+	if name != "" {
+		if location, err := time.LoadLocation(name); err == nil {
+			return location
+		}
+	}
+	if location, err := time.LoadLocation("Asia/Ho_Chi_Minh"); err == nil {
+		return location
+	}
+	return time.FixedZone("ICT", 7*60*60)
+}
+
+// streakDay returns the calendar day of t in location, as midnight UTC so days are
+// always 24 hours apart
+func streakDay(t time.Time, location *time.Location) time.Time {
+	t = t.In(location)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// onlyWeekendsBetween reports whether the days strictly between from and daysDiff days
+// later are all Saturdays or Sundays
+func onlyWeekendsBetween(from time.Time, daysDiff int) bool {
+	for i := 1; i < daysDiff; i++ {
+		switch from.AddDate(0, 0, i).Weekday() {
+		case time.Saturday, time.Sunday:
+		default:
+			return false
+		}
+	}
+	return true
 }
 
 func (svc *UserService) checkCharacterUnlock(userID, lessonID string) error {
-	// TODO: Implement character unlock logic based on lesson completion
-	// This would check if enough lessons are completed for a character
+	lesson, err := svc.contentRepo.GetLesson(lessonID)
+	if err != nil {
+		return err
+	}
+
+	// Completing any lesson of a character unlocks that character
+	unlocked := eventMessage(&CharacterUnlockedEvent{
+		UserID:        userID,
+		CharacterID:   lesson.CharacterID,
+		CharacterName: lesson.Character.Name,
+		LessonID:      lessonID,
+	})
+	isNewUnlock, err := svc.progressRepo.CreateUserCharacter(&model.UserCharacter{
+		UserID:      userID,
+		CharacterID: lesson.CharacterID,
+		Source:      model.UnlockSourceLesson,
+	}, unlocked)
+	if err != nil {
+		return err
+	}
+
+	if isNewUnlock {
+		log.Printf("User %s unlocked character %s", userID, lesson.CharacterID)
+		svc.outboxSvc.Relay(unlocked)
+	}
 	return nil
 }
 
@@ -333,21 +644,24 @@ func max(a, b int) int {
 
 // ==================== PROFILE METHODS ====================
 
-func (svc *UserService) updateUserSpirit(userID string, birthYear int) error {
-	spirit, err := svc.sqlSvc.contentRepo.GetUserSpirit(userID)
+func (svc *UserService) updateUserSpirit(userID, newType string, changeType bool) error {
+	spirit, err := svc.progressRepo.GetUserSpirit(userID)
 	if err != nil {
 		// If no spirit exists, create one
 		if strings.Contains(err.Error(), "not found") {
-			return svc.InitializeUserProfile(userID, birthYear)
+			return svc.createSpirit(userID, newType)
 		}
 		return err
 	}
 
-	newType := svc.getZodiacAnimal(birthYear)
-	if spirit.Type != newType {
+	if changeType && spirit.Type != newType {
 		spirit.Type = newType
 		spirit.ImageURL = svc.getSpiritImageURL(newType, spirit.Stage)
-		return svc.sqlSvc.contentRepo.UpdateSpirit(spirit)
+		if err := svc.progressRepo.UpdateSpirit(spirit); err != nil {
+			return err
+		}
+		svc.refreshLeaderboardProfile(userID)
+		svc.publishProgressChanged(userID, "spirit_type")
 	}
 
 	return nil
@@ -355,29 +669,64 @@ func (svc *UserService) updateUserSpirit(userID string, birthYear int) error {
 
 // ==================== PROGRESS METHODS ====================
 
+// GetUserProgress returns the user's progress snapshot, served from the progress cache
+// when it has not changed since it was last built
 func (svc *UserService) GetUserProgress(userID string) (*dto.UserProgressResponse, error) {
-	progress, err := svc.sqlSvc.contentRepo.GetUserProgress(userID)
+	return svc.progressCache.Get(userID, func() (*dto.UserProgressResponse, error) {
+		return svc.loadUserProgress(userID)
+	})
+}
+
+// publishProgressChanged tells listeners, the progress cache among them, that a write
+// not covered by another domain event changed the user's progress
+func (svc *UserService) publishProgressChanged(userID, reason string) {
+	svc.eventBusSvc.Publish(&ProgressChangedEvent{UserID: userID, Reason: reason})
+}
+
+func (svc *UserService) invalidateProgressSnapshot(event DomainEvent) {
+	var userID string
+	switch e := event.(type) {
+	case *LessonCompletedEvent:
+		userID = e.UserID
+	case *LevelUpEvent:
+		userID = e.UserID
+	case *StreakBrokenEvent:
+		userID = e.UserID
+	case *CharacterUnlockedEvent:
+		userID = e.UserID
+	case *ProgressChangedEvent:
+		userID = e.UserID
+	default:
+		return
+	}
+	svc.progressCache.Invalidate(userID)
+}
+
+func (svc *UserService) loadUserProgress(userID string) (*dto.UserProgressResponse, error) {
+	progress, err := svc.progressRepo.GetUserProgress(userID)
 	if err != nil {
 		return nil, err
 	}
 
-	spirit, err := svc.sqlSvc.contentRepo.GetUserSpirit(userID)
+	spirit, err := svc.progressRepo.GetUserSpirit(userID)
 	if err != nil {
 		return nil, err
 	}
 
-	var completedLessons []string
-	if err := json.Unmarshal([]byte(progress.CompletedLessons), &completedLessons); err != nil {
+	completedLessons, err := svc.progressRepo.GetCompletedLessonIDs(userID)
+	if err != nil {
+		log.Printf("Failed to get completed lessons: %v", err)
 		completedLessons = []string{}
 	}
 
-	var unlockedCharacters []string
-	if err := json.Unmarshal([]byte(progress.UnlockedCharacters), &unlockedCharacters); err != nil {
+	unlockedCharacters, err := svc.progressRepo.GetUnlockedCharacterIDs(userID)
+	if err != nil {
+		log.Printf("Failed to get unlocked characters: %v", err)
 		unlockedCharacters = []string{}
 	}
 
 	// Get recent achievements
-	achievements, err := svc.sqlSvc.contentRepo.GetUserAchievements(userID)
+	achievements, err := svc.progressRepo.GetUserAchievements(userID)
 	if err != nil {
 		log.Printf("Failed to get user achievements: %v", err)
 		achievements = []model.UserAchievement{}
@@ -399,6 +748,14 @@ func (svc *UserService) GetUserProgress(userID string) (*dto.UserProgressRespons
 		}
 	}
 
+	var comebackBonus *dto.ComebackBonus
+	if progress.HasComebackBonus(time.Now()) {
+		comebackBonus = &dto.ComebackBonus{
+			XPMultiplier: progress.ComebackMultiplier,
+			ExpiresAt:    *progress.ComebackUntil,
+		}
+	}
+
 	return &dto.UserProgressResponse{
 		UserID:             userID,
 		Hearts:             progress.Hearts,
@@ -421,7 +778,10 @@ func (svc *UserService) GetUserProgress(userID string) (*dto.UserProgressRespons
 			Name:     spirit.Name,
 			ImageURL: spirit.ImageURL,
 		},
-		Achievements: recentAchievements,
+		Achievements:  recentAchievements,
+		ComebackBonus: comebackBonus,
+
+		StreakProtectedDays: progress.StreakProtectedDays,
 	}, nil
 }
 
@@ -454,11 +814,35 @@ func (svc *UserService) getTotalXPForLevel(targetLevel int) int {
 // ==================== LESSON ACCESS AND COMPLETION ====================
 
 func (svc *UserService) CheckLessonAccess(userID, lessonID string) (*dto.LessonAccessResponse, error) {
-	progress, err := svc.sqlSvc.contentRepo.GetUserProgress(userID)
+	progress, err := svc.progressRepo.GetUserProgress(userID)
 	if err != nil {
 		return nil, err
 	}
 
+	lesson, err := svc.contentRepo.GetLesson(lessonID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Lesson not found")
+	}
+
+	if !lesson.IsActive {
+		return &dto.LessonAccessResponse{
+			CanAccess: false,
+			Reason:    "Lesson has been withdrawn for review",
+		}, nil
+	}
+
+	user, err := svc.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get user")
+	}
+
+	if !lesson.SuitableForAge(user.ContentAgeLimit(time.Now())) {
+		return &dto.LessonAccessResponse{
+			CanAccess: false,
+			Reason:    "Lesson is not available for your age",
+		}, nil
+	}
+
 	// Check hearts
 	if progress.Hearts <= 0 {
 		return &dto.LessonAccessResponse{
@@ -488,7 +872,7 @@ func (svc *UserService) checkCharacterUnlockForLesson(userID, lessonID string) s
 // ==================== HEARTS MANAGEMENT ====================
 
 func (svc *UserService) GetHeartStatus(userID string) (*dto.HeartStatusResponse, error) {
-	progress, err := svc.sqlSvc.contentRepo.GetUserProgress(userID)
+	progress, err := svc.progressRepo.GetUserProgress(userID)
 	if err != nil {
 		return nil, err
 	}
@@ -510,11 +894,6 @@ func (svc *UserService) GetHeartStatus(userID string) (*dto.HeartStatusResponse,
 }
 
 func (svc *UserService) AddHearts(userID, source string, amount int) (*dto.HeartStatusResponse, error) {
-	progress, err := svc.sqlSvc.contentRepo.GetUserProgress(userID)
-	if err != nil {
-		return nil, err
-	}
-
 	// Validate source and amount
 	switch source {
 	case "ad":
@@ -523,37 +902,44 @@ func (svc *UserService) AddHearts(userID, source string, amount int) (*dto.Heart
 		}
 		// TODO: Check ad watch limits
 	case "daily_reset":
-		progress.Hearts = progress.MaxHearts
-		now := time.Now()
-		progress.LastHeartReset = &now
 	case "purchase":
 		// TODO: Validate purchase
 	default:
 		return nil, fmt.Errorf("invalid heart source")
 	}
 
-	if source != "daily_reset" {
-		progress.Hearts = min(progress.Hearts+amount, progress.MaxHearts)
-	}
-
-	if err := svc.sqlSvc.contentRepo.UpdateUserProgress(progress); err != nil {
+	_, err := svc.progressRepo.ModifyUserProgress(userID, func(progress *model.UserProgress) (*model.ProgressChange, error) {
+		if source == "daily_reset" {
+			progress.Hearts = progress.MaxHearts
+			now := time.Now()
+			progress.LastHeartReset = &now
+		} else {
+			progress.Hearts = min(progress.Hearts+amount, progress.MaxHearts)
+		}
+		return &model.ProgressChange{}, nil
+	})
+	if err != nil {
 		return nil, err
 	}
+	svc.publishProgressChanged(userID, "hearts_"+source)
 
 	return svc.GetHeartStatus(userID)
 }
 
 func (svc *UserService) LoseHeart(userID string) (*dto.HeartStatusResponse, error) {
-	progress, err := svc.sqlSvc.contentRepo.GetUserProgress(userID)
+	lost := false
+	_, err := svc.progressRepo.ModifyUserProgress(userID, func(progress *model.UserProgress) (*model.ProgressChange, error) {
+		if lost = progress.Hearts > 0; !lost {
+			return nil, nil
+		}
+		progress.Hearts--
+		return &model.ProgressChange{}, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-
-	if progress.Hearts > 0 {
-		progress.Hearts--
-		if err := svc.sqlSvc.contentRepo.UpdateUserProgress(progress); err != nil {
-			return nil, err
-		}
+	if lost {
+		svc.publishProgressChanged(userID, "heart_lost")
 	}
 
 	return svc.GetHeartStatus(userID)
@@ -568,36 +954,67 @@ func min(a, b int) int {
 
 // ==================== COLLECTION METHODS ====================
 
-func (svc *UserService) GetUserCollection(userID string) (*dto.CollectionResponse, error) {
-	progress, err := svc.sqlSvc.contentRepo.GetUserProgress(userID)
+func (svc *UserService) GetUserCollection(userID string, query dto.CollectionQuery, markViewed bool) (*dto.CollectionResponse, error) {
+	progress, err := svc.progressRepo.GetUserProgress(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get user's unlocked characters in one query, keyed by character ID
+	userCharacters, err := svc.progressRepo.GetUserCharacters(userID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Get user's unlocked characters
-	var unlockedCharacterIDs []string
-	if err := json.Unmarshal([]byte(progress.UnlockedCharacters), &unlockedCharacterIDs); err != nil {
-		unlockedCharacterIDs = []string{}
+	unlockedByID := make(map[string]model.UserCharacter, len(userCharacters))
+	for _, uc := range userCharacters {
+		unlockedByID[uc.CharacterID] = uc
+	}
+
+	favoriteIDs, err := svc.progressRepo.GetFavoriteCharacterIDs(userID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Get all characters to show collection progress
-	allCharacters, err := svc.sqlSvc.contentRepo.GetCharactersByDynasty("") // Get all
+	allCharacters, err := svc.contentRepo.GetCharactersByDynasty("") // Get all
 	if err != nil {
 		return nil, err
 	}
 
-	// Map characters to responses with unlock status
-	characterResponses := make([]dto.CharacterResponse, len(allCharacters))
+	// Until the user has opened their collection once, anything from the last week counts as new
+	newSince := time.Now().Add(-7 * 24 * time.Hour)
+	if progress.CollectionViewedAt != nil {
+		newSince = *progress.CollectionViewedAt
+	}
+
+	// Map characters to responses with unlock status. Stats always cover the whole
+	// collection, filters only narrow the returned list.
+	characterResponses := make([]dto.CharacterResponse, 0, len(allCharacters))
 	unlockedCount := 0
 	rarityBreakdown := make(map[string]int)
 	dynastyBreakdown := make(map[string]int)
 
-	for i, char := range allCharacters {
-		isUnlocked := svc.isCharacterUnlocked(char.ID, unlockedCharacterIDs)
+	for _, char := range allCharacters {
+		userCharacter, isUnlocked := unlockedByID[char.ID]
+		isFavorite := slices.Contains(favoriteIDs, char.ID)
+
+		// Update breakdowns
+		rarityBreakdown[char.Rarity]++
+		dynastyBreakdown[char.Dynasty]++
+		if isUnlocked {
+			unlockedCount++
+		}
+
+		if (query.UnlockedOnly && !isUnlocked) || (query.FavoritesOnly && !isFavorite) ||
+			(query.Era != "" && char.Era != query.Era) {
+			continue
+		}
 
-		characterResponses[i] = dto.CharacterResponse{
+		response := dto.CharacterResponse{
 			ID:          char.ID,
 			Name:        char.Name,
+			Era:         char.Era,
 			Dynasty:     char.Dynasty,
 			Rarity:      char.Rarity,
 			BirthYear:   char.BirthYear,
@@ -606,19 +1023,30 @@ func (svc *UserService) GetUserCollection(userID string) (*dto.CollectionRespons
 			FamousQuote: char.FamousQuote,
 			ImageURL:    char.ImageURL,
 			IsUnlocked:  isUnlocked,
+			IsFavorite:  isFavorite,
 		}
 
 		if isUnlocked {
-			unlockedCount++
+			unlockedAt := userCharacter.UnlockedAt
+			response.UnlockedAt = &unlockedAt
+			response.IsNew = unlockedAt.After(newSince)
 		}
 
-		// Update breakdowns
-		rarityBreakdown[char.Rarity]++
-		dynastyBreakdown[char.Dynasty]++
+		characterResponses = append(characterResponses, response)
+	}
+
+	if err := svc.sortCollection(characterResponses, query.Sort, query.Order == "desc"); err != nil {
+		return nil, err
+	}
+
+	// Load progress first: rows seeded from history can unlock tiers listed below
+	achievementProgress, err := svc.achievementSvc.GetUserAchievementProgress(userID)
+	if err != nil {
+		return nil, err
 	}
 
 	// Get user achievements
-	userAchievements, err := svc.sqlSvc.contentRepo.GetUserAchievements(userID)
+	userAchievements, err := svc.progressRepo.GetUserAchievements(userID)
 	if err != nil {
 		return nil, err
 	}
@@ -632,12 +1060,20 @@ func (svc *UserService) GetUserCollection(userID string) (*dto.CollectionRespons
 			BadgeURL:    ua.Achievement.BadgeURL,
 			Category:    ua.Achievement.Category,
 			XPReward:    ua.Achievement.XPReward,
+			Tier:        ua.Tier,
 			UnlockedAt:  &ua.UnlockedAt,
 		}
 	}
 
 	completionRate := float64(unlockedCount) / float64(len(allCharacters)) * 100
 
+	// Characters shown now are no longer new on the next visit
+	if markViewed {
+		if err := svc.progressRepo.MarkCollectionViewed(userID, time.Now()); err != nil {
+			log.Printf("Failed to record collection view for user %s: %v", userID, err)
+		}
+	}
+
 	return &dto.CollectionResponse{
 		Characters: dto.CharacterCollectionResponse{
 			Characters: characterResponses,
@@ -652,121 +1088,402 @@ func (svc *UserService) GetUserCollection(userID string) (*dto.CollectionRespons
 			RarityBreakdown:    rarityBreakdown,
 			DynastyBreakdown:   dynastyBreakdown,
 		},
+		AchievementProgress: achievementProgress,
+		LastViewedAt:        progress.CollectionViewedAt,
 	}, nil
 }
 
-func (svc *UserService) isCharacterUnlocked(characterID string, unlockedIDs []string) bool {
-	for _, id := range unlockedIDs {
-		if id == characterID {
-			return true
+// sortCollection orders collection entries in place. Without an explicit sort the
+// repository order is kept. Locked characters always go last when sorting by unlock date.
+func (svc *UserService) sortCollection(characters []dto.CharacterResponse, sortBy string, desc bool) error {
+	if sortBy == "" {
+		return nil
+	}
+
+	// Dynasties are ordered by their position on the timeline, unknown ones go last
+	var dynastyOrder map[string]int
+	if sortBy == model.CollectionSortDynasty {
+		timelines, err := svc.contentRepo.GetTimeline()
+		if err != nil {
+			return shared.NewInternalError(err, "Failed to load timeline")
+		}
+
+		dynastyOrder = make(map[string]int, len(timelines))
+		for i, t := range timelines {
+			if _, ok := dynastyOrder[t.Dynasty]; !ok {
+				dynastyOrder[t.Dynasty] = i
+			}
+		}
+	}
+	dynastyRank := func(dynasty string) int {
+		if rank, ok := dynastyOrder[dynasty]; ok {
+			return rank
+		}
+		return len(dynastyOrder)
+	}
+
+	slices.SortStableFunc(characters, func(a, b dto.CharacterResponse) int {
+		var c int
+		switch sortBy {
+		case model.CollectionSortRarity:
+			c = cmp.Compare(model.CharacterRarityRank[a.Rarity], model.CharacterRarityRank[b.Rarity])
+		case model.CollectionSortDynasty:
+			c = cmp.Compare(dynastyRank(a.Dynasty), dynastyRank(b.Dynasty))
+			if c == 0 && a.BirthYear != nil && b.BirthYear != nil {
+				c = cmp.Compare(*a.BirthYear, *b.BirthYear)
+			}
+		case model.CollectionSortUnlockedAt:
+			switch {
+			case a.UnlockedAt == nil && b.UnlockedAt == nil:
+				c = 0
+			case a.UnlockedAt == nil:
+				return 1
+			case b.UnlockedAt == nil:
+				return -1
+			default:
+				c = a.UnlockedAt.Compare(*b.UnlockedAt)
+			}
+		}
+
+		if c == 0 {
+			c = strings.Compare(a.Name, b.Name)
 		}
+		if desc {
+			c = -c
+		}
+		return c
+	})
+
+	return nil
+}
+
+func (svc *UserService) FavoriteCharacter(userID, characterID string) error {
+	if _, err := svc.contentRepo.GetCharacter(characterID); err != nil {
+		return shared.NewNotFoundError(err, "Character not found")
+	}
+
+	if _, err := svc.progressRepo.CreateFavoriteCharacter(&model.UserFavoriteCharacter{
+		UserID:      userID,
+		CharacterID: characterID,
+	}); err != nil {
+		return shared.NewInternalError(err, "Failed to favorite character")
 	}
-	return false
+	return nil
+}
+
+func (svc *UserService) UnfavoriteCharacter(userID, characterID string) error {
+	removed, err := svc.progressRepo.DeleteFavoriteCharacter(userID, characterID)
+	if err != nil {
+		return shared.NewInternalError(err, "Failed to remove favorite")
+	}
+	if !removed {
+		return shared.NewNotFoundError(fmt.Errorf("favorite not found"), "Favorite not found")
+	}
+	return nil
+}
+
+// ==================== BOOKMARKS ====================
+
+func (svc *UserService) BookmarkLesson(userID, lessonID string) (*dto.LessonBookmarkResponse, error) {
+	lesson, err := svc.contentRepo.GetLesson(lessonID)
+	if err != nil || !lesson.IsActive {
+		return nil, shared.NewNotFoundError(err, "Lesson not found")
+	}
+
+	bookmark := &model.UserLessonBookmark{UserID: userID, LessonID: lessonID}
+	if _, err := svc.progressRepo.CreateLessonBookmark(bookmark); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to bookmark lesson")
+	}
+
+	completed, err := svc.progressRepo.HasCompletedLesson(userID, lessonID)
+	if err != nil {
+		log.Printf("Failed to check completion of lesson %s for user %s: %v", lessonID, userID, err)
+	}
+
+	return &dto.LessonBookmarkResponse{
+		LessonID:      lesson.ID,
+		Title:         lesson.Title,
+		CharacterID:   lesson.CharacterID,
+		CharacterName: lesson.Character.Name,
+		ThumbnailURL:  lesson.ThumbnailURL,
+		Completed:     completed,
+		BookmarkedAt:  bookmark.CreatedAt,
+	}, nil
+}
+
+func (svc *UserService) RemoveBookmark(userID, lessonID string) error {
+	removed, err := svc.progressRepo.DeleteLessonBookmark(userID, lessonID)
+	if err != nil {
+		return shared.NewInternalError(err, "Failed to remove bookmark")
+	}
+	if !removed {
+		return shared.NewNotFoundError(fmt.Errorf("bookmark not found"), "Bookmark not found")
+	}
+	return nil
+}
+
+func (svc *UserService) GetBookmarks(userID string, page, limit int) (*dto.LessonBookmarkListResponse, error) {
+	bookmarks, total, err := svc.progressRepo.GetUserLessonBookmarks(userID, page, limit)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get bookmarks")
+	}
+
+	completedIDs, err := svc.progressRepo.GetCompletedLessonIDs(userID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get completed lessons")
+	}
+
+	responses := make([]dto.LessonBookmarkResponse, len(bookmarks))
+	for i, b := range bookmarks {
+		responses[i] = dto.LessonBookmarkResponse{
+			LessonID:      b.LessonID,
+			Title:         b.Lesson.Title,
+			CharacterID:   b.Lesson.CharacterID,
+			CharacterName: b.Lesson.Character.Name,
+			ThumbnailURL:  b.Lesson.ThumbnailURL,
+			Completed:     slices.Contains(completedIDs, b.LessonID),
+			BookmarkedAt:  b.CreatedAt,
+		}
+	}
+
+	return &dto.LessonBookmarkListResponse{
+		Bookmarks: responses,
+		Total:     int(total),
+		Page:      page,
+		Limit:     limit,
+	}, nil
 }
 
 // ==================== LEADERBOARD METHODS ====================
 
-func (svc *UserService) GetWeeklyLeaderboard(limit int, currentUserID string) (*dto.LeaderboardResponse, error) {
-	users, err := svc.sqlSvc.contentRepo.GetWeeklyLeaderboard(limit)
+func (svc *UserService) GetWeeklyLeaderboard(leaderboardType string, limit int, currentUserID string) (*dto.LeaderboardResponse, error) {
+	since := time.Now().AddDate(0, 0, -7)
+	if leaderboardType != model.LeaderboardTypeXP {
+		return svc.getRankedLeaderboard("weekly", leaderboardType, since, limit, currentUserID)
+	}
+
+	users, err := svc.progressRepo.GetWeeklyLeaderboard(limit)
 	if err != nil {
 		return nil, err
 	}
 
-	return svc.buildLeaderboardResponse("weekly", users, currentUserID)
+	return svc.buildLeaderboardResponse("weekly", leaderboardType, since, xpLeaderboardEntries(users), currentUserID)
 }
 
-func (svc *UserService) GetMonthlyLeaderboard(limit int, currentUserID string) (*dto.LeaderboardResponse, error) {
-	users, err := svc.sqlSvc.contentRepo.GetMonthlyLeaderboard(limit)
+func (svc *UserService) GetMonthlyLeaderboard(leaderboardType string, limit int, currentUserID string) (*dto.LeaderboardResponse, error) {
+	since := time.Now().AddDate(0, -1, 0)
+	if leaderboardType != model.LeaderboardTypeXP {
+		return svc.getRankedLeaderboard("monthly", leaderboardType, since, limit, currentUserID)
+	}
+
+	users, err := svc.progressRepo.GetMonthlyLeaderboard(limit)
 	if err != nil {
 		return nil, err
 	}
 
-	return svc.buildLeaderboardResponse("monthly", users, currentUserID)
+	return svc.buildLeaderboardResponse("monthly", leaderboardType, since, xpLeaderboardEntries(users), currentUserID)
 }
 
-func (svc *UserService) GetAllTimeLeaderboard(limit int, currentUserID string) (*dto.LeaderboardResponse, error) {
-	users, err := svc.sqlSvc.contentRepo.GetAllTimeLeaderboard(limit)
+func (svc *UserService) GetAllTimeLeaderboard(leaderboardType string, limit int, currentUserID string) (*dto.LeaderboardResponse, error) {
+	if leaderboardType != model.LeaderboardTypeXP {
+		return svc.getRankedLeaderboard("all_time", leaderboardType, time.Time{}, limit, currentUserID)
+	}
+
+	users, err := svc.progressRepo.GetAllTimeLeaderboard(limit)
 	if err != nil {
 		return nil, err
 	}
 
-	return svc.buildLeaderboardResponse("all_time", users, currentUserID)
+	return svc.buildLeaderboardResponse("all_time", leaderboardType, time.Time{}, xpLeaderboardEntries(users), currentUserID)
 }
 
-func (svc *UserService) buildLeaderboardResponse(period string, users []model.UserProgress, currentUserID string) (*dto.LeaderboardResponse, error) {
-	topUsers := make([]dto.LeaderboardUserResponse, len(users))
-	var currentUser dto.LeaderboardUserResponse
+// getRankedLeaderboard ranks users active since the given time, or all users when it is
+// zero, by spirit stage or collection completion
+func (svc *UserService) getRankedLeaderboard(period, leaderboardType string, since time.Time, limit int, currentUserID string) (*dto.LeaderboardResponse, error) {
+	entries, err := svc.progressRepo.GetRankedLeaderboard(leaderboardType, since, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return svc.buildLeaderboardResponse(period, leaderboardType, since, entries, currentUserID)
+}
 
+func xpLeaderboardEntries(users []model.UserProgress) []model.LeaderboardEntry {
+	entries := make([]model.LeaderboardEntry, len(users))
 	for i, user := range users {
-		// Get user details
-		userDetails, err := svc.sqlSvc.userRepo.GetUser(user.UserID)
-		if err != nil {
-			log.Printf("Failed to get user details for %s: %v", user.UserID, err)
-			continue
-		}
+		entries[i] = model.LeaderboardEntry{UserProgress: user}
+	}
+	return entries
+}
 
-		// Get user's spirit
-		spirit, err := svc.sqlSvc.contentRepo.GetUserSpirit(user.UserID)
-		if err != nil {
-			log.Printf("Failed to get spirit for user %s: %v", user.UserID, err)
-			spirit = &model.Spirit{Type: "unknown", Stage: 1}
-		}
+// newLeaderboardUser builds a leaderboard row. Rows of spirit and collection
+// leaderboards show the entry's scores, XP rows the spirit stage of the projection.
+func newLeaderboardUser(leaderboardType string, entry model.LeaderboardEntry, profile model.LeaderboardProfile, rank int) dto.LeaderboardUserResponse {
+	user := dto.LeaderboardUserResponse{
+		UserID:      entry.UserID,
+		Username:    profile.Username,
+		Level:       entry.Level,
+		XP:          entry.XP,
+		Rank:        rank,
+		SpiritType:  profile.SpiritType,
+		SpiritStage: profile.SpiritStage,
+
+		Streak:              entry.Streak,
+		StreakProtectedDays: entry.StreakProtectedDays,
+	}
+	if leaderboardType != model.LeaderboardTypeXP {
+		user.SpiritStage = entry.SpiritStage
+		user.CollectedCharacters = entry.CollectedCharacters
+		user.CollectionPercent = entry.CollectionPercent
+	}
+	return user
+}
+
+func (svc *UserService) buildLeaderboardResponse(period, leaderboardType string, since time.Time, entries []model.LeaderboardEntry, currentUserID string) (*dto.LeaderboardResponse, error) {
+	userIDs := make([]string, 0, len(entries)+1)
+	for _, entry := range entries {
+		userIDs = append(userIDs, entry.UserID)
+	}
+	if currentUserID != "" && !slices.Contains(userIDs, currentUserID) {
+		userIDs = append(userIDs, currentUserID)
+	}
+	profiles := svc.getLeaderboardProfiles(userIDs)
 
-		leaderboardUser := dto.LeaderboardUserResponse{
-			UserID:      user.UserID,
-			Username:    userDetails.Username,
-			Level:       user.Level,
-			XP:          user.XP,
-			Rank:        i + 1,
-			SpiritType:  spirit.Type,
-			SpiritStage: spirit.Stage,
+	topUsers := make([]dto.LeaderboardUserResponse, 0, len(entries))
+	var currentUser dto.LeaderboardUserResponse
+
+	for i, entry := range entries {
+		profile, ok := profiles[entry.UserID]
+		if !ok {
+			log.Printf("No leaderboard profile for user %s", entry.UserID)
+			continue
 		}
 
-		topUsers[i] = leaderboardUser
+		leaderboardUser := newLeaderboardUser(leaderboardType, entry, profile, i+1)
 
-		if user.UserID == currentUserID {
+		if entry.UserID == currentUserID {
 			currentUser = leaderboardUser
+		} else if profile.Visibility == model.LeaderboardVisibilityAnonymous {
+			leaderboardUser.UserID = ""
+			leaderboardUser.Username = leaderboardAlias(entry.UserID)
+			leaderboardUser.Anonymous = true
 		}
+
+		topUsers = append(topUsers, leaderboardUser)
 	}
 
-	// If current user is not in top list, get their rank
-	if currentUserID != "" && currentUser.UserID == "" {
-		rank, err := svc.sqlSvc.contentRepo.GetUserRank(currentUserID)
-		if err == nil {
-			userProgress, err := svc.sqlSvc.contentRepo.GetUserProgress(currentUserID)
+	// If current user is not in top list, get their rank. Users who opted out
+	// of leaderboards are not ranked at all.
+	if profile, ok := profiles[currentUserID]; ok && currentUser.UserID == "" && profile.Visibility != model.LeaderboardVisibilityHidden {
+		if leaderboardType == model.LeaderboardTypeXP {
+			rank, err := svc.progressRepo.GetUserRank(currentUserID)
 			if err == nil {
-				userDetails, err := svc.sqlSvc.userRepo.GetUser(currentUserID)
+				userProgress, err := svc.progressRepo.GetUserProgress(currentUserID)
 				if err == nil {
-					spirit, err := svc.sqlSvc.contentRepo.GetUserSpirit(currentUserID)
-					if err != nil {
-						spirit = &model.Spirit{Type: "unknown", Stage: 1}
-					}
-
-					currentUser = dto.LeaderboardUserResponse{
-						UserID:      currentUserID,
-						Username:    userDetails.Username,
-						Level:       userProgress.Level,
-						XP:          userProgress.XP,
-						Rank:        rank,
-						SpiritType:  spirit.Type,
-						SpiritStage: spirit.Stage,
-					}
+					currentUser = newLeaderboardUser(leaderboardType, model.LeaderboardEntry{UserProgress: *userProgress}, profile, rank)
 				}
 			}
+		} else {
+			// Spirit and collection ranks count only users active in the same period
+			entry, rank, err := svc.progressRepo.GetLeaderboardEntry(leaderboardType, currentUserID, since)
+			if err == nil {
+				currentUser = newLeaderboardUser(leaderboardType, *entry, profile, rank)
+			} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+				log.Printf("Failed to rank user %s on the %s leaderboard: %v", currentUserID, leaderboardType, err)
+			}
 		}
 	}
 
 	return &dto.LeaderboardResponse{
 		Period:      period,
+		Type:        leaderboardType,
 		CurrentUser: currentUser,
 		TopUsers:    topUsers,
 	}, nil
 }
 
+// getLeaderboardProfiles reads the leaderboard projection of the given users. Users
+// without a projection row are hydrated from the users and spirits tables in one query
+// each, and their rows are saved for next time.
+func (svc *UserService) getLeaderboardProfiles(userIDs []string) map[string]model.LeaderboardProfile {
+	profiles := make(map[string]model.LeaderboardProfile, len(userIDs))
+
+	stored, err := svc.progressRepo.GetLeaderboardProfiles(userIDs)
+	if err != nil {
+		log.Printf("Failed to get leaderboard profiles: %v", err)
+	}
+	for _, profile := range stored {
+		profiles[profile.UserID] = profile
+	}
+
+	var missing []string
+	for _, userID := range userIDs {
+		if _, ok := profiles[userID]; !ok {
+			missing = append(missing, userID)
+		}
+	}
+	if len(missing) == 0 {
+		return profiles
+	}
+
+	users, err := svc.userRepo.GetUsersByIDs(missing)
+	if err != nil {
+		log.Printf("Failed to get users for leaderboard: %v", err)
+		return profiles
+	}
+	spirits, err := svc.progressRepo.GetSpiritsByUserIDs(missing)
+	if err != nil {
+		log.Printf("Failed to get spirits for leaderboard: %v", err)
+	}
+	spiritsByUser := make(map[string]model.Spirit, len(spirits))
+	for _, spirit := range spirits {
+		spiritsByUser[spirit.UserID] = spirit
+	}
+
+	hydrated := make([]model.LeaderboardProfile, 0, len(users))
+	for _, user := range users {
+		profile := model.LeaderboardProfile{
+			UserID:      user.ID,
+			Username:    user.Username,
+			Visibility:  user.LeaderboardVisibility,
+			SpiritType:  "unknown",
+			SpiritStage: 1,
+		}
+		if spirit, ok := spiritsByUser[user.ID]; ok {
+			profile.SpiritType = spirit.Type
+			profile.SpiritStage = spirit.Stage
+		}
+		profiles[user.ID] = profile
+		hydrated = append(hydrated, profile)
+	}
+
+	if err := svc.progressRepo.SaveLeaderboardProfiles(hydrated); err != nil {
+		log.Printf("Failed to save leaderboard profiles: %v", err)
+	}
+
+	return profiles
+}
+
+// refreshLeaderboardProfile updates the user's leaderboard projection after their
+// username, leaderboard visibility or spirit changed
+func (svc *UserService) refreshLeaderboardProfile(userID string) {
+	if err := svc.progressRepo.RefreshLeaderboardProfile(userID); err != nil {
+		log.Printf("Failed to refresh leaderboard profile for user %s: %v", userID, err)
+	}
+}
+
+// leaderboardAlias returns a stable display name for users ranked anonymously,
+// so the same player keeps the same alias across periods without revealing
+// their username or ID.
+func leaderboardAlias(userID string) string {
+	sum := sha256.Sum256([]byte("leaderboard:" + userID))
+	return "Player-" + strings.ToUpper(hex.EncodeToString(sum[:3]))
+}
+
 // ==================== SOCIAL FEATURES ====================
 
 func (svc *UserService) CreateShareContent(userID string, req dto.ShareRequest) (*dto.ShareResponse, error) {
-	progress, err := svc.sqlSvc.contentRepo.GetUserProgress(userID)
+	progress, err := svc.progressRepo.GetUserProgress(userID)
 	if err != nil {
 		return nil, err
 	}
@@ -792,6 +1509,15 @@ func (svc *UserService) CreateShareContent(userID string, req dto.ShareRequest)
 	// Generate share URL (could include referral tracking)
 	shareURL := fmt.Sprintf("https://ven.app/shared/%s/%s", req.Type, userID)
 
+	// Prefer a card rendered for this user; the static image is the fallback
+	if card, err := svc.buildShareCard(userID, req, progress.Level); err != nil {
+		log.Printf("Failed to build share card for user %s: %v", userID, err)
+	} else if cardURL, err := svc.shareCardURL(*card); err != nil {
+		log.Printf("Failed to render share card for user %s: %v", userID, err)
+	} else {
+		shareImage = cardURL
+	}
+
 	return &dto.ShareResponse{
 		ShareURL:   shareURL,
 		ShareImage: shareImage,
@@ -800,6 +1526,22 @@ func (svc *UserService) CreateShareContent(userID string, req dto.ShareRequest)
 	}, nil
 }
 
+func (svc *UserService) buildShareCard(userID string, req dto.ShareRequest, level int) (*shareCard, error) {
+	user, err := svc.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	card := &shareCard{Type: req.Type, Username: user.Username, Level: level}
+	if spirit, err := svc.progressRepo.GetUserSpirit(userID); err == nil {
+		card.SpiritArt = spirit.ImageURL
+	}
+	if character := svc.shareCardCharacter(userID, req.Type, req.ItemID); character != nil {
+		card.CharacterArt = character.ImageURL
+	}
+	return card, nil
+}
+
 // ==================== USERNAME VALIDATION ====================
 
 func (svc *UserService) CheckUsernameAvailability(username string) (bool, error) {
@@ -807,20 +1549,17 @@ func (svc *UserService) CheckUsernameAvailability(username string) (bool, error)
 		return false, fmt.Errorf("username cannot be empty")
 	}
 
-	if len(username) < 3 || len(username) > 20 {
-		return false, fmt.Errorf("username must be between 3 and 20 characters")
+	if !dto.ValidUsername(username) {
+		return false, fmt.Errorf("username must be 3 to 30 letters, numbers or underscores")
 	}
+	username = text.NFC(username)
 
-	// Check for valid characters (alphanumeric and underscores only)
-	for _, char := range username {
-		if !((char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') ||
-			(char >= '0' && char <= '9') || char == '_') {
-			return false, fmt.Errorf("username can only contain letters, numbers, and underscores")
-		}
+	if _, err := svc.moderationSvc.Moderate(model.ModerationContextUsername, username); err != nil {
+		return false, fmt.Errorf("username is not allowed")
 	}
 
 	// Check if username is already taken
-	_, err := svc.sqlSvc.userRepo.GetUserByUsername(username)
+	_, err := svc.userRepo.GetUserByUsername(username)
 	if err == nil {
 		return false, nil // Username exists
 	}
@@ -831,12 +1570,12 @@ func (svc *UserService) CheckUsernameAvailability(username string) (bool, error)
 // ==================== USER PROFILE METHODS ====================
 
 func (svc *UserService) GetUserProfile(userID string) (*dto.UserProfileResponse, error) {
-	user, err := svc.sqlSvc.userRepo.GetUserProfile(userID)
+	user, err := svc.userRepo.GetUserProfile(userID)
 	if err != nil {
 		return nil, shared.NewInternalError(err, "Failed to get user profile")
 	}
 
-	stats, err := svc.sqlSvc.userRepo.GetUserStats(userID)
+	stats, err := svc.userRepo.GetUserStats(userID)
 	if err != nil {
 		// Don't fail if stats can't be retrieved, just log it
 		log.WithError(err).WithField("userID", userID).Error("Failed to get user stats")
@@ -849,11 +1588,21 @@ func (svc *UserService) GetUserProfile(userID string) (*dto.UserProfileResponse,
 		Email:         user.Email,
 		Role:          user.Role,
 		EmailVerified: user.EmailVerified,
+		PendingEmail:  user.PendingEmail,
 		CreatedAt:     user.CreatedAt,
 		LastLoginAt:   user.LastLoginAt,
 		LastLoginIP:   user.LastLoginIP,
 		IsActive:      user.IsActive,
 		Stats:         *stats,
+
+		Timezone:      user.Timezone,
+		WeekendAmulet: user.WeekendAmulet,
+		Accessibility: dto.AccessibilityPreferences{
+			ReducedMotion:  user.ReducedMotion,
+			DyslexiaFont:   user.DyslexiaFont,
+			HighContrast:   user.HighContrast,
+			ColorBlindSafe: user.ColorBlindSafe,
+		},
 	}
 
 	return profile, nil
@@ -863,38 +1612,67 @@ func (svc *UserService) UpdateUserProfile(userID string, req dto.UpdateProfileRe
 	// Validate updates
 	updates := make(map[string]interface{})
 
+	var moderated *ModerationResult
 	if req.Username != "" {
+		result, err := svc.moderationSvc.Moderate(model.ModerationContextUsername, req.Username)
+		if err != nil {
+			return nil, err
+		}
+		moderated = result
+
 		// Check if username is available (excluding current user)
 		var existingUser model.User
-		err := svc.sqlSvc.Db().Where("LOWER(username) = LOWER(?) AND id != ? AND deleted_at IS NULL",
-			req.Username, userID).First(&existingUser).Error
+		err = svc.sqlSvc.Db().Where("LOWER(username) = LOWER(?) AND id != ? AND deleted_at IS NULL",
+			moderated.Text, userID).First(&existingUser).Error
 
 		if err == nil {
 			return nil, shared.NewBadRequestError(fmt.Errorf("username taken"), "Username is already taken")
 		}
 
-		updates["username"] = req.Username
+		updates["username"] = moderated.Text
 	}
 
-	if req.Email != "" {
-		// Check if email is available (excluding current user)
-		var existingUser model.User
-		err := svc.sqlSvc.Db().Where("LOWER(email) = LOWER(?) AND id != ? AND deleted_at IS NULL",
-			req.Email, userID).First(&existingUser).Error
-
-		if err == nil {
-			return nil, shared.NewBadRequestError(fmt.Errorf("email taken"), "Email is already taken")
+	if req.Timezone != nil {
+		if *req.Timezone != "" {
+			if _, err := time.LoadLocation(*req.Timezone); err != nil {
+				return nil, shared.NewBadRequestError(err, "Unknown timezone")
+			}
+		}
+		updates["timezone"] = *req.Timezone
+	}
+	if req.WeekendAmulet != nil {
+		updates["weekend_amulet"] = *req.WeekendAmulet
+	}
+	if a := req.Accessibility; a != nil {
+		for column, value := range map[string]*bool{
+			"reduced_motion":   a.ReducedMotion,
+			"dyslexia_font":    a.DyslexiaFont,
+			"high_contrast":    a.HighContrast,
+			"color_blind_safe": a.ColorBlindSafe,
+		} {
+			if value != nil {
+				updates[column] = *value
+			}
 		}
-
-		updates["email"] = req.Email
-		updates["email_verified"] = false // Reset verification if email changes
 	}
 
 	if len(updates) > 0 {
-		err := svc.sqlSvc.userRepo.UpdateUserProfile(userID, updates)
+		err := svc.userRepo.UpdateUserProfile(userID, updates)
 		if err != nil {
 			return nil, shared.NewInternalError(err, "Failed to update profile")
 		}
+		svc.moderationSvc.Flag(userID, moderated)
+		svc.refreshLeaderboardProfile(userID)
+		if req.Timezone != nil {
+			svc.notificationSvc.RescheduleStudyReminder(userID)
+		}
+	}
+
+	// Email changes only take effect once the new address is confirmed
+	if req.Email != "" {
+		if err := svc.authSvc.RequestEmailChange(userID, req.Email); err != nil {
+			return nil, err
+		}
 	}
 
 	// Return updated profile
@@ -902,7 +1680,7 @@ func (svc *UserService) UpdateUserProfile(userID string, req dto.UpdateProfileRe
 }
 
 func (svc *UserService) IsEmailAvailable(email string) (bool, error) {
-	available, err := svc.sqlSvc.userRepo.IsEmailAvailable(email)
+	available, err := svc.userRepo.IsEmailAvailable(email)
 	if err != nil {
 		return false, shared.NewInternalError(err, "Failed to check email availability")
 	}
@@ -912,7 +1690,7 @@ func (svc *UserService) IsEmailAvailable(email string) (bool, error) {
 // ==================== SESSION MANAGEMENT ====================
 
 func (svc *UserService) GetUserSessions(userID, currentSessionID string) (*dto.SessionListResponse, error) {
-	sessions, err := svc.sqlSvc.userRepo.GetUserSessions(userID)
+	sessions, err := svc.userRepo.GetUserSessions(userID)
 	if err != nil {
 		return nil, shared.NewInternalError(err, "Failed to get user sessions")
 	}
@@ -938,17 +1716,18 @@ func (svc *UserService) GetUserSessions(userID, currentSessionID string) (*dto.S
 }
 
 func (svc *UserService) RevokeUserSession(userID, sessionID string) error {
-	err := svc.sqlSvc.userRepo.DeactivateSession(sessionID, userID)
+	err := svc.userRepo.DeactivateSession(sessionID, userID)
 	if err != nil {
 		return shared.NewInternalError(err, "Failed to revoke session")
 	}
+	svc.authSvc.invalidateAuthState(userID)
 	return nil
 }
 
 // ==================== SECURITY SETTINGS ====================
 
 func (svc *UserService) GetSecuritySettings(userID string) (*dto.SecuritySettings, error) {
-	settings, err := svc.sqlSvc.userRepo.GetSecuritySettings(userID)
+	settings, err := svc.userRepo.GetSecuritySettings(userID)
 	if err != nil {
 		return nil, shared.NewInternalError(err, "Failed to get security settings")
 	}
@@ -964,19 +1743,82 @@ func (svc *UserService) UpdateSecuritySettings(userID string, req dto.UpdateSecu
 		}
 	}
 
-	err := svc.sqlSvc.userRepo.UpdateSecuritySettings(userID, req)
+	err := svc.userRepo.UpdateSecuritySettings(userID, req)
 	if err != nil {
 		return nil, shared.NewInternalError(err, "Failed to update security settings")
 	}
+	if req.LeaderboardVisibility != nil {
+		svc.refreshLeaderboardProfile(userID)
+	}
+
+	// Apply a shorter timeout to existing sessions right away instead of on their next request
+	if req.SessionTimeout != nil {
+		idleSince := time.Now().Add(-time.Duration(*req.SessionTimeout) * time.Minute)
+		if _, err := svc.userRepo.DeactivateIdleSessions(userID, idleSince); err != nil {
+			log.Printf("Failed to expire idle sessions for user %s: %v", userID, err)
+		}
+	}
+	svc.authSvc.invalidateAuthState(userID)
 
 	// Return updated settings
 	return svc.GetSecuritySettings(userID)
 }
 
+// ==================== PARENTAL CONTROLS ====================
+
+func (svc *UserService) GetParentalControls(userID string) (*dto.ParentalControlsResponse, error) {
+	user, err := svc.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "User not found")
+	}
+	return mapParentalControls(user), nil
+}
+
+// UpdateParentalControls sets the content rating limit chosen by a parent, replacing the
+// one derived from the user's age. The PIN is set on first use and required after that.
+func (svc *UserService) UpdateParentalControls(userID string, req dto.UpdateParentalControlsRequest) (*dto.ParentalControlsResponse, error) {
+	user, err := svc.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "User not found")
+	}
+
+	if user.ParentalPIN != "" {
+		if !svc.authSvc.checkPasswordHash(req.PIN, user.ParentalPIN) {
+			return nil, shared.NewForbiddenError(nil, "Incorrect parental PIN")
+		}
+	}
+
+	updates := map[string]interface{}{"parental_max_rating": req.MaxContentRating}
+	if req.MaxContentRating == "" {
+		updates["parental_pin"] = ""
+	} else if user.ParentalPIN == "" {
+		hash, err := svc.authSvc.hashPassword(req.PIN)
+		if err != nil {
+			return nil, shared.NewInternalError(err, "Failed to set parental PIN")
+		}
+		updates["parental_pin"] = hash
+	}
+
+	if err := svc.userRepo.UpdateUserProfile(userID, updates); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to update parental controls")
+	}
+
+	user.ParentalMaxRating = req.MaxContentRating
+	return mapParentalControls(user), nil
+}
+
+func mapParentalControls(user *model.User) *dto.ParentalControlsResponse {
+	return &dto.ParentalControlsResponse{
+		Enabled:          user.ParentalMaxRating != "",
+		MaxContentRating: user.ParentalMaxRating,
+		AllowedRatings:   allowedContentRatings(user.ContentAgeLimit(time.Now())),
+	}
+}
+
 // ==================== AUDIT LOGS ====================
 
 func (svc *UserService) GetUserAuditLogs(userID string, page, limit int) (*dto.AuditLogResponse, error) {
-	logs, total, err := svc.sqlSvc.userRepo.GetUserAuditLogs(userID, page, limit)
+	logs, total, err := svc.userRepo.GetUserAuditLogs(userID, page, limit)
 	if err != nil {
 		return nil, shared.NewInternalError(err, "Failed to get audit logs")
 	}
@@ -1005,12 +1847,61 @@ func (svc *UserService) GetUserAuditLogs(userID string, page, limit int) (*dto.A
 
 // ==================== ADMIN USER MANAGEMENT ====================
 
+// GetXPLedger returns a user's XP history, newest first, for investigating disputes
+func (svc *UserService) GetXPLedger(userID string, page, limit int) (*dto.XPLedgerResponse, error) {
+	progress, err := svc.progressRepo.GetUserProgress(userID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "User progress not found")
+	}
+
+	txns, total, err := svc.progressRepo.GetXPTransactions(userID, page, limit)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get XP transactions")
+	}
+
+	ledgerXP, err := svc.progressRepo.SumXPTransactions(userID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get XP transactions")
+	}
+
+	responses := make([]dto.XPTransactionResponse, len(txns))
+	for i, txn := range txns {
+		responses[i] = dto.XPTransactionResponse{
+			ID:           txn.ID,
+			Source:       txn.Source,
+			Amount:       txn.Amount,
+			BalanceAfter: txn.BalanceAfter,
+			ReferenceID:  txn.ReferenceID,
+			ReasonCode:   txn.ReasonCode,
+			ActorID:      txn.ActorID,
+			Note:         txn.Note,
+			CreatedAt:    txn.CreatedAt,
+		}
+	}
+
+	return &dto.XPLedgerResponse{
+		UserID:       userID,
+		XP:           progress.XP,
+		LedgerXP:     ledgerXP,
+		Transactions: responses,
+		Total:        int(total),
+		Page:         page,
+		Limit:        limit,
+	}, nil
+}
+
 func (svc *UserService) AdminGetUsers(page, limit int, search string) (*dto.AdminUserListResponse, error) {
-	users, total, err := svc.sqlSvc.userRepo.AdminGetUsers(page, limit, search)
+	users, total, err := svc.userRepo.AdminGetUsers(page, limit, search)
 	if err != nil {
 		return nil, shared.NewInternalError(err, "Failed to get users")
 	}
 
+	userIDs := make([]string, len(users))
+	for i, user := range users {
+		userIDs[i] = user.ID
+	}
+	noteCounts := svc.userNoteCounts(userIDs...)
+
 	userInfos := make([]dto.AdminUserInfo, len(users))
 	for i, user := range users {
 		userInfos[i] = dto.AdminUserInfo{
@@ -1024,6 +1915,9 @@ func (svc *UserService) AdminGetUsers(page, limit int, search string) (*dto.Admi
 			LastLoginAt:    user.LastLoginAt,
 			FailedAttempts: user.FailedAttempts,
 			LockedUntil:    user.LockedUntil,
+			TenantID:       user.TenantID,
+
+			NoteCount: noteCounts[user.ID],
 		}
 	}
 
@@ -1058,15 +1952,20 @@ func (svc *UserService) AdminUpdateUser(userID string, req dto.AdminUpdateUserRe
 		updates["is_active"] = *req.IsActive
 	}
 
+	if req.TenantID != nil {
+		updates["tenant_id"] = strings.TrimSpace(*req.TenantID)
+	}
+
 	if len(updates) > 0 {
-		err := svc.sqlSvc.userRepo.AdminUpdateUser(userID, updates)
+		err := svc.userRepo.AdminUpdateUser(userID, updates)
 		if err != nil {
 			return nil, shared.NewInternalError(err, "Failed to update user")
 		}
+		svc.authSvc.invalidateAuthState(userID)
 	}
 
 	// Get updated user info
-	user, err := svc.sqlSvc.userRepo.GetUserByID(userID)
+	user, err := svc.userRepo.GetUserByID(userID)
 	if err != nil {
 		return nil, shared.NewInternalError(err, "Failed to get updated user")
 	}
@@ -1082,21 +1981,25 @@ func (svc *UserService) AdminUpdateUser(userID string, req dto.AdminUpdateUserRe
 		LastLoginAt:    user.LastLoginAt,
 		FailedAttempts: user.FailedAttempts,
 		LockedUntil:    user.LockedUntil,
+		TenantID:       user.TenantID,
+
+		NoteCount: svc.userNoteCounts(user.ID)[user.ID],
 	}, nil
 }
 
 func (svc *UserService) AdminDeleteUser(userID string) error {
-	err := svc.sqlSvc.userRepo.AdminDeleteUser(userID)
+	err := svc.userRepo.AdminDeleteUser(userID)
 	if err != nil {
 		return shared.NewInternalError(err, "Failed to delete user")
 	}
+	svc.authSvc.invalidateAuthState(userID)
 	return nil
 }
 
 // ==================== UTILITY METHODS ====================
 
 func (svc *UserService) GetUserInfo(userID string) (*dto.UserInfo, error) {
-	user, err := svc.sqlSvc.userRepo.GetUserByID(userID)
+	user, err := svc.userRepo.GetUserByID(userID)
 	if err != nil {
 		return nil, shared.NewInternalError(err, "Failed to get user info")
 	}
@@ -1113,7 +2016,7 @@ func (svc *UserService) GetUserInfo(userID string) (*dto.UserInfo, error) {
 }
 
 func (svc *UserService) ValidateUser(userID string) (*model.User, error) {
-	user, err := svc.sqlSvc.userRepo.GetUserByID(userID)
+	user, err := svc.userRepo.GetUserByID(userID)
 	if err != nil {
 		return nil, shared.NewUnauthorizedError(err, "User not found")
 	}
@@ -1187,6 +2090,9 @@ func (svc *UserService) BulkUpdateUsers(userIDs []string, updates map[string]int
 	if err != nil {
 		return shared.NewInternalError(err, "Failed to bulk update users")
 	}
+	for _, userID := range userIDs {
+		svc.authSvc.invalidateAuthState(userID)
+	}
 
 	return nil
 }
@@ -1265,3 +2171,188 @@ func (svc *UserService) maskPhone(phone string) string {
 	}
 	return phone[:2] + strings.Repeat("*", len(phone)-4) + phone[len(phone)-2:]
 }
+
+// ==================== GAME CONFIG METHODS ====================
+
+func (svc *UserService) GetGameConfig() (*model.GameConfig, error) {
+	config, err := svc.contentRepo.GetGameConfig()
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to load game config")
+	}
+	return config, nil
+}
+
+func (svc *UserService) UpdateGameConfig(adminID string, req dto.UpdateGameConfigRequest) (*model.GameConfig, error) {
+	config, err := svc.contentRepo.GetGameConfig()
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to load game config")
+	}
+
+	if req.ComebackInactiveDays != nil {
+		config.ComebackInactiveDays = *req.ComebackInactiveDays
+	}
+	if req.ComebackXPMultiplier != nil {
+		config.ComebackXPMultiplier = *req.ComebackXPMultiplier
+	}
+	if req.ComebackDurationHours != nil {
+		config.ComebackDurationHours = *req.ComebackDurationHours
+	}
+	if req.ComebackRestoresHearts != nil {
+		config.ComebackRestoresHearts = *req.ComebackRestoresHearts
+	}
+	if req.SpeedCheckMode != nil {
+		config.SpeedCheckMode = *req.SpeedCheckMode
+	}
+	if req.MinVideoWatchRatio != nil {
+		config.MinVideoWatchRatio = *req.MinVideoWatchRatio
+	}
+	if req.MinSecondsPerQuestion != nil {
+		config.MinSecondsPerQuestion = *req.MinSecondsPerQuestion
+	}
+	if req.BurstWindowMinutes != nil {
+		config.BurstWindowMinutes = *req.BurstWindowMinutes
+	}
+	if req.BurstMaxCompletions != nil {
+		config.BurstMaxCompletions = *req.BurstMaxCompletions
+	}
+	if req.KnowledgeWeight != nil {
+		config.KnowledgeWeight = *req.KnowledgeWeight
+	}
+	if req.ComprehensionWeight != nil {
+		config.ComprehensionWeight = *req.ComprehensionWeight
+	}
+	if req.BonusMinWatchPercent != nil {
+		config.BonusMinWatchPercent = *req.BonusMinWatchPercent
+	}
+	if req.AnomalyMaxDailyXP != nil {
+		config.AnomalyMaxDailyXP = *req.AnomalyMaxDailyXP
+	}
+	if req.AnomalyMaxHourlyXP != nil {
+		config.AnomalyMaxHourlyXP = *req.AnomalyMaxHourlyXP
+	}
+	if req.CoinsPerLesson != nil {
+		config.CoinsPerLesson = *req.CoinsPerLesson
+	}
+	if req.CoinsPerAchievementTier != nil {
+		config.CoinsPerAchievementTier = *req.CoinsPerAchievementTier
+	}
+	if req.FiftyFiftyPerLesson != nil {
+		config.FiftyFiftyPerLesson = *req.FiftyFiftyPerLesson
+	}
+	if req.FiftyFiftyCoinPrice != nil {
+		config.FiftyFiftyCoinPrice = *req.FiftyFiftyCoinPrice
+	}
+	if req.QuestionReportThreshold != nil {
+		config.QuestionReportThreshold = *req.QuestionReportThreshold
+	}
+	config.UpdatedBy = adminID
+
+	if err := svc.contentRepo.UpdateGameConfig(config); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to update game config")
+	}
+
+	return config, nil
+}
+
+// ==================== ONBOARDING METHODS ====================
+
+// AdvanceOnboarding moves the user past every onboarding step they have satisfied. Services
+// call it after events that can complete a step; failures are logged, not returned.
+func (svc *UserService) AdvanceOnboarding(userID string) {
+	if _, err := svc.advanceOnboarding(userID); err != nil {
+		log.Printf("Failed to advance onboarding for user %s: %v", userID, err)
+	}
+}
+
+func (svc *UserService) advanceOnboarding(userID string) (*model.User, error) {
+	user, err := svc.userRepo.GetUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for user.OnboardingStep != model.OnboardingStepCompleted {
+		done, err := svc.isOnboardingStepDone(user)
+		if err != nil {
+			return nil, err
+		}
+		if !done {
+			break
+		}
+
+		next := nextOnboardingStep(user.OnboardingStep)
+		advanced, err := svc.userRepo.AdvanceOnboarding(user.ID, user.OnboardingStep, next)
+		if err != nil {
+			return nil, err
+		}
+		if !advanced {
+			// Another request moved the user on first
+			return svc.userRepo.GetUser(userID)
+		}
+
+		user.OnboardingStep = next
+		if next == model.OnboardingStepCompleted {
+			now := time.Now()
+			user.OnboardingCompletedAt = &now
+			svc.notificationSvc.Notify(userID, model.NotificationTypeAchievement, "You're all set!",
+				"You finished getting started. Keep learning to unlock more characters", nil)
+		}
+	}
+
+	return user, nil
+}
+
+func (svc *UserService) isOnboardingStepDone(user *model.User) (bool, error) {
+	switch user.OnboardingStep {
+	case model.OnboardingStepVerifyEmail:
+		return user.EmailVerified, nil
+	case model.OnboardingStepSetBirthYear:
+		return user.BirthYear > 0, nil
+	case model.OnboardingStepFirstLesson:
+		count, err := svc.progressRepo.CountCompletedLessons(user.ID)
+		return count > 0, err
+	case model.OnboardingStepFirstCharacter:
+		count, err := svc.progressRepo.CountUnlockedCharacters(user.ID)
+		return count > 0, err
+	}
+
+	// Unknown step: restart from the beginning, completed steps are skipped again
+	return true, nil
+}
+
+// nextOnboardingStep returns the step after step, or the first step if step is unknown
+func nextOnboardingStep(step string) string {
+	for i, s := range model.OnboardingSteps {
+		if s == step {
+			if i+1 < len(model.OnboardingSteps) {
+				return model.OnboardingSteps[i+1]
+			}
+			return model.OnboardingStepCompleted
+		}
+	}
+	return model.OnboardingSteps[0]
+}
+
+func (svc *UserService) GetOnboardingState(userID string) (*dto.OnboardingResponse, error) {
+	user, err := svc.advanceOnboarding(userID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to load onboarding state")
+	}
+
+	completed := user.OnboardingStep == model.OnboardingStepCompleted
+	current := slices.Index(model.OnboardingSteps, user.OnboardingStep)
+
+	steps := make([]dto.OnboardingStepStatus, len(model.OnboardingSteps))
+	for i, step := range model.OnboardingSteps {
+		steps[i] = dto.OnboardingStepStatus{
+			Step:      step,
+			Completed: completed || i < current,
+		}
+	}
+
+	return &dto.OnboardingResponse{
+		CurrentStep: user.OnboardingStep,
+		Steps:       steps,
+		Completed:   completed,
+		CompletedAt: user.OnboardingCompletedAt,
+	}, nil
+}
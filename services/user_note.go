@@ -0,0 +1,158 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ==================== USER NOTE METHODS ====================
+
+func (svc *UserService) GetUserNotes(userID, tag string, page, limit int) (*dto.UserNoteListResponse, error) {
+	notes, total, err := svc.userRepo.GetUserNotes(userID, normalizeNoteTag(tag), page, limit)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get user notes")
+	}
+
+	responses := make([]dto.UserNoteResponse, len(notes))
+	for i := range notes {
+		responses[i] = mapUserNote(&notes[i])
+	}
+
+	return &dto.UserNoteListResponse{
+		Notes: responses,
+		Total: int(total),
+		Page:  page,
+		Limit: limit,
+	}, nil
+}
+
+func (svc *UserService) CreateUserNote(authorID, userID string, req dto.UserNoteRequest) (*dto.UserNoteResponse, error) {
+	if _, err := svc.userRepo.GetUserByID(userID); err != nil {
+		return nil, shared.NewNotFoundError(err, "User not found")
+	}
+
+	note := &model.UserNote{
+		UserID:   userID,
+		AuthorID: authorID,
+		Note:     strings.TrimSpace(req.Note),
+		Tags:     joinNoteTags(req.Tags),
+		TicketID: strings.TrimSpace(req.TicketID),
+	}
+	if err := svc.userRepo.CreateUserNote(note); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to create user note")
+	}
+
+	log.Printf("Support note %s added to user %s by %s", note.ID, userID, authorID)
+	return svc.getUserNote(userID, note.ID)
+}
+
+// UpdateUserNote replaces a note's text, tags and ticket. Moderators can only edit their
+// own notes, admins can edit any.
+func (svc *UserService) UpdateUserNote(actor *model.User, userID, noteID string, req dto.UserNoteRequest) (*dto.UserNoteResponse, error) {
+	note, err := svc.editableUserNote(actor, userID, noteID)
+	if err != nil {
+		return nil, err
+	}
+
+	note.Note = strings.TrimSpace(req.Note)
+	note.Tags = joinNoteTags(req.Tags)
+	note.TicketID = strings.TrimSpace(req.TicketID)
+	if err := svc.userRepo.UpdateUserNote(note); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to update user note")
+	}
+
+	return svc.getUserNote(userID, noteID)
+}
+
+// DeleteUserNote removes a note. Moderators can only delete their own notes.
+func (svc *UserService) DeleteUserNote(actor *model.User, userID, noteID string) error {
+	if _, err := svc.editableUserNote(actor, userID, noteID); err != nil {
+		return err
+	}
+
+	if err := svc.userRepo.DeleteUserNote(noteID); err != nil {
+		return shared.NewInternalError(err, "Failed to delete user note")
+	}
+
+	log.Printf("Support note %s of user %s deleted by %s", noteID, userID, actor.ID)
+	return nil
+}
+
+func (svc *UserService) getUserNote(userID, noteID string) (*dto.UserNoteResponse, error) {
+	note, err := svc.userRepo.GetUserNote(userID, noteID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get user note")
+	}
+
+	response := mapUserNote(note)
+	return &response, nil
+}
+
+func (svc *UserService) editableUserNote(actor *model.User, userID, noteID string) (*model.UserNote, error) {
+	note, err := svc.userRepo.GetUserNote(userID, noteID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, shared.NewNotFoundError(err, "User note not found")
+	}
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get user note")
+	}
+
+	if actor.Role != model.RoleAdmin && note.AuthorID != actor.ID {
+		return nil, shared.NewForbiddenError(errors.New("not the note's author"), "Only the author or an admin can change this note")
+	}
+	return note, nil
+}
+
+// userNoteCounts returns the number of support notes per user. On failure the counts
+// are left at zero, they are not worth failing the user list for.
+func (svc *UserService) userNoteCounts(userIDs ...string) map[string]int {
+	counts, err := svc.userRepo.CountUserNotes(userIDs)
+	if err != nil {
+		log.WithError(err).Warn("Failed to count user notes")
+		return map[string]int{}
+	}
+	return counts
+}
+
+func mapUserNote(note *model.UserNote) dto.UserNoteResponse {
+	tags := []string{}
+	if note.Tags != "" {
+		tags = strings.Split(note.Tags, ",")
+	}
+
+	return dto.UserNoteResponse{
+		ID:             note.ID,
+		UserID:         note.UserID,
+		AuthorID:       note.AuthorID,
+		AuthorUsername: note.Author.Username,
+		Note:           note.Note,
+		Tags:           tags,
+		TicketID:       note.TicketID,
+		CreatedAt:      note.CreatedAt,
+		UpdatedAt:      note.UpdatedAt,
+	}
+}
+
+// joinNoteTags normalizes tags and stores them comma separated, dropping duplicates
+func joinNoteTags(tags []string) string {
+	var normalized []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = normalizeNoteTag(tag)
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	return strings.Join(normalized, ",")
+}
+
+func normalizeNoteTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
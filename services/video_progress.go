@@ -0,0 +1,191 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	// Seconds of playback credited beyond the time between two heartbeats, covering
+	// network delay and the first heartbeat of a play
+	videoHeartbeatSlack = 5
+
+	defaultVideoAnalyticsDays = 30
+	maxVideoAnalyticsDays     = 365
+)
+
+// ==================== VIDEO PROGRESS METHODS ====================
+
+// RecordVideoProgress stores a heartbeat of the lesson video. A play counts as watched
+// only as far as the time between heartbeats allows, so seeking to the end doesn't
+// earn the watch bonus. Skipping is allowed once the lesson's CanSkipAfter seconds
+// were watched. The position is also the lesson session's resume point.
+func (svc *ContentService) RecordVideoProgress(userID, lessonID string, req dto.VideoProgressRequest) (*dto.VideoProgressResponse, error) {
+	lesson, err := svc.contentRepo.GetLesson(lessonID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Lesson not found")
+	}
+	duration := svc.lessonVideoDuration(lessonID)
+	if duration == 0 {
+		return nil, shared.NewBadRequestError(nil, "Lesson has no video")
+	}
+
+	progress, err := svc.progressRepo.GetLessonVideoProgress(userID, lessonID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		progress = &model.LessonVideoProgress{UserID: userID, LessonID: lessonID}
+		svc.recordPopularity(model.PopularityEntityLesson, lessonID, popularityMetricVideoPlays)
+	} else if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get video progress")
+	}
+	progress.DurationSeconds = duration
+
+	now := time.Now()
+	position := req.PositionSeconds
+	if req.Event == model.VideoEventEnded {
+		position = duration
+	}
+	advanceVideoProgress(progress, position, now)
+
+	if req.Event == model.VideoEventSkip && !progress.Skipped {
+		if progress.WatchedSeconds < lesson.CanSkipAfter {
+			return nil, shared.NewBadRequestError(nil, "Video can't be skipped yet")
+		}
+		progress.Skipped = true
+		progress.SkippedAtSeconds = progress.PositionSeconds
+		svc.recordPopularity(model.PopularityEntityLesson, lessonID, popularityMetricVideoSkips)
+	}
+
+	if err := svc.progressRepo.SaveLessonVideoProgress(progress); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to save video progress")
+	}
+
+	session, err := svc.touchLessonSession(userID, lessonID)
+	if err != nil {
+		return nil, err
+	}
+	session.VideoPositionSeconds = progress.PositionSeconds
+	if err := svc.progressRepo.SaveLessonSession(session); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to save lesson session")
+	}
+
+	minPercent := model.DefaultGameConfig().BonusMinWatchPercent
+	if config, err := svc.contentRepo.GetGameConfig(); err == nil {
+		minPercent = config.BonusMinWatchPercent
+	}
+
+	return &dto.VideoProgressResponse{
+		LessonID:             lessonID,
+		DurationSeconds:      progress.DurationSeconds,
+		PositionSeconds:      progress.PositionSeconds,
+		WatchedSeconds:       progress.WatchedSeconds,
+		WatchPercent:         progress.WatchPercent,
+		Skipped:              progress.Skipped,
+		BonusEligible:        progress.WatchPercent >= minPercent,
+		BonusMinWatchPercent: minPercent,
+	}, nil
+}
+
+// GetLessonVideoAnalytics reports plays and skips of a lesson video per day and how much
+// of it viewers watch
+func (svc *ContentService) GetLessonVideoAnalytics(lessonID string, days int) (*dto.LessonVideoAnalyticsResponse, error) {
+	lesson, err := svc.contentRepo.GetLesson(lessonID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Lesson not found")
+	}
+	if days <= 0 {
+		days = defaultVideoAnalyticsDays
+	}
+	days = min(days, maxVideoAnalyticsDays)
+
+	since := streakDay(time.Now(), time.UTC).AddDate(0, 0, -(days - 1))
+	stats, err := svc.contentRepo.GetContentPopularityDays(model.PopularityEntityLesson, lessonID, since)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get video analytics")
+	}
+	watch, err := svc.contentRepo.GetLessonVideoWatchStats(lessonID)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get video analytics")
+	}
+
+	response := &dto.LessonVideoAnalyticsResponse{
+		LessonID:            lessonID,
+		DurationSeconds:     svc.lessonVideoDuration(lessonID),
+		CanSkipAfter:        lesson.CanSkipAfter,
+		Days:                days,
+		Viewers:             watch.Viewers,
+		AverageWatchPercent: math.Round(watch.AverageWatchPercent*10) / 10,
+		AverageSkipSecond:   math.Round(watch.AverageSkipSecond*10) / 10,
+		Daily:               []dto.VideoAnalyticsDay{},
+	}
+	for _, stat := range stats {
+		if stat.VideoPlays == 0 && stat.VideoSkips == 0 {
+			continue
+		}
+		response.Plays += stat.VideoPlays
+		response.Skips += stat.VideoSkips
+		response.Daily = append(response.Daily, dto.VideoAnalyticsDay{
+			Day:   stat.Day.Format("2006-01-02"),
+			Plays: stat.VideoPlays,
+			Skips: stat.VideoSkips,
+		})
+	}
+	if response.Plays > 0 {
+		response.SkipRate = math.Round(float64(response.Skips)/float64(response.Plays)*1000) / 1000
+	}
+
+	return response, nil
+}
+
+// advanceVideoProgress moves the video to position. Playing forward counts as watched up
+// to the seconds passed since the last heartbeat, seeking back counts nothing.
+func advanceVideoProgress(progress *model.LessonVideoProgress, position int, now time.Time) {
+	position = min(max(position, 0), progress.DurationSeconds)
+
+	allowed := videoHeartbeatSlack
+	if !progress.LastHeartbeatAt.IsZero() {
+		allowed += int(now.Sub(progress.LastHeartbeatAt).Seconds())
+	}
+	if played := position - progress.PositionSeconds; played > 0 {
+		progress.WatchedSeconds = min(progress.WatchedSeconds+min(played, allowed), progress.DurationSeconds)
+	}
+
+	progress.PositionSeconds = position
+	progress.LastHeartbeatAt = now
+	if progress.DurationSeconds > 0 {
+		progress.WatchPercent = progress.WatchedSeconds * 100 / progress.DurationSeconds
+	}
+}
+
+// watchedEnoughForBonus reports whether the user watched enough of the lesson video to
+// earn the score bonus XP, and how much they watched. Lessons without a video, and
+// failures to check, don't hold the bonus back.
+func (svc *UserService) watchedEnoughForBonus(userID, lessonID string) (bool, int) {
+	config, err := svc.contentRepo.GetGameConfig()
+	if err != nil {
+		log.Printf("Failed to load game config: %v", err)
+		return true, 0
+	}
+	if config.BonusMinWatchPercent == 0 {
+		return true, 0
+	}
+	if _, err := svc.sqlSvc.mediaRepo.GetLessonMediaByType(lessonID, "animation"); err != nil {
+		return true, 0
+	}
+
+	progress, err := svc.progressRepo.GetLessonVideoProgress(userID, lessonID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, 0
+	}
+	if err != nil {
+		log.Printf("Failed to get video progress of user %s for lesson %s: %v", userID, lessonID, err)
+		return true, 0
+	}
+	return progress.WatchPercent >= config.BonusMinWatchPercent, progress.WatchPercent
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/model"
+)
+
+func TestAdvanceVideoProgress(t *testing.T) {
+	start := time.Now()
+	progress := &model.LessonVideoProgress{DurationSeconds: 100}
+
+	advanceVideoProgress(progress, 4, start)
+	if progress.WatchedSeconds != 4 {
+		t.Fatalf("watched %d seconds after the first heartbeat, want 4", progress.WatchedSeconds)
+	}
+
+	// Ten seconds later the video can't be more than ten seconds (plus slack) further
+	advanceVideoProgress(progress, 90, start.Add(10*time.Second))
+	if want := 4 + 10 + videoHeartbeatSlack; progress.WatchedSeconds != want {
+		t.Errorf("watched %d seconds after seeking ahead, want %d", progress.WatchedSeconds, want)
+	}
+	if progress.PositionSeconds != 90 {
+		t.Errorf("position = %d, want 90", progress.PositionSeconds)
+	}
+
+	watched := progress.WatchedSeconds
+	advanceVideoProgress(progress, 30, start.Add(20*time.Second))
+	if progress.WatchedSeconds != watched {
+		t.Errorf("seeking back added %d watched seconds", progress.WatchedSeconds-watched)
+	}
+
+	advanceVideoProgress(progress, 500, start.Add(time.Hour))
+	if progress.PositionSeconds != 100 || progress.WatchedSeconds != 100-30+watched || progress.WatchPercent != progress.WatchedSeconds {
+		t.Errorf("progress = %+v, want the position clamped to the video", progress)
+	}
+}
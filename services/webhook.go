@@ -0,0 +1,455 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloakd/common/context"
+	serviceContext "github.com/cloakd/common/services"
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/services/repositories"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	"github.com/lac-hong-legacy/ven_api/shared/ids"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	webhookPollInterval = 5 * time.Second
+	webhookBatchSize    = 50
+	// How long a claimed delivery is hidden from other workers while it is being sent
+	webhookLease = 2 * time.Minute
+
+	// Retries back off from webhookBaseBackoff, doubling each time, over webhookMaxAttempts
+	webhookMaxAttempts = 8
+	webhookBaseBackoff = 30 * time.Second
+	webhookMaxBackoff  = 6 * time.Hour
+)
+
+// WebhookService pushes progress events to external systems such as a school's LMS.
+// Events are queued as deliveries and sent by a background worker that signs each
+// request and retries failures with exponential backoff. A learner's events only go to
+// the endpoints of the learner's tenant.
+type WebhookService struct {
+	serviceContext.DefaultService
+
+	sqlSvc      *PostgresService
+	eventBusSvc *EventBusService
+	httpClient  *http.Client
+
+	// Set from sqlSvc in Start
+	userRepo repositories.UserRepo
+}
+
+// Events about a learner, delivered to endpoints of the learner's tenant. Every other
+// event is a platform event for endpoints without a tenant.
+var webhookLearnerEvents = []string{model.WebhookEventLessonCompleted, model.WebhookEventCharacterUnlocked, model.WebhookEventLevelUp}
+
+const WEBHOOK_SVC = "webhook_svc"
+
+func (svc WebhookService) Id() string {
+	return WEBHOOK_SVC
+}
+
+func (svc *WebhookService) Configure(ctx *context.Context) error {
+	svc.httpClient = &http.Client{
+		Timeout: 10 * time.Second,
+	}
+	return svc.DefaultService.Configure(ctx)
+}
+
+func (svc *WebhookService) Start() error {
+	deps := newDependencies(svc.Id(), svc.Service)
+	svc.sqlSvc = resolve[*PostgresService](deps, POSTGRES_SVC)
+	svc.eventBusSvc = resolve[*EventBusService](deps, EVENT_BUS_SVC)
+	if err := deps.err(); err != nil {
+		return err
+	}
+	svc.userRepo = svc.sqlSvc.userRepo
+
+	// Webhook event names match the domain event names, and the event is the payload data
+	for _, event := range webhookLearnerEvents {
+		svc.eventBusSvc.Subscribe(event, WEBHOOK_SVC, svc.publishLearnerEvent)
+	}
+
+	go svc.startDeliveryWorker()
+
+	return nil
+}
+
+// Publish queues a platform event for every active endpoint without a tenant subscribed
+// to it. Failures are logged so they never break the caller.
+func (svc *WebhookService) Publish(event string, data interface{}) {
+	svc.queue(event, "", data)
+}
+
+// publishLearnerEvent queues a learner's event for the endpoints of the learner's tenant.
+// Learners outside any tenant are not sent anywhere.
+func (svc *WebhookService) publishLearnerEvent(e DomainEvent) {
+	var userID string
+	switch event := e.(type) {
+	case *LessonCompletedEvent:
+		userID = event.UserID
+	case *LevelUpEvent:
+		userID = event.UserID
+	case *CharacterUnlockedEvent:
+		userID = event.UserID
+	default:
+		return
+	}
+
+	user, err := svc.userRepo.GetUserByID(userID)
+	if err != nil {
+		log.WithError(err).WithField("user_id", userID).Error("Failed to load user for webhook event")
+		return
+	}
+	if user.TenantID == "" {
+		return
+	}
+	svc.queue(e.EventName(), user.TenantID, e)
+}
+
+func (svc *WebhookService) queue(event, tenantID string, data interface{}) {
+	endpoints, err := svc.sqlSvc.webhookRepo.GetActiveEndpointsForEvent(event, tenantID)
+	if err != nil {
+		log.WithError(err).WithField("event", event).Error("Failed to load webhook endpoints")
+		return
+	}
+	if len(endpoints) == 0 {
+		return
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"id":         ids.New(),
+		"event":      event,
+		"created_at": time.Now().UTC(),
+		"data":       data,
+	})
+	if err != nil {
+		log.WithError(err).WithField("event", event).Error("Failed to marshal webhook payload")
+		return
+	}
+
+	now := time.Now()
+	deliveries := make([]model.WebhookDelivery, len(endpoints))
+	for i, endpoint := range endpoints {
+		deliveries[i] = model.WebhookDelivery{
+			EndpointID:    endpoint.ID,
+			Event:         event,
+			Payload:       payload,
+			Status:        model.WebhookDeliveryPending,
+			NextAttemptAt: now,
+		}
+	}
+	if err := svc.sqlSvc.webhookRepo.CreateDeliveries(deliveries); err != nil {
+		log.WithError(err).WithField("event", event).Error("Failed to queue webhook deliveries")
+	}
+}
+
+func (svc *WebhookService) startDeliveryWorker() {
+	ticker := time.NewTicker(webhookPollInterval)
+	for range ticker.C {
+		deliveries, err := svc.sqlSvc.webhookRepo.ClaimDueDeliveries(webhookBatchSize, webhookLease)
+		if err != nil {
+			log.WithError(err).Error("Failed to claim webhook deliveries")
+			continue
+		}
+
+		for i := range deliveries {
+			svc.deliver(&deliveries[i])
+		}
+	}
+}
+
+func (svc *WebhookService) deliver(delivery *model.WebhookDelivery) {
+	endpoint, err := svc.sqlSvc.webhookRepo.GetEndpoint(delivery.EndpointID)
+	if err != nil || !endpoint.IsActive {
+		delivery.Status = model.WebhookDeliveryFailed
+		delivery.LastError = "endpoint deleted or disabled"
+		svc.saveDelivery(delivery)
+		return
+	}
+
+	delivery.Attempts++
+	statusCode, err := svc.send(endpoint, delivery)
+	delivery.LastStatusCode = statusCode
+
+	if err == nil {
+		now := time.Now()
+		delivery.Status = model.WebhookDeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+		svc.saveDelivery(delivery)
+		return
+	}
+
+	delivery.LastError = truncate(err.Error(), 500)
+	if delivery.Attempts >= webhookMaxAttempts {
+		delivery.Status = model.WebhookDeliveryFailed
+		log.WithFields(log.Fields{
+			"delivery_id": delivery.ID,
+			"endpoint_id": endpoint.ID,
+			"event":       delivery.Event,
+		}).Warn("Giving up on webhook delivery")
+	} else {
+		delivery.NextAttemptAt = time.Now().Add(webhookBackoff(delivery.Attempts))
+	}
+	svc.saveDelivery(delivery)
+}
+
+// send posts the payload, signed as HMAC-SHA256 over "<timestamp>.<body>" with the
+// endpoint's secret so receivers can check authenticity and reject replays
+func (svc *WebhookService) send(endpoint *model.WebhookEndpoint, delivery *model.WebhookDelivery) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, endpoint.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Ven-Webhooks/1.0")
+	req.Header.Set("X-Ven-Event", delivery.Event)
+	req.Header.Set("X-Ven-Delivery", delivery.ID)
+	req.Header.Set("X-Ven-Timestamp", timestamp)
+	req.Header.Set("X-Ven-Signature", "sha256="+signWebhook(endpoint.Secret, timestamp, delivery.Payload))
+
+	resp, err := svc.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint returned status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func (svc *WebhookService) saveDelivery(delivery *model.WebhookDelivery) {
+	if err := svc.sqlSvc.webhookRepo.UpdateDelivery(delivery); err != nil {
+		log.WithError(err).WithField("delivery_id", delivery.ID).Error("Failed to update webhook delivery")
+	}
+}
+
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func webhookBackoff(attempts int) time.Duration {
+	backoff := webhookBaseBackoff << (attempts - 1)
+	if backoff <= 0 || backoff > webhookMaxBackoff {
+		return webhookMaxBackoff
+	}
+	return backoff
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// ==================== ADMIN METHODS ====================
+
+func (svc *WebhookService) CreateWebhook(adminID string, req dto.CreateWebhookRequest) (*dto.WebhookResponse, error) {
+	tenantID := strings.TrimSpace(req.TenantID)
+	if err := checkWebhookEvents(tenantID, req.Events); err != nil {
+		return nil, err
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to generate webhook secret")
+	}
+
+	endpoint := &model.WebhookEndpoint{
+		TenantID:  tenantID,
+		Name:      req.Name,
+		URL:       req.URL,
+		Secret:    secret,
+		Events:    joinWebhookEvents(req.Events),
+		IsActive:  true,
+		CreatedBy: adminID,
+	}
+	if err := svc.sqlSvc.webhookRepo.CreateEndpoint(endpoint); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to create webhook")
+	}
+
+	resp := toWebhookResponse(endpoint)
+	resp.Secret = secret
+	return resp, nil
+}
+
+func (svc *WebhookService) GetWebhooks() ([]dto.WebhookResponse, error) {
+	endpoints, err := svc.sqlSvc.webhookRepo.GetEndpoints()
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get webhooks")
+	}
+
+	responses := make([]dto.WebhookResponse, len(endpoints))
+	for i := range endpoints {
+		responses[i] = *toWebhookResponse(&endpoints[i])
+	}
+	return responses, nil
+}
+
+func (svc *WebhookService) UpdateWebhook(webhookID string, req dto.UpdateWebhookRequest) (*dto.WebhookResponse, error) {
+	endpoint, err := svc.sqlSvc.webhookRepo.GetEndpoint(webhookID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Webhook not found")
+	}
+
+	if req.Name != nil {
+		endpoint.Name = *req.Name
+	}
+	if req.URL != nil {
+		endpoint.URL = *req.URL
+	}
+	if req.Events != nil {
+		if err := checkWebhookEvents(endpoint.TenantID, req.Events); err != nil {
+			return nil, err
+		}
+		endpoint.Events = joinWebhookEvents(req.Events)
+	}
+	if req.IsActive != nil {
+		endpoint.IsActive = *req.IsActive
+	}
+
+	if err := svc.sqlSvc.webhookRepo.UpdateEndpoint(endpoint); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to update webhook")
+	}
+	return toWebhookResponse(endpoint), nil
+}
+
+// RotateWebhookSecret replaces the signing secret. Deliveries sent afterwards, including
+// retries, are signed with the new one.
+func (svc *WebhookService) RotateWebhookSecret(webhookID string) (*dto.WebhookResponse, error) {
+	endpoint, err := svc.sqlSvc.webhookRepo.GetEndpoint(webhookID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Webhook not found")
+	}
+
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to generate webhook secret")
+	}
+	endpoint.Secret = secret
+	if err := svc.sqlSvc.webhookRepo.UpdateEndpoint(endpoint); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to update webhook")
+	}
+
+	resp := toWebhookResponse(endpoint)
+	resp.Secret = secret
+	return resp, nil
+}
+
+func (svc *WebhookService) DeleteWebhook(webhookID string) error {
+	deleted, err := svc.sqlSvc.webhookRepo.DeleteEndpoint(webhookID)
+	if err != nil {
+		return shared.NewInternalError(err, "Failed to delete webhook")
+	}
+	if !deleted {
+		return shared.NewNotFoundError(errors.New("webhook not found"), "Webhook not found")
+	}
+	return nil
+}
+
+func (svc *WebhookService) GetWebhookDeliveries(webhookID, status string, page, limit int) (*dto.WebhookDeliveryListResponse, error) {
+	if _, err := svc.sqlSvc.webhookRepo.GetEndpoint(webhookID); err != nil {
+		return nil, shared.NewNotFoundError(err, "Webhook not found")
+	}
+
+	deliveries, total, err := svc.sqlSvc.webhookRepo.GetDeliveries(webhookID, status, page, limit)
+	if err != nil {
+		return nil, shared.NewInternalError(err, "Failed to get webhook deliveries")
+	}
+
+	return &dto.WebhookDeliveryListResponse{
+		Deliveries: deliveries,
+		Total:      int(total),
+		Page:       page,
+		Limit:      limit,
+	}, nil
+}
+
+// RedeliverWebhook queues a delivery to be sent again straight away with a fresh set of retries
+func (svc *WebhookService) RedeliverWebhook(webhookID, deliveryID string) (*model.WebhookDelivery, error) {
+	delivery, err := svc.sqlSvc.webhookRepo.GetDelivery(webhookID, deliveryID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Delivery not found")
+	}
+
+	delivery.Status = model.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now()
+	delivery.LastError = ""
+	if err := svc.sqlSvc.webhookRepo.UpdateDelivery(delivery); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to queue redelivery")
+	}
+	return delivery, nil
+}
+
+// checkWebhookEvents keeps learner events to endpoints of a tenant and platform events
+// to endpoints without one
+func checkWebhookEvents(tenantID string, events []string) error {
+	for _, event := range events {
+		learnerEvent := slices.Contains(webhookLearnerEvents, event)
+		if learnerEvent && tenantID == "" {
+			return shared.NewBadRequestError(nil, "A tenant is required to receive "+event+" events")
+		}
+		if !learnerEvent && tenantID != "" {
+			return shared.NewBadRequestError(nil, event+" events are only sent to webhooks without a tenant")
+		}
+	}
+	return nil
+}
+
+func joinWebhookEvents(events []string) string {
+	events = slices.Clone(events)
+	slices.Sort(events)
+	return strings.Join(slices.Compact(events), ",")
+}
+
+func toWebhookResponse(endpoint *model.WebhookEndpoint) *dto.WebhookResponse {
+	events := []string{}
+	if endpoint.Events != "" {
+		events = strings.Split(endpoint.Events, ",")
+	}
+	return &dto.WebhookResponse{
+		ID:        endpoint.ID,
+		TenantID:  endpoint.TenantID,
+		Name:      endpoint.Name,
+		URL:       endpoint.URL,
+		Events:    events,
+		IsActive:  endpoint.IsActive,
+		CreatedBy: endpoint.CreatedBy,
+		CreatedAt: endpoint.CreatedAt,
+		UpdatedAt: endpoint.UpdatedAt,
+	}
+}
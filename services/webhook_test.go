@@ -0,0 +1,50 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/services/repositories/mocks"
+	"github.com/lac-hong-legacy/ven_api/shared"
+)
+
+// Learner events need a tenant so they can't reach another school's endpoint, and
+// platform events stay with endpoints without one
+func TestCreateWebhookTenantScope(t *testing.T) {
+	tests := []struct {
+		name     string
+		tenantID string
+		events   []string
+	}{
+		{"learner event without tenant", "", []string{model.WebhookEventLessonCompleted}},
+		{"platform event with tenant", "lac-hong-hs", []string{model.WebhookEventMediaProcessingFailed}},
+		{"mixed events with tenant", "lac-hong-hs", []string{model.WebhookEventLevelUp, model.WebhookEventMediaProcessingFailed}},
+	}
+
+	svc := &WebhookService{}
+	for _, tt := range tests {
+		_, err := svc.CreateWebhook("admin_1", dto.CreateWebhookRequest{
+			Name:     "LMS",
+			URL:      "https://lms.example.edu/hooks/ven",
+			TenantID: tt.tenantID,
+			Events:   tt.events,
+		})
+		if appErr, ok := shared.GetAppError(err); !ok || appErr.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: got %v, want a bad request", tt.name, err)
+		}
+	}
+}
+
+// Events of a learner outside any tenant are dropped before any endpoint is looked up
+func TestPublishLearnerEventWithoutTenant(t *testing.T) {
+	svc := &WebhookService{userRepo: &mocks.UserRepo{
+		GetUserByIDFunc: func(userID string) (*model.User, error) {
+			return &model.User{ID: userID}, nil
+		},
+	}}
+
+	// sqlSvc is nil, so reaching the endpoint lookup would panic
+	svc.publishLearnerEvent(&LessonCompletedEvent{UserID: "user_1", LessonID: "lesson_1"})
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"slices"
+	"time"
+
+	"github.com/lac-hong-legacy/ven_api/dto"
+	"github.com/lac-hong-legacy/ven_api/model"
+	"github.com/lac-hong-legacy/ven_api/shared"
+	"github.com/lac-hong-legacy/ven_api/shared/lunar"
+	log "github.com/sirupsen/logrus"
+)
+
+// Spirit types by lunar year, starting from a rat year
+var zodiacAnimals = []string{
+	"rat", "ox", "tiger", "rabbit", "dragon", "snake",
+	"horse", "goat", "monkey", "rooster", "dog", "pig",
+}
+
+// zodiacAnimal is the animal of a lunar year. Year 4 was a rat year; years before it
+// count backwards instead of going negative.
+func zodiacAnimal(lunarYear int) string {
+	return zodiacAnimals[((lunarYear-4)%12+12)%12]
+}
+
+// zodiacLunarYear is the lunar year of a birth. Without the date the birth year is taken
+// as is, which is a year too late for births before Tết.
+func zodiacLunarYear(birthYear int, birthDate *time.Time) int {
+	if birthDate != nil {
+		return lunar.Year(*birthDate)
+	}
+	return birthYear
+}
+
+// zodiacCandidates are the animals a birth year can have: that of the lunar year ending
+// at Tết and that of the one starting then
+func zodiacCandidates(birthYear int) []string {
+	return []string{zodiacAnimal(birthYear - 1), zodiacAnimal(birthYear)}
+}
+
+// parseBirthDate reads an optional YYYY-MM-DD birth date, which has to be in birthYear
+func parseBirthDate(value string, birthYear int) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	birthDate, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, shared.NewBadRequestError(err, "Invalid birth date")
+	}
+	if birthDate.Year() != birthYear {
+		return nil, shared.NewBadRequestError(nil, "Birth date must be in the birth year")
+	}
+	return &birthDate, nil
+}
+
+// ==================== ZODIAC METHODS ====================
+
+// GetZodiac shows the zodiac of the user's spirit, whether it may be wrong for lack of a
+// birth date and whether it can still be corrected
+func (svc *UserService) GetZodiac(userID string) (*dto.ZodiacResponse, error) {
+	user, err := svc.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "User not found")
+	}
+	spirit, err := svc.progressRepo.GetUserSpirit(userID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Spirit not found")
+	}
+
+	return zodiacResponse(user, spirit), nil
+}
+
+// CorrectZodiac fixes the user's zodiac once, from the exact birth date or by choosing
+// between the two animals of the birth year. The spirit changes animal and keeps its
+// stage, XP and name. A correction that doesn't change the animal only stores the date
+// and can be repeated.
+func (svc *UserService) CorrectZodiac(userID string, req dto.CorrectZodiacRequest) (*dto.ZodiacResponse, error) {
+	user, err := svc.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "User not found")
+	}
+	if user.BirthYear <= 0 {
+		return nil, shared.NewBadRequestError(nil, "Set your birth year first")
+	}
+	if user.ZodiacCorrectedAt != nil {
+		return nil, shared.NewConflictError(nil, "Zodiac can only be corrected once")
+	}
+
+	spirit, err := svc.progressRepo.GetUserSpirit(userID)
+	if err != nil {
+		return nil, shared.NewNotFoundError(err, "Spirit not found")
+	}
+
+	updates := map[string]interface{}{}
+	var animal string
+	switch {
+	case req.BirthDate != "":
+		birthDate, err := parseBirthDate(req.BirthDate, user.BirthYear)
+		if err != nil {
+			return nil, err
+		}
+		animal = zodiacAnimal(lunar.Year(*birthDate))
+		if req.Animal != "" && req.Animal != animal {
+			return nil, shared.NewBadRequestError(nil, "Animal doesn't match the birth date")
+		}
+		updates["birth_date"] = *birthDate
+		user.BirthDate = birthDate
+	case req.Animal != "":
+		if !slices.Contains(zodiacCandidates(user.BirthYear), req.Animal) {
+			return nil, shared.NewBadRequestError(nil, "Animal doesn't match the birth year")
+		}
+		if user.BirthDate != nil && req.Animal != zodiacAnimal(lunar.Year(*user.BirthDate)) {
+			return nil, shared.NewBadRequestError(nil, "Animal doesn't match the birth date")
+		}
+		animal = req.Animal
+	default:
+		return nil, shared.NewBadRequestError(nil, "Birth date or animal is required")
+	}
+
+	if animal == spirit.Type {
+		if len(updates) > 0 {
+			if err := svc.userRepo.UpdateUserProfile(userID, updates); err != nil {
+				return nil, shared.NewInternalError(err, "Failed to save birth date")
+			}
+		}
+		return zodiacResponse(user, spirit), nil
+	}
+
+	now := time.Now()
+	updates["zodiac_corrected_at"] = now
+	previous := spirit.Type
+	spirit.Type = animal
+	spirit.ImageURL = svc.getSpiritImageURL(animal, spirit.Stage)
+	if err := svc.progressRepo.MigrateSpirit(spirit, updates); err != nil {
+		return nil, shared.NewInternalError(err, "Failed to correct zodiac")
+	}
+	user.ZodiacCorrectedAt = &now
+	log.Printf("User %s corrected their zodiac from %s to %s", userID, previous, animal)
+
+	svc.refreshLeaderboardProfile(userID)
+	svc.publishProgressChanged(userID, "spirit_type")
+
+	return zodiacResponse(user, spirit), nil
+}
+
+func zodiacResponse(user *model.User, spirit *model.Spirit) *dto.ZodiacResponse {
+	response := &dto.ZodiacResponse{
+		Animal:      spirit.Type,
+		LunarYear:   user.BirthYear,
+		BirthYear:   user.BirthYear,
+		CanCorrect:  user.BirthYear > 0 && user.ZodiacCorrectedAt == nil,
+		CorrectedAt: user.ZodiacCorrectedAt,
+		Spirit: dto.SpiritResponse{
+			ID:       spirit.ID,
+			Type:     spirit.Type,
+			Stage:    spirit.Stage,
+			XP:       spirit.XP,
+			XPToNext: spirit.XPToNext,
+			Name:     spirit.Name,
+			ImageURL: spirit.ImageURL,
+		},
+	}
+
+	switch {
+	case user.BirthDate != nil:
+		response.BirthDate = user.BirthDate.Format("2006-01-02")
+		response.LunarYear = lunar.Year(*user.BirthDate)
+	case user.BirthYear > 0:
+		if spirit.Type == zodiacAnimal(user.BirthYear-1) {
+			response.LunarYear = user.BirthYear - 1
+		}
+		if user.ZodiacCorrectedAt == nil {
+			response.Uncertain = true
+			response.Candidates = zodiacCandidates(user.BirthYear)
+		}
+	}
+	return response
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestZodiacAnimal(t *testing.T) {
+	tests := map[int]string{
+		2008: "rat",
+		2024: "dragon",
+		2025: "snake",
+		4:    "rat",
+		3:    "pig",
+		-1:   "goat",
+	}
+	for year, want := range tests {
+		if got := zodiacAnimal(year); got != want {
+			t.Errorf("zodiacAnimal(%d) = %s, want %s", year, got, want)
+		}
+	}
+}
+
+func TestZodiacLunarYear(t *testing.T) {
+	date := func(value string) *time.Time {
+		parsed, _ := time.Parse("2006-01-02", value)
+		return &parsed
+	}
+
+	tests := []struct {
+		birthYear int
+		birthDate *time.Time
+		want      string
+	}{
+		{2008, nil, "rat"},
+		// Tết 2008 was on February 7
+		{2008, date("2008-02-06"), "pig"},
+		{2008, date("2008-02-07"), "rat"},
+		{2025, date("2025-01-28"), "dragon"},
+		{2025, date("2025-01-29"), "snake"},
+	}
+	for _, tt := range tests {
+		if got := zodiacAnimal(zodiacLunarYear(tt.birthYear, tt.birthDate)); got != tt.want {
+			t.Errorf("zodiac of %d %v = %s, want %s", tt.birthYear, tt.birthDate, got, tt.want)
+		}
+	}
+}
@@ -100,6 +100,18 @@ func NewInternalError(err error, message string) *AppError {
 	}
 }
 
+func NewConflictError(err error, message string) *AppError {
+	if message == "" {
+		message = "Conflict"
+	}
+	return &AppError{
+		Err:        err,
+		StatusCode: http.StatusConflict,
+		Message:    message,
+		Code:       "CONFLICT",
+	}
+}
+
 func NewTooManyRequestsError(err error, message string) *AppError {
 	if message == "" {
 		message = "Too Many Requests"
@@ -112,6 +124,42 @@ func NewTooManyRequestsError(err error, message string) *AppError {
 	}
 }
 
+func NewPayloadTooLargeError(err error, message string) *AppError {
+	if message == "" {
+		message = "Request Entity Too Large"
+	}
+	return &AppError{
+		Err:        err,
+		StatusCode: http.StatusRequestEntityTooLarge,
+		Message:    message,
+		Code:       "PAYLOAD_TOO_LARGE",
+	}
+}
+
+func NewUpgradeRequiredError(err error, message string) *AppError {
+	if message == "" {
+		message = "Upgrade Required"
+	}
+	return &AppError{
+		Err:        err,
+		StatusCode: http.StatusUpgradeRequired,
+		Message:    message,
+		Code:       "UPGRADE_REQUIRED",
+	}
+}
+
+func NewGoneError(err error, message string) *AppError {
+	if message == "" {
+		message = "Gone"
+	}
+	return &AppError{
+		Err:        err,
+		StatusCode: http.StatusGone,
+		Message:    message,
+		Code:       "GONE",
+	}
+}
+
 func (e *AppError) WithData(data interface{}) *AppError {
 	e.Data = data
 	return e
@@ -1,7 +1,18 @@
 package shared
 
 const (
-	UserID = "user_id"
+	UserID     = "user_id"
+	APIVersion = "api_version"
+	ClientIP   = "client_ip"
+
+	AuthModeHeader     = "X-Auth-Mode"
+	AuthModeCookie     = "cookie"
+	CSRFTokenHeader    = "X-CSRF-Token"
+	AppPlatformHeader  = "X-App-Platform"
+	AppVersionHeader   = "X-App-Version"
+	AccessTokenCookie  = "access_token"
+	RefreshTokenCookie = "refresh_token"
+	CSRFTokenCookie    = "csrf_token"
 
 	RarityCommon    = "common"
 	RarityRare      = "rare"
@@ -25,12 +36,13 @@ const (
 	SessionCacheTTL   = 7200
 	RateLimitCacheTTL = 60
 
-	MediaTypeVideo           = "video"
-	MediaTypeSubtitle        = "subtitle"
-	MediaTypeThumbnail       = "thumbnail"
-	MediaTypeAudio           = "audio"
-	MediaTypeBackgroundMusic = "background_music"
-	MediaTypeVoiceOver       = "voice_over"
-	MediaTypeAnimation       = "animation"
-	MediaTypeIllustration    = "illustration"
+	MediaTypeVideo            = "video"
+	MediaTypeSubtitle         = "subtitle"
+	MediaTypeThumbnail        = "thumbnail"
+	MediaTypeAudio            = "audio"
+	MediaTypeBackgroundMusic  = "background_music"
+	MediaTypeVoiceOver        = "voice_over"
+	MediaTypeAnimation        = "animation"
+	MediaTypeIllustration     = "illustration"
+	MediaTypeAudioDescription = "audio_description"
 )
@@ -0,0 +1,82 @@
+// Package ids generates and checks record IDs. New IDs are UUIDv7s, which sort by
+// creation time and so index well. Users, lessons and characters also carry a type
+// prefix (usr_, lsn_, chr_) so an ID in a log line or support ticket says what it is.
+//
+// Existing rows keep the IDs they were created with: rewriting primary keys would mean
+// rewriting every table that references them, and the IDs are already in tokens, links
+// and exports held outside the database. Older IDs therefore stay valid: bare UUIDs for
+// every kind, the seeded slugs of lessons and characters (lesson_hung_vuong_1,
+// char_le_loi) and the default admin's admin-<timestamp>. Clients must treat IDs as
+// opaque strings of up to 50 characters. Once no legacy IDs are left of a kind, its
+// entry in legacyPatterns can be removed.
+package ids
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Prefix names the kind of record an ID belongs to
+type Prefix string
+
+const (
+	None      Prefix = ""
+	User      Prefix = "usr"
+	Lesson    Prefix = "lsn"
+	Character Prefix = "chr"
+)
+
+// IDs created before prefixes, other than bare UUIDs
+var legacyPatterns = map[Prefix]*regexp.Regexp{
+	User:      regexp.MustCompile(`^admin-\d{14}$`),
+	Lesson:    regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`),
+	Character: regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`),
+}
+
+// New returns an unprefixed ID, for records nobody refers to by hand
+func New() string {
+	id, err := uuid.NewV7()
+	if err != nil {
+		// Only fails when the system random source does
+		return uuid.NewString()
+	}
+	return id.String()
+}
+
+// NewPrefixed returns an ID for a record of the given kind, e.g. usr_0192…
+func NewPrefixed(prefix Prefix) string {
+	if prefix == None {
+		return New()
+	}
+	return string(prefix) + "_" + New()
+}
+
+// Valid reports whether id can be an ID of the given kind: a new ID with its prefix, or
+// an ID from before prefixes were introduced
+func Valid(prefix Prefix, id string) bool {
+	if isUUID(id) {
+		return true
+	}
+	if prefix == None {
+		return false
+	}
+	if rest, ok := strings.CutPrefix(id, string(prefix)+"_"); ok && isUUID(rest) {
+		return true
+	}
+	if legacy := legacyPatterns[prefix]; legacy != nil {
+		return legacy.MatchString(id)
+	}
+	return false
+}
+
+// isUUID only accepts the canonical 36 character form; uuid.Parse also takes braces and
+// urn:uuid: prefixes
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	_, err := uuid.Parse(s)
+	return err == nil
+}
@@ -0,0 +1,48 @@
+package ids
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	id := NewPrefixed(User)
+	if !strings.HasPrefix(id, "usr_") || len(id) != 40 {
+		t.Errorf("NewPrefixed(User) = %q", id)
+	}
+	if !Valid(User, id) {
+		t.Errorf("Valid(User, %q) = false", id)
+	}
+	if Valid(Lesson, id) {
+		t.Errorf("Valid(Lesson, %q) = true for a user ID", id)
+	}
+	if id := New(); !Valid(None, id) || len(id) != 36 {
+		t.Errorf("New() = %q", id)
+	}
+}
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		prefix Prefix
+		id     string
+		want   bool
+	}{
+		{User, "0192f1c4-6a8e-7b3c-9d2e-3f4a5b6c7d8e", true},
+		{User, "usr_0192f1c4-6a8e-7b3c-9d2e-3f4a5b6c7d8e", true},
+		{User, "admin-20250101120000", true},
+		{User, "usr_123456789", false},
+		{User, "{0192f1c4-6a8e-7b3c-9d2e-3f4a5b6c7d8e}", false},
+		{User, "", false},
+		{Lesson, "lesson_hung_vuong_1", true},
+		{Lesson, "lsn_0192f1c4-6a8e-7b3c-9d2e-3f4a5b6c7d8e", true},
+		{Lesson, "../etc/passwd", false},
+		{Lesson, strings.Repeat("a", 51), false},
+		{Character, "char_le_loi", true},
+		{None, "char_le_loi", false},
+	}
+	for _, tt := range tests {
+		if got := Valid(tt.prefix, tt.id); got != tt.want {
+			t.Errorf("Valid(%q, %q) = %v, want %v", tt.prefix, tt.id, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package ids
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Param rejects requests whose path parameter name is not an ID of the given kind with
+// 400, before the handler looks it up
+func Param(name string, prefix Prefix) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !Valid(prefix, c.Params(name)) {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Invalid %s", name))
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,133 @@
+// Package lunar finds Tết, the first day of the Vietnamese lunar year, so a birth date can
+// be placed in its lunar year. New moons and solar terms are computed for Vietnam's time
+// zone (UTC+7) after Hồ Ngọc Đức's calendar algorithm, which is why a few years differ
+// from the Chinese calendar.
+package lunar
+
+import (
+	"math"
+	"time"
+)
+
+// Vietnam's offset from UTC in hours, which decides the day a new moon falls on
+const timeZone = 7.0
+
+// Julian day number of 1970-01-01
+const unixEpochJD = 2440588
+
+// NewYear returns the date of Tết starting lunar year year, at midnight UTC
+func NewYear(year int) time.Time {
+	a11 := lunarMonth11(year - 1)
+	b11 := lunarMonth11(year)
+	k := int(math.Floor(0.5 + (float64(a11)-2415021.076998695)/29.530588853))
+
+	// Month 1 is the second month after month 11, or the third when a leap month
+	// comes in between
+	offset := 2
+	if b11-a11 > 365 && offset >= leapMonthOffset(a11) {
+		offset++
+	}
+	return fromJulianDay(newMoonDay(k + offset))
+}
+
+// Year returns the lunar year the calendar date of t falls in. Dates before Tết belong to
+// the year before.
+func Year(t time.Time) int {
+	date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	if date.Before(NewYear(t.Year())) {
+		return t.Year() - 1
+	}
+	return t.Year()
+}
+
+func julianDay(day, month, year int) int {
+	a := (14 - month) / 12
+	y := year + 4800 - a
+	m := month + 12*a - 3
+	return day + (153*m+2)/5 + 365*y + y/4 - y/100 + y/400 - 32045
+}
+
+func fromJulianDay(jd int) time.Time {
+	return time.Date(1970, 1, 1+jd-unixEpochJD, 0, 0, 0, 0, time.UTC)
+}
+
+// newMoon is the Julian date of the k-th new moon after 1900-01-01
+func newMoon(k int) float64 {
+	kf := float64(k)
+	t := kf / 1236.85
+	t2 := t * t
+	t3 := t2 * t
+	dr := math.Pi / 180
+
+	jd := 2415020.75933 + 29.53058868*kf + 0.0001178*t2 - 0.000000155*t3
+	jd += 0.00033 * math.Sin((166.56+132.87*t-0.009173*t2)*dr)
+	m := 359.2242 + 29.10535608*kf - 0.0000333*t2 - 0.00000347*t3
+	mpr := 306.0253 + 385.81691806*kf + 0.0107306*t2 + 0.00001236*t3
+	f := 21.2964 + 390.67050646*kf - 0.0016528*t2 - 0.00000239*t3
+
+	c1 := (0.1734-0.000393*t)*math.Sin(m*dr) + 0.0021*math.Sin(2*dr*m)
+	c1 = c1 - 0.4068*math.Sin(mpr*dr) + 0.0161*math.Sin(dr*2*mpr)
+	c1 = c1 - 0.0004*math.Sin(dr*3*mpr)
+	c1 = c1 + 0.0104*math.Sin(dr*2*f) - 0.0051*math.Sin(dr*(m+mpr))
+	c1 = c1 - 0.0074*math.Sin(dr*(m-mpr)) + 0.0004*math.Sin(dr*(2*f+m))
+	c1 = c1 - 0.0004*math.Sin(dr*(2*f-m)) - 0.0006*math.Sin(dr*(2*f+mpr))
+	c1 = c1 + 0.0010*math.Sin(dr*(2*f-mpr)) + 0.0005*math.Sin(dr*(2*mpr+m))
+
+	var deltaT float64
+	if t < -11 {
+		deltaT = 0.001 + 0.000839*t + 0.0002261*t2 - 0.00000845*t3 - 0.000000081*t*t3
+	} else {
+		deltaT = -0.000278 + 0.000265*t + 0.000262*t2
+	}
+	return jd + c1 - deltaT
+}
+
+// newMoonDay is the Julian day number, in Vietnam, of the k-th new moon
+func newMoonDay(k int) int {
+	return int(math.Floor(newMoon(k) + 0.5 + timeZone/24))
+}
+
+// sunLongitudeSector is which of the twelve 30° sectors the sun is in at the start of a
+// day, 0 from the March equinox
+func sunLongitudeSector(dayNumber int) int {
+	t := (float64(dayNumber) - 0.5 - timeZone/24 - 2451545.0) / 36525
+	t2 := t * t
+	dr := math.Pi / 180
+
+	m := 357.52910 + 35999.05030*t - 0.0001559*t2 - 0.00000048*t*t2
+	l0 := 280.46645 + 36000.76983*t + 0.0003032*t2
+	dl := (1.914600 - 0.004817*t - 0.000014*t2) * math.Sin(dr*m)
+	dl += (0.019993-0.000101*t)*math.Sin(dr*2*m) + 0.000290*math.Sin(dr*3*m)
+
+	l := (l0 + dl) * dr
+	l -= 2 * math.Pi * math.Floor(l/(2*math.Pi))
+	return int(l / math.Pi * 6)
+}
+
+// lunarMonth11 is the first day of the lunar month holding the winter solstice of year
+func lunarMonth11(year int) int {
+	offset := julianDay(31, 12, year) - 2415021
+	k := int(math.Floor(float64(offset) / 29.530588853))
+	day := newMoonDay(k)
+	if sunLongitudeSector(day) >= 9 {
+		day = newMoonDay(k - 1)
+	}
+	return day
+}
+
+// leapMonthOffset is how many months after month 11 starting on a11 the leap month
+// comes: the first month without a new solar term
+func leapMonthOffset(a11 int) int {
+	k := int(math.Floor((float64(a11)-2415021.076998695)/29.530588853 + 0.5))
+	i := 1
+	arc := sunLongitudeSector(newMoonDay(k + i))
+	for {
+		last := arc
+		i++
+		arc = sunLongitudeSector(newMoonDay(k + i))
+		if arc == last || i >= 14 {
+			break
+		}
+	}
+	return i - 1
+}
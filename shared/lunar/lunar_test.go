@@ -0,0 +1,48 @@
+package lunar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewYear(t *testing.T) {
+	// 1985 and 2007 are years where Tết comes on a different day than in China
+	tests := map[int]string{
+		1968: "1968-01-29",
+		1985: "1985-01-21",
+		1990: "1990-01-27",
+		2000: "2000-02-05",
+		2007: "2007-02-17",
+		2023: "2023-01-22",
+		2024: "2024-02-10",
+		2025: "2025-01-29",
+		2026: "2026-02-17",
+		// Follows the leap eleventh month of 2033
+		2034: "2034-02-19",
+	}
+	for year, want := range tests {
+		if got := NewYear(year).Format("2006-01-02"); got != want {
+			t.Errorf("NewYear(%d) = %s, want %s", year, got, want)
+		}
+	}
+}
+
+func TestYear(t *testing.T) {
+	tests := []struct {
+		date string
+		want int
+	}{
+		{"2024-02-09", 2023},
+		{"2024-02-10", 2024},
+		{"2024-12-31", 2024},
+		{"2025-01-28", 2024},
+		{"2025-01-29", 2025},
+		{"2000-01-01", 1999},
+	}
+	for _, tt := range tests {
+		date, _ := time.Parse("2006-01-02", tt.date)
+		if got := Year(date); got != tt.want {
+			t.Errorf("Year(%s) = %d, want %d", tt.date, got, tt.want)
+		}
+	}
+}
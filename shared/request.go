@@ -0,0 +1,12 @@
+package shared
+
+import "github.com/gofiber/fiber/v2"
+
+// RequestIP returns the client address resolved from trusted proxy headers by the HTTP
+// service, or the peer address when none was resolved
+func RequestIP(c *fiber.Ctx) string {
+	if ip, ok := c.Locals(ClientIP).(string); ok && ip != "" {
+		return ip
+	}
+	return c.IP()
+}
@@ -0,0 +1,173 @@
+// Package text normalizes Vietnamese text so answers, search queries and usernames are
+// compared the same way everywhere.
+package text
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Combining marks for the five Vietnamese tones: huyền, sắc, hỏi, ngã and nặng
+var toneMarks = map[rune]bool{
+	'\u0300': true,
+	'\u0301': true,
+	'\u0309': true,
+	'\u0303': true,
+	'\u0323': true,
+}
+
+// Lowercase letters of the Vietnamese alphabet, including the ones only foreign words
+// use (f, j, w, z)
+const vietnameseLetters = "abcdefghijklmnopqrstuvwxyz" +
+	"àáảãạăằắẳẵặâầấẩẫậđèéẻẽẹêềếểễệìíỉĩịòóỏõọôồốổỗộơờớởỡợùúủũụưừứửữựỳýỷỹỵ"
+
+// NFC returns text in composed form. Some keyboards and clipboard sources send
+// decomposed diacritics, which look the same but don't compare equal.
+func NFC(s string) string {
+	return norm.NFC.String(s)
+}
+
+// StripToneMarks removes the tone marks and keeps the other diacritics, so "Chiến thắng"
+// becomes "Chiên thăng" and "Lê Lợi" becomes "Lê Lơi"
+func StripToneMarks(s string) string {
+	var out strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		if !toneMarks[r] {
+			out.WriteRune(r)
+		}
+	}
+	return norm.NFC.String(out.String())
+}
+
+// StripDiacritics removes every diacritic and turns đ into d, so "Đinh Bộ Lĩnh" becomes
+// "Dinh Bo Linh"
+func StripDiacritics(s string) string {
+	var out strings.Builder
+	for _, r := range norm.NFD.String(s) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			continue
+		case r == 'đ':
+			r = 'd'
+		case r == 'Đ':
+			r = 'D'
+		}
+		out.WriteRune(r)
+	}
+	return out.String()
+}
+
+// Fold lowercases text, strips diacritics and turns runs of punctuation and spaces into
+// single spaces, so "Đinh - Tiền Lê" folds to "dinh tien le"
+func Fold(s string) string {
+	return foldWords(s, ' ')
+}
+
+// Slug folds text into lowercase ASCII words joined by hyphens, for file names and URLs.
+// "Chiến thắng Bạch Đằng (938)" becomes "chien-thang-bach-dang-938".
+func Slug(s string) string {
+	return foldWords(s, '-')
+}
+
+func foldWords(s string, separator rune) string {
+	var out strings.Builder
+	pending := false
+	for _, r := range StripDiacritics(s) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			pending = out.Len() > 0
+			continue
+		}
+		if pending {
+			out.WriteRune(separator)
+			pending = false
+		}
+		out.WriteRune(unicode.ToLower(r))
+	}
+	return out.String()
+}
+
+// MatchOptions loosens Match
+type MatchOptions struct {
+	// IgnoreDiacritics accepts letters typed without diacritics or without only their
+	// tone mark, e.g. "Thang Long" for "Thăng Long". A wrong diacritic still fails.
+	IgnoreDiacritics bool
+	// MaxEdits is how many letters may be missing, extra or wrong (Levenshtein
+	// distance). Digits are never forgiven, so "939" doesn't match "938".
+	MaxEdits int
+}
+
+// Match compares two answers ignoring case, surrounding and repeated spaces and how the
+// diacritics were encoded
+func Match(given, expected string, opts MatchOptions) bool {
+	given, expected = matchKey(given), matchKey(expected)
+	if given == expected {
+		return true
+	}
+	if !opts.IgnoreDiacritics && opts.MaxEdits <= 0 {
+		return false
+	}
+
+	// Each letter must be the expected one or, if diacritics are ignored, the expected
+	// one typed without its tone mark or without any diacritic
+	same := func(typed, want rune) bool {
+		if typed == want {
+			return true
+		}
+		if !opts.IgnoreDiacritics {
+			return false
+		}
+		letter := string(want)
+		return string(typed) == StripToneMarks(letter) || string(typed) == StripDiacritics(letter)
+	}
+	limit := max(opts.MaxEdits, 0)
+	return editDistance([]rune(given), []rune(expected), same, limit) <= limit
+}
+
+// editDistance is the Levenshtein distance between a and b, where edits involving a
+// digit count as more than limit. Rows stop early once every cell is past limit.
+func editDistance(a, b []rune, same func(typed, want rune) bool, limit int) int {
+	if diff := len(a) - len(b); diff > limit || -diff > limit {
+		return limit + 1
+	}
+	cost := func(r rune) int {
+		if unicode.IsDigit(r) {
+			return limit + 1
+		}
+		return 1
+	}
+
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j, r := range b {
+		previous[j+1] = previous[j] + cost(r)
+	}
+	for _, typed := range a {
+		current[0] = previous[0] + cost(typed)
+		best := current[0]
+		for j, want := range b {
+			substitute := previous[j]
+			if !same(typed, want) {
+				substitute += max(cost(typed), cost(want))
+			}
+			current[j+1] = min(substitute, previous[j+1]+cost(typed), current[j]+cost(want))
+			best = min(best, current[j+1])
+		}
+		if best > limit {
+			return limit + 1
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func matchKey(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(NFC(s)), " "))
+}
+
+// IsVietnameseLetter reports whether r is a letter of the Vietnamese alphabet, with or
+// without diacritics, in either case. Text must be NFC normalized.
+func IsVietnameseLetter(r rune) bool {
+	return strings.ContainsRune(vietnameseLetters, unicode.ToLower(r))
+}
@@ -0,0 +1,87 @@
+package text
+
+import "testing"
+
+func TestStripping(t *testing.T) {
+	// "Lợi" with a decomposed horn and dot below
+	decomposed := "Lê Lợi"
+
+	tests := []struct {
+		name, got, want string
+	}{
+		{"nfc", NFC(decomposed), "Lê Lợi"},
+		{"tone marks", StripToneMarks("Chiến thắng " + decomposed), "Chiên thăng Lê Lơi"},
+		{"diacritics", StripDiacritics("Đinh Bộ Lĩnh, " + decomposed), "Dinh Bo Linh, Le Loi"},
+		{"fold", Fold("  Đinh - Tiền Lê!"), "dinh tien le"},
+		{"slug", Slug("Chiến thắng Bạch Đằng (938)"), "chien-thang-bach-dang-938"},
+		{"slug of punctuation", Slug("?!"), ""},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		given, expected  string
+		ignoreDiacritics bool
+		want             bool
+	}{
+		{"  thăng   LONG ", "Thăng Long", false, true},
+		{"Le Lợi", "Lê Lợi", false, false},
+		{"Lê Lợi", "Lê Lợi", false, true},
+		{"Thang Long", "Thăng Long", false, false},
+		{"Thang Long", "Thăng Long", true, true},
+		{"thang long", "Thăng Long", true, true},
+		{"dinh bo linh", "Đinh Bộ Lĩnh", true, true},
+		{"Đinh Bô Lĩnh", "Đinh Bộ Lĩnh", true, true},
+		// A wrong diacritic is a different word, not a missing one
+		{"Thắng Long", "Thăng Long", true, false},
+		{"Thang Lon", "Thăng Long", true, false},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.given, tt.expected, MatchOptions{IgnoreDiacritics: tt.ignoreDiacritics}); got != tt.want {
+			t.Errorf("Match(%q, %q, ignoreDiacritics=%v) = %v, want %v", tt.given, tt.expected, tt.ignoreDiacritics, got, tt.want)
+		}
+	}
+}
+
+func TestMatchWithTypos(t *testing.T) {
+	tests := []struct {
+		given, expected string
+		opts            MatchOptions
+		want            bool
+	}{
+		{"Hai Ba Trung", "Hai Bà Trưng", MatchOptions{MaxEdits: 1}, false},
+		{"Hai Ba Trung", "Hai Bà Trưng", MatchOptions{MaxEdits: 2}, true},
+		{"Hai Ba Trng", "Hai Bà Trưng", MatchOptions{IgnoreDiacritics: true, MaxEdits: 1}, true},
+		{"Ngo Quyen", "Ngô Quyền", MatchOptions{IgnoreDiacritics: true, MaxEdits: 1}, true},
+		{"Nqo Quyenn", "Ngô Quyền", MatchOptions{IgnoreDiacritics: true, MaxEdits: 1}, false},
+		{"Bach Dang", "Bạch Đằng", MatchOptions{MaxEdits: 3}, true},
+		{"Bạch Đằn", "Bạch Đằng", MatchOptions{MaxEdits: 1}, true},
+		// Numbers are never typos
+		{"939", "938", MatchOptions{MaxEdits: 2}, false},
+		{"năm 93", "năm 938", MatchOptions{MaxEdits: 2}, false},
+		{"nam 938", "năm 938", MatchOptions{MaxEdits: 1}, true},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.given, tt.expected, tt.opts); got != tt.want {
+			t.Errorf("Match(%q, %q, %+v) = %v, want %v", tt.given, tt.expected, tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestIsVietnameseLetter(t *testing.T) {
+	for _, r := range "aZđĐỹỰw" {
+		if !IsVietnameseLetter(r) {
+			t.Errorf("IsVietnameseLetter(%q) = false", r)
+		}
+	}
+	for _, r := range "ñßаο1_ " {
+		if IsVietnameseLetter(r) {
+			t.Errorf("IsVietnameseLetter(%q) = true", r)
+		}
+	}
+}
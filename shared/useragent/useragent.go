@@ -0,0 +1,169 @@
+// Package useragent reads the operating system, browser and kind of device from a
+// User-Agent header, enough to describe a login to the user. It only knows the common
+// browsers and the app's own HTTP clients; anything else is reported as unknown.
+package useragent
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Device types, matching TrustedDevice.Type
+const (
+	TypeMobile  = "mobile"
+	TypeTablet  = "tablet"
+	TypeDesktop = "desktop"
+	TypeBot     = "bot"
+	TypeUnknown = "unknown"
+)
+
+// Info is what a User-Agent says about the device. Empty fields are unknown.
+type Info struct {
+	OS         string
+	Browser    string
+	DeviceType string
+}
+
+var (
+	windowsVersion = regexp.MustCompile(`Windows NT (\d+\.\d+)`)
+	iosVersion     = regexp.MustCompile(`(?:iPhone OS|CPU OS) (\d+(?:_\d+)*)`)
+	androidVersion = regexp.MustCompile(`Android (\d+(?:\.\d+)?)`)
+	macVersion     = regexp.MustCompile(`Mac OS X (\d+(?:[_.]\d+)*)`)
+)
+
+var windowsReleases = map[string]string{
+	"10.0": "10",
+	"6.3":  "8.1",
+	"6.2":  "8",
+	"6.1":  "7",
+}
+
+// Browsers in the order they are checked. Many browsers also claim to be Chrome or
+// Safari, so the specific ones come first.
+var browsers = []struct {
+	token string
+	name  string
+}{
+	{"Edg/", "Edge"},
+	{"EdgiOS/", "Edge"},
+	{"OPR/", "Opera"},
+	{"SamsungBrowser/", "Samsung Internet"},
+	{"coc_coc_browser/", "Cốc Cốc"},
+	{"CriOS/", "Chrome"},
+	{"FxiOS/", "Firefox"},
+	{"Firefox/", "Firefox"},
+	{"Chrome/", "Chrome"},
+	{"Version/", "Safari"},
+	{"Dart/", "App"},
+	{"okhttp/", "App"},
+	{"CFNetwork/", "App"},
+}
+
+var botTokens = []string{"bot", "spider", "crawl", "curl/", "wget/", "python-requests"}
+
+// Parse reads a User-Agent header
+func Parse(userAgent string) Info {
+	userAgent = strings.TrimSpace(userAgent)
+	if userAgent == "" {
+		return Info{DeviceType: TypeUnknown}
+	}
+
+	info := Info{
+		OS:      parseOS(userAgent),
+		Browser: parseBrowser(userAgent),
+	}
+	info.DeviceType = parseDeviceType(userAgent, info.OS)
+	return info
+}
+
+func parseOS(userAgent string) string {
+	switch {
+	case strings.Contains(userAgent, "Windows"):
+		if match := windowsVersion.FindStringSubmatch(userAgent); match != nil {
+			if release, ok := windowsReleases[match[1]]; ok {
+				return "Windows " + release
+			}
+		}
+		return "Windows"
+	case strings.Contains(userAgent, "iPhone") || strings.Contains(userAgent, "iPad") || strings.Contains(userAgent, "iPod"):
+		if match := iosVersion.FindStringSubmatch(userAgent); match != nil {
+			return "iOS " + strings.ReplaceAll(match[1], "_", ".")
+		}
+		return "iOS"
+	case strings.Contains(userAgent, "Android"):
+		if match := androidVersion.FindStringSubmatch(userAgent); match != nil {
+			return "Android " + match[1]
+		}
+		return "Android"
+	case strings.Contains(userAgent, "CrOS"):
+		return "ChromeOS"
+	case strings.Contains(userAgent, "Mac OS X") || strings.Contains(userAgent, "Macintosh"):
+		if match := macVersion.FindStringSubmatch(userAgent); match != nil {
+			return "macOS " + strings.ReplaceAll(match[1], "_", ".")
+		}
+		return "macOS"
+	case strings.Contains(userAgent, "Linux"):
+		return "Linux"
+	}
+	return ""
+}
+
+func parseBrowser(userAgent string) string {
+	for _, browser := range browsers {
+		index := strings.Index(userAgent, browser.token)
+		if index < 0 {
+			continue
+		}
+		// Safari is only Safari when nothing above matched and it says so
+		if browser.name == "Safari" && !strings.Contains(userAgent, "Safari/") {
+			continue
+		}
+		if browser.name == "App" {
+			return browser.name
+		}
+
+		version := userAgent[index+len(browser.token):]
+		if end := strings.IndexAny(version, ". ;)"); end >= 0 {
+			version = version[:end]
+		}
+		if version == "" {
+			return browser.name
+		}
+		return browser.name + " " + version
+	}
+	return ""
+}
+
+func parseDeviceType(userAgent, os string) string {
+	lower := strings.ToLower(userAgent)
+	for _, token := range botTokens {
+		if strings.Contains(lower, token) {
+			return TypeBot
+		}
+	}
+
+	switch {
+	case strings.Contains(userAgent, "iPad") || strings.Contains(lower, "tablet"):
+		return TypeTablet
+	case strings.Contains(userAgent, "Android") && !strings.Contains(userAgent, "Mobile"):
+		return TypeTablet
+	case strings.Contains(userAgent, "Mobi") || strings.Contains(userAgent, "iPhone") || strings.HasPrefix(os, "Android"):
+		return TypeMobile
+	case os != "":
+		return TypeDesktop
+	}
+	return TypeUnknown
+}
+
+// String describes the device for people, like "Chrome 120 on Windows 10"
+func (i Info) String() string {
+	switch {
+	case i.Browser != "" && i.OS != "":
+		return i.Browser + " on " + i.OS
+	case i.OS != "":
+		return i.OS
+	case i.Browser != "":
+		return i.Browser
+	}
+	return "Unknown device"
+}
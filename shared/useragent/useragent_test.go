@@ -0,0 +1,62 @@
+package useragent
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		want      Info
+	}{
+		{
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			Info{OS: "Windows 10", Browser: "Chrome 120", DeviceType: TypeDesktop},
+		},
+		{
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.91",
+			Info{OS: "Windows 10", Browser: "Edge 120", DeviceType: TypeDesktop},
+		},
+		{
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1",
+			Info{OS: "iOS 17.1.2", Browser: "Safari 17", DeviceType: TypeMobile},
+		},
+		{
+			"Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/119.0.6045.169 Mobile/15E148 Safari/604.1",
+			Info{OS: "iOS 16.6", Browser: "Chrome 119", DeviceType: TypeTablet},
+		},
+		{
+			"Mozilla/5.0 (Linux; Android 14; SM-S918B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/23.0 Chrome/115.0.0.0 Mobile Safari/537.36",
+			Info{OS: "Android 14", Browser: "Samsung Internet 23", DeviceType: TypeMobile},
+		},
+		{
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 10.15; rv:121.0) Gecko/20100101 Firefox/121.0",
+			Info{OS: "macOS 10.15", Browser: "Firefox 121", DeviceType: TypeDesktop},
+		},
+		{
+			"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			Info{OS: "Linux", Browser: "Chrome 120", DeviceType: TypeDesktop},
+		},
+		{"Dart/3.2 (dart:io)", Info{Browser: "App", DeviceType: TypeUnknown}},
+		{"curl/8.4.0", Info{DeviceType: TypeBot}},
+		{"", Info{DeviceType: TypeUnknown}},
+	}
+
+	for _, test := range tests {
+		if got := Parse(test.userAgent); got != test.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", test.userAgent, got, test.want)
+		}
+	}
+}
+
+func TestInfoString(t *testing.T) {
+	tests := map[Info]string{
+		{OS: "Windows 10", Browser: "Chrome 120"}: "Chrome 120 on Windows 10",
+		{OS: "Android 14"}:                        "Android 14",
+		{Browser: "App"}:                          "App",
+		{}:                                        "Unknown device",
+	}
+	for info, want := range tests {
+		if got := info.String(); got != want {
+			t.Errorf("%+v.String() = %q, want %q", info, got, want)
+		}
+	}
+}